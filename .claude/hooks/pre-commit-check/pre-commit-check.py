@@ -23,7 +23,7 @@ CHECKS = {
         ],
     },
     "cli": {
-        "when": "When CLI behavior is modified (cmd/twig/**, *.go)",
+        "when": "When CLI behavior is modified (cli/**, cmd/twig/**, *.go)",
         "commands": [
             "Review docs/reference/ for accuracy",
             "Update docs if command options/behavior changed",
@@ -0,0 +1,589 @@
+package twig
+
+import (
+	"github.com/708u/twig/core"
+	"github.com/708u/twig/format"
+)
+
+// Types.
+type (
+	AddBatchEntry         = core.AddBatchEntry
+	AddBatchResult        = core.AddBatchResult
+	AddCommand            = core.AddCommand
+	AddFormatOptions      = core.AddFormatOptions
+	AddOptions            = core.AddOptions
+	AddResult             = core.AddResult
+	AddSource             = core.AddSource
+	AdoptCommand          = core.AdoptCommand
+	AdoptOptions          = core.AdoptOptions
+	AdoptResult           = core.AdoptResult
+	AdoptedWorktree       = core.AdoptedWorktree
+	AgeResolver           = core.AgeResolver
+	AgeSource             = core.AgeSource
+	ApplyPatchOption      = core.ApplyPatchOption
+	AuditCommand          = core.AuditCommand
+	AuditEntry            = core.AuditEntry
+	AuditFormatOptions    = core.AuditFormatOptions
+	AuditLog              = core.AuditLog
+	AuditOptions          = core.AuditOptions
+	AuditResult           = core.AuditResult
+	BranchDeleteOption    = core.BranchDeleteOption
+	BranchMergeStatus     = core.BranchMergeStatus
+	BranchStatus          = core.BranchStatus
+	CLIHandler            = core.CLIHandler
+	CaseCollision         = core.CaseCollision
+	CaseCollisionError    = core.CaseCollisionError
+	CheckOptions          = core.CheckOptions
+	CheckResult           = core.CheckResult
+	CleanCandidate        = core.CleanCandidate
+	CleanCommand          = core.CleanCommand
+	CleanFormatOptions    = core.CleanFormatOptions
+	CleanOptions          = core.CleanOptions
+	CleanReason           = core.CleanReason
+	CleanResult           = core.CleanResult
+	CleanSummary          = core.CleanSummary
+	ColorMode             = format.ColorMode
+	ColorsConfig          = core.ColorsConfig
+	CompletionCache       = core.CompletionCache
+	Config                = core.Config
+	ConfigCheckCommand    = core.ConfigCheckCommand
+	ConfigCheckResult     = core.ConfigCheckResult
+	ConfigDiffEntry       = core.ConfigDiffEntry
+	ConfigDiffResult      = core.ConfigDiffResult
+	ConfigDiffSide        = core.ConfigDiffSide
+	ConfigEntry           = core.ConfigEntry
+	ConfigFileMigration   = core.ConfigFileMigration
+	ConfigGetOptions      = core.ConfigGetOptions
+	ConfigGetResult       = core.ConfigGetResult
+	ConfigIssue           = core.ConfigIssue
+	ConfigIssueSeverity   = core.ConfigIssueSeverity
+	ConfigKeyRename       = core.ConfigKeyRename
+	ConfigMigrateCommand  = core.ConfigMigrateCommand
+	ConfigMigrateOptions  = core.ConfigMigrateOptions
+	ConfigMigrateResult   = core.ConfigMigrateResult
+	ConfigSetCommand      = core.ConfigSetCommand
+	ConfigSetOptions      = core.ConfigSetOptions
+	ConfigSetResult       = core.ConfigSetResult
+	DiskUsage             = core.DiskUsage
+	DoctorCheckName       = core.DoctorCheckName
+	DoctorCheckResult     = core.DoctorCheckResult
+	DoctorCommand         = core.DoctorCommand
+	DoctorIssue           = core.DoctorIssue
+	DoctorOptions         = core.DoctorOptions
+	DoctorResult          = core.DoctorResult
+	EffectiveConfigResult = core.EffectiveConfigResult
+	ExportCommand         = core.ExportCommand
+	FaultError            = core.FaultError
+	FaultProfile          = core.FaultProfile
+	FaultRule             = core.FaultRule
+	FileStatus            = core.FileStatus
+	FileSystem            = core.FileSystem
+	ForgeClient           = core.ForgeClient
+	FormatOptions         = core.FormatOptions
+	FormatResult          = core.FormatResult
+	Formatter             = core.Formatter
+	GCCommand             = core.GCCommand
+	GCConfig              = core.GCConfig
+	GCFormatOptions       = core.GCFormatOptions
+	GCOptions             = core.GCOptions
+	GCPolicy              = core.GCPolicy
+	GCReason              = core.GCReason
+	GCResult              = core.GCResult
+	GCSelection           = core.GCSelection
+	GCStatus              = core.GCStatus
+	GitError              = core.GitError
+	GitErrorCode          = core.GitErrorCode
+	GitExecutor           = core.GitExecutor
+	GitHookCommand        = core.GitHookCommand
+	GitHookOptions        = core.GitHookOptions
+	GitHookResult         = core.GitHookResult
+	GitLockBusyError      = core.GitLockBusyError
+	GitLockHolder         = core.GitLockHolder
+	GitLockWaiter         = core.GitLockWaiter
+	GitOp                 = core.GitOp
+	GitRunner             = core.GitRunner
+	GitRunnerOption       = core.GitRunnerOption
+	GrepCommand           = core.GrepCommand
+	GrepFailure           = core.GrepFailure
+	GrepFormatOptions     = core.GrepFormatOptions
+	GrepMatch             = core.GrepMatch
+	GrepOptions           = core.GrepOptions
+	GrepResult            = core.GrepResult
+	HookResult            = core.HookResult
+	ImportCommand         = core.ImportCommand
+	ImportOptions         = core.ImportOptions
+	ImportResult          = core.ImportResult
+	ImportStatus          = core.ImportStatus
+	ImportedWorktree      = core.ImportedWorktree
+	InferredSource        = core.InferredSource
+	InitCommand           = core.InitCommand
+	InitFormatOptions     = core.InitFormatOptions
+	InitOptions           = core.InitOptions
+	InitResult            = core.InitResult
+	ListCommand           = core.ListCommand
+	ListFilter            = core.ListFilter
+	ListFormatOptions     = core.ListFormatOptions
+	ListOptions           = core.ListOptions
+	ListResult            = core.ListResult
+	ListSortKey           = core.ListSortKey
+	LoadConfigOption      = core.LoadConfigOption
+	LoadConfigResult      = core.LoadConfigResult
+	LockBusyError         = core.LockBusyError
+	LogAttrKey            = core.LogAttrKey
+	LogFormat             = core.LogFormat
+	ModuleDir             = core.ModuleDir
+	Note                  = core.Note
+	NoteCommand           = core.NoteCommand
+	NoteEntry             = core.NoteEntry
+	NoteOptions           = core.NoteOptions
+	NoteResult            = core.NoteResult
+	NoteStore             = core.NoteStore
+	Notification          = core.Notification
+	Notifier              = core.Notifier
+	OnExists              = core.OnExists
+	OpenCommand           = core.OpenCommand
+	OpenOptions           = core.OpenOptions
+	OpenResult            = core.OpenResult
+	OperationLock         = core.OperationLock
+	OperationLockOptions  = core.OperationLockOptions
+	OverlayCommand        = core.OverlayCommand
+	OverlayFormatOptions  = core.OverlayFormatOptions
+	OverlayOptions        = core.OverlayOptions
+	OverlayResult         = core.OverlayResult
+	PRState               = core.PRState
+	PathCommand           = core.PathCommand
+	PathOptions           = core.PathOptions
+	PathResult            = core.PathResult
+	Profile               = core.Profile
+	ProfileSummary        = core.ProfileSummary
+	ProfilesResult        = core.ProfilesResult
+	PromptFormatOptions   = core.PromptFormatOptions
+	PromptInfo            = core.PromptInfo
+	PromptInfoCommand     = core.PromptInfoCommand
+	PromptInfoOptions     = core.PromptInfoOptions
+	PromptShell           = core.PromptShell
+	Provenance            = core.Provenance
+	ProvenanceStore       = core.ProvenanceStore
+	PullRequest           = core.PullRequest
+	RefReader             = core.RefReader
+	RemoveCommand         = core.RemoveCommand
+	RemoveOptions         = core.RemoveOptions
+	RemoveResult          = core.RemoveResult
+	RemovedBranch         = core.RemovedBranch
+	RemovedWorktree       = core.RemovedWorktree
+	RenameCommand         = core.RenameCommand
+	RenameOptions         = core.RenameOptions
+	RenameResult          = core.RenameResult
+	RepositoryPaths       = core.RepositoryPaths
+	ScratchDir            = core.ScratchDir
+	ScratchDirOptions     = core.ScratchDirOptions
+	ScratchLeftover       = core.ScratchLeftover
+	SkipError             = core.SkipError
+	SkipReason            = core.SkipReason
+	StaleSymlink          = core.StaleSymlink
+	StaleWorktreeError    = core.StaleWorktreeError
+	StashEntry            = core.StashEntry
+	State                 = core.State
+	StateWorktree         = core.StateWorktree
+	SubmoduleCleanStatus  = core.SubmoduleCleanStatus
+	SubmoduleInfo         = core.SubmoduleInfo
+	SubmoduleInitResult   = core.SubmoduleInitResult
+	SubmoduleState        = core.SubmoduleState
+	SubmoduleUpdateOption = core.SubmoduleUpdateOption
+	SubmoduleUpdateResult = core.SubmoduleUpdateResult
+	SymlinkResult         = core.SymlinkResult
+	SymlinkState          = core.SymlinkState
+	SyncCommand           = core.SyncCommand
+	SyncFormatOptions     = core.SyncFormatOptions
+	SyncOptions           = core.SyncOptions
+	SyncResult            = core.SyncResult
+	SyncTargetResult      = core.SyncTargetResult
+	TimingPhase           = core.TimingPhase
+	Timings               = core.Timings
+	UpstreamResult        = core.UpstreamResult
+	VCS                   = core.VCS
+	Warning               = core.Warning
+	WarningCode           = core.WarningCode
+	WatchChange           = core.WatchChange
+	WatchCommand          = core.WatchCommand
+	WatchConfigError      = core.WatchConfigError
+	WatchOptions          = core.WatchOptions
+	WatchSyncFunc         = core.WatchSyncFunc
+	Worktree              = core.Worktree
+	WorktreeAddOption     = core.WorktreeAddOption
+	WorktreeAge           = core.WorktreeAge
+	WorktreeArchiver      = core.WorktreeArchiver
+	WorktreeForceLevel    = core.WorktreeForceLevel
+	WorktreeRemoveOption  = core.WorktreeRemoveOption
+)
+
+// Constants.
+const (
+	AgeSourceAdminDir             = core.AgeSourceAdminDir
+	AgeSourceGitDirFile           = core.AgeSourceGitDirFile
+	AgeSourceNone                 = core.AgeSourceNone
+	AgeSourceProvenance           = core.AgeSourceProvenance
+	AuditCommandClean             = core.AuditCommandClean
+	AuditCommandRemove            = core.AuditCommandRemove
+	CaseCollisionsAuto            = core.CaseCollisionsAuto
+	CaseCollisionsError           = core.CaseCollisionsError
+	CaseCollisionsWarn            = core.CaseCollisionsWarn
+	CleanDetached                 = core.CleanDetached
+	CleanMerged                   = core.CleanMerged
+	CleanPRClosed                 = core.CleanPRClosed
+	CleanPRMerged                 = core.CleanPRMerged
+	CleanSquashMerged             = core.CleanSquashMerged
+	CleanUpstreamGone             = core.CleanUpstreamGone
+	ColorModeAlways               = format.ColorModeAlways
+	ColorModeAuto                 = format.ColorModeAuto
+	ColorModeNever                = format.ColorModeNever
+	CompletionKeyBranches         = core.CompletionKeyBranches
+	CompletionKeyLinkedBranches   = core.CompletionKeyLinkedBranches
+	CompletionKeyWorktreeBranches = core.CompletionKeyWorktreeBranches
+	ConfigIssueError              = core.ConfigIssueError
+	ConfigIssueWarning            = core.ConfigIssueWarning
+	ConfigSchemaID                = core.ConfigSchemaID
+	DefaultAddJobs                = core.DefaultAddJobs
+	DefaultCommandIDBytes         = core.DefaultCommandIDBytes
+	DefaultDiskUsageCacheTTL      = core.DefaultDiskUsageCacheTTL
+	DefaultForgeCacheTTL          = core.DefaultForgeCacheTTL
+	DefaultGitHookInterval        = core.DefaultGitHookInterval
+	DefaultGitLockWait            = core.DefaultGitLockWait
+	DefaultLockTimeout            = core.DefaultLockTimeout
+	DefaultNotifyAfter            = core.DefaultNotifyAfter
+	DefaultPRBranchTemplate       = core.DefaultPRBranchTemplate
+	DefaultPromptCacheTTL         = core.DefaultPromptCacheTTL
+	DefaultWatchInterval          = core.DefaultWatchInterval
+	DoctorCheckScratch            = core.DoctorCheckScratch
+	DoctorCheckStash              = core.DoctorCheckStash
+	EnvArchiveDir                 = core.EnvArchiveDir
+	EnvBranch                     = core.EnvBranch
+	EnvBranchPrefix               = core.EnvBranchPrefix
+	EnvCaseCollisions             = core.EnvCaseCollisions
+	EnvCleanFetch                 = core.EnvCleanFetch
+	EnvCleanOnlyTwigManaged       = core.EnvCleanOnlyTwigManaged
+	EnvCleanStale                 = core.EnvCleanStale
+	EnvCleanVerifyCommand         = core.EnvCleanVerifyCommand
+	EnvCleanupEmptyDirs           = core.EnvCleanupEmptyDirs
+	EnvDefaultSource              = core.EnvDefaultSource
+	EnvDetectSquashMerges         = core.EnvDetectSquashMerges
+	EnvEnvFile                    = core.EnvEnvFile
+	EnvFetchOnAdd                 = core.EnvFetchOnAdd
+	EnvFileName                   = core.EnvFileName
+	EnvForge                      = core.EnvForge
+	EnvGitLockWait                = core.EnvGitLockWait
+	EnvGitRemoteTimeout           = core.EnvGitRemoteTimeout
+	EnvGitTimeout                 = core.EnvGitTimeout
+	EnvInitSubmodules             = core.EnvInitSubmodules
+	EnvIsMain                     = core.EnvIsMain
+	EnvLookupRemoteBranches       = core.EnvLookupRemoteBranches
+	EnvNoSyncHook                 = core.EnvNoSyncHook
+	EnvNotify                     = core.EnvNotify
+	EnvNotifyAfter                = core.EnvNotifyAfter
+	EnvOpenCommand                = core.EnvOpenCommand
+	EnvPRBranchTemplate           = core.EnvPRBranchTemplate
+	EnvSourcePath                 = core.EnvSourcePath
+	EnvStrictSymlinks             = core.EnvStrictSymlinks
+	EnvSubmoduleRecursive         = core.EnvSubmoduleRecursive
+	EnvSubmoduleRefDir            = core.EnvSubmoduleRefDir
+	EnvSubmoduleReference         = core.EnvSubmoduleReference
+	EnvSymlinkStyle               = core.EnvSymlinkStyle
+	EnvWorktreeDestBaseDir        = core.EnvWorktreeDestBaseDir
+	EnvWorktreePath               = core.EnvWorktreePath
+	FaultOpFSPrefix               = core.FaultOpFSPrefix
+	FaultOpGitPrefix              = core.FaultOpGitPrefix
+	ForgeGitHub                   = core.ForgeGitHub
+	ForgeGitLab                   = core.ForgeGitLab
+	GCExpired                     = core.GCExpired
+	GCOverLimit                   = core.GCOverLimit
+	GitCmdAdd                     = core.GitCmdAdd
+	GitCmdApply                   = core.GitCmdApply
+	GitCmdBranch                  = core.GitCmdBranch
+	GitCmdCheckIgnore             = core.GitCmdCheckIgnore
+	GitCmdCheckout                = core.GitCmdCheckout
+	GitCmdCherry                  = core.GitCmdCherry
+	GitCmdCommitTree              = core.GitCmdCommitTree
+	GitCmdConfig                  = core.GitCmdConfig
+	GitCmdDiff                    = core.GitCmdDiff
+	GitCmdFetch                   = core.GitCmdFetch
+	GitCmdForEachRef              = core.GitCmdForEachRef
+	GitCmdGrep                    = core.GitCmdGrep
+	GitCmdLsFiles                 = core.GitCmdLsFiles
+	GitCmdLsRemote                = core.GitCmdLsRemote
+	GitCmdMergeBase               = core.GitCmdMergeBase
+	GitCmdPush                    = core.GitCmdPush
+	GitCmdReadTree                = core.GitCmdReadTree
+	GitCmdRemote                  = core.GitCmdRemote
+	GitCmdReset                   = core.GitCmdReset
+	GitCmdRevList                 = core.GitCmdRevList
+	GitCmdRevParse                = core.GitCmdRevParse
+	GitCmdSparseCheckout          = core.GitCmdSparseCheckout
+	GitCmdStash                   = core.GitCmdStash
+	GitCmdStatus                  = core.GitCmdStatus
+	GitCmdSubmodule               = core.GitCmdSubmodule
+	GitCmdSymbolicRef             = core.GitCmdSymbolicRef
+	GitCmdWorktree                = core.GitCmdWorktree
+	GitCmdWriteTree               = core.GitCmdWriteTree
+	GitErrorDirtyWorktree         = core.GitErrorDirtyWorktree
+	GitErrorLockedWorktree        = core.GitErrorLockedWorktree
+	GitErrorMissingRef            = core.GitErrorMissingRef
+	GitErrorPermissionDenied      = core.GitErrorPermissionDenied
+	GitErrorUnknown               = core.GitErrorUnknown
+	GitHookPostMerge              = core.GitHookPostMerge
+	GitStashList                  = core.GitStashList
+	GitSubmoduleDeinit            = core.GitSubmoduleDeinit
+	GitSubmoduleUpdate            = core.GitSubmoduleUpdate
+	GitWorktreeAdd                = core.GitWorktreeAdd
+	GitWorktreeList               = core.GitWorktreeList
+	GitWorktreeMove               = core.GitWorktreeMove
+	GitWorktreePrune              = core.GitWorktreePrune
+	GitWorktreeRemove             = core.GitWorktreeRemove
+	GitWorktreeRepair             = core.GitWorktreeRepair
+	ImportCreated                 = core.ImportCreated
+	ImportFailed                  = core.ImportFailed
+	ImportSkipped                 = core.ImportSkipped
+	ListSortDefault               = core.ListSortDefault
+	ListSortPath                  = core.ListSortPath
+	ListSortSize                  = core.ListSortSize
+	LogAttrKeyCategory            = core.LogAttrKeyCategory
+	LogAttrKeyCmdID               = core.LogAttrKeyCmdID
+	LogCategoryAdopt              = core.LogCategoryAdopt
+	LogCategoryAudit              = core.LogCategoryAudit
+	LogCategoryClean              = core.LogCategoryClean
+	LogCategoryCompletion         = core.LogCategoryCompletion
+	LogCategoryConfig             = core.LogCategoryConfig
+	LogCategoryDebug              = core.LogCategoryDebug
+	LogCategoryDiskUse            = core.LogCategoryDiskUse
+	LogCategoryDoctor             = core.LogCategoryDoctor
+	LogCategoryExport             = core.LogCategoryExport
+	LogCategoryForge              = core.LogCategoryForge
+	LogCategoryGC                 = core.LogCategoryGC
+	LogCategoryGit                = core.LogCategoryGit
+	LogCategoryGlob               = core.LogCategoryGlob
+	LogCategoryGrep               = core.LogCategoryGrep
+	LogCategoryImport             = core.LogCategoryImport
+	LogCategoryLock               = core.LogCategoryLock
+	LogCategoryNotify             = core.LogCategoryNotify
+	LogCategoryOpen               = core.LogCategoryOpen
+	LogCategoryOverlay            = core.LogCategoryOverlay
+	LogCategoryPath               = core.LogCategoryPath
+	LogCategoryPrompt             = core.LogCategoryPrompt
+	LogCategoryRemove             = core.LogCategoryRemove
+	LogCategoryRename             = core.LogCategoryRename
+	LogCategoryScratch            = core.LogCategoryScratch
+	LogCategorySync               = core.LogCategorySync
+	LogCategoryWatch              = core.LogCategoryWatch
+	LogFormatJSON                 = core.LogFormatJSON
+	LogFormatText                 = core.LogFormatText
+	OnExistsAdopt                 = core.OnExistsAdopt
+	OnExistsFail                  = core.OnExistsFail
+	OnExistsReplace               = core.OnExistsReplace
+	OpBranchDelete                = core.OpBranchDelete
+	OpBranchRename                = core.OpBranchRename
+	OpWorktreeMove                = core.OpWorktreeMove
+	OpWorktreeRemove              = core.OpWorktreeRemove
+	PRStateClosed                 = core.PRStateClosed
+	PRStateMerged                 = core.PRStateMerged
+	PRStateNone                   = core.PRStateNone
+	PRStateOpen                   = core.PRStateOpen
+	PorcelainBare                 = core.PorcelainBare
+	PorcelainBranchPrefix         = core.PorcelainBranchPrefix
+	PorcelainDetached             = core.PorcelainDetached
+	PorcelainHEADPrefix           = core.PorcelainHEADPrefix
+	PorcelainLocked               = core.PorcelainLocked
+	PorcelainPrunable             = core.PorcelainPrunable
+	PorcelainWorktreePrefix       = core.PorcelainWorktreePrefix
+	PromptShellFish               = core.PromptShellFish
+	PromptShellZsh                = core.PromptShellZsh
+	ProvenanceCommandAdd          = core.ProvenanceCommandAdd
+	ProvenanceCommandAdopt        = core.ProvenanceCommandAdopt
+	RefsHeadsPrefix               = core.RefsHeadsPrefix
+	RefsRemotesPrefix             = core.RefsRemotesPrefix
+	SkipCurrentDir                = core.SkipCurrentDir
+	SkipDetached                  = core.SkipDetached
+	SkipDirtySubmodule            = core.SkipDirtySubmodule
+	SkipExcluded                  = core.SkipExcluded
+	SkipHasChanges                = core.SkipHasChanges
+	SkipLocked                    = core.SkipLocked
+	SkipNotManaged                = core.SkipNotManaged
+	SkipNotMerged                 = core.SkipNotMerged
+	SkipProtected                 = core.SkipProtected
+	SkipSameCommit                = core.SkipSameCommit
+	SkipUnpushedCommits           = core.SkipUnpushedCommits
+	SkipVerifyFailed              = core.SkipVerifyFailed
+	StateVersion                  = core.StateVersion
+	SubmoduleCleanStatusClean     = core.SubmoduleCleanStatusClean
+	SubmoduleCleanStatusDirty     = core.SubmoduleCleanStatusDirty
+	SubmoduleCleanStatusNone      = core.SubmoduleCleanStatusNone
+	SubmoduleStateClean           = core.SubmoduleStateClean
+	SubmoduleStateConflict        = core.SubmoduleStateConflict
+	SubmoduleStateModified        = core.SubmoduleStateModified
+	SubmoduleStateUninitialized   = core.SubmoduleStateUninitialized
+	SymlinkCorrect                = core.SymlinkCorrect
+	SymlinkMissing                = core.SymlinkMissing
+	SymlinkStyleAbsolute          = core.SymlinkStyleAbsolute
+	SymlinkStyleRelative          = core.SymlinkStyleRelative
+	SymlinkWrongTarget            = core.SymlinkWrongTarget
+	TimingPhaseConfig             = core.TimingPhaseConfig
+	TimingPhaseSubmodules         = core.TimingPhaseSubmodules
+	TimingPhaseSymlinks           = core.TimingPhaseSymlinks
+	VCSGit                        = core.VCSGit
+	VCSJJColocated                = core.VCSJJColocated
+	WarningAdoptedChanges         = core.WarningAdoptedChanges
+	WarningAuditFailed            = core.WarningAuditFailed
+	WarningAuditMalformed         = core.WarningAuditMalformed
+	WarningCarryLeft              = core.WarningCarryLeft
+	WarningCaseCollision          = core.WarningCaseCollision
+	WarningDescriptionFailed      = core.WarningDescriptionFailed
+	WarningFetchFailed            = core.WarningFetchFailed
+	WarningForgeFailed            = core.WarningForgeFailed
+	WarningHookFailed             = core.WarningHookFailed
+	WarningPROutdated             = core.WarningPROutdated
+	WarningProvenanceFailed       = core.WarningProvenanceFailed
+	WarningReplacedLeft           = core.WarningReplacedLeft
+	WarningRepointFailed          = core.WarningRepointFailed
+	WarningSearchFailed           = core.WarningSearchFailed
+	WarningSquashCheckFailed      = core.WarningSquashCheckFailed
+	WarningSubmoduleNoReference   = core.WarningSubmoduleNoReference
+	WarningSubmodulePathUnmatched = core.WarningSubmodulePathUnmatched
+	WarningSubmoduleSkipped       = core.WarningSubmoduleSkipped
+	WarningSymlinkSkipped         = core.WarningSymlinkSkipped
+	WarningSymlinkSource          = core.WarningSymlinkSource
+	WarningUpstreamFailed         = core.WarningUpstreamFailed
+	WorktreeForceLevelLocked      = core.WorktreeForceLevelLocked
+	WorktreeForceLevelNone        = core.WorktreeForceLevelNone
+	WorktreeForceLevelUnclean     = core.WorktreeForceLevelUnclean
+	WorktreeNoteFileName          = core.WorktreeNoteFileName
+)
+
+// Variables and functions.
+var (
+	AcquireOperationLock           = core.AcquireOperationLock
+	CommandNotification            = core.CommandNotification
+	ConfigKeyNames                 = core.ConfigKeyNames
+	ConfigSchema                   = core.ConfigSchema
+	CreateScratchDir               = core.CreateScratchDir
+	DetectVCS                      = core.DetectVCS
+	DiffConfig                     = core.DiffConfig
+	EffectiveConfig                = core.EffectiveConfig
+	ErrGitTimeout                  = core.ErrGitTimeout
+	FindScratchLeftovers           = core.FindScratchLeftovers
+	GenerateCommandID              = core.GenerateCommandID
+	GenerateCommandIDWithLength    = core.GenerateCommandIDWithLength
+	GetConfig                      = core.GetConfig
+	GitErrorCodeOf                 = core.GitErrorCodeOf
+	GitRemoteCommands              = core.GitRemoteCommands
+	InferDefaultSource             = core.InferDefaultSource
+	IsColorEnabled                 = format.IsColorEnabled
+	ListProfiles                   = core.ListProfiles
+	LoadConfig                     = core.LoadConfig
+	NewAddCommand                  = core.NewAddCommand
+	NewAdoptCommand                = core.NewAdoptCommand
+	NewAgeResolver                 = core.NewAgeResolver
+	NewAuditCommand                = core.NewAuditCommand
+	NewAuditLog                    = core.NewAuditLog
+	NewCLIHandler                  = core.NewCLIHandler
+	NewCleanCommand                = core.NewCleanCommand
+	NewCompletionCache             = core.NewCompletionCache
+	NewConfig                      = core.NewConfig
+	NewConfigCheckCommand          = core.NewConfigCheckCommand
+	NewConfigMigrateCommand        = core.NewConfigMigrateCommand
+	NewConfigSetCommand            = core.NewConfigSetCommand
+	NewDefaultAddCommand           = core.NewDefaultAddCommand
+	NewDefaultAdoptCommand         = core.NewDefaultAdoptCommand
+	NewDefaultAuditCommand         = core.NewDefaultAuditCommand
+	NewDefaultCleanCommand         = core.NewDefaultCleanCommand
+	NewDefaultCompletionCache      = core.NewDefaultCompletionCache
+	NewDefaultConfigCheckCommand   = core.NewDefaultConfigCheckCommand
+	NewDefaultConfigMigrateCommand = core.NewDefaultConfigMigrateCommand
+	NewDefaultConfigSetCommand     = core.NewDefaultConfigSetCommand
+	NewDefaultDoctorCommand        = core.NewDefaultDoctorCommand
+	NewDefaultExportCommand        = core.NewDefaultExportCommand
+	NewDefaultForgeClient          = core.NewDefaultForgeClient
+	NewDefaultGCCommand            = core.NewDefaultGCCommand
+	NewDefaultGitHookCommand       = core.NewDefaultGitHookCommand
+	NewDefaultGrepCommand          = core.NewDefaultGrepCommand
+	NewDefaultImportCommand        = core.NewDefaultImportCommand
+	NewDefaultInitCommand          = core.NewDefaultInitCommand
+	NewDefaultListCommand          = core.NewDefaultListCommand
+	NewDefaultNoteCommand          = core.NewDefaultNoteCommand
+	NewDefaultOpenCommand          = core.NewDefaultOpenCommand
+	NewDefaultOverlayCommand       = core.NewDefaultOverlayCommand
+	NewDefaultPathCommand          = core.NewDefaultPathCommand
+	NewDefaultPromptInfoCommand    = core.NewDefaultPromptInfoCommand
+	NewDefaultRefReader            = core.NewDefaultRefReader
+	NewDefaultRemoveCommand        = core.NewDefaultRemoveCommand
+	NewDefaultRenameCommand        = core.NewDefaultRenameCommand
+	NewDefaultSyncCommand          = core.NewDefaultSyncCommand
+	NewDefaultWatchCommand         = core.NewDefaultWatchCommand
+	NewDiskUsage                   = core.NewDiskUsage
+	NewDoctorCommand               = core.NewDoctorCommand
+	NewExportCommand               = core.NewExportCommand
+	NewFaultProfile                = core.NewFaultProfile
+	NewForgeClient                 = core.NewForgeClient
+	NewGCCommand                   = core.NewGCCommand
+	NewGitHookCommand              = core.NewGitHookCommand
+	NewGitLockWaiter               = core.NewGitLockWaiter
+	NewGitRunner                   = core.NewGitRunner
+	NewGrepCommand                 = core.NewGrepCommand
+	NewImportCommand               = core.NewImportCommand
+	NewInitCommand                 = core.NewInitCommand
+	NewListCommand                 = core.NewListCommand
+	NewLogHandler                  = core.NewLogHandler
+	NewNopLogger                   = core.NewNopLogger
+	NewNoteCommand                 = core.NewNoteCommand
+	NewNoteStore                   = core.NewNoteStore
+	NewNotifier                    = core.NewNotifier
+	NewOSFileSystem                = core.NewOSFileSystem
+	NewOpenCommand                 = core.NewOpenCommand
+	NewOverlayCommand              = core.NewOverlayCommand
+	NewPathCommand                 = core.NewPathCommand
+	NewPromptInfoCommand           = core.NewPromptInfoCommand
+	NewProvenanceStore             = core.NewProvenanceStore
+	NewRefReader                   = core.NewRefReader
+	NewRemoveCommand               = core.NewRemoveCommand
+	NewRenameCommand               = core.NewRenameCommand
+	NewSyncCommand                 = core.NewSyncCommand
+	NewTimings                     = core.NewTimings
+	NewWatchCommand                = core.NewWatchCommand
+	NewWorktreeArchiver            = core.NewWorktreeArchiver
+	ParseAddBatch                  = core.ParseAddBatch
+	ParseAuditSince                = core.ParseAuditSince
+	ParseFaultProfile              = core.ParseFaultProfile
+	ParseLogFormat                 = core.ParseLogFormat
+	ParseState                     = core.ParseState
+	PromptSegmentScript            = core.PromptSegmentScript
+	ResolveAddSource               = core.ResolveAddSource
+	SaveDefaultSource              = core.SaveDefaultSource
+	SetColorMode                   = format.SetColorMode
+	SetColorTheme                  = core.SetColorTheme
+	SupportedCaseCollisions        = core.SupportedCaseCollisions
+	SupportedForges                = core.SupportedForges
+	SupportedGitHooks              = core.SupportedGitHooks
+	SupportedSymlinkStyles         = core.SupportedSymlinkStyles
+	VerbosityToLevel               = core.VerbosityToLevel
+	WithCommandTimeout             = core.WithCommandTimeout
+	WithCreateBranch               = core.WithCreateBranch
+	WithDetach                     = core.WithDetach
+	WithExecutor                   = core.WithExecutor
+	WithFaults                     = core.WithFaults
+	WithForceDelete                = core.WithForceDelete
+	WithForceRemove                = core.WithForceRemove
+	WithGetenv                     = core.WithGetenv
+	WithLock                       = core.WithLock
+	WithLockReason                 = core.WithLockReason
+	WithLogger                     = core.WithLogger
+	WithMainWorktreeDir            = core.WithMainWorktreeDir
+	WithNoCheckout                 = core.WithNoCheckout
+	WithProfile                    = core.WithProfile
+	WithReverse                    = core.WithReverse
+	WithScratchDir                 = core.WithScratchDir
+	WithStartPoint                 = core.WithStartPoint
+	WithSubmodulePaths             = core.WithSubmodulePaths
+	WithSubmoduleReference         = core.WithSubmoduleReference
+	WithSubmoduleReferenceDir      = core.WithSubmoduleReferenceDir
+	WithThreeWay                   = core.WithThreeWay
+	WithTimeout                    = core.WithTimeout
+	WithTimings                    = core.WithTimings
+	WithoutSubmoduleRecursion      = core.WithoutSubmoduleRecursion
+)
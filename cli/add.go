@@ -0,0 +1,606 @@
+package cli
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/708u/twig/core"
+	"github.com/spf13/cobra"
+)
+
+// newAddCmd creates the twig add command.
+func (a *app) newAddCmd() *cobra.Command {
+	// Set by add's PreRunE when the source is a ref without a worktree
+	var sourceStartPoint string
+
+	addCmd := &cobra.Command{
+		Use:   "add <name>...",
+		Short: "Create a new worktree with a new branch",
+		Long: `Create a new worktree with a new branch.
+
+Creates worktree at WorktreeDestBaseDir/<name> and sets up symlinks
+based on configuration.
+
+Multiple names can be specified to create several worktrees in parallel.
+Errors on individual branches will not stop processing of remaining branches.
+
+Use --sync to copy uncommitted changes (both worktrees keep them).
+Use --carry to move uncommitted changes (only new worktree has them).
+
+Use --file with --sync or --carry to target specific files:
+
+  twig add feat/new --sync --file "*.go"
+  twig add feat/new --carry --file "*.go" --file "cmd/**"
+
+Files ignored by .gitignore are left behind even when a --file pattern
+matches them; add --include-ignored to carry them too.
+
+Use --batch to read branch names from a file (or "-" for stdin), one per
+line. Each line may add --source, --lock, --reason, --init-submodules
+or --no-prefix. Lines starting with "#" are ignored:
+
+  printf '%s\n' feat/a 'feat/b --source develop' | twig add --batch -
+
+Use --ci on ephemeral CI agents: symlinks and submodule init are skipped,
+files are checked out only for the --sparse directories (all files when
+none are given), and output is porcelain records on stdout:
+
+  twig add --ci --sparse api --sparse proto build/123
+
+Use --track to set the upstream to an existing remote branch, or --push
+to publish a new branch so that plain "git push" works right away:
+
+  twig add feat/review --track
+  twig add feat/new --push
+
+Use --fetch (or fetch_on_add) to fetch a branch missing locally from the
+remotes first, so that a branch pushed after the last git fetch is
+checked out instead of created anew:
+
+  twig add feat/teammate --fetch
+
+Use --restore to recreate a branch deleted by twig remove or twig clean
+at the commit it pointed to, as recorded in the audit log:
+
+  twig add feat/deleted --restore
+
+Use --detach to check out a commit or tag with a detached HEAD, without
+creating a branch. The worktree is named after the argument:
+
+  twig add --detach v1.2.3
+
+Use --no-symlinks to skip the configured symlinks, and --symlink to link
+additional patterns, for this worktree only:
+
+  twig add feat/experiment --no-symlinks --symlink .tool-versions
+
+Use --repo to create the worktree in another repository without changing
+directories. Its config, default source and destination are used:
+
+  twig add feat/x --repo ~/src/other-repo
+
+Use --base-dir to put this worktree under another directory instead of
+worktree_destination_base_dir, for example on a larger disk. The directory
+is created if missing. remove, clean and list find the worktree through
+git, so nothing needs to be configured:
+
+  twig add scratch/big-build --base-dir /mnt/scratch/worktrees
+
+Use --no-checkout when another tool populates the files (a sparse checkout
+script, a build system). Symlinks and submodules are skipped; run
+"twig sync" in the worktree once the files are in place:
+
+  twig add feat/big --no-checkout
+
+If the worktree directory exists but is not a worktree, for example after
+a crashed run, use --on-exists adopt to register the files in it as the
+worktree, or --on-exists replace to delete it first:
+
+  twig add feat/x --on-exists adopt
+
+Use --pr to review a pull request in its own worktree. Its head is fetched
+from origin (pull/<n>/head, or merge-requests/<n>/head with forge =
+"gitlab") and the branch is named after pr_branch_template, by default
+pr/<n>-<title> with the title looked up on the configured forge. A name
+argument replaces the template:
+
+  twig add --pr 1234
+  twig add --pr 1234 review/login`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if cmd.Flags().Changed("batch") {
+				if len(args) > 0 {
+					return fmt.Errorf("cannot use --batch with branch arguments")
+				}
+				return nil
+			}
+			if cmd.Flags().Changed("pr") {
+				return cobra.MaximumNArgs(1)(cmd, args)
+			}
+			return cobra.MinimumNArgs(1)(cmd, args)
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			branches, err := completeBranches(cmd)
+			if err != nil {
+				return nil, cobra.ShellCompDirectiveError
+			}
+			// Exclude already-specified branches
+			var available []string
+			for _, b := range branches {
+				if !slices.Contains(args, b) {
+					available = append(available, b)
+				}
+			}
+			return available, cobra.ShellCompDirectiveNoFileComp
+		},
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			source, _ := cmd.Flags().GetString("source")
+			sync, _ := cmd.Flags().GetBool("sync")
+			carryEnabled := cmd.Flags().Changed("carry")
+
+			// --sync and --carry are mutually exclusive
+			if sync && carryEnabled {
+				return fmt.Errorf("cannot use --sync and --carry together")
+			}
+
+			if cmd.Flags().Changed("batch") && (sync || carryEnabled) {
+				return fmt.Errorf("--sync and --carry cannot be used with --batch")
+			}
+
+			// Without a branch, --carry moves changes out of the current
+			// directory, which is not in the --repo repository
+			if carryValue, _ := cmd.Flags().GetString("carry"); carryValue == carryFromCurrent && cmd.Flags().Changed("repo") {
+				return fmt.Errorf("--carry requires a branch (--carry=<branch>) when used with --repo")
+			}
+
+			trackEnabled := cmd.Flags().Changed("track")
+			if trackEnabled && cmd.Flags().Changed("push") {
+				return fmt.Errorf("cannot use --track and --push together")
+			}
+			if restore, _ := cmd.Flags().GetBool("restore"); restore && trackEnabled {
+				return fmt.Errorf("cannot use --restore and --track together")
+			}
+			if cmd.Flags().Changed("pr") {
+				for _, name := range []string{"detach", "restore", "batch"} {
+					if cmd.Flags().Changed(name) {
+						return fmt.Errorf("cannot use --pr and --%s together", name)
+					}
+				}
+			}
+			if detach, _ := cmd.Flags().GetBool("detach"); detach {
+				for _, name := range []string{"track", "push", "restore", "batch"} {
+					if cmd.Flags().Changed(name) {
+						return fmt.Errorf("cannot use --detach and --%s together", name)
+					}
+				}
+			}
+			if trackValue, _ := cmd.Flags().GetString("track"); trackEnabled && trackValue != trackSameName {
+				if trackValue == "" {
+					return fmt.Errorf("track value cannot be empty")
+				}
+				if len(args) > 1 || cmd.Flags().Changed("batch") {
+					return fmt.Errorf("--track=<remote>/<branch> requires a single branch")
+				}
+			}
+			if pushRemote, _ := cmd.Flags().GetString("push"); cmd.Flags().Changed("push") && pushRemote == "" {
+				return fmt.Errorf("push remote cannot be empty")
+			}
+
+			ci, _ := cmd.Flags().GetBool("ci")
+			if cmd.Flags().Changed("sparse") && !ci {
+				return fmt.Errorf("--sparse requires --ci")
+			}
+			if ci {
+				if sync || carryEnabled {
+					return fmt.Errorf("--sync and --carry cannot be used with --ci")
+				}
+				if cmd.Flags().Changed("init-submodules") || cmd.Flags().Changed("submodule-reference") {
+					return fmt.Errorf("--init-submodules and --submodule-reference cannot be used with --ci")
+				}
+				if cmd.Flags().Changed("symlink") {
+					return fmt.Errorf("cannot use --ci and --symlink together")
+				}
+			}
+			if noCheckout, _ := cmd.Flags().GetBool("no-checkout"); noCheckout {
+				for _, name := range []string{"ci", "sync", "carry", "init-submodules", "submodule-reference", "symlink"} {
+					if cmd.Flags().Changed(name) {
+						return fmt.Errorf("cannot use --no-checkout and --%s together", name)
+					}
+				}
+			}
+
+			// Changes can only be synced or carried to a single new worktree.
+			// This also catches "--carry <branch>", which cobra parses as
+			// an extra positional argument.
+			if len(args) > 1 && (sync || carryEnabled) {
+				return fmt.Errorf("--sync and --carry require a single branch (use --carry=<branch> to specify the source)")
+			}
+
+			// Resolve effective source: CLI --source > config default_source
+			if source == "" {
+				source = a.cfg.DefaultSource
+			}
+
+			if source == "" {
+				return nil
+			}
+
+			// Load config from the source worktree, or from the main
+			// worktree for a ref that is not checked out
+			result, src, err := loadSourceConfig(cmd.Context(), a.cwd, source, a.profileFlag)
+			if err != nil {
+				return err
+			}
+			if src.StartPoint != "" && sync {
+				return fmt.Errorf("--sync requires the source branch %q to be checked out in a worktree", source)
+			}
+			for _, w := range result.Warnings {
+				fmt.Fprintln(cmd.ErrOrStderr(), "warning:", w)
+			}
+			a.cwd = src.Dir
+			a.cfg = result.Config
+			sourceStartPoint = src.StartPoint
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			verbosity, _ := cmd.Flags().GetCount("verbose")
+			verbose := verbosity >= 1
+			syncChanges, _ := cmd.Flags().GetBool("sync")
+			quiet, _ := cmd.Flags().GetBool("quiet")
+			lock, _ := cmd.Flags().GetBool("lock")
+			lockReason, _ := cmd.Flags().GetString("reason")
+			noPrefix, _ := cmd.Flags().GetBool("no-prefix")
+			carryEnabled := cmd.Flags().Changed("carry")
+			batchPath, _ := cmd.Flags().GetString("batch")
+			jobs, _ := cmd.Flags().GetInt("jobs")
+			ci, _ := cmd.Flags().GetBool("ci")
+			sparsePaths, _ := cmd.Flags().GetStringArray("sparse")
+			trackEnabled := cmd.Flags().Changed("track")
+			var upstream string
+			if trackValue, _ := cmd.Flags().GetString("track"); trackValue != trackSameName {
+				upstream = trackValue
+			}
+			pushRemote, _ := cmd.Flags().GetString("push")
+			restore, _ := cmd.Flags().GetBool("restore")
+			detach, _ := cmd.Flags().GetBool("detach")
+			noSymlinks, _ := cmd.Flags().GetBool("no-symlinks")
+			extraSymlinks, _ := cmd.Flags().GetStringArray("symlink")
+			noCheckout, _ := cmd.Flags().GetBool("no-checkout")
+			fetch, _ := cmd.Flags().GetBool("fetch")
+			onExists, _ := cmd.Flags().GetString("on-exists")
+			repair, _ := cmd.Flags().GetBool("repair")
+			description, _ := cmd.Flags().GetString("description")
+			pr, _ := cmd.Flags().GetInt("pr")
+			// Relative to where the command was typed, not the --source
+			// or --repo worktree
+			baseDir, err := baseDirFlag(cmd, a.originalCwd)
+			if err != nil {
+				return err
+			}
+
+			if jobs < 1 {
+				return fmt.Errorf("--jobs must be at least 1")
+			}
+
+			// Get file patterns from --file flag
+			filePatterns, _ := cmd.Flags().GetStringArray("file")
+
+			// --file requires --carry or --sync
+			if len(filePatterns) > 0 && !carryEnabled && !syncChanges {
+				return fmt.Errorf("--file requires --carry or --sync flag")
+			}
+			includeIgnored, _ := cmd.Flags().GetBool("include-ignored")
+			if includeIgnored && len(filePatterns) == 0 {
+				return fmt.Errorf("--include-ignored requires --file")
+			}
+
+			// --init-submodules forces enable, otherwise use config
+			initSubmodules := cmd.Flags().Changed("init-submodules")
+
+			// --submodule-reference forces enable, otherwise use config
+			submoduleReference := cmd.Flags().Changed("submodule-reference")
+
+			// --reason requires --lock
+			if lockReason != "" && !lock {
+				return fmt.Errorf("--reason requires --lock")
+			}
+
+			idGen := core.GenerateCommandID
+			if a.commandIDGenerator != nil {
+				idGen = a.commandIDGenerator
+			}
+			log := createLogger(cmd.ErrOrStderr(), verbosity, a.logFormat, idGen)
+
+			if a.addCommander == nil {
+				release, err := a.lockRepository(cmd, a.cwd, log)
+				if err != nil {
+					return err
+				}
+				defer release()
+			}
+
+			// Resolve CarryFrom path
+			var carryFrom string
+			if carryEnabled {
+				carryValue, _ := cmd.Flags().GetString("carry")
+				git := core.NewGitRunner(a.cwd, append(a.gitOptions(), core.WithLogger(log))...)
+				var err error
+				carryFrom, err = resolveCarryFrom(cmd.Context(), carryValue, a.originalCwd, git)
+				if err != nil {
+					return err
+				}
+			}
+
+			formatOpts := core.AddFormatOptions{
+				Verbose:   verbose,
+				Quiet:     quiet,
+				Porcelain: ci,
+			}
+
+			if batchPath != "" {
+				entries, err := readAddBatch(cmd.InOrStdin(), batchPath)
+				if err != nil {
+					return err
+				}
+
+				// Configs are loaded once per source before any worktree is created
+				type sourceConfig struct {
+					cfg        *core.Config
+					startPoint string
+				}
+				configs := map[string]sourceConfig{"": {cfg: a.cfg, startPoint: sourceStartPoint}}
+				runs := make([]func(context.Context) (core.AddResult, error), len(entries))
+				for i, e := range entries {
+					opts := core.AddOptions{
+						Lock:               lock || e.Lock,
+						LockReason:         cmp.Or(e.LockReason, lockReason),
+						InitSubmodules:     initSubmodules || e.InitSubmodules,
+						SubmoduleReference: submoduleReference,
+						NoPrefix:           noPrefix || e.NoPrefix,
+						CI:                 ci,
+						SparsePaths:        sparsePaths,
+						Track:              trackEnabled,
+						PushRemote:         pushRemote,
+						Restore:            restore,
+						NoSymlinks:         noSymlinks,
+						ExtraSymlinks:      extraSymlinks,
+						BaseDir:            baseDir,
+						NoCheckout:         noCheckout,
+						Fetch:              fetch,
+						OnExists:           core.OnExists(onExists),
+						Repair:             repair,
+						Description:        description,
+						Provenance:         provenance(cmd),
+					}
+					if a.addCommander != nil {
+						runs[i] = func(ctx context.Context) (core.AddResult, error) {
+							return a.addCommander.Run(ctx, e.Name)
+						}
+						continue
+					}
+
+					entry, ok := configs[e.Source]
+					if !ok {
+						result, src, err := loadSourceConfig(cmd.Context(), a.cwd, e.Source, a.profileFlag)
+						if err != nil {
+							err = fmt.Errorf("line %d: %w", e.Line, err)
+							runs[i] = func(context.Context) (core.AddResult, error) {
+								return core.AddResult{}, err
+							}
+							continue
+						}
+						for _, w := range result.Warnings {
+							fmt.Fprintln(cmd.ErrOrStderr(), "warning:", w)
+						}
+						entry = sourceConfig{cfg: result.Config, startPoint: src.StartPoint}
+						configs[e.Source] = entry
+					}
+					opts.StartPoint = entry.startPoint
+					addCmd := core.NewDefaultAddCommand(entry.cfg, log, opts, a.gitOptions()...)
+					runs[i] = func(ctx context.Context) (core.AddResult, error) {
+						return addCmd.Run(ctx, e.Name)
+					}
+				}
+
+				batch := runAdds(cmd.Context(), jobs, runs)
+				for i := range batch.Added {
+					if batch.Added[i].Branch == "" {
+						batch.Added[i].Branch = entries[i].Name
+					}
+				}
+
+				formatOpts.Summary = true
+				formatted := batch.Format(formatOpts)
+				if formatted.Stderr != "" {
+					fmt.Fprint(cmd.ErrOrStderr(), formatted.Stderr)
+				}
+				fmt.Fprint(cmd.OutOrStdout(), formatted.Stdout)
+
+				if batch.HasErrors() {
+					return fmt.Errorf("failed to add %d branch(es)", batch.ErrorCount())
+				}
+				return nil
+			}
+
+			var addCmd AddCommander
+			if a.addCommander != nil {
+				addCmd = a.addCommander
+			} else {
+				addCmd = core.NewDefaultAddCommand(a.cfg, log, core.AddOptions{
+					Sync:               syncChanges,
+					CarryFrom:          carryFrom,
+					FilePatterns:       filePatterns,
+					IncludeIgnored:     includeIgnored,
+					Lock:               lock,
+					LockReason:         lockReason,
+					InitSubmodules:     initSubmodules,
+					SubmoduleReference: submoduleReference,
+					NoPrefix:           noPrefix,
+					CI:                 ci,
+					SparsePaths:        sparsePaths,
+					Track:              trackEnabled,
+					Upstream:           upstream,
+					PushRemote:         pushRemote,
+					Restore:            restore,
+					Detach:             detach,
+					NoSymlinks:         noSymlinks,
+					ExtraSymlinks:      extraSymlinks,
+					BaseDir:            baseDir,
+					NoCheckout:         noCheckout,
+					StartPoint:         sourceStartPoint,
+					Fetch:              fetch,
+					OnExists:           core.OnExists(onExists),
+					Repair:             repair,
+					Description:        description,
+					PR:                 pr,
+					Provenance:         provenance(cmd),
+				}, a.gitOptions()...)
+			}
+
+			if len(args) <= 1 {
+				// With --pr the name is optional
+				var name string
+				if len(args) == 1 {
+					name = args[0]
+				}
+				result, err := addCmd.Run(cmd.Context(), name)
+				if err != nil {
+					return err
+				}
+
+				formatted := result.Format(formatOpts)
+				if formatted.Stderr != "" {
+					fmt.Fprint(cmd.ErrOrStderr(), formatted.Stderr)
+				}
+				fmt.Fprint(cmd.OutOrStdout(), formatted.Stdout)
+				if a.addCommander == nil && !quiet && !ci {
+					printGCHint(cmd, a.cfg, log, a.gitOptions())
+				}
+				return nil
+			}
+
+			runs := make([]func(context.Context) (core.AddResult, error), len(args))
+			for i, name := range args {
+				runs[i] = func(ctx context.Context) (core.AddResult, error) {
+					return addCmd.Run(ctx, name)
+				}
+			}
+			batch := runAdds(cmd.Context(), jobs, runs)
+			for i := range batch.Added {
+				if batch.Added[i].Branch == "" {
+					batch.Added[i].Branch = args[i]
+				}
+			}
+
+			formatted := batch.Format(formatOpts)
+			if formatted.Stderr != "" {
+				fmt.Fprint(cmd.ErrOrStderr(), formatted.Stderr)
+			}
+			fmt.Fprint(cmd.OutOrStdout(), formatted.Stdout)
+			if a.addCommander == nil && !quiet && !ci {
+				printGCHint(cmd, a.cfg, log, a.gitOptions())
+			}
+
+			if batch.HasErrors() {
+				return fmt.Errorf("failed to add %d branch(es)", batch.ErrorCount())
+			}
+			return nil
+		},
+	}
+
+	addCmd.Flags().BoolP("sync", "s", false, "Sync uncommitted changes to new worktree")
+	addCmd.Flags().StringP("carry", "c", "", "Move uncommitted changes (<branch>: from specified worktree)")
+	addCmd.Flags().Lookup("carry").NoOptDefVal = carryFromCurrent
+	addCmd.Flags().String("source", "", "Source branch's worktree to use")
+	addCmd.Flags().Bool("lock", false, "Lock the worktree after creation")
+	addCmd.Flags().String("reason", "", "Reason for locking (requires --lock)")
+	addCmd.Flags().StringArrayP("file", "F", nil, "File patterns to sync/carry (requires --sync or --carry)")
+	addCmd.Flags().Bool("include-ignored", false, "Also sync/carry files ignored by .gitignore that match --file")
+	addCmd.Flags().Bool("init-submodules", false, "Initialize submodules in new worktree")
+	addCmd.Flags().Bool("submodule-reference", false, "Use main worktree as reference for submodule init")
+	addCmd.Flags().Bool("no-prefix", false, "Use the name as the branch name, ignoring branch_prefix and branch_aliases")
+	addCmd.Flags().String("batch", "", "Read branch names from a file (- for stdin), one per line")
+	addCmd.Flags().IntP("jobs", "j", core.DefaultAddJobs, "Maximum number of worktrees to create in parallel")
+	addCmd.Flags().Bool("ci", false, "Create a minimal worktree for CI: no symlinks or submodules, porcelain output")
+	addCmd.Flags().StringArray("sparse", nil, "Directory to check out with --ci (repeatable; default: all files)")
+	addCmd.Flags().String("track", "", "Set upstream to an existing remote branch (default: same name on its remote)")
+	addCmd.Flags().Lookup("track").NoOptDefVal = trackSameName
+	addCmd.Flags().String("push", "", "Push the new branch to a remote and set it as upstream (default: origin)")
+	addCmd.Flags().Lookup("push").NoOptDefVal = defaultPushRemote
+	addCmd.Flags().Bool("restore", false, "Recreate a removed branch at the commit recorded in the audit log")
+	addCmd.Flags().Bool("detach", false, "Check out the given commit or tag with a detached HEAD instead of a branch")
+	addCmd.Flags().Bool("no-symlinks", false, "Skip the configured symlinks for this worktree")
+	addCmd.Flags().StringArray("symlink", nil, "Additional symlink pattern for this worktree (repeatable)")
+	addCmd.Flags().String("repo", "", "Create the worktree in the repository at <path> instead of the current one")
+	addCmd.Flags().String("base-dir", "", "Create the worktree under <path> instead of worktree_destination_base_dir")
+	addCmd.Flags().Bool("no-checkout", false, "Create the worktree without checking out files; symlinks and submodules wait for twig sync")
+	addCmd.Flags().Bool("fetch", false, "Fetch a branch missing locally from the remotes before creating it as a new branch")
+	addCmd.Flags().Int("pr", 0, "Check out a pull request: fetch its head from origin and name the branch after pr_branch_template")
+	addCmd.Flags().String("on-exists", string(core.OnExistsFail), "What to do when the worktree directory exists but is not a worktree: fail, adopt or replace")
+	addCmd.Flags().Bool("repair", false, "Prune a stale worktree entry left by an interrupted add instead of failing")
+	addCmd.Flags().String("description", "", "Set the branch description (branch.<name>.description) shown by list -l and clean --check")
+	addCmd.RegisterFlagCompletionFunc("on-exists", cobra.FixedCompletions(
+		[]string{string(core.OnExistsFail), string(core.OnExistsAdopt), string(core.OnExistsReplace)},
+		cobra.ShellCompDirectiveNoFileComp))
+	addCmd.RegisterFlagCompletionFunc("base-dir", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return nil, cobra.ShellCompDirectiveFilterDirs
+	})
+	addCmd.RegisterFlagCompletionFunc("repo", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return nil, cobra.ShellCompDirectiveFilterDirs
+	})
+	addCmd.RegisterFlagCompletionFunc("file", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		// Resolve target directory from -C flag
+		dir, err := resolveCompletionDirectory(cmd)
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+
+		ctx := cmd.Context()
+		git := core.NewGitRunner(dir)
+
+		// Use --carry target's worktree if specified
+		if cmd.Flags().Changed("carry") {
+			carryValue, _ := cmd.Flags().GetString("carry")
+			if carryValue != "" && carryValue != carryFromCurrent {
+				if carryWT, err := git.WorktreeFindByBranch(ctx, carryValue); err == nil {
+					dir = carryWT.Path
+				}
+			}
+		}
+
+		// Recreate with resolved dir (GitRunner holds dir internally)
+		git = core.NewGitRunner(dir)
+		files, err := git.ChangedFiles(ctx)
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+
+		// Filter by prefix
+		var completions []string
+		for _, file := range files {
+			if strings.HasPrefix(file.Path, toComplete) {
+				completions = append(completions, file.Path)
+			}
+		}
+
+		return completions, cobra.ShellCompDirectiveNoSpace
+	})
+	addCmd.RegisterFlagCompletionFunc("carry", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		branches, err := completeWorktreeBranches(cmd)
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+		return branches, cobra.ShellCompDirectiveNoFileComp
+	})
+	addCmd.RegisterFlagCompletionFunc("source", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		branches, err := completeWorktreeBranches(cmd)
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+		return branches, cobra.ShellCompDirectiveNoFileComp
+	})
+	a.notifyOnFinish(addCmd)
+	return addCmd
+}
@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/708u/twig/core"
+	"github.com/708u/twig/format"
+	"github.com/spf13/cobra"
+)
+
+// newAdoptCmd creates the twig adopt command.
+func (a *app) newAdoptCmd() *cobra.Command {
+	adoptCmd := &cobra.Command{
+		Use:   "adopt [<branch>...]",
+		Short: "Set up worktrees created without twig",
+		Long: `Set up worktrees created with plain git worktree add like twig add would.
+
+Without branches, every worktree outside worktree_destination_base_dir is
+adopted; the main worktree and the symlink source never are. The
+configured symlinks (and .twig.env with env_file) are created in each
+worktree. Files that already exist are kept and reported.
+
+With --move, the worktrees are also moved to the path twig add uses,
+<worktree_destination_base_dir>/<branch>, with git worktree move.
+Locked worktrees are not moved.
+
+  twig adopt --check --move   # show the plan
+  twig adopt --move
+
+twig finds worktrees through git, so adopted worktrees work with list,
+remove, clean and sync right away.`,
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			branches, err := completeLinkedBranches(cmd)
+			if err != nil {
+				return nil, cobra.ShellCompDirectiveError
+			}
+			return branches, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			verbosity, _ := cmd.Flags().GetCount("verbose")
+			move, _ := cmd.Flags().GetBool("move")
+			check, _ := cmd.Flags().GetBool("check")
+
+			idGen := core.GenerateCommandID
+			if a.commandIDGenerator != nil {
+				idGen = a.commandIDGenerator
+			}
+			log := createLogger(cmd.ErrOrStderr(), verbosity, a.logFormat, idGen)
+
+			var adoptCmdRunner AdoptCommander
+			if a.adoptCommander != nil {
+				adoptCmdRunner = a.adoptCommander
+			} else {
+				defaultAdopt := core.NewDefaultAdoptCommand(a.cfg, log, a.gitOptions()...)
+				defaultAdopt.Provenance = provenance(cmd)
+				adoptCmdRunner = defaultAdopt
+				if !check {
+					release, err := a.lockRepository(cmd, a.cwd, log)
+					if err != nil {
+						return err
+					}
+					defer release()
+				}
+			}
+			result, err := adoptCmdRunner.Run(cmd.Context(), args, a.originalCwd, core.AdoptOptions{
+				Move:  move,
+				Check: check,
+			})
+			if err != nil {
+				return err
+			}
+
+			formatted := result.Format(core.FormatOptions{Verbose: verbosity >= 1, ColorEnabled: format.IsColorEnabled()})
+			if formatted.Stderr != "" {
+				fmt.Fprint(cmd.ErrOrStderr(), formatted.Stderr)
+			}
+			fmt.Fprint(cmd.OutOrStdout(), formatted.Stdout)
+
+			if result.HasErrors() {
+				return fmt.Errorf("failed to adopt %d worktree(s)", result.ErrorCount())
+			}
+			return nil
+		},
+	}
+	adoptCmd.Flags().Bool("move", false, "Move the worktrees under worktree_destination_base_dir")
+	adoptCmd.Flags().Bool("check", false, "Show what would be done without making changes")
+	return adoptCmd
+}
@@ -0,0 +1,72 @@
+package cli
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/708u/twig/core"
+	"github.com/spf13/cobra"
+)
+
+// newAuditCmd creates the twig audit command.
+func (a *app) newAuditCmd() *cobra.Command {
+	auditCmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Show the log of worktrees removed by clean and remove",
+		Long: `Show worktrees and branches removed by twig clean and twig remove.
+
+Each removal is recorded in <git-common-dir>/twig/audit.jsonl with the
+branch, worktree path, HEAD commit, size, flags, user, and timestamp.
+Entries are shown oldest first.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			verbosity, _ := cmd.Flags().GetCount("verbose")
+			branch, _ := cmd.Flags().GetString("branch")
+			sinceFlag, _ := cmd.Flags().GetString("since")
+			limit, _ := cmd.Flags().GetInt("limit")
+
+			if limit < 0 {
+				return fmt.Errorf("--limit must be non-negative")
+			}
+
+			var since time.Time
+			if sinceFlag != "" {
+				var err error
+				since, err = core.ParseAuditSince(sinceFlag, time.Now())
+				if err != nil {
+					return err
+				}
+			}
+
+			idGen := core.GenerateCommandID
+			if a.commandIDGenerator != nil {
+				idGen = a.commandIDGenerator
+			}
+			log := createLogger(cmd.ErrOrStderr(), verbosity, a.logFormat, idGen)
+
+			var auditCmd AuditCommander
+			if a.auditCommander != nil {
+				auditCmd = a.auditCommander
+			} else {
+				auditCmd = core.NewDefaultAuditCommand(a.cwd, log, a.gitOptions()...)
+			}
+			result, err := auditCmd.Run(cmd.Context(), core.AuditOptions{
+				Branch: branch,
+				Since:  since,
+				Limit:  limit,
+			})
+			if err != nil {
+				return err
+			}
+
+			formatted := result.Format(core.AuditFormatOptions{Verbose: verbosity >= 1})
+			fmt.Fprint(cmd.ErrOrStderr(), formatted.Stderr)
+			fmt.Fprint(cmd.OutOrStdout(), formatted.Stdout)
+			return nil
+		},
+	}
+	auditCmd.Flags().String("branch", "", "Show only entries whose branch matches the glob pattern")
+	auditCmd.Flags().String("since", "", "Show only entries since a time (e.g. 7d, 36h, 2026-01-02)")
+	auditCmd.Flags().IntP("limit", "n", 0, "Show only the most recent N entries (0 = all)")
+	return auditCmd
+}
@@ -0,0 +1,210 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+
+	"github.com/708u/twig/core"
+	"github.com/708u/twig/format"
+	"github.com/spf13/cobra"
+)
+
+// newCleanCmd creates the twig clean command.
+func (a *app) newCleanCmd() *cobra.Command {
+	cleanCmd := &cobra.Command{
+		Use:   "clean",
+		Short: "Remove merged worktrees that are no longer needed",
+		Long: `Remove worktrees that have been merged to the target branch.
+
+With several --target branches (e.g. --target main --target release/2.4),
+a branch merged into any of them is cleanable.
+
+By default, shows candidates and prompts for confirmation.
+Use --yes to skip confirmation and remove immediately.
+Use --check to only show candidates without prompting.
+
+Safety checks (all must pass):
+  - Branch is merged to target (or its PR is merged/closed, with forge set)
+  - No uncommitted changes
+  - No commits missing from the remotes (unless the upstream is gone)
+  - Worktree is not locked
+  - Not the current directory
+  - Not the main worktree
+  - clean_verify_command, if set, exits with 0 (run with {path} replaced)
+
+Detached HEAD worktrees (e.g. from twig add --detach) are skipped unless
+--detached is given; they have no branch, so only the worktree is removed.
+
+Branches matching --exclude (repeatable, e.g. --exclude 'spike/*') or
+clean_exclude are never offered; -v lists them as excluded.
+
+Use --porcelain for a machine-readable dry run: like --check, nothing is
+removed, and each candidate (skipped ones included) is printed as
+
+  <branch>\t<path>\t<action>\t<reason>
+
+where action is "remove" or "skip" and reason is the clean or skip
+reason code (e.g. "merged", "not_merged"). The branch is empty for detached
+worktrees.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			verbosity, _ := cmd.Flags().GetCount("verbose")
+			verbose := verbosity >= 1
+			yes, _ := cmd.Flags().GetBool("yes")
+			check, _ := cmd.Flags().GetBool("check")
+			porcelain, _ := cmd.Flags().GetBool("porcelain")
+			quiet, _ := cmd.Flags().GetBool("quiet")
+			if yes && porcelain {
+				return fmt.Errorf("cannot use --yes and --porcelain together")
+			}
+			if quiet && porcelain {
+				return fmt.Errorf("cannot use --quiet and --porcelain together")
+			}
+			targets, _ := cmd.Flags().GetStringSlice("target")
+			forceCount, _ := cmd.Flags().GetCount("force")
+			stale, _ := cmd.Flags().GetBool("stale")
+			stale = stale || a.cfg.ShouldCleanStale()
+			fetch, _ := cmd.Flags().GetBool("fetch")
+			fetch = fetch || a.cfg.ShouldCleanFetch()
+			keepEmptyDirs, _ := cmd.Flags().GetBool("keep-empty-dirs")
+			keepEmptyDirs = keepEmptyDirs || !a.cfg.ShouldCleanupEmptyDirs()
+			detached, _ := cmd.Flags().GetBool("detached")
+			exclude, _ := cmd.Flags().GetStringArray("exclude")
+			archive, archiveDir := archiveFlag(cmd, a.cwd)
+
+			idGen := core.GenerateCommandID
+			if a.commandIDGenerator != nil {
+				idGen = a.commandIDGenerator
+			}
+			log := createLogger(cmd.ErrOrStderr(), verbosity, a.logFormat, idGen)
+
+			var cleanCmd CleanCommander
+			if a.cleanCommander != nil {
+				cleanCmd = a.cleanCommander
+			} else {
+				cleanCmd = core.NewDefaultCleanCommand(a.cfg, log, a.gitOptions()...)
+			}
+
+			// First pass: analyze candidates (always in check mode first).
+			// Remotes are fetched only here; the second pass reuses the refs.
+			result, err := cleanCmd.Run(cmd.Context(), a.cwd, core.CleanOptions{
+				Check:    true,
+				Targets:  targets,
+				Verbose:  verbose,
+				Force:    core.WorktreeForceLevel(forceCount),
+				Stale:    stale,
+				Fetch:    fetch,
+				Detached: detached,
+				Exclude:  exclude,
+			})
+			if err != nil {
+				return err
+			}
+
+			// If check mode or no candidates, just show output and exit
+			if check || porcelain || result.CleanableCount() == 0 {
+				formatted := result.Format(core.CleanFormatOptions{
+					Verbose:      verbose,
+					Quiet:        quiet,
+					ColorEnabled: format.IsColorEnabled(),
+					Porcelain:    porcelain,
+				})
+				if formatted.Stderr != "" {
+					fmt.Fprint(cmd.ErrOrStderr(), formatted.Stderr)
+				}
+				fmt.Fprint(cmd.OutOrStdout(), formatted.Stdout)
+				return nil
+			}
+
+			// Show candidates. With --quiet, stdout is kept for the removed
+			// branches, so the candidates and the prompt go to stderr.
+			promptOut := cmd.OutOrStdout()
+			if quiet {
+				promptOut = cmd.ErrOrStderr()
+			}
+			if !quiet || !yes {
+				formatted := result.Format(core.CleanFormatOptions{
+					Verbose:      verbose,
+					ColorEnabled: format.IsColorEnabled(),
+				})
+				if formatted.Stderr != "" {
+					fmt.Fprint(cmd.ErrOrStderr(), formatted.Stderr)
+				}
+				fmt.Fprint(promptOut, formatted.Stdout)
+			}
+
+			// If not --yes, prompt for confirmation
+			if !yes {
+				fmt.Fprint(promptOut, "\nProceed? [y/N]: ")
+				reader := bufio.NewReader(cmd.InOrStdin())
+				input, err := reader.ReadString('\n')
+				if err != nil {
+					return err
+				}
+				input = strings.TrimSpace(strings.ToLower(input))
+				if input != "y" && input != "yes" {
+					return nil
+				}
+			}
+
+			if a.cleanCommander == nil {
+				release, err := a.lockRepository(cmd, a.cwd, log)
+				if err != nil {
+					return err
+				}
+				defer release()
+			}
+
+			// Second pass: execute removal
+			result, err = cleanCmd.Run(cmd.Context(), a.cwd, core.CleanOptions{
+				Check:         false,
+				Targets:       targets,
+				Verbose:       verbose,
+				Force:         core.WorktreeForceLevel(forceCount),
+				Stale:         stale,
+				Detached:      detached,
+				Exclude:       exclude,
+				KeepEmptyDirs: keepEmptyDirs,
+				Archive:       archive,
+				ArchiveDir:    archiveDir,
+			})
+			if err != nil {
+				return err
+			}
+
+			formatted := result.Format(core.CleanFormatOptions{
+				Verbose:      verbose,
+				Quiet:        quiet,
+				ColorEnabled: format.IsColorEnabled(),
+			})
+			if formatted.Stderr != "" {
+				fmt.Fprint(cmd.ErrOrStderr(), formatted.Stderr)
+			}
+			fmt.Fprint(cmd.OutOrStdout(), formatted.Stdout)
+			return nil
+		},
+	}
+
+	cleanCmd.Flags().BoolP("yes", "y", false, "Execute removal without confirmation")
+	cleanCmd.Flags().Bool("check", false, "Show candidates without prompting or removing")
+	cleanCmd.Flags().Bool("porcelain", false, "Show all candidates as tab-separated records without removing (implies --check)")
+	cleanCmd.Flags().StringSlice("target", nil, "Target branch for merge check, repeatable or comma-separated (default: auto-detect)")
+	cleanCmd.Flags().CountP("force", "f", "Force clean (-f: unmerged/uncommitted/unpushed, -ff: also locked)")
+	cleanCmd.Flags().Bool("stale", false, "Remove merged/upstream-gone worktrees even with uncommitted changes")
+	cleanCmd.Flags().Bool("fetch", false, "Run git fetch --prune for each remote before checking candidates")
+	cleanCmd.Flags().Bool("detached", false, "Also remove detached HEAD worktrees without uncommitted changes")
+	cleanCmd.Flags().StringArray("exclude", nil, "Never offer branches matching a pattern (e.g. 'spike/*'), repeatable")
+	cleanCmd.Flags().Bool("keep-empty-dirs", false, "Keep parent directories (e.g. feat/) emptied by the removal")
+	cleanCmd.Flags().String("archive", "", "Archive uncommitted changes to a tarball before removal (optionally in <dir>)")
+	cleanCmd.Flags().Lookup("archive").NoOptDefVal = archiveToConfiguredDir
+	cleanCmd.RegisterFlagCompletionFunc("target", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		branches, err := completeBranches(cmd)
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+		return branches, cobra.ShellCompDirectiveNoFileComp
+	})
+	a.notifyOnFinish(cleanCmd)
+	return cleanCmd
+}
@@ -0,0 +1,342 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/708u/twig/core"
+	"github.com/708u/twig/format"
+	"github.com/spf13/cobra"
+)
+
+// newConfigCmd creates the twig config command.
+func (a *app) newConfigCmd() *cobra.Command {
+	configCmd := &cobra.Command{
+		Use:   "config",
+		Short: "Inspect and edit twig configuration",
+		Args:  cobra.NoArgs,
+	}
+	configCmd.AddCommand(
+		a.newConfigProfilesCmd(),
+		a.newConfigCheckCmd(),
+		a.newConfigMigrateCmd(),
+		a.newConfigSetCmd(),
+		a.newConfigGetCmd(),
+		a.newConfigSchemaCmd(),
+		a.newConfigEffectiveCmd(),
+		a.newConfigDiffCmd(),
+	)
+	return configCmd
+}
+
+// completeConfigKeys completes the key argument of twig config get and set.
+func completeConfigKeys(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return core.ConfigKeyNames(), cobra.ShellCompDirectiveNoFileComp
+}
+
+// newConfigProfilesCmd creates the twig config profiles command.
+func (a *app) newConfigProfilesCmd() *cobra.Command {
+	configProfilesCmd := &cobra.Command{
+		Use:   "profiles",
+		Short: "List profiles defined in config",
+		Long: `List profiles defined under [profiles.<name>] in .twig/settings.toml
+and .twig/settings.local.toml.
+
+The profile selected with --profile is marked with "*".
+Use -v to show which settings each profile overrides.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			verbosity, _ := cmd.Flags().GetCount("verbose")
+
+			formatted := core.ListProfiles(a.cfg).Format(core.FormatOptions{Verbose: verbosity >= 1})
+			fmt.Fprint(cmd.OutOrStdout(), formatted.Stdout)
+			return nil
+		},
+	}
+	return configProfilesCmd
+}
+
+// newConfigCheckCmd creates the twig config check command.
+func (a *app) newConfigCheckCmd() *cobra.Command {
+	configCheckCmd := &cobra.Command{
+		Use:   "check",
+		Short: "Validate config files",
+		Long: `Validate .twig/settings.toml and .twig/settings.local.toml.
+
+Reports:
+  - syntax and type errors
+  - unknown keys (including top-level settings placed under [profiles.*])
+  - local settings that replace different project settings
+  - a worktree_destination_base_dir that is not a directory
+  - a default_source branch that is not checked out in any worktree
+  - symlink patterns that are invalid or match no files in the source worktree
+
+Runs even when the config cannot be loaded.
+Exits with status 1 if any error is found; warnings do not fail.`,
+		Args:        cobra.NoArgs,
+		Annotations: map[string]string{annotationLoadsConfig: ""},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			verbosity, _ := cmd.Flags().GetCount("verbose")
+
+			idGen := core.GenerateCommandID
+			if a.commandIDGenerator != nil {
+				idGen = a.commandIDGenerator
+			}
+			log := createLogger(cmd.ErrOrStderr(), verbosity, a.logFormat, idGen)
+
+			root, loadOpts := configLoadOptions(cmd.Context(), a.cwd, a.profileFlag)
+			result, err := core.NewDefaultConfigCheckCommand(root, log, a.gitOptions()...).Run(cmd.Context(), root, loadOpts...)
+			if err != nil {
+				return err
+			}
+
+			formatted := result.Format(core.FormatOptions{Verbose: verbosity >= 1})
+			fmt.Fprint(cmd.OutOrStdout(), formatted.Stdout)
+			if n := result.ErrorCount(); n > 0 {
+				return fmt.Errorf("found %d error(s)", n)
+			}
+			return nil
+		},
+	}
+	return configCheckCmd
+}
+
+// newConfigMigrateCmd creates the twig config migrate command.
+func (a *app) newConfigMigrateCmd() *cobra.Command {
+	configMigrateCmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Rewrite renamed settings to their current names",
+		Long: `Rewrite settings that were renamed in .twig/settings.toml and
+.twig/settings.local.toml to their current names.
+
+Old names keep working with a warning until they are removed.
+Only the keys are rewritten; comments, layout and values are kept.
+Use --check to list the renames without writing the files.`,
+		Args:        cobra.NoArgs,
+		Annotations: map[string]string{annotationLoadsConfig: ""},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			verbosity, _ := cmd.Flags().GetCount("verbose")
+			check, _ := cmd.Flags().GetBool("check")
+
+			idGen := core.GenerateCommandID
+			if a.commandIDGenerator != nil {
+				idGen = a.commandIDGenerator
+			}
+			log := createLogger(cmd.ErrOrStderr(), verbosity, a.logFormat, idGen)
+
+			root, _ := repositoryRoot(cmd.Context(), a.cwd)
+			result, err := core.NewDefaultConfigMigrateCommand(log, a.gitOptions()...).Run(
+				cmd.Context(), root, core.ConfigMigrateOptions{Check: check})
+			if err != nil {
+				return err
+			}
+
+			formatted := result.Format(core.FormatOptions{Verbose: verbosity >= 1})
+			fmt.Fprint(cmd.OutOrStdout(), formatted.Stdout)
+			return nil
+		},
+	}
+	configMigrateCmd.Flags().Bool("check", false, "Show what would be renamed without writing")
+	return configMigrateCmd
+}
+
+// newConfigSetCmd creates the twig config set command.
+func (a *app) newConfigSetCmd() *cobra.Command {
+	configSetCmd := &cobra.Command{
+		Use:   "set <key> <value>...",
+		Short: "Set a value in the config file",
+		Long: `Set a setting in .twig/settings.toml, or .twig/settings.local.toml
+with --local. The file is created when missing.
+
+Only the assignment is rewritten; comments and layout are kept.
+Settings in tables are written as dotted keys (e.g. gc.max_age).
+Lists take one or more values; other settings take exactly one.
+Values of settings with fixed choices (e.g. symlink_style) and durations
+(e.g. git_timeout) are checked before the file is written.
+
+Examples:
+  twig config set default_source develop
+  twig config set symlinks .envrc .tool-versions
+  twig config set --local gc.max_age 30d`,
+		Args:              cobra.MinimumNArgs(1),
+		ValidArgsFunction: completeConfigKeys,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			verbosity, _ := cmd.Flags().GetCount("verbose")
+			local, _ := cmd.Flags().GetBool("local")
+
+			idGen := core.GenerateCommandID
+			if a.commandIDGenerator != nil {
+				idGen = a.commandIDGenerator
+			}
+			log := createLogger(cmd.ErrOrStderr(), verbosity, a.logFormat, idGen)
+
+			root, _ := repositoryRoot(cmd.Context(), a.cwd)
+			result, err := core.NewDefaultConfigSetCommand(log, a.gitOptions()...).Run(
+				cmd.Context(), root, args[0], args[1:], core.ConfigSetOptions{Local: local})
+			if err != nil {
+				return err
+			}
+
+			formatted := result.Format(core.FormatOptions{Verbose: verbosity >= 1})
+			fmt.Fprint(cmd.OutOrStdout(), formatted.Stdout)
+			return nil
+		},
+	}
+	configSetCmd.Flags().Bool("local", false, "Write .twig/settings.local.toml")
+	return configSetCmd
+}
+
+// newConfigGetCmd creates the twig config get command.
+func (a *app) newConfigGetCmd() *cobra.Command {
+	configGetCmd := &cobra.Command{
+		Use:   "get <key>",
+		Short: "Print the effective value of a setting",
+		Long: `Print the effective value of a setting after merging both config files,
+TWIG_* environment variables, and the profile selected with --profile.
+Unset settings print their default.
+
+Strings are printed without quotes, lists one item per line, and
+tables as "key = value" lines, for use in scripts.
+With --local, only .twig/settings.local.toml is read, and an unset
+setting is an error.`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeConfigKeys,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			local, _ := cmd.Flags().GetBool("local")
+
+			root, loadOpts := configLoadOptions(cmd.Context(), a.cwd, a.profileFlag)
+			result, err := core.GetConfig(root, args[0], core.ConfigGetOptions{Local: local}, loadOpts...)
+			if err != nil {
+				return err
+			}
+			fmt.Fprint(cmd.OutOrStdout(), result.Format(core.FormatOptions{}).Stdout)
+			return nil
+		},
+	}
+	configGetCmd.Flags().Bool("local", false, "Read only .twig/settings.local.toml")
+	return configGetCmd
+}
+
+// newConfigSchemaCmd creates the twig config schema command.
+func (a *app) newConfigSchemaCmd() *cobra.Command {
+	configSchemaCmd := &cobra.Command{
+		Use:   "schema",
+		Short: "Print the JSON Schema of the config files",
+		Long: `Print a JSON Schema describing .twig/settings.toml and
+.twig/settings.local.toml, for editors to validate and complete settings.
+
+With a TOML language server such as Taplo (Even Better TOML), reference
+the published schema at the top of the file:
+
+  #:schema ` + core.ConfigSchemaID + `
+
+Works outside a git repository.`,
+		Args:        cobra.NoArgs,
+		Annotations: map[string]string{annotationLoadsConfig: ""},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			schema, err := core.ConfigSchema()
+			if err != nil {
+				return err
+			}
+			_, err = cmd.OutOrStdout().Write(schema)
+			return err
+		},
+	}
+	return configSchemaCmd
+}
+
+// newConfigEffectiveCmd creates the twig config effective command.
+func (a *app) newConfigEffectiveCmd() *cobra.Command {
+	configEffectiveCmd := &cobra.Command{
+		Use:     "effective",
+		Aliases: []string{"show"},
+		Short:   "Show the effective config and where each value comes from",
+		Long: `Show the effective configuration after merging .twig/settings.toml,
+.twig/settings.local.toml, and the profile selected with --profile.
+
+Each setting is printed as TOML followed by a comment naming the file or
+profile that set it, or "default" when it is not set anywhere.
+Flags of individual commands (e.g. twig add --source) are applied on top
+when those commands run and are not shown.
+
+Use --json for machine-readable output:
+
+  twig config effective --json | jq .settings.worktree_destination_base_dir`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			asJSON, _ := cmd.Flags().GetBool("json")
+
+			root, loadOpts := configLoadOptions(cmd.Context(), a.cwd, a.profileFlag)
+			result, err := core.EffectiveConfig(root, loadOpts...)
+			if err != nil {
+				return fmt.Errorf("failed to load config: %w", err)
+			}
+			if asJSON {
+				data, err := json.MarshalIndent(result, "", "  ")
+				if err != nil {
+					return err
+				}
+				fmt.Fprintln(cmd.OutOrStdout(), string(data))
+				return nil
+			}
+			fmt.Fprint(cmd.OutOrStdout(), result.Format(core.FormatOptions{}).Stdout)
+			return nil
+		},
+	}
+	configEffectiveCmd.Flags().Bool("json", false, "Print as JSON")
+	return configEffectiveCmd
+}
+
+// newConfigDiffCmd creates the twig config diff command.
+func (a *app) newConfigDiffCmd() *cobra.Command {
+	configDiffCmd := &cobra.Command{
+		Use:   "diff <branch-a> <branch-b>",
+		Short: "Compare the effective config of two worktrees",
+		Long: `Compare the effective configuration loaded from the worktrees of two
+branches.
+
+Branches can carry different committed .twig/settings.toml files, so a
+command like twig sync may behave differently depending on the chosen
+source. Only settings whose values differ are printed, as a unified diff
+of TOML assignments followed by the file or profile that set them.
+
+Environment variables and the profile selected with --profile apply to
+both sides. Use --json for machine-readable output.`,
+		Args: cobra.ExactArgs(2),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) >= 2 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			branches, err := completeWorktreeBranches(cmd)
+			if err != nil {
+				return nil, cobra.ShellCompDirectiveError
+			}
+			return branches, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			asJSON, _ := cmd.Flags().GetBool("json")
+
+			git := core.NewGitRunner(a.cwd, a.gitOptions()...)
+			_, loadOpts := configLoadOptions(cmd.Context(), a.cwd, a.profileFlag)
+			result, err := core.DiffConfig(cmd.Context(), git, args[0], args[1], loadOpts...)
+			if err != nil {
+				return err
+			}
+			if asJSON {
+				data, err := json.MarshalIndent(result, "", "  ")
+				if err != nil {
+					return err
+				}
+				fmt.Fprintln(cmd.OutOrStdout(), string(data))
+				return nil
+			}
+			fmt.Fprint(cmd.OutOrStdout(), result.Format(core.FormatOptions{ColorEnabled: format.IsColorEnabled()}).Stdout)
+			return nil
+		},
+	}
+	configDiffCmd.Flags().Bool("json", false, "Print as JSON")
+	return configDiffCmd
+}
@@ -0,0 +1,60 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/708u/twig/core"
+	"github.com/spf13/cobra"
+)
+
+// newDoctorCmd creates the twig doctor command.
+func (a *app) newDoctorCmd() *cobra.Command {
+	doctorCmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Check for leftovers from interrupted twig operations",
+		Long: `Check the repository for state left behind by interrupted or failed
+twig operations and print how to resolve each problem.
+
+Checks:
+  stash    Stashes left by add --sync/--carry in earlier versions
+  scratch  Scratch dirs left behind by crashed twig processes
+
+Use --prune to remove leftover scratch dirs. Stashes are never removed
+automatically because they hold uncommitted changes.
+
+Exits with status 1 if any problem is found.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			verbosity, _ := cmd.Flags().GetCount("verbose")
+			prune, _ := cmd.Flags().GetBool("prune")
+
+			idGen := core.GenerateCommandID
+			if a.commandIDGenerator != nil {
+				idGen = a.commandIDGenerator
+			}
+			log := createLogger(cmd.ErrOrStderr(), verbosity, a.logFormat, idGen)
+
+			var doctorCmd DoctorCommander
+			if a.doctorCommander != nil {
+				doctorCmd = a.doctorCommander
+			} else {
+				doctorCmd = core.NewDefaultDoctorCommand(a.cwd, log, a.gitOptions()...)
+			}
+			result, err := doctorCmd.Run(cmd.Context(), core.DoctorOptions{Prune: prune})
+			if err != nil {
+				return err
+			}
+
+			formatted := result.Format(core.FormatOptions{Verbose: verbosity >= 1})
+			fmt.Fprint(cmd.ErrOrStderr(), formatted.Stderr)
+			fmt.Fprint(cmd.OutOrStdout(), formatted.Stdout)
+
+			if n := result.ProblemCount(); n > 0 {
+				return fmt.Errorf("found %d problem(s)", n)
+			}
+			return nil
+		},
+	}
+	doctorCmd.Flags().Bool("prune", false, "Remove leftover scratch dirs")
+	return doctorCmd
+}
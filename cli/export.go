@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/708u/twig/core"
+	"github.com/spf13/cobra"
+)
+
+// newExportCmd creates the twig export command.
+func (a *app) newExportCmd() *cobra.Command {
+	exportCmd := &cobra.Command{
+		Use:   "export",
+		Short: "Print the worktrees as JSON for twig import",
+		Long: `Print the linked worktrees of the repository as JSON, for recreating
+them with twig import on another machine:
+
+  twig export > state.json
+
+Each worktree is recorded with its branch (or commit, when detached), its
+path relative to worktree_destination_base_dir, its lock reason and its
+note. The main worktree and worktrees whose directory is gone are left
+out. Uncommitted changes are not exported.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			verbosity, _ := cmd.Flags().GetCount("verbose")
+
+			idGen := core.GenerateCommandID
+			if a.commandIDGenerator != nil {
+				idGen = a.commandIDGenerator
+			}
+			log := createLogger(cmd.ErrOrStderr(), verbosity, a.logFormat, idGen)
+
+			var exportCmdRunner ExportCommander
+			if a.exportCommander != nil {
+				exportCmdRunner = a.exportCommander
+			} else {
+				exportCmdRunner = core.NewDefaultExportCommand(a.cfg, log, a.gitOptions()...)
+			}
+			state, err := exportCmdRunner.Run(cmd.Context())
+			if err != nil {
+				return err
+			}
+
+			data, err := json.MarshalIndent(state, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), string(data))
+			return nil
+		},
+	}
+	return exportCmd
+}
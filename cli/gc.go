@@ -0,0 +1,134 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+
+	"github.com/708u/twig/core"
+	"github.com/708u/twig/format"
+	"github.com/spf13/cobra"
+)
+
+// newGCCmd creates the twig gc command.
+func (a *app) newGCCmd() *cobra.Command {
+	gcCmd := &cobra.Command{
+		Use:   "gc",
+		Short: "Remove worktrees over the [gc] policy",
+		Long: `Remove worktrees over the worktree policy set under [gc]:
+
+  [gc]
+  max_worktrees = 20   # keep at most 20 worktrees besides the main one
+  max_age = "45d"      # collect worktrees created more than 45 days ago
+
+The oldest worktrees beyond max_worktrees and those older than max_age
+are selected, then go through the same safety checks as twig clean:
+only merged worktrees (detached ones included) without uncommitted
+changes are removed. Worktrees that fail a check are kept and reported.
+
+By default, shows the selected worktrees and prompts for confirmation.
+Use --yes to skip confirmation and remove immediately.
+Use --check to only show the selection without prompting.
+
+With check_on_add = true under [gc], twig add prints a hint when the
+worktrees are over the policy.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			verbosity, _ := cmd.Flags().GetCount("verbose")
+			verbose := verbosity >= 1
+			yes, _ := cmd.Flags().GetBool("yes")
+			check, _ := cmd.Flags().GetBool("check")
+			quiet, _ := cmd.Flags().GetBool("quiet")
+			keepEmptyDirs := !a.cfg.ShouldCleanupEmptyDirs()
+
+			idGen := core.GenerateCommandID
+			if a.commandIDGenerator != nil {
+				idGen = a.commandIDGenerator
+			}
+			log := createLogger(cmd.ErrOrStderr(), verbosity, a.logFormat, idGen)
+
+			var gcCmd GCCommander
+			if a.gcCommander != nil {
+				gcCmd = a.gcCommander
+			} else {
+				gcCmd = core.NewDefaultGCCommand(a.cfg, log, a.gitOptions()...)
+			}
+
+			formatOpts := core.GCFormatOptions{
+				Verbose:      verbose,
+				Quiet:        quiet,
+				ColorEnabled: format.IsColorEnabled(),
+			}
+
+			// First pass: select worktrees and run the clean checks on them
+			result, err := gcCmd.Run(cmd.Context(), a.cwd, core.GCOptions{
+				Check:   true,
+				Verbose: verbose,
+			})
+			if err != nil {
+				return err
+			}
+
+			// As with clean, the selection and the prompt go to stderr with
+			// --quiet unless nothing will be removed
+			final := check || result.CleanableCount() == 0
+			promptOut := cmd.OutOrStdout()
+			firstOpts := formatOpts
+			if quiet && !final {
+				promptOut = cmd.ErrOrStderr()
+				firstOpts.Quiet = false
+			}
+			if final || !quiet || !yes {
+				formatted := result.Format(firstOpts)
+				if formatted.Stderr != "" {
+					fmt.Fprint(cmd.ErrOrStderr(), formatted.Stderr)
+				}
+				fmt.Fprint(promptOut, formatted.Stdout)
+			}
+			if final {
+				return nil
+			}
+
+			if !yes {
+				fmt.Fprint(promptOut, "\nProceed? [y/N]: ")
+				reader := bufio.NewReader(cmd.InOrStdin())
+				input, err := reader.ReadString('\n')
+				if err != nil {
+					return err
+				}
+				input = strings.TrimSpace(strings.ToLower(input))
+				if input != "y" && input != "yes" {
+					return nil
+				}
+			}
+
+			if a.gcCommander == nil {
+				release, err := a.lockRepository(cmd, a.cwd, log)
+				if err != nil {
+					return err
+				}
+				defer release()
+			}
+
+			// Second pass: execute removal
+			result, err = gcCmd.Run(cmd.Context(), a.cwd, core.GCOptions{
+				Verbose:       verbose,
+				KeepEmptyDirs: keepEmptyDirs,
+			})
+			if err != nil {
+				return err
+			}
+
+			formatted := result.Format(formatOpts)
+			if formatted.Stderr != "" {
+				fmt.Fprint(cmd.ErrOrStderr(), formatted.Stderr)
+			}
+			fmt.Fprint(cmd.OutOrStdout(), formatted.Stdout)
+			return nil
+		},
+	}
+
+	gcCmd.Flags().BoolP("yes", "y", false, "Execute removal without confirmation")
+	gcCmd.Flags().Bool("check", false, "Show the selected worktrees without prompting or removing")
+	return gcCmd
+}
@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/708u/twig/core"
+	"github.com/708u/twig/format"
+	"github.com/spf13/cobra"
+)
+
+// newGrepCmd creates the twig grep command.
+func (a *app) newGrepCmd() *cobra.Command {
+	grepCmd := &cobra.Command{
+		Use:   "grep <pattern> [-- <pathspec>...]",
+		Short: "Search tracked files in every worktree",
+		Long: `Search the tracked files of every worktree with git grep.
+
+Worktrees are searched in parallel. Each match is prefixed with the
+branch of its worktree (the short commit for a detached worktree):
+
+  feat/a:app.go:3:const Version = 2
+
+Working tree contents are searched, so uncommitted changes are found too.
+Paths after -- limit the search, as with git grep.
+
+--dirty and --branch-glob select worktrees like twig list does:
+
+  twig grep --dirty TODO
+  twig grep --branch-glob 'feat/*' -l NewClient -- '*.go'
+
+A worktree that cannot be searched is reported as a warning; twig grep
+fails only when none can be. Finding no match is not an error.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			verbosity, _ := cmd.Flags().GetCount("verbose")
+			ignoreCase, _ := cmd.Flags().GetBool("ignore-case")
+			fixedStrings, _ := cmd.Flags().GetBool("fixed-strings")
+			wordRegexp, _ := cmd.Flags().GetBool("word-regexp")
+			filesOnly, _ := cmd.Flags().GetBool("files-with-matches")
+			var filter core.ListFilter
+			filter.Dirty, _ = cmd.Flags().GetBool("dirty")
+			filter.BranchGlob, _ = cmd.Flags().GetString("branch-glob")
+
+			idGen := core.GenerateCommandID
+			if a.commandIDGenerator != nil {
+				idGen = a.commandIDGenerator
+			}
+			log := createLogger(cmd.ErrOrStderr(), verbosity, a.logFormat, idGen)
+
+			var grepCmdRunner GrepCommander
+			if a.grepCommander != nil {
+				grepCmdRunner = a.grepCommander
+			} else {
+				grepCmdRunner = core.NewDefaultGrepCommand(a.cwd, log, a.gitOptions()...)
+			}
+			result, err := grepCmdRunner.Run(cmd.Context(), core.GrepOptions{
+				Pattern:      args[0],
+				Pathspecs:    args[1:],
+				IgnoreCase:   ignoreCase,
+				FixedStrings: fixedStrings,
+				WordRegexp:   wordRegexp,
+				FilesOnly:    filesOnly,
+				Filter:       filter,
+			})
+			if err != nil {
+				return err
+			}
+
+			formatted := result.Format(core.GrepFormatOptions{ColorEnabled: format.IsColorEnabled()})
+			if formatted.Stderr != "" {
+				fmt.Fprint(cmd.ErrOrStderr(), formatted.Stderr)
+			}
+			fmt.Fprint(cmd.OutOrStdout(), formatted.Stdout)
+			return nil
+		},
+	}
+	grepCmd.Flags().BoolP("ignore-case", "i", false, "Ignore case differences")
+	grepCmd.Flags().BoolP("fixed-strings", "F", false, "Match the pattern as a fixed string, not a regexp")
+	grepCmd.Flags().BoolP("word-regexp", "w", false, "Match the pattern only at word boundaries")
+	grepCmd.Flags().BoolP("files-with-matches", "l", false, "Show only the names of matching files")
+	grepCmd.Flags().Bool("dirty", false, "Only search worktrees with uncommitted changes")
+	grepCmd.Flags().String("branch-glob", "", "Only search branches matching a pattern (e.g. 'feat/*')")
+	return grepCmd
+}
@@ -0,0 +1,114 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/708u/twig/core"
+	"github.com/spf13/cobra"
+)
+
+// newHookCmd creates the twig hook command.
+func (a *app) newHookCmd() *cobra.Command {
+	hookCmd := &cobra.Command{
+		Use:   "hook",
+		Short: "Manage git hooks that keep worktrees in sync",
+		Args:  cobra.NoArgs,
+	}
+	hookCmd.AddCommand(
+		a.newHookInstallCmd(),
+		a.newHookUninstallCmd(),
+	)
+	return hookCmd
+}
+
+// runGitHook runs the install or uninstall operation of twig hook and
+// prints its result.
+func (a *app) runGitHook(cmd *cobra.Command, hook string, opts core.GitHookOptions) error {
+	verbosity, _ := cmd.Flags().GetCount("verbose")
+
+	idGen := core.GenerateCommandID
+	if a.commandIDGenerator != nil {
+		idGen = a.commandIDGenerator
+	}
+	log := createLogger(cmd.ErrOrStderr(), verbosity, a.logFormat, idGen)
+
+	var hookCmdRunner GitHookCommander
+	if a.gitHookCommander != nil {
+		hookCmdRunner = a.gitHookCommander
+	} else {
+		hookCmdRunner = core.NewDefaultGitHookCommand(a.cfg, log, a.gitOptions()...)
+	}
+	result, err := hookCmdRunner.Run(cmd.Context(), hook, opts)
+	if err != nil {
+		return err
+	}
+
+	formatted := result.Format(core.FormatOptions{Verbose: verbosity >= 1})
+	fmt.Fprint(cmd.OutOrStdout(), formatted.Stdout)
+	return nil
+}
+
+// newHookInstallCmd creates the twig hook install command.
+func (a *app) newHookInstallCmd() *cobra.Command {
+	hookInstallCmd := &cobra.Command{
+		Use:   "install <hook>",
+		Short: "Install a git hook that runs twig sync",
+		Long: `Install a git hook that runs twig sync automatically.
+
+post-merge runs twig sync --all --quiet after each git merge or git pull
+in the source worktree, so changes to config and symlinked files reach
+all worktrees. The source worktree is the worktree of --source, of
+default_source, or the main worktree.
+
+Git shares hooks between worktrees; the hook does nothing in other
+worktrees. It also does nothing when twig is not on PATH, or when
+TWIG_NO_SYNC_HOOK is set. Syncs are limited to one per --interval, and a
+failed sync never fails the pull.
+
+An existing hook that was not installed by twig is left alone unless
+--force is given. Reinstalling replaces a hook installed by twig.`,
+		Example: `  twig hook install post-merge
+  twig hook install post-merge --interval 5m`,
+		Args:      cobra.ExactArgs(1),
+		ValidArgs: core.SupportedGitHooks,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			source, _ := cmd.Flags().GetString("source")
+			interval, _ := cmd.Flags().GetDuration("interval")
+			force, _ := cmd.Flags().GetBool("force")
+
+			if interval <= 0 {
+				return fmt.Errorf("invalid --interval %s: must be positive", interval)
+			}
+			return a.runGitHook(cmd, args[0], core.GitHookOptions{
+				Source:   source,
+				Interval: interval,
+				Force:    force,
+			})
+		},
+	}
+	hookInstallCmd.Flags().String("source", "", "Branch whose worktree runs the hook (default: default_source config, then main)")
+	hookInstallCmd.Flags().Duration("interval", core.DefaultGitHookInterval, "Minimum time between syncs")
+	hookInstallCmd.Flags().Bool("force", false, "Overwrite a hook that was not installed by twig")
+	hookInstallCmd.RegisterFlagCompletionFunc("source", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		branches, err := completeWorktreeBranches(cmd)
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+		return branches, cobra.ShellCompDirectiveNoFileComp
+	})
+	return hookInstallCmd
+}
+
+// newHookUninstallCmd creates the twig hook uninstall command.
+func (a *app) newHookUninstallCmd() *cobra.Command {
+	hookUninstallCmd := &cobra.Command{
+		Use:       "uninstall <hook>",
+		Short:     "Remove a git hook installed by twig",
+		Args:      cobra.ExactArgs(1),
+		ValidArgs: core.SupportedGitHooks,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return a.runGitHook(cmd, args[0], core.GitHookOptions{Uninstall: true})
+		},
+	}
+	return hookUninstallCmd
+}
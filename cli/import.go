@@ -0,0 +1,92 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/708u/twig/core"
+	"github.com/708u/twig/format"
+	"github.com/spf13/cobra"
+)
+
+// newImportCmd creates the twig import command.
+func (a *app) newImportCmd() *cobra.Command {
+	importCmd := &cobra.Command{
+		Use:   "import <file>",
+		Short: "Recreate the worktrees written by twig export",
+		Long: `Recreate the worktrees of a twig export file ("-" reads stdin):
+
+  twig import state.json
+
+Worktrees are created at the same paths relative to
+worktree_destination_base_dir, with their locks and notes, like twig add
+does: symlinks, submodules and hooks follow the configuration. Branches
+missing locally are fetched from the remotes; a branch found nowhere is
+recreated at its exported commit when that commit exists, and skipped
+otherwise. Worktrees that already exist are skipped.
+
+Use --check to show what would be created without fetching or creating
+anything.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			verbosity, _ := cmd.Flags().GetCount("verbose")
+			check, _ := cmd.Flags().GetBool("check")
+
+			var data []byte
+			var err error
+			if args[0] == "-" {
+				data, err = io.ReadAll(cmd.InOrStdin())
+			} else {
+				data, err = os.ReadFile(args[0])
+			}
+			if err != nil {
+				return fmt.Errorf("failed to read export: %w", err)
+			}
+			state, err := core.ParseState(data)
+			if err != nil {
+				return err
+			}
+
+			idGen := core.GenerateCommandID
+			if a.commandIDGenerator != nil {
+				idGen = a.commandIDGenerator
+			}
+			log := createLogger(cmd.ErrOrStderr(), verbosity, a.logFormat, idGen)
+
+			var importCmdRunner ImportCommander
+			if a.importCommander != nil {
+				importCmdRunner = a.importCommander
+			} else {
+				defaultImport := core.NewDefaultImportCommand(a.cfg, log, a.gitOptions()...)
+				defaultImport.Provenance = provenance(cmd)
+				importCmdRunner = defaultImport
+				if !check {
+					release, err := a.lockRepository(cmd, a.cwd, log)
+					if err != nil {
+						return err
+					}
+					defer release()
+				}
+			}
+			result, err := importCmdRunner.Run(cmd.Context(), state, core.ImportOptions{Check: check})
+			if err != nil {
+				return err
+			}
+
+			formatted := result.Format(core.FormatOptions{Verbose: verbosity >= 1, ColorEnabled: format.IsColorEnabled()})
+			if formatted.Stderr != "" {
+				fmt.Fprint(cmd.ErrOrStderr(), formatted.Stderr)
+			}
+			fmt.Fprint(cmd.OutOrStdout(), formatted.Stdout)
+
+			if result.HasErrors() {
+				return fmt.Errorf("failed to import %d worktree(s)", result.Count(core.ImportFailed))
+			}
+			return nil
+		},
+	}
+	importCmd.Flags().Bool("check", false, "Show what would be created without making changes")
+	a.notifyOnFinish(importCmd)
+	return importCmd
+}
@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/708u/twig/core"
+	"github.com/spf13/cobra"
+)
+
+// newInitCmd creates the twig init command.
+func (a *app) newInitCmd() *cobra.Command {
+	initCmd := &cobra.Command{
+		Use:   "init",
+		Short: "Initialize twig configuration",
+		Long: `Create a .twig/settings.toml configuration file at the root of the current
+worktree, or in the current directory outside a git repository.
+
+With --update-gitignore, also add .twig/settings.local.toml and the files twig
+generates in worktrees (.twig.env, WORKTREE_NOTE) to .gitignore, creating it
+if missing. Entries already in .gitignore are not added again.`,
+		Args: cobra.NoArgs,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			// Override parent's PersistentPreRunE to skip config loading
+			// since init creates the config file
+			a.startTiming()
+
+			if err := checkOutputLevel(cmd); err != nil {
+				return err
+			}
+
+			var err error
+			a.originalCwd, err = os.Getwd()
+			if err != nil {
+				return fmt.Errorf("failed to get current directory: %w", err)
+			}
+
+			a.cwd, err = resolveDirectory(a.dirFlag, a.originalCwd)
+			if err != nil {
+				return err
+			}
+
+			a.logFormat, err = core.ParseLogFormat(a.logFlag)
+			if err != nil {
+				return fmt.Errorf("invalid --log-format: %w", err)
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			verbosity, _ := cmd.Flags().GetCount("verbose")
+			quiet, _ := cmd.Flags().GetBool("quiet")
+			force, _ := cmd.Flags().GetBool("force")
+			updateGitignore, _ := cmd.Flags().GetBool("update-gitignore")
+
+			idGen := core.GenerateCommandID
+			if a.commandIDGenerator != nil {
+				idGen = a.commandIDGenerator
+			}
+			log := createLogger(cmd.ErrOrStderr(), verbosity, a.logFormat, idGen)
+
+			var initCommand InitCommander
+			if a.initCommander != nil {
+				initCommand = a.initCommander
+			} else {
+				initCommand = core.NewDefaultInitCommand(log, a.gitOptions()...)
+			}
+			root, _ := repositoryRoot(cmd.Context(), a.cwd)
+			result, err := initCommand.Run(cmd.Context(), root, core.InitOptions{Force: force, UpdateGitignore: updateGitignore})
+			if err != nil {
+				return err
+			}
+
+			formatted := result.Format(core.InitFormatOptions{Quiet: quiet})
+			fmt.Fprint(cmd.OutOrStdout(), formatted.Stdout)
+			return nil
+		},
+	}
+	initCmd.Flags().BoolP("force", "f", false, "Overwrite existing configuration file")
+	initCmd.Flags().Bool("update-gitignore", false, "Add twig's local files to .gitignore")
+	return initCmd
+}
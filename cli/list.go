@@ -0,0 +1,126 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/708u/twig/core"
+	"github.com/708u/twig/format"
+	"github.com/spf13/cobra"
+)
+
+// newListCmd creates the twig list command.
+func (a *app) newListCmd() *cobra.Command {
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List all worktrees",
+		Long: `List all worktrees.
+
+The main worktree is marked with * and the worktree containing the
+current directory with @. Use --porcelain for the same information as
+explicit fields.
+
+With --long, the note of each branch (see twig note) is shown as well.
+
+Filter flags list only matching worktrees, and can be combined (a
+worktree must match all of them):
+
+  twig list --dirty                  # uncommitted changes
+  twig list --locked                 # locked worktrees
+  twig list --merged                 # merged into the main worktree branch
+  twig list --merged=release/2.4     # merged into another branch
+  twig list --branch-glob 'feat/*'   # branch name pattern
+
+Filters work with every output format, e.g. twig list -q --merged to get
+paths for a script.
+
+With --tree, worktrees under worktree_destination_base_dir are grouped by
+directory, which mirrors the branch namespace (feat/a is at <base>/feat/a).
+Each directory shows how many worktrees it holds and how many are dirty or
+locked, and dirty and locked worktrees are colored. Worktrees elsewhere,
+such as the main worktree, are listed above the tree.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			quiet, _ := cmd.Flags().GetBool("quiet")
+			porcelain, _ := cmd.Flags().GetBool("porcelain")
+			verbosity, _ := cmd.Flags().GetCount("verbose")
+			size, _ := cmd.Flags().GetBool("size")
+			sortKey, _ := cmd.Flags().GetString("sort")
+			refresh, _ := cmd.Flags().GetBool("refresh")
+			long, _ := cmd.Flags().GetBool("long")
+			tree, _ := cmd.Flags().GetBool("tree")
+			filter := core.ListFilter{
+				Merged: cmd.Flags().Changed("merged"),
+			}
+			filter.Dirty, _ = cmd.Flags().GetBool("dirty")
+			filter.Locked, _ = cmd.Flags().GetBool("locked")
+			filter.BranchGlob, _ = cmd.Flags().GetString("branch-glob")
+			if mergedValue, _ := cmd.Flags().GetString("merged"); filter.Merged && mergedValue != mergedIntoMain {
+				if mergedValue == "" {
+					return fmt.Errorf("--merged value cannot be empty")
+				}
+				filter.MergedInto = mergedValue
+			}
+
+			if quiet && porcelain {
+				return fmt.Errorf("cannot use --quiet and --porcelain together")
+			}
+			if tree && (quiet || porcelain) {
+				return fmt.Errorf("cannot use --tree with --quiet or --porcelain")
+			}
+
+			idGen := core.GenerateCommandID
+			if a.commandIDGenerator != nil {
+				idGen = a.commandIDGenerator
+			}
+			log := createLogger(cmd.ErrOrStderr(), verbosity, a.logFormat, idGen)
+
+			var listCmd ListCommander
+			if a.listCommander != nil {
+				listCmd = a.listCommander
+			} else {
+				listCmd = core.NewDefaultListCommand(a.cwd, log, a.gitOptions()...)
+			}
+			result, err := listCmd.Run(cmd.Context(), core.ListOptions{
+				Size:       size,
+				Refresh:    refresh,
+				Sort:       core.ListSortKey(sortKey),
+				Notes:      long,
+				Filter:     filter,
+				CheckDirty: tree,
+			})
+			if err != nil {
+				return err
+			}
+
+			var treeRoot string
+			if a.cfg != nil {
+				treeRoot = a.cfg.WorktreeDestBaseDir
+			}
+			formatted := result.Format(core.ListFormatOptions{
+				Quiet:        quiet,
+				Porcelain:    porcelain,
+				ColorEnabled: format.IsColorEnabled(),
+				Tree:         tree,
+				TreeRoot:     treeRoot,
+			})
+			fmt.Fprint(cmd.OutOrStdout(), formatted.Stdout)
+			return nil
+		},
+	}
+
+	listCmd.Flags().Bool("porcelain", false, "Output machine-readable records, including main and current fields")
+	listCmd.Flags().Bool("size", false, "Show disk usage of each worktree and the total")
+	listCmd.Flags().String("sort", "", "Sort worktrees by key (path, size)")
+	listCmd.Flags().Bool("refresh", false, "Recalculate disk usage instead of using cached sizes")
+	listCmd.Flags().BoolP("long", "l", false, "Show the provenance, note and description of each branch")
+	listCmd.Flags().Bool("dirty", false, "Only list worktrees with uncommitted changes")
+	listCmd.Flags().Bool("locked", false, "Only list locked worktrees")
+	listCmd.Flags().String("merged", "", "Only list branches merged into a branch (default: main worktree branch)")
+	listCmd.Flags().Lookup("merged").NoOptDefVal = mergedIntoMain
+	listCmd.Flags().String("branch-glob", "", "Only list branches matching a pattern (e.g. 'feat/*')")
+	listCmd.Flags().Bool("tree", false, "Group worktrees by directory under worktree_destination_base_dir")
+	listCmd.RegisterFlagCompletionFunc("sort", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{string(core.ListSortPath), string(core.ListSortSize)}, cobra.ShellCompDirectiveNoFileComp
+	})
+	return listCmd
+}
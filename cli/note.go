@@ -0,0 +1,102 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/708u/twig/core"
+	"github.com/spf13/cobra"
+)
+
+// newNoteCmd creates the twig note command.
+func (a *app) newNoteCmd() *cobra.Command {
+	noteCmd := &cobra.Command{
+		Use:   "note [<branch>] [<text>]",
+		Short: "Show or set a note on a branch",
+		Long: `Attach a free-form note to a branch.
+
+With a branch and text, the note of the branch is replaced. With only a
+branch, its note is shown. Without arguments, all notes are listed.
+Use --clear to remove a note.
+
+Notes are stored in the git common directory, so they are shared by all
+worktrees and never committed. They are shown by twig list --long and
+twig clean, and follow the branch on twig rename. Notes of deleted
+branches are dropped whenever notes are written.
+
+With --file, the note is also written to WORKTREE_NOTE in the worktree
+of the branch, so it is visible to anyone working there. Clearing a note
+removes that file as well.
+
+Names are resolved like twig add (branch_aliases, branch_prefix).`,
+		Example: `  twig note feat/a "waiting on review"
+  twig note --file bench "do not touch, long-running benchmark"
+  twig note feat/a
+  twig note
+  twig note --clear feat/a`,
+		Args: cobra.MaximumNArgs(2),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) > 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			branches, err := completeBranches(cmd)
+			if err != nil {
+				return nil, cobra.ShellCompDirectiveError
+			}
+			return branches, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			verbosity, _ := cmd.Flags().GetCount("verbose")
+			clearNote, _ := cmd.Flags().GetBool("clear")
+			file, _ := cmd.Flags().GetBool("file")
+
+			var branch, text string
+			if len(args) > 0 {
+				branch = args[0]
+			}
+			if len(args) > 1 {
+				text = args[1]
+				if strings.TrimSpace(text) == "" {
+					return fmt.Errorf("note text is empty (use --clear to remove a note)")
+				}
+			}
+			if clearNote && text != "" {
+				return fmt.Errorf("cannot use --clear and note text together")
+			}
+			if file && text == "" {
+				return fmt.Errorf("--file requires note text")
+			}
+
+			idGen := core.GenerateCommandID
+			if a.commandIDGenerator != nil {
+				idGen = a.commandIDGenerator
+			}
+			log := createLogger(cmd.ErrOrStderr(), verbosity, a.logFormat, idGen)
+
+			var noteCmdRunner NoteCommander
+			if a.noteCommander != nil {
+				noteCmdRunner = a.noteCommander
+			} else {
+				noteCmdRunner = core.NewDefaultNoteCommand(a.cfg, log, a.gitOptions()...)
+				if clearNote || text != "" {
+					release, err := a.lockRepository(cmd, a.cwd, log)
+					if err != nil {
+						return err
+					}
+					defer release()
+				}
+			}
+			result, err := noteCmdRunner.Run(cmd.Context(), branch, text, core.NoteOptions{Clear: clearNote, File: file})
+			if err != nil {
+				return err
+			}
+
+			formatted := result.Format(core.FormatOptions{Verbose: verbosity >= 1})
+			fmt.Fprint(cmd.OutOrStdout(), formatted.Stdout)
+			return nil
+		},
+	}
+	noteCmd.Flags().Bool("clear", false, "Remove the note of the branch")
+	noteCmd.Flags().Bool("file", false, "Also write the note to WORKTREE_NOTE in the branch's worktree")
+	return noteCmd
+}
@@ -0,0 +1,78 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/708u/twig/core"
+	"github.com/spf13/cobra"
+)
+
+// newOpenCmd creates the twig open command.
+func (a *app) newOpenCmd() *cobra.Command {
+	openCmd := &cobra.Command{
+		Use:   "open <name>",
+		Short: "Open a worktree with the configured editor command",
+		Long: `Open the worktree of a branch with open_command from settings.
+
+{path} in open_command is replaced with the quoted worktree path;
+without it, the path is appended as the last argument:
+
+  open_command = "code {path}"
+
+The name is resolved like twig add (branch_aliases, branch_prefix).
+Use --add to create the worktree first when the branch has none.`,
+		Args: cobra.ExactArgs(1),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) > 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			branches, err := completeWorktreeBranches(cmd)
+			if err != nil {
+				return nil, cobra.ShellCompDirectiveError
+			}
+			return branches, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			verbosity, _ := cmd.Flags().GetCount("verbose")
+			add, _ := cmd.Flags().GetBool("add")
+			noPrefix, _ := cmd.Flags().GetBool("no-prefix")
+
+			idGen := core.GenerateCommandID
+			if a.commandIDGenerator != nil {
+				idGen = a.commandIDGenerator
+			}
+			log := createLogger(cmd.ErrOrStderr(), verbosity, a.logFormat, idGen)
+
+			var openCmd OpenCommander
+			if a.openCommander != nil {
+				openCmd = a.openCommander
+			} else {
+				defaultOpen := core.NewDefaultOpenCommand(a.cfg, log, a.gitOptions()...)
+				defaultOpen.Provenance = provenance(cmd)
+				// Hold the lock only while adding, not while the editor runs
+				defaultOpen.LockAdd = func(ctx context.Context) (func(), error) {
+					return a.lockRepository(cmd, a.cwd, log)
+				}
+				openCmd = defaultOpen
+			}
+			result, err := openCmd.Run(cmd.Context(), args[0], core.OpenOptions{
+				Add:      add,
+				NoPrefix: noPrefix,
+			})
+			if err != nil {
+				return err
+			}
+
+			formatted := result.Format(core.AddFormatOptions{Verbose: verbosity >= 1})
+			if formatted.Stderr != "" {
+				fmt.Fprint(cmd.ErrOrStderr(), formatted.Stderr)
+			}
+			fmt.Fprint(cmd.OutOrStdout(), formatted.Stdout)
+			return nil
+		},
+	}
+	openCmd.Flags().Bool("add", false, "Create the worktree first if the branch has none")
+	openCmd.Flags().Bool("no-prefix", false, "Use the name as the branch name, ignoring branch_prefix and branch_aliases")
+	return openCmd
+}
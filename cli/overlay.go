@@ -0,0 +1,134 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/708u/twig/core"
+	"github.com/spf13/cobra"
+)
+
+// newOverlayCmd creates the twig overlay command.
+func (a *app) newOverlayCmd() *cobra.Command {
+	overlayCmd := &cobra.Command{
+		Use:   "overlay [<source-branch>] [flags]",
+		Short: "Overlay file contents from another branch",
+		Long: `Overlay file contents from a source branch onto a target worktree.
+
+This is useful for testing changes from a feature branch in the context
+of another worktree.
+
+Use --restore to return the target worktree to its original state.
+
+Examples:
+  # Overlay feat/x onto main worktree
+  twig overlay feat/x --target main
+
+  # Overlay onto current worktree
+  twig overlay feat/x
+
+  # Restore original state
+  twig overlay --restore --target main
+
+  # Preview changes
+  twig overlay feat/x --target main --check
+
+  # Include uncommitted changes from source worktree
+  twig overlay feat/x --target main --dirty`,
+		Args: cobra.MaximumNArgs(1),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) >= 1 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			branches, err := completeBranches(cmd)
+			if err != nil {
+				return nil, cobra.ShellCompDirectiveError
+			}
+			return branches, cobra.ShellCompDirectiveNoFileComp
+		},
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			restore, _ := cmd.Flags().GetBool("restore")
+			dirty, _ := cmd.Flags().GetBool("dirty")
+			if restore && len(args) > 0 {
+				return fmt.Errorf("cannot specify source branch with --restore")
+			}
+			if !restore && len(args) == 0 {
+				return fmt.Errorf("source branch is required (or use --restore)")
+			}
+			if dirty && restore {
+				return fmt.Errorf("cannot use --dirty with --restore")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			verbosity, _ := cmd.Flags().GetCount("verbose")
+			verbose := verbosity >= 1
+			quiet, _ := cmd.Flags().GetBool("quiet")
+			check, _ := cmd.Flags().GetBool("check")
+			restore, _ := cmd.Flags().GetBool("restore")
+			force, _ := cmd.Flags().GetBool("force")
+			dirty, _ := cmd.Flags().GetBool("dirty")
+			target, _ := cmd.Flags().GetString("target")
+
+			idGen := core.GenerateCommandID
+			if a.commandIDGenerator != nil {
+				idGen = a.commandIDGenerator
+			}
+			log := createLogger(cmd.ErrOrStderr(), verbosity, a.logFormat, idGen)
+
+			opts := core.OverlayOptions{
+				Restore: restore,
+				Check:   check,
+				Force:   force,
+				Dirty:   dirty,
+				Target:  target,
+			}
+
+			var overlayCmdRunner OverlayCommander
+			if a.overlayCommander != nil {
+				overlayCmdRunner = a.overlayCommander
+			} else {
+				overlayCmdRunner = core.NewDefaultOverlayCommand(a.cwd, log, a.gitOptions()...)
+				if !check {
+					release, err := a.lockRepository(cmd, a.cwd, log)
+					if err != nil {
+						return err
+					}
+					defer release()
+				}
+			}
+
+			var sourceBranch string
+			if len(args) > 0 {
+				sourceBranch = args[0]
+			}
+
+			result, err := overlayCmdRunner.Run(cmd.Context(), sourceBranch, a.cwd, opts)
+			if err != nil {
+				return err
+			}
+
+			formatted := result.Format(core.OverlayFormatOptions{
+				Verbose: verbose,
+				Quiet:   quiet,
+			})
+			if formatted.Stderr != "" {
+				fmt.Fprint(cmd.ErrOrStderr(), formatted.Stderr)
+			}
+			fmt.Fprint(cmd.OutOrStdout(), formatted.Stdout)
+			return nil
+		},
+	}
+	overlayCmd.Flags().Bool("restore", false, "Restore target worktree to original state")
+	overlayCmd.Flags().String("target", "", "Target worktree branch (default: current)")
+	overlayCmd.Flags().Bool("check", false, "Show what would be done (dry-run)")
+	overlayCmd.Flags().BoolP("force", "f", false, "Proceed even if target is dirty or HEAD has moved")
+	overlayCmd.Flags().Bool("dirty", false, "Include uncommitted changes from source worktree")
+	overlayCmd.RegisterFlagCompletionFunc("target", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		branches, err := completeWorktreeBranches(cmd)
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+		return branches, cobra.ShellCompDirectiveNoFileComp
+	})
+	return overlayCmd
+}
@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/708u/twig/core"
+	"github.com/spf13/cobra"
+)
+
+// newRootPathCmd creates the twig root command.
+func (a *app) newRootPathCmd() *cobra.Command {
+	rootPathCmd := &cobra.Command{
+		Use:   "root",
+		Short: "Print the main worktree path",
+		Long: `Print the path of the main worktree, from any worktree of the repository.
+
+With --dest, print worktree_destination_base_dir instead: the directory
+new worktrees are created in, resolved like twig add.
+
+  cd "$(twig root)"`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dest, _ := cmd.Flags().GetBool("dest")
+
+			pathCmd := a.pathCommand(cmd)
+			var result core.PathResult
+			var err error
+			if dest {
+				result, err = pathCmd.Dest()
+			} else {
+				result, err = pathCmd.Root(cmd.Context())
+			}
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprint(cmd.OutOrStdout(), result.Format(core.FormatOptions{}).Stdout)
+			return nil
+		},
+	}
+	rootPathCmd.Flags().Bool("dest", false, "Print the destination base directory for new worktrees")
+	return rootPathCmd
+}
+
+// pathCommand returns the PathCommander for twig root and twig path.
+func (a *app) pathCommand(cmd *cobra.Command) PathCommander {
+	if a.pathCommander != nil {
+		return a.pathCommander
+	}
+	verbosity, _ := cmd.Flags().GetCount("verbose")
+	idGen := core.GenerateCommandID
+	if a.commandIDGenerator != nil {
+		idGen = a.commandIDGenerator
+	}
+	return core.NewDefaultPathCommand(a.cfg, createLogger(cmd.ErrOrStderr(), verbosity, a.logFormat, idGen), a.gitOptions()...)
+}
+
+// newPathCmd creates the twig path command.
+func (a *app) newPathCmd() *cobra.Command {
+	pathCmd := &cobra.Command{
+		Use:   "path <name>",
+		Short: "Print the worktree path of a branch",
+		Long: `Print the worktree path of a branch, and exit with status 1 when the
+branch is not checked out in any worktree.
+
+The name is resolved like twig add (branch_aliases, branch_prefix).
+
+  cd "$(twig path feat/a)"`,
+		Args: cobra.ExactArgs(1),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) > 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			branches, err := completeWorktreeBranches(cmd)
+			if err != nil {
+				return nil, cobra.ShellCompDirectiveError
+			}
+			return branches, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			noPrefix, _ := cmd.Flags().GetBool("no-prefix")
+
+			result, err := a.pathCommand(cmd).Run(cmd.Context(), args[0], core.PathOptions{NoPrefix: noPrefix})
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprint(cmd.OutOrStdout(), result.Format(core.FormatOptions{}).Stdout)
+			return nil
+		},
+	}
+	pathCmd.Flags().Bool("no-prefix", false, "Use the name as the branch name, ignoring branch_prefix and branch_aliases")
+	return pathCmd
+}
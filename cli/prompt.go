@@ -0,0 +1,140 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/708u/twig/core"
+	"github.com/spf13/cobra"
+)
+
+// newPromptInfoCmd creates the twig prompt-info command.
+func (a *app) newPromptInfoCmd() *cobra.Command {
+	promptInfoCmd := &cobra.Command{
+		Use:   "prompt-info",
+		Short: "Print a one-line worktree summary for shell prompts",
+		Long: `Print the current branch, the number of worktrees, and the number of
+worktrees twig clean would remove, e.g. "main [3 wt, 1 cleanable]".
+
+The cleanable count is cached in <git-common-dir>/twig/prompt-cache.json
+and recalculated when any worktree's branch or HEAD changes, or after 5 minutes.
+Prints nothing outside a git repository.
+
+This is the backend used by twig prompt-segment.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			verbosity, _ := cmd.Flags().GetCount("verbose")
+			refresh, _ := cmd.Flags().GetBool("refresh")
+
+			idGen := core.GenerateCommandID
+			if a.commandIDGenerator != nil {
+				idGen = a.commandIDGenerator
+			}
+			log := createLogger(cmd.ErrOrStderr(), verbosity, a.logFormat, idGen)
+
+			var promptCmd PromptInfoCommander
+			if a.promptInfoCommander != nil {
+				promptCmd = a.promptInfoCommander
+			} else {
+				promptCmd = core.NewDefaultPromptInfoCommand(a.cfg, log, a.gitOptions()...)
+			}
+			info, err := promptCmd.Run(cmd.Context(), a.cwd, core.PromptInfoOptions{Refresh: refresh})
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprint(cmd.OutOrStdout(), info.Format(core.PromptFormatOptions{}).Stdout)
+			return nil
+		},
+	}
+	promptInfoCmd.Flags().Bool("refresh", false, "Recalculate the cleanable count instead of using the cache")
+	return promptInfoCmd
+}
+
+// newPromptCmd creates the twig prompt command.
+func (a *app) newPromptCmd() *cobra.Command {
+	promptCmd := &cobra.Command{
+		Use:   "prompt",
+		Short: "Print a compact worktree summary for PS1 or starship",
+		Long: `Print the current branch, a "*" marker when the current worktree has
+uncommitted changes, and the number of worktrees twig clean would remove,
+e.g. "feat/a* [2 cleanable]". The badge is omitted when nothing is cleanable.
+
+The cleanable count is cached in <git-common-dir>/twig/prompt-cache.json
+(shared with twig prompt-info), so a prompt only pays for git worktree list
+and git status. Prints nothing outside a git repository.
+
+bash (~/.bashrc):
+  PS1='$(twig prompt 2>/dev/null) \$ '
+
+starship (~/.config/starship.toml):
+  [custom.twig]
+  command = "twig prompt"
+  when = "git rev-parse --is-inside-work-tree"`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			verbosity, _ := cmd.Flags().GetCount("verbose")
+			refresh, _ := cmd.Flags().GetBool("refresh")
+
+			idGen := core.GenerateCommandID
+			if a.commandIDGenerator != nil {
+				idGen = a.commandIDGenerator
+			}
+			log := createLogger(cmd.ErrOrStderr(), verbosity, a.logFormat, idGen)
+
+			var promptInfoCmd PromptInfoCommander
+			if a.promptInfoCommander != nil {
+				promptInfoCmd = a.promptInfoCommander
+			} else {
+				promptInfoCmd = core.NewDefaultPromptInfoCommand(a.cfg, log, a.gitOptions()...)
+			}
+			info, err := promptInfoCmd.Run(cmd.Context(), a.cwd, core.PromptInfoOptions{
+				Refresh: refresh,
+				Dirty:   true,
+			})
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprint(cmd.OutOrStdout(), info.Format(core.PromptFormatOptions{Compact: true}).Stdout)
+			return nil
+		},
+	}
+	promptCmd.Flags().Bool("refresh", false, "Recalculate the cleanable count instead of using the cache")
+	return promptCmd
+}
+
+// newPromptSegmentCmd creates the twig prompt-segment command.
+func (a *app) newPromptSegmentCmd() *cobra.Command {
+	promptSegmentCmd := &cobra.Command{
+		Use:   "prompt-segment",
+		Short: "Print a shell script that shows twig status in the prompt",
+		Long: `Print a script that keeps a prompt segment updated asynchronously
+using twig prompt-info. The prompt is never blocked; the segment is
+redrawn when the result arrives.
+
+zsh (~/.zshrc):
+  eval "$(twig prompt-segment --shell zsh)"
+  RPROMPT='${_twig_prompt_segment}'
+
+fish (~/.config/fish/config.fish):
+  twig prompt-segment --shell fish | source
+  # then call twig_prompt_segment from fish_right_prompt`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			shell, _ := cmd.Flags().GetString("shell")
+
+			script, err := core.PromptSegmentScript(core.PromptShell(shell))
+			if err != nil {
+				return err
+			}
+			fmt.Fprint(cmd.OutOrStdout(), script)
+			return nil
+		},
+	}
+	promptSegmentCmd.Flags().String("shell", "", "Shell to generate the segment for (zsh, fish)")
+	promptSegmentCmd.MarkFlagRequired("shell")
+	promptSegmentCmd.RegisterFlagCompletionFunc("shell", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{string(core.PromptShellZsh), string(core.PromptShellFish)}, cobra.ShellCompDirectiveNoFileComp
+	})
+	return promptSegmentCmd
+}
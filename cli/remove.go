@@ -0,0 +1,232 @@
+package cli
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/708u/twig/core"
+	"github.com/708u/twig/format"
+	"github.com/spf13/cobra"
+)
+
+// newRemoveCmd creates the twig remove command.
+func (a *app) newRemoveCmd() *cobra.Command {
+	removeCmd := &cobra.Command{
+		Use:   "remove <branch|path>...",
+		Short: "Remove worktrees and their branches",
+		Long: `Remove git worktrees and delete their associated branches.
+
+The branch names are used to locate the worktrees. A worktree can also be
+given by path: ".", "..", or a path starting with "/", "./" or "../"
+selects the worktree containing it.
+By default, fails if there are uncommitted changes or the branch is not merged.
+Use --force to override these checks.
+
+The worktree containing the current directory is never removed, even with
+--force, unless --force-cwd is given. Your shell is then left in a deleted
+directory, and twig prints where to cd:
+
+  twig remove . --force-cwd
+
+Multiple branches can be specified. Errors on individual branches will not
+stop processing of remaining branches.
+
+Each removal is recorded in the audit log, so the branch can later be
+recreated at its last commit with twig add --restore.
+
+With --archive, uncommitted changes and untracked files are saved to a
+tarball (archive_dir, or .git/twig/archives) before the worktree is
+removed.
+
+With --deinit-submodules, initialized submodules are deinitialized and
+their module storage (.git/worktrees/<id>/modules) is removed first.
+Dirty submodules still require --force.
+
+With --all-merged, the worktrees twig clean would offer are removed
+without a prompt, for scripts: merged into --target (repeatable; default:
+auto-detect like clean), without uncommitted changes or unpushed commits,
+unlocked, and not excluded by clean_exclude. Use twig clean to review
+candidates interactively.
+
+  twig remove --all-merged --target main`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if allMerged, _ := cmd.Flags().GetBool("all-merged"); allMerged {
+				if len(args) > 0 {
+					return fmt.Errorf("cannot use --all-merged with branch arguments")
+				}
+				return nil
+			}
+			return cobra.MinimumNArgs(1)(cmd, args)
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			branches, err := completeLinkedBranches(cmd)
+			if err != nil {
+				return nil, cobra.ShellCompDirectiveError
+			}
+			// Exclude already-specified branches
+			var available []string
+			for _, b := range branches {
+				if !slices.Contains(args, b) {
+					available = append(available, b)
+				}
+			}
+			return available, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			verbosity, _ := cmd.Flags().GetCount("verbose")
+			verbose := verbosity >= 1
+			quiet, _ := cmd.Flags().GetBool("quiet")
+			forceCount, _ := cmd.Flags().GetCount("force")
+			check, _ := cmd.Flags().GetBool("check")
+			keepEmptyDirs, _ := cmd.Flags().GetBool("keep-empty-dirs")
+			keepEmptyDirs = keepEmptyDirs || !a.cfg.ShouldCleanupEmptyDirs()
+			archive, archiveDir := archiveFlag(cmd, a.cwd)
+
+			idGen := core.GenerateCommandID
+			if a.commandIDGenerator != nil {
+				idGen = a.commandIDGenerator
+			}
+			log := createLogger(cmd.ErrOrStderr(), verbosity, a.logFormat, idGen)
+
+			forceCwd, _ := cmd.Flags().GetBool("force-cwd")
+			deinitSubmodules, _ := cmd.Flags().GetBool("deinit-submodules")
+			allMerged, _ := cmd.Flags().GetBool("all-merged")
+			targets, _ := cmd.Flags().GetStringSlice("target")
+			if allMerged && forceCount > 0 {
+				return fmt.Errorf("cannot use --force with --all-merged (use twig clean --force)")
+			}
+			if !allMerged && len(targets) > 0 {
+				return fmt.Errorf("--target requires --all-merged")
+			}
+
+			opts := core.RemoveOptions{
+				Force:            core.WorktreeForceLevel(forceCount),
+				Check:            check,
+				KeepEmptyDirs:    keepEmptyDirs,
+				Archive:          archive,
+				ArchiveDir:       archiveDir,
+				DeinitSubmodules: deinitSubmodules,
+				ForceCwd:         forceCwd,
+			}
+
+			var removeCmdRunner RemoveCommander
+			if a.removeCommander != nil {
+				removeCmdRunner = a.removeCommander
+			} else {
+				removeCmdRunner = core.NewDefaultRemoveCommand(a.cfg, log, a.gitOptions()...)
+				if !check {
+					release, err := a.lockRepository(cmd, a.cwd, log)
+					if err != nil {
+						return err
+					}
+					defer release()
+				}
+			}
+
+			// Branches to remove, with the options for each
+			type removal struct {
+				branch string
+				opts   core.RemoveOptions
+			}
+			var removals []removal
+			if allMerged {
+				// The candidates of twig clean, removed the way clean does
+				var cleanCmd CleanCommander
+				if a.cleanCommander != nil {
+					cleanCmd = a.cleanCommander
+				} else {
+					cleanCmd = core.NewDefaultCleanCommand(a.cfg, log, a.gitOptions()...)
+				}
+				candidates, err := cleanCmd.Run(cmd.Context(), a.cwd, core.CleanOptions{
+					Check:   true,
+					Targets: targets,
+				})
+				if err != nil {
+					return err
+				}
+				for _, c := range candidates.Candidates {
+					if c.Skipped || c.Detached {
+						continue
+					}
+					branchOpts := opts
+					branchOpts.Target = c.Target
+					branchOpts.ForceDeleteBranch = c.CleanReason.IsPR()
+					removals = append(removals, removal{branch: c.Branch, opts: branchOpts})
+				}
+				if len(removals) == 0 {
+					if !quiet {
+						fmt.Fprintln(cmd.OutOrStdout(), "No merged worktrees to remove")
+					}
+					return nil
+				}
+			} else {
+				for _, branch := range args {
+					removals = append(removals, removal{branch: branch, opts: opts})
+				}
+			}
+
+			// Parallel execution with goroutines
+			type indexedResult struct {
+				index int
+				wt    core.RemovedWorktree
+			}
+
+			var wg sync.WaitGroup
+			var mu sync.Mutex
+			results := make([]indexedResult, 0, len(removals))
+
+			for i, r := range removals {
+				wg.Add(1)
+				go func(idx int, branch string, opts core.RemoveOptions) {
+					defer wg.Done()
+					wt, err := removeCmdRunner.Run(cmd.Context(), branch, a.cwd, opts)
+					if err != nil {
+						// Keep the branch a path argument resolved to
+						if wt.Branch == "" {
+							wt.Branch = branch
+						}
+						wt.Err = err
+					}
+					mu.Lock()
+					results = append(results, indexedResult{index: idx, wt: wt})
+					mu.Unlock()
+				}(i, r.branch, r.opts)
+			}
+			wg.Wait()
+
+			// Sort by original index to maintain consistent ordering
+			slices.SortFunc(results, func(a, b indexedResult) int {
+				return a.index - b.index
+			})
+
+			var result core.RemoveResult
+			for i := range results {
+				result.Removed = append(result.Removed, results[i].wt)
+			}
+
+			formatted := result.Format(core.FormatOptions{Verbose: verbose, Quiet: quiet, ColorEnabled: format.IsColorEnabled()})
+			if formatted.Stderr != "" {
+				fmt.Fprint(cmd.ErrOrStderr(), formatted.Stderr)
+			}
+			fmt.Fprint(cmd.OutOrStdout(), formatted.Stdout)
+
+			if result.HasErrors() {
+				return fmt.Errorf("failed to remove %d branch(es)", result.ErrorCount())
+			}
+			return nil
+		},
+	}
+
+	removeCmd.Flags().CountP("force", "f", "Force removal (-f: uncommitted/unmerged, -ff: also locked)")
+	removeCmd.Flags().Bool("check", false, "Show removal eligibility without making changes")
+	removeCmd.Flags().Bool("keep-empty-dirs", false, "Keep parent directories (e.g. feat/) emptied by the removal")
+	removeCmd.Flags().String("archive", "", "Archive uncommitted changes to a tarball before removal (optionally in <dir>)")
+	removeCmd.Flags().Lookup("archive").NoOptDefVal = archiveToConfiguredDir
+	removeCmd.Flags().Bool("force-cwd", false, "Allow removing the worktree containing the current directory")
+	removeCmd.Flags().Bool("deinit-submodules", false, "Deinit submodules and remove their module storage before removal")
+	removeCmd.Flags().Bool("all-merged", false, "Remove every worktree twig clean would offer, without prompting")
+	removeCmd.Flags().StringSlice("target", nil, "Target branch for --all-merged, repeatable or comma-separated (default: auto-detect)")
+	a.notifyOnFinish(removeCmd)
+	return removeCmd
+}
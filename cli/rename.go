@@ -0,0 +1,76 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/708u/twig/core"
+	"github.com/spf13/cobra"
+)
+
+// newRenameCmd creates the twig rename command.
+func (a *app) newRenameCmd() *cobra.Command {
+	renameCmd := &cobra.Command{
+		Use:   "rename <old> <new>",
+		Short: "Rename a branch and move its worktree to match",
+		Long: `Rename a branch and its worktree together.
+
+The branch is renamed with git branch -m and the worktree is moved to the
+path for the new name under worktree_destination_base_dir. Symlinks that
+the move would break are re-pointed, and empty parent directories left
+behind are removed.
+
+If the branch tracked a remote branch of the same name, the upstream is
+moved to <remote>/<new> when that branch exists and unset otherwise.
+
+Names are resolved like twig add (branch_aliases, branch_prefix).
+The main worktree and locked worktrees cannot be renamed.`,
+		Args: cobra.ExactArgs(2),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) > 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			branches, err := completeLinkedBranches(cmd)
+			if err != nil {
+				return nil, cobra.ShellCompDirectiveError
+			}
+			return branches, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			verbosity, _ := cmd.Flags().GetCount("verbose")
+			noPrefix, _ := cmd.Flags().GetBool("no-prefix")
+
+			idGen := core.GenerateCommandID
+			if a.commandIDGenerator != nil {
+				idGen = a.commandIDGenerator
+			}
+			log := createLogger(cmd.ErrOrStderr(), verbosity, a.logFormat, idGen)
+
+			var renameCmdRunner RenameCommander
+			if a.renameCommander != nil {
+				renameCmdRunner = a.renameCommander
+			} else {
+				renameCmdRunner = core.NewDefaultRenameCommand(a.cfg, log, a.gitOptions()...)
+				release, err := a.lockRepository(cmd, a.cwd, log)
+				if err != nil {
+					return err
+				}
+				defer release()
+			}
+			result, err := renameCmdRunner.Run(cmd.Context(), args[0], args[1], a.originalCwd, core.RenameOptions{
+				NoPrefix: noPrefix,
+			})
+			if err != nil {
+				return err
+			}
+
+			formatted := result.Format(core.FormatOptions{Verbose: verbosity >= 1})
+			if formatted.Stderr != "" {
+				fmt.Fprint(cmd.ErrOrStderr(), formatted.Stderr)
+			}
+			fmt.Fprint(cmd.OutOrStdout(), formatted.Stdout)
+			return nil
+		},
+	}
+	renameCmd.Flags().Bool("no-prefix", false, "Use the names as branch names, ignoring branch_prefix and branch_aliases")
+	return renameCmd
+}
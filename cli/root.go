@@ -0,0 +1,937 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/708u/twig/core"
+	"github.com/708u/twig/format"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// AddCommander is the interface for AddCommand execution.
+type AddCommander interface {
+	Run(ctx context.Context, name string) (core.AddResult, error)
+}
+
+// CleanCommander defines the interface for clean operations.
+type CleanCommander interface {
+	Run(ctx context.Context, cwd string, opts core.CleanOptions) (core.CleanResult, error)
+}
+
+// GCCommander defines the interface for gc operations.
+type GCCommander interface {
+	Run(ctx context.Context, cwd string, opts core.GCOptions) (core.GCResult, error)
+}
+
+// ListCommander defines the interface for list operations.
+type ListCommander interface {
+	Run(ctx context.Context, opts core.ListOptions) (core.ListResult, error)
+}
+
+// GrepCommander defines the interface for grep operations.
+type GrepCommander interface {
+	Run(ctx context.Context, opts core.GrepOptions) (core.GrepResult, error)
+}
+
+// RemoveCommander defines the interface for remove operations.
+type RemoveCommander interface {
+	Run(ctx context.Context, branch string, cwd string, opts core.RemoveOptions) (core.RemovedWorktree, error)
+}
+
+// InitCommander defines the interface for init operations.
+type InitCommander interface {
+	Run(ctx context.Context, dir string, opts core.InitOptions) (core.InitResult, error)
+}
+
+// SyncCommander defines the interface for sync operations.
+type SyncCommander interface {
+	Run(ctx context.Context, targets []string, cwd string, opts core.SyncOptions) (core.SyncResult, error)
+}
+
+// OverlayCommander defines the interface for overlay operations.
+type OverlayCommander interface {
+	Run(ctx context.Context, sourceBranch string, cwd string, opts core.OverlayOptions) (core.OverlayResult, error)
+}
+
+// AuditCommander defines the interface for audit log queries.
+type AuditCommander interface {
+	Run(ctx context.Context, opts core.AuditOptions) (core.AuditResult, error)
+}
+
+// DoctorCommander defines the interface for doctor checks.
+type DoctorCommander interface {
+	Run(ctx context.Context, opts core.DoctorOptions) (core.DoctorResult, error)
+}
+
+// PromptInfoCommander defines the interface for prompt info collection.
+type PromptInfoCommander interface {
+	Run(ctx context.Context, cwd string, opts core.PromptInfoOptions) (core.PromptInfo, error)
+}
+
+// RenameCommander defines the interface for rename operations.
+type RenameCommander interface {
+	Run(ctx context.Context, oldName, newName, cwd string, opts core.RenameOptions) (core.RenameResult, error)
+}
+
+// OpenCommander defines the interface for open operations.
+type OpenCommander interface {
+	Run(ctx context.Context, name string, opts core.OpenOptions) (core.OpenResult, error)
+}
+
+// PathCommander defines the interface for the root and path commands.
+type PathCommander interface {
+	Root(ctx context.Context) (core.PathResult, error)
+	Dest() (core.PathResult, error)
+	Run(ctx context.Context, name string, opts core.PathOptions) (core.PathResult, error)
+}
+
+// GitHookCommander defines the interface for git hook installation.
+type GitHookCommander interface {
+	Run(ctx context.Context, hook string, opts core.GitHookOptions) (core.GitHookResult, error)
+}
+
+// ExportCommander defines the interface for export operations.
+type ExportCommander interface {
+	Run(ctx context.Context) (core.State, error)
+}
+
+// ImportCommander defines the interface for import operations.
+type ImportCommander interface {
+	Run(ctx context.Context, state core.State, opts core.ImportOptions) (core.ImportResult, error)
+}
+
+// AdoptCommander defines the interface for adopt operations.
+type AdoptCommander interface {
+	Run(ctx context.Context, branches []string, cwd string, opts core.AdoptOptions) (core.AdoptResult, error)
+}
+
+// NoteCommander defines the interface for branch note operations.
+type NoteCommander interface {
+	Run(ctx context.Context, branch, text string, opts core.NoteOptions) (core.NoteResult, error)
+}
+
+type options struct {
+	addCommander        AddCommander        // nil = use default
+	cleanCommander      CleanCommander      // nil = use default
+	gcCommander         GCCommander         // nil = use default
+	listCommander       ListCommander       // nil = use default
+	grepCommander       GrepCommander       // nil = use default
+	removeCommander     RemoveCommander     // nil = use default
+	initCommander       InitCommander       // nil = use default
+	syncCommander       SyncCommander       // nil = use default
+	overlayCommander    OverlayCommander    // nil = use default
+	auditCommander      AuditCommander      // nil = use default
+	promptInfoCommander PromptInfoCommander // nil = use default
+	doctorCommander     DoctorCommander     // nil = use default
+	openCommander       OpenCommander       // nil = use default
+	renameCommander     RenameCommander     // nil = use default
+	noteCommander       NoteCommander       // nil = use default
+	exportCommander     ExportCommander     // nil = use default
+	importCommander     ImportCommander     // nil = use default
+	adoptCommander      AdoptCommander      // nil = use default
+	gitHookCommander    GitHookCommander    // nil = use default
+	pathCommander       PathCommander       // nil = use default
+	commandIDGenerator  func() string       // nil = use core.GenerateCommandID
+
+	// Build information printed by twig version, set with WithVersion
+	version string
+	commit  string
+	date    string
+}
+
+// Option configures NewRootCmd.
+type Option func(*options)
+
+// WithAddCommander sets the AddCommander instance for testing.
+func WithAddCommander(cmd AddCommander) Option {
+	return func(o *options) {
+		o.addCommander = cmd
+	}
+}
+
+// WithCleanCommander sets the CleanCommander instance for testing.
+func WithCleanCommander(cmd CleanCommander) Option {
+	return func(o *options) {
+		o.cleanCommander = cmd
+	}
+}
+
+// WithGCCommander sets the GCCommander instance for testing.
+func WithGCCommander(cmd GCCommander) Option {
+	return func(o *options) {
+		o.gcCommander = cmd
+	}
+}
+
+// WithListCommander sets the ListCommander instance for testing.
+func WithListCommander(cmd ListCommander) Option {
+	return func(o *options) {
+		o.listCommander = cmd
+	}
+}
+
+// WithGrepCommander sets the GrepCommander instance for testing.
+func WithGrepCommander(cmd GrepCommander) Option {
+	return func(o *options) {
+		o.grepCommander = cmd
+	}
+}
+
+// WithRemoveCommander sets the RemoveCommander instance for testing.
+func WithRemoveCommander(cmd RemoveCommander) Option {
+	return func(o *options) {
+		o.removeCommander = cmd
+	}
+}
+
+// WithInitCommander sets the InitCommander instance for testing.
+func WithInitCommander(cmd InitCommander) Option {
+	return func(o *options) {
+		o.initCommander = cmd
+	}
+}
+
+// WithSyncCommander sets the SyncCommander instance for testing.
+func WithSyncCommander(cmd SyncCommander) Option {
+	return func(o *options) {
+		o.syncCommander = cmd
+	}
+}
+
+// WithOverlayCommander sets the OverlayCommander instance for testing.
+func WithOverlayCommander(cmd OverlayCommander) Option {
+	return func(o *options) {
+		o.overlayCommander = cmd
+	}
+}
+
+// WithAuditCommander sets the AuditCommander instance for testing.
+func WithAuditCommander(cmd AuditCommander) Option {
+	return func(o *options) {
+		o.auditCommander = cmd
+	}
+}
+
+// WithPromptInfoCommander sets the PromptInfoCommander instance for testing.
+func WithPromptInfoCommander(cmd PromptInfoCommander) Option {
+	return func(o *options) {
+		o.promptInfoCommander = cmd
+	}
+}
+
+// WithDoctorCommander sets the DoctorCommander instance for testing.
+func WithDoctorCommander(cmd DoctorCommander) Option {
+	return func(o *options) {
+		o.doctorCommander = cmd
+	}
+}
+
+// WithRenameCommander sets the RenameCommander instance for testing.
+func WithRenameCommander(cmd RenameCommander) Option {
+	return func(o *options) {
+		o.renameCommander = cmd
+	}
+}
+
+// WithOpenCommander sets the OpenCommander instance for testing.
+func WithOpenCommander(cmd OpenCommander) Option {
+	return func(o *options) {
+		o.openCommander = cmd
+	}
+}
+
+// WithPathCommander sets the PathCommander instance for testing.
+func WithPathCommander(cmd PathCommander) Option {
+	return func(o *options) {
+		o.pathCommander = cmd
+	}
+}
+
+// WithExportCommander sets the ExportCommander instance for testing.
+func WithExportCommander(cmd ExportCommander) Option {
+	return func(o *options) {
+		o.exportCommander = cmd
+	}
+}
+
+// WithImportCommander sets the ImportCommander instance for testing.
+func WithImportCommander(cmd ImportCommander) Option {
+	return func(o *options) {
+		o.importCommander = cmd
+	}
+}
+
+// WithAdoptCommander sets the AdoptCommander instance for testing.
+func WithAdoptCommander(cmd AdoptCommander) Option {
+	return func(o *options) {
+		o.adoptCommander = cmd
+	}
+}
+
+// WithNoteCommander sets the NoteCommander instance for testing.
+func WithNoteCommander(cmd NoteCommander) Option {
+	return func(o *options) {
+		o.noteCommander = cmd
+	}
+}
+
+// WithGitHookCommander sets the GitHookCommander instance for testing.
+func WithGitHookCommander(cmd GitHookCommander) Option {
+	return func(o *options) {
+		o.gitHookCommander = cmd
+	}
+}
+
+// WithCommandIDGenerator sets the command ID generator for testing.
+func WithCommandIDGenerator(gen func() string) Option {
+	return func(o *options) {
+		o.commandIDGenerator = gen
+	}
+}
+
+// WithVersion sets the build information printed by twig version and
+// --version, and recorded in the provenance of created worktrees.
+func WithVersion(version, commit, date string) Option {
+	return func(o *options) {
+		o.version = version
+		o.commit = commit
+		o.date = date
+	}
+}
+
+// carryFromCurrent is the sentinel value for --carry flag to use current worktree.
+const carryFromCurrent = "<current>"
+
+// trackSameName is the sentinel value for --track flag to track the remote
+// branch of the same name.
+const trackSameName = "<remote>/<branch>"
+
+// mergedIntoMain is the sentinel value for list --merged to check against
+// the branch of the main worktree.
+const mergedIntoMain = "<main>"
+
+// archiveToConfiguredDir is the sentinel value for --archive flag to use
+// archive_dir (or its default).
+const archiveToConfiguredDir = "<archive_dir>"
+
+// archiveFlag reads --archive. It returns whether archiving is enabled
+// and the directory override, resolved against cwd ("" = archive_dir).
+func archiveFlag(cmd *cobra.Command, cwd string) (bool, string) {
+	value, _ := cmd.Flags().GetString("archive")
+	switch value {
+	case "":
+		return false, ""
+	case archiveToConfiguredDir:
+		return true, ""
+	}
+	if !filepath.IsAbs(value) {
+		value = filepath.Join(cwd, value)
+	}
+	return true, value
+}
+
+// baseDirFlag reads --base-dir, expanding "~/" and resolving a relative
+// path against cwd ("" = worktree_destination_base_dir).
+func baseDirFlag(cmd *cobra.Command, cwd string) (string, error) {
+	value, _ := cmd.Flags().GetString("base-dir")
+	if value == "" {
+		return "", nil
+	}
+	if rest, ok := strings.CutPrefix(value, "~/"); ok {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to resolve --base-dir: %w", err)
+		}
+		value = filepath.Join(home, rest)
+	}
+	if !filepath.IsAbs(value) {
+		value = filepath.Join(cwd, value)
+	}
+	return filepath.Clean(value), nil
+}
+
+// printGCHint prints a hint to stderr after twig add when gc.check_on_add
+// is set and the worktrees are over the [gc] policy. Failures only get
+// logged: the worktree has been created either way.
+func printGCHint(cmd *cobra.Command, cfg *core.Config, log *slog.Logger, gitOpts []core.GitRunnerOption) {
+	if !cfg.ShouldCheckGCOnAdd() || cfg.GCPolicy().IsZero() {
+		return
+	}
+	status, err := core.NewDefaultGCCommand(cfg, log, gitOpts...).Status(cmd.Context(), time.Now())
+	if err != nil {
+		log.DebugContext(cmd.Context(), "gc check failed",
+			core.LogAttrKeyCategory.String(), core.LogCategoryGC,
+			"error", err)
+		return
+	}
+	fmt.Fprint(cmd.ErrOrStderr(), status.Hint())
+}
+
+// defaultPushRemote is the remote used by --push without a value.
+const defaultPushRemote = "origin"
+
+// readAddBatch parses batch entries from path, or from stdin when path is "-".
+func readAddBatch(stdin io.Reader, path string) ([]core.AddBatchEntry, error) {
+	if path == "-" {
+		return core.ParseAddBatch(stdin)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open batch file: %w", err)
+	}
+	defer f.Close()
+	entries, err := core.ParseAddBatch(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return entries, nil
+}
+
+// loadSourceConfig loads the config of the twig add source (see
+// core.ResolveAddSource) and returns it with the source.
+func loadSourceConfig(ctx context.Context, dir, source, profile string) (*core.LoadConfigResult, core.AddSource, error) {
+	src, err := core.ResolveAddSource(ctx, core.NewGitRunner(dir), source)
+	if err != nil {
+		return nil, src, fmt.Errorf("failed to resolve source: %w", err)
+	}
+	result, err := loadConfigWithMainWorktree(ctx, src.Dir, profile)
+	if err != nil {
+		return nil, src, fmt.Errorf("failed to load config: %w", err)
+	}
+	return result, src, nil
+}
+
+// runAdds executes runs with at most jobs running at once.
+// Results keep the order of runs; errors are recorded in AddResult.Err.
+func runAdds(ctx context.Context, jobs int, runs []func(context.Context) (core.AddResult, error)) core.AddBatchResult {
+	results := make([]core.AddResult, len(runs))
+	sem := make(chan struct{}, jobs)
+
+	var wg sync.WaitGroup
+	for i, run := range runs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			res, err := run(ctx)
+			if err != nil {
+				res.Err = err
+			}
+			results[i] = res
+		}()
+	}
+	wg.Wait()
+
+	return core.AddBatchResult{Added: results}
+}
+
+// resolveCarryFrom resolves the --carry flag value to a worktree path.
+func resolveCarryFrom(ctx context.Context, carryValue, originalCwd string, git *core.GitRunner) (string, error) {
+	switch carryValue {
+	case carryFromCurrent:
+		// From a subdirectory, carry the whole worktree
+		root, _ := repositoryRoot(ctx, originalCwd)
+		return root, nil
+	case "":
+		return "", fmt.Errorf("carry value cannot be empty")
+	default:
+		wt, err := git.WorktreeFindByBranch(ctx, carryValue)
+		if err != nil {
+			return "", fmt.Errorf("failed to find worktree for branch %q: %w", carryValue, err)
+		}
+		return wt.Path, nil
+	}
+}
+
+func resolveDirectory(dirFlag, baseCwd string) (string, error) {
+	if dirFlag == "" {
+		return baseCwd, nil
+	}
+
+	var resolved string
+	if !filepath.IsAbs(dirFlag) {
+		resolved = filepath.Join(baseCwd, dirFlag)
+	} else {
+		resolved = dirFlag
+	}
+
+	resolved, err := filepath.Abs(resolved)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve path: %w", err)
+	}
+
+	info, err := os.Stat(resolved)
+	if err != nil {
+		return "", fmt.Errorf("cannot change to '%s': %w", dirFlag, err)
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("cannot change to '%s': not a directory", dirFlag)
+	}
+
+	return resolved, nil
+}
+
+// resolveRepository resolves the --repo flag of twig add to a directory.
+// A leading "~/" is expanded to the home directory, since the shell does
+// not expand it in "--repo=~/src/repo".
+func resolveRepository(repoFlag, baseCwd string) (string, error) {
+	if rest, ok := strings.CutPrefix(repoFlag, "~/"); ok {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to resolve --repo: %w", err)
+		}
+		repoFlag = filepath.Join(home, rest)
+	}
+	dir, err := resolveDirectory(repoFlag, baseCwd)
+	if err != nil {
+		return "", fmt.Errorf("invalid --repo: %w", err)
+	}
+	return dir, nil
+}
+
+// checkOutputLevel rejects --quiet together with --verbose, which ask for
+// opposite amounts of output.
+func checkOutputLevel(cmd *cobra.Command) error {
+	quiet, _ := cmd.Flags().GetBool("quiet")
+	verbosity, _ := cmd.Flags().GetCount("verbose")
+	if quiet && verbosity > 0 {
+		return fmt.Errorf("cannot use --quiet and --verbose together")
+	}
+	return nil
+}
+
+// provenance returns the twig version and the flags given to cmd, for the
+// provenance marker of the worktrees it creates. Global flags such as -C
+// and -v are left out.
+func provenance(cmd *cobra.Command) core.Provenance {
+	var flags []string
+	inherited := cmd.InheritedFlags()
+	cmd.Flags().Visit(func(f *pflag.Flag) {
+		if inherited.Lookup(f.Name) != nil {
+			return
+		}
+		if f.Value.Type() == "bool" {
+			flags = append(flags, "--"+f.Name)
+			return
+		}
+		flags = append(flags, "--"+f.Name+"="+f.Value.String())
+	})
+	return core.Provenance{Version: cmd.Root().Version, Flags: flags}
+}
+
+// createLogger creates a logger based on verbosity level.
+// Returns a nop logger for verbosity < 2, or a CLI handler logger for -vv.
+func createLogger(w io.Writer, verbosity int, format core.LogFormat, idGen func() string) *slog.Logger {
+	if verbosity < 2 {
+		return core.NewNopLogger()
+	}
+	handler := core.NewLogHandler(w, core.VerbosityToLevel(verbosity), format)
+	handlerWithID := handler.WithAttrs([]slog.Attr{
+		core.LogAttrKeyCmdID.Attr(idGen()),
+	})
+	return slog.New(handlerWithID)
+}
+
+// loadConfigWithMainWorktree loads config with the given profile and resolves
+// WorktreeDestBaseDir relative to the main worktree root. Falls back to
+// dir-based resolution if main worktree cannot be determined (e.g., outside a git repo).
+func loadConfigWithMainWorktree(ctx context.Context, dir, profile string) (*core.LoadConfigResult, error) {
+	root, opts := configLoadOptions(ctx, dir, profile)
+	return core.LoadConfig(root, opts...)
+}
+
+// configLoadOptions returns the directory to load config from for dir
+// (see repositoryRoot) and the LoadConfig options used by
+// loadConfigWithMainWorktree.
+func configLoadOptions(ctx context.Context, dir, profile string) (string, []core.LoadConfigOption) {
+	root, mainPath := repositoryRoot(ctx, dir)
+	opts := []core.LoadConfigOption{core.WithProfile(profile)}
+	if mainPath != "" {
+		opts = append(opts, core.WithMainWorktreeDir(mainPath))
+	}
+	return root, opts
+}
+
+// repositoryRoot returns the root of the worktree containing dir, which
+// may be any of its subdirectories, and the main worktree. Config, the
+// symlink source and .twig/ files are all relative to the worktree root.
+// Outside a worktree (not in a repository, or in a bare one) dir itself
+// is returned, and mainPath is empty when there is no repository.
+func repositoryRoot(ctx context.Context, dir string) (root, mainPath string) {
+	paths, err := core.NewGitRunner(dir).RepositoryPaths(ctx)
+	if err != nil {
+		return dir, ""
+	}
+	if paths.WorktreeRoot != "" {
+		dir = paths.WorktreeRoot
+	}
+	return dir, paths.MainWorktree()
+}
+
+// isTerminal reports whether r is an interactive terminal.
+func isTerminal(r io.Reader) bool {
+	f, ok := r.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// offerSaveDefaultSource asks whether to save an inferred default_source
+// to the local config in dir. Only interactive sessions are asked, so that
+// scripts never block on the prompt.
+func offerSaveDefaultSource(cmd *cobra.Command, dir, branch string) {
+	if !isTerminal(cmd.InOrStdin()) {
+		fmt.Fprintf(cmd.ErrOrStderr(), "hint: set default_source = %q in .twig/settings.local.toml to skip inference\n", branch)
+		return
+	}
+	fmt.Fprintf(cmd.ErrOrStderr(), "Save default_source = %q to .twig/settings.local.toml? [y/N]: ", branch)
+	input, err := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+	if err != nil {
+		return
+	}
+	input = strings.TrimSpace(strings.ToLower(input))
+	if input != "y" && input != "yes" {
+		return
+	}
+	root, _ := repositoryRoot(cmd.Context(), dir)
+	path, err := core.SaveDefaultSource(root, branch)
+	if err != nil {
+		fmt.Fprintln(cmd.ErrOrStderr(), "warning: failed to save default_source:", err)
+		return
+	}
+	fmt.Fprintf(cmd.ErrOrStderr(), "Saved default_source to %s\n", path)
+}
+
+// annotationLoadsConfig marks commands that load the config themselves (or
+// need none), so that they can run (and report why) when the config cannot
+// be loaded.
+const annotationLoadsConfig = "twig.loads-config"
+
+// app holds the state shared by the twig commands: the options of
+// NewRootCmd, the global flags, and what PersistentPreRunE resolved from
+// them for the command being run.
+type app struct {
+	*options
+
+	cfg         *core.Config
+	cwd         string
+	originalCwd string
+	dirFlag     string
+	colorFlag   string
+	profileFlag string
+	chaosFlag   string
+	logFlag     string
+	logFormat   core.LogFormat
+	lockTimeout time.Duration
+	timingFlag  bool
+	timings     *core.Timings
+	timingStart time.Time
+	faults      *core.FaultProfile
+}
+
+// NewRootCmd creates the twig command tree. Commands run the default core
+// commands unless replaced with the With...Commander options.
+func NewRootCmd(opts ...Option) *cobra.Command {
+	o := &options{version: "dev", commit: "unknown", date: "unknown"}
+	for _, opt := range opts {
+		opt(o)
+	}
+	a := &app{options: o}
+
+	rootCmd := &cobra.Command{
+		Use:           "twig",
+		Short:         "Manage git worktrees and branches together",
+		Version:       a.version,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			a.startTiming()
+
+			var err error
+			a.originalCwd, err = os.Getwd()
+			if err != nil {
+				return fmt.Errorf("failed to get current directory: %w", err)
+			}
+
+			a.cwd, err = resolveDirectory(a.dirFlag, a.originalCwd)
+			if err != nil {
+				return err
+			}
+
+			// twig add --repo runs in another repository, resolved like -C
+			if repo, _ := cmd.Flags().GetString("repo"); repo != "" {
+				a.cwd, err = resolveRepository(repo, a.cwd)
+				if err != nil {
+					return err
+				}
+				if _, err := core.NewGitRunner(a.cwd).WorktreeRoot(cmd.Context()); err != nil {
+					return fmt.Errorf("invalid --repo: %s is not in a git repository", a.cwd)
+				}
+			}
+
+			if err := checkOutputLevel(cmd); err != nil {
+				return err
+			}
+
+			// Set color mode based on flag
+			format.SetColorMode(format.ColorMode(a.colorFlag))
+
+			a.logFormat, err = core.ParseLogFormat(a.logFlag)
+			if err != nil {
+				return fmt.Errorf("invalid --log-format: %w", err)
+			}
+
+			// Inject faults for robustness testing (hidden --chaos flag)
+			if a.chaosFlag != "" {
+				a.faults, err = core.ParseFaultProfile(a.chaosFlag)
+				if err != nil {
+					return fmt.Errorf("invalid --chaos: %w", err)
+				}
+			}
+
+			if _, ok := cmd.Annotations[annotationLoadsConfig]; ok {
+				return nil
+			}
+
+			result, err := a.loadConfig(cmd.Context(), a.cwd, a.profileFlag)
+			if err != nil {
+				return fmt.Errorf("failed to load config: %w", err)
+			}
+			for _, w := range result.Warnings {
+				fmt.Fprintln(cmd.ErrOrStderr(), "warning:", w)
+			}
+			a.cfg = result.Config
+			core.SetColorTheme(a.cfg.Colors)
+			return nil
+		},
+	}
+	rootCmd.SetVersionTemplate("{{.Version}}\n")
+
+	// Register flags
+	rootCmd.PersistentFlags().StringVarP(&a.dirFlag, "directory", "C", "", "Run as if twig was started in <path>")
+	rootCmd.PersistentFlags().CountP("verbose", "v", "Enable verbose output (-v for verbose, -vv for debug)")
+	rootCmd.PersistentFlags().BoolP("quiet", "q", false, "Print only the paths or branches a command produces, and errors")
+	rootCmd.PersistentFlags().StringVar(&a.colorFlag, "color", "auto", "Color output: auto, always, never")
+	rootCmd.PersistentFlags().StringVar(&a.profileFlag, "profile", "", "Use settings from the named profile in .twig/settings.toml")
+	rootCmd.PersistentFlags().DurationVar(&a.lockTimeout, "lock-timeout", core.DefaultLockTimeout, "How long to wait for another twig command to finish (0 = fail immediately)")
+	rootCmd.PersistentFlags().StringVar(&a.logFlag, "log-format", string(core.LogFormatText), "Debug log format for -vv: text, json")
+	rootCmd.PersistentFlags().Bool("no-cache", false, "Do not use cached branch and worktree names for shell completion")
+	rootCmd.PersistentFlags().BoolVar(&a.timingFlag, "timing", false, "Print how long config loading, git commands, symlinks and submodules took")
+	rootCmd.PersistentFlags().StringVar(&a.chaosFlag, "chaos", "", "Inject git/filesystem faults (development only)")
+	rootCmd.PersistentFlags().MarkHidden("chaos")
+	rootCmd.RegisterFlagCompletionFunc("profile", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		dir, err := resolveCompletionDirectory(cmd)
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+		result, err := loadConfigWithMainWorktree(cmd.Context(), dir, "")
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+		var names []string
+		for _, p := range core.ListProfiles(result.Config).Profiles {
+			names = append(names, p.Name)
+		}
+		return names, cobra.ShellCompDirectiveNoFileComp
+	})
+
+	rootCmd.AddCommand(
+		a.newAddCmd(),
+		a.newListCmd(),
+		a.newGrepCmd(),
+		a.newCleanCmd(),
+		a.newGCCmd(),
+		a.newRemoveCmd(),
+		a.newInitCmd(),
+		a.newSyncCmd(),
+		a.newWatchCmd(),
+		a.newOverlayCmd(),
+		a.newAuditCmd(),
+		a.newDoctorCmd(),
+		a.newPromptInfoCmd(),
+		a.newPromptCmd(),
+		a.newPromptSegmentCmd(),
+		a.newOpenCmd(),
+		a.newRootPathCmd(),
+		a.newPathCmd(),
+		a.newRenameCmd(),
+		a.newAdoptCmd(),
+		a.newNoteCmd(),
+		a.newExportCmd(),
+		a.newImportCmd(),
+		a.newHookCmd(),
+		a.newConfigCmd(),
+		a.newVersionCmd(),
+	)
+	a.reportTiming(rootCmd)
+
+	return rootCmd
+}
+
+// startTiming starts recording the phases of the command for --timing,
+// so that they include loading config.
+func (a *app) startTiming() {
+	if a.timingFlag {
+		a.timings = core.NewTimings()
+		a.timingStart = time.Now()
+	}
+}
+
+// gitOptions returns the options every GitRunner of the command is
+// created with: the git_timeout and git_remote_timeout limits,
+// recording for --timing and the faults of --chaos.
+func (a *app) gitOptions() []core.GitRunnerOption {
+	opts := []core.GitRunnerOption{
+		core.WithTimeout(a.cfg.GitTimeoutDuration()),
+		core.WithTimings(a.timings),
+		core.WithFaults(a.faults),
+	}
+	for _, sub := range core.GitRemoteCommands {
+		opts = append(opts, core.WithCommandTimeout(sub, a.cfg.GitRemoteTimeoutDuration()))
+	}
+	return opts
+}
+
+// loadConfig is loadConfigWithMainWorktree recorded for --timing.
+func (a *app) loadConfig(ctx context.Context, dir, profile string) (*core.LoadConfigResult, error) {
+	start := time.Now()
+	defer func() { a.timings.Record(core.TimingPhaseConfig, time.Since(start)) }()
+	return loadConfigWithMainWorktree(ctx, dir, profile)
+}
+
+// lockRepository takes the repository operation lock for a mutating
+// command. Outside a git repository nothing is locked and the command
+// reports its own error.
+func (a *app) lockRepository(cmd *cobra.Command, dir string, log *slog.Logger) (release func(), err error) {
+	commonDir, err := core.NewGitRunner(dir, append(a.gitOptions(), core.WithLogger(log))...).GitCommonDir(cmd.Context())
+	if err != nil {
+		return func() {}, nil
+	}
+	lock, err := core.AcquireOperationLock(cmd.Context(), commonDir, core.OperationLockOptions{
+		Command: cmd.Name(),
+		Timeout: a.lockTimeout,
+		Log:     log,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return func() {
+		if err := lock.Release(); err != nil {
+			fmt.Fprintln(cmd.ErrOrStderr(), "warning:", err)
+		}
+	}, nil
+}
+
+// notifyOnFinish wraps the RunE of a long-running command so that it
+// sends a notification when notify is set and the command ran longer
+// than notify_after, whether it succeeded or failed.
+func (a *app) notifyOnFinish(c *cobra.Command) {
+	run := c.RunE
+	c.RunE = func(cmd *cobra.Command, args []string) error {
+		start := time.Now()
+		err := run(cmd, args)
+		if elapsed := time.Since(start); a.cfg.ShouldNotify() && elapsed >= a.cfg.NotifyAfterDuration() {
+			core.NewNotifier(cmd.ErrOrStderr(), nil).Notify(cmd.Context(),
+				core.CommandNotification(cmd.CommandPath(), elapsed, err))
+		}
+		return err
+	}
+}
+
+// reportTiming makes c and its subcommands print the phase breakdown of
+// --timing after they ran, whether they succeeded or failed.
+func (a *app) reportTiming(c *cobra.Command) {
+	if run := c.RunE; run != nil {
+		c.RunE = func(cmd *cobra.Command, args []string) error {
+			err := run(cmd, args)
+			if a.timings != nil {
+				fmt.Fprint(cmd.ErrOrStderr(), a.timings.Format(time.Since(a.timingStart)))
+				a.timings = nil
+			}
+			return err
+		}
+	}
+	for _, sub := range c.Commands() {
+		a.reportTiming(sub)
+	}
+}
+
+// resolveCompletionDirectory returns the directory a completion runs in,
+// resolving -C and --repo like PersistentPreRunE does.
+func resolveCompletionDirectory(cmd *cobra.Command) (string, error) {
+	currentCwd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	flag, _ := cmd.Root().PersistentFlags().GetString("directory")
+	dir, err := resolveDirectory(flag, currentCwd)
+	if err != nil {
+		return "", err
+	}
+	if repo, _ := cmd.Flags().GetString("repo"); repo != "" {
+		return resolveRepository(repo, dir)
+	}
+	return dir, nil
+}
+
+// completeWithCache returns the completion candidates of key. They are
+// cached per repository and reused until refs or worktrees change;
+// --no-cache always asks git.
+func completeWithCache(cmd *cobra.Command, key string, load func(context.Context, *core.GitRunner) ([]string, error)) ([]string, error) {
+	dir, err := resolveCompletionDirectory(cmd)
+	if err != nil {
+		return nil, err
+	}
+	git := core.NewGitRunner(dir)
+	cache := core.NewDefaultCompletionCache(git, nil)
+	cache.Disabled, _ = cmd.Root().PersistentFlags().GetBool("no-cache")
+	return cache.Get(cmd.Context(), key, func(ctx context.Context) ([]string, error) {
+		return load(ctx, git)
+	})
+}
+
+// completeBranches returns the local branch names.
+func completeBranches(cmd *cobra.Command) ([]string, error) {
+	return completeWithCache(cmd, core.CompletionKeyBranches, func(ctx context.Context, git *core.GitRunner) ([]string, error) {
+		return core.NewDefaultRefReader(git).BranchList(ctx)
+	})
+}
+
+// completeWorktreeBranches returns the branches checked out in worktrees.
+func completeWorktreeBranches(cmd *cobra.Command) ([]string, error) {
+	return completeWithCache(cmd, core.CompletionKeyWorktreeBranches, func(ctx context.Context, git *core.GitRunner) ([]string, error) {
+		return git.WorktreeListBranches(ctx)
+	})
+}
+
+// completeLinkedBranches returns the branches of linked worktrees,
+// excluding the main worktree and detached HEAD.
+func completeLinkedBranches(cmd *cobra.Command) ([]string, error) {
+	return completeWithCache(cmd, core.CompletionKeyLinkedBranches, func(ctx context.Context, git *core.GitRunner) ([]string, error) {
+		worktrees, err := git.WorktreeList(ctx)
+		if err != nil {
+			return nil, err
+		}
+		var branches []string
+		for i, wt := range worktrees {
+			if i == 0 || wt.Branch == "" {
+				continue
+			}
+			branches = append(branches, wt.Branch)
+		}
+		return branches, nil
+	})
+}
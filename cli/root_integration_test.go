@@ -0,0 +1,1065 @@
+//go:build integration
+
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/708u/twig/core"
+	"github.com/708u/twig/internal/testutil"
+)
+
+func TestAddCommand_SourceFlag_Integration(t *testing.T) {
+	t.Parallel()
+
+	t.Run("SourceBranchWorktree", func(t *testing.T) {
+		t.Parallel()
+
+		repoDir, mainDir := testutil.SetupTestRepo(t, testutil.Symlinks(".envrc"))
+
+		// Commit the settings (but not .envrc - it should be symlinked, not tracked)
+		testutil.RunGit(t, mainDir, "add", ".twig")
+		testutil.RunGit(t, mainDir, "commit", "-m", "add twig settings")
+
+		// Create .envrc in main after commit (local file to be symlinked)
+		if err := os.WriteFile(filepath.Join(mainDir, ".envrc"), []byte("# main envrc"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		// Create first derived worktree (feat/a) from main
+		result, err := core.LoadConfig(mainDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		addCmd := core.NewDefaultAddCommand(result.Config, core.NewNopLogger(), core.AddOptions{})
+		_, err = addCmd.Run(t.Context(), "feat/a")
+		if err != nil {
+			t.Fatalf("failed to create feat/a worktree: %v", err)
+		}
+
+		featAPath := filepath.Join(repoDir, "feat", "a")
+
+		// Now simulate --source main from feat/a worktree
+		// The PreRunE logic: resolve main branch to its worktree path, then reload config
+		git := core.NewGitRunner(featAPath)
+		mainWT, err := git.WorktreeFindByBranch(t.Context(), "main")
+		if err != nil {
+			t.Fatalf("failed to find main worktree: %v", err)
+		}
+
+		// Load config from main (as --source would do)
+		result, err = core.LoadConfig(mainWT.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Create feat/b from main's config
+		addCmd = core.NewDefaultAddCommand(result.Config, core.NewNopLogger(), core.AddOptions{})
+		addResult, err := addCmd.Run(t.Context(), "feat/b")
+		if err != nil {
+			t.Fatalf("failed to create feat/b worktree: %v", err)
+		}
+
+		// Verify worktree was created
+		featBPath := filepath.Join(repoDir, "feat", "b")
+		if _, err := os.Stat(featBPath); os.IsNotExist(err) {
+			t.Errorf("worktree directory does not exist: %s", featBPath)
+		}
+
+		// Verify symlink points to main, not feat/a
+		envrcPath := filepath.Join(featBPath, ".envrc")
+		target, err := os.Readlink(envrcPath)
+		if err != nil {
+			t.Fatalf("failed to read symlink: %v", err)
+		}
+		expectedTarget, _ := filepath.Rel(filepath.Dir(envrcPath), filepath.Join(mainDir, ".envrc"))
+		if target != expectedTarget {
+			t.Errorf("symlink target = %q, want %q", target, expectedTarget)
+		}
+
+		// Verify result
+		if addResult.Branch != "feat/b" {
+			t.Errorf("result.Branch = %q, want %q", addResult.Branch, "feat/b")
+		}
+	})
+
+	t.Run("SourceAndDirectoryCoexistence", func(t *testing.T) {
+		t.Parallel()
+
+		repoDir, mainDir := testutil.SetupTestRepo(t)
+
+		// Commit the settings
+		testutil.RunGit(t, mainDir, "add", ".twig")
+		testutil.RunGit(t, mainDir, "commit", "-m", "add twig settings")
+
+		// Create .envrc in main
+		if err := os.WriteFile(filepath.Join(mainDir, ".envrc"), []byte("# main envrc"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		// Simulate -C pointing to mainDir and --source pointing to main
+		// This should work: -C sets working directory, --source sets source branch
+		git := core.NewGitRunner(mainDir)
+		sourceWT, err := git.WorktreeFindByBranch(t.Context(), "main")
+		if err != nil {
+			t.Fatalf("failed to find main worktree: %v", err)
+		}
+
+		// Load config from source (as --source would do after -C sets cwd)
+		result, err := core.LoadConfig(sourceWT.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Create worktree using the resolved config
+		addCmd := core.NewDefaultAddCommand(result.Config, core.NewNopLogger(), core.AddOptions{})
+		addResult, err := addCmd.Run(t.Context(), "feat/coexist")
+		if err != nil {
+			t.Fatalf("failed to create worktree: %v", err)
+		}
+
+		// Verify worktree was created
+		worktreePath := filepath.Join(repoDir, "feat", "coexist")
+		if _, err := os.Stat(worktreePath); os.IsNotExist(err) {
+			t.Errorf("worktree directory does not exist: %s", worktreePath)
+		}
+
+		// Verify result
+		if addResult.Branch != "feat/coexist" {
+			t.Errorf("result.Branch = %q, want %q", addResult.Branch, "feat/coexist")
+		}
+	})
+
+	t.Run("SourceBranchNotFound", func(t *testing.T) {
+		t.Parallel()
+
+		_, mainDir := testutil.SetupTestRepo(t, testutil.WithoutSettings())
+
+		git := core.NewGitRunner(mainDir)
+		_, err := git.WorktreeFindByBranch(t.Context(), "nonexistent")
+		if err == nil {
+			t.Fatal("expected error for nonexistent branch")
+		}
+		if !strings.Contains(err.Error(), "not checked out in any worktree") {
+			t.Errorf("error %q should mention branch not checked out", err.Error())
+		}
+	})
+
+	t.Run("SourceBranchExistsButNoWorktree", func(t *testing.T) {
+		t.Parallel()
+
+		_, mainDir := testutil.SetupTestRepo(t, testutil.WithoutSettings())
+
+		// Create a branch without a worktree
+		testutil.RunGit(t, mainDir, "branch", "orphan-branch")
+
+		git := core.NewGitRunner(mainDir)
+		_, err := git.WorktreeFindByBranch(t.Context(), "orphan-branch")
+		if err == nil {
+			t.Fatal("expected error for branch without worktree")
+		}
+		if !strings.Contains(err.Error(), "not checked out in any worktree") {
+			t.Errorf("error %q should mention branch not checked out", err.Error())
+		}
+	})
+}
+
+func TestAddCommand_DefaultSource_Integration(t *testing.T) {
+	t.Parallel()
+
+	t.Run("DefaultSourceAppliedWhenNoCliArg", func(t *testing.T) {
+		t.Parallel()
+
+		repoDir, mainDir := testutil.SetupTestRepo(t,
+			testutil.Symlinks(".envrc"),
+			testutil.DefaultSource("main"))
+
+		// Commit the settings
+		testutil.RunGit(t, mainDir, "add", ".twig")
+		testutil.RunGit(t, mainDir, "commit", "-m", "add twig settings with default_source")
+
+		// Create .envrc in main
+		if err := os.WriteFile(filepath.Join(mainDir, ".envrc"), []byte("# main envrc"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		// Create first derived worktree (feat/a) from main
+		result, err := core.LoadConfig(mainDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		addCmd := core.NewDefaultAddCommand(result.Config, core.NewNopLogger(), core.AddOptions{})
+		_, err = addCmd.Run(t.Context(), "feat/a")
+		if err != nil {
+			t.Fatalf("failed to create feat/a worktree: %v", err)
+		}
+
+		featAPath := filepath.Join(repoDir, "feat", "a")
+
+		// Create a file unique to feat/a (not in symlinks, not in main)
+		featAOnlyFile := filepath.Join(featAPath, "feat-a-only.txt")
+		if err := os.WriteFile(featAOnlyFile, []byte("only in feat/a"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		// Load config from feat/a - it should have default_source = "main"
+		resultFeatA, err := core.LoadConfig(featAPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Verify default_source is loaded
+		if resultFeatA.Config.DefaultSource != "main" {
+			t.Errorf("DefaultSource = %q, want %q", resultFeatA.Config.DefaultSource, "main")
+		}
+
+		// When default_source is applied, config should be reloaded from main
+		// Simulate the PreRunE logic
+		git := core.NewGitRunner(featAPath)
+		mainWT, err := git.WorktreeFindByBranch(t.Context(), resultFeatA.Config.DefaultSource)
+		if err != nil {
+			t.Fatalf("failed to find worktree for default_source: %v", err)
+		}
+
+		// Load config from main (as default_source would do)
+		resultMain, err := core.LoadConfig(mainWT.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Create feat/b using main's config
+		addCmd = core.NewDefaultAddCommand(resultMain.Config, core.NewNopLogger(), core.AddOptions{})
+		_, err = addCmd.Run(t.Context(), "feat/b")
+		if err != nil {
+			t.Fatalf("failed to create feat/b worktree: %v", err)
+		}
+
+		// Verify worktree was created
+		featBPath := filepath.Join(repoDir, "feat", "b")
+		if _, err := os.Stat(featBPath); os.IsNotExist(err) {
+			t.Errorf("worktree directory does not exist: %s", featBPath)
+		}
+
+		// Verify symlink points to main, not feat/a
+		envrcPath := filepath.Join(featBPath, ".envrc")
+		target, err := os.Readlink(envrcPath)
+		if err != nil {
+			t.Fatalf("failed to read symlink: %v", err)
+		}
+		expectedTarget, _ := filepath.Rel(filepath.Dir(envrcPath), filepath.Join(mainDir, ".envrc"))
+		if target != expectedTarget {
+			t.Errorf("symlink target = %q, want %q", target, expectedTarget)
+		}
+
+		// Verify feat/b does NOT have feat-a-only.txt
+		// (created from main, not feat/a)
+		featBOnlyFile := filepath.Join(featBPath, "feat-a-only.txt")
+		if _, err := os.Stat(featBOnlyFile); !os.IsNotExist(err) {
+			t.Errorf("feat-a-only.txt should NOT exist in feat/b (created from main)")
+		}
+	})
+
+	t.Run("DefaultSourceAppliedWithDirFlag", func(t *testing.T) {
+		t.Parallel()
+
+		_, mainDir := testutil.SetupTestRepo(t, testutil.DefaultSource("main"))
+
+		// Commit the settings
+		testutil.RunGit(t, mainDir, "add", ".twig")
+		testutil.RunGit(t, mainDir, "commit", "-m", "add twig settings")
+
+		// Create .envrc in main
+		if err := os.WriteFile(filepath.Join(mainDir, ".envrc"), []byte("# main envrc"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		// Simulate -C flag being set (dirFlag is not empty)
+		// Load config (as PersistentPreRunE would do with -C)
+		result, err := core.LoadConfig(mainDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// When -C is specified, default_source should now be applied
+		// (The PreRunE logic checks: if source == "" && cfg.DefaultSource != "")
+		cliSource := ""
+		var effectiveSource string
+		if cliSource == "" && result.Config.DefaultSource != "" {
+			effectiveSource = result.Config.DefaultSource
+		}
+
+		// Since cliSource is empty but default_source is set, effectiveSource should be "main"
+		if effectiveSource != "main" {
+			t.Errorf("effective source = %q, want %q (default_source should be applied with -C)", effectiveSource, "main")
+		}
+	})
+
+	t.Run("LocalConfigOverridesDefaultSource", func(t *testing.T) {
+		t.Parallel()
+
+		_, mainDir := testutil.SetupTestRepo(t, testutil.WithoutSettings())
+
+		// Setup twig settings with default_source = "main"
+		twigDir := filepath.Join(mainDir, ".twig")
+		if err := os.MkdirAll(twigDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		projectSettings := `default_source = "main"`
+		if err := os.WriteFile(filepath.Join(twigDir, "settings.toml"), []byte(projectSettings), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		// Create local settings that overrides default_source
+		localSettings := `default_source = "develop"`
+		if err := os.WriteFile(filepath.Join(twigDir, "settings.local.toml"), []byte(localSettings), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		// Load config
+		result, err := core.LoadConfig(mainDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Local config should override project config
+		if result.Config.DefaultSource != "develop" {
+			t.Errorf("DefaultSource = %q, want %q", result.Config.DefaultSource, "develop")
+		}
+	})
+}
+
+func TestAddCommand_Batch_Integration(t *testing.T) {
+	t.Parallel()
+
+	repoDir, mainDir := testutil.SetupTestRepo(t)
+	testutil.RunGit(t, mainDir, "add", ".twig")
+	testutil.RunGit(t, mainDir, "commit", "-m", "add twig settings")
+
+	// develop gets a commit that main does not have
+	developPath := filepath.Join(repoDir, "develop")
+	testutil.RunGit(t, mainDir, "worktree", "add", "-b", "develop", developPath)
+	testutil.RunGit(t, developPath, "commit", "--allow-empty", "-m", "develop only")
+	developHead := strings.TrimSpace(testutil.RunGit(t, developPath, "rev-parse", "HEAD"))
+
+	cmd := NewRootCmd()
+
+	var stdout, stderr bytes.Buffer
+	cmd.SetOut(&stdout)
+	cmd.SetErr(&stderr)
+	cmd.SetIn(strings.NewReader("feat/a\nfeat/b --source develop --lock --reason \"batch test\"\n"))
+	cmd.SetArgs([]string{"-C", mainDir, "add", "--batch", "-", "--jobs", "2"})
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Execute failed: %v\nstderr: %s", err, stderr.String())
+	}
+
+	featAPath := filepath.Join(repoDir, "feat", "a")
+	featBPath := filepath.Join(repoDir, "feat", "b")
+	for _, p := range []string{featAPath, featBPath} {
+		if _, err := os.Stat(p); err != nil {
+			t.Errorf("worktree not created: %v", err)
+		}
+	}
+
+	if head := strings.TrimSpace(testutil.RunGit(t, featBPath, "rev-parse", "HEAD")); head != developHead {
+		t.Errorf("feat/b HEAD = %s, want develop HEAD %s", head, developHead)
+	}
+	if head := strings.TrimSpace(testutil.RunGit(t, featAPath, "rev-parse", "HEAD")); head == developHead {
+		t.Error("feat/a should be based on main, not develop")
+	}
+
+	list := testutil.RunGit(t, mainDir, "worktree", "list", "--porcelain")
+	if !strings.Contains(list, "worktree "+featBPath+"\nHEAD "+developHead+"\nbranch refs/heads/feat/b\nlocked batch test") {
+		t.Errorf("feat/b should be locked with reason, worktree list:\n%s", list)
+	}
+
+	if !strings.Contains(stdout.String(), "Summary: 2 added, 0 failed") {
+		t.Errorf("stdout should contain summary, got: %s", stdout.String())
+	}
+}
+
+func TestAddCommand_Repo_Integration(t *testing.T) {
+	t.Parallel()
+
+	_, currentDir := testutil.SetupTestRepo(t)
+	otherRepoDir, otherDir := testutil.SetupTestRepo(t)
+
+	cmd := NewRootCmd()
+
+	var stdout, stderr bytes.Buffer
+	cmd.SetOut(&stdout)
+	cmd.SetErr(&stderr)
+	cmd.SetArgs([]string{"-C", currentDir, "add", "feat/x", "--repo", otherDir})
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Execute failed: %v\nstderr: %s", err, stderr.String())
+	}
+
+	// The worktree is created at the other repository's destination
+	wtPath := filepath.Join(otherRepoDir, "feat", "x")
+	if _, err := os.Stat(wtPath); err != nil {
+		t.Errorf("worktree not created in other repository: %v", err)
+	}
+	if branch := strings.TrimSpace(testutil.RunGit(t, otherDir, "branch", "--list", "feat/x")); branch == "" {
+		t.Error("branch feat/x should exist in other repository")
+	}
+	if branch := strings.TrimSpace(testutil.RunGit(t, currentDir, "branch", "--list", "feat/x")); branch != "" {
+		t.Errorf("branch feat/x should not exist in current repository, got %q", branch)
+	}
+}
+
+func TestOperationLock_Integration(t *testing.T) {
+	t.Parallel()
+
+	repoDir, mainDir := testutil.SetupTestRepo(t)
+	commonDir := filepath.Join(mainDir, ".git")
+
+	held, err := core.AcquireOperationLock(t.Context(), commonDir, core.OperationLockOptions{Command: "clean"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	run := func(args ...string) (string, error) {
+		cmd := NewRootCmd()
+		var stdout, stderr bytes.Buffer
+		cmd.SetOut(&stdout)
+		cmd.SetErr(&stderr)
+		cmd.SetArgs(append([]string{"-C", mainDir}, args...))
+		err := cmd.Execute()
+		return stdout.String(), err
+	}
+
+	wtPath := filepath.Join(repoDir, "feat", "locked")
+
+	_, err = run("add", "--lock-timeout", "0", "feat/locked")
+	if err == nil || !strings.Contains(err.Error(), "twig clean (pid") {
+		t.Fatalf("error = %v, want lock busy error naming twig clean", err)
+	}
+	if _, statErr := os.Stat(wtPath); !os.IsNotExist(statErr) {
+		t.Errorf("worktree should not be created while locked")
+	}
+
+	// Read-only commands do not take the lock
+	if _, err := run("list"); err != nil {
+		t.Errorf("list should not wait for the lock: %v", err)
+	}
+
+	if err := held.Release(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := run("add", "--lock-timeout", "0", "feat/locked"); err != nil {
+		t.Fatalf("add failed after release: %v", err)
+	}
+	if _, err := os.Stat(wtPath); err != nil {
+		t.Errorf("worktree not created: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(commonDir, "twig.lock")); !os.IsNotExist(err) {
+		t.Errorf("lock file should be released after add, stat err = %v", err)
+	}
+}
+
+func TestListCommand_VerboseFlag_Integration(t *testing.T) {
+	t.Parallel()
+
+	t.Run("DoubleVerboseOutputsDebugLog", func(t *testing.T) {
+		t.Parallel()
+
+		_, mainDir := testutil.SetupTestRepo(t)
+
+		cmd := NewRootCmd(WithCommandIDGenerator(func() string { return "testid00" }))
+
+		stdout := &bytes.Buffer{}
+		stderr := &bytes.Buffer{}
+
+		cmd.SetOut(stdout)
+		cmd.SetErr(stderr)
+		cmd.SetArgs([]string{"-C", mainDir, "list", "-vv"})
+
+		err := cmd.Execute()
+		if err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+
+		// Verify debug log is output to stderr (format: [DEBUG] [cmd_id] git:)
+		if !strings.Contains(stderr.String(), "[DEBUG] [testid00] git:") {
+			t.Errorf("stderr should contain debug log with cmd_id, got: %q", stderr.String())
+		}
+
+		// Verify normal output is still on stdout
+		if !strings.Contains(stdout.String(), "[main]") {
+			t.Errorf("stdout should contain worktree list, got: %q", stdout.String())
+		}
+	})
+
+	t.Run("JSONLogFormat", func(t *testing.T) {
+		t.Parallel()
+
+		_, mainDir := testutil.SetupTestRepo(t)
+
+		cmd := NewRootCmd(WithCommandIDGenerator(func() string { return "testid00" }))
+
+		stdout := &bytes.Buffer{}
+		stderr := &bytes.Buffer{}
+
+		cmd.SetOut(stdout)
+		cmd.SetErr(stderr)
+		cmd.SetArgs([]string{"-C", mainDir, "list", "-vv", "--log-format", "json"})
+
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+
+		lines := strings.Split(strings.TrimSpace(stderr.String()), "\n")
+		var gitRecords int
+		for _, line := range lines {
+			var record map[string]any
+			if err := json.Unmarshal([]byte(line), &record); err != nil {
+				t.Fatalf("stderr line is not JSON: %q", line)
+			}
+			if record["cmd_id"] != "testid00" {
+				t.Errorf("cmd_id = %v, want testid00", record["cmd_id"])
+			}
+			if record["category"] == "git" {
+				gitRecords++
+				if record["exit_code"] != float64(0) || record["duration_ms"] == nil || record["args"] == nil {
+					t.Errorf("git record missing fields: %q", line)
+				}
+			}
+		}
+		if gitRecords == 0 {
+			t.Errorf("no git records in stderr: %q", stderr.String())
+		}
+		if !strings.Contains(stdout.String(), "[main]") {
+			t.Errorf("stdout should contain worktree list, got: %q", stdout.String())
+		}
+	})
+
+	t.Run("NoVerboseFlagNoDebugLog", func(t *testing.T) {
+		t.Parallel()
+
+		_, mainDir := testutil.SetupTestRepo(t)
+
+		cmd := NewRootCmd()
+
+		stdout := &bytes.Buffer{}
+		stderr := &bytes.Buffer{}
+
+		cmd.SetOut(stdout)
+		cmd.SetErr(stderr)
+		cmd.SetArgs([]string{"-C", mainDir, "list"})
+
+		err := cmd.Execute()
+		if err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+
+		// Verify no debug log on stderr
+		if strings.Contains(stderr.String(), "[DEBUG]") {
+			t.Errorf("stderr should not contain debug log, got: %q", stderr.String())
+		}
+
+		// Verify normal output is on stdout
+		if !strings.Contains(stdout.String(), "[main]") {
+			t.Errorf("stdout should contain worktree list, got: %q", stdout.String())
+		}
+	})
+}
+
+func TestCleanCommand_InteractiveConfirmation_Integration(t *testing.T) {
+	t.Parallel()
+
+	t.Run("ConfirmWithY", func(t *testing.T) {
+		t.Parallel()
+
+		repoDir, mainDir := testutil.SetupTestRepo(t)
+
+		// Create a worktree with a commit
+		wtPath := filepath.Join(repoDir, "feature", "interactive-y")
+		testutil.RunGit(t, mainDir, "worktree", "add", "-b", "feature/interactive-y", wtPath)
+
+		// Make a commit on the branch
+		testFile := filepath.Join(wtPath, "test.txt")
+		if err := os.WriteFile(testFile, []byte("test"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		testutil.RunGit(t, wtPath, "add", "test.txt")
+		testutil.RunGit(t, wtPath, "commit", "-m", "test commit")
+
+		// Merge the branch to main
+		testutil.RunGit(t, mainDir, "merge", "--no-ff", "-m", "Merge feature/interactive-y", "feature/interactive-y")
+
+		// Create command with real implementation (no mock)
+		cmd := NewRootCmd()
+
+		stdout := &bytes.Buffer{}
+		stderr := &bytes.Buffer{}
+		stdin := strings.NewReader("y\n")
+
+		cmd.SetOut(stdout)
+		cmd.SetErr(stderr)
+		cmd.SetIn(stdin)
+		cmd.SetArgs([]string{"-C", mainDir, "clean"})
+
+		err := cmd.Execute()
+		if err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+
+		// Verify worktree was removed
+		if _, err := os.Stat(wtPath); !os.IsNotExist(err) {
+			t.Errorf("worktree should be removed: %s", wtPath)
+		}
+
+		// Verify branch was deleted
+		out := testutil.RunGit(t, mainDir, "branch", "--list", "feature/interactive-y")
+		if strings.TrimSpace(out) != "" {
+			t.Errorf("branch should be deleted, got: %s", out)
+		}
+
+		// Verify output contains prompt
+		if !strings.Contains(stdout.String(), "Proceed? [y/N]:") {
+			t.Errorf("stdout should contain prompt, got: %s", stdout.String())
+		}
+	})
+
+	t.Run("DeclineWithN", func(t *testing.T) {
+		t.Parallel()
+
+		repoDir, mainDir := testutil.SetupTestRepo(t)
+
+		// Create a worktree with a commit
+		wtPath := filepath.Join(repoDir, "feature", "interactive-n")
+		testutil.RunGit(t, mainDir, "worktree", "add", "-b", "feature/interactive-n", wtPath)
+
+		// Make a commit on the branch
+		testFile := filepath.Join(wtPath, "test.txt")
+		if err := os.WriteFile(testFile, []byte("test"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		testutil.RunGit(t, wtPath, "add", "test.txt")
+		testutil.RunGit(t, wtPath, "commit", "-m", "test commit")
+
+		// Merge the branch to main
+		testutil.RunGit(t, mainDir, "merge", "--no-ff", "-m", "Merge feature/interactive-n", "feature/interactive-n")
+
+		// Create command with real implementation (no mock)
+		cmd := NewRootCmd()
+
+		stdout := &bytes.Buffer{}
+		stderr := &bytes.Buffer{}
+		stdin := strings.NewReader("n\n")
+
+		cmd.SetOut(stdout)
+		cmd.SetErr(stderr)
+		cmd.SetIn(stdin)
+		cmd.SetArgs([]string{"-C", mainDir, "clean"})
+
+		err := cmd.Execute()
+		if err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+
+		// Verify worktree still exists
+		if _, err := os.Stat(wtPath); os.IsNotExist(err) {
+			t.Errorf("worktree should still exist: %s", wtPath)
+		}
+
+		// Verify branch still exists
+		out := testutil.RunGit(t, mainDir, "branch", "--list", "feature/interactive-n")
+		if strings.TrimSpace(out) == "" {
+			t.Error("branch should still exist")
+		}
+
+		// Verify output contains prompt
+		if !strings.Contains(stdout.String(), "Proceed? [y/N]:") {
+			t.Errorf("stdout should contain prompt, got: %s", stdout.String())
+		}
+	})
+
+	t.Run("ConfirmWithYes", func(t *testing.T) {
+		t.Parallel()
+
+		repoDir, mainDir := testutil.SetupTestRepo(t)
+
+		// Create a worktree with a commit
+		wtPath := filepath.Join(repoDir, "feature", "interactive-yes")
+		testutil.RunGit(t, mainDir, "worktree", "add", "-b", "feature/interactive-yes", wtPath)
+
+		// Make a commit on the branch
+		testFile := filepath.Join(wtPath, "test.txt")
+		if err := os.WriteFile(testFile, []byte("test"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		testutil.RunGit(t, wtPath, "add", "test.txt")
+		testutil.RunGit(t, wtPath, "commit", "-m", "test commit")
+
+		// Merge the branch to main
+		testutil.RunGit(t, mainDir, "merge", "--no-ff", "-m", "Merge feature/interactive-yes", "feature/interactive-yes")
+
+		// Create command with real implementation (no mock)
+		cmd := NewRootCmd()
+
+		stdout := &bytes.Buffer{}
+		stderr := &bytes.Buffer{}
+		stdin := strings.NewReader("yes\n")
+
+		cmd.SetOut(stdout)
+		cmd.SetErr(stderr)
+		cmd.SetIn(stdin)
+		cmd.SetArgs([]string{"-C", mainDir, "clean"})
+
+		err := cmd.Execute()
+		if err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+
+		// Verify worktree was removed
+		if _, err := os.Stat(wtPath); !os.IsNotExist(err) {
+			t.Errorf("worktree should be removed: %s", wtPath)
+		}
+
+		// Verify branch was deleted
+		out := testutil.RunGit(t, mainDir, "branch", "--list", "feature/interactive-yes")
+		if strings.TrimSpace(out) != "" {
+			t.Errorf("branch should be deleted, got: %s", out)
+		}
+	})
+
+	t.Run("EmptyInputDeclinesConfirmation", func(t *testing.T) {
+		t.Parallel()
+
+		repoDir, mainDir := testutil.SetupTestRepo(t)
+
+		// Create a worktree with a commit
+		wtPath := filepath.Join(repoDir, "feature", "interactive-empty")
+		testutil.RunGit(t, mainDir, "worktree", "add", "-b", "feature/interactive-empty", wtPath)
+
+		// Make a commit on the branch
+		testFile := filepath.Join(wtPath, "test.txt")
+		if err := os.WriteFile(testFile, []byte("test"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		testutil.RunGit(t, wtPath, "add", "test.txt")
+		testutil.RunGit(t, wtPath, "commit", "-m", "test commit")
+
+		// Merge the branch to main
+		testutil.RunGit(t, mainDir, "merge", "--no-ff", "-m", "Merge feature/interactive-empty", "feature/interactive-empty")
+
+		// Create command with real implementation (no mock)
+		cmd := NewRootCmd()
+
+		stdout := &bytes.Buffer{}
+		stderr := &bytes.Buffer{}
+		stdin := strings.NewReader("\n") // Just Enter
+
+		cmd.SetOut(stdout)
+		cmd.SetErr(stderr)
+		cmd.SetIn(stdin)
+		cmd.SetArgs([]string{"-C", mainDir, "clean"})
+
+		err := cmd.Execute()
+		if err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+
+		// Verify worktree still exists (empty input = decline)
+		if _, err := os.Stat(wtPath); os.IsNotExist(err) {
+			t.Errorf("worktree should still exist: %s", wtPath)
+		}
+
+		// Verify branch still exists
+		out := testutil.RunGit(t, mainDir, "branch", "--list", "feature/interactive-empty")
+		if strings.TrimSpace(out) == "" {
+			t.Error("branch should still exist")
+		}
+	})
+}
+
+func TestAddCommandCompletion_Integration(t *testing.T) {
+	t.Parallel()
+
+	t.Run("SourceFlag", func(t *testing.T) {
+		t.Parallel()
+
+		_, mainDir := testutil.SetupTestRepo(t)
+
+		testutil.RunGit(t, mainDir, "worktree", "add", "-b", "feat/b", filepath.Join(filepath.Dir(mainDir), "feat-b"))
+
+		cmd := NewRootCmd()
+		addCmd, _, _ := cmd.Find([]string{"add"})
+		if addCmd == nil {
+			t.Fatal("add command not found")
+		}
+
+		if err := cmd.PersistentFlags().Set("directory", mainDir); err != nil {
+			t.Fatalf("failed to set directory flag: %v", err)
+		}
+		addCmd.SetContext(t.Context())
+
+		completionFunc, exists := addCmd.GetFlagCompletionFunc("source")
+		if !exists {
+			t.Fatal("source flag completion function not registered")
+		}
+
+		completions, directive := completionFunc(addCmd, []string{}, "")
+
+		if directive != 4 { // cobra.ShellCompDirectiveNoFileComp = 4 (1 << 2)
+			t.Errorf("directive = %d, want %d (NoFileComp)", directive, 4)
+		}
+		if len(completions) < 1 {
+			t.Errorf("expected at least 1 completion, got %d", len(completions))
+		}
+	})
+
+	t.Run("CarryFlag", func(t *testing.T) {
+		t.Parallel()
+
+		_, mainDir := testutil.SetupTestRepo(t)
+
+		testutil.RunGit(t, mainDir, "worktree", "add", "-b", "feat/a", filepath.Join(filepath.Dir(mainDir), "feat-a"))
+
+		cmd := NewRootCmd()
+		addCmd, _, _ := cmd.Find([]string{"add"})
+		if addCmd == nil {
+			t.Fatal("add command not found")
+		}
+
+		// Set directory flag directly since we're calling completion function
+		// without Execute() (which would parse flags from SetArgs)
+		if err := cmd.PersistentFlags().Set("directory", mainDir); err != nil {
+			t.Fatalf("failed to set directory flag: %v", err)
+		}
+		addCmd.SetContext(t.Context())
+
+		completionFunc, exists := addCmd.GetFlagCompletionFunc("carry")
+		if !exists {
+			t.Fatal("carry flag completion function not registered")
+		}
+
+		completions, directive := completionFunc(addCmd, []string{}, "")
+
+		if directive != 4 { // cobra.ShellCompDirectiveNoFileComp = 4 (1 << 2)
+			t.Errorf("directive = %d, want %d (NoFileComp)", directive, 4)
+		}
+		if len(completions) < 1 {
+			t.Errorf("expected at least 1 completion, got %d", len(completions))
+		}
+	})
+
+	t.Run("FileFlag_WithCarryBranch", func(t *testing.T) {
+		t.Parallel()
+
+		_, mainDir := testutil.SetupTestRepo(t)
+		featDir := filepath.Join(filepath.Dir(mainDir), "feat-file")
+
+		testutil.RunGit(t, mainDir, "worktree", "add", "-b", "feat/file", featDir)
+
+		// Create uncommitted file only in feat/file worktree
+		if err := os.WriteFile(filepath.Join(featDir, "feat-only.txt"), []byte("test"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		cmd := NewRootCmd()
+		addCmd, _, _ := cmd.Find([]string{"add"})
+		if addCmd == nil {
+			t.Fatal("add command not found")
+		}
+
+		if err := cmd.PersistentFlags().Set("directory", mainDir); err != nil {
+			t.Fatalf("failed to set directory flag: %v", err)
+		}
+		// Set --carry=feat/file to get files from that worktree
+		if err := addCmd.Flags().Set("carry", "feat/file"); err != nil {
+			t.Fatalf("failed to set carry flag: %v", err)
+		}
+		addCmd.SetContext(t.Context())
+
+		completionFunc, exists := addCmd.GetFlagCompletionFunc("file")
+		if !exists {
+			t.Fatal("file flag completion function not registered")
+		}
+
+		completions, directive := completionFunc(addCmd, []string{}, "")
+
+		if directive != 2 { // cobra.ShellCompDirectiveNoSpace = 2 (1 << 1)
+			t.Errorf("directive = %d, want %d (NoSpace)", directive, 2)
+		}
+
+		// Should include feat-only.txt from feat/file worktree
+		if !slices.Contains(completions, "feat-only.txt") {
+			t.Errorf("expected feat-only.txt in completions, got %v", completions)
+		}
+	})
+
+}
+
+func TestSyncCommandCompletion_Integration(t *testing.T) {
+	t.Parallel()
+
+	t.Run("SourceFlag", func(t *testing.T) {
+		t.Parallel()
+
+		_, mainDir := testutil.SetupTestRepo(t)
+
+		testutil.RunGit(t, mainDir, "worktree", "add", "-b", "feat/c", filepath.Join(filepath.Dir(mainDir), "feat-c"))
+
+		cmd := NewRootCmd()
+		syncCmd, _, _ := cmd.Find([]string{"sync"})
+		if syncCmd == nil {
+			t.Fatal("sync command not found")
+		}
+
+		// Set directory flag directly since we're calling completion function
+		// without Execute() (which would parse flags from SetArgs)
+		if err := cmd.PersistentFlags().Set("directory", mainDir); err != nil {
+			t.Fatalf("failed to set directory flag: %v", err)
+		}
+		syncCmd.SetContext(t.Context())
+
+		completionFunc, exists := syncCmd.GetFlagCompletionFunc("source")
+		if !exists {
+			t.Fatal("source flag completion function not registered")
+		}
+
+		completions, directive := completionFunc(syncCmd, []string{}, "")
+
+		if directive != 4 { // cobra.ShellCompDirectiveNoFileComp = 4 (1 << 2)
+			t.Errorf("directive = %d, want %d (NoFileComp)", directive, 4)
+		}
+		if len(completions) < 1 {
+			t.Errorf("expected at least 1 completion, got %d", len(completions))
+		}
+	})
+}
+
+func TestRemoveCommandCompletion_Integration(t *testing.T) {
+	t.Parallel()
+
+	t.Run("SuggestsRemovableWorktrees", func(t *testing.T) {
+		t.Parallel()
+
+		_, mainDir := testutil.SetupTestRepo(t)
+
+		testutil.RunGit(t, mainDir, "worktree", "add", "-b", "feat/test", filepath.Join(filepath.Dir(mainDir), "feat-test"))
+
+		cmd := NewRootCmd()
+		removeCmd, _, _ := cmd.Find([]string{"remove"})
+		if removeCmd == nil {
+			t.Fatal("remove command not found")
+		}
+
+		if err := cmd.PersistentFlags().Set("directory", mainDir); err != nil {
+			t.Fatalf("failed to set directory flag: %v", err)
+		}
+		removeCmd.SetContext(t.Context())
+
+		completionFunc := removeCmd.ValidArgsFunction
+		if completionFunc == nil {
+			t.Fatal("ValidArgsFunction not registered")
+		}
+
+		completions, directive := completionFunc(removeCmd, []string{}, "")
+
+		if directive != 4 { // cobra.ShellCompDirectiveNoFileComp = 4 (1 << 2)
+			t.Errorf("directive = %d, want %d (NoFileComp)", directive, 4)
+		}
+
+		// main should not be in completion candidates
+		if slices.Contains(completions, "main") {
+			t.Errorf("completions should not contain main, got %v", completions)
+		}
+
+		// feat/test should be in completion candidates
+		if !slices.Contains(completions, "feat/test") {
+			t.Errorf("completions should contain feat/test, got %v", completions)
+		}
+	})
+	t.Run("CacheRefreshedAfterWorktreeAdd", func(t *testing.T) {
+		t.Parallel()
+
+		_, mainDir := testutil.SetupTestRepo(t)
+		testutil.RunGit(t, mainDir, "worktree", "add", "-b", "feat/a", filepath.Join(filepath.Dir(mainDir), "feat-a"))
+
+		complete := func() []string {
+			t.Helper()
+			cmd := NewRootCmd()
+			removeCmd, _, _ := cmd.Find([]string{"remove"})
+			if err := cmd.PersistentFlags().Set("directory", mainDir); err != nil {
+				t.Fatalf("failed to set directory flag: %v", err)
+			}
+			removeCmd.SetContext(t.Context())
+			completions, _ := removeCmd.ValidArgsFunction(removeCmd, []string{}, "")
+			return completions
+		}
+
+		if got := complete(); !slices.Equal(got, []string{"feat/a"}) {
+			t.Fatalf("completions = %v, want [feat/a]", got)
+		}
+		if _, err := os.Stat(filepath.Join(mainDir, ".git", "twig", "completion-cache.json")); err != nil {
+			t.Fatalf("completion cache not written: %v", err)
+		}
+
+		testutil.RunGit(t, mainDir, "worktree", "add", "-b", "feat/b", filepath.Join(filepath.Dir(mainDir), "feat-b"))
+
+		if got := complete(); !slices.Equal(got, []string{"feat/a", "feat/b"}) {
+			t.Errorf("completions after worktree add = %v, want [feat/a feat/b]", got)
+		}
+	})
+}
+
+func TestCleanCommandCompletion_Integration(t *testing.T) {
+	t.Parallel()
+
+	t.Run("TargetFlag", func(t *testing.T) {
+		t.Parallel()
+
+		_, mainDir := testutil.SetupTestRepo(t)
+
+		// Create additional branches for completion
+		testutil.RunGit(t, mainDir, "branch", "develop")
+		testutil.RunGit(t, mainDir, "branch", "feat/a")
+
+		cmd := NewRootCmd()
+		cleanCmd, _, _ := cmd.Find([]string{"clean"})
+		if cleanCmd == nil {
+			t.Fatal("clean command not found")
+		}
+
+		if err := cmd.PersistentFlags().Set("directory", mainDir); err != nil {
+			t.Fatalf("failed to set directory flag: %v", err)
+		}
+		cleanCmd.SetContext(t.Context())
+
+		completionFunc, exists := cleanCmd.GetFlagCompletionFunc("target")
+		if !exists {
+			t.Fatal("target flag completion function not registered")
+		}
+
+		completions, directive := completionFunc(cleanCmd, []string{}, "")
+
+		if directive != 4 { // cobra.ShellCompDirectiveNoFileComp = 4 (1 << 2)
+			t.Errorf("directive = %d, want %d (NoFileComp)", directive, 4)
+		}
+		if len(completions) < 3 {
+			t.Errorf("expected at least 3 completions (main, develop, feat/a), got %d: %v", len(completions), completions)
+		}
+
+		// Verify expected branches are in completions
+		expectedBranches := []string{"main", "develop", "feat/a"}
+		for _, branch := range expectedBranches {
+			if !slices.Contains(completions, branch) {
+				t.Errorf("expected %q in completions, got %v", branch, completions)
+			}
+		}
+	})
+}
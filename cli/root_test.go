@@ -0,0 +1,3853 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/708u/twig/core"
+	"github.com/708u/twig/internal/testutil"
+)
+
+func TestResolveDirectory(t *testing.T) {
+	t.Parallel()
+
+	t.Run("EmptyDirFlag", func(t *testing.T) {
+		t.Parallel()
+
+		baseCwd := "/some/path"
+		got, err := resolveDirectory("", baseCwd)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got != baseCwd {
+			t.Errorf("got %q, want %q", got, baseCwd)
+		}
+	})
+
+	t.Run("NonexistentPath", func(t *testing.T) {
+		t.Parallel()
+
+		baseCwd := t.TempDir()
+		_, err := resolveDirectory("/nonexistent/path", baseCwd)
+		if err == nil {
+			t.Fatal("expected error, got nil")
+		}
+		if !strings.Contains(err.Error(), "cannot change to '/nonexistent/path'") {
+			t.Errorf("error %q should contain path", err.Error())
+		}
+	})
+
+	t.Run("PathIsFile", func(t *testing.T) {
+		t.Parallel()
+
+		tmpDir := t.TempDir()
+		filePath := filepath.Join(tmpDir, "file.txt")
+		if err := os.WriteFile(filePath, []byte("content"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		_, err := resolveDirectory(filePath, tmpDir)
+		if err == nil {
+			t.Fatal("expected error, got nil")
+		}
+		if !strings.Contains(err.Error(), "not a directory") {
+			t.Errorf("error %q should contain 'not a directory'", err.Error())
+		}
+	})
+
+	t.Run("ValidAbsolutePath", func(t *testing.T) {
+		t.Parallel()
+
+		targetDir := t.TempDir()
+		baseCwd := t.TempDir()
+
+		got, err := resolveDirectory(targetDir, baseCwd)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		// Resolve symlinks for comparison (macOS /var -> /private/var)
+		want, _ := filepath.EvalSymlinks(targetDir)
+		gotResolved, _ := filepath.EvalSymlinks(got)
+		if gotResolved != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	})
+
+	t.Run("ValidRelativePath", func(t *testing.T) {
+		t.Parallel()
+
+		baseCwd := t.TempDir()
+		subDir := filepath.Join(baseCwd, "subdir")
+		if err := os.Mkdir(subDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := resolveDirectory("subdir", baseCwd)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		want, _ := filepath.EvalSymlinks(subDir)
+		gotResolved, _ := filepath.EvalSymlinks(got)
+		if gotResolved != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	})
+}
+
+func TestResolveCarryFrom(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		carryValue  string
+		originalCwd string
+		worktrees   []testutil.MockWorktree
+		want        string
+		wantErr     string
+	}{
+		{
+			name:        "EmptyValue",
+			carryValue:  "",
+			originalCwd: "/original",
+			wantErr:     "carry value cannot be empty",
+		},
+		{
+			name:        "CurrentValue",
+			carryValue:  carryFromCurrent,
+			originalCwd: "/path/to/original",
+			want:        "/path/to/original",
+		},
+		{
+			name:        "BranchValue",
+			carryValue:  "main",
+			originalCwd: "/original",
+			worktrees:   []testutil.MockWorktree{{Path: "/path/to/main", Branch: "main"}},
+			want:        "/path/to/main",
+		},
+		{
+			name:        "BranchNotFound",
+			carryValue:  "nonexistent",
+			originalCwd: "/original",
+			worktrees:   []testutil.MockWorktree{{Path: "/path/to/main", Branch: "main"}},
+			wantErr:     "failed to find worktree for branch",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var git *core.GitRunner
+			if tt.worktrees != nil {
+				git = &core.GitRunner{
+					Executor: &testutil.MockGitExecutor{Worktrees: tt.worktrees},
+					Dir:      "/mock",
+					Log:      core.NewNopLogger(),
+				}
+			}
+
+			got, err := resolveCarryFrom(t.Context(), tt.carryValue, tt.originalCwd, git)
+			if tt.wantErr != "" {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				if !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("error = %q, want to contain %q", err.Error(), tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// mockCleanCommander is a test double for CleanCommander interface.
+type mockCleanCommander struct {
+	result   core.CleanResult
+	err      error
+	lastOpts core.CleanOptions
+}
+
+func (m *mockCleanCommander) Run(ctx context.Context, cwd string, opts core.CleanOptions) (core.CleanResult, error) {
+	m.lastOpts = opts
+	return m.result, m.err
+}
+
+func TestCleanCmd(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		args       []string
+		stdin      string
+		result     core.CleanResult
+		wantStdout string
+		wantErr    bool
+	}{
+		{
+			name:  "check_shows_candidates",
+			args:  []string{"clean", "--check"},
+			stdin: "",
+			result: core.CleanResult{
+				Candidates: []core.CleanCandidate{
+					{Branch: "feat/a", Skipped: false, CleanReason: core.CleanMerged},
+				},
+				Check: true,
+			},
+			wantStdout: "clean:\n  feat/a (merged)\n",
+		},
+		{
+			name:  "check_no_candidates",
+			args:  []string{"clean", "--check"},
+			stdin: "",
+			result: core.CleanResult{
+				Candidates: []core.CleanCandidate{},
+				Check:      true,
+			},
+			wantStdout: "No worktrees to clean\n",
+		},
+		{
+			name:  "prompt_declined",
+			args:  []string{"clean"},
+			stdin: "n\n",
+			result: core.CleanResult{
+				Candidates: []core.CleanCandidate{
+					{Branch: "feat/a", Skipped: false, CleanReason: core.CleanMerged},
+				},
+				Check: true,
+			},
+			wantStdout: "clean:\n  feat/a (merged)\n\nProceed? [y/N]: ",
+		},
+		{
+			name:  "stale_flag_passed",
+			args:  []string{"clean", "--check", "--stale"},
+			stdin: "",
+			result: core.CleanResult{
+				Candidates: []core.CleanCandidate{
+					{Branch: "feat/a", Skipped: false, CleanReason: core.CleanMerged, StaleOverride: true},
+				},
+				Check: true,
+			},
+			wantStdout: "clean:\n  feat/a (merged, stale)\n",
+		},
+		{
+			name:  "verbose_shows_skipped",
+			args:  []string{"clean", "--check", "-v"},
+			stdin: "",
+			result: core.CleanResult{
+				Candidates: []core.CleanCandidate{
+					{Branch: "feat/a", Skipped: false, CleanReason: core.CleanMerged},
+					{Branch: "feat/b", Skipped: true, SkipReason: core.SkipNotMerged},
+				},
+				Check: true,
+			},
+			wantStdout: "clean:\n  feat/a (merged)\n\nskip:\n  feat/b\n    ✗ not merged\n",
+		},
+		{
+			name:  "porcelain_does_not_prompt",
+			args:  []string{"clean", "--porcelain"},
+			stdin: "y\n",
+			result: core.CleanResult{
+				Candidates: []core.CleanCandidate{
+					{Branch: "feat/a", WorktreePath: "/wt/feat/a", Skipped: false, CleanReason: core.CleanMerged},
+					{Branch: "feat/b", WorktreePath: "/wt/feat/b", Skipped: true, SkipReason: core.SkipNotMerged},
+				},
+				Check: true,
+			},
+			wantStdout: "feat/a\t/wt/feat/a\tremove\tmerged\nfeat/b\t/wt/feat/b\tskip\tnot_merged\n",
+		},
+		{
+			name:    "porcelain_with_yes",
+			args:    []string{"clean", "--porcelain", "--yes"},
+			wantErr: true,
+		},
+		{
+			name:  "quiet_check_lists_branches",
+			args:  []string{"clean", "--check", "-q"},
+			stdin: "",
+			result: core.CleanResult{
+				Candidates: []core.CleanCandidate{
+					{Branch: "feat/a", Skipped: false, CleanReason: core.CleanMerged},
+					{Branch: "feat/b", Skipped: true, SkipReason: core.SkipNotMerged},
+				},
+				Check: true,
+			},
+			wantStdout: "feat/a\n",
+		},
+		{
+			name:  "quiet_prompts_on_stderr",
+			args:  []string{"clean", "-q"},
+			stdin: "n\n",
+			result: core.CleanResult{
+				Candidates: []core.CleanCandidate{
+					{Branch: "feat/a", Skipped: false, CleanReason: core.CleanMerged},
+				},
+				Check: true,
+			},
+			wantStdout: "",
+		},
+		{
+			name:    "quiet_with_verbose",
+			args:    []string{"clean", "-q", "-v"},
+			wantErr: true,
+		},
+		{
+			name:    "quiet_with_porcelain",
+			args:    []string{"clean", "-q", "--porcelain"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mock := &mockCleanCommander{result: tt.result}
+
+			cmd := NewRootCmd(WithCleanCommander(mock))
+
+			stdout := &bytes.Buffer{}
+			stderr := &bytes.Buffer{}
+			stdin := strings.NewReader(tt.stdin)
+
+			cmd.SetOut(stdout)
+			cmd.SetErr(stderr)
+			cmd.SetIn(stdin)
+			cmd.SetArgs(tt.args)
+
+			err := cmd.Execute()
+
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if stdout.String() != tt.wantStdout {
+				t.Errorf("stdout = %q, want %q", stdout.String(), tt.wantStdout)
+			}
+		})
+	}
+}
+
+// mockGCCommander is a test double for GCCommander interface.
+type mockGCCommander struct {
+	result core.GCResult
+	calls  []core.GCOptions
+}
+
+func (m *mockGCCommander) Run(ctx context.Context, cwd string, opts core.GCOptions) (core.GCResult, error) {
+	m.calls = append(m.calls, opts)
+	result := m.result
+	result.Clean.Check = opts.Check
+	return result, nil
+}
+
+func TestGCCmd(t *testing.T) {
+	t.Parallel()
+
+	policy := core.GCPolicy{MaxWorktrees: 1}
+	selected := core.GCResult{
+		Status: core.GCStatus{Policy: policy, Worktrees: 2, Selected: []core.GCSelection{
+			{Branch: "feat/a", WorktreePath: "/wt/feat/a", Reason: core.GCOverLimit},
+		}},
+		Clean: core.CleanResult{Candidates: []core.CleanCandidate{
+			{Branch: "feat/a", WorktreePath: "/wt/feat/a", CleanReason: core.CleanMerged},
+		}},
+	}
+
+	tests := []struct {
+		name       string
+		args       []string
+		stdin      string
+		result     core.GCResult
+		wantCalls  int
+		wantStdout string
+	}{
+		{
+			name:       "within_policy",
+			args:       []string{"gc"},
+			result:     core.GCResult{Status: core.GCStatus{Policy: policy, Worktrees: 1}},
+			wantCalls:  1,
+			wantStdout: "1 worktree(s) within the gc policy (max_worktrees = 1)\n",
+		},
+		{
+			name:      "check_does_not_prompt",
+			args:      []string{"gc", "--check"},
+			stdin:     "y\n",
+			result:    selected,
+			wantCalls: 1,
+			wantStdout: "1 of 2 worktree(s) over the gc policy (max_worktrees = 1):\n" +
+				"  feat/a (over max_worktrees, age unknown)\n\n" +
+				"clean:\n  feat/a (merged)\n",
+		},
+		{
+			name:      "prompt_declined",
+			args:      []string{"gc"},
+			stdin:     "n\n",
+			result:    selected,
+			wantCalls: 1,
+			wantStdout: "1 of 2 worktree(s) over the gc policy (max_worktrees = 1):\n" +
+				"  feat/a (over max_worktrees, age unknown)\n\n" +
+				"clean:\n  feat/a (merged)\n\nProceed? [y/N]: ",
+		},
+		{
+			name:      "yes_removes",
+			args:      []string{"gc", "--yes"},
+			result:    selected,
+			wantCalls: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mock := &mockGCCommander{result: tt.result}
+			cmd := NewRootCmd(WithGCCommander(mock))
+
+			stdout := &bytes.Buffer{}
+			cmd.SetOut(stdout)
+			cmd.SetErr(&bytes.Buffer{})
+			cmd.SetIn(strings.NewReader(tt.stdin))
+			cmd.SetArgs(tt.args)
+
+			if err := cmd.Execute(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(mock.calls) != tt.wantCalls {
+				t.Fatalf("Run called %d times, want %d", len(mock.calls), tt.wantCalls)
+			}
+			if !mock.calls[0].Check {
+				t.Error("first pass should run in check mode")
+			}
+			if tt.wantCalls == 2 && mock.calls[1].Check {
+				t.Error("second pass should not run in check mode")
+			}
+			if tt.wantStdout != "" && stdout.String() != tt.wantStdout {
+				t.Errorf("stdout = %q, want %q", stdout.String(), tt.wantStdout)
+			}
+		})
+	}
+}
+
+func TestCleanCmd_StaleFromConfig(t *testing.T) {
+	t.Parallel()
+
+	mock := &mockCleanCommander{
+		result: core.CleanResult{
+			Candidates: []core.CleanCandidate{},
+			Check:      true,
+		},
+	}
+
+	// Create a real git repo with clean_stale = true in config
+	_, mainDir := testutil.SetupTestRepo(t)
+	twigDir := filepath.Join(mainDir, ".twig")
+	if err := os.MkdirAll(twigDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	settingsContent := fmt.Sprintf("worktree_destination_base_dir = %q\nclean_stale = true\n", filepath.Dir(mainDir))
+	if err := os.WriteFile(filepath.Join(twigDir, "settings.toml"), []byte(settingsContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+	testutil.RunGit(t, mainDir, "add", ".twig")
+	testutil.RunGit(t, mainDir, "commit", "-m", "add twig settings")
+
+	cmd := NewRootCmd(WithCleanCommander(mock))
+
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+
+	cmd.SetOut(stdout)
+	cmd.SetErr(stderr)
+	cmd.SetArgs([]string{"-C", mainDir, "clean", "--check"})
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !mock.lastOpts.Stale {
+		t.Error("expected Stale to be true from config")
+	}
+}
+
+func TestCleanCmd_StaleFlagOverridesConfig(t *testing.T) {
+	t.Parallel()
+
+	mock := &mockCleanCommander{
+		result: core.CleanResult{
+			Candidates: []core.CleanCandidate{},
+			Check:      true,
+		},
+	}
+
+	// Create a real git repo WITHOUT clean_stale in config
+	_, mainDir := testutil.SetupTestRepo(t)
+	twigDir := filepath.Join(mainDir, ".twig")
+	if err := os.MkdirAll(twigDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	settingsContent := fmt.Sprintf("worktree_destination_base_dir = %q\n", filepath.Dir(mainDir))
+	if err := os.WriteFile(filepath.Join(twigDir, "settings.toml"), []byte(settingsContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+	testutil.RunGit(t, mainDir, "add", ".twig")
+	testutil.RunGit(t, mainDir, "commit", "-m", "add twig settings")
+
+	cmd := NewRootCmd(WithCleanCommander(mock))
+
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+
+	cmd.SetOut(stdout)
+	cmd.SetErr(stderr)
+	cmd.SetArgs([]string{"-C", mainDir, "clean", "--check", "--stale"})
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !mock.lastOpts.Stale {
+		t.Error("expected Stale to be true from CLI flag")
+	}
+}
+
+func TestCleanCmd_StaleFlag(t *testing.T) {
+	t.Parallel()
+
+	mock := &mockCleanCommander{
+		result: core.CleanResult{
+			Candidates: []core.CleanCandidate{},
+			Check:      true,
+		},
+	}
+
+	cmd := NewRootCmd(WithCleanCommander(mock))
+
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+
+	cmd.SetOut(stdout)
+	cmd.SetErr(stderr)
+	cmd.SetArgs([]string{"clean", "--check", "--stale"})
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !mock.lastOpts.Stale {
+		t.Error("expected Stale to be true in CleanOptions")
+	}
+}
+
+// mockAddCommander is a mock implementation of AddCommander for testing.
+// Thread-safe for parallel execution.
+type mockAddCommander struct {
+	mu         sync.Mutex
+	result     core.AddResult
+	err        error
+	calledName string
+	calls      []string
+	results    map[string]addResult // keyed by branch name, overrides result/err
+}
+
+type addResult struct {
+	result core.AddResult
+	err    error
+}
+
+func (m *mockAddCommander) Run(ctx context.Context, name string) (core.AddResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.calledName = name
+	m.calls = append(m.calls, name)
+	if r, ok := m.results[name]; ok {
+		return r.result, r.err
+	}
+	return m.result, m.err
+}
+
+// mockListCommander is a test double for ListCommander interface.
+type mockListCommander struct {
+	result     core.ListResult
+	err        error
+	calledOpts core.ListOptions
+}
+
+func (m *mockListCommander) Run(ctx context.Context, opts core.ListOptions) (core.ListResult, error) {
+	m.calledOpts = opts
+	return m.result, m.err
+}
+
+func TestListCmd(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		args       []string
+		result     core.ListResult
+		err        error
+		wantStdout string
+		wantErr    bool
+	}{
+		{
+			name: "default output",
+			args: []string{"list"},
+			result: core.ListResult{
+				Worktrees: []core.Worktree{
+					{Path: "/repo/main", Branch: "main", HEAD: "abc1234567890"},
+					{Path: "/repo/feat-a", Branch: "feat/a", HEAD: "def5678901234"},
+				},
+			},
+			wantStdout: "/repo/main    abc1234 [main]\n/repo/feat-a  def5678 [feat/a]\n",
+		},
+		{
+			name: "quiet flag outputs paths only",
+			args: []string{"list", "--quiet"},
+			result: core.ListResult{
+				Worktrees: []core.Worktree{
+					{Path: "/repo/main", Branch: "main", HEAD: "abc1234567890"},
+					{Path: "/repo/feat-a", Branch: "feat/a", HEAD: "def5678901234"},
+				},
+			},
+			wantStdout: "/repo/main\n/repo/feat-a\n",
+		},
+		{
+			name: "short flag -q",
+			args: []string{"list", "-q"},
+			result: core.ListResult{
+				Worktrees: []core.Worktree{
+					{Path: "/repo/main", Branch: "main", HEAD: "abc1234567890"},
+				},
+			},
+			wantStdout: "/repo/main\n",
+		},
+		{
+			name: "empty list",
+			args: []string{"list"},
+			result: core.ListResult{
+				Worktrees: []core.Worktree{},
+			},
+			wantStdout: "",
+		},
+		{
+			name:    "error from commander",
+			args:    []string{"list"},
+			err:     errors.New("git error"),
+			wantErr: true,
+		},
+		{
+			name:    "tree with quiet",
+			args:    []string{"list", "--tree", "-q"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mock := &mockListCommander{result: tt.result, err: tt.err}
+
+			cmd := NewRootCmd(WithListCommander(mock))
+
+			stdout := &bytes.Buffer{}
+			stderr := &bytes.Buffer{}
+
+			cmd.SetOut(stdout)
+			cmd.SetErr(stderr)
+			cmd.SetArgs(tt.args)
+
+			err := cmd.Execute()
+
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if stdout.String() != tt.wantStdout {
+				t.Errorf("stdout = %q, want %q", stdout.String(), tt.wantStdout)
+			}
+		})
+	}
+}
+
+func TestListCmd_Filter(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantFilter core.ListFilter
+		wantErr    string
+	}{
+		{
+			name:       "no filter",
+			args:       []string{"list"},
+			wantFilter: core.ListFilter{},
+		},
+		{
+			name:       "dirty and locked",
+			args:       []string{"list", "--dirty", "--locked"},
+			wantFilter: core.ListFilter{Dirty: true, Locked: true},
+		},
+		{
+			name:       "merged into main worktree branch",
+			args:       []string{"list", "--merged"},
+			wantFilter: core.ListFilter{Merged: true},
+		},
+		{
+			name:       "merged into given branch",
+			args:       []string{"list", "--merged=release/2.4", "--branch-glob", "feat/*"},
+			wantFilter: core.ListFilter{Merged: true, MergedInto: "release/2.4", BranchGlob: "feat/*"},
+		},
+		{
+			name:    "empty merged value",
+			args:    []string{"list", "--merged="},
+			wantErr: "--merged value cannot be empty",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mock := &mockListCommander{}
+			cmd := NewRootCmd(WithListCommander(mock))
+			cmd.SetOut(&bytes.Buffer{})
+			cmd.SetErr(&bytes.Buffer{})
+			cmd.SetArgs(tt.args)
+
+			err := cmd.Execute()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if mock.calledOpts.Filter != tt.wantFilter {
+				t.Errorf("Filter = %+v, want %+v", mock.calledOpts.Filter, tt.wantFilter)
+			}
+		})
+	}
+}
+
+// mockGrepCommander is a test double for GrepCommander interface.
+type mockGrepCommander struct {
+	result     core.GrepResult
+	err        error
+	calledOpts core.GrepOptions
+}
+
+func (m *mockGrepCommander) Run(ctx context.Context, opts core.GrepOptions) (core.GrepResult, error) {
+	m.calledOpts = opts
+	return m.result, m.err
+}
+
+func TestGrepCmd(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		args       []string
+		result     core.GrepResult
+		wantOpts   core.GrepOptions
+		wantStdout string
+		wantStderr string
+		wantErr    bool
+	}{
+		{
+			name: "pattern only",
+			args: []string{"grep", "TODO"},
+			result: core.GrepResult{Matches: []core.GrepMatch{
+				{Branch: "feat/a", File: "a.go", Line: 3, Text: "// TODO"},
+			}},
+			wantOpts:   core.GrepOptions{Pattern: "TODO"},
+			wantStdout: "feat/a:a.go:3:// TODO\n",
+		},
+		{
+			name: "flags and pathspecs",
+			args: []string{"grep", "-i", "-F", "-w", "-l", "--dirty", "--branch-glob", "feat/*", "todo", "--", "*.go", "docs"},
+			wantOpts: core.GrepOptions{
+				Pattern:      "todo",
+				Pathspecs:    []string{"*.go", "docs"},
+				IgnoreCase:   true,
+				FixedStrings: true,
+				WordRegexp:   true,
+				FilesOnly:    true,
+				Filter:       core.ListFilter{Dirty: true, BranchGlob: "feat/*"},
+			},
+		},
+		{
+			name: "failures are warnings",
+			args: []string{"grep", "x"},
+			result: core.GrepResult{Failures: []core.GrepFailure{
+				{WorktreePath: "/repo/feat-b", Err: errors.New("git grep: boom")},
+			}},
+			wantOpts:   core.GrepOptions{Pattern: "x"},
+			wantStderr: "warning: failed to search /repo/feat-b: git grep: boom\n",
+		},
+		{
+			name:    "missing pattern",
+			args:    []string{"grep"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mock := &mockGrepCommander{result: tt.result}
+			cmd := NewRootCmd(WithGrepCommander(mock))
+
+			stdout := &bytes.Buffer{}
+			stderr := &bytes.Buffer{}
+			cmd.SetOut(stdout)
+			cmd.SetErr(stderr)
+			cmd.SetArgs(tt.args)
+
+			err := cmd.Execute()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got, want := mock.calledOpts, tt.wantOpts
+			if !slices.Equal(got.Pathspecs, want.Pathspecs) {
+				t.Errorf("Pathspecs = %q, want %q", got.Pathspecs, want.Pathspecs)
+			}
+			got.Pathspecs, want.Pathspecs = nil, nil
+			if fmt.Sprintf("%+v", got) != fmt.Sprintf("%+v", want) {
+				t.Errorf("opts = %+v, want %+v", got, want)
+			}
+			if stdout.String() != tt.wantStdout {
+				t.Errorf("stdout = %q, want %q", stdout.String(), tt.wantStdout)
+			}
+			if stderr.String() != tt.wantStderr {
+				t.Errorf("stderr = %q, want %q", stderr.String(), tt.wantStderr)
+			}
+		})
+	}
+}
+
+func TestListCmd_SizeFlags(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		args     []string
+		wantOpts core.ListOptions
+	}{
+		{
+			name:     "size flag",
+			args:     []string{"list", "--size"},
+			wantOpts: core.ListOptions{Size: true},
+		},
+		{
+			name:     "sort and refresh",
+			args:     []string{"list", "--sort", "size", "--refresh"},
+			wantOpts: core.ListOptions{Sort: core.ListSortSize, Refresh: true},
+		},
+		{
+			name:     "tree checks for changes",
+			args:     []string{"list", "--tree"},
+			wantOpts: core.ListOptions{CheckDirty: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mock := &mockListCommander{}
+
+			cmd := NewRootCmd(WithListCommander(mock))
+			cmd.SetOut(&bytes.Buffer{})
+			cmd.SetErr(&bytes.Buffer{})
+			cmd.SetArgs(tt.args)
+
+			if err := cmd.Execute(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if mock.calledOpts != tt.wantOpts {
+				t.Errorf("opts = %+v, want %+v", mock.calledOpts, tt.wantOpts)
+			}
+		})
+	}
+}
+
+// mockAuditCommander is a test double for AuditCommander interface.
+type mockAuditCommander struct {
+	result     core.AuditResult
+	err        error
+	calledOpts core.AuditOptions
+}
+
+func (m *mockAuditCommander) Run(ctx context.Context, opts core.AuditOptions) (core.AuditResult, error) {
+	m.calledOpts = opts
+	return m.result, m.err
+}
+
+func TestAuditCmd(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		args       []string
+		result     core.AuditResult
+		err        error
+		wantOpts   core.AuditOptions
+		wantSince  bool
+		wantStdout string
+		wantStderr string
+		wantErr    string
+	}{
+		{
+			name:       "no filters",
+			args:       []string{"audit"},
+			wantStdout: "no audit entries\n",
+		},
+		{
+			name:       "branch and limit passed through",
+			args:       []string{"audit", "--branch", "feat/*", "-n", "5"},
+			wantOpts:   core.AuditOptions{Branch: "feat/*", Limit: 5},
+			wantStdout: "no audit entries\n",
+		},
+		{
+			name:       "since parsed",
+			args:       []string{"audit", "--since", "7d"},
+			wantSince:  true,
+			wantStdout: "no audit entries\n",
+		},
+		{
+			name:       "malformed lines warned on stderr",
+			args:       []string{"audit"},
+			result:     core.AuditResult{Malformed: 2},
+			wantStdout: "no audit entries\n",
+			wantStderr: "warning: skipped 2 malformed audit log line(s)\n",
+		},
+		{
+			name:    "invalid since",
+			args:    []string{"audit", "--since", "yesterday"},
+			wantErr: "invalid --since value",
+		},
+		{
+			name:    "negative limit",
+			args:    []string{"audit", "--limit", "-1"},
+			wantErr: "--limit must be non-negative",
+		},
+		{
+			name:    "error from commander",
+			args:    []string{"audit"},
+			err:     errors.New("git error"),
+			wantErr: "git error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mock := &mockAuditCommander{result: tt.result, err: tt.err}
+
+			cmd := NewRootCmd(WithAuditCommander(mock))
+
+			stdout := &bytes.Buffer{}
+			stderr := &bytes.Buffer{}
+
+			cmd.SetOut(stdout)
+			cmd.SetErr(stderr)
+			cmd.SetArgs(tt.args)
+
+			err := cmd.Execute()
+
+			if tt.wantErr != "" {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				if !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("error = %q, want to contain %q", err.Error(), tt.wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if mock.calledOpts.Branch != tt.wantOpts.Branch {
+				t.Errorf("Branch = %q, want %q", mock.calledOpts.Branch, tt.wantOpts.Branch)
+			}
+			if mock.calledOpts.Limit != tt.wantOpts.Limit {
+				t.Errorf("Limit = %d, want %d", mock.calledOpts.Limit, tt.wantOpts.Limit)
+			}
+			if mock.calledOpts.Since.IsZero() == tt.wantSince {
+				t.Errorf("Since = %v, want set = %v", mock.calledOpts.Since, tt.wantSince)
+			}
+			if stdout.String() != tt.wantStdout {
+				t.Errorf("stdout = %q, want %q", stdout.String(), tt.wantStdout)
+			}
+			if stderr.String() != tt.wantStderr {
+				t.Errorf("stderr = %q, want %q", stderr.String(), tt.wantStderr)
+			}
+		})
+	}
+}
+
+type mockOpenCommander struct {
+	result     core.OpenResult
+	err        error
+	calledName string
+	calledOpts core.OpenOptions
+}
+
+func (m *mockOpenCommander) Run(ctx context.Context, name string, opts core.OpenOptions) (core.OpenResult, error) {
+	m.calledName = name
+	m.calledOpts = opts
+	return m.result, m.err
+}
+
+func TestOpenCmd(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		args       []string
+		result     core.OpenResult
+		err        error
+		wantOpts   core.OpenOptions
+		wantStdout string
+		wantErr    string
+	}{
+		{
+			name:       "existing worktree",
+			args:       []string{"open", "feat/a"},
+			result:     core.OpenResult{Branch: "feat/a", WorktreePath: "/wt/feat/a"},
+			wantStdout: "twig open: feat/a (/wt/feat/a)\n",
+		},
+		{
+			name:     "add and no-prefix flags",
+			args:     []string{"open", "--add", "--no-prefix", "feat/a"},
+			result:   core.OpenResult{Branch: "feat/a", WorktreePath: "/wt/feat/a", Added: &core.AddResult{Branch: "feat/a", WorktreePath: "/wt/feat/a"}},
+			wantOpts: core.OpenOptions{Add: true, NoPrefix: true},
+			wantStdout: "twig add: feat/a (0 symlinks)\n" +
+				"twig open: feat/a (/wt/feat/a)\n",
+		},
+		{
+			name:    "error from commander",
+			args:    []string{"open", "feat/a"},
+			err:     errors.New("open_command is not configured"),
+			wantErr: "open_command is not configured",
+		},
+		{
+			name:    "requires name",
+			args:    []string{"open"},
+			wantErr: "accepts 1 arg(s)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mock := &mockOpenCommander{result: tt.result, err: tt.err}
+			cmd := NewRootCmd(WithOpenCommander(mock))
+
+			stdout := &bytes.Buffer{}
+			cmd.SetOut(stdout)
+			cmd.SetErr(&bytes.Buffer{})
+			cmd.SetArgs(tt.args)
+
+			err := cmd.Execute()
+
+			if tt.wantErr != "" {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				if !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("error = %q, want to contain %q", err.Error(), tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if mock.calledName != "feat/a" {
+				t.Errorf("called with %q, want %q", mock.calledName, "feat/a")
+			}
+			if mock.calledOpts != tt.wantOpts {
+				t.Errorf("opts = %+v, want %+v", mock.calledOpts, tt.wantOpts)
+			}
+			if stdout.String() != tt.wantStdout {
+				t.Errorf("stdout = %q, want %q", stdout.String(), tt.wantStdout)
+			}
+		})
+	}
+}
+
+type mockPathCommander struct {
+	root       core.PathResult
+	dest       core.PathResult
+	path       core.PathResult
+	err        error
+	calledName string
+	calledOpts core.PathOptions
+}
+
+func (m *mockPathCommander) Root(ctx context.Context) (core.PathResult, error) {
+	return m.root, m.err
+}
+
+func (m *mockPathCommander) Dest() (core.PathResult, error) {
+	return m.dest, m.err
+}
+
+func (m *mockPathCommander) Run(ctx context.Context, name string, opts core.PathOptions) (core.PathResult, error) {
+	m.calledName = name
+	m.calledOpts = opts
+	return m.path, m.err
+}
+
+func TestRootAndPathCmd(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		args       []string
+		err        error
+		wantOpts   core.PathOptions
+		wantStdout string
+		wantErr    string
+	}{
+		{
+			name:       "root",
+			args:       []string{"root"},
+			wantStdout: "/repo/main\n",
+		},
+		{
+			name:       "root dest",
+			args:       []string{"root", "--dest"},
+			wantStdout: "/repo/main-worktree\n",
+		},
+		{
+			name:       "path",
+			args:       []string{"path", "--no-prefix", "feat/a"},
+			wantOpts:   core.PathOptions{NoPrefix: true},
+			wantStdout: "/repo/main-worktree/feat/a\n",
+		},
+		{
+			name:    "path not found",
+			args:    []string{"path", "feat/a"},
+			err:     errors.New(`branch "feat/a" is not checked out in any worktree`),
+			wantErr: "is not checked out in any worktree",
+		},
+		{
+			name:    "path requires name",
+			args:    []string{"path"},
+			wantErr: "accepts 1 arg(s)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mock := &mockPathCommander{
+				root: core.PathResult{Branch: "main", Path: "/repo/main"},
+				dest: core.PathResult{Path: "/repo/main-worktree"},
+				path: core.PathResult{Branch: "feat/a", Path: "/repo/main-worktree/feat/a"},
+				err:  tt.err,
+			}
+			cmd := NewRootCmd(WithPathCommander(mock))
+
+			stdout := &bytes.Buffer{}
+			cmd.SetOut(stdout)
+			cmd.SetErr(&bytes.Buffer{})
+			cmd.SetArgs(tt.args)
+
+			err := cmd.Execute()
+
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want to contain %q", err, tt.wantErr)
+				}
+				if stdout.Len() != 0 {
+					t.Errorf("stdout = %q, want empty", stdout.String())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if mock.calledOpts != tt.wantOpts {
+				t.Errorf("opts = %+v, want %+v", mock.calledOpts, tt.wantOpts)
+			}
+			if stdout.String() != tt.wantStdout {
+				t.Errorf("stdout = %q, want %q", stdout.String(), tt.wantStdout)
+			}
+		})
+	}
+}
+
+type mockRenameCommander struct {
+	result     core.RenameResult
+	err        error
+	calledOld  string
+	calledNew  string
+	calledOpts core.RenameOptions
+}
+
+func (m *mockRenameCommander) Run(ctx context.Context, oldName, newName, cwd string, opts core.RenameOptions) (core.RenameResult, error) {
+	m.calledOld = oldName
+	m.calledNew = newName
+	m.calledOpts = opts
+	return m.result, m.err
+}
+
+func TestRenameCmd(t *testing.T) {
+	t.Parallel()
+
+	result := core.RenameResult{
+		OldBranch: "feat/a",
+		NewBranch: "feat/b",
+		OldPath:   "/wt/feat/a",
+		NewPath:   "/wt/feat/b",
+	}
+
+	tests := []struct {
+		name       string
+		args       []string
+		result     core.RenameResult
+		err        error
+		wantOpts   core.RenameOptions
+		wantStdout string
+		wantErr    string
+	}{
+		{
+			name:       "renames",
+			args:       []string{"rename", "feat/a", "feat/b"},
+			result:     result,
+			wantStdout: "twig rename: feat/a -> feat/b (/wt/feat/b)\n",
+		},
+		{
+			name:     "verbose and no-prefix",
+			args:     []string{"rename", "-v", "--no-prefix", "feat/a", "feat/b"},
+			result:   result,
+			wantOpts: core.RenameOptions{NoPrefix: true},
+			wantStdout: "Renamed branch: feat/a -> feat/b\n" +
+				"Moved worktree: /wt/feat/a -> /wt/feat/b\n" +
+				"twig rename: feat/a -> feat/b (/wt/feat/b)\n",
+		},
+		{
+			name:    "error from commander",
+			args:    []string{"rename", "feat/a", "feat/b"},
+			err:     errors.New("branch feat/b already exists"),
+			wantErr: "branch feat/b already exists",
+		},
+		{
+			name:    "requires two names",
+			args:    []string{"rename", "feat/a"},
+			wantErr: "accepts 2 arg(s)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mock := &mockRenameCommander{result: tt.result, err: tt.err}
+			cmd := NewRootCmd(WithRenameCommander(mock))
+
+			stdout := &bytes.Buffer{}
+			cmd.SetOut(stdout)
+			cmd.SetErr(&bytes.Buffer{})
+			cmd.SetArgs(tt.args)
+
+			err := cmd.Execute()
+
+			if tt.wantErr != "" {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				if !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("error = %q, want to contain %q", err.Error(), tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if mock.calledOld != "feat/a" || mock.calledNew != "feat/b" {
+				t.Errorf("called with %q, %q, want feat/a, feat/b", mock.calledOld, mock.calledNew)
+			}
+			if mock.calledOpts != tt.wantOpts {
+				t.Errorf("opts = %+v, want %+v", mock.calledOpts, tt.wantOpts)
+			}
+			if stdout.String() != tt.wantStdout {
+				t.Errorf("stdout = %q, want %q", stdout.String(), tt.wantStdout)
+			}
+		})
+	}
+}
+
+type mockDoctorCommander struct {
+	result     core.DoctorResult
+	err        error
+	calledOpts core.DoctorOptions
+}
+
+func (m *mockDoctorCommander) Run(ctx context.Context, opts core.DoctorOptions) (core.DoctorResult, error) {
+	m.calledOpts = opts
+	return m.result, m.err
+}
+
+func TestDoctorCmd(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		args       []string
+		result     core.DoctorResult
+		err        error
+		wantPrune  bool
+		wantStdout string
+		wantErr    string
+	}{
+		{
+			name:       "no problems",
+			result:     core.DoctorResult{Checks: []core.DoctorCheckResult{{Name: core.DoctorCheckStash}}},
+			wantStdout: "stash: ok\n",
+		},
+		{
+			name: "prune passed through",
+			args: []string{"--prune"},
+			result: core.DoctorResult{Checks: []core.DoctorCheckResult{{
+				Name:   core.DoctorCheckScratch,
+				Pruned: []string{"/repo/.git/twig/tmp/carry-123"},
+			}}},
+			wantPrune:  true,
+			wantStdout: "scratch: ok (pruned 1)\n  removed /repo/.git/twig/tmp/carry-123\n",
+		},
+		{
+			name: "problems exit with error",
+			result: core.DoctorResult{Checks: []core.DoctorCheckResult{{
+				Name:   core.DoctorCheckStash,
+				Issues: []core.DoctorIssue{{Summary: "stranded stash stash@{0} (abc1234): On main: twig carry"}},
+			}}},
+			wantStdout: "stash: 1 issue(s)\n  stranded stash stash@{0} (abc1234): On main: twig carry\n",
+			wantErr:    "found 1 problem(s)",
+		},
+		{
+			name:    "error from commander",
+			err:     errors.New("git error"),
+			wantErr: "git error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mock := &mockDoctorCommander{result: tt.result, err: tt.err}
+			cmd := NewRootCmd(WithDoctorCommander(mock))
+
+			stdout := &bytes.Buffer{}
+			cmd.SetOut(stdout)
+			cmd.SetErr(&bytes.Buffer{})
+			cmd.SetArgs(append([]string{"doctor"}, tt.args...))
+
+			err := cmd.Execute()
+
+			if tt.wantErr != "" {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				if !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("error = %q, want to contain %q", err.Error(), tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if mock.calledOpts.Prune != tt.wantPrune {
+				t.Errorf("Prune = %v, want %v", mock.calledOpts.Prune, tt.wantPrune)
+			}
+			if stdout.String() != tt.wantStdout {
+				t.Errorf("stdout = %q, want %q", stdout.String(), tt.wantStdout)
+			}
+		})
+	}
+}
+
+type mockPromptInfoCommander struct {
+	info       core.PromptInfo
+	err        error
+	calledOpts core.PromptInfoOptions
+}
+
+func (m *mockPromptInfoCommander) Run(ctx context.Context, cwd string, opts core.PromptInfoOptions) (core.PromptInfo, error) {
+	m.calledOpts = opts
+	return m.info, m.err
+}
+
+func TestPromptInfoCmd(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		args        []string
+		info        core.PromptInfo
+		err         error
+		wantRefresh bool
+		wantDirty   bool
+		wantStdout  string
+		wantErr     string
+	}{
+		{
+			name:       "prints segment",
+			args:       []string{"prompt-info"},
+			info:       core.PromptInfo{InRepo: true, Branch: "main", Worktrees: 2, Cleanable: 1},
+			wantStdout: "main [2 wt, 1 cleanable]\n",
+		},
+		{
+			name:        "refresh passed through",
+			args:        []string{"prompt-info", "--refresh"},
+			info:        core.PromptInfo{InRepo: true, Branch: "main", Worktrees: 1},
+			wantRefresh: true,
+			wantStdout:  "main [1 wt]\n",
+		},
+		{
+			name:       "silent outside repository",
+			args:       []string{"prompt-info"},
+			wantStdout: "",
+		},
+		{
+			name:    "error from commander",
+			args:    []string{"prompt-info"},
+			err:     errors.New("git error"),
+			wantErr: "git error",
+		},
+		{
+			name:       "prompt prints compact summary",
+			args:       []string{"prompt"},
+			info:       core.PromptInfo{InRepo: true, Branch: "feat/a", Worktrees: 3, Cleanable: 2, Dirty: true},
+			wantDirty:  true,
+			wantStdout: "feat/a* [2 cleanable]\n",
+		},
+		{
+			name:        "prompt refresh passed through",
+			args:        []string{"prompt", "--refresh"},
+			info:        core.PromptInfo{InRepo: true, Branch: "main", Worktrees: 1},
+			wantRefresh: true,
+			wantDirty:   true,
+			wantStdout:  "main\n",
+		},
+		{
+			name:       "prompt silent outside repository",
+			args:       []string{"prompt"},
+			wantDirty:  true,
+			wantStdout: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mock := &mockPromptInfoCommander{info: tt.info, err: tt.err}
+
+			cmd := NewRootCmd(WithPromptInfoCommander(mock))
+
+			stdout := &bytes.Buffer{}
+			cmd.SetOut(stdout)
+			cmd.SetErr(&bytes.Buffer{})
+			cmd.SetArgs(tt.args)
+
+			err := cmd.Execute()
+
+			if tt.wantErr != "" {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				if !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("error = %q, want to contain %q", err.Error(), tt.wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if mock.calledOpts.Refresh != tt.wantRefresh {
+				t.Errorf("Refresh = %v, want %v", mock.calledOpts.Refresh, tt.wantRefresh)
+			}
+			if mock.calledOpts.Dirty != tt.wantDirty {
+				t.Errorf("Dirty = %v, want %v", mock.calledOpts.Dirty, tt.wantDirty)
+			}
+			if stdout.String() != tt.wantStdout {
+				t.Errorf("stdout = %q, want %q", stdout.String(), tt.wantStdout)
+			}
+		})
+	}
+}
+
+func TestPromptSegmentCmd(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantStdout string
+		wantErr    string
+	}{
+		{
+			name:       "zsh",
+			args:       []string{"prompt-segment", "--shell", "zsh"},
+			wantStdout: "add-zsh-hook precmd",
+		},
+		{
+			name:       "fish",
+			args:       []string{"prompt-segment", "--shell", "fish"},
+			wantStdout: "--on-event fish_prompt",
+		},
+		{
+			name:    "unsupported shell",
+			args:    []string{"prompt-segment", "--shell", "bash"},
+			wantErr: "unsupported shell",
+		},
+		{
+			name:    "shell required",
+			args:    []string{"prompt-segment"},
+			wantErr: "shell",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cmd := NewRootCmd()
+
+			stdout := &bytes.Buffer{}
+			cmd.SetOut(stdout)
+			cmd.SetErr(&bytes.Buffer{})
+			cmd.SetArgs(tt.args)
+
+			err := cmd.Execute()
+
+			if tt.wantErr != "" {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				if !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("error = %q, want to contain %q", err.Error(), tt.wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !strings.Contains(stdout.String(), tt.wantStdout) {
+				t.Errorf("stdout does not contain %q:\n%s", tt.wantStdout, stdout.String())
+			}
+		})
+	}
+}
+
+// mockRemoveCommander implements RemoveCommander for testing.
+// Thread-safe for parallel execution.
+type mockRemoveCommander struct {
+	mu      sync.Mutex
+	calls   []removeCall
+	results map[string]removeResult // keyed by branch name
+}
+
+type removeCall struct {
+	branch string
+	cwd    string
+	opts   core.RemoveOptions
+}
+
+type removeResult struct {
+	wt  core.RemovedWorktree
+	err error
+}
+
+func (m *mockRemoveCommander) Run(ctx context.Context, branch, cwd string, opts core.RemoveOptions) (core.RemovedWorktree, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.calls = append(m.calls, removeCall{branch, cwd, opts})
+	if m.results != nil {
+		if r, ok := m.results[branch]; ok {
+			return r.wt, r.err
+		}
+	}
+	return core.RemovedWorktree{Branch: branch, WorktreePath: "/test/" + branch}, nil
+}
+
+// mockInitCommander implements InitCommander for testing.
+type mockInitCommander struct {
+	result     core.InitResult
+	err        error
+	calledDir  string
+	calledOpts core.InitOptions
+}
+
+func (m *mockInitCommander) Run(ctx context.Context, dir string, opts core.InitOptions) (core.InitResult, error) {
+	m.calledDir = dir
+	m.calledOpts = opts
+	return m.result, m.err
+}
+
+func TestAddCmd(t *testing.T) {
+	t.Parallel()
+
+	t.Run("BasicExecution", func(t *testing.T) {
+		t.Parallel()
+
+		_, mainDir := testutil.SetupTestRepo(t)
+		twigDir := filepath.Join(mainDir, ".twig")
+		if err := os.MkdirAll(twigDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		settingsContent := fmt.Sprintf(`worktree_destination_base_dir = %q
+`, filepath.Dir(mainDir))
+		if err := os.WriteFile(filepath.Join(twigDir, "settings.toml"), []byte(settingsContent), 0644); err != nil {
+			t.Fatal(err)
+		}
+		testutil.RunGit(t, mainDir, "add", ".twig")
+		testutil.RunGit(t, mainDir, "commit", "-m", "add twig settings")
+
+		mock := &mockAddCommander{
+			result: core.AddResult{
+				Branch:       "feat/test",
+				WorktreePath: "/path/to/worktree",
+				Symlinks:     []core.SymlinkResult{},
+			},
+		}
+
+		cmd := NewRootCmd(WithAddCommander(mock))
+
+		var stdout, stderr bytes.Buffer
+		cmd.SetOut(&stdout)
+		cmd.SetErr(&stderr)
+		cmd.SetArgs([]string{"-C", mainDir, "add", "feat/test"})
+
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if mock.calledName != "feat/test" {
+			t.Errorf("calledName = %q, want %q", mock.calledName, "feat/test")
+		}
+	})
+
+	t.Run("SyncFlag", func(t *testing.T) {
+		t.Parallel()
+
+		_, mainDir := testutil.SetupTestRepo(t)
+		twigDir := filepath.Join(mainDir, ".twig")
+		if err := os.MkdirAll(twigDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		settingsContent := fmt.Sprintf(`worktree_destination_base_dir = %q
+`, filepath.Dir(mainDir))
+		if err := os.WriteFile(filepath.Join(twigDir, "settings.toml"), []byte(settingsContent), 0644); err != nil {
+			t.Fatal(err)
+		}
+		testutil.RunGit(t, mainDir, "add", ".twig")
+		testutil.RunGit(t, mainDir, "commit", "-m", "add twig settings")
+
+		mock := &mockAddCommander{
+			result: core.AddResult{
+				Branch:        "feat/sync",
+				WorktreePath:  "/path/to/worktree",
+				ChangesSynced: true,
+			},
+		}
+
+		cmd := NewRootCmd(WithAddCommander(mock))
+
+		var stdout, stderr bytes.Buffer
+		cmd.SetOut(&stdout)
+		cmd.SetErr(&stderr)
+		cmd.SetArgs([]string{"-C", mainDir, "add", "--sync", "feat/sync"})
+
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if mock.calledName != "feat/sync" {
+			t.Errorf("calledName = %q, want %q", mock.calledName, "feat/sync")
+		}
+	})
+
+	t.Run("QuietFlag", func(t *testing.T) {
+		t.Parallel()
+
+		_, mainDir := testutil.SetupTestRepo(t)
+		twigDir := filepath.Join(mainDir, ".twig")
+		if err := os.MkdirAll(twigDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		settingsContent := fmt.Sprintf(`worktree_destination_base_dir = %q
+`, filepath.Dir(mainDir))
+		if err := os.WriteFile(filepath.Join(twigDir, "settings.toml"), []byte(settingsContent), 0644); err != nil {
+			t.Fatal(err)
+		}
+		testutil.RunGit(t, mainDir, "add", ".twig")
+		testutil.RunGit(t, mainDir, "commit", "-m", "add twig settings")
+
+		mock := &mockAddCommander{
+			result: core.AddResult{
+				Branch:       "feat/quiet",
+				WorktreePath: "/path/to/worktree",
+			},
+		}
+
+		cmd := NewRootCmd(WithAddCommander(mock))
+
+		var stdout, stderr bytes.Buffer
+		cmd.SetOut(&stdout)
+		cmd.SetErr(&stderr)
+		cmd.SetArgs([]string{"-C", mainDir, "add", "--quiet", "feat/quiet"})
+
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if !strings.Contains(stdout.String(), "/path/to/worktree") {
+			t.Errorf("stdout = %q, want to contain worktree path", stdout.String())
+		}
+		if strings.Contains(stdout.String(), "twig add:") {
+			t.Errorf("stdout = %q, should not contain 'twig add:'", stdout.String())
+		}
+	})
+
+	t.Run("LockFlags", func(t *testing.T) {
+		t.Parallel()
+
+		_, mainDir := testutil.SetupTestRepo(t)
+		twigDir := filepath.Join(mainDir, ".twig")
+		if err := os.MkdirAll(twigDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		settingsContent := fmt.Sprintf(`worktree_destination_base_dir = %q
+`, filepath.Dir(mainDir))
+		if err := os.WriteFile(filepath.Join(twigDir, "settings.toml"), []byte(settingsContent), 0644); err != nil {
+			t.Fatal(err)
+		}
+		testutil.RunGit(t, mainDir, "add", ".twig")
+		testutil.RunGit(t, mainDir, "commit", "-m", "add twig settings")
+
+		mock := &mockAddCommander{
+			result: core.AddResult{
+				Branch:       "feat/lock",
+				WorktreePath: "/path/to/worktree",
+			},
+		}
+
+		cmd := NewRootCmd(WithAddCommander(mock))
+
+		var stdout, stderr bytes.Buffer
+		cmd.SetOut(&stdout)
+		cmd.SetErr(&stderr)
+		cmd.SetArgs([]string{"-C", mainDir, "add", "--lock", "--reason", "USB work", "feat/lock"})
+
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if mock.calledName != "feat/lock" {
+			t.Errorf("calledName = %q, want %q", mock.calledName, "feat/lock")
+		}
+	})
+
+	t.Run("ReasonWithoutLock", func(t *testing.T) {
+		t.Parallel()
+
+		_, mainDir := testutil.SetupTestRepo(t)
+		twigDir := filepath.Join(mainDir, ".twig")
+		if err := os.MkdirAll(twigDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		settingsContent := fmt.Sprintf(`worktree_destination_base_dir = %q
+`, filepath.Dir(mainDir))
+		if err := os.WriteFile(filepath.Join(twigDir, "settings.toml"), []byte(settingsContent), 0644); err != nil {
+			t.Fatal(err)
+		}
+		testutil.RunGit(t, mainDir, "add", ".twig")
+		testutil.RunGit(t, mainDir, "commit", "-m", "add twig settings")
+
+		mock := &mockAddCommander{
+			result: core.AddResult{
+				Branch:       "feat/error",
+				WorktreePath: "/path/to/worktree",
+			},
+		}
+
+		cmd := NewRootCmd(WithAddCommander(mock))
+
+		var stdout, stderr bytes.Buffer
+		cmd.SetOut(&stdout)
+		cmd.SetErr(&stderr)
+		cmd.SetArgs([]string{"-C", mainDir, "add", "--reason", "some reason", "feat/error"})
+
+		err := cmd.Execute()
+		if err == nil {
+			t.Fatal("expected error, got nil")
+		}
+		if !strings.Contains(err.Error(), "--reason requires --lock") {
+			t.Errorf("error = %q, want to contain %q", err.Error(), "--reason requires --lock")
+		}
+	})
+
+	t.Run("ErrorFromCommand", func(t *testing.T) {
+		t.Parallel()
+
+		_, mainDir := testutil.SetupTestRepo(t)
+		twigDir := filepath.Join(mainDir, ".twig")
+		if err := os.MkdirAll(twigDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		settingsContent := fmt.Sprintf(`worktree_destination_base_dir = %q
+`, filepath.Dir(mainDir))
+		if err := os.WriteFile(filepath.Join(twigDir, "settings.toml"), []byte(settingsContent), 0644); err != nil {
+			t.Fatal(err)
+		}
+		testutil.RunGit(t, mainDir, "add", ".twig")
+		testutil.RunGit(t, mainDir, "commit", "-m", "add twig settings")
+
+		mock := &mockAddCommander{
+			result: core.AddResult{
+				Branch:       "feat/fail",
+				WorktreePath: "/path/to/worktree",
+			},
+			err: errors.New("worktree creation failed"),
+		}
+
+		cmd := NewRootCmd(WithAddCommander(mock))
+
+		var stdout, stderr bytes.Buffer
+		cmd.SetOut(&stdout)
+		cmd.SetErr(&stderr)
+		cmd.SetArgs([]string{"-C", mainDir, "add", "feat/fail"})
+
+		err := cmd.Execute()
+		if err == nil {
+			t.Fatal("expected error, got nil")
+		}
+		if !strings.Contains(err.Error(), "worktree creation failed") {
+			t.Errorf("error = %q, want to contain %q", err.Error(), "worktree creation failed")
+		}
+	})
+
+	t.Run("OutputWithSymlinks", func(t *testing.T) {
+		t.Parallel()
+
+		_, mainDir := testutil.SetupTestRepo(t)
+		twigDir := filepath.Join(mainDir, ".twig")
+		if err := os.MkdirAll(twigDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		settingsContent := fmt.Sprintf(`worktree_destination_base_dir = %q
+`, filepath.Dir(mainDir))
+		if err := os.WriteFile(filepath.Join(twigDir, "settings.toml"), []byte(settingsContent), 0644); err != nil {
+			t.Fatal(err)
+		}
+		testutil.RunGit(t, mainDir, "add", ".twig")
+		testutil.RunGit(t, mainDir, "commit", "-m", "add twig settings")
+
+		mock := &mockAddCommander{
+			result: core.AddResult{
+				Branch:       "feat/symlinks",
+				WorktreePath: "/path/to/worktree",
+				Symlinks: []core.SymlinkResult{
+					{Src: "/src/.envrc", Dst: "/dst/.envrc"},
+					{Src: "/src/.tool-versions", Dst: "/dst/.tool-versions"},
+				},
+			},
+		}
+
+		cmd := NewRootCmd(WithAddCommander(mock))
+
+		var stdout, stderr bytes.Buffer
+		cmd.SetOut(&stdout)
+		cmd.SetErr(&stderr)
+		cmd.SetArgs([]string{"-C", mainDir, "add", "feat/symlinks"})
+
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if !strings.Contains(stdout.String(), "2 symlinks") {
+			t.Errorf("stdout = %q, want to contain '2 symlinks'", stdout.String())
+		}
+	})
+
+	t.Run("OutputWithWarnings", func(t *testing.T) {
+		t.Parallel()
+
+		_, mainDir := testutil.SetupTestRepo(t)
+		twigDir := filepath.Join(mainDir, ".twig")
+		if err := os.MkdirAll(twigDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		settingsContent := fmt.Sprintf(`worktree_destination_base_dir = %q
+`, filepath.Dir(mainDir))
+		if err := os.WriteFile(filepath.Join(twigDir, "settings.toml"), []byte(settingsContent), 0644); err != nil {
+			t.Fatal(err)
+		}
+		testutil.RunGit(t, mainDir, "add", ".twig")
+		testutil.RunGit(t, mainDir, "commit", "-m", "add twig settings")
+
+		mock := &mockAddCommander{
+			result: core.AddResult{
+				Branch:       "feat/warn",
+				WorktreePath: "/path/to/worktree",
+				Symlinks: []core.SymlinkResult{
+					{Src: "/src/.envrc", Dst: "/dst/.envrc"},
+					{Skipped: true, Reason: "pattern.txt does not match any files, skipping"},
+				},
+			},
+		}
+
+		cmd := NewRootCmd(WithAddCommander(mock))
+
+		var stdout, stderr bytes.Buffer
+		cmd.SetOut(&stdout)
+		cmd.SetErr(&stderr)
+		cmd.SetArgs([]string{"-C", mainDir, "add", "feat/warn"})
+
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if !strings.Contains(stdout.String(), "1 symlinks") {
+			t.Errorf("stdout = %q, want to contain '1 symlinks'", stdout.String())
+		}
+		if !strings.Contains(stderr.String(), "pattern.txt does not match any files") {
+			t.Errorf("stderr = %q, want to contain warning", stderr.String())
+		}
+	})
+
+	t.Run("CarryFlagWithoutValue", func(t *testing.T) {
+		t.Parallel()
+
+		_, mainDir := testutil.SetupTestRepo(t)
+
+		mock := &mockAddCommander{
+			result: core.AddResult{
+				Branch:       "feat/carry",
+				WorktreePath: "/path/to/worktree",
+			},
+		}
+
+		cmd := NewRootCmd(WithAddCommander(mock))
+
+		var stdout, stderr bytes.Buffer
+		cmd.SetOut(&stdout)
+		cmd.SetErr(&stderr)
+		cmd.SetArgs([]string{"-C", mainDir, "add", "feat/carry", "--carry"})
+
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if mock.calledName != "feat/carry" {
+			t.Errorf("calledName = %q, want %q", mock.calledName, "feat/carry")
+		}
+	})
+
+	t.Run("CarryShortFlagWithoutValue", func(t *testing.T) {
+		t.Parallel()
+
+		_, mainDir := testutil.SetupTestRepo(t)
+
+		mock := &mockAddCommander{
+			result: core.AddResult{
+				Branch:       "feat/carry-short",
+				WorktreePath: "/path/to/worktree",
+			},
+		}
+
+		cmd := NewRootCmd(WithAddCommander(mock))
+
+		var stdout, stderr bytes.Buffer
+		cmd.SetOut(&stdout)
+		cmd.SetErr(&stderr)
+		cmd.SetArgs([]string{"-C", mainDir, "add", "feat/carry-short", "-c"})
+
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if mock.calledName != "feat/carry-short" {
+			t.Errorf("calledName = %q, want %q", mock.calledName, "feat/carry-short")
+		}
+	})
+
+	t.Run("CarrySpaceSeparatedValueNotAllowed", func(t *testing.T) {
+		t.Parallel()
+
+		_, mainDir := testutil.SetupTestRepo(t)
+
+		mock := &mockAddCommander{
+			result: core.AddResult{
+				Branch:       "feat/target",
+				WorktreePath: "/path/to/worktree",
+			},
+		}
+
+		cmd := NewRootCmd(WithAddCommander(mock))
+
+		var stdout, stderr bytes.Buffer
+		cmd.SetOut(&stdout)
+		cmd.SetErr(&stderr)
+		// --carry feat/other uses space-separated syntax which is not allowed
+		// feat/other becomes a second positional argument, causing an error
+		cmd.SetArgs([]string{"-C", mainDir, "add", "feat/target", "--carry", "feat/other"})
+
+		err := cmd.Execute()
+		if err == nil {
+			t.Fatal("expected error for space-separated --carry value, got nil")
+		}
+		if !strings.Contains(err.Error(), "--carry=<branch>") {
+			t.Errorf("error = %q, want to contain %q", err.Error(), "--carry=<branch>")
+		}
+		if mock.calledName != "" {
+			t.Errorf("Run should not be called, got %q", mock.calledName)
+		}
+	})
+
+	t.Run("SyncAndCarryMutuallyExclusive", func(t *testing.T) {
+		t.Parallel()
+
+		_, mainDir := testutil.SetupTestRepo(t)
+		twigDir := filepath.Join(mainDir, ".twig")
+		if err := os.MkdirAll(twigDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		settingsContent := fmt.Sprintf(`worktree_destination_base_dir = %q
+`, filepath.Dir(mainDir))
+		if err := os.WriteFile(filepath.Join(twigDir, "settings.toml"), []byte(settingsContent), 0644); err != nil {
+			t.Fatal(err)
+		}
+		testutil.RunGit(t, mainDir, "add", ".twig")
+		testutil.RunGit(t, mainDir, "commit", "-m", "add twig settings")
+
+		mock := &mockAddCommander{
+			result: core.AddResult{
+				Branch:       "feat/conflict",
+				WorktreePath: "/path/to/worktree",
+			},
+		}
+
+		cmd := NewRootCmd(WithAddCommander(mock))
+
+		var stdout, stderr bytes.Buffer
+		cmd.SetOut(&stdout)
+		cmd.SetErr(&stderr)
+		cmd.SetArgs([]string{"-C", mainDir, "add", "--sync", "--carry=@", "feat/conflict"})
+
+		err := cmd.Execute()
+		if err == nil {
+			t.Fatal("expected error, got nil")
+		}
+		if !strings.Contains(err.Error(), "cannot use --sync and --carry together") {
+			t.Errorf("error = %q, want to contain %q", err.Error(), "cannot use --sync and --carry together")
+		}
+	})
+
+	t.Run("file_requires_carry_or_sync", func(t *testing.T) {
+		t.Parallel()
+
+		_, mainDir := testutil.SetupTestRepo(t, testutil.WithoutSettings())
+
+		cmd := NewRootCmd()
+
+		var stderr bytes.Buffer
+		cmd.SetErr(&stderr)
+		cmd.SetArgs([]string{"-C", mainDir, "add", "--file", "*.go", "feat/test"})
+
+		err := cmd.Execute()
+		if err == nil {
+			t.Fatal("expected error, got nil")
+		}
+		if !strings.Contains(err.Error(), "--file requires --carry or --sync flag") {
+			t.Errorf("error = %q, want to contain %q", err.Error(), "--file requires --carry or --sync flag")
+		}
+	})
+
+	t.Run("file_with_carry", func(t *testing.T) {
+		t.Parallel()
+
+		_, mainDir := testutil.SetupTestRepo(t, testutil.WithoutSettings())
+
+		mock := &mockAddCommander{
+			result: core.AddResult{
+				Branch:       "feat/file-test",
+				WorktreePath: "/path/to/worktree",
+			},
+		}
+
+		cmd := NewRootCmd(WithAddCommander(mock))
+
+		var stdout bytes.Buffer
+		cmd.SetOut(&stdout)
+		cmd.SetArgs([]string{"-C", mainDir, "add", "--carry", "--file", "*.go", "--file", "cmd/**", "feat/file-test"})
+
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("InitSubmodulesFlag", func(t *testing.T) {
+		t.Parallel()
+
+		_, mainDir := testutil.SetupTestRepo(t, testutil.WithoutSettings())
+
+		mock := &mockAddCommander{
+			result: core.AddResult{
+				Branch:       "feat/submodule",
+				WorktreePath: "/path/to/worktree",
+				SubmoduleInit: core.SubmoduleInitResult{
+					Attempted: true,
+					Count:     2,
+				},
+			},
+		}
+
+		cmd := NewRootCmd(WithAddCommander(mock))
+
+		var stdout bytes.Buffer
+		cmd.SetOut(&stdout)
+		cmd.SetArgs([]string{"-C", mainDir, "add", "--init-submodules", "feat/submodule"})
+
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if mock.calledName != "feat/submodule" {
+			t.Errorf("calledName = %q, want %q", mock.calledName, "feat/submodule")
+		}
+		if !strings.Contains(stdout.String(), "2 submodules") {
+			t.Errorf("stdout = %q, want to contain '2 submodules'", stdout.String())
+		}
+	})
+
+	t.Run("SubmoduleReferenceFlag", func(t *testing.T) {
+		t.Parallel()
+
+		_, mainDir := testutil.SetupTestRepo(t, testutil.WithoutSettings())
+
+		mock := &mockAddCommander{
+			result: core.AddResult{
+				Branch:       "feat/submodule-ref",
+				WorktreePath: "/path/to/worktree",
+				SubmoduleInit: core.SubmoduleInitResult{
+					Attempted: true,
+					Count:     2,
+				},
+			},
+		}
+
+		cmd := NewRootCmd(WithAddCommander(mock))
+
+		var stdout bytes.Buffer
+		cmd.SetOut(&stdout)
+		cmd.SetArgs([]string{"-C", mainDir, "add", "--init-submodules", "--submodule-reference", "feat/submodule-ref"})
+
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if mock.calledName != "feat/submodule-ref" {
+			t.Errorf("calledName = %q, want %q", mock.calledName, "feat/submodule-ref")
+		}
+	})
+
+	t.Run("OutputWithSubmoduleReferenceWarning", func(t *testing.T) {
+		t.Parallel()
+
+		_, mainDir := testutil.SetupTestRepo(t, testutil.WithoutSettings())
+
+		mock := &mockAddCommander{
+			result: core.AddResult{
+				Branch:       "feat/submodule-warn",
+				WorktreePath: "/path/to/worktree",
+				SubmoduleInit: core.SubmoduleInitResult{
+					Attempted:             true,
+					Count:                 2,
+					NoReferenceSubmodules: []string{"submod/a", "submod/b"},
+				},
+			},
+		}
+
+		cmd := NewRootCmd(WithAddCommander(mock))
+
+		var stdout, stderr bytes.Buffer
+		cmd.SetOut(&stdout)
+		cmd.SetErr(&stderr)
+		cmd.SetArgs([]string{"-C", mainDir, "add", "--init-submodules", "--submodule-reference", "feat/submodule-warn"})
+
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		// Verify warnings are output for submodules that couldn't use reference
+		if !strings.Contains(stderr.String(), "submod/a: reference not available") {
+			t.Errorf("stderr = %q, want to contain 'submod/a: reference not available'", stderr.String())
+		}
+		if !strings.Contains(stderr.String(), "submod/b: reference not available") {
+			t.Errorf("stderr = %q, want to contain 'submod/b: reference not available'", stderr.String())
+		}
+	})
+}
+
+func TestAddCmd_FromSubdirectory(t *testing.T) {
+	t.Parallel()
+
+	repoDir, mainDir := testutil.SetupTestRepo(t, testutil.Symlinks(".envrc"))
+	testutil.RunGit(t, mainDir, "add", ".twig")
+	testutil.RunGit(t, mainDir, "commit", "-m", "add twig settings")
+
+	// Run from a nested directory of a secondary worktree, which has its
+	// own .envrc to link
+	baseDir := filepath.Join(repoDir, "feat", "base")
+	testutil.RunGit(t, mainDir, "worktree", "add", "-b", "feat/base", baseDir)
+	if err := os.WriteFile(filepath.Join(baseDir, ".envrc"), []byte("export A=1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	nested := filepath.Join(baseDir, "pkg", "api")
+	if err := os.MkdirAll(nested, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := NewRootCmd()
+	var stdout, stderr bytes.Buffer
+	cmd.SetOut(&stdout)
+	cmd.SetErr(&stderr)
+	cmd.SetArgs([]string{"-C", nested, "add", "feat/nested"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v\nstderr: %s", err, stderr.String())
+	}
+
+	link := filepath.Join(repoDir, "feat", "nested", ".envrc")
+	target, err := filepath.EvalSymlinks(link)
+	if err != nil {
+		t.Fatalf("symlink not created: %v\nstderr: %s", err, stderr.String())
+	}
+	if want := filepath.Join(baseDir, ".envrc"); target != want {
+		t.Errorf(".envrc resolves to %s, want %s", target, want)
+	}
+}
+
+func TestAddCmd_MultipleBranches(t *testing.T) {
+	t.Parallel()
+
+	t.Run("AllSucceed", func(t *testing.T) {
+		t.Parallel()
+
+		_, mainDir := testutil.SetupTestRepo(t)
+
+		mock := &mockAddCommander{
+			results: map[string]addResult{
+				"feat/a": {result: core.AddResult{Branch: "feat/a", WorktreePath: "/wt/feat/a"}},
+				"feat/b": {result: core.AddResult{Branch: "feat/b", WorktreePath: "/wt/feat/b"}},
+				"feat/c": {result: core.AddResult{Branch: "feat/c", WorktreePath: "/wt/feat/c"}},
+			},
+		}
+
+		cmd := NewRootCmd(WithAddCommander(mock))
+
+		var stdout, stderr bytes.Buffer
+		cmd.SetOut(&stdout)
+		cmd.SetErr(&stderr)
+		cmd.SetArgs([]string{"-C", mainDir, "add", "feat/a", "feat/b", "feat/c"})
+
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(mock.calls) != 3 {
+			t.Fatalf("expected 3 calls, got %d", len(mock.calls))
+		}
+
+		// Output keeps argument order regardless of completion order
+		want := "twig add: feat/a (0 symlinks)\ntwig add: feat/b (0 symlinks)\ntwig add: feat/c (0 symlinks)\n"
+		if stdout.String() != want {
+			t.Errorf("stdout = %q, want %q", stdout.String(), want)
+		}
+	})
+
+	t.Run("QuietOutputsAllPaths", func(t *testing.T) {
+		t.Parallel()
+
+		_, mainDir := testutil.SetupTestRepo(t)
+
+		mock := &mockAddCommander{
+			results: map[string]addResult{
+				"feat/a": {result: core.AddResult{Branch: "feat/a", WorktreePath: "/wt/feat/a"}},
+				"feat/b": {result: core.AddResult{Branch: "feat/b", WorktreePath: "/wt/feat/b"}},
+			},
+		}
+
+		cmd := NewRootCmd(WithAddCommander(mock))
+
+		var stdout, stderr bytes.Buffer
+		cmd.SetOut(&stdout)
+		cmd.SetErr(&stderr)
+		cmd.SetArgs([]string{"-C", mainDir, "add", "-q", "feat/a", "feat/b"})
+
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if want := "/wt/feat/a\n/wt/feat/b\n"; stdout.String() != want {
+			t.Errorf("stdout = %q, want %q", stdout.String(), want)
+		}
+	})
+
+	t.Run("PartialFailureContinues", func(t *testing.T) {
+		t.Parallel()
+
+		_, mainDir := testutil.SetupTestRepo(t)
+
+		mock := &mockAddCommander{
+			results: map[string]addResult{
+				"feat/a": {result: core.AddResult{Branch: "feat/a", WorktreePath: "/wt/feat/a"}},
+				"feat/b": {err: errors.New("directory already exists: /wt/feat/b")},
+			},
+		}
+
+		cmd := NewRootCmd(WithAddCommander(mock))
+
+		var stdout, stderr bytes.Buffer
+		cmd.SetOut(&stdout)
+		cmd.SetErr(&stderr)
+		cmd.SetArgs([]string{"-C", mainDir, "add", "feat/a", "feat/b"})
+
+		err := cmd.Execute()
+		if err == nil {
+			t.Fatal("expected error, got nil")
+		}
+		if !strings.Contains(err.Error(), "failed to add 1 branch(es)") {
+			t.Errorf("error = %q, want to contain %q", err.Error(), "failed to add 1 branch(es)")
+		}
+		if want := "twig add: feat/a (0 symlinks)\n"; stdout.String() != want {
+			t.Errorf("stdout = %q, want %q", stdout.String(), want)
+		}
+		if !strings.Contains(stderr.String(), "error: feat/b: directory already exists") {
+			t.Errorf("stderr = %q, want error for feat/b", stderr.String())
+		}
+	})
+
+	t.Run("SyncRequiresSingleBranch", func(t *testing.T) {
+		t.Parallel()
+
+		_, mainDir := testutil.SetupTestRepo(t)
+
+		mock := &mockAddCommander{}
+
+		cmd := NewRootCmd(WithAddCommander(mock))
+
+		var stdout, stderr bytes.Buffer
+		cmd.SetOut(&stdout)
+		cmd.SetErr(&stderr)
+		cmd.SetArgs([]string{"-C", mainDir, "add", "--sync", "feat/a", "feat/b"})
+
+		err := cmd.Execute()
+		if err == nil {
+			t.Fatal("expected error, got nil")
+		}
+		if !strings.Contains(err.Error(), "require a single branch") {
+			t.Errorf("error = %q, want to contain %q", err.Error(), "require a single branch")
+		}
+		if len(mock.calls) != 0 {
+			t.Errorf("expected no calls, got %v", mock.calls)
+		}
+	})
+}
+
+func TestAddCmd_Batch(t *testing.T) {
+	t.Parallel()
+
+	t.Run("StdinWithSummary", func(t *testing.T) {
+		t.Parallel()
+
+		_, mainDir := testutil.SetupTestRepo(t)
+
+		mock := &mockAddCommander{
+			results: map[string]addResult{
+				"feat/a": {result: core.AddResult{Branch: "feat/a", WorktreePath: "/wt/feat/a"}},
+				"feat/b": {err: errors.New("branch feat/b is already checked out in another worktree")},
+			},
+		}
+
+		cmd := NewRootCmd(WithAddCommander(mock))
+
+		var stdout, stderr bytes.Buffer
+		cmd.SetOut(&stdout)
+		cmd.SetErr(&stderr)
+		cmd.SetIn(strings.NewReader("# onboarding\nfeat/a --lock\n\nfeat/b\n"))
+		cmd.SetArgs([]string{"-C", mainDir, "add", "--batch", "-", "-j", "1"})
+
+		err := cmd.Execute()
+		if err == nil || !strings.Contains(err.Error(), "failed to add 1 branch(es)") {
+			t.Fatalf("error = %v, want failed to add 1 branch(es)", err)
+		}
+		if !slices.Equal(mock.calls, []string{"feat/a", "feat/b"}) {
+			t.Errorf("calls = %v, want [feat/a feat/b]", mock.calls)
+		}
+
+		want := "twig add: feat/a (0 symlinks)\n" +
+			"\nSummary: 1 added, 1 failed\n" +
+			"BRANCH  STATUS  PATH\n" +
+			"feat/a  added   /wt/feat/a\n" +
+			"feat/b  failed  -\n"
+		if stdout.String() != want {
+			t.Errorf("stdout = %q, want %q", stdout.String(), want)
+		}
+		if !strings.Contains(stderr.String(), "error: feat/b: branch feat/b is already checked out") {
+			t.Errorf("stderr = %q, want error for feat/b", stderr.String())
+		}
+	})
+
+	t.Run("File", func(t *testing.T) {
+		t.Parallel()
+
+		_, mainDir := testutil.SetupTestRepo(t)
+		batchFile := filepath.Join(t.TempDir(), "branches.txt")
+		if err := os.WriteFile(batchFile, []byte("feat/a\nfeat/b\nfeat/c\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		mock := &mockAddCommander{}
+		cmd := NewRootCmd(WithAddCommander(mock))
+
+		var stdout, stderr bytes.Buffer
+		cmd.SetOut(&stdout)
+		cmd.SetErr(&stderr)
+		cmd.SetArgs([]string{"-C", mainDir, "add", "--batch", batchFile, "-q"})
+
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(mock.calls) != 3 {
+			t.Errorf("calls = %v, want 3 calls", mock.calls)
+		}
+		if strings.Contains(stdout.String(), "Summary") {
+			t.Errorf("quiet output should not include summary, got %q", stdout.String())
+		}
+	})
+
+	errorTests := []struct {
+		name    string
+		args    []string
+		stdin   string
+		wantErr string
+	}{
+		{
+			name:    "with_branch_arguments",
+			args:    []string{"add", "--batch", "-", "feat/a"},
+			wantErr: "cannot use --batch with branch arguments",
+		},
+		{
+			name:    "with_sync",
+			args:    []string{"add", "--batch", "-", "--sync"},
+			wantErr: "--sync and --carry cannot be used with --batch",
+		},
+		{
+			name:    "invalid_line",
+			args:    []string{"add", "--batch", "-"},
+			stdin:   "feat/a\nfeat/b --carry\n",
+			wantErr: "line 2: unsupported flag --carry",
+		},
+		{
+			name:    "zero_jobs",
+			args:    []string{"add", "--batch", "-", "--jobs", "0"},
+			wantErr: "--jobs must be at least 1",
+		},
+		{
+			name:    "missing_file",
+			args:    []string{"add", "--batch", "/nonexistent/branches.txt"},
+			wantErr: "failed to open batch file",
+		},
+	}
+
+	for _, tt := range errorTests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, mainDir := testutil.SetupTestRepo(t)
+
+			mock := &mockAddCommander{}
+			cmd := NewRootCmd(WithAddCommander(mock))
+
+			var stdout, stderr bytes.Buffer
+			cmd.SetOut(&stdout)
+			cmd.SetErr(&stderr)
+			cmd.SetIn(strings.NewReader(tt.stdin))
+			cmd.SetArgs(append([]string{"-C", mainDir}, tt.args...))
+
+			err := cmd.Execute()
+			if err == nil {
+				t.Fatal("expected error, got nil")
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %q, want to contain %q", err.Error(), tt.wantErr)
+			}
+			if len(mock.calls) != 0 {
+				t.Errorf("expected no calls, got %v", mock.calls)
+			}
+		})
+	}
+}
+
+func TestAddCmd_CI(t *testing.T) {
+	t.Parallel()
+
+	t.Run("PorcelainOutput", func(t *testing.T) {
+		t.Parallel()
+
+		_, mainDir := testutil.SetupTestRepo(t)
+
+		mock := &mockAddCommander{
+			results: map[string]addResult{
+				"build/1": {result: core.AddResult{
+					Branch:       "build/1",
+					WorktreePath: "/wt/build/1",
+					Symlinks:     []core.SymlinkResult{{Skipped: true, Reason: "ignored"}},
+				}},
+				"build/2": {result: core.AddResult{Branch: "build/2", WorktreePath: "/wt/build/2"}},
+			},
+		}
+		cmd := NewRootCmd(WithAddCommander(mock))
+
+		var stdout, stderr bytes.Buffer
+		cmd.SetOut(&stdout)
+		cmd.SetErr(&stderr)
+		cmd.SetArgs([]string{"-C", mainDir, "add", "--ci", "--sparse", "api", "-j", "1", "build/1", "build/2"})
+
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		want := "worktree /wt/build/1\nbranch build/1\n\n" +
+			"worktree /wt/build/2\nbranch build/2\n\n"
+		if stdout.String() != want {
+			t.Errorf("stdout = %q, want %q", stdout.String(), want)
+		}
+		if stderr.String() != "" {
+			t.Errorf("stderr = %q, want empty", stderr.String())
+		}
+	})
+
+	errorTests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{
+			name:    "sparse_without_ci",
+			args:    []string{"add", "--sparse", "api", "feat/a"},
+			wantErr: "--sparse requires --ci",
+		},
+		{
+			name:    "with_sync",
+			args:    []string{"add", "--ci", "--sync", "feat/a"},
+			wantErr: "--sync and --carry cannot be used with --ci",
+		},
+		{
+			name:    "with_init_submodules",
+			args:    []string{"add", "--ci", "--init-submodules", "feat/a"},
+			wantErr: "--init-submodules and --submodule-reference cannot be used with --ci",
+		},
+		{
+			name:    "ci_with_symlink",
+			args:    []string{"add", "--ci", "--symlink", ".envrc", "feat/a"},
+			wantErr: "cannot use --ci and --symlink together",
+		},
+		{
+			name:    "repo_with_carry_from_current",
+			args:    []string{"add", "--repo", ".", "--carry", "feat/a"},
+			wantErr: "--carry requires a branch (--carry=<branch>) when used with --repo",
+		},
+		{
+			name:    "repo_missing",
+			args:    []string{"add", "--repo", "missing", "feat/a"},
+			wantErr: "invalid --repo: cannot change to 'missing'",
+		},
+		{
+			name:    "repo_not_in_work_tree",
+			args:    []string{"add", "--repo", ".git", "feat/a"},
+			wantErr: "is not in a git repository",
+		},
+		{
+			name:    "track_with_push",
+			args:    []string{"add", "--track", "--push", "feat/a"},
+			wantErr: "cannot use --track and --push together",
+		},
+		{
+			name:    "explicit_track_with_multiple_branches",
+			args:    []string{"add", "--track=origin/main", "feat/a", "feat/b"},
+			wantErr: "--track=<remote>/<branch> requires a single branch",
+		},
+		{
+			name:    "empty_push_remote",
+			args:    []string{"add", "--push=", "feat/a"},
+			wantErr: "push remote cannot be empty",
+		},
+		{
+			name:    "restore_with_track",
+			args:    []string{"add", "--restore", "--track", "feat/a"},
+			wantErr: "cannot use --restore and --track together",
+		},
+		{
+			name:    "detach_with_push",
+			args:    []string{"add", "--detach", "--push", "v1.2.3"},
+			wantErr: "cannot use --detach and --push together",
+		},
+		{
+			name:    "pr_with_detach",
+			args:    []string{"add", "--pr", "12", "--detach"},
+			wantErr: "cannot use --pr and --detach together",
+		},
+		{
+			name:    "pr_with_two_names",
+			args:    []string{"add", "--pr", "12", "feat/a", "feat/b"},
+			wantErr: "accepts at most 1 arg(s), received 2",
+		},
+		{
+			name:    "no_checkout_with_ci",
+			args:    []string{"add", "--no-checkout", "--ci", "feat/a"},
+			wantErr: "cannot use --no-checkout and --ci together",
+		},
+		{
+			name:    "no_checkout_with_sync",
+			args:    []string{"add", "--no-checkout", "--sync", "feat/a"},
+			wantErr: "cannot use --no-checkout and --sync together",
+		},
+		{
+			name:    "invalid_chaos_spec",
+			args:    []string{"list", "--chaos", "net.dial"},
+			wantErr: "invalid --chaos",
+		},
+		{
+			name:    "invalid_log_format",
+			args:    []string{"list", "--log-format", "yaml"},
+			wantErr: `invalid --log-format: unknown log format "yaml"`,
+		},
+		{
+			name:    "note_clear_with_text",
+			args:    []string{"note", "--clear", "feat/a", "done"},
+			wantErr: "cannot use --clear and note text together",
+		},
+		{
+			name:    "note_file_without_text",
+			args:    []string{"note", "--file", "feat/a"},
+			wantErr: "--file requires note text",
+		},
+		{
+			name:    "hook_install_invalid_interval",
+			args:    []string{"hook", "install", "post-merge", "--interval", "0s"},
+			wantErr: "invalid --interval 0s: must be positive",
+		},
+	}
+
+	for _, tt := range errorTests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, mainDir := testutil.SetupTestRepo(t)
+
+			mock := &mockAddCommander{}
+			cmd := NewRootCmd(WithAddCommander(mock))
+			cmd.SetOut(&bytes.Buffer{})
+			cmd.SetErr(&bytes.Buffer{})
+			cmd.SetArgs(append([]string{"-C", mainDir}, tt.args...))
+
+			err := cmd.Execute()
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want to contain %q", err, tt.wantErr)
+			}
+			if len(mock.calls) != 0 {
+				t.Errorf("expected no calls, got %v", mock.calls)
+			}
+		})
+	}
+}
+
+func TestRemoveCmd(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		args      []string
+		wantForce core.WorktreeForceLevel
+		wantCheck bool
+	}{
+		{
+			name:      "no_flags",
+			args:      []string{"remove", "feat/a"},
+			wantForce: core.WorktreeForceLevelNone,
+			wantCheck: false,
+		},
+		{
+			name:      "force_flag",
+			args:      []string{"remove", "--force", "feat/a"},
+			wantForce: core.WorktreeForceLevelUnclean,
+			wantCheck: false,
+		},
+		{
+			name:      "force_short_flag",
+			args:      []string{"remove", "-f", "feat/a"},
+			wantForce: core.WorktreeForceLevelUnclean,
+			wantCheck: false,
+		},
+		{
+			name:      "force_double_short_flag",
+			args:      []string{"remove", "-ff", "feat/a"},
+			wantForce: core.WorktreeForceLevelLocked,
+			wantCheck: false,
+		},
+		{
+			name:      "force_double_separate_flags",
+			args:      []string{"remove", "-f", "-f", "feat/a"},
+			wantForce: core.WorktreeForceLevelLocked,
+			wantCheck: false,
+		},
+		{
+			name:      "check_flag",
+			args:      []string{"remove", "--check", "feat/a"},
+			wantForce: core.WorktreeForceLevelNone,
+			wantCheck: true,
+		},
+		{
+			name:      "force_and_check",
+			args:      []string{"remove", "--force", "--check", "feat/a"},
+			wantForce: core.WorktreeForceLevelUnclean,
+			wantCheck: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mock := &mockRemoveCommander{}
+
+			cmd := NewRootCmd(WithRemoveCommander(mock))
+
+			stdout := &bytes.Buffer{}
+			stderr := &bytes.Buffer{}
+
+			cmd.SetOut(stdout)
+			cmd.SetErr(stderr)
+			cmd.SetArgs(tt.args)
+
+			_ = cmd.Execute()
+
+			if len(mock.calls) != 1 {
+				t.Fatalf("expected 1 call, got %d", len(mock.calls))
+			}
+
+			call := mock.calls[0]
+			if call.opts.Force != tt.wantForce {
+				t.Errorf("Force = %v, want %v", call.opts.Force, tt.wantForce)
+			}
+			if call.opts.Check != tt.wantCheck {
+				t.Errorf("Check = %v, want %v", call.opts.Check, tt.wantCheck)
+			}
+		})
+	}
+}
+
+func TestRemoveCmd_OutputFormat(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		args       []string
+		results    map[string]removeResult
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			name: "success_output",
+			args: []string{"remove", "feat/a"},
+			results: map[string]removeResult{
+				"feat/a": {wt: core.RemovedWorktree{Branch: "feat/a", WorktreePath: "/test/feat/a"}},
+			},
+			wantStdout: "",
+			wantStderr: "",
+		},
+		{
+			name: "error_output",
+			args: []string{"remove", "feat/a"},
+			results: map[string]removeResult{
+				"feat/a": {wt: core.RemovedWorktree{}, err: errors.New("not found")},
+			},
+			wantStdout: "",
+			wantStderr: "error: feat/a: not found\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mock := &mockRemoveCommander{results: tt.results}
+
+			cmd := NewRootCmd(WithRemoveCommander(mock))
+
+			stdout := &bytes.Buffer{}
+			stderr := &bytes.Buffer{}
+
+			cmd.SetOut(stdout)
+			cmd.SetErr(stderr)
+			cmd.SetArgs(tt.args)
+
+			_ = cmd.Execute()
+
+			if stdout.String() != tt.wantStdout {
+				t.Errorf("stdout = %q, want %q", stdout.String(), tt.wantStdout)
+			}
+			if stderr.String() != tt.wantStderr {
+				t.Errorf("stderr = %q, want %q", stderr.String(), tt.wantStderr)
+			}
+		})
+	}
+}
+
+func TestRemoveCmd_MultipleBranches(t *testing.T) {
+	t.Parallel()
+
+	mock := &mockRemoveCommander{}
+
+	cmd := NewRootCmd(WithRemoveCommander(mock))
+
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+
+	cmd.SetOut(stdout)
+	cmd.SetErr(stderr)
+	cmd.SetArgs([]string{"remove", "feat/a", "feat/b", "feat/c"})
+
+	_ = cmd.Execute()
+
+	if len(mock.calls) != 3 {
+		t.Fatalf("expected 3 calls, got %d", len(mock.calls))
+	}
+
+	// Check that all branches were called (order may vary due to parallel execution)
+	calledBranches := make(map[string]bool)
+	for _, call := range mock.calls {
+		calledBranches[call.branch] = true
+	}
+	expectedBranches := []string{"feat/a", "feat/b", "feat/c"}
+	for _, branch := range expectedBranches {
+		if !calledBranches[branch] {
+			t.Errorf("branch %q was not called", branch)
+		}
+	}
+
+	// Success output is silent by default
+	if stdout.String() != "" {
+		t.Errorf("stdout should be empty on success, got %q", stdout.String())
+	}
+}
+
+func TestRemoveCmd_AllMerged(t *testing.T) {
+	t.Parallel()
+
+	t.Run("RemovesCleanCandidates", func(t *testing.T) {
+		t.Parallel()
+
+		cleanMock := &mockCleanCommander{
+			result: core.CleanResult{
+				Candidates: []core.CleanCandidate{
+					{Branch: "feat/a", Target: "main", CleanReason: core.CleanMerged},
+					{Branch: "feat/b", Target: "develop", CleanReason: core.CleanPRMerged},
+					{Branch: "feat/c", Skipped: true},
+				},
+			},
+		}
+		removeMock := &mockRemoveCommander{}
+
+		cmd := NewRootCmd(WithCleanCommander(cleanMock), WithRemoveCommander(removeMock))
+
+		stdout := &bytes.Buffer{}
+		stderr := &bytes.Buffer{}
+		cmd.SetOut(stdout)
+		cmd.SetErr(stderr)
+		cmd.SetArgs([]string{"remove", "--all-merged", "--target", "main,develop"})
+
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if !cleanMock.lastOpts.Check {
+			t.Error("clean should only check candidates")
+		}
+		if !slices.Equal(cleanMock.lastOpts.Targets, []string{"main", "develop"}) {
+			t.Errorf("Targets = %v, want [main develop]", cleanMock.lastOpts.Targets)
+		}
+
+		if len(removeMock.calls) != 2 {
+			t.Fatalf("expected 2 calls, got %d", len(removeMock.calls))
+		}
+		calls := make(map[string]core.RemoveOptions)
+		for _, call := range removeMock.calls {
+			calls[call.branch] = call.opts
+		}
+		if opts := calls["feat/a"]; opts.Target != "main" || opts.ForceDeleteBranch {
+			t.Errorf("feat/a opts = %+v, want Target main without ForceDeleteBranch", opts)
+		}
+		if opts := calls["feat/b"]; opts.Target != "develop" || !opts.ForceDeleteBranch {
+			t.Errorf("feat/b opts = %+v, want Target develop with ForceDeleteBranch", opts)
+		}
+		if _, ok := calls["feat/c"]; ok {
+			t.Error("skipped candidate feat/c should not be removed")
+		}
+	})
+
+	t.Run("NoCandidates", func(t *testing.T) {
+		t.Parallel()
+
+		removeMock := &mockRemoveCommander{}
+
+		cmd := NewRootCmd(WithCleanCommander(&mockCleanCommander{}), WithRemoveCommander(removeMock))
+
+		stdout := &bytes.Buffer{}
+		cmd.SetOut(stdout)
+		cmd.SetErr(&bytes.Buffer{})
+		cmd.SetArgs([]string{"remove", "--all-merged"})
+
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(removeMock.calls) != 0 {
+			t.Errorf("expected no calls, got %d", len(removeMock.calls))
+		}
+		if stdout.String() != "No merged worktrees to remove\n" {
+			t.Errorf("stdout = %q", stdout.String())
+		}
+	})
+
+	errTests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{
+			name:    "with_branch_args",
+			args:    []string{"remove", "--all-merged", "feat/a"},
+			wantErr: "cannot use --all-merged with branch arguments",
+		},
+		{
+			name:    "with_force",
+			args:    []string{"remove", "--all-merged", "--force"},
+			wantErr: "cannot use --force with --all-merged",
+		},
+		{
+			name:    "target_without_all_merged",
+			args:    []string{"remove", "--target", "main", "feat/a"},
+			wantErr: "--target requires --all-merged",
+		},
+	}
+
+	for _, tt := range errTests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			removeMock := &mockRemoveCommander{}
+
+			cmd := NewRootCmd(WithCleanCommander(&mockCleanCommander{}), WithRemoveCommander(removeMock))
+			cmd.SetOut(&bytes.Buffer{})
+			cmd.SetErr(&bytes.Buffer{})
+			cmd.SetArgs(tt.args)
+
+			err := cmd.Execute()
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+			}
+			if len(removeMock.calls) != 0 {
+				t.Errorf("expected no calls, got %d", len(removeMock.calls))
+			}
+		})
+	}
+}
+
+func TestInitCmd(t *testing.T) {
+	t.Parallel()
+
+	t.Run("BasicExecution", func(t *testing.T) {
+		t.Parallel()
+
+		tmpDir := t.TempDir()
+
+		mock := &mockInitCommander{
+			result: core.InitResult{
+				Created: true,
+			},
+		}
+
+		cmd := NewRootCmd(WithInitCommander(mock))
+
+		var stdout, stderr bytes.Buffer
+		cmd.SetOut(&stdout)
+		cmd.SetErr(&stderr)
+		cmd.SetArgs([]string{"-C", tmpDir, "init"})
+
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if !mock.calledOpts.Force {
+			// Expected: Force should be false by default
+		} else {
+			t.Error("expected Force to be false")
+		}
+	})
+
+	t.Run("ForceFlag", func(t *testing.T) {
+		t.Parallel()
+
+		tmpDir := t.TempDir()
+
+		mock := &mockInitCommander{
+			result: core.InitResult{
+				Created:     true,
+				Overwritten: true,
+			},
+		}
+
+		cmd := NewRootCmd(WithInitCommander(mock))
+
+		var stdout, stderr bytes.Buffer
+		cmd.SetOut(&stdout)
+		cmd.SetErr(&stderr)
+		cmd.SetArgs([]string{"-C", tmpDir, "init", "--force"})
+
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if !mock.calledOpts.Force {
+			t.Error("expected Force to be true")
+		}
+	})
+
+	t.Run("ForceShortFlag", func(t *testing.T) {
+		t.Parallel()
+
+		tmpDir := t.TempDir()
+
+		mock := &mockInitCommander{
+			result: core.InitResult{
+				Created:     true,
+				Overwritten: true,
+			},
+		}
+
+		cmd := NewRootCmd(WithInitCommander(mock))
+
+		var stdout, stderr bytes.Buffer
+		cmd.SetOut(&stdout)
+		cmd.SetErr(&stderr)
+		cmd.SetArgs([]string{"-C", tmpDir, "init", "-f"})
+
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if !mock.calledOpts.Force {
+			t.Error("expected Force to be true")
+		}
+	})
+
+	t.Run("UpdateGitignoreFlag", func(t *testing.T) {
+		t.Parallel()
+
+		tmpDir := t.TempDir()
+
+		mock := &mockInitCommander{
+			result: core.InitResult{
+				Created:        true,
+				GitignorePath:  filepath.Join(tmpDir, ".gitignore"),
+				GitignoreAdded: []string{".twig.env"},
+			},
+		}
+
+		cmd := NewRootCmd(WithInitCommander(mock))
+
+		var stdout, stderr bytes.Buffer
+		cmd.SetOut(&stdout)
+		cmd.SetErr(&stderr)
+		cmd.SetArgs([]string{"-C", tmpDir, "init", "--update-gitignore"})
+
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if !mock.calledOpts.UpdateGitignore {
+			t.Error("expected UpdateGitignore to be true")
+		}
+		if !strings.Contains(stdout.String(), "Updated .gitignore (added .twig.env)") {
+			t.Errorf("stdout = %q, want gitignore update", stdout.String())
+		}
+	})
+
+	t.Run("ErrorFromCommand", func(t *testing.T) {
+		t.Parallel()
+
+		tmpDir := t.TempDir()
+
+		mock := &mockInitCommander{
+			err: errors.New("permission denied"),
+		}
+
+		cmd := NewRootCmd(WithInitCommander(mock))
+
+		var stdout, stderr bytes.Buffer
+		cmd.SetOut(&stdout)
+		cmd.SetErr(&stderr)
+		cmd.SetArgs([]string{"-C", tmpDir, "init"})
+
+		err := cmd.Execute()
+		if err == nil {
+			t.Fatal("expected error, got nil")
+		}
+		if !strings.Contains(err.Error(), "permission denied") {
+			t.Errorf("error = %q, want to contain %q", err.Error(), "permission denied")
+		}
+	})
+
+	t.Run("SkippedOutput", func(t *testing.T) {
+		t.Parallel()
+
+		tmpDir := t.TempDir()
+
+		mock := &mockInitCommander{
+			result: core.InitResult{
+				Skipped: true,
+			},
+		}
+
+		cmd := NewRootCmd(WithInitCommander(mock))
+
+		var stdout, stderr bytes.Buffer
+		cmd.SetOut(&stdout)
+		cmd.SetErr(&stderr)
+		cmd.SetArgs([]string{"-C", tmpDir, "init"})
+
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if !strings.Contains(stdout.String(), "Skipped") {
+			t.Errorf("stdout = %q, want to contain 'Skipped'", stdout.String())
+		}
+	})
+
+	t.Run("CreatedOutput", func(t *testing.T) {
+		t.Parallel()
+
+		tmpDir := t.TempDir()
+
+		mock := &mockInitCommander{
+			result: core.InitResult{
+				Created: true,
+			},
+		}
+
+		cmd := NewRootCmd(WithInitCommander(mock))
+
+		var stdout, stderr bytes.Buffer
+		cmd.SetOut(&stdout)
+		cmd.SetErr(&stderr)
+		cmd.SetArgs([]string{"-C", tmpDir, "init"})
+
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if !strings.Contains(stdout.String(), "Created") {
+			t.Errorf("stdout = %q, want to contain 'Created'", stdout.String())
+		}
+	})
+}
+
+func TestConfigProfilesCmd(t *testing.T) {
+	t.Parallel()
+
+	_, mainDir := testutil.SetupTestRepo(t)
+	twigDir := filepath.Join(mainDir, ".twig")
+	if err := os.MkdirAll(twigDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	settingsContent := "[profiles.review]\nsymlinks = []\nhooks = []\n\n[profiles.dev]\nextra_symlinks = [\".claude\"]\n"
+	if err := os.WriteFile(filepath.Join(twigDir, "settings.toml"), []byte(settingsContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantStdout string
+		wantErr    string
+	}{
+		{
+			name:       "lists profiles",
+			args:       []string{"-C", mainDir, "config", "profiles"},
+			wantStdout: "  dev\n  review\n",
+		},
+		{
+			name:       "marks active profile",
+			args:       []string{"-C", mainDir, "--profile", "review", "config", "profiles"},
+			wantStdout: "  dev\n* review\n",
+		},
+		{
+			name:       "verbose shows overrides",
+			args:       []string{"-C", mainDir, "config", "profiles", "-v"},
+			wantStdout: "  dev     extra_symlinks\n  review  symlinks, hooks\n",
+		},
+		{
+			name:    "unknown profile",
+			args:    []string{"-C", mainDir, "--profile", "missing", "config", "profiles"},
+			wantErr: `profile "missing" is not defined`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cmd := NewRootCmd()
+
+			stdout := &bytes.Buffer{}
+			stderr := &bytes.Buffer{}
+
+			cmd.SetOut(stdout)
+			cmd.SetErr(stderr)
+			cmd.SetArgs(tt.args)
+
+			err := cmd.Execute()
+
+			if tt.wantErr != "" {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				if !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("error = %q, want to contain %q", err.Error(), tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if stdout.String() != tt.wantStdout {
+				t.Errorf("stdout = %q, want %q", stdout.String(), tt.wantStdout)
+			}
+		})
+	}
+}
+
+func TestConfigSchemaCmd(t *testing.T) {
+	t.Parallel()
+
+	// No git repository: the schema needs no config
+	cmd := NewRootCmd()
+	stdout := &bytes.Buffer{}
+	cmd.SetOut(stdout)
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs([]string{"-C", t.TempDir(), "config", "schema"})
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want, err := core.ConfigSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stdout.String() != string(want) {
+		t.Errorf("stdout = %q, want the config schema", stdout.String())
+	}
+}
+
+func TestConfigCheckCmd(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		settings   string
+		args       []string
+		wantStdout string
+		wantErr    string
+	}{
+		{
+			name:       "valid config",
+			settings:   "branch_prefix = \"me/\"\n",
+			args:       []string{"config", "check"},
+			wantStdout: "twig config check: ok\n",
+		},
+		{
+			name:       "warnings do not fail",
+			settings:   "symlink = [\".envrc\"]\n",
+			args:       []string{"config", "check"},
+			wantStdout: ".twig/settings.toml: warning: unknown key \"symlink\"\n",
+		},
+		{
+			name:     "syntax error is reported",
+			settings: "symlinks = [\n",
+			args:     []string{"config", "check"},
+			wantErr:  "found 1 error(s)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, mainDir := testutil.SetupTestRepo(t, testutil.WithoutSettings())
+			twigDir := filepath.Join(mainDir, ".twig")
+			if err := os.MkdirAll(twigDir, 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(twigDir, "settings.toml"), []byte(tt.settings), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			cmd := NewRootCmd()
+
+			stdout := &bytes.Buffer{}
+			cmd.SetOut(stdout)
+			cmd.SetErr(&bytes.Buffer{})
+			cmd.SetArgs(append([]string{"-C", mainDir}, tt.args...))
+
+			err := cmd.Execute()
+
+			if tt.wantErr != "" {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				if !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("error = %q, want to contain %q", err.Error(), tt.wantErr)
+				}
+				if !strings.HasPrefix(stdout.String(), ".twig/settings.toml: error: toml: ") {
+					t.Errorf("stdout = %q, want the syntax error", stdout.String())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if stdout.String() != tt.wantStdout {
+				t.Errorf("stdout = %q, want %q", stdout.String(), tt.wantStdout)
+			}
+		})
+	}
+}
+
+func TestConfigEffectiveCmd(t *testing.T) {
+	t.Parallel()
+
+	_, mainDir := testutil.SetupTestRepo(t, testutil.WithoutSettings())
+	twigDir := filepath.Join(mainDir, ".twig")
+	if err := os.MkdirAll(twigDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(twigDir, "settings.toml"), []byte("branch_prefix = \"team/\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(twigDir, "settings.local.toml"), []byte("branch_prefix = \"me/\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	run := func(t *testing.T, args ...string) string {
+		t.Helper()
+		cmd := NewRootCmd()
+		stdout := &bytes.Buffer{}
+		cmd.SetOut(stdout)
+		cmd.SetErr(&bytes.Buffer{})
+		cmd.SetArgs(append([]string{"-C", mainDir, "config"}, args...))
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return stdout.String()
+	}
+
+	for _, name := range []string{"effective", "show"} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var line string
+			for l := range strings.Lines(run(t, name)) {
+				if strings.HasPrefix(l, "branch_prefix = ") {
+					line = l
+				}
+			}
+			if !strings.Contains(line, `"me/"`) || !strings.HasSuffix(line, "# .twig/settings.local.toml\n") {
+				t.Errorf("branch_prefix line = %q, want the local value and source", line)
+			}
+		})
+	}
+
+	t.Run("json", func(t *testing.T) {
+		t.Parallel()
+
+		var got struct {
+			Settings map[string]struct {
+				Value   any      `json:"value"`
+				Sources []string `json:"sources"`
+			} `json:"settings"`
+		}
+		if err := json.Unmarshal([]byte(run(t, "effective", "--json")), &got); err != nil {
+			t.Fatalf("invalid JSON: %v", err)
+		}
+		prefix := got.Settings["branch_prefix"]
+		if prefix.Value != "me/" || !slices.Equal(prefix.Sources, []string{".twig/settings.local.toml"}) {
+			t.Errorf("branch_prefix = %+v, want me/ from .twig/settings.local.toml", prefix)
+		}
+	})
+}
+
+func TestSyncCmd_InferredSource(t *testing.T) {
+	t.Parallel()
+
+	repoDir, mainDir := testutil.SetupTestRepo(t, testutil.WithoutSettings())
+	wtDir := filepath.Join(repoDir, "feat-a")
+	testutil.RunGit(t, mainDir, "worktree", "add", wtDir, "-b", "feat/a")
+
+	cmd := NewRootCmd()
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	cmd.SetOut(stdout)
+	cmd.SetErr(stderr)
+	cmd.SetIn(strings.NewReader("y\n"))
+	cmd.SetArgs([]string{"-C", wtDir, "sync"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v\nstderr: %s", err, stderr.String())
+	}
+
+	if !strings.Contains(stderr.String(), "note: default_source is not set, using main (branch main exists)") {
+		t.Errorf("stderr = %q, want inference note", stderr.String())
+	}
+	// Non-interactive input is never prompted
+	if strings.Contains(stderr.String(), "[y/N]") {
+		t.Errorf("stderr = %q, want no prompt", stderr.String())
+	}
+	if _, err := os.Stat(filepath.Join(wtDir, ".twig", "settings.local.toml")); !os.IsNotExist(err) {
+		t.Errorf("settings.local.toml was written without confirmation")
+	}
+}
+
+func TestRootCmd_Timing(t *testing.T) {
+	t.Parallel()
+
+	_, mainDir := testutil.SetupTestRepo(t, testutil.WithoutSettings())
+
+	cmd := NewRootCmd()
+	stderr := &bytes.Buffer{}
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(stderr)
+	cmd.SetArgs([]string{"-C", mainDir, "list", "--timing"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v\nstderr: %s", err, stderr.String())
+	}
+
+	for _, want := range []string{"timing: ", "  config load ", "  git worktree list "} {
+		if !strings.Contains(stderr.String(), want) {
+			t.Errorf("stderr = %q, want %q", stderr.String(), want)
+		}
+	}
+}
+
+func TestSyncCmd_ScopeFlags(t *testing.T) {
+	t.Parallel()
+
+	_, mainDir := testutil.SetupTestRepo(t, testutil.WithoutSettings())
+
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{
+			name:    "symlinks and submodules only",
+			args:    []string{"--symlinks-only", "--submodules-only"},
+			wantErr: "cannot use --symlinks-only and --submodules-only together",
+		},
+		{
+			name:    "submodules only with delete stale",
+			args:    []string{"--submodules-only", "--delete-stale"},
+			wantErr: "cannot use --submodules-only and --delete-stale together",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cmd := NewRootCmd()
+			cmd.SetOut(&bytes.Buffer{})
+			cmd.SetErr(&bytes.Buffer{})
+			cmd.SetArgs(append([]string{"-C", mainDir, "sync", "--all"}, tt.args...))
+			err := cmd.Execute()
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNoteCmd_ShownInList(t *testing.T) {
+	t.Parallel()
+
+	repoDir, mainDir := testutil.SetupTestRepo(t, testutil.WithoutSettings())
+	wtDir := filepath.Join(repoDir, "feat-a")
+	testutil.RunGit(t, mainDir, "worktree", "add", wtDir, "-b", "feat/a")
+
+	run := func(args ...string) string {
+		t.Helper()
+		cmd := NewRootCmd()
+		stdout := &bytes.Buffer{}
+		stderr := &bytes.Buffer{}
+		cmd.SetOut(stdout)
+		cmd.SetErr(stderr)
+		cmd.SetArgs(append([]string{"-C", mainDir}, args...))
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("twig %v: %v\nstderr: %s", args, err, stderr.String())
+		}
+		return stdout.String()
+	}
+
+	run("note", "feat/a", "waiting on review")
+
+	if got := run("note", "feat/a"); got != "feat/a  waiting on review\n" {
+		t.Errorf("note feat/a = %q", got)
+	}
+	if got := run("list", "-l"); !strings.Contains(got, "[feat/a]  waiting on review") {
+		t.Errorf("list -l = %q, want note column", got)
+	}
+	if got := run("list"); strings.Contains(got, "waiting on review") {
+		t.Errorf("list = %q, want no notes without --long", got)
+	}
+
+	run("note", "--clear", "feat/a")
+	if got := run("note"); got != "" {
+		t.Errorf("note after clear = %q, want empty", got)
+	}
+}
+
+func TestConfigSetGetCmd(t *testing.T) {
+	t.Parallel()
+
+	_, mainDir := testutil.SetupTestRepo(t, testutil.WithoutSettings())
+
+	run := func(t *testing.T, args ...string) string {
+		t.Helper()
+		cmd := NewRootCmd()
+		stdout := &bytes.Buffer{}
+		cmd.SetOut(stdout)
+		cmd.SetErr(&bytes.Buffer{})
+		cmd.SetArgs(append([]string{"-C", mainDir, "config"}, args...))
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return stdout.String()
+	}
+
+	if got := run(t, "set", "symlinks", ".envrc", ".tool-versions"); got != "Set symlinks = [\".envrc\", \".tool-versions\"] in .twig/settings.toml\n" {
+		t.Errorf("set output = %q", got)
+	}
+	run(t, "set", "--local", "default_source", "develop")
+
+	if got := run(t, "get", "symlinks"); got != ".envrc\n.tool-versions\n" {
+		t.Errorf("get symlinks = %q", got)
+	}
+	if got := run(t, "get", "default_source"); got != "develop\n" {
+		t.Errorf("get default_source = %q", got)
+	}
+	data, err := os.ReadFile(filepath.Join(mainDir, ".twig", "settings.local.toml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "default_source = \"develop\"\n" {
+		t.Errorf("settings.local.toml = %q", data)
+	}
+}
+
+func TestConfigDiffCmd(t *testing.T) {
+	t.Parallel()
+
+	repoDir, mainDir := testutil.SetupTestRepo(t, testutil.WithoutSettings())
+	wtDir := filepath.Join(repoDir, "feat-a")
+	testutil.RunGit(t, mainDir, "worktree", "add", wtDir, "-b", "feat/a")
+
+	for dir, content := range map[string]string{
+		mainDir: "symlinks = [\".envrc\"]\n",
+		wtDir:   "symlinks = [\".envrc\", \".tool-versions\"]\n",
+	} {
+		if err := os.MkdirAll(filepath.Join(dir, ".twig"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, ".twig", "settings.toml"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cmd := NewRootCmd()
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	cmd.SetOut(stdout)
+	cmd.SetErr(stderr)
+	cmd.SetArgs([]string{"-C", mainDir, "config", "diff", "main", "feat/a"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v\nstderr: %s", err, stderr.String())
+	}
+
+	want := "-symlinks = [\".envrc\"]                    # .twig/settings.toml\n" +
+		"+symlinks = [\".envrc\", \".tool-versions\"]  # .twig/settings.toml\n"
+	if !strings.HasSuffix(stdout.String(), want) {
+		t.Errorf("stdout = %q, want suffix %q", stdout.String(), want)
+	}
+}
+
+// mockExportCommander is a test double for ExportCommander interface.
+type mockExportCommander struct {
+	state core.State
+}
+
+func (m *mockExportCommander) Run(ctx context.Context) (core.State, error) {
+	return m.state, nil
+}
+
+// mockImportCommander is a test double for ImportCommander interface.
+type mockImportCommander struct {
+	result core.ImportResult
+	state  core.State
+	opts   core.ImportOptions
+}
+
+func (m *mockImportCommander) Run(ctx context.Context, state core.State, opts core.ImportOptions) (core.ImportResult, error) {
+	m.state = state
+	m.opts = opts
+	result := m.result
+	result.Check = opts.Check
+	return result, nil
+}
+
+func TestExportImportCmd(t *testing.T) {
+	t.Parallel()
+
+	state := core.State{Version: core.StateVersion, Worktrees: []core.StateWorktree{
+		{Branch: "feat/a", Path: "feat/a", HEAD: "abc1234", Locked: true, LockReason: "on usb"},
+	}}
+
+	exportCmd := NewRootCmd(WithExportCommander(&mockExportCommander{state: state}))
+	exported := &bytes.Buffer{}
+	exportCmd.SetOut(exported)
+	exportCmd.SetErr(&bytes.Buffer{})
+	exportCmd.SetArgs([]string{"export"})
+	if err := exportCmd.Execute(); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		args       []string
+		stdin      string
+		result     core.ImportResult
+		wantErr    string
+		wantStdout string
+		wantCheck  bool
+	}{
+		{
+			name:  "check_from_stdin",
+			args:  []string{"import", "--check", "-"},
+			stdin: exported.String(),
+			result: core.ImportResult{Worktrees: []core.ImportedWorktree{
+				{Branch: "feat/a", WorktreePath: "/wt/feat/a", Status: core.ImportCreated},
+			}},
+			wantStdout: "Would create feat/a: /wt/feat/a\n",
+			wantCheck:  true,
+		},
+		{
+			name:  "failed_worktree_returns_error",
+			args:  []string{"import", "-"},
+			stdin: exported.String(),
+			result: core.ImportResult{Worktrees: []core.ImportedWorktree{
+				{Branch: "feat/a", WorktreePath: "/wt/feat/a", Status: core.ImportFailed, Err: errors.New("boom")},
+			}},
+			wantErr:    "failed to import 1 worktree(s)",
+			wantStdout: "twig import: 0 created, 0 skipped, 1 failed\n",
+		},
+		{
+			name:    "unsupported_version",
+			args:    []string{"import", "-"},
+			stdin:   `{"version": 99, "worktrees": []}`,
+			wantErr: "unsupported export version 99",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mock := &mockImportCommander{result: tt.result}
+			cmd := NewRootCmd(WithImportCommander(mock))
+
+			stdout := &bytes.Buffer{}
+			cmd.SetOut(stdout)
+			cmd.SetErr(&bytes.Buffer{})
+			cmd.SetIn(strings.NewReader(tt.stdin))
+			cmd.SetArgs(tt.args)
+
+			err := cmd.Execute()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if tt.wantStdout != "" {
+				if stdout.String() != tt.wantStdout {
+					t.Errorf("stdout = %q, want %q", stdout.String(), tt.wantStdout)
+				}
+				if len(mock.state.Worktrees) != 1 || mock.state.Worktrees[0].LockReason != "on usb" {
+					t.Errorf("state = %+v, want the exported state", mock.state)
+				}
+				if mock.opts.Check != tt.wantCheck {
+					t.Errorf("Check = %v, want %v", mock.opts.Check, tt.wantCheck)
+				}
+			}
+		})
+	}
+}
+
+// mockAdoptCommander is a test double for AdoptCommander interface.
+type mockAdoptCommander struct {
+	result   core.AdoptResult
+	branches []string
+	opts     core.AdoptOptions
+}
+
+func (m *mockAdoptCommander) Run(ctx context.Context, branches []string, cwd string, opts core.AdoptOptions) (core.AdoptResult, error) {
+	m.branches = branches
+	m.opts = opts
+	result := m.result
+	result.Check = opts.Check
+	return result, nil
+}
+
+func TestAdoptCmd(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		args         []string
+		result       core.AdoptResult
+		wantErr      string
+		wantStdout   string
+		wantBranches []string
+		wantOpts     core.AdoptOptions
+	}{
+		{
+			name: "check_move",
+			args: []string{"adopt", "--check", "--move", "feat/a"},
+			result: core.AdoptResult{Worktrees: []core.AdoptedWorktree{
+				{Branch: "feat/a", OldPath: "/src/a", WorktreePath: "/wt/a"},
+			}},
+			wantStdout:   "Would adopt feat/a: /src/a -> /wt/a (0 symlinks)\n",
+			wantBranches: []string{"feat/a"},
+			wantOpts:     core.AdoptOptions{Move: true, Check: true},
+		},
+		{
+			name: "failed_worktree_returns_error",
+			args: []string{"adopt"},
+			result: core.AdoptResult{Worktrees: []core.AdoptedWorktree{
+				{Branch: "feat/a", WorktreePath: "/src/a", Err: errors.New("worktree is locked")},
+			}},
+			wantErr: "failed to adopt 1 worktree(s)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mock := &mockAdoptCommander{result: tt.result}
+			cmd := NewRootCmd(WithAdoptCommander(mock))
+
+			stdout := &bytes.Buffer{}
+			cmd.SetOut(stdout)
+			cmd.SetErr(&bytes.Buffer{})
+			cmd.SetArgs(tt.args)
+
+			err := cmd.Execute()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if stdout.String() != tt.wantStdout {
+				t.Errorf("stdout = %q, want %q", stdout.String(), tt.wantStdout)
+			}
+			if !slices.Equal(mock.branches, tt.wantBranches) {
+				t.Errorf("branches = %v, want %v", mock.branches, tt.wantBranches)
+			}
+			if mock.opts != tt.wantOpts {
+				t.Errorf("opts = %+v, want %+v", mock.opts, tt.wantOpts)
+			}
+		})
+	}
+}
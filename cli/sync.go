@@ -0,0 +1,214 @@
+package cli
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/708u/twig/core"
+	"github.com/708u/twig/format"
+	"github.com/spf13/cobra"
+)
+
+// newSyncCmd creates the twig sync command.
+func (a *app) newSyncCmd() *cobra.Command {
+	syncCmd := &cobra.Command{
+		Use:   "sync [<branch>...]",
+		Short: "Sync symlinks and submodules from source worktree",
+		Long: `Sync symlinks and submodules from source worktree to target worktrees.
+
+By default, syncs to the current worktree. Use --all to sync all worktrees
+except main. Source is determined by --source flag or default_source config.
+Without either (and without targets), the source is inferred from
+origin/HEAD, main, or master, with an offer to save it as default_source.
+
+Examples:
+  # Sync current worktree from default_source
+  twig sync
+
+  # Sync specific worktrees
+  twig sync feat/a feat/b
+
+  # Sync all worktrees (except main)
+  twig sync --all
+
+  # Sync from a specific source branch
+  twig sync --source develop
+
+  # Preview what would be synced
+  twig sync --check
+
+  # Also remove symlinks whose source or pattern was removed
+  twig sync --delete-stale
+
+  # Fix symlinks without initializing submodules
+  twig sync --symlinks-only
+
+  # Sync automatically after each pull in the source worktree
+  twig hook install post-merge`,
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			branches, err := completeWorktreeBranches(cmd)
+			if err != nil {
+				return nil, cobra.ShellCompDirectiveError
+			}
+			// Exclude already-specified branches from suggestions
+			available := make([]string, 0, len(branches))
+			for _, b := range branches {
+				if !slices.Contains(args, b) {
+					available = append(available, b)
+				}
+			}
+			return available, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			verbosity, _ := cmd.Flags().GetCount("verbose")
+			verbose := verbosity >= 1
+			check, _ := cmd.Flags().GetBool("check")
+			all, _ := cmd.Flags().GetBool("all")
+			includeMain, _ := cmd.Flags().GetBool("include-main")
+			source, _ := cmd.Flags().GetString("source")
+			deleteStale, _ := cmd.Flags().GetBool("delete-stale")
+			quiet, _ := cmd.Flags().GetBool("quiet")
+			symlinksOnly, _ := cmd.Flags().GetBool("symlinks-only")
+			submodulesOnly, _ := cmd.Flags().GetBool("submodules-only")
+
+			// --all and specific targets are mutually exclusive
+			if all && len(args) > 0 {
+				return fmt.Errorf("cannot use --all with specific targets")
+			}
+			if includeMain && !all {
+				return fmt.Errorf("--include-main requires --all")
+			}
+			if symlinksOnly && submodulesOnly {
+				return fmt.Errorf("cannot use --symlinks-only and --submodules-only together")
+			}
+			if submodulesOnly && deleteStale {
+				return fmt.Errorf("cannot use --submodules-only and --delete-stale together")
+			}
+
+			// Create logger early so git operations are logged
+			idGen := core.GenerateCommandID
+			if a.commandIDGenerator != nil {
+				idGen = a.commandIDGenerator
+			}
+			log := createLogger(cmd.ErrOrStderr(), verbosity, a.logFormat, idGen)
+
+			// Resolve source: CLI --source > config default_source > current worktree
+			git := core.NewGitRunner(a.cwd, append(a.gitOptions(), core.WithLogger(log))...)
+			if source == "" {
+				source = a.cfg.DefaultSource
+			}
+
+			// Without a source or targets the current worktree would sync
+			// with itself, so infer the source from the repository
+			if source == "" && !all && len(args) == 0 {
+				inferred, err := core.InferDefaultSource(cmd.Context(), git)
+				if err != nil {
+					return fmt.Errorf("failed to infer default source: %w", err)
+				}
+				if inferred == nil {
+					return fmt.Errorf("cannot sync: no source specified and no targets specified\nhint: use --source flag or set default_source in config")
+				}
+				source = inferred.Branch
+				fmt.Fprintf(cmd.ErrOrStderr(), "note: default_source is not set, using %s (%s)\n", source, inferred.Reason)
+				offerSaveDefaultSource(cmd, a.cwd, source)
+			}
+
+			var sourcePath string
+			var sourceCfg *core.Config
+			if source == "" {
+				// Use current worktree as source
+				sourcePath, _ = repositoryRoot(cmd.Context(), a.cwd)
+				sourceCfg = a.cfg
+				// Get current branch name for result
+				branch, err := git.CurrentBranch(cmd.Context())
+				if err != nil {
+					return fmt.Errorf("failed to get current branch: %w", err)
+				}
+				source = branch
+			} else {
+				// Find source worktree and load config
+				sourceWT, err := git.WorktreeFindByBranch(cmd.Context(), source)
+				if err != nil {
+					return fmt.Errorf("failed to find worktree for branch %q: %w", source, err)
+				}
+				sourcePath = sourceWT.Path
+
+				configResult, err := a.loadConfig(cmd.Context(), sourcePath, a.profileFlag)
+				if err != nil {
+					return fmt.Errorf("failed to load config from source worktree: %w", err)
+				}
+				for _, w := range configResult.Warnings {
+					fmt.Fprintln(cmd.ErrOrStderr(), "warning:", w)
+				}
+				sourceCfg = configResult.Config
+			}
+
+			var syncCmdRunner SyncCommander
+			if a.syncCommander != nil {
+				syncCmdRunner = a.syncCommander
+			} else {
+				syncCmdRunner = core.NewDefaultSyncCommand(sourcePath, log, a.gitOptions()...)
+				if !check {
+					release, err := a.lockRepository(cmd, a.cwd, log)
+					if err != nil {
+						return err
+					}
+					defer release()
+				}
+			}
+
+			result, err := syncCmdRunner.Run(cmd.Context(), args, a.cwd, core.SyncOptions{
+				Check:              check,
+				All:                all,
+				IncludeMain:        includeMain,
+				Source:             source,
+				SourcePath:         sourcePath,
+				Symlinks:           sourceCfg.Symlinks,
+				InitSubmodules:     sourceCfg.ShouldInitSubmodules(),
+				SubmoduleReference: sourceCfg.ShouldUseSubmoduleReference() || cmd.Flags().Changed("submodule-reference"),
+				SubmoduleRefDir:    sourceCfg.SubmoduleRefDir,
+				SubmodulePaths:     sourceCfg.SubmodulePaths,
+				NoSubmoduleRecurse: !sourceCfg.ShouldInitSubmodulesRecursively(),
+				DeleteStale:        deleteStale,
+				StrictSymlinks:     sourceCfg.ShouldUseStrictSymlinks(),
+				SymlinkStyle:       sourceCfg.SymlinkTargetStyle(),
+				EnvFile:            sourceCfg.ShouldWriteEnvFile(),
+				EnvFileVars:        sourceCfg.EnvFileVars,
+				SymlinksOnly:       symlinksOnly,
+				SubmodulesOnly:     submodulesOnly,
+				Verbose:            verbose,
+			})
+			if err != nil {
+				return err
+			}
+
+			formatted := result.Format(core.SyncFormatOptions{Verbose: verbose, Quiet: quiet, ColorEnabled: format.IsColorEnabled()})
+			if formatted.Stderr != "" {
+				fmt.Fprint(cmd.ErrOrStderr(), formatted.Stderr)
+			}
+			fmt.Fprint(cmd.OutOrStdout(), formatted.Stdout)
+
+			if result.HasErrors() {
+				return fmt.Errorf("failed to sync %d target(s)", result.ErrorCount())
+			}
+			return nil
+		},
+	}
+	syncCmd.Flags().String("source", "", "Source branch (default: default_source config)")
+	syncCmd.Flags().BoolP("all", "a", false, "Sync all worktrees (except main)")
+	syncCmd.Flags().Bool("include-main", false, "With --all, also sync the main worktree (unless it is the source)")
+	syncCmd.Flags().Bool("check", false, "Show what would be synced (dry-run)")
+	syncCmd.Flags().Bool("delete-stale", false, "Remove twig-managed symlinks that are broken or no longer configured")
+	syncCmd.Flags().Bool("symlinks-only", false, "Sync only symlinks (skip submodules and .twig.env)")
+	syncCmd.Flags().Bool("submodules-only", false, "Sync only submodules (skip symlinks and .twig.env)")
+	syncCmd.Flags().Bool("submodule-reference", false, "Use main worktree as reference for submodule init")
+	syncCmd.RegisterFlagCompletionFunc("source", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		branches, err := completeWorktreeBranches(cmd)
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+		return branches, cobra.ShellCompDirectiveNoFileComp
+	})
+	a.notifyOnFinish(syncCmd)
+	return syncCmd
+}
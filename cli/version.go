@@ -0,0 +1,25 @@
+package cli
+
+import (
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// newVersionCmd creates the twig version command.
+func (a *app) newVersionCmd() *cobra.Command {
+	versionCmd := &cobra.Command{
+		Use:   "version",
+		Short: "Print version information",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 1, ' ', 0)
+			fmt.Fprintf(w, "version:\t%s\n", a.version)
+			fmt.Fprintf(w, "commit:\t%s\n", a.commit)
+			fmt.Fprintf(w, "date:\t%s\n", a.date)
+			w.Flush()
+		},
+	}
+	return versionCmd
+}
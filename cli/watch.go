@@ -0,0 +1,169 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/708u/twig/core"
+	"github.com/708u/twig/format"
+	"github.com/spf13/cobra"
+)
+
+// newWatchCmd creates the twig watch command.
+func (a *app) newWatchCmd() *cobra.Command {
+	watchCmd := &cobra.Command{
+		Use:   "watch",
+		Short: "Re-sync worktrees whenever symlink sources or config change",
+		Long: `Watch the source worktree and sync all worktrees (except main) whenever
+a file matching the symlinks patterns appears or disappears, or the
+config files (.twig/settings.toml, .twig/settings.local.toml) change.
+
+All worktrees are synced once at start. Config is reloaded on every
+change, so new patterns and env_file_vars take effect without restarting.
+Submodules are not initialized; use twig sync for those.
+
+The source worktree is polled every --interval. The repository lock is
+only held while syncing. A sync that fails, for example because the lock
+is busy, is reported and retried with the next change; a config that
+cannot be loaded stops the watch. Stop with Ctrl-C.
+
+Examples:
+  # Watch the default_source worktree
+  twig watch
+
+  # Watch develop, polling every 500ms
+  twig watch --source develop --interval 500ms
+
+  # Also remove symlinks whose source or pattern was removed
+  twig watch --delete-stale`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			verbosity, _ := cmd.Flags().GetCount("verbose")
+			verbose := verbosity >= 1
+			quiet, _ := cmd.Flags().GetBool("quiet")
+			source, _ := cmd.Flags().GetString("source")
+			interval, _ := cmd.Flags().GetDuration("interval")
+			deleteStale, _ := cmd.Flags().GetBool("delete-stale")
+
+			if interval <= 0 {
+				return fmt.Errorf("--interval must be positive")
+			}
+
+			idGen := core.GenerateCommandID
+			if a.commandIDGenerator != nil {
+				idGen = a.commandIDGenerator
+			}
+			log := createLogger(cmd.ErrOrStderr(), verbosity, a.logFormat, idGen)
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+			defer stop()
+			cmd.SetContext(ctx)
+
+			// Resolve source: CLI --source > config default_source > current worktree
+			git := core.NewGitRunner(a.cwd, append(a.gitOptions(), core.WithLogger(log))...)
+			if source == "" {
+				source = a.cfg.DefaultSource
+			}
+			var sourcePath string
+			if source == "" {
+				sourcePath, _ = repositoryRoot(ctx, a.cwd)
+				branch, err := git.CurrentBranch(ctx)
+				if err != nil {
+					return fmt.Errorf("failed to get current branch: %w", err)
+				}
+				source = branch
+			} else {
+				sourceWT, err := git.WorktreeFindByBranch(ctx, source)
+				if err != nil {
+					return fmt.Errorf("failed to find worktree for branch %q: %w", source, err)
+				}
+				sourcePath = sourceWT.Path
+			}
+
+			// syncAll reloads config from the source worktree and syncs all
+			// worktrees, returning the symlink patterns to watch.
+			var symlinks []string
+			syncAll := func(ctx context.Context, change core.WatchChange) ([]string, error) {
+				if len(change.Paths) > 0 && !quiet {
+					fmt.Fprintf(cmd.ErrOrStderr(), "changed: %s\n", strings.Join(change.Paths, ", "))
+				}
+
+				configResult, err := a.loadConfig(ctx, sourcePath, a.profileFlag)
+				if err != nil {
+					return symlinks, &core.WatchConfigError{Err: err}
+				}
+				for _, w := range configResult.Warnings {
+					fmt.Fprintln(cmd.ErrOrStderr(), "warning:", w)
+				}
+				sourceCfg := configResult.Config
+				symlinks = sourceCfg.Symlinks
+
+				release, err := a.lockRepository(cmd, a.cwd, log)
+				if err != nil {
+					return symlinks, err
+				}
+				defer release()
+
+				result, err := core.NewDefaultSyncCommand(sourcePath, log, a.gitOptions()...).Run(ctx, nil, a.cwd, core.SyncOptions{
+					All:            true,
+					Source:         source,
+					SourcePath:     sourcePath,
+					Symlinks:       sourceCfg.Symlinks,
+					DeleteStale:    deleteStale,
+					StrictSymlinks: sourceCfg.ShouldUseStrictSymlinks(),
+					SymlinkStyle:   sourceCfg.SymlinkTargetStyle(),
+					EnvFile:        sourceCfg.ShouldWriteEnvFile(),
+					EnvFileVars:    sourceCfg.EnvFileVars,
+					Verbose:        verbose,
+				})
+				if err != nil {
+					return symlinks, err
+				}
+				formatted := result.Format(core.SyncFormatOptions{Verbose: verbose, Quiet: quiet, ColorEnabled: format.IsColorEnabled()})
+				if formatted.Stderr != "" {
+					fmt.Fprint(cmd.ErrOrStderr(), formatted.Stderr)
+				}
+				fmt.Fprint(cmd.OutOrStdout(), formatted.Stdout)
+				return symlinks, nil
+			}
+
+			warn := func(err error) {
+				fmt.Fprintln(cmd.ErrOrStderr(), "warning:", err)
+			}
+			symlinks, err := syncAll(ctx, core.WatchChange{})
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+				var configErr *core.WatchConfigError
+				if errors.As(err, &configErr) {
+					return err
+				}
+				warn(err)
+			}
+			if !quiet {
+				fmt.Fprintf(cmd.ErrOrStderr(), "watching %s (%s), press Ctrl-C to stop\n", source, sourcePath)
+			}
+			return core.NewDefaultWatchCommand(log, a.gitOptions()...).Run(ctx, symlinks, core.WatchOptions{
+				SourcePath: sourcePath,
+				Interval:   interval,
+				OnError:    warn,
+			}, syncAll)
+		},
+	}
+	watchCmd.Flags().String("source", "", "Source branch (default: default_source config)")
+	watchCmd.Flags().Duration("interval", core.DefaultWatchInterval, "How often to poll the source worktree")
+	watchCmd.Flags().Bool("delete-stale", false, "Remove twig-managed symlinks that are broken or no longer configured")
+	watchCmd.RegisterFlagCompletionFunc("source", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		branches, err := completeWorktreeBranches(cmd)
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+		return branches, cobra.ShellCompDirectiveNoFileComp
+	})
+	return watchCmd
+}
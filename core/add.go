@@ -0,0 +1,1605 @@
+package core
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// remoteLookupTimeout is the shared deadline for asking the remotes
+// about a branch when lookup_remote_branches is enabled.
+const remoteLookupTimeout = 10 * time.Second
+
+// AddCommand creates git worktrees with symlinks.
+type AddCommand struct {
+	FS                 FileSystem
+	Git                *GitRunner
+	Config             *Config
+	Log                *slog.Logger
+	Sync               bool
+	CarryFrom          string
+	FilePatterns       []string
+	IncludeIgnored     bool
+	Lock               bool
+	LockReason         string
+	InitSubmodules     bool
+	SubmoduleReference bool
+	NoPrefix           bool
+	CI                 bool
+	SparsePaths        []string
+	Track              bool
+	Upstream           string
+	PushRemote         string
+	Restore            bool
+	Detach             bool
+	NoSymlinks         bool
+	ExtraSymlinks      []string
+	BaseDir            string
+	NoCheckout         bool
+	StartPoint         string
+	Fetch              bool
+	OnExists           OnExists
+	Repair             bool
+	Description        string
+	Path               string
+	PR                 int
+	Provenance         Provenance
+	Forge              *ForgeClient // Looks up PR titles for PR (nil = disabled)
+}
+
+// OnExists selects what twig add does when the worktree directory already
+// exists but is not a registered worktree, e.g. after a crashed run.
+type OnExists string
+
+const (
+	OnExistsFail    OnExists = "fail"    // Report an error (default)
+	OnExistsAdopt   OnExists = "adopt"   // Register the files in the directory as the worktree
+	OnExistsReplace OnExists = "replace" // Delete the directory and create the worktree
+)
+
+// adoptSuffix names the scratch directory a worktree is created in before
+// its .git file is moved into the adopted directory.
+const adoptSuffix = ".twig-adopt"
+
+// replaceSuffix names the directory a replaced directory is moved to
+// until the new worktree is set up.
+const replaceSuffix = ".twig-replace"
+
+// AddOptions holds options for the add command.
+type AddOptions struct {
+	Sync               bool
+	CarryFrom          string   // empty: no carry, non-empty: resolved path to carry from
+	FilePatterns       []string // file patterns to carry (empty means all files)
+	IncludeIgnored     bool     // carry ignored files matched by FilePatterns too
+	Lock               bool
+	LockReason         string
+	InitSubmodules     bool
+	SubmoduleReference bool
+	NoPrefix           bool // use name verbatim, ignoring branch_prefix and branch_aliases
+
+	// CI creates a bare-bones worktree for ephemeral CI agents: no symlinks
+	// or submodules, and files are checked out only after SparsePaths
+	// (directories, cone mode) are applied. Empty SparsePaths checks out
+	// everything.
+	CI          bool
+	SparsePaths []string
+
+	// Track sets the new branch's upstream to an existing remote-tracking
+	// branch: Upstream ("<remote>/<branch>") if set, otherwise the branch
+	// of the same name on the only remote that has it.
+	Track    bool
+	Upstream string
+
+	// PushRemote, if set, pushes the new branch to this remote with -u.
+	PushRemote string
+
+	// Restore recreates a branch removed by twig remove or twig clean at
+	// the commit recorded in the audit log, instead of the source HEAD.
+	Restore bool
+
+	// Detach treats the name as a commit (hash, tag, or branch) and checks
+	// it out with a detached HEAD, without creating a branch. The name is
+	// used verbatim for the worktree directory.
+	Detach bool
+
+	// NoSymlinks skips the configured symlinks for this worktree only.
+	// ExtraSymlinks are patterns linked in addition (or, with NoSymlinks,
+	// instead).
+	NoSymlinks    bool
+	ExtraSymlinks []string
+
+	// BaseDir replaces worktree_destination_base_dir for this invocation
+	// (absolute path). It is created if missing. Later commands find the
+	// worktree through git, so nothing about it is recorded in the config.
+	BaseDir string
+
+	// NoCheckout creates the worktree without checking out any files, for
+	// a follow-up tool (sparse checkout script, build system) to populate.
+	// Symlinks and submodules are left to a later twig sync; the env file
+	// and hooks are handled as usual.
+	NoCheckout bool
+
+	// StartPoint is the ref a new branch starts at instead of the source
+	// HEAD (see ResolveAddSource). Existing branches are checked out as is.
+	StartPoint string
+
+	// Fetch fetches a branch missing locally from the remotes before
+	// creating it as a new branch, so that a branch pushed after the last
+	// fetch is checked out instead. fetch_on_add enables it by default.
+	Fetch bool
+
+	// OnExists handles a destination directory that exists but is not a
+	// registered worktree (empty = OnExistsFail). Adopt keeps the files in
+	// the directory instead of checking the branch out over them; replace
+	// deletes the directory first.
+	OnExists OnExists
+
+	// Repair prunes a stale worktree entry left at the destination or for
+	// the branch by an interrupted run (see StaleWorktreeError) instead of
+	// failing.
+	Repair bool
+
+	// Description sets branch.<name>.description, the text shown by git
+	// branch --edit-description, twig list -l and twig clean --check.
+	Description string
+
+	// Path places the worktree at this absolute path instead of
+	// <base dir>/<name>, e.g. to recreate an exported layout (twig import).
+	Path string
+
+	// PR checks out pull request PR: its head is fetched from origin and
+	// a new branch named after pr_branch_template starts at it. The name
+	// passed to Run, if any, replaces the template.
+	PR int
+
+	// Provenance holds the twig version and command-line flags recorded
+	// in the provenance marker of the new worktree. Run fills in the
+	// rest.
+	Provenance Provenance
+}
+
+// NewAddCommand creates an AddCommand with explicit dependencies (for testing).
+func NewAddCommand(fs FileSystem, git *GitRunner, cfg *Config, log *slog.Logger, opts AddOptions) *AddCommand {
+	if log == nil {
+		log = NewNopLogger()
+	}
+	return &AddCommand{
+		FS:                 fs,
+		Git:                git,
+		Config:             cfg,
+		Log:                log,
+		Sync:               opts.Sync,
+		CarryFrom:          opts.CarryFrom,
+		FilePatterns:       opts.FilePatterns,
+		IncludeIgnored:     opts.IncludeIgnored,
+		Lock:               opts.Lock,
+		LockReason:         opts.LockReason,
+		InitSubmodules:     opts.InitSubmodules,
+		SubmoduleReference: opts.SubmoduleReference,
+		NoPrefix:           opts.NoPrefix,
+		CI:                 opts.CI,
+		SparsePaths:        opts.SparsePaths,
+		Track:              opts.Track,
+		Upstream:           opts.Upstream,
+		PushRemote:         opts.PushRemote,
+		Restore:            opts.Restore,
+		Detach:             opts.Detach,
+		NoSymlinks:         opts.NoSymlinks,
+		ExtraSymlinks:      opts.ExtraSymlinks,
+		BaseDir:            opts.BaseDir,
+		NoCheckout:         opts.NoCheckout,
+		StartPoint:         opts.StartPoint,
+		Fetch:              opts.Fetch,
+		OnExists:           opts.OnExists,
+		Repair:             opts.Repair,
+		Description:        opts.Description,
+		Path:               opts.Path,
+		PR:                 opts.PR,
+		Provenance:         opts.Provenance,
+	}
+}
+
+// AddSource is where twig add takes its config and symlinks from, and
+// where new branches start.
+type AddSource struct {
+	Dir        string // Worktree to load config and create symlinks from
+	StartPoint string // Ref new branches start at (empty = HEAD of Dir)
+}
+
+// ResolveAddSource resolves the --source (or default_source) branch to the
+// worktree that has it checked out. A local branch checked out nowhere, or
+// a remote-tracking branch such as origin/main, falls back to the main
+// worktree with the ref as the start point, as with
+// git worktree add -b <branch> <path> <ref>.
+func ResolveAddSource(ctx context.Context, git *GitRunner, source string) (AddSource, error) {
+	worktrees, err := git.WorktreeList(ctx)
+	if err != nil {
+		return AddSource{}, err
+	}
+	if wt := findWorktreeByBranch(worktrees, source); wt != nil {
+		return AddSource{Dir: wt.Path}, nil
+	}
+
+	local, err := git.LocalBranchExists(ctx, source)
+	if err != nil {
+		return AddSource{}, fmt.Errorf("failed to check branch existence: %w", err)
+	}
+	remote := false
+	if !local {
+		remote, err = git.RemoteBranchExists(ctx, source)
+		if err != nil {
+			return AddSource{}, fmt.Errorf("failed to check branch existence: %w", err)
+		}
+	}
+	if !local && !remote {
+		return AddSource{}, fmt.Errorf("branch %q is not checked out in any worktree and is not a local or remote-tracking branch", source)
+	}
+
+	mainPath, err := git.MainWorktreePath(ctx)
+	if err != nil {
+		return AddSource{}, fmt.Errorf("failed to find main worktree: %w", err)
+	}
+	return AddSource{Dir: mainPath, StartPoint: source}, nil
+}
+
+// NewDefaultAddCommand creates an AddCommand with production defaults.
+// PR titles are looked up when a forge is configured.
+func NewDefaultAddCommand(cfg *Config, log *slog.Logger, opts AddOptions, gitOpts ...GitRunnerOption) *AddCommand {
+	git := newDefaultGitRunner(cfg.WorktreeSourceDir, log, gitOpts)
+	fs := git.defaultFS()
+	cmd := NewAddCommand(fs, git, cfg, log, opts)
+	if opts.PR > 0 {
+		cmd.Forge = NewDefaultForgeClient(cfg, fs, git, log)
+	}
+	return cmd
+}
+
+// SymlinkResult holds information about a symlink operation.
+type SymlinkResult struct {
+	Src     string
+	Dst     string
+	Skipped bool
+	Reason  string
+	Warning string // Set when the link was created but its source is suspicious
+
+	// Set by sync --check from the link found at Dst
+	State  SymlinkState
+	Target string // Current target of a SymlinkWrongTarget link
+}
+
+// SymlinkState is what sync --check finds at a symlink destination.
+type SymlinkState string
+
+const (
+	SymlinkMissing     SymlinkState = "missing"      // Nothing at the destination yet
+	SymlinkCorrect     SymlinkState = "correct"      // Already points at the source
+	SymlinkWrongTarget SymlinkState = "wrong_target" // Points somewhere else
+)
+
+// SubmoduleInitResult holds information about submodule initialization.
+type SubmoduleInitResult struct {
+	Attempted             bool     // true if initialization was attempted
+	Count                 int      // number of initialized submodules
+	Skipped               bool     // true if initialization failed
+	Reason                string   // reason for failure (warning message)
+	NoReferenceSubmodules []string // submodules that couldn't use reference
+	UnmatchedPaths        []string // submodule_paths entries that matched no submodule
+}
+
+// UpstreamResult holds the result of setting up upstream tracking.
+type UpstreamResult struct {
+	Upstream string // Remote-tracking branch set as upstream ("<remote>/<branch>")
+	Pushed   bool   // true if the branch was pushed to create the upstream
+	Skipped  bool   // true if setting the upstream failed
+	Reason   string // reason for failure (warning message)
+}
+
+// RemovedBranch is an earlier removal of a branch, read from the audit log.
+type RemovedBranch struct {
+	HEAD      string        // Commit the branch pointed to when removed
+	RemovedAt time.Time     // When the branch was removed
+	Age       time.Duration // Time since removal when looked up
+}
+
+// ShortHEAD returns the first 7 characters of the recorded commit hash.
+func (b RemovedBranch) ShortHEAD() string {
+	if len(b.HEAD) >= 7 {
+		return b.HEAD[:7]
+	}
+	return b.HEAD
+}
+
+// HookResult holds the result of a single hook execution.
+type HookResult struct {
+	Command string
+	Output  []byte
+	Err     error
+}
+
+// AddResult holds the result of an add operation.
+type AddResult struct {
+	Branch         string
+	WorktreePath   string
+	Symlinks       []SymlinkResult
+	GitOutput      []byte
+	ChangesSynced  bool
+	ChangesCarried bool
+	CarryLeft      string // Why carried changes could not be removed from the source, and where they are (changes were applied)
+	IgnoredSkipped int    // Ignored paths matched by FilePatterns that were not carried
+	SubmoduleInit  SubmoduleInitResult
+	Upstream       UpstreamResult
+	HookResults    []HookResult
+	Removed        *RemovedBranch // Earlier removal of the newly created branch (hint only)
+	Restored       *RemovedBranch // Removal the branch was recreated from (--restore)
+	DetachedAt     string         // Commit checked out with a detached HEAD (--detach)
+	EnvFile        string         // Generated .twig.env path (empty = env_file disabled)
+	NoCheckout     bool           // Files were not checked out (--no-checkout)
+	SetupDeferred  bool           // Symlinks or submodules were left to twig sync (--no-checkout)
+	Adopted        bool           // An existing directory was registered as the worktree (OnExistsAdopt)
+	AdoptedChanges bool           // The adopted files differ from the checked out commit
+	Replaced       bool           // An existing directory was replaced (OnExistsReplace)
+	ReplacedLeft   string         // Where the replaced directory was moved, if it could not be deleted
+	Repaired       string         // Path of the stale worktree entry that was pruned (--repair)
+	Description    string         // Branch description that was set (--description)
+	DescriptionErr error          // Failure to set Description (the worktree was created)
+	ProvenanceErr  error          // Failure to record the provenance marker (the worktree was created)
+	PR             *PullRequest   // Pull request checked out (--pr)
+	PROutdated     bool           // The branch already existed at another commit than the PR head
+	CaseCollision  *CaseCollision // Name differing only in case from an existing one (warned, not refused)
+	Err            error          // nil if success (set when adding multiple branches)
+}
+
+// AddBatchResult aggregates results from adding multiple worktrees.
+type AddBatchResult struct {
+	Added []AddResult
+}
+
+// HasErrors returns true if any errors occurred.
+func (r AddBatchResult) HasErrors() bool {
+	for i := range r.Added {
+		if r.Added[i].Err != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// ErrorCount returns the number of failed additions.
+func (r AddBatchResult) ErrorCount() int {
+	count := 0
+	for i := range r.Added {
+		if r.Added[i].Err != nil {
+			count++
+		}
+	}
+	return count
+}
+
+// Format formats the AddBatchResult for display.
+// Failed branches are reported on stderr; successful ones use AddResult.Format.
+func (r AddBatchResult) Format(opts AddFormatOptions) FormatResult {
+	var stdout, stderr strings.Builder
+
+	for i := range r.Added {
+		res := &r.Added[i]
+		if res.Err != nil {
+			fmt.Fprintf(&stderr, "error: %s: %v\n", res.Branch, res.Err)
+			continue
+		}
+		formatted := res.Format(opts)
+		stdout.WriteString(formatted.Stdout)
+		stderr.WriteString(formatted.Stderr)
+	}
+
+	if opts.Summary && !opts.Quiet && !opts.Porcelain && len(r.Added) > 0 {
+		stdout.WriteString(r.formatSummary())
+	}
+
+	return FormatResult{Stdout: stdout.String(), Stderr: stderr.String()}
+}
+
+// formatSummary renders one row per branch with its outcome.
+func (r AddBatchResult) formatSummary() string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "\nSummary: %d added, %d failed\n", len(r.Added)-r.ErrorCount(), r.ErrorCount())
+
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "BRANCH\tSTATUS\tPATH")
+	for i := range r.Added {
+		res := &r.Added[i]
+		status := "added"
+		if res.Err != nil {
+			status = "failed"
+		}
+		path := res.WorktreePath
+		if path == "" {
+			path = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", res.Branch, status, path)
+	}
+	w.Flush()
+	return buf.String()
+}
+
+// AddFormatOptions configures add output formatting.
+type AddFormatOptions struct {
+	Verbose bool
+	Quiet   bool
+	Summary bool // Append a per-branch summary table (batch output only)
+
+	// Porcelain prints one stable, machine-readable record per worktree
+	// (see AddResult.Format); used by --ci.
+	Porcelain bool
+}
+
+// Format formats the AddResult for display.
+//
+// Porcelain records mirror git worktree list --porcelain:
+//
+//	worktree <path>
+//	branch <branch>
+//	<blank line>
+func (r AddResult) Format(opts AddFormatOptions) FormatResult {
+	if opts.Porcelain {
+		return r.formatPorcelain()
+	}
+	if opts.Quiet {
+		return r.formatQuiet(opts)
+	}
+	return r.formatDefault(opts)
+}
+
+// Warnings returns the warnings of the add, in the order Format prints
+// them. Hook failures come last.
+func (r AddResult) Warnings() []Warning {
+	return append(r.setupWarnings(), hookWarnings(r.HookResults)...)
+}
+
+// setupWarnings returns the warnings of creating and setting up the
+// worktree, before hooks ran.
+func (r AddResult) setupWarnings() []Warning {
+	warnings := symlinkWarnings(r.Symlinks)
+	if r.CarryLeft != "" {
+		warnings = append(warnings, Warning{Code: WarningCarryLeft, Subject: r.Branch, Message: r.CarryLeft})
+	}
+	warnings = append(warnings, r.SubmoduleInit.Warnings()...)
+	if r.Upstream.Skipped {
+		warnings = append(warnings, Warning{Code: WarningUpstreamFailed, Subject: r.Branch, Message: r.Upstream.Reason})
+	}
+	if r.AdoptedChanges {
+		warnings = append(warnings, Warning{
+			Code:    WarningAdoptedChanges,
+			Subject: r.WorktreePath,
+			Message: fmt.Sprintf("adopted files in %s differ from %s; review them with git status", r.WorktreePath, r.Branch),
+		})
+	}
+	if r.ReplacedLeft != "" {
+		warnings = append(warnings, Warning{
+			Code:    WarningReplacedLeft,
+			Subject: r.WorktreePath,
+			Message: fmt.Sprintf("the replaced directory could not be deleted and is at %s", r.ReplacedLeft),
+		})
+	}
+	if r.PROutdated {
+		warnings = append(warnings, Warning{
+			Code:    WarningPROutdated,
+			Subject: r.Branch,
+			Message: fmt.Sprintf("branch %s already exists and was checked out as is; the head of #%d is %s",
+				r.Branch, r.PR.Number, shortHash(r.PR.Commit)),
+		})
+	}
+	if r.CaseCollision != nil {
+		warnings = append(warnings, Warning{
+			Code:    WarningCaseCollision,
+			Subject: r.Branch,
+			Message: fmt.Sprintf("%s; they cannot both exist on case-insensitive filesystems", r.CaseCollision),
+		})
+	}
+	if r.DescriptionErr != nil {
+		warnings = append(warnings, Warning{Code: WarningDescriptionFailed, Subject: r.Branch, Message: r.DescriptionErr.Error()})
+	}
+	if r.ProvenanceErr != nil {
+		warnings = append(warnings, Warning{Code: WarningProvenanceFailed, Subject: r.WorktreePath, Message: r.ProvenanceErr.Error()})
+	}
+	return warnings
+}
+
+// formatQuiet outputs only the worktree path. Warnings are kept on stderr.
+func (r AddResult) formatQuiet(opts AddFormatOptions) FormatResult {
+	return FormatResult{Stdout: r.WorktreePath + "\n", Stderr: r.formatDefault(opts).Stderr}
+}
+
+// formatPorcelain outputs the porcelain record. Only hook failures are
+// reported on stderr, as they are the only warnings a CI worktree can have.
+func (r AddResult) formatPorcelain() FormatResult {
+	var stderr strings.Builder
+	writeWarnings(&stderr, hookWarnings(r.HookResults))
+	stdout := fmt.Sprintf("worktree %s\nbranch %s\n\n", r.WorktreePath, r.Branch)
+	if r.DetachedAt != "" {
+		stdout = fmt.Sprintf("worktree %s\nHEAD %s\ndetached\n\n", r.WorktreePath, r.DetachedAt)
+	}
+	return FormatResult{Stdout: stdout, Stderr: stderr.String()}
+}
+
+// formatDefault outputs the default or verbose format.
+func (r AddResult) formatDefault(opts AddFormatOptions) FormatResult {
+	var stdout, stderr strings.Builder
+
+	var createdCount int
+	for _, s := range r.Symlinks {
+		if !s.Skipped {
+			createdCount++
+		}
+	}
+
+	writeWarnings(&stderr, r.setupWarnings())
+
+	if r.Removed != nil {
+		fmt.Fprintf(&stderr, "hint: %s was removed %s at %s; to recreate it from that commit, run:\n"+
+			"  twig remove %s && twig add %s --restore\n",
+			r.Branch, formatAgo(r.Removed.Age), r.Removed.ShortHEAD(), r.Branch, r.Branch)
+	}
+
+	if r.SetupDeferred {
+		fmt.Fprintf(&stderr, "hint: files are not checked out; once they are, run 'twig sync' in %s to set up symlinks and submodules\n", r.WorktreePath)
+	}
+
+	// Output hook results (single pass: warnings to stderr, count successes)
+	var hookRanCount int
+	for _, h := range r.HookResults {
+		if h.Err != nil {
+			fmt.Fprintln(&stderr, h.warning())
+			if len(h.Output) > 0 {
+				stderr.Write(h.Output)
+			}
+		} else {
+			hookRanCount++
+		}
+	}
+
+	if opts.Verbose {
+		if r.Repaired != "" {
+			fmt.Fprintf(&stdout, "Pruned stale worktree entry for %s\n", r.Repaired)
+		}
+		if len(r.GitOutput) > 0 {
+			stdout.Write(r.GitOutput)
+		}
+		switch {
+		case r.Adopted:
+			fmt.Fprintf(&stdout, "Adopted existing directory as worktree at %s\n", r.WorktreePath)
+		case r.Replaced:
+			fmt.Fprintf(&stdout, "Removed existing directory and created worktree at %s\n", r.WorktreePath)
+		default:
+			fmt.Fprintf(&stdout, "Created worktree at %s\n", r.WorktreePath)
+		}
+		for _, s := range r.Symlinks {
+			if !s.Skipped {
+				fmt.Fprintf(&stdout, "Created symlink: %s -> %s\n", s.Dst, s.Src)
+			}
+		}
+		if r.ChangesSynced {
+			stdout.WriteString("Synced uncommitted changes\n")
+		}
+		switch {
+		case r.ChangesCarried && r.CarryLeft != "":
+			stdout.WriteString("Carried uncommitted changes (source still has them, see warning)\n")
+		case r.ChangesCarried:
+			stdout.WriteString("Carried uncommitted changes (source is now clean)\n")
+		}
+		if r.IgnoredSkipped > 0 {
+			fmt.Fprintf(&stdout, "Skipped %d ignored path(s) matched by --file (use --include-ignored to include them)\n", r.IgnoredSkipped)
+		}
+		if r.SubmoduleInit.Attempted && r.SubmoduleInit.Count > 0 {
+			fmt.Fprintf(&stdout, "Initialized %d submodule(s)\n", r.SubmoduleInit.Count)
+		}
+		if r.PR != nil {
+			fmt.Fprintf(&stdout, "Fetched #%d at %s\n", r.PR.Number, shortHash(r.PR.Commit))
+		}
+		if r.EnvFile != "" {
+			fmt.Fprintf(&stdout, "Wrote %s\n", r.EnvFile)
+		}
+		if r.Description != "" && r.DescriptionErr == nil {
+			fmt.Fprintf(&stdout, "Set branch description: %s\n", formatDescription(r.Description))
+		}
+		if r.Upstream.Pushed {
+			fmt.Fprintf(&stdout, "Pushed branch to %s\n", r.Upstream.Upstream)
+		} else if r.Upstream.Upstream != "" && !r.Upstream.Skipped {
+			fmt.Fprintf(&stdout, "Set upstream to %s\n", r.Upstream.Upstream)
+		}
+		for _, h := range r.HookResults {
+			if h.Err == nil {
+				fmt.Fprintf(&stdout, "Ran hook: %s\n", h.Command)
+				if len(h.Output) > 0 {
+					stdout.Write(h.Output)
+				}
+			}
+		}
+	}
+
+	var syncInfo string
+	if r.ChangesSynced {
+		syncInfo = ", synced"
+	} else if r.ChangesCarried {
+		syncInfo = ", carried"
+	}
+
+	var submoduleInfo string
+	if r.SubmoduleInit.Attempted && r.SubmoduleInit.Count > 0 {
+		submoduleInfo = fmt.Sprintf(", %d submodules", r.SubmoduleInit.Count)
+	}
+
+	var upstreamInfo string
+	if r.Upstream.Upstream != "" && !r.Upstream.Skipped {
+		upstreamInfo = ", tracking " + r.Upstream.Upstream
+	}
+
+	var hookInfo string
+	if hookRanCount > 0 {
+		hookInfo = fmt.Sprintf(", %d hooks ran", hookRanCount)
+	}
+
+	var restoreInfo string
+	if r.Restored != nil {
+		restoreInfo = ", restored from " + r.Restored.ShortHEAD()
+	}
+	if r.PR != nil {
+		restoreInfo = fmt.Sprintf(", PR #%d", r.PR.Number)
+	}
+
+	var detachedInfo string
+	if r.DetachedAt != "" {
+		detachedInfo = "detached at " + shortHash(r.DetachedAt) + ", "
+	}
+	var checkoutInfo string
+	if r.NoCheckout {
+		checkoutInfo = "no checkout, "
+	}
+	switch {
+	case r.Adopted:
+		checkoutInfo += "adopted existing directory, "
+	case r.Replaced:
+		checkoutInfo += "replaced existing directory, "
+	}
+	fmt.Fprintf(&stdout, "twig add: %s (%s%s%d symlinks%s%s%s%s%s)\n", r.Branch, detachedInfo, checkoutInfo, createdCount, restoreInfo, syncInfo, submoduleInfo, upstreamInfo, hookInfo)
+
+	return FormatResult{Stdout: stdout.String(), Stderr: stderr.String()}
+}
+
+// Run creates a new worktree for the given name.
+// The name is resolved to a branch via branch_aliases and branch_prefix
+// unless NoPrefix is set; the worktree directory uses the short name.
+func (c *AddCommand) Run(ctx context.Context, name string) (AddResult, error) {
+	var result AddResult
+	result.Branch = name
+
+	// The PR head is fetched first, as the branch is named after the PR
+	noPrefix := c.NoPrefix
+	if c.PR != 0 {
+		if c.PR < 0 {
+			return result, fmt.Errorf("invalid pull request number %d", c.PR)
+		}
+		if c.Detach || c.Restore {
+			return result, fmt.Errorf("--pr cannot be used with --detach or --restore")
+		}
+		pr, err := c.resolvePR(ctx, c.PR)
+		if err != nil {
+			return result, err
+		}
+		result.PR = &pr
+		if name == "" {
+			name = c.Config.PRBranchName(pr)
+			noPrefix = true
+			result.Branch = name
+		}
+	}
+
+	if name == "" {
+		return result, fmt.Errorf("branch name is required")
+	}
+
+	branch, wtName := name, name
+	if !noPrefix && !c.Detach {
+		branch, wtName = c.Config.ResolveBranch(name)
+	}
+	if branch != name {
+		c.Log.DebugContext(ctx, "resolved branch name",
+			"name", name,
+			"branch", branch,
+			"worktree", wtName)
+	}
+	result.Branch = branch
+
+	if c.Config.WorktreeSourceDir == "" {
+		return result, fmt.Errorf("worktree source directory is not configured")
+	}
+	baseDir := c.Config.WorktreeDestBaseDir
+	if c.BaseDir != "" {
+		if err := c.prepareBaseDir(); err != nil {
+			return result, err
+		}
+		baseDir = c.BaseDir
+	}
+	if baseDir == "" {
+		return result, fmt.Errorf("worktree destination base directory is not configured")
+	}
+
+	wtPath := filepath.Join(baseDir, wtName)
+	if c.Path != "" {
+		wtPath = c.Path
+	}
+	result.WorktreePath = wtPath
+
+	switch c.OnExists {
+	case "", OnExistsFail, OnExistsReplace:
+	case OnExistsAdopt:
+		if c.CI {
+			return result, fmt.Errorf("--on-exists adopt cannot be used with --ci")
+		}
+	default:
+		return result, fmt.Errorf("invalid on-exists action %q (use %q, %q or %q)",
+			c.OnExists, OnExistsFail, OnExistsAdopt, OnExistsReplace)
+	}
+
+	// Names differing only in case map to the same files on macOS and
+	// Windows, where git would fail halfway with confusing errors
+	collisionBranch := branch
+	if c.Detach {
+		collisionBranch = ""
+	}
+	collision, err := c.findCaseCollision(ctx, collisionBranch, wtPath, baseDir)
+	if err != nil {
+		return result, err
+	}
+	if collision != nil {
+		mode := c.Config.CaseCollisionMode()
+		if mode == CaseCollisionsError || (mode == CaseCollisionsAuto && isCaseInsensitiveDir(c.FS, c.Config.WorktreeSourceDir)) {
+			return result, &CaseCollisionError{Collision: *collision}
+		}
+		result.CaseCollision = collision
+	}
+
+	// Leftovers of an interrupted add make git fail with errors that do
+	// not say what to do, so they are reported (or pruned) first
+	stale, err := c.findStaleWorktree(ctx, collisionBranch, wtPath)
+	if err != nil {
+		return result, err
+	}
+	if stale != nil {
+		if !c.Repair {
+			return result, &StaleWorktreeError{Worktree: *stale}
+		}
+		if err := c.pruneWorktreeEntry(ctx, *stale); err != nil {
+			return result, err
+		}
+		result.Repaired = stale.Path
+	}
+
+	if c.Detach {
+		if c.Track || c.PushRemote != "" || c.Restore || c.Description != "" {
+			return result, fmt.Errorf("--track, --push, --restore and --description cannot be used with --detach")
+		}
+		commit, err := c.Git.ResolveCommit(ctx, name)
+		if err != nil {
+			return result, err
+		}
+		result.DetachedAt = commit
+	}
+
+	// Resolve the upstream before creating anything so that a missing
+	// remote branch does not leave a half-configured worktree behind.
+	var upstream string
+	if c.Track {
+		var err error
+		upstream, err = c.resolveUpstream(ctx, branch)
+		if err != nil {
+			return result, err
+		}
+	}
+
+	// Look up an earlier removal before creating anything so that
+	// --restore fails without side effects.
+	var removed *RemovedBranch
+	if !c.Detach {
+		var err error
+		removed, err = c.findRemovedBranch(ctx, branch)
+		if err != nil {
+			return result, err
+		}
+	}
+	startPoint := c.StartPoint
+	if result.PR != nil {
+		startPoint = result.PR.Commit
+		outdated, err := c.branchDiffersFrom(ctx, branch, startPoint)
+		if err != nil {
+			return result, err
+		}
+		result.PROutdated = outdated
+	}
+	if c.Restore {
+		if removed == nil {
+			return result, fmt.Errorf("no removal of %s with an existing commit found in the audit log", branch)
+		}
+		startPoint = removed.HEAD
+		result.Restored = removed
+	} else {
+		result.Removed = removed
+	}
+
+	// Determine transfer mode and source
+	var transferMode string
+	var isCarry bool
+	var sourceGit *GitRunner
+	if c.Sync {
+		transferMode = "sync"
+		sourceGit = c.Git
+	}
+	if c.CarryFrom != "" {
+		transferMode = "carry"
+		isCarry = true
+		sourceGit = c.Git.InDir(c.CarryFrom)
+	}
+
+	// Capture changes if sync or carry is enabled. The source is only
+	// modified (for carry) once the changes are in the new worktree.
+	var changes *changeSet
+	var pathspecs []string
+	if transferMode != "" {
+		hasChanges, err := sourceGit.HasChanges(ctx)
+		if err != nil {
+			return result, fmt.Errorf("failed to check for changes: %w", err)
+		}
+		if hasChanges {
+			if len(c.FilePatterns) > 0 {
+				// Expand glob patterns to actual file paths using doublestar
+				globDir := c.Config.WorktreeSourceDir
+				if isCarry {
+					globDir = c.CarryFrom
+				}
+				seen := make(map[string]bool)
+				for _, pattern := range c.FilePatterns {
+					matches, err := c.FS.Glob(globDir, pattern)
+					if err != nil {
+						return result, fmt.Errorf("invalid glob pattern %q: %w", pattern, err)
+					}
+					for _, match := range matches {
+						if !seen[match] {
+							seen[match] = true
+							pathspecs = append(pathspecs, match)
+						}
+					}
+				}
+				// Broad patterns also match build artifacts and
+				// dependencies, which are left behind unless asked for
+				if !c.IncludeIgnored {
+					ignored, err := sourceGit.CheckIgnore(ctx, pathspecs)
+					if err != nil {
+						return result, err
+					}
+					skip := make(map[string]bool, len(ignored))
+					for _, p := range ignored {
+						skip[p] = true
+					}
+					pathspecs = slices.DeleteFunc(pathspecs, func(p string) bool {
+						return skip[p]
+					})
+					result.IgnoredSkipped = len(ignored)
+				}
+			}
+			// Patterns that matched nothing to carry must not fall back
+			// to carrying everything
+			if len(c.FilePatterns) == 0 || len(pathspecs) > 0 {
+				captured, err := c.captureChanges(ctx, sourceGit, pathspecs, c.IncludeIgnored)
+				if err != nil {
+					return result, fmt.Errorf("failed to read changes: %w", err)
+				}
+				if !captured.empty() {
+					changes = captured
+				}
+			}
+		}
+	}
+
+	// The patch is written before creating the worktree so that a failure
+	// here leaves nothing to clean up.
+	var patchFile string
+	if changes != nil && len(changes.patch) > 0 {
+		commonDir, err := c.Git.GitCommonDir(ctx)
+		if err != nil {
+			return result, fmt.Errorf("failed to find git directory: %w", err)
+		}
+		if commonDir == "" {
+			return result, fmt.Errorf("failed to find git directory")
+		}
+		scratch, err := CreateScratchDir(ctx, commonDir, ScratchDirOptions{
+			Purpose: transferMode,
+			Command: "add",
+			Log:     c.Log,
+		})
+		if err != nil {
+			return result, err
+		}
+		defer func() {
+			if err := scratch.Remove(); err != nil {
+				c.Log.DebugContext(ctx, "failed to remove scratch dir",
+					LogAttrKeyCategory.String(), LogCategoryScratch,
+					"path", scratch.Path,
+					"error", err.Error())
+			}
+		}()
+		patchFile = filepath.Join(scratch.Path, changesPatchFile)
+		if err := c.FS.WriteFile(patchFile, changes.patch, 0644); err != nil {
+			return result, fmt.Errorf("failed to write changes: %w", err)
+		}
+	}
+
+	// Every check runs before the destination is touched, so that a
+	// failure leaves an existing directory as it was
+	existing, err := c.prepareDestination(ctx, wtPath)
+	if err != nil {
+		return result, err
+	}
+	target, addOpts := result.DetachedAt, []WorktreeAddOption{WithDetach()}
+	if !c.Detach {
+		target = branch
+		addOpts, err = c.branchAddOptions(ctx, branch, startPoint)
+		if err != nil {
+			return result, err
+		}
+	}
+	create := func(path string, noCheckout bool) ([]byte, error) {
+		return c.createWorktree(ctx, target, path, addOpts, noCheckout)
+	}
+
+	// A replaced directory is kept aside until the worktree is set up
+	var aside string
+	if existing == OnExistsReplace {
+		aside, err = c.moveAside(ctx, wtPath)
+		if err != nil {
+			return result, err
+		}
+	}
+	var adopted *adoption
+	var gitOutput []byte
+	if existing == OnExistsAdopt {
+		gitOutput, result.AdoptedChanges, adopted, err = c.adoptDirectory(ctx, wtPath, create)
+	} else {
+		gitOutput, err = create(wtPath, false)
+	}
+	if err != nil {
+		return result, c.restoreReplaced(ctx, wtPath, aside, err)
+	}
+	result.GitOutput = gitOutput
+	result.Adopted = existing == OnExistsAdopt
+
+	// undo reverts the add after a failure. An adopted directory only
+	// loses its registration; its files are never removed.
+	undo := func(err error) error {
+		if adopted != nil {
+			c.unadopt(ctx, adopted)
+			return err
+		}
+		c.removeFailedWorktree(ctx, wtPath)
+		return c.restoreReplaced(ctx, wtPath, aside, err)
+	}
+
+	if c.CI {
+		if err := c.checkoutCI(ctx, wtPath); err != nil {
+			return result, undo(err)
+		}
+	}
+
+	// Symlinks and submodules need the checked out files, so with
+	// --no-checkout they are left to twig sync
+	result.NoCheckout = c.NoCheckout
+	initSubmodules := !c.CI && (c.InitSubmodules || c.Config.ShouldInitSubmodules())
+	if c.NoCheckout {
+		result.SetupDeferred = initSubmodules || len(c.symlinkPatterns()) > 0
+		initSubmodules = false
+	}
+
+	// Initialize submodules in new worktree (CLI flag forces enable)
+	if initSubmodules {
+		wtGit := c.Git.InDir(wtPath)
+		opts := c.Config.SubmoduleUpdateOptions()
+
+		if c.SubmoduleReference || c.Config.ShouldUseSubmoduleReference() {
+			mainPath, _ := c.Git.MainWorktreePath(ctx)
+			opts = append(opts, c.Config.SubmoduleReferenceOptions(mainPath)...)
+		}
+
+		subResult, subErr := wtGit.SubmoduleUpdate(ctx, opts...)
+		if subErr != nil {
+			result.SubmoduleInit.Attempted = true
+			result.SubmoduleInit.Skipped = true
+			result.SubmoduleInit.Reason = subErr.Error()
+		} else if subResult.Count > 0 {
+			result.SubmoduleInit.Attempted = true
+			result.SubmoduleInit.Count = subResult.Count
+			result.SubmoduleInit.NoReferenceSubmodules = subResult.NoReference
+		}
+		result.SubmoduleInit.UnmatchedPaths = subResult.Unmatched
+	}
+
+	// Apply captured changes to new worktree. For sync the source keeps
+	// its changes; for carry they are removed from it afterwards.
+	if changes != nil {
+		if err := c.applyChanges(ctx, wtPath, changes, patchFile); err != nil {
+			return result, undo(fmt.Errorf("failed to apply changes to new worktree: %w", err))
+		}
+		if isCarry {
+			result.ChangesCarried = true
+			result.CarryLeft = c.finishCarry(ctx, sourceGit, changes, pathspecs, patchFile, wtPath)
+		} else {
+			result.ChangesSynced = true
+		}
+	}
+
+	if aside != "" {
+		result.Replaced = true
+		if err := c.FS.RemoveAll(aside); err != nil {
+			result.ReplacedLeft = aside
+			c.Log.DebugContext(ctx, "failed to remove replaced directory", "path", aside, "error", err)
+		}
+	}
+
+	switch {
+	case upstream != "":
+		result.Upstream.Upstream = upstream
+		if err := c.Git.SetUpstream(ctx, branch, upstream); err != nil {
+			result.Upstream.Skipped = true
+			result.Upstream.Reason = err.Error()
+		}
+	case c.PushRemote != "":
+		result.Upstream.Upstream = c.PushRemote + "/" + branch
+		if err := c.Git.PushSetUpstream(ctx, c.PushRemote, branch); err != nil {
+			result.Upstream.Skipped = true
+			result.Upstream.Reason = fmt.Sprintf("%v (run git push -u %s %s in the worktree)", err, c.PushRemote, branch)
+		} else {
+			result.Upstream.Pushed = true
+		}
+	}
+
+	if c.Description != "" {
+		result.Description = c.Description
+		result.DescriptionErr = c.Git.SetBranchDescription(ctx, branch, c.Description)
+	}
+
+	result.ProvenanceErr = c.recordProvenance(ctx, wtPath)
+
+	patterns := c.symlinkPatterns()
+	var tracked trackedPaths
+	if len(patterns) > 0 && !c.CI && !c.NoCheckout {
+		tracked, err = loadTrackedPaths(ctx, c.Git.InDir(wtPath))
+		if err != nil {
+			c.Log.DebugContext(ctx, "failed to list tracked files", "path", wtPath, "error", err)
+		}
+	}
+
+	if !c.CI && !c.NoCheckout {
+		start := time.Now()
+		symlinks, err := createSymlinks(c.FS, c.Config.WorktreeSourceDir, wtPath, patterns, tracked, c.Config.ShouldUseStrictSymlinks(), c.Config.SymlinkTargetStyle())
+		c.Git.Timings.recordSince(TimingPhaseSymlinks, start)
+		if err != nil {
+			return result, err
+		}
+		result.Symlinks = symlinks
+	}
+
+	envBranch := branch
+	if c.Detach {
+		envBranch = ""
+	}
+
+	// Written before the hooks so that they can load it (e.g. direnv allow)
+	if !c.CI && c.Config.ShouldWriteEnvFile() {
+		content := envFileContent(envBranch, wtPath, c.Config.WorktreeSourceDir, c.Config.EnvFileVars)
+		envFile, _, err := writeEnvFile(c.FS, wtPath, content, false)
+		if err != nil {
+			return result, err
+		}
+		result.EnvFile = envFile
+	}
+
+	// Run post-create hooks
+	if len(c.Config.Hooks) > 0 {
+		result.HookResults = c.runHooks(ctx, wtPath, envBranch)
+	}
+
+	return result, nil
+}
+
+// recordProvenance writes the provenance marker of the new worktree at
+// wtPath. The source is the --source ref, or the branch of the source
+// worktree.
+func (c *AddCommand) recordProvenance(ctx context.Context, wtPath string) error {
+	p := c.Provenance
+	p.CreatedAt = time.Now()
+	p.Command = ProvenanceCommandAdd
+	p.Source = c.StartPoint
+	if p.Source == "" {
+		worktrees, err := c.Git.WorktreeList(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to record provenance: %w", err)
+		}
+		if wt := currentWorktree(worktrees, c.Config.WorktreeSourceDir); wt != nil {
+			p.Source = wt.Branch
+		}
+	}
+	return NewProvenanceStore(c.FS, c.Git).Write(ctx, wtPath, p)
+}
+
+// symlinkPatterns returns the symlink patterns for the new worktree: the
+// configured ones unless NoSymlinks is set, followed by ExtraSymlinks.
+func (c *AddCommand) symlinkPatterns() []string {
+	var patterns []string
+	if !c.NoSymlinks {
+		patterns = append(patterns, c.Config.Symlinks...)
+	}
+	for _, p := range c.ExtraSymlinks {
+		if !slices.Contains(patterns, p) {
+			patterns = append(patterns, p)
+		}
+	}
+	return patterns
+}
+
+// prepareBaseDir checks that BaseDir is a directory, creating it (and its
+// parents) if it does not exist yet.
+func (c *AddCommand) prepareBaseDir() error {
+	info, err := c.FS.Stat(c.BaseDir)
+	if err == nil {
+		if !info.IsDir() {
+			return fmt.Errorf("--base-dir %s is not a directory", c.BaseDir)
+		}
+		return nil
+	}
+	if !c.FS.IsNotExist(err) {
+		return fmt.Errorf("failed to check --base-dir %s: %w", c.BaseDir, err)
+	}
+	if err := c.FS.MkdirAll(c.BaseDir, 0755); err != nil {
+		return fmt.Errorf("failed to create --base-dir %s: %w", c.BaseDir, err)
+	}
+	return nil
+}
+
+// lookupRemote asks the remotes for branch when no remote-tracking branch
+// of it exists locally, e.g. because it was pushed after the last fetch.
+// Returns the remote that has it as selected by SelectRemote, or "" when
+// none does.
+func (c *AddCommand) lookupRemote(ctx context.Context, branch string) (string, error) {
+	remotes, err := c.Git.LookupRemotesForBranch(ctx, branch, remoteLookupTimeout)
+	if err != nil {
+		return "", err
+	}
+	return c.Git.SelectRemote(ctx, branch, remotes)
+}
+
+// guessRemote reports whether a branch missing locally should be based on
+// a remote branch of the same name. It follows worktree.guessRemote, but
+// only an explicit false disables guessing since twig always guessed.
+func (c *AddCommand) guessRemote(ctx context.Context) (bool, error) {
+	guess, ok, err := c.Git.ConfigGetBool(ctx, "worktree.guessRemote")
+	if err != nil {
+		return false, err
+	}
+	return guess || !ok, nil
+}
+
+// resolveUpstream returns the remote-tracking branch to track for branch.
+// Tracking requires the remote branch to exist locally; use PushRemote to
+// create it instead.
+func (c *AddCommand) resolveUpstream(ctx context.Context, branch string) (string, error) {
+	if c.Upstream == "" {
+		remote, err := c.Git.FindRemoteForBranch(ctx, branch)
+		if err != nil {
+			return "", err
+		}
+		if remote == "" {
+			return "", fmt.Errorf("no remote branch found for %s to track (use --push to create it)", branch)
+		}
+		return remote + "/" + branch, nil
+	}
+
+	exists, err := c.Git.RemoteBranchExists(ctx, c.Upstream)
+	if err != nil {
+		return "", fmt.Errorf("failed to check remote branch existence: %w", err)
+	}
+	if !exists {
+		return "", fmt.Errorf("remote branch %s not found (run git fetch first)", c.Upstream)
+	}
+	return c.Upstream, nil
+}
+
+// findRemovedBranch returns the most recent removal of branch recorded in
+// the audit log whose commit still exists, or nil. A branch that exists
+// locally or on a remote is checked out as is, so it yields nil, or an
+// error with --restore.
+func (c *AddCommand) findRemovedBranch(ctx context.Context, branch string) (*RemovedBranch, error) {
+	exists, err := c.Git.LocalBranchExists(ctx, branch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check branch existence: %w", err)
+	}
+	if !exists {
+		remote, err := c.Git.FindRemoteForBranch(ctx, branch)
+		if err != nil {
+			return nil, err
+		}
+		exists = remote != ""
+	}
+	if exists {
+		if c.Restore {
+			return nil, fmt.Errorf("branch %s already exists; --restore only recreates removed branches", branch)
+		}
+		return nil, nil
+	}
+
+	entries, _, err := NewAuditLog(c.FS, c.Git).Read(ctx)
+	if err != nil {
+		// Without --restore the removal is only a hint; never fail add over it
+		c.Log.DebugContext(ctx, "failed to read audit log", "error", err)
+		if c.Restore {
+			return nil, err
+		}
+		return nil, nil
+	}
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		if e.Branch != branch || e.HEAD == "" {
+			continue
+		}
+		// Commits of deleted branches are eventually garbage collected
+		if ok, err := c.Git.CommitExists(ctx, e.HEAD); err != nil || !ok {
+			c.Log.DebugContext(ctx, "removed branch commit is gone", "branch", branch, "head", e.HEAD)
+			continue
+		}
+		return &RemovedBranch{HEAD: e.HEAD, RemovedAt: e.Time, Age: time.Since(e.Time)}, nil
+	}
+	return nil, nil
+}
+
+// formatAgo formats d as a coarse relative time such as "2 days ago".
+func formatAgo(d time.Duration) string {
+	unit := func(n int, name string) string {
+		if n == 1 {
+			return fmt.Sprintf("1 %s ago", name)
+		}
+		return fmt.Sprintf("%d %ss ago", n, name)
+	}
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return unit(int(d/time.Minute), "minute")
+	case d < 48*time.Hour:
+		return unit(int(d/time.Hour), "hour")
+	default:
+		return unit(int(d/(24*time.Hour)), "day")
+	}
+}
+
+// shortHash returns the first 7 characters of a commit hash.
+func shortHash(hash string) string {
+	if len(hash) >= 7 {
+		return hash[:7]
+	}
+	return hash
+}
+
+// checkoutCI fills a worktree created with --no-checkout, limited to
+// SparsePaths when set.
+func (c *AddCommand) checkoutCI(ctx context.Context, wtPath string) error {
+	wtGit := c.Git.InDir(wtPath)
+	if len(c.SparsePaths) > 0 {
+		if err := wtGit.SparseCheckoutSet(ctx, c.SparsePaths...); err != nil {
+			return err
+		}
+	}
+	return wtGit.CheckoutHEAD(ctx)
+}
+
+// restoreReplaced moves the directory moved aside by moveAside back to
+// wtPath after the add failed with err, and returns err. If it cannot be
+// moved back, the returned error says where it is.
+func (c *AddCommand) restoreReplaced(ctx context.Context, wtPath, aside string, err error) error {
+	if aside == "" {
+		return err
+	}
+	if renameErr := c.FS.Rename(aside, wtPath); renameErr != nil {
+		c.Log.DebugContext(ctx, "failed to move replaced directory back", "path", aside, "error", renameErr)
+		return fmt.Errorf("%w\nthe existing directory could not be moved back and is at %s: %v", err, aside, renameErr)
+	}
+	return err
+}
+
+// removeFailedWorktree removes a worktree created by a failed add.
+func (c *AddCommand) removeFailedWorktree(ctx context.Context, wtPath string) {
+	if _, err := c.Git.WorktreeRemove(ctx, wtPath, WithForceRemove(WorktreeForceLevelUnclean)); err != nil {
+		c.Log.DebugContext(ctx, "failed to remove worktree after add failure",
+			"path", wtPath,
+			"error", err)
+	}
+}
+
+// runHooks runs the post-create hooks in dir with the TWIG_* worktree
+// variables set, stopping at the first failure.
+func (c *AddCommand) runHooks(ctx context.Context, dir, branch string) []HookResult {
+	var results []HookResult
+	env := worktreeEnv(branch, dir, false)
+	for _, hook := range c.Config.Hooks {
+		c.Log.DebugContext(ctx, "running hook", "command", hook, "dir", dir)
+		cmd := exec.CommandContext(ctx, "sh", "-c", hook)
+		cmd.Dir = dir
+		cmd.Env = env
+		output, err := cmd.CombinedOutput()
+		results = append(results, HookResult{
+			Command: hook,
+			Output:  output,
+			Err:     err,
+		})
+		if err != nil {
+			c.Log.WarnContext(ctx, "hook failed", "command", hook, "error", err)
+			break
+		}
+		c.Log.DebugContext(ctx, "hook completed", "command", hook)
+	}
+	return results
+}
+
+// branchAddOptions runs the branch checks of adding a worktree for
+// branch and returns the options git worktree add needs. A branch that
+// exists neither locally nor on a remote is created at startPoint
+// (empty = source HEAD), as is any branch missing locally when
+// worktree.guessRemote is false. A remote branch is fetched.
+func (c *AddCommand) branchAddOptions(ctx context.Context, branch, startPoint string) ([]WorktreeAddOption, error) {
+	exists, err := c.Git.LocalBranchExists(ctx, branch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check branch existence: %w", err)
+	}
+	if exists {
+		branches, err := c.Git.WorktreeListBranches(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list worktree branches: %w", err)
+		}
+		if slices.Contains(branches, branch) {
+			return nil, fmt.Errorf("branch %s is already checked out in another worktree", branch)
+		}
+		return nil, nil
+	}
+
+	var remote string
+	guess, err := c.guessRemote(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if guess {
+		remote, err = c.Git.FindRemoteForBranch(ctx, branch)
+		if err != nil {
+			return nil, err
+		}
+	}
+	fetch := c.Fetch || (c.Config != nil && c.Config.ShouldFetchOnAdd())
+	fetched := false
+	if guess && remote == "" && fetch {
+		var remotes []string
+		remotes, err = c.Git.FetchBranchFromRemotes(ctx, branch)
+		if err != nil {
+			return nil, err
+		}
+		remote, err = c.Git.SelectRemote(ctx, branch, remotes)
+		if err != nil {
+			return nil, err
+		}
+		fetched = remote != ""
+	}
+	if guess && remote == "" && !fetch && c.Config != nil && c.Config.ShouldLookupRemoteBranches() {
+		remote, err = c.lookupRemote(ctx, branch)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if remote == "" {
+		// No remote branch found, create new local branch
+		opts := []WorktreeAddOption{WithCreateBranch()}
+		if startPoint != "" {
+			opts = append(opts, WithStartPoint(startPoint))
+		}
+		return opts, nil
+	}
+	// Remote branch found, fetch it; git worktree add then tracks it
+	if !fetched {
+		if err := c.Git.Fetch(ctx, remote, branch); err != nil {
+			return nil, fmt.Errorf("failed to fetch %s from %s: %w", branch, remote, err)
+		}
+	}
+	return nil, nil
+}
+
+// createWorktree adds the worktree at path checking out target, the
+// branch or the commit for --detach, with the options chosen by
+// branchAddOptions.
+func (c *AddCommand) createWorktree(ctx context.Context, target, path string, opts []WorktreeAddOption, noCheckout bool) ([]byte, error) {
+	opts = slices.Clone(opts)
+	if c.CI || c.NoCheckout || noCheckout {
+		opts = append(opts, WithNoCheckout())
+	}
+	if c.Lock {
+		opts = append(opts, WithLock())
+		if c.LockReason != "" {
+			opts = append(opts, WithLockReason(c.LockReason))
+		}
+	}
+
+	output, err := c.Git.WorktreeAdd(ctx, path, target, opts...)
+	if err != nil {
+		return nil, explainGitError(fmt.Errorf("failed to create worktree: %w", err))
+	}
+	return output, nil
+}
+
+// prepareDestination checks the worktree directory before it is created,
+// without changing it. If it exists, it returns the OnExists action to
+// take: OnExistsAdopt or OnExistsReplace. It returns "" when the directory
+// does not exist.
+func (c *AddCommand) prepareDestination(ctx context.Context, wtPath string) (OnExists, error) {
+	if _, err := c.FS.Stat(wtPath); err != nil {
+		if c.FS.IsNotExist(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to check %s: %w", wtPath, err)
+	}
+
+	action := cmp.Or(c.OnExists, OnExistsFail)
+	if action == OnExistsFail {
+		return "", fmt.Errorf("directory already exists: %s (use --on-exists adopt or replace for leftovers)", wtPath)
+	}
+
+	// Neither action may touch a worktree that git still knows about
+	worktrees, err := c.Git.WorktreeList(ctx)
+	if err != nil {
+		return "", err
+	}
+	for _, wt := range worktrees {
+		if filepath.Clean(wt.Path) == filepath.Clean(wtPath) {
+			owner := wt.Branch
+			if owner == "" {
+				owner = "detached HEAD " + wt.ShortHEAD()
+			}
+			return "", fmt.Errorf("directory already exists: %s is the worktree of %s", wtPath, owner)
+		}
+	}
+	return action, nil
+}
+
+// moveAside renames the directory replaced by OnExistsReplace next to it,
+// so that it can be moved back if the add fails, and returns where it is.
+func (c *AddCommand) moveAside(ctx context.Context, wtPath string) (string, error) {
+	aside := filepath.Join(filepath.Dir(wtPath), "."+filepath.Base(wtPath)+replaceSuffix)
+	if err := c.checkLeftover(wtPath, aside); err != nil {
+		return "", err
+	}
+	c.Log.DebugContext(ctx, "moving existing directory aside", "path", wtPath, "to", aside)
+	if err := c.FS.Rename(wtPath, aside); err != nil {
+		return "", fmt.Errorf("failed to move existing directory aside: %w", err)
+	}
+	return aside, nil
+}
+
+// checkLeftover fails if path, a directory an earlier attempt to set up
+// wtPath may have left behind, exists.
+func (c *AddCommand) checkLeftover(wtPath, path string) error {
+	_, err := c.FS.Stat(path)
+	switch {
+	case err == nil:
+		return fmt.Errorf("cannot set up %s: %s exists from an earlier attempt; remove it first", wtPath, path)
+	case !c.FS.IsNotExist(err):
+		return fmt.Errorf("failed to check %s: %w", path, err)
+	}
+	return nil
+}
+
+// adoption records what adoptDirectory changed, so that a failed add can
+// undo the registration without touching the adopted files.
+type adoption struct {
+	gitFile    string // .git file written into the adopted directory
+	oldGitFile []byte // Its content before (nil = it did not exist)
+	adminDir   string // $GIT_COMMON_DIR/worktrees/<name> of the worktree
+}
+
+// adoptDirectory registers the existing directory wtPath as a worktree
+// without touching its files. The worktree is created without a checkout
+// in a scratch directory next to it, and its .git file is moved into
+// wtPath. It reports whether the adopted files differ from the checked
+// out commit. On failure the registration is undone.
+func (c *AddCommand) adoptDirectory(ctx context.Context, wtPath string, create func(path string, noCheckout bool) ([]byte, error)) ([]byte, bool, *adoption, error) {
+	gitFile := filepath.Join(wtPath, ".git")
+	info, err := c.FS.Lstat(gitFile)
+	switch {
+	case err == nil && info != nil && info.IsDir():
+		return nil, false, nil, fmt.Errorf("cannot adopt %s: it is a git repository, not a worktree checkout", wtPath)
+	case err != nil && !c.FS.IsNotExist(err):
+		return nil, false, nil, fmt.Errorf("failed to check %s: %w", gitFile, err)
+	}
+	a := &adoption{gitFile: gitFile}
+	if err == nil {
+		// A stale .git file left by a crashed run is replaced, and put
+		// back if this attempt fails too
+		if a.oldGitFile, err = c.FS.ReadFile(gitFile); err != nil {
+			return nil, false, nil, fmt.Errorf("failed to read %s: %w", gitFile, err)
+		}
+	}
+	scratch := filepath.Join(filepath.Dir(wtPath), "."+filepath.Base(wtPath)+adoptSuffix)
+	if err := c.checkLeftover(wtPath, scratch); err != nil {
+		return nil, false, nil, err
+	}
+
+	output, err := create(scratch, true)
+	if err != nil {
+		return nil, false, nil, err
+	}
+	data, err := c.FS.ReadFile(filepath.Join(scratch, ".git"))
+	if err == nil {
+		a.adminDir = gitDirFromGitFile(data, scratch)
+		err = c.FS.WriteFile(gitFile, data, 0644)
+	}
+	if err != nil {
+		// The scratch directory has no files of the user's
+		c.removeFailedWorktree(ctx, scratch)
+		return nil, false, nil, fmt.Errorf("failed to adopt %s: %w", wtPath, err)
+	}
+	if err := c.FS.RemoveAll(scratch); err != nil {
+		c.Log.DebugContext(ctx, "failed to remove adopt scratch dir", "path", scratch, "error", err)
+	}
+	// Point the administrative files at the adopted directory
+	if err := c.Git.WorktreeRepair(ctx, wtPath); err != nil {
+		c.unadopt(ctx, a)
+		return nil, false, nil, err
+	}
+
+	// Without a checkout the index is empty; fill it from HEAD so that
+	// only real differences show up as changes
+	wtGit := c.Git.InDir(wtPath)
+	if err := wtGit.ResetIndex(ctx); err != nil {
+		c.unadopt(ctx, a)
+		return nil, false, nil, fmt.Errorf("failed to read the index of %s: %w", wtPath, err)
+	}
+	changed, err := wtGit.HasChanges(ctx)
+	if err != nil {
+		c.unadopt(ctx, a)
+		return nil, false, nil, fmt.Errorf("failed to check for changes: %w", err)
+	}
+	return output, changed, a, nil
+}
+
+// unadopt undoes the registration of an adopted directory: the .git file
+// is put back as it was and the administrative directory is removed. The
+// adopted files are never touched.
+func (c *AddCommand) unadopt(ctx context.Context, a *adoption) {
+	var err error
+	if a.oldGitFile != nil {
+		err = c.FS.WriteFile(a.gitFile, a.oldGitFile, 0644)
+	} else {
+		err = c.FS.Remove(a.gitFile)
+	}
+	if err != nil {
+		c.Log.DebugContext(ctx, "failed to restore .git file after add failure",
+			"path", a.gitFile,
+			"error", err)
+	}
+	if a.adminDir == "" {
+		return
+	}
+	if err := c.FS.RemoveAll(a.adminDir); err != nil {
+		c.Log.DebugContext(ctx, "failed to remove worktree admin dir after add failure",
+			"path", a.adminDir,
+			"error", err)
+	}
+}
@@ -0,0 +1,159 @@
+package core
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// DefaultAddJobs is the default number of worktrees created concurrently.
+const DefaultAddJobs = 4
+
+// AddBatchEntry is one line of an add batch file.
+//
+// Each non-empty line holds a branch name optionally followed by flags:
+//
+//	feat/login --source develop --lock --reason "pairing"
+//
+// Lines starting with "#" are comments.
+type AddBatchEntry struct {
+	Line           int    // 1-based line number in the batch input
+	Name           string // Branch name as given (resolved like a CLI argument)
+	Source         string // Source branch (empty: use --source or default_source)
+	Lock           bool
+	LockReason     string
+	InitSubmodules bool
+	NoPrefix       bool
+}
+
+// ParseAddBatch reads batch entries from r.
+// Parsing stops at the first invalid line, reported with its line number.
+func ParseAddBatch(r io.Reader) ([]AddBatchEntry, error) {
+	var entries []AddBatchEntry
+	seen := make(map[string]int)
+
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		entry, err := parseAddBatchLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNum, err)
+		}
+		if prev, ok := seen[entry.Name]; ok {
+			return nil, fmt.Errorf("line %d: %s is already listed on line %d", lineNum, entry.Name, prev)
+		}
+		seen[entry.Name] = lineNum
+		entry.Line = lineNum
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read batch input: %w", err)
+	}
+	return entries, nil
+}
+
+// parseAddBatchLine parses a single non-comment batch line.
+func parseAddBatchLine(line string) (AddBatchEntry, error) {
+	var entry AddBatchEntry
+
+	fields, err := splitBatchFields(line)
+	if err != nil {
+		return entry, err
+	}
+
+	for i := 0; i < len(fields); i++ {
+		field := fields[i]
+		if !strings.HasPrefix(field, "--") {
+			if entry.Name != "" {
+				return entry, fmt.Errorf("unexpected argument %q (one branch per line)", field)
+			}
+			entry.Name = field
+			continue
+		}
+
+		flag, value, hasValue := strings.Cut(field, "=")
+		switch flag {
+		case "--source", "--reason":
+			if !hasValue {
+				if i+1 >= len(fields) {
+					return entry, fmt.Errorf("%s requires a value", flag)
+				}
+				i++
+				value = fields[i]
+			}
+			if flag == "--source" {
+				entry.Source = value
+			} else {
+				entry.LockReason = value
+			}
+		case "--lock", "--init-submodules", "--no-prefix":
+			if hasValue {
+				return entry, fmt.Errorf("%s does not take a value", flag)
+			}
+			switch flag {
+			case "--lock":
+				entry.Lock = true
+			case "--init-submodules":
+				entry.InitSubmodules = true
+			case "--no-prefix":
+				entry.NoPrefix = true
+			}
+		default:
+			return entry, fmt.Errorf("unsupported flag %s (allowed: --source, --lock, --reason, --init-submodules, --no-prefix)", flag)
+		}
+	}
+
+	if entry.Name == "" {
+		return entry, fmt.Errorf("branch name is required")
+	}
+	if entry.LockReason != "" && !entry.Lock {
+		return entry, fmt.Errorf("--reason requires --lock")
+	}
+	return entry, nil
+}
+
+// splitBatchFields splits a line on whitespace, keeping single- or
+// double-quoted text together (e.g. --reason "long running").
+func splitBatchFields(line string) ([]string, error) {
+	var fields []string
+	var cur strings.Builder
+	var quote rune
+	inField := false
+
+	for _, r := range line {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				cur.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote = r
+			inField = true
+		case r == ' ' || r == '\t':
+			if inField {
+				fields = append(fields, cur.String())
+				cur.Reset()
+				inField = false
+			}
+		default:
+			cur.WriteRune(r)
+			inField = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote")
+	}
+	if inField {
+		fields = append(fields, cur.String())
+	}
+	return fields, nil
+}
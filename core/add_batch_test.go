@@ -0,0 +1,112 @@
+package core
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseAddBatch(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		input   string
+		want    []AddBatchEntry
+		wantErr string
+	}{
+		{
+			name:  "names with comments and blank lines",
+			input: "# team onboarding\nfeat/a\n\n  feat/b  \n",
+			want: []AddBatchEntry{
+				{Line: 2, Name: "feat/a"},
+				{Line: 4, Name: "feat/b"},
+			},
+		},
+		{
+			name:  "per-line flags",
+			input: "feat/a --source develop --no-prefix\n--lock --reason=\"long review\" feat/b\nfeat/c --source=main --init-submodules\n",
+			want: []AddBatchEntry{
+				{Line: 1, Name: "feat/a", Source: "develop", NoPrefix: true},
+				{Line: 2, Name: "feat/b", Lock: true, LockReason: "long review"},
+				{Line: 3, Name: "feat/c", Source: "main", InitSubmodules: true},
+			},
+		},
+		{
+			name:  "single-quoted value",
+			input: "feat/a --lock --reason 'do not clean'\n",
+			want:  []AddBatchEntry{{Line: 1, Name: "feat/a", Lock: true, LockReason: "do not clean"}},
+		},
+		{
+			name:  "empty input",
+			input: "\n# nothing\n",
+		},
+		{
+			name:    "unsupported flag",
+			input:   "feat/a\nfeat/b --sync\n",
+			wantErr: "line 2: unsupported flag --sync",
+		},
+		{
+			name:    "two names on one line",
+			input:   "feat/a feat/b\n",
+			wantErr: "line 1: unexpected argument \"feat/b\"",
+		},
+		{
+			name:    "missing flag value",
+			input:   "feat/a --source\n",
+			wantErr: "line 1: --source requires a value",
+		},
+		{
+			name:    "value on boolean flag",
+			input:   "feat/a --lock=yes\n",
+			wantErr: "line 1: --lock does not take a value",
+		},
+		{
+			name:    "reason without lock",
+			input:   "feat/a --reason wip\n",
+			wantErr: "line 1: --reason requires --lock",
+		},
+		{
+			name:    "flags without name",
+			input:   "--lock\n",
+			wantErr: "line 1: branch name is required",
+		},
+		{
+			name:    "unterminated quote",
+			input:   "feat/a --lock --reason \"wip\n",
+			wantErr: "line 1: unterminated quote",
+		},
+		{
+			name:    "duplicate branch",
+			input:   "feat/a\nfeat/b\nfeat/a --lock\n",
+			wantErr: "line 3: feat/a is already listed on line 1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ParseAddBatch(strings.NewReader(tt.input))
+			if tt.wantErr != "" {
+				if err == nil {
+					t.Fatalf("expected error containing %q, got nil", tt.wantErr)
+				}
+				if !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("error = %q, want to contain %q", err.Error(), tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d entries, want %d: %+v", len(got), len(tt.want), got)
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("entry[%d] = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -1,12 +1,15 @@
 //go:build integration
 
-package twig
+package core
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/708u/twig/internal/testutil"
@@ -603,6 +606,117 @@ worktree_destination_base_dir = %q
 		}
 	})
 
+	t.Run("TrackedFileInBranchNotSymlinked", func(t *testing.T) {
+		t.Parallel()
+
+		repoDir, mainDir := testutil.SetupTestRepo(t,
+			testutil.Symlinks(".envrc"))
+
+		// Commit .envrc in the branch to be added
+		testutil.RunGit(t, mainDir, "checkout", "-b", "feature/tracked")
+		if err := os.WriteFile(filepath.Join(mainDir, ".envrc"), []byte("# tracked"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		testutil.RunGit(t, mainDir, "add", ".envrc")
+		testutil.RunGit(t, mainDir, "commit", "-m", "add envrc")
+		testutil.RunGit(t, mainDir, "checkout", "-")
+
+		// Untracked .envrc in the source worktree
+		if err := os.WriteFile(filepath.Join(mainDir, ".envrc"), []byte("# local"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := LoadConfig(mainDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		cmd := &AddCommand{
+			FS:     osFS{},
+			Git:    NewGitRunner(mainDir),
+			Config: result.Config,
+		}
+
+		addResult, err := cmd.Run(t.Context(), "feature/tracked")
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+
+		if len(addResult.Symlinks) != 1 || !addResult.Symlinks[0].Skipped {
+			t.Fatalf("expected .envrc to be skipped, got %+v", addResult.Symlinks)
+		}
+		if !strings.Contains(addResult.Symlinks[0].Reason, "tracked in target branch") {
+			t.Errorf("Reason = %q, want tracked reason", addResult.Symlinks[0].Reason)
+		}
+
+		envrcPath := filepath.Join(repoDir, "feature", "tracked", ".envrc")
+		content, err := os.ReadFile(envrcPath)
+		if err != nil {
+			t.Fatalf("failed to read .envrc: %v", err)
+		}
+		if string(content) != "# tracked" {
+			t.Errorf(".envrc content = %q, want tracked content", content)
+		}
+	})
+
+	t.Run("MultipleBranchesInParallel", func(t *testing.T) {
+		t.Parallel()
+
+		repoDir, mainDir := testutil.SetupTestRepo(t,
+			testutil.Symlinks(".envrc"))
+
+		if err := os.WriteFile(filepath.Join(mainDir, ".envrc"), []byte("# envrc"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := LoadConfig(mainDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		cmd := &AddCommand{
+			FS:     osFS{},
+			Git:    NewGitRunner(mainDir),
+			Config: result.Config,
+			Log:    NewNopLogger(),
+		}
+
+		// feat/review and fix/review share a basename, which git uses for admin dir names
+		branches := []string{"feat/review", "fix/review", "feat/other"}
+		errs := make([]error, len(branches))
+		var wg sync.WaitGroup
+		for i, branch := range branches {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, errs[i] = cmd.Run(t.Context(), branch)
+			}()
+		}
+		wg.Wait()
+
+		for i, branch := range branches {
+			if errs[i] != nil {
+				t.Errorf("Run(%q) failed: %v", branch, errs[i])
+				continue
+			}
+			info, err := os.Lstat(filepath.Join(repoDir, branch, ".envrc"))
+			if err != nil {
+				t.Errorf("%s: failed to stat .envrc: %v", branch, err)
+				continue
+			}
+			if info.Mode()&os.ModeSymlink == 0 {
+				t.Errorf("%s: .envrc is not a symlink", branch)
+			}
+		}
+
+		out := testutil.RunGit(t, mainDir, "worktree", "list")
+		for _, branch := range branches {
+			if !strings.Contains(out, "["+branch+"]") {
+				t.Errorf("worktree list missing %s: %s", branch, out)
+			}
+		}
+	})
+
 	t.Run("MultipleSymlinkPatterns", func(t *testing.T) {
 		t.Parallel()
 
@@ -733,7 +847,7 @@ worktree_destination_base_dir = %q
 			t.Errorf("synced file content = %q, want %q", string(content), "uncommitted content")
 		}
 
-		// Verify the file still exists in source (restored via stash pop)
+		// Verify the file still exists in source
 		sourceContent, err := os.ReadFile(modifiedFile)
 		if err != nil {
 			t.Fatalf("failed to read source file: %v", err)
@@ -767,6 +881,7 @@ worktree_destination_base_dir = %q
 			FS:        osFS{},
 			Git:       NewGitRunner(mainDir),
 			Config:    result.Config,
+			Log:       NewNopLogger(),
 			CarryFrom: mainDir,
 		}
 
@@ -808,6 +923,162 @@ worktree_destination_base_dir = %q
 		}
 	})
 
+	t.Run("CarryApplyFailureRestoresSource", func(t *testing.T) {
+		t.Parallel()
+
+		repoDir, mainDir := testutil.SetupTestRepo(t)
+
+		testutil.RunGit(t, mainDir, "add", ".twig")
+		testutil.RunGit(t, mainDir, "commit", "-m", "add twig settings")
+
+		// A post-checkout hook creates a conflicting untracked file in the
+		// new worktree, so applying the carried changes there fails
+		hook := filepath.Join(mainDir, ".git", "hooks", "post-checkout")
+		if err := os.MkdirAll(filepath.Dir(hook), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(hook, []byte("#!/bin/sh\necho conflict > carried.txt\n"), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		modifiedFile := filepath.Join(mainDir, "carried.txt")
+		if err := os.WriteFile(modifiedFile, []byte("carried content"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := LoadConfig(mainDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		cmd := &AddCommand{
+			FS:        osFS{},
+			Git:       NewGitRunner(mainDir),
+			Config:    result.Config,
+			Log:       NewNopLogger(),
+			CarryFrom: mainDir,
+		}
+
+		_, err = cmd.Run(t.Context(), "feature/carry-fail")
+		if err == nil {
+			t.Fatal("expected error, got nil")
+		}
+		if !strings.Contains(err.Error(), "failed to apply changes to new worktree") {
+			t.Errorf("error = %q, want apply failure", err.Error())
+		}
+
+		// Changes are back in the source
+		content, err := os.ReadFile(modifiedFile)
+		if err != nil {
+			t.Fatalf("source changes were not restored: %v", err)
+		}
+		if string(content) != "carried content" {
+			t.Errorf("source file content = %q, want %q", string(content), "carried content")
+		}
+
+		// The failed worktree is removed and the stash is not used
+		wtPath := filepath.Join(repoDir, "feature", "carry-fail")
+		if _, err := os.Stat(wtPath); !os.IsNotExist(err) {
+			t.Errorf("worktree should be removed after failure: %s", wtPath)
+		}
+		if stashes := testutil.RunGit(t, mainDir, "stash", "list"); strings.TrimSpace(stashes) != "" {
+			t.Errorf("stash should not be used, got: %q", stashes)
+		}
+	})
+
+	t.Run("CarryKeepsStashAndIndexState", func(t *testing.T) {
+		t.Parallel()
+
+		repoDir, mainDir := testutil.SetupTestRepo(t)
+
+		testutil.RunGit(t, mainDir, "add", ".twig")
+		testutil.RunGit(t, mainDir, "commit", "-m", "add twig settings")
+		if err := os.WriteFile(filepath.Join(mainDir, "tracked.txt"), []byte("base\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		testutil.RunGit(t, mainDir, "add", "tracked.txt")
+		testutil.RunGit(t, mainDir, "commit", "-m", "add tracked file")
+
+		// A stash of the user's own must survive the carry untouched
+		if err := os.WriteFile(filepath.Join(mainDir, "tracked.txt"), []byte("stashed\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		testutil.RunGit(t, mainDir, "stash", "push", "-m", "user wip")
+		stashesBefore := testutil.RunGit(t, mainDir, "stash", "list", "--format=%H %gs")
+
+		// Modified, staged new, and untracked files
+		files := map[string]string{
+			"tracked.txt":       "modified\n",
+			"staged.txt":        "staged\n",
+			"dir/untracked.txt": "untracked\n",
+		}
+		for name, content := range files {
+			path := filepath.Join(mainDir, name)
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		testutil.RunGit(t, mainDir, "add", "staged.txt")
+
+		result, err := LoadConfig(mainDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		cmd := &AddCommand{
+			FS:        osFS{},
+			Git:       NewGitRunner(mainDir),
+			Config:    result.Config,
+			Log:       NewNopLogger(),
+			CarryFrom: mainDir,
+		}
+
+		addResult, err := cmd.Run(t.Context(), "feature/carry-index")
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		if !addResult.ChangesCarried || addResult.CarryLeft != "" {
+			t.Errorf("ChangesCarried = %v, CarryLeft = %q", addResult.ChangesCarried, addResult.CarryLeft)
+		}
+
+		wtPath := filepath.Join(repoDir, "feature", "carry-index")
+		for name, want := range files {
+			got, err := os.ReadFile(filepath.Join(wtPath, name))
+			if err != nil {
+				t.Fatalf("failed to read carried file: %v", err)
+			}
+			if string(got) != want {
+				t.Errorf("%s = %q, want %q", name, got, want)
+			}
+		}
+
+		// Same index state as git stash apply: only new files are staged
+		status := testutil.RunGit(t, wtPath, "status", "--porcelain", "-uall")
+		wantStatus := "A  staged.txt\n M tracked.txt\n?? dir/untracked.txt\n"
+		if status != wantStatus {
+			t.Errorf("worktree status = %q, want %q", status, wantStatus)
+		}
+
+		if status := testutil.RunGit(t, mainDir, "status", "--porcelain", "-uall"); status != "" {
+			t.Errorf("source should be clean after carry, got: %q", status)
+		}
+		if _, err := os.Stat(filepath.Join(mainDir, "dir")); !os.IsNotExist(err) {
+			t.Errorf("emptied untracked dir should be removed from source")
+		}
+		if stashes := testutil.RunGit(t, mainDir, "stash", "list", "--format=%H %gs"); stashes != stashesBefore {
+			t.Errorf("stash list = %q, want %q", stashes, stashesBefore)
+		}
+
+		// The scratch dir holding the patch is removed
+		entries, _ := os.ReadDir(filepath.Join(mainDir, ".git", auditDirName, scratchDirName))
+		if len(entries) != 0 {
+			t.Errorf("scratch dirs left behind: %v", entries)
+		}
+	})
+
 	t.Run("CarryFromDifferentWorktree", func(t *testing.T) {
 		t.Parallel()
 
@@ -837,6 +1108,7 @@ worktree_destination_base_dir = %q
 			FS:        osFS{},
 			Git:       NewGitRunner(mainDir),
 			Config:    result.Config,
+			Log:       NewNopLogger(),
 			CarryFrom: featureWtPath, // Carry from different worktree
 		}
 
@@ -1183,120 +1455,582 @@ worktree_destination_base_dir = %q
 		}
 	})
 
-	t.Run("CarrySpecificFiles", func(t *testing.T) {
+	t.Run("BaseDirOverride", func(t *testing.T) {
 		t.Parallel()
 
-		repoDir, mainDir := testutil.SetupTestRepo(t)
-
-		// Commit .twig/settings.toml first
-		testutil.RunGit(t, mainDir, "add", ".twig")
-		testutil.RunGit(t, mainDir, "commit", "-m", "add twig settings")
-
-		// Create multiple uncommitted files
-		goFile := filepath.Join(mainDir, "main.go")
-		if err := os.WriteFile(goFile, []byte("package main"), 0644); err != nil {
-			t.Fatal(err)
-		}
-		txtFile := filepath.Join(mainDir, "readme.txt")
-		if err := os.WriteFile(txtFile, []byte("readme content"), 0644); err != nil {
-			t.Fatal(err)
-		}
+		_, mainDir := testutil.SetupTestRepo(t)
+		baseDir := filepath.Join(t.TempDir(), "scratch", "worktrees")
 
 		result, err := LoadConfig(mainDir)
 		if err != nil {
 			t.Fatal(err)
 		}
 
-		// Carry only *.go files
 		cmd := &AddCommand{
-			FS:           osFS{},
-			Git:          NewGitRunner(mainDir),
-			Config:       result.Config,
-			CarryFrom:    mainDir,
-			FilePatterns: []string{"*.go"},
+			FS:      osFS{},
+			Git:     NewGitRunner(mainDir),
+			Config:  result.Config,
+			BaseDir: baseDir,
 		}
 
-		addResult, err := cmd.Run(t.Context(), "feature/carry-go-only")
+		addResult, err := cmd.Run(t.Context(), "feature/scratch")
 		if err != nil {
 			t.Fatalf("Run failed: %v", err)
 		}
 
-		// Verify ChangesCarried is true
-		if !addResult.ChangesCarried {
-			t.Error("expected ChangesCarried to be true")
+		wtPath := filepath.Join(baseDir, "feature", "scratch")
+		if addResult.WorktreePath != wtPath {
+			t.Errorf("WorktreePath = %q, want %q", addResult.WorktreePath, wtPath)
 		}
-
-		// Verify worktree was created
-		wtPath := filepath.Join(repoDir, "feature", "carry-go-only")
-		if _, err := os.Stat(wtPath); os.IsNotExist(err) {
-			t.Errorf("worktree directory does not exist: %s", wtPath)
+		if _, err := os.Stat(filepath.Join(result.Config.WorktreeDestBaseDir, "feature", "scratch")); !os.IsNotExist(err) {
+			t.Error("worktree should not be created under worktree_destination_base_dir")
 		}
 
-		// Verify main.go exists in new worktree (carried)
-		carriedGoFile := filepath.Join(wtPath, "main.go")
-		content, err := os.ReadFile(carriedGoFile)
-		if err != nil {
-			t.Fatalf("failed to read carried go file: %v", err)
+		// remove finds the worktree through git, not the config
+		removeCmd := &RemoveCommand{
+			FS:     osFS{},
+			Git:    NewGitRunner(mainDir),
+			Config: result.Config,
+			Log:    NewNopLogger(),
 		}
-		if string(content) != "package main" {
-			t.Errorf("carried go file content = %q, want %q", string(content), "package main")
+		if _, err := removeCmd.Run(t.Context(), "feature/scratch", mainDir, RemoveOptions{}); err != nil {
+			t.Fatalf("remove failed: %v", err)
 		}
-
-		// Verify readme.txt does NOT exist in new worktree (not carried)
-		notCarriedTxtFile := filepath.Join(wtPath, "readme.txt")
-		if _, err := os.Stat(notCarriedTxtFile); !os.IsNotExist(err) {
-			t.Errorf("readme.txt should not exist in new worktree: %s", notCarriedTxtFile)
+		if _, err := os.Stat(wtPath); !os.IsNotExist(err) {
+			t.Errorf("worktree directory should be removed: %s", wtPath)
 		}
+	})
 
-		// Verify main.go does NOT exist in source (carried away)
-		if _, err := os.Stat(goFile); !os.IsNotExist(err) {
-			t.Errorf("main.go should not exist in source after carry: %s", goFile)
+	t.Run("BaseDirIsFile", func(t *testing.T) {
+		t.Parallel()
+
+		_, mainDir := testutil.SetupTestRepo(t)
+		baseDir := filepath.Join(t.TempDir(), "not-a-dir")
+		if err := os.WriteFile(baseDir, nil, 0644); err != nil {
+			t.Fatal(err)
 		}
 
-		// Verify readme.txt still exists in source (not carried)
-		sourceContent, err := os.ReadFile(txtFile)
+		result, err := LoadConfig(mainDir)
 		if err != nil {
-			t.Fatalf("failed to read source txt file: %v", err)
+			t.Fatal(err)
 		}
-		if string(sourceContent) != "readme content" {
-			t.Errorf("source txt file content = %q, want %q", string(sourceContent), "readme content")
+
+		cmd := &AddCommand{
+			FS:      osFS{},
+			Git:     NewGitRunner(mainDir),
+			Config:  result.Config,
+			BaseDir: baseDir,
 		}
 
-		// Verify source has remaining changes (readme.txt)
-		status := testutil.RunGit(t, mainDir, "status", "--porcelain")
-		if !strings.Contains(status, "readme.txt") {
-			t.Errorf("source should have readme.txt as untracked, got: %q", status)
+		_, err = cmd.Run(t.Context(), "feature/scratch")
+		if err == nil || !strings.Contains(err.Error(), "is not a directory") {
+			t.Fatalf("error = %v, want not a directory", err)
 		}
 	})
 
-	t.Run("CarryMultiplePatterns", func(t *testing.T) {
+	t.Run("OnExistsAdoptLeftoverCheckout", func(t *testing.T) {
 		t.Parallel()
 
-		repoDir, mainDir := testutil.SetupTestRepo(t)
+		tests := []struct {
+			name        string
+			modify      bool
+			wantChanges bool
+		}{
+			{name: "clean checkout"},
+			{name: "checkout with new file", modify: true, wantChanges: true},
+		}
+
+		for i, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				t.Parallel()
+
+				repoDir, mainDir := testutil.SetupTestRepo(t)
+				branch := fmt.Sprintf("feature/adopt-%d", i)
+				wtPath := filepath.Join(repoDir, branch)
+
+				if err := os.WriteFile(filepath.Join(mainDir, "tracked.txt"), []byte("tracked\n"), 0644); err != nil {
+					t.Fatal(err)
+				}
+				testutil.RunGit(t, mainDir, "add", "tracked.txt")
+				testutil.RunGit(t, mainDir, "commit", "-m", "add tracked file")
+
+				// A crashed run: the checkout is there, git no longer knows it
+				testutil.RunGit(t, mainDir, "worktree", "add", "-b", branch, wtPath)
+				if err := os.RemoveAll(filepath.Join(mainDir, ".git", "worktrees", filepath.Base(wtPath))); err != nil {
+					t.Fatal(err)
+				}
+				if tt.modify {
+					if err := os.WriteFile(filepath.Join(wtPath, "notes.txt"), []byte("edited\n"), 0644); err != nil {
+						t.Fatal(err)
+					}
+				}
+
+				cfg, err := LoadConfig(mainDir)
+				if err != nil {
+					t.Fatal(err)
+				}
+				cmd := &AddCommand{
+					FS:       osFS{},
+					Git:      NewGitRunner(mainDir),
+					Config:   cfg.Config,
+					Log:      NewNopLogger(),
+					OnExists: OnExistsAdopt,
+				}
+				result, err := cmd.Run(t.Context(), branch)
+				if err != nil {
+					t.Fatalf("Run failed: %v", err)
+				}
+
+				if !result.Adopted || result.AdoptedChanges != tt.wantChanges {
+					t.Errorf("Adopted = %v, AdoptedChanges = %v, want true, %v", result.Adopted, result.AdoptedChanges, tt.wantChanges)
+				}
+				out := testutil.RunGit(t, wtPath, "rev-parse", "--abbrev-ref", "HEAD")
+				if strings.TrimSpace(out) != branch {
+					t.Errorf("HEAD = %q, want %s", out, branch)
+				}
+				list := testutil.RunGit(t, mainDir, "worktree", "list", "--porcelain")
+				if !strings.Contains(list, "worktree "+wtPath+"\n") {
+					t.Errorf("worktree list does not contain %s:\n%s", wtPath, list)
+				}
+				if _, err := os.Stat(filepath.Join(repoDir, "feature", "."+filepath.Base(wtPath)+adoptSuffix)); !os.IsNotExist(err) {
+					t.Errorf("scratch directory left behind: %v", err)
+				}
+				if tt.modify {
+					data, _ := os.ReadFile(filepath.Join(wtPath, "notes.txt"))
+					if string(data) != "edited\n" {
+						t.Errorf("adopted file was overwritten: %q", data)
+					}
+				}
+			})
+		}
+	})
 
-		// Commit .twig/settings.toml first
-		testutil.RunGit(t, mainDir, "add", ".twig")
-		testutil.RunGit(t, mainDir, "commit", "-m", "add twig settings")
+	t.Run("OnExistsReplaceStaleDirectory", func(t *testing.T) {
+		t.Parallel()
 
-		// Create directory structure with multiple file types
-		cmdDir := filepath.Join(mainDir, "cmd")
-		if err := os.MkdirAll(cmdDir, 0755); err != nil {
+		repoDir, mainDir := testutil.SetupTestRepo(t)
+		wtPath := filepath.Join(repoDir, "feature", "replace")
+		if err := os.MkdirAll(wtPath, 0755); err != nil {
 			t.Fatal(err)
 		}
-
-		// Create files to carry
-		file1 := filepath.Join(mainDir, "main.go")
-		if err := os.WriteFile(file1, []byte("package main"), 0644); err != nil {
+		if err := os.WriteFile(filepath.Join(wtPath, "junk.txt"), []byte("junk"), 0644); err != nil {
 			t.Fatal(err)
 		}
-		file2 := filepath.Join(cmdDir, "app.go")
-		if err := os.WriteFile(file2, []byte("package cmd"), 0644); err != nil {
+
+		cfg, err := LoadConfig(mainDir)
+		if err != nil {
 			t.Fatal(err)
 		}
-		// File to leave behind
-		file3 := filepath.Join(mainDir, "config.toml")
-		if err := os.WriteFile(file3, []byte("[config]"), 0644); err != nil {
-			t.Fatal(err)
+		cmd := &AddCommand{
+			FS:     osFS{},
+			Git:    NewGitRunner(mainDir),
+			Config: cfg.Config,
+			Log:    NewNopLogger(),
+		}
+
+		// The default keeps the directory
+		if _, err := cmd.Run(t.Context(), "feature/replace"); err == nil || !strings.Contains(err.Error(), "directory already exists") {
+			t.Fatalf("error = %v, want directory already exists", err)
+		}
+
+		cmd.OnExists = OnExistsReplace
+		result, err := cmd.Run(t.Context(), "feature/replace")
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		if !result.Replaced {
+			t.Error("Replaced = false, want true")
+		}
+		if _, err := os.Stat(filepath.Join(wtPath, "junk.txt")); !os.IsNotExist(err) {
+			t.Errorf("junk.txt should be removed, stat err = %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(wtPath, ".git")); err != nil {
+			t.Errorf("worktree not created: %v", err)
+		}
+
+		// A registered worktree is never replaced
+		cmd.OnExists = OnExistsReplace
+		if _, err := cmd.Run(t.Context(), "feature/replace"); err == nil || !strings.Contains(err.Error(), "is the worktree of feature/replace") {
+			t.Fatalf("error = %v, want refusal for a registered worktree", err)
+		}
+		if _, err := os.Stat(filepath.Join(wtPath, ".git")); err != nil {
+			t.Errorf("registered worktree was touched: %v", err)
+		}
+	})
+
+	t.Run("OnExistsReplaceRestoresDirectory", func(t *testing.T) {
+		t.Parallel()
+
+		repoDir, mainDir := testutil.SetupTestRepo(t)
+		wtPath := filepath.Join(repoDir, "feature", "restore")
+		if err := os.MkdirAll(wtPath, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(wtPath, "keep.txt"), []byte("keep"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		cfg, err := LoadConfig(mainDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		faults, err := ParseFaultProfile("git.worktree.add@1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		cmd := &AddCommand{
+			FS:       osFS{},
+			Git:      &GitRunner{Executor: faults.WrapGitExecutor(osGitExecutor{}), Dir: mainDir, Log: NewNopLogger()},
+			Config:   cfg.Config,
+			Log:      NewNopLogger(),
+			OnExists: OnExistsReplace,
+		}
+		var fault *FaultError
+		if _, err := cmd.Run(t.Context(), "feature/restore"); !errors.As(err, &fault) {
+			t.Fatalf("error = %v, want injected fault", err)
+		}
+
+		data, err := os.ReadFile(filepath.Join(wtPath, "keep.txt"))
+		if err != nil || string(data) != "keep" {
+			t.Errorf("keep.txt = %q, %v, want the directory moved back", data, err)
+		}
+		if _, err := os.Stat(filepath.Join(repoDir, "feature", ".restore"+replaceSuffix)); !os.IsNotExist(err) {
+			t.Errorf("replaced directory left aside: %v", err)
+		}
+	})
+
+	t.Run("OnExistsAdoptRollbackKeepsFiles", func(t *testing.T) {
+		t.Parallel()
+
+		tests := []struct {
+			name   string
+			faults string
+			sync   bool
+		}{
+			{name: "repair fails", faults: "git.worktree.repair@1"},
+			{name: "sync apply fails", faults: "git.apply@1", sync: true},
+		}
+
+		for i, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				t.Parallel()
+
+				repoDir, mainDir := testutil.SetupTestRepo(t)
+				branch := fmt.Sprintf("feature/adopt-rollback-%d", i)
+				wtPath := filepath.Join(repoDir, branch)
+
+				if err := os.WriteFile(filepath.Join(mainDir, "tracked.txt"), []byte("tracked\n"), 0644); err != nil {
+					t.Fatal(err)
+				}
+				testutil.RunGit(t, mainDir, "add", "tracked.txt")
+				testutil.RunGit(t, mainDir, "commit", "-m", "add tracked file")
+				if err := os.WriteFile(filepath.Join(mainDir, "tracked.txt"), []byte("changed\n"), 0644); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.MkdirAll(wtPath, 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(filepath.Join(wtPath, "tracked.txt"), []byte("mine\n"), 0644); err != nil {
+					t.Fatal(err)
+				}
+
+				cfg, err := LoadConfig(mainDir)
+				if err != nil {
+					t.Fatal(err)
+				}
+				faults, err := ParseFaultProfile(tt.faults)
+				if err != nil {
+					t.Fatal(err)
+				}
+				cmd := &AddCommand{
+					FS:       osFS{},
+					Git:      &GitRunner{Executor: faults.WrapGitExecutor(osGitExecutor{}), Dir: mainDir, Log: NewNopLogger()},
+					Config:   cfg.Config,
+					Log:      NewNopLogger(),
+					OnExists: OnExistsAdopt,
+					Sync:     tt.sync,
+				}
+				var fault *FaultError
+				if _, err := cmd.Run(t.Context(), branch); !errors.As(err, &fault) {
+					t.Fatalf("error = %v, want injected fault", err)
+				}
+
+				data, err := os.ReadFile(filepath.Join(wtPath, "tracked.txt"))
+				if err != nil || string(data) != "mine\n" {
+					t.Errorf("tracked.txt = %q, %v, want the adopted file kept", data, err)
+				}
+				if _, err := os.Lstat(filepath.Join(wtPath, ".git")); !os.IsNotExist(err) {
+					t.Errorf(".git file left in the adopted directory: %v", err)
+				}
+				if out := testutil.RunGit(t, mainDir, "worktree", "list", "--porcelain"); strings.Contains(out, wtPath) {
+					t.Errorf("worktree still registered:\n%s", out)
+				}
+			})
+		}
+	})
+
+	t.Run("RepairStaleWorktreeEntry", func(t *testing.T) {
+		t.Parallel()
+
+		tests := []struct {
+			name        string
+			interrupted bool
+			wantReason  string
+		}{
+			{name: "directory deleted", wantReason: "its directory is missing"},
+			{name: "interrupted add", interrupted: true, wantReason: "its creation was interrupted"},
+		}
+
+		for i, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				t.Parallel()
+
+				repoDir, mainDir := testutil.SetupTestRepo(t)
+				branch := fmt.Sprintf("feature/stale-%d", i)
+				wtPath := filepath.Join(repoDir, branch)
+
+				// Leftovers of a crashed run, and an unrelated stale entry
+				// that --repair must not touch
+				testutil.RunGit(t, mainDir, "worktree", "add", "-b", branch, wtPath)
+				testutil.RunGit(t, mainDir, "worktree", "add", "-b", "feature/other", filepath.Join(repoDir, "feature", "other"))
+				if tt.interrupted {
+					lockFile := filepath.Join(mainDir, ".git", "worktrees", filepath.Base(wtPath), "locked")
+					if err := os.WriteFile(lockFile, []byte("initializing"), 0644); err != nil {
+						t.Fatal(err)
+					}
+				}
+				for _, dir := range []string{wtPath, filepath.Join(repoDir, "feature", "other")} {
+					if err := os.RemoveAll(dir); err != nil {
+						t.Fatal(err)
+					}
+				}
+
+				cfg, err := LoadConfig(mainDir)
+				if err != nil {
+					t.Fatal(err)
+				}
+				cmd := &AddCommand{
+					FS:     osFS{},
+					Git:    NewGitRunner(mainDir),
+					Config: cfg.Config,
+					Log:    NewNopLogger(),
+				}
+
+				_, err = cmd.Run(t.Context(), branch)
+				var staleErr *StaleWorktreeError
+				if !errors.As(err, &staleErr) {
+					t.Fatalf("error = %v, want StaleWorktreeError", err)
+				}
+				if staleErr.Worktree.Path != wtPath || !strings.Contains(err.Error(), tt.wantReason) {
+					t.Errorf("error = %v, want stale entry at %s (%s)", err, wtPath, tt.wantReason)
+				}
+
+				cmd.Repair = true
+				result, err := cmd.Run(t.Context(), branch)
+				if err != nil {
+					t.Fatalf("Run with Repair failed: %v", err)
+				}
+				if result.Repaired != wtPath {
+					t.Errorf("Repaired = %q, want %q", result.Repaired, wtPath)
+				}
+				if _, err := os.Stat(filepath.Join(wtPath, ".git")); err != nil {
+					t.Errorf("worktree not created: %v", err)
+				}
+				out := testutil.RunGit(t, mainDir, "worktree", "list", "--porcelain")
+				if !strings.Contains(out, "branch refs/heads/feature/other") {
+					t.Errorf("unrelated stale entry was pruned:\n%s", out)
+				}
+			})
+		}
+	})
+
+	t.Run("Description", func(t *testing.T) {
+		t.Parallel()
+
+		_, mainDir := testutil.SetupTestRepo(t)
+
+		cfg, err := LoadConfig(mainDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cmd := &AddCommand{
+			FS:          osFS{},
+			Git:         NewGitRunner(mainDir),
+			Config:      cfg.Config,
+			Log:         NewNopLogger(),
+			Description: "Fix login redirect",
+		}
+
+		result, err := cmd.Run(t.Context(), "feature/described")
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		if result.DescriptionErr != nil {
+			t.Errorf("DescriptionErr = %v", result.DescriptionErr)
+		}
+		got := strings.TrimSpace(testutil.RunGit(t, mainDir, "config", "branch.feature/described.description"))
+		if got != "Fix login redirect" {
+			t.Errorf("branch description = %q, want %q", got, "Fix login redirect")
+		}
+	})
+
+	t.Run("Provenance", func(t *testing.T) {
+		t.Parallel()
+
+		_, mainDir := testutil.SetupTestRepo(t)
+
+		cfg, err := LoadConfig(mainDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cmd := &AddCommand{
+			FS:         osFS{},
+			Git:        NewGitRunner(mainDir),
+			Config:     cfg.Config,
+			Log:        NewNopLogger(),
+			Provenance: Provenance{Version: "v1.2.3", Flags: []string{"--lock"}},
+		}
+
+		result, err := cmd.Run(t.Context(), "feature/marked")
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		if result.ProvenanceErr != nil {
+			t.Fatalf("ProvenanceErr = %v", result.ProvenanceErr)
+		}
+
+		markers, err := NewProvenanceStore(osFS{}, NewGitRunner(mainDir)).Load(t.Context())
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, ok := markers[result.WorktreePath]
+		if !ok {
+			t.Fatalf("no provenance for %s in %v", result.WorktreePath, markers)
+		}
+		if got.Command != ProvenanceCommandAdd || got.Source != "main" || got.Version != "v1.2.3" ||
+			!reflect.DeepEqual(got.Flags, []string{"--lock"}) || got.CreatedAt.IsZero() {
+			t.Errorf("provenance = %+v", got)
+		}
+		if _, ok := markers[mainDir]; ok {
+			t.Error("main worktree has a provenance marker")
+		}
+	})
+
+	t.Run("CarrySpecificFiles", func(t *testing.T) {
+		t.Parallel()
+
+		repoDir, mainDir := testutil.SetupTestRepo(t)
+
+		// Commit .twig/settings.toml first
+		testutil.RunGit(t, mainDir, "add", ".twig")
+		testutil.RunGit(t, mainDir, "commit", "-m", "add twig settings")
+
+		// Create multiple uncommitted files
+		goFile := filepath.Join(mainDir, "main.go")
+		if err := os.WriteFile(goFile, []byte("package main"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		txtFile := filepath.Join(mainDir, "readme.txt")
+		if err := os.WriteFile(txtFile, []byte("readme content"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := LoadConfig(mainDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Carry only *.go files
+		cmd := &AddCommand{
+			FS:           osFS{},
+			Git:          NewGitRunner(mainDir),
+			Config:       result.Config,
+			Log:          NewNopLogger(),
+			CarryFrom:    mainDir,
+			FilePatterns: []string{"*.go"},
+		}
+
+		addResult, err := cmd.Run(t.Context(), "feature/carry-go-only")
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+
+		// Verify ChangesCarried is true
+		if !addResult.ChangesCarried {
+			t.Error("expected ChangesCarried to be true")
+		}
+
+		// Verify worktree was created
+		wtPath := filepath.Join(repoDir, "feature", "carry-go-only")
+		if _, err := os.Stat(wtPath); os.IsNotExist(err) {
+			t.Errorf("worktree directory does not exist: %s", wtPath)
+		}
+
+		// Verify main.go exists in new worktree (carried)
+		carriedGoFile := filepath.Join(wtPath, "main.go")
+		content, err := os.ReadFile(carriedGoFile)
+		if err != nil {
+			t.Fatalf("failed to read carried go file: %v", err)
+		}
+		if string(content) != "package main" {
+			t.Errorf("carried go file content = %q, want %q", string(content), "package main")
+		}
+
+		// Verify readme.txt does NOT exist in new worktree (not carried)
+		notCarriedTxtFile := filepath.Join(wtPath, "readme.txt")
+		if _, err := os.Stat(notCarriedTxtFile); !os.IsNotExist(err) {
+			t.Errorf("readme.txt should not exist in new worktree: %s", notCarriedTxtFile)
+		}
+
+		// Verify main.go does NOT exist in source (carried away)
+		if _, err := os.Stat(goFile); !os.IsNotExist(err) {
+			t.Errorf("main.go should not exist in source after carry: %s", goFile)
+		}
+
+		// Verify readme.txt still exists in source (not carried)
+		sourceContent, err := os.ReadFile(txtFile)
+		if err != nil {
+			t.Fatalf("failed to read source txt file: %v", err)
+		}
+		if string(sourceContent) != "readme content" {
+			t.Errorf("source txt file content = %q, want %q", string(sourceContent), "readme content")
+		}
+
+		// Verify source has remaining changes (readme.txt)
+		status := testutil.RunGit(t, mainDir, "status", "--porcelain")
+		if !strings.Contains(status, "readme.txt") {
+			t.Errorf("source should have readme.txt as untracked, got: %q", status)
+		}
+	})
+
+	t.Run("CarryMultiplePatterns", func(t *testing.T) {
+		t.Parallel()
+
+		repoDir, mainDir := testutil.SetupTestRepo(t)
+
+		// Commit .twig/settings.toml first
+		testutil.RunGit(t, mainDir, "add", ".twig")
+		testutil.RunGit(t, mainDir, "commit", "-m", "add twig settings")
+
+		// Create directory structure with multiple file types
+		cmdDir := filepath.Join(mainDir, "cmd")
+		if err := os.MkdirAll(cmdDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		// Create files to carry
+		file1 := filepath.Join(mainDir, "main.go")
+		if err := os.WriteFile(file1, []byte("package main"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		file2 := filepath.Join(cmdDir, "app.go")
+		if err := os.WriteFile(file2, []byte("package cmd"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		// File to leave behind
+		file3 := filepath.Join(mainDir, "config.toml")
+		if err := os.WriteFile(file3, []byte("[config]"), 0644); err != nil {
+			t.Fatal(err)
 		}
 
 		result, err := LoadConfig(mainDir)
@@ -1309,6 +2043,7 @@ worktree_destination_base_dir = %q
 			FS:           osFS{},
 			Git:          NewGitRunner(mainDir),
 			Config:       result.Config,
+			Log:          NewNopLogger(),
 			CarryFrom:    mainDir,
 			FilePatterns: []string{"*.go", "cmd/**"},
 		}
@@ -1383,6 +2118,7 @@ worktree_destination_base_dir = %q
 			FS:           osFS{},
 			Git:          NewGitRunner(mainDir),
 			Config:       result.Config,
+			Log:          NewNopLogger(),
 			CarryFrom:    mainDir,
 			FilePatterns: []string{"**/*.go"},
 		}
@@ -1420,6 +2156,91 @@ worktree_destination_base_dir = %q
 		}
 	})
 
+	t.Run("CarrySkipsIgnoredFiles", func(t *testing.T) {
+		t.Parallel()
+
+		tests := []struct {
+			name           string
+			includeIgnored bool
+			wantCarried    []string
+			wantLeft       []string
+		}{
+			{
+				name:        "ignored files stay",
+				wantCarried: []string{"notes.txt"},
+				wantLeft:    []string{"build/out.bin", "debug.log"},
+			},
+			{
+				name:           "include ignored",
+				includeIgnored: true,
+				wantCarried:    []string{"notes.txt", "build/out.bin", "debug.log"},
+			},
+		}
+
+		for i, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				t.Parallel()
+
+				_, mainDir := testutil.SetupTestRepo(t, testutil.WithoutSettings())
+				if err := os.WriteFile(filepath.Join(mainDir, ".gitignore"), []byte("build/\n*.log\n"), 0644); err != nil {
+					t.Fatal(err)
+				}
+				testutil.RunGit(t, mainDir, "add", ".gitignore")
+				testutil.RunGit(t, mainDir, "commit", "-m", "add gitignore")
+
+				for _, rel := range []string{"notes.txt", "build/out.bin", "debug.log"} {
+					path := filepath.Join(mainDir, rel)
+					if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+						t.Fatal(err)
+					}
+					if err := os.WriteFile(path, []byte(rel), 0644); err != nil {
+						t.Fatal(err)
+					}
+				}
+
+				result, err := LoadConfig(mainDir)
+				if err != nil {
+					t.Fatal(err)
+				}
+				branch := fmt.Sprintf("feature/ignored-%d", i)
+				cmd := &AddCommand{
+					FS:             osFS{},
+					Git:            NewGitRunner(mainDir),
+					Config:         result.Config,
+					Log:            NewNopLogger(),
+					CarryFrom:      mainDir,
+					FilePatterns:   []string{"*", "build/**"},
+					IncludeIgnored: tt.includeIgnored,
+				}
+				addResult, err := cmd.Run(t.Context(), branch)
+				if err != nil {
+					t.Fatalf("Run failed: %v", err)
+				}
+				if !tt.includeIgnored && addResult.IgnoredSkipped == 0 {
+					t.Error("IgnoredSkipped = 0, want ignored paths reported")
+				}
+
+				wtPath := addResult.WorktreePath
+				for _, rel := range tt.wantCarried {
+					if _, err := os.Stat(filepath.Join(wtPath, rel)); err != nil {
+						t.Errorf("%s should be carried: %v", rel, err)
+					}
+					if _, err := os.Stat(filepath.Join(mainDir, rel)); !os.IsNotExist(err) {
+						t.Errorf("%s should be removed from source", rel)
+					}
+				}
+				for _, rel := range tt.wantLeft {
+					if _, err := os.Stat(filepath.Join(wtPath, rel)); !os.IsNotExist(err) {
+						t.Errorf("%s should not be carried", rel)
+					}
+					if _, err := os.Stat(filepath.Join(mainDir, rel)); err != nil {
+						t.Errorf("%s should stay in source: %v", rel, err)
+					}
+				}
+			})
+		}
+	})
+
 	t.Run("RemoteBranchFetchAndCreateWorktree", func(t *testing.T) {
 		t.Parallel()
 
@@ -1519,19 +2340,14 @@ worktree_destination_base_dir = %q
 		}
 	})
 
-	t.Run("LocalBranchTakesPrecedenceOverRemote", func(t *testing.T) {
+	t.Run("LookupRemoteBranchesFindsUnfetchedBranch", func(t *testing.T) {
 		t.Parallel()
 
-		// Create a bare "origin" repository
 		tmpDir := t.TempDir()
 		tmpDir, _ = filepath.EvalSymlinks(tmpDir)
 		originDir := filepath.Join(tmpDir, "origin.git")
-		if err := os.MkdirAll(originDir, 0755); err != nil {
-			t.Fatal(err)
-		}
-		testutil.RunGit(t, originDir, "init", "--bare")
+		testutil.RunGit(t, tmpDir, "init", "--bare", originDir)
 
-		// Create main repo and push to origin
 		mainDir := filepath.Join(tmpDir, "repo", "main")
 		if err := os.MkdirAll(mainDir, 0755); err != nil {
 			t.Fatal(err)
@@ -1542,38 +2358,28 @@ worktree_destination_base_dir = %q
 		testutil.RunGit(t, mainDir, "commit", "--allow-empty", "-m", "initial")
 		testutil.RunGit(t, mainDir, "remote", "add", "origin", originDir)
 		testutil.RunGit(t, mainDir, "push", "-u", "origin", "main")
+		// An unreachable remote must not block the lookup
+		testutil.RunGit(t, mainDir, "remote", "add", "fork", filepath.Join(tmpDir, "missing.git"))
 
-		// Create local branch with local-only content
-		testutil.RunGit(t, mainDir, "branch", "feature/both-local-remote")
-		testutil.RunGit(t, mainDir, "checkout", "feature/both-local-remote")
-		if err := os.WriteFile(filepath.Join(mainDir, "local-file.txt"), []byte("from local"), 0644); err != nil {
-			t.Fatal(err)
-		}
-		testutil.RunGit(t, mainDir, "add", ".")
-		testutil.RunGit(t, mainDir, "commit", "-m", "local commit")
-		testutil.RunGit(t, mainDir, "checkout", "main")
-
-		// Push different content to origin under same branch name
+		// Push a branch from another clone; main never fetches it
 		cloneDir := filepath.Join(tmpDir, "clone")
 		testutil.RunGit(t, tmpDir, "clone", originDir, "clone")
 		testutil.RunGit(t, cloneDir, "config", "user.email", "test@example.com")
 		testutil.RunGit(t, cloneDir, "config", "user.name", "Test User")
-		testutil.RunGit(t, cloneDir, "checkout", "-b", "feature/both-local-remote")
+		testutil.RunGit(t, cloneDir, "checkout", "-b", "feature/unfetched")
 		if err := os.WriteFile(filepath.Join(cloneDir, "remote-file.txt"), []byte("from remote"), 0644); err != nil {
 			t.Fatal(err)
 		}
 		testutil.RunGit(t, cloneDir, "add", ".")
 		testutil.RunGit(t, cloneDir, "commit", "-m", "remote commit")
-		testutil.RunGit(t, cloneDir, "push", "-u", "origin", "feature/both-local-remote")
+		testutil.RunGit(t, cloneDir, "push", "-u", "origin", "feature/unfetched")
 
-		// Setup twig config
 		twigDir := filepath.Join(mainDir, ".twig")
 		if err := os.MkdirAll(twigDir, 0755); err != nil {
 			t.Fatal(err)
 		}
 		repoDir := filepath.Join(tmpDir, "repo")
-		settings := fmt.Sprintf(`worktree_destination_base_dir = %q
-`, repoDir)
+		settings := fmt.Sprintf("worktree_destination_base_dir = %q\nlookup_remote_branches = true\n", repoDir)
 		if err := os.WriteFile(filepath.Join(twigDir, "settings.toml"), []byte(settings), 0644); err != nil {
 			t.Fatal(err)
 		}
@@ -1588,30 +2394,252 @@ worktree_destination_base_dir = %q
 			Git:    NewGitRunner(mainDir),
 			Config: result.Config,
 		}
-
-		_, err = cmd.Run(t.Context(), "feature/both-local-remote")
-		if err != nil {
+		if _, err := cmd.Run(t.Context(), "feature/unfetched"); err != nil {
 			t.Fatalf("Run failed: %v", err)
 		}
 
-		// Verify worktree was created
-		wtPath := filepath.Join(repoDir, "feature", "both-local-remote")
-		if _, err := os.Stat(wtPath); os.IsNotExist(err) {
-			t.Errorf("worktree directory does not exist: %s", wtPath)
-		}
-
-		// Verify local file exists (proving we used local branch)
-		localFile := filepath.Join(wtPath, "local-file.txt")
-		content, err := os.ReadFile(localFile)
+		wtPath := filepath.Join(repoDir, "feature", "unfetched")
+		content, err := os.ReadFile(filepath.Join(wtPath, "remote-file.txt"))
 		if err != nil {
-			t.Fatalf("failed to read local file: %v", err)
+			t.Fatalf("failed to read remote file: %v", err)
 		}
-		if string(content) != "from local" {
-			t.Errorf("local file content = %q, want %q", string(content), "from local")
+		if string(content) != "from remote" {
+			t.Errorf("remote file content = %q, want %q", string(content), "from remote")
 		}
+		upstream := testutil.RunGit(t, wtPath, "rev-parse", "--abbrev-ref", "@{upstream}")
+		if strings.TrimSpace(upstream) != "origin/feature/unfetched" {
+			t.Errorf("upstream = %q, want origin/feature/unfetched", strings.TrimSpace(upstream))
+		}
+	})
 
-		// Verify remote file does NOT exist (we didn't fetch from remote)
-		remoteFile := filepath.Join(wtPath, "remote-file.txt")
+	t.Run("FetchFindsUnfetchedBranch", func(t *testing.T) {
+		t.Parallel()
+
+		tmpDir := t.TempDir()
+		tmpDir, _ = filepath.EvalSymlinks(tmpDir)
+		originDir := filepath.Join(tmpDir, "origin.git")
+		testutil.RunGit(t, tmpDir, "init", "--bare", originDir)
+
+		mainDir := filepath.Join(tmpDir, "repo", "main")
+		if err := os.MkdirAll(mainDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		testutil.RunGit(t, mainDir, "init", "-b", "main")
+		testutil.RunGit(t, mainDir, "config", "user.email", "test@example.com")
+		testutil.RunGit(t, mainDir, "config", "user.name", "Test User")
+		testutil.RunGit(t, mainDir, "commit", "--allow-empty", "-m", "initial")
+		testutil.RunGit(t, mainDir, "remote", "add", "origin", originDir)
+		testutil.RunGit(t, mainDir, "push", "-u", "origin", "main")
+		// An unreachable remote is skipped
+		testutil.RunGit(t, mainDir, "remote", "add", "fork", filepath.Join(tmpDir, "missing.git"))
+
+		// Push a branch from another clone; main never fetches it
+		cloneDir := filepath.Join(tmpDir, "clone")
+		testutil.RunGit(t, tmpDir, "clone", originDir, "clone")
+		testutil.RunGit(t, cloneDir, "config", "user.email", "test@example.com")
+		testutil.RunGit(t, cloneDir, "config", "user.name", "Test User")
+		testutil.RunGit(t, cloneDir, "checkout", "-b", "feature/unfetched")
+		if err := os.WriteFile(filepath.Join(cloneDir, "remote-file.txt"), []byte("from remote"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		testutil.RunGit(t, cloneDir, "add", ".")
+		testutil.RunGit(t, cloneDir, "commit", "-m", "remote commit")
+		testutil.RunGit(t, cloneDir, "push", "-u", "origin", "feature/unfetched")
+
+		repoDir := filepath.Join(tmpDir, "repo")
+		cmd := &AddCommand{
+			FS:     osFS{},
+			Git:    NewGitRunner(mainDir),
+			Config: &Config{WorktreeSourceDir: mainDir, WorktreeDestBaseDir: repoDir},
+			Log:    NewNopLogger(),
+			Fetch:  true,
+		}
+		if _, err := cmd.Run(t.Context(), "feature/unfetched"); err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+
+		wtPath := filepath.Join(repoDir, "feature", "unfetched")
+		content, err := os.ReadFile(filepath.Join(wtPath, "remote-file.txt"))
+		if err != nil {
+			t.Fatalf("failed to read remote file: %v", err)
+		}
+		if string(content) != "from remote" {
+			t.Errorf("remote file content = %q, want %q", string(content), "from remote")
+		}
+		upstream := testutil.RunGit(t, wtPath, "rev-parse", "--abbrev-ref", "@{upstream}")
+		if strings.TrimSpace(upstream) != "origin/feature/unfetched" {
+			t.Errorf("upstream = %q, want origin/feature/unfetched", strings.TrimSpace(upstream))
+		}
+
+		// A branch on no remote is still created
+		if _, err := cmd.Run(t.Context(), "feature/brand-new"); err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		newPath := filepath.Join(repoDir, "feature", "brand-new")
+		head := testutil.RunGit(t, newPath, "rev-parse", "--abbrev-ref", "HEAD")
+		if strings.TrimSpace(head) != "feature/brand-new" {
+			t.Errorf("HEAD = %q, want feature/brand-new", strings.TrimSpace(head))
+		}
+	})
+
+	t.Run("RemoteBranchFollowsGitConfig", func(t *testing.T) {
+		t.Parallel()
+
+		tmpDir := t.TempDir()
+		tmpDir, _ = filepath.EvalSymlinks(tmpDir)
+		mainDir := filepath.Join(tmpDir, "repo", "main")
+		if err := os.MkdirAll(mainDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		testutil.RunGit(t, mainDir, "init", "-b", "main")
+		testutil.RunGit(t, mainDir, "config", "user.email", "test@example.com")
+		testutil.RunGit(t, mainDir, "config", "user.name", "Test User")
+		testutil.RunGit(t, mainDir, "commit", "--allow-empty", "-m", "initial")
+
+		// Push the same branches to two remotes, then drop them locally
+		for _, remote := range []string{"origin", "upstream"} {
+			remoteDir := filepath.Join(tmpDir, remote+".git")
+			testutil.RunGit(t, tmpDir, "init", "--bare", remoteDir)
+			testutil.RunGit(t, mainDir, "remote", "add", remote, remoteDir)
+		}
+		testutil.RunGit(t, mainDir, "checkout", "-b", "feature/shared")
+		testutil.RunGit(t, mainDir, "commit", "--allow-empty", "-m", "shared commit")
+		testutil.RunGit(t, mainDir, "branch", "feature/unguessed")
+		for _, remote := range []string{"origin", "upstream"} {
+			testutil.RunGit(t, mainDir, "push", remote, "feature/shared", "feature/unguessed")
+		}
+		testutil.RunGit(t, mainDir, "checkout", "main")
+		testutil.RunGit(t, mainDir, "branch", "-D", "feature/shared", "feature/unguessed")
+
+		repoDir := filepath.Join(tmpDir, "repo")
+		cmd := &AddCommand{
+			FS:     osFS{},
+			Git:    NewGitRunner(mainDir),
+			Config: &Config{WorktreeSourceDir: mainDir, WorktreeDestBaseDir: repoDir},
+		}
+
+		if _, err := cmd.Run(t.Context(), "feature/shared"); err == nil || !strings.Contains(err.Error(), "multiple remotes") {
+			t.Fatalf("Run without checkout.defaultRemote error = %v, want multiple remotes", err)
+		}
+
+		testutil.RunGit(t, mainDir, "config", "checkout.defaultRemote", "upstream")
+		if _, err := cmd.Run(t.Context(), "feature/shared"); err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		wtPath := filepath.Join(repoDir, "feature", "shared")
+		upstream := testutil.RunGit(t, wtPath, "rev-parse", "--abbrev-ref", "@{upstream}")
+		if strings.TrimSpace(upstream) != "upstream/feature/shared" {
+			t.Errorf("upstream = %q, want upstream/feature/shared", strings.TrimSpace(upstream))
+		}
+
+		// worktree.guessRemote=false creates a new branch from HEAD instead
+		testutil.RunGit(t, mainDir, "config", "worktree.guessRemote", "false")
+		if _, err := cmd.Run(t.Context(), "feature/unguessed"); err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		got := testutil.RunGit(t, mainDir, "rev-parse", "feature/unguessed")
+		want := testutil.RunGit(t, mainDir, "rev-parse", "main")
+		if got != want {
+			t.Errorf("feature/unguessed = %s, want main HEAD %s", got, want)
+		}
+		if out := testutil.RunGit(t, mainDir, "for-each-ref", "--format=%(upstream)", "refs/heads/feature/unguessed"); strings.TrimSpace(out) != "" {
+			t.Errorf("feature/unguessed tracks %q, want no upstream", strings.TrimSpace(out))
+		}
+	})
+
+	t.Run("LocalBranchTakesPrecedenceOverRemote", func(t *testing.T) {
+		t.Parallel()
+
+		// Create a bare "origin" repository
+		tmpDir := t.TempDir()
+		tmpDir, _ = filepath.EvalSymlinks(tmpDir)
+		originDir := filepath.Join(tmpDir, "origin.git")
+		if err := os.MkdirAll(originDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		testutil.RunGit(t, originDir, "init", "--bare")
+
+		// Create main repo and push to origin
+		mainDir := filepath.Join(tmpDir, "repo", "main")
+		if err := os.MkdirAll(mainDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		testutil.RunGit(t, mainDir, "init", "-b", "main")
+		testutil.RunGit(t, mainDir, "config", "user.email", "test@example.com")
+		testutil.RunGit(t, mainDir, "config", "user.name", "Test User")
+		testutil.RunGit(t, mainDir, "commit", "--allow-empty", "-m", "initial")
+		testutil.RunGit(t, mainDir, "remote", "add", "origin", originDir)
+		testutil.RunGit(t, mainDir, "push", "-u", "origin", "main")
+
+		// Create local branch with local-only content
+		testutil.RunGit(t, mainDir, "branch", "feature/both-local-remote")
+		testutil.RunGit(t, mainDir, "checkout", "feature/both-local-remote")
+		if err := os.WriteFile(filepath.Join(mainDir, "local-file.txt"), []byte("from local"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		testutil.RunGit(t, mainDir, "add", ".")
+		testutil.RunGit(t, mainDir, "commit", "-m", "local commit")
+		testutil.RunGit(t, mainDir, "checkout", "main")
+
+		// Push different content to origin under same branch name
+		cloneDir := filepath.Join(tmpDir, "clone")
+		testutil.RunGit(t, tmpDir, "clone", originDir, "clone")
+		testutil.RunGit(t, cloneDir, "config", "user.email", "test@example.com")
+		testutil.RunGit(t, cloneDir, "config", "user.name", "Test User")
+		testutil.RunGit(t, cloneDir, "checkout", "-b", "feature/both-local-remote")
+		if err := os.WriteFile(filepath.Join(cloneDir, "remote-file.txt"), []byte("from remote"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		testutil.RunGit(t, cloneDir, "add", ".")
+		testutil.RunGit(t, cloneDir, "commit", "-m", "remote commit")
+		testutil.RunGit(t, cloneDir, "push", "-u", "origin", "feature/both-local-remote")
+
+		// Setup twig config
+		twigDir := filepath.Join(mainDir, ".twig")
+		if err := os.MkdirAll(twigDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		repoDir := filepath.Join(tmpDir, "repo")
+		settings := fmt.Sprintf(`worktree_destination_base_dir = %q
+`, repoDir)
+		if err := os.WriteFile(filepath.Join(twigDir, "settings.toml"), []byte(settings), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := LoadConfig(mainDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		cmd := &AddCommand{
+			FS:     osFS{},
+			Git:    NewGitRunner(mainDir),
+			Config: result.Config,
+		}
+
+		_, err = cmd.Run(t.Context(), "feature/both-local-remote")
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+
+		// Verify worktree was created
+		wtPath := filepath.Join(repoDir, "feature", "both-local-remote")
+		if _, err := os.Stat(wtPath); os.IsNotExist(err) {
+			t.Errorf("worktree directory does not exist: %s", wtPath)
+		}
+
+		// Verify local file exists (proving we used local branch)
+		localFile := filepath.Join(wtPath, "local-file.txt")
+		content, err := os.ReadFile(localFile)
+		if err != nil {
+			t.Fatalf("failed to read local file: %v", err)
+		}
+		if string(content) != "from local" {
+			t.Errorf("local file content = %q, want %q", string(content), "from local")
+		}
+
+		// Verify remote file does NOT exist (we didn't fetch from remote)
+		remoteFile := filepath.Join(wtPath, "remote-file.txt")
 		if _, err := os.Stat(remoteFile); !os.IsNotExist(err) {
 			t.Errorf("remote-file.txt should not exist (local branch takes precedence)")
 		}
@@ -1693,11 +2721,128 @@ worktree_destination_base_dir = %q
 			t.Errorf("worktree list should contain feature/brand-new: %s", listOut)
 		}
 	})
+
+	t.Run("BranchPrefixAndAlias", func(t *testing.T) {
+		t.Parallel()
+
+		repoDir, mainDir := testutil.SetupTestRepo(t)
+
+		localSettings := "branch_prefix = \"users/me/\"\nbranch_aliases = { hot = \"hotfix/urgent\" }\n"
+		if err := os.WriteFile(filepath.Join(mainDir, ".twig", "settings.local.toml"), []byte(localSettings), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := LoadConfig(mainDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		cmd := NewAddCommand(osFS{}, NewGitRunner(mainDir), result.Config, nil, AddOptions{})
+
+		for _, tc := range []struct{ name, branch string }{
+			{"fix-login", "users/me/fix-login"},
+			{"hot", "hotfix/urgent"},
+		} {
+			addResult, err := cmd.Run(t.Context(), tc.name)
+			if err != nil {
+				t.Fatalf("Run(%q) failed: %v", tc.name, err)
+			}
+			if addResult.Branch != tc.branch {
+				t.Errorf("Branch = %q, want %q", addResult.Branch, tc.branch)
+			}
+
+			wtPath := filepath.Join(repoDir, tc.name)
+			out := testutil.RunGit(t, wtPath, "rev-parse", "--abbrev-ref", "HEAD")
+			if got := strings.TrimSpace(out); got != tc.branch {
+				t.Errorf("worktree %s is on %q, want %q", wtPath, got, tc.branch)
+			}
+		}
+	})
+
+	t.Run("PullRequestHead", func(t *testing.T) {
+		t.Parallel()
+
+		// The forge publishes the PR head under refs/pull/<n>/head
+		_, forgeDir := testutil.SetupTestRepo(t)
+		testutil.RunGit(t, forgeDir, "commit", "--allow-empty", "-m", "PR commit")
+		prHead := strings.TrimSpace(testutil.RunGit(t, forgeDir, "rev-parse", "HEAD"))
+		testutil.RunGit(t, forgeDir, "update-ref", "refs/pull/42/head", prHead)
+		testutil.RunGit(t, forgeDir, "reset", "--hard", "HEAD~1")
+
+		repoDir, mainDir := testutil.SetupTestRepo(t)
+		testutil.RunGit(t, mainDir, "remote", "add", "origin", forgeDir)
+
+		result, err := LoadConfig(mainDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		cmd := NewAddCommand(osFS{}, NewGitRunner(mainDir), result.Config, nil, AddOptions{PR: 42})
+		addResult, err := cmd.Run(t.Context(), "")
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		if addResult.Branch != "pr/42" {
+			t.Errorf("Branch = %q, want pr/42", addResult.Branch)
+		}
+
+		wtPath := filepath.Join(repoDir, "pr", "42")
+		if got := strings.TrimSpace(testutil.RunGit(t, wtPath, "rev-parse", "HEAD")); got != prHead {
+			t.Errorf("worktree HEAD = %s, want PR head %s", got, prHead)
+		}
+	})
 }
 
 func TestAddCommand_Hooks_Integration(t *testing.T) {
 	t.Parallel()
 
+	t.Run("EnvFileWrittenBeforeHooks", func(t *testing.T) {
+		t.Parallel()
+
+		repoDir, mainDir := testutil.SetupTestRepo(t)
+
+		settings := fmt.Sprintf(`worktree_destination_base_dir = %q
+env_file = true
+hooks = ["cp .twig.env .hook-saw-env"]
+
+[env_file_vars]
+PORT = "3001"
+`, repoDir)
+		if err := os.WriteFile(filepath.Join(mainDir, ".twig", "settings.toml"), []byte(settings), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := LoadConfig(mainDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cmd := NewDefaultAddCommand(result.Config, NewNopLogger(), AddOptions{})
+
+		addResult, err := cmd.Run(t.Context(), "feature/env-file")
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+
+		wtPath := filepath.Join(repoDir, "feature", "env-file")
+		if addResult.EnvFile != filepath.Join(wtPath, EnvFileName) {
+			t.Errorf("EnvFile = %q", addResult.EnvFile)
+		}
+		data, err := os.ReadFile(filepath.Join(wtPath, ".hook-saw-env"))
+		if err != nil {
+			t.Fatalf("hook did not see %s: %v", EnvFileName, err)
+		}
+		for _, line := range []string{
+			`TWIG_BRANCH="feature/env-file"`,
+			`TWIG_WORKTREE_PATH="` + wtPath + `"`,
+			`TWIG_SOURCE_PATH="` + result.Config.WorktreeSourceDir + `"`,
+			`PORT="3001"`,
+		} {
+			if !strings.Contains(string(data), line+"\n") {
+				t.Errorf("%s is missing %s:\n%s", EnvFileName, line, data)
+			}
+		}
+	})
+
 	t.Run("HooksExecuteInNewWorktree", func(t *testing.T) {
 		t.Parallel()
 
@@ -1748,15 +2893,14 @@ hooks = ["touch .hook-executed"]
 		}
 	})
 
-	t.Run("HookFailureIsWarning", func(t *testing.T) {
+	t.Run("HooksReceiveWorktreeEnv", func(t *testing.T) {
 		t.Parallel()
 
 		repoDir, mainDir := testutil.SetupTestRepo(t)
 
-		// Setup twig config with failing hook
 		twigDir := filepath.Join(mainDir, ".twig")
 		settings := fmt.Sprintf(`worktree_destination_base_dir = %q
-hooks = ["exit 1"]
+hooks = ['printf "%%s|%%s|%%s" "$TWIG_BRANCH" "$TWIG_WORKTREE_PATH" "$TWIG_IS_MAIN"']
 `, repoDir)
 		if err := os.WriteFile(filepath.Join(twigDir, "settings.toml"), []byte(settings), 0644); err != nil {
 			t.Fatal(err)
@@ -1769,23 +2913,62 @@ hooks = ["exit 1"]
 
 		cmd := NewDefaultAddCommand(result.Config, NewNopLogger(), AddOptions{})
 
-		addResult, err := cmd.Run(t.Context(), "feature/hooks-fail")
-		// Worktree creation should succeed even with hook failure
+		addResult, err := cmd.Run(t.Context(), "feature/hooks-env")
 		if err != nil {
-			t.Fatalf("Run failed: %v (should succeed despite hook failure)", err)
-		}
-
-		// Verify worktree was created
-		wtPath := filepath.Join(repoDir, "feature", "hooks-fail")
-		if _, err := os.Stat(wtPath); os.IsNotExist(err) {
-			t.Errorf("worktree directory does not exist: %s", wtPath)
+			t.Fatalf("Run failed: %v", err)
 		}
 
-		// Verify hook result captures error
 		if len(addResult.HookResults) != 1 {
 			t.Fatalf("HookResults length = %d, want 1", len(addResult.HookResults))
 		}
-		if addResult.HookResults[0].Err == nil {
+		if addResult.HookResults[0].Err != nil {
+			t.Fatalf("hook error = %v, want nil", addResult.HookResults[0].Err)
+		}
+		wtPath := filepath.Join(repoDir, "feature", "hooks-env")
+		want := "feature/hooks-env|" + wtPath + "|0"
+		if got := string(addResult.HookResults[0].Output); got != want {
+			t.Errorf("hook output = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("HookFailureIsWarning", func(t *testing.T) {
+		t.Parallel()
+
+		repoDir, mainDir := testutil.SetupTestRepo(t)
+
+		// Setup twig config with failing hook
+		twigDir := filepath.Join(mainDir, ".twig")
+		settings := fmt.Sprintf(`worktree_destination_base_dir = %q
+hooks = ["exit 1"]
+`, repoDir)
+		if err := os.WriteFile(filepath.Join(twigDir, "settings.toml"), []byte(settings), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := LoadConfig(mainDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		cmd := NewDefaultAddCommand(result.Config, NewNopLogger(), AddOptions{})
+
+		addResult, err := cmd.Run(t.Context(), "feature/hooks-fail")
+		// Worktree creation should succeed even with hook failure
+		if err != nil {
+			t.Fatalf("Run failed: %v (should succeed despite hook failure)", err)
+		}
+
+		// Verify worktree was created
+		wtPath := filepath.Join(repoDir, "feature", "hooks-fail")
+		if _, err := os.Stat(wtPath); os.IsNotExist(err) {
+			t.Errorf("worktree directory does not exist: %s", wtPath)
+		}
+
+		// Verify hook result captures error
+		if len(addResult.HookResults) != 1 {
+			t.Fatalf("HookResults length = %d, want 1", len(addResult.HookResults))
+		}
+		if addResult.HookResults[0].Err == nil {
 			t.Error("hook error should not be nil")
 		}
 	})
@@ -2113,6 +3296,111 @@ init_submodules = false
 	})
 }
 
+// Not parallel: uses t.Setenv for file:// protocol in local submodule URLs.
+func TestAddCommand_SubmoduleSelection_Integration(t *testing.T) {
+	t.Setenv("GIT_CONFIG_COUNT", "1")
+	t.Setenv("GIT_CONFIG_KEY_0", "protocol.file.allow")
+	t.Setenv("GIT_CONFIG_VALUE_0", "always")
+
+	newRepo := func(t *testing.T, dir string) {
+		t.Helper()
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		testutil.RunGit(t, dir, "init")
+		testutil.RunGit(t, dir, "config", "user.email", "test@example.com")
+		testutil.RunGit(t, dir, "config", "user.name", "Test")
+		if err := os.WriteFile(filepath.Join(dir, "file.txt"), []byte(filepath.Base(dir)), 0644); err != nil {
+			t.Fatal(err)
+		}
+		testutil.RunGit(t, dir, "add", ".")
+		testutil.RunGit(t, dir, "commit", "-m", "initial")
+	}
+
+	// libs/a has a nested submodule, libs/b is not selected
+	setup := func(t *testing.T, settings string) (string, *Config) {
+		t.Helper()
+		repoDir, mainDir := testutil.SetupTestRepo(t)
+		for _, name := range []string{"nested-repo", "a-repo", "b-repo"} {
+			newRepo(t, filepath.Join(repoDir, name))
+		}
+		aRepo := filepath.Join(repoDir, "a-repo")
+		testutil.RunGit(t, aRepo, "submodule", "add", filepath.Join(repoDir, "nested-repo"), "nested")
+		testutil.RunGit(t, aRepo, "commit", "-m", "add nested")
+		testutil.RunGit(t, mainDir, "submodule", "add", aRepo, "libs/a")
+		testutil.RunGit(t, mainDir, "submodule", "add", filepath.Join(repoDir, "b-repo"), "libs/b")
+		testutil.RunGit(t, mainDir, "commit", "-m", "add submodules")
+
+		config := fmt.Sprintf("worktree_destination_base_dir = %q\ninit_submodules = true\n%s", repoDir, settings)
+		if err := os.WriteFile(filepath.Join(mainDir, ".twig", "settings.toml"), []byte(config), 0644); err != nil {
+			t.Fatal(err)
+		}
+		result, err := LoadConfig(mainDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return repoDir, result.Config
+	}
+
+	tests := []struct {
+		name          string
+		settings      string
+		wantNested    bool
+		wantCount     int
+		wantUnmatched []string
+	}{
+		{
+			name:       "selected_with_nested",
+			settings:   "submodule_paths = [\"libs/a\", \"vendor/*\"]\n",
+			wantNested: true,
+			wantCount:  1,
+			// A glob that matches nothing is reported
+			wantUnmatched: []string{"vendor/*"},
+		},
+		{
+			name:      "selected_without_recursion",
+			settings:  "submodule_paths = [\"libs/a\"]\nsubmodule_recursive = false\n",
+			wantCount: 1,
+		},
+		{
+			name:       "selected_with_reference",
+			settings:   "submodule_paths = [\"libs/a\"]\nsubmodule_reference = true\n",
+			wantNested: true,
+			wantCount:  1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repoDir, cfg := setup(t, tt.settings)
+
+			cmd := NewDefaultAddCommand(cfg, NewNopLogger(), AddOptions{})
+			addResult, err := cmd.Run(t.Context(), "feature/selected")
+			if err != nil {
+				t.Fatalf("Run failed: %v", err)
+			}
+
+			wtPath := filepath.Join(repoDir, "feature", "selected")
+			if _, err := os.Stat(filepath.Join(wtPath, "libs", "a", "file.txt")); err != nil {
+				t.Errorf("libs/a should be initialized: %v", err)
+			}
+			if _, err := os.Stat(filepath.Join(wtPath, "libs", "b", "file.txt")); !os.IsNotExist(err) {
+				t.Errorf("libs/b should not be initialized, err = %v", err)
+			}
+			_, err = os.Stat(filepath.Join(wtPath, "libs", "a", "nested", "file.txt"))
+			if gotNested := err == nil; gotNested != tt.wantNested {
+				t.Errorf("nested submodule initialized = %v, want %v", gotNested, tt.wantNested)
+			}
+			if addResult.SubmoduleInit.Count != tt.wantCount {
+				t.Errorf("SubmoduleInit.Count = %d, want %d", addResult.SubmoduleInit.Count, tt.wantCount)
+			}
+			if !reflect.DeepEqual(addResult.SubmoduleInit.UnmatchedPaths, tt.wantUnmatched) {
+				t.Errorf("UnmatchedPaths = %v, want %v", addResult.SubmoduleInit.UnmatchedPaths, tt.wantUnmatched)
+			}
+		})
+	}
+}
+
 // Not parallel: uses t.Setenv for file:// protocol in local submodule URLs.
 func TestAddCommand_SubmoduleReference_Integration(t *testing.T) {
 	// Allow file:// protocol for local submodule URLs in tests
@@ -2260,4 +3548,664 @@ submodule_reference = true
 			t.Errorf("NoReferenceSubmodules[0] = %q, want %q", addResult.SubmoduleInit.NoReferenceSubmodules[0], "mysub")
 		}
 	})
+
+	t.Run("ReferenceDir", func(t *testing.T) {
+		repoDir, mainDir := testutil.SetupTestRepo(t)
+
+		submoduleRepo := filepath.Join(repoDir, "submodule-repo")
+		if err := os.MkdirAll(submoduleRepo, 0755); err != nil {
+			t.Fatal(err)
+		}
+		testutil.RunGit(t, submoduleRepo, "init")
+		testutil.RunGit(t, submoduleRepo, "config", "user.email", "test@example.com")
+		testutil.RunGit(t, submoduleRepo, "config", "user.name", "Test")
+		if err := os.WriteFile(filepath.Join(submoduleRepo, "submodule-file.txt"), []byte("submodule content"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		testutil.RunGit(t, submoduleRepo, "add", ".")
+		testutil.RunGit(t, submoduleRepo, "commit", "-m", "initial")
+
+		testutil.RunGit(t, mainDir, "submodule", "add", submoduleRepo, "mysub")
+		testutil.RunGit(t, mainDir, "commit", "-m", "add submodule")
+
+		// Only the mirror has the submodule
+		if err := os.RemoveAll(filepath.Join(mainDir, ".git", "modules")); err != nil {
+			t.Fatal(err)
+		}
+		mirror := filepath.Join(repoDir, "mirror")
+		testutil.RunGit(t, repoDir, "clone", "--bare", submoduleRepo, filepath.Join(mirror, "mysub"))
+
+		twigDir := filepath.Join(mainDir, ".twig")
+		settings := fmt.Sprintf(`worktree_destination_base_dir = %q
+init_submodules = true
+submodule_reference_dir = "../mirror"
+`, repoDir)
+		if err := os.WriteFile(filepath.Join(twigDir, "settings.toml"), []byte(settings), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := LoadConfig(mainDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		cmd := NewDefaultAddCommand(result.Config, NewNopLogger(), AddOptions{})
+
+		addResult, err := cmd.Run(t.Context(), "feature/mirror")
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		if addResult.SubmoduleInit.Count != 1 || len(addResult.SubmoduleInit.NoReferenceSubmodules) != 0 {
+			t.Errorf("SubmoduleInit = %+v, want 1 submodule with reference", addResult.SubmoduleInit)
+		}
+
+		subPath := filepath.Join(repoDir, "feature", "mirror", "mysub")
+		alternates := strings.TrimSpace(testutil.RunGit(t, subPath, "rev-parse", "--git-path", "objects/info/alternates"))
+		if !filepath.IsAbs(alternates) {
+			alternates = filepath.Join(subPath, alternates)
+		}
+		data, err := os.ReadFile(alternates)
+		if err != nil {
+			t.Fatalf("submodule has no alternates: %v", err)
+		}
+		if !strings.Contains(string(data), filepath.Join(mirror, "mysub")) {
+			t.Errorf("alternates = %q, want the mirror", data)
+		}
+	})
+}
+
+func TestAddCommand_CI_Integration(t *testing.T) {
+	t.Parallel()
+
+	setup := func(t *testing.T) (string, string, *Config) {
+		t.Helper()
+
+		repoDir, mainDir := testutil.SetupTestRepo(t, testutil.Symlinks(".envrc"))
+		for _, f := range []string{"api/main.go", "web/index.html", "README.md", ".envrc"} {
+			path := filepath.Join(mainDir, f)
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, []byte(f), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		testutil.RunGit(t, mainDir, "add", "api", "web", "README.md")
+		testutil.RunGit(t, mainDir, "commit", "-m", "add sources")
+
+		result, err := LoadConfig(mainDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return repoDir, mainDir, result.Config
+	}
+
+	t.Run("SparsePaths", func(t *testing.T) {
+		t.Parallel()
+
+		repoDir, _, cfg := setup(t)
+
+		cmd := NewDefaultAddCommand(cfg, NewNopLogger(), AddOptions{CI: true, SparsePaths: []string{"api"}})
+		result, err := cmd.Run(t.Context(), "build/sparse")
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+
+		wtPath := filepath.Join(repoDir, "build", "sparse")
+		for _, f := range []string{"api/main.go", "README.md"} {
+			if _, err := os.Stat(filepath.Join(wtPath, f)); err != nil {
+				t.Errorf("%s should be checked out: %v", f, err)
+			}
+		}
+		for _, f := range []string{"web/index.html", ".envrc"} {
+			if _, err := os.Lstat(filepath.Join(wtPath, f)); !os.IsNotExist(err) {
+				t.Errorf("%s should not exist in CI worktree (err = %v)", f, err)
+			}
+		}
+		if len(result.Symlinks) != 0 {
+			t.Errorf("Symlinks = %v, want none", result.Symlinks)
+		}
+
+		if out := testutil.RunGit(t, wtPath, "status", "--porcelain"); out != "" {
+			t.Errorf("worktree should be clean, got status:\n%s", out)
+		}
+	})
+
+	t.Run("FullCheckout", func(t *testing.T) {
+		t.Parallel()
+
+		repoDir, _, cfg := setup(t)
+
+		cmd := NewDefaultAddCommand(cfg, NewNopLogger(), AddOptions{CI: true})
+		if _, err := cmd.Run(t.Context(), "build/full"); err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+
+		wtPath := filepath.Join(repoDir, "build", "full")
+		for _, f := range []string{"api/main.go", "web/index.html", "README.md"} {
+			if _, err := os.Stat(filepath.Join(wtPath, f)); err != nil {
+				t.Errorf("%s should be checked out: %v", f, err)
+			}
+		}
+		if out := testutil.RunGit(t, wtPath, "status", "--porcelain"); out != "" {
+			t.Errorf("worktree should be clean, got status:\n%s", out)
+		}
+	})
+
+	t.Run("NoCheckout", func(t *testing.T) {
+		t.Parallel()
+
+		repoDir, _, cfg := setup(t)
+
+		cmd := NewDefaultAddCommand(cfg, NewNopLogger(), AddOptions{NoCheckout: true})
+		result, err := cmd.Run(t.Context(), "feat/empty")
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+
+		wtPath := filepath.Join(repoDir, "feat", "empty")
+		entries, err := os.ReadDir(wtPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, e := range entries {
+			if e.Name() != ".git" {
+				t.Errorf("%s should not exist before checkout", e.Name())
+			}
+		}
+		if len(result.Symlinks) != 0 {
+			t.Errorf("Symlinks = %v, want none", result.Symlinks)
+		}
+		if !result.SetupDeferred {
+			t.Error("SetupDeferred = false, want true with symlinks configured")
+		}
+		formatted := result.Format(AddFormatOptions{})
+		if want := "twig add: feat/empty (no checkout, 0 symlinks)\n"; formatted.Stdout != want {
+			t.Errorf("Stdout = %q, want %q", formatted.Stdout, want)
+		}
+		if !strings.Contains(formatted.Stderr, "run 'twig sync' in "+wtPath) {
+			t.Errorf("Stderr = %q, want twig sync hint", formatted.Stderr)
+		}
+
+		// The follow-up tool populates the files from the branch
+		testutil.RunGit(t, wtPath, "checkout", "HEAD", "--", ".")
+		if _, err := os.Stat(filepath.Join(wtPath, "api", "main.go")); err != nil {
+			t.Errorf("api/main.go should be checked out: %v", err)
+		}
+	})
+}
+
+func TestAddCommand_Upstream_Integration(t *testing.T) {
+	t.Parallel()
+
+	setup := func(t *testing.T) (string, *Config) {
+		t.Helper()
+
+		_, mainDir := testutil.SetupTestRepo(t)
+		originDir := filepath.Join(t.TempDir(), "origin.git")
+		testutil.RunGit(t, t.TempDir(), "init", "--bare", originDir)
+		testutil.RunGit(t, mainDir, "remote", "add", "origin", originDir)
+		testutil.RunGit(t, mainDir, "push", "origin", "main")
+
+		result, err := LoadConfig(mainDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return mainDir, result.Config
+	}
+
+	upstreamOf := func(t *testing.T, dir, branch string) string {
+		t.Helper()
+		return strings.TrimSpace(testutil.RunGit(t, dir, "rev-parse", "--abbrev-ref", branch+"@{upstream}"))
+	}
+
+	t.Run("PushNewBranch", func(t *testing.T) {
+		t.Parallel()
+
+		mainDir, cfg := setup(t)
+
+		cmd := NewDefaultAddCommand(cfg, NewNopLogger(), AddOptions{PushRemote: "origin"})
+		result, err := cmd.Run(t.Context(), "feat/pushed")
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		if !result.Upstream.Pushed || result.Upstream.Skipped {
+			t.Errorf("Upstream = %+v, want pushed", result.Upstream)
+		}
+		if got := upstreamOf(t, mainDir, "feat/pushed"); got != "origin/feat/pushed" {
+			t.Errorf("upstream = %q, want origin/feat/pushed", got)
+		}
+	})
+
+	t.Run("TrackExistingRemoteBranch", func(t *testing.T) {
+		t.Parallel()
+
+		mainDir, cfg := setup(t)
+		// Local branch published without -u, so it has no upstream yet
+		testutil.RunGit(t, mainDir, "branch", "feat/shared")
+		testutil.RunGit(t, mainDir, "push", "origin", "feat/shared")
+
+		cmd := NewDefaultAddCommand(cfg, NewNopLogger(), AddOptions{Track: true})
+		result, err := cmd.Run(t.Context(), "feat/shared")
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		if result.Upstream.Upstream != "origin/feat/shared" || result.Upstream.Skipped {
+			t.Errorf("Upstream = %+v, want origin/feat/shared", result.Upstream)
+		}
+		if got := upstreamOf(t, mainDir, "feat/shared"); got != "origin/feat/shared" {
+			t.Errorf("upstream = %q, want origin/feat/shared", got)
+		}
+	})
+
+	t.Run("TrackWithoutRemoteBranch", func(t *testing.T) {
+		t.Parallel()
+
+		mainDir, cfg := setup(t)
+
+		cmd := NewDefaultAddCommand(cfg, NewNopLogger(), AddOptions{Track: true})
+		_, err := cmd.Run(t.Context(), "feat/unpublished")
+		if err == nil || !strings.Contains(err.Error(), "use --push to create it") {
+			t.Fatalf("error = %v, want no remote branch error", err)
+		}
+		if out := testutil.RunGit(t, mainDir, "branch", "--list", "feat/unpublished"); strings.TrimSpace(out) != "" {
+			t.Errorf("branch should not be created, got %q", out)
+		}
+	})
+}
+
+func TestAddCommand_SymlinkSourceIssues_Integration(t *testing.T) {
+	t.Parallel()
+
+	setup := func(t *testing.T, strict bool) (string, *Config) {
+		t.Helper()
+
+		repoDir, mainDir := testutil.SetupTestRepo(t, testutil.Symlinks(".env", ".envrc"))
+
+		// .env points into a directory shared with other repositories
+		shared := filepath.Join(t.TempDir(), ".env")
+		if err := os.WriteFile(shared, []byte("SECRET=1"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Symlink(shared, filepath.Join(mainDir, ".env")); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(mainDir, ".envrc"), []byte("# envrc"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if strict {
+			if err := os.WriteFile(filepath.Join(mainDir, ".twig", "settings.local.toml"), []byte("strict_symlinks = true\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		result, err := LoadConfig(mainDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return repoDir, result.Config
+	}
+
+	t.Run("WarnsByDefault", func(t *testing.T) {
+		t.Parallel()
+
+		repoDir, cfg := setup(t, false)
+
+		result, err := NewDefaultAddCommand(cfg, NewNopLogger(), AddOptions{}).Run(t.Context(), "feat/warn")
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+
+		if _, err := os.Lstat(filepath.Join(repoDir, "feat", "warn", ".env")); err != nil {
+			t.Errorf(".env symlink should be created: %v", err)
+		}
+		formatted := result.Format(AddFormatOptions{})
+		if !strings.Contains(formatted.Stderr, "warning: symlink source .env resolves outside the source worktree") {
+			t.Errorf("stderr = %q, want outside warning", formatted.Stderr)
+		}
+		if strings.Contains(formatted.Stderr, ".envrc") {
+			t.Errorf("stderr = %q, .envrc should not warn", formatted.Stderr)
+		}
+	})
+
+	t.Run("StrictRefuses", func(t *testing.T) {
+		t.Parallel()
+
+		repoDir, cfg := setup(t, true)
+
+		result, err := NewDefaultAddCommand(cfg, NewNopLogger(), AddOptions{}).Run(t.Context(), "feat/strict")
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+
+		wtPath := filepath.Join(repoDir, "feat", "strict")
+		if _, err := os.Lstat(filepath.Join(wtPath, ".env")); !os.IsNotExist(err) {
+			t.Errorf(".env should not be linked in strict mode (err = %v)", err)
+		}
+		if _, err := os.Lstat(filepath.Join(wtPath, ".envrc")); err != nil {
+			t.Errorf(".envrc should still be linked: %v", err)
+		}
+		formatted := result.Format(AddFormatOptions{})
+		if !strings.Contains(formatted.Stderr, "skipping symlink for .env (source resolves outside the source worktree") {
+			t.Errorf("stderr = %q, want strict skip warning", formatted.Stderr)
+		}
+	})
+}
+
+func TestAddCommand_Restore_Integration(t *testing.T) {
+	t.Parallel()
+
+	_, mainDir := testutil.SetupTestRepo(t)
+
+	result, err := LoadConfig(mainDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := result.Config
+
+	// Create a branch with an unmerged commit, then force-remove it
+	addResult, err := NewDefaultAddCommand(cfg, NewNopLogger(), AddOptions{}).Run(t.Context(), "feat/removed")
+	if err != nil {
+		t.Fatalf("add failed: %v", err)
+	}
+	testutil.RunGit(t, addResult.WorktreePath, "commit", "--allow-empty", "-m", "work in progress")
+	head := strings.TrimSpace(testutil.RunGit(t, addResult.WorktreePath, "rev-parse", "HEAD"))
+
+	removeCmd := NewDefaultRemoveCommand(cfg, NewNopLogger())
+	removed, err := removeCmd.Run(t.Context(), "feat/removed", mainDir, RemoveOptions{Force: WorktreeForceLevelUnclean})
+	if err != nil {
+		t.Fatalf("remove failed: %v", err)
+	}
+	if removed.AuditErr != nil {
+		t.Fatalf("AuditErr = %v", removed.AuditErr)
+	}
+
+	restored, err := NewDefaultAddCommand(cfg, NewNopLogger(), AddOptions{Restore: true}).Run(t.Context(), "feat/removed")
+	if err != nil {
+		t.Fatalf("add --restore failed: %v", err)
+	}
+	if restored.Restored == nil || restored.Restored.HEAD != head {
+		t.Errorf("Restored = %+v, want HEAD %s", restored.Restored, head)
+	}
+	if got := strings.TrimSpace(testutil.RunGit(t, restored.WorktreePath, "rev-parse", "HEAD")); got != head {
+		t.Errorf("restored HEAD = %s, want %s", got, head)
+	}
+}
+
+func TestAddCommand_SourceRef_Integration(t *testing.T) {
+	t.Parallel()
+
+	_, mainDir := testutil.SetupTestRepo(t)
+	originDir := filepath.Join(t.TempDir(), "origin.git")
+	testutil.RunGit(t, t.TempDir(), "init", "--bare", originDir)
+	testutil.RunGit(t, mainDir, "remote", "add", "origin", originDir)
+	testutil.RunGit(t, mainDir, "push", "origin", "main")
+
+	// release exists only as a local branch, and hotfix only on the remote
+	testutil.RunGit(t, mainDir, "branch", "release")
+	testutil.RunGit(t, mainDir, "commit", "--allow-empty", "-m", "main moves on")
+	release := strings.TrimSpace(testutil.RunGit(t, mainDir, "rev-parse", "release"))
+	testutil.RunGit(t, mainDir, "push", "origin", "release:hotfix")
+	testutil.RunGit(t, mainDir, "fetch", "origin")
+
+	result, err := LoadConfig(mainDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	git := NewGitRunner(mainDir)
+
+	tests := []struct {
+		name    string
+		source  string
+		want    AddSource
+		wantErr string
+	}{
+		{name: "checked_out_branch", source: "main", want: AddSource{Dir: mainDir}},
+		{name: "local_branch_without_worktree", source: "release", want: AddSource{Dir: mainDir, StartPoint: "release"}},
+		{name: "remote_tracking_branch", source: "origin/hotfix", want: AddSource{Dir: mainDir, StartPoint: "origin/hotfix"}},
+		{name: "missing", source: "nope", wantErr: `branch "nope" is not checked out in any worktree and is not a local or remote-tracking branch`},
+	}
+	// Subtests share the repository, so worktrees are added one at a time
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src, err := ResolveAddSource(t.Context(), git, tt.source)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if src != tt.want {
+				t.Fatalf("ResolveAddSource() = %+v, want %+v", src, tt.want)
+			}
+			if src.StartPoint == "" {
+				return
+			}
+
+			added, err := NewDefaultAddCommand(result.Config, NewNopLogger(), AddOptions{StartPoint: src.StartPoint}).Run(t.Context(), "feat/from-"+tt.name)
+			if err != nil {
+				t.Fatalf("add failed: %v", err)
+			}
+			if got := strings.TrimSpace(testutil.RunGit(t, added.WorktreePath, "rev-parse", "HEAD")); got != release {
+				t.Errorf("HEAD = %s, want %s from %s", got, release, tt.source)
+			}
+		})
+	}
+}
+
+func TestAddCommand_Detach_Integration(t *testing.T) {
+	t.Parallel()
+
+	_, mainDir := testutil.SetupTestRepo(t)
+	testutil.RunGit(t, mainDir, "tag", "v1.2.3")
+	head := strings.TrimSpace(testutil.RunGit(t, mainDir, "rev-parse", "HEAD"))
+
+	result, err := LoadConfig(mainDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := result.Config
+
+	added, err := NewDefaultAddCommand(cfg, NewNopLogger(), AddOptions{Detach: true}).Run(t.Context(), "v1.2.3")
+	if err != nil {
+		t.Fatalf("add --detach failed: %v", err)
+	}
+	if added.DetachedAt != head {
+		t.Errorf("DetachedAt = %s, want %s", added.DetachedAt, head)
+	}
+	if out := testutil.RunGit(t, mainDir, "branch", "--list", "v1.2.3"); strings.TrimSpace(out) != "" {
+		t.Errorf("branch v1.2.3 was created: %q", out)
+	}
+
+	// Detached worktrees are only cleaned with --detached
+	cleanCmd := NewDefaultCleanCommand(cfg, NewNopLogger())
+	check, err := cleanCmd.Run(t.Context(), mainDir, CleanOptions{Check: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := check.CleanableCount(); n != 0 {
+		t.Errorf("CleanableCount() without --detached = %d, want 0", n)
+	}
+
+	cleaned, err := cleanCmd.Run(t.Context(), mainDir, CleanOptions{Detached: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cleaned.Removed) != 1 || cleaned.Removed[0].Err != nil {
+		t.Fatalf("Removed = %+v, want the detached worktree", cleaned.Removed)
+	}
+	if _, err := os.Stat(added.WorktreePath); !os.IsNotExist(err) {
+		t.Errorf("worktree %s still exists", added.WorktreePath)
+	}
+}
+
+func TestAddCommand_FaultInjection_Integration(t *testing.T) {
+	t.Parallel()
+
+	repoDir, mainDir := testutil.SetupTestRepo(t)
+	testutil.RunGit(t, mainDir, "add", ".twig")
+	testutil.RunGit(t, mainDir, "commit", "-m", "add twig settings")
+
+	tracked := filepath.Join(mainDir, "tracked.txt")
+	if err := os.WriteFile(tracked, []byte("committed\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	testutil.RunGit(t, mainDir, "add", "tracked.txt")
+	testutil.RunGit(t, mainDir, "commit", "-m", "add tracked")
+	if err := os.WriteFile(tracked, []byte("uncommitted\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := LoadConfig(mainDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	faults, err := ParseFaultProfile("git.apply@1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	git := &GitRunner{
+		Executor: faults.WrapGitExecutor(osGitExecutor{}),
+		Dir:      mainDir,
+		Log:      NewNopLogger(),
+	}
+	cmd := NewAddCommand(faults.WrapFS(osFS{}), git, result.Config, nil, AddOptions{Sync: true})
+
+	_, err = cmd.Run(t.Context(), "feature/fault")
+	var fault *FaultError
+	if !errors.As(err, &fault) {
+		t.Fatalf("Run() err = %v, want injected fault", err)
+	}
+
+	// The new worktree is rolled back and the source keeps its changes
+	wtPath := filepath.Join(repoDir, "feature", "fault")
+	if _, err := os.Stat(wtPath); !os.IsNotExist(err) {
+		t.Errorf("worktree %s was not removed after the failed apply", wtPath)
+	}
+	if out := testutil.RunGit(t, mainDir, "worktree", "list", "--porcelain"); strings.Contains(out, wtPath) {
+		t.Errorf("worktree still registered:\n%s", out)
+	}
+	content, err := os.ReadFile(tracked)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "uncommitted\n" {
+		t.Errorf("source content = %q, want uncommitted changes kept", content)
+	}
+}
+
+func TestAddCommand_CarryRecovery_Integration(t *testing.T) {
+	t.Parallel()
+
+	// setup creates a repository whose main worktree has a modified, a
+	// staged new and an untracked file, and returns its git status and
+	// staged diff
+	setup := func(t *testing.T) (repoDir, mainDir, status, staged string) {
+		t.Helper()
+		repoDir, mainDir = testutil.SetupTestRepo(t)
+		testutil.RunGit(t, mainDir, "add", ".twig")
+		testutil.RunGit(t, mainDir, "commit", "-m", "add twig settings")
+		if err := os.WriteFile(filepath.Join(mainDir, "tracked.txt"), []byte("base\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		testutil.RunGit(t, mainDir, "add", "tracked.txt")
+		testutil.RunGit(t, mainDir, "commit", "-m", "add tracked file")
+
+		for name, content := range map[string]string{
+			"tracked.txt":   "modified\n",
+			"staged.txt":    "staged\n",
+			"untracked.txt": "untracked\n",
+		} {
+			if err := os.WriteFile(filepath.Join(mainDir, name), []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		testutil.RunGit(t, mainDir, "add", "staged.txt", "tracked.txt")
+		status = testutil.RunGit(t, mainDir, "status", "--porcelain", "-uall")
+		staged = testutil.RunGit(t, mainDir, "diff", "--cached")
+		return repoDir, mainDir, status, staged
+	}
+
+	run := func(t *testing.T, mainDir, spec string) AddResult {
+		t.Helper()
+		result, err := LoadConfig(mainDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		faults, err := ParseFaultProfile(spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		git := &GitRunner{
+			Executor: faults.WrapGitExecutor(osGitExecutor{}),
+			Dir:      mainDir,
+			Log:      NewNopLogger(),
+		}
+		cmd := NewAddCommand(faults.WrapFS(osFS{}), git, result.Config, nil, AddOptions{CarryFrom: mainDir})
+		addResult, err := cmd.Run(t.Context(), "feature/carry")
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		return addResult
+	}
+
+	t.Run("RestoresSourceWhenRemovalFails", func(t *testing.T) {
+		t.Parallel()
+
+		repoDir, mainDir, status, staged := setup(t)
+
+		// The patch is reversed, then removing the untracked file fails
+		result := run(t, mainDir, "fs.remove@1")
+		if !strings.Contains(result.CarryLeft, "restored there") {
+			t.Errorf("CarryLeft = %q, want source restored", result.CarryLeft)
+		}
+
+		// The source is as before the command, index included
+		if got := testutil.RunGit(t, mainDir, "status", "--porcelain", "-uall"); got != status {
+			t.Errorf("source status = %q, want %q", got, status)
+		}
+		if got := testutil.RunGit(t, mainDir, "diff", "--cached"); got != staged {
+			t.Errorf("source staged diff = %q, want %q", got, staged)
+		}
+
+		wtPath := filepath.Join(repoDir, "feature", "carry")
+		if got, err := os.ReadFile(filepath.Join(wtPath, "tracked.txt")); err != nil || string(got) != "modified\n" {
+			t.Errorf("carried tracked.txt = %q (%v), want %q", got, err, "modified\n")
+		}
+	})
+
+	t.Run("ReportsLocationsWhenRestoreFails", func(t *testing.T) {
+		t.Parallel()
+
+		repoDir, mainDir, _, _ := setup(t)
+
+		// Applying the patch again in the source (the third git apply,
+		// after the new worktree and the reverse) fails as well
+		result := run(t, mainDir, "fs.remove@1,git.apply@3")
+
+		wtPath := filepath.Join(repoDir, "feature", "carry")
+		if !strings.Contains(result.CarryLeft, "the carried changes are in "+wtPath) {
+			t.Errorf("CarryLeft = %q, want new worktree path", result.CarryLeft)
+		}
+		saved, _ := filepath.Glob(filepath.Join(mainDir, ".git", auditDirName, "carry-*.patch"))
+		if len(saved) != 1 {
+			t.Fatalf("saved patches = %v, want one", saved)
+		}
+		if !strings.Contains(result.CarryLeft, "saved at "+saved[0]) {
+			t.Errorf("CarryLeft = %q, want saved patch %s", result.CarryLeft, saved[0])
+		}
+		if !strings.Contains(result.CarryLeft, "git read-tree ") {
+			t.Errorf("CarryLeft = %q, want index tree", result.CarryLeft)
+		}
+
+		// The saved patch restores the tracked changes
+		testutil.RunGit(t, mainDir, "apply", saved[0])
+		if got, err := os.ReadFile(filepath.Join(mainDir, "tracked.txt")); err != nil || string(got) != "modified\n" {
+			t.Errorf("tracked.txt after applying saved patch = %q (%v), want %q", got, err, "modified\n")
+		}
+	})
 }
@@ -0,0 +1,2924 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/708u/twig/internal/testutil"
+)
+
+func TestAddCommand_Run(t *testing.T) {
+	t.Parallel()
+
+	boolPtr := func(b bool) *bool { return &b }
+
+	tests := []struct {
+		name         string
+		branch       string
+		config       *Config
+		sync         bool
+		carryFrom    string
+		filePatterns []string
+		setupFS      func(t *testing.T) *testutil.MockFS
+		setupGit     func(t *testing.T, captured *[]string) *testutil.MockGitExecutor
+		wantErr      bool
+		errContains  string
+		wantBFlag    bool
+		checkPath    string
+		wantSynced   bool
+		wantCarried  bool
+	}{
+		{
+			name:   "new_branch",
+			branch: "feature/test",
+			config: &Config{WorktreeSourceDir: "/repo/main", WorktreeDestBaseDir: "/repo/main-worktree", Symlinks: []string{".envrc"}},
+			setupFS: func(t *testing.T) *testutil.MockFS {
+				t.Helper()
+				return &testutil.MockFS{}
+			},
+			setupGit: func(t *testing.T, captured *[]string) *testutil.MockGitExecutor {
+				t.Helper()
+				return &testutil.MockGitExecutor{CapturedArgs: captured}
+			},
+			wantErr:   false,
+			wantBFlag: true,
+		},
+		{
+			name:   "existing_branch",
+			branch: "existing",
+			config: &Config{WorktreeSourceDir: "/repo/main", WorktreeDestBaseDir: "/repo/main-worktree", Symlinks: []string{".envrc"}},
+			setupFS: func(t *testing.T) *testutil.MockFS {
+				t.Helper()
+				return &testutil.MockFS{}
+			},
+			setupGit: func(t *testing.T, captured *[]string) *testutil.MockGitExecutor {
+				t.Helper()
+				return &testutil.MockGitExecutor{
+					ExistingBranches: []string{"existing"},
+					Worktrees:        []testutil.MockWorktree{{Path: "/repo/main", Branch: "main"}},
+					CapturedArgs:     captured,
+				}
+			},
+			wantErr:   false,
+			wantBFlag: false,
+		},
+		{
+			name:   "directory_exists",
+			branch: "feature/test",
+			config: &Config{WorktreeSourceDir: "/repo/main", WorktreeDestBaseDir: "/repo/main-worktree"},
+			setupFS: func(t *testing.T) *testutil.MockFS {
+				t.Helper()
+				return &testutil.MockFS{
+					ExistingPaths: []string{"/repo/main-worktree/feature/test"},
+				}
+			},
+			setupGit: func(t *testing.T, captured *[]string) *testutil.MockGitExecutor {
+				t.Helper()
+				return &testutil.MockGitExecutor{}
+			},
+			wantErr:     true,
+			errContains: "directory already exists",
+		},
+		{
+			name:   "empty_name",
+			branch: "",
+			config: &Config{WorktreeSourceDir: "/repo/main"},
+			setupFS: func(t *testing.T) *testutil.MockFS {
+				t.Helper()
+				return &testutil.MockFS{}
+			},
+			setupGit: func(t *testing.T, captured *[]string) *testutil.MockGitExecutor {
+				t.Helper()
+				return &testutil.MockGitExecutor{}
+			},
+			wantErr:     true,
+			errContains: "branch name is required",
+		},
+		{
+			name:   "branch_checked_out",
+			branch: "already-used",
+			config: &Config{WorktreeSourceDir: "/repo/main", WorktreeDestBaseDir: "/repo/main-worktree"},
+			setupFS: func(t *testing.T) *testutil.MockFS {
+				t.Helper()
+				return &testutil.MockFS{}
+			},
+			setupGit: func(t *testing.T, captured *[]string) *testutil.MockGitExecutor {
+				t.Helper()
+				return &testutil.MockGitExecutor{
+					Worktrees: []testutil.MockWorktree{{Path: "/repo/already-used", Branch: "already-used"}},
+				}
+			},
+			wantErr:     true,
+			errContains: "already checked out",
+		},
+		{
+			name:   "worktree_add_error",
+			branch: "feature/test",
+			config: &Config{WorktreeSourceDir: "/repo/main", WorktreeDestBaseDir: "/repo/main-worktree"},
+			setupFS: func(t *testing.T) *testutil.MockFS {
+				t.Helper()
+				return &testutil.MockFS{}
+			},
+			setupGit: func(t *testing.T, captured *[]string) *testutil.MockGitExecutor {
+				t.Helper()
+				return &testutil.MockGitExecutor{
+					WorktreeAddErr: errors.New("worktree add failed"),
+				}
+			},
+			wantErr:     true,
+			errContains: "failed to create worktree",
+		},
+		{
+			name:      "slash_in_branch_name",
+			branch:    "feature/foo",
+			config:    &Config{WorktreeSourceDir: "/repo/main", WorktreeDestBaseDir: "/worktrees"},
+			checkPath: "/worktrees/feature/foo",
+			setupFS: func(t *testing.T) *testutil.MockFS {
+				t.Helper()
+				return &testutil.MockFS{}
+			},
+			setupGit: func(t *testing.T, captured *[]string) *testutil.MockGitExecutor {
+				t.Helper()
+				return &testutil.MockGitExecutor{CapturedArgs: captured}
+			},
+			wantErr:   false,
+			wantBFlag: true,
+		},
+		{
+			name:   "sync_with_changes",
+			branch: "feature/sync",
+			config: &Config{WorktreeSourceDir: "/repo/main", WorktreeDestBaseDir: "/repo/main-worktree", Symlinks: []string{".envrc"}},
+			sync:   true,
+			setupFS: func(t *testing.T) *testutil.MockFS {
+				t.Helper()
+				return &testutil.MockFS{}
+			},
+			setupGit: func(t *testing.T, captured *[]string) *testutil.MockGitExecutor {
+				t.Helper()
+				return &testutil.MockGitExecutor{
+					CapturedArgs:    captured,
+					HasChanges:      true,
+					Worktrees:       []testutil.MockWorktree{{Path: "/repo/main", Branch: "main"}},
+					GitCommonDir:    t.TempDir(),
+					DiffPatchOutput: testChangesPatch,
+				}
+			},
+			wantErr:    false,
+			wantBFlag:  true,
+			wantSynced: true,
+		},
+		{
+			name:   "sync_no_changes",
+			branch: "feature/sync-no-changes",
+			config: &Config{WorktreeSourceDir: "/repo/main", WorktreeDestBaseDir: "/repo/main-worktree", Symlinks: []string{".envrc"}},
+			sync:   true,
+			setupFS: func(t *testing.T) *testutil.MockFS {
+				t.Helper()
+				return &testutil.MockFS{}
+			},
+			setupGit: func(t *testing.T, captured *[]string) *testutil.MockGitExecutor {
+				t.Helper()
+				return &testutil.MockGitExecutor{
+					CapturedArgs: captured,
+					HasChanges:   false,
+				}
+			},
+			wantErr:    false,
+			wantBFlag:  true,
+			wantSynced: false,
+		},
+		{
+			name:         "sync_with_file_pattern",
+			branch:       "feature/sync-file",
+			config:       &Config{WorktreeSourceDir: "/repo/main", WorktreeDestBaseDir: "/repo/main-worktree", Symlinks: []string{".envrc"}},
+			sync:         true,
+			filePatterns: []string{"*.go"},
+			setupFS: func(t *testing.T) *testutil.MockFS {
+				t.Helper()
+				return &testutil.MockFS{
+					GlobResults: map[string][]string{
+						"*.go": {"main.go", "util.go"},
+					},
+				}
+			},
+			setupGit: func(t *testing.T, captured *[]string) *testutil.MockGitExecutor {
+				t.Helper()
+				return &testutil.MockGitExecutor{
+					CapturedArgs:    captured,
+					HasChanges:      true,
+					Worktrees:       []testutil.MockWorktree{{Path: "/repo/main", Branch: "main"}},
+					GitCommonDir:    t.TempDir(),
+					DiffPatchOutput: testChangesPatch,
+				}
+			},
+			wantErr:    false,
+			wantBFlag:  true,
+			wantSynced: true,
+		},
+		{
+			name:   "sync_read_changes_error",
+			branch: "feature/sync-read-err",
+			config: &Config{WorktreeSourceDir: "/repo/main", WorktreeDestBaseDir: "/repo/main-worktree"},
+			sync:   true,
+			setupFS: func(t *testing.T) *testutil.MockFS {
+				t.Helper()
+				return &testutil.MockFS{}
+			},
+			setupGit: func(t *testing.T, captured *[]string) *testutil.MockGitExecutor {
+				t.Helper()
+				// No worktree contains the source, so its root is not found
+				return &testutil.MockGitExecutor{
+					HasChanges: true,
+				}
+			},
+			wantErr:     true,
+			errContains: "failed to read changes",
+		},
+		{
+			name:   "sync_apply_error",
+			branch: "feature/sync-apply-err",
+			config: &Config{WorktreeSourceDir: "/repo/main", WorktreeDestBaseDir: "/repo/main-worktree"},
+			sync:   true,
+			setupFS: func(t *testing.T) *testutil.MockFS {
+				t.Helper()
+				return &testutil.MockFS{}
+			},
+			setupGit: func(t *testing.T, captured *[]string) *testutil.MockGitExecutor {
+				t.Helper()
+				return &testutil.MockGitExecutor{
+					HasChanges:      true,
+					Worktrees:       []testutil.MockWorktree{{Path: "/repo/main", Branch: "main"}},
+					GitCommonDir:    t.TempDir(),
+					DiffPatchOutput: testChangesPatch,
+					ApplyErrMap:     map[string]error{"/repo/main-worktree/feature/sync-apply-err": errors.New("patch does not apply")},
+				}
+			},
+			wantErr:     true,
+			errContains: "failed to apply changes",
+		},
+		{
+			name:   "sync_disabled_with_changes",
+			branch: "feature/no-sync",
+			config: &Config{WorktreeSourceDir: "/repo/main", WorktreeDestBaseDir: "/repo/main-worktree", Symlinks: []string{".envrc"}},
+			sync:   false,
+			setupFS: func(t *testing.T) *testutil.MockFS {
+				t.Helper()
+				return &testutil.MockFS{}
+			},
+			setupGit: func(t *testing.T, captured *[]string) *testutil.MockGitExecutor {
+				t.Helper()
+				return &testutil.MockGitExecutor{
+					CapturedArgs: captured,
+					HasChanges:   true,
+				}
+			},
+			wantErr:    false,
+			wantBFlag:  true,
+			wantSynced: false,
+		},
+		{
+			name:      "carry_with_changes",
+			branch:    "feature/carry",
+			config:    &Config{WorktreeSourceDir: "/repo/main", WorktreeDestBaseDir: "/repo/main-worktree", Symlinks: []string{".envrc"}},
+			carryFrom: "/repo/main",
+			setupFS: func(t *testing.T) *testutil.MockFS {
+				t.Helper()
+				return &testutil.MockFS{}
+			},
+			setupGit: func(t *testing.T, captured *[]string) *testutil.MockGitExecutor {
+				t.Helper()
+				return &testutil.MockGitExecutor{
+					CapturedArgs:    captured,
+					HasChanges:      true,
+					Worktrees:       []testutil.MockWorktree{{Path: "/repo/main", Branch: "main"}},
+					GitCommonDir:    t.TempDir(),
+					DiffPatchOutput: testChangesPatch,
+				}
+			},
+			wantErr:     false,
+			wantBFlag:   true,
+			wantCarried: true,
+		},
+		{
+			name:      "carry_no_changes",
+			branch:    "feature/carry-no-changes",
+			config:    &Config{WorktreeSourceDir: "/repo/main", WorktreeDestBaseDir: "/repo/main-worktree", Symlinks: []string{".envrc"}},
+			carryFrom: "/repo/main",
+			setupFS: func(t *testing.T) *testutil.MockFS {
+				t.Helper()
+				return &testutil.MockFS{}
+			},
+			setupGit: func(t *testing.T, captured *[]string) *testutil.MockGitExecutor {
+				t.Helper()
+				return &testutil.MockGitExecutor{
+					CapturedArgs: captured,
+					HasChanges:   false,
+				}
+			},
+			wantErr:     false,
+			wantBFlag:   true,
+			wantCarried: false,
+		},
+		{
+			name:      "carry_apply_error",
+			branch:    "feature/carry-apply-err",
+			config:    &Config{WorktreeSourceDir: "/repo/main", WorktreeDestBaseDir: "/repo/main-worktree"},
+			carryFrom: "/repo/main",
+			setupFS: func(t *testing.T) *testutil.MockFS {
+				t.Helper()
+				return &testutil.MockFS{}
+			},
+			setupGit: func(t *testing.T, captured *[]string) *testutil.MockGitExecutor {
+				t.Helper()
+				return &testutil.MockGitExecutor{
+					HasChanges:      true,
+					Worktrees:       []testutil.MockWorktree{{Path: "/repo/main", Branch: "main"}},
+					GitCommonDir:    t.TempDir(),
+					DiffPatchOutput: testChangesPatch,
+					ApplyErrMap:     map[string]error{"/repo/main-worktree/feature/carry-apply-err": errors.New("patch does not apply")},
+				}
+			},
+			wantErr:     true,
+			errContains: "failed to apply changes",
+		},
+		{
+			name:   "remote_branch_single_remote",
+			branch: "feature/remote-only",
+			config: &Config{WorktreeSourceDir: "/repo/main", WorktreeDestBaseDir: "/repo/main-worktree", Symlinks: []string{".envrc"}},
+			setupFS: func(t *testing.T) *testutil.MockFS {
+				t.Helper()
+				return &testutil.MockFS{}
+			},
+			setupGit: func(t *testing.T, captured *[]string) *testutil.MockGitExecutor {
+				t.Helper()
+				return &testutil.MockGitExecutor{
+					CapturedArgs: captured,
+					Remotes:      []string{"origin"},
+					RemoteBranches: map[string][]string{
+						"origin": {"feature/remote-only"},
+					},
+				}
+			},
+			wantErr:   false,
+			wantBFlag: false, // Should not use -b flag since it fetches from remote
+		},
+		{
+			name:   "remote_branch_multiple_remotes_one_has_it",
+			branch: "feature/on-upstream",
+			config: &Config{WorktreeSourceDir: "/repo/main", WorktreeDestBaseDir: "/repo/main-worktree", Symlinks: []string{".envrc"}},
+			setupFS: func(t *testing.T) *testutil.MockFS {
+				t.Helper()
+				return &testutil.MockFS{}
+			},
+			setupGit: func(t *testing.T, captured *[]string) *testutil.MockGitExecutor {
+				t.Helper()
+				return &testutil.MockGitExecutor{
+					CapturedArgs: captured,
+					Remotes:      []string{"origin", "upstream"},
+					RemoteBranches: map[string][]string{
+						"origin":   {},
+						"upstream": {"feature/on-upstream"},
+					},
+				}
+			},
+			wantErr:   false,
+			wantBFlag: false,
+		},
+		{
+			name:   "remote_branch_ambiguous",
+			branch: "feature/ambiguous",
+			config: &Config{WorktreeSourceDir: "/repo/main", WorktreeDestBaseDir: "/repo/main-worktree"},
+			setupFS: func(t *testing.T) *testutil.MockFS {
+				t.Helper()
+				return &testutil.MockFS{}
+			},
+			setupGit: func(t *testing.T, captured *[]string) *testutil.MockGitExecutor {
+				t.Helper()
+				return &testutil.MockGitExecutor{
+					Remotes: []string{"origin", "upstream"},
+					RemoteBranches: map[string][]string{
+						"origin":   {"feature/ambiguous"},
+						"upstream": {"feature/ambiguous"},
+					},
+				}
+			},
+			wantErr:     true,
+			errContains: "exists on multiple remotes",
+		},
+		{
+			name:   "remote_branch_ambiguous_uses_default_remote",
+			branch: "feature/ambiguous",
+			config: &Config{WorktreeSourceDir: "/repo/main", WorktreeDestBaseDir: "/repo/main-worktree"},
+			setupFS: func(t *testing.T) *testutil.MockFS {
+				t.Helper()
+				return &testutil.MockFS{}
+			},
+			setupGit: func(t *testing.T, captured *[]string) *testutil.MockGitExecutor {
+				t.Helper()
+				return &testutil.MockGitExecutor{
+					CapturedArgs: captured,
+					Remotes:      []string{"origin", "upstream"},
+					RemoteBranches: map[string][]string{
+						"origin":   {"feature/ambiguous"},
+						"upstream": {"feature/ambiguous"},
+					},
+					GitConfig: map[string]string{"checkout.defaultRemote": "upstream"},
+				}
+			},
+			wantBFlag: false,
+			checkPath: "upstream", // Fetched from checkout.defaultRemote
+		},
+		{
+			name:   "guess_remote_disabled_creates_new_branch",
+			branch: "feature/remote-only",
+			config: &Config{WorktreeSourceDir: "/repo/main", WorktreeDestBaseDir: "/repo/main-worktree"},
+			setupFS: func(t *testing.T) *testutil.MockFS {
+				t.Helper()
+				return &testutil.MockFS{}
+			},
+			setupGit: func(t *testing.T, captured *[]string) *testutil.MockGitExecutor {
+				t.Helper()
+				return &testutil.MockGitExecutor{
+					CapturedArgs: captured,
+					Remotes:      []string{"origin"},
+					RemoteBranches: map[string][]string{
+						"origin": {"feature/remote-only"},
+					},
+					GitConfig: map[string]string{"worktree.guessRemote": "false"},
+				}
+			},
+			wantBFlag: true, // worktree.guessRemote=false ignores the remote branch
+		},
+		{
+			name:   "remote_branch_fetch_error",
+			branch: "feature/fetch-fail",
+			config: &Config{WorktreeSourceDir: "/repo/main", WorktreeDestBaseDir: "/repo/main-worktree"},
+			setupFS: func(t *testing.T) *testutil.MockFS {
+				t.Helper()
+				return &testutil.MockFS{}
+			},
+			setupGit: func(t *testing.T, captured *[]string) *testutil.MockGitExecutor {
+				t.Helper()
+				return &testutil.MockGitExecutor{
+					Remotes: []string{"origin"},
+					RemoteBranches: map[string][]string{
+						"origin": {"feature/fetch-fail"},
+					},
+					FetchErr: errors.New("network error"),
+				}
+			},
+			wantErr:     true,
+			errContains: "failed to fetch",
+		},
+		{
+			name:   "no_remote_no_local_creates_new_branch",
+			branch: "feature/brand-new",
+			config: &Config{WorktreeSourceDir: "/repo/main", WorktreeDestBaseDir: "/repo/main-worktree", Symlinks: []string{".envrc"}},
+			setupFS: func(t *testing.T) *testutil.MockFS {
+				t.Helper()
+				return &testutil.MockFS{}
+			},
+			setupGit: func(t *testing.T, captured *[]string) *testutil.MockGitExecutor {
+				t.Helper()
+				return &testutil.MockGitExecutor{
+					CapturedArgs:   captured,
+					Remotes:        []string{"origin"},
+					RemoteBranches: map[string][]string{},
+				}
+			},
+			wantErr:   false,
+			wantBFlag: true, // Should use -b flag since branch doesn't exist anywhere
+		},
+		{
+			name:   "lookup_remote_branches_fetches_from_remote_that_has_it",
+			branch: "feature/not-fetched",
+			config: &Config{WorktreeSourceDir: "/repo/main", WorktreeDestBaseDir: "/repo/main-worktree", LookupRemoteBranches: boolPtr(true)},
+			setupFS: func(t *testing.T) *testutil.MockFS {
+				t.Helper()
+				return &testutil.MockFS{}
+			},
+			setupGit: func(t *testing.T, captured *[]string) *testutil.MockGitExecutor {
+				t.Helper()
+				return &testutil.MockGitExecutor{
+					CapturedArgs: captured,
+					Remotes:      []string{"origin", "fork", "upstream"},
+					LsRemoteBranches: map[string][]string{
+						"upstream": {"feature/not-fetched"},
+					},
+					LsRemoteErrs: map[string]error{"fork": errors.New("could not read from remote")},
+				}
+			},
+			wantBFlag: false,
+			checkPath: "upstream", // Fetched from the remote that has it only
+		},
+		{
+			name:   "lookup_remote_branches_ambiguous",
+			branch: "feature/not-fetched",
+			config: &Config{WorktreeSourceDir: "/repo/main", WorktreeDestBaseDir: "/repo/main-worktree", LookupRemoteBranches: boolPtr(true)},
+			setupFS: func(t *testing.T) *testutil.MockFS {
+				t.Helper()
+				return &testutil.MockFS{}
+			},
+			setupGit: func(t *testing.T, captured *[]string) *testutil.MockGitExecutor {
+				t.Helper()
+				return &testutil.MockGitExecutor{
+					Remotes: []string{"origin", "upstream"},
+					LsRemoteBranches: map[string][]string{
+						"origin":   {"feature/not-fetched"},
+						"upstream": {"feature/not-fetched"},
+					},
+				}
+			},
+			wantErr:     true,
+			errContains: `branch "feature/not-fetched" exists on multiple remotes: [origin upstream]`,
+		},
+		{
+			name:   "fetch_on_add_fetches_branch_pushed_after_last_fetch",
+			branch: "feature/pushed",
+			config: &Config{WorktreeSourceDir: "/repo/main", WorktreeDestBaseDir: "/repo/main-worktree", FetchOnAdd: boolPtr(true)},
+			setupFS: func(t *testing.T) *testutil.MockFS {
+				t.Helper()
+				return &testutil.MockFS{}
+			},
+			setupGit: func(t *testing.T, captured *[]string) *testutil.MockGitExecutor {
+				t.Helper()
+				return &testutil.MockGitExecutor{
+					CapturedArgs: captured,
+					Remotes:      []string{"origin", "upstream"},
+					FetchableBranches: map[string][]string{
+						"upstream": {"feature/pushed"},
+					},
+				}
+			},
+			wantBFlag: false,
+			checkPath: "upstream",
+		},
+		{
+			name:   "fetch_on_add_creates_branch_missing_on_remotes",
+			branch: "feature/brand-new",
+			config: &Config{WorktreeSourceDir: "/repo/main", WorktreeDestBaseDir: "/repo/main-worktree", FetchOnAdd: boolPtr(true)},
+			setupFS: func(t *testing.T) *testutil.MockFS {
+				t.Helper()
+				return &testutil.MockFS{}
+			},
+			setupGit: func(t *testing.T, captured *[]string) *testutil.MockGitExecutor {
+				t.Helper()
+				return &testutil.MockGitExecutor{
+					CapturedArgs:      captured,
+					Remotes:           []string{"origin"},
+					FetchableBranches: map[string][]string{},
+				}
+			},
+			wantBFlag: true,
+		},
+		{
+			name:   "fetch_on_add_ambiguous",
+			branch: "feature/pushed",
+			config: &Config{WorktreeSourceDir: "/repo/main", WorktreeDestBaseDir: "/repo/main-worktree", FetchOnAdd: boolPtr(true)},
+			setupFS: func(t *testing.T) *testutil.MockFS {
+				t.Helper()
+				return &testutil.MockFS{}
+			},
+			setupGit: func(t *testing.T, captured *[]string) *testutil.MockGitExecutor {
+				t.Helper()
+				return &testutil.MockGitExecutor{
+					Remotes: []string{"origin", "upstream"},
+					FetchableBranches: map[string][]string{
+						"origin":   {"feature/pushed"},
+						"upstream": {"feature/pushed"},
+					},
+				}
+			},
+			wantErr:     true,
+			errContains: `branch "feature/pushed" exists on multiple remotes: [origin upstream]`,
+		},
+		{
+			name:   "lookup_remote_branches_disabled_creates_new_branch",
+			branch: "feature/not-fetched",
+			config: &Config{WorktreeSourceDir: "/repo/main", WorktreeDestBaseDir: "/repo/main-worktree"},
+			setupFS: func(t *testing.T) *testutil.MockFS {
+				t.Helper()
+				return &testutil.MockFS{}
+			},
+			setupGit: func(t *testing.T, captured *[]string) *testutil.MockGitExecutor {
+				t.Helper()
+				return &testutil.MockGitExecutor{
+					CapturedArgs: captured,
+					Remotes:      []string{"origin"},
+					LsRemoteBranches: map[string][]string{
+						"origin": {"feature/not-fetched"},
+					},
+				}
+			},
+			wantBFlag: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var captured []string
+
+			mockFS := tt.setupFS(t)
+			mockGit := tt.setupGit(t, &captured)
+
+			cmd := &AddCommand{
+				FS:           mockFS,
+				Git:          &GitRunner{Executor: mockGit, Dir: "/repo/main", Log: NewNopLogger()},
+				Config:       tt.config,
+				Sync:         tt.sync,
+				CarryFrom:    tt.carryFrom,
+				FilePatterns: tt.filePatterns,
+				Log:          NewNopLogger(),
+			}
+
+			result, err := cmd.Run(t.Context(), tt.branch)
+
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				if tt.errContains != "" && !strings.Contains(err.Error(), tt.errContains) {
+					t.Errorf("error %q should contain %q", err.Error(), tt.errContains)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if tt.wantBFlag && !slices.Contains(captured, "-b") {
+				t.Errorf("expected -b flag, got: %v", captured)
+			}
+
+			if !tt.wantBFlag && len(captured) > 0 && slices.Contains(captured, "-b") {
+				t.Errorf("unexpected -b flag, got: %v", captured)
+			}
+
+			if tt.checkPath != "" && !slices.Contains(captured, tt.checkPath) {
+				t.Errorf("expected path %q in args, got: %v", tt.checkPath, captured)
+			}
+
+			if result.ChangesSynced != tt.wantSynced {
+				t.Errorf("ChangesSynced = %v, want %v", result.ChangesSynced, tt.wantSynced)
+			}
+
+			if result.ChangesCarried != tt.wantCarried {
+				t.Errorf("ChangesCarried = %v, want %v", result.ChangesCarried, tt.wantCarried)
+			}
+		})
+	}
+}
+
+func TestAddCommand_Run_BranchPrefix(t *testing.T) {
+	t.Parallel()
+
+	config := &Config{
+		WorktreeSourceDir:   "/repo/main",
+		WorktreeDestBaseDir: "/repo/main-worktree",
+		BranchPrefix:        "users/me/",
+		BranchAliases:       map[string]string{"login": "team/fix-login-redirect"},
+	}
+
+	tests := []struct {
+		name       string
+		input      string
+		noPrefix   bool
+		wantBranch string
+		wantPath   string
+	}{
+		{
+			name:       "prefix_applied",
+			input:      "fix-login",
+			wantBranch: "users/me/fix-login",
+			wantPath:   "/repo/main-worktree/fix-login",
+		},
+		{
+			name:       "alias_resolved",
+			input:      "login",
+			wantBranch: "team/fix-login-redirect",
+			wantPath:   "/repo/main-worktree/login",
+		},
+		{
+			name:       "no_prefix_uses_name_verbatim",
+			input:      "login",
+			noPrefix:   true,
+			wantBranch: "login",
+			wantPath:   "/repo/main-worktree/login",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var captured []string
+			cmd := &AddCommand{
+				FS:       &testutil.MockFS{},
+				Git:      &GitRunner{Executor: &testutil.MockGitExecutor{CapturedArgs: &captured}, Log: NewNopLogger()},
+				Config:   config,
+				Log:      NewNopLogger(),
+				NoPrefix: tt.noPrefix,
+			}
+
+			result, err := cmd.Run(t.Context(), tt.input)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if result.Branch != tt.wantBranch {
+				t.Errorf("Branch = %q, want %q", result.Branch, tt.wantBranch)
+			}
+			if result.WorktreePath != tt.wantPath {
+				t.Errorf("WorktreePath = %q, want %q", result.WorktreePath, tt.wantPath)
+			}
+			if !slices.Contains(captured, tt.wantBranch) || !slices.Contains(captured, tt.wantPath) {
+				t.Errorf("worktree add args = %v, want branch %q and path %q", captured, tt.wantBranch, tt.wantPath)
+			}
+		})
+	}
+}
+
+// testChangesPatch stands in for the patch of captured changes.
+const testChangesPatch = "diff --git a/main.go b/main.go\n"
+
+func TestAddCommand_Run_ChangeTransfer(t *testing.T) {
+	t.Parallel()
+
+	const wtPath = "/repo/main-worktree/feature/x"
+
+	tests := []struct {
+		name          string
+		sync          bool
+		carryFrom     string
+		applyErrMap   map[string]error
+		removeErr     error // Returned when removing untracked files from the source
+		reapplyErr    error // Returned by git apply without -R or --3way
+		wantErr       string
+		wantApplyArgs []string // Expected git apply args, in order
+		wantCopied    bool     // Untracked file copied to the new worktree
+		wantRemoved   bool     // Untracked file removed from the source
+		wantCarried   bool
+		wantSynced    bool
+		wantCarryLeft string
+	}{
+		{
+			name:          "sync_keeps_source",
+			sync:          true,
+			wantApplyArgs: []string{"apply", "--3way"},
+			wantCopied:    true,
+			wantSynced:    true,
+		},
+		{
+			name:          "carry_removes_changes_from_source",
+			carryFrom:     "/repo/main",
+			wantApplyArgs: []string{"apply", "--3way", "apply", "-R"},
+			wantCopied:    true,
+			wantRemoved:   true,
+			wantCarried:   true,
+		},
+		{
+			name:          "carry_apply_failure_keeps_source",
+			carryFrom:     "/repo/main",
+			applyErrMap:   map[string]error{wtPath: errors.New("patch does not apply")},
+			wantErr:       "failed to apply changes to new worktree: patch does not apply",
+			wantApplyArgs: []string{"apply", "--3way"},
+		},
+		{
+			name:          "carry_source_cleanup_failure_restores_source",
+			carryFrom:     "/repo/main",
+			applyErrMap:   map[string]error{"/repo/main": errors.New("patch does not apply")},
+			wantApplyArgs: []string{"apply", "--3way", "apply", "-R"},
+			wantCopied:    true,
+			wantCarried:   true,
+			wantCarryLeft: "failed to remove carried changes from /repo/main, so they were restored there and are in both worktrees: patch does not apply",
+		},
+		{
+			name:          "carry_untracked_removal_failure_reapplies_patch",
+			carryFrom:     "/repo/main",
+			removeErr:     errors.New("permission denied"),
+			wantApplyArgs: []string{"apply", "--3way", "apply", "-R", "apply", "<patch>"},
+			wantCopied:    true,
+			wantRemoved:   true,
+			wantCarried:   true,
+			wantCarryLeft: "failed to remove carried changes from /repo/main, so they were restored there and are in both worktrees: permission denied",
+		},
+		{
+			name:          "carry_restore_failure_reports_locations",
+			carryFrom:     "/repo/main",
+			removeErr:     errors.New("permission denied"),
+			reapplyErr:    errors.New("patch does not apply"),
+			wantApplyArgs: []string{"apply", "--3way", "apply", "-R"},
+			wantCopied:    true,
+			wantRemoved:   true,
+			wantCarried:   true,
+			wantCarryLeft: "failed to remove carried changes from /repo/main: permission denied; restoring them failed too: failed to apply the changes again: patch does not apply\n" +
+				"  the carried changes are in " + wtPath + "\n" +
+				"  the patch of the tracked changes is saved at <saved>\n" +
+				"  the index of /repo/main before the carry is tree " + testutil.MockIndexTree + " (git read-tree " + testutil.MockIndexTree + ")",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var captured, removed []string
+			mockFS := &testutil.MockFS{
+				ExistingPaths:   []string{"/repo/main/notes.txt"},
+				ReadFileResults: map[string][]byte{"/repo/main/notes.txt": []byte("notes")},
+				WrittenFiles:    map[string][]byte{},
+				RemoveFunc: func(name string) error {
+					removed = append(removed, name)
+					return tt.removeErr
+				},
+			}
+			commonDir := t.TempDir()
+			mockGit := &testutil.MockGitExecutor{
+				CapturedArgs:      &captured,
+				HasChanges:        true,
+				Worktrees:         []testutil.MockWorktree{{Path: "/repo/main", Branch: "main"}},
+				GitCommonDir:      commonDir,
+				DiffPatchOutput:   testChangesPatch,
+				UntrackedFilesMap: map[string][]string{"/repo/main": {"notes.txt"}},
+				ApplyErrMap:       tt.applyErrMap,
+			}
+			var executor GitExecutor = mockGit
+			if tt.reapplyErr != nil {
+				executor = reapplyFailingExecutor{MockGitExecutor: mockGit, err: tt.reapplyErr}
+			}
+
+			cmd := &AddCommand{
+				FS:        mockFS,
+				Git:       &GitRunner{Executor: executor, Dir: "/repo/main", Log: NewNopLogger()},
+				Config:    &Config{WorktreeSourceDir: "/repo/main", WorktreeDestBaseDir: "/repo/main-worktree"},
+				Log:       NewNopLogger(),
+				Sync:      tt.sync,
+				CarryFrom: tt.carryFrom,
+			}
+
+			result, err := cmd.Run(t.Context(), "feature/x")
+
+			var applyArgs []string
+			for i, arg := range captured {
+				if arg == "apply" && i+1 < len(captured) {
+					next := captured[i+1]
+					if !strings.HasPrefix(next, "-") {
+						next = "<patch>"
+					}
+					applyArgs = append(applyArgs, arg, next)
+				}
+			}
+			if !slices.Equal(applyArgs, tt.wantApplyArgs) {
+				t.Errorf("apply args = %v, want %v", applyArgs, tt.wantApplyArgs)
+			}
+
+			if tt.wantErr != "" {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				if !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("error %q should contain %q", err.Error(), tt.wantErr)
+				}
+				if len(removed) != 0 {
+					t.Errorf("removed %v from source, want nothing", removed)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if _, ok := mockFS.WrittenFiles[wtPath+"/notes.txt"]; ok != tt.wantCopied {
+				t.Errorf("untracked file copied = %v, want %v", ok, tt.wantCopied)
+			}
+			if got := slices.Contains(removed, "/repo/main/notes.txt"); got != tt.wantRemoved {
+				t.Errorf("untracked file removed = %v, want %v", got, tt.wantRemoved)
+			}
+			if result.ChangesCarried != tt.wantCarried {
+				t.Errorf("ChangesCarried = %v, want %v", result.ChangesCarried, tt.wantCarried)
+			}
+			if result.ChangesSynced != tt.wantSynced {
+				t.Errorf("ChangesSynced = %v, want %v", result.ChangesSynced, tt.wantSynced)
+			}
+			// The saved patch is named after the time of the carry
+			carryLeft := result.CarryLeft
+			for path, data := range mockFS.WrittenFiles {
+				if strings.HasPrefix(path, filepath.Join(commonDir, "twig", "carry-")) {
+					if string(data) != testChangesPatch {
+						t.Errorf("saved patch = %q, want %q", data, testChangesPatch)
+					}
+					carryLeft = strings.ReplaceAll(carryLeft, path, "<saved>")
+				}
+			}
+			if carryLeft != tt.wantCarryLeft {
+				t.Errorf("CarryLeft = %q, want %q", carryLeft, tt.wantCarryLeft)
+			}
+		})
+	}
+}
+
+// reapplyFailingExecutor fails git apply when it applies the patch forward
+// in the source, so that restoring carried changes fails.
+type reapplyFailingExecutor struct {
+	*testutil.MockGitExecutor
+	err error
+}
+
+func (e reapplyFailingExecutor) Run(ctx context.Context, args ...string) ([]byte, error) {
+	if slices.Contains(args, GitCmdApply) && !slices.Contains(args, "-R") && !slices.Contains(args, "--3way") {
+		return nil, e.err
+	}
+	return e.MockGitExecutor.Run(ctx, args...)
+}
+
+func TestAddCommand_Run_Lock(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		branch         string
+		config         *Config
+		lock           bool
+		lockReason     string
+		setupFS        func(t *testing.T) *testutil.MockFS
+		setupGit       func(t *testing.T, captured *[]string) *testutil.MockGitExecutor
+		wantLockFlag   bool
+		wantReasonFlag bool
+	}{
+		{
+			name:   "lock_flag",
+			branch: "feature/locked",
+			config: &Config{WorktreeSourceDir: "/repo/main", WorktreeDestBaseDir: "/repo/main-worktree", Symlinks: []string{".envrc"}},
+			lock:   true,
+			setupFS: func(t *testing.T) *testutil.MockFS {
+				t.Helper()
+				return &testutil.MockFS{}
+			},
+			setupGit: func(t *testing.T, captured *[]string) *testutil.MockGitExecutor {
+				t.Helper()
+				return &testutil.MockGitExecutor{CapturedArgs: captured}
+			},
+			wantLockFlag:   true,
+			wantReasonFlag: false,
+		},
+		{
+			name:       "lock_with_reason",
+			branch:     "feature/locked-reason",
+			config:     &Config{WorktreeSourceDir: "/repo/main", WorktreeDestBaseDir: "/repo/main-worktree", Symlinks: []string{".envrc"}},
+			lock:       true,
+			lockReason: "USB drive",
+			setupFS: func(t *testing.T) *testutil.MockFS {
+				t.Helper()
+				return &testutil.MockFS{}
+			},
+			setupGit: func(t *testing.T, captured *[]string) *testutil.MockGitExecutor {
+				t.Helper()
+				return &testutil.MockGitExecutor{CapturedArgs: captured}
+			},
+			wantLockFlag:   true,
+			wantReasonFlag: true,
+		},
+		{
+			name:   "no_lock",
+			branch: "feature/unlocked",
+			config: &Config{WorktreeSourceDir: "/repo/main", WorktreeDestBaseDir: "/repo/main-worktree", Symlinks: []string{".envrc"}},
+			lock:   false,
+			setupFS: func(t *testing.T) *testutil.MockFS {
+				t.Helper()
+				return &testutil.MockFS{}
+			},
+			setupGit: func(t *testing.T, captured *[]string) *testutil.MockGitExecutor {
+				t.Helper()
+				return &testutil.MockGitExecutor{CapturedArgs: captured}
+			},
+			wantLockFlag:   false,
+			wantReasonFlag: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var captured []string
+
+			mockFS := tt.setupFS(t)
+			mockGit := tt.setupGit(t, &captured)
+
+			cmd := &AddCommand{
+				FS:         mockFS,
+				Git:        &GitRunner{Executor: mockGit, Log: NewNopLogger()},
+				Config:     tt.config,
+				Lock:       tt.lock,
+				LockReason: tt.lockReason,
+				Log:        NewNopLogger(),
+			}
+
+			_, err := cmd.Run(t.Context(), tt.branch)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			hasLockFlag := slices.Contains(captured, "--lock")
+			if tt.wantLockFlag != hasLockFlag {
+				t.Errorf("--lock flag: got %v, want %v; args: %v", hasLockFlag, tt.wantLockFlag, captured)
+			}
+
+			hasReasonFlag := slices.Contains(captured, "--reason")
+			if tt.wantReasonFlag != hasReasonFlag {
+				t.Errorf("--reason flag: got %v, want %v; args: %v", hasReasonFlag, tt.wantReasonFlag, captured)
+			}
+
+			if tt.wantReasonFlag && tt.lockReason != "" {
+				if !slices.Contains(captured, tt.lockReason) {
+					t.Errorf("expected reason %q in args, got: %v", tt.lockReason, captured)
+				}
+			}
+		})
+	}
+}
+
+func TestAddResult_Format(t *testing.T) {
+	t.Parallel()
+
+	result := AddResult{
+		Branch:       "feature/test",
+		WorktreePath: "/worktrees/feature/test",
+		Symlinks: []SymlinkResult{
+			{Src: "/repo/.envrc", Dst: "/worktrees/feature/test/.envrc"},
+		},
+		ChangesSynced: false,
+	}
+
+	tests := []struct {
+		name       string
+		opts       AddFormatOptions
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			name:       "default_output",
+			opts:       AddFormatOptions{},
+			wantStdout: "twig add: feature/test (1 symlinks)\n",
+			wantStderr: "",
+		},
+		{
+			name:       "quiet",
+			opts:       AddFormatOptions{Quiet: true},
+			wantStdout: "/worktrees/feature/test\n",
+			wantStderr: "",
+		},
+		{
+			name:       "quiet_ignores_verbose",
+			opts:       AddFormatOptions{Verbose: true, Quiet: true},
+			wantStdout: "/worktrees/feature/test\n",
+			wantStderr: "",
+		},
+		{
+			name:       "verbose_output",
+			opts:       AddFormatOptions{Verbose: true},
+			wantStdout: "Created worktree at /worktrees/feature/test\nCreated symlink: /worktrees/feature/test/.envrc -> /repo/.envrc\ntwig add: feature/test (1 symlinks)\n",
+			wantStderr: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := result.Format(tt.opts)
+
+			if got.Stdout != tt.wantStdout {
+				t.Errorf("Stdout = %q, want %q", got.Stdout, tt.wantStdout)
+			}
+			if got.Stderr != tt.wantStderr {
+				t.Errorf("Stderr = %q, want %q", got.Stderr, tt.wantStderr)
+			}
+		})
+	}
+
+	// Test carried output
+	t.Run("default_output_carried", func(t *testing.T) {
+		t.Parallel()
+
+		carriedResult := AddResult{
+			Branch:       "feature/test",
+			WorktreePath: "/worktrees/feature/test",
+			Symlinks: []SymlinkResult{
+				{Src: "/repo/.envrc", Dst: "/worktrees/feature/test/.envrc"},
+			},
+			ChangesCarried: true,
+		}
+
+		got := carriedResult.Format(AddFormatOptions{})
+		want := "twig add: feature/test (1 symlinks, carried)\n"
+
+		if got.Stdout != want {
+			t.Errorf("Stdout = %q, want %q", got.Stdout, want)
+		}
+	})
+
+	t.Run("verbose_output_carried", func(t *testing.T) {
+		t.Parallel()
+
+		carriedResult := AddResult{
+			Branch:       "feature/test",
+			WorktreePath: "/worktrees/feature/test",
+			Symlinks: []SymlinkResult{
+				{Src: "/repo/.envrc", Dst: "/worktrees/feature/test/.envrc"},
+			},
+			ChangesCarried: true,
+		}
+
+		got := carriedResult.Format(AddFormatOptions{Verbose: true})
+		wantContains := "Carried uncommitted changes (source is now clean)"
+
+		if !strings.Contains(got.Stdout, wantContains) {
+			t.Errorf("Stdout = %q, should contain %q", got.Stdout, wantContains)
+		}
+	})
+
+	t.Run("carry_left_warning", func(t *testing.T) {
+		t.Parallel()
+
+		carriedResult := AddResult{
+			Branch:         "feature/test",
+			WorktreePath:   "/worktrees/feature/test",
+			ChangesCarried: true,
+			CarryLeft:      "failed to remove carried changes from /repo/main: patch does not apply",
+		}
+
+		got := carriedResult.Format(AddFormatOptions{})
+		want := "warning: failed to remove carried changes from /repo/main: patch does not apply\n"
+
+		if got.Stderr != want {
+			t.Errorf("Stderr = %q, want %q", got.Stderr, want)
+		}
+		verbose := carriedResult.Format(AddFormatOptions{Verbose: true})
+		if wantStdout := "Carried uncommitted changes (source still has them, see warning)"; !strings.Contains(verbose.Stdout, wantStdout) {
+			t.Errorf("verbose Stdout = %q, should contain %q", verbose.Stdout, wantStdout)
+		}
+	})
+}
+
+func TestAddCommand_Run_InitSubmodules(t *testing.T) {
+	t.Parallel()
+
+	boolPtr := func(b bool) *bool { return &b }
+
+	tests := []struct {
+		name                      string
+		branch                    string
+		config                    *Config
+		initSubmodules            bool // true forces enable, false uses config
+		setupFS                   func(t *testing.T) *testutil.MockFS
+		setupGit                  func(t *testing.T, captured *[]string) *testutil.MockGitExecutor
+		wantSubmodulesInited      bool
+		wantSubmoduleCount        int
+		wantSubmoduleUpdateCalled bool
+		wantSubmoduleInitError    bool
+	}{
+		{
+			name:           "cli_flag_forces_enable",
+			branch:         "feature/submod",
+			config:         &Config{WorktreeSourceDir: "/repo/main", WorktreeDestBaseDir: "/repo/main-worktree"},
+			initSubmodules: true,
+			setupFS: func(t *testing.T) *testutil.MockFS {
+				t.Helper()
+				return &testutil.MockFS{}
+			},
+			setupGit: func(t *testing.T, captured *[]string) *testutil.MockGitExecutor {
+				t.Helper()
+				return &testutil.MockGitExecutor{
+					CapturedArgs:          captured,
+					SubmoduleStatusOutput: " abc123 submodule1 (v1.0.0)\n",
+				}
+			},
+			wantSubmodulesInited:      true,
+			wantSubmoduleCount:        1,
+			wantSubmoduleUpdateCalled: true,
+		},
+		{
+			name:           "cli_flag_enabled_no_submodules",
+			branch:         "feature/no-submod",
+			config:         &Config{WorktreeSourceDir: "/repo/main", WorktreeDestBaseDir: "/repo/main-worktree"},
+			initSubmodules: true,
+			setupFS: func(t *testing.T) *testutil.MockFS {
+				t.Helper()
+				return &testutil.MockFS{}
+			},
+			setupGit: func(t *testing.T, captured *[]string) *testutil.MockGitExecutor {
+				t.Helper()
+				return &testutil.MockGitExecutor{
+					CapturedArgs:          captured,
+					SubmoduleStatusOutput: "", // No submodules
+				}
+			},
+			wantSubmodulesInited:      false,
+			wantSubmoduleCount:        0,
+			wantSubmoduleUpdateCalled: true, // SubmoduleUpdate is always called when enabled
+		},
+		{
+			name:           "config_disabled_no_flag",
+			branch:         "feature/disabled",
+			config:         &Config{WorktreeSourceDir: "/repo/main", WorktreeDestBaseDir: "/repo/main-worktree", InitSubmodules: boolPtr(false)},
+			initSubmodules: false, // Use config
+			setupFS: func(t *testing.T) *testutil.MockFS {
+				t.Helper()
+				return &testutil.MockFS{}
+			},
+			setupGit: func(t *testing.T, captured *[]string) *testutil.MockGitExecutor {
+				t.Helper()
+				return &testutil.MockGitExecutor{
+					CapturedArgs:          captured,
+					SubmoduleStatusOutput: " abc123 submodule1 (v1.0.0)\n",
+				}
+			},
+			wantSubmodulesInited:      false,
+			wantSubmoduleCount:        0,
+			wantSubmoduleUpdateCalled: false,
+		},
+		{
+			name:           "config_enabled_no_flag",
+			branch:         "feature/from-config",
+			config:         &Config{WorktreeSourceDir: "/repo/main", WorktreeDestBaseDir: "/repo/main-worktree", InitSubmodules: boolPtr(true)},
+			initSubmodules: false, // Use config
+			setupFS: func(t *testing.T) *testutil.MockFS {
+				t.Helper()
+				return &testutil.MockFS{}
+			},
+			setupGit: func(t *testing.T, captured *[]string) *testutil.MockGitExecutor {
+				t.Helper()
+				return &testutil.MockGitExecutor{
+					CapturedArgs:          captured,
+					SubmoduleStatusOutput: " abc123 submodule1 (v1.0.0)\n",
+				}
+			},
+			wantSubmodulesInited:      true,
+			wantSubmoduleCount:        1,
+			wantSubmoduleUpdateCalled: true,
+		},
+		{
+			name:           "default_disabled_no_config",
+			branch:         "feature/default",
+			config:         &Config{WorktreeSourceDir: "/repo/main", WorktreeDestBaseDir: "/repo/main-worktree"},
+			initSubmodules: false, // Use config (nil = default false)
+			setupFS: func(t *testing.T) *testutil.MockFS {
+				t.Helper()
+				return &testutil.MockFS{}
+			},
+			setupGit: func(t *testing.T, captured *[]string) *testutil.MockGitExecutor {
+				t.Helper()
+				return &testutil.MockGitExecutor{
+					CapturedArgs:          captured,
+					SubmoduleStatusOutput: " abc123 submodule1 (v1.0.0)\n",
+				}
+			},
+			wantSubmodulesInited:      false,
+			wantSubmoduleCount:        0,
+			wantSubmoduleUpdateCalled: false,
+		},
+		{
+			name:           "init_error_is_warning",
+			branch:         "feature/init-error",
+			config:         &Config{WorktreeSourceDir: "/repo/main", WorktreeDestBaseDir: "/repo/main-worktree"},
+			initSubmodules: true,
+			setupFS: func(t *testing.T) *testutil.MockFS {
+				t.Helper()
+				return &testutil.MockFS{}
+			},
+			setupGit: func(t *testing.T, captured *[]string) *testutil.MockGitExecutor {
+				t.Helper()
+				return &testutil.MockGitExecutor{
+					CapturedArgs:          captured,
+					SubmoduleStatusOutput: " abc123 submodule1 (v1.0.0)\n",
+					SubmoduleUpdateErr:    errors.New("submodule update failed"),
+				}
+			},
+			wantSubmodulesInited:      true,
+			wantSubmoduleCount:        0,
+			wantSubmoduleUpdateCalled: true,
+			wantSubmoduleInitError:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var captured []string
+
+			mockFS := tt.setupFS(t)
+			mockGit := tt.setupGit(t, &captured)
+
+			cmd := &AddCommand{
+				FS:             mockFS,
+				Git:            &GitRunner{Executor: mockGit, Log: NewNopLogger()},
+				Config:         tt.config,
+				InitSubmodules: tt.initSubmodules,
+				Log:            NewNopLogger(),
+			}
+
+			result, err := cmd.Run(t.Context(), tt.branch)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if result.SubmoduleInit.Attempted != tt.wantSubmodulesInited {
+				t.Errorf("SubmoduleInit.Attempted = %v, want %v", result.SubmoduleInit.Attempted, tt.wantSubmodulesInited)
+			}
+
+			if result.SubmoduleInit.Count != tt.wantSubmoduleCount {
+				t.Errorf("SubmoduleInit.Count = %v, want %v", result.SubmoduleInit.Count, tt.wantSubmoduleCount)
+			}
+
+			if mockGit.SubmoduleUpdateCalled != tt.wantSubmoduleUpdateCalled {
+				t.Errorf("SubmoduleUpdateCalled = %v, want %v", mockGit.SubmoduleUpdateCalled, tt.wantSubmoduleUpdateCalled)
+			}
+
+			if tt.wantSubmoduleInitError && !result.SubmoduleInit.Skipped {
+				t.Error("expected SubmoduleInit.Skipped = true, got false")
+			}
+			if !tt.wantSubmoduleInitError && result.SubmoduleInit.Skipped {
+				t.Errorf("unexpected SubmoduleInit.Skipped: %s", result.SubmoduleInit.Reason)
+			}
+		})
+	}
+}
+
+func TestAddResult_Format_Submodules(t *testing.T) {
+	t.Parallel()
+
+	t.Run("default_output_with_submodules", func(t *testing.T) {
+		t.Parallel()
+
+		result := AddResult{
+			Branch:        "feature/test",
+			WorktreePath:  "/worktrees/feature/test",
+			Symlinks:      []SymlinkResult{},
+			SubmoduleInit: SubmoduleInitResult{Attempted: true, Count: 2},
+		}
+
+		got := result.Format(AddFormatOptions{})
+		want := "twig add: feature/test (0 symlinks, 2 submodules)\n"
+
+		if got.Stdout != want {
+			t.Errorf("Stdout = %q, want %q", got.Stdout, want)
+		}
+	})
+
+	t.Run("verbose_output_with_submodules", func(t *testing.T) {
+		t.Parallel()
+
+		result := AddResult{
+			Branch:        "feature/test",
+			WorktreePath:  "/worktrees/feature/test",
+			Symlinks:      []SymlinkResult{},
+			SubmoduleInit: SubmoduleInitResult{Attempted: true, Count: 3},
+		}
+
+		got := result.Format(AddFormatOptions{Verbose: true})
+		wantContains := "Initialized 3 submodule(s)"
+
+		if !strings.Contains(got.Stdout, wantContains) {
+			t.Errorf("Stdout = %q, should contain %q", got.Stdout, wantContains)
+		}
+	})
+
+	t.Run("submodule_init_error_as_warning", func(t *testing.T) {
+		t.Parallel()
+
+		result := AddResult{
+			Branch:        "feature/test",
+			WorktreePath:  "/worktrees/feature/test",
+			Symlinks:      []SymlinkResult{},
+			SubmoduleInit: SubmoduleInitResult{Attempted: true, Skipped: true, Reason: "failed to initialize submodules"},
+		}
+
+		got := result.Format(AddFormatOptions{})
+
+		if !strings.Contains(got.Stderr, "warning:") {
+			t.Errorf("Stderr = %q, should contain 'warning:'", got.Stderr)
+		}
+		if !strings.Contains(got.Stderr, "failed to initialize submodules") {
+			t.Errorf("Stderr = %q, should contain error message", got.Stderr)
+		}
+	})
+
+	t.Run("no_submodule_info_when_count_is_zero", func(t *testing.T) {
+		t.Parallel()
+
+		result := AddResult{
+			Branch:        "feature/test",
+			WorktreePath:  "/worktrees/feature/test",
+			Symlinks:      []SymlinkResult{},
+			SubmoduleInit: SubmoduleInitResult{Attempted: true, Count: 0},
+		}
+
+		got := result.Format(AddFormatOptions{})
+		want := "twig add: feature/test (0 symlinks)\n"
+
+		if got.Stdout != want {
+			t.Errorf("Stdout = %q, want %q", got.Stdout, want)
+		}
+	})
+}
+
+func TestCreateSymlinks(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		targets        []string
+		tracked        trackedPaths
+		setupFS        func(t *testing.T) *testutil.MockFS
+		wantErr        bool
+		errContains    string
+		wantSkipped    int
+		wantCreated    int
+		wantReasonLike string
+	}{
+		{
+			name:    "success",
+			targets: []string{".envrc", ".tool-versions"},
+			setupFS: func(t *testing.T) *testutil.MockFS {
+				t.Helper()
+				return &testutil.MockFS{
+					GlobResults: map[string][]string{
+						".envrc":         {".envrc"},
+						".tool-versions": {".tool-versions"},
+					},
+				}
+			},
+			wantErr:     false,
+			wantCreated: 2,
+		},
+		{
+			name:    "source_not_exist",
+			targets: []string{".envrc"},
+			setupFS: func(t *testing.T) *testutil.MockFS {
+				t.Helper()
+				return &testutil.MockFS{
+					GlobResults: map[string][]string{},
+				}
+			},
+			wantErr:        false,
+			wantSkipped:    1,
+			wantReasonLike: "does not match any files",
+		},
+		{
+			name:    "symlink_error",
+			targets: []string{".envrc"},
+			setupFS: func(t *testing.T) *testutil.MockFS {
+				t.Helper()
+				return &testutil.MockFS{
+					GlobResults: map[string][]string{
+						".envrc": {".envrc"},
+					},
+					SymlinkErr: errors.New("symlink failed"),
+				}
+			},
+			wantErr:     true,
+			errContains: "failed to create symlink",
+		},
+		{
+			name:    "destination_already_exists",
+			targets: []string{".claude"},
+			setupFS: func(t *testing.T) *testutil.MockFS {
+				t.Helper()
+				return &testutil.MockFS{
+					GlobResults: map[string][]string{
+						".claude": {".claude"},
+					},
+					ExistingPaths: []string{"/dst/.claude"},
+					LstatFunc: func(name string) (fs.FileInfo, error) {
+						if name == "/dst/.claude" {
+							return &testutil.MockFileInfo{NameVal: ".claude"}, nil
+						}
+						return nil, fs.ErrNotExist
+					},
+				}
+			},
+			wantErr:        false,
+			wantSkipped:    1,
+			wantReasonLike: "regular file exists",
+		},
+		{
+			name:    "tracked_in_target_branch",
+			targets: []string{".envrc", ".tool-versions"},
+			tracked: trackedPaths{".envrc": {}},
+			setupFS: func(t *testing.T) *testutil.MockFS {
+				t.Helper()
+				return &testutil.MockFS{
+					GlobResults: map[string][]string{
+						".envrc":         {".envrc"},
+						".tool-versions": {".tool-versions"},
+					},
+				}
+			},
+			wantErr:        false,
+			wantSkipped:    1,
+			wantCreated:    1,
+			wantReasonLike: "tracked in target branch",
+		},
+		{
+			name:    "directory_containing_tracked_files",
+			targets: []string{"config"},
+			tracked: trackedPaths{"config": {}, "config/app.yml": {}},
+			setupFS: func(t *testing.T) *testutil.MockFS {
+				t.Helper()
+				return &testutil.MockFS{
+					GlobResults: map[string][]string{
+						"config": {"config"},
+					},
+				}
+			},
+			wantErr:        false,
+			wantSkipped:    1,
+			wantReasonLike: "exclude it from symlinks",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockFS := tt.setupFS(t)
+
+			results, err := createSymlinks(mockFS, "/src", "/dst", tt.targets, tt.tracked, false, SymlinkStyleRelative)
+
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				if tt.errContains != "" && !strings.Contains(err.Error(), tt.errContains) {
+					t.Errorf("error %q should contain %q", err.Error(), tt.errContains)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var skipped, created int
+			for _, r := range results {
+				if r.Skipped {
+					skipped++
+					if tt.wantReasonLike != "" && !strings.Contains(r.Reason, tt.wantReasonLike) {
+						t.Errorf("reason %q should contain %q", r.Reason, tt.wantReasonLike)
+					}
+				} else {
+					created++
+				}
+			}
+
+			if skipped != tt.wantSkipped {
+				t.Errorf("got %d skipped, want %d", skipped, tt.wantSkipped)
+			}
+			if created != tt.wantCreated {
+				t.Errorf("got %d created, want %d", created, tt.wantCreated)
+			}
+		})
+	}
+}
+
+func TestLoadTrackedPaths(t *testing.T) {
+	t.Parallel()
+
+	mockGit := &testutil.MockGitExecutor{
+		TrackedFilesMap: map[string][]string{
+			"/wt": {".envrc", "config/app/settings.yml"},
+		},
+	}
+	git := &GitRunner{Executor: mockGit, Dir: "/repo", Log: NewNopLogger()}
+
+	tracked, err := loadTrackedPaths(t.Context(), git.InDir("/wt"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, p := range []string{".envrc", "config", "config/app", "config/app/settings.yml"} {
+		if !tracked.contains(p) {
+			t.Errorf("contains(%q) = false, want true", p)
+		}
+	}
+	for _, p := range []string{".tool-versions", "config/local.yml", "conf"} {
+		if tracked.contains(p) {
+			t.Errorf("contains(%q) = true, want false", p)
+		}
+	}
+
+	var nilTracked trackedPaths
+	if nilTracked.contains(".envrc") {
+		t.Error("nil trackedPaths should not contain any path")
+	}
+}
+
+func TestCreateSymlinks_RelativePath(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		pattern     string
+		match       string
+		style       string
+		wantRelBase string // filepath.Dir(dst) for computing expected relative path
+		wantAbsSrc  string // expected absolute SymlinkResult.Src
+	}{
+		{
+			name:        "root_level",
+			pattern:     ".envrc",
+			match:       ".envrc",
+			wantRelBase: "/dst",
+			wantAbsSrc:  "/src/.envrc",
+		},
+		{
+			name:        "nested_glob",
+			pattern:     "config/**/*.toml",
+			match:       "config/app.toml",
+			wantRelBase: "/dst/config",
+			wantAbsSrc:  "/src/config/app.toml",
+		},
+		{
+			name:       "absolute_style",
+			pattern:    "config/**/*.toml",
+			match:      "config/app.toml",
+			style:      SymlinkStyleAbsolute,
+			wantAbsSrc: "/src/config/app.toml",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var capturedOldname string
+			mockFS := &testutil.MockFS{
+				GlobResults: map[string][]string{
+					tt.pattern: {tt.match},
+				},
+				SymlinkFunc: func(oldname, newname string) error {
+					capturedOldname = oldname
+					return nil
+				},
+			}
+
+			results, err := createSymlinks(mockFS, "/src", "/dst", []string{tt.pattern}, nil, false, tt.style)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			want := tt.wantAbsSrc
+			if tt.style != SymlinkStyleAbsolute {
+				want, _ = filepath.Rel(tt.wantRelBase, tt.wantAbsSrc)
+			}
+			if capturedOldname != want {
+				t.Errorf("Symlink oldname = %q, want %q", capturedOldname, want)
+			}
+
+			if len(results) != 1 {
+				t.Fatalf("expected 1 result, got %d", len(results))
+			}
+			if results[0].Src != tt.wantAbsSrc {
+				t.Errorf("SymlinkResult.Src = %q, want absolute %q", results[0].Src, tt.wantAbsSrc)
+			}
+		})
+	}
+}
+
+func TestCreateSymlinks_SourceIssues(t *testing.T) {
+	t.Parallel()
+
+	// Links in the source worktree /src (relative targets as in real repos)
+	links := map[string]string{
+		"/src/.envrc":   ".envrc.local",   // single hop inside: fine
+		"/src/.env":     "../shared/.env", // points outside
+		"/src/chain":    "chain-1",        // chain-1 -> chain-2 -> file
+		"/src/chain-1":  "chain-2",
+		"/src/chain-2":  "file",
+		"/src/crossing": "../other/back", // leaves /src and comes back
+		"/other/back":   "../src/file",
+		"/src/vendor":   "/opt/vendor", // symlinked parent directory
+		"/src/loop":     "loop",
+	}
+	newFS := func() *testutil.MockFS {
+		return &testutil.MockFS{
+			SymlinkTargets: links,
+			LstatFunc: func(name string) (fs.FileInfo, error) {
+				if _, ok := links[name]; ok {
+					return &testutil.MockFileInfo{ModeVal: fs.ModeSymlink}, nil
+				}
+				return nil, fs.ErrNotExist
+			},
+			GlobFunc: func(dir, pattern string) ([]string, error) {
+				return []string{pattern}, nil
+			},
+		}
+	}
+
+	tests := []struct {
+		match       string
+		wantIssue   string
+		wantWarning string
+		wantSkip    string
+	}{
+		{match: ".envrc"},
+		{match: "file"},
+		{
+			match:       ".env",
+			wantIssue:   "resolves outside the source worktree (/shared/.env)",
+			wantWarning: "symlink source .env resolves outside the source worktree (/shared/.env)",
+			wantSkip:    "skipping symlink for .env (source resolves outside the source worktree (/shared/.env), strict_symlinks is enabled)",
+		},
+		{
+			match:       "chain",
+			wantIssue:   "is a chain of 3 symlinks",
+			wantWarning: "symlink source chain is a chain of 3 symlinks",
+			wantSkip:    "skipping symlink for chain (source is a chain of 3 symlinks, strict_symlinks is enabled)",
+		},
+		{
+			match:       "crossing",
+			wantIssue:   "resolves outside the source worktree (/other/back)",
+			wantWarning: "symlink source crossing resolves outside the source worktree (/other/back)",
+			wantSkip:    "skipping symlink for crossing (source resolves outside the source worktree (/other/back), strict_symlinks is enabled)",
+		},
+		{
+			match:       "vendor/lib.so",
+			wantIssue:   "resolves outside the source worktree (/opt/vendor)",
+			wantWarning: "symlink source vendor/lib.so resolves outside the source worktree (/opt/vendor)",
+			wantSkip:    "skipping symlink for vendor/lib.so (source resolves outside the source worktree (/opt/vendor), strict_symlinks is enabled)",
+		},
+		{
+			match:       "loop",
+			wantIssue:   "has too many levels of symlinks",
+			wantWarning: "symlink source loop has too many levels of symlinks",
+			wantSkip:    "skipping symlink for loop (source has too many levels of symlinks, strict_symlinks is enabled)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.match, func(t *testing.T) {
+			t.Parallel()
+
+			if got := symlinkSourceIssue(newFS(), "/src", tt.match); got != tt.wantIssue {
+				t.Errorf("symlinkSourceIssue() = %q, want %q", got, tt.wantIssue)
+			}
+
+			results, err := createSymlinks(newFS(), "/src", "/dst", []string{tt.match}, nil, false, SymlinkStyleRelative)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(results) != 1 || results[0].Skipped || results[0].Warning != tt.wantWarning {
+				t.Errorf("non-strict results = %+v, want created with warning %q", results, tt.wantWarning)
+			}
+
+			results, err = createSymlinks(newFS(), "/src", "/dst", []string{tt.match}, nil, true, SymlinkStyleRelative)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(results) != 1 {
+				t.Fatalf("strict results = %+v, want 1 result", results)
+			}
+			if tt.wantSkip == "" {
+				if results[0].Skipped {
+					t.Errorf("strict mode skipped safe source: %s", results[0].Reason)
+				}
+				return
+			}
+			if !results[0].Skipped || results[0].Reason != tt.wantSkip {
+				t.Errorf("strict result = %+v, want skipped with %q", results[0], tt.wantSkip)
+			}
+		})
+	}
+}
+
+func TestAddResult_Format_SymlinkWarning(t *testing.T) {
+	t.Parallel()
+
+	result := AddResult{
+		Branch:       "feature/test",
+		WorktreePath: "/worktrees/feature/test",
+		Symlinks: []SymlinkResult{{
+			Src:     "/repo/.env",
+			Dst:     "/worktrees/feature/test/.env",
+			Warning: "symlink source .env resolves outside the source worktree (/shared/.env)",
+		}},
+	}
+
+	got := result.Format(AddFormatOptions{})
+	if want := "twig add: feature/test (1 symlinks)\n"; got.Stdout != want {
+		t.Errorf("Stdout = %q, want %q", got.Stdout, want)
+	}
+	if want := "warning: symlink source .env resolves outside the source worktree (/shared/.env)\n"; got.Stderr != want {
+		t.Errorf("Stderr = %q, want %q", got.Stderr, want)
+	}
+}
+
+func TestAddResult_Format_Hooks(t *testing.T) {
+	t.Parallel()
+
+	t.Run("default_output_with_hooks", func(t *testing.T) {
+		t.Parallel()
+
+		result := AddResult{
+			Branch:       "feature/test",
+			WorktreePath: "/worktrees/feature/test",
+			Symlinks:     []SymlinkResult{},
+			HookResults: []HookResult{
+				{Command: "npm install"},
+				{Command: "direnv allow"},
+			},
+		}
+
+		got := result.Format(AddFormatOptions{})
+		want := "twig add: feature/test (0 symlinks, 2 hooks ran)\n"
+
+		if got.Stdout != want {
+			t.Errorf("Stdout = %q, want %q", got.Stdout, want)
+		}
+	})
+
+	t.Run("hook_failure_warning", func(t *testing.T) {
+		t.Parallel()
+
+		result := AddResult{
+			Branch:       "feature/test",
+			WorktreePath: "/worktrees/feature/test",
+			Symlinks:     []SymlinkResult{},
+			HookResults: []HookResult{
+				{Command: "npm install", Err: errors.New("exit status 1"), Output: []byte("npm ERR!\n")},
+			},
+		}
+
+		got := result.Format(AddFormatOptions{})
+
+		if !strings.Contains(got.Stderr, "warning:") {
+			t.Errorf("Stderr = %q, should contain 'warning:'", got.Stderr)
+		}
+		if !strings.Contains(got.Stderr, "npm install") {
+			t.Errorf("Stderr = %q, should contain hook command", got.Stderr)
+		}
+		if !strings.Contains(got.Stderr, "npm ERR!") {
+			t.Errorf("Stderr = %q, should contain hook output", got.Stderr)
+		}
+	})
+
+	t.Run("verbose_output_with_hooks", func(t *testing.T) {
+		t.Parallel()
+
+		result := AddResult{
+			Branch:       "feature/test",
+			WorktreePath: "/worktrees/feature/test",
+			Symlinks:     []SymlinkResult{},
+			HookResults: []HookResult{
+				{Command: "npm install", Output: []byte("added 100 packages\n")},
+			},
+		}
+
+		got := result.Format(AddFormatOptions{Verbose: true})
+
+		if !strings.Contains(got.Stdout, "Ran hook: npm install") {
+			t.Errorf("Stdout = %q, should contain 'Ran hook: npm install'", got.Stdout)
+		}
+		if !strings.Contains(got.Stdout, "added 100 packages") {
+			t.Errorf("Stdout = %q, should contain hook output", got.Stdout)
+		}
+	})
+
+	t.Run("no_hook_info_when_empty", func(t *testing.T) {
+		t.Parallel()
+
+		result := AddResult{
+			Branch:       "feature/test",
+			WorktreePath: "/worktrees/feature/test",
+			Symlinks:     []SymlinkResult{},
+			HookResults:  []HookResult{},
+		}
+
+		got := result.Format(AddFormatOptions{})
+		want := "twig add: feature/test (0 symlinks)\n"
+
+		if got.Stdout != want {
+			t.Errorf("Stdout = %q, want %q", got.Stdout, want)
+		}
+	})
+
+	t.Run("failed_hooks_not_counted_in_summary", func(t *testing.T) {
+		t.Parallel()
+
+		result := AddResult{
+			Branch:       "feature/test",
+			WorktreePath: "/worktrees/feature/test",
+			Symlinks:     []SymlinkResult{},
+			HookResults: []HookResult{
+				{Command: "npm install"},
+				{Command: "bad-command", Err: errors.New("exit status 1")},
+			},
+		}
+
+		got := result.Format(AddFormatOptions{})
+		want := "twig add: feature/test (0 symlinks, 1 hooks ran)\n"
+
+		if got.Stdout != want {
+			t.Errorf("Stdout = %q, want %q", got.Stdout, want)
+		}
+	})
+}
+
+func TestAddBatchResult_HasErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		result    AddBatchResult
+		want      bool
+		wantCount int
+	}{
+		{
+			name:   "no_results",
+			result: AddBatchResult{},
+		},
+		{
+			name: "success_only",
+			result: AddBatchResult{
+				Added: []AddResult{{Branch: "feature/a"}},
+			},
+		},
+		{
+			name: "mixed",
+			result: AddBatchResult{
+				Added: []AddResult{
+					{Branch: "feature/a"},
+					{Branch: "feature/b", Err: errors.New("failed")},
+				},
+			},
+			want:      true,
+			wantCount: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := tt.result.HasErrors(); got != tt.want {
+				t.Errorf("HasErrors() = %v, want %v", got, tt.want)
+			}
+			if got := tt.result.ErrorCount(); got != tt.wantCount {
+				t.Errorf("ErrorCount() = %d, want %d", got, tt.wantCount)
+			}
+		})
+	}
+}
+
+func TestAddBatchResult_Format(t *testing.T) {
+	t.Parallel()
+
+	result := AddBatchResult{
+		Added: []AddResult{
+			{Branch: "feature/a", WorktreePath: "/wt/feature/a"},
+			{Branch: "feature/b", Err: errors.New("directory already exists: /wt/feature/b")},
+			{
+				Branch:       "feature/c",
+				WorktreePath: "/wt/feature/c",
+				Symlinks:     []SymlinkResult{{Skipped: true, Reason: ".envrc does not match any files, skipping"}},
+			},
+		},
+	}
+
+	got := result.Format(AddFormatOptions{})
+
+	wantStdout := "twig add: feature/a (0 symlinks)\ntwig add: feature/c (0 symlinks)\n"
+	if got.Stdout != wantStdout {
+		t.Errorf("Stdout = %q, want %q", got.Stdout, wantStdout)
+	}
+	wantStderr := "error: feature/b: directory already exists: /wt/feature/b\n" +
+		"warning: .envrc does not match any files, skipping\n"
+	if got.Stderr != wantStderr {
+		t.Errorf("Stderr = %q, want %q", got.Stderr, wantStderr)
+	}
+
+	quiet := result.Format(AddFormatOptions{Quiet: true, Summary: true})
+	if want := "/wt/feature/a\n/wt/feature/c\n"; quiet.Stdout != want {
+		t.Errorf("quiet Stdout = %q, want %q", quiet.Stdout, want)
+	}
+
+	summary := result.Format(AddFormatOptions{Summary: true})
+	wantSummary := wantStdout +
+		"\nSummary: 2 added, 1 failed\n" +
+		"BRANCH     STATUS  PATH\n" +
+		"feature/a  added   /wt/feature/a\n" +
+		"feature/b  failed  -\n" +
+		"feature/c  added   /wt/feature/c\n"
+	if summary.Stdout != wantSummary {
+		t.Errorf("summary Stdout = %q, want %q", summary.Stdout, wantSummary)
+	}
+}
+
+func TestAddResult_Format_Porcelain(t *testing.T) {
+	t.Parallel()
+
+	result := AddBatchResult{
+		Added: []AddResult{
+			{
+				Branch:       "build/1",
+				WorktreePath: "/wt/build/1",
+				HookResults:  []HookResult{{Command: "make deps", Err: errors.New("exit status 2"), Output: []byte("boom\n")}},
+			},
+			{Branch: "build/2", Err: errors.New("directory already exists: /wt/build/2")},
+		},
+	}
+
+	got := result.Format(AddFormatOptions{Porcelain: true, Verbose: true, Summary: true})
+
+	wantStdout := "worktree /wt/build/1\nbranch build/1\n\n"
+	if got.Stdout != wantStdout {
+		t.Errorf("Stdout = %q, want %q", got.Stdout, wantStdout)
+	}
+	wantStderr := "warning: hook \"make deps\" failed: exit status 2\n" +
+		"error: build/2: directory already exists: /wt/build/2\n"
+	if got.Stderr != wantStderr {
+		t.Errorf("Stderr = %q, want %q", got.Stderr, wantStderr)
+	}
+}
+
+func TestAddCommand_Run_SymlinkOverrides(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		noSymlinks    bool
+		extraSymlinks []string
+		want          []string // Created symlink destinations
+	}{
+		{
+			name: "configured",
+			want: []string{"/repo/main-worktree/feat/a/.envrc"},
+		},
+		{
+			name:       "no_symlinks",
+			noSymlinks: true,
+		},
+		{
+			name:          "extra_symlinks",
+			extraSymlinks: []string{".tool-versions", ".envrc"},
+			want:          []string{"/repo/main-worktree/feat/a/.envrc", "/repo/main-worktree/feat/a/.tool-versions"},
+		},
+		{
+			name:          "no_symlinks_with_extra",
+			noSymlinks:    true,
+			extraSymlinks: []string{".tool-versions"},
+			want:          []string{"/repo/main-worktree/feat/a/.tool-versions"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cmd := &AddCommand{
+				FS: &testutil.MockFS{
+					GlobResults: map[string][]string{
+						".envrc":         {".envrc"},
+						".tool-versions": {".tool-versions"},
+					},
+				},
+				Git: &GitRunner{Executor: &testutil.MockGitExecutor{}, Dir: "/repo/main", Log: NewNopLogger()},
+				Config: &Config{
+					WorktreeSourceDir:   "/repo/main",
+					WorktreeDestBaseDir: "/repo/main-worktree",
+					Symlinks:            []string{".envrc"},
+				},
+				Log:           NewNopLogger(),
+				NoSymlinks:    tt.noSymlinks,
+				ExtraSymlinks: tt.extraSymlinks,
+			}
+
+			result, err := cmd.Run(t.Context(), "feat/a")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var got []string
+			for _, s := range result.Symlinks {
+				if !s.Skipped {
+					got = append(got, s.Dst)
+				}
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("symlinks = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddCommand_Run_CI(t *testing.T) {
+	t.Parallel()
+
+	boolPtr := func(b bool) *bool { return &b }
+
+	tests := []struct {
+		name        string
+		sparse      []string
+		wantCommand [][]string // git commands expected in order (after -C <dir>)
+	}{
+		{
+			name: "full_checkout",
+			wantCommand: [][]string{
+				{"worktree", "add", "--no-checkout", "-b", "build/1", "/repo/main-worktree/build/1"},
+				{"read-tree", "-mu", "HEAD"},
+			},
+		},
+		{
+			name:   "sparse_paths",
+			sparse: []string{"api", "proto"},
+			wantCommand: [][]string{
+				{"worktree", "add", "--no-checkout", "-b", "build/1", "/repo/main-worktree/build/1"},
+				{"sparse-checkout", "set", "--cone", "--", "api", "proto"},
+				{"read-tree", "-mu", "HEAD"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			inner := &testutil.MockGitExecutor{}
+			var commands [][]string
+			mockGit := &testutil.MockGitExecutor{
+				RunFunc: func(ctx context.Context, args ...string) ([]byte, error) {
+					cmdArgs := args[2:] // strip -C <dir>
+					switch cmdArgs[0] {
+					case "worktree", "sparse-checkout", "read-tree", "submodule":
+						if cmdArgs[1] != "list" {
+							commands = append(commands, cmdArgs)
+						}
+					}
+					return inner.Run(ctx, args...)
+				},
+			}
+
+			cmd := &AddCommand{
+				FS:  &testutil.MockFS{},
+				Git: &GitRunner{Executor: mockGit, Dir: "/repo/main", Log: NewNopLogger()},
+				Config: &Config{
+					WorktreeSourceDir:   "/repo/main",
+					WorktreeDestBaseDir: "/repo/main-worktree",
+					Symlinks:            []string{".envrc"},
+					InitSubmodules:      boolPtr(true),
+				},
+				Log:         NewNopLogger(),
+				CI:          true,
+				SparsePaths: tt.sparse,
+			}
+
+			result, err := cmd.Run(t.Context(), "build/1")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(result.Symlinks) != 0 {
+				t.Errorf("Symlinks = %v, want none in CI mode", result.Symlinks)
+			}
+			if result.SubmoduleInit.Attempted {
+				t.Error("submodule init attempted in CI mode")
+			}
+
+			if len(commands) != len(tt.wantCommand) {
+				t.Fatalf("commands = %q, want %q", commands, tt.wantCommand)
+			}
+			for i := range commands {
+				if !slices.Equal(commands[i], tt.wantCommand[i]) {
+					t.Errorf("command[%d] = %q, want %q", i, commands[i], tt.wantCommand[i])
+				}
+			}
+		})
+	}
+}
+
+func TestAddCommand_Run_Detach(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		rev         string
+		config      *Config
+		missing     []string
+		wantErr     string
+		wantCommand []string // worktree add command (after -C <dir>)
+		wantCommit  string
+	}{
+		{
+			name:        "tag",
+			rev:         "v1.2.3",
+			wantCommand: []string{"worktree", "add", "--no-checkout", "--detach", "/repo/main-worktree/v1.2.3", "abc1234def"},
+			wantCommit:  "abc1234def",
+		},
+		{
+			name:        "ignores_branch_prefix",
+			rev:         "v1.2.3",
+			config:      &Config{WorktreeSourceDir: "/repo/main", WorktreeDestBaseDir: "/repo/main-worktree", BranchPrefix: "feat/"},
+			wantCommand: []string{"worktree", "add", "--no-checkout", "--detach", "/repo/main-worktree/v1.2.3", "abc1234def"},
+			wantCommit:  "abc1234def",
+		},
+		{
+			name:    "unknown_commit",
+			rev:     "nope",
+			missing: []string{"nope"},
+			wantErr: "nope is not a commit",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			inner := &testutil.MockGitExecutor{
+				BranchHEADs:    map[string]string{"v1.2.3": "abc1234def"},
+				MissingCommits: tt.missing,
+			}
+			var commands [][]string
+			mockGit := &testutil.MockGitExecutor{
+				RunFunc: func(ctx context.Context, args ...string) ([]byte, error) {
+					cmdArgs := args[2:] // strip -C <dir>
+					if (cmdArgs[0] == "worktree" && cmdArgs[1] != "list") || cmdArgs[0] == "branch" {
+						commands = append(commands, cmdArgs)
+					}
+					return inner.Run(ctx, args...)
+				},
+			}
+
+			cfg := tt.config
+			if cfg == nil {
+				cfg = &Config{WorktreeSourceDir: "/repo/main", WorktreeDestBaseDir: "/repo/main-worktree"}
+			}
+			cmd := NewAddCommand(
+				&testutil.MockFS{},
+				&GitRunner{Executor: mockGit, Dir: "/repo/main", Log: NewNopLogger()},
+				cfg,
+				nil,
+				AddOptions{Detach: true},
+			)
+
+			result, err := cmd.Run(t.Context(), tt.rev)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want to contain %q", err, tt.wantErr)
+				}
+				if len(commands) != 0 {
+					t.Errorf("no git changes expected on error, got %q", commands)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(commands) != 1 || !slices.Equal(commands[0], tt.wantCommand) {
+				t.Errorf("commands = %q, want %q", commands, tt.wantCommand)
+			}
+			if result.DetachedAt != tt.wantCommit {
+				t.Errorf("DetachedAt = %q, want %q", result.DetachedAt, tt.wantCommit)
+			}
+		})
+	}
+}
+
+func TestAddResult_Format_Detached(t *testing.T) {
+	t.Parallel()
+
+	result := AddResult{
+		Branch:       "v1.2.3",
+		WorktreePath: "/repo/main-worktree/v1.2.3",
+		DetachedAt:   "abc1234def",
+	}
+
+	got := result.Format(AddFormatOptions{})
+	if want := "twig add: v1.2.3 (detached at abc1234, 0 symlinks)\n"; got.Stdout != want {
+		t.Errorf("Stdout = %q, want %q", got.Stdout, want)
+	}
+
+	got = result.Format(AddFormatOptions{Porcelain: true})
+	if want := "worktree /repo/main-worktree/v1.2.3\nHEAD abc1234def\ndetached\n\n"; got.Stdout != want {
+		t.Errorf("porcelain Stdout = %q, want %q", got.Stdout, want)
+	}
+}
+
+func TestAddCommand_Run_Upstream(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		track          bool
+		upstream       string
+		pushRemote     string
+		remoteBranches map[string][]string
+		pushErr        error
+		wantErr        string
+		wantCommand    []string // upstream git command (after -C <dir>)
+		wantResult     UpstreamResult
+	}{
+		{
+			name:           "track_same_name",
+			track:          true,
+			remoteBranches: map[string][]string{"origin": {"feat/a"}},
+			wantCommand:    []string{"branch", "--set-upstream-to=origin/feat/a", "feat/a"},
+			wantResult:     UpstreamResult{Upstream: "origin/feat/a"},
+		},
+		{
+			name:           "track_explicit_upstream",
+			track:          true,
+			upstream:       "upstream/main",
+			remoteBranches: map[string][]string{"upstream": {"main"}},
+			wantCommand:    []string{"branch", "--set-upstream-to=upstream/main", "feat/a"},
+			wantResult:     UpstreamResult{Upstream: "upstream/main"},
+		},
+		{
+			name:    "track_without_remote_branch",
+			track:   true,
+			wantErr: "no remote branch found for feat/a to track (use --push to create it)",
+		},
+		{
+			name:           "track_missing_explicit_upstream",
+			track:          true,
+			upstream:       "origin/nope",
+			remoteBranches: map[string][]string{"origin": {"main"}},
+			wantErr:        "remote branch origin/nope not found",
+		},
+		{
+			name:        "push",
+			pushRemote:  "origin",
+			wantCommand: []string{"push", "-u", "origin", "feat/a"},
+			wantResult:  UpstreamResult{Upstream: "origin/feat/a", Pushed: true},
+		},
+		{
+			name:        "push_failure_is_warning",
+			pushRemote:  "origin",
+			pushErr:     errors.New("exit status 128"),
+			wantCommand: []string{"push", "-u", "origin", "feat/a"},
+			wantResult: UpstreamResult{
+				Upstream: "origin/feat/a",
+				Skipped:  true,
+				Reason:   "failed to push feat/a to origin: exit status 128 (run git push -u origin feat/a in the worktree)",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			inner := &testutil.MockGitExecutor{RemoteBranches: tt.remoteBranches, PushErr: tt.pushErr}
+			var commands [][]string
+			mockGit := &testutil.MockGitExecutor{
+				RunFunc: func(ctx context.Context, args ...string) ([]byte, error) {
+					cmdArgs := args[2:] // strip -C <dir>
+					switch {
+					case cmdArgs[0] == "push",
+						cmdArgs[0] == "branch" && strings.HasPrefix(cmdArgs[1], "--set-upstream-to="),
+						cmdArgs[0] == "worktree" && cmdArgs[1] == "add":
+						commands = append(commands, cmdArgs)
+					}
+					return inner.Run(ctx, args...)
+				},
+			}
+
+			cmd := NewAddCommand(
+				&testutil.MockFS{},
+				&GitRunner{Executor: mockGit, Dir: "/repo/main", Log: NewNopLogger()},
+				&Config{WorktreeSourceDir: "/repo/main", WorktreeDestBaseDir: "/repo/main-worktree"},
+				nil,
+				AddOptions{Track: tt.track, Upstream: tt.upstream, PushRemote: tt.pushRemote},
+			)
+
+			result, err := cmd.Run(t.Context(), "feat/a")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want to contain %q", err, tt.wantErr)
+				}
+				if len(commands) != 0 {
+					t.Errorf("no git changes expected on error, got %q", commands)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(commands) != 2 || !slices.Equal(commands[1], tt.wantCommand) {
+				t.Errorf("commands = %q, want worktree add then %q", commands, tt.wantCommand)
+			}
+			if result.Upstream != tt.wantResult {
+				t.Errorf("Upstream = %+v, want %+v", result.Upstream, tt.wantResult)
+			}
+		})
+	}
+}
+
+func TestAddResult_Format_Upstream(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		upstream   UpstreamResult
+		verbose    bool
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			name:       "tracking",
+			upstream:   UpstreamResult{Upstream: "origin/feat/a"},
+			wantStdout: "twig add: feat/a (0 symlinks, tracking origin/feat/a)\n",
+		},
+		{
+			name:     "tracking_verbose",
+			upstream: UpstreamResult{Upstream: "origin/feat/a"},
+			verbose:  true,
+			wantStdout: "Created worktree at /wt/feat/a\n" +
+				"Set upstream to origin/feat/a\n" +
+				"twig add: feat/a (0 symlinks, tracking origin/feat/a)\n",
+		},
+		{
+			name:     "pushed_verbose",
+			upstream: UpstreamResult{Upstream: "origin/feat/a", Pushed: true},
+			verbose:  true,
+			wantStdout: "Created worktree at /wt/feat/a\n" +
+				"Pushed branch to origin/feat/a\n" +
+				"twig add: feat/a (0 symlinks, tracking origin/feat/a)\n",
+		},
+		{
+			name:       "push_failed",
+			upstream:   UpstreamResult{Upstream: "origin/feat/a", Skipped: true, Reason: "failed to push feat/a to origin"},
+			wantStdout: "twig add: feat/a (0 symlinks)\n",
+			wantStderr: "warning: failed to push feat/a to origin\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			result := AddResult{Branch: "feat/a", WorktreePath: "/wt/feat/a", Upstream: tt.upstream}
+			got := result.Format(AddFormatOptions{Verbose: tt.verbose})
+			if got.Stdout != tt.wantStdout {
+				t.Errorf("Stdout = %q, want %q", got.Stdout, tt.wantStdout)
+			}
+			if got.Stderr != tt.wantStderr {
+				t.Errorf("Stderr = %q, want %q", got.Stderr, tt.wantStderr)
+			}
+		})
+	}
+}
+
+func TestAddCommand_Run_RemovedBranch(t *testing.T) {
+	t.Parallel()
+
+	const auditPath = "/repo/main/.git/twig/audit.jsonl"
+	auditLog := `{"time":"2026-01-08T10:00:00Z","command":"clean","branch":"feat/a","head":"1111111111"}
+{"time":"2026-01-09T10:00:00Z","command":"remove","branch":"feat/b","head":"3333333333"}
+{"time":"2026-01-10T10:00:00Z","command":"remove","branch":"feat/a","head":"2222222222"}
+`
+
+	tests := []struct {
+		name             string
+		restore          bool
+		existingBranches []string
+		missingCommits   []string
+		wantErr          string
+		wantRemoved      string // HEAD of the hinted removal
+		wantRestored     string // HEAD the branch was restored from
+	}{
+		{
+			name:        "hints_latest_removal",
+			wantRemoved: "2222222222",
+		},
+		{
+			name:           "skips_garbage_collected_commit",
+			missingCommits: []string{"2222222222"},
+			wantRemoved:    "1111111111",
+		},
+		{
+			name:             "existing_branch_has_no_hint",
+			existingBranches: []string{"feat/a"},
+		},
+		{
+			name:         "restore",
+			restore:      true,
+			wantRestored: "2222222222",
+		},
+		{
+			name:           "restore_without_commit",
+			restore:        true,
+			missingCommits: []string{"1111111111", "2222222222"},
+			wantErr:        "no removal of feat/a with an existing commit found in the audit log",
+		},
+		{
+			name:             "restore_existing_branch",
+			restore:          true,
+			existingBranches: []string{"feat/a"},
+			wantErr:          "branch feat/a already exists; --restore only recreates removed branches",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			inner := &testutil.MockGitExecutor{
+				ExistingBranches: tt.existingBranches,
+				MissingCommits:   tt.missingCommits,
+				GitCommonDir:     "/repo/main/.git",
+			}
+			var worktreeAdd []string
+			mockGit := &testutil.MockGitExecutor{
+				RunFunc: func(ctx context.Context, args ...string) ([]byte, error) {
+					if cmdArgs := args[2:]; cmdArgs[0] == "worktree" && cmdArgs[1] == "add" {
+						worktreeAdd = cmdArgs
+					}
+					return inner.Run(ctx, args...)
+				},
+			}
+
+			cmd := NewAddCommand(
+				&testutil.MockFS{WrittenFiles: map[string][]byte{auditPath: []byte(auditLog)}},
+				&GitRunner{Executor: mockGit, Dir: "/repo/main", Log: NewNopLogger()},
+				&Config{WorktreeSourceDir: "/repo/main", WorktreeDestBaseDir: "/repo/main-worktree"},
+				nil,
+				AddOptions{Restore: tt.restore},
+			)
+
+			result, err := cmd.Run(t.Context(), "feat/a")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want to contain %q", err, tt.wantErr)
+				}
+				if worktreeAdd != nil {
+					t.Errorf("no worktree expected on error, got %q", worktreeAdd)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var gotRemoved, gotRestored string
+			if result.Removed != nil {
+				gotRemoved = result.Removed.HEAD
+			}
+			if result.Restored != nil {
+				gotRestored = result.Restored.HEAD
+			}
+			if gotRemoved != tt.wantRemoved || gotRestored != tt.wantRestored {
+				t.Errorf("Removed = %q, Restored = %q, want %q, %q", gotRemoved, gotRestored, tt.wantRemoved, tt.wantRestored)
+			}
+
+			// Only a restored branch is created at the recorded commit
+			if last := worktreeAdd[len(worktreeAdd)-1]; (last == tt.wantRestored) != (tt.wantRestored != "") {
+				t.Errorf("worktree add args = %q, want start point %q", worktreeAdd, tt.wantRestored)
+			}
+		})
+	}
+}
+
+func TestAddResult_Format_RemovedBranch(t *testing.T) {
+	t.Parallel()
+
+	removed := &RemovedBranch{HEAD: "2222222222", Age: 50 * time.Hour}
+
+	tests := []struct {
+		name       string
+		result     AddResult
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			name:       "hint",
+			result:     AddResult{Branch: "feat/a", Removed: removed},
+			wantStdout: "twig add: feat/a (0 symlinks)\n",
+			wantStderr: "hint: feat/a was removed 2 days ago at 2222222; to recreate it from that commit, run:\n" +
+				"  twig remove feat/a && twig add feat/a --restore\n",
+		},
+		{
+			name:       "restored",
+			result:     AddResult{Branch: "feat/a", Restored: removed},
+			wantStdout: "twig add: feat/a (0 symlinks, restored from 2222222)\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := tt.result.Format(AddFormatOptions{})
+			if got.Stdout != tt.wantStdout {
+				t.Errorf("Stdout = %q, want %q", got.Stdout, tt.wantStdout)
+			}
+			if got.Stderr != tt.wantStderr {
+				t.Errorf("Stderr = %q, want %q", got.Stderr, tt.wantStderr)
+			}
+		})
+	}
+}
+
+func TestFormatAgo(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{d: 30 * time.Second, want: "just now"},
+		{d: time.Minute, want: "1 minute ago"},
+		{d: 45 * time.Minute, want: "45 minutes ago"},
+		{d: 5 * time.Hour, want: "5 hours ago"},
+		{d: 47 * time.Hour, want: "47 hours ago"},
+		{d: 72 * time.Hour, want: "3 days ago"},
+	}
+
+	for _, tt := range tests {
+		if got := formatAgo(tt.d); got != tt.want {
+			t.Errorf("formatAgo(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
+
+func TestAddCommand_Run_OnExists(t *testing.T) {
+	t.Parallel()
+
+	const (
+		wtPath = "/repo/main-worktree/feature/test"
+		aside  = "/repo/main-worktree/feature/.test.twig-replace"
+	)
+
+	tests := []struct {
+		name         string
+		onExists     OnExists
+		ci           bool
+		worktrees    []testutil.MockWorktree
+		addErr       error
+		wantErr      string
+		wantRenamed  []string // "old -> new"
+		wantRemoved  bool
+		wantReplaced bool
+	}{
+		{
+			name:     "fail_by_default",
+			onExists: "",
+			wantErr:  "directory already exists: " + wtPath + " (use --on-exists adopt or replace",
+		},
+		{
+			name:         "replace_removes_directory",
+			onExists:     OnExistsReplace,
+			wantRenamed:  []string{wtPath + " -> " + aside},
+			wantRemoved:  true,
+			wantReplaced: true,
+		},
+		{
+			name:      "replace_refuses_registered_worktree",
+			onExists:  OnExistsReplace,
+			worktrees: []testutil.MockWorktree{{Path: "/repo/main", Branch: "main"}, {Path: wtPath, Branch: "feature/test"}},
+			wantErr:   wtPath + " is the worktree of feature/test",
+		},
+		{
+			name:      "replace_checks_branch_first",
+			onExists:  OnExistsReplace,
+			worktrees: []testutil.MockWorktree{{Path: "/repo/main", Branch: "main"}, {Path: "/elsewhere", Branch: "feature/test"}},
+			wantErr:   "branch feature/test is already checked out",
+		},
+		{
+			name:        "replace_restores_directory_on_add_failure",
+			onExists:    OnExistsReplace,
+			addErr:      errors.New("fatal: invalid reference"),
+			wantErr:     "failed to create worktree",
+			wantRenamed: []string{wtPath + " -> " + aside, aside + " -> " + wtPath},
+		},
+		{
+			name:      "adopt_refuses_registered_worktree",
+			onExists:  OnExistsAdopt,
+			worktrees: []testutil.MockWorktree{{Path: "/repo/main", Branch: "main"}, {Path: wtPath, HEAD: "abc1234def", Detached: true}},
+			wantErr:   wtPath + " is the worktree of detached HEAD abc1234",
+		},
+		{
+			name:     "adopt_with_ci",
+			onExists: OnExistsAdopt,
+			ci:       true,
+			wantErr:  "cannot be used with --ci",
+		},
+		{
+			name:     "invalid_action",
+			onExists: "skip",
+			wantErr:  `invalid on-exists action "skip"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var removed, renamed []string
+			mockFS := &testutil.MockFS{
+				ExistingPaths: []string{wtPath},
+				RemoveAllFunc: func(path string) error {
+					removed = append(removed, path)
+					return nil
+				},
+				RenameFunc: func(oldpath, newpath string) error {
+					renamed = append(renamed, oldpath+" -> "+newpath)
+					return nil
+				},
+			}
+			mockGit := &testutil.MockGitExecutor{Worktrees: tt.worktrees, WorktreeAddErr: tt.addErr}
+
+			cmd := NewAddCommand(mockFS, &GitRunner{Executor: mockGit, Log: NewNopLogger()},
+				&Config{WorktreeSourceDir: "/repo/main", WorktreeDestBaseDir: "/repo/main-worktree"}, nil,
+				AddOptions{OnExists: tt.onExists, CI: tt.ci})
+			result, err := cmd.Run(t.Context(), "feature/test")
+
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+				}
+				if len(removed) > 0 {
+					t.Errorf("removed %v after an error", removed)
+				}
+				if !slices.Equal(renamed, tt.wantRenamed) {
+					t.Errorf("renamed = %v, want %v", renamed, tt.wantRenamed)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !slices.Equal(renamed, tt.wantRenamed) {
+				t.Errorf("renamed = %v, want %v", renamed, tt.wantRenamed)
+			}
+			if got := slices.Equal(removed, []string{aside}); got != tt.wantRemoved {
+				t.Errorf("removed = %v, want removal: %v", removed, tt.wantRemoved)
+			}
+			if result.Replaced != tt.wantReplaced {
+				t.Errorf("Replaced = %v, want %v", result.Replaced, tt.wantReplaced)
+			}
+		})
+	}
+}
+
+func TestAddResult_Format_OnExists(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		result     AddResult
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			name:       "adopted",
+			result:     AddResult{Branch: "feat/x", WorktreePath: "/wt/feat/x", Adopted: true},
+			wantStdout: "twig add: feat/x (adopted existing directory, 0 symlinks)\n",
+		},
+		{
+			name:       "adopted_with_changes",
+			result:     AddResult{Branch: "feat/x", WorktreePath: "/wt/feat/x", Adopted: true, AdoptedChanges: true},
+			wantStdout: "twig add: feat/x (adopted existing directory, 0 symlinks)\n",
+			wantStderr: "warning: adopted files in /wt/feat/x differ from feat/x; review them with git status\n",
+		},
+		{
+			name:       "replaced",
+			result:     AddResult{Branch: "feat/x", WorktreePath: "/wt/feat/x", Replaced: true},
+			wantStdout: "twig add: feat/x (replaced existing directory, 0 symlinks)\n",
+		},
+		{
+			name:       "replaced_left",
+			result:     AddResult{Branch: "feat/x", WorktreePath: "/wt/feat/x", Replaced: true, ReplacedLeft: "/wt/feat/.x.twig-replace"},
+			wantStdout: "twig add: feat/x (replaced existing directory, 0 symlinks)\n",
+			wantStderr: "warning: the replaced directory could not be deleted and is at /wt/feat/.x.twig-replace\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := tt.result.Format(AddFormatOptions{})
+			if got.Stdout != tt.wantStdout {
+				t.Errorf("Stdout = %q, want %q", got.Stdout, tt.wantStdout)
+			}
+			if got.Stderr != tt.wantStderr {
+				t.Errorf("Stderr = %q, want %q", got.Stderr, tt.wantStderr)
+			}
+		})
+	}
+}
+
+func TestAddCommand_Run_CaseCollision(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		mode          string
+		insensitive   bool
+		branches      []string
+		worktrees     []testutil.MockWorktree
+		dirs          map[string][]os.DirEntry
+		wantErr       string
+		wantCollision string
+	}{
+		{
+			name:     "branch_error",
+			mode:     CaseCollisionsError,
+			branches: []string{"main", "feat/Login"},
+			wantErr:  "branch feat/login differs only in case from existing branch feat/Login, and both cannot exist on case-insensitive filesystems; run 'twig add feat/Login'",
+		},
+		{
+			name: "worktree_error",
+			mode: CaseCollisionsError,
+			worktrees: []testutil.MockWorktree{
+				{Path: "/repo/main", Branch: "main"},
+				{Path: "/repo/main-worktree/feat/LOGIN", HEAD: "abc1234", Detached: true},
+			},
+			wantErr: "worktree path /repo/main-worktree/feat/login differs only in case from /repo/main-worktree/feat/LOGIN (worktree of detached HEAD abc1234)",
+		},
+		{
+			name: "parent_directory_error",
+			mode: CaseCollisionsError,
+			dirs: map[string][]os.DirEntry{
+				"/repo/main-worktree": {mockDirEntry{name: "Feat", isDir: true}},
+			},
+			wantErr: "differs only in case from existing /repo/main-worktree/Feat",
+		},
+		{
+			name:          "warn",
+			mode:          CaseCollisionsWarn,
+			insensitive:   true,
+			branches:      []string{"feat/Login"},
+			wantCollision: "feat/Login",
+		},
+		{
+			name:          "auto_warns_on_case_sensitive",
+			branches:      []string{"feat/Login"},
+			wantCollision: "feat/Login",
+		},
+		{
+			name:        "auto_fails_on_case_insensitive",
+			insensitive: true,
+			branches:    []string{"feat/Login"},
+			wantErr:     "differs only in case from existing branch feat/Login",
+		},
+		{
+			name: "matching_case",
+			mode: CaseCollisionsError,
+			dirs: map[string][]os.DirEntry{
+				"/repo/main-worktree":      {mockDirEntry{name: "feat", isDir: true}},
+				"/repo/main-worktree/feat": {mockDirEntry{name: "signup", isDir: true}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockFS := &testutil.MockFS{DirContents: tt.dirs}
+			if tt.insensitive {
+				mockFS.ExistingPaths = []string{"/REPO/MAIN"}
+			}
+			mockGit := &testutil.MockGitExecutor{ExistingBranches: tt.branches, Worktrees: tt.worktrees}
+
+			cmd := NewAddCommand(mockFS, &GitRunner{Executor: mockGit, Dir: "/repo/main", Log: NewNopLogger()},
+				&Config{WorktreeSourceDir: "/repo/main", WorktreeDestBaseDir: "/repo/main-worktree", CaseCollisions: tt.mode}, nil,
+				AddOptions{})
+			result, err := cmd.Run(t.Context(), "feat/login")
+
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+				}
+				var collisionErr *CaseCollisionError
+				if !errors.As(err, &collisionErr) {
+					t.Errorf("error %T is not a *CaseCollisionError", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var got string
+			if result.CaseCollision != nil {
+				got = result.CaseCollision.Existing
+			}
+			if got != tt.wantCollision {
+				t.Errorf("CaseCollision = %+v, want existing %q", result.CaseCollision, tt.wantCollision)
+			}
+		})
+	}
+}
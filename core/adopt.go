@@ -0,0 +1,377 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/708u/twig/format"
+)
+
+// AdoptOptions configures the adopt operation.
+type AdoptOptions struct {
+	Move  bool // Move worktrees under worktree_destination_base_dir
+	Check bool // Show what would be done without making changes
+}
+
+// AdoptedWorktree is the result of adopting one worktree.
+type AdoptedWorktree struct {
+	Branch        string // Empty for detached worktrees
+	OldPath       string // Path before the move (empty = not moved)
+	WorktreePath  string
+	Symlinks      []SymlinkResult
+	Repointed     []string // Existing symlinks re-pointed after the move
+	RepointErr    error    // Failure to re-point symlinks (the move itself succeeded)
+	EnvFile       string   // Written .twig.env path (empty = env_file disabled)
+	ProvenanceErr error    // Failure to record the provenance marker
+	Err           error
+}
+
+// name returns the branch, or the directory name of detached worktrees.
+func (w AdoptedWorktree) name() string {
+	if w.Branch != "" {
+		return w.Branch
+	}
+	return filepath.Base(w.WorktreePath)
+}
+
+// AdoptResult aggregates the results of an adopt operation.
+type AdoptResult struct {
+	Worktrees []AdoptedWorktree
+	Check     bool
+	CwdMoved  string // New path of the worktree holding the current directory, if it was moved
+}
+
+// Warnings returns the warnings of adopting the worktree, each prefixed
+// with its name.
+func (w AdoptedWorktree) Warnings() []Warning {
+	if w.Err != nil {
+		return nil
+	}
+	var warnings []Warning
+	if w.RepointErr != nil {
+		warnings = append(warnings, Warning{Code: WarningRepointFailed, Subject: w.WorktreePath, Message: w.RepointErr.Error()})
+	}
+	warnings = append(warnings, symlinkWarnings(w.Symlinks)...)
+	if w.ProvenanceErr != nil {
+		warnings = append(warnings, Warning{Code: WarningProvenanceFailed, Subject: w.WorktreePath, Message: w.ProvenanceErr.Error()})
+	}
+	for i := range warnings {
+		warnings[i].Message = w.name() + ": " + warnings[i].Message
+	}
+	return warnings
+}
+
+// Warnings returns the warnings of all adopted worktrees.
+func (r AdoptResult) Warnings() []Warning {
+	var warnings []Warning
+	for _, wt := range r.Worktrees {
+		warnings = append(warnings, wt.Warnings()...)
+	}
+	return warnings
+}
+
+// HasErrors returns true if any worktree failed to be adopted.
+func (r AdoptResult) HasErrors() bool {
+	return r.ErrorCount() > 0
+}
+
+// ErrorCount returns the number of worktrees that failed to be adopted.
+func (r AdoptResult) ErrorCount() int {
+	n := 0
+	for _, wt := range r.Worktrees {
+		if wt.Err != nil {
+			n++
+		}
+	}
+	return n
+}
+
+// Format formats the AdoptResult for display.
+func (r AdoptResult) Format(opts FormatOptions) FormatResult {
+	var stdout, stderr strings.Builder
+
+	if len(r.Worktrees) == 0 {
+		stdout.WriteString("No worktrees to adopt\n")
+		return FormatResult{Stdout: stdout.String()}
+	}
+
+	verb := "Adopted"
+	if r.Check {
+		verb = "Would adopt"
+	}
+	for _, wt := range r.Worktrees {
+		if wt.Err != nil {
+			fmt.Fprintf(&stderr, "%s %s: %v\n", format.Paint(opts.ColorEnabled, format.ColorError, "error:"), wt.name(), wt.Err)
+			continue
+		}
+		writeWarnings(&stderr, wt.Warnings())
+		var created int
+		for _, s := range wt.Symlinks {
+			if !s.Skipped && s.State != SymlinkCorrect {
+				created++
+			}
+		}
+
+		if opts.Verbose {
+			for _, s := range wt.Symlinks {
+				if !s.Skipped && s.State != SymlinkCorrect {
+					fmt.Fprintf(&stdout, "  symlink: %s -> %s\n", s.Dst, s.Src)
+				}
+			}
+			for _, link := range wt.Repointed {
+				fmt.Fprintf(&stdout, "  updated symlink: %s\n", link)
+			}
+			if wt.EnvFile != "" {
+				fmt.Fprintf(&stdout, "  wrote %s\n", wt.EnvFile)
+			}
+		}
+
+		location := wt.WorktreePath
+		if wt.OldPath != "" {
+			location = wt.OldPath + " -> " + wt.WorktreePath
+		}
+		fmt.Fprintf(&stdout, "%s %s: %s (%d symlinks)\n", verb, wt.name(), location, created)
+	}
+
+	if r.CwdMoved != "" {
+		fmt.Fprintf(&stderr, "hint: the current directory was moved; run:\n  cd %s\n", r.CwdMoved)
+	}
+	return FormatResult{Stdout: stdout.String(), Stderr: stderr.String()}
+}
+
+// AdoptCommand brings worktrees created with plain git worktree add under
+// twig: it optionally moves them under worktree_destination_base_dir and
+// creates the configured symlinks (and .twig.env), so that they match the
+// worktrees twig add creates. twig finds worktrees through git; only a
+// provenance marker is recorded, for clean_only_twig_managed.
+type AdoptCommand struct {
+	FS     FileSystem
+	Git    *GitRunner
+	Config *Config
+	Log    *slog.Logger
+
+	// Provenance holds the twig version and command-line flags recorded
+	// in the provenance marker of adopted worktrees that have none.
+	Provenance Provenance
+}
+
+// NewAdoptCommand creates an AdoptCommand with explicit dependencies.
+func NewAdoptCommand(fs FileSystem, git *GitRunner, cfg *Config, log *slog.Logger) *AdoptCommand {
+	if log == nil {
+		log = NewNopLogger()
+	}
+	return &AdoptCommand{
+		FS:     fs,
+		Git:    git,
+		Config: cfg,
+		Log:    log,
+	}
+}
+
+// NewDefaultAdoptCommand creates an AdoptCommand with production dependencies.
+func NewDefaultAdoptCommand(cfg *Config, log *slog.Logger, gitOpts ...GitRunnerOption) *AdoptCommand {
+	git := newDefaultGitRunner(cfg.WorktreeSourceDir, log, gitOpts)
+	return NewAdoptCommand(git.defaultFS(), git, cfg, log)
+}
+
+// Run adopts the worktrees of branches, or without branches every linked
+// worktree outside worktree_destination_base_dir. The main worktree and
+// the symlink source are never adopted. cwd is only used to tell the
+// caller when the current directory was moved.
+func (c *AdoptCommand) Run(ctx context.Context, branches []string, cwd string, opts AdoptOptions) (AdoptResult, error) {
+	result := AdoptResult{Check: opts.Check}
+	baseDir := c.Config.WorktreeDestBaseDir
+	if baseDir == "" {
+		return result, fmt.Errorf("worktree destination base directory is not configured")
+	}
+
+	targets, err := c.resolveTargets(ctx, branches)
+	if err != nil {
+		return result, err
+	}
+
+	for _, wt := range targets {
+		adopted := c.adopt(ctx, wt, opts)
+		if adopted.Err == nil && adopted.OldPath != "" && !opts.Check && cwd != "" && isWithinDir(adopted.OldPath, cwd) {
+			result.CwdMoved = adopted.WorktreePath
+		}
+		c.Log.DebugContext(ctx, "adopted worktree",
+			LogAttrKeyCategory.String(), LogCategoryAdopt,
+			"branch", wt.Branch,
+			"path", adopted.WorktreePath,
+			"moved", adopted.OldPath != "",
+			"error", adopted.Err)
+		result.Worktrees = append(result.Worktrees, adopted)
+	}
+	return result, nil
+}
+
+// resolveTargets returns the worktrees of branches, or the linked
+// worktrees outside the destination base directory.
+func (c *AdoptCommand) resolveTargets(ctx context.Context, branches []string) ([]Worktree, error) {
+	worktrees, err := c.Git.WorktreeList(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list worktrees: %w", err)
+	}
+
+	adoptable := func(i int, wt Worktree) bool {
+		return i > 0 && !wt.Bare && !wt.Prunable && wt.Path != c.Config.WorktreeSourceDir
+	}
+
+	if len(branches) == 0 {
+		var targets []Worktree
+		for i, wt := range worktrees {
+			if adoptable(i, wt) && !isWithinDir(c.Config.WorktreeDestBaseDir, wt.Path) {
+				targets = append(targets, wt)
+			}
+		}
+		return targets, nil
+	}
+
+	var targets []Worktree
+	for _, branch := range branches {
+		i := -1
+		for j := range worktrees {
+			if worktrees[j].Branch == branch {
+				i = j
+				break
+			}
+		}
+		if i < 0 {
+			return nil, fmt.Errorf("branch %q is not checked out in any worktree", branch)
+		}
+		if !adoptable(i, worktrees[i]) {
+			return nil, fmt.Errorf("cannot adopt %s: it is the main worktree or the symlink source", branch)
+		}
+		targets = append(targets, worktrees[i])
+	}
+	return targets, nil
+}
+
+// adopt moves wt when requested and sets it up like twig add would.
+func (c *AdoptCommand) adopt(ctx context.Context, wt Worktree, opts AdoptOptions) AdoptedWorktree {
+	adopted := AdoptedWorktree{Branch: wt.Branch, WorktreePath: wt.Path}
+
+	var tracked trackedPaths
+	if len(c.Config.Symlinks) > 0 {
+		var err error
+		tracked, err = loadTrackedPaths(ctx, c.Git.InDir(wt.Path))
+		if err != nil {
+			c.Log.DebugContext(ctx, "failed to list tracked files",
+				LogAttrKeyCategory.String(), LogCategoryAdopt,
+				"path", wt.Path,
+				"error", err.Error())
+		}
+	}
+
+	if opts.Move {
+		dest := c.destination(wt)
+		if dest != wt.Path {
+			if err := c.move(ctx, wt, dest, opts.Check); err != nil {
+				adopted.Err = err
+				return adopted
+			}
+			adopted.OldPath = wt.Path
+			adopted.WorktreePath = dest
+			if !opts.Check {
+				adopted.Repointed, adopted.RepointErr = repointSymlinks(c.FS, wt.Path, dest)
+			}
+		}
+	}
+
+	strict := c.Config.ShouldUseStrictSymlinks()
+	if opts.Check {
+		// Symlinks are predicted in the current location, which has the
+		// same files as the destination
+		symlinks, err := NewSyncCommand(c.FS, c.Git, c.Log).predictSymlinks(c.Config.WorktreeSourceDir, wt.Path, c.Config.Symlinks, tracked, strict)
+		if err != nil {
+			adopted.Err = err
+			return adopted
+		}
+		adopted.Symlinks = symlinks
+		return adopted
+	}
+
+	start := time.Now()
+	symlinks, err := createSymlinks(c.FS, c.Config.WorktreeSourceDir, adopted.WorktreePath, c.Config.Symlinks, tracked, strict, c.Config.SymlinkTargetStyle())
+	c.Git.Timings.recordSince(TimingPhaseSymlinks, start)
+	if err != nil {
+		adopted.Err = err
+		return adopted
+	}
+	adopted.Symlinks = symlinks
+
+	if c.Config.ShouldWriteEnvFile() {
+		content := envFileContent(wt.Branch, adopted.WorktreePath, c.Config.WorktreeSourceDir, c.Config.EnvFileVars)
+		envFile, _, err := writeEnvFile(c.FS, adopted.WorktreePath, content, false)
+		if err != nil {
+			adopted.Err = err
+			return adopted
+		}
+		adopted.EnvFile = envFile
+	}
+
+	adopted.ProvenanceErr = c.recordProvenance(ctx, adopted.WorktreePath)
+	return adopted
+}
+
+// recordProvenance writes the provenance marker of the adopted worktree at
+// wtPath. A marker left by twig add is kept, as it tells more.
+func (c *AdoptCommand) recordProvenance(ctx context.Context, wtPath string) error {
+	store := NewProvenanceStore(c.FS, c.Git)
+	markers, err := store.Load(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to record provenance: %w", err)
+	}
+	if _, ok := markers[filepath.Clean(wtPath)]; ok {
+		return nil
+	}
+	p := c.Provenance
+	p.CreatedAt = time.Now()
+	p.Command = ProvenanceCommandAdopt
+	return store.Write(ctx, wtPath, p)
+}
+
+// destination returns the path twig add would have created wt at: the
+// branch name without branch_prefix, or the directory name of detached
+// worktrees.
+func (c *AdoptCommand) destination(wt Worktree) string {
+	name := filepath.Base(wt.Path)
+	if wt.Branch != "" {
+		name = wt.Branch
+		if short, ok := strings.CutPrefix(name, c.Config.BranchPrefix); ok && c.Config.BranchPrefix != "" && short != "" {
+			name = short
+		}
+	}
+	return filepath.Join(c.Config.WorktreeDestBaseDir, name)
+}
+
+// move moves wt to dest with git worktree move. In check mode only the
+// preconditions are verified.
+func (c *AdoptCommand) move(ctx context.Context, wt Worktree, dest string, check bool) error {
+	if wt.Locked {
+		return fmt.Errorf("worktree is locked; unlock it first (git worktree unlock %s)", wt.Path)
+	}
+	_, err := c.FS.Stat(dest)
+	if err == nil {
+		return fmt.Errorf("destination %s already exists", dest)
+	}
+	if !c.FS.IsNotExist(err) {
+		return fmt.Errorf("failed to check destination %s: %w", dest, err)
+	}
+	if check {
+		return nil
+	}
+
+	if err := NewGitLockWaiter(c.FS, c.Git, c.Config.GitLockWaitDuration(), c.Log).WaitFor(ctx, wt.Path); err != nil {
+		return err
+	}
+	if err := c.FS.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", dest, err)
+	}
+	return c.Git.WorktreeMove(ctx, wt.Path, dest)
+}
@@ -0,0 +1,105 @@
+//go:build integration
+
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/708u/twig/internal/testutil"
+)
+
+func TestAdoptCommand_Integration(t *testing.T) {
+	t.Parallel()
+
+	t.Run("MovesAndCreatesSymlinks", func(t *testing.T) {
+		t.Parallel()
+
+		repoDir, mainDir := testutil.SetupTestRepo(t, testutil.Symlinks(".envrc"))
+		if err := os.WriteFile(filepath.Join(mainDir, ".envrc"), []byte("export FOO=1\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		outside, err := filepath.EvalSymlinks(t.TempDir())
+		if err != nil {
+			t.Fatal(err)
+		}
+		oldPath := filepath.Join(outside, "plain")
+		testutil.RunGit(t, mainDir, "worktree", "add", "-b", "feat/plain", oldPath)
+
+		cfgResult, err := LoadConfig(mainDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		cmd := NewAdoptCommand(osFS{}, NewGitRunner(mainDir), cfgResult.Config, nil)
+		result, err := cmd.Run(t.Context(), nil, oldPath, AdoptOptions{Move: true})
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		if result.HasErrors() || len(result.Worktrees) != 1 {
+			t.Fatalf("Worktrees = %+v", result.Worktrees)
+		}
+
+		newPath := filepath.Join(repoDir, "feat", "plain")
+		if got := result.Worktrees[0].WorktreePath; got != newPath {
+			t.Errorf("WorktreePath = %q, want %q", got, newPath)
+		}
+		if result.CwdMoved != newPath {
+			t.Errorf("CwdMoved = %q, want %q", result.CwdMoved, newPath)
+		}
+		if _, err := os.Stat(oldPath); !os.IsNotExist(err) {
+			t.Errorf("old path should be gone, stat err = %v", err)
+		}
+		if content, err := os.ReadFile(filepath.Join(newPath, ".envrc")); err != nil || string(content) != "export FOO=1\n" {
+			t.Errorf(".envrc symlink not created: %q, %v", content, err)
+		}
+		if out := testutil.RunGit(t, mainDir, "worktree", "list"); !strings.Contains(out, newPath) {
+			t.Errorf("git worktree list does not show %s:\n%s", newPath, out)
+		}
+
+		// Adopting again finds nothing outside the base directory
+		result, err = cmd.Run(t.Context(), nil, "", AdoptOptions{Move: true})
+		if err != nil {
+			t.Fatalf("second Run failed: %v", err)
+		}
+		if len(result.Worktrees) != 0 {
+			t.Errorf("second Run adopted %+v, want none", result.Worktrees)
+		}
+	})
+
+	t.Run("InPlace", func(t *testing.T) {
+		t.Parallel()
+
+		_, mainDir := testutil.SetupTestRepo(t, testutil.Symlinks(".envrc"))
+		if err := os.WriteFile(filepath.Join(mainDir, ".envrc"), []byte("export FOO=1\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		outside, err := filepath.EvalSymlinks(t.TempDir())
+		if err != nil {
+			t.Fatal(err)
+		}
+		wtPath := filepath.Join(outside, "plain")
+		testutil.RunGit(t, mainDir, "worktree", "add", "-b", "feat/plain", wtPath)
+
+		cfgResult, err := LoadConfig(mainDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		cmd := NewAdoptCommand(osFS{}, NewGitRunner(mainDir), cfgResult.Config, nil)
+		result, err := cmd.Run(t.Context(), []string{"feat/plain"}, "", AdoptOptions{})
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		if result.HasErrors() || len(result.Worktrees) != 1 || result.Worktrees[0].OldPath != "" {
+			t.Fatalf("Worktrees = %+v", result.Worktrees)
+		}
+		if _, err := os.Readlink(filepath.Join(wtPath, ".envrc")); err != nil {
+			t.Errorf(".envrc symlink not created: %v", err)
+		}
+	})
+}
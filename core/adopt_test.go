@@ -0,0 +1,174 @@
+package core
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/708u/twig/internal/testutil"
+)
+
+func TestAdoptCommand_Run_Check(t *testing.T) {
+	t.Parallel()
+
+	mockGit := &testutil.MockGitExecutor{
+		Worktrees: []testutil.MockWorktree{
+			{Path: "/repo/main", Branch: "main"},
+			{Path: "/repo/worktrees/feat/a", Branch: "feat/a"},
+			{Path: "/elsewhere/b", Branch: "feat/b"},
+			{Path: "/elsewhere/locked", Branch: "feat/locked", Locked: true},
+			{Path: "/elsewhere/c", Branch: "feat/c"},
+			{Path: "/elsewhere/gone", Branch: "feat/gone", Prunable: true},
+		},
+	}
+	mockFS := &testutil.MockFS{ExistingPaths: []string{"/repo/worktrees/c"}}
+	cfg := &Config{WorktreeSourceDir: "/repo/main", WorktreeDestBaseDir: "/repo/worktrees", BranchPrefix: "feat/"}
+
+	tests := []struct {
+		name     string
+		branches []string
+		move     bool
+		want     []AdoptedWorktree
+		wantErr  string
+	}{
+		{
+			name: "all outside base dir",
+			want: []AdoptedWorktree{
+				{Branch: "feat/b", WorktreePath: "/elsewhere/b"},
+				{Branch: "feat/locked", WorktreePath: "/elsewhere/locked"},
+				{Branch: "feat/c", WorktreePath: "/elsewhere/c"},
+			},
+		},
+		{
+			name: "move",
+			move: true,
+			want: []AdoptedWorktree{
+				{Branch: "feat/b", OldPath: "/elsewhere/b", WorktreePath: "/repo/worktrees/b"},
+				{Branch: "feat/locked", WorktreePath: "/elsewhere/locked", Err: errors.New("worktree is locked")},
+				{Branch: "feat/c", WorktreePath: "/elsewhere/c", Err: errors.New("destination /repo/worktrees/c already exists")},
+			},
+		},
+		{
+			name:     "explicit branch inside base dir",
+			branches: []string{"feat/a"},
+			want: []AdoptedWorktree{
+				{Branch: "feat/a", WorktreePath: "/repo/worktrees/feat/a"},
+			},
+		},
+		{
+			name:     "main worktree",
+			branches: []string{"main"},
+			wantErr:  "cannot adopt main",
+		},
+		{
+			name:     "branch without worktree",
+			branches: []string{"feat/none"},
+			wantErr:  `branch "feat/none" is not checked out in any worktree`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cmd := NewAdoptCommand(mockFS, &GitRunner{Executor: mockGit, Dir: "/repo/main", Log: NewNopLogger()}, cfg, nil)
+			result, err := cmd.Run(t.Context(), tt.branches, "", AdoptOptions{Move: tt.move, Check: true})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(result.Worktrees) != len(tt.want) {
+				t.Fatalf("Worktrees = %+v, want %d entries", result.Worktrees, len(tt.want))
+			}
+			for i, w := range tt.want {
+				got := result.Worktrees[i]
+				if got.Branch != w.Branch || got.OldPath != w.OldPath || got.WorktreePath != w.WorktreePath {
+					t.Errorf("Worktrees[%d] = %+v, want %+v", i, got, w)
+				}
+				switch {
+				case w.Err == nil && got.Err != nil:
+					t.Errorf("Worktrees[%d].Err = %v, want nil", i, got.Err)
+				case w.Err != nil && (got.Err == nil || !strings.Contains(got.Err.Error(), w.Err.Error())):
+					t.Errorf("Worktrees[%d].Err = %v, want %q", i, got.Err, w.Err)
+				}
+			}
+		})
+	}
+}
+
+func TestAdoptResult_Format(t *testing.T) {
+	t.Parallel()
+
+	result := AdoptResult{
+		Worktrees: []AdoptedWorktree{
+			{
+				Branch:       "feat/a",
+				OldPath:      "/elsewhere/a",
+				WorktreePath: "/wt/a",
+				Symlinks: []SymlinkResult{
+					{Src: "/repo/main/.envrc", Dst: "/wt/a/.envrc"},
+					{Src: "/repo/main/.tool-versions", Dst: "/wt/a/.tool-versions", State: SymlinkCorrect},
+				},
+				Repointed: []string{"/wt/a/link"},
+			},
+			{Branch: "feat/b", WorktreePath: "/elsewhere/b", Err: errors.New("worktree is locked")},
+		},
+		CwdMoved: "/wt/a",
+	}
+
+	tests := []struct {
+		name       string
+		result     AdoptResult
+		verbose    bool
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			name:       "default",
+			result:     result,
+			wantStdout: "Adopted feat/a: /elsewhere/a -> /wt/a (1 symlinks)\n",
+			wantStderr: "error: feat/b: worktree is locked\nhint: the current directory was moved; run:\n  cd /wt/a\n",
+		},
+		{
+			name:    "verbose",
+			result:  result,
+			verbose: true,
+			wantStdout: "  symlink: /wt/a/.envrc -> /repo/main/.envrc\n" +
+				"  updated symlink: /wt/a/link\n" +
+				"Adopted feat/a: /elsewhere/a -> /wt/a (1 symlinks)\n",
+			wantStderr: "error: feat/b: worktree is locked\nhint: the current directory was moved; run:\n  cd /wt/a\n",
+		},
+		{
+			name: "check",
+			result: AdoptResult{Check: true, Worktrees: []AdoptedWorktree{
+				{Branch: "feat/c", OldPath: "/elsewhere/c", WorktreePath: "/wt/c"},
+			}},
+			wantStdout: "Would adopt feat/c: /elsewhere/c -> /wt/c (0 symlinks)\n",
+		},
+		{
+			name:       "empty",
+			result:     AdoptResult{},
+			wantStdout: "No worktrees to adopt\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := tt.result.Format(FormatOptions{Verbose: tt.verbose})
+			if got.Stdout != tt.wantStdout {
+				t.Errorf("Stdout = %q, want %q", got.Stdout, tt.wantStdout)
+			}
+			if got.Stderr != tt.wantStderr {
+				t.Errorf("Stderr = %q, want %q", got.Stderr, tt.wantStderr)
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// worktreesAdminDir is the directory under the git common dir that holds
+// per-worktree administrative files (.git/worktrees/<id>).
+const worktreesAdminDir = "worktrees"
+
+// adminFileGitDir is the administrative file pointing back to <worktree>/.git.
+const adminFileGitDir = "gitdir"
+
+// AgeSource describes where a worktree creation time was derived from.
+type AgeSource string
+
+const (
+	AgeSourceNone       AgeSource = ""           // Creation time could not be determined
+	AgeSourceProvenance AgeSource = "provenance" // created_at of the twig.json marker
+	AgeSourceAdminDir   AgeSource = "admin dir"  // .git/worktrees/<id> directory mtime
+	AgeSourceGitDirFile AgeSource = "gitdir"     // .git/worktrees/<id>/gitdir file mtime
+)
+
+// WorktreeAge holds the resolved creation time of a worktree.
+type WorktreeAge struct {
+	CreatedAt time.Time
+	Source    AgeSource
+}
+
+// Known reports whether the creation time was resolved.
+func (a WorktreeAge) Known() bool {
+	return a.Source != AgeSourceNone && !a.CreatedAt.IsZero()
+}
+
+// Age returns the elapsed time since creation relative to now.
+// Returns 0 if the creation time is unknown.
+func (a WorktreeAge) Age(now time.Time) time.Duration {
+	if !a.Known() {
+		return 0
+	}
+	return now.Sub(a.CreatedAt)
+}
+
+// AgeResolver resolves worktree creation times from git administrative files.
+// The main worktree has no administrative directory, so its age is unknown.
+// Not safe for concurrent use: the admin dir index is loaded lazily.
+type AgeResolver struct {
+	FS  FileSystem
+	Git *GitRunner
+
+	adminDirs map[string]string // worktree path -> admin dir (lazily loaded)
+}
+
+// NewAgeResolver creates an AgeResolver with explicit dependencies.
+func NewAgeResolver(fs FileSystem, git *GitRunner) *AgeResolver {
+	return &AgeResolver{FS: fs, Git: git}
+}
+
+// Resolve returns the creation time of the worktree at wtPath.
+// The created_at recorded by twig add or adopt (see Provenance) is used
+// when present. Otherwise directory mtime and gitdir file mtime are both
+// candidates; the oldest is used since later writes (HEAD updates,
+// locking) only move mtimes forward. Modification time is used instead
+// of birth time for consistent behavior across platforms.
+func (r *AgeResolver) Resolve(ctx context.Context, wtPath string) (WorktreeAge, error) {
+	if r.adminDirs == nil {
+		dirs, err := loadWorktreeAdminDirs(ctx, r.FS, r.Git)
+		if err != nil {
+			return WorktreeAge{}, err
+		}
+		r.adminDirs = dirs
+	}
+
+	adminDir, ok := r.adminDirs[filepath.Clean(wtPath)]
+	if !ok {
+		return WorktreeAge{}, nil
+	}
+
+	if p, ok := readProvenance(r.FS, adminDir); ok && !p.CreatedAt.IsZero() {
+		return WorktreeAge{CreatedAt: p.CreatedAt, Source: AgeSourceProvenance}, nil
+	}
+
+	var age WorktreeAge
+	candidates := []struct {
+		path   string
+		source AgeSource
+	}{
+		{adminDir, AgeSourceAdminDir},
+		{filepath.Join(adminDir, adminFileGitDir), AgeSourceGitDirFile},
+	}
+	for _, c := range candidates {
+		info, err := r.FS.Stat(c.path)
+		if err != nil || info == nil {
+			continue
+		}
+		mtime := info.ModTime()
+		if mtime.IsZero() {
+			continue
+		}
+		if age.CreatedAt.IsZero() || mtime.Before(age.CreatedAt) {
+			age.CreatedAt = mtime
+			age.Source = c.source
+		}
+	}
+	return age, nil
+}
+
+// loadWorktreeAdminDirs maps each linked worktree path to its
+// administrative directory by reading .git/worktrees/<id>/gitdir. This
+// works for prunable worktrees whose directory no longer exists.
+func loadWorktreeAdminDirs(ctx context.Context, fs FileSystem, git *GitRunner) (map[string]string, error) {
+	commonDir, err := git.GitCommonDir(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get git common directory: %w", err)
+	}
+
+	dirs := make(map[string]string)
+	base := filepath.Join(commonDir, worktreesAdminDir)
+	entries, err := fs.ReadDir(base)
+	if err != nil {
+		if fs.IsNotExist(err) {
+			return dirs, nil
+		}
+		return nil, fmt.Errorf("failed to read worktree admin directory: %w", err)
+	}
+
+	for _, entry := range entries {
+		adminDir := filepath.Join(base, entry.Name())
+		data, err := fs.ReadFile(filepath.Join(adminDir, adminFileGitDir))
+		if err != nil {
+			continue
+		}
+		// gitdir points to <worktree>/.git, relative to adminDir with
+		// worktree.useRelativePaths
+		gitFile := strings.TrimSpace(string(data))
+		if gitFile == "" {
+			continue
+		}
+		if !filepath.IsAbs(gitFile) {
+			gitFile = filepath.Join(adminDir, gitFile)
+		}
+		dirs[filepath.Dir(filepath.Clean(gitFile))] = adminDir
+	}
+	return dirs, nil
+}
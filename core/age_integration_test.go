@@ -0,0 +1,97 @@
+//go:build integration
+
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/708u/twig/internal/testutil"
+)
+
+func TestAgeResolver_Integration(t *testing.T) {
+	t.Parallel()
+
+	t.Run("LinkedWorktreeHasCreationTime", func(t *testing.T) {
+		t.Parallel()
+
+		repoDir, mainDir := testutil.SetupTestRepo(t)
+
+		before := time.Now().Add(-time.Minute)
+		wtPath := filepath.Join(repoDir, "feat", "age")
+		testutil.RunGit(t, mainDir, "worktree", "add", "-b", "feat/age", wtPath)
+
+		r := NewAgeResolver(osFS{}, NewGitRunner(mainDir))
+		age, err := r.Resolve(t.Context(), wtPath)
+		if err != nil {
+			t.Fatalf("Resolve failed: %v", err)
+		}
+		if !age.Known() {
+			t.Fatal("expected creation time to be known")
+		}
+		if age.CreatedAt.Before(before) || age.CreatedAt.After(time.Now().Add(time.Minute)) {
+			t.Errorf("CreatedAt = %v, want around now", age.CreatedAt)
+		}
+	})
+
+	t.Run("ProvenanceCreationTimeIsUsed", func(t *testing.T) {
+		t.Parallel()
+
+		repoDir, mainDir := testutil.SetupTestRepo(t)
+
+		wtPath := filepath.Join(repoDir, "feat", "recorded")
+		testutil.RunGit(t, mainDir, "worktree", "add", "-b", "feat/recorded", wtPath)
+		// The admin dir is new, but twig recorded an older creation time
+		created := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+		git := NewGitRunner(mainDir)
+		if err := NewProvenanceStore(osFS{}, git).Write(t.Context(), wtPath, Provenance{CreatedAt: created, Command: ProvenanceCommandAdd}); err != nil {
+			t.Fatal(err)
+		}
+
+		age, err := NewAgeResolver(osFS{}, git).Resolve(t.Context(), wtPath)
+		if err != nil {
+			t.Fatalf("Resolve failed: %v", err)
+		}
+		if !age.CreatedAt.Equal(created) || age.Source != AgeSourceProvenance {
+			t.Errorf("age = %+v, want %v from provenance", age, created)
+		}
+	})
+
+	t.Run("MainWorktreeIsUnknown", func(t *testing.T) {
+		t.Parallel()
+
+		_, mainDir := testutil.SetupTestRepo(t)
+
+		r := NewAgeResolver(osFS{}, NewGitRunner(mainDir))
+		age, err := r.Resolve(t.Context(), mainDir)
+		if err != nil {
+			t.Fatalf("Resolve failed: %v", err)
+		}
+		if age.Known() {
+			t.Errorf("expected main worktree age to be unknown, got %v", age.CreatedAt)
+		}
+	})
+
+	t.Run("PrunableWorktreeStillResolves", func(t *testing.T) {
+		t.Parallel()
+
+		repoDir, mainDir := testutil.SetupTestRepo(t)
+
+		wtPath := filepath.Join(repoDir, "feat", "gone")
+		testutil.RunGit(t, mainDir, "worktree", "add", "-b", "feat/gone", wtPath)
+		if err := os.RemoveAll(wtPath); err != nil {
+			t.Fatal(err)
+		}
+
+		r := NewAgeResolver(osFS{}, NewGitRunner(mainDir))
+		age, err := r.Resolve(t.Context(), wtPath)
+		if err != nil {
+			t.Fatalf("Resolve failed: %v", err)
+		}
+		if !age.Known() {
+			t.Error("expected creation time of prunable worktree to be known")
+		}
+	})
+}
@@ -0,0 +1,177 @@
+package core
+
+import (
+	"io/fs"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/708u/twig/internal/testutil"
+)
+
+func TestAgeResolver_Resolve(t *testing.T) {
+	t.Parallel()
+
+	older := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	newer := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		wtPath     string
+		mtimes     map[string]time.Time
+		provenance string
+		wantKnown  bool
+		wantTime   time.Time
+		wantSource AgeSource
+	}{
+		{
+			name:   "admin dir older than gitdir file",
+			wtPath: "/repo/wt/feat-a",
+			mtimes: map[string]time.Time{
+				"/repo/.git/worktrees/feat-a":        older,
+				"/repo/.git/worktrees/feat-a/gitdir": newer,
+			},
+			wantKnown:  true,
+			wantTime:   older,
+			wantSource: AgeSourceAdminDir,
+		},
+		{
+			name:   "provenance created_at wins over mtimes",
+			wtPath: "/repo/wt/feat-a",
+			mtimes: map[string]time.Time{
+				"/repo/.git/worktrees/feat-a":        older,
+				"/repo/.git/worktrees/feat-a/gitdir": older,
+			},
+			provenance: `{"created_at": "2026-02-01T00:00:00Z", "command": "add"}`,
+			wantKnown:  true,
+			wantTime:   newer,
+			wantSource: AgeSourceProvenance,
+		},
+		{
+			name:   "unreadable provenance falls back to mtimes",
+			wtPath: "/repo/wt/feat-a",
+			mtimes: map[string]time.Time{
+				"/repo/.git/worktrees/feat-a":        older,
+				"/repo/.git/worktrees/feat-a/gitdir": newer,
+			},
+			provenance: `{`,
+			wantKnown:  true,
+			wantTime:   older,
+			wantSource: AgeSourceAdminDir,
+		},
+		{
+			name:   "gitdir file older than admin dir",
+			wtPath: "/repo/wt/feat-a",
+			mtimes: map[string]time.Time{
+				"/repo/.git/worktrees/feat-a":        newer,
+				"/repo/.git/worktrees/feat-a/gitdir": older,
+			},
+			wantKnown:  true,
+			wantTime:   older,
+			wantSource: AgeSourceGitDirFile,
+		},
+		{
+			name:   "fallback to gitdir file when admin dir stat fails",
+			wtPath: "/repo/wt/feat-a",
+			mtimes: map[string]time.Time{
+				"/repo/.git/worktrees/feat-a/gitdir": newer,
+			},
+			wantKnown:  true,
+			wantTime:   newer,
+			wantSource: AgeSourceGitDirFile,
+		},
+		{
+			name:      "main worktree has no admin dir",
+			wtPath:    "/repo",
+			mtimes:    map[string]time.Time{},
+			wantKnown: false,
+		},
+		{
+			name:      "no timestamps available",
+			wtPath:    "/repo/wt/feat-a",
+			mtimes:    map[string]time.Time{},
+			wantKnown: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			files := map[string][]byte{
+				"/repo/.git/worktrees/feat-a/gitdir": []byte("/repo/wt/feat-a/.git\n"),
+			}
+			if tt.provenance != "" {
+				files["/repo/.git/worktrees/feat-a/twig.json"] = []byte(tt.provenance)
+			}
+			mockFS := &testutil.MockFS{
+				DirContents: map[string][]os.DirEntry{
+					"/repo/.git/worktrees": {mockDirEntry{name: "feat-a", isDir: true}},
+				},
+				ReadFileResults: files,
+				StatFunc: func(name string) (fs.FileInfo, error) {
+					if mtime, ok := tt.mtimes[name]; ok {
+						return &testutil.MockFileInfo{ModTimeVal: mtime}, nil
+					}
+					return nil, fs.ErrNotExist
+				},
+			}
+			mockGit := &testutil.MockGitExecutor{GitCommonDir: "/repo/.git"}
+
+			r := NewAgeResolver(mockFS, &GitRunner{Executor: mockGit, Dir: "/repo", Log: NewNopLogger()})
+			age, err := r.Resolve(t.Context(), tt.wtPath)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if age.Known() != tt.wantKnown {
+				t.Fatalf("Known() = %v, want %v", age.Known(), tt.wantKnown)
+			}
+			if !tt.wantKnown {
+				return
+			}
+			if !age.CreatedAt.Equal(tt.wantTime) {
+				t.Errorf("CreatedAt = %v, want %v", age.CreatedAt, tt.wantTime)
+			}
+			if age.Source != tt.wantSource {
+				t.Errorf("Source = %q, want %q", age.Source, tt.wantSource)
+			}
+		})
+	}
+}
+
+func TestAgeResolver_Resolve_NoWorktreesDir(t *testing.T) {
+	t.Parallel()
+
+	mockFS := &testutil.MockFS{
+		ReadDirFunc: func(name string) ([]os.DirEntry, error) {
+			return nil, fs.ErrNotExist
+		},
+	}
+	mockGit := &testutil.MockGitExecutor{GitCommonDir: "/repo/.git"}
+
+	r := NewAgeResolver(mockFS, &GitRunner{Executor: mockGit, Dir: "/repo", Log: NewNopLogger()})
+	age, err := r.Resolve(t.Context(), "/repo")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if age.Known() {
+		t.Errorf("Known() = true, want false")
+	}
+}
+
+func TestWorktreeAge_Age(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 1, 10, 0, 0, 0, 0, time.UTC)
+
+	known := WorktreeAge{CreatedAt: now.Add(-48 * time.Hour), Source: AgeSourceAdminDir}
+	if got := known.Age(now); got != 48*time.Hour {
+		t.Errorf("Age() = %v, want %v", got, 48*time.Hour)
+	}
+
+	var unknown WorktreeAge
+	if got := unknown.Age(now); got != 0 {
+		t.Errorf("Age() of unknown = %v, want 0", got)
+	}
+}
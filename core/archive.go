@@ -0,0 +1,187 @@
+package core
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// archivesDirName is the default archive directory under <git-common-dir>/twig.
+	archivesDirName = "archives"
+
+	// archiveTimeFormat stamps archive file names so they sort by time.
+	archiveTimeFormat = "20060102-150405"
+
+	// archiveExt is the extension of worktree archives.
+	archiveExt = ".tar.gz"
+
+	// archivePatchName is the patch of tracked changes against HEAD,
+	// stored at the root of the archive.
+	archivePatchName = "changes.patch"
+
+	// archiveFilesDir holds the changed and untracked files in the archive,
+	// at their paths relative to the worktree root.
+	archiveFilesDir = "files"
+)
+
+// WorktreeArchiver saves the uncommitted changes and untracked files of a
+// worktree to a gzipped tarball before it is removed, so that a forced
+// removal can be undone by hand. The archive holds changes.patch, a binary
+// patch against HEAD for git apply, and files/, the current content of
+// every changed or untracked file.
+type WorktreeArchiver struct {
+	FS  FileSystem
+	Git *GitRunner
+	Dir string // Archive directory (empty = <git-common-dir>/twig/archives)
+	Log *slog.Logger
+}
+
+// NewWorktreeArchiver creates a WorktreeArchiver with explicit dependencies.
+func NewWorktreeArchiver(fs FileSystem, git *GitRunner, dir string, log *slog.Logger) *WorktreeArchiver {
+	if log == nil {
+		log = NewNopLogger()
+	}
+	return &WorktreeArchiver{FS: fs, Git: git, Dir: dir, Log: log}
+}
+
+// ArchivePath returns the path an archive of name created at now is
+// written to: <dir>/<name>-<timestamp>.tar.gz, with "/" in branch names
+// replaced by "-". A numeric suffix is added if the path already exists.
+func (a *WorktreeArchiver) ArchivePath(ctx context.Context, name string, now time.Time) (string, error) {
+	dir, err := a.dir(ctx)
+	if err != nil {
+		return "", err
+	}
+	base := strings.ReplaceAll(name, "/", "-") + "-" + now.Format(archiveTimeFormat)
+	archivePath := filepath.Join(dir, base+archiveExt)
+	for i := 1; ; i++ {
+		// Any error other than an existing file is reported when writing
+		if _, err := a.FS.Stat(archivePath); err != nil {
+			return archivePath, nil
+		}
+		archivePath = filepath.Join(dir, base+"-"+strconv.Itoa(i)+archiveExt)
+	}
+}
+
+// Archive writes the changes of the worktree at wtPath to a new archive
+// named after name and returns its path, or "" if the worktree is clean.
+func (a *WorktreeArchiver) Archive(ctx context.Context, wtPath, name string) (string, error) {
+	wtGit := a.Git.InDir(wtPath)
+	changed, err := wtGit.ChangedFilesAll(ctx)
+	if err != nil {
+		return "", err
+	}
+	if len(changed) == 0 {
+		return "", nil
+	}
+	patch, err := wtGit.DiffPatch(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to diff uncommitted changes: %w", err)
+	}
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	now := time.Now()
+
+	if len(patch) > 0 {
+		hdr := &tar.Header{Name: archivePatchName, Mode: 0644, Size: int64(len(patch)), ModTime: now}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return "", err
+		}
+		if _, err := tw.Write(patch); err != nil {
+			return "", err
+		}
+	}
+	for _, f := range changed {
+		if err := a.addFile(tw, wtPath, f.Path); err != nil {
+			return "", fmt.Errorf("failed to archive %s: %w", f.Path, err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return "", err
+	}
+	if err := gz.Close(); err != nil {
+		return "", err
+	}
+
+	archivePath, err := a.ArchivePath(ctx, name, now)
+	if err != nil {
+		return "", err
+	}
+	if err := a.FS.MkdirAll(filepath.Dir(archivePath), 0755); err != nil {
+		return "", fmt.Errorf("failed to create archive directory: %w", err)
+	}
+	if err := a.FS.WriteFile(archivePath, buf.Bytes(), 0644); err != nil {
+		return "", fmt.Errorf("failed to write archive: %w", err)
+	}
+
+	a.Log.DebugContext(ctx, "archived worktree changes",
+		LogAttrKeyCategory.String(), LogCategoryRemove,
+		"path", archivePath,
+		"files", len(changed))
+	return archivePath, nil
+}
+
+// addFile adds a changed file of the worktree to the archive. Deleted
+// files are only recorded in the patch, and directories (submodules) are
+// skipped since their changes live in their own repository.
+func (a *WorktreeArchiver) addFile(tw *tar.Writer, wtPath, rel string) error {
+	fullPath := filepath.Join(wtPath, rel)
+	info, err := a.FS.Lstat(fullPath)
+	if a.FS.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var link string
+	var data []byte
+	switch mode := info.Mode(); {
+	case mode&fs.ModeSymlink != 0:
+		if link, err = a.FS.Readlink(fullPath); err != nil {
+			return err
+		}
+	case mode.IsRegular():
+		if data, err = a.FS.ReadFile(fullPath); err != nil {
+			return err
+		}
+	default:
+		return nil
+	}
+
+	hdr, err := tar.FileInfoHeader(info, link)
+	if err != nil {
+		return err
+	}
+	hdr.Name = path.Join(archiveFilesDir, filepath.ToSlash(rel))
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err = tw.Write(data)
+	return err
+}
+
+// dir returns the archive directory, defaulting to a directory under the
+// git common dir so that archives are shared by all worktrees.
+func (a *WorktreeArchiver) dir(ctx context.Context) (string, error) {
+	if a.Dir != "" {
+		return a.Dir, nil
+	}
+	commonDir, err := a.Git.GitCommonDir(ctx)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(commonDir, auditDirName, archivesDirName), nil
+}
@@ -0,0 +1,301 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/user"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const (
+	// auditDirName is the directory under the git common dir holding the audit log.
+	// Stored inside .git so it is shared by all worktrees and never committed.
+	auditDirName = "twig"
+
+	auditLogFileName = "audit.jsonl"
+
+	// AuditCommandClean identifies entries written by twig clean.
+	AuditCommandClean = "clean"
+
+	// AuditCommandRemove identifies entries written by twig remove.
+	AuditCommandRemove = "remove"
+)
+
+// AuditEntry is a single destructive action recorded in the audit log.
+// Each entry is stored as one JSON line.
+type AuditEntry struct {
+	Time         time.Time `json:"time"`
+	Command      string    `json:"command"`
+	Branch       string    `json:"branch"`
+	WorktreePath string    `json:"worktree_path"`
+	HEAD         string    `json:"head"`
+	SizeBytes    int64     `json:"size_bytes"`
+	Reason       string    `json:"reason,omitempty"`
+	Target       string    `json:"target,omitempty"`
+	Force        int       `json:"force"`
+	Stale        bool      `json:"stale,omitempty"`
+	Pruned       bool      `json:"pruned,omitempty"`
+	User         string    `json:"user"`
+}
+
+// AuditLog reads and appends entries to the per-repository audit log.
+type AuditLog struct {
+	FS  FileSystem
+	Git *GitRunner
+}
+
+// NewAuditLog creates an AuditLog with explicit dependencies.
+func NewAuditLog(fs FileSystem, git *GitRunner) *AuditLog {
+	return &AuditLog{FS: fs, Git: git}
+}
+
+// Path returns the audit log file path (<git-common-dir>/twig/audit.jsonl).
+func (l *AuditLog) Path(ctx context.Context) (string, error) {
+	commonDir, err := l.Git.GitCommonDir(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get git common directory: %w", err)
+	}
+	if commonDir == "" {
+		return "", fmt.Errorf("git common directory is empty")
+	}
+	return filepath.Join(commonDir, auditDirName, auditLogFileName), nil
+}
+
+// Append writes entries to the audit log in a single append.
+func (l *AuditLog) Append(ctx context.Context, entries ...AuditEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	logPath, err := l.Path(ctx)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	for _, e := range entries {
+		line, err := json.Marshal(e)
+		if err != nil {
+			return fmt.Errorf("failed to encode audit entry: %w", err)
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+
+	if err := l.FS.MkdirAll(filepath.Dir(logPath), 0755); err != nil {
+		return fmt.Errorf("failed to create audit log directory: %w", err)
+	}
+	if err := l.FS.AppendFile(logPath, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to append audit log: %w", err)
+	}
+	return nil
+}
+
+// Read returns all entries in the audit log in the order they were written.
+// A missing log file yields no entries. Lines that cannot be decoded
+// (e.g. truncated writes) are skipped and counted in malformed.
+func (l *AuditLog) Read(ctx context.Context) (entries []AuditEntry, malformed int, err error) {
+	logPath, err := l.Path(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	data, err := l.FS.ReadFile(logPath)
+	if err != nil {
+		if l.FS.IsNotExist(err) {
+			return nil, 0, nil
+		}
+		return nil, 0, fmt.Errorf("failed to read audit log: %w", err)
+	}
+
+	for line := range strings.SplitSeq(string(data), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		var e AuditEntry
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			malformed++
+			continue
+		}
+		entries = append(entries, e)
+	}
+	return entries, malformed, nil
+}
+
+// currentUser returns the name of the user running twig for audit entries.
+func currentUser() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
+
+// AuditCommand queries the audit log.
+type AuditCommand struct {
+	FS  FileSystem
+	Git *GitRunner
+	Log *slog.Logger
+}
+
+// AuditOptions configures audit log filtering.
+type AuditOptions struct {
+	Branch string    // Branch glob pattern (empty = all)
+	Since  time.Time // Only entries at or after this time (zero = all)
+	Limit  int       // Show only the most recent N entries (0 = all)
+}
+
+// AuditResult holds the filtered audit entries.
+type AuditResult struct {
+	Entries   []AuditEntry
+	Malformed int // Number of log lines that could not be decoded
+}
+
+// AuditFormatOptions configures audit output formatting.
+type AuditFormatOptions struct {
+	Verbose bool
+}
+
+// NewAuditCommand creates an AuditCommand with explicit dependencies.
+func NewAuditCommand(fs FileSystem, git *GitRunner, log *slog.Logger) *AuditCommand {
+	if log == nil {
+		log = NewNopLogger()
+	}
+	return &AuditCommand{
+		FS:  fs,
+		Git: git,
+		Log: log,
+	}
+}
+
+// NewDefaultAuditCommand creates an AuditCommand with production defaults.
+func NewDefaultAuditCommand(dir string, log *slog.Logger, gitOpts ...GitRunnerOption) *AuditCommand {
+	git := newDefaultGitRunner(dir, log, gitOpts)
+	return NewAuditCommand(git.defaultFS(), git, log)
+}
+
+// Run reads the audit log and applies the filters in opts.
+func (c *AuditCommand) Run(ctx context.Context, opts AuditOptions) (AuditResult, error) {
+	var result AuditResult
+
+	if opts.Branch != "" {
+		if _, err := path.Match(opts.Branch, ""); err != nil {
+			return result, fmt.Errorf("invalid branch pattern %q: %w", opts.Branch, err)
+		}
+	}
+
+	entries, malformed, err := NewAuditLog(c.FS, c.Git).Read(ctx)
+	if err != nil {
+		return result, err
+	}
+	result.Malformed = malformed
+
+	c.Log.DebugContext(ctx, "audit log read",
+		LogAttrKeyCategory.String(), LogCategoryAudit,
+		"entries", len(entries),
+		"malformed", malformed)
+
+	for _, e := range entries {
+		if !opts.Since.IsZero() && e.Time.Before(opts.Since) {
+			continue
+		}
+		if opts.Branch != "" {
+			if matched, _ := path.Match(opts.Branch, e.Branch); !matched {
+				continue
+			}
+		}
+		result.Entries = append(result.Entries, e)
+	}
+
+	if opts.Limit > 0 && len(result.Entries) > opts.Limit {
+		result.Entries = result.Entries[len(result.Entries)-opts.Limit:]
+	}
+	return result, nil
+}
+
+// Warnings returns a warning when log lines were skipped.
+func (r AuditResult) Warnings() []Warning {
+	if r.Malformed == 0 {
+		return nil
+	}
+	return []Warning{{
+		Code:    WarningAuditMalformed,
+		Message: fmt.Sprintf("skipped %d malformed audit log line(s)", r.Malformed),
+	}}
+}
+
+// Format formats the AuditResult for display.
+func (r AuditResult) Format(opts AuditFormatOptions) FormatResult {
+	var stdout, stderr strings.Builder
+
+	writeWarnings(&stderr, r.Warnings())
+
+	if len(r.Entries) == 0 {
+		fmt.Fprintln(&stdout, "no audit entries")
+		return FormatResult{Stdout: stdout.String(), Stderr: stderr.String()}
+	}
+
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	for _, e := range r.Entries {
+		head := e.HEAD
+		if len(head) > 7 {
+			head = head[:7]
+		}
+		reason := e.Reason
+		if reason == "" {
+			reason = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			e.Time.Local().Format(time.DateTime), e.Command, e.Branch, head,
+			reason, formatBytes(e.SizeBytes), e.User)
+		if opts.Verbose {
+			fmt.Fprintf(w, "\tpath: %s\n", e.WorktreePath)
+			fmt.Fprintf(w, "\tflags: force=%d stale=%t pruned=%t target=%s\n",
+				e.Force, e.Stale, e.Pruned, e.Target)
+		}
+	}
+	w.Flush()
+	stdout.Write(buf.Bytes())
+
+	return FormatResult{Stdout: stdout.String(), Stderr: stderr.String()}
+}
+
+// formatBytes formats a byte count in human-readable units.
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// ParseAuditSince parses a --since value relative to now.
+// Accepts a number of days ("7d"), a Go duration ("36h"), or a date ("2026-01-02").
+func ParseAuditSince(s string, now time.Time) (time.Time, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(s); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	if t, err := time.ParseInLocation(time.DateOnly, s, now.Location()); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid --since value %q (use e.g. 7d, 36h, or 2026-01-02)", s)
+}
@@ -0,0 +1,337 @@
+package core
+
+import (
+	"errors"
+	"io/fs"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/708u/twig/internal/testutil"
+)
+
+func newTestAuditGit() *GitRunner {
+	return &GitRunner{
+		Executor: &testutil.MockGitExecutor{GitCommonDir: "/repo/.git"},
+		Dir:      "/repo",
+		Log:      NewNopLogger(),
+	}
+}
+
+func TestAuditLog_AppendAndRead(t *testing.T) {
+	t.Parallel()
+
+	mockFS := &testutil.MockFS{WrittenFiles: map[string][]byte{}}
+	log := NewAuditLog(mockFS, newTestAuditGit())
+
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	first := AuditEntry{Time: now, Command: AuditCommandClean, Branch: "feat/a", WorktreePath: "/repo/wt/feat-a", HEAD: "abc1234567", SizeBytes: 2048, Reason: "merged", Target: "main", User: "alice"}
+	second := AuditEntry{Time: now.Add(time.Minute), Command: AuditCommandClean, Branch: "feat/b", Force: 1, Stale: true, User: "alice"}
+
+	if err := log.Append(t.Context(), first); err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+	if err := log.Append(t.Context(), second); err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+
+	data := string(mockFS.WrittenFiles["/repo/.git/twig/audit.jsonl"])
+	if got := strings.Count(data, "\n"); got != 2 {
+		t.Fatalf("log has %d lines, want 2:\n%s", got, data)
+	}
+
+	entries, malformed, err := log.Read(t.Context())
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if malformed != 0 {
+		t.Errorf("malformed = %d, want 0", malformed)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("len(entries) = %d, want 2", len(entries))
+	}
+	if entries[0] != first {
+		t.Errorf("entries[0] = %+v, want %+v", entries[0], first)
+	}
+	if entries[1] != second {
+		t.Errorf("entries[1] = %+v, want %+v", entries[1], second)
+	}
+}
+
+func TestAuditLog_Read(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		content       string // empty = file does not exist
+		readErr       error
+		wantEntries   int
+		wantMalformed int
+		wantErr       bool
+	}{
+		{
+			name:        "missing log file",
+			wantEntries: 0,
+		},
+		{
+			name:          "malformed lines skipped",
+			content:       "{\"branch\":\"feat/a\"}\nnot json\n\n{\"branch\":\"feat/b\"}\n{\"branch\":",
+			wantEntries:   2,
+			wantMalformed: 2,
+		},
+		{
+			name:    "read error",
+			readErr: errors.New("permission denied"),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockFS := &testutil.MockFS{
+				ReadFileFunc: func(name string) ([]byte, error) {
+					if tt.readErr != nil {
+						return nil, tt.readErr
+					}
+					if tt.content == "" || name != "/repo/.git/twig/audit.jsonl" {
+						return nil, fs.ErrNotExist
+					}
+					return []byte(tt.content), nil
+				},
+			}
+
+			entries, malformed, err := NewAuditLog(mockFS, newTestAuditGit()).Read(t.Context())
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(entries) != tt.wantEntries {
+				t.Errorf("len(entries) = %d, want %d", len(entries), tt.wantEntries)
+			}
+			if malformed != tt.wantMalformed {
+				t.Errorf("malformed = %d, want %d", malformed, tt.wantMalformed)
+			}
+		})
+	}
+}
+
+func TestAuditLog_Append_Error(t *testing.T) {
+	t.Parallel()
+
+	mockFS := &testutil.MockFS{AppendFileErr: errors.New("disk full")}
+	err := NewAuditLog(mockFS, newTestAuditGit()).Append(t.Context(), AuditEntry{Branch: "feat/a"})
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if !strings.Contains(err.Error(), "disk full") {
+		t.Errorf("error = %q, want to contain %q", err.Error(), "disk full")
+	}
+}
+
+func TestAuditCommand_Run(t *testing.T) {
+	t.Parallel()
+
+	base := time.Date(2026, 1, 10, 0, 0, 0, 0, time.UTC)
+	content := strings.Join([]string{
+		`{"time":"2026-01-01T00:00:00Z","command":"clean","branch":"feat/a"}`,
+		`{"time":"2026-01-05T00:00:00Z","command":"clean","branch":"fix/b"}`,
+		`{"time":"2026-01-08T00:00:00Z","command":"clean","branch":"feat/c"}`,
+		`{"time":"2026-01-09T00:00:00Z","command":"clean","branch":"feat/d"}`,
+	}, "\n") + "\n"
+
+	tests := []struct {
+		name         string
+		opts         AuditOptions
+		wantBranches []string
+		wantErr      bool
+	}{
+		{
+			name:         "no filters",
+			wantBranches: []string{"feat/a", "fix/b", "feat/c", "feat/d"},
+		},
+		{
+			name:         "branch glob",
+			opts:         AuditOptions{Branch: "feat/*"},
+			wantBranches: []string{"feat/a", "feat/c", "feat/d"},
+		},
+		{
+			name:         "since",
+			opts:         AuditOptions{Since: base.AddDate(0, 0, -5)},
+			wantBranches: []string{"fix/b", "feat/c", "feat/d"},
+		},
+		{
+			name:         "limit keeps most recent",
+			opts:         AuditOptions{Limit: 2},
+			wantBranches: []string{"feat/c", "feat/d"},
+		},
+		{
+			name:         "combined filters",
+			opts:         AuditOptions{Branch: "feat/*", Since: base.AddDate(0, 0, -5), Limit: 1},
+			wantBranches: []string{"feat/d"},
+		},
+		{
+			name:    "invalid pattern",
+			opts:    AuditOptions{Branch: "feat/["},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockFS := &testutil.MockFS{
+				ReadFileResults: map[string][]byte{
+					"/repo/.git/twig/audit.jsonl": []byte(content),
+				},
+			}
+
+			cmd := NewAuditCommand(mockFS, newTestAuditGit(), nil)
+			result, err := cmd.Run(t.Context(), tt.opts)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var got []string
+			for _, e := range result.Entries {
+				got = append(got, e.Branch)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.wantBranches, ",") {
+				t.Errorf("branches = %v, want %v", got, tt.wantBranches)
+			}
+		})
+	}
+}
+
+func TestAuditResult_Format(t *testing.T) {
+	t.Parallel()
+
+	entry := AuditEntry{
+		Time:         time.Date(2026, 1, 2, 3, 4, 5, 0, time.Local),
+		Command:      AuditCommandClean,
+		Branch:       "feat/a",
+		WorktreePath: "/repo/wt/feat-a",
+		HEAD:         "abc1234567890",
+		SizeBytes:    1536,
+		Reason:       "merged",
+		Target:       "main",
+		User:         "alice",
+	}
+
+	tests := []struct {
+		name       string
+		result     AuditResult
+		opts       AuditFormatOptions
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			name:       "empty",
+			wantStdout: "no audit entries\n",
+		},
+		{
+			name:       "single entry",
+			result:     AuditResult{Entries: []AuditEntry{entry}},
+			wantStdout: "2026-01-02 03:04:05  clean  feat/a  abc1234  merged  1.5 KiB  alice\n",
+		},
+		{
+			name:   "verbose shows path and flags",
+			result: AuditResult{Entries: []AuditEntry{entry}},
+			opts:   AuditFormatOptions{Verbose: true},
+			wantStdout: "2026-01-02 03:04:05  clean  feat/a  abc1234  merged  1.5 KiB  alice\n" +
+				"                     path: /repo/wt/feat-a\n" +
+				"                     flags: force=0 stale=false pruned=false target=main\n",
+		},
+		{
+			name:       "malformed warning",
+			result:     AuditResult{Entries: []AuditEntry{entry}, Malformed: 1},
+			wantStdout: "2026-01-02 03:04:05  clean  feat/a  abc1234  merged  1.5 KiB  alice\n",
+			wantStderr: "warning: skipped 1 malformed audit log line(s)\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := tt.result.Format(tt.opts)
+			if got.Stdout != tt.wantStdout {
+				t.Errorf("Stdout = %q, want %q", got.Stdout, tt.wantStdout)
+			}
+			if got.Stderr != tt.wantStderr {
+				t.Errorf("Stderr = %q, want %q", got.Stderr, tt.wantStderr)
+			}
+		})
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		n    int64
+		want string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1024, "1.0 KiB"},
+		{5 * 1024 * 1024, "5.0 MiB"},
+		{3 * 1024 * 1024 * 1024, "3.0 GiB"},
+	}
+
+	for _, tt := range tests {
+		if got := formatBytes(tt.n); got != tt.want {
+			t.Errorf("formatBytes(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestParseAuditSince(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		in      string
+		want    time.Time
+		wantErr bool
+	}{
+		{in: "7d", want: now.AddDate(0, 0, -7)},
+		{in: "0d", want: now},
+		{in: "36h", want: now.Add(-36 * time.Hour)},
+		{in: "2026-01-02", want: time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{in: "-1d", wantErr: true},
+		{in: "-2h", wantErr: true},
+		{in: "yesterday", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseAuditSince(tt.in, now)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseAuditSince(%q) expected error", tt.in)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseAuditSince(%q) error = %v", tt.in, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("ParseAuditSince(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
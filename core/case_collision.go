@@ -0,0 +1,155 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// Values of the case_collisions setting.
+const (
+	// CaseCollisionsAuto fails on case-insensitive filesystems and warns
+	// elsewhere.
+	CaseCollisionsAuto = "auto"
+	// CaseCollisionsError always fails.
+	CaseCollisionsError = "error"
+	// CaseCollisionsWarn always proceeds with a warning.
+	CaseCollisionsWarn = "warn"
+)
+
+// SupportedCaseCollisions lists the valid values of the case_collisions
+// setting.
+var SupportedCaseCollisions = []string{CaseCollisionsAuto, CaseCollisionsError, CaseCollisionsWarn}
+
+// CaseCollision is a branch or worktree path that differs only in case
+// from one that already exists. On case-insensitive filesystems (macOS,
+// Windows) both map to the same files.
+type CaseCollision struct {
+	Name     string // Branch or path being created
+	Existing string // Branch or path it collides with
+	Owner    string // Branch (or detached HEAD) of the worktree at Existing, for paths
+	IsPath   bool
+}
+
+// String describes the collision.
+func (c CaseCollision) String() string {
+	if !c.IsPath {
+		return fmt.Sprintf("branch %s differs only in case from existing branch %s", c.Name, c.Existing)
+	}
+	if c.Owner != "" {
+		return fmt.Sprintf("worktree path %s differs only in case from %s (worktree of %s)", c.Name, c.Existing, c.Owner)
+	}
+	return fmt.Sprintf("worktree path %s differs only in case from existing %s", c.Name, c.Existing)
+}
+
+// CaseCollisionError is returned by twig add when case_collisions rejects
+// a collision.
+type CaseCollisionError struct {
+	Collision CaseCollision
+}
+
+func (e *CaseCollisionError) Error() string {
+	c := e.Collision
+	if !c.IsPath {
+		return fmt.Sprintf("%s, and both cannot exist on case-insensitive filesystems; "+
+			"run 'twig add %s' to use the existing branch, or choose a name that differs in more than case",
+			c, c.Existing)
+	}
+	return fmt.Sprintf("%s, and both cannot exist on case-insensitive filesystems; "+
+		"choose another name or --base-dir, or match the case of the existing path",
+		c)
+}
+
+// CaseCollisionMode returns the case_collisions setting, or
+// CaseCollisionsAuto when unset.
+func (c *Config) CaseCollisionMode() string {
+	if c == nil || c.CaseCollisions == "" {
+		return CaseCollisionsAuto
+	}
+	return c.CaseCollisions
+}
+
+// findCaseCollision looks for a local branch or an existing worktree or
+// directory that differs only in case from branch or wtPath. Directories
+// are compared component by component below baseDir, so a "Feat"
+// directory collides with "feat/login" too. branch is empty for
+// detached worktrees.
+func (c *AddCommand) findCaseCollision(ctx context.Context, branch, wtPath, baseDir string) (*CaseCollision, error) {
+	if branch != "" {
+		branches, err := c.Git.BranchList(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list branches: %w", err)
+		}
+		for _, b := range branches {
+			if b != branch && strings.EqualFold(b, branch) {
+				return &CaseCollision{Name: branch, Existing: b}, nil
+			}
+		}
+	}
+
+	worktrees, err := c.Git.WorktreeList(ctx)
+	if err != nil {
+		return nil, err
+	}
+	wtPath = filepath.Clean(wtPath)
+	for _, wt := range worktrees {
+		path := filepath.Clean(wt.Path)
+		if path != wtPath && strings.EqualFold(path, wtPath) {
+			owner := wt.Branch
+			if owner == "" {
+				owner = "detached HEAD " + wt.ShortHEAD()
+			}
+			return &CaseCollision{Name: wtPath, Existing: path, Owner: owner, IsPath: true}, nil
+		}
+	}
+
+	// Directories left without a worktree, or parents named in another case
+	dir, rel := filepath.Dir(wtPath), filepath.Base(wtPath)
+	if baseDir != "" && isWithinDir(baseDir, wtPath) {
+		if r, err := filepath.Rel(baseDir, wtPath); err == nil {
+			dir, rel = baseDir, r
+		}
+	}
+	for _, part := range strings.Split(rel, string(filepath.Separator)) {
+		entries, err := c.FS.ReadDir(dir)
+		if err != nil {
+			break
+		}
+		var exact bool
+		for _, e := range entries {
+			if e.Name() == part {
+				exact = true
+				break
+			}
+		}
+		if !exact {
+			for _, e := range entries {
+				if strings.EqualFold(e.Name(), part) {
+					return &CaseCollision{Name: wtPath, Existing: filepath.Join(dir, e.Name()), IsPath: true}, nil
+				}
+			}
+			break
+		}
+		dir = filepath.Join(dir, part)
+	}
+	return nil, nil
+}
+
+// isCaseInsensitiveDir reports whether dir is on a case-insensitive
+// filesystem: the path with its letters in swapped case resolves too.
+// Paths without letters are reported as case-sensitive.
+func isCaseInsensitiveDir(fs FileSystem, dir string) bool {
+	swapped := strings.Map(func(r rune) rune {
+		if unicode.IsUpper(r) {
+			return unicode.ToLower(r)
+		}
+		return unicode.ToUpper(r)
+	}, dir)
+	if swapped == dir {
+		return false
+	}
+	_, err := fs.Stat(swapped)
+	return err == nil
+}
@@ -0,0 +1,1262 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/708u/twig/format"
+)
+
+// CleanCommand removes merged worktrees that are no longer needed.
+type CleanCommand struct {
+	FS     FileSystem
+	Git    *GitRunner
+	Config *Config
+	Log    *slog.Logger
+	Audit  *AuditLog    // Records removals (nil = disabled)
+	Forge  *ForgeClient // Looks up PR states for unmerged branches (nil = disabled)
+
+	// Verify runs clean_verify_command in dir with env as its environment
+	// and returns its combined output. nil runs it with sh -c.
+	Verify func(ctx context.Context, dir, command string, env []string) ([]byte, error)
+}
+
+// CleanOptions configures the clean operation.
+type CleanOptions struct {
+	Yes     bool               // Execute without confirmation
+	Check   bool               // Show candidates only (no prompt)
+	Targets []string           // Target branches for merge check; merged into any counts (auto-detect if empty)
+	Verbose bool               // Show skip reasons
+	Force   WorktreeForceLevel // Force level: -f for unclean, -ff for locked
+	Stale   bool               // Bypass changes check for merged/upstream-gone branches
+	Fetch   bool               // Run git fetch --prune for each remote before checking
+
+	// KeepEmptyDirs leaves parent directories in place even when a
+	// removal empties them (--keep-empty-dirs, cleanup_empty_dirs = false).
+	KeepEmptyDirs bool
+
+	// Detached also removes detached HEAD worktrees (e.g. from
+	// twig add --detach) that pass the non-merge safety checks.
+	// They have no branch, so nothing is deleted besides the worktree.
+	Detached bool
+
+	// Archive saves uncommitted changes of each removed worktree to a
+	// tarball first (--archive), as with twig remove --archive.
+	Archive bool
+	// ArchiveDir overrides archive_dir (--archive=<dir>).
+	ArchiveDir string
+
+	// Worktrees limits the candidates to the worktrees at these paths
+	// (empty = all). Used by twig gc for the worktrees over its policy.
+	Worktrees []string
+
+	// Exclude skips branches matching these patterns (path.Match syntax,
+	// --exclude), in addition to clean_exclude.
+	Exclude []string
+}
+
+// NewCleanCommand creates a new CleanCommand with explicit dependencies.
+// Use this for testing or when custom dependencies are needed.
+func NewCleanCommand(fs FileSystem, git *GitRunner, cfg *Config, log *slog.Logger) *CleanCommand {
+	if log == nil {
+		log = NewNopLogger()
+	}
+	return &CleanCommand{
+		FS:     fs,
+		Git:    git,
+		Config: cfg,
+		Log:    log,
+	}
+}
+
+// NewDefaultCleanCommand creates a new CleanCommand with production dependencies.
+// Removals are recorded in the audit log, and PR states are looked up
+// when a forge is configured.
+func NewDefaultCleanCommand(cfg *Config, log *slog.Logger, gitOpts ...GitRunnerOption) *CleanCommand {
+	git := newDefaultGitRunner(cfg.WorktreeSourceDir, log, gitOpts)
+	fs := git.defaultFS()
+	cmd := NewCleanCommand(fs, git, cfg, log)
+	cmd.Audit = NewAuditLog(fs, git)
+	cmd.Forge = NewDefaultForgeClient(cfg, fs, git, log)
+	return cmd
+}
+
+// CleanCandidate represents a worktree that can be cleaned.
+type CleanCandidate struct {
+	Branch        string
+	WorktreePath  string
+	HEAD          string
+	Prunable      bool
+	Skipped       bool
+	SkipReason    SkipReason
+	CleanReason   CleanReason
+	ChangedFiles  []FileStatus
+	StaleOverride bool   // Changes check bypassed via --stale for merged/upstream-gone
+	Detached      bool   // Detached HEAD worktree (no branch)
+	Note          string // Branch note set with twig note
+	Description   string // Branch description (git branch --edit-description)
+	Target        string // Target branch the merge status was checked against
+	VerifyOutput  string // Output of a failed clean_verify_command
+	Unpushed      int    // Commits of the branch not on any remote (SkipUnpushedCommits)
+}
+
+// displayName returns the branch, or the worktree path for detached
+// worktrees, which have no branch.
+func (c CleanCandidate) displayName() string {
+	if c.Detached {
+		return c.WorktreePath
+	}
+	return c.Branch
+}
+
+// fetchRemotes runs git fetch --prune for each configured remote and
+// returns the failures.
+func (c *CleanCommand) fetchRemotes(ctx context.Context) []error {
+	remotes, err := c.Git.Remotes(ctx)
+	if err != nil {
+		return []error{err}
+	}
+	var errs []error
+	for _, remote := range remotes {
+		c.Log.DebugContext(ctx, "fetching remote",
+			LogAttrKeyCategory.String(), LogCategoryClean,
+			"remote", remote)
+		if err := c.Git.FetchPrune(ctx, remote); err != nil {
+			errs = append(errs, fmt.Errorf("failed to fetch %s: %w", remote, err))
+		}
+	}
+	return errs
+}
+
+// verifyCandidates runs clean_verify_command for each candidate that
+// would be removed. A non-zero exit keeps the worktree, skipped with
+// SkipVerifyFailed. Prunable worktrees have no directory to check.
+func (c *CleanCommand) verifyCandidates(ctx context.Context, candidates []CleanCandidate) {
+	verify := c.Verify
+	if verify == nil {
+		verify = runVerifyCommand
+	}
+	for i := range candidates {
+		cand := &candidates[i]
+		if cand.Skipped || cand.Prunable {
+			continue
+		}
+		command := expandOpenCommand(c.Config.CleanVerifyCommand, cand.WorktreePath)
+		output, err := verify(ctx, cand.WorktreePath, command, worktreeEnv(cand.Branch, cand.WorktreePath, false))
+		if err == nil {
+			continue
+		}
+		c.Log.DebugContext(ctx, "verify command failed",
+			LogAttrKeyCategory.String(), LogCategoryClean,
+			"branch", cand.Branch,
+			"command", command,
+			"error", err.Error())
+		cand.Skipped = true
+		cand.SkipReason = SkipVerifyFailed
+		cand.StaleOverride = false
+		cand.VerifyOutput = strings.TrimSpace(string(output))
+	}
+}
+
+// checkUnpushed skips candidates whose branch has commits that are not on
+// any remote, with SkipUnpushedCommits. Branches whose upstream is gone
+// were pushed before and are not checked, nor are repositories without
+// remotes.
+func (c *CleanCommand) checkUnpushed(ctx context.Context, candidates []CleanCandidate, branches map[string]BranchStatus) {
+	remotes, err := c.Git.Remotes(ctx)
+	if err != nil || len(remotes) == 0 {
+		c.Log.DebugContext(ctx, "skipping unpushed check without remotes",
+			LogAttrKeyCategory.String(), LogCategoryClean,
+			"error", err)
+		return
+	}
+	for i := range candidates {
+		cand := &candidates[i]
+		if cand.Skipped || cand.Branch == "" || branches[cand.Branch].Gone {
+			continue
+		}
+		n, err := c.Git.UnpushedCount(ctx, cand.Branch)
+		if err != nil {
+			c.Log.DebugContext(ctx, "failed to count unpushed commits",
+				LogAttrKeyCategory.String(), LogCategoryClean,
+				"branch", cand.Branch,
+				"error", err.Error())
+			continue
+		}
+		if n == 0 {
+			continue
+		}
+		cand.Skipped = true
+		cand.SkipReason = SkipUnpushedCommits
+		cand.StaleOverride = false
+		cand.Unpushed = n
+	}
+}
+
+// checkManaged skips candidates without a provenance marker, i.e. not
+// created or adopted by twig, with SkipNotManaged
+// (clean_only_twig_managed). If the markers cannot be read, every
+// candidate is skipped.
+func (c *CleanCommand) checkManaged(ctx context.Context, candidates []CleanCandidate) {
+	markers, err := NewProvenanceStore(c.FS, c.Git).Load(ctx)
+	if err != nil {
+		c.Log.DebugContext(ctx, "failed to load provenance",
+			LogAttrKeyCategory.String(), LogCategoryClean,
+			"error", err.Error())
+	}
+	for i := range candidates {
+		cand := &candidates[i]
+		if cand.Skipped {
+			continue
+		}
+		if _, ok := markers[filepath.Clean(cand.WorktreePath)]; ok {
+			continue
+		}
+		cand.Skipped = true
+		cand.SkipReason = SkipNotManaged
+		cand.StaleOverride = false
+	}
+}
+
+// runVerifyCommand runs command with sh -c in dir and returns its
+// combined output.
+func runVerifyCommand(ctx context.Context, dir, command string, env []string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Dir = dir
+	cmd.Env = env
+	return cmd.CombinedOutput()
+}
+
+// splitOutputLines splits command output into lines, dropping empty ones.
+func splitOutputLines(output string) []string {
+	var lines []string
+	for line := range strings.SplitSeq(output, "\n") {
+		if line = strings.TrimRight(line, "\r"); strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// CleanResult aggregates results from clean operations.
+type CleanResult struct {
+	Candidates   []CleanCandidate
+	Removed      []RemovedWorktree
+	TargetBranch string   // First target branch
+	Targets      []string // All target branches, in the order given
+	Pruned       bool
+	Check        bool             // --check mode (show candidates only, no prompt)
+	AuditErr     error            // Failure to record removals in the audit log
+	ForgeErr     error            // Failure to look up PR states on the forge
+	FetchErrs    []error          // Remotes that could not be fetched with --fetch
+	SquashErrs   map[string]error // Branches whose squash merge check failed (detect_squash_merges)
+}
+
+// CleanSummary totals the successful removals of a clean run.
+type CleanSummary struct {
+	Worktrees      int   // Worktrees removed
+	Branches       int   // Branches deleted (detached worktrees have none)
+	Dirs           int   // Empty parent directories removed
+	ReclaimedBytes int64 // Disk usage of the removed worktrees, measured before removal
+}
+
+// removalPhase reports whether r holds the removals of a clean run
+// rather than its candidates.
+func (r CleanResult) removalPhase() bool {
+	return !r.Check && len(r.Removed) > 0
+}
+
+// Warnings returns the warnings Format prints: failures to record the
+// removals after removal, and failures to look up the candidates before.
+func (r CleanResult) Warnings() []Warning {
+	if r.removalPhase() {
+		if r.AuditErr != nil {
+			return []Warning{{Code: WarningAuditFailed, Message: r.AuditErr.Error()}}
+		}
+		return nil
+	}
+	var warnings []Warning
+	for _, err := range r.FetchErrs {
+		warnings = append(warnings, Warning{Code: WarningFetchFailed, Message: err.Error()})
+	}
+	if r.ForgeErr != nil {
+		warnings = append(warnings, Warning{Code: WarningForgeFailed, Message: "PR lookup failed: " + r.ForgeErr.Error()})
+	}
+	for _, branch := range slices.Sorted(maps.Keys(r.SquashErrs)) {
+		warnings = append(warnings, Warning{
+			Code:    WarningSquashCheckFailed,
+			Subject: branch,
+			Message: fmt.Sprintf("could not check whether %s was squash-merged, treating it as not merged: %v", branch, r.SquashErrs[branch]),
+		})
+	}
+	return warnings
+}
+
+// Summary totals the removals that succeeded.
+func (r CleanResult) Summary() CleanSummary {
+	var s CleanSummary
+	for _, wt := range r.Removed {
+		if wt.Err != nil {
+			continue
+		}
+		s.Worktrees++
+		if wt.Branch != "" {
+			s.Branches++
+		}
+		s.Dirs += len(wt.CleanedDirs)
+		s.ReclaimedBytes += wt.SizeBytes
+	}
+	return s
+}
+
+// CleanableCount returns the number of worktrees that can be cleaned.
+func (r CleanResult) CleanableCount() int {
+	count := 0
+	for _, c := range r.Candidates {
+		if !c.Skipped {
+			count++
+		}
+	}
+	return count
+}
+
+// lineWriter provides indented line writing for formatted output.
+type lineWriter struct {
+	w *strings.Builder
+}
+
+// Line writes a formatted line with the specified indentation level.
+// Each level adds 2 spaces of indentation.
+func (lw *lineWriter) Line(level int, format string, args ...any) {
+	fmt.Fprintf(lw.w, "%s"+format+"\n",
+		append([]any{strings.Repeat("  ", level)}, args...)...)
+}
+
+// CleanFormatOptions configures CleanResult formatting.
+type CleanFormatOptions struct {
+	Verbose      bool
+	Quiet        bool // Print only the cleanable or removed branches (see FormatOptions.Quiet)
+	ColorEnabled bool // Enable color output (--color=auto/always)
+
+	// Porcelain prints one tab-separated record per candidate instead
+	// (see CleanResult.Format); used by --porcelain.
+	Porcelain bool
+}
+
+// Format formats the CleanResult for display.
+//
+// Porcelain records list every candidate, skipped ones included:
+//
+//	<branch>\t<path>\t<action>\t<reason>
+//
+// action is "remove" or "skip", and reason is the CleanReason or
+// SkipReason value (e.g. "merged", "not merged"). The branch field is
+// empty for detached worktrees. Fields are not added or reordered between
+// versions, so scripts can split on tabs.
+func (r CleanResult) Format(opts CleanFormatOptions) FormatResult {
+	if opts.Porcelain {
+		return r.formatPorcelain()
+	}
+	if opts.Quiet {
+		return r.formatQuiet(opts)
+	}
+
+	var stdout, stderr strings.Builder
+
+	// Color helper functions (apply color only when enabled)
+	applyClean := func(s string) string {
+		if opts.ColorEnabled {
+			return format.ColorClean(s)
+		}
+		return s
+	}
+	applySkip := func(s string) string {
+		if opts.ColorEnabled {
+			return format.ColorSkip(s)
+		}
+		return s
+	}
+	applySuccess := func(s string) string {
+		if opts.ColorEnabled {
+			return format.ColorSuccess(s)
+		}
+		return s
+	}
+	applyFailure := func(s string) string {
+		if opts.ColorEnabled {
+			return format.ColorFailure(s)
+		}
+		return s
+	}
+	applyReason := func(s string) string {
+		if opts.ColorEnabled {
+			return format.ColorReason(s)
+		}
+		return s
+	}
+	applyError := func(s string) string {
+		if opts.ColorEnabled {
+			return format.ColorError(s)
+		}
+		return s
+	}
+
+	// Show removal results (execution completed)
+	if r.removalPhase() {
+		for i := range r.Removed {
+			wt := &r.Removed[i]
+			if wt.Branch == "" {
+				if wt.Err != nil {
+					fmt.Fprintf(&stderr, "%s %s: %v\n", applyError("error:"), wt.WorktreePath, wt.Err)
+					writeGitErrorHint(&stderr, wt.Err)
+				} else if opts.Verbose {
+					fmt.Fprintf(&stdout, "Removed detached worktree: %s\n", wt.WorktreePath)
+				}
+			} else if wt.Err != nil {
+				fmt.Fprintf(&stderr, "%s %s: %v\n",
+					applyError("error:"), wt.Branch, wt.Err)
+				writeGitErrorHint(&stderr, wt.Err)
+			} else if opts.Verbose {
+				fmt.Fprintf(&stdout, "Removed worktree and branch: %s\n", wt.Branch)
+			}
+			if wt.Err == nil && wt.ArchivePath != "" {
+				fmt.Fprintf(&stdout, "Archived uncommitted changes: %s\n", wt.ArchivePath)
+			}
+		}
+		if s := r.Summary(); s.Worktrees > 0 {
+			fmt.Fprintf(&stdout, "Removed %d worktree(s), %d branch(es), %d empty dir(s); reclaimed %s\n",
+				s.Worktrees, s.Branches, s.Dirs, formatBytes(s.ReclaimedBytes))
+		}
+		writeWarnings(&stderr, r.Warnings())
+		return FormatResult{Stdout: stdout.String(), Stderr: stderr.String()}
+	}
+
+	// Show candidates (check mode or before execution)
+	writeWarnings(&stderr, r.Warnings())
+	var cleanable, skipped []CleanCandidate
+	for _, c := range r.Candidates {
+		if c.Skipped {
+			skipped = append(skipped, c)
+		} else {
+			cleanable = append(cleanable, c)
+		}
+	}
+
+	// No cleanable candidates
+	lw := &lineWriter{w: &stdout}
+	if len(cleanable) == 0 {
+		if opts.Verbose && len(skipped) > 0 {
+			lw.Line(0, "%s", applySkip("skip:"))
+			for _, c := range skipped {
+				lw.Line(1, "%s", c.displayName())
+				if c.Note != "" {
+					lw.Line(2, "note: %s", formatNote(c.Note))
+				}
+				if c.Description != "" {
+					lw.Line(2, "description: %s", formatDescription(c.Description))
+				}
+				if c.CleanReason != "" {
+					lw.Line(2, "%s %s", applySuccess("✓"), c.CleanReason)
+				}
+				lw.Line(2, "%s %s", applyFailure("✗"), c.SkipReason.Format(r.candidateTarget(c)))
+				if (c.SkipReason == SkipHasChanges || c.SkipReason == SkipDirtySubmodule) &&
+					len(c.ChangedFiles) > 0 {
+					for _, f := range c.ChangedFiles {
+						lw.Line(3, "%s %s", f.Status, f.Path)
+					}
+				}
+				if c.Unpushed > 0 {
+					lw.Line(3, "%d commit(s) not pushed", c.Unpushed)
+				}
+				for _, line := range splitOutputLines(c.VerifyOutput) {
+					lw.Line(3, "%s", line)
+				}
+			}
+			fmt.Fprintln(&stdout)
+		}
+		fmt.Fprintln(&stdout, "No worktrees to clean")
+		return FormatResult{Stdout: stdout.String(), Stderr: stderr.String()}
+	}
+
+	// Output cleanable candidates with group header and reasons
+	lw.Line(0, "%s", applyClean("clean:"))
+	for _, c := range cleanable {
+		reason := string(c.CleanReason)
+		if c.Prunable {
+			reason = "prunable, " + reason
+		}
+		if c.StaleOverride {
+			reason += ", stale"
+		}
+		if len(r.Targets) > 1 && (c.CleanReason == CleanMerged || c.CleanReason == CleanSquashMerged) {
+			reason += " into " + c.Target
+		}
+		lw.Line(1, "%s %s", c.displayName(), applyReason("("+reason+")"))
+		if c.Note != "" {
+			lw.Line(2, "note: %s", formatNote(c.Note))
+		}
+		if c.Description != "" {
+			lw.Line(2, "description: %s", formatDescription(c.Description))
+		}
+	}
+
+	// Output skipped candidates with group header (verbose only)
+	if opts.Verbose && len(skipped) > 0 {
+		fmt.Fprintln(&stdout)
+		lw.Line(0, "%s", applySkip("skip:"))
+		for _, c := range skipped {
+			lw.Line(1, "%s", c.displayName())
+			if c.Note != "" {
+				lw.Line(2, "note: %s", formatNote(c.Note))
+			}
+			if c.Description != "" {
+				lw.Line(2, "description: %s", formatDescription(c.Description))
+			}
+			if c.CleanReason != "" {
+				lw.Line(2, "%s %s", applySuccess("✓"), c.CleanReason)
+			}
+			lw.Line(2, "%s %s", applyFailure("✗"), c.SkipReason.Format(r.candidateTarget(c)))
+			if (c.SkipReason == SkipHasChanges || c.SkipReason == SkipDirtySubmodule) &&
+				len(c.ChangedFiles) > 0 {
+				for _, f := range c.ChangedFiles {
+					lw.Line(3, "%s %s", f.Status, f.Path)
+				}
+			}
+			if c.Unpushed > 0 {
+				lw.Line(3, "%d commit(s) not pushed", c.Unpushed)
+			}
+			for _, line := range splitOutputLines(c.VerifyOutput) {
+				lw.Line(3, "%s", line)
+			}
+		}
+	}
+
+	return FormatResult{Stdout: stdout.String(), Stderr: stderr.String()}
+}
+
+// formatQuiet outputs one line per removed worktree, or per cleanable
+// candidate before removal: the branch, or the path of detached
+// worktrees. Errors, warnings and archive paths go to stderr.
+func (r CleanResult) formatQuiet(opts CleanFormatOptions) FormatResult {
+	var stdout, stderr strings.Builder
+	if r.removalPhase() {
+		for _, wt := range r.Removed {
+			name := worktreeIdentifier(wt.Branch, wt.WorktreePath)
+			if wt.Err != nil {
+				fmt.Fprintf(&stderr, "%s %s: %v\n", format.Paint(opts.ColorEnabled, format.ColorError, "error:"), name, wt.Err)
+				writeGitErrorHint(&stderr, wt.Err)
+				continue
+			}
+			if wt.ArchivePath != "" {
+				fmt.Fprintf(&stderr, "Archived uncommitted changes: %s\n", wt.ArchivePath)
+			}
+			fmt.Fprintln(&stdout, name)
+		}
+		writeWarnings(&stderr, r.Warnings())
+		return FormatResult{Stdout: stdout.String(), Stderr: stderr.String()}
+	}
+
+	writeWarnings(&stderr, r.Warnings())
+	for _, c := range r.Candidates {
+		if !c.Skipped {
+			fmt.Fprintln(&stdout, c.displayName())
+		}
+	}
+	return FormatResult{Stdout: stdout.String(), Stderr: stderr.String()}
+}
+
+// Porcelain actions for clean candidates.
+const (
+	cleanActionRemove = "remove"
+	cleanActionSkip   = "skip"
+)
+
+// formatPorcelain outputs one record per candidate. Warnings still go to
+// stderr.
+func (r CleanResult) formatPorcelain() FormatResult {
+	var stdout, stderr strings.Builder
+	writeWarnings(&stderr, r.Warnings())
+	for _, c := range r.Candidates {
+		action, reason := cleanActionRemove, c.CleanReason.Code()
+		if c.Skipped {
+			action, reason = cleanActionSkip, c.SkipReason.Code()
+		}
+		fmt.Fprintf(&stdout, "%s\t%s\t%s\t%s\n", c.Branch, c.WorktreePath, action, reason)
+	}
+	return FormatResult{Stdout: stdout.String(), Stderr: stderr.String()}
+}
+
+// Run analyzes worktrees and optionally removes them.
+// cwd is the current working directory (absolute path) passed from CLI layer.
+func (c *CleanCommand) Run(ctx context.Context, cwd string, opts CleanOptions) (CleanResult, error) {
+	c.Log.DebugContext(ctx, "run started",
+		LogAttrKeyCategory.String(), LogCategoryClean,
+		"check", opts.Check,
+		"force", opts.Force,
+		"targets", opts.Targets)
+
+	var result CleanResult
+	result.Check = opts.Check
+
+	for _, p := range opts.Exclude {
+		if _, err := path.Match(p, ""); err != nil {
+			return result, fmt.Errorf("invalid exclude pattern %q: %w", p, err)
+		}
+	}
+
+	worktrees, err := c.Git.WorktreeList(ctx)
+	if err != nil {
+		return result, fmt.Errorf("failed to list worktrees: %w", err)
+	}
+
+	// Resolve target branches
+	targets := uniqueTargets(opts.Targets)
+	if len(targets) == 0 {
+		target, err := c.resolveTarget("", worktrees)
+		if err != nil {
+			return result, err
+		}
+		targets = []string{target}
+	}
+	result.TargetBranch = targets[0]
+	result.Targets = targets
+
+	c.Log.DebugContext(ctx, "target resolved",
+		LogAttrKeyCategory.String(), LogCategoryClean,
+		"targets", targets)
+
+	// Fetching and classifying every branch is slow in large repositories
+	// and pointless without a worktree to check
+	if !hasCleanCandidates(worktrees, opts.Worktrees) {
+		c.Log.DebugContext(ctx, "no worktrees to check",
+			LogAttrKeyCategory.String(), LogCategoryClean,
+			"count", len(worktrees))
+		return result, nil
+	}
+
+	// Refresh remote-tracking branches so gone upstreams are detected.
+	// Failures only warn; the local view is still usable.
+	if opts.Fetch {
+		result.FetchErrs = c.fetchRemotes(ctx)
+	}
+
+	// Branch tracking state for all branches in one pass. Fetching does
+	// not change the worktrees, so the list above is still current.
+	branches, err := c.Git.BranchStatuses(ctx)
+	if err != nil {
+		return result, err
+	}
+
+	c.Log.DebugContext(ctx, "worktrees listed",
+		LogAttrKeyCategory.String(), LogCategoryClean,
+		"count", len(worktrees),
+		"branches", len(branches))
+
+	// Pre-fetch branch merge status to avoid redundant git branch --merged calls
+	mergeStatuses := make(map[string]BranchMergeStatus, len(targets))
+	for _, target := range targets {
+		mergeStatus, err := c.Git.ClassifyBranchMergeStatusWith(ctx, target, branches)
+		if err != nil {
+			c.Log.DebugContext(ctx, "failed to classify branch merge status",
+				LogAttrKeyCategory.String(), LogCategoryClean,
+				"target", target,
+				"error", err.Error())
+			// Continue without cache - Check() will fall back to individual calls
+			mergeStatus = BranchMergeStatus{}
+		} else {
+			c.Log.DebugContext(ctx, "branch merge status classified",
+				LogAttrKeyCategory.String(), LogCategoryClean,
+				"target", target,
+				"mergedCount", len(mergeStatus.Merged),
+				"sameCommitCount", len(mergeStatus.SameCommit))
+		}
+		mergeStatuses[target] = mergeStatus
+	}
+
+	// RemoveCommand is used for both Check and Run
+	removeCmd := &RemoveCommand{
+		FS:     c.FS,
+		Git:    c.Git,
+		Config: c.Config,
+		Log:    c.Log,
+
+		squashErrs: &squashErrors{},
+	}
+
+	// Analyze each worktree using RemoveCommand.Check (parallel execution)
+	type indexedCandidate struct {
+		index     int
+		candidate CleanCandidate
+	}
+
+	var (
+		wg         sync.WaitGroup
+		mu         sync.Mutex
+		candidates []indexedCandidate
+	)
+
+	candidateIndex := 0
+	for i, wt := range worktrees {
+		// Skip main worktree (first non-bare worktree)
+		if i == 0 || wt.Bare {
+			continue
+		}
+		if len(opts.Worktrees) > 0 && !slices.Contains(opts.Worktrees, wt.Path) {
+			continue
+		}
+
+		// A jj bookmark only names a worktree git reports as detached, so
+		// it is cleaned as detached and the bookmark is never deleted
+		if wt.Bookmark {
+			wt.Branch, wt.Detached, wt.Bookmark = "", true, false
+		}
+
+		// Handle detached HEAD worktrees directly (they have no branch name)
+		if wt.Detached || wt.Branch == "" {
+			if opts.Detached {
+				// checkDetached runs git status, so check in parallel too
+				wg.Add(1)
+				go func(idx int, wt Worktree) {
+					defer wg.Done()
+					candidate := c.checkDetached(ctx, wt, cwd, opts.Force)
+					mu.Lock()
+					candidates = append(candidates, indexedCandidate{index: idx, candidate: candidate})
+					mu.Unlock()
+				}(candidateIndex, wt)
+				candidateIndex++
+				continue
+			}
+			c.Log.DebugContext(ctx, "skipping detached worktree",
+				LogAttrKeyCategory.String(), LogCategoryClean,
+				"path", wt.Path)
+			mu.Lock()
+			candidates = append(candidates, indexedCandidate{
+				index: candidateIndex,
+				candidate: CleanCandidate{
+					Branch:       wt.Branch,
+					WorktreePath: wt.Path,
+					HEAD:         wt.HEAD,
+					Prunable:     wt.Prunable,
+					Skipped:      true,
+					SkipReason:   SkipDetached,
+					Detached:     true,
+				},
+			})
+			mu.Unlock()
+			candidateIndex++
+			continue
+		}
+
+		// Excluded branches are never offered, so there is nothing to check
+		if c.Config.IsCleanExcluded(wt.Branch) || matchBranchPatterns(opts.Exclude, wt.Branch) {
+			c.Log.DebugContext(ctx, "skipping excluded branch",
+				LogAttrKeyCategory.String(), LogCategoryClean,
+				"branch", wt.Branch)
+			mu.Lock()
+			candidates = append(candidates, indexedCandidate{
+				index: candidateIndex,
+				candidate: CleanCandidate{
+					Branch:       wt.Branch,
+					WorktreePath: wt.Path,
+					HEAD:         wt.HEAD,
+					Prunable:     wt.Prunable,
+					Skipped:      true,
+					SkipReason:   SkipExcluded,
+				},
+			})
+			mu.Unlock()
+			candidateIndex++
+			continue
+		}
+
+		// Launch parallel check.
+		// Each Check() runs git status which is slow for large repos.
+		// Parallelizing gives ~3x speedup.
+		wg.Add(1)
+		go func(idx int, wt Worktree) {
+			defer wg.Done()
+
+			c.Log.DebugContext(ctx, "checking worktree",
+				LogAttrKeyCategory.String(), LogCategoryClean,
+				"branch", wt.Branch)
+
+			target := c.selectTarget(ctx, removeCmd, wt.Branch, targets, mergeStatuses)
+			checkResult, err := removeCmd.Check(ctx, wt.Branch, CheckOptions{
+				Force:        opts.Force,
+				Target:       target,
+				Cwd:          cwd,
+				WorktreeInfo: &wt,
+				MergeStatus:  mergeStatuses[target],
+			})
+			if err != nil {
+				c.Log.DebugContext(ctx, "check failed",
+					LogAttrKeyCategory.String(), LogCategoryClean,
+					"branch", wt.Branch,
+					"error", err.Error())
+				// Skip worktrees that fail to check (e.g., not in any worktree)
+				return
+			}
+
+			candidate := CleanCandidate{
+				Branch:       wt.Branch,
+				WorktreePath: checkResult.WorktreePath,
+				HEAD:         wt.HEAD,
+				Prunable:     checkResult.Prunable,
+				Skipped:      !checkResult.CanRemove,
+				SkipReason:   checkResult.SkipReason,
+				CleanReason:  checkResult.CleanReason,
+				ChangedFiles: checkResult.ChangedFiles,
+				Target:       target,
+			}
+
+			c.Log.DebugContext(ctx, "check completed",
+				LogAttrKeyCategory.String(), LogCategoryClean,
+				"branch", wt.Branch,
+				"canRemove", checkResult.CanRemove,
+				"reason", string(checkResult.CleanReason),
+				"skipReason", string(checkResult.SkipReason))
+
+			mu.Lock()
+			candidates = append(candidates, indexedCandidate{index: idx, candidate: candidate})
+			mu.Unlock()
+		}(candidateIndex, wt)
+		candidateIndex++
+	}
+
+	wg.Wait()
+	result.SquashErrs = removeCmd.squashErrs.all()
+
+	// Sort candidates by original index to maintain consistent ordering
+	slices.SortFunc(candidates, func(a, b indexedCandidate) int {
+		return a.index - b.index
+	})
+
+	// Extract candidates in order, with their notes and descriptions as
+	// context
+	notes := noteTexts(ctx, NewNoteStore(c.FS, c.Git), c.Log)
+	descriptions := branchDescriptions(ctx, c.Git, c.Log)
+	for _, ic := range candidates {
+		ic.candidate.Note = notes[ic.candidate.Branch]
+		ic.candidate.Description = descriptions[ic.candidate.Branch]
+		result.Candidates = append(result.Candidates, ic.candidate)
+	}
+
+	// Mark branches whose PR was merged or closed on the forge
+	if c.Forge != nil {
+		result.ForgeErr = c.applyPRStates(ctx, result.Candidates)
+	}
+
+	// Apply stale override: bypass changes check for merged/upstream-gone branches
+	if opts.Stale {
+		for i := range result.Candidates {
+			cand := &result.Candidates[i]
+			if !cand.Skipped || cand.CleanReason == "" {
+				continue
+			}
+			if cand.SkipReason == SkipHasChanges || cand.SkipReason == SkipDirtySubmodule {
+				cand.Skipped = false
+				cand.StaleOverride = true
+				c.Log.DebugContext(ctx, "stale override applied",
+					LogAttrKeyCategory.String(), LogCategoryClean,
+					"branch", cand.Branch,
+					"skipReason", string(cand.SkipReason),
+					"cleanReason", string(cand.CleanReason))
+			}
+		}
+	}
+
+	if c.Config.ShouldCleanOnlyTwigManaged() {
+		c.checkManaged(ctx, result.Candidates)
+	}
+
+	// Merged into the target does not mean backed up, e.g. after a local
+	// rebase or merge that was never pushed
+	if opts.Force < WorktreeForceLevelUnclean {
+		c.checkUnpushed(ctx, result.Candidates, branches)
+	}
+
+	// Out-of-git state is checked last, only for worktrees still to be removed
+	if c.Config.CleanVerifyCommand != "" {
+		c.verifyCandidates(ctx, result.Candidates)
+	}
+
+	// If check mode, just return candidates (no execution)
+	if result.Check {
+		c.Log.DebugContext(ctx, "run completed (check mode)",
+			LogAttrKeyCategory.String(), LogCategoryClean,
+			"candidates", len(result.Candidates))
+		return result, nil
+	}
+
+	// Execute removal for cleanable candidates (parallel execution)
+	// Pass the same force level since RemoveCommand.Check already validated conditions
+	type indexedRemoved struct {
+		index int
+		wt    RemovedWorktree
+		audit AuditEntry
+	}
+
+	var (
+		removeWg      sync.WaitGroup
+		removeMu      sync.Mutex
+		removedResult []indexedRemoved
+	)
+
+	removeIndex := 0
+	for _, candidate := range result.Candidates {
+		if candidate.Skipped {
+			continue
+		}
+
+		removeWg.Add(1)
+		go func(idx int, candidate CleanCandidate) {
+			defer removeWg.Done()
+
+			c.Log.DebugContext(ctx, "removing worktree",
+				LogAttrKeyCategory.String(), LogCategoryClean,
+				"branch", candidate.Branch)
+
+			effectiveForce := opts.Force
+			if candidate.StaleOverride && effectiveForce < WorktreeForceLevelUnclean {
+				effectiveForce = WorktreeForceLevelUnclean
+			}
+
+			// Measure size before removal; the directory is gone afterwards
+			var size int64
+			if !candidate.Prunable {
+				size = dirSize(c.FS, candidate.WorktreePath)
+			}
+			var audit AuditEntry
+			if c.Audit != nil {
+				audit = AuditEntry{
+					Command:      AuditCommandClean,
+					Branch:       candidate.Branch,
+					WorktreePath: candidate.WorktreePath,
+					HEAD:         candidate.HEAD,
+					Reason:       string(candidate.CleanReason),
+					Target:       candidate.Target,
+					Force:        int(opts.Force),
+					Stale:        candidate.StaleOverride,
+					SizeBytes:    size,
+				}
+			}
+
+			var wt RemovedWorktree
+			var err error
+			if candidate.Detached {
+				wt, err = c.removeDetached(ctx, candidate, effectiveForce, opts)
+			} else {
+				wt, err = removeCmd.Run(ctx, candidate.Branch, cwd, RemoveOptions{
+					Force:             effectiveForce,
+					Check:             false,
+					Target:            candidate.Target,
+					ForceDeleteBranch: candidate.CleanReason.IsPR(),
+					KeepEmptyDirs:     opts.KeepEmptyDirs,
+					Archive:           opts.Archive,
+					ArchiveDir:        opts.ArchiveDir,
+				})
+			}
+			if err != nil {
+				c.Log.DebugContext(ctx, "removal failed",
+					LogAttrKeyCategory.String(), LogCategoryClean,
+					"branch", candidate.Branch,
+					"error", err.Error())
+				wt.Branch = candidate.Branch
+				wt.Err = err
+			}
+			wt.SizeBytes = size
+
+			audit.Time = time.Now()
+			audit.Pruned = wt.Pruned
+
+			removeMu.Lock()
+			removedResult = append(removedResult, indexedRemoved{index: idx, wt: wt, audit: audit})
+			removeMu.Unlock()
+		}(removeIndex, candidate)
+		removeIndex++
+	}
+
+	removeWg.Wait()
+
+	// Sort by original index to maintain consistent ordering
+	slices.SortFunc(removedResult, func(a, b indexedRemoved) int {
+		return a.index - b.index
+	})
+
+	// Extract results in order and track prunable branches
+	var auditEntries []AuditEntry
+	for i := range removedResult {
+		result.Removed = append(result.Removed, removedResult[i].wt)
+		if removedResult[i].wt.Pruned {
+			result.Pruned = true
+		}
+		if removedResult[i].wt.Err == nil {
+			auditEntries = append(auditEntries, removedResult[i].audit)
+		}
+	}
+
+	// Record actual removals; a logging failure must not fail the clean
+	if c.Audit != nil && len(auditEntries) > 0 {
+		user := currentUser()
+		for i := range auditEntries {
+			auditEntries[i].User = user
+		}
+		if err := c.Audit.Append(ctx, auditEntries...); err != nil {
+			c.Log.DebugContext(ctx, "audit log append failed",
+				LogAttrKeyCategory.String(), LogCategoryClean,
+				"error", err.Error())
+			result.AuditErr = err
+		}
+	}
+
+	c.Log.DebugContext(ctx, "run completed",
+		LogAttrKeyCategory.String(), LogCategoryClean,
+		"removed", len(result.Removed))
+
+	return result, nil
+}
+
+// checkDetached returns the candidate for a detached HEAD worktree with
+// --detached. The safety checks of RemoveCommand apply, except that there
+// is no branch to be merged.
+func (c *CleanCommand) checkDetached(ctx context.Context, wt Worktree, cwd string, force WorktreeForceLevel) CleanCandidate {
+	candidate := CleanCandidate{
+		WorktreePath: wt.Path,
+		HEAD:         wt.HEAD,
+		Prunable:     wt.Prunable,
+		CleanReason:  CleanDetached,
+		Detached:     true,
+	}
+	skip := func(reason SkipReason) CleanCandidate {
+		candidate.Skipped = true
+		candidate.SkipReason = reason
+		c.Log.DebugContext(ctx, "detached worktree skipped",
+			LogAttrKeyCategory.String(), LogCategoryClean,
+			"path", wt.Path,
+			"skipReason", string(reason))
+		return candidate
+	}
+
+	if wt.Prunable {
+		return candidate
+	}
+	if root, err := c.Git.InDir(cwd).WorktreeRoot(ctx); err == nil && root == wt.Path {
+		return skip(SkipCurrentDir)
+	}
+	if wt.Locked && force < WorktreeForceLevelLocked {
+		return skip(SkipLocked)
+	}
+	if force < WorktreeForceLevelUnclean {
+		wtGit := c.Git.InDir(wt.Path)
+		if status, err := wtGit.CheckSubmoduleCleanStatus(ctx); err == nil && status == SubmoduleCleanStatusDirty {
+			return skip(SkipDirtySubmodule)
+		}
+		changed, err := wtGit.ChangedFiles(ctx)
+		if err != nil {
+			c.Log.DebugContext(ctx, "failed to check uncommitted changes",
+				LogAttrKeyCategory.String(), LogCategoryClean,
+				"path", wt.Path,
+				"error", err.Error())
+			return skip(SkipHasChanges)
+		}
+		if len(changed) > 0 {
+			candidate.ChangedFiles = changed
+			return skip(SkipHasChanges)
+		}
+	}
+	return candidate
+}
+
+// removeDetached removes a detached HEAD worktree. There is no branch to
+// delete, so only the worktree (or its stale record) is removed.
+func (c *CleanCommand) removeDetached(ctx context.Context, candidate CleanCandidate, force WorktreeForceLevel, opts CleanOptions) (RemovedWorktree, error) {
+	result := RemovedWorktree{WorktreePath: candidate.WorktreePath, Pruned: candidate.Prunable}
+
+	lockWaiter := NewGitLockWaiter(c.FS, c.Git, c.Config.GitLockWaitDuration(), c.Log)
+	if err := lockWaiter.WaitFor(ctx, candidate.WorktreePath); err != nil {
+		return result, err
+	}
+
+	if candidate.Prunable {
+		if _, err := c.Git.WorktreePrune(ctx); err != nil {
+			return result, fmt.Errorf("failed to prune worktrees: %w", err)
+		}
+		return result, nil
+	}
+
+	// Detached worktrees are named after their directory in the archive
+	if opts.Archive {
+		dir := opts.ArchiveDir
+		if dir == "" {
+			dir = c.Config.ArchiveDir
+		}
+		archivePath, err := NewWorktreeArchiver(c.FS, c.Git, dir, c.Log).
+			Archive(ctx, candidate.WorktreePath, filepath.Base(candidate.WorktreePath))
+		if err != nil {
+			return result, fmt.Errorf("failed to archive uncommitted changes: %w", err)
+		}
+		result.ArchivePath = archivePath
+	}
+
+	// Clean submodules still require force for git worktree remove
+	if status, err := c.Git.InDir(candidate.WorktreePath).CheckSubmoduleCleanStatus(ctx); err == nil &&
+		status == SubmoduleCleanStatusClean && force < WorktreeForceLevelUnclean {
+		force = WorktreeForceLevelUnclean
+	}
+	var wtOpts []WorktreeRemoveOption
+	if force > WorktreeForceLevelNone {
+		wtOpts = append(wtOpts, WithForceRemove(force))
+	}
+	out, err := c.Git.WorktreeRemove(ctx, candidate.WorktreePath, wtOpts...)
+	if err != nil {
+		return result, err
+	}
+	result.GitOutput = out
+	if opts.KeepEmptyDirs {
+		result.KeptDirs = emptyParentDirs(c.FS, c.Config.WorktreeDestBaseDir, candidate.WorktreePath)
+	} else {
+		result.CleanedDirs = removeEmptyParentDirs(ctx, c.FS, c.Config.WorktreeDestBaseDir, candidate.WorktreePath, c.Log, LogCategoryClean)
+	}
+	return result, nil
+}
+
+// applyPRStates looks up the PR of each candidate that local merge
+// detection did not find cleanable (e.g. rebase-merged branches) and
+// sets its CleanReason when the PR was merged or closed. Not-merged
+// candidates become cleanable; candidates with changes only gain the
+// reason, so --stale still decides whether they are removed.
+func (c *CleanCommand) applyPRStates(ctx context.Context, candidates []CleanCandidate) error {
+	heads := make(map[string]string)
+	for _, cand := range candidates {
+		if !cand.Skipped || cand.CleanReason != "" || cand.HEAD == "" {
+			continue
+		}
+		switch cand.SkipReason {
+		case SkipNotMerged, SkipHasChanges, SkipDirtySubmodule:
+			heads[cand.Branch] = cand.HEAD
+		}
+	}
+	if len(heads) == 0 {
+		return nil
+	}
+
+	states, err := c.Forge.PRStates(ctx, heads, time.Now())
+	for i := range candidates {
+		cand := &candidates[i]
+		if _, ok := heads[cand.Branch]; !ok {
+			continue
+		}
+		switch states[cand.Branch] {
+		case PRStateMerged:
+			cand.CleanReason = CleanPRMerged
+		case PRStateClosed:
+			cand.CleanReason = CleanPRClosed
+		default:
+			continue
+		}
+		if cand.SkipReason == SkipNotMerged {
+			cand.Skipped = false
+			cand.SkipReason = ""
+		}
+		c.Log.DebugContext(ctx, "PR state applied",
+			LogAttrKeyCategory.String(), LogCategoryClean,
+			"branch", cand.Branch,
+			"cleanReason", string(cand.CleanReason),
+			"skipped", cand.Skipped)
+	}
+	return err
+}
+
+// selectTarget returns the target whose merge status decides whether
+// branch is cleanable: the first target at the same commit as branch,
+// which keeps a fresh branch from being cleaned; otherwise the first
+// target branch was merged or squash-merged into; otherwise the first
+// target.
+func (c *CleanCommand) selectTarget(ctx context.Context, removeCmd *RemoveCommand, branch string, targets []string, mergeStatuses map[string]BranchMergeStatus) string {
+	if len(targets) == 1 {
+		return targets[0]
+	}
+	for _, target := range targets {
+		if mergeStatuses[target].SameCommit[branch] {
+			return target
+		}
+	}
+	for _, target := range targets {
+		status := mergeStatuses[target]
+		if len(status.Merged) > 0 || len(status.SameCommit) > 0 {
+			if status.Merged[branch] {
+				return target
+			}
+			continue
+		}
+		// No cached status for this target
+		if merged, err := c.Git.IsBranchMerged(ctx, branch, target); err == nil && merged {
+			return target
+		}
+	}
+	for _, target := range targets {
+		if removeCmd.isSquashMerged(ctx, branch, target) {
+			return target
+		}
+	}
+	return targets[0]
+}
+
+// uniqueTargets returns targets without empty and duplicate entries.
+func uniqueTargets(targets []string) []string {
+	var unique []string
+	for _, t := range targets {
+		if t != "" && !slices.Contains(unique, t) {
+			unique = append(unique, t)
+		}
+	}
+	return unique
+}
+
+// candidateTarget returns the target the candidate was checked against.
+func (r CleanResult) candidateTarget(c CleanCandidate) string {
+	if c.Target != "" {
+		return c.Target
+	}
+	return r.TargetBranch
+}
+
+// hasCleanCandidates reports whether any worktree is a candidate that Run
+// would check: a linked, non-bare worktree, in only if set.
+func hasCleanCandidates(worktrees []Worktree, only []string) bool {
+	for i, wt := range worktrees {
+		if i == 0 || wt.Bare {
+			continue
+		}
+		if len(only) == 0 || slices.Contains(only, wt.Path) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveTarget resolves the target branch for merge checking.
+// If target is specified, use it. Otherwise, auto-detect from first non-bare worktree.
+func (c *CleanCommand) resolveTarget(target string, worktrees []Worktree) (string, error) {
+	if target != "" {
+		return target, nil
+	}
+
+	// Find first non-bare worktree (usually main)
+	for _, wt := range worktrees {
+		if !wt.Bare && wt.Branch != "" {
+			return wt.Branch, nil
+		}
+	}
+
+	return "", fmt.Errorf("no target branch found")
+}
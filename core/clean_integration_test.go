@@ -1,11 +1,12 @@
 //go:build integration
 
-package twig
+package core
 
 import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 
@@ -308,6 +309,122 @@ func TestCleanCommand_Integration(t *testing.T) {
 		}
 	})
 
+	t.Run("SkipsFailedVerification", func(t *testing.T) {
+		t.Parallel()
+
+		repoDir, mainDir := testutil.SetupTestRepo(t)
+
+		for _, name := range []string{"done", "keep"} {
+			wtPath := filepath.Join(repoDir, "feature", name)
+			testutil.RunGit(t, mainDir, "worktree", "add", "-b", "feature/"+name, wtPath)
+			if err := os.WriteFile(filepath.Join(wtPath, name+".txt"), []byte("test"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			testutil.RunGit(t, wtPath, "add", ".")
+			testutil.RunGit(t, wtPath, "commit", "-m", "test commit")
+		}
+		testutil.RunGit(t, mainDir, "merge", "--no-ff", "-m", "Merge feature branches", "feature/done", "feature/keep")
+
+		cfgResult, err := LoadConfig(mainDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cfg := cfgResult.Config
+		cfg.CleanVerifyCommand = `test -d {path} && test "$TWIG_BRANCH" != feature/keep || { echo "dump not uploaded"; exit 1; }`
+
+		cmd := &CleanCommand{
+			FS:     osFS{},
+			Git:    NewGitRunner(mainDir),
+			Config: cfg,
+			Log:    NewNopLogger(),
+		}
+
+		result, err := cmd.Run(t.Context(), mainDir, CleanOptions{Yes: true})
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+
+		for _, c := range result.Candidates {
+			switch c.Branch {
+			case "feature/done":
+				if c.Skipped {
+					t.Errorf("feature/done skipped: %s", c.SkipReason)
+				}
+			case "feature/keep":
+				if c.SkipReason != SkipVerifyFailed || c.VerifyOutput != "dump not uploaded" {
+					t.Errorf("feature/keep = %+v, want skipped with %s", c, SkipVerifyFailed)
+				}
+			}
+		}
+		if _, err := os.Stat(filepath.Join(repoDir, "feature", "done")); !os.IsNotExist(err) {
+			t.Errorf("feature/done should be removed, stat err = %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(repoDir, "feature", "keep")); err != nil {
+			t.Errorf("feature/keep should be kept: %v", err)
+		}
+	})
+
+	t.Run("SkipsWorktreesNotManagedByTwig", func(t *testing.T) {
+		t.Parallel()
+
+		repoDir, mainDir := testutil.SetupTestRepo(t)
+
+		for _, name := range []string{"twig", "manual"} {
+			wtPath := filepath.Join(repoDir, "feature", name)
+			testutil.RunGit(t, mainDir, "worktree", "add", "-b", "feature/"+name, wtPath)
+			if err := os.WriteFile(filepath.Join(wtPath, name+".txt"), []byte("test"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			testutil.RunGit(t, wtPath, "add", ".")
+			testutil.RunGit(t, wtPath, "commit", "-m", "test commit")
+		}
+		testutil.RunGit(t, mainDir, "merge", "--no-ff", "-m", "Merge feature branches", "feature/twig", "feature/manual")
+		store := NewProvenanceStore(osFS{}, NewGitRunner(mainDir))
+		twigPath := filepath.Join(repoDir, "feature", "twig")
+		if err := store.Write(t.Context(), twigPath, Provenance{Command: ProvenanceCommandAdd}); err != nil {
+			t.Fatal(err)
+		}
+
+		cfgResult, err := LoadConfig(mainDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cfg := cfgResult.Config
+		enabled := true
+		cfg.CleanOnlyTwigManaged = &enabled
+
+		cmd := &CleanCommand{
+			FS:     osFS{},
+			Git:    NewGitRunner(mainDir),
+			Config: cfg,
+			Log:    NewNopLogger(),
+		}
+
+		result, err := cmd.Run(t.Context(), mainDir, CleanOptions{Yes: true})
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+
+		for _, c := range result.Candidates {
+			switch c.Branch {
+			case "feature/twig":
+				if c.Skipped {
+					t.Errorf("feature/twig skipped: %s", c.SkipReason)
+				}
+			case "feature/manual":
+				if c.SkipReason != SkipNotManaged {
+					t.Errorf("feature/manual = %+v, want skipped with %s", c, SkipNotManaged)
+				}
+			}
+		}
+		if _, err := os.Stat(twigPath); !os.IsNotExist(err) {
+			t.Errorf("feature/twig should be removed, stat err = %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(repoDir, "feature", "manual")); err != nil {
+			t.Errorf("feature/manual should be kept: %v", err)
+		}
+	})
+
 	t.Run("SkipsCurrentDirectory", func(t *testing.T) {
 		t.Parallel()
 
@@ -347,6 +464,58 @@ func TestCleanCommand_Integration(t *testing.T) {
 		}
 	})
 
+	t.Run("KeepsBookmarkWorktreesDetached", func(t *testing.T) {
+		t.Parallel()
+
+		repoDir, mainDir := testutil.SetupTestRepo(t)
+
+		// In a jj colocated repository the worktree is detached at its
+		// merged bookmark, which WorktreeList reports as its branch
+		wtPath := filepath.Join(repoDir, "feature", "bookmark")
+		testutil.RunGit(t, mainDir, "worktree", "add", "-b", "feature/bookmark", wtPath)
+		if err := os.WriteFile(filepath.Join(wtPath, "test.txt"), []byte("test"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		testutil.RunGit(t, wtPath, "add", "test.txt")
+		testutil.RunGit(t, wtPath, "commit", "-m", "test commit")
+		testutil.RunGit(t, mainDir, "merge", "--no-ff", "-m", "Merge feature/bookmark", "feature/bookmark")
+		testutil.RunGit(t, wtPath, "checkout", "--detach")
+		if err := os.Mkdir(filepath.Join(mainDir, jjDirName), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		cfgResult, err := LoadConfig(mainDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		cmd := &CleanCommand{
+			FS:     osFS{},
+			Git:    NewGitRunner(mainDir),
+			Config: cfgResult.Config,
+			Log:    NewNopLogger(),
+		}
+
+		result, err := cmd.Run(t.Context(), mainDir, CleanOptions{Yes: true})
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+
+		if len(result.Candidates) != 1 || !result.Candidates[0].Detached || result.Candidates[0].SkipReason != SkipDetached {
+			t.Errorf("candidates = %+v, want the worktree skipped as detached", result.Candidates)
+		}
+		if len(result.Removed) != 0 {
+			t.Errorf("removed = %+v, want none", result.Removed)
+		}
+		if _, err := os.Stat(wtPath); err != nil {
+			t.Errorf("worktree should be kept: %v", err)
+		}
+		out := testutil.RunGit(t, mainDir, "branch", "--list", "feature/bookmark")
+		if strings.TrimSpace(out) == "" {
+			t.Error("bookmark should be kept")
+		}
+	})
+
 	t.Run("ExecutesWithYesFlag", func(t *testing.T) {
 		t.Parallel()
 
@@ -453,7 +622,7 @@ func TestCleanCommand_Integration(t *testing.T) {
 		}
 
 		// Check against develop (should find feature as merged)
-		result, err := cmd.Run(t.Context(), mainDir, CleanOptions{Target: "develop"})
+		result, err := cmd.Run(t.Context(), mainDir, CleanOptions{Targets: []string{"develop"}})
 		if err != nil {
 			t.Fatalf("Run failed: %v", err)
 		}
@@ -471,6 +640,70 @@ func TestCleanCommand_Integration(t *testing.T) {
 		}
 	})
 
+	t.Run("MergedIntoAnyOfMultipleTargets", func(t *testing.T) {
+		t.Parallel()
+
+		repoDir, mainDir := testutil.SetupTestRepo(t)
+
+		// hotfix/a is merged into release/2.4 only, never into main
+		releasePath := filepath.Join(repoDir, "release")
+		testutil.RunGit(t, mainDir, "worktree", "add", "-b", "release/2.4", releasePath)
+		hotfixPath := filepath.Join(repoDir, "hotfix", "a")
+		testutil.RunGit(t, releasePath, "worktree", "add", "-b", "hotfix/a", hotfixPath)
+		if err := os.WriteFile(filepath.Join(hotfixPath, "fix.txt"), []byte("fix"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		testutil.RunGit(t, hotfixPath, "add", "fix.txt")
+		testutil.RunGit(t, hotfixPath, "commit", "-m", "hotfix")
+		testutil.RunGit(t, releasePath, "merge", "--no-ff", "-m", "Merge hotfix/a", "hotfix/a")
+
+		cfgResult, err := LoadConfig(mainDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		cmd := &CleanCommand{
+			FS:     osFS{},
+			Git:    NewGitRunner(mainDir),
+			Config: cfgResult.Config,
+			Log:    NewNopLogger(),
+		}
+
+		hotfix := func(result CleanResult) CleanCandidate {
+			t.Helper()
+			for _, c := range result.Candidates {
+				if c.Branch == "hotfix/a" {
+					return c
+				}
+			}
+			t.Fatal("hotfix/a is not a candidate")
+			return CleanCandidate{}
+		}
+
+		result, err := cmd.Run(t.Context(), mainDir, CleanOptions{Check: true, Targets: []string{"main"}})
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		if c := hotfix(result); !c.Skipped {
+			t.Error("hotfix/a should be skipped when checked against main only")
+		}
+
+		result, err = cmd.Run(t.Context(), mainDir, CleanOptions{Check: true, Targets: []string{"main", "release/2.4"}})
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		c := hotfix(result)
+		if c.Skipped || c.CleanReason != CleanMerged || c.Target != "release/2.4" {
+			t.Errorf("hotfix/a = %+v, want merged into release/2.4", c)
+		}
+		if !slices.Equal(result.Targets, []string{"main", "release/2.4"}) {
+			t.Errorf("Targets = %v", result.Targets)
+		}
+		if out := result.Format(CleanFormatOptions{}).Stdout; !strings.Contains(out, "hotfix/a (merged into release/2.4)") {
+			t.Errorf("output = %q, want the matching target", out)
+		}
+	})
+
 	t.Run("AutoDetectsTarget", func(t *testing.T) {
 		t.Parallel()
 
@@ -862,6 +1095,59 @@ func TestCleanCommand_Integration(t *testing.T) {
 		}
 	})
 
+	t.Run("SkipsUnpushedCommits", func(t *testing.T) {
+		t.Parallel()
+
+		repoDir, mainDir := testutil.SetupTestRepo(t)
+
+		remoteDir := filepath.Join(repoDir, "remote.git")
+		testutil.RunGit(t, repoDir, "init", "--bare", remoteDir)
+		testutil.RunGit(t, mainDir, "remote", "add", "origin", remoteDir)
+		testutil.RunGit(t, mainDir, "push", "-u", "origin", "main")
+
+		for _, name := range []string{"pushed", "local"} {
+			wtPath := filepath.Join(repoDir, "feature", name)
+			testutil.RunGit(t, mainDir, "worktree", "add", "-b", "feature/"+name, wtPath)
+			if err := os.WriteFile(filepath.Join(wtPath, name+".txt"), []byte("test"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			testutil.RunGit(t, wtPath, "add", ".")
+			testutil.RunGit(t, wtPath, "commit", "-m", "add "+name)
+		}
+		testutil.RunGit(t, filepath.Join(repoDir, "feature", "pushed"), "push", "-u", "origin", "feature/pushed")
+
+		// Both are merged locally, but main is not pushed
+		testutil.RunGit(t, mainDir, "merge", "--no-ff", "-m", "Merge feature branches", "feature/pushed", "feature/local")
+
+		cfgResult, err := LoadConfig(mainDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		cmd := &CleanCommand{
+			FS:     osFS{},
+			Git:    NewGitRunner(mainDir),
+			Config: cfgResult.Config,
+			Log:    NewNopLogger(),
+		}
+
+		for _, force := range []WorktreeForceLevel{WorktreeForceLevelNone, WorktreeForceLevelUnclean} {
+			result, err := cmd.Run(t.Context(), mainDir, CleanOptions{Check: true, Force: force})
+			if err != nil {
+				t.Fatalf("Run failed: %v", err)
+			}
+			for _, c := range result.Candidates {
+				wantSkipped := c.Branch == "feature/local" && force == WorktreeForceLevelNone
+				if c.Skipped != wantSkipped {
+					t.Errorf("force %d: %s Skipped = %v (%s), want %v", force, c.Branch, c.Skipped, c.SkipReason, wantSkipped)
+				}
+				if wantSkipped && (c.SkipReason != SkipUnpushedCommits || c.Unpushed != 1) {
+					t.Errorf("%s = %+v, want %s with 1 commit", c.Branch, c, SkipUnpushedCommits)
+				}
+			}
+		}
+	})
+
 	t.Run("DetectsSquashMergedBranches", func(t *testing.T) {
 		t.Parallel()
 
@@ -1404,6 +1690,60 @@ func TestCleanCommand_Integration(t *testing.T) {
 		}
 	})
 
+	t.Run("FetchDetectsUpstreamGone", func(t *testing.T) {
+		t.Parallel()
+
+		repoDir, mainDir := testutil.SetupTestRepo(t)
+
+		remoteDir := filepath.Join(repoDir, "remote.git")
+		testutil.RunGit(t, repoDir, "init", "--bare", remoteDir)
+		testutil.RunGit(t, mainDir, "remote", "add", "origin", remoteDir)
+		testutil.RunGit(t, mainDir, "push", "-u", "origin", "main")
+
+		wtPath := filepath.Join(repoDir, "feature", "fetch-gone")
+		testutil.RunGit(t, mainDir, "worktree", "add", "-b", "feature/fetch-gone", wtPath)
+		if err := os.WriteFile(filepath.Join(wtPath, "feature.txt"), []byte("feature"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		testutil.RunGit(t, wtPath, "add", "feature.txt")
+		testutil.RunGit(t, wtPath, "commit", "-m", "add feature")
+		testutil.RunGit(t, wtPath, "push", "-u", "origin", "feature/fetch-gone")
+
+		// Delete the branch on the remote only; the local
+		// remote-tracking ref stays until the next fetch --prune
+		testutil.RunGit(t, remoteDir, "branch", "-D", "feature/fetch-gone")
+
+		cfgResult, err := LoadConfig(mainDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cmd := &CleanCommand{
+			FS:     osFS{},
+			Git:    NewGitRunner(mainDir),
+			Config: cfgResult.Config,
+			Log:    NewNopLogger(),
+		}
+
+		result, err := cmd.Run(t.Context(), mainDir, CleanOptions{Check: true})
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		if !result.Candidates[0].Skipped {
+			t.Error("without fetch, the deleted upstream should not be detected")
+		}
+
+		result, err = cmd.Run(t.Context(), mainDir, CleanOptions{Check: true, Fetch: true})
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		if len(result.FetchErrs) > 0 {
+			t.Fatalf("FetchErrs = %v", result.FetchErrs)
+		}
+		if got := result.Candidates[0]; got.Skipped || got.CleanReason != CleanUpstreamGone {
+			t.Errorf("candidate = %+v, want cleanable with reason %q", got, CleanUpstreamGone)
+		}
+	})
+
 	t.Run("StaleDoesNotOverrideUnmergedWithChanges", func(t *testing.T) {
 		t.Parallel()
 
@@ -1664,4 +2004,167 @@ func TestCleanCommand_Integration(t *testing.T) {
 			t.Errorf("skip reason should be %q, got %q", SkipSameCommit, candidate.SkipReason)
 		}
 	})
+
+	t.Run("DetectSquashMergesWithoutRemote", func(t *testing.T) {
+		t.Parallel()
+
+		repoDir, mainDir := testutil.SetupTestRepo(t)
+
+		// Enable squash-merge detection
+		settings := fmt.Sprintf("worktree_destination_base_dir = %q\ndetect_squash_merges = true\n", repoDir)
+		if err := os.WriteFile(filepath.Join(mainDir, ".twig", "settings.toml"), []byte(settings), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		// Feature branch with multiple commits, squash merged locally (no remote)
+		wtPath := filepath.Join(repoDir, "feature", "local-squash")
+		testutil.RunGit(t, mainDir, "worktree", "add", "-b", "feature/local-squash", wtPath)
+		for i, name := range []string{"a.txt", "b.txt"} {
+			if err := os.WriteFile(filepath.Join(wtPath, name), []byte(name), 0644); err != nil {
+				t.Fatal(err)
+			}
+			testutil.RunGit(t, wtPath, "add", name)
+			testutil.RunGit(t, wtPath, "commit", "-m", fmt.Sprintf("commit %d", i))
+		}
+		testutil.RunGit(t, mainDir, "merge", "--squash", "feature/local-squash")
+		testutil.RunGit(t, mainDir, "commit", "-m", "feat: local squash")
+
+		cfgResult, err := LoadConfig(mainDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		cmd := &CleanCommand{
+			FS:     osFS{},
+			Git:    NewGitRunner(mainDir),
+			Config: cfgResult.Config,
+			Log:    NewNopLogger(),
+		}
+
+		result, err := cmd.Run(t.Context(), mainDir, CleanOptions{Check: true})
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		if len(result.Candidates) != 1 {
+			t.Fatalf("expected 1 candidate, got %d", len(result.Candidates))
+		}
+		candidate := result.Candidates[0]
+		if candidate.Skipped {
+			t.Fatalf("squash merged branch should not be skipped, reason: %s", candidate.SkipReason)
+		}
+		if candidate.CleanReason != CleanSquashMerged {
+			t.Errorf("CleanReason = %q, want %q", candidate.CleanReason, CleanSquashMerged)
+		}
+
+		// Execute removal: branch requires force delete since commits differ
+		result, err = cmd.Run(t.Context(), mainDir, CleanOptions{Yes: true})
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		for _, r := range result.Removed {
+			if r.Err != nil {
+				t.Errorf("removal error for %s: %v", r.Branch, r.Err)
+			}
+		}
+		out := testutil.RunGit(t, mainDir, "branch", "--list", "feature/local-squash")
+		if strings.TrimSpace(out) != "" {
+			t.Errorf("branch should be deleted, got: %s", out)
+		}
+	})
+
+	t.Run("SquashMergeNotDetectedWhenDisabled", func(t *testing.T) {
+		t.Parallel()
+
+		repoDir, mainDir := testutil.SetupTestRepo(t)
+
+		wtPath := filepath.Join(repoDir, "feature", "squash-off")
+		testutil.RunGit(t, mainDir, "worktree", "add", "-b", "feature/squash-off", wtPath)
+		if err := os.WriteFile(filepath.Join(wtPath, "a.txt"), []byte("a"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		testutil.RunGit(t, wtPath, "add", "a.txt")
+		testutil.RunGit(t, wtPath, "commit", "-m", "add a")
+		testutil.RunGit(t, mainDir, "merge", "--squash", "feature/squash-off")
+		testutil.RunGit(t, mainDir, "commit", "-m", "feat: squash off")
+
+		cfgResult, err := LoadConfig(mainDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		cmd := &CleanCommand{
+			FS:     osFS{},
+			Git:    NewGitRunner(mainDir),
+			Config: cfgResult.Config,
+			Log:    NewNopLogger(),
+		}
+
+		result, err := cmd.Run(t.Context(), mainDir, CleanOptions{Check: true})
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		if len(result.Candidates) != 1 || !result.Candidates[0].Skipped {
+			t.Errorf("squash merged branch should be skipped when detection is disabled")
+		}
+	})
+
+	t.Run("RecordsRemovalsInAuditLog", func(t *testing.T) {
+		t.Parallel()
+
+		repoDir, mainDir := testutil.SetupTestRepo(t)
+
+		wtPath := filepath.Join(repoDir, "feature", "audited")
+		testutil.RunGit(t, mainDir, "worktree", "add", "-b", "feature/audited", wtPath)
+
+		testFile := filepath.Join(wtPath, "test.txt")
+		if err := os.WriteFile(testFile, []byte("audited content"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		testutil.RunGit(t, wtPath, "add", "test.txt")
+		testutil.RunGit(t, wtPath, "commit", "-m", "test commit")
+		head := strings.TrimSpace(testutil.RunGit(t, wtPath, "rev-parse", "HEAD"))
+
+		testutil.RunGit(t, mainDir, "merge", "--no-ff", "-m", "Merge feature/audited", "feature/audited")
+
+		cfgResult, err := LoadConfig(mainDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		cmd := NewDefaultCleanCommand(cfgResult.Config, NewNopLogger())
+		result, err := cmd.Run(t.Context(), mainDir, CleanOptions{Yes: true})
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		if result.AuditErr != nil {
+			t.Fatalf("AuditErr: %v", result.AuditErr)
+		}
+
+		// Log lives in the git common dir, shared by all worktrees
+		logPath := filepath.Join(mainDir, ".git", "twig", "audit.jsonl")
+		if _, err := os.Stat(logPath); err != nil {
+			t.Fatalf("audit log not written: %v", err)
+		}
+
+		auditResult, err := NewDefaultAuditCommand(mainDir, NewNopLogger()).Run(t.Context(), AuditOptions{Branch: "feature/*"})
+		if err != nil {
+			t.Fatalf("audit Run failed: %v", err)
+		}
+		if len(auditResult.Entries) != 1 {
+			t.Fatalf("expected 1 audit entry, got %d", len(auditResult.Entries))
+		}
+		e := auditResult.Entries[0]
+		if e.Branch != "feature/audited" {
+			t.Errorf("Branch = %q, want %q", e.Branch, "feature/audited")
+		}
+		if e.HEAD != head {
+			t.Errorf("HEAD = %q, want %q", e.HEAD, head)
+		}
+		if e.SizeBytes < int64(len("audited content")) {
+			t.Errorf("SizeBytes = %d, want at least %d", e.SizeBytes, len("audited content"))
+		}
+		if e.Reason != string(CleanMerged) {
+			t.Errorf("Reason = %q, want %q", e.Reason, CleanMerged)
+		}
+	})
 }
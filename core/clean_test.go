@@ -0,0 +1,1854 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/708u/twig/internal/testutil"
+)
+
+func TestCleanResult_CleanableCount(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		result CleanResult
+		want   int
+	}{
+		{
+			name:   "empty",
+			result: CleanResult{},
+			want:   0,
+		},
+		{
+			name: "all_cleanable",
+			result: CleanResult{
+				Candidates: []CleanCandidate{
+					{Branch: "feat/a", Skipped: false},
+					{Branch: "feat/b", Skipped: false},
+				},
+			},
+			want: 2,
+		},
+		{
+			name: "all_skipped",
+			result: CleanResult{
+				Candidates: []CleanCandidate{
+					{Branch: "feat/a", Skipped: true},
+					{Branch: "feat/b", Skipped: true},
+				},
+			},
+			want: 0,
+		},
+		{
+			name: "mixed",
+			result: CleanResult{
+				Candidates: []CleanCandidate{
+					{Branch: "feat/a", Skipped: false},
+					{Branch: "feat/b", Skipped: true},
+					{Branch: "feat/c", Skipped: false},
+				},
+			},
+			want: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := tt.result.CleanableCount(); got != tt.want {
+				t.Errorf("CleanableCount() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCleanResult_Format(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		result     CleanResult
+		opts       CleanFormatOptions
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			name: "check_with_candidates",
+			result: CleanResult{
+				Candidates: []CleanCandidate{
+					{Branch: "feat/a", Skipped: false, CleanReason: CleanMerged},
+					{Branch: "feat/b", Skipped: true, SkipReason: SkipNotMerged},
+				},
+				Check: true,
+			},
+			opts:       CleanFormatOptions{},
+			wantStdout: "clean:\n  feat/a (merged)\n",
+			wantStderr: "",
+		},
+		{
+			name: "check_verbose_shows_skipped",
+			result: CleanResult{
+				Candidates: []CleanCandidate{
+					{Branch: "feat/a", Skipped: false, CleanReason: CleanMerged},
+					{Branch: "feat/b", Skipped: true, SkipReason: SkipNotMerged},
+				},
+				Check: true,
+			},
+			opts:       CleanFormatOptions{Verbose: true},
+			wantStdout: "clean:\n  feat/a (merged)\n\nskip:\n  feat/b\n    ✗ not merged\n",
+			wantStderr: "",
+		},
+		{
+			name: "check_with_multiple_targets",
+			result: CleanResult{
+				Candidates: []CleanCandidate{
+					{Branch: "hotfix/a", CleanReason: CleanMerged, Target: "release/2.4"},
+					{Branch: "feat/b", CleanReason: CleanUpstreamGone, Target: "main"},
+					{Branch: "hotfix/b", Skipped: true, SkipReason: SkipSameCommit, Target: "release/2.4"},
+				},
+				TargetBranch: "main",
+				Targets:      []string{"main", "release/2.4"},
+				Check:        true,
+			},
+			opts:       CleanFormatOptions{Verbose: true},
+			wantStdout: "clean:\n  hotfix/a (merged into release/2.4)\n  feat/b (upstream gone)\n\nskip:\n  hotfix/b\n    ✗ same commit as release/2.4\n",
+		},
+		{
+			name: "verify_failed_shows_output",
+			result: CleanResult{
+				Candidates: []CleanCandidate{
+					{Branch: "feat/db", Skipped: true, SkipReason: SkipVerifyFailed, CleanReason: CleanMerged, VerifyOutput: "dump.sql not uploaded\n\nrun make upload"},
+				},
+				Check: true,
+			},
+			opts:       CleanFormatOptions{Verbose: true},
+			wantStdout: "skip:\n  feat/db\n    ✓ merged\n    ✗ verify-failed\n      dump.sql not uploaded\n      run make upload\n\nNo worktrees to clean\n",
+		},
+		{
+			name: "unpushed_commits_shows_count",
+			result: CleanResult{
+				Candidates: []CleanCandidate{
+					{Branch: "feat/rebased", Skipped: true, SkipReason: SkipUnpushedCommits, CleanReason: CleanMerged, Unpushed: 2},
+				},
+				Check: true,
+			},
+			opts:       CleanFormatOptions{Verbose: true},
+			wantStdout: "skip:\n  feat/rebased\n    ✓ merged\n    ✗ unpushed-commits\n      2 commit(s) not pushed\n\nNo worktrees to clean\n",
+		},
+		{
+			name: "check_shows_notes",
+			result: CleanResult{
+				Candidates: []CleanCandidate{
+					{Branch: "feat/a", Skipped: false, CleanReason: CleanMerged, Note: "waiting on review", Description: "Fix login\n\nDetails"},
+					{Branch: "feat/b", Skipped: true, SkipReason: SkipNotMerged, Note: "keep for demo"},
+				},
+				Check: true,
+			},
+			opts:       CleanFormatOptions{Verbose: true},
+			wantStdout: "clean:\n  feat/a (merged)\n    note: waiting on review\n    description: Fix login\n\nskip:\n  feat/b\n    note: keep for demo\n    ✗ not merged\n",
+			wantStderr: "",
+		},
+		{
+			name: "no_candidates",
+			result: CleanResult{
+				Candidates: []CleanCandidate{},
+				Check:      true,
+			},
+			opts:       CleanFormatOptions{},
+			wantStdout: "No worktrees to clean\n",
+			wantStderr: "",
+		},
+		{
+			name: "all_skipped",
+			result: CleanResult{
+				Candidates: []CleanCandidate{
+					{Branch: "feat/a", Skipped: true, SkipReason: SkipLocked},
+				},
+				Check: true,
+			},
+			opts:       CleanFormatOptions{},
+			wantStdout: "No worktrees to clean\n",
+			wantStderr: "",
+		},
+		{
+			name: "execution_results",
+			result: CleanResult{
+				Removed: []RemovedWorktree{
+					{Branch: "feat/a"},
+					{Branch: "feat/b"},
+				},
+				Check: false,
+			},
+			opts:       CleanFormatOptions{},
+			wantStdout: "Removed 2 worktree(s), 2 branch(es), 0 empty dir(s); reclaimed 0 B\n",
+			wantStderr: "",
+		},
+		{
+			name: "execution_summary_counts_successes_only",
+			result: CleanResult{
+				Removed: []RemovedWorktree{
+					{Branch: "feat/a", SizeBytes: 3 * 1024 * 1024, CleanedDirs: []string{"/repo/wt/feat"}},
+					{WorktreePath: "/repo/wt/detached", SizeBytes: 1024 * 1024},
+					{Branch: "feat/b", SizeBytes: 5 * 1024 * 1024, Err: errors.New("boom")},
+				},
+				Check: false,
+			},
+			opts:       CleanFormatOptions{},
+			wantStdout: "Removed 2 worktree(s), 1 branch(es), 1 empty dir(s); reclaimed 4.0 MiB\n",
+			wantStderr: "error: feat/b: boom\n",
+		},
+		{
+			name: "execution_results_verbose",
+			result: CleanResult{
+				Removed: []RemovedWorktree{
+					{Branch: "feat/a"},
+					{Branch: "feat/b"},
+				},
+				Check: false,
+			},
+			opts:       CleanFormatOptions{Verbose: true},
+			wantStdout: "Removed worktree and branch: feat/a\nRemoved worktree and branch: feat/b\nRemoved 2 worktree(s), 2 branch(es), 0 empty dir(s); reclaimed 0 B\n",
+			wantStderr: "",
+		},
+		// Prunable branch tests
+		{
+			name: "prunable_only",
+			result: CleanResult{
+				Candidates: []CleanCandidate{
+					{Branch: "feat/prunable", Prunable: true, Skipped: false, CleanReason: CleanMerged},
+				},
+				Check: true,
+			},
+			opts:       CleanFormatOptions{},
+			wantStdout: "clean:\n  feat/prunable (prunable, merged)\n",
+			wantStderr: "",
+		},
+		{
+			name: "clean_and_prunable",
+			result: CleanResult{
+				Candidates: []CleanCandidate{
+					{Branch: "feat/a", Skipped: false, CleanReason: CleanMerged},
+					{Branch: "feat/prunable", Prunable: true, Skipped: false, CleanReason: CleanUpstreamGone},
+				},
+				Check: true,
+			},
+			opts:       CleanFormatOptions{},
+			wantStdout: "clean:\n  feat/a (merged)\n  feat/prunable (prunable, upstream gone)\n",
+			wantStderr: "",
+		},
+		{
+			name: "clean_prunable_and_skipped_verbose",
+			result: CleanResult{
+				Candidates: []CleanCandidate{
+					{Branch: "feat/a", Skipped: false, CleanReason: CleanMerged},
+					{Branch: "feat/prunable", Prunable: true, Skipped: false, CleanReason: CleanMerged},
+					{Branch: "feat/wip", Skipped: true, SkipReason: SkipNotMerged},
+				},
+				Check: true,
+			},
+			opts:       CleanFormatOptions{Verbose: true},
+			wantStdout: "clean:\n  feat/a (merged)\n  feat/prunable (prunable, merged)\n\nskip:\n  feat/wip\n    ✗ not merged\n",
+			wantStderr: "",
+		},
+		{
+			name: "prunable_skipped_shows_no_worktrees",
+			result: CleanResult{
+				Candidates: []CleanCandidate{
+					{Branch: "feat/prunable", Prunable: true, Skipped: true, SkipReason: SkipNotMerged},
+				},
+				Check: true,
+			},
+			opts:       CleanFormatOptions{},
+			wantStdout: "No worktrees to clean\n",
+			wantStderr: "",
+		},
+		// Verbose with changed files tests
+		{
+			name: "verbose_with_changed_files",
+			result: CleanResult{
+				Candidates: []CleanCandidate{
+					{Branch: "feat/a", Skipped: false, CleanReason: CleanMerged},
+					{
+						Branch:      "feat/wip",
+						Skipped:     true,
+						SkipReason:  SkipHasChanges,
+						CleanReason: CleanMerged, // merged but has uncommitted changes
+						ChangedFiles: []FileStatus{
+							{Status: " M", Path: "src/main.go"},
+							{Status: "??", Path: "tmp/debug.log"},
+						},
+					},
+				},
+				Check: true,
+			},
+			opts:       CleanFormatOptions{Verbose: true},
+			wantStdout: "clean:\n  feat/a (merged)\n\nskip:\n  feat/wip\n    ✓ merged\n    ✗ has uncommitted changes\n       M src/main.go\n      ?? tmp/debug.log\n",
+			wantStderr: "",
+		},
+		{
+			name: "verbose_with_dirty_submodule_changed_files",
+			result: CleanResult{
+				Candidates: []CleanCandidate{
+					{
+						Branch:      "feat/submod",
+						Skipped:     true,
+						SkipReason:  SkipDirtySubmodule,
+						CleanReason: CleanMerged, // merged but dirty submodule
+						ChangedFiles: []FileStatus{
+							{Status: " M", Path: "submodule/file.go"},
+						},
+					},
+				},
+				Check: true,
+			},
+			opts:       CleanFormatOptions{Verbose: true},
+			wantStdout: "skip:\n  feat/submod\n    ✓ merged\n    ✗ submodule has uncommitted changes\n       M submodule/file.go\n\nNo worktrees to clean\n",
+			wantStderr: "",
+		},
+		{
+			name: "verbose_skip_reason_without_changed_files",
+			result: CleanResult{
+				Candidates: []CleanCandidate{
+					{Branch: "feat/a", Skipped: false, CleanReason: CleanMerged},
+					{Branch: "feat/locked", Skipped: true, SkipReason: SkipLocked, CleanReason: CleanMerged},
+				},
+				Check: true,
+			},
+			opts:       CleanFormatOptions{Verbose: true},
+			wantStdout: "clean:\n  feat/a (merged)\n\nskip:\n  feat/locked\n    ✓ merged\n    ✗ locked\n",
+			wantStderr: "",
+		},
+		// Skip without CleanReason (merge-related skip reasons)
+		{
+			name: "verbose_skip_not_merged_no_clean_reason",
+			result: CleanResult{
+				Candidates: []CleanCandidate{
+					{Branch: "feat/a", Skipped: false, CleanReason: CleanMerged},
+					{Branch: "feat/wip", Skipped: true, SkipReason: SkipNotMerged},
+				},
+				Check: true,
+			},
+			opts:       CleanFormatOptions{Verbose: true},
+			wantStdout: "clean:\n  feat/a (merged)\n\nskip:\n  feat/wip\n    ✗ not merged\n",
+			wantStderr: "",
+		},
+		{
+			name: "verbose_skip_upstream_gone_with_uncommitted_changes",
+			result: CleanResult{
+				Candidates: []CleanCandidate{
+					{
+						Branch:      "feat/a",
+						Skipped:     true,
+						SkipReason:  SkipHasChanges,
+						CleanReason: CleanUpstreamGone,
+						ChangedFiles: []FileStatus{
+							{Status: " M", Path: "src/main.go"},
+						},
+					},
+				},
+				Check: true,
+			},
+			opts:       CleanFormatOptions{Verbose: true},
+			wantStdout: "skip:\n  feat/a\n    ✓ upstream gone\n    ✗ has uncommitted changes\n       M src/main.go\n\nNo worktrees to clean\n",
+			wantStderr: "",
+		},
+		// StaleOverride tests
+		{
+			name: "stale_override_merged",
+			result: CleanResult{
+				Candidates: []CleanCandidate{
+					{Branch: "feat/dirty", Skipped: false, CleanReason: CleanMerged, StaleOverride: true},
+				},
+				Check: true,
+			},
+			opts:       CleanFormatOptions{},
+			wantStdout: "clean:\n  feat/dirty (merged, stale)\n",
+			wantStderr: "",
+		},
+		{
+			name: "stale_override_upstream_gone",
+			result: CleanResult{
+				Candidates: []CleanCandidate{
+					{Branch: "feat/gone", Skipped: false, CleanReason: CleanUpstreamGone, StaleOverride: true},
+				},
+				Check: true,
+			},
+			opts:       CleanFormatOptions{},
+			wantStdout: "clean:\n  feat/gone (upstream gone, stale)\n",
+			wantStderr: "",
+		},
+		{
+			name: "stale_override_prunable",
+			result: CleanResult{
+				Candidates: []CleanCandidate{
+					{Branch: "feat/prunable", Prunable: true, Skipped: false, CleanReason: CleanMerged, StaleOverride: true},
+				},
+				Check: true,
+			},
+			opts:       CleanFormatOptions{},
+			wantStdout: "clean:\n  feat/prunable (prunable, merged, stale)\n",
+			wantStderr: "",
+		},
+		{
+			name: "stale_override_mixed_with_normal",
+			result: CleanResult{
+				Candidates: []CleanCandidate{
+					{Branch: "feat/a", Skipped: false, CleanReason: CleanMerged},
+					{Branch: "feat/dirty", Skipped: false, CleanReason: CleanMerged, StaleOverride: true},
+					{Branch: "feat/wip", Skipped: true, SkipReason: SkipNotMerged},
+				},
+				Check: true,
+			},
+			opts:       CleanFormatOptions{Verbose: true},
+			wantStdout: "clean:\n  feat/a (merged)\n  feat/dirty (merged, stale)\n\nskip:\n  feat/wip\n    ✗ not merged\n",
+			wantStderr: "",
+		},
+		// ColorEnabled tests - output should be identical when color disabled
+		{
+			name: "color_disabled_same_as_no_color",
+			result: CleanResult{
+				Candidates: []CleanCandidate{
+					{Branch: "feat/a", Skipped: false, CleanReason: CleanMerged},
+					{Branch: "feat/b", Skipped: true, SkipReason: SkipNotMerged},
+				},
+				Check: true,
+			},
+			opts:       CleanFormatOptions{ColorEnabled: false},
+			wantStdout: "clean:\n  feat/a (merged)\n",
+			wantStderr: "",
+		},
+		{
+			name: "color_disabled_verbose_same_as_no_color",
+			result: CleanResult{
+				Candidates: []CleanCandidate{
+					{Branch: "feat/a", Skipped: false, CleanReason: CleanMerged},
+					{Branch: "feat/b", Skipped: true, SkipReason: SkipNotMerged},
+				},
+				Check: true,
+			},
+			opts:       CleanFormatOptions{Verbose: true, ColorEnabled: false},
+			wantStdout: "clean:\n  feat/a (merged)\n\nskip:\n  feat/b\n    ✗ not merged\n",
+			wantStderr: "",
+		},
+		{
+			name: "fetch_failure_warns",
+			result: CleanResult{
+				Candidates: []CleanCandidate{
+					{Branch: "feat/a", Skipped: false, CleanReason: CleanUpstreamGone},
+				},
+				Check:     true,
+				FetchErrs: []error{errors.New("failed to fetch upstream: exit status 128")},
+			},
+			wantStdout: "clean:\n  feat/a (upstream gone)\n",
+			wantStderr: "warning: failed to fetch upstream: exit status 128\n",
+		},
+		{
+			name: "squash_check_failure_warns",
+			result: CleanResult{
+				Candidates: []CleanCandidate{
+					{Branch: "feat/a", Skipped: true, SkipReason: SkipNotMerged},
+				},
+				Check:      true,
+				SquashErrs: map[string]error{"feat/a": errors.New("failed to create squash commit: exit status 128")},
+			},
+			wantStdout: "No worktrees to clean\n",
+			wantStderr: "warning: could not check whether feat/a was squash-merged, treating it as not merged: failed to create squash commit: exit status 128\n",
+		},
+		{
+			name: "porcelain_lists_all_candidates",
+			result: CleanResult{
+				Candidates: []CleanCandidate{
+					{Branch: "feat/a", WorktreePath: "/wt/feat/a", CleanReason: CleanMerged, Prunable: true},
+					{Branch: "feat/b", WorktreePath: "/wt/feat/b", Skipped: true, SkipReason: SkipNotMerged},
+					{Branch: "feat/c", WorktreePath: "/wt/feat/c", Skipped: true, SkipReason: SkipSameCommit, Target: "main"},
+					{WorktreePath: "/wt/detached", CleanReason: CleanDetached, Detached: true},
+				},
+				Check: true,
+			},
+			opts: CleanFormatOptions{Porcelain: true, ColorEnabled: true},
+			wantStdout: "feat/a\t/wt/feat/a\tremove\tmerged\n" +
+				"feat/b\t/wt/feat/b\tskip\tnot_merged\n" +
+				"feat/c\t/wt/feat/c\tskip\tsame_commit\n" +
+				"\t/wt/detached\tremove\tdetached\n",
+		},
+		{
+			name: "porcelain_no_candidates",
+			result: CleanResult{
+				Check:    true,
+				ForgeErr: errors.New("gh: not logged in"),
+			},
+			opts:       CleanFormatOptions{Porcelain: true},
+			wantStdout: "",
+			wantStderr: "warning: PR lookup failed: gh: not logged in\n",
+		},
+		{
+			name: "quiet_check_lists_cleanable",
+			result: CleanResult{
+				Candidates: []CleanCandidate{
+					{Branch: "feat/a", CleanReason: CleanMerged},
+					{Branch: "feat/b", Skipped: true, SkipReason: SkipNotMerged},
+					{WorktreePath: "/repo/detached", Detached: true, CleanReason: CleanMerged},
+				},
+				Check: true,
+			},
+			opts:       CleanFormatOptions{Quiet: true},
+			wantStdout: "feat/a\n/repo/detached\n",
+		},
+		{
+			name: "quiet_removed",
+			result: CleanResult{
+				Removed: []RemovedWorktree{
+					{Branch: "feat/a", WorktreePath: "/repo/feat-a", ArchivePath: "/archive/feat-a.tar.gz"},
+					{Branch: "feat/b", Err: errors.New("failed")},
+				},
+			},
+			opts:       CleanFormatOptions{Quiet: true},
+			wantStdout: "feat/a\n",
+			wantStderr: "Archived uncommitted changes: /archive/feat-a.tar.gz\nerror: feat/b: failed\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got := tt.result.Format(tt.opts)
+			if got.Stdout != tt.wantStdout {
+				t.Errorf("Stdout = %q, want %q", got.Stdout, tt.wantStdout)
+			}
+			if got.Stderr != tt.wantStderr {
+				t.Errorf("Stderr = %q, want %q", got.Stderr, tt.wantStderr)
+			}
+		})
+	}
+}
+
+func TestCleanCommand_Run(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		cwd            string
+		opts           CleanOptions
+		config         *Config
+		setupGit       func() *testutil.MockGitExecutor
+		wantErr        bool
+		errContains    string
+		wantCandidates int
+		wantSkipped    int
+	}{
+		{
+			name: "finds_merged_candidates",
+			cwd:  "/other/dir",
+			opts: CleanOptions{},
+			config: &Config{
+				WorktreeSourceDir: "/repo/main",
+				DefaultSource:     "main",
+			},
+			setupGit: func() *testutil.MockGitExecutor {
+				return &testutil.MockGitExecutor{
+					Worktrees: []testutil.MockWorktree{
+						{Path: "/repo/main", Branch: "main"},
+						{Path: "/repo/feat/a", Branch: "feat/a"},
+						{Path: "/repo/feat/b", Branch: "feat/b"},
+					},
+					MergedBranches: map[string][]string{
+						"main": {"main", "feat/a"},
+					},
+				}
+			},
+			wantCandidates: 2,
+			wantSkipped:    1, // feat/b not merged
+		},
+		{
+			name: "skips_locked_worktrees",
+			cwd:  "/other/dir",
+			opts: CleanOptions{},
+			config: &Config{
+				WorktreeSourceDir: "/repo/main",
+				DefaultSource:     "main",
+			},
+			setupGit: func() *testutil.MockGitExecutor {
+				return &testutil.MockGitExecutor{
+					Worktrees: []testutil.MockWorktree{
+						{Path: "/repo/main", Branch: "main"},
+						{Path: "/repo/feat/a", Branch: "feat/a", Locked: true},
+					},
+					MergedBranches: map[string][]string{
+						"main": {"main", "feat/a"},
+					},
+				}
+			},
+			wantCandidates: 1,
+			wantSkipped:    1,
+		},
+		{
+			name: "skips_current_directory",
+			cwd:  "/repo/feat/a/subdir",
+			opts: CleanOptions{},
+			config: &Config{
+				WorktreeSourceDir: "/repo/main",
+				DefaultSource:     "main",
+			},
+			setupGit: func() *testutil.MockGitExecutor {
+				return &testutil.MockGitExecutor{
+					Worktrees: []testutil.MockWorktree{
+						{Path: "/repo/main", Branch: "main"},
+						{Path: "/repo/feat/a", Branch: "feat/a"},
+					},
+					MergedBranches: map[string][]string{
+						"main": {"main", "feat/a"},
+					},
+				}
+			},
+			wantCandidates: 1,
+			wantSkipped:    1,
+		},
+		{
+			name: "skips_worktrees_with_changes",
+			cwd:  "/other/dir",
+			opts: CleanOptions{},
+			config: &Config{
+				WorktreeSourceDir: "/repo/main",
+				DefaultSource:     "main",
+			},
+			setupGit: func() *testutil.MockGitExecutor {
+				return &testutil.MockGitExecutor{
+					Worktrees: []testutil.MockWorktree{
+						{Path: "/repo/main", Branch: "main"},
+						{Path: "/repo/feat/a", Branch: "feat/a"},
+					},
+					MergedBranches: map[string][]string{
+						"main": {"main", "feat/a"},
+					},
+					HasChanges: true,
+				}
+			},
+			wantCandidates: 1,
+			wantSkipped:    1,
+		},
+		{
+			name: "skips_detached_head",
+			cwd:  "/other/dir",
+			opts: CleanOptions{},
+			config: &Config{
+				WorktreeSourceDir: "/repo/main",
+				DefaultSource:     "main",
+			},
+			setupGit: func() *testutil.MockGitExecutor {
+				return &testutil.MockGitExecutor{
+					Worktrees: []testutil.MockWorktree{
+						{Path: "/repo/main", Branch: "main"},
+						{Path: "/repo/feat/a", Detached: true},
+					},
+					MergedBranches: map[string][]string{
+						"main": {"main"},
+					},
+				}
+			},
+			wantCandidates: 1,
+			wantSkipped:    1,
+		},
+		{
+			name: "detached_flag_cleans_detached_head",
+			cwd:  "/other/dir",
+			opts: CleanOptions{Detached: true},
+			config: &Config{
+				WorktreeSourceDir: "/repo/main",
+				DefaultSource:     "main",
+			},
+			setupGit: func() *testutil.MockGitExecutor {
+				return &testutil.MockGitExecutor{
+					Worktrees: []testutil.MockWorktree{
+						{Path: "/repo/main", Branch: "main"},
+						{Path: "/repo/v1.2.3", Detached: true},
+					},
+					MergedBranches: map[string][]string{
+						"main": {"main"},
+					},
+				}
+			},
+			wantCandidates: 1,
+			wantSkipped:    0,
+		},
+		{
+			name: "detached_flag_skips_dirty_detached_head",
+			cwd:  "/other/dir",
+			opts: CleanOptions{Detached: true},
+			config: &Config{
+				WorktreeSourceDir: "/repo/main",
+				DefaultSource:     "main",
+			},
+			setupGit: func() *testutil.MockGitExecutor {
+				return &testutil.MockGitExecutor{
+					Worktrees: []testutil.MockWorktree{
+						{Path: "/repo/main", Branch: "main"},
+						{Path: "/repo/v1.2.3", Detached: true},
+					},
+					MergedBranches: map[string][]string{
+						"main": {"main"},
+					},
+					HasChanges: true,
+				}
+			},
+			wantCandidates: 1,
+			wantSkipped:    1,
+		},
+		{
+			name: "uses_target_flag",
+			cwd:  "/other/dir",
+			opts: CleanOptions{Targets: []string{"develop"}},
+			config: &Config{
+				WorktreeSourceDir: "/repo/main",
+				DefaultSource:     "main",
+			},
+			setupGit: func() *testutil.MockGitExecutor {
+				return &testutil.MockGitExecutor{
+					Worktrees: []testutil.MockWorktree{
+						{Path: "/repo/main", Branch: "main"},
+						{Path: "/repo/feat/a", Branch: "feat/a"},
+					},
+					MergedBranches: map[string][]string{
+						"develop": {"develop", "feat/a"},
+					},
+				}
+			},
+			wantCandidates: 1,
+			wantSkipped:    0,
+		},
+		{
+			name: "auto_detects_target",
+			cwd:  "/other/dir",
+			opts: CleanOptions{},
+			config: &Config{
+				WorktreeSourceDir: "/repo/main",
+			},
+			setupGit: func() *testutil.MockGitExecutor {
+				return &testutil.MockGitExecutor{
+					Worktrees: []testutil.MockWorktree{
+						{Path: "/repo/main", Branch: "main"},
+						{Path: "/repo/feat/a", Branch: "feat/a"},
+					},
+					MergedBranches: map[string][]string{
+						"main": {"main", "feat/a"},
+					},
+				}
+			},
+			wantCandidates: 1,
+			wantSkipped:    0,
+		},
+		{
+			name: "skips_bare_worktrees",
+			cwd:  "/other/dir",
+			opts: CleanOptions{},
+			config: &Config{
+				WorktreeSourceDir: "/repo/main",
+				DefaultSource:     "main",
+			},
+			setupGit: func() *testutil.MockGitExecutor {
+				return &testutil.MockGitExecutor{
+					Worktrees: []testutil.MockWorktree{
+						{Path: "/repo/main", Branch: "main"},
+						{Path: "/repo/.git/worktrees/bare", Bare: true},
+						{Path: "/repo/feat/a", Branch: "feat/a"},
+					},
+					MergedBranches: map[string][]string{
+						"main": {"main", "feat/a"},
+					},
+				}
+			},
+			wantCandidates: 1,
+			wantSkipped:    0,
+		},
+		// Orphaned branch tests
+		{
+			name: "detects_prunable_as_orphaned",
+			cwd:  "/other/dir",
+			opts: CleanOptions{Check: true},
+			config: &Config{
+				WorktreeSourceDir: "/repo/main",
+				DefaultSource:     "main",
+			},
+			setupGit: func() *testutil.MockGitExecutor {
+				return &testutil.MockGitExecutor{
+					Worktrees: []testutil.MockWorktree{
+						{Path: "/repo/main", Branch: "main"},
+						{Path: "/repo/feat/orphaned", Branch: "feat/orphaned", Prunable: true},
+					},
+					MergedBranches: map[string][]string{
+						"main": {"main", "feat/orphaned"},
+					},
+				}
+			},
+			wantCandidates: 1,
+			wantSkipped:    0,
+		},
+		{
+			name: "orphaned_not_merged_is_skipped",
+			cwd:  "/other/dir",
+			opts: CleanOptions{Check: true},
+			config: &Config{
+				WorktreeSourceDir: "/repo/main",
+				DefaultSource:     "main",
+			},
+			setupGit: func() *testutil.MockGitExecutor {
+				return &testutil.MockGitExecutor{
+					Worktrees: []testutil.MockWorktree{
+						{Path: "/repo/main", Branch: "main"},
+						{Path: "/repo/feat/orphaned", Branch: "feat/orphaned", Prunable: true},
+					},
+					MergedBranches: map[string][]string{
+						"main": {"main"},
+					},
+				}
+			},
+			wantCandidates: 1,
+			wantSkipped:    1,
+		},
+		{
+			name: "mixed_worktree_and_orphaned",
+			cwd:  "/other/dir",
+			opts: CleanOptions{Check: true},
+			config: &Config{
+				WorktreeSourceDir: "/repo/main",
+				DefaultSource:     "main",
+			},
+			setupGit: func() *testutil.MockGitExecutor {
+				return &testutil.MockGitExecutor{
+					Worktrees: []testutil.MockWorktree{
+						{Path: "/repo/main", Branch: "main"},
+						{Path: "/repo/feat/a", Branch: "feat/a"},
+						{Path: "/repo/feat/orphaned", Branch: "feat/orphaned", Prunable: true},
+					},
+					MergedBranches: map[string][]string{
+						"main": {"main", "feat/a", "feat/orphaned"},
+					},
+				}
+			},
+			wantCandidates: 2,
+			wantSkipped:    0,
+		},
+		{
+			name: "stale_overrides_has_changes_when_merged",
+			cwd:  "/other/dir",
+			opts: CleanOptions{Check: true, Stale: true},
+			config: &Config{
+				WorktreeSourceDir: "/repo/main",
+				DefaultSource:     "main",
+			},
+			setupGit: func() *testutil.MockGitExecutor {
+				return &testutil.MockGitExecutor{
+					Worktrees: []testutil.MockWorktree{
+						{Path: "/repo/main", Branch: "main"},
+						{Path: "/repo/feat/a", Branch: "feat/a"},
+					},
+					MergedBranches: map[string][]string{
+						"main": {"main", "feat/a"},
+					},
+					HasChanges: true,
+				}
+			},
+			wantCandidates: 1,
+			wantSkipped:    0, // stale overrides SkipHasChanges
+		},
+		{
+			name: "stale_does_not_override_not_merged",
+			cwd:  "/other/dir",
+			opts: CleanOptions{Check: true, Stale: true},
+			config: &Config{
+				WorktreeSourceDir: "/repo/main",
+				DefaultSource:     "main",
+			},
+			setupGit: func() *testutil.MockGitExecutor {
+				return &testutil.MockGitExecutor{
+					Worktrees: []testutil.MockWorktree{
+						{Path: "/repo/main", Branch: "main"},
+						{Path: "/repo/feat/a", Branch: "feat/a"},
+					},
+					MergedBranches: map[string][]string{
+						"main": {"main"},
+					},
+					HasChanges: true,
+				}
+			},
+			wantCandidates: 1,
+			wantSkipped:    1, // not merged, stale does not override
+		},
+		{
+			name: "stale_does_not_override_locked",
+			cwd:  "/other/dir",
+			opts: CleanOptions{Check: true, Stale: true},
+			config: &Config{
+				WorktreeSourceDir: "/repo/main",
+				DefaultSource:     "main",
+			},
+			setupGit: func() *testutil.MockGitExecutor {
+				return &testutil.MockGitExecutor{
+					Worktrees: []testutil.MockWorktree{
+						{Path: "/repo/main", Branch: "main"},
+						{Path: "/repo/feat/a", Branch: "feat/a", Locked: true},
+					},
+					MergedBranches: map[string][]string{
+						"main": {"main", "feat/a"},
+					},
+				}
+			},
+			wantCandidates: 1,
+			wantSkipped:    1, // locked, stale does not override
+		},
+		{
+			name: "stale_does_not_override_wip_on_first_parent",
+			cwd:  "/other/dir",
+			opts: CleanOptions{Check: true, Stale: true},
+			config: &Config{
+				WorktreeSourceDir: "/repo/main",
+				DefaultSource:     "main",
+			},
+			setupGit: func() *testutil.MockGitExecutor {
+				return &testutil.MockGitExecutor{
+					Worktrees: []testutil.MockWorktree{
+						{Path: "/repo/main", Branch: "main"},
+						{Path: "/repo/feat/a", Branch: "feat/a", HEAD: "wip-commit"},
+					},
+					MergedBranches: map[string][]string{
+						"main": {"main", "feat/a"},
+					},
+					HasChanges: true,
+					FirstParentAncestors: map[string][]string{
+						"main": {"wip-commit"},
+					},
+				}
+			},
+			wantCandidates: 1,
+			wantSkipped:    1, // WIP on first-parent: CleanReason cleared, stale does not override
+		},
+		{
+			name: "stale_overrides_genuinely_merged_not_first_parent",
+			cwd:  "/other/dir",
+			opts: CleanOptions{Check: true, Stale: true},
+			config: &Config{
+				WorktreeSourceDir: "/repo/main",
+				DefaultSource:     "main",
+			},
+			setupGit: func() *testutil.MockGitExecutor {
+				return &testutil.MockGitExecutor{
+					Worktrees: []testutil.MockWorktree{
+						{Path: "/repo/main", Branch: "main"},
+						{Path: "/repo/feat/a", Branch: "feat/a", HEAD: "merged-commit"},
+					},
+					MergedBranches: map[string][]string{
+						"main": {"main", "feat/a"},
+					},
+					HasChanges: true,
+					// FirstParentAncestors not set -> not on first-parent -> genuinely merged
+				}
+			},
+			wantCandidates: 1,
+			wantSkipped:    0, // genuinely merged: stale override applies
+		},
+		{
+			name: "skips_new_branch_pointing_to_same_commit_as_target",
+			cwd:  "/other/dir",
+			opts: CleanOptions{},
+			config: &Config{
+				WorktreeSourceDir: "/repo/main",
+				DefaultSource:     "main",
+			},
+			setupGit: func() *testutil.MockGitExecutor {
+				return &testutil.MockGitExecutor{
+					Worktrees: []testutil.MockWorktree{
+						{Path: "/repo/main", Branch: "main", HEAD: "same-commit-abc123"},
+						{Path: "/repo/feat/new", Branch: "feat/new", HEAD: "same-commit-abc123"},
+					},
+					MergedBranches: map[string][]string{
+						"main": {"main", "feat/new"}, // git branch --merged returns this
+					},
+				}
+			},
+			wantCandidates: 1,
+			wantSkipped:    1, // feat/new should be skipped because same commit as main
+		},
+		{
+			name: "protected_branch_skipped_even_with_force",
+			cwd:  "/other/dir",
+			opts: CleanOptions{Force: WorktreeForceLevelLocked},
+			config: &Config{
+				WorktreeSourceDir: "/repo/main",
+				DefaultSource:     "main",
+				ProtectedBranches: []string{"develop", "release/*"},
+			},
+			setupGit: func() *testutil.MockGitExecutor {
+				return &testutil.MockGitExecutor{
+					Worktrees: []testutil.MockWorktree{
+						{Path: "/repo/main", Branch: "main"},
+						{Path: "/repo/develop", Branch: "develop"},
+						{Path: "/repo/release/1.0", Branch: "release/1.0"},
+						{Path: "/repo/feat/a", Branch: "feat/a"},
+					},
+					MergedBranches: map[string][]string{
+						"main": {"main", "develop", "release/1.0", "feat/a"},
+					},
+				}
+			},
+			wantCandidates: 3,
+			wantSkipped:    2, // develop and release/1.0 are protected
+		},
+		{
+			name: "squash_merged_detected_when_enabled",
+			cwd:  "/other/dir",
+			opts: CleanOptions{},
+			config: &Config{
+				WorktreeSourceDir:  "/repo/main",
+				DefaultSource:      "main",
+				DetectSquashMerges: func() *bool { b := true; return &b }(),
+			},
+			setupGit: func() *testutil.MockGitExecutor {
+				return &testutil.MockGitExecutor{
+					Worktrees: []testutil.MockWorktree{
+						{Path: "/repo/main", Branch: "main"},
+						{Path: "/repo/feat/squashed", Branch: "feat/squashed"},
+						{Path: "/repo/feat/b", Branch: "feat/b"},
+					},
+					MergedBranches: map[string][]string{
+						"main": {"main"},
+					},
+					SquashMergedBranches: map[string][]string{
+						"main": {"feat/squashed"},
+					},
+				}
+			},
+			wantCandidates: 2,
+			wantSkipped:    1, // feat/b not merged
+		},
+		{
+			name: "squash_merged_ignored_when_disabled",
+			cwd:  "/other/dir",
+			opts: CleanOptions{},
+			config: &Config{
+				WorktreeSourceDir: "/repo/main",
+				DefaultSource:     "main",
+			},
+			setupGit: func() *testutil.MockGitExecutor {
+				return &testutil.MockGitExecutor{
+					Worktrees: []testutil.MockWorktree{
+						{Path: "/repo/main", Branch: "main"},
+						{Path: "/repo/feat/squashed", Branch: "feat/squashed"},
+					},
+					MergedBranches: map[string][]string{
+						"main": {"main"},
+					},
+					SquashMergedBranches: map[string][]string{
+						"main": {"feat/squashed"},
+					},
+				}
+			},
+			wantCandidates: 1,
+			wantSkipped:    1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockGit := tt.setupGit()
+
+			cmd := &CleanCommand{
+				FS:     &testutil.MockFS{},
+				Git:    &GitRunner{Executor: mockGit, Log: NewNopLogger()},
+				Config: tt.config,
+				Log:    NewNopLogger(),
+			}
+
+			result, err := cmd.Run(t.Context(), tt.cwd, tt.opts)
+
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				if tt.errContains != "" && !strings.Contains(err.Error(), tt.errContains) {
+					t.Errorf("error %q should contain %q", err.Error(), tt.errContains)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(result.Candidates) != tt.wantCandidates {
+				t.Errorf("got %d candidates, want %d", len(result.Candidates), tt.wantCandidates)
+			}
+
+			skippedCount := 0
+			for _, c := range result.Candidates {
+				if c.Skipped {
+					skippedCount++
+				}
+			}
+			if skippedCount != tt.wantSkipped {
+				t.Errorf("got %d skipped, want %d", skippedCount, tt.wantSkipped)
+			}
+		})
+	}
+}
+
+// TestCleanCommand_Run_MixedDetached checks detached and branch worktrees
+// concurrently; run with -race to catch unsynchronized candidate appends.
+func TestCleanCommand_Run_MixedDetached(t *testing.T) {
+	t.Parallel()
+
+	worktrees := []testutil.MockWorktree{{Path: "/repo/main", Branch: "main"}}
+	merged := []string{"main"}
+	var wantPaths []string
+	for i := range 10 {
+		branch := fmt.Sprintf("feat/%d", i)
+		worktrees = append(worktrees,
+			testutil.MockWorktree{Path: "/repo/" + branch, Branch: branch},
+			testutil.MockWorktree{Path: fmt.Sprintf("/repo/v%d", i), Detached: true})
+		merged = append(merged, branch)
+		wantPaths = append(wantPaths, "/repo/"+branch, fmt.Sprintf("/repo/v%d", i))
+	}
+
+	cmd := &CleanCommand{
+		FS: &testutil.MockFS{},
+		Git: &GitRunner{Executor: &testutil.MockGitExecutor{
+			Worktrees:      worktrees,
+			MergedBranches: map[string][]string{"main": merged},
+		}, Log: NewNopLogger()},
+		Config: &Config{WorktreeSourceDir: "/repo/main", DefaultSource: "main"},
+		Log:    NewNopLogger(),
+	}
+
+	result, err := cmd.Run(t.Context(), "/other/dir", CleanOptions{Detached: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var gotPaths []string
+	for _, c := range result.Candidates {
+		gotPaths = append(gotPaths, c.WorktreePath)
+		if c.Skipped {
+			t.Errorf("%s skipped: %s", c.WorktreePath, c.SkipReason)
+		}
+		if c.Detached != strings.HasPrefix(c.WorktreePath, "/repo/v") {
+			t.Errorf("%s: Detached = %v", c.WorktreePath, c.Detached)
+		}
+	}
+	if !slices.Equal(gotPaths, wantPaths) {
+		t.Errorf("candidates = %v, want %v", gotPaths, wantPaths)
+	}
+}
+
+func TestCleanCommand_ResolveTarget(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		target     string
+		config     *Config
+		worktrees  []Worktree
+		wantTarget string
+		wantErr    bool
+	}{
+		{
+			name:       "uses_provided_target",
+			target:     "develop",
+			config:     &Config{},
+			wantTarget: "develop",
+		},
+		{
+			name:   "auto_detects_from_worktrees",
+			target: "",
+			config: &Config{},
+			worktrees: []Worktree{
+				{Path: "/repo/main", Branch: "main"},
+			},
+			wantTarget: "main",
+		},
+		{
+			name:      "error_when_no_target_found",
+			target:    "",
+			config:    &Config{},
+			worktrees: []Worktree{},
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cmd := &CleanCommand{
+				Config: tt.config,
+				Log:    NewNopLogger(),
+			}
+
+			got, err := cmd.resolveTarget(tt.target, tt.worktrees)
+
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got != tt.wantTarget {
+				t.Errorf("got %q, want %q", got, tt.wantTarget)
+			}
+		})
+	}
+}
+
+func TestCleanCommand_Run_SquashMergedReason(t *testing.T) {
+	t.Parallel()
+
+	detect := true
+	mockGit := &testutil.MockGitExecutor{
+		Worktrees: []testutil.MockWorktree{
+			{Path: "/repo/main", Branch: "main"},
+			{Path: "/repo/feat/squashed", Branch: "feat/squashed"},
+		},
+		MergedBranches: map[string][]string{
+			"main": {"main"},
+		},
+		SquashMergedBranches: map[string][]string{
+			"main": {"feat/squashed"},
+		},
+	}
+
+	cmd := &CleanCommand{
+		FS:  &testutil.MockFS{},
+		Git: &GitRunner{Executor: mockGit, Log: NewNopLogger()},
+		Config: &Config{
+			WorktreeSourceDir:  "/repo/main",
+			DetectSquashMerges: &detect,
+		},
+		Log: NewNopLogger(),
+	}
+
+	result, err := cmd.Run(t.Context(), "/other/dir", CleanOptions{Check: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Candidates) != 1 {
+		t.Fatalf("got %d candidates, want 1", len(result.Candidates))
+	}
+	if got := result.Candidates[0].CleanReason; got != CleanSquashMerged {
+		t.Errorf("CleanReason = %q, want %q", got, CleanSquashMerged)
+	}
+}
+
+func TestCleanCommand_Run_SquashCheckFailureWarns(t *testing.T) {
+	t.Parallel()
+
+	faults, err := ParseFaultProfile("git.commit-tree=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	detect := true
+	mockGit := &testutil.MockGitExecutor{
+		Worktrees: []testutil.MockWorktree{
+			{Path: "/repo/main", Branch: "main"},
+			{Path: "/repo/feat/squashed", Branch: "feat/squashed"},
+		},
+		MergedBranches: map[string][]string{
+			"main": {"main"},
+		},
+		SquashMergedBranches: map[string][]string{
+			"main": {"feat/squashed"},
+		},
+	}
+
+	cmd := &CleanCommand{
+		FS:  &testutil.MockFS{},
+		Git: &GitRunner{Executor: faults.WrapGitExecutor(mockGit), Log: NewNopLogger()},
+		Config: &Config{
+			WorktreeSourceDir:  "/repo/main",
+			DetectSquashMerges: &detect,
+		},
+		Log: NewNopLogger(),
+	}
+
+	result, err := cmd.Run(t.Context(), "/other/dir", CleanOptions{Check: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Candidates) != 1 || !result.Candidates[0].Skipped {
+		t.Fatalf("Candidates = %+v, want feat/squashed skipped as not merged", result.Candidates)
+	}
+	warnings := result.Warnings()
+	if len(warnings) != 1 || warnings[0].Code != WarningSquashCheckFailed || warnings[0].Subject != "feat/squashed" {
+		t.Errorf("Warnings() = %+v, want a squash_check_failed warning for feat/squashed", warnings)
+	}
+}
+
+// countingExecutor counts git invocations by their leading arguments.
+type countingExecutor struct {
+	GitExecutor
+	mu    sync.Mutex
+	calls map[string]int
+}
+
+func (e *countingExecutor) Run(ctx context.Context, args ...string) ([]byte, error) {
+	cmd := args
+	for len(cmd) >= 2 && cmd[0] == "-C" {
+		cmd = cmd[2:]
+	}
+	e.mu.Lock()
+	e.calls[strings.Join(cmd[:min(2, len(cmd))], " ")]++
+	e.mu.Unlock()
+	return e.GitExecutor.Run(ctx, args...)
+}
+
+func TestCleanCommand_Run_BatchedStatus(t *testing.T) {
+	t.Parallel()
+
+	mockGit := &testutil.MockGitExecutor{
+		Worktrees: []testutil.MockWorktree{
+			{Path: "/repo/main", Branch: "main"},
+			{Path: "/repo/feat/a", Branch: "feat/a"},
+			{Path: "/repo/feat/b", Branch: "feat/b"},
+			{Path: "/repo/feat/c", Branch: "feat/c"},
+		},
+		MergedBranches: map[string][]string{
+			"main": {"main", "feat/a"},
+		},
+		UpstreamGoneBranches: []string{"feat/b"},
+	}
+	executor := &countingExecutor{GitExecutor: mockGit, calls: make(map[string]int)}
+
+	cmd := &CleanCommand{
+		FS:     &testutil.MockFS{},
+		Git:    &GitRunner{Executor: executor, Log: NewNopLogger()},
+		Config: &Config{WorktreeSourceDir: "/repo/main"},
+		Log:    NewNopLogger(),
+	}
+
+	result, err := cmd.Run(t.Context(), "/other/dir", CleanOptions{Check: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	reasons := make(map[string]CleanReason)
+	for _, c := range result.Candidates {
+		reasons[c.Branch] = c.CleanReason
+	}
+	want := map[string]CleanReason{"feat/a": CleanMerged, "feat/b": CleanUpstreamGone, "feat/c": ""}
+	for branch, reason := range want {
+		if reasons[branch] != reason {
+			t.Errorf("CleanReason[%s] = %q, want %q", branch, reasons[branch], reason)
+		}
+	}
+
+	// Branch state is collected once, not once per worktree
+	for _, call := range []string{"worktree list", "for-each-ref --format=%(refname:short)%00%(objectname)%00%(upstream:short)%00%(upstream:track,nobracket)", "branch --merged"} {
+		if got := executor.calls[call]; got != 1 {
+			t.Errorf("%q ran %d times, want 1", call, got)
+		}
+	}
+}
+
+func TestCleanCommand_Run_Fetch(t *testing.T) {
+	t.Parallel()
+
+	var captured []string
+	mockGit := &testutil.MockGitExecutor{
+		Worktrees: []testutil.MockWorktree{
+			{Path: "/repo/main", Branch: "main"},
+			{Path: "/repo/feat/a", Branch: "feat/a"},
+		},
+		Remotes:      []string{"origin", "upstream"},
+		FetchErrs:    map[string]error{"upstream": errors.New("exit status 128")},
+		CapturedArgs: &captured,
+	}
+
+	cmd := &CleanCommand{
+		FS:     &testutil.MockFS{},
+		Git:    &GitRunner{Executor: mockGit, Log: NewNopLogger()},
+		Config: &Config{WorktreeSourceDir: "/repo/main"},
+		Log:    NewNopLogger(),
+	}
+
+	result, err := cmd.Run(t.Context(), "/other/dir", CleanOptions{Check: true, Fetch: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "fetch --prune origin fetch --prune upstream"
+	if got := strings.Join(captured, " "); !strings.HasPrefix(got, want) {
+		t.Errorf("captured args = %q, want prefix %q", got, want)
+	}
+	if len(result.FetchErrs) != 1 || !strings.Contains(result.FetchErrs[0].Error(), "failed to fetch upstream") {
+		t.Errorf("FetchErrs = %v, want one upstream failure", result.FetchErrs)
+	}
+}
+
+func TestCleanCommand_Run_NoWorktrees(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		worktrees []testutil.MockWorktree
+		only      []string
+	}{
+		{
+			name:      "main only",
+			worktrees: []testutil.MockWorktree{{Path: "/repo/main", Branch: "main"}},
+		},
+		{
+			name: "none selected",
+			worktrees: []testutil.MockWorktree{
+				{Path: "/repo/main", Branch: "main"},
+				{Path: "/repo/feat/a", Branch: "feat/a"},
+			},
+			only: []string{"/repo/feat/b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockGit := &testutil.MockGitExecutor{
+				Worktrees: tt.worktrees,
+				Remotes:   []string{"origin"},
+			}
+			executor := &countingExecutor{GitExecutor: mockGit, calls: make(map[string]int)}
+
+			cmd := &CleanCommand{
+				FS:     &testutil.MockFS{},
+				Git:    &GitRunner{Executor: executor, Log: NewNopLogger()},
+				Config: &Config{WorktreeSourceDir: "/repo/main"},
+				Log:    NewNopLogger(),
+			}
+
+			result, err := cmd.Run(t.Context(), "/other/dir", CleanOptions{Check: true, Fetch: true, Worktrees: tt.only})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(result.Candidates) != 0 || result.TargetBranch != "main" {
+				t.Errorf("result = %+v, want no candidates with target main", result)
+			}
+
+			// Only the worktree list is needed to tell there is nothing to do
+			for call, n := range executor.calls {
+				if call != "worktree list" {
+					t.Errorf("%q ran %d times, want no calls besides worktree list", call, n)
+				}
+			}
+		})
+	}
+}
+
+func TestCleanCommand_Run_Exclude(t *testing.T) {
+	t.Parallel()
+
+	mockGit := &testutil.MockGitExecutor{
+		Worktrees: []testutil.MockWorktree{
+			{Path: "/repo/main", Branch: "main"},
+			{Path: "/repo/feat/a", Branch: "feat/a"},
+			{Path: "/repo/spike/x", Branch: "spike/x"},
+			{Path: "/repo/keep/y", Branch: "keep/y"},
+		},
+		MergedBranches: map[string][]string{
+			"main": {"feat/a", "spike/x", "keep/y"},
+		},
+	}
+
+	cmd := &CleanCommand{
+		FS:     &testutil.MockFS{},
+		Git:    &GitRunner{Executor: mockGit, Log: NewNopLogger()},
+		Config: &Config{WorktreeSourceDir: "/repo/main", CleanExclude: []string{"keep/*"}},
+		Log:    NewNopLogger(),
+	}
+
+	result, err := cmd.Run(t.Context(), "/other/dir", CleanOptions{Check: true, Exclude: []string{"spike/*"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got := make(map[string]SkipReason)
+	for _, c := range result.Candidates {
+		got[c.Branch] = c.SkipReason
+	}
+	want := map[string]SkipReason{"feat/a": "", "spike/x": SkipExcluded, "keep/y": SkipExcluded}
+	if !maps.Equal(got, want) {
+		t.Errorf("skip reasons = %v, want %v", got, want)
+	}
+
+	if _, err := cmd.Run(t.Context(), "/other/dir", CleanOptions{Check: true, Exclude: []string{"spike/["}}); err == nil || !strings.Contains(err.Error(), "invalid exclude pattern") {
+		t.Errorf("error = %v, want invalid exclude pattern", err)
+	}
+}
+
+func TestCleanCommand_Run_AuditLog(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		appendErr     error
+		wantEntries   int
+		wantAuditErr  bool
+		wantStderrMsg string
+	}{
+		{
+			name:        "records_removed_worktrees",
+			wantEntries: 1,
+		},
+		{
+			name:          "append_failure_is_warning",
+			appendErr:     errors.New("disk full"),
+			wantAuditErr:  true,
+			wantStderrMsg: "warning: failed to append audit log: disk full",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockGit := &testutil.MockGitExecutor{
+				Worktrees: []testutil.MockWorktree{
+					{Path: "/repo/main", Branch: "main", HEAD: "0000000000"},
+					{Path: "/repo/feat/a", Branch: "feat/a", HEAD: "abc1234567"},
+					{Path: "/repo/feat/b", Branch: "feat/b", HEAD: "def5678901"},
+				},
+				MergedBranches: map[string][]string{
+					"main": {"main", "feat/a"},
+				},
+				GitCommonDir: "/repo/main/.git",
+			}
+			mockFS := &testutil.MockFS{
+				WrittenFiles:  map[string][]byte{},
+				AppendFileErr: tt.appendErr,
+			}
+			git := &GitRunner{Executor: mockGit, Dir: "/repo/main", Log: NewNopLogger()}
+
+			cmd := &CleanCommand{
+				FS:     mockFS,
+				Git:    git,
+				Config: &Config{WorktreeSourceDir: "/repo/main", DefaultSource: "main"},
+				Log:    NewNopLogger(),
+				Audit:  NewAuditLog(mockFS, git),
+			}
+
+			result, err := cmd.Run(t.Context(), "/other/dir", CleanOptions{Yes: true})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(result.Removed) != 1 {
+				t.Fatalf("got %d removed, want 1", len(result.Removed))
+			}
+			if (result.AuditErr != nil) != tt.wantAuditErr {
+				t.Fatalf("AuditErr = %v, want error = %v", result.AuditErr, tt.wantAuditErr)
+			}
+			if tt.wantStderrMsg != "" {
+				formatted := result.Format(CleanFormatOptions{})
+				if !strings.Contains(formatted.Stderr, tt.wantStderrMsg) {
+					t.Errorf("Stderr = %q, want to contain %q", formatted.Stderr, tt.wantStderrMsg)
+				}
+			}
+			if tt.wantEntries == 0 {
+				return
+			}
+
+			entries, _, err := cmd.Audit.Read(t.Context())
+			if err != nil {
+				t.Fatalf("Read() error = %v", err)
+			}
+			if len(entries) != tt.wantEntries {
+				t.Fatalf("got %d audit entries, want %d", len(entries), tt.wantEntries)
+			}
+			e := entries[0]
+			if e.Command != AuditCommandClean || e.Branch != "feat/a" || e.WorktreePath != "/repo/feat/a" {
+				t.Errorf("entry = %+v, want clean of feat/a at /repo/feat/a", e)
+			}
+			if e.HEAD != "abc1234567" {
+				t.Errorf("HEAD = %q, want %q", e.HEAD, "abc1234567")
+			}
+			if e.Reason != string(CleanMerged) || e.Target != "main" {
+				t.Errorf("Reason/Target = %q/%q, want %q/%q", e.Reason, e.Target, CleanMerged, "main")
+			}
+			if e.Time.IsZero() {
+				t.Error("Time is zero")
+			}
+		})
+	}
+}
+
+func TestCleanCommand_Run_PRState(t *testing.T) {
+	t.Parallel()
+
+	const cachePath = "/repo/main/.git/twig/forge-cache.json"
+
+	tests := []struct {
+		name            string
+		state           PRState
+		statusOutput    string
+		remoteURLs      map[string]string
+		wantSkipped     bool
+		wantCleanReason CleanReason
+		wantForgeErr    bool
+	}{
+		{
+			name:            "merged_pr_is_cleanable",
+			state:           PRStateMerged,
+			wantCleanReason: CleanPRMerged,
+		},
+		{
+			name:            "closed_pr_is_cleanable",
+			state:           PRStateClosed,
+			wantCleanReason: CleanPRClosed,
+		},
+		{
+			name:        "open_pr_stays_not_merged",
+			state:       PRStateOpen,
+			wantSkipped: true,
+		},
+		{
+			name:            "merged_pr_with_changes_only_gains_reason",
+			state:           PRStateMerged,
+			statusOutput:    " M file.go",
+			wantSkipped:     true,
+			wantCleanReason: CleanPRMerged,
+		},
+		{
+			name:         "lookup_failure_is_warning",
+			remoteURLs:   map[string]string{},
+			wantSkipped:  true,
+			wantForgeErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			written := map[string][]byte{}
+			if tt.state != PRStateNone {
+				data, err := json.Marshal(map[string]forgeCacheEntry{
+					"feat/rebased": {HEAD: "abc1234567", State: tt.state, ComputedAt: time.Now()},
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+				written[cachePath] = data
+			}
+
+			var captured []string
+			mockGit := &testutil.MockGitExecutor{
+				Worktrees: []testutil.MockWorktree{
+					{Path: "/repo/main", Branch: "main", HEAD: "0000000000"},
+					{Path: "/repo/feat/rebased", Branch: "feat/rebased", HEAD: "abc1234567"},
+				},
+				MergedBranches: map[string][]string{
+					"main": {"main"},
+				},
+				StatusOutput: tt.statusOutput,
+				GitCommonDir: "/repo/main/.git",
+				RemoteURLs:   tt.remoteURLs,
+				CapturedArgs: &captured,
+			}
+			mockFS := &testutil.MockFS{WrittenFiles: written}
+			git := &GitRunner{Executor: mockGit, Dir: "/repo/main", Log: NewNopLogger()}
+
+			cmd := &CleanCommand{
+				FS:     mockFS,
+				Git:    git,
+				Config: &Config{WorktreeSourceDir: "/repo/main"},
+				Log:    NewNopLogger(),
+				Forge:  NewForgeClient(mockFS, git, ForgeGitHub, "", nil),
+			}
+
+			result, err := cmd.Run(t.Context(), "/other/dir", CleanOptions{Check: true})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(result.Candidates) != 1 {
+				t.Fatalf("got %d candidates, want 1", len(result.Candidates))
+			}
+			cand := result.Candidates[0]
+			if cand.Skipped != tt.wantSkipped {
+				t.Errorf("Skipped = %v, want %v", cand.Skipped, tt.wantSkipped)
+			}
+			if cand.CleanReason != tt.wantCleanReason {
+				t.Errorf("CleanReason = %q, want %q", cand.CleanReason, tt.wantCleanReason)
+			}
+			if (result.ForgeErr != nil) != tt.wantForgeErr {
+				t.Errorf("ForgeErr = %v, want error = %v", result.ForgeErr, tt.wantForgeErr)
+			}
+			if tt.wantForgeErr {
+				formatted := result.Format(CleanFormatOptions{})
+				if !strings.Contains(formatted.Stderr, "warning: PR lookup failed:") {
+					t.Errorf("Stderr = %q, want PR lookup warning", formatted.Stderr)
+				}
+			}
+			if tt.wantSkipped {
+				return
+			}
+
+			// Rebase-merged commits differ from the target, so the branch needs -D
+			if _, err := cmd.Run(t.Context(), "/other/dir", CleanOptions{Yes: true}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !slices.Contains(captured, "-D") {
+				t.Errorf("branch delete args = %v, want -D", captured)
+			}
+		})
+	}
+}
+
+func TestCleanCommand_Run_Verify(t *testing.T) {
+	t.Parallel()
+
+	mockGit := &testutil.MockGitExecutor{
+		Worktrees: []testutil.MockWorktree{
+			{Path: "/repo/main", Branch: "main"},
+			{Path: "/repo/feat/a", Branch: "feat/a"},
+			{Path: "/repo/feat/db", Branch: "feat/db"},
+			{Path: "/repo/feat/wip", Branch: "feat/wip"},
+		},
+		MergedBranches: map[string][]string{
+			"main": {"main", "feat/a", "feat/db"},
+		},
+	}
+
+	var (
+		mu       sync.Mutex
+		verified []string
+	)
+	cmd := &CleanCommand{
+		FS:  &testutil.MockFS{},
+		Git: &GitRunner{Executor: mockGit, Log: NewNopLogger()},
+		Config: &Config{
+			WorktreeSourceDir:  "/repo/main",
+			CleanVerifyCommand: "./check.sh {path}",
+		},
+		Log: NewNopLogger(),
+		Verify: func(ctx context.Context, dir, command string, env []string) ([]byte, error) {
+			mu.Lock()
+			verified = append(verified, command)
+			mu.Unlock()
+			if dir == "/repo/feat/db" {
+				return []byte("dump.sql not uploaded\n"), errors.New("exit status 1")
+			}
+			return nil, nil
+		},
+	}
+
+	result, err := cmd.Run(t.Context(), "/other/dir", CleanOptions{Check: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Only worktrees that would be removed are verified
+	want := []string{"./check.sh '/repo/feat/a'", "./check.sh '/repo/feat/db'"}
+	if !slices.Equal(verified, want) {
+		t.Errorf("verified = %q, want %q", verified, want)
+	}
+
+	byBranch := make(map[string]CleanCandidate)
+	for _, c := range result.Candidates {
+		byBranch[c.Branch] = c
+	}
+	if c := byBranch["feat/a"]; c.Skipped {
+		t.Errorf("feat/a skipped with %q, want cleanable", c.SkipReason)
+	}
+	db := byBranch["feat/db"]
+	if !db.Skipped || db.SkipReason != SkipVerifyFailed {
+		t.Errorf("feat/db = %+v, want skipped with %q", db, SkipVerifyFailed)
+	}
+	if db.VerifyOutput != "dump.sql not uploaded" {
+		t.Errorf("VerifyOutput = %q", db.VerifyOutput)
+	}
+	if c := byBranch["feat/wip"]; c.SkipReason != SkipNotMerged {
+		t.Errorf("feat/wip SkipReason = %q, want %q", c.SkipReason, SkipNotMerged)
+	}
+}
+
+func TestCleanCommand_Run_Unpushed(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		remotes      []string
+		force        WorktreeForceLevel
+		wantSkipped  map[string]bool
+		wantUnpushed int
+	}{
+		{
+			name:         "skips unpushed branch",
+			remotes:      []string{"origin"},
+			wantSkipped:  map[string]bool{"feat/pushed": false, "feat/local": true, "feat/gone": false},
+			wantUnpushed: 2,
+		},
+		{
+			name:        "force bypasses",
+			remotes:     []string{"origin"},
+			force:       WorktreeForceLevelUnclean,
+			wantSkipped: map[string]bool{"feat/pushed": false, "feat/local": false, "feat/gone": false},
+		},
+		{
+			name:        "no remotes",
+			wantSkipped: map[string]bool{"feat/pushed": false, "feat/local": false, "feat/gone": false},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockGit := &testutil.MockGitExecutor{
+				Worktrees: []testutil.MockWorktree{
+					{Path: "/repo/main", Branch: "main"},
+					{Path: "/repo/feat/pushed", Branch: "feat/pushed"},
+					{Path: "/repo/feat/local", Branch: "feat/local"},
+					{Path: "/repo/feat/gone", Branch: "feat/gone"},
+				},
+				MergedBranches: map[string][]string{
+					"main": {"main", "feat/pushed", "feat/local"},
+				},
+				UpstreamGoneBranches: []string{"feat/gone"},
+				Remotes:              tt.remotes,
+				UnpushedCommits:      map[string]int{"feat/local": 2, "feat/gone": 3},
+			}
+
+			cmd := &CleanCommand{
+				FS:     &testutil.MockFS{},
+				Git:    &GitRunner{Executor: mockGit, Log: NewNopLogger()},
+				Config: &Config{WorktreeSourceDir: "/repo/main"},
+				Log:    NewNopLogger(),
+			}
+
+			result, err := cmd.Run(t.Context(), "/other/dir", CleanOptions{Check: true, Force: tt.force})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			for _, c := range result.Candidates {
+				want, ok := tt.wantSkipped[c.Branch]
+				if !ok {
+					continue
+				}
+				if c.Skipped != want {
+					t.Errorf("%s: Skipped = %v (%s), want %v", c.Branch, c.Skipped, c.SkipReason, want)
+				}
+				if c.Skipped && (c.SkipReason != SkipUnpushedCommits || c.Unpushed != tt.wantUnpushed) {
+					t.Errorf("%s: SkipReason = %q, Unpushed = %d, want %q with %d", c.Branch, c.SkipReason, c.Unpushed, SkipUnpushedCommits, tt.wantUnpushed)
+				}
+			}
+		})
+	}
+}
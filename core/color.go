@@ -0,0 +1,12 @@
+package core
+
+import "github.com/708u/twig/format"
+
+// SetColorTheme overrides the default colors with the [colors] settings.
+// Empty settings keep the default; LoadConfig has already dropped
+// invalid ones.
+func SetColorTheme(theme ColorsConfig) {
+	for _, c := range theme.settings() {
+		_ = format.SetColor(c.name, *c.value)
+	}
+}
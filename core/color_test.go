@@ -0,0 +1,31 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/708u/twig/format"
+	"github.com/fatih/color"
+)
+
+func TestSetColorTheme(t *testing.T) {
+	// Save original state
+	original := color.NoColor
+	originalClean, originalError := format.ColorClean, format.ColorError
+	defer func() {
+		color.NoColor = original
+		format.ColorClean, format.ColorError = originalClean, originalError
+	}()
+
+	color.NoColor = false
+	SetColorTheme(ColorsConfig{Clean: "blue", Error: "normal"})
+
+	if got, want := format.ColorClean("clean:"), color.New(color.FgBlue).Sprint("clean:"); got != want {
+		t.Errorf("format.ColorClean() = %q, want %q", got, want)
+	}
+	if got := format.ColorError("error:"); got != "error:" {
+		t.Errorf("format.ColorError() = %q, want uncolored with normal", got)
+	}
+	if got, want := format.ColorSkip("skip:"), color.New(color.FgYellow, color.Bold).Sprint("skip:"); got != want {
+		t.Errorf("format.ColorSkip() = %q, want default %q", got, want)
+	}
+}
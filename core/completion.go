@@ -0,0 +1,252 @@
+package core
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// completionCacheFileName is stored under <git-common-dir>/twig.
+	completionCacheFileName = "completion-cache.json"
+
+	// gitDirFilePrefix starts the .git file of a linked worktree.
+	gitDirFilePrefix = "gitdir:"
+
+	// commonDirFile in a worktree's git dir points to the common dir.
+	commonDirFile = "commondir"
+
+	// headFile holds the checked out branch of a worktree.
+	headFile = "HEAD"
+)
+
+// Completion cache keys for the candidate lists used by shell completion.
+const (
+	CompletionKeyBranches         = "branches"          // Local branches
+	CompletionKeyWorktreeBranches = "worktree-branches" // Branches checked out in any worktree
+	CompletionKeyLinkedBranches   = "linked-branches"   // Branches checked out in linked worktrees
+)
+
+// completionCacheFile is the on-disk completion cache. All entries are
+// dropped when the stamp of the ref and worktree metadata changes.
+type completionCacheFile struct {
+	Stamp   string              `json:"stamp"`
+	Entries map[string][]string `json:"entries"`
+}
+
+// CompletionCache memoizes shell completion candidates per repository.
+// Completion runs on every TAB, and listing branches or worktrees through
+// git is slow on large repositories or network filesystems. Entries are
+// keyed on the modification times of refs, packed-refs and the worktree
+// metadata, which are read from the filesystem without running git.
+type CompletionCache struct {
+	FS       FileSystem
+	Git      *GitRunner
+	Log      *slog.Logger
+	Disabled bool // Always load candidates (--no-cache)
+}
+
+// NewCompletionCache creates a CompletionCache with explicit dependencies.
+func NewCompletionCache(fs FileSystem, git *GitRunner, log *slog.Logger) *CompletionCache {
+	if log == nil {
+		log = NewNopLogger()
+	}
+	return &CompletionCache{FS: fs, Git: git, Log: log}
+}
+
+// NewDefaultCompletionCache creates a CompletionCache with production defaults.
+func NewDefaultCompletionCache(git *GitRunner, log *slog.Logger) *CompletionCache {
+	return NewCompletionCache(git.defaultFS(), git, log)
+}
+
+// Get returns the cached candidates for key, calling load and storing
+// its result when the cache is missing, stale or disabled.
+func (c *CompletionCache) Get(ctx context.Context, key string, load func(context.Context) ([]string, error)) ([]string, error) {
+	if c.Disabled {
+		return load(ctx)
+	}
+
+	commonDir := c.commonDir(ctx)
+	if commonDir == "" {
+		return load(ctx)
+	}
+	cachePath := filepath.Join(commonDir, auditDirName, completionCacheFileName)
+	stamp := completionStamp(c.FS, commonDir)
+
+	cache := c.loadCache(ctx, cachePath)
+	if cache.Stamp == stamp {
+		if values, ok := cache.Entries[key]; ok {
+			c.Log.DebugContext(ctx, "completion cache hit",
+				LogAttrKeyCategory.String(), LogCategoryCompletion,
+				"key", key)
+			return values, nil
+		}
+	} else {
+		cache = completionCacheFile{Stamp: stamp}
+	}
+
+	values, err := load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if cache.Entries == nil {
+		cache.Entries = make(map[string][]string)
+	}
+	cache.Entries[key] = values
+	c.saveCache(ctx, cachePath, cache)
+	return values, nil
+}
+
+// commonDir resolves the git common dir from the .git entry above
+// Git.Dir, falling back to git when the layout is not recognized.
+// An empty result disables the cache.
+func (c *CompletionCache) commonDir(ctx context.Context) string {
+	if dir := findGitCommonDir(c.FS, c.Git.Dir); dir != "" {
+		return dir
+	}
+	dir, err := c.Git.GitCommonDir(ctx)
+	if err != nil {
+		c.Log.DebugContext(ctx, "completion cache disabled",
+			LogAttrKeyCategory.String(), LogCategoryCompletion,
+			"error", err.Error())
+		return ""
+	}
+	return dir
+}
+
+// loadCache reads the completion cache. Any failure yields an empty cache.
+func (c *CompletionCache) loadCache(ctx context.Context, cachePath string) completionCacheFile {
+	var cache completionCacheFile
+	data, err := c.FS.ReadFile(cachePath)
+	if err != nil {
+		return cache
+	}
+	if err := json.Unmarshal(data, &cache); err != nil {
+		c.Log.DebugContext(ctx, "ignoring corrupt completion cache",
+			LogAttrKeyCategory.String(), LogCategoryCompletion,
+			"error", err.Error())
+		return completionCacheFile{}
+	}
+	return cache
+}
+
+// saveCache writes the completion cache. Failures are logged only.
+func (c *CompletionCache) saveCache(ctx context.Context, cachePath string, cache completionCacheFile) {
+	data, err := json.Marshal(cache)
+	if err == nil {
+		err = c.FS.MkdirAll(filepath.Dir(cachePath), 0755)
+	}
+	if err == nil {
+		err = c.FS.WriteFile(cachePath, data, 0644)
+	}
+	if err != nil {
+		c.Log.DebugContext(ctx, "failed to write completion cache",
+			LogAttrKeyCategory.String(), LogCategoryCompletion,
+			"error", err.Error())
+	}
+}
+
+// findGitCommonDir locates the git common dir for dir by reading the
+// nearest .git directory or file. Returns "" when none is found.
+func findGitCommonDir(fsys FileSystem, dir string) string {
+	for current := dir; ; {
+		dotGit := filepath.Join(current, ".git")
+		if info, err := fsys.Stat(dotGit); err == nil {
+			if info.IsDir() {
+				return dotGit
+			}
+			return commonDirFromGitFile(fsys, dotGit)
+		}
+		parent := filepath.Dir(current)
+		if parent == current {
+			return ""
+		}
+		current = parent
+	}
+}
+
+// commonDirFromGitFile follows the "gitdir:" line of a linked worktree's
+// .git file and the commondir file of the git dir it points to.
+func commonDirFromGitFile(fsys FileSystem, gitFile string) string {
+	data, err := fsys.ReadFile(gitFile)
+	if err != nil {
+		return ""
+	}
+	gitDir := gitDirFromGitFile(data, filepath.Dir(gitFile))
+	if gitDir == "" {
+		return ""
+	}
+
+	data, err = fsys.ReadFile(filepath.Join(gitDir, commonDirFile))
+	if err != nil {
+		return gitDir
+	}
+	commonDir := strings.TrimSpace(string(data))
+	if !filepath.IsAbs(commonDir) {
+		commonDir = filepath.Join(gitDir, commonDir)
+	}
+	return filepath.Clean(commonDir)
+}
+
+// gitDirFromGitFile returns the git dir named by the "gitdir:" line of
+// the .git file content data of the worktree at dir, or "" if there is
+// none.
+func gitDirFromGitFile(data []byte, dir string) string {
+	gitDir, ok := strings.CutPrefix(strings.TrimSpace(string(data)), gitDirFilePrefix)
+	if !ok {
+		return ""
+	}
+	gitDir = strings.TrimSpace(gitDir)
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(dir, gitDir)
+	}
+	return filepath.Clean(gitDir)
+}
+
+// completionStamp fingerprints the metadata completion candidates are
+// derived from: every directory under refs (creating, deleting or renaming
+// a loose ref updates its directory), packed-refs, HEAD, and the worktree
+// admin directories with their HEAD files.
+func completionStamp(fsys FileSystem, commonDir string) string {
+	h := sha256.New()
+	stamp := func(path string) {
+		info, err := fsys.Stat(path)
+		if err != nil {
+			fmt.Fprintf(h, "%s\x00-\n", path)
+			return
+		}
+		fmt.Fprintf(h, "%s\x00%d\x00%d\n", path, info.ModTime().UnixNano(), info.Size())
+	}
+
+	var walk func(dir string)
+	walk = func(dir string) {
+		stamp(dir)
+		entries, err := fsys.ReadDir(dir)
+		if err != nil {
+			return
+		}
+		for _, entry := range entries {
+			if entry.IsDir() {
+				walk(filepath.Join(dir, entry.Name()))
+			}
+		}
+	}
+	walk(filepath.Join(commonDir, "refs"))
+
+	stamp(filepath.Join(commonDir, packedRefsFile))
+	stamp(filepath.Join(commonDir, headFile))
+
+	adminDir := filepath.Join(commonDir, worktreesAdminDir)
+	stamp(adminDir)
+	if entries, err := fsys.ReadDir(adminDir); err == nil {
+		for _, entry := range entries {
+			stamp(filepath.Join(adminDir, entry.Name(), headFile))
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,174 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestCompletionCache_Get(t *testing.T) {
+	t.Parallel()
+
+	setup := func(t *testing.T) (string, *CompletionCache) {
+		t.Helper()
+		repoDir := t.TempDir()
+		if err := os.MkdirAll(filepath.Join(repoDir, ".git", "refs", "heads"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		git := &GitRunner{Dir: repoDir, Log: NewNopLogger()}
+		return repoDir, NewCompletionCache(osFS{}, git, nil)
+	}
+	counting := func(calls *int, values ...string) func(context.Context) ([]string, error) {
+		return func(context.Context) ([]string, error) {
+			*calls++
+			return values, nil
+		}
+	}
+
+	t.Run("reuses_entry_until_refs_change", func(t *testing.T) {
+		t.Parallel()
+
+		repoDir, cache := setup(t)
+		var calls int
+
+		for range 2 {
+			got, err := cache.Get(t.Context(), CompletionKeyBranches, counting(&calls, "main"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got, []string{"main"}) {
+				t.Errorf("Get() = %v, want [main]", got)
+			}
+		}
+		if calls != 1 {
+			t.Errorf("load called %d times, want 1", calls)
+		}
+
+		// A new loose ref directory updates the mtime of refs/heads
+		headsDir := filepath.Join(repoDir, ".git", "refs", "heads")
+		if err := os.Mkdir(filepath.Join(headsDir, "feat"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		future := time.Now().Add(time.Minute)
+		if err := os.Chtimes(headsDir, future, future); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := cache.Get(t.Context(), CompletionKeyBranches, counting(&calls, "feat/a", "main"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(got, []string{"feat/a", "main"}) {
+			t.Errorf("Get() after ref change = %v, want [feat/a main]", got)
+		}
+		if calls != 2 {
+			t.Errorf("load called %d times, want 2", calls)
+		}
+	})
+
+	t.Run("keys_are_independent", func(t *testing.T) {
+		t.Parallel()
+
+		_, cache := setup(t)
+		var calls int
+
+		if _, err := cache.Get(t.Context(), CompletionKeyBranches, counting(&calls, "main", "feat/a")); err != nil {
+			t.Fatal(err)
+		}
+		got, err := cache.Get(t.Context(), CompletionKeyWorktreeBranches, counting(&calls, "main"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(got, []string{"main"}) {
+			t.Errorf("Get() = %v, want [main]", got)
+		}
+		if calls != 2 {
+			t.Errorf("load called %d times, want 2", calls)
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		t.Parallel()
+
+		repoDir, cache := setup(t)
+		cache.Disabled = true
+		var calls int
+
+		for range 2 {
+			if _, err := cache.Get(t.Context(), CompletionKeyBranches, counting(&calls, "main")); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if calls != 2 {
+			t.Errorf("load called %d times, want 2", calls)
+		}
+		if _, err := os.Stat(filepath.Join(repoDir, ".git", auditDirName, completionCacheFileName)); !os.IsNotExist(err) {
+			t.Errorf("cache file written while disabled, err = %v", err)
+		}
+	})
+
+	t.Run("load_error_not_cached", func(t *testing.T) {
+		t.Parallel()
+
+		_, cache := setup(t)
+		var calls int
+
+		_, err := cache.Get(t.Context(), CompletionKeyBranches, func(context.Context) ([]string, error) {
+			return nil, errors.New("git failed")
+		})
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		if _, err := cache.Get(t.Context(), CompletionKeyBranches, counting(&calls, "main")); err != nil {
+			t.Fatal(err)
+		}
+		if calls != 1 {
+			t.Errorf("load called %d times, want 1", calls)
+		}
+	})
+}
+
+func TestFindGitCommonDir(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	commonDir := filepath.Join(root, "main", ".git")
+	adminDir := filepath.Join(commonDir, worktreesAdminDir, "feat-a")
+	wtDir := filepath.Join(root, "feat-a")
+	for _, dir := range []string{adminDir, filepath.Join(root, "main", "sub"), filepath.Join(wtDir, "sub")} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(wtDir, ".git"), []byte("gitdir: "+adminDir+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(adminDir, commonDirFile), []byte("../..\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		dir  string
+		want string
+	}{
+		{"main_worktree", filepath.Join(root, "main"), commonDir},
+		{"main_worktree_subdir", filepath.Join(root, "main", "sub"), commonDir},
+		{"linked_worktree_subdir", filepath.Join(wtDir, "sub"), commonDir},
+		{"outside_repository", root, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := findGitCommonDir(osFS{}, tt.dir); got != tt.want {
+				t.Errorf("findGitCommonDir(%s) = %q, want %q", tt.dir, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,1130 @@
+package core
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/708u/twig/format"
+	"github.com/BurntSushi/toml"
+)
+
+const (
+	configDir           = ".twig"
+	configFileName      = "settings.toml"
+	localConfigFileName = "settings.local.toml"
+)
+
+// Config holds the merged configuration for the application.
+// All path fields are resolved to absolute paths by LoadConfig.
+type Config struct {
+	Symlinks             []string           `toml:"symlinks" doc:"Glob patterns for files to symlink from the source worktree to new worktrees"`
+	ExtraSymlinks        []string           `toml:"extra_symlinks" doc:"Additional symlink patterns, collected from both project and local configs"`
+	WorktreeDestBaseDir  string             `toml:"worktree_destination_base_dir" doc:"Base directory where new worktrees are created"`
+	DefaultSource        string             `toml:"default_source" doc:"Default branch to use as source when creating new worktrees"`
+	WorktreeSourceDir    string             // Set by LoadConfig to the config load directory
+	InitSubmodules       *bool              `toml:"init_submodules" doc:"Initialize submodules when creating worktrees" default:"false"`                               // nil=unset, true=enable, false=disable
+	SubmoduleReference   *bool              `toml:"submodule_reference" doc:"Reuse objects from the main worktree when initializing submodules" default:"false"`       // nil=unset, true=enable, false=disable
+	SubmodulePaths       []string           `toml:"submodule_paths" doc:"Submodules to initialize (paths or glob patterns, default: all)"`                             // Empty = all submodules
+	SubmoduleRecursive   *bool              `toml:"submodule_recursive" doc:"Also initialize nested submodules" default:"true"`                                        // nil=unset (enabled), true=enable, false=disable
+	LookupRemoteBranches *bool              `toml:"lookup_remote_branches" doc:"Ask the remotes for branches not known locally when adding worktrees" default:"false"` // nil=unset, true=enable, false=disable
+	FetchOnAdd           *bool              `toml:"fetch_on_add" doc:"Always enable --fetch for twig add" default:"false"`                                             // nil=unset, true=enable, false=disable
+	CleanStale           *bool              `toml:"clean_stale" doc:"Always enable --stale for twig clean" default:"false"`                                            // nil=unset, true=enable, false=disable
+	CleanFetch           *bool              `toml:"clean_fetch" doc:"Always enable --fetch for twig clean" default:"false"`                                            // nil=unset, true=enable, false=disable
+	CleanOnlyTwigManaged *bool              `toml:"clean_only_twig_managed" doc:"Only let twig clean remove worktrees created or adopted by twig" default:"false"`     // nil=unset, true=enable, false=disable
+	CleanupEmptyDirs     *bool              `toml:"cleanup_empty_dirs" doc:"Remove parent directories left empty by remove, clean and rename" default:"true"`          // nil=unset (enabled), true=enable, false=disable
+	DetectSquashMerges   *bool              `toml:"detect_squash_merges" doc:"Detect squash-merged branches as cleanable" default:"false"`                             // nil=unset, true=enable, false=disable
+	StrictSymlinks       *bool              `toml:"strict_symlinks" doc:"Refuse symlinks whose source resolves outside the source worktree" default:"false"`           // nil=unset, true=enable, false=disable
+	SymlinkStyle         string             `toml:"symlink_style" doc:"Whether symlinks point at their source by a relative or an absolute path" enum:"relative,absolute" default:"relative"`
+	SubmoduleRefDir      string             `toml:"submodule_reference_dir" doc:"Directory of submodule repositories laid out like .git/modules, used as --reference before the main worktree"`
+	ProtectedBranches    []string           `toml:"protected_branches" doc:"Branches that are never removed by twig remove or twig clean"`
+	CleanExclude         []string           `toml:"clean_exclude" doc:"Branches that twig clean never offers for removal"`
+	Hooks                []string           `toml:"hooks" doc:"Commands to run after worktree creation"`
+	BranchPrefix         string             `toml:"branch_prefix" doc:"Prefix added to branch names created by twig add"`
+	BranchAliases        map[string]string  `toml:"branch_aliases" doc:"Short names for twig add that map to full branch names"` // alias -> branch name
+	OpenCommand          string             `toml:"open_command" doc:"Shell command run by twig open; {path} is the worktree path"`
+	CleanVerifyCommand   string             `toml:"clean_verify_command" doc:"Shell command run for each worktree twig clean would remove; {path} is the worktree path, and a non-zero exit keeps the worktree"`
+	PRBranchTemplate     string             `toml:"pr_branch_template" doc:"Branch name for twig add --pr; {number}, {title} and {head} are replaced" default:"pr/{number}-{title}"`
+	CaseCollisions       string             `toml:"case_collisions" doc:"What twig add does when a branch or worktree path differs only in case from an existing one" enum:"auto,error,warn" default:"auto"`
+	Forge                string             `toml:"forge" doc:"Forge to look up pull requests on, for PR state in clean and PR titles in add --pr" enum:",github,gitlab"`               // PR lookup for clean and add --pr: "github", "gitlab", or "" (disabled)
+	GitLockWait          string             `toml:"git_lock_wait" doc:"How long to wait for git locks before removing or moving a worktree (e.g. 30s)" default:"10s"`                   // Duration to wait for git locks before removing or moving worktrees
+	GitTimeout           string             `toml:"git_timeout" doc:"Maximum time a single git command may run before it is stopped (e.g. 60s)"`                                        // Empty = no limit
+	GitRemoteTimeout     string             `toml:"git_remote_timeout" doc:"Maximum time a git fetch, push, ls-remote or submodule command may run, in place of git_timeout (e.g. 5m)"` // Empty = git_timeout
+	ArchiveDir           string             `toml:"archive_dir" doc:"Directory for archives of uncommitted changes written by remove --archive and clean --archive"`                    // Empty = <git-common-dir>/twig/archives
+	Notify               *bool              `toml:"notify" doc:"Send a desktop notification when add, clean, remove or sync runs longer than notify_after" default:"false"`             // nil=unset, true=enable, false=disable
+	NotifyAfter          string             `toml:"notify_after" doc:"How long a command must run before notify sends a notification (e.g. 1m)" default:"30s"`
+	EnvFile              *bool              `toml:"env_file" doc:"Write a .twig.env file describing the worktree into new worktrees and refresh it on sync" default:"false"` // nil=unset, true=enable, false=disable
+	EnvFileVars          map[string]string  `toml:"env_file_vars" doc:"Extra variables written to .twig.env, collected from both project and local configs"`                 // name -> value
+	GC                   GCConfig           `toml:"gc" doc:"Worktree garbage collection policy enforced by twig gc"`
+	Colors               ColorsConfig       `toml:"colors" doc:"Colors of list, clean, remove and sync output"`
+	Profiles             map[string]Profile `toml:"profiles" doc:"Named sets of overrides selected with the global --profile flag"`
+	Profile              string             `toml:"-"` // Active profile name (empty = none)
+}
+
+// GCConfig is the [gc] policy: how many worktrees may exist besides the
+// main worktree, and for how long.
+type GCConfig struct {
+	MaxWorktrees int    `toml:"max_worktrees" doc:"Maximum number of worktrees besides the main worktree (0 = no limit)"`
+	MaxAge       string `toml:"max_age" doc:"Age after which worktrees are collected, in days or as a duration (e.g. 45d, 720h)"` // Empty = no limit
+	CheckOnAdd   *bool  `toml:"check_on_add" doc:"Print a hint after twig add when worktrees exceed the policy" default:"false"`  // nil=unset, true=enable, false=disable
+}
+
+// ColorsConfig is the [colors] theme. Each value is a color name with
+// optional attributes, like git's color settings (e.g. "bold red").
+type ColorsConfig struct {
+	Clean   string `toml:"clean" doc:"Header of worktrees clean removes" default:"bold green"`
+	Skip    string `toml:"skip" doc:"Header of worktrees clean skips" default:"bold yellow"`
+	Success string `toml:"success" doc:"Success markers and created symlinks" default:"green"`
+	Failure string `toml:"failure" doc:"Failure markers and replaced symlinks" default:"red"`
+	Main    string `toml:"main" doc:"Main worktree marker in list output" default:"cyan"`
+	Current string `toml:"current" doc:"Current worktree marker in list output" default:"bold green"`
+	Reason  string `toml:"reason" doc:"Skip and clean reasons" default:"brightblack"`
+	Error   string `toml:"error" doc:"Error prefixes" default:"red"`
+}
+
+// colorSetting is a [colors] value with its setting name.
+type colorSetting struct {
+	name  string
+	value *string
+}
+
+// settings returns the [colors] values in documentation order.
+func (c *ColorsConfig) settings() []colorSetting {
+	return []colorSetting{
+		{"clean", &c.Clean},
+		{"skip", &c.Skip},
+		{"success", &c.Success},
+		{"failure", &c.Failure},
+		{"main", &c.Main},
+		{"current", &c.Current},
+		{"reason", &c.Reason},
+		{"error", &c.Error},
+	}
+}
+
+// ShouldInitSubmodules returns whether submodule initialization is enabled.
+func (c *Config) ShouldInitSubmodules() bool {
+	if c.InitSubmodules != nil {
+		return *c.InitSubmodules
+	}
+	return false
+}
+
+// ShouldUseSubmoduleReference returns whether to use --reference for submodule init.
+// Setting submodule_reference_dir enables it unless submodule_reference is false.
+func (c *Config) ShouldUseSubmoduleReference() bool {
+	if c.SubmoduleReference != nil {
+		return *c.SubmoduleReference
+	}
+	return c.SubmoduleRefDir != ""
+}
+
+// SubmoduleReferenceOptions returns the SubmoduleUpdate options that use
+// submodule_reference_dir, then the main worktree at mainPath, as
+// references. mainPath may be empty when it cannot be determined.
+func (c *Config) SubmoduleReferenceOptions(mainPath string) []SubmoduleUpdateOption {
+	var opts []SubmoduleUpdateOption
+	if c.SubmoduleRefDir != "" {
+		opts = append(opts, WithSubmoduleReferenceDir(c.SubmoduleRefDir))
+	}
+	return append(opts, WithSubmoduleReference(mainPath))
+}
+
+// ShouldInitSubmodulesRecursively returns whether nested submodules are
+// initialized along with their parents.
+func (c *Config) ShouldInitSubmodulesRecursively() bool {
+	if c.SubmoduleRecursive != nil {
+		return *c.SubmoduleRecursive
+	}
+	return true
+}
+
+// SubmoduleUpdateOptions returns the SubmoduleUpdate options for
+// submodule_paths and submodule_recursive.
+func (c *Config) SubmoduleUpdateOptions() []SubmoduleUpdateOption {
+	var opts []SubmoduleUpdateOption
+	if len(c.SubmodulePaths) > 0 {
+		opts = append(opts, WithSubmodulePaths(c.SubmodulePaths))
+	}
+	if !c.ShouldInitSubmodulesRecursively() {
+		opts = append(opts, WithoutSubmoduleRecursion())
+	}
+	return opts
+}
+
+// ShouldCleanStale returns whether --stale behavior is enabled by default for clean.
+func (c *Config) ShouldCleanStale() bool {
+	if c.CleanStale != nil {
+		return *c.CleanStale
+	}
+	return false
+}
+
+// ShouldLookupRemoteBranches returns whether add asks the remotes for
+// branches missing from the local remote-tracking branches.
+func (c *Config) ShouldLookupRemoteBranches() bool {
+	if c.LookupRemoteBranches != nil {
+		return *c.LookupRemoteBranches
+	}
+	return false
+}
+
+// ShouldFetchOnAdd returns whether --fetch behavior is enabled by default for add.
+func (c *Config) ShouldFetchOnAdd() bool {
+	if c.FetchOnAdd != nil {
+		return *c.FetchOnAdd
+	}
+	return false
+}
+
+// ShouldCleanupEmptyDirs returns whether parent directories emptied by
+// removing a worktree are removed too. Enabled unless set to false.
+func (c *Config) ShouldCleanupEmptyDirs() bool {
+	if c.CleanupEmptyDirs != nil {
+		return *c.CleanupEmptyDirs
+	}
+	return true
+}
+
+// ShouldCleanFetch returns whether --fetch behavior is enabled by default for clean.
+func (c *Config) ShouldCleanFetch() bool {
+	if c.CleanFetch != nil {
+		return *c.CleanFetch
+	}
+	return false
+}
+
+// ShouldCleanOnlyTwigManaged returns whether clean skips worktrees that
+// have no provenance marker, i.e. were not created or adopted by twig.
+func (c *Config) ShouldCleanOnlyTwigManaged() bool {
+	if c.CleanOnlyTwigManaged != nil {
+		return *c.CleanOnlyTwigManaged
+	}
+	return false
+}
+
+// ShouldDetectSquashMerges returns whether squash-merged branches are detected via patch-id comparison.
+func (c *Config) ShouldDetectSquashMerges() bool {
+	if c.DetectSquashMerges != nil {
+		return *c.DetectSquashMerges
+	}
+	return false
+}
+
+// ShouldUseStrictSymlinks returns whether symlinks whose source is a chain
+// of symlinks or resolves outside the source worktree are refused rather
+// than created with a warning.
+func (c *Config) ShouldUseStrictSymlinks() bool {
+	if c.StrictSymlinks != nil {
+		return *c.StrictSymlinks
+	}
+	return false
+}
+
+// SymlinkTargetStyle returns the symlink_style setting, or
+// SymlinkStyleRelative when unset.
+func (c *Config) SymlinkTargetStyle() string {
+	if c == nil || c.SymlinkStyle == "" {
+		return SymlinkStyleRelative
+	}
+	return c.SymlinkStyle
+}
+
+// GitLockWaitDuration returns how long to wait for git locks held by other
+// git processes (e.g. a background gc) before removing or moving worktrees.
+func (c *Config) GitLockWaitDuration() time.Duration {
+	if c == nil || c.GitLockWait == "" {
+		return DefaultGitLockWait
+	}
+	d, err := time.ParseDuration(c.GitLockWait)
+	if err != nil || d < 0 {
+		return DefaultGitLockWait
+	}
+	return d
+}
+
+// GitTimeoutDuration returns how long a single git command may run before
+// it is stopped, or 0 for no limit.
+func (c *Config) GitTimeoutDuration() time.Duration {
+	if c == nil || c.GitTimeout == "" {
+		return 0
+	}
+	d, err := time.ParseDuration(c.GitTimeout)
+	if err != nil || d < 0 {
+		return 0
+	}
+	return d
+}
+
+// GitRemoteTimeoutDuration returns how long a git command talking to a
+// remote (see GitRemoteCommands) may run, or 0 for no limit. Unset, it
+// is GitTimeoutDuration.
+func (c *Config) GitRemoteTimeoutDuration() time.Duration {
+	if c == nil || c.GitRemoteTimeout == "" {
+		return c.GitTimeoutDuration()
+	}
+	d, err := time.ParseDuration(c.GitRemoteTimeout)
+	if err != nil || d < 0 {
+		return c.GitTimeoutDuration()
+	}
+	return d
+}
+
+// GCPolicy returns the [gc] policy. Invalid values are dropped by
+// LoadConfig, so an unparsable max_age means no age limit.
+func (c *Config) GCPolicy() GCPolicy {
+	policy := GCPolicy{MaxWorktrees: c.GC.MaxWorktrees}
+	if c.GC.MaxAge != "" {
+		if d, err := parseGCAge(c.GC.MaxAge); err == nil {
+			policy.MaxAge = d
+		}
+	}
+	return policy
+}
+
+// ShouldCheckGCOnAdd returns whether twig add reports worktrees over the
+// [gc] policy.
+func (c *Config) ShouldCheckGCOnAdd() bool {
+	if c.GC.CheckOnAdd != nil {
+		return *c.GC.CheckOnAdd
+	}
+	return false
+}
+
+// ShouldNotify returns whether long-running commands send a notification
+// when they finish.
+func (c *Config) ShouldNotify() bool {
+	if c != nil && c.Notify != nil {
+		return *c.Notify
+	}
+	return false
+}
+
+// ShouldWriteEnvFile returns whether worktrees get a generated .twig.env.
+func (c *Config) ShouldWriteEnvFile() bool {
+	if c != nil && c.EnvFile != nil {
+		return *c.EnvFile
+	}
+	return false
+}
+
+// NotifyAfterDuration returns how long a command must run before notify
+// sends a notification.
+func (c *Config) NotifyAfterDuration() time.Duration {
+	if c == nil || c.NotifyAfter == "" {
+		return DefaultNotifyAfter
+	}
+	d, err := time.ParseDuration(c.NotifyAfter)
+	if err != nil || d < 0 {
+		return DefaultNotifyAfter
+	}
+	return d
+}
+
+// IsProtectedBranch returns whether branch matches any protected_branches pattern.
+// Patterns use path.Match syntax, so "release/*" matches "release/1.0"
+// but not "release/1.0/hotfix".
+func (c *Config) IsProtectedBranch(branch string) bool {
+	return matchBranchPatterns(c.ProtectedBranches, branch)
+}
+
+// IsCleanExcluded returns whether branch matches any clean_exclude
+// pattern (path.Match syntax, as for protected_branches).
+func (c *Config) IsCleanExcluded(branch string) bool {
+	return matchBranchPatterns(c.CleanExclude, branch)
+}
+
+// matchBranchPatterns returns whether branch equals or matches (path.Match
+// syntax) any of patterns.
+func matchBranchPatterns(patterns []string, branch string) bool {
+	for _, pattern := range patterns {
+		if pattern == branch {
+			return true
+		}
+		if matched, err := path.Match(pattern, branch); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+// ResolveBranch maps the name given to twig add to a branch name and
+// the worktree directory name. An alias in branch_aliases maps to its
+// branch verbatim. Otherwise branch_prefix is prepended unless the name
+// already starts with it. The worktree is named after the short name.
+func (c *Config) ResolveBranch(name string) (branch, worktreeName string) {
+	if target, ok := c.BranchAliases[name]; ok && target != "" {
+		return target, name
+	}
+	if c.BranchPrefix == "" {
+		return name, name
+	}
+	if short, ok := strings.CutPrefix(name, c.BranchPrefix); ok && short != "" {
+		return name, short
+	}
+	return c.BranchPrefix + name, name
+}
+
+// PRBranchName returns the branch name for pr from pr_branch_template,
+// or DefaultPRBranchTemplate when unset.
+func (c *Config) PRBranchName(pr PullRequest) string {
+	template := DefaultPRBranchTemplate
+	if c != nil && c.PRBranchTemplate != "" {
+		template = c.PRBranchTemplate
+	}
+	return expandPRBranch(template, pr)
+}
+
+// LoadConfigResult contains the loaded config and any warnings.
+type LoadConfigResult struct {
+	Config   *Config
+	Warnings []string
+}
+
+type loadConfigOptions struct {
+	mainWorktreeDir string
+	profile         string
+	getenv          func(string) string
+}
+
+// LoadConfigOption configures LoadConfig behavior.
+type LoadConfigOption func(*loadConfigOptions)
+
+// WithMainWorktreeDir sets the main worktree directory used as the base
+// for resolving relative WorktreeDestBaseDir paths and the default path.
+// Without this option, the config load directory (dir) is used as fallback.
+func WithMainWorktreeDir(dir string) LoadConfigOption {
+	return func(o *loadConfigOptions) {
+		o.mainWorktreeDir = dir
+	}
+}
+
+// WithProfile selects a profile defined under [profiles.<name>].
+// Profile settings override the merged project and local settings.
+// An empty name selects no profile.
+func WithProfile(name string) LoadConfigOption {
+	return func(o *loadConfigOptions) {
+		o.profile = name
+	}
+}
+
+// WithGetenv replaces os.Getenv for reading TWIG_* setting overrides
+// (for testing).
+func WithGetenv(getenv func(string) string) LoadConfigOption {
+	return func(o *loadConfigOptions) {
+		o.getenv = getenv
+	}
+}
+
+func newLoadConfigOptions(opts []LoadConfigOption) loadConfigOptions {
+	o := loadConfigOptions{getenv: os.Getenv}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// LoadConfig loads and merges the project and local config in dir.
+// Precedence (lowest to highest): project, TWIG_* environment variables,
+// local, selected profile. CLI flags are applied on top by each command.
+func LoadConfig(dir string, opts ...LoadConfigOption) (*LoadConfigResult, error) {
+	o := newLoadConfigOptions(opts)
+
+	projCfg, projRenamed, err := loadConfigFile(filepath.Join(dir, configDir, configFileName))
+	if err != nil {
+		return nil, err
+	}
+
+	// Environment overrides act as part of the project config, so the
+	// local config and profiles still override them
+	envCfg, warnings := envConfig(o.getenv)
+	projCfg = overlayConfig(projCfg, envCfg)
+
+	localCfg, localRenamed, err := loadConfigFile(filepath.Join(dir, configDir, localConfigFileName))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, f := range []struct {
+		name    string
+		renamed []ConfigKeyRename
+	}{{configFileName, projRenamed}, {localConfigFileName, localRenamed}} {
+		for _, r := range f.renamed {
+			warnings = append(warnings, fmt.Sprintf("%s: %s is deprecated, use %s (run twig config migrate)",
+				filepath.Join(configDir, f.name), r.Old, r.New))
+		}
+	}
+
+	// profiles: merged by name, local fields override project fields
+	var profiles map[string]Profile
+	for _, cfg := range []*Config{projCfg, localCfg} {
+		if cfg == nil {
+			continue
+		}
+		for name, p := range cfg.Profiles {
+			if profiles == nil {
+				profiles = make(map[string]Profile)
+			}
+			profiles[name] = mergeProfile(profiles[name], p)
+		}
+	}
+	var profile Profile
+	if o.profile != "" {
+		p, ok := profiles[o.profile]
+		if !ok {
+			return nil, fmt.Errorf("profile %q is not defined (add [profiles.%s] to %s)", o.profile, o.profile, filepath.Join(configDir, configFileName))
+		}
+		profile = p
+	}
+
+	// symlinks: local overrides project if local has any symlinks
+	var symlinks []string
+	if localCfg != nil && len(localCfg.Symlinks) > 0 {
+		symlinks = localCfg.Symlinks
+	} else if projCfg != nil {
+		symlinks = projCfg.Symlinks
+	}
+
+	// extra_symlinks: collect from both configs, deduplicate, append to symlinks
+	seen := make(map[string]bool)
+	for _, s := range symlinks {
+		seen[s] = true
+	}
+	var extraSymlinks []string
+	if projCfg != nil {
+		for _, s := range projCfg.ExtraSymlinks {
+			if !seen[s] {
+				seen[s] = true
+				extraSymlinks = append(extraSymlinks, s)
+			}
+		}
+	}
+	if localCfg != nil {
+		for _, s := range localCfg.ExtraSymlinks {
+			if !seen[s] {
+				seen[s] = true
+				extraSymlinks = append(extraSymlinks, s)
+			}
+		}
+	}
+	symlinks = append(symlinks, extraSymlinks...)
+
+	// profile symlinks replace all symlinks; profile extra_symlinks are appended
+	if profile.Symlinks != nil {
+		symlinks = slices.Clone(*profile.Symlinks)
+		extraSymlinks = nil
+	}
+	for _, s := range profile.ExtraSymlinks {
+		if !slices.Contains(symlinks, s) {
+			symlinks = append(symlinks, s)
+			extraSymlinks = append(extraSymlinks, s)
+		}
+	}
+
+	// default_source: local overrides project
+	var defaultSource string
+	if projCfg != nil && projCfg.DefaultSource != "" {
+		defaultSource = projCfg.DefaultSource
+	}
+	if localCfg != nil && localCfg.DefaultSource != "" {
+		defaultSource = localCfg.DefaultSource
+	}
+	if profile.DefaultSource != "" {
+		defaultSource = profile.DefaultSource
+	}
+
+	// SourceDir is always the directory where config is loaded from
+	srcDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve source directory: %w", err)
+	}
+
+	// worktree_destination_base_dir: local overrides project
+	var destBaseDirConfig string
+	if projCfg != nil && projCfg.WorktreeDestBaseDir != "" {
+		destBaseDirConfig = projCfg.WorktreeDestBaseDir
+	}
+	if localCfg != nil && localCfg.WorktreeDestBaseDir != "" {
+		destBaseDirConfig = localCfg.WorktreeDestBaseDir
+	}
+	if profile.WorktreeDestBaseDir != "" {
+		destBaseDirConfig = profile.WorktreeDestBaseDir
+	}
+
+	// Resolve relative/default WorktreeDestBaseDir from main worktree root
+	// so the result is consistent regardless of which worktree loads config.
+	// Falls back to srcDir when main worktree dir is not provided.
+	resolveBase := srcDir
+	if o.mainWorktreeDir != "" {
+		resolveBase = o.mainWorktreeDir
+	}
+
+	destBaseDir, err := resolveDestBaseDir(destBaseDirConfig, resolveBase)
+	if err != nil {
+		return nil, err
+	}
+
+	// init_submodules: local overrides project
+	var initSubmodules *bool
+	if projCfg != nil && projCfg.InitSubmodules != nil {
+		initSubmodules = projCfg.InitSubmodules
+	}
+	if localCfg != nil && localCfg.InitSubmodules != nil {
+		initSubmodules = localCfg.InitSubmodules
+	}
+	if profile.InitSubmodules != nil {
+		initSubmodules = profile.InitSubmodules
+	}
+
+	// submodule_reference: local overrides project
+	var submoduleReference *bool
+	if projCfg != nil && projCfg.SubmoduleReference != nil {
+		submoduleReference = projCfg.SubmoduleReference
+	}
+	if localCfg != nil && localCfg.SubmoduleReference != nil {
+		submoduleReference = localCfg.SubmoduleReference
+	}
+	if profile.SubmoduleReference != nil {
+		submoduleReference = profile.SubmoduleReference
+	}
+
+	// submodule_reference_dir: local overrides project, relative to the main worktree
+	var submoduleReferenceDir string
+	if projCfg != nil && projCfg.SubmoduleRefDir != "" {
+		submoduleReferenceDir = projCfg.SubmoduleRefDir
+	}
+	if localCfg != nil && localCfg.SubmoduleRefDir != "" {
+		submoduleReferenceDir = localCfg.SubmoduleRefDir
+	}
+	if submoduleReferenceDir != "" && !filepath.IsAbs(submoduleReferenceDir) {
+		submoduleReferenceDir = filepath.Join(resolveBase, submoduleReferenceDir)
+	}
+
+	// submodule_paths: local overrides project
+	var submodulePaths []string
+	if projCfg != nil && len(projCfg.SubmodulePaths) > 0 {
+		submodulePaths = projCfg.SubmodulePaths
+	}
+	if localCfg != nil && len(localCfg.SubmodulePaths) > 0 {
+		submodulePaths = localCfg.SubmodulePaths
+	}
+	for _, p := range submodulePaths {
+		if _, err := path.Match(p, ""); err != nil {
+			warnings = append(warnings, fmt.Sprintf("invalid submodule_paths pattern %q: %v", p, err))
+		}
+	}
+
+	// submodule_recursive: local overrides project
+	var submoduleRecursive *bool
+	if projCfg != nil && projCfg.SubmoduleRecursive != nil {
+		submoduleRecursive = projCfg.SubmoduleRecursive
+	}
+	if localCfg != nil && localCfg.SubmoduleRecursive != nil {
+		submoduleRecursive = localCfg.SubmoduleRecursive
+	}
+
+	// clean_stale: local overrides project
+	var cleanStale *bool
+	if projCfg != nil && projCfg.CleanStale != nil {
+		cleanStale = projCfg.CleanStale
+	}
+	if localCfg != nil && localCfg.CleanStale != nil {
+		cleanStale = localCfg.CleanStale
+	}
+
+	// lookup_remote_branches: local overrides project
+	var lookupRemoteBranches *bool
+	if projCfg != nil && projCfg.LookupRemoteBranches != nil {
+		lookupRemoteBranches = projCfg.LookupRemoteBranches
+	}
+	if localCfg != nil && localCfg.LookupRemoteBranches != nil {
+		lookupRemoteBranches = localCfg.LookupRemoteBranches
+	}
+
+	// fetch_on_add: local overrides project
+	var fetchOnAdd *bool
+	if projCfg != nil && projCfg.FetchOnAdd != nil {
+		fetchOnAdd = projCfg.FetchOnAdd
+	}
+	if localCfg != nil && localCfg.FetchOnAdd != nil {
+		fetchOnAdd = localCfg.FetchOnAdd
+	}
+
+	// cleanup_empty_dirs: local overrides project
+	var cleanupEmptyDirs *bool
+	if projCfg != nil && projCfg.CleanupEmptyDirs != nil {
+		cleanupEmptyDirs = projCfg.CleanupEmptyDirs
+	}
+	if localCfg != nil && localCfg.CleanupEmptyDirs != nil {
+		cleanupEmptyDirs = localCfg.CleanupEmptyDirs
+	}
+
+	// clean_fetch: local overrides project
+	var cleanFetch *bool
+	if projCfg != nil && projCfg.CleanFetch != nil {
+		cleanFetch = projCfg.CleanFetch
+	}
+	if localCfg != nil && localCfg.CleanFetch != nil {
+		cleanFetch = localCfg.CleanFetch
+	}
+
+	// clean_only_twig_managed: local overrides project
+	var cleanOnlyTwigManaged *bool
+	if projCfg != nil && projCfg.CleanOnlyTwigManaged != nil {
+		cleanOnlyTwigManaged = projCfg.CleanOnlyTwigManaged
+	}
+	if localCfg != nil && localCfg.CleanOnlyTwigManaged != nil {
+		cleanOnlyTwigManaged = localCfg.CleanOnlyTwigManaged
+	}
+
+	// detect_squash_merges: local overrides project
+	var detectSquashMerges *bool
+	if projCfg != nil && projCfg.DetectSquashMerges != nil {
+		detectSquashMerges = projCfg.DetectSquashMerges
+	}
+	if localCfg != nil && localCfg.DetectSquashMerges != nil {
+		detectSquashMerges = localCfg.DetectSquashMerges
+	}
+
+	// strict_symlinks: local overrides project
+	var strictSymlinks *bool
+	if projCfg != nil && projCfg.StrictSymlinks != nil {
+		strictSymlinks = projCfg.StrictSymlinks
+	}
+	if localCfg != nil && localCfg.StrictSymlinks != nil {
+		strictSymlinks = localCfg.StrictSymlinks
+	}
+
+	// protected_branches: collect from both configs, deduplicate.
+	// Local config can add protection but never lift project protection.
+	var protectedBranches []string
+	seenProtected := make(map[string]bool)
+	for _, cfg := range []*Config{projCfg, localCfg} {
+		if cfg == nil {
+			continue
+		}
+		for _, p := range cfg.ProtectedBranches {
+			if seenProtected[p] {
+				continue
+			}
+			seenProtected[p] = true
+			if _, err := path.Match(p, ""); err != nil {
+				warnings = append(warnings, fmt.Sprintf("invalid protected_branches pattern %q: %v", p, err))
+			}
+			protectedBranches = append(protectedBranches, p)
+		}
+	}
+
+	// clean_exclude: collect from both configs, deduplicate, like
+	// protected_branches
+	var cleanExclude []string
+	seenExclude := make(map[string]bool)
+	for _, cfg := range []*Config{projCfg, localCfg} {
+		if cfg == nil {
+			continue
+		}
+		for _, p := range cfg.CleanExclude {
+			if seenExclude[p] {
+				continue
+			}
+			seenExclude[p] = true
+			if _, err := path.Match(p, ""); err != nil {
+				warnings = append(warnings, fmt.Sprintf("invalid clean_exclude pattern %q: %v", p, err))
+			}
+			cleanExclude = append(cleanExclude, p)
+		}
+	}
+
+	// hooks: local overrides project
+	var hooks []string
+	if projCfg != nil && len(projCfg.Hooks) > 0 {
+		hooks = projCfg.Hooks
+	}
+	if localCfg != nil && len(localCfg.Hooks) > 0 {
+		hooks = localCfg.Hooks
+	}
+	if profile.Hooks != nil {
+		hooks = *profile.Hooks
+	}
+
+	// branch_prefix: local overrides project
+	var branchPrefix string
+	if projCfg != nil && projCfg.BranchPrefix != "" {
+		branchPrefix = projCfg.BranchPrefix
+	}
+	if localCfg != nil && localCfg.BranchPrefix != "" {
+		branchPrefix = localCfg.BranchPrefix
+	}
+
+	// open_command: local overrides project
+	var openCommand string
+	if projCfg != nil && projCfg.OpenCommand != "" {
+		openCommand = projCfg.OpenCommand
+	}
+	if localCfg != nil && localCfg.OpenCommand != "" {
+		openCommand = localCfg.OpenCommand
+	}
+
+	// clean_verify_command: local overrides project
+	var cleanVerifyCommand string
+	if projCfg != nil && projCfg.CleanVerifyCommand != "" {
+		cleanVerifyCommand = projCfg.CleanVerifyCommand
+	}
+	if localCfg != nil && localCfg.CleanVerifyCommand != "" {
+		cleanVerifyCommand = localCfg.CleanVerifyCommand
+	}
+
+	// pr_branch_template: local overrides project
+	var prBranchTemplate string
+	if projCfg != nil && projCfg.PRBranchTemplate != "" {
+		prBranchTemplate = projCfg.PRBranchTemplate
+	}
+	if localCfg != nil && localCfg.PRBranchTemplate != "" {
+		prBranchTemplate = localCfg.PRBranchTemplate
+	}
+
+	// forge: local overrides project
+	var forge string
+	if projCfg != nil && projCfg.Forge != "" {
+		forge = projCfg.Forge
+	}
+	if localCfg != nil && localCfg.Forge != "" {
+		forge = localCfg.Forge
+	}
+	if forge != "" && !slices.Contains(SupportedForges, forge) {
+		warnings = append(warnings, fmt.Sprintf("unknown forge %q (supported: %s), PR lookup disabled",
+			forge, strings.Join(SupportedForges, ", ")))
+		forge = ""
+	}
+
+	// symlink_style: local overrides project
+	var symlinkStyle string
+	if projCfg != nil && projCfg.SymlinkStyle != "" {
+		symlinkStyle = projCfg.SymlinkStyle
+	}
+	if localCfg != nil && localCfg.SymlinkStyle != "" {
+		symlinkStyle = localCfg.SymlinkStyle
+	}
+	if symlinkStyle != "" && !slices.Contains(SupportedSymlinkStyles, symlinkStyle) {
+		warnings = append(warnings, fmt.Sprintf("unknown symlink_style %q (supported: %s), using %s",
+			symlinkStyle, strings.Join(SupportedSymlinkStyles, ", "), SymlinkStyleRelative))
+		symlinkStyle = ""
+	}
+
+	// case_collisions: local overrides project
+	var caseCollisions string
+	if projCfg != nil && projCfg.CaseCollisions != "" {
+		caseCollisions = projCfg.CaseCollisions
+	}
+	if localCfg != nil && localCfg.CaseCollisions != "" {
+		caseCollisions = localCfg.CaseCollisions
+	}
+	if caseCollisions != "" && !slices.Contains(SupportedCaseCollisions, caseCollisions) {
+		warnings = append(warnings, fmt.Sprintf("unknown case_collisions %q (supported: %s), using %s",
+			caseCollisions, strings.Join(SupportedCaseCollisions, ", "), CaseCollisionsAuto))
+		caseCollisions = ""
+	}
+
+	// git_lock_wait: local overrides project
+	var gitLockWait string
+	if projCfg != nil && projCfg.GitLockWait != "" {
+		gitLockWait = projCfg.GitLockWait
+	}
+	if localCfg != nil && localCfg.GitLockWait != "" {
+		gitLockWait = localCfg.GitLockWait
+	}
+	if gitLockWait != "" {
+		if d, err := time.ParseDuration(gitLockWait); err != nil || d < 0 {
+			warnings = append(warnings, fmt.Sprintf("invalid git_lock_wait %q (e.g. \"30s\"), using %s",
+				gitLockWait, DefaultGitLockWait))
+			gitLockWait = ""
+		}
+	}
+
+	// git_timeout: local overrides project
+	var gitTimeout string
+	if projCfg != nil && projCfg.GitTimeout != "" {
+		gitTimeout = projCfg.GitTimeout
+	}
+	if localCfg != nil && localCfg.GitTimeout != "" {
+		gitTimeout = localCfg.GitTimeout
+	}
+	if gitTimeout != "" {
+		if d, err := time.ParseDuration(gitTimeout); err != nil || d < 0 {
+			warnings = append(warnings, fmt.Sprintf("invalid git_timeout %q (e.g. \"60s\"), git commands run without a time limit",
+				gitTimeout))
+			gitTimeout = ""
+		}
+	}
+
+	// git_remote_timeout: local overrides project
+	var gitRemoteTimeout string
+	if projCfg != nil && projCfg.GitRemoteTimeout != "" {
+		gitRemoteTimeout = projCfg.GitRemoteTimeout
+	}
+	if localCfg != nil && localCfg.GitRemoteTimeout != "" {
+		gitRemoteTimeout = localCfg.GitRemoteTimeout
+	}
+	if gitRemoteTimeout != "" {
+		if d, err := time.ParseDuration(gitRemoteTimeout); err != nil || d < 0 {
+			warnings = append(warnings, fmt.Sprintf("invalid git_remote_timeout %q (e.g. \"5m\"), using git_timeout",
+				gitRemoteTimeout))
+			gitRemoteTimeout = ""
+		}
+	}
+
+	// archive_dir: local overrides project, relative to the main worktree
+	var archiveDir string
+	if projCfg != nil && projCfg.ArchiveDir != "" {
+		archiveDir = projCfg.ArchiveDir
+	}
+	if localCfg != nil && localCfg.ArchiveDir != "" {
+		archiveDir = localCfg.ArchiveDir
+	}
+	if archiveDir != "" && !filepath.IsAbs(archiveDir) {
+		archiveDir = filepath.Join(resolveBase, archiveDir)
+	}
+
+	// notify: local overrides project
+	var notify *bool
+	if projCfg != nil && projCfg.Notify != nil {
+		notify = projCfg.Notify
+	}
+	if localCfg != nil && localCfg.Notify != nil {
+		notify = localCfg.Notify
+	}
+
+	// notify_after: local overrides project
+	var notifyAfter string
+	if projCfg != nil && projCfg.NotifyAfter != "" {
+		notifyAfter = projCfg.NotifyAfter
+	}
+	if localCfg != nil && localCfg.NotifyAfter != "" {
+		notifyAfter = localCfg.NotifyAfter
+	}
+	if notifyAfter != "" {
+		if d, err := time.ParseDuration(notifyAfter); err != nil || d < 0 {
+			warnings = append(warnings, fmt.Sprintf("invalid notify_after %q (e.g. \"1m\"), using %s",
+				notifyAfter, DefaultNotifyAfter))
+			notifyAfter = ""
+		}
+	}
+
+	// env_file: local overrides project
+	var envFile *bool
+	if projCfg != nil && projCfg.EnvFile != nil {
+		envFile = projCfg.EnvFile
+	}
+	if localCfg != nil && localCfg.EnvFile != nil {
+		envFile = localCfg.EnvFile
+	}
+
+	// env_file_vars: collected from both, local overrides the same name
+	var envFileVars map[string]string
+	for _, cfg := range []*Config{projCfg, localCfg} {
+		if cfg == nil {
+			continue
+		}
+		for _, name := range slices.Sorted(maps.Keys(cfg.EnvFileVars)) {
+			if !envVarName.MatchString(name) {
+				warnings = append(warnings, fmt.Sprintf("invalid env_file_vars name %q, ignored", name))
+				continue
+			}
+			if envFileVars == nil {
+				envFileVars = make(map[string]string)
+			}
+			envFileVars[name] = cfg.EnvFileVars[name]
+		}
+	}
+
+	// gc: each setting, local overrides project
+	var gc GCConfig
+	for _, cfg := range []*Config{projCfg, localCfg} {
+		if cfg == nil {
+			continue
+		}
+		if cfg.GC.MaxWorktrees != 0 {
+			gc.MaxWorktrees = cfg.GC.MaxWorktrees
+		}
+		if cfg.GC.MaxAge != "" {
+			gc.MaxAge = cfg.GC.MaxAge
+		}
+		if cfg.GC.CheckOnAdd != nil {
+			gc.CheckOnAdd = cfg.GC.CheckOnAdd
+		}
+	}
+	if gc.MaxWorktrees < 0 {
+		warnings = append(warnings, fmt.Sprintf("invalid gc.max_worktrees %d, the number of worktrees is not limited",
+			gc.MaxWorktrees))
+		gc.MaxWorktrees = 0
+	}
+	if gc.MaxAge != "" {
+		if _, err := parseGCAge(gc.MaxAge); err != nil {
+			warnings = append(warnings, fmt.Sprintf("invalid gc.max_age %q (e.g. \"45d\"), worktrees are not collected by age",
+				gc.MaxAge))
+			gc.MaxAge = ""
+		}
+	}
+
+	// colors: each setting, local overrides project
+	var colors ColorsConfig
+	for _, cfg := range []*Config{projCfg, localCfg} {
+		if cfg == nil {
+			continue
+		}
+		merged, set := colors.settings(), cfg.Colors.settings()
+		for i := range merged {
+			if *set[i].value != "" {
+				*merged[i].value = *set[i].value
+			}
+		}
+	}
+	for _, c := range colors.settings() {
+		if *c.value == "" {
+			continue
+		}
+		if _, err := format.ParseColorSpec(*c.value); err != nil {
+			warnings = append(warnings, fmt.Sprintf("invalid colors.%s: %v, using the default color", c.name, err))
+			*c.value = ""
+		}
+	}
+
+	// branch_aliases: collected from both, local overrides the same alias
+	var branchAliases map[string]string
+	for _, cfg := range []*Config{projCfg, localCfg} {
+		if cfg == nil {
+			continue
+		}
+		for alias, branch := range cfg.BranchAliases {
+			if branchAliases == nil {
+				branchAliases = make(map[string]string)
+			}
+			branchAliases[alias] = branch
+		}
+	}
+
+	return &LoadConfigResult{
+		Config: &Config{
+			Symlinks:             symlinks,
+			ExtraSymlinks:        extraSymlinks,
+			WorktreeDestBaseDir:  destBaseDir,
+			DefaultSource:        defaultSource,
+			WorktreeSourceDir:    srcDir,
+			InitSubmodules:       initSubmodules,
+			SubmoduleReference:   submoduleReference,
+			SubmoduleRefDir:      submoduleReferenceDir,
+			SubmodulePaths:       submodulePaths,
+			SubmoduleRecursive:   submoduleRecursive,
+			CleanStale:           cleanStale,
+			LookupRemoteBranches: lookupRemoteBranches,
+			FetchOnAdd:           fetchOnAdd,
+			CleanFetch:           cleanFetch,
+			CleanOnlyTwigManaged: cleanOnlyTwigManaged,
+			CleanupEmptyDirs:     cleanupEmptyDirs,
+			DetectSquashMerges:   detectSquashMerges,
+			StrictSymlinks:       strictSymlinks,
+			SymlinkStyle:         symlinkStyle,
+			ProtectedBranches:    protectedBranches,
+			CleanExclude:         cleanExclude,
+			Hooks:                hooks,
+			BranchPrefix:         branchPrefix,
+			BranchAliases:        branchAliases,
+			OpenCommand:          openCommand,
+			CleanVerifyCommand:   cleanVerifyCommand,
+			PRBranchTemplate:     prBranchTemplate,
+			Forge:                forge,
+			CaseCollisions:       caseCollisions,
+			GitLockWait:          gitLockWait,
+			GitTimeout:           gitTimeout,
+			GitRemoteTimeout:     gitRemoteTimeout,
+			ArchiveDir:           archiveDir,
+			Notify:               notify,
+			NotifyAfter:          notifyAfter,
+			EnvFile:              envFile,
+			EnvFileVars:          envFileVars,
+			GC:                   gc,
+			Colors:               colors,
+			Profiles:             profiles,
+			Profile:              o.profile,
+		},
+		Warnings: warnings,
+	}, nil
+}
+
+// NewConfig returns the Config LoadConfig would return for dir if it had
+// no config files and no TWIG_* variables were set, without reading any
+// files. Programs embedding twig can set fields on it directly instead of
+// writing a config file. Only WithMainWorktreeDir is used from opts.
+func NewConfig(dir string, opts ...LoadConfigOption) (*Config, error) {
+	o := newLoadConfigOptions(opts)
+
+	srcDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve source directory: %w", err)
+	}
+	resolveBase := srcDir
+	if o.mainWorktreeDir != "" {
+		resolveBase = o.mainWorktreeDir
+	}
+	destBaseDir, err := resolveDestBaseDir("", resolveBase)
+	if err != nil {
+		return nil, err
+	}
+	return &Config{
+		WorktreeSourceDir:   srcDir,
+		WorktreeDestBaseDir: destBaseDir,
+	}, nil
+}
+
+// resolveDestBaseDir resolves worktree_destination_base_dir against base,
+// the main worktree. An empty value means <base>/../<repo>-worktree.
+func resolveDestBaseDir(configured, base string) (string, error) {
+	destBaseDir := configured
+	if destBaseDir == "" {
+		repoName := filepath.Base(base)
+		destBaseDir = filepath.Join(base, "..", repoName+"-worktree")
+	} else if !filepath.IsAbs(destBaseDir) {
+		destBaseDir = filepath.Join(base, destBaseDir)
+	}
+	destBaseDir, err := filepath.Abs(destBaseDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve worktree destination base directory: %w", err)
+	}
+	return destBaseDir, nil
+}
+
+func loadConfigFile(path string) (*Config, []ConfigKeyRename, error) {
+	config, _, renamed, err := decodeConfigFile(path)
+	return config, renamed, err
+}
+
+// decodeConfigFile decodes a single config file. The metadata records which
+// keys the file defines. Renamed settings are read under their current
+// names and returned so that callers can warn about them. Returns a nil
+// config if the file does not exist.
+func decodeConfigFile(path string) (*Config, toml.MetaData, []ConfigKeyRename, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, toml.MetaData{}, nil, nil
+	}
+	if err != nil {
+		return nil, toml.MetaData{}, nil, err
+	}
+	data, renamed, err := migrateConfig(data, configKeyRenames)
+	if err != nil {
+		return nil, toml.MetaData{}, nil, err
+	}
+
+	var config Config
+	meta, err := toml.Decode(string(data), &config)
+	if err != nil {
+		return nil, meta, nil, err
+	}
+
+	return &config, meta, renamed, nil
+}
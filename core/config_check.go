@@ -0,0 +1,224 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// ConfigIssueSeverity classifies a ConfigIssue.
+type ConfigIssueSeverity string
+
+const (
+	// ConfigIssueError means the setting cannot work as written.
+	ConfigIssueError ConfigIssueSeverity = "error"
+	// ConfigIssueWarning means the setting works but likely not as intended.
+	ConfigIssueWarning ConfigIssueSeverity = "warning"
+)
+
+// ConfigIssue is a problem found in the configuration.
+type ConfigIssue struct {
+	Severity ConfigIssueSeverity
+	File     string // Config file the issue is in (empty = merged settings)
+	Key      string // Setting the issue is about (empty = whole file)
+	Message  string
+}
+
+// ConfigCheckResult holds the result of checking the configuration.
+type ConfigCheckResult struct {
+	Files  []string // Config files that were checked
+	Issues []ConfigIssue
+}
+
+// ErrorCount returns the number of issues with error severity.
+func (r ConfigCheckResult) ErrorCount() int {
+	n := 0
+	for _, issue := range r.Issues {
+		if issue.Severity == ConfigIssueError {
+			n++
+		}
+	}
+	return n
+}
+
+// Format formats the ConfigCheckResult for display.
+func (r ConfigCheckResult) Format(opts FormatOptions) FormatResult {
+	var stdout strings.Builder
+	if opts.Verbose {
+		for _, f := range r.Files {
+			fmt.Fprintf(&stdout, "checked %s\n", f)
+		}
+	}
+	for _, issue := range r.Issues {
+		if issue.File != "" {
+			fmt.Fprintf(&stdout, "%s: ", issue.File)
+		}
+		fmt.Fprintf(&stdout, "%s: ", issue.Severity)
+		if issue.Key != "" {
+			fmt.Fprintf(&stdout, "%s: ", issue.Key)
+		}
+		fmt.Fprintln(&stdout, issue.Message)
+	}
+	if len(r.Issues) == 0 {
+		if len(r.Files) == 0 {
+			stdout.WriteString("twig config check: ok (no config files)\n")
+		} else {
+			stdout.WriteString("twig config check: ok\n")
+		}
+	}
+	return FormatResult{Stdout: stdout.String()}
+}
+
+// ConfigCheckCommand validates the configuration files.
+type ConfigCheckCommand struct {
+	FS  FileSystem
+	Git *GitRunner
+	Log *slog.Logger
+}
+
+// NewConfigCheckCommand creates a ConfigCheckCommand with explicit dependencies.
+func NewConfigCheckCommand(fs FileSystem, git *GitRunner, log *slog.Logger) *ConfigCheckCommand {
+	if log == nil {
+		log = NewNopLogger()
+	}
+	return &ConfigCheckCommand{FS: fs, Git: git, Log: log}
+}
+
+// NewDefaultConfigCheckCommand creates a ConfigCheckCommand with production defaults.
+func NewDefaultConfigCheckCommand(dir string, log *slog.Logger, gitOpts ...GitRunnerOption) *ConfigCheckCommand {
+	git := newDefaultGitRunner(dir, log, gitOpts)
+	return NewConfigCheckCommand(git.defaultFS(), git, log)
+}
+
+// Run checks the config files in dir: syntax and types, unknown keys,
+// local settings that replace project settings, and whether the merged
+// settings refer to directories, branches, and files that exist.
+// opts are passed to LoadConfig. Problems are reported as issues; the
+// returned error is reserved for failures of the check itself.
+func (c *ConfigCheckCommand) Run(ctx context.Context, dir string, opts ...LoadConfigOption) (ConfigCheckResult, error) {
+	var result ConfigCheckResult
+	addIssue := func(severity ConfigIssueSeverity, file, key, format string, args ...any) {
+		result.Issues = append(result.Issues, ConfigIssue{
+			Severity: severity,
+			File:     file,
+			Key:      key,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	var files []configFile
+	decodeFailed := false
+	for _, name := range []string{configFileName, localConfigFileName} {
+		rel := filepath.Join(configDir, name)
+		cfg, meta, _, err := decodeConfigFile(filepath.Join(dir, rel))
+		if err != nil {
+			result.Files = append(result.Files, rel)
+			addIssue(ConfigIssueError, rel, "", "%v", err)
+			decodeFailed = true
+			continue
+		}
+		if cfg == nil {
+			continue
+		}
+		result.Files = append(result.Files, rel)
+		files = append(files, configFile{name: rel, cfg: cfg, meta: meta})
+
+		for _, key := range meta.Undecoded() {
+			msg := fmt.Sprintf("unknown key %q", key.String())
+			// [profiles.x] applies to every key below it, so top-level
+			// settings placed after it end up inside the profile
+			if len(key) == 3 && key[0] == "profiles" && slices.ContainsFunc(configKeys, func(k configKey) bool {
+				return k.name == key[2]
+			}) {
+				msg += " (move top-level settings above the [profiles.*] tables)"
+			}
+			addIssue(ConfigIssueWarning, rel, "", "%s", msg)
+		}
+	}
+	if decodeFailed {
+		return result, nil
+	}
+
+	if len(files) == 2 {
+		project, local := files[0], files[1]
+		for _, key := range configKeys {
+			if key.collect || !key.set(project.cfg) || !key.set(local.cfg) {
+				continue
+			}
+			if reflect.DeepEqual(key.value(project.cfg), key.value(local.cfg)) {
+				continue
+			}
+			msg := fmt.Sprintf("replaces %s = %s from %s",
+				key.name, formatConfigValue(key.value(project.cfg)), project.name)
+			if key.name == "symlinks" {
+				msg += " (use extra_symlinks to add patterns instead)"
+			}
+			addIssue(ConfigIssueWarning, local.name, key.name, "%s", msg)
+		}
+	}
+
+	loaded, err := LoadConfig(dir, opts...)
+	if err != nil {
+		addIssue(ConfigIssueError, "", "", "%v", err)
+		return result, nil
+	}
+	for _, w := range loaded.Warnings {
+		addIssue(ConfigIssueWarning, "", "", "%s", w)
+	}
+	c.checkSettings(ctx, loaded.Config, files, addIssue)
+
+	c.Log.DebugContext(ctx, "checked config",
+		LogAttrKeyCategory.String(), LogCategoryConfig,
+		"files", len(result.Files),
+		"issues", len(result.Issues))
+	return result, nil
+}
+
+// checkSettings checks that the merged settings refer to things that exist.
+func (c *ConfigCheckCommand) checkSettings(ctx context.Context, cfg *Config, files []configFile,
+	addIssue func(severity ConfigIssueSeverity, file, key, format string, args ...any)) {
+	destConfigured := slices.ContainsFunc(files, func(f configFile) bool {
+		return f.cfg.WorktreeDestBaseDir != ""
+	})
+	if info, err := c.FS.Stat(cfg.WorktreeDestBaseDir); err == nil {
+		if info != nil && !info.IsDir() {
+			addIssue(ConfigIssueError, "", "worktree_destination_base_dir",
+				"%s is not a directory", cfg.WorktreeDestBaseDir)
+		}
+	} else if destConfigured && c.FS.IsNotExist(err) {
+		if _, err := c.FS.Stat(filepath.Dir(cfg.WorktreeDestBaseDir)); c.FS.IsNotExist(err) {
+			addIssue(ConfigIssueWarning, "", "worktree_destination_base_dir",
+				"neither %s nor its parent directory exists", cfg.WorktreeDestBaseDir)
+		}
+	}
+
+	// twig add and sync take symlinks from the default_source worktree
+	sourceDir := cfg.WorktreeSourceDir
+	if cfg.DefaultSource != "" {
+		wt, err := c.Git.WorktreeFindByBranch(ctx, cfg.DefaultSource)
+		if err != nil {
+			addIssue(ConfigIssueError, "", "default_source", "%v", err)
+		} else {
+			sourceDir = wt.Path
+		}
+	}
+
+	for _, pattern := range cfg.Symlinks {
+		key := "symlinks"
+		if slices.Contains(cfg.ExtraSymlinks, pattern) {
+			key = "extra_symlinks"
+		}
+		matches, err := c.FS.Glob(sourceDir, pattern)
+		if err != nil {
+			addIssue(ConfigIssueError, "", key, "invalid glob pattern %q: %v", pattern, err)
+			continue
+		}
+		if len(matches) == 0 {
+			addIssue(ConfigIssueWarning, "", key, "%q does not match any files in %s", pattern, sourceDir)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/708u/twig/internal/testutil"
+)
+
+func TestConfigCheckCommand_Run(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		project    string
+		local      string
+		files      []string // Files created in the config dir
+		opts       []LoadConfigOption
+		wantIssues []string // Formatted issue lines, in order
+		wantErrors int
+	}{
+		{
+			name:    "valid config",
+			project: `symlinks = [".envrc"]` + "\n" + `default_source = "main"`,
+			files:   []string{".envrc"},
+		},
+		{
+			name:    "unknown keys",
+			project: "symlink = [\".envrc\"]\n[profiles.review]\nhooks = []\nforge = \"github\"\n",
+			wantIssues: []string{
+				`.twig/settings.toml: warning: unknown key "symlink"`,
+				`.twig/settings.toml: warning: unknown key "profiles.review.forge" (move top-level settings above the [profiles.*] tables)`,
+			},
+		},
+		{
+			name:    "syntax error stops further checks",
+			project: `symlinks = [".envrc"`,
+			local:   `symlink = [".envrc"]`,
+			wantIssues: []string{
+				".twig/settings.toml: error: toml: ",
+				`.twig/settings.local.toml: warning: unknown key "symlink"`,
+			},
+			wantErrors: 1,
+		},
+		{
+			name:    "local replaces project settings",
+			project: `symlinks = [".envrc"]` + "\n" + `branch_prefix = "me/"` + "\n" + `extra_symlinks = [".claude"]`,
+			local:   `symlinks = [".tool-versions"]` + "\n" + `branch_prefix = "me/"` + "\n" + `extra_symlinks = [".idea"]`,
+			files:   []string{".envrc", ".tool-versions", ".claude", ".idea"},
+			wantIssues: []string{
+				`.twig/settings.local.toml: warning: symlinks: replaces symlinks = [".envrc"] from .twig/settings.toml (use extra_symlinks to add patterns instead)`,
+			},
+		},
+		{
+			name:    "patterns matching nothing",
+			project: `symlinks = [".envrc", "[bad"]` + "\n" + `extra_symlinks = [".claude"]`,
+			wantIssues: []string{
+				`warning: symlinks: ".envrc" does not match any files in `,
+				`error: symlinks: invalid glob pattern "[bad": `,
+				`warning: extra_symlinks: ".claude" does not match any files in `,
+			},
+			wantErrors: 1,
+		},
+		{
+			name:    "default_source not checked out",
+			project: `default_source = "develop"`,
+			wantIssues: []string{
+				`error: default_source: branch "develop" is not checked out in any worktree`,
+			},
+			wantErrors: 1,
+		},
+		{
+			name:    "destination is a file",
+			project: `worktree_destination_base_dir = "dest"`,
+			files:   []string{"dest"},
+			wantIssues: []string{
+				"error: worktree_destination_base_dir: ",
+			},
+			wantErrors: 1,
+		},
+		{
+			name:    "destination and its parent missing",
+			project: `worktree_destination_base_dir = "missing/dest"`,
+			wantIssues: []string{
+				"warning: worktree_destination_base_dir: neither ",
+			},
+		},
+		{
+			name:    "loader warnings",
+			project: `forge = "bitbucket"`,
+			wantIssues: []string{
+				`warning: unknown forge "bitbucket"`,
+			},
+		},
+		{
+			name:       "undefined profile",
+			project:    `branch_prefix = "me/"`,
+			opts:       []LoadConfigOption{WithProfile("review")},
+			wantIssues: []string{`error: profile "review" is not defined`},
+			wantErrors: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			twigDir := filepath.Join(dir, configDir)
+			if err := os.MkdirAll(twigDir, 0755); err != nil {
+				t.Fatal(err)
+			}
+			if tt.project != "" {
+				if err := os.WriteFile(filepath.Join(twigDir, configFileName), []byte(tt.project), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.local != "" {
+				if err := os.WriteFile(filepath.Join(twigDir, localConfigFileName), []byte(tt.local), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for _, f := range tt.files {
+				if err := os.WriteFile(filepath.Join(dir, f), nil, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			mockGit := &testutil.MockGitExecutor{
+				Worktrees: []testutil.MockWorktree{{Path: dir, Branch: "main"}},
+			}
+			git := &GitRunner{Executor: mockGit, Dir: dir, Log: NewNopLogger()}
+
+			result, err := NewConfigCheckCommand(osFS{}, git, nil).Run(t.Context(), dir, tt.opts...)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			lines := strings.Split(strings.TrimSuffix(result.Format(FormatOptions{}).Stdout, "\n"), "\n")
+			if len(tt.wantIssues) == 0 {
+				if len(result.Issues) != 0 {
+					t.Errorf("unexpected issues: %q", lines)
+				}
+				return
+			}
+			if len(lines) != len(tt.wantIssues) {
+				t.Fatalf("issues = %q, want %d", lines, len(tt.wantIssues))
+			}
+			for i, want := range tt.wantIssues {
+				if !strings.HasPrefix(lines[i], want) {
+					t.Errorf("issue[%d] = %q, want prefix %q", i, lines[i], want)
+				}
+			}
+			if got := result.ErrorCount(); got != tt.wantErrors {
+				t.Errorf("ErrorCount() = %d, want %d", got, tt.wantErrors)
+			}
+		})
+	}
+}
+
+func TestConfigCheckResult_Format(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		result  ConfigCheckResult
+		verbose bool
+		want    string
+	}{
+		{
+			name:   "no config files",
+			result: ConfigCheckResult{},
+			want:   "twig config check: ok (no config files)\n",
+		},
+		{
+			name:    "ok verbose",
+			result:  ConfigCheckResult{Files: []string{".twig/settings.toml"}},
+			verbose: true,
+			want:    "checked .twig/settings.toml\ntwig config check: ok\n",
+		},
+		{
+			name: "issues",
+			result: ConfigCheckResult{
+				Files: []string{".twig/settings.toml"},
+				Issues: []ConfigIssue{
+					{Severity: ConfigIssueWarning, File: ".twig/settings.toml", Message: `unknown key "symlink"`},
+					{Severity: ConfigIssueError, Key: "default_source", Message: "not found"},
+				},
+			},
+			want: ".twig/settings.toml: warning: unknown key \"symlink\"\n" +
+				"error: default_source: not found\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := tt.result.Format(FormatOptions{Verbose: tt.verbose})
+			if got.Stdout != tt.want {
+				t.Errorf("Stdout = %q, want %q", got.Stdout, tt.want)
+			}
+		})
+	}
+}
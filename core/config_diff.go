@@ -0,0 +1,119 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/708u/twig/format"
+)
+
+// ConfigDiffSide identifies one of the compared worktrees.
+type ConfigDiffSide struct {
+	Branch string `json:"branch"`
+	Path   string `json:"path"`
+}
+
+// ConfigDiffEntry is a setting whose effective value differs between
+// the two worktrees.
+type ConfigDiffEntry struct {
+	Key string      `json:"key"`
+	A   ConfigEntry `json:"a"`
+	B   ConfigEntry `json:"b"`
+}
+
+// ConfigDiffResult holds the settings that differ between two worktrees.
+type ConfigDiffResult struct {
+	A       ConfigDiffSide    `json:"a"`
+	B       ConfigDiffSide    `json:"b"`
+	Entries []ConfigDiffEntry `json:"differences"` // In documentation order
+}
+
+// HasDiff reports whether any setting differs.
+func (r ConfigDiffResult) HasDiff() bool {
+	return len(r.Entries) > 0
+}
+
+// DiffConfig compares the effective configuration loaded from the
+// worktrees of branchA and branchB. Each worktree may carry a different
+// committed .twig/settings.toml, so the same command can behave
+// differently depending on where it runs or which source it syncs from.
+// opts are passed to LoadConfig for both worktrees.
+func DiffConfig(ctx context.Context, git *GitRunner, branchA, branchB string, opts ...LoadConfigOption) (ConfigDiffResult, error) {
+	var result ConfigDiffResult
+
+	wtA, err := git.WorktreeFindByBranch(ctx, branchA)
+	if err != nil {
+		return result, err
+	}
+	wtB, err := git.WorktreeFindByBranch(ctx, branchB)
+	if err != nil {
+		return result, err
+	}
+	result.A = ConfigDiffSide{Branch: branchA, Path: wtA.Path}
+	result.B = ConfigDiffSide{Branch: branchB, Path: wtB.Path}
+
+	effA, err := EffectiveConfig(wtA.Path, opts...)
+	if err != nil {
+		return result, fmt.Errorf("%s: %w", branchA, err)
+	}
+	effB, err := EffectiveConfig(wtB.Path, opts...)
+	if err != nil {
+		return result, fmt.Errorf("%s: %w", branchB, err)
+	}
+
+	// Both results list configKeys in the same order
+	for i, a := range effA.Entries {
+		b := effB.Entries[i]
+		if formatConfigValue(a.Value) == formatConfigValue(b.Value) {
+			continue
+		}
+		result.Entries = append(result.Entries, ConfigDiffEntry{Key: a.Key, A: a, B: b})
+	}
+	return result, nil
+}
+
+// MarshalJSON encodes the result with an empty list when nothing differs.
+func (r ConfigDiffResult) MarshalJSON() ([]byte, error) {
+	type plain ConfigDiffResult
+	if r.Entries == nil {
+		r.Entries = []ConfigDiffEntry{}
+	}
+	return json.Marshal(plain(r))
+}
+
+// Format formats the ConfigDiffResult as a unified diff of TOML
+// assignments, each followed by a comment naming its source.
+func (r ConfigDiffResult) Format(opts FormatOptions) FormatResult {
+	if !r.HasDiff() {
+		return FormatResult{Stdout: fmt.Sprintf("No differences between %s and %s\n", r.A.Branch, r.B.Branch)}
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "--- %s (%s)\n", r.A.Branch, r.A.Path)
+	fmt.Fprintf(&buf, "+++ %s (%s)\n", r.B.Branch, r.B.Path)
+
+	// Align the source comments first; colors would skew the widths
+	var table bytes.Buffer
+	w := tabwriter.NewWriter(&table, 0, 0, 2, ' ', 0)
+	for _, e := range r.Entries {
+		fmt.Fprintf(w, "-%s = %s\t# %s\n", e.Key, formatConfigValue(e.A.Value), configEntrySource(e.A))
+		fmt.Fprintf(w, "+%s = %s\t# %s\n", e.Key, formatConfigValue(e.B.Value), configEntrySource(e.B))
+	}
+	w.Flush()
+
+	for line := range strings.SplitSeq(strings.TrimSuffix(table.String(), "\n"), "\n") {
+		if opts.ColorEnabled {
+			if strings.HasPrefix(line, "-") {
+				line = format.ColorFailure(line)
+			} else {
+				line = format.ColorSuccess(line)
+			}
+		}
+		buf.WriteString(line + "\n")
+	}
+	return FormatResult{Stdout: buf.String()}
+}
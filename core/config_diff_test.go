@@ -0,0 +1,155 @@
+package core
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/708u/twig/internal/testutil"
+)
+
+func TestDiffConfig(t *testing.T) {
+	t.Parallel()
+
+	writeConfig := func(t *testing.T, dir, content string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Join(dir, configDir), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, configDir, configFileName), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		configA  string
+		configB  string
+		branchB  string
+		wantKeys []string
+		wantErr  string
+	}{
+		{
+			name:     "differing keys in documentation order",
+			configA:  "symlinks = [\".envrc\"]\ninit_submodules = true\nbranch_prefix = \"me/\"\n",
+			configB:  "symlinks = [\".envrc\", \".tool-versions\"]\nbranch_prefix = \"me/\"\n",
+			branchB:  "feat/b",
+			wantKeys: []string{"symlinks", "init_submodules"},
+		},
+		{
+			name:    "identical",
+			configA: "symlinks = [\".envrc\"]\n",
+			configB: "symlinks = [\".envrc\"]\n",
+			branchB: "feat/b",
+		},
+		{
+			name:    "branch without worktree",
+			branchB: "feat/missing",
+			wantErr: `branch "feat/missing" is not checked out in any worktree`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dirA, dirB := t.TempDir(), t.TempDir()
+			writeConfig(t, dirA, tt.configA)
+			writeConfig(t, dirB, tt.configB)
+
+			mockGit := &testutil.MockGitExecutor{
+				Worktrees: []testutil.MockWorktree{
+					{Path: dirA, Branch: "feat/a"},
+					{Path: dirB, Branch: "feat/b"},
+				},
+			}
+			git := &GitRunner{Executor: mockGit, Dir: dirA, Log: NewNopLogger()}
+
+			result, err := DiffConfig(t.Context(), git, "feat/a", tt.branchB,
+				WithMainWorktreeDir(dirA), WithGetenv(mapGetenv(nil)))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if result.A.Path != dirA || result.B.Path != dirB {
+				t.Errorf("paths = %s, %s, want %s, %s", result.A.Path, result.B.Path, dirA, dirB)
+			}
+			var keys []string
+			for _, e := range result.Entries {
+				keys = append(keys, e.Key)
+			}
+			if strings.Join(keys, ",") != strings.Join(tt.wantKeys, ",") {
+				t.Errorf("keys = %v, want %v", keys, tt.wantKeys)
+			}
+			if result.HasDiff() != (len(tt.wantKeys) > 0) {
+				t.Errorf("HasDiff() = %v", result.HasDiff())
+			}
+		})
+	}
+}
+
+func TestConfigDiffResult_Format(t *testing.T) {
+	t.Parallel()
+
+	a := ConfigDiffSide{Branch: "feat/a", Path: "/repo-worktree/feat/a"}
+	b := ConfigDiffSide{Branch: "feat/b", Path: "/repo-worktree/feat/b"}
+
+	tests := []struct {
+		name   string
+		result ConfigDiffResult
+		want   string
+	}{
+		{
+			name: "differences",
+			result: ConfigDiffResult{A: a, B: b, Entries: []ConfigDiffEntry{{
+				Key: "symlinks",
+				A:   ConfigEntry{Key: "symlinks", Value: []string{".envrc"}, Sources: []string{".twig/settings.toml"}},
+				B:   ConfigEntry{Key: "symlinks", Value: []string{}},
+			}}},
+			want: "--- feat/a (/repo-worktree/feat/a)\n" +
+				"+++ feat/b (/repo-worktree/feat/b)\n" +
+				"-symlinks = [\".envrc\"]  # .twig/settings.toml\n" +
+				"+symlinks = []          # default\n",
+		},
+		{
+			name:   "no differences",
+			result: ConfigDiffResult{A: a, B: b},
+			want:   "No differences between feat/a and feat/b\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := tt.result.Format(FormatOptions{}).Stdout; got != tt.want {
+				t.Errorf("Stdout = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigDiffResult_MarshalJSON(t *testing.T) {
+	t.Parallel()
+
+	result := ConfigDiffResult{
+		A: ConfigDiffSide{Branch: "feat/a", Path: "/a"},
+		B: ConfigDiffSide{Branch: "feat/b", Path: "/b"},
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"a":{"branch":"feat/a","path":"/a"},"b":{"branch":"feat/b","path":"/b"},"differences":[]}`
+	if string(data) != want {
+		t.Errorf("json = %s, want %s", data, want)
+	}
+}
@@ -0,0 +1,299 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/BurntSushi/toml"
+)
+
+// configKey describes how a top-level setting is merged by LoadConfig.
+// Used by twig config effective and check to explain where a value comes from.
+type configKey struct {
+	name    string
+	collect bool                 // Collected from both files; otherwise local overrides project
+	value   func(c *Config) any  // Value in a single file or the merged config
+	set     func(c *Config) bool // Whether the value takes part in the merge
+}
+
+// configKeys lists the top-level settings in documentation order.
+var configKeys = []configKey{
+	stringConfigKey("worktree_destination_base_dir", func(c *Config) string { return c.WorktreeDestBaseDir }),
+	stringConfigKey("default_source", func(c *Config) string { return c.DefaultSource }),
+	listConfigKey("symlinks", false, func(c *Config) []string { return c.Symlinks }),
+	listConfigKey("extra_symlinks", true, func(c *Config) []string { return c.ExtraSymlinks }),
+	boolConfigKey("strict_symlinks", func(c *Config) *bool { return c.StrictSymlinks }),
+	stringConfigKey("symlink_style", func(c *Config) string { return c.SymlinkStyle }),
+	boolConfigKey("init_submodules", func(c *Config) *bool { return c.InitSubmodules }),
+	boolConfigKey("submodule_reference", func(c *Config) *bool { return c.SubmoduleReference }),
+	stringConfigKey("submodule_reference_dir", func(c *Config) string { return c.SubmoduleRefDir }),
+	listConfigKey("submodule_paths", false, func(c *Config) []string { return c.SubmodulePaths }),
+	boolConfigKey("submodule_recursive", func(c *Config) *bool { return c.SubmoduleRecursive }),
+	boolConfigKey("lookup_remote_branches", func(c *Config) *bool { return c.LookupRemoteBranches }),
+	boolConfigKey("fetch_on_add", func(c *Config) *bool { return c.FetchOnAdd }),
+	boolConfigKey("clean_stale", func(c *Config) *bool { return c.CleanStale }),
+	boolConfigKey("clean_fetch", func(c *Config) *bool { return c.CleanFetch }),
+	boolConfigKey("clean_only_twig_managed", func(c *Config) *bool { return c.CleanOnlyTwigManaged }),
+	boolConfigKey("cleanup_empty_dirs", func(c *Config) *bool { return c.CleanupEmptyDirs }),
+	boolConfigKey("detect_squash_merges", func(c *Config) *bool { return c.DetectSquashMerges }),
+	stringConfigKey("forge", func(c *Config) string { return c.Forge }),
+	listConfigKey("protected_branches", true, func(c *Config) []string { return c.ProtectedBranches }),
+	listConfigKey("clean_exclude", true, func(c *Config) []string { return c.CleanExclude }),
+	listConfigKey("hooks", false, func(c *Config) []string { return c.Hooks }),
+	stringConfigKey("branch_prefix", func(c *Config) string { return c.BranchPrefix }),
+	{
+		name:    "branch_aliases",
+		collect: true,
+		value:   func(c *Config) any { return c.BranchAliases },
+		set:     func(c *Config) bool { return len(c.BranchAliases) > 0 },
+	},
+	stringConfigKey("open_command", func(c *Config) string { return c.OpenCommand }),
+	stringConfigKey("clean_verify_command", func(c *Config) string { return c.CleanVerifyCommand }),
+	stringConfigKey("pr_branch_template", func(c *Config) string { return c.PRBranchTemplate }),
+	stringConfigKey("case_collisions", func(c *Config) string { return c.CaseCollisions }),
+	stringConfigKey("git_lock_wait", func(c *Config) string { return c.GitLockWait }),
+	stringConfigKey("git_timeout", func(c *Config) string { return c.GitTimeout }),
+	stringConfigKey("git_remote_timeout", func(c *Config) string { return c.GitRemoteTimeout }),
+	stringConfigKey("archive_dir", func(c *Config) string { return c.ArchiveDir }),
+	boolConfigKey("notify", func(c *Config) *bool { return c.Notify }),
+	stringConfigKey("notify_after", func(c *Config) string { return c.NotifyAfter }),
+	boolConfigKey("env_file", func(c *Config) *bool { return c.EnvFile }),
+	{
+		name:    "env_file_vars",
+		collect: true,
+		value:   func(c *Config) any { return c.EnvFileVars },
+		set:     func(c *Config) bool { return len(c.EnvFileVars) > 0 },
+	},
+	{
+		name:  "gc.max_worktrees",
+		value: func(c *Config) any { return c.GC.MaxWorktrees },
+		set:   func(c *Config) bool { return c.GC.MaxWorktrees != 0 },
+	},
+	stringConfigKey("gc.max_age", func(c *Config) string { return c.GC.MaxAge }),
+	boolConfigKey("gc.check_on_add", func(c *Config) *bool { return c.GC.CheckOnAdd }),
+	stringConfigKey("colors.clean", func(c *Config) string { return c.Colors.Clean }),
+	stringConfigKey("colors.skip", func(c *Config) string { return c.Colors.Skip }),
+	stringConfigKey("colors.success", func(c *Config) string { return c.Colors.Success }),
+	stringConfigKey("colors.failure", func(c *Config) string { return c.Colors.Failure }),
+	stringConfigKey("colors.main", func(c *Config) string { return c.Colors.Main }),
+	stringConfigKey("colors.current", func(c *Config) string { return c.Colors.Current }),
+	stringConfigKey("colors.reason", func(c *Config) string { return c.Colors.Reason }),
+	stringConfigKey("colors.error", func(c *Config) string { return c.Colors.Error }),
+}
+
+func stringConfigKey(name string, field func(*Config) string) configKey {
+	return configKey{
+		name:  name,
+		value: func(c *Config) any { return field(c) },
+		set:   func(c *Config) bool { return field(c) != "" },
+	}
+}
+
+func listConfigKey(name string, collect bool, field func(*Config) []string) configKey {
+	return configKey{
+		name:    name,
+		collect: collect,
+		value:   func(c *Config) any { return field(c) },
+		set:     func(c *Config) bool { return len(field(c)) > 0 },
+	}
+}
+
+func boolConfigKey(name string, field func(*Config) *bool) configKey {
+	return configKey{
+		name:  name,
+		value: func(c *Config) any { return field(c) },
+		set:   func(c *Config) bool { return field(c) != nil },
+	}
+}
+
+// configFile is a decoded config file.
+type configFile struct {
+	name string // Path relative to the config load directory
+	cfg  *Config
+	meta toml.MetaData
+}
+
+// readConfigFiles decodes the project and local config files in dir,
+// skipping files that do not exist.
+func readConfigFiles(dir string) ([]configFile, error) {
+	var files []configFile
+	for _, name := range []string{configFileName, localConfigFileName} {
+		rel := filepath.Join(configDir, name)
+		cfg, meta, _, err := decodeConfigFile(filepath.Join(dir, rel))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", rel, err)
+		}
+		if cfg != nil {
+			files = append(files, configFile{name: rel, cfg: cfg, meta: meta})
+		}
+	}
+	return files, nil
+}
+
+// ConfigEntry is one effective setting and where its value comes from.
+type ConfigEntry struct {
+	Key     string   `json:"-"`
+	Value   any      `json:"value"`   // string, int, bool, []string, or map[string]string
+	Sources []string `json:"sources"` // Files or profile that set the value (empty = default)
+}
+
+// EffectiveConfigResult holds the effective configuration.
+type EffectiveConfigResult struct {
+	Profile string // Active profile (empty = none)
+	Entries []ConfigEntry
+}
+
+// EffectiveConfig returns the configuration loaded from dir after all
+// merges, with the source of each value. opts are passed to LoadConfig.
+func EffectiveConfig(dir string, opts ...LoadConfigOption) (EffectiveConfigResult, error) {
+	files, err := readConfigFiles(dir)
+	if err != nil {
+		return EffectiveConfigResult{}, err
+	}
+	// Environment overrides sit between the project and local config
+	if env, _ := envConfig(newLoadConfigOptions(opts).getenv); env != nil {
+		i := 0
+		if len(files) > 0 && files[0].name == filepath.Join(configDir, configFileName) {
+			i = 1
+		}
+		files = slices.Insert(files, i, configFile{name: envConfigSource, cfg: env})
+	}
+	loaded, err := LoadConfig(dir, opts...)
+	if err != nil {
+		return EffectiveConfigResult{}, err
+	}
+	cfg := loaded.Config
+
+	var profileKeys []string
+	if cfg.Profile != "" {
+		profileKeys = cfg.Profiles[cfg.Profile].OverriddenKeys()
+	}
+	profileSource := "profile " + cfg.Profile
+	// A profile's symlinks replace extra_symlinks from the files as well
+	profileReplacesSymlinks := slices.Contains(profileKeys, "symlinks")
+
+	result := EffectiveConfigResult{Profile: cfg.Profile}
+	for _, key := range configKeys {
+		sources := []string{}
+		for _, f := range files {
+			if key.name == "extra_symlinks" && profileReplacesSymlinks {
+				break
+			}
+			if !key.set(f.cfg) {
+				continue
+			}
+			if key.collect {
+				sources = append(sources, f.name)
+			} else {
+				sources = []string{f.name}
+			}
+		}
+		if slices.Contains(profileKeys, key.name) {
+			if key.collect {
+				sources = append(sources, profileSource)
+			} else {
+				sources = []string{profileSource}
+			}
+		}
+
+		value := key.value(cfg)
+		if key.name == "symlinks" {
+			// The merged symlinks end with the extra_symlinks
+			value = cfg.Symlinks[:len(cfg.Symlinks)-len(cfg.ExtraSymlinks)]
+		}
+		result.Entries = append(result.Entries, ConfigEntry{
+			Key:     key.name,
+			Value:   effectiveConfigValue(value),
+			Sources: sources,
+		})
+	}
+	return result, nil
+}
+
+// effectiveConfigValue replaces unset values with their defaults:
+// false for booleans and empty lists and tables.
+func effectiveConfigValue(v any) any {
+	switch v := v.(type) {
+	case *bool:
+		return v != nil && *v
+	case []string:
+		if v == nil {
+			return []string{}
+		}
+	case map[string]string:
+		if v == nil {
+			return map[string]string{}
+		}
+	}
+	return v
+}
+
+// MarshalJSON encodes the result as an object with the active profile and
+// the settings keyed by name.
+func (r EffectiveConfigResult) MarshalJSON() ([]byte, error) {
+	settings := make(map[string]ConfigEntry, len(r.Entries))
+	for _, e := range r.Entries {
+		settings[e.Key] = e
+	}
+	return json.Marshal(struct {
+		Profile  string                 `json:"profile"`
+		Settings map[string]ConfigEntry `json:"settings"`
+	}{r.Profile, settings})
+}
+
+// formatConfigValue formats a config value as TOML. Unset booleans are
+// shown as false, their default.
+func formatConfigValue(v any) string {
+	switch v := v.(type) {
+	case string:
+		return strconv.Quote(v)
+	case bool:
+		return strconv.FormatBool(v)
+	case *bool:
+		return strconv.FormatBool(v != nil && *v)
+	case []string:
+		quoted := make([]string, len(v))
+		for i, s := range v {
+			quoted[i] = strconv.Quote(s)
+		}
+		return "[" + strings.Join(quoted, ", ") + "]"
+	case map[string]string:
+		if len(v) == 0 {
+			return "{}"
+		}
+		var pairs []string
+		for _, k := range slices.Sorted(maps.Keys(v)) {
+			pairs = append(pairs, strconv.Quote(k)+" = "+strconv.Quote(v[k]))
+		}
+		return "{ " + strings.Join(pairs, ", ") + " }"
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// configEntrySource returns the sources of e for display.
+func configEntrySource(e ConfigEntry) string {
+	if len(e.Sources) == 0 {
+		return "default"
+	}
+	return strings.Join(e.Sources, ", ")
+}
+
+// Format formats the EffectiveConfigResult for display.
+// Each line is a TOML assignment followed by a comment naming its source.
+func (r EffectiveConfigResult) Format(opts FormatOptions) FormatResult {
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	for _, e := range r.Entries {
+		fmt.Fprintf(w, "%s = %s\t# %s\n", e.Key, formatConfigValue(e.Value), configEntrySource(e))
+	}
+	w.Flush()
+	return FormatResult{Stdout: buf.String()}
+}
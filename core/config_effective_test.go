@@ -0,0 +1,186 @@
+package core
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEffectiveConfig(t *testing.T) {
+	t.Parallel()
+
+	project := `symlinks = [".envrc"]
+extra_symlinks = [".claude"]
+branch_prefix = "team/"
+protected_branches = ["main"]
+
+[branch_aliases]
+review = "team/review"
+
+[profiles.lite]
+symlinks = []
+extra_symlinks = [".tool-versions"]
+`
+	local := `extra_symlinks = [".idea"]
+branch_prefix = "me/"
+init_submodules = true
+protected_branches = ["release/*"]
+`
+
+	tests := []struct {
+		name    string
+		opts    []LoadConfigOption
+		want    map[string]string // key -> value
+		sources map[string]string // key -> joined sources
+	}{
+		{
+			name: "project and local",
+			want: map[string]string{
+				"symlinks":           `[".envrc"]`,
+				"extra_symlinks":     `[".claude", ".idea"]`,
+				"branch_prefix":      `"me/"`,
+				"init_submodules":    "true",
+				"clean_stale":        "false",
+				"protected_branches": `["main", "release/*"]`,
+				"branch_aliases":     `{ "review" = "team/review" }`,
+				"open_command":       `""`,
+			},
+			sources: map[string]string{
+				"symlinks":           ".twig/settings.toml",
+				"extra_symlinks":     ".twig/settings.toml, .twig/settings.local.toml",
+				"branch_prefix":      ".twig/settings.local.toml",
+				"init_submodules":    ".twig/settings.local.toml",
+				"clean_stale":        "",
+				"protected_branches": ".twig/settings.toml, .twig/settings.local.toml",
+				"branch_aliases":     ".twig/settings.toml",
+			},
+		},
+		{
+			name: "profile replaces symlinks",
+			opts: []LoadConfigOption{WithProfile("lite")},
+			want: map[string]string{
+				"symlinks":       "[]",
+				"extra_symlinks": `[".tool-versions"]`,
+			},
+			sources: map[string]string{
+				"symlinks":       "profile lite",
+				"extra_symlinks": "profile lite",
+			},
+		},
+		{
+			name: "environment between project and local",
+			opts: []LoadConfigOption{WithGetenv(mapGetenv(map[string]string{
+				EnvBranchPrefix: "ci/",
+				EnvCleanStale:   "true",
+			}))},
+			want: map[string]string{
+				"branch_prefix": `"me/"`,
+				"clean_stale":   "true",
+			},
+			sources: map[string]string{
+				"branch_prefix": ".twig/settings.local.toml",
+				"clean_stale":   "environment",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			twigDir := filepath.Join(dir, configDir)
+			if err := os.MkdirAll(twigDir, 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(twigDir, configFileName), []byte(project), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(twigDir, localConfigFileName), []byte(local), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := EffectiveConfig(dir, tt.opts...)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(result.Entries) != len(configKeys) {
+				t.Errorf("got %d entries, want %d", len(result.Entries), len(configKeys))
+			}
+
+			entries := make(map[string]ConfigEntry)
+			for _, e := range result.Entries {
+				entries[e.Key] = e
+			}
+			for key, want := range tt.want {
+				if got := formatConfigValue(entries[key].Value); got != want {
+					t.Errorf("%s = %s, want %s", key, got, want)
+				}
+			}
+			for key, want := range tt.sources {
+				if got := strings.Join(entries[key].Sources, ", "); got != want {
+					t.Errorf("%s source = %q, want %q", key, got, want)
+				}
+			}
+		})
+	}
+
+	t.Run("syntax error names the file", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		if err := os.MkdirAll(filepath.Join(dir, configDir), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, configDir, localConfigFileName), []byte("symlinks = ["), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		_, err := EffectiveConfig(dir)
+		if err == nil || !strings.HasPrefix(err.Error(), ".twig/settings.local.toml: ") {
+			t.Errorf("error = %v, want it to name .twig/settings.local.toml", err)
+		}
+	})
+}
+
+func TestEffectiveConfigResult_Format(t *testing.T) {
+	t.Parallel()
+
+	result := EffectiveConfigResult{Entries: []ConfigEntry{
+		{Key: "symlinks", Value: []string{".envrc"}, Sources: []string{".twig/settings.toml"}},
+		{Key: "branch_prefix", Value: ""},
+		{Key: "init_submodules", Value: true, Sources: []string{"profile review"}},
+	}}
+
+	want := "symlinks = [\".envrc\"]   # .twig/settings.toml\n" +
+		"branch_prefix = \"\"      # default\n" +
+		"init_submodules = true  # profile review\n"
+	if got := result.Format(FormatOptions{}).Stdout; got != want {
+		t.Errorf("Stdout = %q, want %q", got, want)
+	}
+}
+
+func TestEffectiveConfigResult_MarshalJSON(t *testing.T) {
+	t.Parallel()
+
+	result := EffectiveConfigResult{
+		Profile: "review",
+		Entries: []ConfigEntry{
+			{Key: "symlinks", Value: []string{".envrc"}, Sources: []string{".twig/settings.toml"}},
+			{Key: "init_submodules", Value: false, Sources: []string{}},
+		},
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `{"profile":"review","settings":{` +
+		`"init_submodules":{"value":false,"sources":[]},` +
+		`"symlinks":{"value":[".envrc"],"sources":[".twig/settings.toml"]}}}`
+	if string(data) != want {
+		t.Errorf("json = %s, want %s", data, want)
+	}
+}
@@ -0,0 +1,234 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ConfigKeyRename records a setting that was renamed.
+type ConfigKeyRename struct {
+	Old string // Name no longer documented, still read with a warning
+	New string // Current name
+}
+
+// configKeyRenames lists renamed settings, oldest first. Config files are
+// read as if old names were already rewritten, and twig config migrate
+// rewrites them in place. When renaming a setting, add an entry such as
+// {Old: "worktree_destination_base_dir", New: "dest_base_dir"}.
+var configKeyRenames []ConfigKeyRename
+
+// configKeyLine matches a key/value line and captures the key, which may
+// be bare or quoted. Dotted keys are not settings and do not match.
+var configKeyLine = regexp.MustCompile(`^\s*([A-Za-z0-9_-]+|"[^"]*"|'[^']*')\s*=`)
+
+// configTableHeader matches a [table] or [[array]] header.
+var configTableHeader = regexp.MustCompile(`^\s*\[\[?\s*([^\]]*?)\s*\]\]?`)
+
+// configKeyRef is a key/value line or table header found in a config file.
+type configKeyRef struct {
+	line       int
+	table      string // Enclosing table ("" = top level)
+	key        string // Key without quotes (empty for table headers)
+	start, end int    // Byte range of the key as written
+	header     bool
+}
+
+// migrateConfig rewrites old setting names in the config file content
+// data to their current names. Only the key is replaced, so comments,
+// layout and values are kept as written. Keys are renamed at the top
+// level and in [profiles.<name>] tables. Returns the renames applied.
+func migrateConfig(data []byte, renames []ConfigKeyRename) ([]byte, []ConfigKeyRename, error) {
+	lines := strings.SplitAfter(string(data), "\n")
+	var applied []ConfigKeyRename
+	for _, r := range renames {
+		refs := scanConfigKeys(lines)
+		renamed := false
+		for _, ref := range refs {
+			if ref.header || ref.key != r.Old || !isSettingsTable(ref.table) {
+				continue
+			}
+			for _, other := range refs {
+				if other.key == r.New && other.table == ref.table {
+					return nil, nil, fmt.Errorf("%s and %s are both set; remove %s",
+						qualifiedKey(ref.table, r.Old), qualifiedKey(ref.table, r.New), qualifiedKey(ref.table, r.Old))
+				}
+			}
+			line := lines[ref.line]
+			lines[ref.line] = line[:ref.start] + r.New + line[ref.end:]
+			renamed = true
+		}
+		if renamed {
+			applied = append(applied, r)
+		}
+	}
+	if len(applied) == 0 {
+		return data, nil, nil
+	}
+	return []byte(strings.Join(lines, "")), applied, nil
+}
+
+// scanConfigKeys returns the key/value lines and table headers of a
+// config file with the table each belongs to. Lines inside multi-line
+// strings are skipped.
+func scanConfigKeys(lines []string) []configKeyRef {
+	var refs []configKeyRef
+	var table, openQuote string
+	for i, line := range lines {
+		if openQuote != "" {
+			if strings.Count(line, openQuote)%2 == 1 {
+				openQuote = ""
+			}
+			continue
+		}
+		if m := configTableHeader.FindStringSubmatch(line); m != nil {
+			table = m[1]
+			refs = append(refs, configKeyRef{line: i, table: table, header: true})
+			continue
+		}
+		m := configKeyLine.FindStringSubmatchIndex(line)
+		if m == nil {
+			continue
+		}
+		refs = append(refs, configKeyRef{
+			line:  i,
+			table: table,
+			key:   strings.Trim(line[m[2]:m[3]], `"'`),
+			start: m[2],
+			end:   m[3],
+		})
+		for _, q := range []string{`"""`, `'''`} {
+			if strings.Count(line[m[1]:], q)%2 == 1 {
+				openQuote = q
+			}
+		}
+	}
+	return refs
+}
+
+// isSettingsTable reports whether keys in table are settings: the top
+// level and profile tables, which take the same keys.
+func isSettingsTable(table string) bool {
+	if table == "" {
+		return true
+	}
+	name, ok := strings.CutPrefix(table, "profiles.")
+	return ok && !strings.Contains(strings.Trim(name, `"'`), ".")
+}
+
+// qualifiedKey returns key prefixed with its table, if any.
+func qualifiedKey(table, key string) string {
+	if table == "" {
+		return key
+	}
+	return table + "." + key
+}
+
+// ConfigMigrateOptions holds options for the config migrate command.
+type ConfigMigrateOptions struct {
+	Check bool // Report what would change without writing
+}
+
+// ConfigFileMigration is a config file whose settings were renamed.
+type ConfigFileMigration struct {
+	File    string // Path relative to the config load directory
+	Renamed []ConfigKeyRename
+}
+
+// ConfigMigrateResult holds the result of migrating the config files.
+type ConfigMigrateResult struct {
+	Files []ConfigFileMigration
+	Check bool
+}
+
+// Format formats the ConfigMigrateResult for display.
+func (r ConfigMigrateResult) Format(opts FormatOptions) FormatResult {
+	if len(r.Files) == 0 {
+		return FormatResult{Stdout: "twig config migrate: config is up to date\n"}
+	}
+	var stdout strings.Builder
+	for _, f := range r.Files {
+		if r.Check {
+			fmt.Fprintf(&stdout, "Would migrate %s:\n", f.File)
+		} else {
+			fmt.Fprintf(&stdout, "Migrated %s:\n", f.File)
+		}
+		for _, rename := range f.Renamed {
+			fmt.Fprintf(&stdout, "  %s -> %s\n", rename.Old, rename.New)
+		}
+	}
+	return FormatResult{Stdout: stdout.String()}
+}
+
+// ConfigMigrateCommand rewrites renamed settings in the config files.
+type ConfigMigrateCommand struct {
+	FS      FileSystem
+	Renames []ConfigKeyRename
+	Log     *slog.Logger
+}
+
+// NewConfigMigrateCommand creates a ConfigMigrateCommand with explicit dependencies.
+func NewConfigMigrateCommand(fs FileSystem, log *slog.Logger) *ConfigMigrateCommand {
+	if log == nil {
+		log = NewNopLogger()
+	}
+	return &ConfigMigrateCommand{FS: fs, Renames: configKeyRenames, Log: log}
+}
+
+// NewDefaultConfigMigrateCommand creates a ConfigMigrateCommand with production defaults.
+func NewDefaultConfigMigrateCommand(log *slog.Logger, gitOpts ...GitRunnerOption) *ConfigMigrateCommand {
+	return NewConfigMigrateCommand(newDefaultFS(gitOpts), log)
+}
+
+// Run rewrites old setting names in the project and local config files
+// in dir. Files are checked before any is written, so a conflict in one
+// leaves both untouched.
+func (c *ConfigMigrateCommand) Run(ctx context.Context, dir string, opts ConfigMigrateOptions) (ConfigMigrateResult, error) {
+	result := ConfigMigrateResult{Check: opts.Check}
+
+	type pending struct {
+		path string
+		data []byte
+	}
+	var writes []pending
+	for _, name := range []string{configFileName, localConfigFileName} {
+		rel := filepath.Join(configDir, name)
+		path := filepath.Join(dir, rel)
+		data, err := c.FS.ReadFile(path)
+		if c.FS.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return result, err
+		}
+		migrated, renamed, err := migrateConfig(data, c.Renames)
+		if err != nil {
+			return result, fmt.Errorf("%s: %w", rel, err)
+		}
+		if len(renamed) == 0 {
+			continue
+		}
+		result.Files = append(result.Files, ConfigFileMigration{File: rel, Renamed: renamed})
+		writes = append(writes, pending{path: path, data: migrated})
+	}
+	if opts.Check {
+		return result, nil
+	}
+
+	for _, w := range writes {
+		info, err := c.FS.Stat(w.path)
+		if err != nil {
+			return result, err
+		}
+		if err := c.FS.WriteFile(w.path, w.data, info.Mode().Perm()); err != nil {
+			return result, fmt.Errorf("failed to write %s: %w", w.path, err)
+		}
+		c.Log.DebugContext(ctx, "migrated config file",
+			LogAttrKeyCategory.String(), LogCategoryConfig,
+			"path", w.path)
+	}
+	return result, nil
+}
@@ -0,0 +1,201 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestMigrateConfig(t *testing.T) {
+	t.Parallel()
+
+	renames := []ConfigKeyRename{
+		{Old: "worktree_destination_base_dir", New: "dest_base_dir"},
+		{Old: "old_hooks", New: "hooks"},
+	}
+
+	tests := []struct {
+		name        string
+		input       string
+		want        string
+		wantRenamed []string
+		wantErr     string
+	}{
+		{
+			name:        "keeps_comments_and_layout",
+			input:       "# where worktrees go\nworktree_destination_base_dir   = \"../wt\" # sibling\nsymlinks = [\".envrc\"]\n",
+			want:        "# where worktrees go\ndest_base_dir   = \"../wt\" # sibling\nsymlinks = [\".envrc\"]\n",
+			wantRenamed: []string{"worktree_destination_base_dir"},
+		},
+		{
+			name:        "quoted_key",
+			input:       "\"old_hooks\" = [\"make\"]\n",
+			want:        "hooks = [\"make\"]\n",
+			wantRenamed: []string{"old_hooks"},
+		},
+		{
+			name:        "profile_table",
+			input:       "[profiles.review]\nworktree_destination_base_dir = \"/tmp/review\"\n",
+			want:        "[profiles.review]\ndest_base_dir = \"/tmp/review\"\n",
+			wantRenamed: []string{"worktree_destination_base_dir"},
+		},
+		{
+			name:  "other_tables_untouched",
+			input: "[branch_aliases]\nold_hooks = \"feat/old-hooks\"\n",
+			want:  "[branch_aliases]\nold_hooks = \"feat/old-hooks\"\n",
+		},
+		{
+			name:  "multiline_string_untouched",
+			input: "open_command = \"\"\"\nold_hooks = x\n\"\"\"\n",
+			want:  "open_command = \"\"\"\nold_hooks = x\n\"\"\"\n",
+		},
+		{
+			name:    "old_and_new_both_set",
+			input:   "old_hooks = [\"a\"]\nhooks = [\"b\"]\n",
+			wantErr: "old_hooks and hooks are both set; remove old_hooks",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, renamed, err := migrateConfig([]byte(tt.input), renames)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("migrateConfig() =\n%s\nwant\n%s", got, tt.want)
+			}
+			var olds []string
+			for _, r := range renamed {
+				olds = append(olds, r.Old)
+			}
+			if !slices.Equal(olds, tt.wantRenamed) {
+				t.Errorf("renamed = %v, want %v", olds, tt.wantRenamed)
+			}
+		})
+	}
+}
+
+func TestConfigMigrateCommand_Run(t *testing.T) {
+	t.Parallel()
+
+	renames := []ConfigKeyRename{{Old: "worktree_destination_base_dir", New: "dest_base_dir"}}
+	setup := func(t *testing.T, files map[string]string) string {
+		t.Helper()
+		dir := t.TempDir()
+		if err := os.MkdirAll(filepath.Join(dir, configDir), 0755); err != nil {
+			t.Fatal(err)
+		}
+		for name, content := range files {
+			if err := os.WriteFile(filepath.Join(dir, configDir, name), []byte(content), 0600); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return dir
+	}
+
+	t.Run("rewrites_files", func(t *testing.T) {
+		t.Parallel()
+
+		dir := setup(t, map[string]string{
+			configFileName:      "# base\nworktree_destination_base_dir = \"../wt\"\n",
+			localConfigFileName: "default_source = \"main\"\n",
+		})
+		cmd := &ConfigMigrateCommand{FS: osFS{}, Renames: renames, Log: NewNopLogger()}
+
+		result, err := cmd.Run(t.Context(), dir, ConfigMigrateOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(result.Files) != 1 || result.Files[0].File != filepath.Join(configDir, configFileName) {
+			t.Fatalf("Files = %+v, want only %s", result.Files, configFileName)
+		}
+
+		path := filepath.Join(dir, configDir, configFileName)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := "# base\ndest_base_dir = \"../wt\"\n"; string(data) != want {
+			t.Errorf("settings.toml = %q, want %q", data, want)
+		}
+		if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+			t.Errorf("settings.toml mode = %v (err %v), want 0600", info.Mode().Perm(), err)
+		}
+
+		formatted := result.Format(FormatOptions{})
+		if want := "Migrated .twig/settings.toml:\n  worktree_destination_base_dir -> dest_base_dir\n"; formatted.Stdout != want {
+			t.Errorf("Format() = %q, want %q", formatted.Stdout, want)
+		}
+	})
+
+	t.Run("check_does_not_write", func(t *testing.T) {
+		t.Parallel()
+
+		content := "worktree_destination_base_dir = \"../wt\"\n"
+		dir := setup(t, map[string]string{localConfigFileName: content})
+		cmd := &ConfigMigrateCommand{FS: osFS{}, Renames: renames, Log: NewNopLogger()}
+
+		result, err := cmd.Run(t.Context(), dir, ConfigMigrateOptions{Check: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := os.ReadFile(filepath.Join(dir, configDir, localConfigFileName))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != content {
+			t.Errorf("settings.local.toml was rewritten: %q", data)
+		}
+		if got := result.Format(FormatOptions{}).Stdout; !strings.HasPrefix(got, "Would migrate .twig/settings.local.toml:") {
+			t.Errorf("Format() = %q, want Would migrate", got)
+		}
+	})
+
+	t.Run("conflict_leaves_files_untouched", func(t *testing.T) {
+		t.Parallel()
+
+		project := "worktree_destination_base_dir = \"../wt\"\n"
+		dir := setup(t, map[string]string{
+			configFileName:      project,
+			localConfigFileName: "worktree_destination_base_dir = \"a\"\ndest_base_dir = \"b\"\n",
+		})
+		cmd := &ConfigMigrateCommand{FS: osFS{}, Renames: renames, Log: NewNopLogger()}
+
+		if _, err := cmd.Run(t.Context(), dir, ConfigMigrateOptions{}); err == nil || !strings.Contains(err.Error(), "both set") {
+			t.Fatalf("error = %v, want both set", err)
+		}
+		data, err := os.ReadFile(filepath.Join(dir, configDir, configFileName))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != project {
+			t.Errorf("settings.toml was rewritten: %q", data)
+		}
+	})
+
+	t.Run("up_to_date", func(t *testing.T) {
+		t.Parallel()
+
+		dir := setup(t, nil)
+		cmd := NewConfigMigrateCommand(osFS{}, nil)
+
+		result, err := cmd.Run(t.Context(), dir, ConfigMigrateOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := result.Format(FormatOptions{}).Stdout; got != "twig config migrate: config is up to date\n" {
+			t.Errorf("Format() = %q", got)
+		}
+	})
+}
@@ -0,0 +1,104 @@
+package core
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const (
+	// ConfigSchemaID is where the generated schema is published, for the
+	// "#:schema" directive of TOML editors.
+	ConfigSchemaID = "https://raw.githubusercontent.com/708u/twig/main/docs/reference/settings.schema.json"
+
+	jsonSchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+)
+
+// Struct tags read by ConfigSchema, next to the toml tag.
+const (
+	schemaTagDoc     = "doc"     // Description shown by editors
+	schemaTagDefault = "default" // Default value, parsed by the field type
+	schemaTagEnum    = "enum"    // Comma-separated allowed values
+)
+
+// ConfigSchema returns a JSON Schema for .twig/settings.toml and
+// .twig/settings.local.toml. It is generated from the toml, doc, default
+// and enum tags of Config and Profile, so a new setting only needs its
+// struct field annotated.
+func ConfigSchema() ([]byte, error) {
+	schema := structSchema(reflect.TypeFor[Config]())
+	schema["$schema"] = jsonSchemaDraft
+	schema["$id"] = ConfigSchemaID
+	schema["title"] = "twig settings"
+	schema["description"] = "Settings for twig in .twig/settings.toml and .twig/settings.local.toml"
+
+	data, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// structSchema describes the toml-tagged fields of a struct type.
+// Fields without a toml tag (or tagged "-") are not settings.
+func structSchema(t reflect.Type) map[string]any {
+	properties := make(map[string]any)
+	for i := range t.NumField() {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("toml"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+
+		prop := typeSchema(field.Type)
+		if doc := field.Tag.Get(schemaTagDoc); doc != "" {
+			prop["description"] = doc
+		}
+		if def, ok := field.Tag.Lookup(schemaTagDefault); ok {
+			prop["default"] = defaultValue(field.Type, def)
+		}
+		if enum, ok := field.Tag.Lookup(schemaTagEnum); ok {
+			prop["enum"] = strings.Split(enum, ",")
+		}
+		properties[name] = prop
+	}
+	return map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+}
+
+// typeSchema maps a Go field type to its JSON Schema type.
+func typeSchema(t reflect.Type) map[string]any {
+	switch t.Kind() {
+	case reflect.Pointer:
+		return typeSchema(t.Elem())
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int:
+		return map[string]any{"type": "integer"}
+	case reflect.Slice:
+		return map[string]any{"type": "array", "items": typeSchema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": typeSchema(t.Elem())}
+	case reflect.Struct:
+		return structSchema(t)
+	default:
+		return map[string]any{"type": "string"}
+	}
+}
+
+// defaultValue converts a default tag to the JSON value of the field type.
+func defaultValue(t reflect.Type, def string) any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Bool {
+		if b, err := strconv.ParseBool(def); err == nil {
+			return b
+		}
+	}
+	return def
+}
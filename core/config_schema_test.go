@@ -0,0 +1,78 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestConfigSchema(t *testing.T) {
+	t.Parallel()
+
+	data, err := ConfigSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	type property struct {
+		Type        string              `json:"type"`
+		Description string              `json:"description"`
+		Default     any                 `json:"default"`
+		Properties  map[string]property `json:"properties"`
+	}
+	var schema struct {
+		Properties map[string]property `json:"properties"`
+	}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatalf("schema is not valid JSON: %v", err)
+	}
+
+	// Every documented setting needs an annotated field; dotted keys such
+	// as gc.max_age are nested tables
+	topLevel := map[string]bool{}
+	for _, key := range configKeys {
+		table, name, nested := strings.Cut(key.name, ".")
+		topLevel[table] = true
+		prop, ok := schema.Properties[table]
+		if ok && nested {
+			prop, ok = prop.Properties[name]
+		}
+		if !ok {
+			t.Errorf("schema is missing %s", key.name)
+			continue
+		}
+		if prop.Description == "" {
+			t.Errorf("%s has no doc tag", key.name)
+		}
+	}
+	if _, ok := schema.Properties["profiles"]; !ok {
+		t.Error("schema is missing profiles")
+	}
+	if len(schema.Properties) != len(topLevel)+1 {
+		t.Errorf("schema has %d properties, want %d", len(schema.Properties), len(topLevel)+1)
+	}
+
+	if got := schema.Properties["cleanup_empty_dirs"]; got.Type != "boolean" || got.Default != true {
+		t.Errorf("cleanup_empty_dirs = %+v, want boolean defaulting to true", got)
+	}
+	if got := schema.Properties["git_lock_wait"]; got.Type != "string" || got.Default != "10s" {
+		t.Errorf("git_lock_wait = %+v, want string defaulting to 10s", got)
+	}
+}
+
+func TestConfigSchema_Published(t *testing.T) {
+	t.Parallel()
+
+	want, err := ConfigSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile("../docs/reference/settings.schema.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Error("docs/reference/settings.schema.json is out of date; regenerate it with: go run ./cmd/twig config schema > docs/reference/settings.schema.json")
+	}
+}
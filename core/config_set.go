@@ -0,0 +1,446 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"maps"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// lookupConfigKey returns the top-level setting named name, such as
+// "default_source" or "gc.max_age".
+func lookupConfigKey(name string) (configKey, error) {
+	for _, key := range configKeys {
+		if key.name == name {
+			return key, nil
+		}
+	}
+	return configKey{}, fmt.Errorf("unknown setting %q (see twig config effective)", name)
+}
+
+// ConfigKeyNames returns the names of the top-level settings accepted by
+// twig config get and set, in documentation order.
+func ConfigKeyNames() []string {
+	names := make([]string, len(configKeys))
+	for i, key := range configKeys {
+		names[i] = key.name
+	}
+	return names
+}
+
+// configValueLiteral converts the command line values of key to a TOML
+// value. Lists take one or more values; other settings take exactly one,
+// checked by checkConfigValue.
+func configValueLiteral(key configKey, values []string) (string, error) {
+	switch key.value(&Config{}).(type) {
+	case []string:
+		if len(values) == 0 {
+			return "", fmt.Errorf("%s takes at least one value", key.name)
+		}
+		return formatConfigValue(values), nil
+	case map[string]string:
+		return "", fmt.Errorf("%s is a table; edit the config file directly", key.name)
+	}
+	if len(values) != 1 {
+		return "", fmt.Errorf("%s takes exactly one value, got %d", key.name, len(values))
+	}
+	switch key.value(&Config{}).(type) {
+	case *bool:
+		b, err := strconv.ParseBool(values[0])
+		if err != nil {
+			return "", fmt.Errorf("%s must be true or false, got %q", key.name, values[0])
+		}
+		return strconv.FormatBool(b), nil
+	case int:
+		n, err := strconv.Atoi(values[0])
+		if err != nil {
+			return "", fmt.Errorf("%s must be an integer, got %q", key.name, values[0])
+		}
+		return strconv.Itoa(n), nil
+	default:
+		if err := checkConfigValue(key.name, values[0]); err != nil {
+			return "", err
+		}
+		return formatConfigValue(values[0]), nil
+	}
+}
+
+// configDurationKeys maps the settings that hold a duration to the parser
+// LoadConfig uses for them, so config set rejects what load would ignore.
+var configDurationKeys = map[string]func(string) (time.Duration, error){
+	"git_lock_wait":      parseConfigDuration,
+	"git_timeout":        parseConfigDuration,
+	"git_remote_timeout": parseConfigDuration,
+	"notify_after":       parseConfigDuration,
+	"gc.max_age":         parseGCAge,
+}
+
+// parseConfigDuration parses a non-negative duration such as "30s".
+func parseConfigDuration(s string) (time.Duration, error) {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("negative duration %q", s)
+	}
+	return d, nil
+}
+
+// checkConfigValue checks a string value of the setting name against the
+// enum tag of its field and, for durations, against the parser LoadConfig
+// uses.
+func checkConfigValue(name, value string) error {
+	if allowed, ok := configEnum(name); ok && !slices.Contains(allowed, value) {
+		quoted := make([]string, len(allowed))
+		for i, v := range allowed {
+			quoted[i] = strconv.Quote(v)
+		}
+		return fmt.Errorf("%s must be one of %s, got %q", name, strings.Join(quoted, ", "), value)
+	}
+	if parse, ok := configDurationKeys[name]; ok {
+		if _, err := parse(value); err != nil {
+			if name == "gc.max_age" {
+				return fmt.Errorf("%s must be a number of days or a duration (e.g. 45d, 720h), got %q", name, value)
+			}
+			return fmt.Errorf("%s must be a duration (e.g. 30s, 5m), got %q", name, value)
+		}
+	}
+	return nil
+}
+
+// configEnum returns the allowed values in the enum tag of the field for
+// the setting name, such as "symlink_style".
+func configEnum(name string) ([]string, bool) {
+	t := reflect.TypeFor[Config]()
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		field, ok := configField(t, part)
+		if !ok {
+			return nil, false
+		}
+		if i < len(parts)-1 {
+			t = field.Type
+			continue
+		}
+		enum, ok := field.Tag.Lookup(schemaTagEnum)
+		if !ok {
+			return nil, false
+		}
+		return strings.Split(enum, ","), true
+	}
+	return nil, false
+}
+
+// configField returns the field of struct type t with the toml name name.
+func configField(t reflect.Type, name string) (reflect.StructField, bool) {
+	if t.Kind() != reflect.Struct {
+		return reflect.StructField{}, false
+	}
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if tag, _, _ := strings.Cut(field.Tag.Get("toml"), ","); tag == name {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// setConfigValue assigns the TOML value to key in the config file content
+// data. A dotted key such as "gc.max_age" is set in its table. An existing
+// assignment is replaced in place, keeping the comment after it; a new
+// one is added after the last key of its table, and a missing table is
+// appended to the end.
+func setConfigValue(data []byte, key, value string) []byte {
+	content := string(data)
+	lines := strings.SplitAfter(content, "\n")
+	offsets := make([]int, len(lines)+1)
+	for i, line := range lines {
+		offsets[i+1] = offsets[i] + len(line)
+	}
+
+	table, name := "", key
+	if i := strings.LastIndex(key, "."); i >= 0 {
+		table, name = key[:i], key[i+1:]
+	}
+
+	var last *configKeyRef // Last key or header of table
+	firstHeader := -1
+	refs := scanConfigKeys(lines)
+	for i, ref := range refs {
+		if ref.header && firstHeader < 0 {
+			firstHeader = ref.line
+		}
+		if ref.table != table {
+			continue
+		}
+		if !ref.header && ref.key == name {
+			start := offsets[ref.line] + ref.end
+			start += strings.Index(content[start:], "=") + 1
+			start += len(content[start:]) - len(strings.TrimLeft(content[start:], " \t"))
+			return []byte(content[:start] + value + content[tomlValueEnd(content, start):])
+		}
+		last = &refs[i]
+	}
+
+	assignment := name + " = " + value + "\n"
+	switch {
+	case last != nil:
+		// Insert after the line the last value of the table ends on
+		pos := offsets[last.line+1]
+		if !last.header {
+			start := offsets[last.line] + last.end
+			start += strings.Index(content[start:], "=") + 1
+			end := tomlValueEnd(content, start)
+			pos = len(content)
+			if nl := strings.IndexByte(content[end:], '\n'); nl >= 0 {
+				pos = end + nl + 1
+			}
+		}
+		if pos > 0 && content[pos-1] != '\n' {
+			assignment = "\n" + assignment
+		}
+		return []byte(content[:pos] + assignment + content[pos:])
+	case table == "" && firstHeader >= 0:
+		pos := offsets[firstHeader]
+		return []byte(content[:pos] + assignment + "\n" + content[pos:])
+	case table == "":
+		if content != "" && !strings.HasSuffix(content, "\n") {
+			content += "\n"
+		}
+		return []byte(content + assignment)
+	default:
+		if content != "" {
+			if !strings.HasSuffix(content, "\n") {
+				content += "\n"
+			}
+			content += "\n"
+		}
+		return []byte(content + "[" + table + "]\n" + assignment)
+	}
+}
+
+// tomlValueEnd returns the offset where the TOML value starting at start
+// ends, before any trailing whitespace or comment. Arrays, inline tables
+// and multi-line strings may span lines.
+func tomlValueEnd(s string, start int) int {
+	depth := 0
+	end := start
+	for i := start; i < len(s); {
+		switch c := s[i]; {
+		case strings.HasPrefix(s[i:], `"""`), strings.HasPrefix(s[i:], `'''`):
+			j := strings.Index(s[i+3:], s[i:i+3])
+			if j < 0 {
+				return len(s)
+			}
+			i += 3 + j + 3
+			end = i
+		case c == '"' || c == '\'':
+			i++
+			for i < len(s) && s[i] != c && s[i] != '\n' {
+				if c == '"' && s[i] == '\\' {
+					i++
+				}
+				i++
+			}
+			i++
+			end = min(i, len(s))
+		case c == '#':
+			if depth == 0 {
+				return end
+			}
+			if nl := strings.IndexByte(s[i:], '\n'); nl >= 0 {
+				i += nl
+			} else {
+				i = len(s)
+			}
+		case c == '\n' || c == '\r':
+			if depth == 0 {
+				return end
+			}
+			i++
+		case c == ' ' || c == '\t':
+			i++
+		default:
+			if c == '[' || c == '{' {
+				depth++
+			} else if c == ']' || c == '}' {
+				depth--
+			}
+			i++
+			end = i
+		}
+	}
+	return end
+}
+
+// ConfigSetOptions holds options for the config set command.
+type ConfigSetOptions struct {
+	Local bool // Write .twig/settings.local.toml instead of .twig/settings.toml
+}
+
+// ConfigSetResult holds the result of setting a config value.
+type ConfigSetResult struct {
+	File    string // Path relative to the config load directory
+	Key     string
+	Value   string // TOML value as written
+	Created bool   // The file did not exist
+}
+
+// Format formats the ConfigSetResult for display.
+func (r ConfigSetResult) Format(opts FormatOptions) FormatResult {
+	return FormatResult{Stdout: fmt.Sprintf("Set %s = %s in %s\n", r.Key, r.Value, r.File)}
+}
+
+// ConfigSetCommand sets a value in a config file without rewriting the
+// rest of it, so comments and layout are kept.
+type ConfigSetCommand struct {
+	FS  FileSystem
+	Log *slog.Logger
+}
+
+// NewConfigSetCommand creates a ConfigSetCommand with explicit dependencies.
+func NewConfigSetCommand(fs FileSystem, log *slog.Logger) *ConfigSetCommand {
+	if log == nil {
+		log = NewNopLogger()
+	}
+	return &ConfigSetCommand{FS: fs, Log: log}
+}
+
+// NewDefaultConfigSetCommand creates a ConfigSetCommand with production defaults.
+func NewDefaultConfigSetCommand(log *slog.Logger, gitOpts ...GitRunnerOption) *ConfigSetCommand {
+	return NewConfigSetCommand(newDefaultFS(gitOpts), log)
+}
+
+// Run sets the setting name to values in the project config file in dir,
+// or the local one with opts.Local. The file is created when missing and
+// left untouched when a value is invalid or the result would not decode.
+func (c *ConfigSetCommand) Run(ctx context.Context, dir, name string, values []string, opts ConfigSetOptions) (ConfigSetResult, error) {
+	file := configFileName
+	if opts.Local {
+		file = localConfigFileName
+	}
+	result := ConfigSetResult{File: filepath.Join(configDir, file), Key: name}
+
+	key, err := lookupConfigKey(name)
+	if err != nil {
+		return result, err
+	}
+	value, err := configValueLiteral(key, values)
+	if err != nil {
+		return result, err
+	}
+	result.Value = value
+
+	path := filepath.Join(dir, result.File)
+	perm := fs.FileMode(0644)
+	data, err := c.FS.ReadFile(path)
+	switch {
+	case c.FS.IsNotExist(err):
+		result.Created = true
+	case err != nil:
+		return result, err
+	default:
+		info, err := c.FS.Stat(path)
+		if err != nil {
+			return result, err
+		}
+		perm = info.Mode().Perm()
+	}
+
+	updated := setConfigValue(data, name, value)
+	if _, err := toml.Decode(string(updated), &Config{}); err != nil {
+		return result, fmt.Errorf("%s: cannot set %s: %w", result.File, name, err)
+	}
+
+	if result.Created {
+		if err := c.FS.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return result, fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+		}
+	}
+	if err := c.FS.WriteFile(path, updated, perm); err != nil {
+		return result, fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	c.Log.DebugContext(ctx, "set config value",
+		LogAttrKeyCategory.String(), LogCategoryConfig,
+		"path", path,
+		"key", name,
+		"value", value)
+	return result, nil
+}
+
+// ConfigGetOptions holds options for GetConfig.
+type ConfigGetOptions struct {
+	Local bool // Read only .twig/settings.local.toml
+}
+
+// ConfigGetResult holds a single setting read by GetConfig.
+type ConfigGetResult struct {
+	Entry ConfigEntry
+}
+
+// Format formats the value for scripts: strings without quotes, one list
+// item per line, and one "key = value" line per table entry.
+func (r ConfigGetResult) Format(opts FormatOptions) FormatResult {
+	var stdout strings.Builder
+	switch v := r.Entry.Value.(type) {
+	case []string:
+		for _, s := range v {
+			fmt.Fprintln(&stdout, s)
+		}
+	case map[string]string:
+		for _, k := range slices.Sorted(maps.Keys(v)) {
+			fmt.Fprintf(&stdout, "%s = %s\n", k, v[k])
+		}
+	default:
+		fmt.Fprintln(&stdout, v)
+	}
+	return FormatResult{Stdout: stdout.String()}
+}
+
+// GetConfig returns the effective value of the setting name in dir, or
+// its default when it is not set anywhere. With opts.Local only the
+// local config file is read, and an unset value is an error. loadOpts
+// are passed to LoadConfig.
+func GetConfig(dir, name string, opts ConfigGetOptions, loadOpts ...LoadConfigOption) (ConfigGetResult, error) {
+	key, err := lookupConfigKey(name)
+	if err != nil {
+		return ConfigGetResult{}, err
+	}
+
+	if opts.Local {
+		rel := filepath.Join(configDir, localConfigFileName)
+		cfg, _, _, err := decodeConfigFile(filepath.Join(dir, rel))
+		if err != nil {
+			return ConfigGetResult{}, fmt.Errorf("%s: %w", rel, err)
+		}
+		if cfg == nil || !key.set(cfg) {
+			return ConfigGetResult{}, fmt.Errorf("%s is not set in %s", name, rel)
+		}
+		return ConfigGetResult{Entry: ConfigEntry{
+			Key:     name,
+			Value:   effectiveConfigValue(key.value(cfg)),
+			Sources: []string{rel},
+		}}, nil
+	}
+
+	effective, err := EffectiveConfig(dir, loadOpts...)
+	if err != nil {
+		return ConfigGetResult{}, err
+	}
+	for _, e := range effective.Entries {
+		if e.Key == name {
+			return ConfigGetResult{Entry: e}, nil
+		}
+	}
+	return ConfigGetResult{}, fmt.Errorf("unknown setting %q", name)
+}
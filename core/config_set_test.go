@@ -0,0 +1,349 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSetConfigValue(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+		key   string
+		value string
+		want  string
+	}{
+		{
+			name:  "replace_keeps_comment",
+			input: "# source\ndefault_source   = \"main\" # base branch\nsymlinks = []\n",
+			key:   "default_source",
+			value: `"develop"`,
+			want:  "# source\ndefault_source   = \"develop\" # base branch\nsymlinks = []\n",
+		},
+		{
+			name:  "replace_multiline_array",
+			input: "symlinks = [\n  \".envrc\", # direnv\n  \".tool-versions\",\n]\nhooks = []\n",
+			key:   "symlinks",
+			value: `[".claude"]`,
+			want:  "symlinks = [\".claude\"]\nhooks = []\n",
+		},
+		{
+			name:  "replace_multiline_string",
+			input: "open_command = \"\"\"\ncode\n\"\"\" # editor\n",
+			key:   "open_command",
+			value: `"vim"`,
+			want:  "open_command = \"vim\" # editor\n",
+		},
+		{
+			name:  "add_after_last_top_level_key",
+			input: "#:schema x\ndefault_source = \"main\"\n\n[gc]\nmax_age = \"30d\"\n",
+			key:   "branch_prefix",
+			value: `"me/"`,
+			want:  "#:schema x\ndefault_source = \"main\"\nbranch_prefix = \"me/\"\n\n[gc]\nmax_age = \"30d\"\n",
+		},
+		{
+			name:  "add_before_first_table",
+			input: "# settings\n[gc]\nmax_age = \"30d\"\n",
+			key:   "branch_prefix",
+			value: `"me/"`,
+			want:  "# settings\nbranch_prefix = \"me/\"\n\n[gc]\nmax_age = \"30d\"\n",
+		},
+		{
+			name:  "replace_in_table",
+			input: "max_age = 1\n[gc]\nmax_age = \"30d\"\n",
+			key:   "gc.max_age",
+			value: `"7d"`,
+			want:  "max_age = 1\n[gc]\nmax_age = \"7d\"\n",
+		},
+		{
+			name:  "add_to_existing_table",
+			input: "[gc]\nmax_age = \"30d\"\n[colors]\nclean = \"green\"\n",
+			key:   "gc.check_on_add",
+			value: "true",
+			want:  "[gc]\nmax_age = \"30d\"\ncheck_on_add = true\n[colors]\nclean = \"green\"\n",
+		},
+		{
+			name:  "add_to_empty_table",
+			input: "[gc]\n",
+			key:   "gc.max_worktrees",
+			value: "5",
+			want:  "[gc]\nmax_worktrees = 5\n",
+		},
+		{
+			name:  "append_table",
+			input: "default_source = \"main\"",
+			key:   "gc.max_age",
+			value: `"30d"`,
+			want:  "default_source = \"main\"\n\n[gc]\nmax_age = \"30d\"\n",
+		},
+		{
+			name:  "empty_file",
+			key:   "default_source",
+			value: `"main"`,
+			want:  "default_source = \"main\"\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := setConfigValue([]byte(tt.input), tt.key, tt.value)
+			if string(got) != tt.want {
+				t.Errorf("setConfigValue() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigSetCommand_Run(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		files   map[string]string
+		key     string
+		values  []string
+		opts    ConfigSetOptions
+		file    string
+		want    string
+		wantErr string
+	}{
+		{
+			name:   "creates_project_file",
+			key:    "default_source",
+			values: []string{"develop"},
+			file:   configFileName,
+			want:   "default_source = \"develop\"\n",
+		},
+		{
+			name:   "local_list",
+			files:  map[string]string{localConfigFileName: "# mine\nsymlinks = [\".envrc\"]\n"},
+			key:    "symlinks",
+			values: []string{".envrc", ".tool-versions"},
+			opts:   ConfigSetOptions{Local: true},
+			file:   localConfigFileName,
+			want:   "# mine\nsymlinks = [\".envrc\", \".tool-versions\"]\n",
+		},
+		{
+			name:   "bool",
+			key:    "init_submodules",
+			values: []string{"true"},
+			file:   configFileName,
+			want:   "init_submodules = true\n",
+		},
+		{
+			name:   "enum",
+			key:    "symlink_style",
+			values: []string{"absolute"},
+			file:   configFileName,
+			want:   "symlink_style = \"absolute\"\n",
+		},
+		{
+			name:   "duration",
+			key:    "gc.max_age",
+			values: []string{"45d"},
+			file:   configFileName,
+			want:   "[gc]\nmax_age = \"45d\"\n",
+		},
+		{
+			name:    "invalid_bool",
+			key:     "init_submodules",
+			values:  []string{"yes please"},
+			wantErr: "must be true or false",
+		},
+		{
+			name:    "invalid_int",
+			key:     "gc.max_worktrees",
+			values:  []string{"many"},
+			wantErr: "must be an integer",
+		},
+		{
+			name:    "unknown_key",
+			key:     "symlink",
+			values:  []string{".envrc"},
+			wantErr: `unknown setting "symlink"`,
+		},
+		{
+			name:    "too_many_values",
+			key:     "default_source",
+			values:  []string{"a", "b"},
+			wantErr: "takes exactly one value",
+		},
+		{
+			name:    "table",
+			key:     "branch_aliases",
+			values:  []string{"x"},
+			wantErr: "is a table",
+		},
+		{
+			name:    "invalid_file_untouched",
+			files:   map[string]string{configFileName: "gc = \"oops\"\n"},
+			key:     "gc.max_age",
+			values:  []string{"30d"},
+			file:    configFileName,
+			want:    "gc = \"oops\"\n",
+			wantErr: "cannot set gc.max_age",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			if len(tt.files) > 0 {
+				if err := os.MkdirAll(filepath.Join(dir, configDir), 0755); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for name, content := range tt.files {
+				if err := os.WriteFile(filepath.Join(dir, configDir, name), []byte(content), 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			cmd := NewConfigSetCommand(osFS{}, nil)
+			result, err := cmd.Run(t.Context(), dir, tt.key, tt.values, tt.opts)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			if tt.file == "" {
+				return
+			}
+
+			data, err := os.ReadFile(filepath.Join(dir, configDir, tt.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("%s = %q, want %q", tt.file, data, tt.want)
+			}
+			if tt.wantErr == "" {
+				want := "Set " + tt.key + " = "
+				if got := result.Format(FormatOptions{}).Stdout; !strings.HasPrefix(got, want) {
+					t.Errorf("Format() = %q, want prefix %q", got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestConfigSetCommand_Run_InvalidValue(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		key     string
+		values  []string
+		wantErr string
+	}{
+		{name: "symlink_style", key: "symlink_style", values: []string{"foo"}, wantErr: `symlink_style must be one of "relative", "absolute", got "foo"`},
+		{name: "case_collisions", key: "case_collisions", values: []string{"ignore"}, wantErr: `case_collisions must be one of "auto", "error", "warn"`},
+		{name: "forge", key: "forge", values: []string{"bitbucket"}, wantErr: `forge must be one of "", "github", "gitlab"`},
+		{name: "git_timeout", key: "git_timeout", values: []string{"notaduration"}, wantErr: `git_timeout must be a duration`},
+		{name: "git_timeout_negative", key: "git_timeout", values: []string{"-1s"}, wantErr: `git_timeout must be a duration`},
+		{name: "git_remote_timeout", key: "git_remote_timeout", values: []string{"5"}, wantErr: `git_remote_timeout must be a duration`},
+		{name: "git_lock_wait", key: "git_lock_wait", values: []string{"soon"}, wantErr: `git_lock_wait must be a duration`},
+		{name: "gc_max_age", key: "gc.max_age", values: []string{"0d"}, wantErr: `gc.max_age must be a number of days or a duration`},
+		{name: "empty_list", key: "hooks", values: nil, wantErr: "hooks takes at least one value"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			path := filepath.Join(dir, configDir, configFileName)
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				t.Fatal(err)
+			}
+			const content = "# keep\n"
+			if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			cmd := NewConfigSetCommand(osFS{}, nil)
+			_, err := cmd.Run(t.Context(), dir, tt.key, tt.values, ConfigSetOptions{})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want %q", err, tt.wantErr)
+			}
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != content {
+				t.Errorf("%s = %q, want it untouched", configFileName, data)
+			}
+		})
+	}
+}
+
+func TestGetConfig(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, configDir), 0755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		configFileName:      "default_source = \"main\"\nsymlinks = [\".envrc\", \".tool-versions\"]\n",
+		localConfigFileName: "branch_prefix = \"me/\"\n[branch_aliases]\nx = \"feat/x\"\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, configDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	noEnv := WithGetenv(func(string) string { return "" })
+
+	tests := []struct {
+		name    string
+		key     string
+		opts    ConfigGetOptions
+		want    string
+		wantErr string
+	}{
+		{name: "string", key: "default_source", want: "main\n"},
+		{name: "list", key: "symlinks", want: ".envrc\n.tool-versions\n"},
+		{name: "table", key: "branch_aliases", want: "x = feat/x\n"},
+		{name: "default", key: "init_submodules", want: "false\n"},
+		{name: "local", key: "branch_prefix", opts: ConfigGetOptions{Local: true}, want: "me/\n"},
+		{name: "local_unset", key: "default_source", opts: ConfigGetOptions{Local: true}, wantErr: "default_source is not set in .twig/settings.local.toml"},
+		{name: "unknown", key: "nope", wantErr: `unknown setting "nope"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			result, err := GetConfig(dir, tt.key, tt.opts, noEnv)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := result.Format(FormatOptions{}).Stdout; got != tt.want {
+				t.Errorf("Format() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if names := ConfigKeyNames(); !slices.Contains(names, "gc.max_age") || names[0] != "worktree_destination_base_dir" {
+		t.Errorf("ConfigKeyNames() = %v", names)
+	}
+}
@@ -0,0 +1,2515 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadConfig_SymlinksOverride(t *testing.T) {
+	t.Parallel()
+
+	t.Run("LocalOverridesProject", func(t *testing.T) {
+		t.Parallel()
+
+		tmpDir := t.TempDir()
+		twigDir := filepath.Join(tmpDir, configDir)
+		if err := os.MkdirAll(twigDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		// Project config with symlinks
+		projectSettings := `symlinks = [".envrc", ".config"]
+`
+		if err := os.WriteFile(filepath.Join(twigDir, configFileName), []byte(projectSettings), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		// Local config overrides symlinks
+		localSettings := `symlinks = [".tool-versions"]
+`
+		if err := os.WriteFile(filepath.Join(twigDir, localConfigFileName), []byte(localSettings), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := LoadConfig(tmpDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Local should override project (only .tool-versions)
+		expected := []string{".tool-versions"}
+		if !reflect.DeepEqual(result.Config.Symlinks, expected) {
+			t.Errorf("Symlinks = %v, want %v", result.Config.Symlinks, expected)
+		}
+	})
+
+	t.Run("ProjectUsedWhenNoLocal", func(t *testing.T) {
+		t.Parallel()
+
+		tmpDir := t.TempDir()
+		twigDir := filepath.Join(tmpDir, configDir)
+		if err := os.MkdirAll(twigDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		// Project config with symlinks
+		projectSettings := `symlinks = [".envrc", ".config"]
+`
+		if err := os.WriteFile(filepath.Join(twigDir, configFileName), []byte(projectSettings), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := LoadConfig(tmpDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Project symlinks should be used
+		expected := []string{".envrc", ".config"}
+		if !reflect.DeepEqual(result.Config.Symlinks, expected) {
+			t.Errorf("Symlinks = %v, want %v", result.Config.Symlinks, expected)
+		}
+	})
+
+	t.Run("EmptyLocalDoesNotOverride", func(t *testing.T) {
+		t.Parallel()
+
+		tmpDir := t.TempDir()
+		twigDir := filepath.Join(tmpDir, configDir)
+		if err := os.MkdirAll(twigDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		// Project config with symlinks
+		projectSettings := `symlinks = [".envrc"]
+`
+		if err := os.WriteFile(filepath.Join(twigDir, configFileName), []byte(projectSettings), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		// Local config with empty symlinks array
+		localSettings := `symlinks = []
+`
+		if err := os.WriteFile(filepath.Join(twigDir, localConfigFileName), []byte(localSettings), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := LoadConfig(tmpDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Empty local should not override project
+		expected := []string{".envrc"}
+		if !reflect.DeepEqual(result.Config.Symlinks, expected) {
+			t.Errorf("Symlinks = %v, want %v", result.Config.Symlinks, expected)
+		}
+	})
+}
+
+func TestLoadConfig_ExtraSymlinks(t *testing.T) {
+	t.Parallel()
+
+	t.Run("ExtraSymlinksAppended", func(t *testing.T) {
+		t.Parallel()
+
+		tmpDir := t.TempDir()
+		twigDir := filepath.Join(tmpDir, configDir)
+		if err := os.MkdirAll(twigDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		// Project config with symlinks and extra_symlinks
+		projectSettings := `symlinks = [".envrc"]
+extra_symlinks = [".tool-versions"]
+`
+		if err := os.WriteFile(filepath.Join(twigDir, configFileName), []byte(projectSettings), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := LoadConfig(tmpDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// symlinks should include both
+		expected := []string{".envrc", ".tool-versions"}
+		if !reflect.DeepEqual(result.Config.Symlinks, expected) {
+			t.Errorf("Symlinks = %v, want %v", result.Config.Symlinks, expected)
+		}
+
+		// ExtraSymlinks should only have the extra ones
+		expectedExtra := []string{".tool-versions"}
+		if !reflect.DeepEqual(result.Config.ExtraSymlinks, expectedExtra) {
+			t.Errorf("ExtraSymlinks = %v, want %v", result.Config.ExtraSymlinks, expectedExtra)
+		}
+	})
+
+	t.Run("ExtraSymlinksDeduplicated", func(t *testing.T) {
+		t.Parallel()
+
+		tmpDir := t.TempDir()
+		twigDir := filepath.Join(tmpDir, configDir)
+		if err := os.MkdirAll(twigDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		// Project config with overlapping symlinks and extra_symlinks
+		projectSettings := `symlinks = [".envrc", ".tool-versions"]
+extra_symlinks = [".tool-versions", ".config"]
+`
+		if err := os.WriteFile(filepath.Join(twigDir, configFileName), []byte(projectSettings), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := LoadConfig(tmpDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Duplicates should be removed
+		expected := []string{".envrc", ".tool-versions", ".config"}
+		if !reflect.DeepEqual(result.Config.Symlinks, expected) {
+			t.Errorf("Symlinks = %v, want %v", result.Config.Symlinks, expected)
+		}
+
+		// ExtraSymlinks should only have non-duplicate entries
+		expectedExtra := []string{".config"}
+		if !reflect.DeepEqual(result.Config.ExtraSymlinks, expectedExtra) {
+			t.Errorf("ExtraSymlinks = %v, want %v", result.Config.ExtraSymlinks, expectedExtra)
+		}
+	})
+
+	t.Run("ExtraSymlinksFromBothConfigs", func(t *testing.T) {
+		t.Parallel()
+
+		tmpDir := t.TempDir()
+		twigDir := filepath.Join(tmpDir, configDir)
+		if err := os.MkdirAll(twigDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		// Project config with extra_symlinks
+		projectSettings := `symlinks = [".envrc"]
+extra_symlinks = [".project-extra"]
+`
+		if err := os.WriteFile(filepath.Join(twigDir, configFileName), []byte(projectSettings), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		// Local config with extra_symlinks
+		localSettings := `extra_symlinks = [".local-extra"]
+`
+		if err := os.WriteFile(filepath.Join(twigDir, localConfigFileName), []byte(localSettings), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := LoadConfig(tmpDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// All extra_symlinks should be collected and appended
+		expected := []string{".envrc", ".project-extra", ".local-extra"}
+		if !reflect.DeepEqual(result.Config.Symlinks, expected) {
+			t.Errorf("Symlinks = %v, want %v", result.Config.Symlinks, expected)
+		}
+
+		// ExtraSymlinks should have both
+		expectedExtra := []string{".project-extra", ".local-extra"}
+		if !reflect.DeepEqual(result.Config.ExtraSymlinks, expectedExtra) {
+			t.Errorf("ExtraSymlinks = %v, want %v", result.Config.ExtraSymlinks, expectedExtra)
+		}
+	})
+
+	t.Run("LocalSymlinksOverrideWithExtraSymlinks", func(t *testing.T) {
+		t.Parallel()
+
+		tmpDir := t.TempDir()
+		twigDir := filepath.Join(tmpDir, configDir)
+		if err := os.MkdirAll(twigDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		// Project config
+		projectSettings := `symlinks = [".envrc", ".config"]
+extra_symlinks = [".project-extra"]
+`
+		if err := os.WriteFile(filepath.Join(twigDir, configFileName), []byte(projectSettings), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		// Local config overrides symlinks and adds extra_symlinks
+		localSettings := `symlinks = [".local-only"]
+extra_symlinks = [".local-extra"]
+`
+		if err := os.WriteFile(filepath.Join(twigDir, localConfigFileName), []byte(localSettings), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := LoadConfig(tmpDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Local symlinks override project, but extra_symlinks from both are collected
+		expected := []string{".local-only", ".project-extra", ".local-extra"}
+		if !reflect.DeepEqual(result.Config.Symlinks, expected) {
+			t.Errorf("Symlinks = %v, want %v", result.Config.Symlinks, expected)
+		}
+	})
+}
+
+func TestLoadConfig_WorktreeDirs(t *testing.T) {
+	t.Parallel()
+
+	t.Run("WorktreeSourceDirIsConfigLoadDir", func(t *testing.T) {
+		t.Parallel()
+
+		tmpDir := t.TempDir()
+		tmpDir, _ = filepath.EvalSymlinks(tmpDir)
+		twigDir := filepath.Join(tmpDir, configDir)
+		if err := os.MkdirAll(twigDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		// Empty config - WorktreeSourceDir should be set to tmpDir
+		if err := os.WriteFile(filepath.Join(twigDir, configFileName), []byte(""), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := LoadConfig(tmpDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if result.Config.WorktreeSourceDir != tmpDir {
+			t.Errorf("WorktreeSourceDir = %q, want %q", result.Config.WorktreeSourceDir, tmpDir)
+		}
+	})
+
+	t.Run("LocalOverridesDestBaseDir", func(t *testing.T) {
+		t.Parallel()
+
+		tmpDir := t.TempDir()
+		tmpDir, _ = filepath.EvalSymlinks(tmpDir)
+		twigDir := filepath.Join(tmpDir, configDir)
+		if err := os.MkdirAll(twigDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		projectDestDir := filepath.Join(tmpDir, "project-dest")
+		localDestDir := filepath.Join(tmpDir, "local-dest")
+
+		// Project config
+		projectSettings := "worktree_destination_base_dir = " + `"` + projectDestDir + `"` + "\n"
+		if err := os.WriteFile(filepath.Join(twigDir, configFileName), []byte(projectSettings), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		// Local config overrides
+		localSettings := "worktree_destination_base_dir = " + `"` + localDestDir + `"` + "\n"
+		if err := os.WriteFile(filepath.Join(twigDir, localConfigFileName), []byte(localSettings), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := LoadConfig(tmpDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if result.Config.WorktreeDestBaseDir != localDestDir {
+			t.Errorf("WorktreeDestBaseDir = %q, want %q", result.Config.WorktreeDestBaseDir, localDestDir)
+		}
+	})
+
+	t.Run("NoWarningForLocalWorktreeDestDir", func(t *testing.T) {
+		t.Parallel()
+
+		tmpDir := t.TempDir()
+		tmpDir, _ = filepath.EvalSymlinks(tmpDir)
+		twigDir := filepath.Join(tmpDir, configDir)
+		if err := os.MkdirAll(twigDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		localDestDir := filepath.Join(tmpDir, "local-dest")
+
+		// Project config (empty)
+		projectSettings := ``
+		if err := os.WriteFile(filepath.Join(twigDir, configFileName), []byte(projectSettings), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		// Local config with worktree_destination_base_dir
+		localSettings := "worktree_destination_base_dir = " + `"` + localDestDir + `"` + "\n"
+		if err := os.WriteFile(filepath.Join(twigDir, localConfigFileName), []byte(localSettings), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := LoadConfig(tmpDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// No warnings should be generated
+		if len(result.Warnings) > 0 {
+			t.Errorf("expected no warnings, got: %v", result.Warnings)
+		}
+	})
+
+	t.Run("RelativeDestBaseDirResolvedFromConfigDir", func(t *testing.T) {
+		t.Parallel()
+
+		tmpDir := t.TempDir()
+		tmpDir, _ = filepath.EvalSymlinks(tmpDir)
+		twigDir := filepath.Join(tmpDir, configDir)
+		if err := os.MkdirAll(twigDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		// Project config with relative path
+		projectSettings := `worktree_destination_base_dir = "../workspace"
+`
+		if err := os.WriteFile(filepath.Join(twigDir, configFileName), []byte(projectSettings), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := LoadConfig(tmpDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Relative path should be resolved from config directory (tmpDir)
+		// "../workspace" from tmpDir should resolve to sibling "workspace" directory
+		expected := filepath.Join(filepath.Dir(tmpDir), "workspace")
+		if result.Config.WorktreeDestBaseDir != expected {
+			t.Errorf("WorktreeDestBaseDir = %q, want %q", result.Config.WorktreeDestBaseDir, expected)
+		}
+	})
+
+	t.Run("AbsoluteDestBaseDirUnchanged", func(t *testing.T) {
+		t.Parallel()
+
+		tmpDir := t.TempDir()
+		tmpDir, _ = filepath.EvalSymlinks(tmpDir)
+		twigDir := filepath.Join(tmpDir, configDir)
+		if err := os.MkdirAll(twigDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		absDestDir := "/absolute/path/to/worktrees"
+
+		// Project config with absolute path
+		projectSettings := `worktree_destination_base_dir = "` + absDestDir + `"
+`
+		if err := os.WriteFile(filepath.Join(twigDir, configFileName), []byte(projectSettings), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := LoadConfig(tmpDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Absolute path should remain unchanged
+		if result.Config.WorktreeDestBaseDir != absDestDir {
+			t.Errorf("WorktreeDestBaseDir = %q, want %q", result.Config.WorktreeDestBaseDir, absDestDir)
+		}
+	})
+}
+
+func TestLoadConfig_WithMainWorktreeDir(t *testing.T) {
+	t.Parallel()
+
+	t.Run("RelativeDestBaseDirResolvedFromMainWorktree", func(t *testing.T) {
+		t.Parallel()
+
+		// Simulate: mainDir is /tmp/xxx/repo, worktreeDir is /tmp/xxx/repo-worktree/feat-a
+		baseDir := t.TempDir()
+		baseDir, _ = filepath.EvalSymlinks(baseDir)
+
+		mainDir := filepath.Join(baseDir, "repo")
+		worktreeDir := filepath.Join(baseDir, "repo-worktree", "feat-a")
+
+		// Create config in worktreeDir
+		twigDir := filepath.Join(worktreeDir, configDir)
+		if err := os.MkdirAll(twigDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		settings := `worktree_destination_base_dir = ".claude/worktrees"
+`
+		if err := os.WriteFile(filepath.Join(twigDir, configFileName), []byte(settings), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := LoadConfig(worktreeDir, WithMainWorktreeDir(mainDir))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Should resolve relative to mainDir, not worktreeDir
+		expected := filepath.Join(mainDir, ".claude", "worktrees")
+		if result.Config.WorktreeDestBaseDir != expected {
+			t.Errorf("WorktreeDestBaseDir = %q, want %q", result.Config.WorktreeDestBaseDir, expected)
+		}
+	})
+
+	t.Run("DefaultDestBaseDirUsesMainWorktree", func(t *testing.T) {
+		t.Parallel()
+
+		baseDir := t.TempDir()
+		baseDir, _ = filepath.EvalSymlinks(baseDir)
+
+		mainDir := filepath.Join(baseDir, "my-project")
+		worktreeDir := filepath.Join(baseDir, "my-project-worktree", "feat-a")
+
+		// Create config in worktreeDir with no worktree_destination_base_dir
+		twigDir := filepath.Join(worktreeDir, configDir)
+		if err := os.MkdirAll(twigDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(twigDir, configFileName), []byte(""), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := LoadConfig(worktreeDir, WithMainWorktreeDir(mainDir))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Default should be ../<mainDir-basename>-worktree resolved from mainDir
+		expected := filepath.Join(baseDir, "my-project-worktree")
+		if result.Config.WorktreeDestBaseDir != expected {
+			t.Errorf("WorktreeDestBaseDir = %q, want %q", result.Config.WorktreeDestBaseDir, expected)
+		}
+	})
+
+	t.Run("WithoutMainWorktreeDirFallsBackToSrcDir", func(t *testing.T) {
+		t.Parallel()
+
+		tmpDir := t.TempDir()
+		tmpDir, _ = filepath.EvalSymlinks(tmpDir)
+		twigDir := filepath.Join(tmpDir, configDir)
+		if err := os.MkdirAll(twigDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		settings := `worktree_destination_base_dir = "worktrees"
+`
+		if err := os.WriteFile(filepath.Join(twigDir, configFileName), []byte(settings), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		// No WithMainWorktreeDir option - should resolve from srcDir (tmpDir)
+		result, err := LoadConfig(tmpDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := filepath.Join(tmpDir, "worktrees")
+		if result.Config.WorktreeDestBaseDir != expected {
+			t.Errorf("WorktreeDestBaseDir = %q, want %q", result.Config.WorktreeDestBaseDir, expected)
+		}
+	})
+}
+
+func TestConfig_ShouldInitSubmodules(t *testing.T) {
+	t.Parallel()
+
+	boolPtr := func(b bool) *bool { return &b }
+
+	tests := []struct {
+		name           string
+		initSubmodules *bool
+		want           bool
+	}{
+		{"nil returns false", nil, false},
+		{"true returns true", boolPtr(true), true},
+		{"false returns false", boolPtr(false), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := &Config{InitSubmodules: tt.initSubmodules}
+			if got := cfg.ShouldInitSubmodules(); got != tt.want {
+				t.Errorf("ShouldInitSubmodules() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfig_ShouldCleanStale(t *testing.T) {
+	t.Parallel()
+
+	boolPtr := func(b bool) *bool { return &b }
+
+	tests := []struct {
+		name       string
+		cleanStale *bool
+		want       bool
+	}{
+		{"nil returns false", nil, false},
+		{"true returns true", boolPtr(true), true},
+		{"false returns false", boolPtr(false), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := &Config{CleanStale: tt.cleanStale}
+			if got := cfg.ShouldCleanStale(); got != tt.want {
+				t.Errorf("ShouldCleanStale() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfig_ShouldUseSubmoduleReference(t *testing.T) {
+	t.Parallel()
+
+	boolPtr := func(b bool) *bool { return &b }
+
+	tests := []struct {
+		name               string
+		submoduleReference *bool
+		want               bool
+	}{
+		{"nil returns false", nil, false},
+		{"true returns true", boolPtr(true), true},
+		{"false returns false", boolPtr(false), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := &Config{SubmoduleReference: tt.submoduleReference}
+			if got := cfg.ShouldUseSubmoduleReference(); got != tt.want {
+				t.Errorf("ShouldUseSubmoduleReference() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_CleanStale(t *testing.T) {
+	t.Parallel()
+
+	t.Run("ProjectOnly", func(t *testing.T) {
+		t.Parallel()
+
+		tmpDir := t.TempDir()
+		twigDir := filepath.Join(tmpDir, configDir)
+		if err := os.MkdirAll(twigDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		projectSettings := `clean_stale = true
+`
+		if err := os.WriteFile(filepath.Join(twigDir, configFileName), []byte(projectSettings), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := LoadConfig(tmpDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if result.Config.CleanStale == nil || *result.Config.CleanStale != true {
+			t.Errorf("CleanStale = %v, want true", result.Config.CleanStale)
+		}
+	})
+
+	t.Run("LocalOverridesProject", func(t *testing.T) {
+		t.Parallel()
+
+		tmpDir := t.TempDir()
+		twigDir := filepath.Join(tmpDir, configDir)
+		if err := os.MkdirAll(twigDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		projectSettings := `clean_stale = true
+`
+		if err := os.WriteFile(filepath.Join(twigDir, configFileName), []byte(projectSettings), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		localSettings := `clean_stale = false
+`
+		if err := os.WriteFile(filepath.Join(twigDir, localConfigFileName), []byte(localSettings), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := LoadConfig(tmpDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if result.Config.CleanStale == nil || *result.Config.CleanStale != false {
+			t.Errorf("CleanStale = %v, want false", result.Config.CleanStale)
+		}
+	})
+
+	t.Run("NilWhenUnset", func(t *testing.T) {
+		t.Parallel()
+
+		tmpDir := t.TempDir()
+		twigDir := filepath.Join(tmpDir, configDir)
+		if err := os.MkdirAll(twigDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		projectSettings := ``
+		if err := os.WriteFile(filepath.Join(twigDir, configFileName), []byte(projectSettings), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := LoadConfig(tmpDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if result.Config.CleanStale != nil {
+			t.Errorf("CleanStale = %v, want nil", result.Config.CleanStale)
+		}
+	})
+
+	t.Run("HelperMethodMatchesValue", func(t *testing.T) {
+		t.Parallel()
+
+		tmpDir := t.TempDir()
+		twigDir := filepath.Join(tmpDir, configDir)
+		if err := os.MkdirAll(twigDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		projectSettings := `clean_stale = true
+`
+		if err := os.WriteFile(filepath.Join(twigDir, configFileName), []byte(projectSettings), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := LoadConfig(tmpDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !result.Config.ShouldCleanStale() {
+			t.Error("ShouldCleanStale() = false, want true")
+		}
+	})
+}
+
+func TestLoadConfig_CleanFetch(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		project string
+		local   string
+		want    bool
+	}{
+		{"project only", "clean_fetch = true\n", "", true},
+		{"local overrides project", "clean_fetch = true\n", "clean_fetch = false\n", false},
+		{"unset", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			tmpDir := t.TempDir()
+			twigDir := filepath.Join(tmpDir, configDir)
+			if err := os.MkdirAll(twigDir, 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(twigDir, configFileName), []byte(tt.project), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if tt.local != "" {
+				if err := os.WriteFile(filepath.Join(twigDir, localConfigFileName), []byte(tt.local), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			result, err := LoadConfig(tmpDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := result.Config.ShouldCleanFetch(); got != tt.want {
+				t.Errorf("ShouldCleanFetch() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_FetchOnAdd(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		project string
+		local   string
+		want    bool
+	}{
+		{"project only", "fetch_on_add = true\n", "", true},
+		{"local overrides project", "fetch_on_add = true\n", "fetch_on_add = false\n", false},
+		{"unset", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			tmpDir := t.TempDir()
+			twigDir := filepath.Join(tmpDir, configDir)
+			if err := os.MkdirAll(twigDir, 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(twigDir, configFileName), []byte(tt.project), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if tt.local != "" {
+				if err := os.WriteFile(filepath.Join(twigDir, localConfigFileName), []byte(tt.local), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			result, err := LoadConfig(tmpDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := result.Config.ShouldFetchOnAdd(); got != tt.want {
+				t.Errorf("ShouldFetchOnAdd() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_CleanupEmptyDirs(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		project string
+		local   string
+		want    bool
+	}{
+		{"project only", "cleanup_empty_dirs = false\n", "", false},
+		{"local overrides project", "cleanup_empty_dirs = false\n", "cleanup_empty_dirs = true\n", true},
+		{"unset", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			tmpDir := t.TempDir()
+			twigDir := filepath.Join(tmpDir, configDir)
+			if err := os.MkdirAll(twigDir, 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(twigDir, configFileName), []byte(tt.project), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if tt.local != "" {
+				if err := os.WriteFile(filepath.Join(twigDir, localConfigFileName), []byte(tt.local), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			result, err := LoadConfig(tmpDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := result.Config.ShouldCleanupEmptyDirs(); got != tt.want {
+				t.Errorf("ShouldCleanupEmptyDirs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_ArchiveDir(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		project string
+		local   string
+		want    func(dir string) string
+	}{
+		{"unset", "", "", func(string) string { return "" }},
+		{"absolute", "archive_dir = \"/var/archives\"\n", "", func(string) string { return "/var/archives" }},
+		{"relative to main worktree", "archive_dir = \"../archives\"\n", "", func(dir string) string { return filepath.Join(filepath.Dir(dir), "archives") }},
+		{"local overrides project", "archive_dir = \"/a\"\n", "archive_dir = \"/b\"\n", func(string) string { return "/b" }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			tmpDir := t.TempDir()
+			twigDir := filepath.Join(tmpDir, configDir)
+			if err := os.MkdirAll(twigDir, 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(twigDir, configFileName), []byte(tt.project), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if tt.local != "" {
+				if err := os.WriteFile(filepath.Join(twigDir, localConfigFileName), []byte(tt.local), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			result, err := LoadConfig(tmpDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := result.Config.ArchiveDir, tt.want(tmpDir); got != want {
+				t.Errorf("ArchiveDir = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_SubmoduleReferenceDir(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		project       string
+		local         string
+		want          func(dir string) string
+		wantReference bool
+	}{
+		{"unset", "", "", func(string) string { return "" }, false},
+		{"absolute enables reference", "submodule_reference_dir = \"/srv/mirror\"\n", "", func(string) string { return "/srv/mirror" }, true},
+		{"relative to main worktree", "submodule_reference_dir = \"../mirror\"\n", "", func(dir string) string { return filepath.Join(filepath.Dir(dir), "mirror") }, true},
+		{"local overrides project", "submodule_reference_dir = \"/a\"\n", "submodule_reference_dir = \"/b\"\n", func(string) string { return "/b" }, true},
+		{"reference disabled explicitly", "submodule_reference_dir = \"/a\"\n", "submodule_reference = false\n", func(string) string { return "/a" }, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			tmpDir := t.TempDir()
+			twigDir := filepath.Join(tmpDir, configDir)
+			if err := os.MkdirAll(twigDir, 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(twigDir, configFileName), []byte(tt.project), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if tt.local != "" {
+				if err := os.WriteFile(filepath.Join(twigDir, localConfigFileName), []byte(tt.local), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			result, err := LoadConfig(tmpDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := result.Config.SubmoduleRefDir, tt.want(tmpDir); got != want {
+				t.Errorf("SubmoduleRefDir = %q, want %q", got, want)
+			}
+			if got := result.Config.ShouldUseSubmoduleReference(); got != tt.wantReference {
+				t.Errorf("ShouldUseSubmoduleReference() = %v, want %v", got, tt.wantReference)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_Hooks(t *testing.T) {
+	t.Parallel()
+
+	t.Run("ProjectOnly", func(t *testing.T) {
+		t.Parallel()
+
+		tmpDir := t.TempDir()
+		twigDir := filepath.Join(tmpDir, configDir)
+		if err := os.MkdirAll(twigDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		projectSettings := `hooks = ["npm install", "direnv allow"]
+`
+		if err := os.WriteFile(filepath.Join(twigDir, configFileName), []byte(projectSettings), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := LoadConfig(tmpDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := []string{"npm install", "direnv allow"}
+		if !reflect.DeepEqual(result.Config.Hooks, expected) {
+			t.Errorf("Hooks = %v, want %v", result.Config.Hooks, expected)
+		}
+	})
+
+	t.Run("LocalOverridesProject", func(t *testing.T) {
+		t.Parallel()
+
+		tmpDir := t.TempDir()
+		twigDir := filepath.Join(tmpDir, configDir)
+		if err := os.MkdirAll(twigDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		projectSettings := `hooks = ["npm install", "direnv allow"]
+`
+		if err := os.WriteFile(filepath.Join(twigDir, configFileName), []byte(projectSettings), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		localSettings := `hooks = ["yarn install"]
+`
+		if err := os.WriteFile(filepath.Join(twigDir, localConfigFileName), []byte(localSettings), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := LoadConfig(tmpDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := []string{"yarn install"}
+		if !reflect.DeepEqual(result.Config.Hooks, expected) {
+			t.Errorf("Hooks = %v, want %v", result.Config.Hooks, expected)
+		}
+	})
+
+	t.Run("EmptyLocalDoesNotOverride", func(t *testing.T) {
+		t.Parallel()
+
+		tmpDir := t.TempDir()
+		twigDir := filepath.Join(tmpDir, configDir)
+		if err := os.MkdirAll(twigDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		projectSettings := `hooks = ["npm install"]
+`
+		if err := os.WriteFile(filepath.Join(twigDir, configFileName), []byte(projectSettings), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		localSettings := `hooks = []
+`
+		if err := os.WriteFile(filepath.Join(twigDir, localConfigFileName), []byte(localSettings), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := LoadConfig(tmpDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := []string{"npm install"}
+		if !reflect.DeepEqual(result.Config.Hooks, expected) {
+			t.Errorf("Hooks = %v, want %v", result.Config.Hooks, expected)
+		}
+	})
+
+	t.Run("NilWhenUnset", func(t *testing.T) {
+		t.Parallel()
+
+		tmpDir := t.TempDir()
+		twigDir := filepath.Join(tmpDir, configDir)
+		if err := os.MkdirAll(twigDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(filepath.Join(twigDir, configFileName), []byte(""), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := LoadConfig(tmpDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if result.Config.Hooks != nil {
+			t.Errorf("Hooks = %v, want nil", result.Config.Hooks)
+		}
+	})
+}
+
+func TestConfig_ShouldDetectSquashMerges(t *testing.T) {
+	t.Parallel()
+
+	boolPtr := func(b bool) *bool { return &b }
+
+	tests := []struct {
+		name               string
+		detectSquashMerges *bool
+		want               bool
+	}{
+		{"nil returns false", nil, false},
+		{"true returns true", boolPtr(true), true},
+		{"false returns false", boolPtr(false), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := &Config{DetectSquashMerges: tt.detectSquashMerges}
+			if got := cfg.ShouldDetectSquashMerges(); got != tt.want {
+				t.Errorf("ShouldDetectSquashMerges() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_DetectSquashMerges(t *testing.T) {
+	t.Parallel()
+
+	t.Run("LocalOverridesProject", func(t *testing.T) {
+		t.Parallel()
+
+		tmpDir := t.TempDir()
+		twigDir := filepath.Join(tmpDir, configDir)
+		if err := os.MkdirAll(twigDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		projectSettings := `detect_squash_merges = true
+`
+		if err := os.WriteFile(filepath.Join(twigDir, configFileName), []byte(projectSettings), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		localSettings := `detect_squash_merges = false
+`
+		if err := os.WriteFile(filepath.Join(twigDir, localConfigFileName), []byte(localSettings), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := LoadConfig(tmpDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if result.Config.DetectSquashMerges == nil || *result.Config.DetectSquashMerges != false {
+			t.Errorf("DetectSquashMerges = %v, want false", result.Config.DetectSquashMerges)
+		}
+	})
+
+	t.Run("NilWhenUnset", func(t *testing.T) {
+		t.Parallel()
+
+		tmpDir := t.TempDir()
+
+		result, err := LoadConfig(tmpDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if result.Config.DetectSquashMerges != nil {
+			t.Errorf("DetectSquashMerges = %v, want nil", result.Config.DetectSquashMerges)
+		}
+	})
+}
+
+func TestLoadConfig_StrictSymlinks(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		project  string
+		local    string
+		wantNil  bool
+		wantBool bool
+	}{
+		{name: "unset", wantNil: true},
+		{name: "project", project: "strict_symlinks = true\n", wantBool: true},
+		{name: "local_overrides_project", project: "strict_symlinks = true\n", local: "strict_symlinks = false\n", wantBool: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			tmpDir := t.TempDir()
+			twigDir := filepath.Join(tmpDir, configDir)
+			if err := os.MkdirAll(twigDir, 0755); err != nil {
+				t.Fatal(err)
+			}
+			if tt.project != "" {
+				if err := os.WriteFile(filepath.Join(twigDir, configFileName), []byte(tt.project), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.local != "" {
+				if err := os.WriteFile(filepath.Join(twigDir, localConfigFileName), []byte(tt.local), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			result, err := LoadConfig(tmpDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := result.Config.StrictSymlinks
+			if tt.wantNil {
+				if got != nil {
+					t.Errorf("StrictSymlinks = %v, want nil", *got)
+				}
+				return
+			}
+			if got == nil || *got != tt.wantBool {
+				t.Errorf("StrictSymlinks = %v, want %v", got, tt.wantBool)
+			}
+			if result.Config.ShouldUseStrictSymlinks() != tt.wantBool {
+				t.Errorf("ShouldUseStrictSymlinks() = %v, want %v", result.Config.ShouldUseStrictSymlinks(), tt.wantBool)
+			}
+		})
+	}
+}
+
+func TestConfig_IsProtectedBranch(t *testing.T) {
+	t.Parallel()
+
+	cfg := &Config{ProtectedBranches: []string{"main", "develop", "release/*"}}
+
+	tests := []struct {
+		branch string
+		want   bool
+	}{
+		{"main", true},
+		{"develop", true},
+		{"release/1.0", true},
+		{"release/1.0/hotfix", false},
+		{"feature/main", false},
+		{"maintenance", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.branch, func(t *testing.T) {
+			t.Parallel()
+
+			if got := cfg.IsProtectedBranch(tt.branch); got != tt.want {
+				t.Errorf("IsProtectedBranch(%q) = %v, want %v", tt.branch, got, tt.want)
+			}
+		})
+	}
+
+	t.Run("EmptyListProtectsNothing", func(t *testing.T) {
+		t.Parallel()
+
+		if (&Config{}).IsProtectedBranch("main") {
+			t.Error("IsProtectedBranch(\"main\") = true, want false")
+		}
+	})
+}
+
+func TestLoadConfig_ProtectedBranches(t *testing.T) {
+	t.Parallel()
+
+	t.Run("CollectedFromBothConfigs", func(t *testing.T) {
+		t.Parallel()
+
+		tmpDir := t.TempDir()
+		twigDir := filepath.Join(tmpDir, configDir)
+		if err := os.MkdirAll(twigDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		projectSettings := `protected_branches = ["main", "release/*"]
+`
+		if err := os.WriteFile(filepath.Join(twigDir, configFileName), []byte(projectSettings), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		localSettings := `protected_branches = ["main", "develop"]
+`
+		if err := os.WriteFile(filepath.Join(twigDir, localConfigFileName), []byte(localSettings), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := LoadConfig(tmpDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := []string{"main", "release/*", "develop"}
+		if !reflect.DeepEqual(result.Config.ProtectedBranches, expected) {
+			t.Errorf("ProtectedBranches = %v, want %v", result.Config.ProtectedBranches, expected)
+		}
+	})
+
+	t.Run("InvalidPatternWarning", func(t *testing.T) {
+		t.Parallel()
+
+		tmpDir := t.TempDir()
+		twigDir := filepath.Join(tmpDir, configDir)
+		if err := os.MkdirAll(twigDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		projectSettings := `protected_branches = ["release/["]
+`
+		if err := os.WriteFile(filepath.Join(twigDir, configFileName), []byte(projectSettings), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := LoadConfig(tmpDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(result.Warnings) != 1 || !strings.Contains(result.Warnings[0], "protected_branches") {
+			t.Errorf("Warnings = %v, want invalid pattern warning", result.Warnings)
+		}
+		// Exact match still protects the literal branch name
+		if !result.Config.IsProtectedBranch("release/[") {
+			t.Error("IsProtectedBranch(\"release/[\") = false, want true")
+		}
+	})
+}
+
+func TestLoadConfig_CleanExclude(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	twigDir := filepath.Join(tmpDir, configDir)
+	if err := os.MkdirAll(twigDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	projectSettings := `clean_exclude = ["spike/*", "exp/["]
+`
+	if err := os.WriteFile(filepath.Join(twigDir, configFileName), []byte(projectSettings), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	localSettings := `clean_exclude = ["spike/*", "mine/*"]
+`
+	if err := os.WriteFile(filepath.Join(twigDir, localConfigFileName), []byte(localSettings), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := LoadConfig(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"spike/*", "exp/[", "mine/*"}
+	if !reflect.DeepEqual(result.Config.CleanExclude, expected) {
+		t.Errorf("CleanExclude = %v, want %v", result.Config.CleanExclude, expected)
+	}
+	if len(result.Warnings) != 1 || !strings.Contains(result.Warnings[0], "clean_exclude") {
+		t.Errorf("Warnings = %v, want invalid pattern warning", result.Warnings)
+	}
+	if !result.Config.IsCleanExcluded("mine/x") || result.Config.IsCleanExcluded("feat/x") {
+		t.Error("IsCleanExcluded matched wrong branches")
+	}
+}
+
+func TestLoadConfig_Profiles(t *testing.T) {
+	t.Parallel()
+
+	projectSettings := `default_source = "main"
+symlinks = [".envrc", "config/**"]
+extra_symlinks = [".tool-versions"]
+init_submodules = true
+hooks = ["npm install"]
+
+[profiles.review]
+symlinks = []
+hooks = []
+init_submodules = false
+worktree_destination_base_dir = "../review"
+
+[profiles.dev]
+extra_symlinks = [".claude"]
+`
+
+	tests := []struct {
+		name      string
+		local     string
+		profile   string
+		wantErr   string
+		checkFunc func(t *testing.T, cfg *Config, tmpDir string)
+	}{
+		{
+			name: "NoProfileUsesBaseSettings",
+			checkFunc: func(t *testing.T, cfg *Config, tmpDir string) {
+				t.Helper()
+				want := []string{".envrc", "config/**", ".tool-versions"}
+				if !reflect.DeepEqual(cfg.Symlinks, want) {
+					t.Errorf("Symlinks = %v, want %v", cfg.Symlinks, want)
+				}
+				if cfg.Profile != "" {
+					t.Errorf("Profile = %q, want empty", cfg.Profile)
+				}
+				if len(cfg.Profiles) != 2 {
+					t.Errorf("len(Profiles) = %d, want 2", len(cfg.Profiles))
+				}
+			},
+		},
+		{
+			name:    "ProfileOverridesBaseSettings",
+			profile: "review",
+			checkFunc: func(t *testing.T, cfg *Config, tmpDir string) {
+				t.Helper()
+				if len(cfg.Symlinks) != 0 {
+					t.Errorf("Symlinks = %v, want empty (profile replaces symlinks and extra_symlinks)", cfg.Symlinks)
+				}
+				if len(cfg.Hooks) != 0 {
+					t.Errorf("Hooks = %v, want empty", cfg.Hooks)
+				}
+				if cfg.ShouldInitSubmodules() {
+					t.Error("ShouldInitSubmodules() = true, want false")
+				}
+				wantDest := filepath.Join(filepath.Dir(tmpDir), "review")
+				if cfg.WorktreeDestBaseDir != wantDest {
+					t.Errorf("WorktreeDestBaseDir = %q, want %q", cfg.WorktreeDestBaseDir, wantDest)
+				}
+				if cfg.DefaultSource != "main" {
+					t.Errorf("DefaultSource = %q, want %q (not overridden)", cfg.DefaultSource, "main")
+				}
+				if cfg.Profile != "review" {
+					t.Errorf("Profile = %q, want %q", cfg.Profile, "review")
+				}
+			},
+		},
+		{
+			name:    "ProfileExtraSymlinksAppended",
+			profile: "dev",
+			checkFunc: func(t *testing.T, cfg *Config, tmpDir string) {
+				t.Helper()
+				want := []string{".envrc", "config/**", ".tool-versions", ".claude"}
+				if !reflect.DeepEqual(cfg.Symlinks, want) {
+					t.Errorf("Symlinks = %v, want %v", cfg.Symlinks, want)
+				}
+				if !reflect.DeepEqual(cfg.Hooks, []string{"npm install"}) {
+					t.Errorf("Hooks = %v, want base hooks", cfg.Hooks)
+				}
+			},
+		},
+		{
+			name: "LocalProfileOverridesProjectProfile",
+			local: `[profiles.review]
+hooks = ["make review-setup"]
+
+[profiles.personal]
+default_source = "develop"
+`,
+			profile: "review",
+			checkFunc: func(t *testing.T, cfg *Config, tmpDir string) {
+				t.Helper()
+				if !reflect.DeepEqual(cfg.Hooks, []string{"make review-setup"}) {
+					t.Errorf("Hooks = %v, want local profile hooks", cfg.Hooks)
+				}
+				// Fields not set locally keep the project profile values
+				if len(cfg.Symlinks) != 0 {
+					t.Errorf("Symlinks = %v, want empty from project profile", cfg.Symlinks)
+				}
+				if len(cfg.Profiles) != 3 {
+					t.Errorf("len(Profiles) = %d, want 3", len(cfg.Profiles))
+				}
+			},
+		},
+		{
+			name:    "ProfileOverridesLocalSettings",
+			local:   `hooks = ["yarn install"]` + "\n",
+			profile: "review",
+			checkFunc: func(t *testing.T, cfg *Config, tmpDir string) {
+				t.Helper()
+				if len(cfg.Hooks) != 0 {
+					t.Errorf("Hooks = %v, want empty from profile", cfg.Hooks)
+				}
+			},
+		},
+		{
+			name:    "UnknownProfile",
+			profile: "missing",
+			wantErr: `profile "missing" is not defined`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			tmpDir := t.TempDir()
+			twigDir := filepath.Join(tmpDir, configDir)
+			if err := os.MkdirAll(twigDir, 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(twigDir, configFileName), []byte(projectSettings), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if tt.local != "" {
+				if err := os.WriteFile(filepath.Join(twigDir, localConfigFileName), []byte(tt.local), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			result, err := LoadConfig(tmpDir, WithProfile(tt.profile))
+			if tt.wantErr != "" {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				if !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("error = %q, want to contain %q", err.Error(), tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			tt.checkFunc(t, result.Config, tmpDir)
+		})
+	}
+}
+
+func TestConfig_ResolveBranch(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		cfg          *Config
+		input        string
+		wantBranch   string
+		wantWorktree string
+	}{
+		{
+			name:         "no prefix or aliases",
+			cfg:          &Config{},
+			input:        "fix-login",
+			wantBranch:   "fix-login",
+			wantWorktree: "fix-login",
+		},
+		{
+			name:         "prefix prepended",
+			cfg:          &Config{BranchPrefix: "users/me/"},
+			input:        "fix-login",
+			wantBranch:   "users/me/fix-login",
+			wantWorktree: "fix-login",
+		},
+		{
+			name:         "prefix not duplicated",
+			cfg:          &Config{BranchPrefix: "users/me/"},
+			input:        "users/me/fix-login",
+			wantBranch:   "users/me/fix-login",
+			wantWorktree: "fix-login",
+		},
+		{
+			name:         "name equal to prefix gets prefixed",
+			cfg:          &Config{BranchPrefix: "users/me/"},
+			input:        "users/me/",
+			wantBranch:   "users/me/users/me/",
+			wantWorktree: "users/me/",
+		},
+		{
+			name: "alias used verbatim without prefix",
+			cfg: &Config{
+				BranchPrefix:  "users/me/",
+				BranchAliases: map[string]string{"login": "team/fix-login-redirect"},
+			},
+			input:        "login",
+			wantBranch:   "team/fix-login-redirect",
+			wantWorktree: "login",
+		},
+		{
+			name: "empty alias target ignored",
+			cfg: &Config{
+				BranchAliases: map[string]string{"login": ""},
+			},
+			input:        "login",
+			wantBranch:   "login",
+			wantWorktree: "login",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			branch, worktree := tt.cfg.ResolveBranch(tt.input)
+			if branch != tt.wantBranch {
+				t.Errorf("branch = %q, want %q", branch, tt.wantBranch)
+			}
+			if worktree != tt.wantWorktree {
+				t.Errorf("worktreeName = %q, want %q", worktree, tt.wantWorktree)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_BranchPrefixAndAliases(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	twigDir := filepath.Join(tmpDir, configDir)
+	if err := os.MkdirAll(twigDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	projectSettings := `branch_prefix = "team/"
+branch_aliases = { login = "team/fix-login", docs = "team/docs-refresh" }
+`
+	if err := os.WriteFile(filepath.Join(twigDir, configFileName), []byte(projectSettings), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	localSettings := `branch_prefix = "users/me/"
+branch_aliases = { login = "users/me/fix-login", api = "users/me/api-v2" }
+`
+	if err := os.WriteFile(filepath.Join(twigDir, localConfigFileName), []byte(localSettings), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := LoadConfig(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Config.BranchPrefix != "users/me/" {
+		t.Errorf("BranchPrefix = %q, want %q", result.Config.BranchPrefix, "users/me/")
+	}
+	wantAliases := map[string]string{
+		"login": "users/me/fix-login",
+		"docs":  "team/docs-refresh",
+		"api":   "users/me/api-v2",
+	}
+	if !reflect.DeepEqual(result.Config.BranchAliases, wantAliases) {
+		t.Errorf("BranchAliases = %v, want %v", result.Config.BranchAliases, wantAliases)
+	}
+}
+
+func TestLoadConfig_OpenCommand(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		project  string
+		local    string
+		expected string
+	}{
+		{
+			name:     "project only",
+			project:  `open_command = "code {path}"`,
+			expected: "code {path}",
+		},
+		{
+			name:     "local overrides project",
+			project:  `open_command = "code {path}"`,
+			local:    `open_command = "zed {path}"`,
+			expected: "zed {path}",
+		},
+		{
+			name:     "unset",
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			tmpDir := t.TempDir()
+			twigDir := filepath.Join(tmpDir, configDir)
+			if err := os.MkdirAll(twigDir, 0755); err != nil {
+				t.Fatal(err)
+			}
+			if tt.project != "" {
+				if err := os.WriteFile(filepath.Join(twigDir, configFileName), []byte(tt.project), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.local != "" {
+				if err := os.WriteFile(filepath.Join(twigDir, localConfigFileName), []byte(tt.local), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			result, err := LoadConfig(tmpDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Config.OpenCommand != tt.expected {
+				t.Errorf("OpenCommand = %q, want %q", result.Config.OpenCommand, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_CleanVerifyCommand(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		project  string
+		local    string
+		expected string
+	}{
+		{
+			name:     "project only",
+			project:  `clean_verify_command = "./scripts/check.sh {path}"`,
+			expected: "./scripts/check.sh {path}",
+		},
+		{
+			name:     "local overrides project",
+			project:  `clean_verify_command = "./scripts/check.sh {path}"`,
+			local:    `clean_verify_command = "test ! -e dump.sql"`,
+			expected: "test ! -e dump.sql",
+		},
+		{
+			name:     "unset",
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			tmpDir := t.TempDir()
+			twigDir := filepath.Join(tmpDir, configDir)
+			if err := os.MkdirAll(twigDir, 0755); err != nil {
+				t.Fatal(err)
+			}
+			if tt.project != "" {
+				if err := os.WriteFile(filepath.Join(twigDir, configFileName), []byte(tt.project), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.local != "" {
+				if err := os.WriteFile(filepath.Join(twigDir, localConfigFileName), []byte(tt.local), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			result, err := LoadConfig(tmpDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Config.CleanVerifyCommand != tt.expected {
+				t.Errorf("CleanVerifyCommand = %q, want %q", result.Config.CleanVerifyCommand, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_PRBranchTemplate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		project    string
+		local      string
+		expected   string
+		wantBranch string
+	}{
+		{
+			name:       "project only",
+			project:    `pr_branch_template = "review/{number}"`,
+			expected:   "review/{number}",
+			wantBranch: "review/7",
+		},
+		{
+			name:       "local overrides project",
+			project:    `pr_branch_template = "review/{number}"`,
+			local:      `pr_branch_template = "me/pr-{number}-{title}"`,
+			expected:   "me/pr-{number}-{title}",
+			wantBranch: "me/pr-7-fix-login",
+		},
+		{
+			name:       "unset",
+			expected:   "",
+			wantBranch: "pr/7-fix-login",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			tmpDir := t.TempDir()
+			twigDir := filepath.Join(tmpDir, configDir)
+			if err := os.MkdirAll(twigDir, 0755); err != nil {
+				t.Fatal(err)
+			}
+			if tt.project != "" {
+				if err := os.WriteFile(filepath.Join(twigDir, configFileName), []byte(tt.project), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.local != "" {
+				if err := os.WriteFile(filepath.Join(twigDir, localConfigFileName), []byte(tt.local), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			result, err := LoadConfig(tmpDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Config.PRBranchTemplate != tt.expected {
+				t.Errorf("PRBranchTemplate = %q, want %q", result.Config.PRBranchTemplate, tt.expected)
+			}
+			if got := result.Config.PRBranchName(PullRequest{Number: 7, Title: "Fix login"}); got != tt.wantBranch {
+				t.Errorf("PRBranchName() = %q, want %q", got, tt.wantBranch)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_Forge(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		project     string
+		local       string
+		expected    string
+		wantWarning string
+	}{
+		{
+			name:     "project only",
+			project:  `forge = "github"`,
+			expected: "github",
+		},
+		{
+			name:     "local overrides project",
+			project:  `forge = "github"`,
+			local:    `forge = "gitlab"`,
+			expected: "gitlab",
+		},
+		{
+			name:        "unknown forge is disabled with warning",
+			project:     `forge = "bitbucket"`,
+			expected:    "",
+			wantWarning: `unknown forge "bitbucket"`,
+		},
+		{
+			name:     "unset",
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			tmpDir := t.TempDir()
+			twigDir := filepath.Join(tmpDir, configDir)
+			if err := os.MkdirAll(twigDir, 0755); err != nil {
+				t.Fatal(err)
+			}
+			if tt.project != "" {
+				if err := os.WriteFile(filepath.Join(twigDir, configFileName), []byte(tt.project), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.local != "" {
+				if err := os.WriteFile(filepath.Join(twigDir, localConfigFileName), []byte(tt.local), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			result, err := LoadConfig(tmpDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Config.Forge != tt.expected {
+				t.Errorf("Forge = %q, want %q", result.Config.Forge, tt.expected)
+			}
+			var warned bool
+			for _, w := range result.Warnings {
+				if tt.wantWarning != "" && strings.Contains(w, tt.wantWarning) {
+					warned = true
+				}
+			}
+			if tt.wantWarning != "" && !warned {
+				t.Errorf("Warnings = %v, want to contain %q", result.Warnings, tt.wantWarning)
+			}
+			if tt.wantWarning == "" && len(result.Warnings) > 0 {
+				t.Errorf("unexpected warnings: %v", result.Warnings)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_SymlinkStyle(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		project     string
+		local       string
+		expected    string
+		wantWarning string
+	}{
+		{
+			name:     "unset defaults to relative",
+			expected: SymlinkStyleRelative,
+		},
+		{
+			name:     "local overrides project",
+			project:  `symlink_style = "relative"`,
+			local:    `symlink_style = "absolute"`,
+			expected: SymlinkStyleAbsolute,
+		},
+		{
+			name:        "unknown value falls back to relative with warning",
+			project:     `symlink_style = "hard"`,
+			expected:    SymlinkStyleRelative,
+			wantWarning: `unknown symlink_style "hard"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			tmpDir := t.TempDir()
+			twigDir := filepath.Join(tmpDir, configDir)
+			if err := os.MkdirAll(twigDir, 0755); err != nil {
+				t.Fatal(err)
+			}
+			for name, content := range map[string]string{configFileName: tt.project, localConfigFileName: tt.local} {
+				if err := os.WriteFile(filepath.Join(twigDir, name), []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			result, err := LoadConfig(tmpDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := result.Config.SymlinkTargetStyle(); got != tt.expected {
+				t.Errorf("SymlinkTargetStyle() = %q, want %q", got, tt.expected)
+			}
+			warnings := strings.Join(result.Warnings, "\n")
+			if tt.wantWarning != "" && !strings.Contains(warnings, tt.wantWarning) {
+				t.Errorf("Warnings = %v, want to contain %q", result.Warnings, tt.wantWarning)
+			}
+			if tt.wantWarning == "" && len(result.Warnings) > 0 {
+				t.Errorf("unexpected warnings: %v", result.Warnings)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_CaseCollisions(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		project     string
+		local       string
+		expected    string
+		wantWarning string
+	}{
+		{
+			name:     "project only",
+			project:  `case_collisions = "error"`,
+			expected: "error",
+		},
+		{
+			name:     "local overrides project",
+			project:  `case_collisions = "error"`,
+			local:    `case_collisions = "warn"`,
+			expected: "warn",
+		},
+		{
+			name:        "unknown value falls back to auto with warning",
+			project:     `case_collisions = "ignore"`,
+			expected:    "",
+			wantWarning: `unknown case_collisions "ignore"`,
+		},
+		{
+			name:     "unset",
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			tmpDir := t.TempDir()
+			twigDir := filepath.Join(tmpDir, configDir)
+			if err := os.MkdirAll(twigDir, 0755); err != nil {
+				t.Fatal(err)
+			}
+			if tt.project != "" {
+				if err := os.WriteFile(filepath.Join(twigDir, configFileName), []byte(tt.project), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.local != "" {
+				if err := os.WriteFile(filepath.Join(twigDir, localConfigFileName), []byte(tt.local), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			result, err := LoadConfig(tmpDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Config.CaseCollisions != tt.expected {
+				t.Errorf("CaseCollisions = %q, want %q", result.Config.CaseCollisions, tt.expected)
+			}
+			var warned bool
+			for _, w := range result.Warnings {
+				if tt.wantWarning != "" && strings.Contains(w, tt.wantWarning) {
+					warned = true
+				}
+			}
+			if tt.wantWarning != "" && !warned {
+				t.Errorf("Warnings = %v, want to contain %q", result.Warnings, tt.wantWarning)
+			}
+			if tt.wantWarning == "" && len(result.Warnings) > 0 {
+				t.Errorf("unexpected warnings: %v", result.Warnings)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_GitLockWait(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		project     string
+		local       string
+		expected    time.Duration
+		wantWarning string
+	}{
+		{
+			name:     "unset uses default",
+			expected: DefaultGitLockWait,
+		},
+		{
+			name:     "local overrides project",
+			project:  `git_lock_wait = "1m"`,
+			local:    `git_lock_wait = "0s"`,
+			expected: 0,
+		},
+		{
+			name:        "invalid value uses default with warning",
+			project:     `git_lock_wait = "soon"`,
+			expected:    DefaultGitLockWait,
+			wantWarning: `invalid git_lock_wait "soon"`,
+		},
+		{
+			name:        "negative value uses default with warning",
+			project:     `git_lock_wait = "-5s"`,
+			expected:    DefaultGitLockWait,
+			wantWarning: `invalid git_lock_wait "-5s"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			tmpDir := t.TempDir()
+			twigDir := filepath.Join(tmpDir, configDir)
+			if err := os.MkdirAll(twigDir, 0755); err != nil {
+				t.Fatal(err)
+			}
+			if tt.project != "" {
+				if err := os.WriteFile(filepath.Join(twigDir, configFileName), []byte(tt.project), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.local != "" {
+				if err := os.WriteFile(filepath.Join(twigDir, localConfigFileName), []byte(tt.local), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			result, err := LoadConfig(tmpDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := result.Config.GitLockWaitDuration(); got != tt.expected {
+				t.Errorf("GitLockWaitDuration() = %v, want %v", got, tt.expected)
+			}
+			var warned bool
+			for _, w := range result.Warnings {
+				if tt.wantWarning != "" && strings.Contains(w, tt.wantWarning) {
+					warned = true
+				}
+			}
+			if tt.wantWarning != "" && !warned {
+				t.Errorf("Warnings = %v, want to contain %q", result.Warnings, tt.wantWarning)
+			}
+			if tt.wantWarning == "" && len(result.Warnings) > 0 {
+				t.Errorf("unexpected warnings: %v", result.Warnings)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_GitTimeout(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		project        string
+		local          string
+		expected       time.Duration
+		expectedRemote time.Duration
+		wantWarning    string
+	}{
+		{
+			name:     "unset means no limit",
+			expected: 0,
+		},
+		{
+			name:           "local overrides project",
+			project:        `git_timeout = "1m"`,
+			local:          `git_timeout = "90s"`,
+			expected:       90 * time.Second,
+			expectedRemote: 90 * time.Second,
+		},
+		{
+			name:        "invalid value means no limit with warning",
+			project:     `git_timeout = "60"`,
+			expected:    0,
+			wantWarning: `invalid git_timeout "60"`,
+		},
+		{
+			name:           "remote timeout overrides git_timeout for remotes",
+			project:        "git_timeout = \"10s\"\ngit_remote_timeout = \"5m\"",
+			local:          `git_remote_timeout = "0s"`,
+			expected:       10 * time.Second,
+			expectedRemote: 0,
+		},
+		{
+			name:           "invalid remote timeout falls back with warning",
+			project:        "git_timeout = \"10s\"\ngit_remote_timeout = \"5\"",
+			expected:       10 * time.Second,
+			expectedRemote: 10 * time.Second,
+			wantWarning:    `invalid git_remote_timeout "5"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			tmpDir := t.TempDir()
+			twigDir := filepath.Join(tmpDir, configDir)
+			if err := os.MkdirAll(twigDir, 0755); err != nil {
+				t.Fatal(err)
+			}
+			if tt.project != "" {
+				if err := os.WriteFile(filepath.Join(twigDir, configFileName), []byte(tt.project), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.local != "" {
+				if err := os.WriteFile(filepath.Join(twigDir, localConfigFileName), []byte(tt.local), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			result, err := LoadConfig(tmpDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := result.Config.GitTimeoutDuration(); got != tt.expected {
+				t.Errorf("GitTimeoutDuration() = %v, want %v", got, tt.expected)
+			}
+			if got := result.Config.GitRemoteTimeoutDuration(); got != tt.expectedRemote {
+				t.Errorf("GitRemoteTimeoutDuration() = %v, want %v", got, tt.expectedRemote)
+			}
+			if tt.wantWarning == "" && len(result.Warnings) > 0 {
+				t.Errorf("unexpected warnings: %v", result.Warnings)
+			}
+			if tt.wantWarning != "" && (len(result.Warnings) != 1 || !strings.Contains(result.Warnings[0], tt.wantWarning)) {
+				t.Errorf("Warnings = %v, want %q", result.Warnings, tt.wantWarning)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_Notify(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		project     string
+		local       string
+		wantNotify  bool
+		wantAfter   time.Duration
+		wantWarning string
+	}{
+		{
+			name:      "unset",
+			wantAfter: DefaultNotifyAfter,
+		},
+		{
+			name:       "local overrides project",
+			project:    "notify = true\nnotify_after = \"1m\"\n",
+			local:      "notify_after = \"5s\"\n",
+			wantNotify: true,
+			wantAfter:  5 * time.Second,
+		},
+		{
+			name:        "invalid notify_after uses default with warning",
+			project:     "notify = true\nnotify_after = \"later\"\n",
+			wantNotify:  true,
+			wantAfter:   DefaultNotifyAfter,
+			wantWarning: `invalid notify_after "later"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			tmpDir := t.TempDir()
+			twigDir := filepath.Join(tmpDir, configDir)
+			if err := os.MkdirAll(twigDir, 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(twigDir, configFileName), []byte(tt.project), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if tt.local != "" {
+				if err := os.WriteFile(filepath.Join(twigDir, localConfigFileName), []byte(tt.local), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			result, err := LoadConfig(tmpDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := result.Config.ShouldNotify(); got != tt.wantNotify {
+				t.Errorf("ShouldNotify() = %v, want %v", got, tt.wantNotify)
+			}
+			if got := result.Config.NotifyAfterDuration(); got != tt.wantAfter {
+				t.Errorf("NotifyAfterDuration() = %v, want %v", got, tt.wantAfter)
+			}
+			var warned bool
+			for _, w := range result.Warnings {
+				if tt.wantWarning != "" && strings.Contains(w, tt.wantWarning) {
+					warned = true
+				}
+			}
+			if tt.wantWarning != "" && !warned {
+				t.Errorf("Warnings = %v, want to contain %q", result.Warnings, tt.wantWarning)
+			}
+			if tt.wantWarning == "" && len(result.Warnings) > 0 {
+				t.Errorf("unexpected warnings: %v", result.Warnings)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_EnvFile(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	twigDir := filepath.Join(tmpDir, configDir)
+	if err := os.MkdirAll(twigDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	project := "env_file = true\n\n[env_file_vars]\nPORT = \"3000\"\nAPP_ENV = \"dev\"\n"
+	local := "[env_file_vars]\nPORT = \"3001\"\n\"NOT-VALID\" = \"x\"\n"
+	if err := os.WriteFile(filepath.Join(twigDir, configFileName), []byte(project), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(twigDir, localConfigFileName), []byte(local), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := LoadConfig(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Config.ShouldWriteEnvFile() {
+		t.Error("ShouldWriteEnvFile() = false, want true")
+	}
+	want := map[string]string{"PORT": "3001", "APP_ENV": "dev"}
+	if !reflect.DeepEqual(result.Config.EnvFileVars, want) {
+		t.Errorf("EnvFileVars = %v, want %v", result.Config.EnvFileVars, want)
+	}
+	if len(result.Warnings) != 1 || !strings.Contains(result.Warnings[0], `invalid env_file_vars name "NOT-VALID"`) {
+		t.Errorf("Warnings = %v, want invalid env_file_vars name", result.Warnings)
+	}
+}
+
+func TestLoadConfig_GC(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		project        string
+		local          string
+		expected       GCPolicy
+		wantCheckOnAdd bool
+		wantWarning    string
+	}{
+		{
+			name:     "unset means no policy",
+			expected: GCPolicy{},
+		},
+		{
+			name:     "project policy",
+			project:  "[gc]\nmax_worktrees = 20\nmax_age = \"45d\"\n",
+			expected: GCPolicy{MaxWorktrees: 20, MaxAge: 45 * 24 * time.Hour},
+		},
+		{
+			name:           "local overrides each setting",
+			project:        "[gc]\nmax_worktrees = 20\nmax_age = \"45d\"\n",
+			local:          "[gc]\nmax_age = \"720h\"\ncheck_on_add = true\n",
+			expected:       GCPolicy{MaxWorktrees: 20, MaxAge: 720 * time.Hour},
+			wantCheckOnAdd: true,
+		},
+		{
+			name:        "invalid max_age is dropped with warning",
+			project:     "[gc]\nmax_worktrees = 5\nmax_age = \"45 days\"\n",
+			expected:    GCPolicy{MaxWorktrees: 5},
+			wantWarning: `invalid gc.max_age "45 days"`,
+		},
+		{
+			name:        "negative max_worktrees is dropped with warning",
+			project:     "[gc]\nmax_worktrees = -1\n",
+			expected:    GCPolicy{},
+			wantWarning: "invalid gc.max_worktrees -1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			tmpDir := t.TempDir()
+			twigDir := filepath.Join(tmpDir, configDir)
+			if err := os.MkdirAll(twigDir, 0755); err != nil {
+				t.Fatal(err)
+			}
+			if tt.project != "" {
+				if err := os.WriteFile(filepath.Join(twigDir, configFileName), []byte(tt.project), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.local != "" {
+				if err := os.WriteFile(filepath.Join(twigDir, localConfigFileName), []byte(tt.local), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			result, err := LoadConfig(tmpDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := result.Config.GCPolicy(); got != tt.expected {
+				t.Errorf("GCPolicy() = %+v, want %+v", got, tt.expected)
+			}
+			if got := result.Config.ShouldCheckGCOnAdd(); got != tt.wantCheckOnAdd {
+				t.Errorf("ShouldCheckGCOnAdd() = %v, want %v", got, tt.wantCheckOnAdd)
+			}
+			if tt.wantWarning == "" && len(result.Warnings) > 0 {
+				t.Errorf("unexpected warnings: %v", result.Warnings)
+			}
+			if tt.wantWarning != "" && (len(result.Warnings) != 1 || !strings.Contains(result.Warnings[0], tt.wantWarning)) {
+				t.Errorf("Warnings = %v, want %q", result.Warnings, tt.wantWarning)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_Colors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		project     string
+		local       string
+		expected    ColorsConfig
+		wantWarning string
+	}{
+		{
+			name:     "unset keeps defaults",
+			expected: ColorsConfig{},
+		},
+		{
+			name:     "project theme",
+			project:  "[colors]\nclean = \"green\"\nskip = \"yellow\"\nerror = \"bold red\"\n",
+			expected: ColorsConfig{Clean: "green", Skip: "yellow", Error: "bold red"},
+		},
+		{
+			name:     "local overrides each setting",
+			project:  "[colors]\nclean = \"green\"\nskip = \"yellow\"\n",
+			local:    "[colors]\nskip = \"brightyellow ul\"\n",
+			expected: ColorsConfig{Clean: "green", Skip: "brightyellow ul"},
+		},
+		{
+			name:        "invalid color is dropped with warning",
+			project:     "[colors]\nclean = \"green\"\nerror = \"crimson\"\n",
+			expected:    ColorsConfig{Clean: "green"},
+			wantWarning: `invalid colors.error: unknown color "crimson"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			tmpDir := t.TempDir()
+			twigDir := filepath.Join(tmpDir, configDir)
+			if err := os.MkdirAll(twigDir, 0755); err != nil {
+				t.Fatal(err)
+			}
+			if tt.project != "" {
+				if err := os.WriteFile(filepath.Join(twigDir, configFileName), []byte(tt.project), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.local != "" {
+				if err := os.WriteFile(filepath.Join(twigDir, localConfigFileName), []byte(tt.local), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			result, err := LoadConfig(tmpDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := result.Config.Colors; got != tt.expected {
+				t.Errorf("Colors = %+v, want %+v", got, tt.expected)
+			}
+			if tt.wantWarning == "" && len(result.Warnings) > 0 {
+				t.Errorf("unexpected warnings: %v", result.Warnings)
+			}
+			if tt.wantWarning != "" && (len(result.Warnings) != 1 || !strings.Contains(result.Warnings[0], tt.wantWarning)) {
+				t.Errorf("Warnings = %v, want %q", result.Warnings, tt.wantWarning)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_EnvOverrides(t *testing.T) {
+	t.Parallel()
+
+	project := `worktree_destination_base_dir = "/project/worktrees"
+default_source = "main"
+init_submodules = false
+`
+
+	tests := []struct {
+		name        string
+		local       string
+		env         map[string]string
+		wantDestDir string
+		wantSource  string
+		wantInit    bool
+	}{
+		{
+			name:        "environment overrides project",
+			env:         map[string]string{EnvWorktreeDestBaseDir: "/ci/worktrees", EnvInitSubmodules: "true"},
+			wantDestDir: "/ci/worktrees",
+			wantSource:  "main",
+			wantInit:    true,
+		},
+		{
+			name:        "local overrides environment",
+			local:       "default_source = \"develop\"\n",
+			env:         map[string]string{EnvDefaultSource: "release"},
+			wantDestDir: "/project/worktrees",
+			wantSource:  "develop",
+		},
+		{
+			name:        "unset keeps project",
+			wantDestDir: "/project/worktrees",
+			wantSource:  "main",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			tmpDir := t.TempDir()
+			twigDir := filepath.Join(tmpDir, configDir)
+			if err := os.MkdirAll(twigDir, 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(twigDir, configFileName), []byte(project), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if tt.local != "" {
+				if err := os.WriteFile(filepath.Join(twigDir, localConfigFileName), []byte(tt.local), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			result, err := LoadConfig(tmpDir, WithGetenv(mapGetenv(tt.env)))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if result.Config.WorktreeDestBaseDir != tt.wantDestDir {
+				t.Errorf("WorktreeDestBaseDir = %q, want %q", result.Config.WorktreeDestBaseDir, tt.wantDestDir)
+			}
+			if result.Config.DefaultSource != tt.wantSource {
+				t.Errorf("DefaultSource = %q, want %q", result.Config.DefaultSource, tt.wantSource)
+			}
+			if got := result.Config.ShouldInitSubmodules(); got != tt.wantInit {
+				t.Errorf("ShouldInitSubmodules() = %v, want %v", got, tt.wantInit)
+			}
+		})
+	}
+}
+
+func TestNewConfig(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		opts []LoadConfigOption
+	}{
+		{"default", nil},
+		{"main worktree dir", []LoadConfigOption{WithMainWorktreeDir("/repo/main")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			tmpDir := t.TempDir()
+			got, err := NewConfig(tmpDir, tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Matches loading a directory without config files
+			opts := append([]LoadConfigOption{WithGetenv(func(string) string { return "" })}, tt.opts...)
+			result, err := LoadConfig(tmpDir, opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, result.Config) {
+				t.Errorf("NewConfig() = %+v, want %+v", got, result.Config)
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+// Package core implements git worktree workflows as a reusable library.
+//
+// Each operation is a command struct (AddCommand, RemoveCommand, CleanCommand,
+// SyncCommand, ...) built from a FileSystem, a GitRunner and a Config, with a
+// Run method that returns a result value. Results provide Format methods that
+// render the CLI output with the format package, so callers can either
+// present them as twig does or read the fields directly.
+//
+// # Embedding
+//
+// Every command has a NewXCommand constructor taking its dependencies
+// explicitly, next to the NewDefaultXCommand constructor the CLI uses:
+//
+//   - FileSystem: NewOSFileSystem, or any implementation of the interface.
+//   - *GitRunner: NewGitRunner, with WithExecutor to run git through a
+//     custom GitExecutor.
+//   - *Config: LoadConfig to read .twig/settings.toml as the CLI does, or
+//     NewConfig for the defaults without reading any file.
+//
+// Run methods take a context.Context that stops the git commands they
+// start when it is cancelled.
+//
+// The cobra-based CLI lives in package cli and only parses flags and delegates
+// to this package. This package never imports the CLI framework, so it can be
+// used without pulling in cobra.
+package core
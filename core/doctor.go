@@ -0,0 +1,214 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// DoctorCheckName identifies a doctor check.
+type DoctorCheckName string
+
+const (
+	// DoctorCheckStash finds stashes left behind by an interrupted --sync or --carry.
+	DoctorCheckStash DoctorCheckName = "stash"
+
+	// DoctorCheckScratch finds scratch dirs left behind by crashed twig processes.
+	DoctorCheckScratch DoctorCheckName = "scratch"
+)
+
+// DoctorIssue describes a problem found by a doctor check.
+type DoctorIssue struct {
+	Summary string   // One-line description
+	Fix     []string // Commands that resolve the issue, in order
+}
+
+// DoctorCheckResult holds the outcome of a single check.
+type DoctorCheckResult struct {
+	Name   DoctorCheckName
+	Issues []DoctorIssue
+	Pruned []string // Leftovers removed by --prune
+	Err    error    // non-nil if the check could not run
+}
+
+// DoctorResult holds the outcome of all checks.
+type DoctorResult struct {
+	Checks []DoctorCheckResult
+}
+
+// ProblemCount returns the number of issues plus checks that failed to run.
+func (r DoctorResult) ProblemCount() int {
+	count := 0
+	for _, c := range r.Checks {
+		count += len(c.Issues)
+		if c.Err != nil {
+			count++
+		}
+	}
+	return count
+}
+
+// Format formats the DoctorResult for display.
+func (r DoctorResult) Format(opts FormatOptions) FormatResult {
+	var stdout, stderr strings.Builder
+	for _, c := range r.Checks {
+		switch {
+		case c.Err != nil:
+			fmt.Fprintf(&stderr, "%s: error: %v\n", c.Name, c.Err)
+		case len(c.Issues) == 0 && len(c.Pruned) > 0:
+			fmt.Fprintf(&stdout, "%s: ok (pruned %d)\n", c.Name, len(c.Pruned))
+		case len(c.Issues) == 0:
+			fmt.Fprintf(&stdout, "%s: ok\n", c.Name)
+		default:
+			fmt.Fprintf(&stdout, "%s: %d issue(s)\n", c.Name, len(c.Issues))
+			for _, issue := range c.Issues {
+				fmt.Fprintf(&stdout, "  %s\n", issue.Summary)
+				for _, fix := range issue.Fix {
+					fmt.Fprintf(&stdout, "    %s\n", fix)
+				}
+			}
+		}
+		for _, path := range c.Pruned {
+			fmt.Fprintf(&stdout, "  removed %s\n", path)
+		}
+	}
+	return FormatResult{Stdout: stdout.String(), Stderr: stderr.String()}
+}
+
+// DoctorCommand diagnoses leftover state from interrupted twig operations.
+type DoctorCommand struct {
+	Git *GitRunner
+	Log *slog.Logger
+}
+
+// NewDoctorCommand creates a DoctorCommand with explicit dependencies.
+func NewDoctorCommand(git *GitRunner, log *slog.Logger) *DoctorCommand {
+	if log == nil {
+		log = NewNopLogger()
+	}
+	return &DoctorCommand{Git: git, Log: log}
+}
+
+// NewDefaultDoctorCommand creates a DoctorCommand with production defaults.
+func NewDefaultDoctorCommand(dir string, log *slog.Logger, gitOpts ...GitRunnerOption) *DoctorCommand {
+	return NewDoctorCommand(newDefaultGitRunner(dir, log, gitOpts), log)
+}
+
+// DoctorOptions configures doctor checks.
+type DoctorOptions struct {
+	Prune bool // Remove leftovers that hold no user data (scratch dirs)
+}
+
+// Run executes all checks. A failing check is recorded in its result
+// and does not stop the remaining checks.
+func (c *DoctorCommand) Run(ctx context.Context, opts DoctorOptions) (DoctorResult, error) {
+	checks := []struct {
+		name DoctorCheckName
+		fn   func(context.Context, DoctorOptions) DoctorCheckResult
+	}{
+		{DoctorCheckStash, c.checkStrandedStashes},
+		{DoctorCheckScratch, c.checkScratchLeftovers},
+	}
+
+	var result DoctorResult
+	for _, check := range checks {
+		checkResult := check.fn(ctx, opts)
+		checkResult.Name = check.name
+		c.Log.DebugContext(ctx, "doctor check finished",
+			LogAttrKeyCategory.String(), LogCategoryDoctor,
+			"check", check.name,
+			"issues", len(checkResult.Issues),
+			"pruned", len(checkResult.Pruned),
+			"error", checkResult.Err)
+		result.Checks = append(result.Checks, checkResult)
+	}
+	return result, nil
+}
+
+// Stash messages used by --sync and --carry in earlier versions of twig.
+const (
+	stashMessageSync  = "twig sync"
+	stashMessageCarry = "twig carry"
+)
+
+// checkStrandedStashes reports stashes created by --sync or --carry in
+// earlier versions, which transferred changes through git stash and left
+// the stash behind when changes could not be restored after a failure.
+// Stashes hold user changes, so they are never pruned automatically.
+func (c *DoctorCommand) checkStrandedStashes(ctx context.Context, _ DoctorOptions) DoctorCheckResult {
+	entries, err := c.Git.StashList(ctx)
+	if err != nil {
+		return DoctorCheckResult{Err: fmt.Errorf("failed to list stashes: %w", err)}
+	}
+
+	var issues []DoctorIssue
+	for _, e := range entries {
+		if !isTwigStash(e.Subject) {
+			continue
+		}
+		short := e.Hash
+		if len(short) > 7 {
+			short = short[:7]
+		}
+		issues = append(issues, DoctorIssue{
+			Summary: fmt.Sprintf("stranded stash %s (%s): %s", e.Ref, short, e.Subject),
+			Fix: []string{
+				fmt.Sprintf("git stash apply %s  # run in the worktree to restore into", e.Hash),
+				fmt.Sprintf("git stash drop %s", e.Ref),
+			},
+		})
+	}
+	return DoctorCheckResult{Issues: issues}
+}
+
+// checkScratchLeftovers reports scratch dirs whose owning process is gone.
+// They only hold intermediate files, so --prune removes them.
+func (c *DoctorCommand) checkScratchLeftovers(ctx context.Context, opts DoctorOptions) DoctorCheckResult {
+	commonDir, err := c.Git.GitCommonDir(ctx)
+	if err != nil {
+		return DoctorCheckResult{Err: fmt.Errorf("failed to get git common directory: %w", err)}
+	}
+	leftovers, err := FindScratchLeftovers(commonDir, time.Now())
+	if err != nil {
+		return DoctorCheckResult{Err: err}
+	}
+
+	var result DoctorCheckResult
+	for _, l := range leftovers {
+		if opts.Prune {
+			scratch := &ScratchDir{Path: l.Path}
+			err := scratch.Remove()
+			if err == nil {
+				result.Pruned = append(result.Pruned, l.Path)
+				continue
+			}
+			// Keep reporting the leftover so the failure is visible
+			c.Log.DebugContext(ctx, "failed to prune scratch dir",
+				LogAttrKeyCategory.String(), LogCategoryDoctor,
+				"path", l.Path,
+				"error", err.Error())
+		}
+
+		owner := "unknown process"
+		if l.PID != 0 {
+			owner = fmt.Sprintf("twig %s (pid %d)", l.Command, l.PID)
+		}
+		result.Issues = append(result.Issues, DoctorIssue{
+			Summary: fmt.Sprintf("leftover scratch dir %s from %s, started %s",
+				l.Path, owner, l.StartedAt.Local().Format(time.DateTime)),
+			Fix: []string{
+				"twig doctor --prune",
+			},
+		})
+	}
+	return result
+}
+
+// isTwigStash reports whether a stash subject ("On <branch>: <message>")
+// was created by twig add --sync or --carry in an earlier version.
+func isTwigStash(subject string) bool {
+	return strings.HasSuffix(subject, ": "+stashMessageCarry) ||
+		strings.HasSuffix(subject, ": "+stashMessageSync)
+}
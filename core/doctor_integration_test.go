@@ -0,0 +1,126 @@
+//go:build integration
+
+package core
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/708u/twig/internal/testutil"
+)
+
+func TestDoctorCommand_Integration(t *testing.T) {
+	t.Parallel()
+
+	t.Run("ReportsStrandedTwigStash", func(t *testing.T) {
+		t.Parallel()
+
+		_, mainDir := testutil.SetupTestRepo(t)
+
+		testutil.RunGit(t, mainDir, "add", ".twig")
+		testutil.RunGit(t, mainDir, "commit", "-m", "add twig settings")
+
+		// A user stash is not reported
+		if err := os.WriteFile(filepath.Join(mainDir, "user.txt"), []byte("user"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		testutil.RunGit(t, mainDir, "stash", "push", "-u", "-m", "wip")
+
+		cmd := NewDoctorCommand(NewGitRunner(mainDir), nil)
+		result, err := cmd.Run(t.Context(), DoctorOptions{})
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		if result.ProblemCount() != 0 {
+			t.Fatalf("ProblemCount() = %d, want 0: %+v", result.ProblemCount(), result)
+		}
+
+		// A stash left by an interrupted carry is reported with recovery steps
+		if err := os.WriteFile(filepath.Join(mainDir, "carried.txt"), []byte("carried"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		testutil.RunGit(t, mainDir, "stash", "push", "-u", "-m", stashMessageCarry)
+		hash := strings.TrimSpace(testutil.RunGit(t, mainDir, "rev-parse", "stash@{0}"))
+
+		result, err = cmd.Run(t.Context(), DoctorOptions{})
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		if result.ProblemCount() != 1 {
+			t.Fatalf("ProblemCount() = %d, want 1: %+v", result.ProblemCount(), result)
+		}
+		issue := result.Checks[0].Issues[0]
+		if !strings.Contains(issue.Summary, "stash@{0}") || !strings.Contains(issue.Summary, "twig carry") {
+			t.Errorf("Summary = %q, want stash@{0} twig carry", issue.Summary)
+		}
+		if issue.Fix[0] != "git stash apply "+hash+"  # run in the worktree to restore into" {
+			t.Errorf("Fix[0] = %q, want apply of %s", issue.Fix[0], hash)
+		}
+	})
+
+	t.Run("PrunesScratchLeftovers", func(t *testing.T) {
+		t.Parallel()
+
+		_, mainDir := testutil.SetupTestRepo(t)
+		commonDir := filepath.Join(mainDir, ".git")
+
+		// A scratch dir in use by this process is never reported
+		active, err := CreateScratchDir(t.Context(), commonDir, ScratchDirOptions{Purpose: "carry", Command: "add"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer active.Remove()
+
+		// A scratch dir whose owner exited is a crash leftover
+		exited := exec.Command("true")
+		if err := exited.Run(); err != nil {
+			t.Skipf("cannot run helper process: %v", err)
+		}
+		leftover, err := CreateScratchDir(t.Context(), commonDir, ScratchDirOptions{Purpose: "bundle", Command: "add"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		host, _ := os.Hostname()
+		data, err := json.Marshal(lockOwner{PID: exited.Process.Pid, Host: host, Command: "add"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(leftover.Path, scratchOwnerFileName), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		cmd := NewDoctorCommand(NewGitRunner(mainDir), nil)
+		result, err := cmd.Run(t.Context(), DoctorOptions{})
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		scratch := result.Checks[1]
+		if scratch.Name != DoctorCheckScratch || len(scratch.Issues) != 1 {
+			t.Fatalf("scratch check = %+v, want 1 issue", scratch)
+		}
+		if !strings.Contains(scratch.Issues[0].Summary, leftover.Path) {
+			t.Errorf("Summary = %q, want to contain %s", scratch.Issues[0].Summary, leftover.Path)
+		}
+
+		result, err = cmd.Run(t.Context(), DoctorOptions{Prune: true})
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		if result.ProblemCount() != 0 {
+			t.Fatalf("ProblemCount() = %d, want 0: %+v", result.ProblemCount(), result)
+		}
+		if pruned := result.Checks[1].Pruned; len(pruned) != 1 || pruned[0] != leftover.Path {
+			t.Errorf("Pruned = %v, want [%s]", pruned, leftover.Path)
+		}
+		if _, err := os.Stat(leftover.Path); !os.IsNotExist(err) {
+			t.Errorf("leftover should be removed, stat err = %v", err)
+		}
+		if _, err := os.Stat(active.Path); err != nil {
+			t.Errorf("active scratch dir should remain: %v", err)
+		}
+	})
+}
@@ -0,0 +1,155 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/708u/twig/internal/testutil"
+)
+
+func TestDoctorCommand_Run(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		stashList   string
+		runErr      error
+		wantIssues  []string // expected issue summaries
+		wantFix     string   // expected first fix of the first issue
+		wantErr     bool     // check error recorded in result
+		wantProblem int
+	}{
+		{
+			name:      "no twig stashes",
+			stashList: "stash@{0} 1111111111111111 On main: wip\n",
+		},
+		{
+			name: "stranded carry and sync stashes",
+			stashList: "stash@{0} 1111111111111111 On main: wip\n" +
+				"stash@{1} 2222222222222222 On feat/a: twig carry\n" +
+				"stash@{2} 3333333333333333 On main: twig sync\n",
+			wantIssues: []string{
+				"stranded stash stash@{1} (2222222): On feat/a: twig carry",
+				"stranded stash stash@{2} (3333333): On main: twig sync",
+			},
+			wantFix:     "git stash apply 2222222222222222  # run in the worktree to restore into",
+			wantProblem: 2,
+		},
+		{
+			name:      "message only mentioning twig is ignored",
+			stashList: "stash@{0} 1111111111111111 On main: twig carry later\n",
+		},
+		{
+			// The scratch check cannot resolve the repository either
+			name:        "stash list failure",
+			runErr:      errors.New("not a git repository"),
+			wantErr:     true,
+			wantProblem: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockGit := &testutil.MockGitExecutor{
+				StashListOutput: tt.stashList,
+				GitCommonDir:    t.TempDir(),
+			}
+			if tt.runErr != nil {
+				mockGit.RunFunc = func(ctx context.Context, args ...string) ([]byte, error) {
+					return nil, tt.runErr
+				}
+			}
+			git := &GitRunner{Executor: mockGit, Dir: "/repo/main", Log: NewNopLogger()}
+
+			result, err := NewDoctorCommand(git, nil).Run(t.Context(), DoctorOptions{})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(result.Checks) != 2 || result.Checks[0].Name != DoctorCheckStash {
+				t.Fatalf("Checks = %+v, want stash check first", result.Checks)
+			}
+
+			check := result.Checks[0]
+			if (check.Err != nil) != tt.wantErr {
+				t.Errorf("Err = %v, want error = %v", check.Err, tt.wantErr)
+			}
+			var got []string
+			for _, issue := range check.Issues {
+				got = append(got, issue.Summary)
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.wantIssues, "\n") {
+				t.Errorf("issues = %q, want %q", got, tt.wantIssues)
+			}
+			if tt.wantFix != "" && check.Issues[0].Fix[0] != tt.wantFix {
+				t.Errorf("Fix[0] = %q, want %q", check.Issues[0].Fix[0], tt.wantFix)
+			}
+			if result.ProblemCount() != tt.wantProblem {
+				t.Errorf("ProblemCount() = %d, want %d", result.ProblemCount(), tt.wantProblem)
+			}
+		})
+	}
+}
+
+func TestDoctorResult_Format(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		result     DoctorResult
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			name:       "all ok",
+			result:     DoctorResult{Checks: []DoctorCheckResult{{Name: DoctorCheckStash}}},
+			wantStdout: "stash: ok\n",
+		},
+		{
+			name: "issues with fixes",
+			result: DoctorResult{Checks: []DoctorCheckResult{{
+				Name: DoctorCheckStash,
+				Issues: []DoctorIssue{{
+					Summary: "stranded stash stash@{0} (abc1234): On main: twig carry",
+					Fix:     []string{"git stash apply abc1234", "git stash drop stash@{0}"},
+				}},
+			}}},
+			wantStdout: "stash: 1 issue(s)\n" +
+				"  stranded stash stash@{0} (abc1234): On main: twig carry\n" +
+				"    git stash apply abc1234\n" +
+				"    git stash drop stash@{0}\n",
+		},
+		{
+			name:       "check error",
+			result:     DoctorResult{Checks: []DoctorCheckResult{{Name: DoctorCheckStash, Err: errors.New("boom")}}},
+			wantStderr: "stash: error: boom\n",
+		},
+		{
+			name: "pruned leftovers",
+			result: DoctorResult{Checks: []DoctorCheckResult{{
+				Name:   DoctorCheckScratch,
+				Pruned: []string{"/repo/.git/twig/tmp/carry-1", "/repo/.git/twig/tmp/carry-2"},
+			}}},
+			wantStdout: "scratch: ok (pruned 2)\n" +
+				"  removed /repo/.git/twig/tmp/carry-1\n" +
+				"  removed /repo/.git/twig/tmp/carry-2\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := tt.result.Format(FormatOptions{})
+			if got.Stdout != tt.wantStdout {
+				t.Errorf("Stdout = %q, want %q", got.Stdout, tt.wantStdout)
+			}
+			if got.Stderr != tt.wantStderr {
+				t.Errorf("Stderr = %q, want %q", got.Stderr, tt.wantStderr)
+			}
+		})
+	}
+}
@@ -0,0 +1,194 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// diskUsageCacheFileName is stored next to the audit log under <git-common-dir>/twig.
+	diskUsageCacheFileName = "du-cache.json"
+
+	// DefaultDiskUsageCacheTTL is how long a cached worktree size is reused.
+	DefaultDiskUsageCacheTTL = 10 * time.Minute
+)
+
+// diskUsageCacheEntry is a cached size for one worktree path.
+// The entry is invalidated when HEAD moves or the TTL expires.
+type diskUsageCacheEntry struct {
+	HEAD       string    `json:"head"`
+	SizeBytes  int64     `json:"size_bytes"`
+	ComputedAt time.Time `json:"computed_at"`
+}
+
+// DiskUsage calculates per-worktree disk usage by walking the filesystem.
+// Results are cached per repository to keep repeated listings fast.
+type DiskUsage struct {
+	FS  FileSystem
+	Git *GitRunner
+	Log *slog.Logger
+	TTL time.Duration // Cache lifetime (0 = DefaultDiskUsageCacheTTL)
+}
+
+// NewDiskUsage creates a DiskUsage with explicit dependencies.
+func NewDiskUsage(fs FileSystem, git *GitRunner, log *slog.Logger) *DiskUsage {
+	if log == nil {
+		log = NewNopLogger()
+	}
+	return &DiskUsage{FS: fs, Git: git, Log: log}
+}
+
+// Calculate returns the size in bytes of each worktree keyed by path.
+// Worktrees whose directory is missing (e.g. prunable) are omitted.
+// Worktrees nested inside another worktree are not counted twice.
+// If refresh is true, cached sizes are ignored and recalculated.
+func (d *DiskUsage) Calculate(ctx context.Context, worktrees []Worktree, now time.Time, refresh bool) map[string]int64 {
+	ttl := d.TTL
+	if ttl == 0 {
+		ttl = DefaultDiskUsageCacheTTL
+	}
+
+	cachePath, cache := d.loadCache(ctx)
+
+	sizes := make(map[string]int64, len(worktrees))
+	var stale []Worktree
+	for _, wt := range worktrees {
+		if entry, ok := cache[wt.Path]; ok && !refresh &&
+			entry.HEAD == wt.HEAD && now.Sub(entry.ComputedAt) < ttl {
+			sizes[wt.Path] = entry.SizeBytes
+			continue
+		}
+		if _, err := d.FS.Stat(wt.Path); err != nil {
+			d.Log.DebugContext(ctx, "skipping missing worktree",
+				LogAttrKeyCategory.String(), LogCategoryDiskUse,
+				"path", wt.Path)
+			continue
+		}
+		stale = append(stale, wt)
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, wt := range stale {
+		wg.Add(1)
+		go func(wt Worktree) {
+			defer wg.Done()
+			size := dirSizeExcluding(d.FS, wt.Path, nestedWorktrees(wt.Path, worktrees))
+
+			mu.Lock()
+			sizes[wt.Path] = size
+			cache[wt.Path] = diskUsageCacheEntry{HEAD: wt.HEAD, SizeBytes: size, ComputedAt: now}
+			mu.Unlock()
+		}(wt)
+	}
+	wg.Wait()
+
+	d.Log.DebugContext(ctx, "disk usage calculated",
+		LogAttrKeyCategory.String(), LogCategoryDiskUse,
+		"worktrees", len(worktrees),
+		"walked", len(stale))
+
+	if cachePath != "" {
+		d.saveCache(ctx, cachePath, cache, worktrees)
+	}
+	return sizes
+}
+
+// loadCache reads the size cache. Any failure yields an empty cache
+// and an empty path when the cache location cannot be resolved.
+func (d *DiskUsage) loadCache(ctx context.Context) (string, map[string]diskUsageCacheEntry) {
+	cache := make(map[string]diskUsageCacheEntry)
+
+	commonDir, err := d.Git.GitCommonDir(ctx)
+	if err != nil || commonDir == "" {
+		d.Log.DebugContext(ctx, "disk usage cache disabled",
+			LogAttrKeyCategory.String(), LogCategoryDiskUse,
+			"error", err)
+		return "", cache
+	}
+	cachePath := filepath.Join(commonDir, auditDirName, diskUsageCacheFileName)
+
+	data, err := d.FS.ReadFile(cachePath)
+	if err != nil {
+		return cachePath, cache
+	}
+	if err := json.Unmarshal(data, &cache); err != nil {
+		d.Log.DebugContext(ctx, "ignoring corrupt disk usage cache",
+			LogAttrKeyCategory.String(), LogCategoryDiskUse,
+			"error", err.Error())
+		return cachePath, make(map[string]diskUsageCacheEntry)
+	}
+	return cachePath, cache
+}
+
+// saveCache writes entries for the current worktrees, dropping stale paths.
+// Failures are logged only since the cache is an optimization.
+func (d *DiskUsage) saveCache(ctx context.Context, cachePath string, cache map[string]diskUsageCacheEntry, worktrees []Worktree) {
+	current := make(map[string]diskUsageCacheEntry, len(worktrees))
+	for _, wt := range worktrees {
+		if entry, ok := cache[wt.Path]; ok {
+			current[wt.Path] = entry
+		}
+	}
+
+	data, err := json.Marshal(current)
+	if err == nil {
+		err = d.FS.MkdirAll(filepath.Dir(cachePath), 0755)
+	}
+	if err == nil {
+		err = d.FS.WriteFile(cachePath, data, 0644)
+	}
+	if err != nil {
+		d.Log.DebugContext(ctx, "failed to write disk usage cache",
+			LogAttrKeyCategory.String(), LogCategoryDiskUse,
+			"error", err.Error())
+	}
+}
+
+// nestedWorktrees returns the paths of worktrees located inside root.
+func nestedWorktrees(root string, worktrees []Worktree) map[string]bool {
+	var nested map[string]bool
+	prefix := root + string(filepath.Separator)
+	for _, wt := range worktrees {
+		if strings.HasPrefix(wt.Path, prefix) {
+			if nested == nil {
+				nested = make(map[string]bool)
+			}
+			nested[wt.Path] = true
+		}
+	}
+	return nested
+}
+
+// dirSize returns the total size in bytes of regular files under dir.
+// Symlinks are not followed. Errors are ignored since the size is informational.
+func dirSize(fsys FileSystem, dir string) int64 {
+	return dirSizeExcluding(fsys, dir, nil)
+}
+
+// dirSizeExcluding is dirSize that skips the directories in exclude.
+func dirSizeExcluding(fsys FileSystem, dir string, exclude map[string]bool) int64 {
+	entries, err := fsys.ReadDir(dir)
+	if err != nil {
+		return 0
+	}
+	var total int64
+	for _, entry := range entries {
+		p := filepath.Join(dir, entry.Name())
+		if entry.IsDir() {
+			if !exclude[p] {
+				total += dirSizeExcluding(fsys, p, exclude)
+			}
+			continue
+		}
+		if info, err := entry.Info(); err == nil && info != nil && info.Mode().IsRegular() {
+			total += info.Size()
+		}
+	}
+	return total
+}
@@ -0,0 +1,202 @@
+package core
+
+import (
+	"encoding/json"
+	"io/fs"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/708u/twig/internal/testutil"
+)
+
+// sizedDirEntry is a DirEntry whose Info reports a size and mode.
+type sizedDirEntry struct {
+	name  string
+	isDir bool
+	size  int64
+	mode  fs.FileMode
+}
+
+func (e sizedDirEntry) Name() string      { return e.name }
+func (e sizedDirEntry) IsDir() bool       { return e.isDir }
+func (e sizedDirEntry) Type() fs.FileMode { return e.mode.Type() }
+func (e sizedDirEntry) Info() (fs.FileInfo, error) {
+	return &testutil.MockFileInfo{NameVal: e.name, SizeVal: e.size, ModeVal: e.mode, IsDirVal: e.isDir}, nil
+}
+
+func TestDirSize(t *testing.T) {
+	t.Parallel()
+
+	mockFS := &testutil.MockFS{
+		DirContents: map[string][]os.DirEntry{
+			"/wt":     {sizedDirEntry{name: "a.txt", size: 100}, sizedDirEntry{name: "sub", isDir: true}},
+			"/wt/sub": {sizedDirEntry{name: "b.txt", size: 50}, sizedDirEntry{name: "link", size: 999, mode: fs.ModeSymlink}},
+		},
+	}
+
+	if got := dirSize(mockFS, "/wt"); got != 150 {
+		t.Errorf("dirSize() = %d, want 150", got)
+	}
+	if got := dirSize(mockFS, "/missing"); got != 0 {
+		t.Errorf("dirSize(missing) = %d, want 0", got)
+	}
+}
+
+func TestDiskUsage_Calculate(t *testing.T) {
+	t.Parallel()
+
+	const cachePath = "/repo/main/.git/twig/du-cache.json"
+	now := time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC)
+
+	dirContents := map[string][]os.DirEntry{
+		"/repo/main": {
+			sizedDirEntry{name: "main.txt", size: 100},
+			sizedDirEntry{name: ".worktrees", isDir: true},
+		},
+		"/repo/main/.worktrees":        {sizedDirEntry{name: "nested", isDir: true}},
+		"/repo/main/.worktrees/nested": {sizedDirEntry{name: "n.txt", size: 30}},
+		"/repo/feat-a":                 {sizedDirEntry{name: "a.txt", size: 200}},
+	}
+	worktrees := []Worktree{
+		{Path: "/repo/main", Branch: "main", HEAD: "aaa"},
+		{Path: "/repo/main/.worktrees/nested", Branch: "nested", HEAD: "bbb"},
+		{Path: "/repo/feat-a", Branch: "feat/a", HEAD: "ccc"},
+		{Path: "/repo/gone", Branch: "gone", HEAD: "ddd", Prunable: true},
+	}
+
+	tests := []struct {
+		name      string
+		cache     map[string]diskUsageCacheEntry
+		refresh   bool
+		wantSizes map[string]int64
+	}{
+		{
+			name: "walks worktrees without counting nested ones twice",
+			wantSizes: map[string]int64{
+				"/repo/main":                   100,
+				"/repo/main/.worktrees/nested": 30,
+				"/repo/feat-a":                 200,
+			},
+		},
+		{
+			name: "uses fresh cache entry",
+			cache: map[string]diskUsageCacheEntry{
+				"/repo/feat-a": {HEAD: "ccc", SizeBytes: 9999, ComputedAt: now.Add(-time.Minute)},
+			},
+			wantSizes: map[string]int64{
+				"/repo/main":                   100,
+				"/repo/main/.worktrees/nested": 30,
+				"/repo/feat-a":                 9999,
+			},
+		},
+		{
+			name: "ignores cache when HEAD moved",
+			cache: map[string]diskUsageCacheEntry{
+				"/repo/feat-a": {HEAD: "old", SizeBytes: 9999, ComputedAt: now.Add(-time.Minute)},
+			},
+			wantSizes: map[string]int64{
+				"/repo/main":                   100,
+				"/repo/main/.worktrees/nested": 30,
+				"/repo/feat-a":                 200,
+			},
+		},
+		{
+			name: "ignores expired cache entry",
+			cache: map[string]diskUsageCacheEntry{
+				"/repo/feat-a": {HEAD: "ccc", SizeBytes: 9999, ComputedAt: now.Add(-DefaultDiskUsageCacheTTL)},
+			},
+			wantSizes: map[string]int64{
+				"/repo/main":                   100,
+				"/repo/main/.worktrees/nested": 30,
+				"/repo/feat-a":                 200,
+			},
+		},
+		{
+			name: "refresh ignores cache",
+			cache: map[string]diskUsageCacheEntry{
+				"/repo/feat-a": {HEAD: "ccc", SizeBytes: 9999, ComputedAt: now.Add(-time.Minute)},
+			},
+			refresh: true,
+			wantSizes: map[string]int64{
+				"/repo/main":                   100,
+				"/repo/main/.worktrees/nested": 30,
+				"/repo/feat-a":                 200,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			written := map[string][]byte{}
+			if tt.cache != nil {
+				data, err := json.Marshal(tt.cache)
+				if err != nil {
+					t.Fatal(err)
+				}
+				written[cachePath] = data
+			}
+			mockFS := &testutil.MockFS{
+				DirContents:   dirContents,
+				ExistingPaths: []string{"/repo/main", "/repo/main/.worktrees/nested", "/repo/feat-a"},
+				WrittenFiles:  written,
+			}
+			git := &GitRunner{
+				Executor: &testutil.MockGitExecutor{GitCommonDir: "/repo/main/.git"},
+				Dir:      "/repo/main",
+				Log:      NewNopLogger(),
+			}
+
+			sizes := NewDiskUsage(mockFS, git, nil).Calculate(t.Context(), worktrees, now, tt.refresh)
+
+			if len(sizes) != len(tt.wantSizes) {
+				t.Errorf("got %d sizes, want %d: %v", len(sizes), len(tt.wantSizes), sizes)
+			}
+			for path, want := range tt.wantSizes {
+				if got, ok := sizes[path]; !ok || got != want {
+					t.Errorf("sizes[%q] = %d (ok=%v), want %d", path, got, ok, want)
+				}
+			}
+
+			// Cache is rewritten with the reported sizes for existing worktrees only
+			var saved map[string]diskUsageCacheEntry
+			if err := json.Unmarshal(written[cachePath], &saved); err != nil {
+				t.Fatalf("cache not written: %v", err)
+			}
+			if len(saved) != len(tt.wantSizes) {
+				t.Errorf("cache has %d entries, want %d", len(saved), len(tt.wantSizes))
+			}
+			for path, want := range tt.wantSizes {
+				if saved[path].SizeBytes != want {
+					t.Errorf("cache[%q].SizeBytes = %d, want %d", path, saved[path].SizeBytes, want)
+				}
+			}
+		})
+	}
+}
+
+func TestDiskUsage_Calculate_CorruptCache(t *testing.T) {
+	t.Parallel()
+
+	mockFS := &testutil.MockFS{
+		DirContents: map[string][]os.DirEntry{
+			"/repo/main": {sizedDirEntry{name: "main.txt", size: 100}},
+		},
+		ExistingPaths: []string{"/repo/main"},
+		WrittenFiles: map[string][]byte{
+			"/repo/main/.git/twig/du-cache.json": []byte("{not json"),
+		},
+	}
+	git := &GitRunner{
+		Executor: &testutil.MockGitExecutor{GitCommonDir: "/repo/main/.git"},
+		Dir:      "/repo/main",
+		Log:      NewNopLogger(),
+	}
+
+	sizes := NewDiskUsage(mockFS, git, nil).Calculate(t.Context(), []Worktree{{Path: "/repo/main"}}, time.Now(), false)
+	if sizes["/repo/main"] != 100 {
+		t.Errorf("sizes[/repo/main] = %d, want 100", sizes["/repo/main"])
+	}
+}
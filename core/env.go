@@ -0,0 +1,192 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// Environment variables set for commands twig runs in a worktree
+// (post-create hooks and open_command).
+const (
+	EnvBranch       = "TWIG_BRANCH"        // Branch checked out in the worktree
+	EnvWorktreePath = "TWIG_WORKTREE_PATH" // Absolute path of the worktree
+	EnvIsMain       = "TWIG_IS_MAIN"       // "1" for the main worktree, "0" otherwise
+)
+
+// worktreeEnv returns the current environment with the TWIG_* variables
+// describing the worktree appended, overriding inherited values.
+func worktreeEnv(branch, path string, isMain bool) []string {
+	mainFlag := "0"
+	if isMain {
+		mainFlag = "1"
+	}
+	return append(os.Environ(),
+		EnvBranch+"="+branch,
+		EnvWorktreePath+"="+path,
+		EnvIsMain+"="+mainFlag,
+	)
+}
+
+// Environment variables that override settings, so that CI jobs can
+// redirect worktrees without writing settings.local.toml. They apply on
+// top of the project config and below the local config and profiles.
+const (
+	EnvWorktreeDestBaseDir  = "TWIG_WORKTREE_DEST_BASE_DIR"  // worktree_destination_base_dir
+	EnvDefaultSource        = "TWIG_DEFAULT_SOURCE"          // default_source
+	EnvInitSubmodules       = "TWIG_INIT_SUBMODULES"         // init_submodules
+	EnvSubmoduleReference   = "TWIG_SUBMODULE_REFERENCE"     // submodule_reference
+	EnvSubmoduleRefDir      = "TWIG_SUBMODULE_REF_DIR"       // submodule_reference_dir
+	EnvSubmoduleRecursive   = "TWIG_SUBMODULE_RECURSIVE"     // submodule_recursive
+	EnvLookupRemoteBranches = "TWIG_LOOKUP_REMOTE_BRANCHES"  // lookup_remote_branches
+	EnvFetchOnAdd           = "TWIG_FETCH_ON_ADD"            // fetch_on_add
+	EnvCleanStale           = "TWIG_CLEAN_STALE"             // clean_stale
+	EnvCleanFetch           = "TWIG_CLEAN_FETCH"             // clean_fetch
+	EnvCleanOnlyTwigManaged = "TWIG_CLEAN_ONLY_TWIG_MANAGED" // clean_only_twig_managed
+	EnvCleanupEmptyDirs     = "TWIG_CLEANUP_EMPTY_DIRS"      // cleanup_empty_dirs
+	EnvDetectSquashMerges   = "TWIG_DETECT_SQUASH_MERGES"    // detect_squash_merges
+	EnvStrictSymlinks       = "TWIG_STRICT_SYMLINKS"         // strict_symlinks
+	EnvSymlinkStyle         = "TWIG_SYMLINK_STYLE"           // symlink_style
+	EnvBranchPrefix         = "TWIG_BRANCH_PREFIX"           // branch_prefix
+	EnvOpenCommand          = "TWIG_OPEN_COMMAND"            // open_command
+	EnvCleanVerifyCommand   = "TWIG_CLEAN_VERIFY_COMMAND"    // clean_verify_command
+	EnvPRBranchTemplate     = "TWIG_PR_BRANCH_TEMPLATE"      // pr_branch_template
+	EnvForge                = "TWIG_FORGE"                   // forge
+	EnvCaseCollisions       = "TWIG_CASE_COLLISIONS"         // case_collisions
+	EnvGitLockWait          = "TWIG_GIT_LOCK_WAIT"           // git_lock_wait
+	EnvGitTimeout           = "TWIG_GIT_TIMEOUT"             // git_timeout
+	EnvGitRemoteTimeout     = "TWIG_GIT_REMOTE_TIMEOUT"      // git_remote_timeout
+	EnvArchiveDir           = "TWIG_ARCHIVE_DIR"             // archive_dir
+	EnvNotify               = "TWIG_NOTIFY"                  // notify
+	EnvNotifyAfter          = "TWIG_NOTIFY_AFTER"            // notify_after
+	EnvEnvFile              = "TWIG_ENV_FILE"                // env_file
+)
+
+// envConfigSource names the environment in config sources.
+const envConfigSource = "environment"
+
+// envConfig returns the settings overridden by environment variables, or
+// nil if none are set. Empty variables are ignored. Invalid booleans are
+// ignored with a warning.
+func envConfig(getenv func(string) string) (*Config, []string) {
+	var cfg Config
+	var set bool
+	var warnings []string
+
+	strs := []struct {
+		name  string
+		field *string
+	}{
+		{EnvWorktreeDestBaseDir, &cfg.WorktreeDestBaseDir},
+		{EnvDefaultSource, &cfg.DefaultSource},
+		{EnvBranchPrefix, &cfg.BranchPrefix},
+		{EnvOpenCommand, &cfg.OpenCommand},
+		{EnvCleanVerifyCommand, &cfg.CleanVerifyCommand},
+		{EnvPRBranchTemplate, &cfg.PRBranchTemplate},
+		{EnvForge, &cfg.Forge},
+		{EnvCaseCollisions, &cfg.CaseCollisions},
+		{EnvSymlinkStyle, &cfg.SymlinkStyle},
+		{EnvGitLockWait, &cfg.GitLockWait},
+		{EnvGitTimeout, &cfg.GitTimeout},
+		{EnvGitRemoteTimeout, &cfg.GitRemoteTimeout},
+		{EnvArchiveDir, &cfg.ArchiveDir},
+		{EnvSubmoduleRefDir, &cfg.SubmoduleRefDir},
+		{EnvNotifyAfter, &cfg.NotifyAfter},
+	}
+	for _, s := range strs {
+		if v := getenv(s.name); v != "" {
+			*s.field = v
+			set = true
+		}
+	}
+
+	bools := []struct {
+		name  string
+		field **bool
+	}{
+		{EnvInitSubmodules, &cfg.InitSubmodules},
+		{EnvSubmoduleReference, &cfg.SubmoduleReference},
+		{EnvSubmoduleRecursive, &cfg.SubmoduleRecursive},
+		{EnvLookupRemoteBranches, &cfg.LookupRemoteBranches},
+		{EnvFetchOnAdd, &cfg.FetchOnAdd},
+		{EnvCleanStale, &cfg.CleanStale},
+		{EnvCleanFetch, &cfg.CleanFetch},
+		{EnvCleanOnlyTwigManaged, &cfg.CleanOnlyTwigManaged},
+		{EnvCleanupEmptyDirs, &cfg.CleanupEmptyDirs},
+		{EnvDetectSquashMerges, &cfg.DetectSquashMerges},
+		{EnvStrictSymlinks, &cfg.StrictSymlinks},
+		{EnvNotify, &cfg.Notify},
+		{EnvEnvFile, &cfg.EnvFile},
+	}
+	for _, b := range bools {
+		v := getenv(b.name)
+		if v == "" {
+			continue
+		}
+		enabled, err := strconv.ParseBool(v)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("invalid %s %q (use true or false), ignored", b.name, v))
+			continue
+		}
+		*b.field = &enabled
+		set = true
+	}
+
+	if !set {
+		return nil, warnings
+	}
+	return &cfg, warnings
+}
+
+// overlayConfig returns base with the settings set in top replacing its
+// own. Only the settings envConfig can set are considered.
+func overlayConfig(base, top *Config) *Config {
+	if top == nil {
+		return base
+	}
+	var merged Config
+	if base != nil {
+		merged = *base
+	}
+	for _, s := range []struct{ dst, src *string }{
+		{&merged.WorktreeDestBaseDir, &top.WorktreeDestBaseDir},
+		{&merged.DefaultSource, &top.DefaultSource},
+		{&merged.BranchPrefix, &top.BranchPrefix},
+		{&merged.OpenCommand, &top.OpenCommand},
+		{&merged.CleanVerifyCommand, &top.CleanVerifyCommand},
+		{&merged.PRBranchTemplate, &top.PRBranchTemplate},
+		{&merged.Forge, &top.Forge},
+		{&merged.CaseCollisions, &top.CaseCollisions},
+		{&merged.SymlinkStyle, &top.SymlinkStyle},
+		{&merged.GitLockWait, &top.GitLockWait},
+		{&merged.GitTimeout, &top.GitTimeout},
+		{&merged.GitRemoteTimeout, &top.GitRemoteTimeout},
+		{&merged.ArchiveDir, &top.ArchiveDir},
+		{&merged.SubmoduleRefDir, &top.SubmoduleRefDir},
+		{&merged.NotifyAfter, &top.NotifyAfter},
+	} {
+		if *s.src != "" {
+			*s.dst = *s.src
+		}
+	}
+	for _, b := range []struct{ dst, src **bool }{
+		{&merged.InitSubmodules, &top.InitSubmodules},
+		{&merged.SubmoduleReference, &top.SubmoduleReference},
+		{&merged.SubmoduleRecursive, &top.SubmoduleRecursive},
+		{&merged.LookupRemoteBranches, &top.LookupRemoteBranches},
+		{&merged.FetchOnAdd, &top.FetchOnAdd},
+		{&merged.CleanStale, &top.CleanStale},
+		{&merged.CleanFetch, &top.CleanFetch},
+		{&merged.CleanOnlyTwigManaged, &top.CleanOnlyTwigManaged},
+		{&merged.CleanupEmptyDirs, &top.CleanupEmptyDirs},
+		{&merged.DetectSquashMerges, &top.DetectSquashMerges},
+		{&merged.StrictSymlinks, &top.StrictSymlinks},
+		{&merged.Notify, &top.Notify},
+		{&merged.EnvFile, &top.EnvFile},
+	} {
+		if *b.src != nil {
+			*b.dst = *b.src
+		}
+	}
+	return &merged
+}
@@ -0,0 +1,111 @@
+package core
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestWorktreeEnv(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		branch string
+		path   string
+		isMain bool
+		want   []string
+	}{
+		{
+			name:   "linked worktree",
+			branch: "feat/login",
+			path:   "/repo/main-worktree/feat/login",
+			want: []string{
+				"TWIG_BRANCH=feat/login",
+				"TWIG_WORKTREE_PATH=/repo/main-worktree/feat/login",
+				"TWIG_IS_MAIN=0",
+			},
+		},
+		{
+			name:   "main worktree",
+			branch: "main",
+			path:   "/repo/main",
+			isMain: true,
+			want: []string{
+				"TWIG_BRANCH=main",
+				"TWIG_WORKTREE_PATH=/repo/main",
+				"TWIG_IS_MAIN=1",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			env := worktreeEnv(tt.branch, tt.path, tt.isMain)
+
+			// TWIG_* variables come last so they override inherited values
+			if got := env[len(env)-len(tt.want):]; !slices.Equal(got, tt.want) {
+				t.Errorf("worktreeEnv() tail = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// mapGetenv returns a getenv function reading from vars.
+func mapGetenv(vars map[string]string) func(string) string {
+	return func(name string) string { return vars[name] }
+}
+
+func TestEnvConfig(t *testing.T) {
+	t.Parallel()
+
+	t.Run("unset", func(t *testing.T) {
+		t.Parallel()
+
+		cfg, warnings := envConfig(mapGetenv(nil))
+		if cfg != nil || len(warnings) != 0 {
+			t.Errorf("envConfig() = %+v, %v, want nil", cfg, warnings)
+		}
+	})
+
+	t.Run("values", func(t *testing.T) {
+		t.Parallel()
+
+		cfg, warnings := envConfig(mapGetenv(map[string]string{
+			EnvWorktreeDestBaseDir: "/ci/worktrees",
+			EnvDefaultSource:       "main",
+			EnvInitSubmodules:      "1",
+			EnvStrictSymlinks:      "false",
+			EnvGitLockWait:         "5s",
+		}))
+		if len(warnings) != 0 {
+			t.Errorf("warnings = %v, want none", warnings)
+		}
+		if cfg.WorktreeDestBaseDir != "/ci/worktrees" || cfg.DefaultSource != "main" || cfg.GitLockWait != "5s" {
+			t.Errorf("string settings = %+v", cfg)
+		}
+		if cfg.InitSubmodules == nil || !*cfg.InitSubmodules {
+			t.Errorf("InitSubmodules = %v, want true", cfg.InitSubmodules)
+		}
+		if cfg.StrictSymlinks == nil || *cfg.StrictSymlinks {
+			t.Errorf("StrictSymlinks = %v, want false", cfg.StrictSymlinks)
+		}
+		if cfg.CleanStale != nil {
+			t.Errorf("CleanStale = %v, want nil", cfg.CleanStale)
+		}
+	})
+
+	t.Run("invalid bool", func(t *testing.T) {
+		t.Parallel()
+
+		cfg, warnings := envConfig(mapGetenv(map[string]string{EnvCleanStale: "yes"}))
+		if cfg != nil {
+			t.Errorf("cfg = %+v, want nil", cfg)
+		}
+		want := `invalid TWIG_CLEAN_STALE "yes" (use true or false), ignored`
+		if !slices.Equal(warnings, []string{want}) {
+			t.Errorf("warnings = %v, want [%s]", warnings, want)
+		}
+	})
+}
@@ -0,0 +1,72 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+const (
+	// EnvFileName is the file written at the root of each worktree when
+	// env_file is enabled, for tools such as direnv (dotenv) or mise
+	// (env._.file) to load.
+	EnvFileName = ".twig.env"
+
+	// EnvSourcePath is set in the env file to the worktree symlinks point
+	// into: the source of twig add, or of the last twig sync.
+	EnvSourcePath = "TWIG_SOURCE_PATH"
+)
+
+// envFileHeader starts every generated env file.
+const envFileHeader = "# Generated by twig (env_file). Changes are overwritten by twig add and twig sync;\n" +
+	"# set extra variables with env_file_vars instead.\n"
+
+// envVarName matches a valid environment variable name.
+var envVarName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// envFileContent returns the env file for a worktree: the TWIG_* variables
+// describing it, followed by vars sorted by name.
+func envFileContent(branch, wtPath, sourcePath string, vars map[string]string) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(envFileHeader)
+	writeEnvLine(&buf, EnvBranch, branch)
+	writeEnvLine(&buf, EnvWorktreePath, wtPath)
+	writeEnvLine(&buf, EnvSourcePath, sourcePath)
+	for _, name := range slices.Sorted(maps.Keys(vars)) {
+		writeEnvLine(&buf, name, vars[name])
+	}
+	return buf.Bytes()
+}
+
+// writeEnvLine writes NAME="value", escaped so that dotenv parsers read
+// the value back unchanged. Values without newlines also read the same
+// when the file is sourced by a POSIX shell.
+func writeEnvLine(buf *bytes.Buffer, name, value string) {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `\$`, "`", "\\`", "\n", `\n`)
+	fmt.Fprintf(buf, "%s=\"%s\"\n", name, r.Replace(value))
+}
+
+// writeEnvFile writes content to the env file of the worktree at wtPath
+// unless it already matches. Returns the file path and whether it was
+// (or, with dryRun, would be) written.
+func writeEnvFile(fs FileSystem, wtPath string, content []byte, dryRun bool) (string, bool, error) {
+	path := filepath.Join(wtPath, EnvFileName)
+	existing, err := fs.ReadFile(path)
+	if err == nil && bytes.Equal(existing, content) {
+		return path, false, nil
+	}
+	if err != nil && !fs.IsNotExist(err) {
+		return path, false, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if dryRun {
+		return path, true, nil
+	}
+	if err := fs.WriteFile(path, content, 0644); err != nil {
+		return path, false, fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return path, true, nil
+}
@@ -0,0 +1,79 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEnvFileContent(t *testing.T) {
+	t.Parallel()
+
+	got := string(envFileContent("feat/x", "/wt/feat/x", "/repo/main", map[string]string{
+		"PORT":    "3001",
+		"DB_NAME": `app "dev" $USER`,
+		"NOTE":    "a\\b\nc",
+	}))
+
+	want := envFileHeader +
+		"TWIG_BRANCH=\"feat/x\"\n" +
+		"TWIG_WORKTREE_PATH=\"/wt/feat/x\"\n" +
+		"TWIG_SOURCE_PATH=\"/repo/main\"\n" +
+		"DB_NAME=\"app \\\"dev\\\" \\$USER\"\n" +
+		"NOTE=\"a\\\\b\\nc\"\n" +
+		"PORT=\"3001\"\n"
+	if got != want {
+		t.Errorf("envFileContent() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestWriteEnvFile(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	content := envFileContent("feat/x", dir, "/repo/main", nil)
+
+	tests := []struct {
+		name        string
+		content     []byte
+		dryRun      bool
+		wantWritten bool
+		wantFile    []byte
+	}{
+		{name: "dry run on missing file", content: content, dryRun: true, wantWritten: true},
+		{name: "creates file", content: content, wantWritten: true, wantFile: content},
+		{name: "unchanged", content: content, wantWritten: false, wantFile: content},
+		{name: "dry run on stale file", content: []byte("changed\n"), dryRun: true, wantWritten: true, wantFile: content},
+	}
+
+	// Steps share the file, so they run in order
+	for _, tt := range tests {
+		path, written, err := writeEnvFile(osFS{}, dir, tt.content, tt.dryRun)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if path != filepath.Join(dir, EnvFileName) {
+			t.Errorf("%s: path = %q", tt.name, path)
+		}
+		if written != tt.wantWritten {
+			t.Errorf("%s: written = %v, want %v", tt.name, written, tt.wantWritten)
+		}
+		data, err := os.ReadFile(path)
+		if tt.wantFile == nil {
+			if !os.IsNotExist(err) {
+				t.Errorf("%s: file should not exist, err = %v", tt.name, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if string(data) != string(tt.wantFile) {
+			t.Errorf("%s: file = %q, want %q", tt.name, data, tt.wantFile)
+		}
+		if !strings.HasPrefix(string(data), "# Generated by twig") {
+			t.Errorf("%s: file has no header", tt.name)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"time"
+)
+
+// StateVersion is the version of the twig export format. twig import
+// rejects files with a newer version.
+const StateVersion = 1
+
+// State describes the worktrees of a repository, written by twig export
+// and read by twig import to recreate them on another machine.
+type State struct {
+	Version    int             `json:"version"`
+	ExportedAt time.Time       `json:"exported_at"`
+	Worktrees  []StateWorktree `json:"worktrees"`
+}
+
+// StateWorktree is a linked worktree in the export.
+type StateWorktree struct {
+	Branch string `json:"branch,omitempty"` // Empty for detached worktrees
+	// Path is relative to worktree_destination_base_dir, with forward
+	// slashes, so the layout survives a different base directory. Paths
+	// outside the base directory start with "../".
+	Path       string `json:"path"`
+	HEAD       string `json:"head"`
+	Locked     bool   `json:"locked,omitempty"`
+	LockReason string `json:"lock_reason,omitempty"`
+	Note       string `json:"note,omitempty"`
+}
+
+// ExportCommand captures the linked worktrees of a repository: their
+// branches, layout below the destination base directory, locks and notes.
+type ExportCommand struct {
+	FS     FileSystem
+	Git    *GitRunner
+	Config *Config
+	Log    *slog.Logger
+}
+
+// NewExportCommand creates an ExportCommand with explicit dependencies.
+func NewExportCommand(fs FileSystem, git *GitRunner, cfg *Config, log *slog.Logger) *ExportCommand {
+	if log == nil {
+		log = NewNopLogger()
+	}
+	return &ExportCommand{
+		FS:     fs,
+		Git:    git,
+		Config: cfg,
+		Log:    log,
+	}
+}
+
+// NewDefaultExportCommand creates an ExportCommand with production dependencies.
+func NewDefaultExportCommand(cfg *Config, log *slog.Logger, gitOpts ...GitRunnerOption) *ExportCommand {
+	git := newDefaultGitRunner(cfg.WorktreeSourceDir, log, gitOpts)
+	return NewExportCommand(git.defaultFS(), git, cfg, log)
+}
+
+// Run exports the linked worktrees. The main worktree, bare entries and
+// prunable worktrees (whose directory is gone) are left out.
+func (c *ExportCommand) Run(ctx context.Context) (State, error) {
+	worktrees, err := c.Git.WorktreeList(ctx)
+	if err != nil {
+		return State{}, err
+	}
+	notes := noteTexts(ctx, NewNoteStore(c.FS, c.Git), c.Log)
+
+	state := State{
+		Version:    StateVersion,
+		ExportedAt: time.Now().UTC().Truncate(time.Second),
+		Worktrees:  []StateWorktree{},
+	}
+	for i, wt := range worktrees {
+		if i == 0 || wt.Bare || wt.Prunable {
+			continue
+		}
+		entry := StateWorktree{
+			Branch:     wt.Branch,
+			Path:       c.relativePath(wt.Path),
+			HEAD:       wt.HEAD,
+			Locked:     wt.Locked,
+			LockReason: wt.LockReason,
+		}
+		if wt.Branch != "" {
+			entry.Note = notes[wt.Branch]
+		}
+		state.Worktrees = append(state.Worktrees, entry)
+	}
+
+	c.Log.DebugContext(ctx, "exported worktrees",
+		LogAttrKeyCategory.String(), LogCategoryExport,
+		"count", len(state.Worktrees))
+	return state, nil
+}
+
+// relativePath returns path relative to the destination base directory,
+// or path itself when it has no relative form.
+func (c *ExportCommand) relativePath(path string) string {
+	rel, err := filepath.Rel(c.Config.WorktreeDestBaseDir, path)
+	if err != nil || c.Config.WorktreeDestBaseDir == "" {
+		return filepath.ToSlash(path)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// ParseState decodes an export written by twig export.
+func ParseState(data []byte) (State, error) {
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return State{}, fmt.Errorf("invalid export: %w", err)
+	}
+	if state.Version == 0 || state.Version > StateVersion {
+		return State{}, fmt.Errorf("unsupported export version %d (supported: %d)", state.Version, StateVersion)
+	}
+	return state, nil
+}
@@ -0,0 +1,102 @@
+package core
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/708u/twig/internal/testutil"
+)
+
+func TestExportCommand_Run(t *testing.T) {
+	t.Parallel()
+
+	mockGit := &testutil.MockGitExecutor{
+		GitCommonDir: "/repo/main/.git",
+		Worktrees: []testutil.MockWorktree{
+			{Path: "/repo/main", Branch: "main"},
+			{Path: "/repo/worktrees/feat/a", Branch: "feat/a", HEAD: "aaa111", Locked: true, LockReason: "on usb"},
+			{Path: "/repo/worktrees/scratch", HEAD: "bbb222", Detached: true},
+			{Path: "/repo/elsewhere/b", Branch: "feat/b", HEAD: "ccc333"},
+			{Path: "/repo/worktrees/gone", Branch: "gone", Prunable: true},
+		},
+	}
+	mockFS := &testutil.MockFS{
+		ReadFileResults: map[string][]byte{
+			"/repo/main/.git/twig/notes.json": []byte(`{"feat/a": {"text": "waiting for review"}}`),
+		},
+	}
+
+	cmd := NewExportCommand(mockFS, &GitRunner{Executor: mockGit, Dir: "/repo/main", Log: NewNopLogger()},
+		&Config{WorktreeDestBaseDir: "/repo/worktrees"}, nil)
+	state, err := cmd.Run(t.Context())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if state.Version != StateVersion {
+		t.Errorf("Version = %d, want %d", state.Version, StateVersion)
+	}
+	want := []StateWorktree{
+		{Branch: "feat/a", Path: "feat/a", HEAD: "aaa111", Locked: true, LockReason: "on usb", Note: "waiting for review"},
+		{Path: "scratch", HEAD: "bbb222"},
+		{Branch: "feat/b", Path: "../elsewhere/b", HEAD: "ccc333"},
+	}
+	if len(state.Worktrees) != len(want) {
+		t.Fatalf("Worktrees = %+v, want %+v", state.Worktrees, want)
+	}
+	for i := range want {
+		if state.Worktrees[i] != want[i] {
+			t.Errorf("Worktrees[%d] = %+v, want %+v", i, state.Worktrees[i], want[i])
+		}
+	}
+}
+
+func TestParseState(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		data    string
+		wantErr string
+	}{
+		{
+			name: "valid",
+			data: `{"version": 1, "worktrees": [{"branch": "feat/a", "path": "feat/a", "head": "aaa111"}]}`,
+		},
+		{
+			name:    "newer version",
+			data:    `{"version": 2, "worktrees": []}`,
+			wantErr: "unsupported export version 2",
+		},
+		{
+			name:    "missing version",
+			data:    `{"worktrees": []}`,
+			wantErr: "unsupported export version 0",
+		},
+		{
+			name:    "not json",
+			data:    `worktrees: []`,
+			wantErr: "invalid export",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			state, err := ParseState([]byte(tt.data))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("ParseState() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(state.Worktrees) != 1 || state.Worktrees[0].Branch != "feat/a" {
+				t.Errorf("Worktrees = %+v", state.Worktrees)
+			}
+		})
+	}
+}
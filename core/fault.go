@@ -0,0 +1,317 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"math/rand/v2"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Fault operation names are "git.<subcommand>" (with the action for
+// subcommands that have one, e.g. "git.worktree.add", "git.stash.push")
+// and "fs.<method>" (lowercase FileSystem method, e.g. "fs.symlink").
+const (
+	FaultOpGitPrefix = "git."
+	FaultOpFSPrefix  = "fs."
+)
+
+// gitSubcommandsWithAction are git commands whose first argument selects
+// the action and is part of the fault operation name.
+var gitSubcommandsWithAction = map[string]bool{
+	GitCmdWorktree:       true,
+	GitCmdStash:          true,
+	GitCmdSubmodule:      true,
+	GitCmdRemote:         true,
+	GitCmdSparseCheckout: true,
+}
+
+// FaultRule makes matching operations fail.
+type FaultRule struct {
+	Op   string  // Operation name or path.Match pattern (e.g. "git.worktree.*")
+	Nth  int     // Fail only the Nth matching call (1-based); 0 uses Rate
+	Rate float64 // Probability of failing each matching call (0..1)
+}
+
+// FaultProfile injects failures into git and filesystem operations so that
+// rollback and recovery paths can be exercised. It is used by tests and by
+// the hidden --chaos flag; a nil profile injects nothing.
+type FaultProfile struct {
+	Rules []FaultRule
+
+	mu     sync.Mutex
+	rand   *rand.Rand
+	counts map[int]int // Matching calls seen per rule index
+}
+
+// FaultError is returned by operations failed by a FaultProfile.
+type FaultError struct {
+	Op   string
+	Call int // 1-based count of calls matching the rule
+}
+
+func (e *FaultError) Error() string {
+	return fmt.Sprintf("injected fault: %s (call %d)", e.Op, e.Call)
+}
+
+// ExitCode reports git's generic failure status, so that injected git
+// faults are handled like a failed git command.
+func (e *FaultError) ExitCode() int {
+	return 128
+}
+
+// NewFaultProfile creates a profile whose probabilistic rules draw from a
+// generator seeded with seed, so that a run can be reproduced.
+func NewFaultProfile(seed uint64, rules ...FaultRule) *FaultProfile {
+	return &FaultProfile{
+		Rules:  rules,
+		rand:   rand.New(rand.NewPCG(seed, seed)),
+		counts: make(map[int]int),
+	}
+}
+
+// ParseFaultProfile parses a comma-separated fault spec:
+//
+//	git.apply@1         fail the first git apply
+//	fs.symlink=0.5      fail half of the symlink calls
+//	git.worktree.*@2    fail the second git worktree command of any kind
+//	seed=42             seed for probabilistic rules (default 1)
+func ParseFaultProfile(spec string) (*FaultProfile, error) {
+	var rules []FaultRule
+	seed := uint64(1)
+	for field := range strings.SplitSeq(spec, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		if value, ok := strings.CutPrefix(field, "seed="); ok {
+			n, err := strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid fault seed %q", value)
+			}
+			seed = n
+			continue
+		}
+
+		var rule FaultRule
+		if op, nth, ok := strings.Cut(field, "@"); ok {
+			n, err := strconv.Atoi(nth)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("invalid fault call number in %q", field)
+			}
+			rule = FaultRule{Op: op, Nth: n}
+		} else if op, rate, ok := strings.Cut(field, "="); ok {
+			r, err := strconv.ParseFloat(rate, 64)
+			if err != nil || r < 0 || r > 1 {
+				return nil, fmt.Errorf("invalid fault rate in %q (use 0..1)", field)
+			}
+			rule = FaultRule{Op: op, Rate: r}
+		} else {
+			rule = FaultRule{Op: field, Rate: 1}
+		}
+		if !strings.HasPrefix(rule.Op, FaultOpGitPrefix) && !strings.HasPrefix(rule.Op, FaultOpFSPrefix) {
+			return nil, fmt.Errorf("invalid fault operation %q (use git.<command> or fs.<method>)", rule.Op)
+		}
+		if _, err := path.Match(rule.Op, ""); err != nil {
+			return nil, fmt.Errorf("invalid fault operation %q: %w", rule.Op, err)
+		}
+		rules = append(rules, rule)
+	}
+	if len(rules) == 0 {
+		return nil, fmt.Errorf("fault spec has no rules")
+	}
+	return NewFaultProfile(seed, rules...), nil
+}
+
+// Check returns a FaultError if op should fail. Every rule matching op
+// counts the call, so that Nth rules stay predictable when rules overlap.
+func (p *FaultProfile) Check(op string) error {
+	if p == nil {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.counts == nil {
+		p.counts = make(map[int]int)
+	}
+	if p.rand == nil {
+		p.rand = rand.New(rand.NewPCG(1, 1))
+	}
+
+	var fault error
+	for i, rule := range p.Rules {
+		if ok, _ := path.Match(rule.Op, op); !ok {
+			continue
+		}
+		p.counts[i]++
+		call := p.counts[i]
+		fail := call == rule.Nth
+		if rule.Nth == 0 {
+			fail = p.rand.Float64() < rule.Rate
+		}
+		if fail && fault == nil {
+			fault = &FaultError{Op: op, Call: call}
+		}
+	}
+	return fault
+}
+
+// WrapGitExecutor returns e with faults injected before git runs.
+// A nil profile returns e unchanged.
+func (p *FaultProfile) WrapGitExecutor(e GitExecutor) GitExecutor {
+	if p == nil {
+		return e
+	}
+	return faultGitExecutor{inner: e, faults: p}
+}
+
+// WrapFS returns fsys with faults injected before each operation.
+// A nil profile returns fsys unchanged.
+func (p *FaultProfile) WrapFS(fsys FileSystem) FileSystem {
+	if p == nil {
+		return fsys
+	}
+	return faultFS{inner: fsys, faults: p}
+}
+
+// gitFaultOp returns the fault operation name for git args, skipping
+// leading -C <dir> and -c <name>=<value> options.
+func gitFaultOp(args []string) string {
+	args = gitSubcommandArgs(args)
+	if len(args) == 0 {
+		return FaultOpGitPrefix
+	}
+	op := FaultOpGitPrefix + args[0]
+	if gitSubcommandsWithAction[args[0]] && len(args) > 1 && !strings.HasPrefix(args[1], "-") {
+		op += "." + args[1]
+	}
+	return op
+}
+
+type faultGitExecutor struct {
+	inner  GitExecutor
+	faults *FaultProfile
+}
+
+func (e faultGitExecutor) Run(ctx context.Context, args ...string) ([]byte, error) {
+	if err := e.faults.Check(gitFaultOp(args)); err != nil {
+		return nil, err
+	}
+	return e.inner.Run(ctx, args...)
+}
+
+// faultFS fails mutating and reading operations; IsNotExist only
+// classifies errors and is never failed.
+type faultFS struct {
+	inner  FileSystem
+	faults *FaultProfile
+}
+
+func (f faultFS) Stat(name string) (fs.FileInfo, error) {
+	if err := f.faults.Check(FaultOpFSPrefix + "stat"); err != nil {
+		return nil, err
+	}
+	return f.inner.Stat(name)
+}
+
+func (f faultFS) Lstat(name string) (fs.FileInfo, error) {
+	if err := f.faults.Check(FaultOpFSPrefix + "lstat"); err != nil {
+		return nil, err
+	}
+	return f.inner.Lstat(name)
+}
+
+func (f faultFS) Symlink(oldname, newname string) error {
+	if err := f.faults.Check(FaultOpFSPrefix + "symlink"); err != nil {
+		return err
+	}
+	return f.inner.Symlink(oldname, newname)
+}
+
+func (f faultFS) Readlink(name string) (string, error) {
+	if err := f.faults.Check(FaultOpFSPrefix + "readlink"); err != nil {
+		return "", err
+	}
+	return f.inner.Readlink(name)
+}
+
+func (f faultFS) IsNotExist(err error) bool { return f.inner.IsNotExist(err) }
+
+func (f faultFS) Glob(dir, pattern string) ([]string, error) {
+	if err := f.faults.Check(FaultOpFSPrefix + "glob"); err != nil {
+		return nil, err
+	}
+	return f.inner.Glob(dir, pattern)
+}
+
+func (f faultFS) MkdirAll(path string, perm fs.FileMode) error {
+	if err := f.faults.Check(FaultOpFSPrefix + "mkdirall"); err != nil {
+		return err
+	}
+	return f.inner.MkdirAll(path, perm)
+}
+
+func (f faultFS) ReadDir(name string) ([]os.DirEntry, error) {
+	if err := f.faults.Check(FaultOpFSPrefix + "readdir"); err != nil {
+		return nil, err
+	}
+	return f.inner.ReadDir(name)
+}
+
+func (f faultFS) Remove(name string) error {
+	if err := f.faults.Check(FaultOpFSPrefix + "remove"); err != nil {
+		return err
+	}
+	return f.inner.Remove(name)
+}
+
+func (f faultFS) RemoveAll(path string) error {
+	if err := f.faults.Check(FaultOpFSPrefix + "removeall"); err != nil {
+		return err
+	}
+	return f.inner.RemoveAll(path)
+}
+
+func (f faultFS) Rename(oldpath, newpath string) error {
+	if err := f.faults.Check(FaultOpFSPrefix + "rename"); err != nil {
+		return err
+	}
+	return f.inner.Rename(oldpath, newpath)
+}
+
+func (f faultFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	if err := f.faults.Check(FaultOpFSPrefix + "writefile"); err != nil {
+		return err
+	}
+	return f.inner.WriteFile(name, data, perm)
+}
+
+func (f faultFS) AppendFile(name string, data []byte, perm fs.FileMode) error {
+	if err := f.faults.Check(FaultOpFSPrefix + "appendfile"); err != nil {
+		return err
+	}
+	return f.inner.AppendFile(name, data, perm)
+}
+
+func (f faultFS) ReadFile(name string) ([]byte, error) {
+	if err := f.faults.Check(FaultOpFSPrefix + "readfile"); err != nil {
+		return nil, err
+	}
+	return f.inner.ReadFile(name)
+}
+
+// newDefaultFS returns the production filesystem for a NewDefault*
+// constructor that runs no git, with the faults of WithFaults in opts
+// injected.
+func newDefaultFS(opts []GitRunnerOption) FileSystem {
+	var o gitRunnerOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o.faults.WrapFS(osFS{})
+}
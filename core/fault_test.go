@@ -0,0 +1,199 @@
+package core
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/708u/twig/internal/testutil"
+)
+
+func TestParseFaultProfile(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		spec    string
+		want    []FaultRule
+		wantErr string
+	}{
+		{
+			name: "nth_call",
+			spec: "git.apply@2",
+			want: []FaultRule{{Op: "git.apply", Nth: 2}},
+		},
+		{
+			name: "rate_and_pattern",
+			spec: "fs.symlink=0.5, git.worktree.*",
+			want: []FaultRule{
+				{Op: "fs.symlink", Rate: 0.5},
+				{Op: "git.worktree.*", Rate: 1},
+			},
+		},
+		{
+			name:    "seed_only_is_empty",
+			spec:    "seed=3",
+			wantErr: "fault spec has no rules",
+		},
+		{
+			name:    "unknown_operation_kind",
+			spec:    "net.dial",
+			wantErr: `invalid fault operation "net.dial"`,
+		},
+		{
+			name:    "zero_call",
+			spec:    "git.apply@0",
+			wantErr: "invalid fault call number",
+		},
+		{
+			name:    "rate_out_of_range",
+			spec:    "git.apply=2",
+			wantErr: "invalid fault rate",
+		},
+		{
+			name:    "bad_pattern",
+			spec:    "git.[",
+			wantErr: "invalid fault operation",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ParseFaultProfile(tt.spec)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got.Rules) != len(tt.want) {
+				t.Fatalf("Rules = %+v, want %+v", got.Rules, tt.want)
+			}
+			for i := range tt.want {
+				if got.Rules[i] != tt.want[i] {
+					t.Errorf("Rules[%d] = %+v, want %+v", i, got.Rules[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestFaultProfile_WrapGitExecutor(t *testing.T) {
+	t.Parallel()
+
+	faults := NewFaultProfile(1, FaultRule{Op: "git.worktree.add", Nth: 2})
+	var captured []string
+	git := &GitRunner{
+		Executor: faults.WrapGitExecutor(&testutil.MockGitExecutor{CapturedArgs: &captured}),
+		Dir:      "/repo/main",
+		Log:      NewNopLogger(),
+	}
+
+	if _, err := git.Run(t.Context(), "worktree", "add", "/repo/a"); err != nil {
+		t.Fatalf("first worktree add failed: %v", err)
+	}
+	// Other operations do not count toward the rule
+	if _, err := git.Run(t.Context(), "worktree", "list", "--porcelain"); err != nil {
+		t.Fatalf("worktree list failed: %v", err)
+	}
+
+	_, err := git.Run(t.Context(), "worktree", "add", "/repo/b")
+	var fault *FaultError
+	if !errors.As(err, &fault) {
+		t.Fatalf("second worktree add err = %v, want FaultError", err)
+	}
+	if fault.Op != "git.worktree.add" || fault.Call != 2 {
+		t.Errorf("fault = %+v, want git.worktree.add call 2", fault)
+	}
+	if fault.ExitCode() == 0 {
+		t.Error("ExitCode() = 0, want failure status")
+	}
+	if slices.Contains(captured, "/repo/b") {
+		t.Error("failed git command was executed")
+	}
+
+	if _, err := git.Run(t.Context(), "worktree", "add", "/repo/c"); err != nil {
+		t.Fatalf("third worktree add failed: %v", err)
+	}
+}
+
+func TestFaultProfile_WrapFS(t *testing.T) {
+	t.Parallel()
+
+	faults := NewFaultProfile(1, FaultRule{Op: "fs.symlink", Rate: 1})
+	fsys := faults.WrapFS(&testutil.MockFS{})
+
+	if err := fsys.Symlink("/src", "/dst"); err == nil {
+		t.Error("Symlink succeeded, want injected fault")
+	}
+	if err := fsys.MkdirAll("/dir", 0755); err != nil {
+		t.Errorf("MkdirAll failed: %v", err)
+	}
+}
+
+func TestFaultProfile_Rate_Reproducible(t *testing.T) {
+	t.Parallel()
+
+	run := func(seed uint64) []bool {
+		faults := NewFaultProfile(seed, FaultRule{Op: "git.*", Rate: 0.5})
+		var failed []bool
+		for range 32 {
+			failed = append(failed, faults.Check("git.status") != nil)
+		}
+		return failed
+	}
+
+	a, b := run(7), run(7)
+	var failures int
+	for i := range a {
+		if a[i] != b[i] {
+			t.Fatalf("call %d differs between runs with the same seed", i+1)
+		}
+		if a[i] {
+			failures++
+		}
+	}
+	if failures == 0 || failures == len(a) {
+		t.Errorf("failures = %d of %d, want some but not all", failures, len(a))
+	}
+}
+
+func TestFaultProfile_Nil(t *testing.T) {
+	t.Parallel()
+
+	var faults *FaultProfile
+	if err := faults.Check("git.apply"); err != nil {
+		t.Errorf("Check() = %v, want nil", err)
+	}
+	mock := &testutil.MockGitExecutor{}
+	if got := faults.WrapGitExecutor(mock); got != GitExecutor(mock) {
+		t.Error("nil profile wrapped the executor")
+	}
+}
+
+func TestGitFaultOp(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"-C", "/repo", "apply", "--3way", "p"}, "git.apply"},
+		{[]string{"-C", "/repo", "worktree", "remove", "/wt"}, "git.worktree.remove"},
+		{[]string{"stash", "push", "-m", "x"}, "git.stash.push"},
+		{[]string{"submodule", "--quiet", "update"}, "git.submodule"},
+		{[]string{"rev-parse", "HEAD"}, "git.rev-parse"},
+	}
+
+	for _, tt := range tests {
+		if got := gitFaultOp(tt.args); got != tt.want {
+			t.Errorf("gitFaultOp(%q) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
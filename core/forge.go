@@ -0,0 +1,385 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Supported forge names for the forge setting.
+const (
+	ForgeGitHub = "github"
+	ForgeGitLab = "gitlab"
+)
+
+// SupportedForges lists the valid values of the forge setting.
+var SupportedForges = []string{ForgeGitHub, ForgeGitLab}
+
+// forgeTokenEnv lists the environment variables read for each forge's API token.
+var forgeTokenEnv = map[string][]string{
+	ForgeGitHub: {"GITHUB_TOKEN", "GH_TOKEN"},
+	ForgeGitLab: {"GITLAB_TOKEN"},
+}
+
+const (
+	// forgeCacheFileName is stored under <git-common-dir>/twig.
+	forgeCacheFileName = "forge-cache.json"
+
+	// DefaultForgeCacheTTL is how long a looked-up PR state is reused.
+	DefaultForgeCacheTTL = 10 * time.Minute
+
+	// forgeRemote is the remote whose URL identifies the forge repository.
+	forgeRemote = "origin"
+
+	forgeHTTPTimeout = 10 * time.Second
+)
+
+// PRState is the state of a branch's pull request (merge request on GitLab).
+type PRState string
+
+const (
+	PRStateNone   PRState = ""
+	PRStateOpen   PRState = "open"
+	PRStateMerged PRState = "merged"
+	PRStateClosed PRState = "closed"
+)
+
+// forgeCacheEntry caches the PR state of one branch.
+// The entry is invalidated when the branch HEAD moves or the TTL expires.
+type forgeCacheEntry struct {
+	HEAD       string    `json:"head"`
+	State      PRState   `json:"state"`
+	ComputedAt time.Time `json:"computed_at"`
+}
+
+// ForgeClient looks up pull request states on GitHub or GitLab.
+// Results are cached per repository to avoid repeated API calls.
+type ForgeClient struct {
+	FS      FileSystem
+	Git     *GitRunner
+	Log     *slog.Logger
+	HTTP    *http.Client
+	Kind    string        // ForgeGitHub or ForgeGitLab
+	Token   string        // API token (empty = unauthenticated)
+	BaseURL string        // API base URL (empty = derived from the origin remote)
+	TTL     time.Duration // Cache lifetime (0 = DefaultForgeCacheTTL)
+}
+
+// NewForgeClient creates a ForgeClient with explicit dependencies.
+func NewForgeClient(fs FileSystem, git *GitRunner, kind, token string, log *slog.Logger) *ForgeClient {
+	if log == nil {
+		log = NewNopLogger()
+	}
+	return &ForgeClient{
+		FS:    fs,
+		Git:   git,
+		Log:   log,
+		HTTP:  &http.Client{Timeout: forgeHTTPTimeout},
+		Kind:  kind,
+		Token: token,
+	}
+}
+
+// NewDefaultForgeClient creates a ForgeClient for cfg.Forge with the token
+// read from the environment. Returns nil when no forge is configured.
+func NewDefaultForgeClient(cfg *Config, fs FileSystem, git *GitRunner, log *slog.Logger) *ForgeClient {
+	if cfg.Forge == "" {
+		return nil
+	}
+	var token string
+	for _, env := range forgeTokenEnv[cfg.Forge] {
+		if token = os.Getenv(env); token != "" {
+			break
+		}
+	}
+	return NewForgeClient(fs, git, cfg.Forge, token, log)
+}
+
+// PRStates returns the PR state of each branch in heads (branch -> HEAD).
+// A PR only counts when its head commit equals the local HEAD, so local
+// commits added after the PR never look merged. Branches without such a
+// PR are omitted. The returned error reports the first lookup failure;
+// states that were resolved are still returned.
+func (f *ForgeClient) PRStates(ctx context.Context, heads map[string]string, now time.Time) (map[string]PRState, error) {
+	states := make(map[string]PRState)
+	if len(heads) == 0 {
+		return states, nil
+	}
+
+	ttl := f.TTL
+	if ttl == 0 {
+		ttl = DefaultForgeCacheTTL
+	}
+
+	cachePath, cache := f.loadCache(ctx)
+
+	stale := make(map[string]string)
+	for branch, head := range heads {
+		if entry, ok := cache[branch]; ok && entry.HEAD == head && now.Sub(entry.ComputedAt) < ttl {
+			if entry.State != PRStateNone {
+				states[branch] = entry.State
+			}
+			continue
+		}
+		stale[branch] = head
+	}
+	if len(stale) == 0 {
+		return states, nil
+	}
+
+	baseURL, repo, err := f.resolveRepo(ctx)
+	if err != nil {
+		return states, err
+	}
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		firstErr error
+	)
+	for branch, head := range stale {
+		wg.Add(1)
+		go func(branch, head string) {
+			defer wg.Done()
+			state, err := f.lookup(ctx, baseURL, repo, branch, head)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				f.Log.DebugContext(ctx, "PR lookup failed",
+					LogAttrKeyCategory.String(), LogCategoryForge,
+					"branch", branch,
+					"error", err.Error())
+				if firstErr == nil {
+					firstErr = err
+				}
+				return
+			}
+			if state != PRStateNone {
+				states[branch] = state
+			}
+			cache[branch] = forgeCacheEntry{HEAD: head, State: state, ComputedAt: now}
+		}(branch, head)
+	}
+	wg.Wait()
+
+	f.Log.DebugContext(ctx, "PR states resolved",
+		LogAttrKeyCategory.String(), LogCategoryForge,
+		"branches", len(heads),
+		"fetched", len(stale))
+
+	if cachePath != "" {
+		f.saveCache(ctx, cachePath, cache)
+	}
+	return states, firstErr
+}
+
+// resolveRepo returns the API base URL and the repository path
+// ("owner/repo", or "group/subgroup/repo" on GitLab) of the origin remote.
+func (f *ForgeClient) resolveRepo(ctx context.Context) (string, string, error) {
+	rawURL, err := f.Git.RemoteURL(ctx, forgeRemote)
+	if err != nil {
+		return "", "", err
+	}
+	host, repo, err := parseRemoteURL(rawURL)
+	if err != nil {
+		return "", "", err
+	}
+	if f.BaseURL != "" {
+		return strings.TrimSuffix(f.BaseURL, "/"), repo, nil
+	}
+	switch f.Kind {
+	case ForgeGitHub:
+		if host == "github.com" {
+			return "https://api.github.com", repo, nil
+		}
+		return "https://" + host + "/api/v3", repo, nil
+	case ForgeGitLab:
+		return "https://" + host + "/api/v4", repo, nil
+	}
+	return "", "", fmt.Errorf("unsupported forge %q", f.Kind)
+}
+
+// parseRemoteURL extracts the host and repository path from a remote URL.
+// Supports https://host/owner/repo, ssh://git@host:22/owner/repo and
+// the scp-like git@host:owner/repo forms, with or without ".git".
+func parseRemoteURL(rawURL string) (host, repo string, err error) {
+	if strings.Contains(rawURL, "://") {
+		u, err := url.Parse(rawURL)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid remote URL %q: %w", rawURL, err)
+		}
+		host, repo = u.Hostname(), u.Path
+	} else if at, rest, ok := strings.Cut(rawURL, ":"); ok {
+		_, host, _ = strings.Cut(at, "@")
+		if host == "" {
+			host = at
+		}
+		repo = rest
+	}
+	repo = strings.TrimSuffix(strings.Trim(repo, "/"), ".git")
+	if host == "" || !strings.Contains(repo, "/") {
+		return "", "", fmt.Errorf("cannot determine repository from remote URL %q", rawURL)
+	}
+	return host, repo, nil
+}
+
+// lookup fetches the state of the most recent PR for branch.
+// Returns PRStateNone if there is no PR or its head is not head.
+func (f *ForgeClient) lookup(ctx context.Context, baseURL, repo, branch, head string) (PRState, error) {
+	switch f.Kind {
+	case ForgeGitHub:
+		owner, _, _ := strings.Cut(repo, "/")
+		q := url.Values{"state": {"all"}, "head": {owner + ":" + branch}, "per_page": {"1"}}
+		var pulls []struct {
+			State    string  `json:"state"`
+			MergedAt *string `json:"merged_at"`
+			Head     struct {
+				SHA string `json:"sha"`
+			} `json:"head"`
+		}
+		if err := f.getJSON(ctx, baseURL+"/repos/"+repo+"/pulls?"+q.Encode(), &pulls); err != nil {
+			return PRStateNone, err
+		}
+		if len(pulls) == 0 || pulls[0].Head.SHA != head {
+			return PRStateNone, nil
+		}
+		switch {
+		case pulls[0].MergedAt != nil:
+			return PRStateMerged, nil
+		case pulls[0].State == "closed":
+			return PRStateClosed, nil
+		}
+		return PRStateOpen, nil
+	case ForgeGitLab:
+		q := url.Values{"state": {"all"}, "source_branch": {branch}, "per_page": {"1"}}
+		var mrs []struct {
+			State string `json:"state"`
+			SHA   string `json:"sha"`
+		}
+		if err := f.getJSON(ctx, baseURL+"/projects/"+url.PathEscape(repo)+"/merge_requests?"+q.Encode(), &mrs); err != nil {
+			return PRStateNone, err
+		}
+		if len(mrs) == 0 || mrs[0].SHA != head {
+			return PRStateNone, nil
+		}
+		switch mrs[0].State {
+		case "merged":
+			return PRStateMerged, nil
+		case "closed":
+			return PRStateClosed, nil
+		}
+		return PRStateOpen, nil
+	}
+	return PRStateNone, fmt.Errorf("unsupported forge %q", f.Kind)
+}
+
+// PullRequest returns the title and source branch of PR number
+// (merge request IID on GitLab).
+func (f *ForgeClient) PullRequest(ctx context.Context, number int) (title, head string, err error) {
+	baseURL, repo, err := f.resolveRepo(ctx)
+	if err != nil {
+		return "", "", err
+	}
+	switch f.Kind {
+	case ForgeGitHub:
+		var pull struct {
+			Title string `json:"title"`
+			Head  struct {
+				Ref string `json:"ref"`
+			} `json:"head"`
+		}
+		if err := f.getJSON(ctx, fmt.Sprintf("%s/repos/%s/pulls/%d", baseURL, repo, number), &pull); err != nil {
+			return "", "", err
+		}
+		return pull.Title, pull.Head.Ref, nil
+	case ForgeGitLab:
+		var mr struct {
+			Title        string `json:"title"`
+			SourceBranch string `json:"source_branch"`
+		}
+		if err := f.getJSON(ctx, fmt.Sprintf("%s/projects/%s/merge_requests/%d", baseURL, url.PathEscape(repo), number), &mr); err != nil {
+			return "", "", err
+		}
+		return mr.Title, mr.SourceBranch, nil
+	}
+	return "", "", fmt.Errorf("unsupported forge %q", f.Kind)
+}
+
+// getJSON performs an authenticated GET request and decodes the JSON body into v.
+func (f *ForgeClient) getJSON(ctx context.Context, reqURL string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if f.Token != "" {
+		switch f.Kind {
+		case ForgeGitHub:
+			req.Header.Set("Authorization", "Bearer "+f.Token)
+		case ForgeGitLab:
+			req.Header.Set("PRIVATE-TOKEN", f.Token)
+		}
+	}
+
+	resp, err := f.HTTP.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s API request failed: %w", f.Kind, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s API returned %s", f.Kind, resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode %s API response: %w", f.Kind, err)
+	}
+	return nil
+}
+
+// loadCache reads the PR state cache. Any failure yields an empty cache
+// and an empty path when the cache location cannot be resolved.
+func (f *ForgeClient) loadCache(ctx context.Context) (string, map[string]forgeCacheEntry) {
+	cache := make(map[string]forgeCacheEntry)
+
+	commonDir, err := f.Git.GitCommonDir(ctx)
+	if err != nil || commonDir == "" {
+		return "", cache
+	}
+	cachePath := filepath.Join(commonDir, auditDirName, forgeCacheFileName)
+
+	data, err := f.FS.ReadFile(cachePath)
+	if err != nil {
+		return cachePath, cache
+	}
+	if err := json.Unmarshal(data, &cache); err != nil {
+		f.Log.DebugContext(ctx, "ignoring corrupt forge cache",
+			LogAttrKeyCategory.String(), LogCategoryForge,
+			"error", err.Error())
+		return cachePath, make(map[string]forgeCacheEntry)
+	}
+	return cachePath, cache
+}
+
+// saveCache writes the PR state cache. Failures are logged only.
+func (f *ForgeClient) saveCache(ctx context.Context, cachePath string, cache map[string]forgeCacheEntry) {
+	data, err := json.Marshal(cache)
+	if err == nil {
+		err = f.FS.MkdirAll(filepath.Dir(cachePath), 0755)
+	}
+	if err == nil {
+		err = f.FS.WriteFile(cachePath, data, 0644)
+	}
+	if err != nil {
+		f.Log.DebugContext(ctx, "failed to write forge cache",
+			LogAttrKeyCategory.String(), LogCategoryForge,
+			"error", err.Error())
+	}
+}
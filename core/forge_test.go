@@ -0,0 +1,329 @@
+package core
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/708u/twig/internal/testutil"
+)
+
+func TestParseRemoteURL(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		url      string
+		wantHost string
+		wantRepo string
+		wantErr  bool
+	}{
+		{name: "https", url: "https://github.com/708u/twig.git", wantHost: "github.com", wantRepo: "708u/twig"},
+		{name: "https_without_suffix", url: "https://github.com/708u/twig", wantHost: "github.com", wantRepo: "708u/twig"},
+		{name: "scp_like", url: "git@github.com:708u/twig.git", wantHost: "github.com", wantRepo: "708u/twig"},
+		{name: "ssh_with_port", url: "ssh://git@gitlab.example.com:2222/group/sub/app.git", wantHost: "gitlab.example.com", wantRepo: "group/sub/app"},
+		{name: "local_path", url: "/srv/git/app.git", wantErr: true},
+		{name: "missing_owner", url: "https://github.com/twig", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			host, repo, err := parseRemoteURL(tt.url)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseRemoteURL(%q) error = %v, wantErr %v", tt.url, err, tt.wantErr)
+			}
+			if host != tt.wantHost || repo != tt.wantRepo {
+				t.Errorf("parseRemoteURL(%q) = (%q, %q), want (%q, %q)", tt.url, host, repo, tt.wantHost, tt.wantRepo)
+			}
+		})
+	}
+}
+
+func TestForgeClient_PRStates(t *testing.T) {
+	t.Parallel()
+
+	const cachePath = "/repo/main/.git/twig/forge-cache.json"
+	now := time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC)
+
+	// GitHub responses keyed by the head query parameter
+	githubPulls := map[string]string{
+		"708u:feat/merged": `[{"state":"closed","merged_at":"2026-01-09T00:00:00Z","head":{"sha":"aaa"}}]`,
+		"708u:feat/closed": `[{"state":"closed","merged_at":null,"head":{"sha":"bbb"}}]`,
+		"708u:feat/open":   `[{"state":"open","merged_at":null,"head":{"sha":"ccc"}}]`,
+		"708u:feat/moved":  `[{"state":"closed","merged_at":"2026-01-09T00:00:00Z","head":{"sha":"old"}}]`,
+	}
+	// GitLab responses keyed by the source_branch query parameter
+	gitlabMRs := map[string]string{
+		"feat/merged": `[{"state":"merged","sha":"aaa"}]`,
+		"feat/closed": `[{"state":"closed","sha":"bbb"}]`,
+		"feat/open":   `[{"state":"opened","sha":"ccc"}]`,
+	}
+
+	tests := []struct {
+		name       string
+		kind       string
+		remoteURL  string
+		heads      map[string]string
+		cache      map[string]forgeCacheEntry
+		status     int
+		want       map[string]PRState
+		wantCalls  int32
+		wantErr    string
+		wantHeader string
+	}{
+		{
+			name:      "github",
+			kind:      ForgeGitHub,
+			remoteURL: "git@github.com:708u/twig.git",
+			heads: map[string]string{
+				"feat/merged": "aaa",
+				"feat/closed": "bbb",
+				"feat/open":   "ccc",
+				"feat/moved":  "new",
+				"feat/no-pr":  "ddd",
+			},
+			want: map[string]PRState{
+				"feat/merged": PRStateMerged,
+				"feat/closed": PRStateClosed,
+				"feat/open":   PRStateOpen,
+			},
+			wantCalls:  5,
+			wantHeader: "Bearer secret",
+		},
+		{
+			name:      "gitlab",
+			kind:      ForgeGitLab,
+			remoteURL: "https://gitlab.example.com/group/sub/app.git",
+			heads: map[string]string{
+				"feat/merged": "aaa",
+				"feat/closed": "bbb",
+				"feat/open":   "ccc",
+			},
+			want: map[string]PRState{
+				"feat/merged": PRStateMerged,
+				"feat/closed": PRStateClosed,
+				"feat/open":   PRStateOpen,
+			},
+			wantCalls:  3,
+			wantHeader: "secret",
+		},
+		{
+			name:      "uses fresh cache entry",
+			kind:      ForgeGitHub,
+			remoteURL: "git@github.com:708u/twig.git",
+			heads:     map[string]string{"feat/open": "ccc"},
+			cache: map[string]forgeCacheEntry{
+				"feat/open": {HEAD: "ccc", State: PRStateMerged, ComputedAt: now.Add(-time.Minute)},
+			},
+			want:      map[string]PRState{"feat/open": PRStateMerged},
+			wantCalls: 0,
+		},
+		{
+			name:      "ignores cache when HEAD moved",
+			kind:      ForgeGitHub,
+			remoteURL: "git@github.com:708u/twig.git",
+			heads:     map[string]string{"feat/merged": "aaa"},
+			cache: map[string]forgeCacheEntry{
+				"feat/merged": {HEAD: "old", State: PRStateNone, ComputedAt: now.Add(-time.Minute)},
+			},
+			want:       map[string]PRState{"feat/merged": PRStateMerged},
+			wantCalls:  1,
+			wantHeader: "Bearer secret",
+		},
+		{
+			name:      "ignores expired cache entry",
+			kind:      ForgeGitHub,
+			remoteURL: "git@github.com:708u/twig.git",
+			heads:     map[string]string{"feat/merged": "aaa"},
+			cache: map[string]forgeCacheEntry{
+				"feat/merged": {HEAD: "aaa", State: PRStateNone, ComputedAt: now.Add(-DefaultForgeCacheTTL)},
+			},
+			want:       map[string]PRState{"feat/merged": PRStateMerged},
+			wantCalls:  1,
+			wantHeader: "Bearer secret",
+		},
+		{
+			name:      "api error",
+			kind:      ForgeGitHub,
+			remoteURL: "git@github.com:708u/twig.git",
+			heads:     map[string]string{"feat/merged": "aaa"},
+			status:    http.StatusUnauthorized,
+			want:      map[string]PRState{},
+			wantCalls: 1,
+			wantErr:   "github API returned 401 Unauthorized",
+		},
+		{
+			name:      "unparsable remote",
+			kind:      ForgeGitHub,
+			remoteURL: "/srv/git/app.git",
+			heads:     map[string]string{"feat/merged": "aaa"},
+			want:      map[string]PRState{},
+			wantErr:   "cannot determine repository",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var calls atomic.Int32
+			var gotHeader atomic.Value
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls.Add(1)
+				if tt.status != 0 {
+					w.WriteHeader(tt.status)
+					return
+				}
+				var body string
+				switch tt.kind {
+				case ForgeGitHub:
+					gotHeader.Store(r.Header.Get("Authorization"))
+					if r.URL.Path != "/repos/708u/twig/pulls" {
+						t.Errorf("path = %q, want /repos/708u/twig/pulls", r.URL.Path)
+					}
+					body = githubPulls[r.URL.Query().Get("head")]
+				case ForgeGitLab:
+					gotHeader.Store(r.Header.Get("PRIVATE-TOKEN"))
+					if r.URL.EscapedPath() != "/projects/group%2Fsub%2Fapp/merge_requests" {
+						t.Errorf("path = %q, want /projects/group%%2Fsub%%2Fapp/merge_requests", r.URL.EscapedPath())
+					}
+					body = gitlabMRs[r.URL.Query().Get("source_branch")]
+				}
+				if body == "" {
+					body = "[]"
+				}
+				w.Write([]byte(body))
+			}))
+			t.Cleanup(srv.Close)
+
+			written := map[string][]byte{}
+			if tt.cache != nil {
+				data, err := json.Marshal(tt.cache)
+				if err != nil {
+					t.Fatal(err)
+				}
+				written[cachePath] = data
+			}
+			mockFS := &testutil.MockFS{WrittenFiles: written}
+			git := &GitRunner{
+				Executor: &testutil.MockGitExecutor{
+					GitCommonDir: "/repo/main/.git",
+					RemoteURLs:   map[string]string{"origin": tt.remoteURL},
+				},
+				Dir: "/repo/main",
+				Log: NewNopLogger(),
+			}
+
+			client := NewForgeClient(mockFS, git, tt.kind, "secret", nil)
+			client.BaseURL = srv.URL
+
+			got, err := client.PRStates(t.Context(), tt.heads, now)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("PRStates() error = %v, want to contain %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("PRStates() error = %v", err)
+			}
+
+			if len(got) != len(tt.want) {
+				t.Errorf("got %d states, want %d: %v", len(got), len(tt.want), got)
+			}
+			for branch, want := range tt.want {
+				if got[branch] != want {
+					t.Errorf("states[%q] = %q, want %q", branch, got[branch], want)
+				}
+			}
+			if n := calls.Load(); n != tt.wantCalls {
+				t.Errorf("API calls = %d, want %d", n, tt.wantCalls)
+			}
+			if tt.wantHeader != "" {
+				if h, _ := gotHeader.Load().(string); h != tt.wantHeader {
+					t.Errorf("auth header = %q, want %q", h, tt.wantHeader)
+				}
+			}
+
+			// Successful lookups are cached with the HEAD they were made for
+			if tt.wantErr == "" && tt.wantCalls > 0 {
+				var saved map[string]forgeCacheEntry
+				if err := json.Unmarshal(written[cachePath], &saved); err != nil {
+					t.Fatalf("cache not written: %v", err)
+				}
+				for branch, head := range tt.heads {
+					if saved[branch].HEAD != head || saved[branch].State != tt.want[branch] {
+						t.Errorf("cache[%q] = %+v, want HEAD %q state %q", branch, saved[branch], head, tt.want[branch])
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestForgeClient_PullRequest(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		kind      string
+		remoteURL string
+		wantPath  string
+		body      string
+		wantTitle string
+		wantHead  string
+	}{
+		{
+			name:      "github",
+			kind:      ForgeGitHub,
+			remoteURL: "git@github.com:708u/twig.git",
+			wantPath:  "/repos/708u/twig/pulls/12",
+			body:      `{"title": "Fix login", "head": {"ref": "feat/login"}}`,
+			wantTitle: "Fix login",
+			wantHead:  "feat/login",
+		},
+		{
+			name:      "gitlab",
+			kind:      ForgeGitLab,
+			remoteURL: "git@gitlab.example.com:group/sub/app.git",
+			wantPath:  "/projects/group%2Fsub%2Fapp/merge_requests/12",
+			body:      `{"title": "Fix login", "source_branch": "feat/login"}`,
+			wantTitle: "Fix login",
+			wantHead:  "feat/login",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.EscapedPath() != tt.wantPath {
+					t.Errorf("path = %q, want %q", r.URL.EscapedPath(), tt.wantPath)
+				}
+				w.Write([]byte(tt.body))
+			}))
+			t.Cleanup(srv.Close)
+
+			git := &GitRunner{
+				Executor: &testutil.MockGitExecutor{RemoteURLs: map[string]string{"origin": tt.remoteURL}},
+				Dir:      "/repo/main",
+				Log:      NewNopLogger(),
+			}
+			client := NewForgeClient(&testutil.MockFS{}, git, tt.kind, "", nil)
+			client.BaseURL = srv.URL
+
+			title, head, err := client.PullRequest(t.Context(), 12)
+			if err != nil {
+				t.Fatalf("PullRequest() error = %v", err)
+			}
+			if title != tt.wantTitle || head != tt.wantHead {
+				t.Errorf("PullRequest() = (%q, %q), want (%q, %q)", title, head, tt.wantTitle, tt.wantHead)
+			}
+		})
+	}
+}
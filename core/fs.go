@@ -1,4 +1,4 @@
-package twig
+package core
 
 import (
 	"io/fs"
@@ -12,20 +12,31 @@ type FileSystem interface {
 	Stat(name string) (fs.FileInfo, error)
 	Lstat(name string) (fs.FileInfo, error)
 	Symlink(oldname, newname string) error
+	Readlink(name string) (string, error)
 	IsNotExist(err error) bool
 	Glob(dir, pattern string) ([]string, error)
 	MkdirAll(path string, perm fs.FileMode) error
 	ReadDir(name string) ([]os.DirEntry, error)
 	Remove(name string) error
+	RemoveAll(path string) error
+	Rename(oldpath, newpath string) error
 	WriteFile(name string, data []byte, perm fs.FileMode) error
+	AppendFile(name string, data []byte, perm fs.FileMode) error
 	ReadFile(name string) ([]byte, error)
 }
 
+// NewOSFileSystem returns the FileSystem backed by the os package, as used
+// by the NewDefault* constructors.
+func NewOSFileSystem() FileSystem {
+	return osFS{}
+}
+
 type osFS struct{}
 
 func (osFS) Stat(name string) (fs.FileInfo, error)  { return os.Stat(name) }
 func (osFS) Lstat(name string) (fs.FileInfo, error) { return os.Lstat(name) }
 func (osFS) Symlink(oldname, newname string) error  { return os.Symlink(oldname, newname) }
+func (osFS) Readlink(name string) (string, error)   { return os.Readlink(name) }
 func (osFS) IsNotExist(err error) bool              { return os.IsNotExist(err) }
 func (osFS) Glob(dir, pattern string) ([]string, error) {
 	return doublestar.Glob(os.DirFS(dir), pattern)
@@ -33,7 +44,20 @@ func (osFS) Glob(dir, pattern string) ([]string, error) {
 func (osFS) MkdirAll(path string, perm fs.FileMode) error { return os.MkdirAll(path, perm) }
 func (osFS) ReadDir(name string) ([]os.DirEntry, error)   { return os.ReadDir(name) }
 func (osFS) Remove(name string) error                     { return os.Remove(name) }
+func (osFS) RemoveAll(path string) error                  { return os.RemoveAll(path) }
+func (osFS) Rename(oldpath, newpath string) error         { return os.Rename(oldpath, newpath) }
 func (osFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
 	return os.WriteFile(name, data, perm)
 }
+func (osFS) AppendFile(name string, data []byte, perm fs.FileMode) error {
+	f, err := os.OpenFile(name, os.O_APPEND|os.O_CREATE|os.O_WRONLY, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
 func (osFS) ReadFile(name string) ([]byte, error) { return os.ReadFile(name) }
@@ -0,0 +1,309 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GCPolicy limits the worktrees kept besides the main worktree ([gc]).
+type GCPolicy struct {
+	MaxWorktrees int           // 0 = no limit
+	MaxAge       time.Duration // 0 = no limit
+}
+
+// IsZero reports whether the policy sets no limit.
+func (p GCPolicy) IsZero() bool {
+	return p.MaxWorktrees == 0 && p.MaxAge == 0
+}
+
+// String describes the policy like the settings that define it.
+func (p GCPolicy) String() string {
+	var parts []string
+	if p.MaxWorktrees > 0 {
+		parts = append(parts, fmt.Sprintf("max_worktrees = %d", p.MaxWorktrees))
+	}
+	if p.MaxAge > 0 {
+		age := p.MaxAge.String()
+		if p.MaxAge%(24*time.Hour) == 0 {
+			age = fmt.Sprintf("%dd", p.MaxAge/(24*time.Hour))
+		}
+		parts = append(parts, "max_age = "+age)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// parseGCAge parses gc.max_age: a number of days ("45d") or a Go
+// duration ("720h").
+func parseGCAge(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n > 0 {
+			return time.Duration(n) * 24 * time.Hour, nil
+		}
+	}
+	if d, err := time.ParseDuration(s); err == nil && d > 0 {
+		return d, nil
+	}
+	return 0, fmt.Errorf("invalid age %q", s)
+}
+
+// GCReason describes why the policy selected a worktree.
+type GCReason string
+
+const (
+	GCOverLimit GCReason = "over max_worktrees"
+	GCExpired   GCReason = "older than max_age"
+)
+
+// GCSelection is a worktree selected by the policy.
+type GCSelection struct {
+	Branch       string // Empty for detached worktrees
+	WorktreePath string
+	Age          time.Duration // 0 = unknown
+	Reason       GCReason
+}
+
+// GCStatus compares the worktrees with the policy.
+type GCStatus struct {
+	Policy    GCPolicy
+	Worktrees int           // Worktrees besides the main worktree
+	Selected  []GCSelection // Worktrees over the policy, oldest first
+}
+
+// Exceeded reports whether any worktree is over the policy.
+func (s GCStatus) Exceeded() bool {
+	return len(s.Selected) > 0
+}
+
+// Hint returns the nudge printed after twig add when worktrees are over
+// the policy, or "" otherwise.
+func (s GCStatus) Hint() string {
+	if !s.Exceeded() {
+		return ""
+	}
+	return fmt.Sprintf("hint: %d worktree(s) over the gc policy (%s); run 'twig gc' to clean up\n",
+		len(s.Selected), s.Policy)
+}
+
+// GCCommand removes the worktrees over the [gc] policy: the oldest ones
+// beyond max_worktrees and those older than max_age. They go through the
+// same safety checks as twig clean, so only merged worktrees without
+// changes are removed.
+type GCCommand struct {
+	FS     FileSystem
+	Git    *GitRunner
+	Config *Config
+	Log    *slog.Logger
+	Audit  *AuditLog    // Records removals (nil = disabled)
+	Forge  *ForgeClient // Looks up PR states for unmerged branches (nil = disabled)
+}
+
+// NewGCCommand creates a GCCommand with explicit dependencies.
+func NewGCCommand(fs FileSystem, git *GitRunner, cfg *Config, log *slog.Logger) *GCCommand {
+	if log == nil {
+		log = NewNopLogger()
+	}
+	return &GCCommand{
+		FS:     fs,
+		Git:    git,
+		Config: cfg,
+		Log:    log,
+	}
+}
+
+// NewDefaultGCCommand creates a GCCommand with production dependencies.
+// Like twig clean, removals are recorded in the audit log, and PR states
+// are looked up when a forge is configured.
+func NewDefaultGCCommand(cfg *Config, log *slog.Logger, gitOpts ...GitRunnerOption) *GCCommand {
+	git := newDefaultGitRunner(cfg.WorktreeSourceDir, log, gitOpts)
+	fs := git.defaultFS()
+	cmd := NewGCCommand(fs, git, cfg, log)
+	cmd.Audit = NewAuditLog(fs, git)
+	cmd.Forge = NewDefaultForgeClient(cfg, fs, git, log)
+	return cmd
+}
+
+// GCOptions configures the gc operation.
+type GCOptions struct {
+	Check         bool // Show the selected worktrees only
+	Verbose       bool // Show skip reasons
+	KeepEmptyDirs bool // Leave emptied parent directories in place
+}
+
+// GCResult holds the result of a gc operation.
+type GCResult struct {
+	Status GCStatus
+	Clean  CleanResult // Safety checks and removals of the selected worktrees
+}
+
+// CleanableCount returns the number of selected worktrees that pass the
+// safety checks.
+func (r GCResult) CleanableCount() int {
+	return r.Clean.CleanableCount()
+}
+
+// GCFormatOptions configures gc output formatting.
+type GCFormatOptions struct {
+	Verbose      bool
+	Quiet        bool // Print only the clean output for the selected worktrees
+	ColorEnabled bool
+}
+
+// Format formats the GCResult: the selected worktrees followed by the
+// clean output for them.
+func (r GCResult) Format(opts GCFormatOptions) FormatResult {
+	if opts.Quiet {
+		if !r.Status.Exceeded() {
+			return FormatResult{}
+		}
+		return r.Clean.Format(CleanFormatOptions{Quiet: true, ColorEnabled: opts.ColorEnabled})
+	}
+
+	var stdout strings.Builder
+	switch {
+	case r.Status.Policy.IsZero():
+		fmt.Fprintln(&stdout, "no gc policy configured (set max_worktrees or max_age under [gc])")
+		return FormatResult{Stdout: stdout.String()}
+	case !r.Status.Exceeded():
+		fmt.Fprintf(&stdout, "%d worktree(s) within the gc policy (%s)\n", r.Status.Worktrees, r.Status.Policy)
+		return FormatResult{Stdout: stdout.String()}
+	}
+
+	if r.Clean.Check {
+		fmt.Fprintf(&stdout, "%d of %d worktree(s) over the gc policy (%s):\n",
+			len(r.Status.Selected), r.Status.Worktrees, r.Status.Policy)
+		for _, s := range r.Status.Selected {
+			name := s.Branch
+			if name == "" {
+				name = s.WorktreePath
+			}
+			age := "age unknown"
+			if s.Age > 0 {
+				age = "created " + formatAgo(s.Age)
+			}
+			fmt.Fprintf(&stdout, "  %s (%s, %s)\n", name, s.Reason, age)
+		}
+		fmt.Fprintln(&stdout)
+	}
+	clean := r.Clean.Format(CleanFormatOptions{Verbose: opts.Verbose, ColorEnabled: opts.ColorEnabled})
+	stdout.WriteString(clean.Stdout)
+	return FormatResult{Stdout: stdout.String(), Stderr: clean.Stderr}
+}
+
+// Status compares the worktrees with the policy at now without checking
+// or removing anything. Worktrees whose age is unknown are treated as the
+// newest.
+func (c *GCCommand) Status(ctx context.Context, now time.Time) (GCStatus, error) {
+	status := GCStatus{Policy: c.Config.GCPolicy()}
+	if status.Policy.IsZero() {
+		return status, nil
+	}
+
+	worktrees, err := c.Git.WorktreeList(ctx)
+	if err != nil {
+		return status, err
+	}
+
+	type agedWorktree struct {
+		wt  Worktree
+		age WorktreeAge
+	}
+	ages := NewAgeResolver(c.FS, c.Git)
+	var linked []agedWorktree
+	for i, wt := range worktrees {
+		// The main worktree is never collected, like with twig clean
+		if i == 0 || wt.Bare {
+			continue
+		}
+		age, err := ages.Resolve(ctx, wt.Path)
+		if err != nil {
+			return status, err
+		}
+		linked = append(linked, agedWorktree{wt: wt, age: age})
+	}
+	status.Worktrees = len(linked)
+
+	slices.SortStableFunc(linked, func(a, b agedWorktree) int {
+		switch {
+		case a.age.Known() && b.age.Known():
+			return a.age.CreatedAt.Compare(b.age.CreatedAt)
+		case a.age.Known():
+			return -1
+		case b.age.Known():
+			return 1
+		}
+		return 0
+	})
+
+	over := 0
+	if status.Policy.MaxWorktrees > 0 {
+		over = len(linked) - status.Policy.MaxWorktrees
+	}
+	for i, aw := range linked {
+		age := aw.age.Age(now)
+		var reason GCReason
+		switch {
+		case status.Policy.MaxAge > 0 && aw.age.Known() && age > status.Policy.MaxAge:
+			reason = GCExpired
+		case i < over:
+			reason = GCOverLimit
+		default:
+			continue
+		}
+		status.Selected = append(status.Selected, GCSelection{
+			Branch:       aw.wt.Branch,
+			WorktreePath: aw.wt.Path,
+			Age:          age,
+			Reason:       reason,
+		})
+	}
+
+	c.Log.DebugContext(ctx, "gc policy evaluated",
+		LogAttrKeyCategory.String(), LogCategoryGC,
+		"policy", status.Policy.String(),
+		"worktrees", status.Worktrees,
+		"selected", len(status.Selected))
+	return status, nil
+}
+
+// Run removes the worktrees selected by the policy that pass the safety
+// checks of twig clean. With opts.Check, nothing is removed.
+func (c *GCCommand) Run(ctx context.Context, cwd string, opts GCOptions) (GCResult, error) {
+	var result GCResult
+	status, err := c.Status(ctx, time.Now())
+	if err != nil {
+		return result, err
+	}
+	result.Status = status
+	if !status.Exceeded() {
+		return result, nil
+	}
+
+	paths := make([]string, len(status.Selected))
+	for i, s := range status.Selected {
+		paths[i] = s.WorktreePath
+	}
+	clean := &CleanCommand{
+		FS:     c.FS,
+		Git:    c.Git,
+		Config: c.Config,
+		Log:    c.Log,
+		Audit:  c.Audit,
+		Forge:  c.Forge,
+	}
+	result.Clean, err = clean.Run(ctx, cwd, CleanOptions{
+		Check:         opts.Check,
+		Verbose:       opts.Verbose,
+		Detached:      true,
+		KeepEmptyDirs: opts.KeepEmptyDirs,
+		Worktrees:     paths,
+	})
+	if err != nil {
+		return result, err
+	}
+	return result, nil
+}
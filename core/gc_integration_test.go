@@ -0,0 +1,101 @@
+//go:build integration
+
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/708u/twig/internal/testutil"
+)
+
+func TestGCCommand_Integration(t *testing.T) {
+	t.Parallel()
+
+	t.Run("RemovesOldestOverMaxWorktrees", func(t *testing.T) {
+		t.Parallel()
+
+		repoDir, mainDir := testutil.SetupTestRepo(t)
+
+		oldPath := filepath.Join(repoDir, "feature", "old")
+		newPath := filepath.Join(repoDir, "feature", "new")
+		testutil.RunGit(t, mainDir, "worktree", "add", "-b", "feature/old", oldPath)
+		testutil.RunGit(t, mainDir, "worktree", "add", "-b", "feature/new", newPath)
+
+		// Merge a commit of each branch so both pass the clean checks
+		for _, wtPath := range []string{oldPath, newPath} {
+			if err := os.WriteFile(filepath.Join(wtPath, filepath.Base(wtPath)+".txt"), []byte("test"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			testutil.RunGit(t, wtPath, "add", ".")
+			testutil.RunGit(t, wtPath, "commit", "-m", "test commit")
+		}
+		testutil.RunGit(t, mainDir, "merge", "--no-ff", "-m", "Merge feature branches", "feature/old", "feature/new")
+
+		// Backdate feature/old so the ages differ
+		past := time.Now().Add(-72 * time.Hour)
+		adminDir := filepath.Join(mainDir, ".git", "worktrees", "old")
+		for _, p := range []string{adminDir, filepath.Join(adminDir, "gitdir")} {
+			if err := os.Chtimes(p, past, past); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		cfgResult, err := LoadConfig(mainDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cfg := cfgResult.Config
+		cfg.GC = GCConfig{MaxWorktrees: 1}
+
+		cmd := NewGCCommand(osFS{}, NewGitRunner(mainDir), cfg, nil)
+		result, err := cmd.Run(t.Context(), mainDir, GCOptions{})
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+
+		if len(result.Status.Selected) != 1 || result.Status.Selected[0].Branch != "feature/old" {
+			t.Fatalf("Selected = %+v, want feature/old only", result.Status.Selected)
+		}
+		if result.CleanableCount() != 1 {
+			t.Fatalf("CleanableCount() = %d, want 1: %+v", result.CleanableCount(), result.Clean.Candidates)
+		}
+		if _, err := os.Stat(oldPath); !os.IsNotExist(err) {
+			t.Errorf("feature/old worktree should be removed, stat err = %v", err)
+		}
+		if _, err := os.Stat(newPath); err != nil {
+			t.Errorf("feature/new worktree should be kept: %v", err)
+		}
+	})
+
+	t.Run("WithinPolicyRemovesNothing", func(t *testing.T) {
+		t.Parallel()
+
+		repoDir, mainDir := testutil.SetupTestRepo(t)
+
+		wtPath := filepath.Join(repoDir, "feature", "a")
+		testutil.RunGit(t, mainDir, "worktree", "add", "-b", "feature/a", wtPath)
+
+		cfgResult, err := LoadConfig(mainDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cfg := cfgResult.Config
+		cfg.GC = GCConfig{MaxWorktrees: 5, MaxAge: "30d"}
+
+		cmd := NewGCCommand(osFS{}, NewGitRunner(mainDir), cfg, nil)
+		result, err := cmd.Run(t.Context(), mainDir, GCOptions{})
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+
+		if result.Status.Exceeded() {
+			t.Errorf("Selected = %+v, want none", result.Status.Selected)
+		}
+		if _, err := os.Stat(wtPath); err != nil {
+			t.Errorf("worktree should be kept: %v", err)
+		}
+	})
+}
@@ -0,0 +1,297 @@
+package core
+
+import (
+	"io/fs"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/708u/twig/internal/testutil"
+)
+
+func TestParseGCAge(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{in: "45d", want: 45 * 24 * time.Hour},
+		{in: "1d", want: 24 * time.Hour},
+		{in: "720h", want: 720 * time.Hour},
+		{in: "90m", want: 90 * time.Minute},
+		{in: "0d", wantErr: true},
+		{in: "-1d", wantErr: true},
+		{in: "0s", wantErr: true},
+		{in: "d", wantErr: true},
+		{in: "45 days", wantErr: true},
+		{in: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := parseGCAge(tt.in)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("parseGCAge(%q) = %v, want error", tt.in, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseGCAge(%q) error: %v", tt.in, err)
+			}
+			if got != tt.want {
+				t.Errorf("parseGCAge(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGCPolicy_String(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		policy GCPolicy
+		want   string
+	}{
+		{name: "both", policy: GCPolicy{MaxWorktrees: 20, MaxAge: 45 * 24 * time.Hour}, want: "max_worktrees = 20, max_age = 45d"},
+		{name: "count only", policy: GCPolicy{MaxWorktrees: 5}, want: "max_worktrees = 5"},
+		{name: "duration", policy: GCPolicy{MaxAge: 36 * time.Hour}, want: "max_age = 36h0m0s"},
+		{name: "zero", policy: GCPolicy{}, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := tt.policy.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGCCommand_Status(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	// feat/a is the oldest; feat/d has no admin dir, so its age is unknown
+	created := map[string]time.Time{
+		"/repo/.git/worktrees/a": now.Add(-60 * day),
+		"/repo/.git/worktrees/b": now.Add(-30 * day),
+		"/repo/.git/worktrees/c": now.Add(-10 * day),
+	}
+
+	tests := []struct {
+		name         string
+		gc           GCConfig
+		wantSelected []string
+		wantReasons  []GCReason
+	}{
+		{
+			name: "no policy",
+			gc:   GCConfig{},
+		},
+		{
+			name: "within limits",
+			gc:   GCConfig{MaxWorktrees: 4, MaxAge: "90d"},
+		},
+		{
+			name:         "oldest over max_worktrees",
+			gc:           GCConfig{MaxWorktrees: 2},
+			wantSelected: []string{"feat/a", "feat/b"},
+			wantReasons:  []GCReason{GCOverLimit, GCOverLimit},
+		},
+		{
+			name:         "older than max_age",
+			gc:           GCConfig{MaxAge: "45d"},
+			wantSelected: []string{"feat/a"},
+			wantReasons:  []GCReason{GCExpired},
+		},
+		{
+			name:         "max_age takes precedence in reason",
+			gc:           GCConfig{MaxWorktrees: 1, MaxAge: "20d"},
+			wantSelected: []string{"feat/a", "feat/b", "feat/c"},
+			wantReasons:  []GCReason{GCExpired, GCExpired, GCOverLimit},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockFS := &testutil.MockFS{
+				DirContents: map[string][]os.DirEntry{
+					"/repo/.git/worktrees": {
+						mockDirEntry{name: "a", isDir: true},
+						mockDirEntry{name: "b", isDir: true},
+						mockDirEntry{name: "c", isDir: true},
+					},
+				},
+				ReadFileResults: map[string][]byte{
+					"/repo/.git/worktrees/a/gitdir": []byte("/repo/wt/feat-a/.git\n"),
+					"/repo/.git/worktrees/b/gitdir": []byte("/repo/wt/feat-b/.git\n"),
+					"/repo/.git/worktrees/c/gitdir": []byte("/repo/wt/feat-c/.git\n"),
+				},
+				StatFunc: func(name string) (fs.FileInfo, error) {
+					if mtime, ok := created[name]; ok {
+						return &testutil.MockFileInfo{ModTimeVal: mtime}, nil
+					}
+					return nil, fs.ErrNotExist
+				},
+			}
+			mockGit := &testutil.MockGitExecutor{
+				GitCommonDir: "/repo/.git",
+				// Listed out of age order to check sorting
+				Worktrees: []testutil.MockWorktree{
+					{Path: "/repo", Branch: "main"},
+					{Path: "/repo/wt/feat-d", Branch: "feat/d"},
+					{Path: "/repo/wt/feat-c", Branch: "feat/c"},
+					{Path: "/repo/wt/feat-a", Branch: "feat/a"},
+					{Path: "/repo/wt/feat-b", Branch: "feat/b"},
+				},
+			}
+
+			cmd := NewGCCommand(mockFS, &GitRunner{Executor: mockGit, Dir: "/repo", Log: NewNopLogger()},
+				&Config{GC: tt.gc}, nil)
+			status, err := cmd.Status(t.Context(), now)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if tt.gc == (GCConfig{}) {
+				if !status.Policy.IsZero() || status.Worktrees != 0 {
+					t.Errorf("status = %+v, want zero policy without listing", status)
+				}
+				return
+			}
+			if status.Worktrees != 4 {
+				t.Errorf("Worktrees = %d, want 4", status.Worktrees)
+			}
+
+			var branches []string
+			var reasons []GCReason
+			for _, s := range status.Selected {
+				branches = append(branches, s.Branch)
+				reasons = append(reasons, s.Reason)
+			}
+			if !slices.Equal(branches, tt.wantSelected) {
+				t.Errorf("selected = %v, want %v", branches, tt.wantSelected)
+			}
+			if !slices.Equal(reasons, tt.wantReasons) {
+				t.Errorf("reasons = %v, want %v", reasons, tt.wantReasons)
+			}
+			if status.Exceeded() != (len(tt.wantSelected) > 0) {
+				t.Errorf("Exceeded() = %v", status.Exceeded())
+			}
+		})
+	}
+}
+
+func TestGCCommand_Run_ChecksSelectedOnly(t *testing.T) {
+	t.Parallel()
+
+	mockGit := &testutil.MockGitExecutor{
+		GitCommonDir: "/repo/.git",
+		Worktrees: []testutil.MockWorktree{
+			{Path: "/repo/main", Branch: "main"},
+			{Path: "/repo/feat/a", Branch: "feat/a"},
+			{Path: "/repo/feat/b", Branch: "feat/b"},
+			{Path: "/repo/feat/c", Branch: "feat/c"},
+		},
+		MergedBranches: map[string][]string{
+			"main": {"main", "feat/a", "feat/b", "feat/c"},
+		},
+	}
+	// No admin dirs: ages are unknown, so list order decides
+	cmd := NewGCCommand(&testutil.MockFS{}, &GitRunner{Executor: mockGit, Dir: "/repo/main", Log: NewNopLogger()},
+		&Config{WorktreeSourceDir: "/repo/main", DefaultSource: "main", GC: GCConfig{MaxWorktrees: 2}}, nil)
+	result, err := cmd.Run(t.Context(), "/other", GCOptions{Check: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(result.Status.Selected) != 1 || result.Status.Selected[0].Branch != "feat/a" {
+		t.Fatalf("Selected = %+v, want feat/a only", result.Status.Selected)
+	}
+	if len(result.Clean.Candidates) != 1 || result.Clean.Candidates[0].Branch != "feat/a" {
+		t.Errorf("Candidates = %+v, want feat/a only", result.Clean.Candidates)
+	}
+	if result.CleanableCount() != 1 {
+		t.Errorf("CleanableCount() = %d, want 1", result.CleanableCount())
+	}
+}
+
+func TestGCResult_Format(t *testing.T) {
+	t.Parallel()
+
+	policy := GCPolicy{MaxWorktrees: 1, MaxAge: 45 * 24 * time.Hour}
+	tests := []struct {
+		name   string
+		result GCResult
+		want   []string
+	}{
+		{
+			name:   "no policy",
+			result: GCResult{},
+			want:   []string{"no gc policy configured"},
+		},
+		{
+			name:   "within policy",
+			result: GCResult{Status: GCStatus{Policy: policy, Worktrees: 1}},
+			want:   []string{"1 worktree(s) within the gc policy (max_worktrees = 1, max_age = 45d)"},
+		},
+		{
+			name: "selected in check mode",
+			result: GCResult{
+				Status: GCStatus{Policy: policy, Worktrees: 2, Selected: []GCSelection{
+					{Branch: "feat/a", WorktreePath: "/wt/feat/a", Age: 50 * 24 * time.Hour, Reason: GCExpired},
+					{WorktreePath: "/wt/detached", Reason: GCOverLimit},
+				}},
+				Clean: CleanResult{Check: true},
+			},
+			want: []string{
+				"2 of 2 worktree(s) over the gc policy (max_worktrees = 1, max_age = 45d):",
+				"  feat/a (older than max_age, created ",
+				"  /wt/detached (over max_worktrees, age unknown)",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := tt.result.Format(GCFormatOptions{}).Stdout
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("Format() missing %q in:\n%s", want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestGCStatus_Hint(t *testing.T) {
+	t.Parallel()
+
+	if got := (GCStatus{Policy: GCPolicy{MaxWorktrees: 2}}).Hint(); got != "" {
+		t.Errorf("Hint() = %q, want empty within policy", got)
+	}
+
+	status := GCStatus{
+		Policy:   GCPolicy{MaxWorktrees: 2},
+		Selected: []GCSelection{{Branch: "feat/a", Reason: GCOverLimit}},
+	}
+	want := "hint: 1 worktree(s) over the gc policy (max_worktrees = 2); run 'twig gc' to clean up\n"
+	if got := status.Hint(); got != want {
+		t.Errorf("Hint() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,2153 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// GitExecutor abstracts git command execution for testability.
+// Commands are fixed to "git" - only subcommands and args are passed.
+type GitExecutor interface {
+	// Run executes git with args and returns stdout.
+	Run(ctx context.Context, args ...string) ([]byte, error)
+}
+
+type osGitExecutor struct{}
+
+func (e osGitExecutor) Run(ctx context.Context, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	// Children such as ssh or a credential helper can keep the output
+	// pipes open after git is killed; stop waiting for them.
+	cmd.WaitDelay = gitWaitDelay
+	return cmd.Output()
+}
+
+// gitWaitDelay is how long a cancelled git command may take to release
+// its output before Run returns anyway.
+const gitWaitDelay = 2 * time.Second
+
+// GitOp represents the type of git operation.
+type GitOp int
+
+const (
+	OpWorktreeRemove GitOp = iota + 1
+	OpBranchDelete
+	OpWorktreeMove
+	OpBranchRename
+)
+
+// Git command names.
+const (
+	GitCmdWorktree   = "worktree"
+	GitCmdBranch     = "branch"
+	GitCmdStash      = "stash"
+	GitCmdStatus     = "status"
+	GitCmdRevParse   = "rev-parse"
+	GitCmdDiff       = "diff"
+	GitCmdFetch      = "fetch"
+	GitCmdForEachRef = "for-each-ref"
+	GitCmdRevList    = "rev-list"
+	GitCmdCheckout   = "checkout"
+	GitCmdReset      = "reset"
+	GitCmdMergeBase  = "merge-base"
+	GitCmdCommitTree = "commit-tree"
+	GitCmdCherry     = "cherry"
+	GitCmdLsFiles    = "ls-files"
+	GitCmdReadTree   = "read-tree"
+	GitCmdWriteTree  = "write-tree"
+	GitCmdPush       = "push"
+	GitCmdRemote     = "remote"
+	GitCmdApply      = "apply"
+	GitCmdAdd        = "add"
+	GitCmdConfig     = "config"
+
+	GitCmdSparseCheckout = "sparse-checkout"
+	GitCmdSymbolicRef    = "symbolic-ref"
+	GitCmdLsRemote       = "ls-remote"
+	GitCmdCheckIgnore    = "check-ignore"
+)
+
+// Git worktree subcommands.
+const (
+	GitWorktreeAdd    = "add"
+	GitWorktreeRemove = "remove"
+	GitWorktreeList   = "list"
+	GitWorktreePrune  = "prune"
+	GitWorktreeMove   = "move"
+	GitWorktreeRepair = "repair"
+)
+
+// Git stash subcommands.
+const (
+	GitStashList = "list"
+)
+
+// Porcelain output format prefixes and values.
+const (
+	PorcelainWorktreePrefix = "worktree "
+	PorcelainHEADPrefix     = "HEAD "
+	PorcelainBranchPrefix   = "branch refs/heads/"
+	PorcelainDetached       = "detached"
+	PorcelainBare           = "bare"
+	PorcelainLocked         = "locked"
+	PorcelainPrunable       = "prunable"
+)
+
+// RefsHeadsPrefix is the git refs prefix for local branches.
+const RefsHeadsPrefix = "refs/heads/"
+
+// RefsRemotesPrefix is the git refs prefix for remote-tracking branches.
+const RefsRemotesPrefix = "refs/remotes/"
+
+func (op GitOp) String() string {
+	switch op {
+	case OpWorktreeRemove:
+		return "remove worktree"
+	case OpBranchDelete:
+		return "delete branch"
+	case OpWorktreeMove:
+		return "move worktree"
+	case OpBranchRename:
+		return "rename branch"
+	default:
+		return "unknown operation"
+	}
+}
+
+// GitRemoteCommands are the git subcommands twig runs that talk to a
+// remote, limited by git_remote_timeout instead of git_timeout. git
+// submodule update clones missing submodules, so submodule is included.
+var GitRemoteCommands = []string{GitCmdFetch, GitCmdPush, GitCmdLsRemote, GitCmdSubmodule}
+
+// ErrGitTimeout is wrapped by the GitError returned when a git command
+// runs longer than the GitRunner timeout (git_timeout).
+var ErrGitTimeout = errors.New("timed out")
+
+// GitErrorCode classifies a git failure by the message git printed, so
+// callers can act on the cause without matching git's stderr themselves.
+type GitErrorCode string
+
+const (
+	GitErrorUnknown          GitErrorCode = ""
+	GitErrorLockedWorktree   GitErrorCode = "locked-worktree"   // Worktree is locked (git worktree lock)
+	GitErrorMissingRef       GitErrorCode = "missing-ref"       // Branch, commit or remote ref does not exist
+	GitErrorDirtyWorktree    GitErrorCode = "dirty-worktree"    // Uncommitted changes are in the way
+	GitErrorPermissionDenied GitErrorCode = "permission-denied" // Filesystem or remote access was denied
+)
+
+// gitErrorPatterns maps substrings of git's stderr to the code they
+// indicate, checked in order.
+var gitErrorPatterns = []struct {
+	substr string
+	code   GitErrorCode
+}{
+	{"locked working tree", GitErrorLockedWorktree},
+	{"is locked", GitErrorLockedWorktree},
+	{"contains modified or untracked files", GitErrorDirtyWorktree},
+	{"local changes", GitErrorDirtyWorktree},
+	{"would be overwritten", GitErrorDirtyWorktree},
+	{"invalid reference", GitErrorMissingRef},
+	{"not a valid object name", GitErrorMissingRef},
+	{"unknown revision", GitErrorMissingRef},
+	{"couldn't find remote ref", GitErrorMissingRef},
+	{"did not match any", GitErrorMissingRef},
+	{"not found", GitErrorMissingRef},
+	{"permission denied", GitErrorPermissionDenied},
+	{"operation not permitted", GitErrorPermissionDenied},
+}
+
+// classifyGitStderr returns the code of the failure git reported on
+// stderr, or GitErrorUnknown.
+func classifyGitStderr(stderr string) GitErrorCode {
+	lower := strings.ToLower(stderr)
+	for _, p := range gitErrorPatterns {
+		if strings.Contains(lower, p.substr) {
+			return p.code
+		}
+	}
+	return GitErrorUnknown
+}
+
+// GitErrorCodeOf returns the code of the git failure in err's chain: the
+// Code of a GitError, or the classified stderr of a failed git command
+// returned by GitRunner.Run. It returns GitErrorUnknown otherwise.
+func GitErrorCodeOf(err error) GitErrorCode {
+	var gitErr *GitError
+	if errors.As(err, &gitErr) && gitErr.Stderr != "" {
+		return gitErr.Code()
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return classifyGitStderr(string(exitErr.Stderr))
+	}
+	return GitErrorUnknown
+}
+
+// gitErrorHint returns advice for a git failure that applies whichever
+// command failed, or "" for codes without one. Commands with a better
+// fix, such as twig remove --force, give their own.
+func gitErrorHint(err error) string {
+	switch GitErrorCodeOf(err) {
+	case GitErrorLockedWorktree:
+		return "run 'git worktree unlock <path>' first"
+	case GitErrorMissingRef:
+		return "check the branch or commit name, or run 'git fetch' if it only exists on a remote"
+	case GitErrorDirtyWorktree:
+		return "commit or stash the changes first"
+	case GitErrorPermissionDenied:
+		return "check the permissions of the worktree and .git directories, or your access to the remote"
+	}
+	return ""
+}
+
+// explainGitError adds what git printed on stderr, unless a GitError in
+// the chain already shows it, and the gitErrorHint to err, for errors
+// the CLI prints as is.
+func explainGitError(err error) error {
+	var gitErr *GitError
+	var exitErr *exec.ExitError
+	if !errors.As(err, &gitErr) && errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+		err = fmt.Errorf("%w: %s", err, strings.TrimSpace(string(exitErr.Stderr)))
+	}
+	if hint := gitErrorHint(err); hint != "" {
+		err = fmt.Errorf("%w\nhint: %s", err, hint)
+	}
+	return err
+}
+
+// writeGitErrorHint writes the gitErrorHint of err as a hint line, if any.
+func writeGitErrorHint(w *strings.Builder, err error) {
+	if hint := gitErrorHint(err); hint != "" {
+		fmt.Fprintf(w, "hint: %s\n", hint)
+	}
+}
+
+// GitError represents an error from a git operation with structured information.
+type GitError struct {
+	Op     GitOp
+	Stderr string
+	Err    error
+
+	// Set for errors of a single git command, such as ErrGitTimeout,
+	// where Op is zero.
+	Command string        // Command line, e.g. "git fetch origin main"
+	Elapsed time.Duration // How long the command ran
+}
+
+func (e *GitError) Error() string {
+	if errors.Is(e.Err, ErrGitTimeout) {
+		setting := "git_timeout"
+		if args := gitSubcommandArgs(strings.Fields(e.Command)[1:]); len(args) > 0 && slices.Contains(GitRemoteCommands, args[0]) {
+			setting = "git_remote_timeout"
+		}
+		return fmt.Sprintf("%s %v after %s (raise %s if it needs longer)", e.Command, e.Err, e.Elapsed.Round(time.Millisecond), setting)
+	}
+	if e.Command != "" {
+		return fmt.Sprintf("%s: %v", e.Command, e.Err)
+	}
+	if e.Stderr != "" {
+		return fmt.Sprintf("failed to %s: %v: %s", e.Op, e.Err, e.Stderr)
+	}
+	return fmt.Sprintf("failed to %s: %v", e.Op, e.Err)
+}
+
+func (e *GitError) Unwrap() error {
+	return e.Err
+}
+
+// Code classifies the failure from the stderr of git.
+func (e *GitError) Code() GitErrorCode {
+	return classifyGitStderr(e.Stderr)
+}
+
+// newGitError creates a GitError from a git operation error.
+// It extracts stderr from exec.ExitError if available.
+func newGitError(op GitOp, err error) *GitError {
+	gitErr := &GitError{
+		Op:  op,
+		Err: err,
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+		gitErr.Stderr = strings.TrimSpace(string(exitErr.Stderr))
+	}
+	return gitErr
+}
+
+// GitRunner provides git operations using GitExecutor.
+type GitRunner struct {
+	Executor GitExecutor
+	Dir      string
+	Log      *slog.Logger
+	Timeout  time.Duration // Limit for each git command (0 = none)
+	Timings  *Timings      // Receives the time of each git command (nil = not recorded)
+
+	// CommandTimeouts overrides Timeout for the git subcommands it
+	// contains, e.g. a longer limit for fetch than for local reads.
+	CommandTimeouts map[string]time.Duration
+
+	faults *FaultProfile // Injected into Executor and the default filesystem
+}
+
+type gitRunnerOptions struct {
+	log             *slog.Logger
+	timeout         time.Duration
+	commandTimeouts map[string]time.Duration
+	executor        GitExecutor
+	timings         *Timings
+	faults          *FaultProfile
+}
+
+// GitRunnerOption configures GitRunner.
+type GitRunnerOption func(*gitRunnerOptions)
+
+// WithLogger sets the logger for GitRunner.
+func WithLogger(log *slog.Logger) GitRunnerOption {
+	return func(o *gitRunnerOptions) {
+		o.log = log
+	}
+}
+
+// WithTimeout limits how long each git command may run. Zero, the
+// default, disables the limit.
+func WithTimeout(d time.Duration) GitRunnerOption {
+	return func(o *gitRunnerOptions) {
+		o.timeout = d
+	}
+}
+
+// WithCommandTimeout limits how long git subcommand (e.g. GitCmdFetch)
+// may run, in place of the WithTimeout limit. Zero disables the limit
+// for it.
+func WithCommandTimeout(subcommand string, d time.Duration) GitRunnerOption {
+	return func(o *gitRunnerOptions) {
+		if o.commandTimeouts == nil {
+			o.commandTimeouts = make(map[string]time.Duration)
+		}
+		o.commandTimeouts[subcommand] = d
+	}
+}
+
+// WithExecutor replaces the executor running git, e.g. to record or stub
+// git commands.
+func WithExecutor(e GitExecutor) GitRunnerOption {
+	return func(o *gitRunnerOptions) {
+		o.executor = e
+	}
+}
+
+// WithTimings records how long each git command takes into t, for the
+// --timing breakdown. Commands built on the GitRunner record their other
+// phases (symlink creation, submodule init) there too.
+func WithTimings(t *Timings) GitRunnerOption {
+	return func(o *gitRunnerOptions) {
+		o.timings = t
+	}
+}
+
+// WithFaults injects faults from p into the git commands of the runner
+// and the filesystem NewDefault* constructors create alongside it (set by
+// the hidden --chaos flag). Intended for robustness testing only.
+func WithFaults(p *FaultProfile) GitRunnerOption {
+	return func(o *gitRunnerOptions) {
+		o.faults = p
+	}
+}
+
+// NewGitRunner creates a new GitRunner with the default executor unless
+// WithExecutor is given.
+func NewGitRunner(dir string, opts ...GitRunnerOption) *GitRunner {
+	o := &gitRunnerOptions{
+		log: NewNopLogger(),
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.executor == nil {
+		o.executor = osGitExecutor{}
+	}
+	return &GitRunner{
+		Executor: o.faults.WrapGitExecutor(o.executor),
+		Dir:      dir,
+		Log:      o.log,
+		Timeout:  o.timeout,
+		Timings:  o.timings,
+
+		CommandTimeouts: o.commandTimeouts,
+		faults:          o.faults,
+	}
+}
+
+// newDefaultGitRunner creates the GitRunner of a NewDefault* constructor,
+// logging to log with opts applied on top.
+func newDefaultGitRunner(dir string, log *slog.Logger, opts []GitRunnerOption) *GitRunner {
+	return NewGitRunner(dir, append([]GitRunnerOption{WithLogger(log)}, opts...)...)
+}
+
+// defaultFS returns the production filesystem for commands running git
+// with g, with the faults of WithFaults injected.
+func (g *GitRunner) defaultFS() FileSystem {
+	return g.faults.WrapFS(osFS{})
+}
+
+// InDir returns a GitRunner that executes commands in the specified directory.
+func (g *GitRunner) InDir(dir string) *GitRunner {
+	return &GitRunner{
+		Executor:        g.Executor,
+		Dir:             dir,
+		Log:             g.Log,
+		Timeout:         g.Timeout,
+		Timings:         g.Timings,
+		CommandTimeouts: g.CommandTimeouts,
+		faults:          g.faults,
+	}
+}
+
+// Run executes git command with -C flag. The command is stopped when ctx
+// is cancelled or, with a timeout (see timeoutFor), when it runs longer
+// than that; the latter returns a GitError wrapping ErrGitTimeout.
+func (g *GitRunner) Run(ctx context.Context, args ...string) ([]byte, error) {
+	fullArgs := append([]string{"-C", g.Dir}, args...)
+	runCtx := ctx
+	if timeout := g.timeoutFor(args); timeout > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	start := time.Now()
+	out, err := g.Executor.Run(runCtx, fullArgs...)
+	elapsed := time.Since(start)
+	g.Timings.Record(timingPhaseGit(args), elapsed)
+	if g.Log.Enabled(ctx, slog.LevelDebug) {
+		g.Log.DebugContext(ctx, strings.Join(append([]string{"git"}, fullArgs...), " "),
+			"category", LogCategoryGit,
+			"args", args,
+			"dir", g.Dir,
+			"duration_ms", elapsed.Milliseconds(),
+			"exit_code", gitExitCode(err))
+	}
+	// Only our own deadline is a timeout; a cancelled or expired ctx of
+	// the caller is reported as is.
+	if err != nil && ctx.Err() == nil && errors.Is(runCtx.Err(), context.DeadlineExceeded) {
+		return out, &GitError{
+			Err:     ErrGitTimeout,
+			Command: strings.Join(append([]string{"git"}, args...), " "),
+			Elapsed: elapsed,
+		}
+	}
+	return out, err
+}
+
+// timeoutFor returns the limit for the git command args: the
+// CommandTimeouts entry of its subcommand, or Timeout.
+func (g *GitRunner) timeoutFor(args []string) time.Duration {
+	if args := gitSubcommandArgs(args); len(args) > 0 {
+		if d, ok := g.CommandTimeouts[args[0]]; ok {
+			return d
+		}
+	}
+	return g.Timeout
+}
+
+// gitSubcommandArgs returns args from the git subcommand on, skipping
+// the leading -C <dir> and -c <name>=<value> options.
+func gitSubcommandArgs(args []string) []string {
+	for len(args) >= 2 && (args[0] == "-C" || args[0] == "-c") {
+		args = args[2:]
+	}
+	return args
+}
+
+// gitExitCode returns the exit status of a git command for logging:
+// 0 on success and -1 when git did not exit normally (e.g. not started).
+func gitExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitErr interface{ ExitCode() int }
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
+
+type worktreeAddOptions struct {
+	createBranch bool
+	lock         bool
+	lockReason   string
+	noCheckout   bool
+	detach       bool
+	startPoint   string
+}
+
+func (o worktreeAddOptions) lockArgs() []string {
+	if !o.lock {
+		return nil
+	}
+	if o.lockReason != "" {
+		return []string{"--lock", "--reason", o.lockReason}
+	}
+	return []string{"--lock"}
+}
+
+func (o worktreeAddOptions) args() []string {
+	args := o.lockArgs()
+	if o.noCheckout {
+		args = append(args, "--no-checkout")
+	}
+	if o.detach {
+		args = append(args, "--detach")
+	}
+	return args
+}
+
+// WorktreeAddOption is a functional option for WorktreeAdd.
+type WorktreeAddOption func(*worktreeAddOptions)
+
+// WithCreateBranch creates a new branch when adding the worktree.
+func WithCreateBranch() WorktreeAddOption {
+	return func(o *worktreeAddOptions) {
+		o.createBranch = true
+	}
+}
+
+// WithLock locks the worktree after creation.
+func WithLock() WorktreeAddOption {
+	return func(o *worktreeAddOptions) {
+		o.lock = true
+	}
+}
+
+// WithLockReason sets the reason for locking the worktree.
+func WithLockReason(reason string) WorktreeAddOption {
+	return func(o *worktreeAddOptions) {
+		o.lockReason = reason
+	}
+}
+
+// WithNoCheckout creates the worktree without checking out any files.
+func WithNoCheckout() WorktreeAddOption {
+	return func(o *worktreeAddOptions) {
+		o.noCheckout = true
+	}
+}
+
+// WithDetach checks out the commit passed as branch to WorktreeAdd with
+// a detached HEAD instead of a branch.
+func WithDetach() WorktreeAddOption {
+	return func(o *worktreeAddOptions) {
+		o.detach = true
+	}
+}
+
+// WithStartPoint creates the new branch at commit instead of HEAD.
+// Only effective with WithCreateBranch.
+func WithStartPoint(commit string) WorktreeAddOption {
+	return func(o *worktreeAddOptions) {
+		o.startPoint = commit
+	}
+}
+
+// worktreeAddMu serializes registering worktrees within the process. git
+// reads the administrative directory of every worktree
+// (.git/worktrees/<name>) when it adds one, and fails if another add is
+// still writing its commondir. Only the registration is serialized; the
+// files are checked out afterwards, in parallel.
+var worktreeAddMu sync.Mutex
+
+// WorktreeAdd creates a new worktree at the specified path. If the files
+// cannot be checked out, the worktree is removed again.
+func (g *GitRunner) WorktreeAdd(ctx context.Context, path, branch string, opts ...WorktreeAddOption) ([]byte, error) {
+	var o worktreeAddOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	checkout := !o.noCheckout
+	o.noCheckout = true
+	output, err := g.registerWorktree(ctx, path, branch, o)
+	if err != nil || !checkout {
+		return output, err
+	}
+
+	// Like the checkout of git worktree add, this runs the post-checkout
+	// hook and leaves submodules to be initialized separately
+	if _, err := g.InDir(path).Run(ctx, GitCmdCheckout, "-f", "-q"); err != nil {
+		if _, rmErr := g.worktreeRemove(ctx, path, WorktreeForceLevelUnclean); rmErr != nil {
+			g.Log.DebugContext(ctx, "failed to remove worktree after checkout failure",
+				"path", path,
+				"error", rmErr)
+		}
+		return nil, fmt.Errorf("failed to check out %s: %w", path, err)
+	}
+	return output, nil
+}
+
+// registerWorktree runs git worktree add, holding worktreeAddMu.
+func (g *GitRunner) registerWorktree(ctx context.Context, path, branch string, o worktreeAddOptions) ([]byte, error) {
+	worktreeAddMu.Lock()
+	defer worktreeAddMu.Unlock()
+
+	if o.createBranch {
+		return g.worktreeAddWithNewBranch(ctx, branch, path, o)
+	}
+	return g.worktreeAdd(ctx, path, branch, o)
+}
+
+// SparseCheckoutSet restricts the worktree to the given directories
+// (cone mode).
+func (g *GitRunner) SparseCheckoutSet(ctx context.Context, paths ...string) error {
+	args := append([]string{GitCmdSparseCheckout, "set", "--cone", "--"}, paths...)
+	if _, err := g.Run(ctx, args...); err != nil {
+		return fmt.Errorf("failed to set sparse-checkout paths: %w", err)
+	}
+	return nil
+}
+
+// CheckoutHEAD populates the index and working tree from HEAD, honoring
+// any sparse-checkout patterns. Used after adding a worktree with
+// WithNoCheckout.
+func (g *GitRunner) CheckoutHEAD(ctx context.Context) error {
+	if _, err := g.Run(ctx, GitCmdReadTree, "-mu", "HEAD"); err != nil {
+		return fmt.Errorf("failed to check out HEAD: %w", err)
+	}
+	return nil
+}
+
+// LocalBranchExists checks if a branch exists in the local repository.
+func (g *GitRunner) LocalBranchExists(ctx context.Context, branch string) (bool, error) {
+	return g.refExists(ctx, RefsHeadsPrefix+branch)
+}
+
+// CommitExists checks if commit is present in the object database
+// (e.g. not yet garbage collected).
+func (g *GitRunner) CommitExists(ctx context.Context, commit string) (bool, error) {
+	return g.refExists(ctx, commit+"^{commit}")
+}
+
+// ResolveCommit returns the full hash of the commit rev (branch, tag,
+// or abbreviated hash) refers to.
+func (g *GitRunner) ResolveCommit(ctx context.Context, rev string) (string, error) {
+	out, err := g.Run(ctx, GitCmdRevParse, "--verify", rev+"^{commit}")
+	if err != nil {
+		var exitErr interface{ ExitCode() int }
+		if errors.As(err, &exitErr) {
+			return "", fmt.Errorf("%s is not a commit", rev)
+		}
+		return "", fmt.Errorf("failed to resolve %s: %w", rev, err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// RemoteBranchExists checks if a remote-tracking branch ("<remote>/<branch>")
+// exists locally. No network access is made.
+func (g *GitRunner) RemoteBranchExists(ctx context.Context, remoteBranch string) (bool, error) {
+	return g.refExists(ctx, RefsRemotesPrefix+remoteBranch)
+}
+
+// RemoteHEADBranch returns the default branch of remote recorded in
+// refs/remotes/<remote>/HEAD (by clone or git remote set-head), or "" if
+// none is recorded.
+func (g *GitRunner) RemoteHEADBranch(ctx context.Context, remote string) (string, error) {
+	prefix := RefsRemotesPrefix + remote + "/"
+	out, err := g.Run(ctx, GitCmdSymbolicRef, "--quiet", prefix+"HEAD")
+	if err != nil {
+		// Exit code 1 means the ref is missing or not symbolic
+		var exitErr interface{ ExitCode() int }
+		if errors.As(err, &exitErr) {
+			return "", nil
+		}
+		return "", err
+	}
+	return strings.TrimPrefix(strings.TrimSpace(string(out)), prefix), nil
+}
+
+// SetUpstream sets the upstream of branch to a remote-tracking branch
+// ("<remote>/<branch>").
+func (g *GitRunner) SetUpstream(ctx context.Context, branch, upstream string) error {
+	if _, err := g.Run(ctx, GitCmdBranch, "--set-upstream-to="+upstream, branch); err != nil {
+		return fmt.Errorf("failed to set upstream of %s to %s: %w", branch, upstream, err)
+	}
+	return nil
+}
+
+// BranchUpstream returns the remote and the remote ref (e.g. "origin",
+// "refs/heads/feat/a") that branch tracks. Both are empty without an upstream.
+func (g *GitRunner) BranchUpstream(ctx context.Context, branch string) (remote, remoteRef string, err error) {
+	out, err := g.Run(ctx, GitCmdForEachRef, "--format=%(upstream:remotename) %(upstream:remoteref)", RefsHeadsPrefix+branch)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get upstream of %s: %w", branch, err)
+	}
+	remote, remoteRef, _ = strings.Cut(strings.TrimSpace(string(out)), " ")
+	return remote, remoteRef, nil
+}
+
+// UnsetUpstream removes the upstream configuration of branch.
+func (g *GitRunner) UnsetUpstream(ctx context.Context, branch string) error {
+	if _, err := g.Run(ctx, GitCmdBranch, "--unset-upstream", branch); err != nil {
+		return fmt.Errorf("failed to unset upstream of %s: %w", branch, err)
+	}
+	return nil
+}
+
+// PushSetUpstream pushes branch to remote and sets it as the upstream (-u).
+func (g *GitRunner) PushSetUpstream(ctx context.Context, remote, branch string) error {
+	if _, err := g.Run(ctx, GitCmdPush, "-u", remote, branch); err != nil {
+		return fmt.Errorf("failed to push %s to %s: %w", branch, remote, err)
+	}
+	return nil
+}
+
+func (g *GitRunner) refExists(ctx context.Context, ref string) (bool, error) {
+	_, err := g.Run(ctx, GitCmdRevParse, "--verify", ref)
+	if err != nil {
+		// git rev-parse returns exit code 128 for non-existent refs
+		var exitErr interface{ ExitCode() int }
+		if errors.As(err, &exitErr) {
+			// ExitError means git ran but ref doesn't exist
+			return false, nil
+		}
+		// Other errors (context canceled, etc.)
+		return false, err
+	}
+	return true, nil
+}
+
+// BranchList returns all local branch names.
+func (g *GitRunner) BranchList(ctx context.Context) ([]string, error) {
+	output, err := g.Run(ctx, GitCmdBranch, "--format=%(refname:short)")
+	if err != nil {
+		return nil, err
+	}
+	var branches []string
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		if line != "" {
+			branches = append(branches, line)
+		}
+	}
+	return branches, nil
+}
+
+// RemoteURL returns the fetch URL configured for remote.
+func (g *GitRunner) RemoteURL(ctx context.Context, remote string) (string, error) {
+	out, err := g.Run(ctx, GitCmdRemote, "get-url", remote)
+	if err != nil {
+		return "", fmt.Errorf("failed to get URL of remote %s: %w", remote, err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// FindRemotesForBranch returns all remotes that have the specified branch
+// in local remote-tracking branches.
+// This checks refs/remotes/*/<branch> locally without network access.
+func (g *GitRunner) FindRemotesForBranch(ctx context.Context, branch string) ([]string, error) {
+	out, err := g.Run(ctx, GitCmdForEachRef, "--format=%(refname:short)",
+		fmt.Sprintf("refs/remotes/*/%s", branch))
+	if err != nil {
+		return nil, err
+	}
+
+	remotes := []string{}
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		if line == "" {
+			continue
+		}
+		// Extract "origin" from "origin/branch"
+		if idx := strings.Index(line, "/"); idx > 0 {
+			remotes = append(remotes, line[:idx])
+		}
+	}
+	return remotes, nil
+}
+
+// FindRemoteForBranch finds the remote that has the specified branch.
+// Returns the remote name if exactly one remote has the branch.
+// Returns empty string if no remote has the branch.
+// If multiple remotes have the branch, checkout.defaultRemote picks one
+// as it does for git checkout; otherwise the branch is ambiguous.
+func (g *GitRunner) FindRemoteForBranch(ctx context.Context, branch string) (string, error) {
+	remotes, err := g.FindRemotesForBranch(ctx, branch)
+	if err != nil {
+		return "", err
+	}
+	return g.SelectRemote(ctx, branch, remotes)
+}
+
+// SelectRemote picks the remote to use for branch among remotes that all
+// have it. A single remote is used as is and none yields "". When there
+// are several, the one named by checkout.defaultRemote wins; without it
+// the branch is ambiguous and an error is returned.
+func (g *GitRunner) SelectRemote(ctx context.Context, branch string, remotes []string) (string, error) {
+	switch len(remotes) {
+	case 0:
+		return "", nil
+	case 1:
+		return remotes[0], nil
+	}
+
+	defaultRemote, ok, err := g.ConfigGet(ctx, "checkout.defaultRemote")
+	if err != nil {
+		return "", err
+	}
+	if ok && slices.Contains(remotes, defaultRemote) {
+		return defaultRemote, nil
+	}
+	return "", fmt.Errorf("branch %q exists on multiple remotes: %v (set checkout.defaultRemote to choose one)", branch, remotes)
+}
+
+// ConfigGet returns the value of the git config key and whether it is
+// set, following git's own precedence of local, global and system config.
+func (g *GitRunner) ConfigGet(ctx context.Context, key string) (string, bool, error) {
+	out, err := g.Run(ctx, GitCmdConfig, "--get", key)
+	if err != nil {
+		// Exit code 1 means the key is not set
+		var exitErr interface{ ExitCode() int }
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+			return "", false, nil
+		}
+		return "", false, fmt.Errorf("failed to read git config %s: %w", key, err)
+	}
+	return strings.TrimSpace(string(out)), true, nil
+}
+
+// branchDescriptionKey returns the git config key holding the description
+// of branch (git branch --edit-description).
+func branchDescriptionKey(branch string) string {
+	return "branch." + branch + ".description"
+}
+
+// BranchDescriptions returns the description of every branch that has
+// one, by branch name.
+func (g *GitRunner) BranchDescriptions(ctx context.Context) (map[string]string, error) {
+	out, err := g.Run(ctx, GitCmdConfig, "--null", "--get-regexp", `^branch\..*\.description$`)
+	if err != nil {
+		// Exit code 1 means no branch has a description
+		var exitErr interface{ ExitCode() int }
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+			return map[string]string{}, nil
+		}
+		return nil, fmt.Errorf("failed to read branch descriptions: %w", err)
+	}
+
+	// With --null each entry is "<key>\n<value>\x00"
+	descriptions := make(map[string]string)
+	for _, entry := range strings.Split(string(out), "\x00") {
+		key, value, ok := strings.Cut(entry, "\n")
+		if !ok {
+			continue
+		}
+		branch := strings.TrimSuffix(strings.TrimPrefix(key, "branch."), ".description")
+		if value = strings.TrimSpace(value); branch != "" && value != "" {
+			descriptions[branch] = value
+		}
+	}
+	return descriptions, nil
+}
+
+// SetBranchDescription sets the description of branch, as git branch
+// --edit-description does. An empty description removes it.
+func (g *GitRunner) SetBranchDescription(ctx context.Context, branch, description string) error {
+	key := branchDescriptionKey(branch)
+	if description == "" {
+		if _, err := g.Run(ctx, GitCmdConfig, "--unset", key); err != nil {
+			// Exit code 5 means the key was not set
+			var exitErr interface{ ExitCode() int }
+			if errors.As(err, &exitErr) && exitErr.ExitCode() == 5 {
+				return nil
+			}
+			return fmt.Errorf("failed to remove the description of %s: %w", branch, err)
+		}
+		return nil
+	}
+	if _, err := g.Run(ctx, GitCmdConfig, key, description); err != nil {
+		return fmt.Errorf("failed to set the description of %s: %w", branch, err)
+	}
+	return nil
+}
+
+// ConfigGetBool returns the boolean git config key and whether it is set.
+// Values are interpreted like git does (yes/on/1 are true).
+func (g *GitRunner) ConfigGetBool(ctx context.Context, key string) (bool, bool, error) {
+	value, ok, err := g.ConfigGet(ctx, key)
+	if err != nil || !ok {
+		return false, false, err
+	}
+	switch strings.ToLower(value) {
+	case "true", "yes", "on", "1", "":
+		return true, true, nil
+	case "false", "no", "off", "0":
+		return false, true, nil
+	default:
+		return false, false, fmt.Errorf("invalid boolean value %q for git config %s", value, key)
+	}
+}
+
+// RemoteHasBranch reports whether branch exists on remote. Unlike
+// FindRemotesForBranch, this asks the remote itself over the network.
+func (g *GitRunner) RemoteHasBranch(ctx context.Context, remote, branch string) (bool, error) {
+	out, err := g.Run(ctx, GitCmdLsRemote, "--heads", remote, "refs/heads/"+branch)
+	if err != nil {
+		return false, err
+	}
+	return strings.TrimSpace(string(out)) != "", nil
+}
+
+// LookupRemotesForBranch asks all remotes in parallel whether they have
+// branch, and returns those that do in configured order. The queries
+// share a single deadline of timeout, so one slow remote cannot stall
+// the others; remotes that fail or do not answer in time are skipped.
+func (g *GitRunner) LookupRemotesForBranch(ctx context.Context, branch string, timeout time.Duration) ([]string, error) {
+	remotes, err := g.Remotes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	found := make([]bool, len(remotes))
+	var wg sync.WaitGroup
+	for i, remote := range remotes {
+		wg.Go(func() {
+			ok, err := g.RemoteHasBranch(ctx, remote, branch)
+			if err != nil {
+				g.Log.DebugContext(ctx, "remote lookup failed",
+					LogAttrKeyCategory.String(), LogCategoryGit,
+					"remote", remote,
+					"branch", branch,
+					"error", err.Error())
+				return
+			}
+			found[i] = ok
+		})
+	}
+	wg.Wait()
+
+	var matched []string
+	for i, remote := range remotes {
+		if found[i] {
+			matched = append(matched, remote)
+		}
+	}
+	return matched, nil
+}
+
+// Fetch fetches the specified refspec from the remote.
+func (g *GitRunner) Fetch(ctx context.Context, remote string, refspec ...string) error {
+	args := []string{GitCmdFetch, remote}
+	args = append(args, refspec...)
+	_, err := g.Run(ctx, args...)
+	return err
+}
+
+// FetchBranchFromRemotes fetches branch from every remote and returns
+// the remotes that have it, so that a branch pushed after the last fetch
+// gets a remote-tracking branch. Remotes are fetched one at a time, as
+// git fetch --multiple does by default; a remote whose fetch fails,
+// typically because it does not have the branch, is skipped.
+func (g *GitRunner) FetchBranchFromRemotes(ctx context.Context, branch string) ([]string, error) {
+	remotes, err := g.Remotes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var matched []string
+	for _, remote := range remotes {
+		if err := g.Fetch(ctx, remote, branch); err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			g.Log.DebugContext(ctx, "fetch failed",
+				LogAttrKeyCategory.String(), LogCategoryGit,
+				"remote", remote,
+				"branch", branch,
+				"error", err.Error())
+			continue
+		}
+		exists, err := g.RemoteBranchExists(ctx, remote+"/"+branch)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check remote branch existence: %w", err)
+		}
+		if exists {
+			matched = append(matched, remote)
+		}
+	}
+	return matched, nil
+}
+
+// UnpushedCount returns the number of commits on branch that are not on
+// a remote: ahead of branch@{push}, or when the branch has no push
+// destination, not reachable from any remote-tracking branch.
+func (g *GitRunner) UnpushedCount(ctx context.Context, branch string) (int, error) {
+	out, err := g.Run(ctx, GitCmdRevList, "--count", branch+"@{push}.."+branch)
+	if err != nil {
+		out, err = g.Run(ctx, GitCmdRevList, "--count", branch, "--not", "--remotes")
+		if err != nil {
+			return 0, fmt.Errorf("failed to count unpushed commits of %s: %w", branch, err)
+		}
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(string(out)))
+	if err != nil {
+		return 0, fmt.Errorf("failed to count unpushed commits of %s: %w", branch, err)
+	}
+	return n, nil
+}
+
+// Remotes returns the names of the configured remotes.
+func (g *GitRunner) Remotes(ctx context.Context) ([]string, error) {
+	out, err := g.Run(ctx, GitCmdRemote)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list remotes: %w", err)
+	}
+	var remotes []string
+	for line := range strings.SplitSeq(strings.TrimSpace(string(out)), "\n") {
+		if line != "" {
+			remotes = append(remotes, line)
+		}
+	}
+	return remotes, nil
+}
+
+// FetchPrune fetches remote and removes remote-tracking branches that
+// no longer exist on it, so gone upstreams are detected accurately.
+func (g *GitRunner) FetchPrune(ctx context.Context, remote string) error {
+	_, err := g.Run(ctx, GitCmdFetch, "--prune", remote)
+	return err
+}
+
+// Worktree holds worktree path and branch information.
+type Worktree struct {
+	Path           string
+	Branch         string
+	HEAD           string
+	Detached       bool
+	Locked         bool
+	LockReason     string
+	Prunable       bool
+	PrunableReason string
+	Bare           bool
+	Bookmark       bool // Branch is the jj bookmark at HEAD, which git reports as detached
+}
+
+// ShortHEAD returns the first 7 characters of the HEAD commit hash.
+func (w Worktree) ShortHEAD() string {
+	if len(w.HEAD) >= 7 {
+		return w.HEAD[:7]
+	}
+	return w.HEAD
+}
+
+// WorktreeList returns all worktrees with their paths and branches.
+func (g *GitRunner) WorktreeList(ctx context.Context) ([]Worktree, error) {
+	out, err := g.worktreeListPorcelain(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list worktrees: %w", err)
+	}
+
+	// porcelain format:
+	// worktree /path/to/worktree
+	// HEAD abc123
+	// branch refs/heads/branch-name
+	// detached (optional)
+	// bare (optional)
+	// locked [reason] (optional)
+	// prunable [reason] (optional)
+	// (blank line)
+
+	var worktrees []Worktree
+	var current Worktree
+	for _, line := range strings.Split(string(out), "\n") {
+		switch {
+		case strings.HasPrefix(line, PorcelainWorktreePrefix):
+			current = Worktree{Path: strings.TrimPrefix(line, PorcelainWorktreePrefix)}
+		case strings.HasPrefix(line, PorcelainHEADPrefix):
+			current.HEAD = strings.TrimPrefix(line, PorcelainHEADPrefix)
+		case strings.HasPrefix(line, PorcelainBranchPrefix):
+			current.Branch = strings.TrimPrefix(line, PorcelainBranchPrefix)
+		case line == PorcelainDetached:
+			current.Detached = true
+		case line == PorcelainBare:
+			current.Bare = true
+		case strings.HasPrefix(line, PorcelainLocked):
+			current.Locked = true
+			if reason, ok := strings.CutPrefix(line, PorcelainLocked+" "); ok {
+				current.LockReason = reason
+			}
+		case strings.HasPrefix(line, PorcelainPrunable):
+			current.Prunable = true
+			if reason, ok := strings.CutPrefix(line, PorcelainPrunable+" "); ok {
+				current.PrunableReason = reason
+			}
+		case line == "" && current.Path != "":
+			worktrees = append(worktrees, current)
+			current = Worktree{}
+		}
+	}
+
+	// The main worktree is listed first
+	if len(worktrees) > 0 && !worktrees[0].Bare {
+		if err := DetectVCS(worktrees[0].Path).ResolveBranches(ctx, g, worktrees); err != nil {
+			return nil, err
+		}
+	}
+	return worktrees, nil
+}
+
+// WorktreeListBranches returns a list of branch names currently checked out in worktrees.
+func (g *GitRunner) WorktreeListBranches(ctx context.Context) ([]string, error) {
+	worktrees, err := g.WorktreeList(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var branches []string
+	for _, wt := range worktrees {
+		if wt.Branch != "" {
+			branches = append(branches, wt.Branch)
+		}
+	}
+	return branches, nil
+}
+
+// WorktreeFindByBranch returns the Worktree for the given branch.
+// Returns an error if the branch is not checked out in any worktree.
+func (g *GitRunner) WorktreeFindByBranch(ctx context.Context, branch string) (*Worktree, error) {
+	worktrees, err := g.WorktreeList(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if wt := findWorktreeByBranch(worktrees, branch); wt != nil {
+		return wt, nil
+	}
+
+	return nil, fmt.Errorf("branch %q is not checked out in any worktree", branch)
+}
+
+// WorktreeForceLevel represents the force level for worktree removal.
+// Matches git worktree remove behavior.
+type WorktreeForceLevel uint8
+
+const (
+	// WorktreeForceLevelNone means no force - fail on uncommitted changes or locked.
+	WorktreeForceLevelNone WorktreeForceLevel = iota
+	// WorktreeForceLevelUnclean removes unclean worktrees (-f).
+	WorktreeForceLevelUnclean
+	// WorktreeForceLevelLocked also removes locked worktrees (-f -f).
+	WorktreeForceLevelLocked
+)
+
+type worktreeRemoveOptions struct {
+	forceLevel WorktreeForceLevel
+}
+
+// WorktreeRemoveOption is a functional option for WorktreeRemove.
+type WorktreeRemoveOption func(*worktreeRemoveOptions)
+
+// WithForceRemove forces worktree removal.
+func WithForceRemove(level WorktreeForceLevel) WorktreeRemoveOption {
+	return func(o *worktreeRemoveOptions) {
+		o.forceLevel = level
+	}
+}
+
+// WorktreeRemove removes the worktree at the given path.
+// By default fails if there are uncommitted changes. Use WithForceRemove() to force.
+func (g *GitRunner) WorktreeRemove(ctx context.Context, path string, opts ...WorktreeRemoveOption) ([]byte, error) {
+	var o worktreeRemoveOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	out, err := g.worktreeRemove(ctx, path, o.forceLevel)
+	if err != nil {
+		return nil, newGitError(OpWorktreeRemove, err)
+	}
+	return out, nil
+}
+
+// WorktreeMove moves the worktree at oldPath to newPath.
+// git refuses locked worktrees and worktrees containing submodules.
+func (g *GitRunner) WorktreeMove(ctx context.Context, oldPath, newPath string) error {
+	if _, err := g.Run(ctx, GitCmdWorktree, GitWorktreeMove, oldPath, newPath); err != nil {
+		return newGitError(OpWorktreeMove, err)
+	}
+	return nil
+}
+
+// WorktreeRepair repairs the administrative files of the worktrees at
+// paths, e.g. after their .git file or directory was moved by hand.
+func (g *GitRunner) WorktreeRepair(ctx context.Context, paths ...string) error {
+	args := append([]string{GitCmdWorktree, GitWorktreeRepair}, paths...)
+	if _, err := g.Run(ctx, args...); err != nil {
+		return fmt.Errorf("failed to repair worktree: %w", err)
+	}
+	return nil
+}
+
+// BranchRename renames a local branch (git branch -m). Its reflog and
+// configuration, including the upstream, move with it.
+func (g *GitRunner) BranchRename(ctx context.Context, oldBranch, newBranch string) error {
+	if _, err := g.Run(ctx, GitCmdBranch, "-m", oldBranch, newBranch); err != nil {
+		return newGitError(OpBranchRename, err)
+	}
+	return nil
+}
+
+type branchDeleteOptions struct {
+	force bool
+}
+
+// BranchDeleteOption is a functional option for BranchDelete.
+type BranchDeleteOption func(*branchDeleteOptions)
+
+// WithForceDelete forces branch deletion even if not fully merged.
+func WithForceDelete() BranchDeleteOption {
+	return func(o *branchDeleteOptions) {
+		o.force = true
+	}
+}
+
+// BranchDelete deletes a local branch.
+// By default uses -d (safe delete). Use WithForceDelete() to use -D (force delete).
+func (g *GitRunner) BranchDelete(ctx context.Context, branch string, opts ...BranchDeleteOption) ([]byte, error) {
+	var o branchDeleteOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	out, err := g.branchDelete(ctx, branch, o.force)
+	if err != nil {
+		return nil, newGitError(OpBranchDelete, err)
+	}
+	return out, nil
+}
+
+// FileStatus represents a file with its git status.
+type FileStatus struct {
+	Status string // e.g., " M", "A ", "??"
+	Path   string
+}
+
+// ChangedFiles returns files with uncommitted changes including staged,
+// unstaged, and untracked files. Untracked directories are collapsed
+// into a single entry. Status codes are the first 2 characters from
+// git status --porcelain output.
+func (g *GitRunner) ChangedFiles(ctx context.Context) ([]FileStatus, error) {
+	return g.changedFiles(ctx, "-unormal")
+}
+
+// ChangedFilesAll is like ChangedFiles but lists individual files within
+// untracked directories instead of collapsing them.
+func (g *GitRunner) ChangedFilesAll(ctx context.Context) ([]FileStatus, error) {
+	return g.changedFiles(ctx, "-uall")
+}
+
+func (g *GitRunner) changedFiles(ctx context.Context, untrackedMode string) ([]FileStatus, error) {
+	output, err := g.Run(ctx, GitCmdStatus, "--porcelain", untrackedMode)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check git status: %w", err)
+	}
+
+	files := make([]FileStatus, 0)
+	for _, line := range strings.Split(string(output), "\n") {
+		if len(line) < 3 {
+			continue
+		}
+		// Format: "XY filename" where XY is 2-char status
+		status := line[:2]
+		path := strings.TrimSpace(line[2:])
+		// Handle renamed files "old -> new"
+		if idx := strings.Index(path, " -> "); idx != -1 {
+			path = path[idx+4:]
+		}
+		files = append(files, FileStatus{Status: status, Path: path})
+	}
+	return files, nil
+}
+
+// TrackedFiles returns paths of all files tracked in the index,
+// relative to the worktree root.
+func (g *GitRunner) TrackedFiles(ctx context.Context) ([]string, error) {
+	out, err := g.Run(ctx, GitCmdLsFiles, "-z")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tracked files: %w", err)
+	}
+
+	var files []string
+	for path := range strings.SplitSeq(string(out), "\x00") {
+		if path == "" {
+			continue
+		}
+		files = append(files, path)
+	}
+	return files, nil
+}
+
+// HasChanges checks if there are any uncommitted changes (staged, unstaged, or untracked).
+func (g *GitRunner) HasChanges(ctx context.Context) (bool, error) {
+	files, err := g.ChangedFiles(ctx)
+	if err != nil {
+		return false, err
+	}
+	return len(files) > 0, nil
+}
+
+// StashEntry is an entry in the stash list.
+type StashEntry struct {
+	Ref     string // e.g. stash@{0}
+	Hash    string
+	Subject string // e.g. "On main: twig carry"
+}
+
+// StashList returns the stash entries, most recent first.
+func (g *GitRunner) StashList(ctx context.Context) ([]StashEntry, error) {
+	out, err := g.Run(ctx, GitCmdStash, GitStashList, "--format=%gd %H %gs")
+	if err != nil {
+		return nil, err
+	}
+	var entries []StashEntry
+	for line := range strings.SplitSeq(strings.TrimSpace(string(out)), "\n") {
+		fields := strings.SplitN(line, " ", 3)
+		if len(fields) < 2 {
+			continue
+		}
+		e := StashEntry{Ref: fields[0], Hash: fields[1]}
+		if len(fields) == 3 {
+			e.Subject = fields[2]
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// DiffPatch returns a binary patch of the changes in the index and working
+// tree against HEAD, limited to pathspecs if any. The patch is independent
+// of diff settings in the user's git config, so it can be fed to ApplyPatch.
+func (g *GitRunner) DiffPatch(ctx context.Context, pathspecs ...string) ([]byte, error) {
+	args := []string{GitCmdDiff, "--binary", "--full-index", "--no-color", "--no-ext-diff",
+		"--no-textconv", "--no-renames", "--src-prefix=a/", "--dst-prefix=b/", "HEAD", "--"}
+	return g.Run(ctx, append(args, pathspecs...)...)
+}
+
+// StagedNewFiles returns files added to the index but not in HEAD,
+// relative to the worktree root and limited to pathspecs if any.
+func (g *GitRunner) StagedNewFiles(ctx context.Context, pathspecs ...string) ([]string, error) {
+	args := []string{GitCmdDiff, "--cached", "--name-only", "--diff-filter=A", "-z", "HEAD", "--"}
+	out, err := g.Run(ctx, append(args, pathspecs...)...)
+	if err != nil {
+		return nil, err
+	}
+	return splitNUL(out), nil
+}
+
+// UntrackedFiles returns untracked files that are not ignored, relative to
+// the worktree root and limited to pathspecs if any.
+func (g *GitRunner) UntrackedFiles(ctx context.Context, pathspecs ...string) ([]string, error) {
+	args := []string{GitCmdLsFiles, "--others", "--exclude-standard", "--full-name", "-z", "--"}
+	out, err := g.Run(ctx, append(args, pathspecs...)...)
+	if err != nil {
+		return nil, err
+	}
+	return splitNUL(out), nil
+}
+
+// IgnoredFiles returns untracked files that are ignored by .gitignore and
+// the other standard exclude files, relative to the worktree root and
+// limited to pathspecs if any.
+func (g *GitRunner) IgnoredFiles(ctx context.Context, pathspecs ...string) ([]string, error) {
+	args := []string{GitCmdLsFiles, "--others", "--ignored", "--exclude-standard", "--full-name", "-z", "--"}
+	out, err := g.Run(ctx, append(args, pathspecs...)...)
+	if err != nil {
+		return nil, err
+	}
+	return splitNUL(out), nil
+}
+
+// checkIgnoreBatchSize is the number of paths passed to each git
+// check-ignore run, keeping the command line well under system limits.
+const checkIgnoreBatchSize = 500
+
+// CheckIgnore returns the paths that are ignored by .gitignore and the
+// other standard exclude files, in the order given. Paths are relative to
+// Dir. Tracked files are never reported as ignored.
+func (g *GitRunner) CheckIgnore(ctx context.Context, paths []string) ([]string, error) {
+	var ignored []string
+	for batch := range slices.Chunk(paths, checkIgnoreBatchSize) {
+		// -z requires --stdin, which GitExecutor cannot provide, so paths
+		// come back one per line, C-quoted if unusual
+		args := append([]string{GitCmdCheckIgnore, "--"}, batch...)
+		out, err := g.Run(ctx, args...)
+		if err != nil {
+			// Exit code 1 means none of the paths is ignored
+			var exitErr interface{ ExitCode() int }
+			if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+				continue
+			}
+			return nil, fmt.Errorf("failed to check ignored files: %w", err)
+		}
+		for line := range strings.Lines(string(out)) {
+			path := strings.TrimSuffix(line, "\n")
+			if unquoted, err := strconv.Unquote(path); err == nil && strings.HasPrefix(path, `"`) {
+				path = unquoted
+			}
+			ignored = append(ignored, path)
+		}
+	}
+	return ignored, nil
+}
+
+type applyPatchOptions struct {
+	threeWay bool
+	reverse  bool
+}
+
+// ApplyPatchOption configures ApplyPatch behavior.
+type ApplyPatchOption func(*applyPatchOptions)
+
+// WithThreeWay falls back to a three-way merge when the patch does not
+// apply cleanly. This updates the index as well as the working tree.
+func WithThreeWay() ApplyPatchOption {
+	return func(o *applyPatchOptions) {
+		o.threeWay = true
+	}
+}
+
+// WithReverse applies the patch in reverse, undoing its changes.
+func WithReverse() ApplyPatchOption {
+	return func(o *applyPatchOptions) {
+		o.reverse = true
+	}
+}
+
+// ApplyPatch applies the patch in patchFile to the working tree. It must
+// run at the worktree root, since patch paths are relative to it.
+func (g *GitRunner) ApplyPatch(ctx context.Context, patchFile string, opts ...ApplyPatchOption) error {
+	var o applyPatchOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	args := []string{GitCmdApply}
+	if o.threeWay {
+		args = append(args, "--3way")
+	}
+	if o.reverse {
+		args = append(args, "-R")
+	}
+	_, err := g.Run(ctx, append(args, patchFile)...)
+	return err
+}
+
+// Add stages the given paths.
+func (g *GitRunner) Add(ctx context.Context, paths ...string) error {
+	_, err := g.Run(ctx, append([]string{GitCmdAdd, "--"}, paths...)...)
+	return err
+}
+
+// ResetIndex unstages changes, limited to pathspecs if any, leaving the
+// working tree untouched.
+func (g *GitRunner) ResetIndex(ctx context.Context, pathspecs ...string) error {
+	_, err := g.Run(ctx, append([]string{GitCmdReset, "-q", "--"}, pathspecs...)...)
+	return err
+}
+
+// WriteTree writes the index as a tree object and returns its ID, so that
+// the index can be put back with ReadTree.
+func (g *GitRunner) WriteTree(ctx context.Context) (string, error) {
+	out, err := g.Run(ctx, GitCmdWriteTree)
+	if err != nil {
+		return "", err
+	}
+	tree := strings.TrimSpace(string(out))
+	if tree == "" {
+		return "", fmt.Errorf("git write-tree returned no tree")
+	}
+	return tree, nil
+}
+
+// ReadTree replaces the index with tree, leaving the working tree alone.
+func (g *GitRunner) ReadTree(ctx context.Context, tree string) error {
+	_, err := g.Run(ctx, GitCmdReadTree, tree)
+	return err
+}
+
+// splitNUL splits NUL-terminated git output into its entries.
+func splitNUL(out []byte) []string {
+	var entries []string
+	for entry := range strings.SplitSeq(string(out), "\x00") {
+		if entry != "" {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// private methods for git command execution
+
+func (g *GitRunner) worktreeAdd(ctx context.Context, path, branch string, o worktreeAddOptions) ([]byte, error) {
+	args := []string{GitCmdWorktree, GitWorktreeAdd}
+	args = append(args, o.args()...)
+	args = append(args, path, branch)
+	return g.Run(ctx, args...)
+}
+
+func (g *GitRunner) worktreeAddWithNewBranch(ctx context.Context, branch, path string, o worktreeAddOptions) ([]byte, error) {
+	args := []string{GitCmdWorktree, GitWorktreeAdd}
+	args = append(args, o.args()...)
+	args = append(args, "-b", branch, path)
+	if o.startPoint != "" {
+		args = append(args, o.startPoint)
+	}
+	return g.Run(ctx, args...)
+}
+
+func (g *GitRunner) worktreeListPorcelain(ctx context.Context) ([]byte, error) {
+	return g.Run(ctx, GitCmdWorktree, GitWorktreeList, "--porcelain")
+}
+
+func (g *GitRunner) worktreeRemove(ctx context.Context, path string, forceLevel WorktreeForceLevel) ([]byte, error) {
+	args := []string{GitCmdWorktree, GitWorktreeRemove}
+	// git worktree remove:
+	// -f (once): remove unclean worktree
+	// -f -f (twice): also remove locked worktree
+	for range forceLevel {
+		args = append(args, "-f")
+	}
+	args = append(args, path)
+	return g.Run(ctx, args...)
+}
+
+func (g *GitRunner) branchDelete(ctx context.Context, branch string, force bool) ([]byte, error) {
+	flag := "-d"
+	if force {
+		flag = "-D"
+	}
+	return g.Run(ctx, GitCmdBranch, flag, branch)
+}
+
+// IsBranchMerged checks if branch is merged into target.
+func (g *GitRunner) IsBranchMerged(ctx context.Context, branch, target string) (bool, error) {
+	result, err := g.ClassifyBranchMergeStatus(ctx, target)
+	if err != nil {
+		return false, err
+	}
+	return result.Merged[branch], nil
+}
+
+// BranchMergeStatus holds the classification of branches by merge status.
+type BranchMergeStatus struct {
+	// Merged contains branches that are considered merged
+	// (via git branch --merged or upstream gone, excluding same-commit branches).
+	Merged map[string]bool
+	// SameCommit contains branches pointing to the same commit as target.
+	// These are excluded from Merged because they could be newly created or ff-merged.
+	SameCommit map[string]bool
+	// Listed contains every branch reported by git branch --merged,
+	// including same-commit branches.
+	Listed map[string]bool
+	// UpstreamGone contains branches whose upstream no longer exists.
+	UpstreamGone map[string]bool
+}
+
+// ClassifyBranchMergeStatus classifies branches by their merge status relative to target.
+// A branch is merged if it's in `git branch --merged <target>` or if its upstream is gone.
+// Branches pointing to the same commit as target are returned separately in SameCommit.
+// This is more efficient than calling IsBranchMerged for each branch individually.
+func (g *GitRunner) ClassifyBranchMergeStatus(ctx context.Context, target string) (BranchMergeStatus, error) {
+	branches, err := g.BranchStatuses(ctx)
+	if err != nil {
+		return BranchMergeStatus{}, err
+	}
+	return g.ClassifyBranchMergeStatusWith(ctx, target, branches)
+}
+
+// ClassifyBranchMergeStatusWith is ClassifyBranchMergeStatus for branch
+// statuses already collected by BranchStatuses, so several targets can
+// share one for-each-ref call.
+func (g *GitRunner) ClassifyBranchMergeStatusWith(ctx context.Context, target string, branches map[string]BranchStatus) (BranchMergeStatus, error) {
+	result := BranchMergeStatus{
+		Merged:       make(map[string]bool),
+		SameCommit:   make(map[string]bool),
+		Listed:       make(map[string]bool),
+		UpstreamGone: make(map[string]bool),
+	}
+
+	// Upstream gone indicates squash/rebase merges
+	for name, branch := range branches {
+		if branch.Gone {
+			result.Merged[name] = true
+			result.UpstreamGone[name] = true
+		}
+	}
+
+	// Get target commit for same-commit detection
+	var targetCommit string
+	if branch, ok := branches[target]; ok {
+		targetCommit = branch.Commit
+	} else {
+		// Target might be a remote ref or tag, fall back to rev-parse
+		targetHead, err := g.Run(ctx, GitCmdRevParse, target)
+		if err != nil {
+			return result, fmt.Errorf("failed to get target HEAD: %w", err)
+		}
+		targetCommit = strings.TrimSpace(string(targetHead))
+	}
+
+	// Get traditionally merged branches
+	out, err := g.Run(ctx, GitCmdBranch, "--merged", target, "--format=%(refname:short)")
+	if err != nil {
+		return result, fmt.Errorf("failed to check merged branches: %w", err)
+	}
+	for line := range strings.SplitSeq(strings.TrimSpace(string(out)), "\n") {
+		if line == "" {
+			continue
+		}
+		result.Listed[line] = true
+		// Track branches pointing to the same commit as target separately
+		// (could be newly created or ff-merged - we can't distinguish)
+		if branches[line].Commit == targetCommit {
+			result.SameCommit[line] = true
+			continue
+		}
+		result.Merged[line] = true
+	}
+
+	return result, nil
+}
+
+// IsBranchUpstreamGone checks if the branch's upstream tracking branch is gone.
+// This indicates the remote branch was deleted, typically after a PR merge.
+func (g *GitRunner) IsBranchUpstreamGone(ctx context.Context, branch string) (bool, error) {
+	// git for-each-ref --format='%(upstream:track)' refs/heads/<branch>
+	// Returns "[gone]" if upstream was deleted
+	out, err := g.Run(ctx, "for-each-ref", "--format=%(upstream:track)", "refs/heads/"+branch)
+	if err != nil {
+		return false, fmt.Errorf("failed to check upstream status: %w", err)
+	}
+	return strings.TrimSpace(string(out)) == "[gone]", nil
+}
+
+// IsBranchSquashMerged checks if branch was squash-merged into target.
+// The branch changes are collapsed into a single synthetic commit on top of
+// the merge base, then compared against target by patch-id via git cherry.
+// A "-" prefix means an equivalent change already exists in target.
+func (g *GitRunner) IsBranchSquashMerged(ctx context.Context, branch, target string) (bool, error) {
+	baseOut, err := g.Run(ctx, GitCmdMergeBase, target, branch)
+	if err != nil {
+		return false, fmt.Errorf("failed to find merge base: %w", err)
+	}
+	base := strings.TrimSpace(string(baseOut))
+
+	treeOut, err := g.Run(ctx, GitCmdRevParse, branch+"^{tree}")
+	if err != nil {
+		return false, fmt.Errorf("failed to resolve tree: %w", err)
+	}
+	tree := strings.TrimSpace(string(treeOut))
+
+	// commit-tree writes a dangling object only; no refs are updated. The
+	// identity is never seen, but commit-tree fails without one.
+	squashOut, err := g.Run(ctx, "-c", "user.name=twig", "-c", "user.email=twig@localhost",
+		GitCmdCommitTree, tree, "-p", base, "-m", "twig squash check")
+	if err != nil {
+		return false, fmt.Errorf("failed to create squash commit: %w", err)
+	}
+	squash := strings.TrimSpace(string(squashOut))
+
+	cherryOut, err := g.Run(ctx, GitCmdCherry, target, squash)
+	if err != nil {
+		return false, fmt.Errorf("failed to compare patches: %w", err)
+	}
+	return strings.HasPrefix(strings.TrimSpace(string(cherryOut)), "-"), nil
+}
+
+// WorktreePrune removes references to worktrees that no longer exist.
+func (g *GitRunner) WorktreePrune(ctx context.Context) ([]byte, error) {
+	out, err := g.Run(ctx, GitCmdWorktree, GitWorktreePrune)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prune worktrees: %w", err)
+	}
+	return out, nil
+}
+
+// GitCmdSubmodule is the git submodule command.
+const GitCmdSubmodule = "submodule"
+
+// Git submodule subcommands.
+const (
+	GitSubmoduleUpdate = "update"
+	GitSubmoduleDeinit = "deinit"
+)
+
+// SubmoduleCleanStatus indicates whether it's safe to remove a worktree with submodules.
+type SubmoduleCleanStatus int
+
+const (
+	// SubmoduleCleanStatusNone: no initialized submodules exist.
+	SubmoduleCleanStatusNone SubmoduleCleanStatus = iota
+	// SubmoduleCleanStatusClean: submodules exist but all are clean.
+	SubmoduleCleanStatusClean
+	// SubmoduleCleanStatusDirty: submodules have uncommitted changes or are at different commits.
+	SubmoduleCleanStatusDirty
+)
+
+// SubmoduleState represents the state of a submodule.
+type SubmoduleState int
+
+const (
+	SubmoduleStateUninitialized SubmoduleState = iota
+	SubmoduleStateClean
+	SubmoduleStateModified
+	SubmoduleStateConflict
+)
+
+// SubmoduleInfo holds information about a submodule.
+type SubmoduleInfo struct {
+	SHA   string
+	Path  string
+	State SubmoduleState
+}
+
+// SubmoduleStatus runs `git submodule status --recursive` and parses the output.
+// Returns a list of SubmoduleInfo for all submodules.
+func (g *GitRunner) SubmoduleStatus(ctx context.Context) ([]SubmoduleInfo, error) {
+	out, err := g.Run(ctx, GitCmdSubmodule, "status", "--recursive")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get submodule status: %w", err)
+	}
+
+	var submodules []SubmoduleInfo
+	for line := range strings.SplitSeq(string(out), "\n") {
+		if len(line) == 0 {
+			continue
+		}
+
+		// Format: " SHA path (desc)" or "+SHA path (desc)" or "-SHA path (desc)" or "USHA path (desc)"
+		// The first character is the state prefix
+		var state SubmoduleState
+		switch line[0] {
+		case '-':
+			state = SubmoduleStateUninitialized
+		case ' ':
+			state = SubmoduleStateClean
+		case '+':
+			state = SubmoduleStateModified
+		case 'U':
+			state = SubmoduleStateConflict
+		default:
+			// Unknown prefix, skip
+			continue
+		}
+
+		// Parse SHA and path
+		rest := strings.TrimSpace(line[1:])
+		fields := strings.Fields(rest)
+		if len(fields) < 2 {
+			continue
+		}
+
+		submodules = append(submodules, SubmoduleInfo{
+			SHA:   fields[0],
+			Path:  fields[1],
+			State: state,
+		})
+	}
+
+	return submodules, nil
+}
+
+// CheckSubmoduleCleanStatus determines if it's safe to remove a worktree with submodules.
+// Returns:
+//   - SubmoduleCleanStatusNone: no initialized submodules
+//   - SubmoduleCleanStatusClean: submodules exist but are clean (safe to auto-force)
+//   - SubmoduleCleanStatusDirty: submodules have changes (requires user --force)
+func (g *GitRunner) CheckSubmoduleCleanStatus(ctx context.Context) (SubmoduleCleanStatus, error) {
+	submodules, err := g.SubmoduleStatus(ctx)
+	if err != nil {
+		return SubmoduleCleanStatusNone, err
+	}
+
+	var hasInitialized bool
+	for _, sm := range submodules {
+		if sm.State == SubmoduleStateUninitialized {
+			continue
+		}
+		hasInitialized = true
+
+		// Check for modified commit (+ prefix) or conflict (U prefix)
+		if sm.State == SubmoduleStateModified || sm.State == SubmoduleStateConflict {
+			return SubmoduleCleanStatusDirty, nil
+		}
+
+		// Check for uncommitted changes within the submodule
+		// sm.Path is relative to the worktree, so we need to join it with g.Dir
+		smAbsPath := filepath.Join(g.Dir, sm.Path)
+		smRunner := g.InDir(smAbsPath)
+		hasChanges, err := smRunner.HasChanges(ctx)
+		if err != nil {
+			// If we can't check, assume dirty for safety
+			return SubmoduleCleanStatusDirty, nil
+		}
+		if hasChanges {
+			return SubmoduleCleanStatusDirty, nil
+		}
+	}
+
+	if !hasInitialized {
+		return SubmoduleCleanStatusNone, nil
+	}
+	return SubmoduleCleanStatusClean, nil
+}
+
+// SubmoduleDeinit runs `git submodule deinit -f --all`, which unregisters
+// every submodule and clears its working tree, discarding local changes.
+// The module git directories are left in place.
+func (g *GitRunner) SubmoduleDeinit(ctx context.Context) ([]byte, error) {
+	return g.Run(ctx, GitCmdSubmodule, GitSubmoduleDeinit, "-f", "--all")
+}
+
+// WorktreeRoot returns the root path of the worktree containing the current directory.
+// Uses git rev-parse --show-toplevel which returns the path git internally uses.
+func (g *GitRunner) WorktreeRoot(ctx context.Context) (string, error) {
+	out, err := g.Run(ctx, GitCmdRevParse, "--show-toplevel")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// CurrentBranch returns the branch of the worktree containing the current
+// directory, or "HEAD" when it is detached, like git rev-parse
+// --abbrev-ref HEAD. In a jj colocated repository it is the bookmark at
+// HEAD (see VCS).
+func (g *GitRunner) CurrentBranch(ctx context.Context) (string, error) {
+	root, err := g.WorktreeRoot(ctx)
+	if err != nil {
+		return "", err
+	}
+	worktrees, err := g.WorktreeList(ctx)
+	if err != nil {
+		return "", err
+	}
+	for _, wt := range worktrees {
+		if wt.Path == root && wt.Branch != "" {
+			return wt.Branch, nil
+		}
+	}
+	return "HEAD", nil
+}
+
+// SubmoduleUpdateOption configures SubmoduleUpdate behavior.
+type SubmoduleUpdateOption func(*submoduleUpdateOptions)
+
+type submoduleUpdateOptions struct {
+	referenceDirs []string
+	paths         []string
+	noRecursive   bool
+}
+
+// WithSubmoduleReference enables --reference optimization using main worktree's modules.
+func WithSubmoduleReference(mainWorktreePath string) SubmoduleUpdateOption {
+	if mainWorktreePath == "" {
+		return func(*submoduleUpdateOptions) {}
+	}
+	return WithSubmoduleReferenceDir(filepath.Join(mainWorktreePath, ".git", "modules"))
+}
+
+// WithSubmoduleReferenceDir enables --reference optimization using the
+// repositories in dir, one per submodule path like .git/modules. When
+// given several times, the first directory holding a submodule is used.
+func WithSubmoduleReferenceDir(dir string) SubmoduleUpdateOption {
+	return func(o *submoduleUpdateOptions) {
+		o.referenceDirs = append(o.referenceDirs, dir)
+	}
+}
+
+// WithSubmodulePaths limits initialization to the submodules matching
+// paths: a submodule path, a directory containing submodules, or a glob
+// pattern such as "libs/*".
+func WithSubmodulePaths(paths []string) SubmoduleUpdateOption {
+	return func(o *submoduleUpdateOptions) {
+		o.paths = paths
+	}
+}
+
+// WithoutSubmoduleRecursion initializes only the submodules themselves,
+// leaving their nested submodules uninitialized.
+func WithoutSubmoduleRecursion() SubmoduleUpdateOption {
+	return func(o *submoduleUpdateOptions) {
+		o.noRecursive = true
+	}
+}
+
+// SubmoduleUpdateResult holds the result of SubmoduleUpdate.
+type SubmoduleUpdateResult struct {
+	Count       int      // number of initialized submodules
+	NoReference []string // submodules that couldn't use reference
+	Unmatched   []string // WithSubmodulePaths entries that matched no submodule
+}
+
+// SubmoduleUpdate runs git submodule update --init, recursing into nested
+// submodules unless WithoutSubmoduleRecursion is given.
+// With WithSubmoduleReference, uses --reference for faster initialization.
+// With WithSubmodulePaths, only the matching submodules are initialized.
+func (g *GitRunner) SubmoduleUpdate(ctx context.Context, opts ...SubmoduleUpdateOption) (SubmoduleUpdateResult, error) {
+	defer g.Timings.recordSince(TimingPhaseSubmodules, time.Now())
+
+	var o submoduleUpdateOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	// Without reference or selection: init all at once
+	if len(o.referenceDirs) == 0 && len(o.paths) == 0 {
+		args := []string{GitCmdSubmodule, GitSubmoduleUpdate, "--init"}
+		if !o.noRecursive {
+			args = append(args, "--recursive")
+		}
+		if _, err := g.Run(ctx, args...); err != nil {
+			return SubmoduleUpdateResult{}, fmt.Errorf("failed to initialize submodules: %w", err)
+		}
+
+		submodules, err := g.SubmoduleStatus(ctx)
+		if err != nil {
+			return SubmoduleUpdateResult{}, nil
+		}
+		var count int
+		for _, sm := range submodules {
+			if sm.State != SubmoduleStateUninitialized {
+				count++
+			}
+		}
+		return SubmoduleUpdateResult{Count: count}, nil
+	}
+
+	// With reference or selection: init each submodule individually
+	submodules, err := g.SubmoduleStatus(ctx)
+	if err != nil {
+		return SubmoduleUpdateResult{}, fmt.Errorf("failed to get submodule status: %w", err)
+	}
+
+	var result SubmoduleUpdateResult
+	if len(o.paths) > 0 {
+		submodules, result.Unmatched = selectSubmodules(submodules, o.paths)
+	}
+	for _, sm := range submodules {
+		if sm.State != SubmoduleStateUninitialized {
+			result.Count++
+			continue
+		}
+
+		args := []string{GitCmdSubmodule, GitSubmoduleUpdate, "--init"}
+		if len(o.referenceDirs) > 0 {
+			if refPath := findSubmoduleReference(o.referenceDirs, sm.Path); refPath != "" {
+				args = append(args, "--reference", refPath)
+			} else {
+				result.NoReference = append(result.NoReference, sm.Path)
+			}
+		} else if !o.noRecursive {
+			args = append(args, "--recursive")
+		}
+		args = append(args, "--", sm.Path)
+
+		if _, runErr := g.Run(ctx, args...); runErr != nil {
+			g.Log.Debug("submodule init failed", "path", sm.Path, "error", runErr)
+			continue
+		}
+		result.Count++
+
+		// The reference only holds the submodule's own objects, so nested
+		// submodules are initialized in a second step without it
+		if len(o.referenceDirs) > 0 && !o.noRecursive {
+			if _, statErr := statFunc(filepath.Join(g.Dir, sm.Path, ".gitmodules")); statErr == nil {
+				nested := []string{GitCmdSubmodule, GitSubmoduleUpdate, "--init", "--recursive", "--", sm.Path}
+				if _, runErr := g.Run(ctx, nested...); runErr != nil {
+					g.Log.Debug("nested submodule init failed", "path", sm.Path, "error", runErr)
+				}
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// findSubmoduleReference returns the repository of the submodule at path
+// in the first of dirs that has one, or "" if none does.
+func findSubmoduleReference(dirs []string, path string) string {
+	for _, dir := range dirs {
+		refPath := filepath.Join(dir, path)
+		if _, err := statFunc(refPath); err == nil {
+			return refPath
+		}
+	}
+	return ""
+}
+
+// selectSubmodules returns the top-level submodules matching paths, in
+// status order, and the paths that matched none. Nested submodules are
+// left to recursion, since git only initializes them through their parent.
+func selectSubmodules(submodules []SubmoduleInfo, paths []string) ([]SubmoduleInfo, []string) {
+	var selected []SubmoduleInfo
+	matched := make(map[string]bool)
+	for _, sm := range submodules {
+		if slices.ContainsFunc(submodules, func(parent SubmoduleInfo) bool {
+			return strings.HasPrefix(sm.Path, parent.Path+"/")
+		}) {
+			continue
+		}
+		for _, p := range paths {
+			p = strings.TrimSuffix(p, "/")
+			if ok, _ := path.Match(p, sm.Path); ok || sm.Path == p || strings.HasPrefix(sm.Path, p+"/") {
+				matched[p] = true
+				if !slices.ContainsFunc(selected, func(s SubmoduleInfo) bool { return s.Path == sm.Path }) {
+					selected = append(selected, sm)
+				}
+			}
+		}
+	}
+	var unmatched []string
+	for _, p := range paths {
+		if !matched[strings.TrimSuffix(p, "/")] {
+			unmatched = append(unmatched, p)
+		}
+	}
+	return selected, unmatched
+}
+
+// GitDir returns the worktree-specific git directory.
+// For the main worktree this is .git, for linked worktrees .git/worktrees/<name>.
+func (g *GitRunner) GitDir(ctx context.Context) (string, error) {
+	out, err := g.Run(ctx, GitCmdRevParse, "--path-format=absolute", "--git-dir")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// GitCommonDir returns the git directory shared by all worktrees.
+// For linked worktrees this is the main worktree's .git directory.
+func (g *GitRunner) GitCommonDir(ctx context.Context) (string, error) {
+	out, err := g.Run(ctx, GitCmdRevParse, "--path-format=absolute", "--git-common-dir")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// HooksDir returns the directory git runs hooks from. core.hooksPath is
+// honored, so this may lie outside the git directory.
+func (g *GitRunner) HooksDir(ctx context.Context) (string, error) {
+	out, err := g.Run(ctx, GitCmdRevParse, "--path-format=absolute", "--git-path", "hooks")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// RepositoryPaths locates the repository containing a directory, which
+// may be any subdirectory of any worktree.
+type RepositoryPaths struct {
+	WorktreeRoot string // Root of the worktree containing the directory (empty in a bare repository or a git directory)
+	CommonDir    string // Git directory shared by all worktrees
+}
+
+// MainWorktree returns the path of the main worktree, the parent of
+// CommonDir.
+func (p RepositoryPaths) MainWorktree() string {
+	return filepath.Dir(p.CommonDir)
+}
+
+// RepositoryPaths resolves the repository containing the runner's
+// directory with a single git rev-parse. WorktreeRoot is derived from the
+// directory with --show-cdup rather than --show-toplevel, so a worktree
+// reached through a symlink keeps the path it was reached by.
+func (g *GitRunner) RepositoryPaths(ctx context.Context) (RepositoryPaths, error) {
+	out, err := g.Run(ctx, GitCmdRevParse, "--path-format=absolute", "--git-common-dir", "--is-inside-work-tree", "--show-cdup")
+	if err != nil {
+		return RepositoryPaths{}, err
+	}
+	// The --show-cdup line is empty at the worktree root, and missing
+	// outside a work tree
+	lines := strings.Split(strings.TrimRight(string(out), "\n"), "\n")
+	if len(lines) < 2 || lines[0] == "" {
+		return RepositoryPaths{}, fmt.Errorf("unexpected git rev-parse output: %q", out)
+	}
+	paths := RepositoryPaths{CommonDir: lines[0]}
+	if lines[1] == "true" {
+		dir, err := filepath.Abs(g.Dir)
+		if err != nil {
+			return RepositoryPaths{}, fmt.Errorf("failed to resolve %s: %w", g.Dir, err)
+		}
+		var cdup string
+		if len(lines) > 2 {
+			cdup = lines[2]
+		}
+		paths.WorktreeRoot = filepath.Join(dir, cdup)
+	}
+	return paths, nil
+}
+
+// MainWorktreePath returns the path of the main worktree.
+// Uses git rev-parse --git-common-dir which returns the shared .git directory.
+func (g *GitRunner) MainWorktreePath(ctx context.Context) (string, error) {
+	gitDir, err := g.GitCommonDir(ctx)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Dir(gitDir), nil
+}
+
+// IsFirstParentAncestor checks if commit is on the first-parent lineage of target.
+// This distinguishes WIP branches (ancestor on first-parent line) from genuinely
+// merged branches (reachable only via merge commit second parent).
+func (g *GitRunner) IsFirstParentAncestor(ctx context.Context, commit, target string) (bool, error) {
+	// Get the parent of commit to use as exclusion boundary
+	parentOut, err := g.Run(ctx, GitCmdRevParse, commit+"^")
+	isRoot := err != nil // root commit has no parent
+
+	if isRoot {
+		// Root commit: check if commit appears in first-parent list of target
+		out, err := g.Run(ctx, GitCmdRevList, "--first-parent", target)
+		if err != nil {
+			return false, fmt.Errorf("failed to list first-parent commits: %w", err)
+		}
+		for line := range strings.SplitSeq(strings.TrimSpace(string(out)), "\n") {
+			if line == commit {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+
+	parent := strings.TrimSpace(string(parentOut))
+
+	// List commits on first-parent lineage of target that are NOT ancestors of parent.
+	// If commit appears in this list, it means commit itself is on the first-parent line.
+	out, err := g.Run(ctx, GitCmdRevList, "--first-parent", target, "--not", parent)
+	if err != nil {
+		return false, fmt.Errorf("failed to list first-parent commits: %w", err)
+	}
+	for line := range strings.SplitSeq(strings.TrimSpace(string(out)), "\n") {
+		if line == commit {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// statFunc is a variable for testing purposes.
+var statFunc = osStat
+
+func osStat(path string) (any, error) {
+	return os.Stat(path)
+}
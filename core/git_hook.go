@@ -0,0 +1,209 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// GitHookPostMerge is the git hook that runs after git merge and git pull.
+const GitHookPostMerge = "post-merge"
+
+// SupportedGitHooks lists the git hooks twig can install.
+var SupportedGitHooks = []string{GitHookPostMerge}
+
+// DefaultGitHookInterval is the minimum time between two syncs run by the hook.
+const DefaultGitHookInterval = time.Minute
+
+// gitHookMarker identifies hook scripts written by twig, so that hooks from
+// other tools are never overwritten or removed by accident.
+const gitHookMarker = "# twig-hook: "
+
+// EnvNoSyncHook disables the installed hook for a single git command.
+const EnvNoSyncHook = "TWIG_NO_SYNC_HOOK"
+
+// GitHookOptions configures the hook command.
+type GitHookOptions struct {
+	Source    string        // Branch whose worktree runs the hook (default: default_source, then main)
+	Interval  time.Duration // Minimum time between syncs (0 = DefaultGitHookInterval)
+	Force     bool          // Overwrite a hook not installed by twig
+	Uninstall bool          // Remove the hook instead of installing it
+}
+
+// GitHookResult holds the result of installing or removing a hook.
+type GitHookResult struct {
+	Hook       string
+	Path       string // Hook script path
+	SourcePath string // Worktree the hook syncs from (install only)
+	Replaced   bool   // An existing hook was overwritten
+	Removed    bool   // The hook was uninstalled
+}
+
+// Format formats the GitHookResult for display.
+func (r GitHookResult) Format(opts FormatOptions) FormatResult {
+	var stdout bytes.Buffer
+	if r.Removed {
+		fmt.Fprintf(&stdout, "twig hook: removed %s (%s)\n", r.Hook, r.Path)
+		return FormatResult{Stdout: stdout.String()}
+	}
+	if opts.Verbose {
+		if r.Replaced {
+			fmt.Fprintf(&stdout, "Replaced existing hook: %s\n", r.Path)
+		}
+		fmt.Fprintf(&stdout, "Runs in: %s\n", r.SourcePath)
+	}
+	fmt.Fprintf(&stdout, "twig hook: installed %s (%s)\n", r.Hook, r.Path)
+	return FormatResult{Stdout: stdout.String()}
+}
+
+// GitHookCommand installs and removes git hooks that keep worktrees in sync.
+type GitHookCommand struct {
+	FS     FileSystem
+	Git    *GitRunner
+	Config *Config
+	Log    *slog.Logger
+}
+
+// NewGitHookCommand creates a GitHookCommand with explicit dependencies.
+func NewGitHookCommand(fs FileSystem, git *GitRunner, cfg *Config, log *slog.Logger) *GitHookCommand {
+	if log == nil {
+		log = NewNopLogger()
+	}
+	return &GitHookCommand{
+		FS:     fs,
+		Git:    git,
+		Config: cfg,
+		Log:    log,
+	}
+}
+
+// NewDefaultGitHookCommand creates a GitHookCommand with production defaults.
+func NewDefaultGitHookCommand(cfg *Config, log *slog.Logger, gitOpts ...GitRunnerOption) *GitHookCommand {
+	git := newDefaultGitRunner(cfg.WorktreeSourceDir, log, gitOpts)
+	return NewGitHookCommand(git.defaultFS(), git, cfg, log)
+}
+
+// Run installs hook, or removes it with opts.Uninstall.
+//
+// The post-merge hook runs twig sync --all --quiet after each merge or
+// pull, but only in the source worktree: git shares hooks between all
+// worktrees, and a pull elsewhere does not change what is synced.
+// Syncs are rate limited to one per opts.Interval, a missing twig binary
+// is ignored, and a failed sync never fails the merge.
+func (c *GitHookCommand) Run(ctx context.Context, hook string, opts GitHookOptions) (GitHookResult, error) {
+	result := GitHookResult{Hook: hook}
+	if !slices.Contains(SupportedGitHooks, hook) {
+		return result, fmt.Errorf("unsupported hook %q (supported: %s)", hook, strings.Join(SupportedGitHooks, ", "))
+	}
+
+	hooksDir, err := c.Git.HooksDir(ctx)
+	if err != nil {
+		return result, fmt.Errorf("failed to get hooks directory: %w", err)
+	}
+	result.Path = filepath.Join(hooksDir, hook)
+
+	existing, err := c.FS.ReadFile(result.Path)
+	exists := err == nil
+	if err != nil && !c.FS.IsNotExist(err) {
+		return result, fmt.Errorf("failed to read %s: %w", result.Path, err)
+	}
+	ours := exists && strings.Contains(string(existing), gitHookMarker+hook)
+
+	if opts.Uninstall {
+		if !exists {
+			return result, fmt.Errorf("%s hook is not installed", hook)
+		}
+		if !ours {
+			return result, fmt.Errorf("%s was not installed by twig, not removing it", result.Path)
+		}
+		if err := c.FS.Remove(result.Path); err != nil {
+			return result, fmt.Errorf("failed to remove %s: %w", result.Path, err)
+		}
+		result.Removed = true
+		return result, nil
+	}
+
+	if exists && !ours && !opts.Force {
+		return result, fmt.Errorf("%s already exists and was not installed by twig (use --force to overwrite)", result.Path)
+	}
+	result.Replaced = exists && !ours
+
+	result.SourcePath, err = c.sourcePath(ctx, opts.Source)
+	if err != nil {
+		return result, err
+	}
+	interval := opts.Interval
+	if interval <= 0 {
+		interval = DefaultGitHookInterval
+	}
+
+	if err := c.FS.MkdirAll(hooksDir, 0755); err != nil {
+		return result, fmt.Errorf("failed to create hooks directory: %w", err)
+	}
+	// Remove first: WriteFile keeps the mode of an existing file, and the
+	// hook must be executable
+	if exists {
+		if err := c.FS.Remove(result.Path); err != nil {
+			return result, fmt.Errorf("failed to replace %s: %w", result.Path, err)
+		}
+	}
+	if err := c.FS.WriteFile(result.Path, []byte(postMergeHookScript(result.SourcePath, interval)), 0755); err != nil {
+		return result, fmt.Errorf("failed to write %s: %w", result.Path, err)
+	}
+
+	c.Log.DebugContext(ctx, "hook installed",
+		"hook", hook,
+		"path", result.Path,
+		"source", result.SourcePath,
+		"interval", interval.String())
+	return result, nil
+}
+
+// sourcePath returns the worktree of source, default_source, or the main
+// worktree, in that order.
+func (c *GitHookCommand) sourcePath(ctx context.Context, source string) (string, error) {
+	if source == "" && c.Config != nil {
+		source = c.Config.DefaultSource
+	}
+	if source == "" {
+		return c.Git.MainWorktreePath(ctx)
+	}
+	wt, err := c.Git.WorktreeFindByBranch(ctx, source)
+	if err != nil {
+		return "", err
+	}
+	return wt.Path, nil
+}
+
+// postMergeHookScript returns a post-merge hook that syncs all worktrees
+// when run in sourcePath, at most once per interval.
+func postMergeHookScript(sourcePath string, interval time.Duration) string {
+	seconds := max(int(interval.Seconds()), 1)
+	return `#!/bin/sh
+` + gitHookMarker + GitHookPostMerge + `
+# Installed by twig hook install. Syncs symlinks and submodules to all
+# worktrees after a merge or pull in the source worktree.
+# Remove with: twig hook uninstall ` + GitHookPostMerge + `
+# Skip once with: ` + EnvNoSyncHook + `=1 git pull
+
+[ -z "$` + EnvNoSyncHook + `" ] || exit 0
+command -v twig >/dev/null 2>&1 || exit 0
+[ "$(git rev-parse --show-toplevel 2>/dev/null)" = ` + shellQuote(sourcePath) + ` ] || exit 0
+
+# At most one sync every ` + fmt.Sprint(seconds) + ` seconds
+stamp="$(git rev-parse --path-format=absolute --git-common-dir)/twig/` + GitHookPostMerge + `.stamp"
+now=$(date +%s)
+last=$(cat "$stamp" 2>/dev/null)
+case $last in '' | *[!0-9]*) last=0 ;; esac
+[ $((now - last)) -ge ` + fmt.Sprint(seconds) + ` ] || exit 0
+mkdir -p "$(dirname "$stamp")" && echo "$now" >"$stamp"
+
+twig sync --all --quiet >/dev/null || echo "twig: sync after merge failed, run twig sync --all to retry" >&2
+exit 0
+`
+}
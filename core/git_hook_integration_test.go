@@ -0,0 +1,108 @@
+//go:build integration
+
+package core
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/708u/twig/internal/testutil"
+)
+
+func TestGitHookCommand_Integration(t *testing.T) {
+	t.Parallel()
+
+	repoDir, mainDir := testutil.SetupTestRepo(t, testutil.WithoutSettings())
+	wtDir := filepath.Join(repoDir, "feat-a")
+	testutil.RunGit(t, mainDir, "worktree", "add", wtDir, "-b", "feat/a")
+
+	// A fake twig records its arguments instead of syncing
+	binDir := t.TempDir()
+	logPath := filepath.Join(binDir, "calls")
+	fakeTwig := "#!/bin/sh\necho \"$@\" >>" + shellQuote(logPath) + "\n"
+	if err := os.WriteFile(filepath.Join(binDir, "twig"), []byte(fakeTwig), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := NewGitHookCommand(osFS{}, NewGitRunner(wtDir), &Config{}, nil)
+	result, err := cmd.Run(t.Context(), GitHookPostMerge, GitHookOptions{})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if want := filepath.Join(mainDir, ".git", "hooks", GitHookPostMerge); result.Path != want {
+		t.Errorf("Path = %s, want %s", result.Path, want)
+	}
+	if result.SourcePath != mainDir {
+		t.Errorf("SourcePath = %s, want %s", result.SourcePath, mainDir)
+	}
+	info, err := os.Stat(result.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode()&0111 == 0 {
+		t.Errorf("hook mode = %v, want executable", info.Mode())
+	}
+
+	runHook := func(dir string, env ...string) {
+		t.Helper()
+		c := exec.Command(result.Path)
+		c.Dir = dir
+		c.Env = append(os.Environ(), append(env, "PATH="+binDir+string(os.PathListSeparator)+os.Getenv("PATH"))...)
+		if out, err := c.CombinedOutput(); err != nil {
+			t.Fatalf("hook failed: %v\n%s", err, out)
+		}
+	}
+	calls := func() []string {
+		data, err := os.ReadFile(logPath)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		return strings.Split(strings.TrimSpace(string(data)), "\n")
+	}
+
+	runHook(wtDir)
+	if got := calls(); len(got) != 0 {
+		t.Fatalf("hook ran outside the source worktree: %v", got)
+	}
+	runHook(mainDir, EnvNoSyncHook+"=1")
+	if got := calls(); len(got) != 0 {
+		t.Fatalf("hook ran with %s set: %v", EnvNoSyncHook, got)
+	}
+
+	runHook(mainDir)
+	if got := calls(); len(got) != 1 || got[0] != "sync --all --quiet" {
+		t.Fatalf("calls = %q, want one sync --all --quiet", got)
+	}
+	runHook(mainDir)
+	if got := calls(); len(got) != 1 {
+		t.Errorf("calls = %q, want the second run rate limited", got)
+	}
+
+	if _, err := cmd.Run(t.Context(), GitHookPostMerge, GitHookOptions{Uninstall: true}); err != nil {
+		t.Fatalf("uninstall failed: %v", err)
+	}
+	if _, err := os.Stat(result.Path); !os.IsNotExist(err) {
+		t.Errorf("hook still exists after uninstall, err = %v", err)
+	}
+}
+
+func TestGitRunner_HooksDir_Integration(t *testing.T) {
+	t.Parallel()
+
+	_, mainDir := testutil.SetupTestRepo(t, testutil.WithoutSettings())
+	testutil.RunGit(t, mainDir, "config", "core.hooksPath", ".githooks")
+
+	got, err := NewGitRunner(mainDir).HooksDir(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(mainDir, ".githooks"); got != want {
+		t.Errorf("HooksDir() = %s, want %s", got, want)
+	}
+}
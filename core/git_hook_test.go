@@ -0,0 +1,213 @@
+package core
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/708u/twig/internal/testutil"
+)
+
+const testHookPath = "/repo/main/.git/hooks/post-merge"
+
+func TestGitHookCommand_Run(t *testing.T) {
+	t.Parallel()
+
+	ours := postMergeHookScript("/repo/main", time.Minute)
+	foreign := "#!/bin/sh\nnpx lint-staged\n"
+
+	tests := []struct {
+		name         string
+		hook         string
+		existing     string // Current hook content ("" = none)
+		config       *Config
+		opts         GitHookOptions
+		wantErr      string
+		wantSource   string
+		wantReplaced bool
+		wantRemoved  bool
+		wantContent  string // Hook content after Run ("" = removed or unchanged)
+	}{
+		{
+			name:        "install",
+			hook:        GitHookPostMerge,
+			wantSource:  "/repo/main",
+			wantContent: "= '/repo/main' ]",
+		},
+		{
+			name:        "install_for_default_source",
+			hook:        GitHookPostMerge,
+			config:      &Config{DefaultSource: "develop"},
+			wantSource:  "/repo/develop",
+			wantContent: "= '/repo/develop' ]",
+		},
+		{
+			name:        "install_with_interval",
+			hook:        GitHookPostMerge,
+			opts:        GitHookOptions{Interval: 5 * time.Minute},
+			wantSource:  "/repo/main",
+			wantContent: "-ge 300 ]",
+		},
+		{
+			name:        "reinstall_replaces_own_hook",
+			hook:        GitHookPostMerge,
+			existing:    ours,
+			opts:        GitHookOptions{Source: "develop"},
+			wantSource:  "/repo/develop",
+			wantContent: "= '/repo/develop' ]",
+		},
+		{
+			name:     "refuse_foreign_hook",
+			hook:     GitHookPostMerge,
+			existing: foreign,
+			wantErr:  "already exists and was not installed by twig (use --force to overwrite)",
+		},
+		{
+			name:         "force_replaces_foreign_hook",
+			hook:         GitHookPostMerge,
+			existing:     foreign,
+			opts:         GitHookOptions{Force: true},
+			wantSource:   "/repo/main",
+			wantReplaced: true,
+			wantContent:  "twig sync --all --quiet",
+		},
+		{
+			name:        "uninstall",
+			hook:        GitHookPostMerge,
+			existing:    ours,
+			opts:        GitHookOptions{Uninstall: true},
+			wantRemoved: true,
+		},
+		{
+			name:     "uninstall_foreign_hook",
+			hook:     GitHookPostMerge,
+			existing: foreign,
+			opts:     GitHookOptions{Uninstall: true},
+			wantErr:  "was not installed by twig",
+		},
+		{
+			name:    "uninstall_missing",
+			hook:    GitHookPostMerge,
+			opts:    GitHookOptions{Uninstall: true},
+			wantErr: "post-merge hook is not installed",
+		},
+		{
+			name:    "unsupported_hook",
+			hook:    "pre-commit",
+			wantErr: `unsupported hook "pre-commit"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			files := map[string][]byte{}
+			if tt.existing != "" {
+				files[testHookPath] = []byte(tt.existing)
+			}
+			var removed []string
+			mockFS := &testutil.MockFS{
+				WrittenFiles: files,
+				RemoveFunc: func(name string) error {
+					removed = append(removed, name)
+					delete(files, name)
+					return nil
+				},
+			}
+			mockGit := &testutil.MockGitExecutor{
+				GitCommonDir: "/repo/main/.git",
+				Worktrees: []testutil.MockWorktree{
+					{Path: "/repo/main", Branch: "main"},
+					{Path: "/repo/develop", Branch: "develop"},
+				},
+			}
+			cfg := tt.config
+			if cfg == nil {
+				cfg = &Config{}
+			}
+			git := &GitRunner{Executor: mockGit, Dir: "/repo/main", Log: NewNopLogger()}
+			cmd := NewGitHookCommand(mockFS, git, cfg, nil)
+
+			result, err := cmd.Run(t.Context(), tt.hook, tt.opts)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want containing %q", err, tt.wantErr)
+				}
+				if tt.existing != "" && string(files[testHookPath]) != tt.existing {
+					t.Error("existing hook was modified")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if result.Path != testHookPath {
+				t.Errorf("Path = %s, want %s", result.Path, testHookPath)
+			}
+			if result.Replaced != tt.wantReplaced {
+				t.Errorf("Replaced = %v, want %v", result.Replaced, tt.wantReplaced)
+			}
+			if result.Removed != tt.wantRemoved {
+				t.Errorf("Removed = %v, want %v", result.Removed, tt.wantRemoved)
+			}
+			if tt.wantRemoved {
+				if _, ok := files[testHookPath]; ok {
+					t.Error("hook was not removed")
+				}
+				return
+			}
+			if result.SourcePath != tt.wantSource {
+				t.Errorf("SourcePath = %s, want %s", result.SourcePath, tt.wantSource)
+			}
+			content := string(files[testHookPath])
+			if !strings.Contains(content, tt.wantContent) {
+				t.Errorf("hook content does not contain %q:\n%s", tt.wantContent, content)
+			}
+			if tt.existing != "" && len(removed) == 0 {
+				t.Error("existing hook was not removed before writing, mode would be kept")
+			}
+		})
+	}
+}
+
+func TestGitHookResult_Format(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		result GitHookResult
+		opts   FormatOptions
+		want   string
+	}{
+		{
+			name:   "installed",
+			result: GitHookResult{Hook: "post-merge", Path: "/repo/.git/hooks/post-merge", SourcePath: "/repo/main"},
+			want:   "twig hook: installed post-merge (/repo/.git/hooks/post-merge)\n",
+		},
+		{
+			name:   "installed_verbose",
+			result: GitHookResult{Hook: "post-merge", Path: "/repo/.git/hooks/post-merge", SourcePath: "/repo/main", Replaced: true},
+			opts:   FormatOptions{Verbose: true},
+			want: "Replaced existing hook: /repo/.git/hooks/post-merge\n" +
+				"Runs in: /repo/main\n" +
+				"twig hook: installed post-merge (/repo/.git/hooks/post-merge)\n",
+		},
+		{
+			name:   "removed",
+			result: GitHookResult{Hook: "post-merge", Path: "/repo/.git/hooks/post-merge", Removed: true},
+			want:   "twig hook: removed post-merge (/repo/.git/hooks/post-merge)\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := tt.result.Format(tt.opts).Stdout; got != tt.want {
+				t.Errorf("Stdout = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,549 @@
+//go:build integration
+
+package core
+
+import (
+	"cmp"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/708u/twig/internal/testutil"
+)
+
+func TestGitRunner_WorktreeFindByBranch_Integration(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Found", func(t *testing.T) {
+		t.Parallel()
+
+		repoDir, mainDir := testutil.SetupTestRepo(t, testutil.WithoutSettings())
+
+		wtPath := filepath.Join(repoDir, "feature-wt")
+		testutil.RunGit(t, mainDir, "worktree", "add", wtPath, "-b", "feature/test")
+
+		runner := NewGitRunner(mainDir)
+
+		got, err := runner.WorktreeFindByBranch(t.Context(), "feature/test")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got.Path != wtPath {
+			t.Errorf("got %q, want %q", got.Path, wtPath)
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		t.Parallel()
+
+		_, mainDir := testutil.SetupTestRepo(t, testutil.WithoutSettings())
+
+		runner := NewGitRunner(mainDir)
+
+		_, err := runner.WorktreeFindByBranch(t.Context(), "nonexistent")
+		if err == nil {
+			t.Fatal("expected error, got nil")
+		}
+		if !strings.Contains(err.Error(), "not checked out in any worktree") {
+			t.Errorf("error %q should contain 'not checked out in any worktree'", err.Error())
+		}
+	})
+}
+
+func TestGitRunner_WorktreeRemove_Integration(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Success", func(t *testing.T) {
+		t.Parallel()
+
+		repoDir, mainDir := testutil.SetupTestRepo(t, testutil.WithoutSettings())
+
+		wtPath := filepath.Join(repoDir, "to-remove")
+		testutil.RunGit(t, mainDir, "worktree", "add", wtPath, "-b", "to-remove")
+
+		runner := NewGitRunner(mainDir)
+
+		_, err := runner.WorktreeRemove(t.Context(), wtPath)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		out := testutil.RunGit(t, mainDir, "worktree", "list")
+		if strings.Contains(out, "to-remove") {
+			t.Errorf("worktree should be removed, but still in list: %s", out)
+		}
+	})
+
+	t.Run("NotExists", func(t *testing.T) {
+		t.Parallel()
+
+		_, mainDir := testutil.SetupTestRepo(t, testutil.WithoutSettings())
+
+		runner := NewGitRunner(mainDir)
+
+		_, err := runner.WorktreeRemove(t.Context(), "/nonexistent/path")
+		if err == nil {
+			t.Fatal("expected error, got nil")
+		}
+		if !strings.Contains(err.Error(), "failed to remove worktree") {
+			t.Errorf("error %q should contain 'failed to remove worktree'", err.Error())
+		}
+	})
+}
+
+func TestGitRunner_ChangedFiles_Integration(t *testing.T) {
+	t.Parallel()
+
+	writeFile := func(t *testing.T, dir, name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("NoChanges", func(t *testing.T) {
+		t.Parallel()
+
+		_, mainDir := testutil.SetupTestRepo(t, testutil.WithoutSettings())
+
+		runner := NewGitRunner(mainDir)
+
+		files, err := runner.ChangedFiles(t.Context())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(files) != 0 {
+			t.Errorf("expected empty list, got %v", files)
+		}
+	})
+
+	t.Run("StagedFile", func(t *testing.T) {
+		t.Parallel()
+
+		_, mainDir := testutil.SetupTestRepo(t, testutil.WithoutSettings())
+
+		writeFile(t, mainDir, "staged.txt", "content")
+		testutil.RunGit(t, mainDir, "add", "staged.txt")
+
+		runner := NewGitRunner(mainDir)
+
+		files, err := runner.ChangedFiles(t.Context())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(files) != 1 || files[0].Path != "staged.txt" {
+			t.Errorf("expected [staged.txt], got %v", files)
+		}
+	})
+
+	t.Run("UnstagedFile", func(t *testing.T) {
+		t.Parallel()
+
+		_, mainDir := testutil.SetupTestRepo(t, testutil.WithoutSettings())
+
+		// Create and commit a file first
+		writeFile(t, mainDir, "tracked.txt", "original")
+		testutil.RunGit(t, mainDir, "add", "tracked.txt")
+		testutil.RunGit(t, mainDir, "commit", "-m", "add tracked file")
+
+		// Modify it
+		writeFile(t, mainDir, "tracked.txt", "modified")
+
+		runner := NewGitRunner(mainDir)
+
+		files, err := runner.ChangedFiles(t.Context())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(files) != 1 || files[0].Path != "tracked.txt" {
+			t.Errorf("expected [tracked.txt], got %v", files)
+		}
+	})
+
+	t.Run("UntrackedFile", func(t *testing.T) {
+		t.Parallel()
+
+		_, mainDir := testutil.SetupTestRepo(t, testutil.WithoutSettings())
+
+		writeFile(t, mainDir, "untracked.txt", "content")
+
+		runner := NewGitRunner(mainDir)
+
+		files, err := runner.ChangedFiles(t.Context())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(files) != 1 || files[0].Path != "untracked.txt" {
+			t.Errorf("expected [untracked.txt], got %v", files)
+		}
+	})
+
+	t.Run("MixedChanges", func(t *testing.T) {
+		t.Parallel()
+
+		_, mainDir := testutil.SetupTestRepo(t, testutil.WithoutSettings())
+
+		// Tracked and modified (commit first, then modify)
+		writeFile(t, mainDir, "tracked.txt", "original")
+		testutil.RunGit(t, mainDir, "add", "tracked.txt")
+		testutil.RunGit(t, mainDir, "commit", "-m", "add tracked")
+		writeFile(t, mainDir, "tracked.txt", "modified")
+
+		// Staged (add after commit)
+		writeFile(t, mainDir, "staged.txt", "content")
+		testutil.RunGit(t, mainDir, "add", "staged.txt")
+
+		// Untracked
+		writeFile(t, mainDir, "untracked.txt", "content")
+
+		runner := NewGitRunner(mainDir)
+
+		files, err := runner.ChangedFiles(t.Context())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(files) != 3 {
+			t.Errorf("expected 3 files, got %v", files)
+		}
+	})
+
+	t.Run("RenamedFile", func(t *testing.T) {
+		t.Parallel()
+
+		_, mainDir := testutil.SetupTestRepo(t, testutil.WithoutSettings())
+
+		// Create and commit a file
+		writeFile(t, mainDir, "old.txt", "content")
+		testutil.RunGit(t, mainDir, "add", "old.txt")
+		testutil.RunGit(t, mainDir, "commit", "-m", "add old file")
+
+		// Rename it
+		testutil.RunGit(t, mainDir, "mv", "old.txt", "new.txt")
+
+		runner := NewGitRunner(mainDir)
+
+		files, err := runner.ChangedFiles(t.Context())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		// Should return the new name
+		if len(files) != 1 || files[0].Path != "new.txt" {
+			t.Errorf("expected [new.txt], got %v", files)
+		}
+	})
+}
+
+func TestGitRunner_BranchDelete_Integration(t *testing.T) {
+	t.Parallel()
+
+	t.Run("SafeDelete", func(t *testing.T) {
+		t.Parallel()
+
+		_, mainDir := testutil.SetupTestRepo(t, testutil.WithoutSettings())
+
+		testutil.RunGit(t, mainDir, "branch", "to-delete")
+
+		runner := NewGitRunner(mainDir)
+
+		_, err := runner.BranchDelete(t.Context(), "to-delete")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		out := testutil.RunGit(t, mainDir, "branch", "--list")
+		if strings.Contains(out, "to-delete") {
+			t.Errorf("branch should be deleted, but still in list: %s", out)
+		}
+	})
+
+	t.Run("ForceDelete", func(t *testing.T) {
+		t.Parallel()
+
+		_, mainDir := testutil.SetupTestRepo(t, testutil.WithoutSettings())
+
+		testutil.RunGit(t, mainDir, "checkout", "-b", "unmerged")
+		testutil.RunGit(t, mainDir, "commit", "--allow-empty", "-m", "unmerged commit")
+		testutil.RunGit(t, mainDir, "checkout", "main")
+
+		runner := NewGitRunner(mainDir)
+
+		_, err := runner.BranchDelete(t.Context(), "unmerged", WithForceDelete())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		out := testutil.RunGit(t, mainDir, "branch", "--list")
+		if strings.Contains(out, "unmerged") {
+			t.Errorf("branch should be deleted, but still in list: %s", out)
+		}
+	})
+
+	t.Run("NotExists", func(t *testing.T) {
+		t.Parallel()
+
+		_, mainDir := testutil.SetupTestRepo(t, testutil.WithoutSettings())
+
+		runner := NewGitRunner(mainDir)
+
+		_, err := runner.BranchDelete(t.Context(), "nonexistent")
+		if err == nil {
+			t.Fatal("expected error, got nil")
+		}
+		if !strings.Contains(err.Error(), "failed to delete branch") {
+			t.Errorf("error %q should contain 'failed to delete branch'", err.Error())
+		}
+	})
+}
+
+func TestGitRunner_RepositoryPaths_Integration(t *testing.T) {
+	t.Parallel()
+
+	repoDir, mainDir := testutil.SetupTestRepo(t, testutil.WithoutSettings())
+	wtDir := filepath.Join(repoDir, "feat-a")
+	testutil.RunGit(t, mainDir, "worktree", "add", "-b", "feat/a", wtDir)
+	nested := filepath.Join(wtDir, "a", "b")
+	if err := os.MkdirAll(nested, 0755); err != nil {
+		t.Fatal(err)
+	}
+	commonDir := filepath.Join(mainDir, ".git")
+
+	tests := []struct {
+		name     string
+		dir      string
+		wantRoot string
+	}{
+		{name: "main_root", dir: mainDir, wantRoot: mainDir},
+		{name: "linked_root", dir: wtDir, wantRoot: wtDir},
+		{name: "linked_subdirectory", dir: nested, wantRoot: wtDir},
+		{name: "git_directory", dir: commonDir, wantRoot: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			paths, err := NewGitRunner(tt.dir).RepositoryPaths(t.Context())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if paths.WorktreeRoot != tt.wantRoot {
+				t.Errorf("WorktreeRoot = %q, want %q", paths.WorktreeRoot, tt.wantRoot)
+			}
+			if paths.CommonDir != commonDir || paths.MainWorktree() != mainDir {
+				t.Errorf("CommonDir = %q, MainWorktree = %q, want %q, %q", paths.CommonDir, paths.MainWorktree(), commonDir, mainDir)
+			}
+		})
+	}
+
+	if _, err := NewGitRunner(t.TempDir()).RepositoryPaths(t.Context()); err == nil {
+		t.Error("expected error outside a repository")
+	}
+}
+
+func TestGitRunner_WorktreeList_JJColocated_Integration(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		jj           bool
+		extraBranch  string
+		wantBranch   string
+		wantDetached bool
+	}{
+		{name: "bookmark_at_head", jj: true, wantBranch: "main"},
+		{name: "several_bookmarks_at_head", jj: true, extraBranch: "other", wantDetached: true},
+		{name: "not_colocated", wantDetached: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, mainDir := testutil.SetupTestRepo(t, testutil.WithoutSettings())
+			testutil.RunGit(t, mainDir, "checkout", "--detach")
+			if tt.jj {
+				if err := os.Mkdir(filepath.Join(mainDir, jjDirName), 0755); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.extraBranch != "" {
+				testutil.RunGit(t, mainDir, "branch", tt.extraBranch)
+			}
+
+			runner := NewGitRunner(mainDir)
+			worktrees, err := runner.WorktreeList(t.Context())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			main := worktrees[0]
+			if main.Branch != tt.wantBranch || main.Detached != tt.wantDetached || main.Bookmark != (tt.wantBranch != "") {
+				t.Errorf("main worktree = %+v, want branch %q, detached %v", main, tt.wantBranch, tt.wantDetached)
+			}
+
+			wantCurrent := cmp.Or(tt.wantBranch, "HEAD")
+			if got, err := runner.CurrentBranch(t.Context()); err != nil || got != wantCurrent {
+				t.Errorf("CurrentBranch() = %q, %v, want %q", got, err, wantCurrent)
+			}
+		})
+	}
+}
+
+func TestGitRunner_RemoteHEADBranch_Integration(t *testing.T) {
+	t.Parallel()
+
+	_, mainDir := testutil.SetupTestRepo(t, testutil.WithoutSettings())
+	runner := NewGitRunner(mainDir)
+
+	got, err := runner.RemoteHEADBranch(t.Context(), "origin")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "" {
+		t.Errorf("without origin/HEAD got %q, want empty", got)
+	}
+
+	head := strings.TrimSpace(testutil.RunGit(t, mainDir, "rev-parse", "HEAD"))
+	testutil.RunGit(t, mainDir, "update-ref", "refs/remotes/origin/trunk", head)
+	testutil.RunGit(t, mainDir, "symbolic-ref", "refs/remotes/origin/HEAD", "refs/remotes/origin/trunk")
+
+	got, err = runner.RemoteHEADBranch(t.Context(), "origin")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "trunk" {
+		t.Errorf("got %q, want %q", got, "trunk")
+	}
+}
+
+func TestGitRunner_BranchDescription_Integration(t *testing.T) {
+	t.Parallel()
+
+	_, mainDir := testutil.SetupTestRepo(t, testutil.WithoutSettings())
+	runner := NewGitRunner(mainDir)
+	testutil.RunGit(t, mainDir, "branch", "feature/desc")
+
+	descs, err := runner.BranchDescriptions(t.Context())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(descs) != 0 {
+		t.Errorf("without descriptions got %v, want empty", descs)
+	}
+
+	if err := runner.SetBranchDescription(t.Context(), "feature/desc", "Fix login\n\nDetails"); err != nil {
+		t.Fatalf("SetBranchDescription failed: %v", err)
+	}
+	descs, err = runner.BranchDescriptions(t.Context())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := descs["feature/desc"]; got != "Fix login\n\nDetails" {
+		t.Errorf("description = %q, want %q", got, "Fix login\n\nDetails")
+	}
+
+	// An empty description unsets it, twice without error
+	for range 2 {
+		if err := runner.SetBranchDescription(t.Context(), "feature/desc", ""); err != nil {
+			t.Fatalf("unset failed: %v", err)
+		}
+	}
+	descs, err = runner.BranchDescriptions(t.Context())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := descs["feature/desc"]; ok {
+		t.Errorf("description still set: %v", descs)
+	}
+}
+
+func TestGitRunner_BranchStatuses_Integration(t *testing.T) {
+	t.Parallel()
+
+	repoDir, mainDir := testutil.SetupTestRepo(t, testutil.WithoutSettings())
+
+	// feat/ahead tracks main with one extra commit, main then moves on
+	wtPath := filepath.Join(repoDir, "feat-ahead")
+	testutil.RunGit(t, mainDir, "worktree", "add", wtPath, "-b", "feat/ahead")
+	testutil.RunGit(t, wtPath, "branch", "--set-upstream-to=main")
+	testutil.RunGit(t, wtPath, "commit", "--allow-empty", "-m", "ahead")
+	testutil.RunGit(t, mainDir, "commit", "--allow-empty", "-m", "behind")
+
+	// feat/gone tracks a branch that is deleted afterwards
+	testutil.RunGit(t, mainDir, "branch", "tmp")
+	testutil.RunGit(t, mainDir, "branch", "feat/gone")
+	testutil.RunGit(t, mainDir, "branch", "--set-upstream-to=tmp", "feat/gone")
+	testutil.RunGit(t, mainDir, "branch", "-D", "tmp")
+
+	branches, err := NewGitRunner(mainDir).BranchStatuses(t.Context())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ahead := branches["feat/ahead"]
+	if ahead.Upstream != "main" || ahead.Ahead != 1 || ahead.Behind != 1 || ahead.Gone {
+		t.Errorf("feat/ahead = %+v, want upstream main, ahead 1, behind 1", ahead)
+	}
+	if gone := branches["feat/gone"]; !gone.Gone {
+		t.Errorf("feat/gone = %+v, want upstream gone", gone)
+	}
+	main := branches["main"]
+	if main.Commit == "" || main.Upstream != "" {
+		t.Errorf("main = %+v, want commit without upstream", main)
+	}
+	if head := strings.TrimSpace(testutil.RunGit(t, mainDir, "rev-parse", "HEAD")); main.Commit != head {
+		t.Errorf("main commit = %q, want %q", main.Commit, head)
+	}
+}
+
+// identitylessExecutor runs git without any user identity: no identity
+// in the environment, and a HOME without a global config.
+type identitylessExecutor struct {
+	home string
+}
+
+func (e identitylessExecutor) Run(ctx context.Context, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	for _, kv := range os.Environ() {
+		if strings.HasPrefix(kv, "GIT_AUTHOR_") || strings.HasPrefix(kv, "GIT_COMMITTER_") ||
+			strings.HasPrefix(kv, "HOME=") || strings.HasPrefix(kv, "XDG_CONFIG_HOME=") || strings.HasPrefix(kv, "EMAIL=") {
+			continue
+		}
+		cmd.Env = append(cmd.Env, kv)
+	}
+	cmd.Env = append(cmd.Env, "HOME="+e.home, "GIT_CONFIG_NOSYSTEM=1")
+	return cmd.Output()
+}
+
+func TestGitRunner_IsBranchSquashMerged_Integration(t *testing.T) {
+	t.Parallel()
+
+	t.Run("WithoutUserIdentity", func(t *testing.T) {
+		t.Parallel()
+
+		repoDir, mainDir := testutil.SetupTestRepo(t, testutil.WithoutSettings())
+
+		wtPath := filepath.Join(repoDir, "feat-squashed")
+		testutil.RunGit(t, mainDir, "worktree", "add", "-b", "feat/squashed", wtPath)
+		if err := os.WriteFile(filepath.Join(wtPath, "squashed.txt"), []byte("squashed\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		testutil.RunGit(t, wtPath, "add", "squashed.txt")
+		testutil.RunGit(t, wtPath, "commit", "-m", "add squashed file")
+		testutil.RunGit(t, mainDir, "merge", "--squash", "feat/squashed")
+		testutil.RunGit(t, mainDir, "commit", "-m", "squash feat/squashed")
+		testutil.RunGit(t, mainDir, "config", "--unset", "user.name")
+		testutil.RunGit(t, mainDir, "config", "--unset", "user.email")
+
+		runner := NewGitRunner(mainDir, WithExecutor(identitylessExecutor{home: t.TempDir()}))
+		merged, err := runner.IsBranchSquashMerged(t.Context(), "feat/squashed", "main")
+		if err != nil {
+			t.Fatalf("IsBranchSquashMerged() error = %v", err)
+		}
+		if !merged {
+			t.Error("IsBranchSquashMerged() = false, want true")
+		}
+	})
+}
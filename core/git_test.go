@@ -0,0 +1,922 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/708u/twig/internal/testutil"
+)
+
+func TestWithSubmoduleReference(t *testing.T) {
+	t.Parallel()
+
+	t.Run("sets reference path", func(t *testing.T) {
+		t.Parallel()
+
+		var opts submoduleUpdateOptions
+		opt := WithSubmoduleReference("/path/to/main")
+		opt(&opts)
+
+		want := []string{filepath.Join("/path/to/main", ".git", "modules")}
+		if !slices.Equal(opts.referenceDirs, want) {
+			t.Errorf("referenceDirs = %q, want %q", opts.referenceDirs, want)
+		}
+	})
+
+	t.Run("empty path", func(t *testing.T) {
+		t.Parallel()
+
+		var opts submoduleUpdateOptions
+		opt := WithSubmoduleReference("")
+		opt(&opts)
+
+		if len(opts.referenceDirs) != 0 {
+			t.Errorf("referenceDirs = %q, want empty", opts.referenceDirs)
+		}
+	})
+
+	t.Run("reference dir comes first", func(t *testing.T) {
+		t.Parallel()
+
+		var opts submoduleUpdateOptions
+		WithSubmoduleReferenceDir("/mirrors/repo")(&opts)
+		WithSubmoduleReference("/path/to/main")(&opts)
+
+		want := []string{"/mirrors/repo", filepath.Join("/path/to/main", ".git", "modules")}
+		if !slices.Equal(opts.referenceDirs, want) {
+			t.Errorf("referenceDirs = %q, want %q", opts.referenceDirs, want)
+		}
+	})
+}
+
+func TestNewGitRunner_DefaultLogger(t *testing.T) {
+	t.Parallel()
+
+	// Should use nop logger by default
+	runner := NewGitRunner("/tmp")
+	if runner.Log == nil {
+		t.Error("Log should not be nil after NewGitRunner")
+	}
+}
+
+func TestNewGitRunner_WithExecutor(t *testing.T) {
+	t.Parallel()
+
+	mock := &testutil.MockGitExecutor{}
+	runner := NewGitRunner("/repo", WithExecutor(mock))
+	if runner.Executor != mock {
+		t.Errorf("Executor = %T, want the given executor", runner.Executor)
+	}
+	// InDir keeps the executor
+	if runner.InDir("/other").Executor != mock {
+		t.Error("InDir() dropped the executor")
+	}
+}
+
+func TestNewGitRunner_WithFaults(t *testing.T) {
+	t.Parallel()
+
+	faults, err := ParseFaultProfile("git.status@1,fs.readfile@1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner := NewGitRunner("/repo", WithExecutor(&testutil.MockGitExecutor{}), WithFaults(faults))
+
+	var faultErr *FaultError
+	if _, err := runner.InDir("/other").Run(t.Context(), GitCmdStatus); !errors.As(err, &faultErr) {
+		t.Errorf("Run() error = %v, want injected fault", err)
+	}
+	if _, err := runner.defaultFS().ReadFile("/nonexistent"); !errors.As(err, &faultErr) {
+		t.Errorf("defaultFS().ReadFile() error = %v, want injected fault", err)
+	}
+	// Without WithFaults nothing is injected
+	if _, err := NewGitRunner("/repo", WithExecutor(&testutil.MockGitExecutor{})).Run(t.Context(), GitCmdStatus); err != nil {
+		t.Errorf("Run() error = %v, want nil", err)
+	}
+}
+
+func TestNewGitRunner_WithTimings(t *testing.T) {
+	t.Parallel()
+
+	timings := NewTimings()
+	runner := NewGitRunner("/repo", WithExecutor(&testutil.MockGitExecutor{}), WithTimings(timings))
+	// InDir keeps recording into the same Timings
+	if _, err := runner.InDir("/other").Run(t.Context(), GitCmdStatus, "--porcelain"); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	phases := timings.Phases()
+	if len(phases) != 1 || phases[0].Name != "git status" || phases[0].Count != 1 {
+		t.Errorf("Phases() = %+v, want one run of git status", phases)
+	}
+
+	// Without WithTimings nothing is recorded and nothing fails
+	if _, err := NewGitRunner("/repo", WithExecutor(&testutil.MockGitExecutor{})).Run(t.Context(), GitCmdStatus); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+}
+
+func TestGitRunner_Run_LogsResult(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	runner := &GitRunner{
+		Executor: &testutil.MockGitExecutor{},
+		Dir:      "/repo",
+		Log:      slog.New(NewLogHandler(&buf, slog.LevelDebug, LogFormatJSON)),
+	}
+	// The mock fails symbolic-ref for unknown remotes with exit status 1
+	if _, err := runner.Run(t.Context(), "symbolic-ref", "refs/remotes/origin/HEAD"); err == nil {
+		t.Fatal("expected error for unknown remote HEAD")
+	}
+
+	var got struct {
+		Msg      string   `json:"msg"`
+		Category string   `json:"category"`
+		Args     []string `json:"args"`
+		Dir      string   `json:"dir"`
+		ExitCode int      `json:"exit_code"`
+		Duration *int64   `json:"duration_ms"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, buf.String())
+	}
+	if got.Msg != "git -C /repo symbolic-ref refs/remotes/origin/HEAD" {
+		t.Errorf("msg = %q", got.Msg)
+	}
+	if got.Category != LogCategoryGit || got.Dir != "/repo" || got.ExitCode != 1 || got.Duration == nil {
+		t.Errorf("record = %+v, want git category, dir, exit code 1 and a duration", got)
+	}
+	if !reflect.DeepEqual(got.Args, []string{"symbolic-ref", "refs/remotes/origin/HEAD"}) {
+		t.Errorf("args = %v", got.Args)
+	}
+}
+
+// hangingExecutor blocks every command until its context is done, like a
+// git fetch over a dead connection.
+type hangingExecutor struct{}
+
+func (hangingExecutor) Run(ctx context.Context, args ...string) ([]byte, error) {
+	<-ctx.Done()
+	return nil, errors.New("signal: killed")
+}
+
+func TestGitRunner_Run_Timeout(t *testing.T) {
+	t.Parallel()
+
+	t.Run("timeout", func(t *testing.T) {
+		t.Parallel()
+
+		runner := &GitRunner{Executor: hangingExecutor{}, Dir: "/repo", Log: NewNopLogger(), Timeout: 10 * time.Millisecond}
+		_, err := runner.InDir("/repo/wt").Run(t.Context(), "fetch", "origin", "main")
+
+		var gitErr *GitError
+		if !errors.As(err, &gitErr) || !errors.Is(err, ErrGitTimeout) {
+			t.Fatalf("error = %v, want GitError wrapping ErrGitTimeout", err)
+		}
+		if gitErr.Command != "git fetch origin main" || gitErr.Elapsed < 10*time.Millisecond {
+			t.Errorf("GitError = %+v, want command and elapsed time", gitErr)
+		}
+		if !strings.HasPrefix(err.Error(), "git fetch origin main timed out after ") {
+			t.Errorf("Error() = %q", err.Error())
+		}
+	})
+
+	t.Run("caller_cancel_is_not_a_timeout", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+		runner := &GitRunner{Executor: hangingExecutor{}, Dir: "/repo", Log: NewNopLogger(), Timeout: time.Minute}
+		_, err := runner.Run(ctx, "fetch")
+		if err == nil || errors.Is(err, ErrGitTimeout) {
+			t.Fatalf("error = %v, want the executor error", err)
+		}
+	})
+
+	t.Run("command_timeout_overrides_timeout", func(t *testing.T) {
+		t.Parallel()
+
+		runner := NewGitRunner("/repo",
+			WithExecutor(hangingExecutor{}),
+			WithTimeout(time.Hour),
+			WithCommandTimeout(GitCmdFetch, 10*time.Millisecond))
+		_, err := runner.InDir("/repo/wt").Run(t.Context(), GitCmdFetch, "origin")
+		if !errors.Is(err, ErrGitTimeout) {
+			t.Fatalf("error = %v, want ErrGitTimeout", err)
+		}
+		if !strings.Contains(err.Error(), "raise git_remote_timeout") {
+			t.Errorf("Error() = %q, want hint at git_remote_timeout", err.Error())
+		}
+	})
+
+	t.Run("submodule_gets_remote_timeout", func(t *testing.T) {
+		t.Parallel()
+
+		opts := []GitRunnerOption{WithExecutor(hangingExecutor{}), WithTimeout(time.Hour)}
+		for _, sub := range GitRemoteCommands {
+			opts = append(opts, WithCommandTimeout(sub, 10*time.Millisecond))
+		}
+		runner := NewGitRunner("/repo", opts...)
+		_, err := runner.InDir("/repo/wt").Run(t.Context(), GitCmdSubmodule, GitSubmoduleUpdate, "--init")
+		if !errors.Is(err, ErrGitTimeout) {
+			t.Fatalf("error = %v, want ErrGitTimeout", err)
+		}
+		if !strings.Contains(err.Error(), "raise git_remote_timeout") {
+			t.Errorf("Error() = %q, want hint at git_remote_timeout", err.Error())
+		}
+	})
+
+	t.Run("local_command_hints_git_timeout", func(t *testing.T) {
+		t.Parallel()
+
+		runner := NewGitRunner("/repo",
+			WithExecutor(hangingExecutor{}),
+			WithTimeout(10*time.Millisecond),
+			WithCommandTimeout(GitCmdFetch, time.Hour))
+		_, err := runner.Run(t.Context(), GitCmdStatus)
+		if !errors.Is(err, ErrGitTimeout) || !strings.Contains(err.Error(), "raise git_timeout") {
+			t.Fatalf("error = %v, want timeout hinting at git_timeout", err)
+		}
+	})
+
+	t.Run("with_timeout_kept_by_InDir", func(t *testing.T) {
+		t.Parallel()
+
+		if got := NewGitRunner("/repo", WithTimeout(time.Second)).InDir("/other").Timeout; got != time.Second {
+			t.Errorf("Timeout = %v, want 1s", got)
+		}
+	})
+}
+
+func TestGitRunner_ChangedFiles(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		statusOutput string
+		want         []FileStatus
+	}{
+		{
+			name:         "no changes",
+			statusOutput: "",
+			want:         make([]FileStatus, 0),
+		},
+		{
+			name:         "modified file",
+			statusOutput: " M src/main.go\n",
+			want: []FileStatus{
+				{Status: " M", Path: "src/main.go"},
+			},
+		},
+		{
+			name:         "staged new file",
+			statusOutput: "A  src/new.go\n",
+			want: []FileStatus{
+				{Status: "A ", Path: "src/new.go"},
+			},
+		},
+		{
+			name:         "untracked file",
+			statusOutput: "?? tmp/debug.log\n",
+			want: []FileStatus{
+				{Status: "??", Path: "tmp/debug.log"},
+			},
+		},
+		{
+			name:         "multiple files",
+			statusOutput: " M src/main.go\nA  src/new.go\n?? tmp/debug.log\n",
+			want: []FileStatus{
+				{Status: " M", Path: "src/main.go"},
+				{Status: "A ", Path: "src/new.go"},
+				{Status: "??", Path: "tmp/debug.log"},
+			},
+		},
+		{
+			name:         "renamed file",
+			statusOutput: "R  old.go -> new.go\n",
+			want: []FileStatus{
+				{Status: "R ", Path: "new.go"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockGit := &testutil.MockGitExecutor{
+				StatusOutput: tt.statusOutput,
+			}
+			runner := &GitRunner{Executor: mockGit, Log: NewNopLogger()}
+
+			got, err := runner.ChangedFiles(t.Context())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGitRunner_WorktreeAdd(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		opts         []WorktreeAddOption
+		checkoutErr  error
+		wantErr      string
+		wantCommands []string
+	}{
+		{
+			name: "registers_then_checks_out",
+			opts: []WorktreeAddOption{WithCreateBranch()},
+			wantCommands: []string{
+				"-C /repo worktree add --no-checkout -b feat/a /wt/feat/a",
+				"-C /wt/feat/a checkout -f -q",
+			},
+		},
+		{
+			name:         "no_checkout",
+			opts:         []WorktreeAddOption{WithNoCheckout()},
+			wantCommands: []string{"-C /repo worktree add --no-checkout /wt/feat/a feat/a"},
+		},
+		{
+			name:        "checkout_failure_removes_worktree",
+			checkoutErr: errors.New("exit status 1"),
+			wantErr:     "failed to check out /wt/feat/a",
+			wantCommands: []string{
+				"-C /repo worktree add --no-checkout /wt/feat/a feat/a",
+				"-C /wt/feat/a checkout -f -q",
+				"-C /repo worktree remove -f /wt/feat/a",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var commands []string
+			mockGit := &testutil.MockGitExecutor{
+				RunFunc: func(ctx context.Context, args ...string) ([]byte, error) {
+					commands = append(commands, strings.Join(args, " "))
+					if slices.Contains(args, GitCmdCheckout) {
+						return nil, tt.checkoutErr
+					}
+					return nil, nil
+				},
+			}
+			runner := &GitRunner{Executor: mockGit, Dir: "/repo", Log: NewNopLogger()}
+
+			_, err := runner.WorktreeAdd(t.Context(), "/wt/feat/a", "feat/a", tt.opts...)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+			}
+			if !slices.Equal(commands, tt.wantCommands) {
+				t.Errorf("commands = %q, want %q", commands, tt.wantCommands)
+			}
+		})
+	}
+}
+
+func TestGitRunner_IsBranchUpstreamGone(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		branch       string
+		upstreamGone []string
+		want         bool
+	}{
+		{
+			name:         "upstream is gone",
+			branch:       "feat/squashed",
+			upstreamGone: []string{"feat/squashed"},
+			want:         true,
+		},
+		{
+			name:         "upstream exists",
+			branch:       "feat/new",
+			upstreamGone: []string{},
+			want:         false,
+		},
+		{
+			name:         "multiple gone branches",
+			branch:       "feat/b",
+			upstreamGone: []string{"feat/a", "feat/b", "feat/c"},
+			want:         true,
+		},
+		{
+			name:         "different branch gone",
+			branch:       "feat/x",
+			upstreamGone: []string{"feat/y"},
+			want:         false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockGit := &testutil.MockGitExecutor{
+				UpstreamGoneBranches: tt.upstreamGone,
+			}
+			runner := &GitRunner{Executor: mockGit, Log: NewNopLogger()}
+
+			got, err := runner.IsBranchUpstreamGone(t.Context(), tt.branch)
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGitRunner_ConfigGetBool(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		config  map[string]string
+		want    bool
+		wantOK  bool
+		wantErr bool
+	}{
+		{name: "unset", want: false, wantOK: false},
+		{name: "true", config: map[string]string{"worktree.guessRemote": "true"}, want: true, wantOK: true},
+		{name: "no", config: map[string]string{"worktree.guessRemote": "no"}, want: false, wantOK: true},
+		{name: "valueless_key", config: map[string]string{"worktree.guessRemote": ""}, want: true, wantOK: true},
+		{name: "invalid", config: map[string]string{"worktree.guessRemote": "maybe"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			runner := &GitRunner{Executor: &testutil.MockGitExecutor{GitConfig: tt.config}, Log: NewNopLogger()}
+
+			got, ok, err := runner.ConfigGetBool(t.Context(), "worktree.guessRemote")
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("ConfigGetBool() = (%v, %v), want (%v, %v)", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestGitRunner_CheckIgnore(t *testing.T) {
+	t.Parallel()
+
+	paths := make([]string, checkIgnoreBatchSize+2)
+	for i := range paths {
+		paths[i] = fmt.Sprintf("file%d", i)
+	}
+	paths[0] = "build"
+	paths[len(paths)-1] = "caf\u00e9.log"
+
+	var runs int
+	mockGit := &testutil.MockGitExecutor{
+		RunFunc: func(ctx context.Context, args ...string) ([]byte, error) {
+			runs++
+			// args: ["-C", dir, "check-ignore", "--", <path>...]
+			batch := args[4:]
+			switch {
+			case slices.Contains(batch, "build"):
+				return []byte("build\n"), nil
+			case slices.Contains(batch, "caf\u00e9.log"):
+				// Non-ASCII paths are C-quoted
+				return []byte(`"caf\303\251.log"` + "\n"), nil
+			}
+			return nil, &testutil.MockExitError{Code: 1}
+		},
+	}
+	runner := &GitRunner{Executor: mockGit, Dir: "/repo", Log: NewNopLogger()}
+
+	got, err := runner.CheckIgnore(t.Context(), paths)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runs != 2 {
+		t.Errorf("git check-ignore ran %d times, want 2 batches", runs)
+	}
+	if want := []string{"build", "caf\u00e9.log"}; !slices.Equal(got, want) {
+		t.Errorf("CheckIgnore() = %q, want %q", got, want)
+	}
+}
+
+func TestSelectSubmodules(t *testing.T) {
+	t.Parallel()
+
+	submodules := []SubmoduleInfo{
+		{Path: "libs/a"},
+		{Path: "libs/a/nested"},
+		{Path: "libs/b"},
+		{Path: "tools/gen"},
+	}
+
+	tests := []struct {
+		name          string
+		paths         []string
+		wantSelected  []string
+		wantUnmatched []string
+	}{
+		{name: "exact_path", paths: []string{"libs/b"}, wantSelected: []string{"libs/b"}},
+		{name: "parent_directory", paths: []string{"libs/"}, wantSelected: []string{"libs/a", "libs/b"}},
+		{name: "glob", paths: []string{"*/gen", "libs/*"}, wantSelected: []string{"libs/a", "libs/b", "tools/gen"}},
+		{name: "nested_left_to_recursion", paths: []string{"libs/a/nested"}, wantUnmatched: []string{"libs/a/nested"}},
+		{name: "unmatched", paths: []string{"libs/a", "vendor"}, wantSelected: []string{"libs/a"}, wantUnmatched: []string{"vendor"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			selected, unmatched := selectSubmodules(submodules, tt.paths)
+			var got []string
+			for _, sm := range selected {
+				got = append(got, sm.Path)
+			}
+			if !reflect.DeepEqual(got, tt.wantSelected) {
+				t.Errorf("selected = %v, want %v", got, tt.wantSelected)
+			}
+			if !reflect.DeepEqual(unmatched, tt.wantUnmatched) {
+				t.Errorf("unmatched = %v, want %v", unmatched, tt.wantUnmatched)
+			}
+		})
+	}
+}
+
+func TestGitRunner_SelectRemote(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		remotes []string
+		config  map[string]string
+		want    string
+		wantErr bool
+	}{
+		{name: "none", remotes: nil, want: ""},
+		{name: "single", remotes: []string{"origin"}, want: "origin"},
+		{name: "ambiguous", remotes: []string{"origin", "upstream"}, wantErr: true},
+		{
+			name:    "default_remote",
+			remotes: []string{"origin", "upstream"},
+			config:  map[string]string{"checkout.defaultRemote": "upstream"},
+			want:    "upstream",
+		},
+		{
+			name:    "default_remote_without_branch",
+			remotes: []string{"origin", "upstream"},
+			config:  map[string]string{"checkout.defaultRemote": "fork"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			runner := &GitRunner{Executor: &testutil.MockGitExecutor{GitConfig: tt.config}, Log: NewNopLogger()}
+
+			got, err := runner.SelectRemote(t.Context(), "feat/a", tt.remotes)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("SelectRemote() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGitRunner_IsFirstParentAncestor(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name                 string
+		commit               string
+		target               string
+		firstParentAncestors map[string][]string
+		rootCommits          []string
+		want                 bool
+	}{
+		{
+			name:   "on first-parent lineage",
+			commit: "commit-abc",
+			target: "main",
+			firstParentAncestors: map[string][]string{
+				"main": {"commit-abc", "commit-def"},
+			},
+			want: true,
+		},
+		{
+			name:   "not on first-parent lineage",
+			commit: "commit-xyz",
+			target: "main",
+			firstParentAncestors: map[string][]string{
+				"main": {"commit-abc", "commit-def"},
+			},
+			want: false,
+		},
+		{
+			name:   "root commit on first-parent lineage",
+			commit: "root-commit",
+			target: "main",
+			firstParentAncestors: map[string][]string{
+				"main": {"root-commit", "commit-abc"},
+			},
+			rootCommits: []string{"root-commit"},
+			want:        true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockGit := &testutil.MockGitExecutor{
+				FirstParentAncestors: tt.firstParentAncestors,
+				RootCommits:          tt.rootCommits,
+			}
+			runner := &GitRunner{Executor: mockGit, Log: NewNopLogger()}
+
+			got, err := runner.IsFirstParentAncestor(t.Context(), tt.commit, tt.target)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGitRunner_IsBranchMerged_WithSquashMerge(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		branch       string
+		target       string
+		branchHEADs  map[string]string
+		merged       map[string][]string
+		upstreamGone []string
+		want         bool
+	}{
+		{
+			name:   "traditional merge detected",
+			branch: "feat/merged",
+			target: "main",
+			branchHEADs: map[string]string{
+				"feat/merged": "commit123",
+				"main":        "commit456",
+			},
+			merged: map[string][]string{
+				"main": {"feat/merged"},
+			},
+			upstreamGone: []string{},
+			want:         true,
+		},
+		{
+			name:   "squash merge detected via upstream gone",
+			branch: "feat/squashed",
+			target: "main",
+			branchHEADs: map[string]string{
+				"feat/squashed": "commit123",
+				"main":          "commit456",
+			},
+			merged: map[string][]string{
+				"main": {}, // Not in --merged output
+			},
+			upstreamGone: []string{"feat/squashed"}, // But upstream is gone
+			want:         true,
+		},
+		{
+			name:   "not merged at all",
+			branch: "feat/new",
+			target: "main",
+			branchHEADs: map[string]string{
+				"feat/new": "commit123",
+				"main":     "commit456",
+			},
+			merged: map[string][]string{
+				"main": {},
+			},
+			upstreamGone: []string{},
+			want:         false,
+		},
+		{
+			name:   "traditional merge takes precedence",
+			branch: "feat/both",
+			target: "main",
+			branchHEADs: map[string]string{
+				"feat/both": "commit123",
+				"main":      "commit456",
+			},
+			merged: map[string][]string{
+				"main": {"feat/both"},
+			},
+			upstreamGone: []string{"feat/both"},
+			want:         true,
+		},
+		{
+			name:   "same commit is not considered merged",
+			branch: "feat/new-branch",
+			target: "main",
+			branchHEADs: map[string]string{
+				"feat/new-branch": "same-commit-abc123",
+				"main":            "same-commit-abc123",
+			},
+			merged: map[string][]string{
+				"main": {"feat/new-branch"}, // git branch --merged would return this
+			},
+			upstreamGone: []string{},
+			want:         false, // but we should return false because same commit
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockGit := &testutil.MockGitExecutor{
+				MergedBranches:       tt.merged,
+				UpstreamGoneBranches: tt.upstreamGone,
+				BranchHEADs:          tt.branchHEADs,
+			}
+			runner := &GitRunner{Executor: mockGit, Log: NewNopLogger()}
+
+			got, err := runner.IsBranchMerged(t.Context(), tt.branch, tt.target)
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGitRunner_IsBranchSquashMerged(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		branch       string
+		target       string
+		squashMerged map[string][]string
+		want         bool
+	}{
+		{
+			name:   "squash merged into target",
+			branch: "feat/squashed",
+			target: "main",
+			squashMerged: map[string][]string{
+				"main": {"feat/squashed"},
+			},
+			want: true,
+		},
+		{
+			name:         "not squash merged",
+			branch:       "feat/new",
+			target:       "main",
+			squashMerged: map[string][]string{},
+			want:         false,
+		},
+		{
+			name:   "squash merged into different target",
+			branch: "feat/squashed",
+			target: "main",
+			squashMerged: map[string][]string{
+				"develop": {"feat/squashed"},
+			},
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockGit := &testutil.MockGitExecutor{
+				SquashMergedBranches: tt.squashMerged,
+			}
+			runner := &GitRunner{Executor: mockGit, Log: NewNopLogger()}
+
+			got, err := runner.IsBranchSquashMerged(t.Context(), tt.branch, tt.target)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGitErrorCodeOf(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		err  error
+		want GitErrorCode
+	}{
+		{
+			name: "locked worktree",
+			err:  &GitError{Op: OpWorktreeRemove, Stderr: "fatal: cannot remove a locked working tree, lock reason: wip"},
+			want: GitErrorLockedWorktree,
+		},
+		{
+			name: "dirty worktree",
+			err:  &GitError{Op: OpWorktreeRemove, Stderr: "fatal: '/wt/feat' contains modified or untracked files, use --force to delete it"},
+			want: GitErrorDirtyWorktree,
+		},
+		{
+			name: "missing ref from exec error",
+			err:  fmt.Errorf("failed to create worktree: %w", &exec.ExitError{Stderr: []byte("fatal: invalid reference: nope\n")}),
+			want: GitErrorMissingRef,
+		},
+		{
+			name: "permission denied",
+			err:  &exec.ExitError{Stderr: []byte("error: unable to create file x: Permission denied\n")},
+			want: GitErrorPermissionDenied,
+		},
+		{
+			name: "unrecognized stderr",
+			err:  &GitError{Op: OpBranchDelete, Stderr: "fatal: something else"},
+			want: GitErrorUnknown,
+		},
+		{
+			name: "not a git error",
+			err:  errors.New("invalid reference"),
+			want: GitErrorUnknown,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := GitErrorCodeOf(tt.err); got != tt.want {
+				t.Errorf("GitErrorCodeOf() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExplainGitError(t *testing.T) {
+	t.Parallel()
+
+	err := explainGitError(fmt.Errorf("failed to create worktree: %w", &exec.ExitError{Stderr: []byte("fatal: invalid reference: nope\n")}))
+	// The exit status is not set on a bare ExitError, so only git's
+	// message and the hint are compared
+	want := ": fatal: invalid reference: nope\n" +
+		"hint: check the branch or commit name, or run 'git fetch' if it only exists on a remote"
+	if !strings.HasSuffix(err.Error(), want) {
+		t.Errorf("explainGitError() = %q, want suffix %q", err.Error(), want)
+	}
+	if GitErrorCodeOf(err) != GitErrorMissingRef {
+		t.Errorf("GitErrorCodeOf() = %q, want %q", GitErrorCodeOf(err), GitErrorMissingRef)
+	}
+}
@@ -0,0 +1,208 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultGitLockWait is how long twig waits for git locks to be
+	// released before removing or moving a worktree.
+	DefaultGitLockWait = 10 * time.Second
+
+	// gcPIDFileName is written to the git common dir by a running git gc,
+	// as "<pid> <hostname>".
+	gcPIDFileName = "gc.pid"
+
+	// gcPIDStaleAge is the age after which git gc itself ignores gc.pid.
+	gcPIDStaleAge = 12 * time.Hour
+
+	// indexLockFileName is held while git updates the index of a worktree.
+	indexLockFileName = "index.lock"
+)
+
+// gitLockFiles are lock files in the git common dir that background git
+// processes hold while rewriting data that worktree and branch commands
+// update, with the process that typically holds them.
+var gitLockFiles = []struct {
+	path    string
+	process string
+}{
+	{filepath.Join("objects", "maintenance.lock"), "git maintenance"},
+	{packedRefsFile + lockFileSuffix, "git pack-refs"},
+}
+
+// GitLockHolder is a git lock that is held by another process.
+type GitLockHolder struct {
+	Path    string    // Lock file
+	Process string    // What usually holds the lock (e.g. "git gc")
+	PID     int       // Process holding the lock (0 if unknown)
+	Host    string    // Host of the process (empty if unknown)
+	Since   time.Time // When the lock file was written (zero if unknown)
+}
+
+func (h GitLockHolder) String() string {
+	holder := h.Process
+	switch {
+	case h.PID > 0 && h.Host != "":
+		holder += fmt.Sprintf(" (pid %d on %s)", h.PID, h.Host)
+	case h.PID > 0:
+		holder += fmt.Sprintf(" (pid %d)", h.PID)
+	}
+	s := fmt.Sprintf("%s holds %s", holder, h.Path)
+	if !h.Since.IsZero() {
+		s += fmt.Sprintf(" (since %s)", h.Since.Format(time.DateTime))
+	}
+	return s
+}
+
+// GitLockBusyError is returned when git locks are still held after the
+// grace period.
+type GitLockBusyError struct {
+	Holders []GitLockHolder
+	Waited  time.Duration
+}
+
+func (e *GitLockBusyError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "git is busy in this repository; gave up after %s:\n", e.Waited.Round(time.Millisecond))
+	for _, h := range e.Holders {
+		fmt.Fprintf(&b, "  %s\n", h)
+	}
+	b.WriteString("(set git_lock_wait to wait longer, or remove the lock file if no git process is running)")
+	return b.String()
+}
+
+// GitLockWaiter waits for git processes such as a background git gc or
+// git maintenance to release repository locks. Destructive steps call it
+// first, so they wait instead of failing half way with a lock error.
+type GitLockWaiter struct {
+	FS   FileSystem
+	Git  *GitRunner
+	Wait time.Duration // Grace period (0 = check once)
+	Log  *slog.Logger
+}
+
+// NewGitLockWaiter creates a GitLockWaiter with explicit dependencies.
+func NewGitLockWaiter(fs FileSystem, git *GitRunner, wait time.Duration, log *slog.Logger) *GitLockWaiter {
+	if log == nil {
+		log = NewNopLogger()
+	}
+	return &GitLockWaiter{FS: fs, Git: git, Wait: wait, Log: log}
+}
+
+// WaitFor waits until no git lock that would get in the way of changing
+// the worktree at wtPath is held, or returns a GitLockBusyError naming
+// the holders once the grace period is over.
+func (w *GitLockWaiter) WaitFor(ctx context.Context, wtPath string) error {
+	start := time.Now()
+	for {
+		holders, err := w.Holders(ctx, wtPath)
+		if err != nil {
+			return err
+		}
+		if len(holders) == 0 {
+			return nil
+		}
+
+		waited := time.Since(start)
+		if waited >= w.Wait {
+			return &GitLockBusyError{Holders: holders, Waited: waited}
+		}
+
+		w.Log.DebugContext(ctx, "waiting for git lock",
+			LogAttrKeyCategory.String(), LogCategoryLock,
+			"path", holders[0].Path,
+			"holder", holders[0].Process,
+			"pid", holders[0].PID)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(min(lockPollInterval, w.Wait-waited)):
+		}
+	}
+}
+
+// Holders returns the git locks currently held in the repository and in
+// the worktree at wtPath. wtPath may be empty or no longer exist, in
+// which case only the repository locks are checked.
+func (w *GitLockWaiter) Holders(ctx context.Context, wtPath string) ([]GitLockHolder, error) {
+	commonDir, err := w.Git.GitCommonDir(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find git directory: %w", err)
+	}
+
+	var holders []GitLockHolder
+	if commonDir != "" {
+		if h, ok := w.gcHolder(filepath.Join(commonDir, gcPIDFileName)); ok {
+			holders = append(holders, h)
+		}
+		for _, lock := range gitLockFiles {
+			path := filepath.Join(commonDir, lock.path)
+			if since, ok := w.lockFileSince(path); ok {
+				holders = append(holders, GitLockHolder{Path: path, Process: lock.process, Since: since})
+			}
+		}
+	}
+
+	if wtPath != "" {
+		if gitDir, err := w.Git.InDir(wtPath).GitDir(ctx); err == nil && gitDir != "" {
+			path := filepath.Join(gitDir, indexLockFileName)
+			if since, ok := w.lockFileSince(path); ok {
+				holders = append(holders, GitLockHolder{Path: path, Process: "a git command", Since: since})
+			}
+		}
+	}
+	return holders, nil
+}
+
+// gcHolder reports the git gc recorded in gc.pid if it is still running.
+// Like git gc, it ignores the file when the process is gone from this
+// host or the file is too old to belong to a running gc.
+func (w *GitLockWaiter) gcHolder(path string) (GitLockHolder, bool) {
+	since, ok := w.lockFileSince(path)
+	if !ok {
+		return GitLockHolder{}, false
+	}
+	if !since.IsZero() && time.Since(since) > gcPIDStaleAge {
+		return GitLockHolder{}, false
+	}
+	h := GitLockHolder{Path: path, Process: "git gc", Since: since}
+	data, err := w.FS.ReadFile(path)
+	if err != nil {
+		return h, true
+	}
+	pidField, host, _ := strings.Cut(strings.TrimSpace(string(data)), " ")
+	pid, err := strconv.Atoi(pidField)
+	if err != nil {
+		return h, true
+	}
+	h.PID = pid
+	if localHost, _ := os.Hostname(); host == localHost {
+		if !processAlive(pid) {
+			return GitLockHolder{}, false
+		}
+	} else {
+		h.Host = host
+	}
+	return h, true
+}
+
+// lockFileSince reports whether the lock file at path exists and when it
+// was written (zero if unknown).
+func (w *GitLockWaiter) lockFileSince(path string) (time.Time, bool) {
+	info, err := w.FS.Stat(path)
+	if err != nil {
+		return time.Time{}, false
+	}
+	if info == nil {
+		return time.Time{}, true
+	}
+	return info.ModTime(), true
+}
@@ -0,0 +1,161 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/708u/twig/internal/testutil"
+)
+
+func TestGitLockWaiter_Holders(t *testing.T) {
+	t.Parallel()
+
+	exited := exec.Command("true")
+	if err := exited.Run(); err != nil {
+		t.Skipf("cannot run helper process: %v", err)
+	}
+	host, _ := os.Hostname()
+
+	tests := []struct {
+		name    string
+		files   map[string]string // Paths under the common dir
+		old     bool              // Files are older than gcPIDStaleAge
+		want    []string          // Holder processes, in order
+		wantPID int
+	}{
+		{
+			name: "no locks",
+		},
+		{
+			name:    "running gc",
+			files:   map[string]string{gcPIDFileName: fmt.Sprintf("%d %s", os.Getpid(), host)},
+			want:    []string{"git gc"},
+			wantPID: os.Getpid(),
+		},
+		{
+			name:  "gc that exited",
+			files: map[string]string{gcPIDFileName: fmt.Sprintf("%d %s", exited.Process.Pid, host)},
+		},
+		{
+			name:    "gc on another host",
+			files:   map[string]string{gcPIDFileName: "1234 build-server"},
+			want:    []string{"git gc"},
+			wantPID: 1234,
+		},
+		{
+			name:  "gc.pid too old for a running gc",
+			files: map[string]string{gcPIDFileName: "1234 build-server"},
+			old:   true,
+		},
+		{
+			name: "maintenance and packed refs",
+			files: map[string]string{
+				filepath.Join("objects", "maintenance.lock"): "",
+				"packed-refs.lock":                           "",
+			},
+			want: []string{"git maintenance", "git pack-refs"},
+		},
+		{
+			name:  "worktree index",
+			files: map[string]string{filepath.Join("worktrees", "feat", indexLockFileName): ""},
+			want:  []string{"a git command"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			commonDir := t.TempDir()
+			for name, content := range tt.files {
+				p := filepath.Join(commonDir, name)
+				if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+				if tt.old {
+					old := time.Now().Add(-gcPIDStaleAge - time.Hour)
+					if err := os.Chtimes(p, old, old); err != nil {
+						t.Fatal(err)
+					}
+				}
+			}
+
+			mockGit := &testutil.MockGitExecutor{
+				GitCommonDir: commonDir,
+				GitDirMap:    map[string]string{"/wt/feat": filepath.Join(commonDir, "worktrees", "feat")},
+			}
+			git := &GitRunner{Executor: mockGit, Dir: "/repo/main", Log: NewNopLogger()}
+
+			holders, err := NewGitLockWaiter(osFS{}, git, 0, nil).Holders(t.Context(), "/wt/feat")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var got []string
+			for _, h := range holders {
+				got = append(got, h.Process)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Fatalf("holders = %v, want %v", got, tt.want)
+			}
+			if tt.wantPID != 0 && holders[0].PID != tt.wantPID {
+				t.Errorf("PID = %d, want %d", holders[0].PID, tt.wantPID)
+			}
+		})
+	}
+}
+
+func TestGitLockWaiter_WaitFor(t *testing.T) {
+	t.Parallel()
+
+	t.Run("waits until the lock is released", func(t *testing.T) {
+		t.Parallel()
+
+		commonDir := t.TempDir()
+		lock := filepath.Join(commonDir, "packed-refs.lock")
+		if err := os.WriteFile(lock, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+		go func() {
+			time.Sleep(50 * time.Millisecond)
+			os.Remove(lock)
+		}()
+
+		git := &GitRunner{Executor: &testutil.MockGitExecutor{GitCommonDir: commonDir}, Log: NewNopLogger()}
+		if err := NewGitLockWaiter(osFS{}, git, 5*time.Second, nil).WaitFor(t.Context(), ""); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("reports the holder after the grace period", func(t *testing.T) {
+		t.Parallel()
+
+		commonDir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(commonDir, gcPIDFileName), []byte("4242 build-server"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		git := &GitRunner{Executor: &testutil.MockGitExecutor{GitCommonDir: commonDir}, Log: NewNopLogger()}
+		err := NewGitLockWaiter(osFS{}, git, 50*time.Millisecond, nil).WaitFor(t.Context(), "")
+
+		var busy *GitLockBusyError
+		if !errors.As(err, &busy) {
+			t.Fatalf("error = %v, want GitLockBusyError", err)
+		}
+		if busy.Waited < 50*time.Millisecond {
+			t.Errorf("Waited = %v, want at least 50ms", busy.Waited)
+		}
+		if !strings.Contains(err.Error(), "git gc (pid 4242 on build-server) holds "+filepath.Join(commonDir, gcPIDFileName)) {
+			t.Errorf("error = %q, want gc holder", err.Error())
+		}
+	})
+}
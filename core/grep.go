@@ -0,0 +1,256 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/708u/twig/format"
+)
+
+// GitCmdGrep is the git command searching tracked files.
+const GitCmdGrep = "grep"
+
+// GrepCommand searches the tracked files of every worktree with git grep.
+type GrepCommand struct {
+	FS  FileSystem
+	Git *GitRunner
+	Log *slog.Logger
+}
+
+// NewGrepCommand creates a GrepCommand with explicit dependencies (for testing).
+func NewGrepCommand(fs FileSystem, git *GitRunner, log *slog.Logger) *GrepCommand {
+	if log == nil {
+		log = NewNopLogger()
+	}
+	return &GrepCommand{
+		FS:  fs,
+		Git: git,
+		Log: log,
+	}
+}
+
+// NewDefaultGrepCommand creates a GrepCommand with production defaults.
+func NewDefaultGrepCommand(dir string, log *slog.Logger, gitOpts ...GitRunnerOption) *GrepCommand {
+	git := newDefaultGitRunner(dir, log, gitOpts)
+	return NewGrepCommand(git.defaultFS(), git, log)
+}
+
+// GrepOptions configures the grep operation.
+type GrepOptions struct {
+	Pattern      string     // Pattern to search for (git grep regexp syntax)
+	Pathspecs    []string   // Limit the search to these paths (empty = all)
+	IgnoreCase   bool       // git grep -i
+	FixedStrings bool       // git grep -F
+	WordRegexp   bool       // git grep -w
+	FilesOnly    bool       // git grep -l: report matching files, not lines
+	Filter       ListFilter // Worktrees to search (zero = all)
+}
+
+// GrepMatch is a line matching the pattern, or a matching file with
+// FilesOnly.
+type GrepMatch struct {
+	Branch       string // Branch of the worktree (empty = detached)
+	HEAD         string // Commit of the worktree, to label detached ones
+	WorktreePath string
+	File         string // Path relative to the worktree root
+	Line         int    // 1-based line number (0 with FilesOnly)
+	Text         string // Matching line (empty with FilesOnly)
+}
+
+// Label returns the prefix of the match in output: the branch, or the
+// short commit for a detached worktree.
+func (m GrepMatch) Label() string {
+	if m.Branch != "" {
+		return m.Branch
+	}
+	return Worktree{HEAD: m.HEAD}.ShortHEAD()
+}
+
+// GrepFailure is a worktree that could not be searched.
+type GrepFailure struct {
+	WorktreePath string
+	Err          error
+}
+
+// GrepResult holds the result of a grep operation.
+type GrepResult struct {
+	Matches  []GrepMatch   // In worktree list order, then git grep order
+	Searched int           // Number of worktrees searched
+	Failures []GrepFailure // Worktrees that could not be searched
+}
+
+// GrepFormatOptions configures grep output formatting.
+type GrepFormatOptions struct {
+	ColorEnabled bool
+}
+
+// Warnings returns a warning for each worktree that could not be searched.
+func (r GrepResult) Warnings() []Warning {
+	var warnings []Warning
+	for _, f := range r.Failures {
+		warnings = append(warnings, Warning{
+			Code:    WarningSearchFailed,
+			Subject: f.WorktreePath,
+			Message: fmt.Sprintf("failed to search %s: %v", f.WorktreePath, f.Err),
+		})
+	}
+	return warnings
+}
+
+// Format formats the GrepResult like git grep, with each match prefixed
+// by its branch: "branch:file:line:text", or "branch:file" with FilesOnly.
+func (r GrepResult) Format(opts GrepFormatOptions) FormatResult {
+	var stdout, stderr strings.Builder
+	for _, m := range r.Matches {
+		label, file := m.Label(), m.File
+		if opts.ColorEnabled {
+			label, file = format.ColorGrepBranch(label), format.ColorGrepFile(file)
+		}
+		if m.Line == 0 {
+			fmt.Fprintf(&stdout, "%s:%s\n", label, file)
+			continue
+		}
+		line := strconv.Itoa(m.Line)
+		if opts.ColorEnabled {
+			line = format.ColorGrepLine(line)
+		}
+		fmt.Fprintf(&stdout, "%s:%s:%s:%s\n", label, file, line, m.Text)
+	}
+	writeWarnings(&stderr, r.Warnings())
+	return FormatResult{Stdout: stdout.String(), Stderr: stderr.String()}
+}
+
+// Run searches every worktree matching opts.Filter in parallel. A worktree
+// that cannot be searched is reported in Failures; Run fails only when no
+// worktree could be searched.
+func (c *GrepCommand) Run(ctx context.Context, opts GrepOptions) (GrepResult, error) {
+	if opts.Pattern == "" {
+		return GrepResult{}, fmt.Errorf("pattern is required")
+	}
+
+	worktrees, err := c.Git.WorktreeList(ctx)
+	if err != nil {
+		return GrepResult{}, err
+	}
+	// Select worktrees like twig list does
+	lister := &ListCommand{FS: c.FS, Git: c.Git, Log: c.Log}
+	worktrees, _, err = lister.filter(ctx, worktrees, opts.Filter)
+	if err != nil {
+		return GrepResult{}, err
+	}
+	worktrees = slices.DeleteFunc(worktrees, func(wt Worktree) bool {
+		return wt.Bare || wt.Prunable
+	})
+
+	matches := make([][]GrepMatch, len(worktrees))
+	errs := make([]error, len(worktrees))
+	var wg sync.WaitGroup
+	for i, wt := range worktrees {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			matches[i], errs[i] = c.grepWorktree(ctx, wt, opts)
+		}()
+	}
+	wg.Wait()
+
+	result := GrepResult{Searched: len(worktrees)}
+	for i, wt := range worktrees {
+		if errs[i] != nil {
+			result.Failures = append(result.Failures, GrepFailure{WorktreePath: wt.Path, Err: errs[i]})
+			continue
+		}
+		result.Matches = append(result.Matches, matches[i]...)
+	}
+	if len(worktrees) > 0 && len(result.Failures) == len(worktrees) {
+		// Every worktree failing alike usually means a bad pattern
+		return GrepResult{}, result.Failures[0].Err
+	}
+
+	c.Log.DebugContext(ctx, "grep completed",
+		LogAttrKeyCategory.String(), LogCategoryGrep,
+		"worktrees", len(worktrees),
+		"matches", len(result.Matches),
+		"failures", len(result.Failures))
+
+	return result, nil
+}
+
+// grepWorktree runs git grep in wt. Exit status 1 means no match.
+func (c *GrepCommand) grepWorktree(ctx context.Context, wt Worktree, opts GrepOptions) ([]GrepMatch, error) {
+	// -z keeps file names containing ":" unambiguous; -I skips binary files
+	args := []string{GitCmdGrep, "-z", "-I", "--no-color"}
+	if opts.FilesOnly {
+		args = append(args, "-l")
+	} else {
+		args = append(args, "-n")
+	}
+	if opts.IgnoreCase {
+		args = append(args, "-i")
+	}
+	if opts.FixedStrings {
+		args = append(args, "-F")
+	}
+	if opts.WordRegexp {
+		args = append(args, "-w")
+	}
+	args = append(args, "-e", opts.Pattern, "--")
+	args = append(args, opts.Pathspecs...)
+
+	out, err := c.Git.InDir(wt.Path).Run(ctx, args...)
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			if exitErr.ExitCode() == 1 {
+				return nil, nil
+			}
+			if stderr := strings.TrimSpace(string(exitErr.Stderr)); stderr != "" {
+				return nil, fmt.Errorf("git grep: %s", stderr)
+			}
+		}
+		return nil, fmt.Errorf("git grep: %w", err)
+	}
+	return parseGrepOutput(out, wt, opts.FilesOnly), nil
+}
+
+// parseGrepOutput parses git grep -z output: "file\0line\0text\n" per
+// line, or "file\0" per file with -l.
+func parseGrepOutput(out []byte, wt Worktree, filesOnly bool) []GrepMatch {
+	var matches []GrepMatch
+	if filesOnly {
+		for file := range bytes.SplitSeq(out, []byte{0}) {
+			if len(file) == 0 {
+				continue
+			}
+			matches = append(matches, GrepMatch{Branch: wt.Branch, HEAD: wt.HEAD, WorktreePath: wt.Path, File: string(file)})
+		}
+		return matches
+	}
+	for record := range bytes.SplitSeq(out, []byte{'\n'}) {
+		fields := bytes.SplitN(record, []byte{0}, 3)
+		if len(fields) < 3 {
+			continue
+		}
+		line, err := strconv.Atoi(string(fields[1]))
+		if err != nil {
+			continue
+		}
+		matches = append(matches, GrepMatch{
+			Branch:       wt.Branch,
+			HEAD:         wt.HEAD,
+			WorktreePath: wt.Path,
+			File:         string(fields[0]),
+			Line:         line,
+			Text:         string(fields[2]),
+		})
+	}
+	return matches
+}
@@ -0,0 +1,115 @@
+//go:build integration
+
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/708u/twig/internal/testutil"
+)
+
+func TestGrepCommand_Integration(t *testing.T) {
+	t.Parallel()
+
+	repoDir, mainDir := testutil.SetupTestRepo(t, testutil.WithoutSettings())
+
+	if err := os.WriteFile(filepath.Join(mainDir, "app.go"), []byte("package app\n\nconst Version = 1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	testutil.RunGit(t, mainDir, "add", "app.go")
+	testutil.RunGit(t, mainDir, "commit", "-m", "add app")
+
+	// feat/a commits a change, feat/b only has it in the working tree
+	featA := filepath.Join(repoDir, "feat", "a")
+	testutil.RunGit(t, mainDir, "worktree", "add", "-b", "feat/a", featA)
+	if err := os.WriteFile(filepath.Join(featA, "app.go"), []byte("package app\n\nconst Version = 2 // bump\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	testutil.RunGit(t, featA, "commit", "-am", "bump")
+
+	featB := filepath.Join(repoDir, "feat", "b")
+	testutil.RunGit(t, mainDir, "worktree", "add", "-b", "feat/b", featB)
+	if err := os.WriteFile(filepath.Join(featB, "app.go"), []byte("package app\n\nconst Version = 3 // bump\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	fixC := filepath.Join(repoDir, "fix", "c")
+	testutil.RunGit(t, mainDir, "worktree", "add", "-b", "fix/c", fixC)
+
+	cmd := NewDefaultGrepCommand(mainDir, NewNopLogger())
+
+	tests := []struct {
+		name    string
+		opts    GrepOptions
+		want    []string // formatted output lines
+		wantErr string
+	}{
+		{
+			name: "all worktrees",
+			opts: GrepOptions{Pattern: "Version = "},
+			want: []string{
+				"main:app.go:3:const Version = 1",
+				"feat/a:app.go:3:const Version = 2 // bump",
+				"feat/b:app.go:3:const Version = 3 // bump",
+				"fix/c:app.go:3:const Version = 1",
+			},
+		},
+		{
+			name: "files only with ignore case",
+			opts: GrepOptions{Pattern: "BUMP", IgnoreCase: true, FilesOnly: true},
+			want: []string{"feat/a:app.go", "feat/b:app.go"},
+		},
+		{
+			name: "dirty worktrees",
+			opts: GrepOptions{Pattern: "bump", Filter: ListFilter{Dirty: true}},
+			want: []string{"feat/b:app.go:3:const Version = 3 // bump"},
+		},
+		{
+			name: "branch glob",
+			opts: GrepOptions{Pattern: "Version = 1", FixedStrings: true, Filter: ListFilter{BranchGlob: "fix/*"}},
+			want: []string{"fix/c:app.go:3:const Version = 1"},
+		},
+		{
+			name: "pathspec without matches",
+			opts: GrepOptions{Pattern: "Version", Pathspecs: []string{"*.md"}},
+		},
+		{
+			name:    "invalid pattern",
+			opts:    GrepOptions{Pattern: "Version["},
+			wantErr: "git grep:",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			result, err := cmd.Run(t.Context(), tt.opts)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Run failed: %v", err)
+			}
+
+			formatted := result.Format(GrepFormatOptions{})
+			var got []string
+			if formatted.Stdout != "" {
+				got = strings.Split(strings.TrimSuffix(formatted.Stdout, "\n"), "\n")
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("output = %q, want %q", got, tt.want)
+			}
+			if formatted.Stderr != "" {
+				t.Errorf("stderr = %q, want empty", formatted.Stderr)
+			}
+		})
+	}
+}
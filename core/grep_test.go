@@ -0,0 +1,108 @@
+package core
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestParseGrepOutput(t *testing.T) {
+	t.Parallel()
+
+	wt := Worktree{Path: "/repo/feat-a", Branch: "feat/a", HEAD: "abc1234567890"}
+
+	tests := []struct {
+		name      string
+		out       string
+		filesOnly bool
+		want      []GrepMatch
+	}{
+		{
+			name: "lines",
+			out:  "a:b.txt\x001\x00foo\nc.txt\x0012\x00bar: foo\n",
+			want: []GrepMatch{
+				{Branch: "feat/a", HEAD: wt.HEAD, WorktreePath: wt.Path, File: "a:b.txt", Line: 1, Text: "foo"},
+				{Branch: "feat/a", HEAD: wt.HEAD, WorktreePath: wt.Path, File: "c.txt", Line: 12, Text: "bar: foo"},
+			},
+		},
+		{
+			name:      "files only",
+			out:       "a:b.txt\x00c.txt\x00",
+			filesOnly: true,
+			want: []GrepMatch{
+				{Branch: "feat/a", HEAD: wt.HEAD, WorktreePath: wt.Path, File: "a:b.txt"},
+				{Branch: "feat/a", HEAD: wt.HEAD, WorktreePath: wt.Path, File: "c.txt"},
+			},
+		},
+		{
+			name: "empty",
+			out:  "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := parseGrepOutput([]byte(tt.out), wt, tt.filesOnly)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("parseGrepOutput() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGrepResult_Format(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		result     GrepResult
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			name: "branch prefix",
+			result: GrepResult{Matches: []GrepMatch{
+				{Branch: "main", File: "go.mod", Line: 1, Text: "module example"},
+				{Branch: "feat/a", File: "a.go", Line: 3, Text: "x := 1"},
+			}},
+			wantStdout: "main:go.mod:1:module example\nfeat/a:a.go:3:x := 1\n",
+		},
+		{
+			name: "detached worktree uses short commit",
+			result: GrepResult{Matches: []GrepMatch{
+				{HEAD: "abc1234567890", File: "a.go", Line: 3, Text: "x"},
+			}},
+			wantStdout: "abc1234:a.go:3:x\n",
+		},
+		{
+			name: "files only",
+			result: GrepResult{Matches: []GrepMatch{
+				{Branch: "feat/a", File: "a.go"},
+			}},
+			wantStdout: "feat/a:a.go\n",
+		},
+		{
+			name: "failures go to stderr",
+			result: GrepResult{Failures: []GrepFailure{
+				{WorktreePath: "/repo/feat-b", Err: errors.New("git grep: boom")},
+			}},
+			wantStderr: "warning: failed to search /repo/feat-b: git grep: boom\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := tt.result.Format(GrepFormatOptions{})
+			if got.Stdout != tt.wantStdout {
+				t.Errorf("Stdout = %q, want %q", got.Stdout, tt.wantStdout)
+			}
+			if got.Stderr != tt.wantStderr {
+				t.Errorf("Stderr = %q, want %q", got.Stderr, tt.wantStderr)
+			}
+		})
+	}
+}
@@ -0,0 +1,293 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/708u/twig/format"
+)
+
+// ImportStatus is the outcome of importing one worktree.
+type ImportStatus string
+
+const (
+	ImportCreated ImportStatus = "created"
+	ImportSkipped ImportStatus = "skipped"
+	ImportFailed  ImportStatus = "failed"
+)
+
+// ImportedWorktree is the result of importing one StateWorktree.
+type ImportedWorktree struct {
+	Branch       string // Empty for detached worktrees
+	HEAD         string
+	WorktreePath string
+	Status       ImportStatus
+	Reason       string // Why the worktree was skipped
+	Fetched      bool   // The branch was fetched from a remote
+	Err          error
+}
+
+// name returns the branch, or the short HEAD of detached worktrees.
+func (w ImportedWorktree) name() string {
+	if w.Branch != "" {
+		return w.Branch
+	}
+	return shortHash(w.HEAD)
+}
+
+// ImportOptions configures the import operation.
+type ImportOptions struct {
+	Check bool // Show what would be created without creating anything
+}
+
+// ImportResult aggregates the results of an import.
+type ImportResult struct {
+	Worktrees []ImportedWorktree
+	Check     bool
+}
+
+// Count returns the number of worktrees with status.
+func (r ImportResult) Count(status ImportStatus) int {
+	n := 0
+	for _, wt := range r.Worktrees {
+		if wt.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+// HasErrors returns true if any worktree failed to import.
+func (r ImportResult) HasErrors() bool {
+	return r.Count(ImportFailed) > 0
+}
+
+// Format formats the ImportResult for display.
+func (r ImportResult) Format(opts FormatOptions) FormatResult {
+	var stdout, stderr strings.Builder
+	for _, wt := range r.Worktrees {
+		switch wt.Status {
+		case ImportFailed:
+			fmt.Fprintf(&stderr, "%s %s: %v\n", format.Paint(opts.ColorEnabled, format.ColorError, "error:"), wt.name(), wt.Err)
+		case ImportSkipped:
+			if opts.Verbose || r.Check {
+				fmt.Fprintf(&stdout, "Skipped %s: %s\n", wt.name(), format.Paint(opts.ColorEnabled, format.ColorReason, wt.Reason))
+			}
+		case ImportCreated:
+			verb := "Created"
+			if r.Check {
+				verb = "Would create"
+			}
+			var fetched string
+			switch {
+			case wt.Fetched && r.Check:
+				fetched = " (fetched from remote)"
+			case wt.Fetched:
+				fetched = " (fetched)"
+			}
+			fmt.Fprintf(&stdout, "%s %s: %s%s\n", verb, wt.name(), wt.WorktreePath, fetched)
+		}
+	}
+	if !r.Check {
+		fmt.Fprintf(&stdout, "twig import: %d created, %d skipped, %d failed\n",
+			r.Count(ImportCreated), r.Count(ImportSkipped), r.Count(ImportFailed))
+	}
+	return FormatResult{Stdout: stdout.String(), Stderr: stderr.String()}
+}
+
+// ImportCommand recreates the worktrees of a State written by twig export,
+// at the same paths relative to worktree_destination_base_dir. Branches
+// missing locally are fetched from the remotes.
+type ImportCommand struct {
+	FS         FileSystem
+	Git        *GitRunner
+	Config     *Config
+	Log        *slog.Logger
+	Provenance Provenance // Recorded in the marker of created worktrees (see AddOptions.Provenance)
+}
+
+// NewImportCommand creates an ImportCommand with explicit dependencies.
+func NewImportCommand(fs FileSystem, git *GitRunner, cfg *Config, log *slog.Logger) *ImportCommand {
+	if log == nil {
+		log = NewNopLogger()
+	}
+	return &ImportCommand{
+		FS:     fs,
+		Git:    git,
+		Config: cfg,
+		Log:    log,
+	}
+}
+
+// NewDefaultImportCommand creates an ImportCommand with production dependencies.
+func NewDefaultImportCommand(cfg *Config, log *slog.Logger, gitOpts ...GitRunnerOption) *ImportCommand {
+	git := newDefaultGitRunner(cfg.WorktreeSourceDir, log, gitOpts)
+	return NewImportCommand(git.defaultFS(), git, cfg, log)
+}
+
+// Run imports the worktrees of state. Worktrees that already exist are
+// skipped, and a failure to create one does not stop the others.
+func (c *ImportCommand) Run(ctx context.Context, state State, opts ImportOptions) (ImportResult, error) {
+	result := ImportResult{Check: opts.Check}
+	if c.Config.WorktreeDestBaseDir == "" {
+		return result, fmt.Errorf("worktree destination base directory is not configured")
+	}
+
+	worktrees, err := c.Git.WorktreeList(ctx)
+	if err != nil {
+		return result, err
+	}
+
+	for _, entry := range state.Worktrees {
+		imported := c.importWorktree(ctx, entry, worktrees, opts)
+		c.Log.DebugContext(ctx, "imported worktree",
+			LogAttrKeyCategory.String(), LogCategoryImport,
+			"branch", entry.Branch,
+			"path", imported.WorktreePath,
+			"status", imported.Status)
+		result.Worktrees = append(result.Worktrees, imported)
+	}
+	return result, nil
+}
+
+// importWorktree creates the worktree of entry unless it already exists.
+func (c *ImportCommand) importWorktree(ctx context.Context, entry StateWorktree, worktrees []Worktree, opts ImportOptions) ImportedWorktree {
+	imported := ImportedWorktree{
+		Branch:       entry.Branch,
+		HEAD:         entry.HEAD,
+		WorktreePath: c.absolutePath(entry.Path),
+		Status:       ImportCreated,
+	}
+	fail := func(err error) ImportedWorktree {
+		imported.Status = ImportFailed
+		imported.Err = err
+		return imported
+	}
+	skip := func(reason string) ImportedWorktree {
+		imported.Status = ImportSkipped
+		imported.Reason = reason
+		return imported
+	}
+
+	if entry.Path == "" {
+		return fail(fmt.Errorf("export entry has no path"))
+	}
+	for _, wt := range worktrees {
+		if filepath.Clean(wt.Path) == imported.WorktreePath {
+			return skip("worktree already exists")
+		}
+		if entry.Branch != "" && wt.Branch == entry.Branch {
+			return skip("branch is checked out in " + wt.Path)
+		}
+	}
+
+	addOpts := AddOptions{
+		NoPrefix:   true,
+		Lock:       entry.Locked,
+		LockReason: entry.LockReason,
+		Path:       imported.WorktreePath,
+		Provenance: c.Provenance,
+	}
+	name := entry.Branch
+	if entry.Branch == "" {
+		if _, err := c.Git.ResolveCommit(ctx, entry.HEAD); err != nil {
+			return skip("commit not found")
+		}
+		addOpts.Detach = true
+		name = entry.HEAD
+	} else {
+		found, fetched, startPoint, err := c.locateBranch(ctx, entry, opts.Check)
+		if err != nil {
+			return fail(err)
+		}
+		if !found {
+			return skip("branch not found locally or on any remote")
+		}
+		imported.Fetched = fetched
+		addOpts.StartPoint = startPoint
+	}
+	if opts.Check {
+		return imported
+	}
+
+	addCmd := NewAddCommand(c.FS, c.Git, c.Config, c.Log, addOpts)
+	if _, err := addCmd.Run(ctx, name); err != nil {
+		return fail(err)
+	}
+	if entry.Note != "" && entry.Branch != "" {
+		if err := c.restoreNote(ctx, entry.Branch, entry.Note); err != nil {
+			c.Log.DebugContext(ctx, "failed to restore note",
+				LogAttrKeyCategory.String(), LogCategoryImport,
+				"branch", entry.Branch,
+				"error", err.Error())
+		}
+	}
+	return imported
+}
+
+// locateBranch reports whether the branch of entry can be checked out: it
+// exists locally, on a remote (fetched unless check is set), or its
+// exported HEAD is known, in which case it is recreated at that commit.
+func (c *ImportCommand) locateBranch(ctx context.Context, entry StateWorktree, check bool) (found, fetched bool, startPoint string, err error) {
+	local, err := c.Git.LocalBranchExists(ctx, entry.Branch)
+	if err != nil {
+		return false, false, "", fmt.Errorf("failed to check branch existence: %w", err)
+	}
+	if local {
+		return true, false, "", nil
+	}
+	remote, err := c.Git.FindRemoteForBranch(ctx, entry.Branch)
+	if err != nil {
+		return false, false, "", err
+	}
+	if remote != "" {
+		return true, false, "", nil
+	}
+	// --check only asks the remotes, without fetching
+	var remotes []string
+	if check {
+		remotes, err = c.Git.LookupRemotesForBranch(ctx, entry.Branch, remoteLookupTimeout)
+	} else {
+		remotes, err = c.Git.FetchBranchFromRemotes(ctx, entry.Branch)
+	}
+	if err != nil {
+		return false, false, "", err
+	}
+	if len(remotes) > 0 {
+		return true, true, "", nil
+	}
+	if entry.HEAD != "" {
+		if _, err := c.Git.ResolveCommit(ctx, entry.HEAD); err == nil {
+			return true, false, entry.HEAD, nil
+		}
+	}
+	return false, false, "", nil
+}
+
+// restoreNote sets the note of branch unless it already has one.
+func (c *ImportCommand) restoreNote(ctx context.Context, branch, text string) error {
+	store := NewNoteStore(c.FS, c.Git)
+	notes, err := store.Load(ctx)
+	if err != nil {
+		return err
+	}
+	if _, ok := notes[branch]; ok {
+		return nil
+	}
+	notes[branch] = Note{Text: text, UpdatedAt: time.Now()}
+	return store.Save(ctx, notes)
+}
+
+// absolutePath resolves an exported path against the destination base
+// directory. Absolute paths are kept as is.
+func (c *ImportCommand) absolutePath(path string) string {
+	path = filepath.FromSlash(path)
+	if filepath.IsAbs(path) {
+		return filepath.Clean(path)
+	}
+	return filepath.Join(c.Config.WorktreeDestBaseDir, path)
+}
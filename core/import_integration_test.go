@@ -0,0 +1,94 @@
+//go:build integration
+
+package core
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/708u/twig/internal/testutil"
+)
+
+func TestExportImport_Integration(t *testing.T) {
+	t.Parallel()
+
+	srcRepo, srcMain := testutil.SetupTestRepo(t)
+
+	testutil.RunGit(t, srcMain, "worktree", "add", "--lock", "--reason", "on usb", "-b", "feat/a", filepath.Join(srcRepo, "feat", "a"))
+	testutil.RunGit(t, srcMain, "worktree", "add", "-b", "feat/b", filepath.Join(srcRepo, "custom", "b"))
+
+	srcCfg, err := LoadConfig(srcMain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	noteStore := NewNoteStore(osFS{}, NewGitRunner(srcMain))
+	if err := noteStore.Save(t.Context(), map[string]Note{"feat/a": {Text: "waiting for review"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	state, err := NewExportCommand(osFS{}, NewGitRunner(srcMain), srcCfg.Config, nil).Run(t.Context())
+	if err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	if len(state.Worktrees) != 2 {
+		t.Fatalf("exported %+v, want 2 worktrees", state.Worktrees)
+	}
+
+	// A clone on another machine only knows the branches through its remote
+	dstRepo, dstMain := testutil.SetupTestRepo(t)
+	testutil.RunGit(t, dstMain, "remote", "add", "origin", srcMain)
+
+	dstCfg, err := LoadConfig(dstMain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmd := NewImportCommand(osFS{}, NewGitRunner(dstMain), dstCfg.Config, nil)
+	result, err := cmd.Run(t.Context(), state, ImportOptions{})
+	if err != nil {
+		t.Fatalf("import failed: %v", err)
+	}
+	if result.HasErrors() || result.Count(ImportCreated) != 2 {
+		t.Fatalf("import result = %+v, want 2 created", result.Worktrees)
+	}
+	for _, wt := range result.Worktrees {
+		if !wt.Fetched {
+			t.Errorf("%s should be fetched from origin", wt.Branch)
+		}
+	}
+
+	worktrees, err := NewGitRunner(dstMain).WorktreeList(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]Worktree)
+	for _, wt := range worktrees {
+		got[wt.Branch] = wt
+	}
+	if wt := got["feat/a"]; wt.Path != filepath.Join(dstRepo, "feat", "a") || !wt.Locked || wt.LockReason != "on usb" {
+		t.Errorf("feat/a = %+v, want locked at %s", wt, filepath.Join(dstRepo, "feat", "a"))
+	}
+	if wt := got["feat/b"]; wt.Path != filepath.Join(dstRepo, "custom", "b") {
+		t.Errorf("feat/b path = %q, want %q", wt.Path, filepath.Join(dstRepo, "custom", "b"))
+	}
+
+	notes, err := NewNoteStore(osFS{}, NewGitRunner(dstMain)).Load(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if notes["feat/a"].Text != "waiting for review" {
+		t.Errorf("note = %q, want restored", notes["feat/a"].Text)
+	}
+
+	// Importing again skips what already exists
+	result, err = cmd.Run(t.Context(), state, ImportOptions{})
+	if err != nil {
+		t.Fatalf("second import failed: %v", err)
+	}
+	if result.Count(ImportSkipped) != 2 {
+		t.Errorf("second import = %+v, want all skipped", result.Worktrees)
+	}
+	if out := result.Format(FormatOptions{}).Stdout; !strings.Contains(out, "0 created, 2 skipped, 0 failed") {
+		t.Errorf("summary = %q", out)
+	}
+}
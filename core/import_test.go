@@ -0,0 +1,134 @@
+package core
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/708u/twig/internal/testutil"
+)
+
+func TestImportCommand_Run_Check(t *testing.T) {
+	t.Parallel()
+
+	mockGit := &testutil.MockGitExecutor{
+		Worktrees: []testutil.MockWorktree{
+			{Path: "/repo/main", Branch: "main"},
+			{Path: "/repo/worktrees/feat/a", Branch: "feat/a"},
+			{Path: "/repo/worktrees/other", Branch: "feat/moved"},
+		},
+		ExistingBranches: []string{"feat/local"},
+		Remotes:          []string{"origin"},
+		RemoteBranches:   map[string][]string{"origin": {"feat/tracked"}},
+		LsRemoteBranches: map[string][]string{"origin": {"feat/pushed"}},
+		MissingCommits:   []string{"dead000", "dead111"},
+	}
+	state := State{Version: StateVersion, Worktrees: []StateWorktree{
+		{Branch: "feat/a", Path: "feat/a"},
+		{Branch: "feat/moved", Path: "moved"},
+		{Branch: "feat/local", Path: "feat/local"},
+		{Branch: "feat/tracked", Path: "../elsewhere/tracked"},
+		{Branch: "feat/pushed", Path: "feat/pushed"},
+		{Branch: "feat/gone", Path: "feat/gone", HEAD: "abc1234"},
+		{Branch: "feat/lost", Path: "feat/lost", HEAD: "dead000"},
+		{Path: "scratch", HEAD: "dead111"},
+	}}
+
+	cmd := NewImportCommand(&testutil.MockFS{}, &GitRunner{Executor: mockGit, Dir: "/repo/main", Log: NewNopLogger()},
+		&Config{WorktreeSourceDir: "/repo/main", WorktreeDestBaseDir: "/repo/worktrees"}, nil)
+	result, err := cmd.Run(t.Context(), state, ImportOptions{Check: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []struct {
+		path    string
+		status  ImportStatus
+		fetched bool
+		reason  string
+	}{
+		{path: "/repo/worktrees/feat/a", status: ImportSkipped, reason: "worktree already exists"},
+		{path: "/repo/worktrees/moved", status: ImportSkipped, reason: "branch is checked out in /repo/worktrees/other"},
+		{path: "/repo/worktrees/feat/local", status: ImportCreated},
+		{path: "/repo/elsewhere/tracked", status: ImportCreated},
+		{path: "/repo/worktrees/feat/pushed", status: ImportCreated, fetched: true},
+		{path: "/repo/worktrees/feat/gone", status: ImportCreated},
+		{path: "/repo/worktrees/feat/lost", status: ImportSkipped, reason: "branch not found locally or on any remote"},
+		{path: "/repo/worktrees/scratch", status: ImportSkipped, reason: "commit not found"},
+	}
+	if len(result.Worktrees) != len(want) {
+		t.Fatalf("Worktrees = %+v", result.Worktrees)
+	}
+	for i, w := range want {
+		got := result.Worktrees[i]
+		if got.WorktreePath != w.path || got.Status != w.status || got.Fetched != w.fetched || got.Reason != w.reason {
+			t.Errorf("Worktrees[%d] = %+v, want %+v", i, got, w)
+		}
+	}
+}
+
+func TestImportResult_Format(t *testing.T) {
+	t.Parallel()
+
+	result := ImportResult{Worktrees: []ImportedWorktree{
+		{Branch: "feat/a", WorktreePath: "/wt/feat/a", Status: ImportCreated, Fetched: true},
+		{Branch: "feat/b", WorktreePath: "/wt/feat/b", Status: ImportSkipped, Reason: "worktree already exists"},
+		{HEAD: "abc1234567", WorktreePath: "/wt/scratch", Status: ImportFailed, Err: errors.New("directory already exists")},
+	}}
+
+	tests := []struct {
+		name       string
+		check      bool
+		verbose    bool
+		wantStdout []string
+		wantNot    []string
+	}{
+		{
+			name: "default",
+			wantStdout: []string{
+				"Created feat/a: /wt/feat/a (fetched)\n",
+				"twig import: 1 created, 1 skipped, 1 failed\n",
+			},
+			wantNot: []string{"Skipped"},
+		},
+		{
+			name:    "verbose shows skipped",
+			verbose: true,
+			wantStdout: []string{
+				"Skipped feat/b: worktree already exists\n",
+			},
+		},
+		{
+			name:  "check",
+			check: true,
+			wantStdout: []string{
+				"Would create feat/a: /wt/feat/a (fetched from remote)\n",
+				"Skipped feat/b: worktree already exists\n",
+			},
+			wantNot: []string{"twig import:"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := result
+			r.Check = tt.check
+			got := r.Format(FormatOptions{Verbose: tt.verbose})
+			for _, want := range tt.wantStdout {
+				if !strings.Contains(got.Stdout, want) {
+					t.Errorf("Stdout missing %q in:\n%s", want, got.Stdout)
+				}
+			}
+			for _, not := range tt.wantNot {
+				if strings.Contains(got.Stdout, not) {
+					t.Errorf("Stdout should not contain %q:\n%s", not, got.Stdout)
+				}
+			}
+			if want := "error: abc1234: directory already exists\n"; got.Stderr != want {
+				t.Errorf("Stderr = %q, want %q", got.Stderr, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,306 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+const gitignoreFileName = ".gitignore"
+
+// gitignoreEntries are the files init --update-gitignore keeps out of the
+// repository: per-user settings and files twig generates in each worktree.
+var gitignoreEntries = []string{
+	configDir + "/" + localConfigFileName,
+	EnvFileName,
+	WorktreeNoteFileName,
+}
+
+// gitignoreHeader introduces the entries appended to .gitignore.
+const gitignoreHeader = "# twig local files"
+
+const settingsTemplate = `# twig project configuration
+# See: https://github.com/708u/twig
+
+# Default source branch for new worktrees (prevents symlink chaining)
+default_source = "main"
+
+# Symlink patterns to create in new worktrees
+# Recommend: [".twig/settings.local.toml"] to share local settings across worktrees
+symlinks = []
+
+# Worktree destination base directory (default: ../<repo-name>-worktree)
+# worktree_destination_base_dir = "../my-worktrees"
+
+# Additional symlink patterns (collected from both project and local configs)
+# extra_symlinks = [".envrc", ".tool-versions"]
+
+# Skip symlinks whose source resolves outside this worktree instead of warning (default: false)
+# strict_symlinks = true
+
+# Point symlinks at their source by a "relative" or "absolute" path (default: "relative")
+# symlink_style = "absolute"
+
+# Initialize submodules when creating worktrees (default: false)
+# init_submodules = true
+
+# Reuse objects from main worktree for faster submodule init (default: false)
+# submodule_reference = true
+
+# Mirror of submodule repositories (laid out like .git/modules) to reference first
+# submodule_reference_dir = "/srv/mirrors/app/modules"
+
+# Ask remotes for branches not fetched yet when adding worktrees (default: false)
+# lookup_remote_branches = true
+
+# Always enable --fetch for add command (default: false)
+# fetch_on_add = true
+
+# Always enable --stale for clean command (default: false)
+# clean_stale = true
+
+# Always enable --fetch for clean command (default: false)
+# clean_fetch = true
+
+# Only let clean remove worktrees created or adopted by twig (default: false)
+# clean_only_twig_managed = true
+
+# Command run for each worktree clean would remove; a non-zero exit keeps it ({path} = worktree path)
+# clean_verify_command = "./scripts/has-unpushed-artifacts.sh {path}"
+
+# Remove parent directories (e.g. feat/) emptied by remove/clean (default: true)
+# cleanup_empty_dirs = false
+
+# Detect squash-merged branches as cleanable via patch-id comparison (default: false)
+# detect_squash_merges = true
+
+# Look up PR state for clean and PR titles for add --pr: "github" or "gitlab"
+# (token from GITHUB_TOKEN / GITLAB_TOKEN)
+# forge = "github"
+
+# Branch name for add --pr: {number}, {title} (slug) and {head} (PR branch) (default: "pr/{number}-{title}")
+# pr_branch_template = "review/{number}"
+
+# Branch names or worktree paths that differ only in case from existing ones:
+# "auto" (error on case-insensitive filesystems, warn elsewhere), "error" or "warn" (default: "auto")
+# case_collisions = "error"
+
+# Branches never removed by remove/clean, even with -ff (glob patterns allowed)
+# protected_branches = ["main", "develop", "release/*"]
+
+# Branches clean never offers for removal, e.g. long-lived experiments (glob patterns allowed)
+# clean_exclude = ["spike/*"]
+
+# Commands to run after worktree creation (run in new worktree directory)
+# hooks = ["npm install", "direnv allow"]
+
+# Prefix for branch names created by add (twig add fix-login -> users/me/fix-login)
+# Usually set per user in .twig/settings.local.toml
+# branch_prefix = "users/me/"
+
+# Short names for add that map to full branch names (worktree uses the short name)
+# branch_aliases = { login = "users/me/fix-login-redirect" }
+
+# Worktree garbage collection policy enforced by twig gc (keep tables at the end)
+# [gc]
+# max_worktrees = 20
+# max_age = "45d"
+# check_on_add = true  # Print a hint after add when over the policy
+
+# Output colors: a color name with optional attributes, like git (e.g. "bold red")
+# [colors]
+# clean = "green"
+# skip = "yellow"
+# error = "red"
+
+# Named profiles selected with --profile (e.g. twig --profile review add pr-123)
+# Profile settings override the settings above; keep profile tables at the end
+# [profiles.review]
+# symlinks = []
+# hooks = []
+# worktree_destination_base_dir = "../review-worktrees"
+`
+
+// InitCommand initializes twig configuration in a directory.
+type InitCommand struct {
+	FS  FileSystem
+	Log *slog.Logger
+}
+
+// InitOptions holds options for the init command.
+type InitOptions struct {
+	Force           bool
+	UpdateGitignore bool // Add twig's local files to .gitignore
+}
+
+// InitResult holds the result of the init command.
+type InitResult struct {
+	ConfigDir    string
+	SettingsPath string
+	Created      bool
+	Skipped      bool
+	Overwritten  bool
+
+	GitignorePath    string   // Set when --update-gitignore was given
+	GitignoreCreated bool     // .gitignore did not exist
+	GitignoreAdded   []string // Entries appended to .gitignore
+}
+
+// InitFormatOptions holds formatting options for InitResult.
+type InitFormatOptions struct {
+	Verbose bool
+	Quiet   bool // Print only the path of a written settings file
+}
+
+// NewInitCommand creates an InitCommand with explicit dependencies (for testing).
+func NewInitCommand(fs FileSystem, log *slog.Logger) *InitCommand {
+	if log == nil {
+		log = NewNopLogger()
+	}
+	return &InitCommand{
+		FS:  fs,
+		Log: log,
+	}
+}
+
+// NewDefaultInitCommand creates an InitCommand with production defaults.
+func NewDefaultInitCommand(log *slog.Logger, gitOpts ...GitRunnerOption) *InitCommand {
+	return NewInitCommand(newDefaultFS(gitOpts), log)
+}
+
+// Run executes the init command.
+func (c *InitCommand) Run(ctx context.Context, dir string, opts InitOptions) (InitResult, error) {
+	configDirPath := filepath.Join(dir, configDir)
+	settingsPath := filepath.Join(configDirPath, configFileName)
+
+	result := InitResult{
+		ConfigDir:    configDirPath,
+		SettingsPath: settingsPath,
+	}
+
+	// Check if settings file already exists
+	_, err := c.FS.Stat(settingsPath)
+	exists := err == nil || !c.FS.IsNotExist(err)
+
+	if exists && !opts.Force {
+		result.Skipped = true
+	} else {
+		// Create config directory
+		if err := c.FS.MkdirAll(configDirPath, 0755); err != nil {
+			return result, fmt.Errorf("failed to create config directory: %w", err)
+		}
+
+		// Write settings file
+		if err := c.FS.WriteFile(settingsPath, []byte(settingsTemplate), 0644); err != nil {
+			return result, fmt.Errorf("failed to write settings file: %w", err)
+		}
+
+		result.Created = true
+		if exists {
+			result.Overwritten = true
+		}
+	}
+
+	if opts.UpdateGitignore {
+		if err := c.updateGitignore(dir, &result); err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+// updateGitignore appends the gitignoreEntries missing from the
+// .gitignore in dir, creating it if needed. Entries already listed, with
+// or without a leading slash, are left alone.
+func (c *InitCommand) updateGitignore(dir string, result *InitResult) error {
+	path := filepath.Join(dir, gitignoreFileName)
+	result.GitignorePath = path
+
+	content, err := c.FS.ReadFile(path)
+	if err != nil {
+		if !c.FS.IsNotExist(err) {
+			return fmt.Errorf("failed to read %s: %w", gitignoreFileName, err)
+		}
+		result.GitignoreCreated = true
+	}
+
+	var existing []string
+	for line := range strings.SplitSeq(string(content), "\n") {
+		existing = append(existing, strings.TrimPrefix(strings.TrimSpace(line), "/"))
+	}
+	for _, entry := range gitignoreEntries {
+		if !slices.Contains(existing, entry) {
+			result.GitignoreAdded = append(result.GitignoreAdded, entry)
+		}
+	}
+	if len(result.GitignoreAdded) == 0 {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	if len(content) > 0 {
+		if !bytes.HasSuffix(content, []byte("\n")) {
+			buf.WriteString("\n")
+		}
+		buf.WriteString("\n")
+	}
+	buf.WriteString(gitignoreHeader + "\n")
+	for _, entry := range result.GitignoreAdded {
+		buf.WriteString(entry + "\n")
+	}
+	if err := c.FS.AppendFile(path, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to update %s: %w", gitignoreFileName, err)
+	}
+	c.Log.Debug("updated gitignore",
+		"category", LogCategoryConfig,
+		"path", path,
+		"added", result.GitignoreAdded)
+	return nil
+}
+
+// Format formats the result for output.
+func (r InitResult) Format(opts InitFormatOptions) FormatResult {
+	var stdout string
+
+	relPath := filepath.Join(configDir, configFileName)
+
+	gitignoreWritten := len(r.GitignoreAdded) > 0
+
+	if opts.Quiet {
+		if r.Created {
+			stdout += relPath + "\n"
+		}
+		if gitignoreWritten {
+			stdout += gitignoreFileName + "\n"
+		}
+		return FormatResult{Stdout: stdout}
+	}
+
+	switch {
+	case r.Skipped:
+		stdout = fmt.Sprintf("Skipped %s (already exists)\n", relPath)
+	case r.Overwritten:
+		stdout = fmt.Sprintf("Created %s (overwritten)\n", relPath)
+	case r.Created:
+		stdout = fmt.Sprintf("Created %s\n", relPath)
+	}
+
+	switch {
+	case r.GitignorePath == "":
+	case !gitignoreWritten:
+		stdout += fmt.Sprintf("Skipped %s (already ignores twig files)\n", gitignoreFileName)
+	case r.GitignoreCreated:
+		stdout += fmt.Sprintf("Created %s (%s)\n", gitignoreFileName, strings.Join(r.GitignoreAdded, ", "))
+	default:
+		stdout += fmt.Sprintf("Updated %s (added %s)\n", gitignoreFileName, strings.Join(r.GitignoreAdded, ", "))
+	}
+
+	return FormatResult{
+		Stdout: stdout,
+	}
+}
@@ -1,8 +1,9 @@
-package twig
+package core
 
 import (
 	"errors"
 	"path/filepath"
+	"slices"
 	"testing"
 
 	"github.com/708u/twig/internal/testutil"
@@ -127,6 +128,89 @@ func TestInitCommand_Run(t *testing.T) {
 	}
 }
 
+func TestInitCommand_Run_UpdateGitignore(t *testing.T) {
+	t.Parallel()
+
+	gitignorePath := filepath.Join("/test", ".gitignore")
+	settingsPath := filepath.Join("/test", ".twig", "settings.toml")
+
+	tests := []struct {
+		name        string
+		existing    map[string][]byte
+		opts        InitOptions
+		wantCreated bool
+		wantAdded   []string
+		wantContent string
+	}{
+		{
+			name:        "creates gitignore",
+			opts:        InitOptions{UpdateGitignore: true},
+			wantCreated: true,
+			wantAdded:   []string{".twig/settings.local.toml", ".twig.env", "WORKTREE_NOTE"},
+			wantContent: "# twig local files\n.twig/settings.local.toml\n.twig.env\nWORKTREE_NOTE\n",
+		},
+		{
+			name: "appends missing entries after a blank line",
+			existing: map[string][]byte{
+				gitignorePath: []byte("node_modules/\n/.twig.env"),
+			},
+			opts:        InitOptions{UpdateGitignore: true},
+			wantAdded:   []string{".twig/settings.local.toml", "WORKTREE_NOTE"},
+			wantContent: "node_modules/\n/.twig.env\n\n# twig local files\n.twig/settings.local.toml\nWORKTREE_NOTE\n",
+		},
+		{
+			name: "leaves complete gitignore alone",
+			existing: map[string][]byte{
+				gitignorePath: []byte(".twig/settings.local.toml\n.twig.env\nWORKTREE_NOTE\n"),
+			},
+			opts:        InitOptions{UpdateGitignore: true},
+			wantContent: ".twig/settings.local.toml\n.twig.env\nWORKTREE_NOTE\n",
+		},
+		{
+			name: "updates gitignore when settings are skipped",
+			existing: map[string][]byte{
+				settingsPath: []byte("symlinks = []\n"),
+			},
+			opts:        InitOptions{UpdateGitignore: true},
+			wantCreated: true,
+			wantAdded:   []string{".twig/settings.local.toml", ".twig.env", "WORKTREE_NOTE"},
+			wantContent: "# twig local files\n.twig/settings.local.toml\n.twig.env\nWORKTREE_NOTE\n",
+		},
+		{
+			name: "not requested",
+			opts: InitOptions{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockFS := &testutil.MockFS{WrittenFiles: make(map[string][]byte)}
+			for path, content := range tt.existing {
+				mockFS.ExistingPaths = append(mockFS.ExistingPaths, path)
+				mockFS.WrittenFiles[path] = content
+			}
+			cmd := NewInitCommand(mockFS, NewNopLogger())
+
+			result, err := cmd.Run(t.Context(), "/test", tt.opts)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if result.GitignoreCreated != tt.wantCreated {
+				t.Errorf("GitignoreCreated = %v, want %v", result.GitignoreCreated, tt.wantCreated)
+			}
+			if !slices.Equal(result.GitignoreAdded, tt.wantAdded) {
+				t.Errorf("GitignoreAdded = %v, want %v", result.GitignoreAdded, tt.wantAdded)
+			}
+			if got := string(mockFS.WrittenFiles[gitignorePath]); got != tt.wantContent {
+				t.Errorf(".gitignore = %q, want %q", got, tt.wantContent)
+			}
+		})
+	}
+}
+
 func TestInitResult_Format(t *testing.T) {
 	t.Parallel()
 
@@ -161,6 +245,62 @@ func TestInitResult_Format(t *testing.T) {
 			opts:       InitFormatOptions{},
 			wantStdout: "Created .twig/settings.toml (overwritten)\n",
 		},
+		{
+			name: "quiet created",
+			result: InitResult{
+				Created: true,
+			},
+			opts:       InitFormatOptions{Quiet: true},
+			wantStdout: ".twig/settings.toml\n",
+		},
+		{
+			name: "gitignore created",
+			result: InitResult{
+				Created:          true,
+				GitignorePath:    "/test/.gitignore",
+				GitignoreCreated: true,
+				GitignoreAdded:   []string{".twig/settings.local.toml", ".twig.env"},
+			},
+			opts:       InitFormatOptions{},
+			wantStdout: "Created .twig/settings.toml\nCreated .gitignore (.twig/settings.local.toml, .twig.env)\n",
+		},
+		{
+			name: "gitignore updated with settings skipped",
+			result: InitResult{
+				Skipped:        true,
+				GitignorePath:  "/test/.gitignore",
+				GitignoreAdded: []string{"WORKTREE_NOTE"},
+			},
+			opts:       InitFormatOptions{},
+			wantStdout: "Skipped .twig/settings.toml (already exists)\nUpdated .gitignore (added WORKTREE_NOTE)\n",
+		},
+		{
+			name: "gitignore already complete",
+			result: InitResult{
+				Skipped:       true,
+				GitignorePath: "/test/.gitignore",
+			},
+			opts:       InitFormatOptions{},
+			wantStdout: "Skipped .twig/settings.toml (already exists)\nSkipped .gitignore (already ignores twig files)\n",
+		},
+		{
+			name: "quiet gitignore updated",
+			result: InitResult{
+				Skipped:        true,
+				GitignorePath:  "/test/.gitignore",
+				GitignoreAdded: []string{"WORKTREE_NOTE"},
+			},
+			opts:       InitFormatOptions{Quiet: true},
+			wantStdout: ".gitignore\n",
+		},
+		{
+			name: "quiet skipped",
+			result: InitResult{
+				Skipped: true,
+			},
+			opts:       InitFormatOptions{Quiet: true},
+			wantStdout: "",
+		},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,463 @@
+package core
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+	"path"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/708u/twig/format"
+)
+
+// ListCommand lists all worktrees.
+type ListCommand struct {
+	FS  FileSystem
+	Git *GitRunner
+	Log *slog.Logger
+}
+
+// NewListCommand creates a ListCommand with explicit dependencies (for testing).
+func NewListCommand(fs FileSystem, git *GitRunner, log *slog.Logger) *ListCommand {
+	if log == nil {
+		log = NewNopLogger()
+	}
+	return &ListCommand{
+		FS:  fs,
+		Git: git,
+		Log: log,
+	}
+}
+
+// NewDefaultListCommand creates a ListCommand with production defaults.
+func NewDefaultListCommand(dir string, log *slog.Logger, gitOpts ...GitRunnerOption) *ListCommand {
+	git := newDefaultGitRunner(dir, log, gitOpts)
+	return NewListCommand(git.defaultFS(), git, log)
+}
+
+// ListSortKey selects the order of listed worktrees.
+type ListSortKey string
+
+const (
+	ListSortDefault ListSortKey = ""     // git worktree list order
+	ListSortPath    ListSortKey = "path" // Worktree path, ascending
+	ListSortSize    ListSortKey = "size" // Disk usage, largest first
+)
+
+// ListOptions configures the list operation.
+type ListOptions struct {
+	Size    bool        // Calculate per-worktree disk usage
+	Refresh bool        // Ignore cached sizes
+	Sort    ListSortKey // Output order (size implies Size)
+	Notes   bool        // Load branch notes (twig note), descriptions and provenance
+	Filter  ListFilter  // Worktrees to show (zero = all)
+
+	// CheckDirty checks every listed worktree for uncommitted changes
+	// (fills ListResult.Dirty), as the tree view shows them.
+	CheckDirty bool
+}
+
+// ListFilter selects the worktrees to list. Set fields are combined: a
+// worktree is listed only if it matches all of them.
+type ListFilter struct {
+	Dirty      bool   // Has uncommitted changes
+	Locked     bool   // Is locked
+	Merged     bool   // Branch is merged into MergedInto, as twig clean checks
+	MergedInto string // Target for Merged (empty = branch of the main worktree)
+	BranchGlob string // Branch matches this pattern (path.Match syntax, e.g. "feat/*")
+}
+
+// IsZero reports whether the filter lists every worktree.
+func (f ListFilter) IsZero() bool {
+	return f == ListFilter{}
+}
+
+// ListResult holds the result of a list operation.
+type ListResult struct {
+	Worktrees   []Worktree
+	Sizes       map[string]int64      // Disk usage by worktree path (nil = not calculated)
+	MainPath    string                // Path of the main worktree
+	CurrentPath string                // Path of the worktree containing the working directory (empty = none)
+	Notes       map[string]string     // Note text by branch (nil = not loaded)
+	Descs       map[string]string     // Branch description by branch (nil = not loaded)
+	Provenance  map[string]Provenance // Provenance marker by worktree path (nil = not loaded)
+	Dirty       map[string]bool       // Worktree paths with uncommitted changes (nil = not checked)
+}
+
+// Markers for the main and current worktree in list output.
+const (
+	listMarkerMain    = "*"
+	listMarkerCurrent = "@"
+)
+
+// TotalSize returns the summed disk usage of all sized worktrees.
+func (r ListResult) TotalSize() int64 {
+	var total int64
+	for _, size := range r.Sizes {
+		total += size
+	}
+	return total
+}
+
+// ListFormatOptions configures list output formatting.
+type ListFormatOptions struct {
+	Quiet        bool
+	Porcelain    bool   // Machine-readable records, one attribute per line
+	ColorEnabled bool   // Color the main and current markers
+	Tree         bool   // Group worktrees by directory under TreeRoot
+	TreeRoot     string // Directory grouped by Tree (worktree_destination_base_dir)
+}
+
+// Format formats the ListResult for display.
+func (r ListResult) Format(opts ListFormatOptions) FormatResult {
+	if opts.Porcelain {
+		return r.formatPorcelain()
+	}
+	if opts.Quiet {
+		return r.formatQuiet()
+	}
+	if opts.Tree {
+		return r.formatTree(opts)
+	}
+	return r.formatDefault(opts)
+}
+
+// formatPorcelain outputs git worktree list --porcelain style records,
+// with "main" and "current" lines marking those worktrees and a "size"
+// line in bytes when sizes were calculated.
+func (r ListResult) formatPorcelain() FormatResult {
+	var stdout strings.Builder
+	for _, wt := range r.Worktrees {
+		fmt.Fprintf(&stdout, "worktree %s\n", wt.Path)
+		if wt.HEAD != "" {
+			fmt.Fprintf(&stdout, "HEAD %s\n", wt.HEAD)
+		}
+		switch {
+		case wt.Bare:
+			stdout.WriteString("bare\n")
+		case wt.Detached:
+			stdout.WriteString("detached\n")
+		default:
+			fmt.Fprintf(&stdout, "branch refs/heads/%s\n", wt.Branch)
+			if wt.Bookmark {
+				stdout.WriteString("bookmark\n")
+			}
+		}
+		if wt.Locked {
+			stdout.WriteString(strings.TrimSpace("locked "+wt.LockReason) + "\n")
+		}
+		if wt.Prunable {
+			stdout.WriteString(strings.TrimSpace("prunable "+wt.PrunableReason) + "\n")
+		}
+		if wt.Path == r.MainPath {
+			stdout.WriteString("main\n")
+		}
+		if wt.Path == r.CurrentPath {
+			stdout.WriteString("current\n")
+		}
+		if size, ok := r.Sizes[wt.Path]; ok {
+			fmt.Fprintf(&stdout, "size %d\n", size)
+		}
+		if note := r.Notes[wt.Branch]; note != "" && wt.Branch != "" {
+			fmt.Fprintf(&stdout, "note %s\n", formatNote(note))
+		}
+		if desc := r.Descs[wt.Branch]; desc != "" && wt.Branch != "" {
+			fmt.Fprintf(&stdout, "description %s\n", formatNote(desc))
+		}
+		if p, ok := r.Provenance[wt.Path]; ok {
+			fmt.Fprintf(&stdout, "managed %s\n", p.Command)
+			if !p.CreatedAt.IsZero() {
+				fmt.Fprintf(&stdout, "created %s\n", p.CreatedAt.UTC().Format(time.RFC3339))
+			}
+			if p.Source != "" {
+				fmt.Fprintf(&stdout, "source %s\n", p.Source)
+			}
+			if p.Version != "" {
+				fmt.Fprintf(&stdout, "twig-version %s\n", p.Version)
+			}
+		}
+		if r.Dirty[wt.Path] {
+			stdout.WriteString("dirty\n")
+		}
+		stdout.WriteString("\n")
+	}
+	return FormatResult{Stdout: stdout.String()}
+}
+
+// formatQuiet outputs only the worktree paths.
+func (r ListResult) formatQuiet() FormatResult {
+	var stdout strings.Builder
+	for _, wt := range r.Worktrees {
+		stdout.WriteString(wt.Path)
+		stdout.WriteString("\n")
+	}
+	return FormatResult{Stdout: stdout.String()}
+}
+
+// formatDefault outputs git worktree list compatible format.
+func (r ListResult) formatDefault(opts ListFormatOptions) FormatResult {
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+
+	for _, wt := range r.Worktrees {
+		line := wt.Path + "\t" + wt.ShortHEAD() + " " + wt.formatStatus()
+		if r.Sizes != nil {
+			size := "-"
+			if n, ok := r.Sizes[wt.Path]; ok {
+				size = formatBytes(n)
+			}
+			line += "\t" + size
+		}
+		// An empty trailing cell would only add padding
+		if about := r.about(wt); about != "" {
+			line += "\t" + about
+		}
+		fmt.Fprintln(w, line)
+	}
+	w.Flush()
+
+	stdout := buf.String()
+	if r.MainPath != "" || r.CurrentPath != "" {
+		stdout = r.prefixMarkers(stdout, opts.ColorEnabled)
+	}
+
+	if r.Sizes != nil && len(r.Worktrees) > 0 {
+		stdout += fmt.Sprintf("total: %s in %d worktree(s)\n", formatBytes(r.TotalSize()), len(r.Sizes))
+	}
+
+	return FormatResult{Stdout: stdout}
+}
+
+// about returns the provenance, the note and the description summary of
+// wt for the last column of the default output, joined when several are
+// set.
+func (r ListResult) about(wt Worktree) string {
+	var parts []string
+	if p, ok := r.Provenance[wt.Path]; ok {
+		parts = append(parts, p.Summary())
+	}
+	branch := wt.Branch
+	if branch == "" {
+		return strings.Join(parts, " - ")
+	}
+	if note := r.Notes[branch]; note != "" {
+		parts = append(parts, formatNote(note))
+	}
+	if desc := r.Descs[branch]; desc != "" {
+		parts = append(parts, formatDescription(desc))
+	}
+	return strings.Join(parts, " - ")
+}
+
+// prefixMarkers prefixes each worktree line in table with the main and
+// current markers. Markers are added after alignment so that colors do
+// not affect column widths.
+func (r ListResult) prefixMarkers(table string, colorEnabled bool) string {
+	var sb strings.Builder
+	lines := strings.SplitAfter(table, "\n")
+	for i, wt := range r.Worktrees {
+		main, current := " ", " "
+		if wt.Path == r.MainPath {
+			main = format.Paint(colorEnabled, format.ColorMain, listMarkerMain)
+		}
+		if wt.Path == r.CurrentPath {
+			current = format.Paint(colorEnabled, format.ColorCurrent, listMarkerCurrent)
+		}
+		sb.WriteString(main + current + " " + lines[i])
+	}
+	return sb.String()
+}
+
+// formatStatus returns the status portion of the worktree line (branch, locked, prunable).
+func (w Worktree) formatStatus() string {
+	var sb strings.Builder
+
+	switch {
+	case w.Bare:
+		sb.WriteString("(bare)")
+	case w.Detached:
+		sb.WriteString("(detached HEAD)")
+	default:
+		sb.WriteString("[")
+		sb.WriteString(w.Branch)
+		sb.WriteString("]")
+	}
+
+	if w.Locked {
+		sb.WriteString(" locked")
+	}
+	if w.Prunable {
+		sb.WriteString(" prunable")
+	}
+
+	return sb.String()
+}
+
+// Run lists all worktrees.
+func (c *ListCommand) Run(ctx context.Context, opts ListOptions) (ListResult, error) {
+	switch opts.Sort {
+	case ListSortDefault, ListSortPath, ListSortSize:
+	default:
+		return ListResult{}, fmt.Errorf("invalid sort key %q (use %q or %q)", opts.Sort, ListSortPath, ListSortSize)
+	}
+
+	worktrees, err := c.Git.WorktreeList(ctx)
+	if err != nil {
+		return ListResult{}, err
+	}
+
+	// git lists the main worktree first
+	var result ListResult
+	if len(worktrees) > 0 {
+		result.MainPath = worktrees[0].Path
+	}
+	if wt := currentWorktree(worktrees, c.Git.Dir); wt != nil {
+		result.CurrentPath = wt.Path
+	}
+	result.Worktrees, result.Dirty, err = c.filter(ctx, worktrees, opts.Filter)
+	if err != nil {
+		return ListResult{}, err
+	}
+	if opts.CheckDirty && !opts.Filter.Dirty {
+		result.Dirty = c.dirtyWorktrees(ctx, result.Worktrees)
+	}
+	if opts.Size || opts.Sort == ListSortSize {
+		result.Sizes = NewDiskUsage(c.FS, c.Git, c.Log).Calculate(ctx, result.Worktrees, time.Now(), opts.Refresh)
+	}
+	if opts.Notes {
+		result.Notes = noteTexts(ctx, NewNoteStore(c.FS, c.Git), c.Log)
+		if result.Notes == nil {
+			result.Notes = map[string]string{}
+		}
+		result.Descs = branchDescriptions(ctx, c.Git, c.Log)
+		if result.Descs == nil {
+			result.Descs = map[string]string{}
+		}
+		result.Provenance, err = NewProvenanceStore(c.FS, c.Git).Load(ctx)
+		if err != nil {
+			c.Log.DebugContext(ctx, "failed to load provenance", "error", err.Error())
+			result.Provenance = map[string]Provenance{}
+		}
+	}
+
+	switch opts.Sort {
+	case ListSortPath:
+		slices.SortStableFunc(result.Worktrees, func(a, b Worktree) int {
+			return strings.Compare(a.Path, b.Path)
+		})
+	case ListSortSize:
+		// Unknown sizes (missing directories) sort last
+		slices.SortStableFunc(result.Worktrees, func(a, b Worktree) int {
+			sa, okA := result.Sizes[a.Path]
+			sb, okB := result.Sizes[b.Path]
+			if okA != okB {
+				if okA {
+					return -1
+				}
+				return 1
+			}
+			return cmp.Compare(sb, sa)
+		})
+	}
+
+	return result, nil
+}
+
+// dirtyWorktrees returns the paths of worktrees with uncommitted changes.
+// Worktrees that cannot be checked are reported as clean.
+func (c *ListCommand) dirtyWorktrees(ctx context.Context, worktrees []Worktree) map[string]bool {
+	dirty := map[string]bool{}
+	for _, wt := range worktrees {
+		if wt.Bare || wt.Prunable {
+			continue
+		}
+		changed, err := c.Git.InDir(wt.Path).HasChanges(ctx)
+		if err != nil {
+			c.Log.DebugContext(ctx, "failed to check worktree for changes",
+				"path", wt.Path,
+				"error", err)
+			continue
+		}
+		if changed {
+			dirty[wt.Path] = true
+		}
+	}
+	return dirty
+}
+
+// filter returns the worktrees matching f, with the dirty ones when f
+// checks for changes. Cheap checks run first, so that git status only
+// runs for worktrees that match everything else.
+func (c *ListCommand) filter(ctx context.Context, worktrees []Worktree, f ListFilter) ([]Worktree, map[string]bool, error) {
+	if f.IsZero() {
+		return worktrees, nil, nil
+	}
+	if f.BranchGlob != "" {
+		if _, err := path.Match(f.BranchGlob, ""); err != nil {
+			return nil, nil, fmt.Errorf("invalid branch pattern %q: %w", f.BranchGlob, err)
+		}
+	}
+
+	var merged map[string]bool
+	if f.Merged {
+		target := f.MergedInto
+		if target == "" {
+			for _, wt := range worktrees {
+				if !wt.Bare && wt.Branch != "" {
+					target = wt.Branch
+					break
+				}
+			}
+			if target == "" {
+				return nil, nil, fmt.Errorf("no target branch found for --merged")
+			}
+		}
+		status, err := c.Git.ClassifyBranchMergeStatus(ctx, target)
+		if err != nil {
+			return nil, nil, err
+		}
+		merged = status.Merged
+	}
+
+	var matched []Worktree
+	var dirty map[string]bool
+	if f.Dirty {
+		dirty = map[string]bool{}
+	}
+	for _, wt := range worktrees {
+		if f.Locked && !wt.Locked {
+			continue
+		}
+		if f.BranchGlob != "" {
+			if ok, _ := path.Match(f.BranchGlob, wt.Branch); !ok || wt.Branch == "" {
+				continue
+			}
+		}
+		if f.Merged && (wt.Branch == "" || !merged[wt.Branch]) {
+			continue
+		}
+		if f.Dirty {
+			if wt.Bare || wt.Prunable {
+				continue
+			}
+			changed, err := c.Git.InDir(wt.Path).HasChanges(ctx)
+			if err != nil {
+				c.Log.DebugContext(ctx, "failed to check worktree for changes",
+					"path", wt.Path,
+					"error", err)
+				continue
+			}
+			if !changed {
+				continue
+			}
+			dirty[wt.Path] = true
+		}
+		matched = append(matched, wt)
+	}
+	return matched, dirty, nil
+}
@@ -0,0 +1,369 @@
+//go:build integration
+
+package core
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/708u/twig/internal/testutil"
+)
+
+func TestListCommand_Integration(t *testing.T) {
+	t.Parallel()
+
+	t.Run("ListsAllWorktrees", func(t *testing.T) {
+		t.Parallel()
+
+		repoDir, mainDir := testutil.SetupTestRepo(t)
+
+		// Create additional worktrees
+		wtPathA := filepath.Join(repoDir, "feature", "a")
+		wtPathB := filepath.Join(repoDir, "feature", "b")
+		testutil.RunGit(t, mainDir, "worktree", "add", "-b", "feature/a", wtPathA)
+		testutil.RunGit(t, mainDir, "worktree", "add", "-b", "feature/b", wtPathB)
+
+		cmd := NewDefaultListCommand(mainDir, NewNopLogger())
+		result, err := cmd.Run(t.Context(), ListOptions{})
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+
+		// Should have 3 worktrees: main + 2 feature branches
+		if len(result.Worktrees) != 3 {
+			t.Errorf("expected 3 worktrees, got %d", len(result.Worktrees))
+		}
+
+		// Verify main worktree is included
+		var branches []string
+		for _, wt := range result.Worktrees {
+			branches = append(branches, wt.Branch)
+		}
+
+		if !slices.Contains(branches, "main") {
+			t.Error("main worktree should be included")
+		}
+		if !slices.Contains(branches, "feature/a") {
+			t.Error("feature/a worktree should be included")
+		}
+		if !slices.Contains(branches, "feature/b") {
+			t.Error("feature/b worktree should be included")
+		}
+	})
+
+	t.Run("ListsSingleWorktree", func(t *testing.T) {
+		t.Parallel()
+
+		_, mainDir := testutil.SetupTestRepo(t)
+
+		cmd := NewDefaultListCommand(mainDir, NewNopLogger())
+		result, err := cmd.Run(t.Context(), ListOptions{})
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+
+		// Should have only the main worktree
+		if len(result.Worktrees) != 1 {
+			t.Errorf("expected 1 worktree, got %d", len(result.Worktrees))
+		}
+
+		if result.Worktrees[0].Branch != "main" {
+			t.Errorf("expected main branch, got %q", result.Worktrees[0].Branch)
+		}
+
+		if result.Worktrees[0].Path != mainDir {
+			t.Errorf("expected path %q, got %q", mainDir, result.Worktrees[0].Path)
+		}
+	})
+
+	t.Run("FormatGitWorktreeListCompatible", func(t *testing.T) {
+		t.Parallel()
+
+		repoDir, mainDir := testutil.SetupTestRepo(t)
+
+		wtPath := filepath.Join(repoDir, "feature", "test")
+		testutil.RunGit(t, mainDir, "worktree", "add", "-b", "feature/test", wtPath)
+
+		cmd := NewDefaultListCommand(mainDir, NewNopLogger())
+		result, err := cmd.Run(t.Context(), ListOptions{})
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+
+		formatted := result.Format(ListFormatOptions{})
+
+		// Should contain full paths and branch names
+		if formatted.Stdout == "" {
+			t.Error("formatted output should not be empty")
+		}
+
+		// Verify format contains path, hash, and branch
+		lines := strings.Split(strings.TrimSpace(formatted.Stdout), "\n")
+		for _, line := range lines {
+			// Format: /path/to/worktree  abc1234 [branch]
+			if !strings.Contains(line, "[") || !strings.Contains(line, "]") {
+				t.Errorf("line should contain branch in brackets: %s", line)
+			}
+		}
+
+		// Verify paths are absolute
+		for _, wt := range result.Worktrees {
+			if !filepath.IsAbs(wt.Path) {
+				t.Errorf("path should be absolute: %s", wt.Path)
+			}
+		}
+	})
+
+	t.Run("WorktreeHasHEAD", func(t *testing.T) {
+		t.Parallel()
+
+		_, mainDir := testutil.SetupTestRepo(t)
+
+		cmd := NewDefaultListCommand(mainDir, NewNopLogger())
+		result, err := cmd.Run(t.Context(), ListOptions{})
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+
+		for _, wt := range result.Worktrees {
+			if wt.HEAD == "" {
+				t.Error("HEAD should not be empty")
+			}
+			if len(wt.HEAD) != 40 {
+				t.Errorf("HEAD should be 40 characters, got %d: %s", len(wt.HEAD), wt.HEAD)
+			}
+		}
+	})
+
+	t.Run("QuietFormatOutputsPathsOnly", func(t *testing.T) {
+		t.Parallel()
+
+		repoDir, mainDir := testutil.SetupTestRepo(t)
+
+		wtPath := filepath.Join(repoDir, "feature", "quiet-test")
+		testutil.RunGit(t, mainDir, "worktree", "add", "-b", "feature/quiet-test", wtPath)
+
+		cmd := NewDefaultListCommand(mainDir, NewNopLogger())
+		result, err := cmd.Run(t.Context(), ListOptions{})
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+
+		formatted := result.Format(ListFormatOptions{Quiet: true})
+
+		lines := strings.Split(strings.TrimSpace(formatted.Stdout), "\n")
+
+		// Should have 2 worktrees
+		if len(lines) != 2 {
+			t.Errorf("expected 2 lines, got %d: %v", len(lines), lines)
+		}
+
+		// Each line should be just a path (no brackets, no hash)
+		for _, line := range lines {
+			if strings.Contains(line, "[") || strings.Contains(line, "]") {
+				t.Errorf("quiet output should not contain brackets: %s", line)
+			}
+			if !filepath.IsAbs(line) {
+				t.Errorf("quiet output should be absolute path: %s", line)
+			}
+		}
+
+		// Verify paths match worktree paths
+		for _, wt := range result.Worktrees {
+			if !slices.Contains(lines, wt.Path) {
+				t.Errorf("quiet output should contain path %s", wt.Path)
+			}
+		}
+	})
+
+	t.Run("ReportsDiskUsage", func(t *testing.T) {
+		t.Parallel()
+
+		repoDir, mainDir := testutil.SetupTestRepo(t)
+
+		wtPath := filepath.Join(repoDir, "feature", "big")
+		testutil.RunGit(t, mainDir, "worktree", "add", "-b", "feature/big", wtPath)
+		if err := os.WriteFile(filepath.Join(wtPath, "big.bin"), make([]byte, 64*1024), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		cmd := NewDefaultListCommand(mainDir, NewNopLogger())
+		result, err := cmd.Run(t.Context(), ListOptions{Sort: ListSortSize})
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+
+		if len(result.Sizes) != 2 {
+			t.Fatalf("expected sizes for 2 worktrees, got %v", result.Sizes)
+		}
+		if result.Worktrees[0].Path != wtPath {
+			t.Errorf("largest worktree should be first, got %s", result.Worktrees[0].Path)
+		}
+		if result.Sizes[wtPath] < 64*1024 {
+			t.Errorf("size of %s = %d, want at least %d", wtPath, result.Sizes[wtPath], 64*1024)
+		}
+
+		// Sizes are cached in the git common dir
+		cachePath := filepath.Join(mainDir, ".git", "twig", "du-cache.json")
+		if _, err := os.Stat(cachePath); err != nil {
+			t.Errorf("disk usage cache not written: %v", err)
+		}
+
+		// Cached size is reused until refresh
+		if err := os.WriteFile(filepath.Join(wtPath, "more.bin"), make([]byte, 64*1024), 0644); err != nil {
+			t.Fatal(err)
+		}
+		cached, err := cmd.Run(t.Context(), ListOptions{Size: true})
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		if cached.Sizes[wtPath] != result.Sizes[wtPath] {
+			t.Errorf("cached size = %d, want %d", cached.Sizes[wtPath], result.Sizes[wtPath])
+		}
+		refreshed, err := cmd.Run(t.Context(), ListOptions{Size: true, Refresh: true})
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		if refreshed.Sizes[wtPath] < 128*1024 {
+			t.Errorf("refreshed size = %d, want at least %d", refreshed.Sizes[wtPath], 128*1024)
+		}
+	})
+	t.Run("Filter", func(t *testing.T) {
+		t.Parallel()
+
+		// Without settings, so the main worktree has no untracked files
+		repoDir, mainDir := testutil.SetupTestRepo(t, testutil.WithoutSettings())
+
+		// feat/merged has a commit that main merges
+		mergedPath := filepath.Join(repoDir, "feat", "merged")
+		testutil.RunGit(t, mainDir, "worktree", "add", "-b", "feat/merged", mergedPath)
+		testutil.RunGit(t, mergedPath, "commit", "--allow-empty", "-m", "merged work")
+		testutil.RunGit(t, mainDir, "merge", "--no-ff", "-m", "merge feat/merged", "feat/merged")
+
+		dirtyPath := filepath.Join(repoDir, "feat", "dirty")
+		testutil.RunGit(t, mainDir, "worktree", "add", "-b", "feat/dirty", dirtyPath)
+		if err := os.WriteFile(filepath.Join(dirtyPath, "wip.txt"), []byte("wip"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		lockedPath := filepath.Join(repoDir, "fix", "locked")
+		testutil.RunGit(t, mainDir, "worktree", "add", "-b", "fix/locked", lockedPath)
+		testutil.RunGit(t, mainDir, "worktree", "lock", lockedPath)
+
+		cmd := NewDefaultListCommand(mainDir, NewNopLogger())
+
+		tests := []struct {
+			name       string
+			filter     ListFilter
+			wantPaths  []string
+			wantDirty  []string
+			wantErrMsg string
+		}{
+			{
+				name:      "dirty",
+				filter:    ListFilter{Dirty: true},
+				wantPaths: []string{dirtyPath},
+				wantDirty: []string{dirtyPath},
+			},
+			{
+				name:      "locked",
+				filter:    ListFilter{Locked: true},
+				wantPaths: []string{lockedPath},
+			},
+			{
+				name:      "merged into main worktree branch",
+				filter:    ListFilter{Merged: true},
+				wantPaths: []string{mergedPath},
+			},
+			{
+				name:      "merged into named branch",
+				filter:    ListFilter{Merged: true, MergedInto: "fix/locked"},
+				wantPaths: []string{mergedPath},
+			},
+			{
+				name:      "merged into branch without merges",
+				filter:    ListFilter{Merged: true, MergedInto: "feat/merged"},
+				wantPaths: nil,
+			},
+			{
+				name:      "branch glob",
+				filter:    ListFilter{BranchGlob: "feat/*"},
+				wantPaths: []string{dirtyPath, mergedPath},
+			},
+			{
+				name:      "combined",
+				filter:    ListFilter{BranchGlob: "feat/*", Dirty: true},
+				wantPaths: []string{dirtyPath},
+				wantDirty: []string{dirtyPath},
+			},
+			{
+				name:       "invalid glob",
+				filter:     ListFilter{BranchGlob: "feat/["},
+				wantErrMsg: "invalid branch pattern",
+			},
+		}
+
+		for _, tt := range tests {
+			result, err := cmd.Run(t.Context(), ListOptions{Filter: tt.filter})
+			if tt.wantErrMsg != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErrMsg) {
+					t.Errorf("%s: error = %v, want %q", tt.name, err, tt.wantErrMsg)
+				}
+				continue
+			}
+			if err != nil {
+				t.Fatalf("%s: Run failed: %v", tt.name, err)
+			}
+
+			var paths []string
+			for _, wt := range result.Worktrees {
+				paths = append(paths, wt.Path)
+			}
+			if !slices.Equal(paths, tt.wantPaths) {
+				t.Errorf("%s: paths = %v, want %v", tt.name, paths, tt.wantPaths)
+			}
+			for _, p := range tt.wantDirty {
+				if !result.Dirty[p] {
+					t.Errorf("%s: %s not marked dirty", tt.name, p)
+				}
+			}
+			// The main worktree is still reported even when filtered out
+			if result.MainPath != mainDir {
+				t.Errorf("%s: MainPath = %q, want %q", tt.name, result.MainPath, mainDir)
+			}
+		}
+	})
+
+	t.Run("CheckDirty", func(t *testing.T) {
+		t.Parallel()
+
+		repoDir, mainDir := testutil.SetupTestRepo(t, testutil.WithoutSettings())
+
+		cleanPath := filepath.Join(repoDir, "feat", "clean")
+		testutil.RunGit(t, mainDir, "worktree", "add", "-b", "feat/clean", cleanPath)
+		dirtyPath := filepath.Join(repoDir, "feat", "dirty")
+		testutil.RunGit(t, mainDir, "worktree", "add", "-b", "feat/dirty", dirtyPath)
+		if err := os.WriteFile(filepath.Join(dirtyPath, "wip.txt"), []byte("wip"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		cmd := NewDefaultListCommand(mainDir, NewNopLogger())
+		result, err := cmd.Run(t.Context(), ListOptions{CheckDirty: true})
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+
+		// Every worktree is listed, with only the dirty one marked
+		if len(result.Worktrees) != 3 {
+			t.Errorf("got %d worktrees, want 3", len(result.Worktrees))
+		}
+		want := map[string]bool{dirtyPath: true}
+		if !maps.Equal(result.Dirty, want) {
+			t.Errorf("Dirty = %v, want %v", result.Dirty, want)
+		}
+	})
+}
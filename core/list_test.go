@@ -0,0 +1,521 @@
+package core
+
+import (
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/708u/twig/internal/testutil"
+)
+
+func TestListCommand_Run(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		worktrees []testutil.MockWorktree
+		wantCount int
+		wantErr   bool
+	}{
+		{
+			name: "multiple worktrees",
+			worktrees: []testutil.MockWorktree{
+				{Path: "/repo/main", Branch: "main"},
+				{Path: "/repo/worktree/feat-a", Branch: "feat/a"},
+				{Path: "/repo/worktree/feat-b", Branch: "feat/b"},
+			},
+			wantCount: 3,
+		},
+		{
+			name: "single worktree",
+			worktrees: []testutil.MockWorktree{
+				{Path: "/repo/main", Branch: "main"},
+			},
+			wantCount: 1,
+		},
+		{
+			name:      "empty worktrees",
+			worktrees: []testutil.MockWorktree{},
+			wantCount: 0,
+		},
+		{
+			name: "detached HEAD",
+			worktrees: []testutil.MockWorktree{
+				{Path: "/repo/main", Branch: "main"},
+				{Path: "/repo/worktree/detached", Detached: true, HEAD: "abc1234567890"},
+			},
+			wantCount: 2,
+		},
+		{
+			name: "locked worktree",
+			worktrees: []testutil.MockWorktree{
+				{Path: "/repo/main", Branch: "main"},
+				{Path: "/repo/worktree/locked", Branch: "locked-branch", Locked: true, LockReason: "in use"},
+			},
+			wantCount: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mock := &testutil.MockGitExecutor{
+				Worktrees: tt.worktrees,
+			}
+			cmd := &ListCommand{
+				Git: &GitRunner{Executor: mock, Log: NewNopLogger()},
+			}
+
+			result, err := cmd.Run(t.Context(), ListOptions{})
+
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(result.Worktrees) != tt.wantCount {
+				t.Errorf("got %d worktrees, want %d", len(result.Worktrees), tt.wantCount)
+			}
+
+			for i, wt := range result.Worktrees {
+				if wt.Path != tt.worktrees[i].Path {
+					t.Errorf("worktree[%d].Path = %q, want %q", i, wt.Path, tt.worktrees[i].Path)
+				}
+				if wt.Detached != tt.worktrees[i].Detached {
+					t.Errorf("worktree[%d].Detached = %v, want %v", i, wt.Detached, tt.worktrees[i].Detached)
+				}
+				if wt.Locked != tt.worktrees[i].Locked {
+					t.Errorf("worktree[%d].Locked = %v, want %v", i, wt.Locked, tt.worktrees[i].Locked)
+				}
+			}
+		})
+	}
+}
+
+func TestListCommand_Run_SizeAndSort(t *testing.T) {
+	t.Parallel()
+
+	worktrees := []testutil.MockWorktree{
+		{Path: "/repo/main", Branch: "main"},
+		{Path: "/repo/worktree/feat-b", Branch: "feat/b"},
+		{Path: "/repo/worktree/feat-a", Branch: "feat/a"},
+		{Path: "/repo/worktree/gone", Branch: "gone", Prunable: true},
+	}
+	dirContents := map[string][]os.DirEntry{
+		"/repo/main":            {sizedDirEntry{name: "m.txt", size: 10}},
+		"/repo/worktree/feat-b": {sizedDirEntry{name: "b.txt", size: 300}},
+		"/repo/worktree/feat-a": {sizedDirEntry{name: "a.txt", size: 200}},
+	}
+
+	tests := []struct {
+		name      string
+		opts      ListOptions
+		wantPaths []string
+		wantSizes bool
+		wantErr   bool
+	}{
+		{
+			name:      "size keeps git order",
+			opts:      ListOptions{Size: true},
+			wantPaths: []string{"/repo/main", "/repo/worktree/feat-b", "/repo/worktree/feat-a", "/repo/worktree/gone"},
+			wantSizes: true,
+		},
+		{
+			name:      "sort by size implies size, largest first, unknown last",
+			opts:      ListOptions{Sort: ListSortSize},
+			wantPaths: []string{"/repo/worktree/feat-b", "/repo/worktree/feat-a", "/repo/main", "/repo/worktree/gone"},
+			wantSizes: true,
+		},
+		{
+			name:      "sort by path without sizes",
+			opts:      ListOptions{Sort: ListSortPath},
+			wantPaths: []string{"/repo/main", "/repo/worktree/feat-a", "/repo/worktree/feat-b", "/repo/worktree/gone"},
+		},
+		{
+			name:    "invalid sort key",
+			opts:    ListOptions{Sort: "age"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockGit := &testutil.MockGitExecutor{
+				Worktrees:    worktrees,
+				GitCommonDir: "/repo/main/.git",
+			}
+			mockFS := &testutil.MockFS{
+				DirContents:   dirContents,
+				ExistingPaths: []string{"/repo/main", "/repo/worktree/feat-b", "/repo/worktree/feat-a"},
+			}
+			cmd := NewListCommand(mockFS, &GitRunner{Executor: mockGit, Log: NewNopLogger()}, nil)
+
+			result, err := cmd.Run(t.Context(), tt.opts)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var paths []string
+			for _, wt := range result.Worktrees {
+				paths = append(paths, wt.Path)
+			}
+			if !slices.Equal(paths, tt.wantPaths) {
+				t.Errorf("paths = %v, want %v", paths, tt.wantPaths)
+			}
+			if (result.Sizes != nil) != tt.wantSizes {
+				t.Fatalf("Sizes = %v, want calculated = %v", result.Sizes, tt.wantSizes)
+			}
+			if tt.wantSizes && result.TotalSize() != 510 {
+				t.Errorf("TotalSize() = %d, want 510", result.TotalSize())
+			}
+		})
+	}
+}
+
+func TestListCommand_Run_MainAndCurrent(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		dir         string
+		wantCurrent string
+	}{
+		{name: "in main worktree", dir: "/repo/main", wantCurrent: "/repo/main"},
+		{name: "in subdirectory of linked worktree", dir: "/repo/main-worktree/feat/a/src", wantCurrent: "/repo/main-worktree/feat/a"},
+		{name: "outside any worktree", dir: "/tmp", wantCurrent: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mock := &testutil.MockGitExecutor{
+				Worktrees: []testutil.MockWorktree{
+					{Path: "/repo/main", Branch: "main"},
+					{Path: "/repo/main-worktree/feat/a", Branch: "feat/a"},
+				},
+			}
+			cmd := NewListCommand(&testutil.MockFS{}, &GitRunner{Executor: mock, Dir: tt.dir, Log: NewNopLogger()}, nil)
+
+			result, err := cmd.Run(t.Context(), ListOptions{Sort: ListSortPath})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.MainPath != "/repo/main" {
+				t.Errorf("MainPath = %q, want /repo/main", result.MainPath)
+			}
+			if result.CurrentPath != tt.wantCurrent {
+				t.Errorf("CurrentPath = %q, want %q", result.CurrentPath, tt.wantCurrent)
+			}
+		})
+	}
+}
+
+func TestNewListCommand_NilLogger(t *testing.T) {
+	t.Parallel()
+
+	mock := &testutil.MockGitExecutor{
+		Worktrees: []testutil.MockWorktree{
+			{Path: "/repo/main", Branch: "main"},
+		},
+	}
+	git := &GitRunner{Executor: mock, Log: NewNopLogger()}
+
+	// Should not panic when log is nil
+	cmd := NewListCommand(&testutil.MockFS{}, git, nil)
+	if cmd.Log == nil {
+		t.Error("Log should not be nil after NewListCommand")
+	}
+
+	// Should be able to run without panic
+	_, err := cmd.Run(t.Context(), ListOptions{})
+	if err != nil {
+		t.Errorf("Run() error = %v", err)
+	}
+}
+
+func TestListResult_Format(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		worktrees  []Worktree
+		sizes      map[string]int64
+		mainPath   string
+		current    string
+		notes      map[string]string
+		descs      map[string]string
+		provenance map[string]Provenance
+		opts       ListFormatOptions
+		wantStdout string
+	}{
+		{
+			name: "git worktree list compatible format",
+			worktrees: []Worktree{
+				{Path: "/repo/main", Branch: "main", HEAD: "abc1234567890"},
+				{Path: "/repo/worktree/feat-a", Branch: "feat/a", HEAD: "def5678901234"},
+			},
+			wantStdout: "/repo/main             abc1234 [main]\n/repo/worktree/feat-a  def5678 [feat/a]\n",
+		},
+		{
+			name: "detached HEAD",
+			worktrees: []Worktree{
+				{Path: "/repo/worktree/detached", HEAD: "abc1234567890", Detached: true},
+			},
+			wantStdout: "/repo/worktree/detached  abc1234 (detached HEAD)\n",
+		},
+		{
+			name: "locked worktree",
+			worktrees: []Worktree{
+				{Path: "/repo/worktree/locked", Branch: "locked-branch", HEAD: "abc1234567890", Locked: true},
+			},
+			wantStdout: "/repo/worktree/locked  abc1234 [locked-branch] locked\n",
+		},
+		{
+			name: "prunable worktree",
+			worktrees: []Worktree{
+				{Path: "/repo/worktree/prunable", HEAD: "abc1234567890", Detached: true, Prunable: true},
+			},
+			wantStdout: "/repo/worktree/prunable  abc1234 (detached HEAD) prunable\n",
+		},
+		{
+			name: "bare repository",
+			worktrees: []Worktree{
+				{Path: "/repo/bare", HEAD: "abc1234567890", Bare: true},
+			},
+			wantStdout: "/repo/bare  abc1234 (bare)\n",
+		},
+		{
+			name:       "empty list",
+			worktrees:  []Worktree{},
+			wantStdout: "",
+		},
+		{
+			name: "quiet format outputs paths only",
+			worktrees: []Worktree{
+				{Path: "/repo/main", Branch: "main", HEAD: "abc1234567890"},
+				{Path: "/repo/worktree/feat-a", Branch: "feat/a", HEAD: "def5678901234"},
+			},
+			opts:       ListFormatOptions{Quiet: true},
+			wantStdout: "/repo/main\n/repo/worktree/feat-a\n",
+		},
+		{
+			name: "with sizes and total",
+			worktrees: []Worktree{
+				{Path: "/repo/main", Branch: "main", HEAD: "abc1234567890"},
+				{Path: "/repo/worktree/feat-a", Branch: "feat/a", HEAD: "def5678901234"},
+				{Path: "/repo/worktree/gone", Branch: "gone", HEAD: "0123456789abc", Prunable: true},
+			},
+			sizes: map[string]int64{
+				"/repo/main":            2048,
+				"/repo/worktree/feat-a": 512,
+			},
+			wantStdout: "/repo/main             abc1234 [main]           2.0 KiB\n" +
+				"/repo/worktree/feat-a  def5678 [feat/a]         512 B\n" +
+				"/repo/worktree/gone    0123456 [gone] prunable  -\n" +
+				"total: 2.5 KiB in 2 worktree(s)\n",
+		},
+		{
+			name: "quiet format ignores sizes",
+			worktrees: []Worktree{
+				{Path: "/repo/main", Branch: "main", HEAD: "abc1234567890"},
+			},
+			sizes:      map[string]int64{"/repo/main": 2048},
+			opts:       ListFormatOptions{Quiet: true},
+			wantStdout: "/repo/main\n",
+		},
+		{
+			name: "main and current markers",
+			worktrees: []Worktree{
+				{Path: "/repo/main", Branch: "main", HEAD: "abc1234567890"},
+				{Path: "/repo/worktree/feat-a", Branch: "feat/a", HEAD: "def5678901234"},
+				{Path: "/repo/worktree/feat-b", Branch: "feat/b", HEAD: "0123456789abc"},
+			},
+			mainPath: "/repo/main",
+			current:  "/repo/worktree/feat-a",
+			wantStdout: "*  /repo/main             abc1234 [main]\n" +
+				" @ /repo/worktree/feat-a  def5678 [feat/a]\n" +
+				"   /repo/worktree/feat-b  0123456 [feat/b]\n",
+		},
+		{
+			name: "markers with sizes",
+			worktrees: []Worktree{
+				{Path: "/repo/main", Branch: "main", HEAD: "abc1234567890"},
+			},
+			sizes:    map[string]int64{"/repo/main": 2048},
+			mainPath: "/repo/main",
+			current:  "/repo/main",
+			wantStdout: "*@ /repo/main  abc1234 [main]  2.0 KiB\n" +
+				"total: 2.0 KiB in 1 worktree(s)\n",
+		},
+		{
+			name: "quiet format omits markers",
+			worktrees: []Worktree{
+				{Path: "/repo/main", Branch: "main", HEAD: "abc1234567890"},
+			},
+			mainPath:   "/repo/main",
+			current:    "/repo/main",
+			opts:       ListFormatOptions{Quiet: true},
+			wantStdout: "/repo/main\n",
+		},
+		{
+			name: "porcelain format",
+			worktrees: []Worktree{
+				{Path: "/repo/main", Branch: "main", HEAD: "abc1234567890"},
+				{Path: "/repo/worktree/feat-a", Branch: "feat/a", HEAD: "def5678901234", Locked: true, LockReason: "in use"},
+				{Path: "/repo/worktree/detached", HEAD: "0123456789abc", Detached: true, Prunable: true},
+			},
+			sizes:    map[string]int64{"/repo/main": 2048},
+			mainPath: "/repo/main",
+			current:  "/repo/worktree/feat-a",
+			opts:     ListFormatOptions{Porcelain: true},
+			wantStdout: "worktree /repo/main\nHEAD abc1234567890\nbranch refs/heads/main\nmain\nsize 2048\n\n" +
+				"worktree /repo/worktree/feat-a\nHEAD def5678901234\nbranch refs/heads/feat/a\nlocked in use\ncurrent\n\n" +
+				"worktree /repo/worktree/detached\nHEAD 0123456789abc\ndetached\nprunable\n\n",
+		},
+		{
+			name: "notes column",
+			worktrees: []Worktree{
+				{Path: "/repo/main", Branch: "main", HEAD: "abc1234567890"},
+				{Path: "/repo/worktree/feat-a", Branch: "feat/a", HEAD: "def5678901234"},
+			},
+			notes: map[string]string{"feat/a": "waiting on\nreview"},
+			wantStdout: "/repo/main             abc1234 [main]\n" +
+				"/repo/worktree/feat-a  def5678 [feat/a]  waiting on review\n",
+		},
+		{
+			name: "porcelain format with note",
+			worktrees: []Worktree{
+				{Path: "/repo/worktree/feat-a", Branch: "feat/a", HEAD: "def5678901234"},
+			},
+			notes:      map[string]string{"feat/a": "waiting on review"},
+			opts:       ListFormatOptions{Porcelain: true},
+			wantStdout: "worktree /repo/worktree/feat-a\nHEAD def5678901234\nbranch refs/heads/feat/a\nnote waiting on review\n\n",
+		},
+		{
+			name: "note and description column",
+			worktrees: []Worktree{
+				{Path: "/repo/worktree/feat-a", Branch: "feat/a", HEAD: "def5678901234"},
+				{Path: "/repo/worktree/feat-b", Branch: "feat/b", HEAD: "0123456789abc"},
+			},
+			notes: map[string]string{"feat/a": "waiting on review"},
+			descs: map[string]string{"feat/a": "Fix login\n\nDetails", "feat/b": "Refactor auth"},
+			wantStdout: "/repo/worktree/feat-a  def5678 [feat/a]  waiting on review - Fix login\n" +
+				"/repo/worktree/feat-b  0123456 [feat/b]  Refactor auth\n",
+		},
+		{
+			name: "porcelain format with description",
+			worktrees: []Worktree{
+				{Path: "/repo/worktree/feat-a", Branch: "feat/a", HEAD: "def5678901234"},
+			},
+			descs:      map[string]string{"feat/a": "Fix login\n\nDetails"},
+			opts:       ListFormatOptions{Porcelain: true},
+			wantStdout: "worktree /repo/worktree/feat-a\nHEAD def5678901234\nbranch refs/heads/feat/a\ndescription Fix login Details\n\n",
+		},
+		{
+			name: "provenance column",
+			worktrees: []Worktree{
+				{Path: "/repo/worktree/feat-a", Branch: "feat/a", HEAD: "def5678901234"},
+				{Path: "/repo/worktree/feat-b", Branch: "feat/b", HEAD: "0123456789abc"},
+				{Path: "/repo/worktree/review", Detached: true, HEAD: "456789abcdef0"},
+			},
+			notes: map[string]string{"feat/a": "waiting on review"},
+			provenance: map[string]Provenance{
+				"/repo/worktree/feat-a": {Command: ProvenanceCommandAdd, Source: "main"},
+				"/repo/worktree/review": {Command: ProvenanceCommandAdopt},
+			},
+			wantStdout: "/repo/worktree/feat-a  def5678 [feat/a]  twig from main - waiting on review\n" +
+				"/repo/worktree/feat-b  0123456 [feat/b]\n" +
+				"/repo/worktree/review  456789a (detached HEAD)  twig (adopted)\n",
+		},
+		{
+			name: "porcelain format with provenance",
+			worktrees: []Worktree{
+				{Path: "/repo/worktree/feat-a", Branch: "feat/a", HEAD: "def5678901234"},
+			},
+			provenance: map[string]Provenance{
+				"/repo/worktree/feat-a": {
+					CreatedAt: time.Date(2026, 1, 17, 12, 34, 56, 0, time.UTC),
+					Command:   ProvenanceCommandAdd,
+					Source:    "main",
+					Version:   "v1.2.3",
+				},
+			},
+			opts: ListFormatOptions{Porcelain: true},
+			wantStdout: "worktree /repo/worktree/feat-a\nHEAD def5678901234\nbranch refs/heads/feat/a\n" +
+				"managed add\ncreated 2026-01-17T12:34:56Z\nsource main\ntwig-version v1.2.3\n\n",
+		},
+		{
+			name:       "quiet format with empty list",
+			worktrees:  []Worktree{},
+			opts:       ListFormatOptions{Quiet: true},
+			wantStdout: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			result := ListResult{Worktrees: tt.worktrees, Sizes: tt.sizes, MainPath: tt.mainPath, CurrentPath: tt.current, Notes: tt.notes, Descs: tt.descs, Provenance: tt.provenance}
+			formatted := result.Format(tt.opts)
+
+			if formatted.Stdout != tt.wantStdout {
+				t.Errorf("Stdout = %q, want %q", formatted.Stdout, tt.wantStdout)
+			}
+		})
+	}
+}
+
+func TestWorktree_ShortHEAD(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		head string
+		want string
+	}{
+		{
+			name: "40 character hash",
+			head: "abc1234567890abcdef1234567890abcdef1234",
+			want: "abc1234",
+		},
+		{
+			name: "short hash",
+			head: "abc12",
+			want: "abc12",
+		},
+		{
+			name: "empty hash",
+			head: "",
+			want: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			wt := Worktree{HEAD: tt.head}
+			if got := wt.ShortHEAD(); got != tt.want {
+				t.Errorf("ShortHEAD() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/708u/twig/format"
+)
+
+// Branch drawing for list --tree.
+const (
+	listTreeBranch     = "├── "
+	listTreeLastBranch = "└── "
+	listTreeIndent     = "│   "
+	listTreeLastIndent = "    "
+)
+
+// listTreeNode is a directory or a worktree in the list --tree view.
+type listTreeNode struct {
+	name     string
+	wt       *Worktree // nil for directories
+	children []*listTreeNode
+}
+
+// child returns the child of n called name, adding it if needed.
+func (n *listTreeNode) child(name string) *listTreeNode {
+	for _, c := range n.children {
+		if c.name == name {
+			return c
+		}
+	}
+	c := &listTreeNode{name: name}
+	n.children = append(n.children, c)
+	return c
+}
+
+// listTreeCounts summarizes the worktrees under a directory.
+type listTreeCounts struct {
+	worktrees int
+	dirty     int
+	locked    int
+}
+
+func (c listTreeCounts) String() string {
+	parts := []string{fmt.Sprintf("%d worktree(s)", c.worktrees)}
+	if c.dirty > 0 {
+		parts = append(parts, fmt.Sprintf("%d dirty", c.dirty))
+	}
+	if c.locked > 0 {
+		parts = append(parts, fmt.Sprintf("%d locked", c.locked))
+	}
+	return "(" + strings.Join(parts, ", ") + ")"
+}
+
+// listTreeRow is one line of the tree view: a worktree, or a directory
+// with the counts of the worktrees under it.
+type listTreeRow struct {
+	label  string
+	wt     *Worktree
+	counts listTreeCounts
+}
+
+// formatTree outputs the worktrees under opts.TreeRoot as a tree of the
+// directories they are in, which mirror the branch namespace (feat/a is
+// created at <root>/feat/a). Worktrees outside the root, usually the
+// main worktree, are listed first with their full path.
+func (r ListResult) formatTree(opts ListFormatOptions) FormatResult {
+	root := &listTreeNode{}
+	var rows []listTreeRow
+	for i := range r.Worktrees {
+		wt := &r.Worktrees[i]
+		rel, ok := listTreeRelPath(opts.TreeRoot, wt.Path)
+		if !ok {
+			rows = append(rows, listTreeRow{label: wt.Path, wt: wt})
+			continue
+		}
+		n := root
+		for part := range strings.SplitSeq(rel, "/") {
+			n = n.child(part)
+		}
+		n.wt = wt
+	}
+	if len(root.children) > 0 {
+		rows = append(rows, listTreeRow{label: opts.TreeRoot + "/", counts: r.treeCounts(root)})
+		rows = r.appendTreeRows(rows, root.children, "")
+	}
+
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	for _, row := range rows {
+		if row.wt == nil {
+			fmt.Fprintln(w, row.label+"\t"+row.counts.String())
+			continue
+		}
+		line := row.label + "\t" + row.wt.ShortHEAD() + " " + row.wt.formatStatus()
+		if r.Dirty[row.wt.Path] {
+			line += " dirty"
+		}
+		if r.Sizes != nil {
+			size := "-"
+			if n, ok := r.Sizes[row.wt.Path]; ok {
+				size = formatBytes(n)
+			}
+			line += "\t" + size
+		}
+		if about := r.about(*row.wt); about != "" {
+			line += "\t" + about
+		}
+		fmt.Fprintln(w, line)
+	}
+	w.Flush()
+
+	// Markers and colors are added after alignment so that colors do not
+	// affect column widths
+	var stdout strings.Builder
+	lines := strings.SplitAfter(buf.String(), "\n")
+	for i, row := range rows {
+		line := strings.TrimSuffix(lines[i], "\n")
+		main, current := " ", " "
+		if row.wt != nil {
+			if row.wt.Path == r.MainPath {
+				main = format.Paint(opts.ColorEnabled, format.ColorMain, listMarkerMain)
+			}
+			if row.wt.Path == r.CurrentPath {
+				current = format.Paint(opts.ColorEnabled, format.ColorCurrent, listMarkerCurrent)
+			}
+			switch {
+			case row.wt.Locked:
+				line = format.Paint(opts.ColorEnabled, format.ColorLocked, line)
+			case r.Dirty[row.wt.Path]:
+				line = format.Paint(opts.ColorEnabled, format.ColorDirty, line)
+			}
+		}
+		stdout.WriteString(main + current + " " + line + "\n")
+	}
+
+	if r.Sizes != nil && len(r.Worktrees) > 0 {
+		fmt.Fprintf(&stdout, "total: %s in %d worktree(s)\n", formatBytes(r.TotalSize()), len(r.Sizes))
+	}
+
+	return FormatResult{Stdout: stdout.String()}
+}
+
+// appendTreeRows appends the rows for nodes and everything below them,
+// sorted by name, with indent drawn before their branches.
+func (r ListResult) appendTreeRows(rows []listTreeRow, nodes []*listTreeNode, indent string) []listTreeRow {
+	slices.SortFunc(nodes, func(a, b *listTreeNode) int {
+		return strings.Compare(a.name, b.name)
+	})
+	for i, n := range nodes {
+		branch, next := listTreeBranch, listTreeIndent
+		if i == len(nodes)-1 {
+			branch, next = listTreeLastBranch, listTreeLastIndent
+		}
+		row := listTreeRow{label: indent + branch + n.name, wt: n.wt}
+		if n.wt == nil {
+			row.label += "/"
+			row.counts = r.treeCounts(n)
+		}
+		rows = append(rows, row)
+		rows = r.appendTreeRows(rows, n.children, indent+next)
+	}
+	return rows
+}
+
+// treeCounts counts the worktrees at and below n.
+func (r ListResult) treeCounts(n *listTreeNode) listTreeCounts {
+	var c listTreeCounts
+	if n.wt != nil {
+		c.worktrees++
+		if r.Dirty[n.wt.Path] {
+			c.dirty++
+		}
+		if n.wt.Locked {
+			c.locked++
+		}
+	}
+	for _, child := range n.children {
+		cc := r.treeCounts(child)
+		c.worktrees += cc.worktrees
+		c.dirty += cc.dirty
+		c.locked += cc.locked
+	}
+	return c
+}
+
+// listTreeRelPath returns path relative to root with forward slashes,
+// and whether path is below root.
+func listTreeRelPath(root, path string) (string, bool) {
+	if root == "" {
+		return "", false
+	}
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
@@ -0,0 +1,105 @@
+package core
+
+import (
+	"testing"
+)
+
+func TestListResult_FormatTree(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		result     ListResult
+		opts       ListFormatOptions
+		wantStdout string
+	}{
+		{
+			name: "groups by directory with counts",
+			result: ListResult{
+				Worktrees: []Worktree{
+					{Path: "/repo/main", Branch: "main", HEAD: "abc1234567890"},
+					{Path: "/repo/wt/feat/b", Branch: "feat/b", HEAD: "def5678901234", Locked: true},
+					{Path: "/repo/wt/feat/a", Branch: "feat/a", HEAD: "def5678901234"},
+					{Path: "/repo/wt/feat/ui/form", Branch: "feat/ui/form", HEAD: "def5678901234"},
+					{Path: "/repo/wt/hotfix", Branch: "hotfix", HEAD: "abc1234567890"},
+				},
+				MainPath:    "/repo/main",
+				CurrentPath: "/repo/wt/feat/a",
+				Dirty:       map[string]bool{"/repo/wt/feat/a": true},
+			},
+			opts: ListFormatOptions{Tree: true, TreeRoot: "/repo/wt"},
+			wantStdout: "*  /repo/main        abc1234 [main]\n" +
+				"   /repo/wt/         (4 worktree(s), 1 dirty, 1 locked)\n" +
+				"   ├── feat/         (3 worktree(s), 1 dirty, 1 locked)\n" +
+				" @ │   ├── a         def5678 [feat/a] dirty\n" +
+				"   │   ├── b         def5678 [feat/b] locked\n" +
+				"   │   └── ui/       (1 worktree(s))\n" +
+				"   │       └── form  def5678 [feat/ui/form]\n" +
+				"   └── hotfix        abc1234 [hotfix]\n",
+		},
+		{
+			name: "sizes",
+			result: ListResult{
+				Worktrees: []Worktree{
+					{Path: "/repo/wt/feat/a", Branch: "feat/a", HEAD: "def5678901234"},
+				},
+				Sizes: map[string]int64{"/repo/wt/feat/a": 2048},
+			},
+			opts: ListFormatOptions{Tree: true, TreeRoot: "/repo/wt"},
+			wantStdout: "   /repo/wt/  (1 worktree(s))\n" +
+				"   └── feat/  (1 worktree(s))\n" +
+				"       └── a  def5678 [feat/a]  2.0 KiB\n" +
+				"total: 2.0 KiB in 1 worktree(s)\n",
+		},
+		{
+			name: "no root lists every worktree at the top",
+			result: ListResult{
+				Worktrees: []Worktree{
+					{Path: "/repo/main", Branch: "main", HEAD: "abc1234567890"},
+					{Path: "/repo/wt/feat/a", Branch: "feat/a", HEAD: "def5678901234"},
+				},
+			},
+			opts: ListFormatOptions{Tree: true},
+			wantStdout: "   /repo/main       abc1234 [main]\n" +
+				"   /repo/wt/feat/a  def5678 [feat/a]\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := tt.result.Format(tt.opts)
+			if got.Stdout != tt.wantStdout {
+				t.Errorf("Stdout =\n%s\nwant\n%s", got.Stdout, tt.wantStdout)
+			}
+		})
+	}
+}
+
+func TestListTreeRelPath(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		root, path string
+		want       string
+		wantOK     bool
+	}{
+		{root: "/repo/wt", path: "/repo/wt/feat/a", want: "feat/a", wantOK: true},
+		{root: "/repo/wt", path: "/repo/wt", wantOK: false},
+		{root: "/repo/wt", path: "/repo/main", wantOK: false},
+		{root: "/repo/wt", path: "/repo/wt-other/a", wantOK: false},
+		{root: "", path: "/repo/wt/a", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			t.Parallel()
+
+			got, ok := listTreeRelPath(tt.root, tt.path)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("listTreeRelPath(%q, %q) = %q, %v, want %q, %v", tt.root, tt.path, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
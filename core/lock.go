@@ -0,0 +1,220 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"syscall"
+	"time"
+)
+
+const (
+	// operationLockFileName is stored directly under the git common dir.
+	operationLockFileName = "twig.lock"
+
+	// DefaultLockTimeout is how long a mutating command waits for
+	// another twig command holding the operation lock.
+	DefaultLockTimeout = 30 * time.Second
+
+	// lockPollInterval is how often a waiting command retries the lock.
+	lockPollInterval = 100 * time.Millisecond
+)
+
+// lockOwner is written to the lock file to identify the holder.
+type lockOwner struct {
+	PID       int       `json:"pid"`
+	Host      string    `json:"host"`
+	Command   string    `json:"command"`
+	StartedAt time.Time `json:"started_at"`
+}
+
+// LockBusyError is returned when the operation lock is still held by
+// another twig command after the timeout.
+type LockBusyError struct {
+	Path    string
+	Command string        // Command holding the lock (empty if unknown)
+	PID     int           // Process holding the lock (0 if unknown)
+	Waited  time.Duration // How long the lock was waited for
+}
+
+func (e *LockBusyError) Error() string {
+	holder := "another twig command"
+	if e.Command != "" {
+		holder = fmt.Sprintf("twig %s (pid %d)", e.Command, e.PID)
+	}
+	return fmt.Sprintf("%s is running in this repository; gave up after %s "+
+		"(use --lock-timeout to wait longer, or remove %s if no twig command is running)",
+		holder, e.Waited.Round(time.Millisecond), e.Path)
+}
+
+// OperationLock is an advisory lock that serializes mutating twig
+// commands (add, remove, clean, sync) within a repository. It is a file
+// created exclusively in the git common dir, shared by all worktrees.
+type OperationLock struct {
+	Path string
+}
+
+// OperationLockOptions configures lock acquisition.
+type OperationLockOptions struct {
+	Command string        // Command name recorded for other waiters
+	Timeout time.Duration // How long to wait for a busy lock (0 = fail immediately)
+	Log     *slog.Logger
+}
+
+// AcquireOperationLock takes the operation lock of the repository whose
+// git common dir is commonDir, waiting up to opts.Timeout while another
+// command holds it. A lock left by a process that no longer runs on this
+// host is treated as stale and taken over.
+func AcquireOperationLock(ctx context.Context, commonDir string, opts OperationLockOptions) (*OperationLock, error) {
+	log := opts.Log
+	if log == nil {
+		log = NewNopLogger()
+	}
+
+	path := filepath.Join(commonDir, operationLockFileName)
+	host, _ := os.Hostname()
+	data, err := json.Marshal(lockOwner{
+		PID:       os.Getpid(),
+		Host:      host,
+		Command:   opts.Command,
+		StartedAt: time.Now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	for {
+		err := createLockFile(path, data)
+		if err == nil {
+			log.DebugContext(ctx, "acquired operation lock",
+				LogAttrKeyCategory.String(), LogCategoryLock,
+				"path", path,
+				"waited", time.Since(start))
+			return &OperationLock{Path: path}, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("failed to create lock file: %w", err)
+		}
+
+		owner, readErr := readLockOwner(path)
+		if readErr == nil && owner.Host == host && !processAlive(owner.PID) {
+			log.DebugContext(ctx, "removing stale operation lock",
+				LogAttrKeyCategory.String(), LogCategoryLock,
+				"path", path,
+				"pid", owner.PID,
+				"command", owner.Command)
+			if err := removeStaleLock(path, owner); err != nil {
+				return nil, fmt.Errorf("failed to remove stale lock file: %w", err)
+			}
+			continue
+		}
+
+		waited := time.Since(start)
+		if waited >= opts.Timeout {
+			return nil, &LockBusyError{Path: path, Command: owner.Command, PID: owner.PID, Waited: waited}
+		}
+
+		log.DebugContext(ctx, "waiting for operation lock",
+			LogAttrKeyCategory.String(), LogCategoryLock,
+			"path", path,
+			"holder", owner.Command,
+			"pid", owner.PID)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(min(lockPollInterval, opts.Timeout-waited)):
+		}
+	}
+}
+
+// Release removes the lock file.
+func (l *OperationLock) Release() error {
+	if err := os.Remove(l.Path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to release lock: %w", err)
+	}
+	return nil
+}
+
+// createLockFile creates path with data, failing with os.ErrExist if it exists.
+func createLockFile(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+	}
+	return err
+}
+
+// removeStaleLock removes the lock file at path left by stale. Several
+// commands may find the same stale lock, and one of them may already have
+// removed it and taken the lock when another gets to remove it, so the
+// file is first renamed into a private directory: only the command that
+// renamed it owns it, and it is put back if it is not stale's after all.
+func removeStaleLock(path string, stale lockOwner) error {
+	dir, err := os.MkdirTemp(filepath.Dir(path), operationLockFileName+".stale-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	aside := filepath.Join(dir, operationLockFileName)
+	if err := os.Rename(path, aside); err != nil {
+		if os.IsNotExist(err) {
+			// Removed by another command
+			return nil
+		}
+		return err
+	}
+	// A lock still being written does not read as stale either
+	owner, err := readLockOwner(aside)
+	if err != nil || owner.PID != stale.PID || !owner.StartedAt.Equal(stale.StartedAt) {
+		// Taken over by another command in the meantime. Link fails when
+		// yet another command created the lock after the rename; it then
+		// holds the lock and the one renamed away is lost.
+		if err := os.Link(aside, path); err != nil && !os.IsExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// readLockOwner reads the holder recorded in the lock file.
+func readLockOwner(path string) (lockOwner, error) {
+	var owner lockOwner
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return owner, err
+	}
+	err = json.Unmarshal(data, &owner)
+	return owner, err
+}
+
+// processAlive reports whether a process with pid exists.
+// Processes owned by other users count as alive.
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	// On Windows FindProcess fails for missing processes
+	if runtime.GOOS == "windows" {
+		return true
+	}
+	err = p.Signal(syscall.Signal(0))
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
@@ -0,0 +1,218 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestAcquireOperationLock(t *testing.T) {
+	t.Parallel()
+
+	t.Run("acquire and release", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		lock, err := AcquireOperationLock(t.Context(), dir, OperationLockOptions{Command: "add"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		owner, err := readLockOwner(filepath.Join(dir, operationLockFileName))
+		if err != nil {
+			t.Fatalf("lock file not readable: %v", err)
+		}
+		if owner.PID != os.Getpid() || owner.Command != "add" {
+			t.Errorf("owner = %+v, want pid %d and command add", owner, os.Getpid())
+		}
+
+		if err := lock.Release(); err != nil {
+			t.Fatalf("Release failed: %v", err)
+		}
+		if _, err := os.Stat(lock.Path); !os.IsNotExist(err) {
+			t.Errorf("lock file should be removed, stat err = %v", err)
+		}
+	})
+
+	t.Run("busy lock fails after timeout", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		held, err := AcquireOperationLock(t.Context(), dir, OperationLockOptions{Command: "clean"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer held.Release()
+
+		_, err = AcquireOperationLock(t.Context(), dir, OperationLockOptions{Command: "add", Timeout: 50 * time.Millisecond})
+		var busy *LockBusyError
+		if !errors.As(err, &busy) {
+			t.Fatalf("error = %v, want LockBusyError", err)
+		}
+		if busy.Command != "clean" || busy.PID != os.Getpid() {
+			t.Errorf("busy = %+v, want holder clean (pid %d)", busy, os.Getpid())
+		}
+		if busy.Waited < 50*time.Millisecond {
+			t.Errorf("Waited = %s, want at least 50ms", busy.Waited)
+		}
+	})
+
+	t.Run("waits for release", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		held, err := AcquireOperationLock(t.Context(), dir, OperationLockOptions{Command: "clean"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		time.AfterFunc(150*time.Millisecond, func() { held.Release() })
+
+		lock, err := AcquireOperationLock(t.Context(), dir, OperationLockOptions{Command: "add", Timeout: 5 * time.Second})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		lock.Release()
+	})
+
+	t.Run("stale lock from exited process is taken over", func(t *testing.T) {
+		t.Parallel()
+
+		exited := exec.Command("true")
+		if err := exited.Run(); err != nil {
+			t.Skipf("cannot run helper process: %v", err)
+		}
+
+		dir := t.TempDir()
+		host, _ := os.Hostname()
+		writeLockOwner(t, dir, lockOwner{PID: exited.Process.Pid, Host: host, Command: "remove"})
+
+		lock, err := AcquireOperationLock(t.Context(), dir, OperationLockOptions{Command: "add"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		lock.Release()
+	})
+
+	t.Run("stale lock is taken over by one command at a time", func(t *testing.T) {
+		t.Parallel()
+
+		exited := exec.Command("true")
+		if err := exited.Run(); err != nil {
+			t.Skipf("cannot run helper process: %v", err)
+		}
+
+		dir := t.TempDir()
+		host, _ := os.Hostname()
+		writeLockOwner(t, dir, lockOwner{PID: exited.Process.Pid, Host: host, Command: "remove"})
+
+		var holders, overlaps atomic.Int32
+		var wg sync.WaitGroup
+		for range 8 {
+			wg.Go(func() {
+				lock, err := AcquireOperationLock(t.Context(), dir, OperationLockOptions{Command: "add", Timeout: 10 * time.Second})
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+					return
+				}
+				if holders.Add(1) > 1 {
+					overlaps.Add(1)
+				}
+				time.Sleep(5 * time.Millisecond)
+				holders.Add(-1)
+				lock.Release()
+			})
+		}
+		wg.Wait()
+
+		if n := overlaps.Load(); n > 0 {
+			t.Errorf("lock was held by more than one command %d time(s)", n)
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 0 {
+			t.Errorf("left behind %v, want an empty directory", entries)
+		}
+	})
+
+	t.Run("lock taken over in the meantime is put back", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		held, err := AcquireOperationLock(t.Context(), dir, OperationLockOptions{Command: "clean"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer held.Release()
+
+		stale := lockOwner{PID: os.Getpid(), Command: "remove", StartedAt: time.Now().Add(-time.Hour)}
+		if err := removeStaleLock(held.Path, stale); err != nil {
+			t.Fatalf("removeStaleLock() error = %v", err)
+		}
+		owner, err := readLockOwner(held.Path)
+		if err != nil || owner.Command != "clean" {
+			t.Errorf("lock owner = %+v (err %v), want the clean lock kept", owner, err)
+		}
+	})
+
+	t.Run("lock from another host is not stale", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		writeLockOwner(t, dir, lockOwner{PID: 1 << 30, Host: "other-host", Command: "remove"})
+
+		_, err := AcquireOperationLock(t.Context(), dir, OperationLockOptions{Command: "add"})
+		var busy *LockBusyError
+		if !errors.As(err, &busy) {
+			t.Fatalf("error = %v, want LockBusyError", err)
+		}
+	})
+
+	t.Run("canceled context stops waiting", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		held, err := AcquireOperationLock(t.Context(), dir, OperationLockOptions{Command: "clean"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer held.Release()
+
+		ctx, cancel := context.WithTimeout(t.Context(), 50*time.Millisecond)
+		defer cancel()
+		_, err = AcquireOperationLock(ctx, dir, OperationLockOptions{Command: "add", Timeout: time.Minute})
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("error = %v, want context.DeadlineExceeded", err)
+		}
+	})
+}
+
+func TestLockBusyError_Error(t *testing.T) {
+	t.Parallel()
+
+	err := &LockBusyError{Path: "/repo/.git/twig.lock", Command: "clean", PID: 42, Waited: 30 * time.Second}
+	want := "twig clean (pid 42) is running in this repository; gave up after 30s " +
+		"(use --lock-timeout to wait longer, or remove /repo/.git/twig.lock if no twig command is running)"
+	if err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+}
+
+func writeLockOwner(t *testing.T, dir string, owner lockOwner) {
+	t.Helper()
+	data, err := json.Marshal(owner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, operationLockFileName), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -1,9 +1,10 @@
-package twig
+package core
 
 import (
 	"context"
 	"crypto/rand"
 	"encoding/hex"
+	"fmt"
 	"io"
 	"log/slog"
 	"slices"
@@ -113,6 +114,38 @@ func (h *CLIHandler) WithGroup(_ string) slog.Handler {
 	return h
 }
 
+// LogFormat selects how debug logs are encoded.
+type LogFormat string
+
+const (
+	// LogFormatText writes human-readable lines (CLIHandler).
+	LogFormatText LogFormat = "text"
+	// LogFormatJSON writes one JSON object per record, including all
+	// attributes, for log collectors (e.g. when twig runs in CI).
+	LogFormatJSON LogFormat = "json"
+)
+
+// ParseLogFormat parses a --log-format value. Empty means text.
+func ParseLogFormat(s string) (LogFormat, error) {
+	switch f := LogFormat(s); f {
+	case "":
+		return LogFormatText, nil
+	case LogFormatText, LogFormatJSON:
+		return f, nil
+	default:
+		return "", fmt.Errorf("unknown log format %q (use %q or %q)", s, LogFormatText, LogFormatJSON)
+	}
+}
+
+// NewLogHandler returns the handler for format that writes records at
+// or above level to w.
+func NewLogHandler(w io.Writer, level slog.Level, format LogFormat) slog.Handler {
+	if format == LogFormatJSON {
+		return slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level})
+	}
+	return NewCLIHandler(w, level)
+}
+
 // NewNopLogger creates a logger that discards all output.
 // Used as the default logger when no logging is needed.
 func NewNopLogger() *slog.Logger {
@@ -157,14 +190,32 @@ const (
 
 // Log category values for consistent output prefixes.
 const (
-	LogCategoryDebug   = "debug"
-	LogCategoryGit     = "git"
-	LogCategoryConfig  = "config"
-	LogCategoryGlob    = "glob"
-	LogCategoryRemove  = "remove"
-	LogCategoryClean   = "clean"
-	LogCategorySync    = "sync"
-	LogCategoryOverlay = "overlay"
+	LogCategoryDebug      = "debug"
+	LogCategoryGit        = "git"
+	LogCategoryConfig     = "config"
+	LogCategoryGlob       = "glob"
+	LogCategoryRemove     = "remove"
+	LogCategoryClean      = "clean"
+	LogCategorySync       = "sync"
+	LogCategoryOverlay    = "overlay"
+	LogCategoryAudit      = "audit"
+	LogCategoryDiskUse    = "du"
+	LogCategoryPrompt     = "prompt"
+	LogCategoryDoctor     = "doctor"
+	LogCategoryOpen       = "open"
+	LogCategoryLock       = "lock"
+	LogCategoryForge      = "forge"
+	LogCategoryScratch    = "scratch"
+	LogCategoryRename     = "rename"
+	LogCategoryCompletion = "completion"
+	LogCategoryNotify     = "notify"
+	LogCategoryGrep       = "grep"
+	LogCategoryGC         = "gc"
+	LogCategoryExport     = "export"
+	LogCategoryImport     = "import"
+	LogCategoryAdopt      = "adopt"
+	LogCategoryPath       = "path"
+	LogCategoryWatch      = "watch"
 )
 
 // Command ID generation settings.
@@ -1,8 +1,10 @@
-package twig
+package core
 
 import (
 	"bytes"
+	"encoding/json"
 	"log/slog"
+	"reflect"
 	"testing"
 	"time"
 )
@@ -313,6 +315,65 @@ func TestCLIHandler_RecordAttrsOverrideHandlerAttrs(t *testing.T) {
 	}
 }
 
+func TestParseLogFormat(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input   string
+		want    LogFormat
+		wantErr bool
+	}{
+		{"", LogFormatText, false},
+		{"text", LogFormatText, false},
+		{"json", LogFormatJSON, false},
+		{"yaml", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ParseLogFormat(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseLogFormat(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseLogFormat(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewLogHandler_JSON(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	handler := NewLogHandler(&buf, slog.LevelDebug, LogFormatJSON).
+		WithAttrs([]slog.Attr{LogAttrKeyCmdID.Attr("a1b2c3d4")})
+
+	record := slog.NewRecord(time.Date(2026, 1, 17, 12, 34, 56, 0, time.UTC), slog.LevelDebug, "git -C /repo status", 0)
+	record.AddAttrs(LogAttrKeyCategory.Attr("git"), slog.Int("exit_code", 1))
+	if err := handler.Handle(t.Context(), record); err != nil {
+		t.Fatalf("Handle() error: %v", err)
+	}
+
+	var got map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, buf.String())
+	}
+	want := map[string]any{
+		"time":      "2026-01-17T12:34:56Z",
+		"level":     "DEBUG",
+		"msg":       "git -C /repo status",
+		"cmd_id":    "a1b2c3d4",
+		"category":  "git",
+		"exit_code": float64(1),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
 func TestGenerateCommandID(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,361 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// notesFileName is stored next to the audit log under <git-common-dir>/twig,
+// so notes are shared by all worktrees and never committed.
+const notesFileName = "notes.json"
+
+// WorktreeNoteFileName is the file twig note --file writes into the
+// worktree of the branch, so the note is visible to anyone (or any tool)
+// working there without running twig.
+const WorktreeNoteFileName = "WORKTREE_NOTE"
+
+// Note is a free-form note attached to a branch.
+type Note struct {
+	Text      string    `json:"text"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// NoteStore reads and writes branch notes.
+type NoteStore struct {
+	FS  FileSystem
+	Git *GitRunner
+}
+
+// NewNoteStore creates a NoteStore with explicit dependencies.
+func NewNoteStore(fs FileSystem, git *GitRunner) *NoteStore {
+	return &NoteStore{FS: fs, Git: git}
+}
+
+// Path returns the notes file path (<git-common-dir>/twig/notes.json).
+func (s *NoteStore) Path(ctx context.Context) (string, error) {
+	commonDir, err := s.Git.GitCommonDir(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get git common directory: %w", err)
+	}
+	if commonDir == "" {
+		return "", fmt.Errorf("git common directory is empty")
+	}
+	return filepath.Join(commonDir, auditDirName, notesFileName), nil
+}
+
+// Load returns the notes keyed by branch. A missing file yields no notes.
+func (s *NoteStore) Load(ctx context.Context) (map[string]Note, error) {
+	notesPath, err := s.Path(ctx)
+	if err != nil {
+		return nil, err
+	}
+	data, err := s.FS.ReadFile(notesPath)
+	if err != nil {
+		if s.FS.IsNotExist(err) {
+			return map[string]Note{}, nil
+		}
+		return nil, fmt.Errorf("failed to read notes: %w", err)
+	}
+	notes := make(map[string]Note)
+	if err := json.Unmarshal(data, &notes); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", notesPath, err)
+	}
+	return notes, nil
+}
+
+// Save replaces the stored notes.
+func (s *NoteStore) Save(ctx context.Context, notes map[string]Note) error {
+	notesPath, err := s.Path(ctx)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(notes, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode notes: %w", err)
+	}
+	if err := s.FS.MkdirAll(filepath.Dir(notesPath), 0755); err != nil {
+		return fmt.Errorf("failed to create notes directory: %w", err)
+	}
+	if err := s.FS.WriteFile(notesPath, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write notes: %w", err)
+	}
+	return nil
+}
+
+// Rename moves the note of oldBranch to newBranch, if there is one.
+func (s *NoteStore) Rename(ctx context.Context, oldBranch, newBranch string) error {
+	notes, err := s.Load(ctx)
+	if err != nil {
+		return err
+	}
+	note, ok := notes[oldBranch]
+	if !ok {
+		return nil
+	}
+	delete(notes, oldBranch)
+	notes[newBranch] = note
+	return s.Save(ctx, notes)
+}
+
+// noteTexts returns the note text of each branch, for display next to
+// worktrees. Failures are logged only since notes are informational.
+func noteTexts(ctx context.Context, store *NoteStore, log *slog.Logger) map[string]string {
+	notes, err := store.Load(ctx)
+	if err != nil {
+		log.DebugContext(ctx, "failed to load notes", "error", err.Error())
+		return nil
+	}
+	texts := make(map[string]string, len(notes))
+	for branch, note := range notes {
+		texts[branch] = note.Text
+	}
+	return texts
+}
+
+// NoteOptions configures the note command.
+type NoteOptions struct {
+	Clear bool // Remove the note instead of showing or setting it
+	File  bool // Also write the note to WORKTREE_NOTE in the branch's worktree
+}
+
+// NoteEntry is a branch with its note.
+type NoteEntry struct {
+	Branch string
+	Note   Note
+}
+
+// NoteResult holds the result of a note operation.
+type NoteResult struct {
+	Entries []NoteEntry // Notes shown or set (sorted by branch)
+	Cleared string      // Branch whose note was removed
+	Pruned  []string    // Notes dropped because their branch no longer exists
+	File    string      // WORKTREE_NOTE written, or removed when clearing
+}
+
+// Format formats the NoteResult for display.
+func (r NoteResult) Format(opts FormatOptions) FormatResult {
+	var stdout bytes.Buffer
+
+	if opts.Verbose {
+		for _, branch := range r.Pruned {
+			fmt.Fprintf(&stdout, "Dropped note of deleted branch: %s\n", branch)
+		}
+	}
+	if r.Cleared != "" {
+		if opts.Verbose {
+			if r.File != "" {
+				fmt.Fprintf(&stdout, "Removed note file: %s\n", r.File)
+			}
+			fmt.Fprintf(&stdout, "Cleared note: %s\n", r.Cleared)
+		}
+		return FormatResult{Stdout: stdout.String()}
+	}
+	if r.File != "" && opts.Verbose {
+		fmt.Fprintf(&stdout, "Wrote note file: %s\n", r.File)
+	}
+
+	w := tabwriter.NewWriter(&stdout, 0, 0, 2, ' ', 0)
+	for _, e := range r.Entries {
+		fmt.Fprintf(w, "%s\t%s\n", e.Branch, formatNote(e.Note.Text))
+	}
+	w.Flush()
+	return FormatResult{Stdout: stdout.String()}
+}
+
+// NoteCommand attaches notes to branches. Notes are shown by twig list
+// --long and twig clean, as context when deciding what to remove.
+type NoteCommand struct {
+	FS     FileSystem
+	Git    *GitRunner
+	Config *Config
+	Log    *slog.Logger
+}
+
+// NewNoteCommand creates a NoteCommand with explicit dependencies.
+func NewNoteCommand(fs FileSystem, git *GitRunner, cfg *Config, log *slog.Logger) *NoteCommand {
+	if log == nil {
+		log = NewNopLogger()
+	}
+	return &NoteCommand{
+		FS:     fs,
+		Git:    git,
+		Config: cfg,
+		Log:    log,
+	}
+}
+
+// NewDefaultNoteCommand creates a NoteCommand with production defaults.
+func NewDefaultNoteCommand(cfg *Config, log *slog.Logger, gitOpts ...GitRunnerOption) *NoteCommand {
+	git := newDefaultGitRunner(cfg.WorktreeSourceDir, log, gitOpts)
+	return NewNoteCommand(git.defaultFS(), git, cfg, log)
+}
+
+// Run shows, sets, or clears the note of branch. Without a branch, all
+// notes are listed. With text, the note of branch is replaced; with
+// opts.Clear it is removed. branch is matched against local branches as
+// given first, then after applying branch_aliases and branch_prefix.
+// Notes of deleted branches are dropped whenever notes are written.
+//
+// With opts.File, a set note is also written to WORKTREE_NOTE in the
+// worktree of branch, which must be checked out. Clearing a note always
+// removes that file if it exists.
+func (c *NoteCommand) Run(ctx context.Context, branch, text string, opts NoteOptions) (NoteResult, error) {
+	var result NoteResult
+	store := NewNoteStore(c.FS, c.Git)
+
+	notes, err := store.Load(ctx)
+	if err != nil {
+		return result, err
+	}
+
+	if branch == "" {
+		if opts.Clear || text != "" {
+			return result, fmt.Errorf("a branch is required")
+		}
+		for _, b := range slices.Sorted(maps.Keys(notes)) {
+			result.Entries = append(result.Entries, NoteEntry{Branch: b, Note: notes[b]})
+		}
+		return result, nil
+	}
+
+	resolved, err := c.resolveBranch(ctx, branch)
+	if err != nil {
+		return result, err
+	}
+
+	switch {
+	case opts.Clear:
+		if _, ok := notes[resolved]; !ok {
+			return result, fmt.Errorf("%s has no note", resolved)
+		}
+		delete(notes, resolved)
+		result.Cleared = resolved
+		result.File = c.removeNoteFile(ctx, resolved)
+	case text != "":
+		notes[resolved] = Note{Text: text, UpdatedAt: time.Now()}
+		result.Entries = []NoteEntry{{Branch: resolved, Note: notes[resolved]}}
+		if opts.File {
+			if result.File, err = c.writeNoteFile(ctx, resolved, text); err != nil {
+				return result, err
+			}
+		}
+	default:
+		note, ok := notes[resolved]
+		if !ok {
+			return result, fmt.Errorf("%s has no note", resolved)
+		}
+		result.Entries = []NoteEntry{{Branch: resolved, Note: note}}
+		return result, nil
+	}
+
+	result.Pruned = c.pruneDeleted(ctx, notes)
+	if err := store.Save(ctx, notes); err != nil {
+		return result, err
+	}
+	c.Log.DebugContext(ctx, "notes saved",
+		"branch", resolved,
+		"cleared", opts.Clear,
+		"pruned", len(result.Pruned))
+	return result, nil
+}
+
+// resolveBranch returns the local branch name refers to.
+func (c *NoteCommand) resolveBranch(ctx context.Context, name string) (string, error) {
+	candidates := []string{name}
+	if c.Config != nil {
+		if resolved, _ := c.Config.ResolveBranch(name); resolved != name {
+			candidates = append(candidates, resolved)
+		}
+	}
+	for _, candidate := range candidates {
+		exists, err := c.Git.LocalBranchExists(ctx, candidate)
+		if err != nil {
+			return "", err
+		}
+		if exists {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("branch %q does not exist", candidates[len(candidates)-1])
+}
+
+// writeNoteFile writes text to WORKTREE_NOTE in the worktree of branch
+// and returns the file path.
+func (c *NoteCommand) writeNoteFile(ctx context.Context, branch, text string) (string, error) {
+	wt, err := c.Git.WorktreeFindByBranch(ctx, branch)
+	if err != nil {
+		return "", err
+	}
+	notePath := filepath.Join(wt.Path, WorktreeNoteFileName)
+	if err := c.FS.WriteFile(notePath, []byte(strings.TrimRight(text, "\n")+"\n"), 0644); err != nil {
+		return "", fmt.Errorf("failed to write note file: %w", err)
+	}
+	return notePath, nil
+}
+
+// removeNoteFile removes WORKTREE_NOTE from the worktree of branch and
+// returns its path, or "" when there was none. Failures are logged only
+// since the stored note is already cleared.
+func (c *NoteCommand) removeNoteFile(ctx context.Context, branch string) string {
+	wt, err := c.Git.WorktreeFindByBranch(ctx, branch)
+	if err != nil {
+		return ""
+	}
+	notePath := filepath.Join(wt.Path, WorktreeNoteFileName)
+	if err := c.FS.Remove(notePath); err != nil {
+		if !c.FS.IsNotExist(err) {
+			c.Log.DebugContext(ctx, "failed to remove note file",
+				"path", notePath,
+				"error", err.Error())
+		}
+		return ""
+	}
+	return notePath
+}
+
+// pruneDeleted removes the notes of branches that no longer exist and
+// returns their names. Branches that cannot be checked are kept.
+func (c *NoteCommand) pruneDeleted(ctx context.Context, notes map[string]Note) []string {
+	var pruned []string
+	for _, branch := range slices.Sorted(maps.Keys(notes)) {
+		exists, err := c.Git.LocalBranchExists(ctx, branch)
+		if err != nil || exists {
+			continue
+		}
+		delete(notes, branch)
+		pruned = append(pruned, branch)
+	}
+	return pruned
+}
+
+// formatNote returns text on a single line for tabular output.
+func formatNote(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// formatDescription returns the first line of a branch description, its
+// summary by the convention of git branch --edit-description.
+func formatDescription(text string) string {
+	first, _, _ := strings.Cut(strings.TrimSpace(text), "\n")
+	return formatNote(first)
+}
+
+// branchDescriptions returns the branch descriptions, or nil when they
+// cannot be read; like notes, they are context only.
+func branchDescriptions(ctx context.Context, git *GitRunner, log *slog.Logger) map[string]string {
+	descriptions, err := git.BranchDescriptions(ctx)
+	if err != nil {
+		log.DebugContext(ctx, "failed to load branch descriptions", "error", err.Error())
+		return nil
+	}
+	return descriptions
+}
@@ -0,0 +1,288 @@
+package core
+
+import (
+	"encoding/json"
+	"io/fs"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/708u/twig/internal/testutil"
+)
+
+const (
+	testNotesPath        = "/repo/.git/twig/notes.json"
+	testWorktreeNotePath = "/repo-worktree/feat/a/WORKTREE_NOTE"
+)
+
+func TestNoteCommand_Run(t *testing.T) {
+	t.Parallel()
+
+	stored := map[string]Note{
+		"feat/a":   {Text: "waiting on review"},
+		"feat/old": {Text: "branch was deleted"},
+	}
+
+	tests := []struct {
+		name        string
+		branch      string
+		text        string
+		opts        NoteOptions
+		config      *Config
+		wantErr     string
+		wantEntries []string // branch=text
+		wantCleared string
+		wantPruned  []string
+		wantSaved   []string // branches in the saved file; nil if not saved
+		existing    bool     // WORKTREE_NOTE exists in the feat/a worktree
+		wantFile    string   // WORKTREE_NOTE reported in the result
+	}{
+		{
+			name:        "list_all",
+			wantEntries: []string{"feat/a=waiting on review", "feat/old=branch was deleted"},
+		},
+		{
+			name:        "show",
+			branch:      "feat/a",
+			wantEntries: []string{"feat/a=waiting on review"},
+		},
+		{
+			name:        "set_prunes_deleted_branches",
+			branch:      "feat/b",
+			text:        "blocked by #12",
+			wantEntries: []string{"feat/b=blocked by #12"},
+			wantPruned:  []string{"feat/old"},
+			wantSaved:   []string{"feat/a", "feat/b"},
+		},
+		{
+			name:        "set_with_branch_prefix",
+			branch:      "b",
+			text:        "blocked by #12",
+			config:      &Config{BranchPrefix: "feat/"},
+			wantEntries: []string{"feat/b=blocked by #12"},
+			wantPruned:  []string{"feat/old"},
+			wantSaved:   []string{"feat/a", "feat/b"},
+		},
+		{
+			name:        "clear",
+			branch:      "feat/a",
+			opts:        NoteOptions{Clear: true},
+			wantCleared: "feat/a",
+			wantPruned:  []string{"feat/old"},
+			wantSaved:   []string{},
+		},
+		{
+			name:        "set_with_file",
+			branch:      "feat/a",
+			text:        "do not touch",
+			opts:        NoteOptions{File: true},
+			wantEntries: []string{"feat/a=do not touch"},
+			wantPruned:  []string{"feat/old"},
+			wantSaved:   []string{"feat/a"},
+			wantFile:    "/repo-worktree/feat/a/WORKTREE_NOTE",
+		},
+		{
+			name:        "clear_removes_file",
+			branch:      "feat/a",
+			opts:        NoteOptions{Clear: true},
+			existing:    true,
+			wantCleared: "feat/a",
+			wantPruned:  []string{"feat/old"},
+			wantSaved:   []string{},
+			wantFile:    "/repo-worktree/feat/a/WORKTREE_NOTE",
+		},
+		{
+			name:    "file_without_worktree",
+			branch:  "feat/b",
+			text:    "x",
+			opts:    NoteOptions{File: true},
+			wantErr: `branch "feat/b" is not checked out in any worktree`,
+		},
+		{
+			name:    "show_without_note",
+			branch:  "feat/b",
+			wantErr: "feat/b has no note",
+		},
+		{
+			name:    "clear_without_note",
+			branch:  "feat/b",
+			opts:    NoteOptions{Clear: true},
+			wantErr: "feat/b has no note",
+		},
+		{
+			name:    "unknown_branch",
+			branch:  "feat/missing",
+			text:    "x",
+			wantErr: `branch "feat/missing" does not exist`,
+		},
+		{
+			name:    "text_without_branch",
+			text:    "x",
+			wantErr: "a branch is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			data, err := json.Marshal(stored)
+			if err != nil {
+				t.Fatal(err)
+			}
+			files := map[string][]byte{testNotesPath: data}
+			if tt.existing {
+				files[testWorktreeNotePath] = []byte("waiting on review\n")
+			}
+			mockFS := &testutil.MockFS{
+				WrittenFiles: files,
+				RemoveFunc: func(name string) error {
+					if _, ok := files[name]; !ok {
+						return fs.ErrNotExist
+					}
+					delete(files, name)
+					return nil
+				},
+			}
+			mockGit := &testutil.MockGitExecutor{
+				GitCommonDir:     "/repo/.git",
+				ExistingBranches: []string{"main", "feat/a", "feat/b"},
+				Worktrees: []testutil.MockWorktree{
+					{Path: "/repo", Branch: "main"},
+					{Path: "/repo-worktree/feat/a", Branch: "feat/a"},
+				},
+			}
+			cfg := tt.config
+			if cfg == nil {
+				cfg = &Config{}
+			}
+			cmd := NewNoteCommand(mockFS, &GitRunner{Executor: mockGit, Dir: "/repo", Log: NewNopLogger()}, cfg, nil)
+
+			result, err := cmd.Run(t.Context(), tt.branch, tt.text, tt.opts)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var entries []string
+			for _, e := range result.Entries {
+				entries = append(entries, e.Branch+"="+e.Note.Text)
+			}
+			if strings.Join(entries, ",") != strings.Join(tt.wantEntries, ",") {
+				t.Errorf("Entries = %v, want %v", entries, tt.wantEntries)
+			}
+			if result.Cleared != tt.wantCleared {
+				t.Errorf("Cleared = %q, want %q", result.Cleared, tt.wantCleared)
+			}
+			if strings.Join(result.Pruned, ",") != strings.Join(tt.wantPruned, ",") {
+				t.Errorf("Pruned = %v, want %v", result.Pruned, tt.wantPruned)
+			}
+			if result.File != tt.wantFile {
+				t.Errorf("File = %q, want %q", result.File, tt.wantFile)
+			}
+			_, hasFile := files[testWorktreeNotePath]
+			if wantFile := tt.opts.File; hasFile != wantFile {
+				t.Errorf("WORKTREE_NOTE exists = %v, want %v", hasFile, wantFile)
+			}
+
+			if tt.wantSaved == nil {
+				if string(mockFS.WrittenFiles[testNotesPath]) != string(data) {
+					t.Error("notes were written, want unchanged")
+				}
+				return
+			}
+			var saved map[string]Note
+			if err := json.Unmarshal(mockFS.WrittenFiles[testNotesPath], &saved); err != nil {
+				t.Fatal(err)
+			}
+			if len(saved) != len(tt.wantSaved) {
+				t.Errorf("saved notes = %v, want branches %v", saved, tt.wantSaved)
+			}
+			for _, branch := range tt.wantSaved {
+				if _, ok := saved[branch]; !ok {
+					t.Errorf("saved notes missing %s", branch)
+				}
+			}
+		})
+	}
+}
+
+func TestNoteStore_Rename(t *testing.T) {
+	t.Parallel()
+
+	data := []byte(`{"feat/a": {"text": "waiting on review", "updated_at": "2026-01-02T03:04:05Z"}}`)
+	mockFS := &testutil.MockFS{WrittenFiles: map[string][]byte{testNotesPath: data}}
+	store := NewNoteStore(mockFS, &GitRunner{
+		Executor: &testutil.MockGitExecutor{GitCommonDir: "/repo/.git"},
+		Dir:      "/repo",
+		Log:      NewNopLogger(),
+	})
+
+	if err := store.Rename(t.Context(), "feat/a", "feat/b"); err != nil {
+		t.Fatal(err)
+	}
+	notes, err := store.Load(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := notes["feat/a"]; ok {
+		t.Error("note still stored under the old branch")
+	}
+	want := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	if got := notes["feat/b"]; got.Text != "waiting on review" || !got.UpdatedAt.Equal(want) {
+		t.Errorf("notes[feat/b] = %+v, want the moved note", got)
+	}
+}
+
+func TestNoteResult_Format(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		result     NoteResult
+		opts       FormatOptions
+		wantStdout string
+	}{
+		{
+			name: "entries",
+			result: NoteResult{Entries: []NoteEntry{
+				{Branch: "feat/a", Note: Note{Text: "waiting on\nreview"}},
+				{Branch: "fix/long-name", Note: Note{Text: "blocked"}},
+			}},
+			wantStdout: "feat/a         waiting on review\nfix/long-name  blocked\n",
+		},
+		{
+			name:       "cleared",
+			result:     NoteResult{Cleared: "feat/a", Pruned: []string{"feat/old"}},
+			wantStdout: "",
+		},
+		{
+			name:       "set_with_file_verbose",
+			result:     NoteResult{Entries: []NoteEntry{{Branch: "feat/a", Note: Note{Text: "do not touch"}}}, File: "/wt/WORKTREE_NOTE"},
+			opts:       FormatOptions{Verbose: true},
+			wantStdout: "Wrote note file: /wt/WORKTREE_NOTE\nfeat/a  do not touch\n",
+		},
+		{
+			name:       "cleared_verbose",
+			result:     NoteResult{Cleared: "feat/a", Pruned: []string{"feat/old"}},
+			opts:       FormatOptions{Verbose: true},
+			wantStdout: "Dropped note of deleted branch: feat/old\nCleared note: feat/a\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := tt.result.Format(tt.opts)
+			if got.Stdout != tt.wantStdout {
+				t.Errorf("Stdout = %q, want %q", got.Stdout, tt.wantStdout)
+			}
+		})
+	}
+}
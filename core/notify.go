@@ -0,0 +1,91 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os/exec"
+	"time"
+)
+
+const (
+	// DefaultNotifyAfter is how long a command must run before notify
+	// sends a notification, so that quick commands stay quiet.
+	DefaultNotifyAfter = 30 * time.Second
+
+	// notifyTimeout bounds the notification command, which must never
+	// hold up the exit of twig.
+	notifyTimeout = 5 * time.Second
+
+	// terminalBell rings the terminal when no desktop notification can
+	// be shown.
+	terminalBell = "\a"
+)
+
+// Notification is a message about a finished command.
+type Notification struct {
+	Title   string
+	Message string
+}
+
+// CommandNotification summarizes a command that ran for elapsed and
+// failed with err (nil = succeeded).
+func CommandNotification(command string, elapsed time.Duration, err error) Notification {
+	elapsed = elapsed.Round(time.Second)
+	if err != nil {
+		return Notification{
+			Title:   "twig: failed",
+			Message: fmt.Sprintf("%s failed after %s: %v", command, elapsed, err),
+		}
+	}
+	return Notification{
+		Title:   "twig: done",
+		Message: fmt.Sprintf("%s finished in %s", command, elapsed),
+	}
+}
+
+// Notifier tells the user that a long-running command finished, with a
+// desktop notification where the platform has a notification command
+// (see desktopNotifyCommand) and a terminal bell otherwise.
+type Notifier struct {
+	Bell io.Writer // Where the bell is rung, usually stderr
+	Log  *slog.Logger
+
+	command func(title, message string) []string
+	run     func(ctx context.Context, args []string) error
+}
+
+// NewNotifier creates a Notifier for the current platform.
+func NewNotifier(bell io.Writer, log *slog.Logger) *Notifier {
+	if log == nil {
+		log = NewNopLogger()
+	}
+	return &Notifier{
+		Bell:    bell,
+		Log:     log,
+		command: desktopNotifyCommand,
+		run: func(ctx context.Context, args []string) error {
+			return exec.CommandContext(ctx, args[0], args[1:]...).Run()
+		},
+	}
+}
+
+// Notify shows note as a desktop notification, falling back to the
+// terminal bell when the platform has no notification command or it
+// fails (e.g. notify-send is not installed).
+func (n *Notifier) Notify(ctx context.Context, note Notification) {
+	if args := n.command(note.Title, note.Message); len(args) > 0 {
+		ctx, cancel := context.WithTimeout(ctx, notifyTimeout)
+		defer cancel()
+		err := n.run(ctx, args)
+		if err == nil {
+			return
+		}
+		n.Log.DebugContext(ctx, "desktop notification failed",
+			LogAttrKeyCategory.String(), LogCategoryNotify,
+			"command", args[0],
+			"error", err)
+	}
+	fmt.Fprint(n.Bell, terminalBell)
+}
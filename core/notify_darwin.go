@@ -0,0 +1,19 @@
+//go:build darwin
+
+package core
+
+import (
+	"fmt"
+	"strings"
+)
+
+// appleScriptEscaper escapes text for an AppleScript string literal.
+var appleScriptEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+// desktopNotifyCommand returns the osascript command that shows a
+// notification in Notification Center.
+func desktopNotifyCommand(title, message string) []string {
+	script := fmt.Sprintf(`display notification "%s" with title "%s"`,
+		appleScriptEscaper.Replace(message), appleScriptEscaper.Replace(title))
+	return []string{"osascript", "-e", script}
+}
@@ -0,0 +1,9 @@
+//go:build linux
+
+package core
+
+// desktopNotifyCommand returns the notify-send command (libnotify) that
+// shows a notification on freedesktop.org desktops.
+func desktopNotifyCommand(title, message string) []string {
+	return []string{"notify-send", "--app-name=twig", title, message}
+}
@@ -0,0 +1,9 @@
+//go:build !darwin && !linux
+
+package core
+
+// desktopNotifyCommand returns nil: there is no notification command on
+// this platform, so Notifier rings the terminal bell.
+func desktopNotifyCommand(title, message string) []string {
+	return nil
+}
@@ -0,0 +1,106 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCommandNotification(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		elapsed     time.Duration
+		err         error
+		wantTitle   string
+		wantMessage string
+	}{
+		{
+			name:        "success",
+			elapsed:     2*time.Minute + 3400*time.Millisecond,
+			wantTitle:   "twig: done",
+			wantMessage: "twig clean finished in 2m3s",
+		},
+		{
+			name:        "failure",
+			elapsed:     45 * time.Second,
+			err:         errors.New("failed to remove 1 worktree(s)"),
+			wantTitle:   "twig: failed",
+			wantMessage: "twig clean failed after 45s: failed to remove 1 worktree(s)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := CommandNotification("twig clean", tt.elapsed, tt.err)
+			if got.Title != tt.wantTitle || got.Message != tt.wantMessage {
+				t.Errorf("CommandNotification() = %+v, want {%s %s}", got, tt.wantTitle, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestNotifier_Notify(t *testing.T) {
+	t.Parallel()
+
+	note := Notification{Title: "twig: done", Message: "twig add finished in 1m0s"}
+	command := func(title, message string) []string {
+		return []string{"notify", title, message}
+	}
+
+	tests := []struct {
+		name     string
+		command  func(title, message string) []string
+		runErr   error
+		wantRun  []string
+		wantBell bool
+	}{
+		{
+			name:    "desktop_notification",
+			command: command,
+			wantRun: []string{"notify", "twig: done", "twig add finished in 1m0s"},
+		},
+		{
+			name:     "command_fails_rings_bell",
+			command:  command,
+			runErr:   errors.New("executable file not found in $PATH"),
+			wantRun:  []string{"notify", "twig: done", "twig add finished in 1m0s"},
+			wantBell: true,
+		},
+		{
+			name:     "no_command_rings_bell",
+			command:  func(string, string) []string { return nil },
+			wantBell: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var bell strings.Builder
+			var ran []string
+			n := NewNotifier(&bell, nil)
+			n.command = tt.command
+			n.run = func(_ context.Context, args []string) error {
+				ran = args
+				return tt.runErr
+			}
+
+			n.Notify(t.Context(), note)
+
+			if !slices.Equal(ran, tt.wantRun) {
+				t.Errorf("ran %v, want %v", ran, tt.wantRun)
+			}
+			if got := bell.String() == terminalBell; got != tt.wantBell {
+				t.Errorf("bell = %q, want rung = %v", bell.String(), tt.wantBell)
+			}
+		})
+	}
+}
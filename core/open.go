@@ -0,0 +1,196 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// openPathPlaceholder is replaced with the worktree path in open_command.
+const openPathPlaceholder = "{path}"
+
+// OpenOptions configures the open command.
+type OpenOptions struct {
+	Add      bool // Create the worktree first when the branch has none
+	NoPrefix bool // Use the name verbatim, ignoring branch_prefix and branch_aliases
+}
+
+// OpenResult holds the result of an open operation.
+type OpenResult struct {
+	Branch       string
+	WorktreePath string
+	Command      string     // Expanded command that was launched
+	Added        *AddResult // Set when the worktree was created by --add
+}
+
+// Format formats the OpenResult for display.
+func (r OpenResult) Format(opts AddFormatOptions) FormatResult {
+	var stdout, stderr strings.Builder
+	if r.Added != nil {
+		formatted := r.Added.Format(opts)
+		stdout.WriteString(formatted.Stdout)
+		stderr.WriteString(formatted.Stderr)
+	}
+	if opts.Verbose {
+		fmt.Fprintf(&stdout, "Running: %s\n", r.Command)
+	}
+	fmt.Fprintf(&stdout, "twig open: %s (%s)\n", r.Branch, r.WorktreePath)
+	return FormatResult{Stdout: stdout.String(), Stderr: stderr.String()}
+}
+
+// OpenCommand opens a worktree with the configured open_command.
+type OpenCommand struct {
+	FS     FileSystem
+	Git    *GitRunner
+	Config *Config
+	Log    *slog.Logger
+
+	// Launch runs the expanded command in dir with env as its environment.
+	// nil runs it with sh -c attached to the current terminal.
+	Launch func(ctx context.Context, dir, command string, env []string) error
+
+	// LockAdd, if set, is called before creating a worktree with --add
+	// and the returned release func after it is created.
+	LockAdd func(ctx context.Context) (release func(), err error)
+
+	// Provenance is recorded in the marker of a worktree created with
+	// --add (see AddOptions.Provenance).
+	Provenance Provenance
+}
+
+// NewOpenCommand creates an OpenCommand with explicit dependencies.
+func NewOpenCommand(fs FileSystem, git *GitRunner, cfg *Config, log *slog.Logger) *OpenCommand {
+	if log == nil {
+		log = NewNopLogger()
+	}
+	return &OpenCommand{
+		FS:     fs,
+		Git:    git,
+		Config: cfg,
+		Log:    log,
+	}
+}
+
+// NewDefaultOpenCommand creates an OpenCommand with production defaults.
+func NewDefaultOpenCommand(cfg *Config, log *slog.Logger, gitOpts ...GitRunnerOption) *OpenCommand {
+	git := newDefaultGitRunner(cfg.WorktreeSourceDir, log, gitOpts)
+	return NewOpenCommand(git.defaultFS(), git, cfg, log)
+}
+
+// Run resolves the worktree for name and launches open_command in it.
+// name is matched against checked-out branches as given first, then
+// after applying branch_aliases and branch_prefix.
+func (c *OpenCommand) Run(ctx context.Context, name string, opts OpenOptions) (OpenResult, error) {
+	result := OpenResult{Branch: name}
+
+	if name == "" {
+		return result, fmt.Errorf("branch name is required")
+	}
+	if c.Config.OpenCommand == "" {
+		return result, fmt.Errorf("open_command is not configured (e.g. open_command = %q in .twig/settings.local.toml)", "code "+openPathPlaceholder)
+	}
+
+	branch := name
+	if !opts.NoPrefix {
+		branch, _ = c.Config.ResolveBranch(name)
+	}
+
+	worktrees, err := c.Git.WorktreeList(ctx)
+	if err != nil {
+		return result, fmt.Errorf("failed to list worktrees: %w", err)
+	}
+	var isMain bool
+	for _, candidate := range []string{name, branch} {
+		if wt := findWorktreeByBranch(worktrees, candidate); wt != nil {
+			result.Branch = candidate
+			result.WorktreePath = wt.Path
+			isMain = wt == &worktrees[0]
+			break
+		}
+	}
+
+	if result.WorktreePath == "" {
+		if !opts.Add {
+			return result, fmt.Errorf("branch %q is not checked out in any worktree (use --add to create it)", branch)
+		}
+		c.Log.DebugContext(ctx, "creating worktree before opening",
+			LogAttrKeyCategory.String(), LogCategoryOpen,
+			"branch", branch)
+		added, err := c.add(ctx, name, opts)
+		if err != nil {
+			return result, err
+		}
+		result.Added = &added
+		result.Branch = added.Branch
+		result.WorktreePath = added.WorktreePath
+	}
+
+	result.Command = expandOpenCommand(c.Config.OpenCommand, result.WorktreePath)
+	c.Log.DebugContext(ctx, "launching open command",
+		LogAttrKeyCategory.String(), LogCategoryOpen,
+		"command", result.Command,
+		"dir", result.WorktreePath)
+
+	launch := c.Launch
+	if launch == nil {
+		launch = launchShell
+	}
+	env := worktreeEnv(result.Branch, result.WorktreePath, isMain)
+	if err := launch(ctx, result.WorktreePath, result.Command, env); err != nil {
+		return result, fmt.Errorf("open command failed: %w", err)
+	}
+	return result, nil
+}
+
+// add creates the worktree for name, holding LockAdd while it runs.
+func (c *OpenCommand) add(ctx context.Context, name string, opts OpenOptions) (AddResult, error) {
+	if c.LockAdd != nil {
+		release, err := c.LockAdd(ctx)
+		if err != nil {
+			return AddResult{}, err
+		}
+		defer release()
+	}
+	addCmd := NewAddCommand(c.FS, c.Git, c.Config, c.Log, AddOptions{NoPrefix: opts.NoPrefix, Provenance: c.Provenance})
+	return addCmd.Run(ctx, name)
+}
+
+// findWorktreeByBranch returns the worktree with branch checked out, or nil.
+func findWorktreeByBranch(worktrees []Worktree, branch string) *Worktree {
+	for i := range worktrees {
+		if worktrees[i].Branch == branch {
+			return &worktrees[i]
+		}
+	}
+	return nil
+}
+
+// expandOpenCommand substitutes the quoted path for each {path} in command.
+// Without a placeholder the path is appended as the last argument.
+func expandOpenCommand(command, path string) string {
+	quoted := shellQuote(path)
+	if strings.Contains(command, openPathPlaceholder) {
+		return strings.ReplaceAll(command, openPathPlaceholder, quoted)
+	}
+	return command + " " + quoted
+}
+
+// shellQuote quotes s for use as a single sh word.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// launchShell runs command with sh -c in dir, attached to the current
+// terminal so that terminal editors work as well as GUI launchers.
+func launchShell(ctx context.Context, dir, command string, env []string) error {
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Dir = dir
+	cmd.Env = env
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
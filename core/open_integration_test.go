@@ -0,0 +1,62 @@
+//go:build integration
+
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/708u/twig/internal/testutil"
+)
+
+func TestOpenCommand_Integration(t *testing.T) {
+	t.Parallel()
+
+	t.Run("AddsAndOpensWorktree", func(t *testing.T) {
+		t.Parallel()
+
+		repoDir, mainDir := testutil.SetupTestRepo(t)
+		outFile := filepath.Join(t.TempDir(), "opened")
+
+		cfgResult, err := LoadConfig(mainDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cfg := cfgResult.Config
+		cfg.OpenCommand = "printf %s {path} > " + outFile
+
+		cmd := NewOpenCommand(osFS{}, NewGitRunner(mainDir), cfg, nil)
+
+		if _, err := cmd.Run(t.Context(), "feat/open", OpenOptions{}); err == nil {
+			t.Fatal("expected error for missing worktree without --add")
+		}
+
+		result, err := cmd.Run(t.Context(), "feat/open", OpenOptions{Add: true})
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+
+		wtPath := filepath.Join(repoDir, "feat", "open")
+		if result.WorktreePath != wtPath || result.Added == nil {
+			t.Errorf("result = %+v, want worktree added at %s", result, wtPath)
+		}
+
+		data, err := os.ReadFile(outFile)
+		if err != nil {
+			t.Fatalf("open command did not run: %v", err)
+		}
+		if string(data) != wtPath {
+			t.Errorf("open command received %q, want %q", data, wtPath)
+		}
+
+		// A second open finds the existing worktree
+		result, err = cmd.Run(t.Context(), "feat/open", OpenOptions{Add: true})
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		if result.Added != nil {
+			t.Error("existing worktree should not be added again")
+		}
+	})
+}
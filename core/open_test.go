@@ -0,0 +1,200 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/708u/twig/internal/testutil"
+)
+
+func TestOpenCommand_Run(t *testing.T) {
+	t.Parallel()
+
+	worktrees := []testutil.MockWorktree{
+		{Path: "/repo/main", Branch: "main"},
+		{Path: "/repo/main-worktree/login", Branch: "users/me/login"},
+	}
+
+	tests := []struct {
+		name        string
+		branch      string
+		opts        OpenOptions
+		openCommand string
+		launchErr   error
+		wantBranch  string
+		wantDir     string
+		wantCommand string
+		wantIsMain  string
+		wantAdded   bool
+		errContains string
+	}{
+		{
+			name:        "existing worktree by branch name",
+			branch:      "main",
+			openCommand: "code {path}",
+			wantBranch:  "main",
+			wantDir:     "/repo/main",
+			wantCommand: "code '/repo/main'",
+			wantIsMain:  "1",
+		},
+		{
+			name:        "resolves branch_prefix",
+			branch:      "login",
+			openCommand: "code --new-window {path}",
+			wantBranch:  "users/me/login",
+			wantDir:     "/repo/main-worktree/login",
+			wantCommand: "code --new-window '/repo/main-worktree/login'",
+			wantIsMain:  "0",
+		},
+		{
+			name:        "no placeholder appends path",
+			branch:      "main",
+			openCommand: "idea",
+			wantBranch:  "main",
+			wantDir:     "/repo/main",
+			wantCommand: "idea '/repo/main'",
+			wantIsMain:  "1",
+		},
+		{
+			name:        "missing worktree without add",
+			branch:      "feat",
+			openCommand: "code {path}",
+			errContains: `branch "users/me/feat" is not checked out in any worktree (use --add to create it)`,
+		},
+		{
+			name:        "missing worktree with add",
+			branch:      "feat",
+			opts:        OpenOptions{Add: true},
+			openCommand: "code {path}",
+			wantBranch:  "users/me/feat",
+			wantDir:     "/repo/main-worktree/feat",
+			wantCommand: "code '/repo/main-worktree/feat'",
+			wantIsMain:  "0",
+			wantAdded:   true,
+		},
+		{
+			name:        "open_command not configured",
+			branch:      "main",
+			errContains: "open_command is not configured",
+		},
+		{
+			name:        "launch failure",
+			branch:      "main",
+			openCommand: "code {path}",
+			launchErr:   errors.New("exit status 127"),
+			errContains: "open command failed: exit status 127",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockGit := &testutil.MockGitExecutor{Worktrees: worktrees}
+			git := &GitRunner{Executor: mockGit, Dir: "/repo/main", Log: NewNopLogger()}
+			cfg := &Config{
+				WorktreeSourceDir:   "/repo/main",
+				WorktreeDestBaseDir: "/repo/main-worktree",
+				BranchPrefix:        "users/me/",
+				OpenCommand:         tt.openCommand,
+			}
+
+			var gotDir, gotCommand string
+			var gotEnv []string
+			var locks, releases int
+			cmd := NewOpenCommand(&testutil.MockFS{}, git, cfg, nil)
+			cmd.LockAdd = func(ctx context.Context) (func(), error) {
+				locks++
+				return func() { releases++ }, nil
+			}
+			cmd.Launch = func(ctx context.Context, dir, command string, env []string) error {
+				gotDir, gotCommand, gotEnv = dir, command, env
+				return tt.launchErr
+			}
+
+			result, err := cmd.Run(t.Context(), tt.branch, tt.opts)
+			if tt.errContains != "" {
+				if err == nil {
+					t.Fatalf("expected error containing %q, got nil", tt.errContains)
+				}
+				if !strings.Contains(err.Error(), tt.errContains) {
+					t.Errorf("error = %q, want to contain %q", err.Error(), tt.errContains)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if result.Branch != tt.wantBranch {
+				t.Errorf("Branch = %q, want %q", result.Branch, tt.wantBranch)
+			}
+			if gotDir != tt.wantDir || result.WorktreePath != tt.wantDir {
+				t.Errorf("launch dir = %q, WorktreePath = %q, want %q", gotDir, result.WorktreePath, tt.wantDir)
+			}
+			if gotCommand != tt.wantCommand || result.Command != tt.wantCommand {
+				t.Errorf("launched %q (Command = %q), want %q", gotCommand, result.Command, tt.wantCommand)
+			}
+			for _, want := range []string{
+				EnvBranch + "=" + tt.wantBranch,
+				EnvWorktreePath + "=" + tt.wantDir,
+				EnvIsMain + "=" + tt.wantIsMain,
+			} {
+				if !slices.Contains(gotEnv, want) {
+					t.Errorf("launch env missing %q", want)
+				}
+			}
+			if (result.Added != nil) != tt.wantAdded {
+				t.Errorf("Added = %v, want added = %v", result.Added, tt.wantAdded)
+			}
+			// The lock is held only while the worktree is created
+			if wantLocks := map[bool]int{true: 1}[tt.wantAdded]; locks != wantLocks || releases != wantLocks {
+				t.Errorf("locks = %d, releases = %d, want %d", locks, releases, wantLocks)
+			}
+		})
+	}
+}
+
+func TestExpandOpenCommand(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		command string
+		path    string
+		want    string
+	}{
+		{command: "code {path}", path: "/repo/feat", want: "code '/repo/feat'"},
+		{command: "tmux new-window -c {path} -n {path}", path: "/a", want: "tmux new-window -c '/a' -n '/a'"},
+		{command: "subl", path: "/repo/it's here", want: `subl '/repo/it'\''s here'`},
+	}
+
+	for _, tt := range tests {
+		if got := expandOpenCommand(tt.command, tt.path); got != tt.want {
+			t.Errorf("expandOpenCommand(%q, %q) = %q, want %q", tt.command, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestOpenResult_Format(t *testing.T) {
+	t.Parallel()
+
+	result := OpenResult{
+		Branch:       "feat/a",
+		WorktreePath: "/wt/feat/a",
+		Command:      "code '/wt/feat/a'",
+		Added:        &AddResult{Branch: "feat/a", WorktreePath: "/wt/feat/a"},
+	}
+
+	got := result.Format(AddFormatOptions{})
+	if want := "twig add: feat/a (0 symlinks)\ntwig open: feat/a (/wt/feat/a)\n"; got.Stdout != want {
+		t.Errorf("Stdout = %q, want %q", got.Stdout, want)
+	}
+
+	verbose := OpenResult{Branch: "main", WorktreePath: "/repo", Command: "code '/repo'"}.Format(AddFormatOptions{Verbose: true})
+	if want := "Running: code '/repo'\ntwig open: main (/repo)\n"; verbose.Stdout != want {
+		t.Errorf("verbose Stdout = %q, want %q", verbose.Stdout, want)
+	}
+}
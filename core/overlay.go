@@ -1,4 +1,4 @@
-package twig
+package core
 
 import (
 	"context"
@@ -72,8 +72,9 @@ func NewOverlayCommand(fs FileSystem, git *GitRunner, log *slog.Logger) *Overlay
 }
 
 // NewDefaultOverlayCommand creates an OverlayCommand with production defaults.
-func NewDefaultOverlayCommand(gitDir string, log *slog.Logger) *OverlayCommand {
-	return NewOverlayCommand(osFS{}, NewGitRunner(gitDir, WithLogger(log)), log)
+func NewDefaultOverlayCommand(gitDir string, log *slog.Logger, gitOpts ...GitRunnerOption) *OverlayCommand {
+	git := newDefaultGitRunner(gitDir, log, gitOpts)
+	return NewOverlayCommand(git.defaultFS(), git, log)
 }
 
 // Run executes the overlay operation.
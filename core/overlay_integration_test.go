@@ -1,6 +1,6 @@
 //go:build integration
 
-package twig
+package core
 
 import (
 	"os"
@@ -1,4 +1,4 @@
-package twig
+package core
 
 import (
 	"context"
@@ -0,0 +1,99 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+// PathOptions configures the path command.
+type PathOptions struct {
+	NoPrefix bool // Use the name verbatim, ignoring branch_prefix and branch_aliases
+}
+
+// PathResult holds a path printed by twig root or twig path.
+type PathResult struct {
+	Branch string // Branch whose worktree was resolved (twig path only)
+	Path   string
+}
+
+// Format formats the PathResult as a single line for scripts.
+func (r PathResult) Format(opts FormatOptions) FormatResult {
+	return FormatResult{Stdout: r.Path + "\n"}
+}
+
+// PathCommand resolves the paths scripts need without parsing twig list:
+// the main worktree, the destination base directory, and the worktree of
+// a branch.
+type PathCommand struct {
+	Git    *GitRunner
+	Config *Config
+	Log    *slog.Logger
+}
+
+// NewPathCommand creates a PathCommand with explicit dependencies.
+func NewPathCommand(git *GitRunner, cfg *Config, log *slog.Logger) *PathCommand {
+	if log == nil {
+		log = NewNopLogger()
+	}
+	return &PathCommand{
+		Git:    git,
+		Config: cfg,
+		Log:    log,
+	}
+}
+
+// NewDefaultPathCommand creates a PathCommand with production defaults.
+func NewDefaultPathCommand(cfg *Config, log *slog.Logger, gitOpts ...GitRunnerOption) *PathCommand {
+	return NewPathCommand(newDefaultGitRunner(cfg.WorktreeSourceDir, log, gitOpts), cfg, log)
+}
+
+// Root returns the path of the main worktree.
+func (c *PathCommand) Root(ctx context.Context) (PathResult, error) {
+	worktrees, err := c.Git.WorktreeList(ctx)
+	if err != nil {
+		return PathResult{}, fmt.Errorf("failed to list worktrees: %w", err)
+	}
+	if len(worktrees) == 0 {
+		return PathResult{}, fmt.Errorf("no worktrees found")
+	}
+	return PathResult{Branch: worktrees[0].Branch, Path: worktrees[0].Path}, nil
+}
+
+// Dest returns worktree_destination_base_dir, resolved against the main
+// worktree by LoadConfig.
+func (c *PathCommand) Dest() (PathResult, error) {
+	if c.Config.WorktreeDestBaseDir == "" {
+		return PathResult{}, fmt.Errorf("worktree_destination_base_dir is not resolved")
+	}
+	return PathResult{Path: c.Config.WorktreeDestBaseDir}, nil
+}
+
+// Run returns the worktree path of the branch name. name is matched
+// against checked-out branches as given first, then after applying
+// branch_aliases and branch_prefix, like twig open.
+func (c *PathCommand) Run(ctx context.Context, name string, opts PathOptions) (PathResult, error) {
+	if name == "" {
+		return PathResult{}, fmt.Errorf("branch name is required")
+	}
+
+	branch := name
+	if !opts.NoPrefix {
+		branch, _ = c.Config.ResolveBranch(name)
+	}
+
+	worktrees, err := c.Git.WorktreeList(ctx)
+	if err != nil {
+		return PathResult{}, fmt.Errorf("failed to list worktrees: %w", err)
+	}
+	for _, candidate := range []string{name, branch} {
+		if wt := findWorktreeByBranch(worktrees, candidate); wt != nil {
+			c.Log.DebugContext(ctx, "resolved worktree path",
+				LogAttrKeyCategory.String(), LogCategoryPath,
+				"branch", candidate,
+				"path", wt.Path)
+			return PathResult{Branch: candidate, Path: wt.Path}, nil
+		}
+	}
+	return PathResult{Branch: branch}, fmt.Errorf("branch %q is not checked out in any worktree", branch)
+}
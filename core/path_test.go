@@ -0,0 +1,92 @@
+package core
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/708u/twig/internal/testutil"
+)
+
+func TestPathCommand(t *testing.T) {
+	t.Parallel()
+
+	worktrees := []testutil.MockWorktree{
+		{Path: "/repo/main", Branch: "main"},
+		{Path: "/repo/main-worktree/login", Branch: "users/me/login"},
+		{Path: "/repo/main-worktree/fix", Branch: "fix"},
+	}
+
+	tests := []struct {
+		name        string
+		run         func(c *PathCommand) (PathResult, error)
+		wantPath    string
+		errContains string
+	}{
+		{
+			name:     "root",
+			run:      func(c *PathCommand) (PathResult, error) { return c.Root(t.Context()) },
+			wantPath: "/repo/main",
+		},
+		{
+			name:     "dest",
+			run:      func(c *PathCommand) (PathResult, error) { return c.Dest() },
+			wantPath: "/repo/main-worktree",
+		},
+		{
+			name: "branch as given",
+			run: func(c *PathCommand) (PathResult, error) {
+				return c.Run(t.Context(), "fix", PathOptions{})
+			},
+			wantPath: "/repo/main-worktree/fix",
+		},
+		{
+			name: "resolves branch_prefix",
+			run: func(c *PathCommand) (PathResult, error) {
+				return c.Run(t.Context(), "login", PathOptions{})
+			},
+			wantPath: "/repo/main-worktree/login",
+		},
+		{
+			name: "no prefix",
+			run: func(c *PathCommand) (PathResult, error) {
+				return c.Run(t.Context(), "login", PathOptions{NoPrefix: true})
+			},
+			errContains: `branch "login" is not checked out in any worktree`,
+		},
+		{
+			name: "not found",
+			run: func(c *PathCommand) (PathResult, error) {
+				return c.Run(t.Context(), "feat", PathOptions{})
+			},
+			errContains: `branch "users/me/feat" is not checked out in any worktree`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockGit := &testutil.MockGitExecutor{Worktrees: worktrees}
+			git := &GitRunner{Executor: mockGit, Dir: "/repo/main-worktree/fix", Log: NewNopLogger()}
+			cfg := &Config{
+				WorktreeSourceDir:   "/repo/main-worktree/fix",
+				WorktreeDestBaseDir: "/repo/main-worktree",
+				BranchPrefix:        "users/me/",
+			}
+
+			result, err := tt.run(NewPathCommand(git, cfg, nil))
+			if tt.errContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+					t.Fatalf("error = %v, want to contain %q", err, tt.errContains)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := result.Format(FormatOptions{}).Stdout; got != tt.wantPath+"\n" {
+				t.Errorf("Format() = %q, want %q", got, tt.wantPath+"\n")
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DefaultPRBranchTemplate names the branches created by twig add --pr.
+const DefaultPRBranchTemplate = "pr/{number}-{title}"
+
+// prTitleMaxLen caps the length of the {title} slug in branch names.
+const prTitleMaxLen = 40
+
+// PullRequest is a pull request (merge request on GitLab) checked out by
+// twig add --pr.
+type PullRequest struct {
+	Number int
+	Title  string // Empty when no forge is configured or the lookup failed
+	Head   string // Source branch of the PR (empty if unknown)
+	Commit string // Fetched head commit
+}
+
+// prHeadRef returns the ref the forge publishes the head of PR number
+// under. GitHub's layout is assumed when no forge is configured.
+func prHeadRef(forge string, number int) string {
+	if forge == ForgeGitLab {
+		return fmt.Sprintf("merge-requests/%d/head", number)
+	}
+	return fmt.Sprintf("pull/%d/head", number)
+}
+
+// expandPRBranch substitutes {number}, {title} (as a slug) and {head} in
+// template. Separators left dangling by empty placeholders are dropped,
+// so "pr/{number}-{title}" yields "pr/1234" without a title.
+func expandPRBranch(template string, pr PullRequest) string {
+	name := strings.NewReplacer(
+		"{number}", strconv.Itoa(pr.Number),
+		"{title}", slugify(pr.Title, prTitleMaxLen),
+		"{head}", pr.Head,
+	).Replace(template)
+	for _, sep := range []string{"--", "-/", "/-", "//"} {
+		for strings.Contains(name, sep) {
+			name = strings.ReplaceAll(name, sep, sep[1:])
+		}
+	}
+	return strings.Trim(name, "-/")
+}
+
+// slugify lowercases s and replaces each run of characters other than
+// ASCII letters and digits with a single "-", keeping at most maxLen
+// characters and cutting at a "-" where possible.
+func slugify(s string, maxLen int) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(s) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+			continue
+		}
+		dash = true
+	}
+	slug := b.String()
+	if len(slug) > maxLen {
+		slug = slug[:maxLen]
+		if i := strings.LastIndexByte(slug, '-'); i > 0 {
+			slug = slug[:i]
+		}
+	}
+	return strings.Trim(slug, "-")
+}
+
+// resolvePR fetches the head of PR number from the forge remote and looks
+// up its title when a forge is configured. A failed title lookup is only
+// logged: the branch is then named without it.
+func (c *AddCommand) resolvePR(ctx context.Context, number int) (PullRequest, error) {
+	pr := PullRequest{Number: number}
+	var forge string
+	if c.Config != nil {
+		forge = c.Config.Forge
+	}
+
+	ref := prHeadRef(forge, number)
+	if err := c.Git.Fetch(ctx, forgeRemote, ref); err != nil {
+		return pr, fmt.Errorf("failed to fetch %s from %s: %w", ref, forgeRemote, err)
+	}
+	commit, err := c.Git.ResolveCommit(ctx, "FETCH_HEAD")
+	if err != nil {
+		return pr, err
+	}
+	pr.Commit = commit
+
+	if c.Forge != nil {
+		title, head, err := c.Forge.PullRequest(ctx, number)
+		if err != nil {
+			c.Log.DebugContext(ctx, "PR lookup failed",
+				LogAttrKeyCategory.String(), LogCategoryForge,
+				"number", number,
+				"error", err.Error())
+		}
+		pr.Title, pr.Head = title, head
+	}
+	return pr, nil
+}
+
+// branchDiffersFrom reports whether branch exists locally at a commit
+// other than commit. Existing branches are checked out as is, so the PR
+// head would not be checked out.
+func (c *AddCommand) branchDiffersFrom(ctx context.Context, branch, commit string) (bool, error) {
+	exists, err := c.Git.LocalBranchExists(ctx, branch)
+	if err != nil {
+		return false, fmt.Errorf("failed to check branch existence: %w", err)
+	}
+	if !exists {
+		return false, nil
+	}
+	head, err := c.Git.ResolveCommit(ctx, branch)
+	if err != nil {
+		return false, err
+	}
+	return head != commit, nil
+}
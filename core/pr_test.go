@@ -0,0 +1,223 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/708u/twig/internal/testutil"
+)
+
+func TestExpandPRBranch(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		template string
+		pr       PullRequest
+		want     string
+	}{
+		{
+			name:     "default",
+			template: DefaultPRBranchTemplate,
+			pr:       PullRequest{Number: 1234, Title: "Fix login redirect (again!)"},
+			want:     "pr/1234-fix-login-redirect-again",
+		},
+		{
+			name:     "without_title",
+			template: DefaultPRBranchTemplate,
+			pr:       PullRequest{Number: 1234},
+			want:     "pr/1234",
+		},
+		{
+			name:     "long_title_cut_at_word",
+			template: DefaultPRBranchTemplate,
+			pr:       PullRequest{Number: 7, Title: "Refactor the configuration loader to support profiles and env overrides"},
+			want:     "pr/7-refactor-the-configuration-loader-to",
+		},
+		{
+			name:     "head",
+			template: "review/{head}",
+			pr:       PullRequest{Number: 7, Head: "feat/login"},
+			want:     "review/feat/login",
+		},
+		{
+			name:     "empty_head_drops_separator",
+			template: "review/{head}/{number}",
+			pr:       PullRequest{Number: 7},
+			want:     "review/7",
+		},
+		{
+			name:     "non_ascii_title",
+			template: "{number}-{title}",
+			pr:       PullRequest{Number: 7, Title: "日本語"},
+			want:     "7",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := expandPRBranch(tt.template, tt.pr); got != tt.want {
+				t.Errorf("expandPRBranch(%q) = %q, want %q", tt.template, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddCommand_Run_PR(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		arg          string
+		config       *Config
+		forge        bool
+		existing     string // existing local branch at commit "old"
+		fetchErr     bool
+		wantErr      string
+		wantFetch    []string
+		wantCommand  []string // worktree add command (after -C <dir>)
+		wantOutdated bool
+	}{
+		{
+			name:        "without_forge",
+			wantFetch:   []string{"fetch", "origin", "pull/1234/head"},
+			wantCommand: []string{"worktree", "add", "--no-checkout", "-b", "pr/1234", "/repo/main-worktree/pr/1234", "abc1234def"},
+		},
+		{
+			name:        "title_from_forge",
+			forge:       true,
+			wantFetch:   []string{"fetch", "origin", "pull/1234/head"},
+			wantCommand: []string{"worktree", "add", "--no-checkout", "-b", "pr/1234-fix-login", "/repo/main-worktree/pr/1234-fix-login", "abc1234def"},
+		},
+		{
+			name:        "gitlab_ref",
+			config:      &Config{WorktreeSourceDir: "/repo/main", WorktreeDestBaseDir: "/repo/main-worktree", Forge: ForgeGitLab, PRBranchTemplate: "mr/{number}"},
+			wantFetch:   []string{"fetch", "origin", "merge-requests/1234/head"},
+			wantCommand: []string{"worktree", "add", "--no-checkout", "-b", "mr/1234", "/repo/main-worktree/mr/1234", "abc1234def"},
+		},
+		{
+			name:        "name_replaces_template",
+			arg:         "review",
+			config:      &Config{WorktreeSourceDir: "/repo/main", WorktreeDestBaseDir: "/repo/main-worktree", BranchPrefix: "me/"},
+			wantFetch:   []string{"fetch", "origin", "pull/1234/head"},
+			wantCommand: []string{"worktree", "add", "--no-checkout", "-b", "me/review", "/repo/main-worktree/review", "abc1234def"},
+		},
+		{
+			name:         "existing_branch_outdated",
+			existing:     "pr/1234",
+			wantFetch:    []string{"fetch", "origin", "pull/1234/head"},
+			wantCommand:  []string{"worktree", "add", "--no-checkout", "/repo/main-worktree/pr/1234", "pr/1234"},
+			wantOutdated: true,
+		},
+		{
+			name:     "fetch_fails",
+			fetchErr: true,
+			wantErr:  "failed to fetch pull/1234/head from origin",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			inner := &testutil.MockGitExecutor{
+				BranchHEADs: map[string]string{"FETCH_HEAD": "abc1234def"},
+				RemoteURLs:  map[string]string{"origin": "git@github.com:708u/twig.git"},
+				Worktrees:   []testutil.MockWorktree{{Path: "/repo/main", Branch: "main"}},
+			}
+			if tt.existing != "" {
+				inner.ExistingBranches = []string{tt.existing}
+				inner.BranchHEADs[tt.existing] = "old"
+			}
+			if tt.fetchErr {
+				inner.FetchErrs = map[string]error{"pull/1234/head": errors.New("couldn't find remote ref")}
+			}
+			var fetches, commands [][]string
+			mockGit := &testutil.MockGitExecutor{
+				RunFunc: func(ctx context.Context, args ...string) ([]byte, error) {
+					cmdArgs := args[2:] // strip -C <dir>
+					switch cmdArgs[0] {
+					case "fetch":
+						fetches = append(fetches, cmdArgs)
+					case "worktree":
+						if cmdArgs[1] == "add" {
+							commands = append(commands, cmdArgs)
+						}
+					}
+					return inner.Run(ctx, args...)
+				},
+			}
+
+			cfg := tt.config
+			if cfg == nil {
+				cfg = &Config{WorktreeSourceDir: "/repo/main", WorktreeDestBaseDir: "/repo/main-worktree"}
+			}
+			git := &GitRunner{Executor: mockGit, Dir: "/repo/main", Log: NewNopLogger()}
+			cmd := NewAddCommand(&testutil.MockFS{}, git, cfg, nil, AddOptions{PR: 1234})
+			if tt.forge {
+				srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					if r.URL.Path != "/repos/708u/twig/pulls/1234" {
+						t.Errorf("path = %q, want /repos/708u/twig/pulls/1234", r.URL.Path)
+					}
+					w.Write([]byte(`{"title": "Fix: login", "head": {"ref": "feat/login"}}`))
+				}))
+				t.Cleanup(srv.Close)
+				cmd.Forge = NewForgeClient(&testutil.MockFS{}, git, ForgeGitHub, "", nil)
+				cmd.Forge.BaseURL = srv.URL
+			}
+
+			result, err := cmd.Run(t.Context(), tt.arg)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want to contain %q", err, tt.wantErr)
+				}
+				if len(commands) != 0 {
+					t.Errorf("no worktree expected on error, got %q", commands)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(fetches) == 0 || !slices.Equal(fetches[0], tt.wantFetch) {
+				t.Errorf("fetches = %q, want first %q", fetches, tt.wantFetch)
+			}
+			if len(commands) != 1 || !slices.Equal(commands[0], tt.wantCommand) {
+				t.Errorf("commands = %q, want %q", commands, tt.wantCommand)
+			}
+			if result.PR == nil || result.PR.Number != 1234 || result.PR.Commit != "abc1234def" {
+				t.Errorf("PR = %+v, want #1234 at abc1234def", result.PR)
+			}
+			if result.PROutdated != tt.wantOutdated {
+				t.Errorf("PROutdated = %v, want %v", result.PROutdated, tt.wantOutdated)
+			}
+		})
+	}
+}
+
+func TestAddResult_Format_PR(t *testing.T) {
+	t.Parallel()
+
+	result := AddResult{
+		Branch:       "pr/1234-fix-login",
+		WorktreePath: "/repo/main-worktree/pr/1234-fix-login",
+		PR:           &PullRequest{Number: 1234, Commit: "abc1234def"},
+		PROutdated:   true,
+	}
+
+	got := result.Format(AddFormatOptions{})
+	if want := "twig add: pr/1234-fix-login (0 symlinks, PR #1234)\n"; got.Stdout != want {
+		t.Errorf("Stdout = %q, want %q", got.Stdout, want)
+	}
+	if want := "warning: branch pr/1234-fix-login already exists and was checked out as is; the head of #1234 is abc1234\n"; got.Stderr != want {
+		t.Errorf("Stderr = %q, want %q", got.Stderr, want)
+	}
+}
@@ -0,0 +1,137 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+	"text/tabwriter"
+)
+
+// Profile overrides a subset of settings for a named workflow
+// (e.g. a lightweight "review" profile). Profiles are defined under
+// [profiles.<name>] and selected with --profile.
+// Unset fields keep the value from the merged project and local config.
+type Profile struct {
+	Symlinks            *[]string `toml:"symlinks" doc:"Replaces symlinks and extra_symlinks"`     // nil=unset; replaces symlinks and extra_symlinks
+	ExtraSymlinks       []string  `toml:"extra_symlinks" doc:"Appended to the resulting symlinks"` // Appended to the resulting symlinks
+	WorktreeDestBaseDir string    `toml:"worktree_destination_base_dir" doc:"Base directory where new worktrees are created"`
+	DefaultSource       string    `toml:"default_source" doc:"Default branch to use as source when creating new worktrees"`
+	InitSubmodules      *bool     `toml:"init_submodules" doc:"Initialize submodules when creating worktrees"`                         // nil=unset
+	SubmoduleReference  *bool     `toml:"submodule_reference" doc:"Reuse objects from the main worktree when initializing submodules"` // nil=unset
+	Hooks               *[]string `toml:"hooks" doc:"Commands to run after worktree creation; an empty list disables hooks"`           // nil=unset; empty list disables hooks
+}
+
+// OverriddenKeys returns the config keys set by the profile, in config file order.
+func (p Profile) OverriddenKeys() []string {
+	var keys []string
+	if p.WorktreeDestBaseDir != "" {
+		keys = append(keys, "worktree_destination_base_dir")
+	}
+	if p.DefaultSource != "" {
+		keys = append(keys, "default_source")
+	}
+	if p.Symlinks != nil {
+		keys = append(keys, "symlinks")
+	}
+	if len(p.ExtraSymlinks) > 0 {
+		keys = append(keys, "extra_symlinks")
+	}
+	if p.InitSubmodules != nil {
+		keys = append(keys, "init_submodules")
+	}
+	if p.SubmoduleReference != nil {
+		keys = append(keys, "submodule_reference")
+	}
+	if p.Hooks != nil {
+		keys = append(keys, "hooks")
+	}
+	return keys
+}
+
+// mergeProfile merges a profile from a later config file over an earlier one.
+// Fields set in override win; extra_symlinks are collected from both.
+func mergeProfile(base, override Profile) Profile {
+	merged := base
+	if override.Symlinks != nil {
+		merged.Symlinks = override.Symlinks
+	}
+	for _, s := range override.ExtraSymlinks {
+		if !slices.Contains(merged.ExtraSymlinks, s) {
+			merged.ExtraSymlinks = append(merged.ExtraSymlinks, s)
+		}
+	}
+	if override.WorktreeDestBaseDir != "" {
+		merged.WorktreeDestBaseDir = override.WorktreeDestBaseDir
+	}
+	if override.DefaultSource != "" {
+		merged.DefaultSource = override.DefaultSource
+	}
+	if override.InitSubmodules != nil {
+		merged.InitSubmodules = override.InitSubmodules
+	}
+	if override.SubmoduleReference != nil {
+		merged.SubmoduleReference = override.SubmoduleReference
+	}
+	if override.Hooks != nil {
+		merged.Hooks = override.Hooks
+	}
+	return merged
+}
+
+// ProfileSummary describes one configured profile.
+type ProfileSummary struct {
+	Name      string
+	Active    bool
+	Overrides []string // Config keys set by the profile
+}
+
+// ProfilesResult holds the profiles available in the loaded config.
+type ProfilesResult struct {
+	Profiles []ProfileSummary
+}
+
+// ListProfiles returns the configured profiles sorted by name.
+func ListProfiles(cfg *Config) ProfilesResult {
+	var result ProfilesResult
+	for name, p := range cfg.Profiles {
+		result.Profiles = append(result.Profiles, ProfileSummary{
+			Name:      name,
+			Active:    name == cfg.Profile,
+			Overrides: p.OverriddenKeys(),
+		})
+	}
+	slices.SortFunc(result.Profiles, func(a, b ProfileSummary) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return result
+}
+
+// Format formats the ProfilesResult for display.
+// The active profile is marked with "*".
+func (r ProfilesResult) Format(opts FormatOptions) FormatResult {
+	if len(r.Profiles) == 0 {
+		return FormatResult{Stdout: "no profiles configured\n"}
+	}
+
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	for _, p := range r.Profiles {
+		marker := " "
+		if p.Active {
+			marker = "*"
+		}
+		overrides := "(no overrides)"
+		if len(p.Overrides) > 0 {
+			overrides = strings.Join(p.Overrides, ", ")
+		}
+		if opts.Verbose {
+			fmt.Fprintf(w, "%s %s\t%s\n", marker, p.Name, overrides)
+		} else {
+			fmt.Fprintf(w, "%s %s\n", marker, p.Name)
+		}
+	}
+	w.Flush()
+
+	return FormatResult{Stdout: buf.String()}
+}
@@ -0,0 +1,144 @@
+package core
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestProfile_OverriddenKeys(t *testing.T) {
+	t.Parallel()
+
+	empty := []string{}
+	enable := true
+
+	tests := []struct {
+		name    string
+		profile Profile
+		want    []string
+	}{
+		{
+			name:    "no overrides",
+			profile: Profile{},
+			want:    nil,
+		},
+		{
+			name: "empty lists count as overrides",
+			profile: Profile{
+				Symlinks: &empty,
+				Hooks:    &empty,
+			},
+			want: []string{"symlinks", "hooks"},
+		},
+		{
+			name: "all keys",
+			profile: Profile{
+				Symlinks:            &empty,
+				ExtraSymlinks:       []string{".claude"},
+				WorktreeDestBaseDir: "../review",
+				DefaultSource:       "main",
+				InitSubmodules:      &enable,
+				SubmoduleReference:  &enable,
+				Hooks:               &empty,
+			},
+			want: []string{
+				"worktree_destination_base_dir", "default_source", "symlinks",
+				"extra_symlinks", "init_submodules", "submodule_reference", "hooks",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := tt.profile.OverriddenKeys(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("OverriddenKeys() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeProfile(t *testing.T) {
+	t.Parallel()
+
+	projectHooks := []string{"npm install"}
+	localHooks := []string{"yarn install"}
+	disable := false
+
+	base := Profile{
+		ExtraSymlinks:       []string{".envrc"},
+		WorktreeDestBaseDir: "../review",
+		Hooks:               &projectHooks,
+	}
+	override := Profile{
+		ExtraSymlinks:  []string{".envrc", ".claude"},
+		InitSubmodules: &disable,
+		Hooks:          &localHooks,
+	}
+
+	got := mergeProfile(base, override)
+
+	if !reflect.DeepEqual(got.ExtraSymlinks, []string{".envrc", ".claude"}) {
+		t.Errorf("ExtraSymlinks = %v, want collected from both", got.ExtraSymlinks)
+	}
+	if got.WorktreeDestBaseDir != "../review" {
+		t.Errorf("WorktreeDestBaseDir = %q, want base value", got.WorktreeDestBaseDir)
+	}
+	if got.InitSubmodules == nil || *got.InitSubmodules {
+		t.Errorf("InitSubmodules = %v, want false from override", got.InitSubmodules)
+	}
+	if got.Hooks == nil || !reflect.DeepEqual(*got.Hooks, localHooks) {
+		t.Errorf("Hooks = %v, want %v", got.Hooks, localHooks)
+	}
+	if len(base.ExtraSymlinks) != 1 {
+		t.Errorf("base ExtraSymlinks modified: %v", base.ExtraSymlinks)
+	}
+}
+
+func TestProfilesResult_Format(t *testing.T) {
+	t.Parallel()
+
+	empty := []string{}
+	cfg := &Config{
+		Profile: "review",
+		Profiles: map[string]Profile{
+			"review": {Symlinks: &empty, Hooks: &empty},
+			"dev":    {},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		cfg        *Config
+		opts       FormatOptions
+		wantStdout string
+	}{
+		{
+			name:       "no profiles",
+			cfg:        &Config{},
+			wantStdout: "no profiles configured\n",
+		},
+		{
+			name:       "names sorted with active marker",
+			cfg:        cfg,
+			wantStdout: "  dev\n* review\n",
+		},
+		{
+			name:       "verbose shows overrides",
+			cfg:        cfg,
+			opts:       FormatOptions{Verbose: true},
+			wantStdout: "  dev     (no overrides)\n* review  symlinks, hooks\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := ListProfiles(tt.cfg).Format(tt.opts)
+			if got.Stdout != tt.wantStdout {
+				t.Errorf("Stdout = %q, want %q", got.Stdout, tt.wantStdout)
+			}
+		})
+	}
+}
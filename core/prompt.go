@@ -0,0 +1,330 @@
+package core
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// promptCacheFileName is stored under <git-common-dir>/twig.
+	promptCacheFileName = "prompt-cache.json"
+
+	// DefaultPromptCacheTTL bounds how long a cleanable count is reused
+	// when no worktree changed (e.g. to notice upstream branches deleted remotely).
+	DefaultPromptCacheTTL = 5 * time.Minute
+)
+
+// promptCacheEntry caches the cleanable count for one current worktree.
+// The entry is invalidated when any worktree's branch or HEAD changes.
+type promptCacheEntry struct {
+	Signature  string    `json:"signature"`
+	Cleanable  int       `json:"cleanable"`
+	ComputedAt time.Time `json:"computed_at"`
+}
+
+// PromptInfo holds the data shown in a shell prompt segment.
+type PromptInfo struct {
+	Branch    string // Current branch, or short HEAD when detached
+	Worktrees int    // Number of worktrees including the main worktree
+	Cleanable int    // Worktrees twig clean would remove from here
+	Dirty     bool   // Current worktree has uncommitted changes (only when requested)
+	InRepo    bool   // false outside a git repository
+}
+
+// promptDirtyMarker is appended to the branch when the worktree is dirty.
+const promptDirtyMarker = "*"
+
+// PromptFormatOptions configures prompt info output.
+type PromptFormatOptions struct {
+	Compact bool // Omit the worktree count (twig prompt)
+}
+
+// Format formats the PromptInfo as a single-line prompt segment.
+// Outside a repository the segment is empty.
+func (p PromptInfo) Format(opts PromptFormatOptions) FormatResult {
+	if !p.InRepo {
+		return FormatResult{}
+	}
+	var sb strings.Builder
+	sb.WriteString(p.Branch)
+	if p.Dirty {
+		sb.WriteString(promptDirtyMarker)
+	}
+
+	var badges []string
+	if !opts.Compact {
+		badges = append(badges, fmt.Sprintf("%d wt", p.Worktrees))
+	}
+	if p.Cleanable > 0 {
+		badges = append(badges, fmt.Sprintf("%d cleanable", p.Cleanable))
+	}
+	if len(badges) > 0 {
+		fmt.Fprintf(&sb, " [%s]", strings.Join(badges, ", "))
+	}
+	sb.WriteString("\n")
+	return FormatResult{Stdout: sb.String()}
+}
+
+// PromptInfoOptions configures prompt info collection.
+type PromptInfoOptions struct {
+	Refresh bool // Ignore the cached cleanable count
+	Dirty   bool // Check the current worktree for uncommitted changes
+}
+
+// PromptInfoCommand collects prompt info. The cleanable count requires
+// merge checks for every worktree, so it is cached per repository.
+type PromptInfoCommand struct {
+	FS     FileSystem
+	Git    *GitRunner
+	Config *Config
+	Log    *slog.Logger
+	TTL    time.Duration // Cache lifetime (0 = DefaultPromptCacheTTL)
+}
+
+// NewPromptInfoCommand creates a PromptInfoCommand with explicit dependencies.
+func NewPromptInfoCommand(fs FileSystem, git *GitRunner, cfg *Config, log *slog.Logger) *PromptInfoCommand {
+	if log == nil {
+		log = NewNopLogger()
+	}
+	return &PromptInfoCommand{
+		FS:     fs,
+		Git:    git,
+		Config: cfg,
+		Log:    log,
+	}
+}
+
+// NewDefaultPromptInfoCommand creates a PromptInfoCommand with production defaults.
+func NewDefaultPromptInfoCommand(cfg *Config, log *slog.Logger, gitOpts ...GitRunnerOption) *PromptInfoCommand {
+	git := newDefaultGitRunner(cfg.WorktreeSourceDir, log, gitOpts)
+	return NewPromptInfoCommand(git.defaultFS(), git, cfg, log)
+}
+
+// Run collects prompt info for cwd. Outside a git repository it returns
+// an empty PromptInfo without error so prompts stay quiet.
+func (c *PromptInfoCommand) Run(ctx context.Context, cwd string, opts PromptInfoOptions) (PromptInfo, error) {
+	var info PromptInfo
+
+	worktrees, err := c.Git.WorktreeList(ctx)
+	if err != nil {
+		c.Log.DebugContext(ctx, "not in a git repository",
+			LogAttrKeyCategory.String(), LogCategoryPrompt,
+			"error", err.Error())
+		return info, nil
+	}
+	info.InRepo = true
+	info.Worktrees = len(worktrees)
+
+	current := currentWorktree(worktrees, cwd)
+	if current != nil {
+		info.Branch = current.Branch
+		if current.Detached || info.Branch == "" {
+			info.Branch = current.ShortHEAD()
+		}
+	}
+
+	// The dirty state changes without touching any worktree metadata,
+	// so it is checked on every run rather than cached.
+	if opts.Dirty && current != nil && !current.Bare {
+		dirty, err := c.Git.InDir(current.Path).HasChanges(ctx)
+		if err != nil {
+			c.Log.DebugContext(ctx, "failed to check worktree status",
+				LogAttrKeyCategory.String(), LogCategoryPrompt,
+				"error", err.Error())
+		}
+		info.Dirty = dirty
+	}
+
+	ttl := c.TTL
+	if ttl == 0 {
+		ttl = DefaultPromptCacheTTL
+	}
+	now := time.Now()
+
+	var key string
+	if current != nil {
+		key = current.Path
+	}
+	signature := worktreeSignature(worktrees)
+
+	cachePath, cache := c.loadCache(ctx)
+	if entry, ok := cache[key]; ok && !opts.Refresh &&
+		entry.Signature == signature && now.Sub(entry.ComputedAt) < ttl {
+		info.Cleanable = entry.Cleanable
+		return info, nil
+	}
+
+	clean := NewCleanCommand(c.FS, c.Git, c.Config, c.Log)
+	result, err := clean.Run(ctx, cwd, CleanOptions{Check: true})
+	if err != nil {
+		// Keep the prompt usable; the count is only informational
+		c.Log.DebugContext(ctx, "failed to count cleanable worktrees",
+			LogAttrKeyCategory.String(), LogCategoryPrompt,
+			"error", err.Error())
+		return info, nil
+	}
+	info.Cleanable = result.CleanableCount()
+
+	if cachePath != "" {
+		cache[key] = promptCacheEntry{Signature: signature, Cleanable: info.Cleanable, ComputedAt: now}
+		c.saveCache(ctx, cachePath, cache)
+	}
+	return info, nil
+}
+
+// loadCache reads the prompt cache. Any failure yields an empty cache
+// and an empty path when the cache location cannot be resolved.
+func (c *PromptInfoCommand) loadCache(ctx context.Context) (string, map[string]promptCacheEntry) {
+	cache := make(map[string]promptCacheEntry)
+
+	commonDir, err := c.Git.GitCommonDir(ctx)
+	if err != nil || commonDir == "" {
+		return "", cache
+	}
+	cachePath := filepath.Join(commonDir, auditDirName, promptCacheFileName)
+
+	data, err := c.FS.ReadFile(cachePath)
+	if err != nil {
+		return cachePath, cache
+	}
+	if err := json.Unmarshal(data, &cache); err != nil {
+		c.Log.DebugContext(ctx, "ignoring corrupt prompt cache",
+			LogAttrKeyCategory.String(), LogCategoryPrompt,
+			"error", err.Error())
+		return cachePath, make(map[string]promptCacheEntry)
+	}
+	return cachePath, cache
+}
+
+// saveCache writes the prompt cache. Failures are logged only.
+func (c *PromptInfoCommand) saveCache(ctx context.Context, cachePath string, cache map[string]promptCacheEntry) {
+	data, err := json.Marshal(cache)
+	if err == nil {
+		err = c.FS.MkdirAll(filepath.Dir(cachePath), 0755)
+	}
+	if err == nil {
+		err = c.FS.WriteFile(cachePath, data, 0644)
+	}
+	if err != nil {
+		c.Log.DebugContext(ctx, "failed to write prompt cache",
+			LogAttrKeyCategory.String(), LogCategoryPrompt,
+			"error", err.Error())
+	}
+}
+
+// currentWorktree returns the worktree containing cwd (deepest match), or nil.
+func currentWorktree(worktrees []Worktree, cwd string) *Worktree {
+	var found *Worktree
+	for i := range worktrees {
+		wt := &worktrees[i]
+		if cwd != wt.Path && !strings.HasPrefix(cwd, wt.Path+string(filepath.Separator)) {
+			continue
+		}
+		if found == nil || len(wt.Path) > len(found.Path) {
+			found = wt
+		}
+	}
+	return found
+}
+
+// worktreeSignature identifies the worktree state relevant to clean checks.
+func worktreeSignature(worktrees []Worktree) string {
+	h := sha256.New()
+	for _, wt := range worktrees {
+		fmt.Fprintf(h, "%s\x00%s\x00%s\x00%t\x00%t\n", wt.Path, wt.Branch, wt.HEAD, wt.Locked, wt.Prunable)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// PromptShell identifies a shell supported by prompt-segment.
+type PromptShell string
+
+const (
+	PromptShellZsh  PromptShell = "zsh"
+	PromptShellFish PromptShell = "fish"
+)
+
+// PromptSegmentScript returns a script for shell that keeps a prompt
+// segment updated asynchronously via twig prompt-info.
+func PromptSegmentScript(shell PromptShell) (string, error) {
+	switch shell {
+	case PromptShellZsh:
+		return zshPromptSegmentScript, nil
+	case PromptShellFish:
+		return fishPromptSegmentScript, nil
+	default:
+		return "", fmt.Errorf("unsupported shell %q (use %q or %q)", shell, PromptShellZsh, PromptShellFish)
+	}
+}
+
+// zshPromptSegmentScript runs prompt-info in the background on each prompt
+// and redraws when the result arrives. Use ${_twig_prompt_segment} in PROMPT/RPROMPT.
+const zshPromptSegmentScript = `# twig prompt segment for zsh
+# Add to ~/.zshrc:
+#   eval "$(twig prompt-segment --shell zsh)"
+#   RPROMPT='${_twig_prompt_segment}'
+
+typeset -g _twig_prompt_segment=""
+typeset -g _twig_prompt_fd=0
+
+_twig_prompt_done() {
+  local fd=$1 out=""
+  IFS= read -r -u $fd out
+  zle -F $fd
+  exec {fd}<&-
+  _twig_prompt_fd=0
+  if [[ "$out" != "$_twig_prompt_segment" ]]; then
+    _twig_prompt_segment=$out
+    zle && zle reset-prompt
+  fi
+}
+
+_twig_prompt_precmd() {
+  if (( _twig_prompt_fd )); then
+    zle -F $_twig_prompt_fd 2>/dev/null
+    exec {_twig_prompt_fd}<&-
+    _twig_prompt_fd=0
+  fi
+  exec {_twig_prompt_fd}< <(command twig prompt-info 2>/dev/null)
+  zle -F $_twig_prompt_fd _twig_prompt_done
+}
+
+setopt prompt_subst
+autoload -Uz add-zsh-hook
+add-zsh-hook precmd _twig_prompt_precmd
+`
+
+// fishPromptSegmentScript runs prompt-info in a background fish process that
+// stores the result in a per-session universal variable, then repaints.
+// Call twig_prompt_segment from fish_prompt or fish_right_prompt.
+const fishPromptSegmentScript = `# twig prompt segment for fish
+# Add to ~/.config/fish/config.fish:
+#   twig prompt-segment --shell fish | source
+# and call twig_prompt_segment from fish_right_prompt (or fish_prompt).
+
+set -g _twig_prompt_var _twig_prompt_$fish_pid
+
+function _twig_prompt_update --on-event fish_prompt
+    command fish --private --command "set -U $_twig_prompt_var (command twig prompt-info 2>/dev/null)" &
+    builtin disown
+end
+
+function _twig_prompt_repaint --on-variable $_twig_prompt_var
+    commandline --function repaint
+end
+
+function _twig_prompt_cleanup --on-event fish_exit
+    set -eU $_twig_prompt_var
+end
+
+function twig_prompt_segment
+    echo -n $$_twig_prompt_var
+end
+`
@@ -0,0 +1,111 @@
+//go:build integration
+
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/708u/twig/internal/testutil"
+)
+
+func TestPromptInfoCommand_Integration(t *testing.T) {
+	t.Parallel()
+
+	t.Run("CountsCleanableWorktrees", func(t *testing.T) {
+		t.Parallel()
+
+		repoDir, mainDir := testutil.SetupTestRepo(t)
+
+		mergedPath := filepath.Join(repoDir, "feature", "merged")
+		testutil.RunGit(t, mainDir, "worktree", "add", "-b", "feature/merged", mergedPath)
+		testutil.RunGit(t, mergedPath, "commit", "--allow-empty", "-m", "merged work")
+		testutil.RunGit(t, mainDir, "merge", "--no-ff", "-m", "Merge feature/merged", "feature/merged")
+
+		activePath := filepath.Join(repoDir, "feature", "active")
+		testutil.RunGit(t, mainDir, "worktree", "add", "-b", "feature/active", activePath)
+		testutil.RunGit(t, activePath, "commit", "--allow-empty", "-m", "active work")
+
+		cfgResult, err := LoadConfig(mainDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cmd := NewPromptInfoCommand(osFS{}, NewGitRunner(mainDir), cfgResult.Config, nil)
+
+		info, err := cmd.Run(t.Context(), filepath.Join(activePath, "sub"), PromptInfoOptions{})
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		if got := info.Format(PromptFormatOptions{}).Stdout; got != "feature/active [3 wt, 1 cleanable]\n" {
+			t.Errorf("Stdout = %q, want %q", got, "feature/active [3 wt, 1 cleanable]\n")
+		}
+
+		cachePath := filepath.Join(mainDir, ".git", "twig", "prompt-cache.json")
+		if _, err := os.Stat(cachePath); err != nil {
+			t.Errorf("prompt cache not written: %v", err)
+		}
+
+		// Removing the merged worktree changes the signature, so the count is recalculated
+		testutil.RunGit(t, mainDir, "worktree", "remove", mergedPath)
+		info, err = cmd.Run(t.Context(), activePath, PromptInfoOptions{})
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		if got := info.Format(PromptFormatOptions{}).Stdout; got != "feature/active [2 wt]\n" {
+			t.Errorf("Stdout = %q, want %q", got, "feature/active [2 wt]\n")
+		}
+	})
+
+	t.Run("CompactWithDirtyMarker", func(t *testing.T) {
+		t.Parallel()
+
+		repoDir, mainDir := testutil.SetupTestRepo(t)
+
+		activePath := filepath.Join(repoDir, "feature", "dirty")
+		testutil.RunGit(t, mainDir, "worktree", "add", "-b", "feature/dirty", activePath)
+
+		cfgResult, err := LoadConfig(mainDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cmd := NewPromptInfoCommand(osFS{}, NewGitRunner(mainDir), cfgResult.Config, nil)
+		opts := PromptInfoOptions{Dirty: true}
+		compact := PromptFormatOptions{Compact: true}
+
+		info, err := cmd.Run(t.Context(), activePath, opts)
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		if got := info.Format(compact).Stdout; got != "feature/dirty\n" {
+			t.Errorf("Stdout = %q, want %q", got, "feature/dirty\n")
+		}
+
+		// The dirty marker is not cached, so it appears without a worktree change
+		if err := os.WriteFile(filepath.Join(activePath, "wip.txt"), []byte("wip"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		info, err = cmd.Run(t.Context(), activePath, opts)
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		if got := info.Format(compact).Stdout; got != "feature/dirty*\n" {
+			t.Errorf("Stdout = %q, want %q", got, "feature/dirty*\n")
+		}
+	})
+
+	t.Run("OutsideRepository", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		cmd := NewPromptInfoCommand(osFS{}, NewGitRunner(dir), &Config{}, nil)
+
+		info, err := cmd.Run(t.Context(), dir, PromptInfoOptions{})
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		if info.InRepo {
+			t.Error("InRepo = true, want false")
+		}
+	})
+}
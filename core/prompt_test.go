@@ -0,0 +1,366 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/708u/twig/internal/testutil"
+)
+
+func TestPromptInfoCommand_Run(t *testing.T) {
+	t.Parallel()
+
+	const cachePath = "/repo/main/.git/twig/prompt-cache.json"
+
+	mockWorktrees := []testutil.MockWorktree{
+		{Path: "/repo/main", Branch: "main", HEAD: "0000000000"},
+		{Path: "/repo/feat/a", Branch: "feat/a", HEAD: "abc1234567"},
+		{Path: "/repo/feat/b", Branch: "feat/b", HEAD: "def5678901"},
+	}
+	var worktrees []Worktree
+	for _, wt := range mockWorktrees {
+		worktrees = append(worktrees, Worktree{Path: wt.Path, Branch: wt.Branch, HEAD: wt.HEAD})
+	}
+	signature := worktreeSignature(worktrees)
+
+	tests := []struct {
+		name          string
+		cwd           string
+		cache         map[string]promptCacheEntry
+		refresh       bool
+		wantBranch    string
+		wantCleanable int
+		wantCacheKey  string
+	}{
+		{
+			name:          "computes cleanable count without cache",
+			cwd:           "/repo/main/sub",
+			wantBranch:    "main",
+			wantCleanable: 1,
+			wantCacheKey:  "/repo/main",
+		},
+		{
+			name: "uses fresh cache entry",
+			cwd:  "/repo/main",
+			cache: map[string]promptCacheEntry{
+				"/repo/main": {Signature: signature, Cleanable: 7, ComputedAt: time.Now()},
+			},
+			wantBranch:    "main",
+			wantCleanable: 7,
+			wantCacheKey:  "/repo/main",
+		},
+		{
+			name: "ignores cache when worktrees changed",
+			cwd:  "/repo/main",
+			cache: map[string]promptCacheEntry{
+				"/repo/main": {Signature: "old", Cleanable: 7, ComputedAt: time.Now()},
+			},
+			wantBranch:    "main",
+			wantCleanable: 1,
+			wantCacheKey:  "/repo/main",
+		},
+		{
+			name: "ignores expired cache entry",
+			cwd:  "/repo/main",
+			cache: map[string]promptCacheEntry{
+				"/repo/main": {Signature: signature, Cleanable: 7, ComputedAt: time.Now().Add(-DefaultPromptCacheTTL)},
+			},
+			wantBranch:    "main",
+			wantCleanable: 1,
+			wantCacheKey:  "/repo/main",
+		},
+		{
+			name: "refresh ignores cache",
+			cwd:  "/repo/main",
+			cache: map[string]promptCacheEntry{
+				"/repo/main": {Signature: signature, Cleanable: 7, ComputedAt: time.Now()},
+			},
+			refresh:       true,
+			wantBranch:    "main",
+			wantCleanable: 1,
+			wantCacheKey:  "/repo/main",
+		},
+		{
+			name:          "current worktree is not counted as cleanable",
+			cwd:           "/repo/feat/a",
+			wantBranch:    "feat/a",
+			wantCleanable: 0,
+			wantCacheKey:  "/repo/feat/a",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			written := map[string][]byte{}
+			if tt.cache != nil {
+				data, err := json.Marshal(tt.cache)
+				if err != nil {
+					t.Fatal(err)
+				}
+				written[cachePath] = data
+			}
+			mockFS := &testutil.MockFS{WrittenFiles: written}
+			mockGit := &testutil.MockGitExecutor{
+				Worktrees: mockWorktrees,
+				MergedBranches: map[string][]string{
+					"main": {"main", "feat/a"},
+				},
+				GitCommonDir: "/repo/main/.git",
+			}
+			git := &GitRunner{Executor: mockGit, Dir: "/repo/main", Log: NewNopLogger()}
+			cfg := &Config{WorktreeSourceDir: "/repo/main", DefaultSource: "main"}
+
+			info, err := NewPromptInfoCommand(mockFS, git, cfg, nil).Run(t.Context(), tt.cwd, PromptInfoOptions{Refresh: tt.refresh})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !info.InRepo {
+				t.Error("InRepo = false, want true")
+			}
+			if info.Branch != tt.wantBranch {
+				t.Errorf("Branch = %q, want %q", info.Branch, tt.wantBranch)
+			}
+			if info.Worktrees != 3 {
+				t.Errorf("Worktrees = %d, want 3", info.Worktrees)
+			}
+			if info.Cleanable != tt.wantCleanable {
+				t.Errorf("Cleanable = %d, want %d", info.Cleanable, tt.wantCleanable)
+			}
+
+			var saved map[string]promptCacheEntry
+			if err := json.Unmarshal(written[cachePath], &saved); err != nil {
+				t.Fatalf("cache not written: %v", err)
+			}
+			entry := saved[tt.wantCacheKey]
+			if entry.Signature != signature || entry.Cleanable != tt.wantCleanable {
+				t.Errorf("cache[%q] = %+v, want signature %q and cleanable %d", tt.wantCacheKey, entry, signature, tt.wantCleanable)
+			}
+		})
+	}
+}
+
+func TestPromptInfoCommand_Run_Dirty(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		cwd       string
+		dirty     bool
+		status    map[string]string
+		wantDirty bool
+	}{
+		{
+			name:      "dirty worktree",
+			cwd:       "/repo/feat/a/sub",
+			dirty:     true,
+			status:    map[string]string{"/repo/feat/a": " M main.go\n"},
+			wantDirty: true,
+		},
+		{
+			name:   "clean worktree",
+			cwd:    "/repo/feat/a",
+			dirty:  true,
+			status: map[string]string{"/repo/main": " M main.go\n"},
+		},
+		{
+			name:   "not requested",
+			cwd:    "/repo/feat/a",
+			status: map[string]string{"/repo/feat/a": " M main.go\n"},
+		},
+		{
+			name:  "outside any worktree",
+			cwd:   "/elsewhere",
+			dirty: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockGit := &testutil.MockGitExecutor{
+				Worktrees: []testutil.MockWorktree{
+					{Path: "/repo/main", Branch: "main", HEAD: "0000000000"},
+					{Path: "/repo/feat/a", Branch: "feat/a", HEAD: "abc1234567"},
+				},
+				StatusOutputMap: tt.status,
+				GitCommonDir:    "/repo/main/.git",
+			}
+			git := &GitRunner{Executor: mockGit, Dir: "/repo/main", Log: NewNopLogger()}
+			cfg := &Config{WorktreeSourceDir: "/repo/main", DefaultSource: "main"}
+
+			info, err := NewPromptInfoCommand(&testutil.MockFS{WrittenFiles: map[string][]byte{}}, git, cfg, nil).
+				Run(t.Context(), tt.cwd, PromptInfoOptions{Dirty: tt.dirty})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if info.Dirty != tt.wantDirty {
+				t.Errorf("Dirty = %v, want %v", info.Dirty, tt.wantDirty)
+			}
+		})
+	}
+}
+
+func TestPromptInfoCommand_Run_OutsideRepo(t *testing.T) {
+	t.Parallel()
+
+	mockGit := &testutil.MockGitExecutor{
+		RunFunc: func(ctx context.Context, args ...string) ([]byte, error) {
+			return nil, errors.New("fatal: not a git repository")
+		},
+	}
+	git := &GitRunner{Executor: mockGit, Dir: "/tmp", Log: NewNopLogger()}
+
+	info, err := NewPromptInfoCommand(&testutil.MockFS{}, git, &Config{}, nil).Run(t.Context(), "/tmp", PromptInfoOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if info.InRepo {
+		t.Error("InRepo = true, want false")
+	}
+	if got := info.Format(PromptFormatOptions{}).Stdout; got != "" {
+		t.Errorf("Stdout = %q, want empty", got)
+	}
+}
+
+func TestPromptInfo_Format(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		info    PromptInfo
+		compact bool
+		want    string
+	}{
+		{
+			name: "outside repository",
+			want: "",
+		},
+		{
+			name: "nothing cleanable",
+			info: PromptInfo{InRepo: true, Branch: "main", Worktrees: 3},
+			want: "main [3 wt]\n",
+		},
+		{
+			name: "cleanable badge",
+			info: PromptInfo{InRepo: true, Branch: "feat/a", Worktrees: 3, Cleanable: 2},
+			want: "feat/a [3 wt, 2 cleanable]\n",
+		},
+		{
+			name: "dirty marker",
+			info: PromptInfo{InRepo: true, Branch: "feat/a", Worktrees: 3, Dirty: true},
+			want: "feat/a* [3 wt]\n",
+		},
+		{
+			name:    "compact",
+			info:    PromptInfo{InRepo: true, Branch: "feat/a", Worktrees: 3, Cleanable: 2, Dirty: true},
+			compact: true,
+			want:    "feat/a* [2 cleanable]\n",
+		},
+		{
+			name:    "compact nothing cleanable",
+			info:    PromptInfo{InRepo: true, Branch: "main", Worktrees: 3},
+			compact: true,
+			want:    "main\n",
+		},
+		{
+			name:    "compact outside repository",
+			compact: true,
+			want:    "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := tt.info.Format(PromptFormatOptions{Compact: tt.compact}).Stdout; got != tt.want {
+				t.Errorf("Stdout = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCurrentWorktree(t *testing.T) {
+	t.Parallel()
+
+	worktrees := []Worktree{
+		{Path: "/repo/main", Branch: "main"},
+		{Path: "/repo/main/.worktrees/nested", Branch: "nested"},
+		{Path: "/repo/feat", Branch: "feat"},
+	}
+
+	tests := []struct {
+		cwd  string
+		want string // empty = no match
+	}{
+		{cwd: "/repo/main", want: "main"},
+		{cwd: "/repo/main/src", want: "main"},
+		{cwd: "/repo/main/.worktrees/nested/src", want: "nested"},
+		{cwd: "/repo/feature", want: ""},
+		{cwd: "/elsewhere", want: ""},
+	}
+
+	for _, tt := range tests {
+		got := currentWorktree(worktrees, tt.cwd)
+		var branch string
+		if got != nil {
+			branch = got.Branch
+		}
+		if branch != tt.want {
+			t.Errorf("currentWorktree(%q) = %q, want %q", tt.cwd, branch, tt.want)
+		}
+	}
+}
+
+func TestPromptSegmentScript(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		shell        PromptShell
+		wantContains []string
+		wantErr      bool
+	}{
+		{
+			shell:        PromptShellZsh,
+			wantContains: []string{"twig prompt-info", "zle -F", "add-zsh-hook precmd", "_twig_prompt_segment"},
+		},
+		{
+			shell:        PromptShellFish,
+			wantContains: []string{"twig prompt-info", "--on-event fish_prompt", "commandline --function repaint", "function twig_prompt_segment"},
+		},
+		{
+			shell:   "bash",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.shell), func(t *testing.T) {
+			t.Parallel()
+
+			script, err := PromptSegmentScript(tt.shell)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, want := range tt.wantContains {
+				if !strings.Contains(script, want) {
+					t.Errorf("script does not contain %q", want)
+				}
+			}
+		})
+	}
+}
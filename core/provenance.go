@@ -0,0 +1,105 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"time"
+)
+
+// provenanceFileName is the marker written into the administrative
+// directory of worktrees created or adopted by twig
+// (.git/worktrees/<id>/twig.json). It goes away with the worktree.
+const provenanceFileName = "twig.json"
+
+// Commands recorded in Provenance.Command.
+const (
+	ProvenanceCommandAdd   = "add"
+	ProvenanceCommandAdopt = "adopt"
+)
+
+// Provenance records how twig created (or adopted) a worktree, so that
+// worktrees made with plain git worktree add can be told apart.
+type Provenance struct {
+	CreatedAt time.Time `json:"created_at"`
+	Command   string    `json:"command"`           // ProvenanceCommandAdd or ProvenanceCommandAdopt
+	Source    string    `json:"source,omitempty"`  // Branch or ref the worktree was created from
+	Version   string    `json:"version,omitempty"` // twig version
+	Flags     []string  `json:"flags,omitempty"`   // Command-line flags given
+}
+
+// Summary returns the short form shown by twig list -l.
+func (p Provenance) Summary() string {
+	s := "twig"
+	if p.Command == ProvenanceCommandAdopt {
+		s += " (adopted)"
+	}
+	if p.Source != "" {
+		s += " from " + p.Source
+	}
+	return s
+}
+
+// ProvenanceStore reads and writes the provenance markers of worktrees.
+// The main worktree has no administrative directory, so it never has one.
+type ProvenanceStore struct {
+	FS  FileSystem
+	Git *GitRunner
+}
+
+// NewProvenanceStore creates a ProvenanceStore with explicit dependencies.
+func NewProvenanceStore(fs FileSystem, git *GitRunner) *ProvenanceStore {
+	return &ProvenanceStore{FS: fs, Git: git}
+}
+
+// Load returns the provenance of every linked worktree that has a marker,
+// by worktree path. Unreadable markers are skipped.
+func (s *ProvenanceStore) Load(ctx context.Context) (map[string]Provenance, error) {
+	dirs, err := loadWorktreeAdminDirs(ctx, s.FS, s.Git)
+	if err != nil {
+		return nil, err
+	}
+	markers := make(map[string]Provenance)
+	for wtPath, adminDir := range dirs {
+		if p, ok := readProvenance(s.FS, adminDir); ok {
+			markers[wtPath] = p
+		}
+	}
+	return markers, nil
+}
+
+// readProvenance reads the marker in the administrative directory
+// adminDir, reporting false when there is none or it is unreadable.
+func readProvenance(fs FileSystem, adminDir string) (Provenance, bool) {
+	data, err := fs.ReadFile(filepath.Join(adminDir, provenanceFileName))
+	if err != nil {
+		return Provenance{}, false
+	}
+	var p Provenance
+	if err := json.Unmarshal(data, &p); err != nil {
+		return Provenance{}, false
+	}
+	return p, true
+}
+
+// Write records p as the provenance of the linked worktree at wtPath,
+// replacing an existing marker.
+func (s *ProvenanceStore) Write(ctx context.Context, wtPath string, p Provenance) error {
+	dirs, err := loadWorktreeAdminDirs(ctx, s.FS, s.Git)
+	if err != nil {
+		return err
+	}
+	adminDir, ok := dirs[filepath.Clean(wtPath)]
+	if !ok {
+		return fmt.Errorf("failed to record provenance: no worktree entry for %s", wtPath)
+	}
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to record provenance: %w", err)
+	}
+	if err := s.FS.WriteFile(filepath.Join(adminDir, provenanceFileName), append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to record provenance: %w", err)
+	}
+	return nil
+}
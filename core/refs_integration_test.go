@@ -0,0 +1,39 @@
+//go:build integration
+
+package core
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/708u/twig/internal/testutil"
+)
+
+func TestRefReader_Integration(t *testing.T) {
+	t.Parallel()
+
+	t.Run("MatchesGitBranchList", func(t *testing.T) {
+		t.Parallel()
+
+		_, mainDir := testutil.SetupTestRepo(t)
+		testutil.RunGit(t, mainDir, "branch", "feat/packed")
+		testutil.RunGit(t, mainDir, "branch", "feat/deleted")
+		testutil.RunGit(t, mainDir, "pack-refs", "--all")
+		testutil.RunGit(t, mainDir, "branch", "feat/loose")
+		testutil.RunGit(t, mainDir, "branch", "-D", "feat/deleted")
+
+		git := NewGitRunner(mainDir)
+		want, err := git.BranchList(t.Context())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := NewDefaultRefReader(git).BranchList(t.Context())
+		if err != nil {
+			t.Fatalf("BranchList failed: %v", err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("BranchList() = %v, want %v (git branch)", got, want)
+		}
+	})
+}
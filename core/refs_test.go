@@ -0,0 +1,97 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/708u/twig/internal/testutil"
+)
+
+func TestRefReader_BranchList(t *testing.T) {
+	t.Parallel()
+
+	const oid = "0123456789abcdef0123456789abcdef01234567"
+
+	tests := []struct {
+		name         string
+		packedRefs   string
+		looseRefs    []string // Paths under the common dir
+		reftable     bool
+		want         []string
+		wantFallback bool
+	}{
+		{
+			name: "packed and loose refs",
+			packedRefs: "# pack-refs with: peeled fully-peeled sorted\n" +
+				oid + " refs/heads/main\n" +
+				oid + " refs/heads/feat/a\n" +
+				oid + " refs/remotes/origin/main\n" +
+				oid + " refs/tags/v1.0\n" +
+				"^" + oid + "\n",
+			looseRefs: []string{"refs/heads/main", "refs/heads/feat/b", "refs/heads/users/me/x"},
+			want:      []string{"feat/a", "feat/b", "main", "users/me/x"},
+		},
+		{
+			name:      "loose refs only",
+			looseRefs: []string{"refs/heads/main"},
+			want:      []string{"main"},
+		},
+		{
+			name:      "skips refs being updated",
+			looseRefs: []string{"refs/heads/main", "refs/heads/feat.lock"},
+			want:      []string{"main"},
+		},
+		{
+			name:         "reftable falls back to git",
+			reftable:     true,
+			wantFallback: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			commonDir := t.TempDir()
+			if tt.packedRefs != "" {
+				if err := os.WriteFile(filepath.Join(commonDir, packedRefsFile), []byte(tt.packedRefs), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for _, ref := range tt.looseRefs {
+				p := filepath.Join(commonDir, filepath.FromSlash(ref))
+				if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(p, []byte(oid+"\n"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.reftable {
+				if err := os.MkdirAll(filepath.Join(commonDir, reftableDir), 0755); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			var captured []string
+			mockGit := &testutil.MockGitExecutor{GitCommonDir: commonDir, CapturedArgs: &captured}
+			git := &GitRunner{Executor: mockGit, Dir: "/repo/main", Log: NewNopLogger()}
+
+			got, err := NewRefReader(osFS{}, git).BranchList(t.Context())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			fallback := strings.Contains(strings.Join(captured, " "), "branch --format=%(refname:short)")
+			if fallback != tt.wantFallback {
+				t.Errorf("fell back to git = %v, want %v", fallback, tt.wantFallback)
+			}
+			if !tt.wantFallback && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("BranchList() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,1226 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/708u/twig/format"
+)
+
+// SkipReason describes why a worktree was skipped.
+type SkipReason string
+
+const (
+	SkipNotMerged       SkipReason = "not merged"
+	SkipSameCommit      SkipReason = "same commit"
+	SkipHasChanges      SkipReason = "has uncommitted changes"
+	SkipLocked          SkipReason = "locked"
+	SkipCurrentDir      SkipReason = "current directory"
+	SkipDetached        SkipReason = "detached HEAD"
+	SkipDirtySubmodule  SkipReason = "submodule has uncommitted changes"
+	SkipProtected       SkipReason = "protected branch"
+	SkipVerifyFailed    SkipReason = "verify-failed"
+	SkipUnpushedCommits SkipReason = "unpushed-commits"
+	SkipNotManaged      SkipReason = "not-managed"
+	SkipExcluded        SkipReason = "excluded"
+)
+
+// skipReasonCodes are the fixed machine codes of skip reasons, printed by
+// clean --porcelain in place of the prose.
+var skipReasonCodes = map[SkipReason]string{
+	SkipNotMerged:       "not_merged",
+	SkipSameCommit:      "same_commit",
+	SkipHasChanges:      "has_changes",
+	SkipLocked:          "locked",
+	SkipCurrentDir:      "current_directory",
+	SkipDetached:        "detached",
+	SkipDirtySubmodule:  "dirty_submodule",
+	SkipProtected:       "protected",
+	SkipVerifyFailed:    "verify_failed",
+	SkipUnpushedCommits: "unpushed_commits",
+	SkipNotManaged:      "not_managed",
+	SkipExcluded:        "excluded",
+}
+
+// Code returns the machine code of the reason (e.g. "not_merged").
+func (r SkipReason) Code() string {
+	return skipReasonCodes[r]
+}
+
+// SkipError represents an error when a worktree cannot be removed due to a skip condition.
+type SkipError struct {
+	Reason SkipReason
+}
+
+func (e *SkipError) Error() string {
+	return fmt.Sprintf("cannot remove: %s", e.Reason)
+}
+
+// Format returns the display string for a SkipReason.
+// For SkipSameCommit, it appends the target branch name.
+func (r SkipReason) Format(target string) string {
+	if r == SkipSameCommit && target != "" {
+		return fmt.Sprintf("same commit as %s", target)
+	}
+	return string(r)
+}
+
+// CleanReason describes why a branch is cleanable.
+type CleanReason string
+
+const (
+	CleanMerged       CleanReason = "merged"
+	CleanUpstreamGone CleanReason = "upstream gone"
+	CleanSquashMerged CleanReason = "squash merged"
+	CleanPRMerged     CleanReason = "pr merged"
+	CleanPRClosed     CleanReason = "pr closed"
+	CleanDetached     CleanReason = "detached"
+)
+
+// cleanReasonCodes are the fixed machine codes of clean reasons, printed
+// by clean --porcelain in place of the prose.
+var cleanReasonCodes = map[CleanReason]string{
+	CleanMerged:       "merged",
+	CleanUpstreamGone: "upstream_gone",
+	CleanSquashMerged: "squash_merged",
+	CleanPRMerged:     "pr_merged",
+	CleanPRClosed:     "pr_closed",
+	CleanDetached:     "detached",
+}
+
+// Code returns the machine code of the reason (e.g. "upstream_gone").
+func (r CleanReason) Code() string {
+	return cleanReasonCodes[r]
+}
+
+// IsPR reports whether the reason comes from the forge PR state.
+func (r CleanReason) IsPR() bool {
+	return r == CleanPRMerged || r == CleanPRClosed
+}
+
+// CheckResult holds the result of checking whether a worktree can be removed.
+type CheckResult struct {
+	CanRemove    bool         // Whether the worktree can be removed
+	SkipReason   SkipReason   // Reason if cannot be removed
+	CleanReason  CleanReason  // Reason if can be removed (for clean command display)
+	Prunable     bool         // Whether worktree is prunable (directory was deleted externally)
+	WorktreePath string       // Path to the worktree
+	Branch       string       // Branch name
+	HEAD         string       // Commit the branch points to
+	ChangedFiles []FileStatus // Uncommitted changes (for verbose output)
+}
+
+// CheckOptions configures the check operation.
+type CheckOptions struct {
+	Force        WorktreeForceLevel // Force level to apply
+	Target       string             // Target branch for merged check (empty = skip merged check)
+	Cwd          string             // Current directory for cwd check
+	AllowCwd     bool               // Allow removing the worktree containing Cwd
+	WorktreeInfo *Worktree          // Pre-fetched worktree info (skips WorktreeFindByBranch if set)
+	MergeStatus  BranchMergeStatus  // Pre-fetched branch merge status (skips IsBranchMerged if set)
+}
+
+// RemoveCommand removes git worktrees with their associated branches.
+type RemoveCommand struct {
+	FS     FileSystem
+	Git    *GitRunner
+	Config *Config
+	Log    *slog.Logger
+	Audit  *AuditLog // Records removals (nil = disabled)
+
+	squashErrs *squashErrors // Failed squash merge checks (nil = not kept)
+}
+
+// squashErrors collects the failed squash merge checks of a RemoveCommand
+// by branch, shared with its copies from inDir. Checks run in parallel
+// for clean, so it is safe for concurrent use.
+type squashErrors struct {
+	mu   sync.Mutex
+	errs map[string]error
+}
+
+// add keeps err as the failure of branch unless one is kept already.
+func (s *squashErrors) add(branch string, err error) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.errs == nil {
+		s.errs = make(map[string]error)
+	}
+	if _, ok := s.errs[branch]; !ok {
+		s.errs[branch] = err
+	}
+}
+
+// all returns the kept failures by branch.
+func (s *squashErrors) all() map[string]error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return maps.Clone(s.errs)
+}
+
+// RemoveOptions configures the remove operation.
+type RemoveOptions struct {
+	// Force specifies the force level.
+	// Matches git worktree behavior: -f for unclean, -f -f for locked.
+	Force WorktreeForceLevel
+	Check bool // Show what would be removed without making changes
+	// Target is the branch used for squash-merge detection when deleting
+	// the branch (empty = skip). Only effective with detect_squash_merges.
+	Target string
+	// ForceDeleteBranch deletes the branch with -D even without --force.
+	// Set by clean when the forge reports the branch's PR as merged or closed.
+	ForceDeleteBranch bool
+	// KeepEmptyDirs leaves parent directories in place even when the
+	// removal empties them (--keep-empty-dirs, cleanup_empty_dirs = false).
+	KeepEmptyDirs bool
+	// Archive saves uncommitted changes and untracked files to a tarball
+	// before removing the worktree (--archive). The removal is aborted if
+	// the archive cannot be written.
+	Archive bool
+	// ArchiveDir overrides archive_dir for this removal (--archive=<dir>).
+	ArchiveDir string
+	// DeinitSubmodules runs git submodule deinit -f in the worktree and
+	// removes the module git directories of its submodules before the
+	// worktree itself (--deinit-submodules).
+	DeinitSubmodules bool
+	// ForceCwd allows removing the worktree containing cwd (--force-cwd).
+	// The shell is left in a deleted directory, so ReturnDir is set to
+	// the main worktree for a cd hint.
+	ForceCwd bool
+}
+
+// NewRemoveCommand creates a RemoveCommand with explicit dependencies.
+func NewRemoveCommand(fs FileSystem, git *GitRunner, cfg *Config, log *slog.Logger) *RemoveCommand {
+	if log == nil {
+		log = NewNopLogger()
+	}
+	return &RemoveCommand{
+		FS:     fs,
+		Git:    git,
+		Config: cfg,
+		Log:    log,
+	}
+}
+
+// NewDefaultRemoveCommand creates a RemoveCommand with production defaults.
+// Removals are recorded in the audit log.
+func NewDefaultRemoveCommand(cfg *Config, log *slog.Logger, gitOpts ...GitRunnerOption) *RemoveCommand {
+	git := newDefaultGitRunner(cfg.WorktreeSourceDir, log, gitOpts)
+	fs := git.defaultFS()
+	cmd := NewRemoveCommand(fs, git, cfg, log)
+	cmd.Audit = NewAuditLog(fs, git)
+	return cmd
+}
+
+// RemovedWorktree holds the result of a single worktree removal.
+type RemovedWorktree struct {
+	Branch       string
+	WorktreePath string
+	CleanedDirs  []string     // Empty parent directories that were removed
+	KeptDirs     []string     // Empty parent directories left in place (KeepEmptyDirs)
+	ArchivePath  string       // Archive of uncommitted changes (--archive)
+	ReturnDir    string       // Where to cd after the current directory was removed (--force-cwd)
+	Pruned       bool         // Stale worktree record was pruned (directory was already deleted)
+	Check        bool         // --check mode: show what would be removed
+	CanRemove    bool         // Whether the worktree can be removed (from Check)
+	SkipReason   SkipReason   // Reason if cannot be removed (from Check)
+	ChangedFiles []FileStatus // Uncommitted changes (for verbose output)
+	Submodules   []string     // Initialized submodules, relative to the worktree
+	Modules      []ModuleDir  // Module git directories of Submodules in the worktree's git directory
+	Deinited     bool         // Submodules were deinitialized and Modules removed (DeinitSubmodules)
+	GitOutput    []byte
+	SizeBytes    int64 // Disk usage measured before removal (clean only; 0 if prunable)
+	AuditErr     error // Failure to record the removal in the audit log
+	Err          error // nil if success
+}
+
+// ModuleDir is the git directory of a submodule, stored under the git
+// directory of the worktree (.git/worktrees/<id>/modules/<name>).
+type ModuleDir struct {
+	Path      string
+	SizeBytes int64
+}
+
+// RemoveResult aggregates results from remove operations.
+type RemoveResult struct {
+	Removed []RemovedWorktree
+}
+
+// HasErrors returns true if any errors occurred.
+func (r RemoveResult) HasErrors() bool {
+	for i := range r.Removed {
+		if r.Removed[i].Err != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// ErrorCount returns the number of failed removals.
+func (r RemoveResult) ErrorCount() int {
+	count := 0
+	for i := range r.Removed {
+		if r.Removed[i].Err != nil {
+			count++
+		}
+	}
+	return count
+}
+
+// Warnings returns the warnings of all successful removals.
+func (r RemoveResult) Warnings() []Warning {
+	var warnings []Warning
+	for i := range r.Removed {
+		if r.Removed[i].Err == nil {
+			warnings = append(warnings, r.Removed[i].Warnings()...)
+		}
+	}
+	return warnings
+}
+
+// Format formats the RemoveResult for display.
+func (r RemoveResult) Format(opts FormatOptions) FormatResult {
+	var stdout, stderr strings.Builder
+
+	for i := range r.Removed {
+		wt := &r.Removed[i]
+		if wt.Err != nil {
+			formatRemoveError(&stderr, wt.Branch, wt.Err, opts, wt.ChangedFiles)
+			continue
+		}
+		formatted := wt.Format(opts)
+		stdout.WriteString(formatted.Stdout)
+		stderr.WriteString(formatted.Stderr)
+	}
+
+	return FormatResult{Stdout: stdout.String(), Stderr: stderr.String()}
+}
+
+// formatRemoveError formats an error from the remove operation.
+// It shows a short error message, and optionally the detailed git error.
+func formatRemoveError(w *strings.Builder, branch string, err error, opts FormatOptions, changedFiles []FileStatus) {
+	var skipErr *SkipError
+	var gitErr *GitError
+
+	// Format error message
+	prefix := format.Paint(opts.ColorEnabled, format.ColorError, "error:")
+	switch {
+	case errors.As(err, &gitErr):
+		fmt.Fprintf(w, "%s %s: failed to %s\n", prefix, branch, gitErr.Op)
+		if opts.Verbose && gitErr.Stderr != "" {
+			fmt.Fprintf(w, "       git: %s\n", gitErr.Stderr)
+		}
+	default:
+		fmt.Fprintf(w, "%s %s: %v\n", prefix, branch, err)
+	}
+
+	// Show changed files in verbose mode for SkipHasChanges
+	if opts.Verbose && len(changedFiles) > 0 {
+		if errors.As(err, &skipErr) && skipErr.Reason == SkipHasChanges {
+			fmt.Fprintf(w, "Uncommitted changes:\n")
+			for _, f := range changedFiles {
+				fmt.Fprintf(w, "  %s %s\n", f.Status, f.Path)
+			}
+		}
+	}
+
+	// Format hint based on error type
+	var hint string
+	switch {
+	case errors.As(err, &skipErr):
+		switch skipErr.Reason {
+		case SkipHasChanges, SkipNotMerged, SkipDirtySubmodule:
+			hint = "use 'twig remove --force' to force removal"
+		case SkipLocked:
+			hint = "run 'git worktree unlock <path>' first, or use 'twig remove -f -f'"
+		case SkipProtected:
+			hint = "branch matches protected_branches in .twig/settings.toml"
+		case SkipCurrentDir:
+			hint = "cd out of the worktree first, or use 'twig remove --force-cwd'"
+		}
+	case errors.As(err, &gitErr):
+		switch gitErr.Code() {
+		case GitErrorDirtyWorktree:
+			hint = "use 'twig remove --force' to force removal"
+		case GitErrorLockedWorktree:
+			hint = "run 'git worktree unlock <path>' first, or use 'twig remove -f -f'"
+		default:
+			hint = gitErrorHint(err)
+		}
+	}
+	if hint != "" {
+		fmt.Fprintf(w, "hint: %s\n", hint)
+	}
+}
+
+// Warnings returns the warnings of the removal.
+func (r RemovedWorktree) Warnings() []Warning {
+	if r.AuditErr == nil {
+		return nil
+	}
+	return []Warning{{Code: WarningAuditFailed, Subject: worktreeIdentifier(r.Branch, r.WorktreePath), Message: r.AuditErr.Error()}}
+}
+
+// Format formats the RemovedWorktree for display.
+func (r RemovedWorktree) Format(opts FormatOptions) FormatResult {
+	var stdout strings.Builder
+
+	if opts.Quiet {
+		return r.formatQuiet()
+	}
+
+	if r.Check {
+		if r.Pruned {
+			fmt.Fprintf(&stdout, "Would prune stale worktree record\n")
+		} else if r.WorktreePath != "" {
+			fmt.Fprintf(&stdout, "Would remove worktree: %s\n", r.WorktreePath)
+		}
+		// Show changed files in verbose check mode
+		if opts.Verbose && len(r.ChangedFiles) > 0 {
+			fmt.Fprintf(&stdout, "Uncommitted changes:\n")
+			for _, f := range r.ChangedFiles {
+				fmt.Fprintf(&stdout, "  %s %s\n", f.Status, f.Path)
+			}
+		}
+		if r.ArchivePath != "" {
+			fmt.Fprintf(&stdout, "Would archive uncommitted changes to: %s\n", r.ArchivePath)
+		}
+		if r.Deinited {
+			for _, sm := range r.Submodules {
+				fmt.Fprintf(&stdout, "Would deinit submodule: %s\n", sm)
+			}
+			for _, m := range r.Modules {
+				fmt.Fprintf(&stdout, "Would remove module storage: %s (%s)\n", m.Path, formatBytes(m.SizeBytes))
+			}
+		} else if len(r.Submodules) > 0 {
+			fmt.Fprintf(&stdout, "Contains initialized submodules: %s (use --deinit-submodules to deinit them first)\n",
+				strings.Join(r.Submodules, ", "))
+		}
+		fmt.Fprintf(&stdout, "Would delete branch: %s\n", r.Branch)
+		for _, dir := range r.CleanedDirs {
+			fmt.Fprintf(&stdout, "Would remove empty directory: %s\n", dir)
+		}
+		return FormatResult{Stdout: stdout.String()}
+	}
+
+	// The archive path is needed to recover the changes, so it is shown
+	// even without --verbose
+	if r.ArchivePath != "" {
+		fmt.Fprintf(&stdout, "Archived uncommitted changes: %s\n", r.ArchivePath)
+	}
+
+	if opts.Verbose {
+		if r.Deinited {
+			for _, sm := range r.Submodules {
+				fmt.Fprintf(&stdout, "Deinitialized submodule: %s\n", sm)
+			}
+			for _, m := range r.Modules {
+				fmt.Fprintf(&stdout, "Removed module storage: %s (%s)\n", m.Path, formatBytes(m.SizeBytes))
+			}
+		}
+		if len(r.GitOutput) > 0 {
+			stdout.Write(r.GitOutput)
+		}
+		if r.Pruned {
+			fmt.Fprintf(&stdout, "Pruned stale worktree and deleted branch: %s\n",
+				format.Paint(opts.ColorEnabled, format.ColorSuccess, r.Branch))
+		} else {
+			fmt.Fprintf(&stdout, "Removed worktree and branch: %s\n",
+				format.Paint(opts.ColorEnabled, format.ColorSuccess, r.Branch))
+		}
+		for _, dir := range r.CleanedDirs {
+			fmt.Fprintf(&stdout, "Removed empty directory: %s\n", dir)
+		}
+		for _, dir := range r.KeptDirs {
+			fmt.Fprintf(&stdout, "Kept empty directory: %s\n", dir)
+		}
+	}
+
+	var stderr strings.Builder
+	writeWarnings(&stderr, r.Warnings())
+	if r.ReturnDir != "" {
+		fmt.Fprintf(&stderr, "hint: the current directory was removed; run: cd %s\n", r.ReturnDir)
+	}
+	return FormatResult{Stdout: stdout.String(), Stderr: stderr.String()}
+}
+
+// formatQuiet outputs the branch that was (or would be) removed, or the
+// path of a detached worktree. The archive path goes to stderr so stdout
+// stays a plain list.
+func (r RemovedWorktree) formatQuiet() FormatResult {
+	var stdout, stderr strings.Builder
+	fmt.Fprintln(&stdout, worktreeIdentifier(r.Branch, r.WorktreePath))
+	if r.ArchivePath != "" && !r.Check {
+		fmt.Fprintf(&stderr, "Archived uncommitted changes: %s\n", r.ArchivePath)
+	}
+	writeWarnings(&stderr, r.Warnings())
+	if r.ReturnDir != "" {
+		fmt.Fprintf(&stderr, "hint: the current directory was removed; run: cd %s\n", r.ReturnDir)
+	}
+	return FormatResult{Stdout: stdout.String(), Stderr: stderr.String()}
+}
+
+// Run removes the worktree and branch for the given branch name, or for
+// the worktree at a path such as "." (see ResolveRemoveTarget).
+// cwd is used to prevent removal when inside the target worktree.
+func (c *RemoveCommand) Run(ctx context.Context, branch string, cwd string, opts RemoveOptions) (RemovedWorktree, error) {
+	c.Log.DebugContext(ctx, "run started",
+		"category", LogCategoryRemove,
+		"branch", branch,
+		"check", opts.Check,
+		"force", opts.Force)
+
+	var result RemovedWorktree
+	result.Branch = branch
+	result.Check = opts.Check
+
+	branch, err := c.ResolveRemoveTarget(ctx, branch, cwd)
+	if err != nil {
+		return result, err
+	}
+	result.Branch = branch
+
+	// Check removal eligibility first
+	checkResult, err := c.Check(ctx, branch, CheckOptions{
+		Force:    opts.Force,
+		Cwd:      cwd,
+		AllowCwd: opts.ForceCwd,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	// Copy check results
+	result.WorktreePath = checkResult.WorktreePath
+	result.Pruned = checkResult.Prunable
+	result.CanRemove = checkResult.CanRemove
+	result.SkipReason = checkResult.SkipReason
+	result.ChangedFiles = checkResult.ChangedFiles
+
+	c.Log.DebugContext(ctx, "check completed",
+		"category", LogCategoryRemove,
+		"canRemove", checkResult.CanRemove,
+		"branch", branch)
+
+	if !checkResult.CanRemove {
+		return result, &SkipError{Reason: checkResult.SkipReason}
+	}
+
+	// Handle prunable worktree (directory already deleted externally)
+	if checkResult.Prunable {
+		c.Log.DebugContext(ctx, "handling prunable worktree",
+			"category", LogCategoryRemove,
+			"branch", branch)
+		result, err = c.removePrunable(ctx, branch, opts, result)
+		if err == nil && !opts.Check {
+			result.AuditErr = c.recordRemoval(ctx, checkResult, opts, 0)
+		}
+		return result, err
+	}
+
+	// Check submodule status to determine effective force level.
+	// Clean submodules require auto-force for git worktree remove,
+	// but this is safe since Check() already verified no dirty submodules.
+	effectiveForce := opts.Force
+	smStatus, err := c.Git.InDir(checkResult.WorktreePath).CheckSubmoduleCleanStatus(ctx)
+	if err == nil && smStatus == SubmoduleCleanStatusClean {
+		if effectiveForce < WorktreeForceLevelUnclean {
+			effectiveForce = WorktreeForceLevelUnclean
+		}
+	}
+	if smStatus != SubmoduleCleanStatusNone {
+		result.Submodules, result.Modules, err = c.submoduleModules(ctx, checkResult.WorktreePath)
+		if err != nil {
+			return result, err
+		}
+		// Deinitialized submodules no longer keep git from removing the worktree
+		if opts.DeinitSubmodules && len(result.Submodules) > 0 {
+			result.Deinited = true
+			effectiveForce = opts.Force
+		}
+	}
+	c.Log.DebugContext(ctx, "submodule check",
+		"category", LogCategoryRemove,
+		"status", smStatus,
+		"submodules", len(result.Submodules),
+		"effectiveForce", effectiveForce,
+		"branch", branch)
+
+	if opts.Check {
+		if !opts.KeepEmptyDirs {
+			result.CleanedDirs = c.predictEmptyParentDirs(checkResult.WorktreePath)
+		}
+		if opts.Archive && len(checkResult.ChangedFiles) > 0 {
+			result.ArchivePath, err = c.archiver(opts).ArchivePath(ctx, branch, time.Now())
+			if err != nil {
+				return result, fmt.Errorf("failed to resolve archive path: %w", err)
+			}
+		}
+		return result, nil
+	}
+
+	// Git commands run in the worktree twig was started from. When that is
+	// the one being removed, continue from the main worktree instead.
+	if opts.ForceCwd {
+		if root, err := c.Git.InDir(cwd).WorktreeRoot(ctx); err == nil && root == checkResult.WorktreePath {
+			mainPath, err := c.Git.MainWorktreePath(ctx)
+			if err != nil {
+				return result, fmt.Errorf("failed to find main worktree: %w", err)
+			}
+			c = c.inDir(mainPath)
+			result.ReturnDir = mainPath
+		}
+	}
+
+	// A background git gc or maintenance would make the steps below fail
+	// part way, leaving the worktree removed but the branch in place
+	if err := c.waitForGitLocks(ctx, checkResult.WorktreePath); err != nil {
+		return result, err
+	}
+
+	// Archive before anything is removed, so a failure leaves the worktree intact
+	if opts.Archive && len(checkResult.ChangedFiles) > 0 {
+		result.ArchivePath, err = c.archiver(opts).Archive(ctx, checkResult.WorktreePath, branch)
+		if err != nil {
+			return result, fmt.Errorf("failed to archive uncommitted changes: %w", err)
+		}
+	}
+
+	if result.Deinited {
+		if err := c.deinitSubmodules(ctx, checkResult.WorktreePath); err != nil {
+			return result, err
+		}
+	}
+
+	// Measure size before removal; the directory is gone afterwards
+	var size int64
+	if c.Audit != nil {
+		size = dirSize(c.FS, checkResult.WorktreePath)
+	}
+
+	var gitOutput []byte
+	var wtOpts []WorktreeRemoveOption
+	if effectiveForce > WorktreeForceLevelNone {
+		wtOpts = append(wtOpts, WithForceRemove(effectiveForce))
+	}
+	wtOut, err := c.Git.WorktreeRemove(ctx, checkResult.WorktreePath, wtOpts...)
+	if err != nil {
+		return result, err
+	}
+	gitOutput = append(gitOutput, wtOut...)
+
+	if opts.KeepEmptyDirs {
+		result.KeptDirs = c.predictEmptyParentDirs(checkResult.WorktreePath)
+	} else {
+		result.CleanedDirs = c.cleanupEmptyParentDirs(ctx, checkResult.WorktreePath)
+	}
+	if len(result.CleanedDirs) > 0 {
+		c.Log.DebugContext(ctx, "cleaned empty dirs",
+			"category", LogCategoryRemove,
+			"count", len(result.CleanedDirs),
+			"branch", branch)
+	}
+
+	var branchOpts []BranchDeleteOption
+	if opts.Force > WorktreeForceLevelNone || opts.ForceDeleteBranch {
+		branchOpts = append(branchOpts, WithForceDelete())
+	} else {
+		// upstream gone (squash/rebase merge) requires -D since commits differ
+		// Run() reaches here only after Check() verified no uncommitted changes
+		if gone, err := c.Git.IsBranchUpstreamGone(ctx, branch); err == nil && gone {
+			c.Log.DebugContext(ctx, "upstream gone, using force delete",
+				"category", LogCategoryRemove,
+				"branch", branch)
+			branchOpts = append(branchOpts, WithForceDelete())
+		} else if c.isSquashMerged(ctx, branch, opts.Target) {
+			c.Log.DebugContext(ctx, "squash merged, using force delete",
+				"category", LogCategoryRemove,
+				"branch", branch)
+			branchOpts = append(branchOpts, WithForceDelete())
+		}
+	}
+	brOut, err := c.Git.BranchDelete(ctx, branch, branchOpts...)
+	if err != nil {
+		return result, err
+	}
+	gitOutput = append(gitOutput, brOut...)
+
+	result.GitOutput = gitOutput
+	result.AuditErr = c.recordRemoval(ctx, checkResult, opts, size)
+
+	c.Log.DebugContext(ctx, "run completed",
+		"category", LogCategoryRemove,
+		"branch", branch)
+
+	return result, nil
+}
+
+// submoduleModules returns the initialized submodules of the worktree at
+// wtPath and the module git directories holding their data. Only
+// directories inside the worktree's own git directory are returned, so
+// storage shared with other worktrees is never touched. Nested module
+// directories are covered by their parent's.
+func (c *RemoveCommand) submoduleModules(ctx context.Context, wtPath string) ([]string, []ModuleDir, error) {
+	git := c.Git.InDir(wtPath)
+	submodules, err := git.SubmoduleStatus(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	gitDir, err := git.GitDir(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to resolve git directory of %s: %w", wtPath, err)
+	}
+	modulesDir := filepath.Join(gitDir, "modules")
+
+	var paths []string
+	var modules []ModuleDir
+	for _, sm := range submodules {
+		if sm.State == SubmoduleStateUninitialized {
+			continue
+		}
+		paths = append(paths, sm.Path)
+		dir, err := git.InDir(filepath.Join(wtPath, sm.Path)).GitDir(ctx)
+		if err != nil || !isWithinDir(modulesDir, dir) {
+			continue
+		}
+		if slices.ContainsFunc(modules, func(m ModuleDir) bool { return isWithinDir(m.Path, dir) }) {
+			continue
+		}
+		modules = append(modules, ModuleDir{Path: dir, SizeBytes: dirSize(c.FS, dir)})
+	}
+	return paths, modules, nil
+}
+
+// deinitSubmodules deinitializes the submodules of the worktree at wtPath
+// and removes the modules directory of its git directory, so that neither
+// the submodule working trees nor their storage are left behind. The
+// modules directory belongs to this worktree alone.
+func (c *RemoveCommand) deinitSubmodules(ctx context.Context, wtPath string) error {
+	git := c.Git.InDir(wtPath)
+	gitDir, err := git.GitDir(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to resolve git directory of %s: %w", wtPath, err)
+	}
+	if _, err := git.SubmoduleDeinit(ctx); err != nil {
+		return err
+	}
+	// git refuses to remove a worktree while its modules directory exists
+	modulesDir := filepath.Join(gitDir, "modules")
+	if err := c.FS.RemoveAll(modulesDir); err != nil {
+		return fmt.Errorf("failed to remove module storage %s: %w", modulesDir, err)
+	}
+	c.Log.DebugContext(ctx, "removed module storage",
+		"category", LogCategoryRemove,
+		"path", modulesDir)
+	return nil
+}
+
+// inDir returns a copy of c that runs git commands in dir.
+func (c *RemoveCommand) inDir(dir string) *RemoveCommand {
+	moved := *c
+	moved.Git = c.Git.InDir(dir)
+	if c.Audit != nil {
+		audit := *c.Audit
+		audit.Git = c.Audit.Git.InDir(dir)
+		moved.Audit = &audit
+	}
+	return &moved
+}
+
+// ResolveRemoveTarget returns the branch to remove for arg. A branch name
+// is returned as is. A path (".", "..", or one starting with "/", "./" or
+// "../") is resolved against cwd to the worktree containing it, which
+// must be a linked worktree with a branch checked out.
+func (c *RemoveCommand) ResolveRemoveTarget(ctx context.Context, arg, cwd string) (string, error) {
+	if !isPathArg(arg) {
+		return arg, nil
+	}
+	path := arg
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(cwd, path)
+	}
+	path = filepath.Clean(path)
+
+	worktrees, err := c.Git.WorktreeList(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to list worktrees: %w", err)
+	}
+	// The exact path also matches worktrees whose directory was deleted
+	index := slices.IndexFunc(worktrees, func(wt Worktree) bool { return wt.Path == path })
+	if index < 0 {
+		root, err := c.Git.InDir(path).WorktreeRoot(ctx)
+		if err != nil {
+			return "", fmt.Errorf("%s is not in a worktree", arg)
+		}
+		index = slices.IndexFunc(worktrees, func(wt Worktree) bool { return wt.Path == root })
+	}
+	switch {
+	case index < 0:
+		return "", fmt.Errorf("%s is not in a worktree of this repository", arg)
+	case index == 0:
+		return "", fmt.Errorf("%s is the main worktree, which cannot be removed", arg)
+	case worktrees[index].Branch == "":
+		return "", fmt.Errorf("worktree %s has no branch (detached HEAD)", worktrees[index].Path)
+	}
+	return worktrees[index].Branch, nil
+}
+
+// isPathArg reports whether a remove argument names a worktree by path
+// rather than by branch. Relative paths need a "./" or "../" prefix, since
+// branch names such as "feat/x" look like relative paths.
+func isPathArg(arg string) bool {
+	return arg == "." || arg == ".." || filepath.IsAbs(arg) ||
+		strings.HasPrefix(arg, "./") || strings.HasPrefix(arg, "../")
+}
+
+// recordRemoval appends the removal to the audit log so that a later
+// twig add of the same branch can offer to restore it. A logging failure
+// is returned for display but does not fail the removal.
+func (c *RemoveCommand) recordRemoval(ctx context.Context, check CheckResult, opts RemoveOptions, size int64) error {
+	if c.Audit == nil {
+		return nil
+	}
+	err := c.Audit.Append(ctx, AuditEntry{
+		Time:         time.Now(),
+		Command:      AuditCommandRemove,
+		Branch:       check.Branch,
+		WorktreePath: check.WorktreePath,
+		HEAD:         check.HEAD,
+		SizeBytes:    size,
+		Force:        int(opts.Force),
+		Pruned:       check.Prunable,
+		User:         currentUser(),
+	})
+	if err != nil {
+		c.Log.DebugContext(ctx, "audit log append failed",
+			"category", LogCategoryRemove,
+			"branch", check.Branch,
+			"error", err.Error())
+	}
+	return err
+}
+
+// removePrunable handles removal of a prunable worktree (directory already deleted).
+// It prunes the stale worktree record and deletes the branch.
+func (c *RemoveCommand) removePrunable(ctx context.Context, branch string, opts RemoveOptions, result RemovedWorktree) (RemovedWorktree, error) {
+	if opts.Check {
+		return result, nil
+	}
+
+	if err := c.waitForGitLocks(ctx, result.WorktreePath); err != nil {
+		return result, err
+	}
+
+	c.Log.DebugContext(ctx, "pruning stale worktree record",
+		"category", LogCategoryRemove,
+		"branch", branch)
+
+	// Prune stale worktree records
+	if _, err := c.Git.WorktreePrune(ctx); err != nil {
+		return result, fmt.Errorf("failed to prune worktrees: %w", err)
+	}
+
+	// Delete the branch
+	var branchOpts []BranchDeleteOption
+	if opts.Force > WorktreeForceLevelNone || opts.ForceDeleteBranch {
+		branchOpts = append(branchOpts, WithForceDelete())
+	} else {
+		// upstream gone (squash/rebase merge) requires -D since commits differ
+		if gone, err := c.Git.IsBranchUpstreamGone(ctx, branch); err == nil && gone {
+			c.Log.DebugContext(ctx, "prunable: upstream gone, using force delete",
+				"category", LogCategoryRemove,
+				"branch", branch)
+			branchOpts = append(branchOpts, WithForceDelete())
+		} else if c.isSquashMerged(ctx, branch, opts.Target) {
+			c.Log.DebugContext(ctx, "prunable: squash merged, using force delete",
+				"category", LogCategoryRemove,
+				"branch", branch)
+			branchOpts = append(branchOpts, WithForceDelete())
+		}
+	}
+	brOut, err := c.Git.BranchDelete(ctx, branch, branchOpts...)
+	if err != nil {
+		result.Err = err
+		return result, err
+	}
+	result.GitOutput = brOut
+
+	c.Log.DebugContext(ctx, "run completed",
+		"category", LogCategoryRemove,
+		"branch", branch,
+		"prunable", true)
+
+	return result, nil
+}
+
+// archiver returns the WorktreeArchiver for --archive, writing to the
+// --archive=<dir> override, archive_dir or the default directory.
+func (c *RemoveCommand) archiver(opts RemoveOptions) *WorktreeArchiver {
+	dir := opts.ArchiveDir
+	if dir == "" {
+		dir = c.Config.ArchiveDir
+	}
+	return NewWorktreeArchiver(c.FS, c.Git, dir, c.Log)
+}
+
+// waitForGitLocks waits for git locks held by other git processes before
+// the worktree at wtPath and its branch are removed.
+func (c *RemoveCommand) waitForGitLocks(ctx context.Context, wtPath string) error {
+	return NewGitLockWaiter(c.FS, c.Git, c.Config.GitLockWaitDuration(), c.Log).WaitFor(ctx, wtPath)
+}
+
+// cleanupEmptyParentDirs removes empty parent directories up to WorktreeDestBaseDir.
+// Returns the list of directories that were removed. Errors are ignored since
+// cleanup failures should not fail the overall remove operation.
+func (c *RemoveCommand) cleanupEmptyParentDirs(ctx context.Context, wtPath string) []string {
+	return removeEmptyParentDirs(ctx, c.FS, c.Config.WorktreeDestBaseDir, wtPath, c.Log, LogCategoryRemove)
+}
+
+// removeEmptyParentDirs removes the empty parent directories of wtPath,
+// stopping at baseDir (exclusive) or the first non-empty directory.
+// Returns the directories that were removed.
+func removeEmptyParentDirs(ctx context.Context, fsys FileSystem, baseDir, wtPath string, log *slog.Logger, category string) []string {
+	var cleaned []string
+	if baseDir == "" {
+		return cleaned
+	}
+
+	current := filepath.Dir(wtPath)
+	for current != baseDir && strings.HasPrefix(current, baseDir) {
+		entries, err := fsys.ReadDir(current)
+		if err != nil {
+			break
+		}
+		if len(entries) > 0 {
+			break
+		}
+		if err := fsys.Remove(current); err != nil {
+			break
+		}
+		log.DebugContext(ctx, "removed empty dir",
+			"category", category,
+			"dir", current)
+		cleaned = append(cleaned, current)
+		current = filepath.Dir(current)
+	}
+
+	return cleaned
+}
+
+// predictEmptyParentDirs predicts which parent directories would become empty
+// if wtPath were removed. Used for dry-run mode.
+func (c *RemoveCommand) predictEmptyParentDirs(wtPath string) []string {
+	return emptyParentDirs(c.FS, c.Config.WorktreeDestBaseDir, wtPath)
+}
+
+// emptyParentDirs returns the parent directories of wtPath, up to baseDir
+// (exclusive), that are empty apart from wtPath. The result is the same
+// whether or not wtPath has already been removed.
+func emptyParentDirs(fsys FileSystem, baseDir, wtPath string) []string {
+	var wouldClean []string
+	if baseDir == "" {
+		return wouldClean
+	}
+
+	// Track the path being "removed" in simulation
+	removedPath := wtPath
+	current := filepath.Dir(wtPath)
+
+	for current != baseDir && strings.HasPrefix(current, baseDir) {
+		entries, err := fsys.ReadDir(current)
+		if err != nil {
+			break
+		}
+		// Check if directory would be empty after removing the simulated path
+		remaining := 0
+		for _, entry := range entries {
+			entryPath := filepath.Join(current, entry.Name())
+			if entryPath != removedPath {
+				remaining++
+			}
+		}
+		if remaining > 0 {
+			break
+		}
+		wouldClean = append(wouldClean, current)
+		removedPath = current
+		current = filepath.Dir(current)
+	}
+
+	return wouldClean
+}
+
+// Check checks whether a worktree can be removed based on the given options.
+// This method does not modify any state.
+func (c *RemoveCommand) Check(ctx context.Context, branch string, opts CheckOptions) (CheckResult, error) {
+	var result CheckResult
+	result.Branch = branch
+
+	if branch == "" {
+		return result, fmt.Errorf("branch name is required")
+	}
+	if c.Config.WorktreeSourceDir == "" {
+		return result, fmt.Errorf("worktree source directory is not configured")
+	}
+
+	// Use pre-fetched worktree info if available to avoid redundant WorktreeList calls
+	var wtInfo *Worktree
+	if opts.WorktreeInfo != nil {
+		wtInfo = opts.WorktreeInfo
+	} else {
+		var err error
+		wtInfo, err = c.Git.WorktreeFindByBranch(ctx, branch)
+		if err != nil {
+			return result, err
+		}
+	}
+	result.WorktreePath = wtInfo.Path
+	result.Prunable = wtInfo.Prunable
+	result.HEAD = wtInfo.HEAD
+
+	c.Log.DebugContext(ctx, "checking",
+		"category", LogCategoryRemove,
+		"branch", branch,
+		"path", wtInfo.Path,
+		"prunable", wtInfo.Prunable)
+
+	// Check protected branch (never bypassed, even with -ff)
+	if c.Config.IsProtectedBranch(branch) {
+		result.CanRemove = false
+		result.SkipReason = SkipProtected
+		c.Log.DebugContext(ctx, "skip",
+			"category", LogCategoryRemove,
+			"reason", SkipProtected,
+			"branch", branch)
+		return result, nil
+	}
+
+	if wtInfo.Prunable {
+		// Prunable branch: worktree directory was deleted externally
+		if reason := c.checkPrunableSkipReason(ctx, branch, opts.Target, opts.Force, opts.MergeStatus); reason != "" {
+			result.CanRemove = false
+			result.SkipReason = reason
+			c.Log.DebugContext(ctx, "skip",
+				"category", LogCategoryRemove,
+				"reason", reason,
+				"branch", branch)
+			return result, nil
+		}
+	} else {
+		// Normal worktree
+		wt := Worktree{
+			Path:     wtInfo.Path,
+			Branch:   wtInfo.Branch,
+			Locked:   wtInfo.Locked,
+			Detached: wtInfo.Detached,
+		}
+		// Get changed files for verbose output (low cost, useful for all cases)
+		changedFiles, err := c.Git.InDir(wtInfo.Path).ChangedFiles(ctx)
+		if err != nil {
+			// git status failed - return error to caller for proper handling
+			return result, fmt.Errorf("failed to check uncommitted changes: %w", err)
+		}
+		result.ChangedFiles = changedFiles
+		if reason := c.checkSkipReason(ctx, wt, opts, changedFiles); reason != "" {
+			result.CanRemove = false
+			result.SkipReason = reason
+			// Calculate CleanReason for skip candidates (except merge-related skip reasons)
+			isMergeRelated := reason == SkipNotMerged || reason == SkipSameCommit
+			if opts.Target != "" && !isMergeRelated {
+				result.CleanReason = c.getCleanReason(ctx, branch, opts.Target, opts.MergeStatus)
+				// Clear CleanMerged for WIP branches on first-parent lineage.
+				// A branch with no new commits whose HEAD is a direct ancestor
+				// of target via first-parent is WIP, not genuinely merged.
+				if result.CleanReason == CleanMerged &&
+					(reason == SkipHasChanges || reason == SkipDirtySubmodule) {
+					isWIP, fpErr := c.Git.IsFirstParentAncestor(
+						ctx, wtInfo.HEAD, opts.Target,
+					)
+					// fail-closed: on error, treat as WIP to prevent accidental deletion
+					if fpErr != nil || isWIP {
+						result.CleanReason = ""
+					}
+				}
+			}
+			c.Log.DebugContext(ctx, "skip",
+				"category", LogCategoryRemove,
+				"reason", reason,
+				"cleanReason", result.CleanReason,
+				"branch", branch)
+			return result, nil
+		}
+	}
+
+	result.CanRemove = true
+	// CleanReason requires a target branch to determine merge status
+	if opts.Target != "" {
+		result.CleanReason = c.getCleanReason(ctx, branch, opts.Target, opts.MergeStatus)
+		if result.CleanReason != "" {
+			c.Log.DebugContext(ctx, "clean reason",
+				"category", LogCategoryRemove,
+				"reason", result.CleanReason,
+				"branch", branch)
+		}
+	}
+	return result, nil
+}
+
+// checkSkipReason checks if worktree should be skipped and returns the reason.
+// force level controls which conditions can be bypassed (matches git worktree behavior).
+// changedFiles is pre-fetched to avoid redundant git status calls.
+func (c *RemoveCommand) checkSkipReason(ctx context.Context, wt Worktree, opts CheckOptions, changedFiles []FileStatus) SkipReason {
+	// Check detached HEAD (never bypassed)
+	if wt.Detached {
+		return SkipDetached
+	}
+
+	// Check current directory (only bypassed by AllowCwd, not by force)
+	// Use git rev-parse --show-toplevel to get the worktree root of cwd
+	if !opts.AllowCwd {
+		if root, err := c.Git.InDir(opts.Cwd).WorktreeRoot(ctx); err == nil && root == wt.Path {
+			return SkipCurrentDir
+		}
+	}
+
+	// Check locked
+	if wt.Locked && opts.Force < WorktreeForceLevelLocked {
+		return SkipLocked
+	}
+
+	// Check dirty submodule and uncommitted changes
+	if opts.Force < WorktreeForceLevelUnclean {
+		smStatus, err := c.Git.InDir(wt.Path).CheckSubmoduleCleanStatus(ctx)
+		if err == nil && smStatus == SubmoduleCleanStatusDirty {
+			return SkipDirtySubmodule
+		}
+
+		// Check for uncommitted changes using pre-fetched result
+		if len(changedFiles) > 0 {
+			return SkipHasChanges
+		}
+	}
+
+	// Check merged (only when target is specified)
+	if opts.Target != "" && opts.Force < WorktreeForceLevelUnclean {
+		return c.checkMergedSkipReason(ctx, wt.Branch, opts.Target, opts.MergeStatus)
+	}
+
+	return ""
+}
+
+// checkPrunableSkipReason checks if a prunable branch should be skipped.
+// Only checks merged status since worktree-specific conditions don't apply.
+// mergeStatus is pre-fetched to avoid redundant git branch --merged calls.
+func (c *RemoveCommand) checkPrunableSkipReason(ctx context.Context, branch, target string, force WorktreeForceLevel, mergeStatus BranchMergeStatus) SkipReason {
+	// Check merged (only when target is specified)
+	if target != "" && force < WorktreeForceLevelUnclean {
+		return c.checkMergedSkipReason(ctx, branch, target, mergeStatus)
+	}
+	return ""
+}
+
+// checkMergedSkipReason checks if a branch should be skipped due to merge status.
+// Returns appropriate SkipReason: empty if merged, SkipNotMerged if not merged,
+// or a dynamic "same commit as <target>" if pointing to same commit as target.
+func (c *RemoveCommand) checkMergedSkipReason(ctx context.Context, branch, target string, mergeStatus BranchMergeStatus) SkipReason {
+	// Check if we have cached results
+	hasCachedResult := len(mergeStatus.Merged) > 0 || len(mergeStatus.SameCommit) > 0
+
+	if hasCachedResult {
+		// Use cached results
+		if mergeStatus.Merged[branch] {
+			return "" // merged, can remove
+		}
+		if mergeStatus.SameCommit[branch] {
+			return SkipSameCommit
+		}
+		if c.isSquashMerged(ctx, branch, target) {
+			return ""
+		}
+		return SkipNotMerged
+	}
+
+	// Fallback: no cache available, use IsBranchMerged
+	// Note: this fallback doesn't distinguish same-commit from not-merged
+	merged, err := c.Git.IsBranchMerged(ctx, branch, target)
+	if err == nil && merged {
+		return ""
+	}
+	if c.isSquashMerged(ctx, branch, target) {
+		return ""
+	}
+	return SkipNotMerged
+}
+
+// isSquashMerged reports whether branch was squash-merged into target.
+// Returns false when detection is disabled in config or target is empty.
+// Errors are treated as not merged (fail-closed) and kept in squashErrs.
+func (c *RemoveCommand) isSquashMerged(ctx context.Context, branch, target string) bool {
+	if target == "" || c.Config == nil || !c.Config.ShouldDetectSquashMerges() {
+		return false
+	}
+	merged, err := c.Git.IsBranchSquashMerged(ctx, branch, target)
+	if err != nil {
+		c.Log.DebugContext(ctx, "squash merge check failed",
+			"category", LogCategoryRemove,
+			"branch", branch,
+			"error", err.Error())
+		c.squashErrs.add(branch, err)
+		return false
+	}
+	return merged
+}
+
+// getCleanReason determines why a branch is cleanable.
+// mergeStatus is used when it was pre-fetched (Listed is set), avoiding
+// per-branch git branch --merged and for-each-ref calls.
+func (c *RemoveCommand) getCleanReason(ctx context.Context, branch, target string, mergeStatus BranchMergeStatus) CleanReason {
+	if mergeStatus.Listed != nil {
+		switch {
+		case mergeStatus.Listed[branch]:
+			return CleanMerged
+		case mergeStatus.UpstreamGone[branch]:
+			return CleanUpstreamGone
+		case c.isSquashMerged(ctx, branch, target):
+			return CleanSquashMerged
+		}
+		return ""
+	}
+
+	// Check if branch is merged via traditional merge
+	out, err := c.Git.Run(ctx, GitCmdBranch, "--merged", target, "--format=%(refname:short)")
+	if err == nil {
+		for line := range strings.SplitSeq(strings.TrimSpace(string(out)), "\n") {
+			if line == branch {
+				return CleanMerged
+			}
+		}
+	}
+
+	// Check if upstream is gone (squash/rebase merge)
+	gone, err := c.Git.IsBranchUpstreamGone(ctx, branch)
+	if err == nil && gone {
+		return CleanUpstreamGone
+	}
+
+	// Check if branch was squash-merged (patch-id comparison, opt-in)
+	if c.isSquashMerged(ctx, branch, target) {
+		return CleanSquashMerged
+	}
+
+	return ""
+}
@@ -1,12 +1,16 @@
 //go:build integration
 
-package twig
+package core
 
 import (
+	"archive/tar"
+	"compress/gzip"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 
@@ -61,6 +65,100 @@ func TestRemoveCommand_Integration(t *testing.T) {
 		}
 	})
 
+	t.Run("CurrentWorktreeByPath", func(t *testing.T) {
+		t.Parallel()
+
+		repoDir, mainDir := testutil.SetupTestRepo(t)
+
+		wtPath := filepath.Join(repoDir, "feature", "here")
+		testutil.RunGit(t, mainDir, "worktree", "add", "-b", "feature/here", wtPath)
+		cwd := filepath.Join(wtPath, "sub")
+		if err := os.MkdirAll(cwd, 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		// Config loaded in the worktree, so git runs in the one being removed
+		result, err := LoadConfig(wtPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cmd := NewDefaultRemoveCommand(result.Config, NewNopLogger())
+
+		_, err = cmd.Run(t.Context(), ".", cwd, RemoveOptions{Force: WorktreeForceLevelLocked})
+		var skipErr *SkipError
+		if !errors.As(err, &skipErr) || skipErr.Reason != SkipCurrentDir {
+			t.Fatalf("without --force-cwd: error = %v, want %s", err, SkipCurrentDir)
+		}
+
+		removed, err := cmd.Run(t.Context(), ".", cwd, RemoveOptions{Force: WorktreeForceLevelUnclean, ForceCwd: true})
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		if removed.Branch != "feature/here" {
+			t.Errorf("Branch = %q, want feature/here", removed.Branch)
+		}
+		if removed.ReturnDir != mainDir {
+			t.Errorf("ReturnDir = %q, want %q", removed.ReturnDir, mainDir)
+		}
+		if _, err := os.Stat(wtPath); !os.IsNotExist(err) {
+			t.Errorf("worktree directory should be removed: %s", wtPath)
+		}
+		out := testutil.RunGit(t, mainDir, "branch", "--list", "feature/here")
+		if strings.TrimSpace(out) != "" {
+			t.Errorf("branch should be deleted, got: %s", out)
+		}
+	})
+
+	t.Run("WaitsForGitGC", func(t *testing.T) {
+		t.Parallel()
+
+		repoDir, mainDir := testutil.SetupTestRepo(t)
+
+		wtPath := filepath.Join(repoDir, "feature", "gc-busy")
+		testutil.RunGit(t, mainDir, "worktree", "add", "-b", "feature/gc-busy", wtPath)
+
+		// A gc running on another host never finishes within the wait
+		gcPID := filepath.Join(mainDir, ".git", gcPIDFileName)
+		if err := os.WriteFile(gcPID, []byte("4242 build-server"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := LoadConfig(mainDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		result.Config.GitLockWait = "0s"
+
+		cmd := &RemoveCommand{
+			FS:     osFS{},
+			Git:    NewGitRunner(mainDir),
+			Config: result.Config,
+			Log:    NewNopLogger(),
+		}
+
+		_, err = cmd.Run(t.Context(), "feature/gc-busy", mainDir, RemoveOptions{})
+		var busy *GitLockBusyError
+		if !errors.As(err, &busy) {
+			t.Fatalf("error = %v, want GitLockBusyError", err)
+		}
+
+		// Nothing was removed
+		if _, err := os.Stat(wtPath); err != nil {
+			t.Errorf("worktree should be kept: %v", err)
+		}
+		if out := testutil.RunGit(t, mainDir, "branch", "--list", "feature/gc-busy"); strings.TrimSpace(out) == "" {
+			t.Error("branch should be kept")
+		}
+
+		// Once gc is done, the removal goes through
+		if err := os.Remove(gcPID); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := cmd.Run(t.Context(), "feature/gc-busy", mainDir, RemoveOptions{}); err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+	})
+
 	t.Run("Check", func(t *testing.T) {
 		t.Parallel()
 
@@ -151,6 +249,110 @@ func TestRemoveCommand_Integration(t *testing.T) {
 		}
 	})
 
+	t.Run("ArchiveBeforeForceRemove", func(t *testing.T) {
+		t.Parallel()
+
+		repoDir, mainDir := testutil.SetupTestRepo(t)
+
+		wtPath := filepath.Join(repoDir, "feature", "archive")
+		testutil.RunGit(t, mainDir, "worktree", "add", "-b", "feature/archive", wtPath)
+		if err := os.WriteFile(filepath.Join(wtPath, "tracked.txt"), []byte("v1\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		testutil.RunGit(t, wtPath, "add", "tracked.txt")
+		testutil.RunGit(t, wtPath, "commit", "-m", "add tracked")
+
+		// Modified tracked file and an untracked file in a new directory
+		if err := os.WriteFile(filepath.Join(wtPath, "tracked.txt"), []byte("v2\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.MkdirAll(filepath.Join(wtPath, "notes"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(wtPath, "notes", "todo.txt"), []byte("todo\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := LoadConfig(mainDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cmd := &RemoveCommand{
+			FS:     osFS{},
+			Git:    NewGitRunner(mainDir),
+			Config: result.Config,
+			Log:    NewNopLogger(),
+		}
+
+		archiveDir := filepath.Join(t.TempDir(), "archives")
+		removed, err := cmd.Run(t.Context(), "feature/archive", mainDir, RemoveOptions{
+			Force:      WorktreeForceLevelUnclean,
+			Archive:    true,
+			ArchiveDir: archiveDir,
+		})
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		if _, err := os.Stat(wtPath); !os.IsNotExist(err) {
+			t.Errorf("worktree directory should be removed: %s", wtPath)
+		}
+
+		if filepath.Dir(removed.ArchivePath) != archiveDir ||
+			!strings.HasPrefix(filepath.Base(removed.ArchivePath), "feature-archive-") {
+			t.Errorf("ArchivePath = %q, want feature-archive-<timestamp>.tar.gz in %s", removed.ArchivePath, archiveDir)
+		}
+		entries := readTarGz(t, removed.ArchivePath)
+		if got := entries["files/tracked.txt"]; got != "v2\n" {
+			t.Errorf("files/tracked.txt = %q, want %q", got, "v2\n")
+		}
+		if got := entries["files/notes/todo.txt"]; got != "todo\n" {
+			t.Errorf("files/notes/todo.txt = %q, want %q", got, "todo\n")
+		}
+		if patch := entries[archivePatchName]; !strings.Contains(patch, "-v1") || !strings.Contains(patch, "+v2") {
+			t.Errorf("%s does not contain the tracked change:\n%s", archivePatchName, patch)
+		}
+	})
+
+	t.Run("ArchiveFailureKeepsWorktree", func(t *testing.T) {
+		t.Parallel()
+
+		repoDir, mainDir := testutil.SetupTestRepo(t)
+
+		wtPath := filepath.Join(repoDir, "feature", "archive-fail")
+		testutil.RunGit(t, mainDir, "worktree", "add", "-b", "feature/archive-fail", wtPath)
+		if err := os.WriteFile(filepath.Join(wtPath, "uncommitted.txt"), []byte("wip"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := LoadConfig(mainDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cmd := &RemoveCommand{
+			FS:     osFS{},
+			Git:    NewGitRunner(mainDir),
+			Config: result.Config,
+			Log:    NewNopLogger(),
+		}
+
+		// A regular file where the archive directory should be
+		blocker := filepath.Join(t.TempDir(), "not-a-dir")
+		if err := os.WriteFile(blocker, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+		_, err = cmd.Run(t.Context(), "feature/archive-fail", mainDir, RemoveOptions{
+			Force:      WorktreeForceLevelUnclean,
+			Archive:    true,
+			ArchiveDir: blocker,
+		})
+		if err == nil || !strings.Contains(err.Error(), "failed to archive uncommitted changes") {
+			t.Fatalf("err = %v, want archive failure", err)
+		}
+		if _, err := os.Stat(filepath.Join(wtPath, "uncommitted.txt")); err != nil {
+			t.Errorf("worktree should be kept when archiving fails: %v", err)
+		}
+	})
+
 	t.Run("ErrorWithHintForLockedWorktree", func(t *testing.T) {
 		t.Parallel()
 
@@ -218,6 +420,42 @@ func TestRemoveCommand_Integration(t *testing.T) {
 		}
 	})
 
+	t.Run("ProtectedBranchNotRemovedWithForce", func(t *testing.T) {
+		t.Parallel()
+
+		repoDir, mainDir := testutil.SetupTestRepo(t)
+
+		wtPath := filepath.Join(repoDir, "develop")
+		testutil.RunGit(t, mainDir, "worktree", "add", "-b", "develop", wtPath)
+
+		result, err := LoadConfig(mainDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		result.Config.ProtectedBranches = []string{"develop"}
+
+		cmd := &RemoveCommand{
+			FS:     osFS{},
+			Git:    NewGitRunner(mainDir),
+			Config: result.Config,
+			Log:    NewNopLogger(),
+		}
+
+		_, err = cmd.Run(t.Context(), "develop", mainDir, RemoveOptions{Force: WorktreeForceLevelLocked})
+		var skipErr *SkipError
+		if !errors.As(err, &skipErr) || skipErr.Reason != SkipProtected {
+			t.Fatalf("expected SkipProtected error, got %v", err)
+		}
+
+		if _, err := os.Stat(wtPath); err != nil {
+			t.Errorf("protected worktree should remain: %v", err)
+		}
+		out := testutil.RunGit(t, mainDir, "branch", "--list", "develop")
+		if strings.TrimSpace(out) == "" {
+			t.Error("protected branch should not be deleted")
+		}
+	})
+
 	t.Run("ErrorWhenInsideWorktree", func(t *testing.T) {
 		t.Parallel()
 
@@ -798,6 +1036,70 @@ func TestRemoveCommand_Integration(t *testing.T) {
 		}
 	})
 
+	t.Run("DeinitSubmodulesRemovesModuleStorage", func(t *testing.T) {
+		t.Parallel()
+
+		repoDir, mainDir := testutil.SetupTestRepo(t)
+
+		submoduleRepo := filepath.Join(repoDir, "submodule-repo-deinit")
+		if err := os.MkdirAll(submoduleRepo, 0755); err != nil {
+			t.Fatal(err)
+		}
+		testutil.RunGit(t, submoduleRepo, "init")
+		testutil.RunGit(t, submoduleRepo, "config", "user.email", "test@example.com")
+		testutil.RunGit(t, submoduleRepo, "config", "user.name", "Test")
+		if err := os.WriteFile(filepath.Join(submoduleRepo, "file.txt"), []byte("content"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		testutil.RunGit(t, submoduleRepo, "add", ".")
+		testutil.RunGit(t, submoduleRepo, "commit", "-m", "initial")
+
+		// Merged into main, so no --force is needed for the branch
+		wtPath := filepath.Join(repoDir, "feature", "deinit-submodule")
+		testutil.RunGit(t, mainDir, "worktree", "add", "-b", "feature/deinit-submodule", wtPath)
+		testutil.RunGit(t, wtPath, "-c", "protocol.file.allow=always", "submodule", "add", submoduleRepo, "sub")
+		testutil.RunGit(t, wtPath, "commit", "-m", "add submodule")
+		testutil.RunGit(t, mainDir, "merge", "feature/deinit-submodule")
+
+		gitDir := strings.TrimSpace(testutil.RunGit(t, wtPath, "rev-parse", "--path-format=absolute", "--git-dir"))
+		moduleDir := filepath.Join(gitDir, "modules", "sub")
+
+		cfgResult, err := LoadConfig(mainDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cmd := NewRemoveCommand(osFS{}, NewGitRunner(mainDir), cfgResult.Config, nil)
+
+		checked, err := cmd.Run(t.Context(), "feature/deinit-submodule", mainDir, RemoveOptions{
+			Check:            true,
+			DeinitSubmodules: true,
+		})
+		if err != nil {
+			t.Fatalf("Run (check) failed: %v", err)
+		}
+		if !checked.Deinited || !slices.Equal(checked.Submodules, []string{"sub"}) {
+			t.Errorf("Submodules = %v, Deinited = %v, want [sub] deinited", checked.Submodules, checked.Deinited)
+		}
+		if len(checked.Modules) != 1 || checked.Modules[0].Path != moduleDir || checked.Modules[0].SizeBytes == 0 {
+			t.Errorf("Modules = %+v, want %s with its size", checked.Modules, moduleDir)
+		}
+		if _, err := os.Stat(moduleDir); err != nil {
+			t.Fatalf("check must not remove module storage: %v", err)
+		}
+
+		if _, err := cmd.Run(t.Context(), "feature/deinit-submodule", mainDir, RemoveOptions{
+			DeinitSubmodules: true,
+		}); err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		if _, err := os.Stat(wtPath); !os.IsNotExist(err) {
+			t.Errorf("worktree should be removed: %s", wtPath)
+		}
+		if _, err := os.Stat(gitDir); !os.IsNotExist(err) {
+			t.Errorf("worktree git directory should be removed: %s", gitDir)
+		}
+	})
+
 	t.Run("CheckReturnsChangedFiles", func(t *testing.T) {
 		t.Parallel()
 
@@ -1430,3 +1732,34 @@ func TestRemoveCommand_Check_Integration(t *testing.T) {
 		}
 	})
 }
+
+// readTarGz returns the regular files of a .tar.gz archive by name.
+func readTarGz(t *testing.T, path string) map[string]string {
+	t.Helper()
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gz)
+	entries := make(map[string]string)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return entries
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries[hdr.Name] = string(data)
+	}
+}
@@ -1,6 +1,7 @@
-package twig
+package core
 
 import (
+	"encoding/json"
 	"errors"
 	"os"
 	"slices"
@@ -154,6 +155,27 @@ func TestRemoveResult_Format(t *testing.T) {
 			wantStdout: "Pruned stale worktree and deleted branch: feature/deleted\n",
 			wantStderr: "",
 		},
+		{
+			name: "quiet_lists_removed",
+			result: RemoveResult{
+				Removed: []RemovedWorktree{
+					{Branch: "feature/a", WorktreePath: "/repo/feature/a", ArchivePath: "/archive/feature-a.tar.gz"},
+					{WorktreePath: "/repo/detached"},
+					{Branch: "feature/b", Err: errors.New("failed")},
+				},
+			},
+			opts:       FormatOptions{Quiet: true},
+			wantStdout: "feature/a\n/repo/detached\n",
+			wantStderr: "Archived uncommitted changes: /archive/feature-a.tar.gz\nerror: feature/b: failed\n",
+		},
+		{
+			name: "quiet_check",
+			result: RemoveResult{
+				Removed: []RemovedWorktree{{Branch: "feature/a", WorktreePath: "/repo/feature/a", Check: true, CanRemove: true}},
+			},
+			opts:       FormatOptions{Quiet: true},
+			wantStdout: "feature/a\n",
+		},
 	}
 
 	for _, tt := range tests {
@@ -530,6 +552,59 @@ func TestRemoveCommand_Run(t *testing.T) {
 	}
 }
 
+func TestRemoveCommand_ResolveRemoveTarget(t *testing.T) {
+	t.Parallel()
+
+	worktrees := []testutil.MockWorktree{
+		{Path: "/repo/main", Branch: "main"},
+		{Path: "/repo/feat/a", Branch: "feat/a"},
+		{Path: "/repo/v1", Detached: true},
+	}
+
+	tests := []struct {
+		name    string
+		arg     string
+		cwd     string
+		want    string
+		wantErr string
+	}{
+		{name: "branch_name", arg: "feat/a", cwd: "/repo/main", want: "feat/a"},
+		{name: "dot", arg: ".", cwd: "/repo/feat/a", want: "feat/a"},
+		{name: "dot_in_subdir", arg: ".", cwd: "/repo/feat/a/src/pkg", want: "feat/a"},
+		{name: "relative", arg: "../feat/a", cwd: "/repo/main", want: "feat/a"},
+		{name: "absolute", arg: "/repo/feat/a", cwd: "/elsewhere", want: "feat/a"},
+		{name: "main_worktree", arg: ".", cwd: "/repo/main", wantErr: "is the main worktree"},
+		{name: "detached", arg: "/repo/v1", cwd: "/repo/main", wantErr: "has no branch"},
+		{name: "outside", arg: "/tmp/x", cwd: "/repo/main", wantErr: "/tmp/x is not in a worktree"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cmd := &RemoveCommand{
+				FS:     &testutil.MockFS{},
+				Git:    &GitRunner{Executor: &testutil.MockGitExecutor{Worktrees: worktrees}, Dir: "/repo/main", Log: NewNopLogger()},
+				Config: &Config{WorktreeSourceDir: "/repo/main"},
+				Log:    NewNopLogger(),
+			}
+			got, err := cmd.ResolveRemoveTarget(t.Context(), tt.arg, tt.cwd)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("ResolveRemoveTarget() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestRemoveCommand_CleanupEmptyParentDirs(t *testing.T) {
 	t.Parallel()
 
@@ -674,6 +749,75 @@ func TestRemoveCommand_PredictEmptyParentDirs(t *testing.T) {
 	}
 }
 
+func TestRemoveCommand_Run_KeepEmptyDirs(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		opts        RemoveOptions
+		wantCleaned []string
+		wantKept    []string
+		wantRemoved bool
+	}{
+		{
+			name:        "default_cleans_empty_parent",
+			opts:        RemoveOptions{},
+			wantCleaned: []string{"/base/feat"},
+			wantRemoved: true,
+		},
+		{
+			name:     "keep_empty_dirs",
+			opts:     RemoveOptions{KeepEmptyDirs: true},
+			wantKept: []string{"/base/feat"},
+		},
+		{
+			name: "check_with_keep_empty_dirs_predicts_nothing",
+			opts: RemoveOptions{Check: true, KeepEmptyDirs: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var removed []string
+			mockFS := &testutil.MockFS{
+				// State after git worktree remove has deleted the worktree
+				DirContents: map[string][]os.DirEntry{
+					"/base/feat": {},
+				},
+				RemoveFunc: func(name string) error {
+					removed = append(removed, name)
+					return nil
+				},
+			}
+			cmd := &RemoveCommand{
+				FS: mockFS,
+				Git: &GitRunner{Executor: &testutil.MockGitExecutor{
+					Worktrees: []testutil.MockWorktree{{Path: "/base/feat/test", Branch: "feat/test"}},
+				}, Log: NewNopLogger()},
+				Config: &Config{WorktreeSourceDir: "/repo/main", WorktreeDestBaseDir: "/base"},
+				Log:    NewNopLogger(),
+			}
+
+			result, err := cmd.Run(t.Context(), "feat/test", "/other/dir", tt.opts)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !slices.Equal(result.CleanedDirs, tt.wantCleaned) {
+				t.Errorf("CleanedDirs = %v, want %v", result.CleanedDirs, tt.wantCleaned)
+			}
+			if !slices.Equal(result.KeptDirs, tt.wantKept) {
+				t.Errorf("KeptDirs = %v, want %v", result.KeptDirs, tt.wantKept)
+			}
+			if got := slices.Contains(removed, "/base/feat"); got != tt.wantRemoved {
+				t.Errorf("removed /base/feat = %v, want %v (removed: %v)", got, tt.wantRemoved, removed)
+			}
+		})
+	}
+}
+
 func TestRemovedWorktree_Format_WithCleanedDirs(t *testing.T) {
 	t.Parallel()
 
@@ -708,6 +852,17 @@ func TestRemovedWorktree_Format_WithCleanedDirs(t *testing.T) {
 			wantStdout: "Removed worktree and branch: feat/test\n" +
 				"Removed empty directory: /base/feat\n",
 		},
+		{
+			name: "verbose_with_kept_dirs",
+			result: RemovedWorktree{
+				Branch:       "feat/test",
+				WorktreePath: "/base/feat/test",
+				KeptDirs:     []string{"/base/feat"},
+			},
+			opts: FormatOptions{Verbose: true},
+			wantStdout: "Removed worktree and branch: feat/test\n" +
+				"Kept empty directory: /base/feat\n",
+		},
 		{
 			name: "normal_with_cleaned_dirs_not_shown",
 			result: RemovedWorktree{
@@ -719,6 +874,29 @@ func TestRemovedWorktree_Format_WithCleanedDirs(t *testing.T) {
 			opts:       FormatOptions{Verbose: false},
 			wantStdout: "",
 		},
+		{
+			name: "archived_shown_without_verbose",
+			result: RemovedWorktree{
+				Branch:       "feat/test",
+				WorktreePath: "/base/feat/test",
+				ArchivePath:  "/archives/feat-test-20260102-150405.tar.gz",
+			},
+			opts:       FormatOptions{Verbose: false},
+			wantStdout: "Archived uncommitted changes: /archives/feat-test-20260102-150405.tar.gz\n",
+		},
+		{
+			name: "check_with_archive",
+			result: RemovedWorktree{
+				Branch:       "feat/test",
+				WorktreePath: "/base/feat/test",
+				ArchivePath:  "/archives/feat-test-20260102-150405.tar.gz",
+				Check:        true,
+			},
+			opts: FormatOptions{Verbose: false},
+			wantStdout: "Would remove worktree: /base/feat/test\n" +
+				"Would archive uncommitted changes to: /archives/feat-test-20260102-150405.tar.gz\n" +
+				"Would delete branch: feat/test\n",
+		},
 	}
 
 	for _, tt := range tests {
@@ -809,6 +987,28 @@ func TestRemoveResult_Format_WithHint(t *testing.T) {
 			opts:       FormatOptions{Verbose: false},
 			wantStderr: "error: feature/a: failed to remove worktree\nhint: run 'git worktree unlock <path>' first, or use 'twig remove -f -f'\n",
 		},
+		{
+			name: "skip_error_protected_hint",
+			result: RemoveResult{
+				Removed: []RemovedWorktree{{
+					Branch: "develop",
+					Err:    &SkipError{Reason: SkipProtected},
+				}},
+			},
+			opts:       FormatOptions{Verbose: false},
+			wantStderr: "error: develop: cannot remove: protected branch\nhint: branch matches protected_branches in .twig/settings.toml\n",
+		},
+		{
+			name: "skip_error_current_dir_hint",
+			result: RemoveResult{
+				Removed: []RemovedWorktree{{
+					Branch: "feature/a",
+					Err:    &SkipError{Reason: SkipCurrentDir},
+				}},
+			},
+			opts:       FormatOptions{Verbose: false},
+			wantStderr: "error: feature/a: cannot remove: current directory\nhint: cd out of the worktree first, or use 'twig remove --force-cwd'\n",
+		},
 		{
 			name: "non_git_error_fallback",
 			result: RemoveResult{
@@ -892,6 +1092,66 @@ func TestRemoveCommand_Check(t *testing.T) {
 			wantCanRemove: true,
 			wantClean:     CleanUpstreamGone,
 		},
+		// Protected branch cases
+		{
+			name:   "skip_protected_branch_even_with_force_locked",
+			branch: "develop",
+			opts: CheckOptions{
+				Force:  WorktreeForceLevelLocked,
+				Target: "main",
+				Cwd:    "/other/dir",
+			},
+			config: &Config{WorktreeSourceDir: "/repo/main", ProtectedBranches: []string{"develop"}},
+			setupGit: func() *testutil.MockGitExecutor {
+				return &testutil.MockGitExecutor{
+					Worktrees: []testutil.MockWorktree{
+						{Path: "/repo/develop", Branch: "develop"},
+					},
+					MergedBranches: map[string][]string{
+						"main": {"develop"},
+					},
+				}
+			},
+			wantCanRemove: false,
+			wantSkip:      SkipProtected,
+		},
+		{
+			name:   "skip_protected_branch_by_pattern",
+			branch: "release/1.0",
+			opts: CheckOptions{
+				Force: WorktreeForceLevelNone,
+				Cwd:   "/other/dir",
+			},
+			config: &Config{WorktreeSourceDir: "/repo/main", ProtectedBranches: []string{"release/*"}},
+			setupGit: func() *testutil.MockGitExecutor {
+				return &testutil.MockGitExecutor{
+					Worktrees: []testutil.MockWorktree{
+						{Path: "/repo/release/1.0", Branch: "release/1.0"},
+					},
+				}
+			},
+			wantCanRemove: false,
+			wantSkip:      SkipProtected,
+		},
+		{
+			name:   "skip_protected_prunable_branch",
+			branch: "develop",
+			opts: CheckOptions{
+				Force:  WorktreeForceLevelUnclean,
+				Target: "main",
+				Cwd:    "/other/dir",
+			},
+			config: &Config{WorktreeSourceDir: "/repo/main", ProtectedBranches: []string{"develop"}},
+			setupGit: func() *testutil.MockGitExecutor {
+				return &testutil.MockGitExecutor{
+					Worktrees: []testutil.MockWorktree{
+						{Path: "/repo/develop", Branch: "develop", Prunable: true},
+					},
+				}
+			},
+			wantCanRemove: false,
+			wantSkip:      SkipProtected,
+		},
 		// Skip cases
 		// Note: Detached HEAD worktrees are handled directly in CleanCommand.Run
 		// since they have no branch name and cannot be found by WorktreeFindByBranch.
@@ -1659,3 +1919,276 @@ func TestRemoveCommand_UpstreamGoneUsesForceDelete(t *testing.T) {
 		})
 	}
 }
+
+func TestRemoveCommand_RecordsRemoval(t *testing.T) {
+	t.Parallel()
+
+	const auditPath = "/repo/main/.git/twig/audit.jsonl"
+
+	tests := []struct {
+		name         string
+		prunable     bool
+		check        bool
+		appendErr    error
+		wantRecorded bool
+		wantAuditErr bool
+	}{
+		{
+			name:         "removal",
+			wantRecorded: true,
+		},
+		{
+			name:         "prunable_worktree",
+			prunable:     true,
+			wantRecorded: true,
+		},
+		{
+			name:  "check_mode",
+			check: true,
+		},
+		{
+			name:         "append_failure",
+			appendErr:    errors.New("disk full"),
+			wantAuditErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockFS := &testutil.MockFS{
+				WrittenFiles:  map[string][]byte{},
+				AppendFileErr: tt.appendErr,
+			}
+			git := &GitRunner{
+				Executor: &testutil.MockGitExecutor{
+					Worktrees: []testutil.MockWorktree{{
+						Path:     "/repo/feature/a",
+						Branch:   "feature/a",
+						HEAD:     "abc1234567",
+						Prunable: tt.prunable,
+					}},
+					GitCommonDir: "/repo/main/.git",
+				},
+				Log: NewNopLogger(),
+			}
+			cmd := &RemoveCommand{
+				FS:     mockFS,
+				Git:    git,
+				Config: &Config{WorktreeSourceDir: "/repo/main"},
+				Log:    NewNopLogger(),
+				Audit:  NewAuditLog(mockFS, git),
+			}
+
+			result, err := cmd.Run(t.Context(), "feature/a", "/other/dir", RemoveOptions{Check: tt.check})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if (result.AuditErr != nil) != tt.wantAuditErr {
+				t.Errorf("AuditErr = %v, wantAuditErr %v", result.AuditErr, tt.wantAuditErr)
+			}
+
+			data, recorded := mockFS.WrittenFiles[auditPath]
+			if recorded != tt.wantRecorded {
+				t.Fatalf("audit log written = %v, want %v", recorded, tt.wantRecorded)
+			}
+			if !recorded {
+				return
+			}
+			var entry AuditEntry
+			if err := json.Unmarshal(data, &entry); err != nil {
+				t.Fatalf("failed to decode audit entry: %v", err)
+			}
+			if entry.Command != AuditCommandRemove || entry.Branch != "feature/a" || entry.HEAD != "abc1234567" {
+				t.Errorf("entry = %+v, want remove of feature/a at abc1234567", entry)
+			}
+			if entry.Pruned != tt.prunable {
+				t.Errorf("entry.Pruned = %v, want %v", entry.Pruned, tt.prunable)
+			}
+		})
+	}
+}
+
+func TestRemovedWorktree_Format_AuditErr(t *testing.T) {
+	t.Parallel()
+
+	r := RemovedWorktree{
+		Branch:       "feature/a",
+		WorktreePath: "/repo/feature/a",
+		AuditErr:     errors.New("failed to append audit log: disk full"),
+	}
+
+	got := r.Format(FormatOptions{})
+	if want := "warning: failed to append audit log: disk full\n"; got.Stderr != want {
+		t.Errorf("Stderr = %q, want %q", got.Stderr, want)
+	}
+}
+
+func TestRemoveCommand_Run_DeinitSubmodules(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name             string
+		deinit           bool
+		wantDeinit       bool
+		wantRemovedPaths []string
+		wantForce        bool
+	}{
+		{
+			name:      "clean submodules are removed with auto-force",
+			deinit:    false,
+			wantForce: true,
+		},
+		{
+			name:             "deinit removes module storage without force",
+			deinit:           true,
+			wantDeinit:       true,
+			wantRemovedPaths: []string{"/repo/.git/worktrees/a/modules"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var captured, removed []string
+			mockGit := &testutil.MockGitExecutor{
+				Worktrees:             []testutil.MockWorktree{{Path: "/repo/feature/a", Branch: "feature/a"}},
+				MergedBranches:        map[string][]string{"main": {"feature/a"}},
+				SubmoduleStatusOutput: " abc123 sub (v1.0.0)\n",
+				GitDirMap: map[string]string{
+					"/repo/feature/a":     "/repo/.git/worktrees/a",
+					"/repo/feature/a/sub": "/repo/.git/worktrees/a/modules/sub",
+				},
+				CapturedArgs: &captured,
+			}
+			mockFS := &testutil.MockFS{
+				RemoveAllFunc: func(path string) error {
+					removed = append(removed, path)
+					return nil
+				},
+			}
+
+			cmd := NewRemoveCommand(mockFS, &GitRunner{Executor: mockGit, Log: NewNopLogger()},
+				&Config{WorktreeSourceDir: "/repo/main", DefaultSource: "main"}, nil)
+			result, err := cmd.Run(t.Context(), "feature/a", "/other", RemoveOptions{
+				DeinitSubmodules: tt.deinit,
+				Target:           "main",
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !slices.Equal(result.Submodules, []string{"sub"}) {
+				t.Errorf("Submodules = %v, want [sub]", result.Submodules)
+			}
+			if len(result.Modules) != 1 || result.Modules[0].Path != "/repo/.git/worktrees/a/modules/sub" {
+				t.Errorf("Modules = %+v, want the sub module directory", result.Modules)
+			}
+			if result.Deinited != tt.wantDeinit || mockGit.SubmoduleDeinitCalled != tt.wantDeinit {
+				t.Errorf("Deinited = %v, deinit called = %v, want %v",
+					result.Deinited, mockGit.SubmoduleDeinitCalled, tt.wantDeinit)
+			}
+			if !slices.Equal(removed, tt.wantRemovedPaths) {
+				t.Errorf("RemoveAll paths = %v, want %v", removed, tt.wantRemovedPaths)
+			}
+			if got := slices.Contains(captured, "-f"); got != tt.wantForce {
+				t.Errorf("worktree remove -f = %v, want %v (args %v)", got, tt.wantForce, captured)
+			}
+		})
+	}
+}
+
+func TestRemovedWorktree_Format_Submodules(t *testing.T) {
+	t.Parallel()
+
+	base := RemovedWorktree{
+		Branch:       "feature/a",
+		WorktreePath: "/repo/feature/a",
+		Submodules:   []string{"sub", "vendor/lib"},
+		Modules: []ModuleDir{
+			{Path: "/repo/.git/worktrees/a/modules/sub", SizeBytes: 2048},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		check    bool
+		deinited bool
+		verbose  bool
+		want     []string
+	}{
+		{
+			name:  "check notes initialized submodules",
+			check: true,
+			want: []string{
+				"Contains initialized submodules: sub, vendor/lib (use --deinit-submodules to deinit them first)\n",
+			},
+		},
+		{
+			name:     "check with deinit lists submodules and storage",
+			check:    true,
+			deinited: true,
+			want: []string{
+				"Would deinit submodule: sub\n",
+				"Would deinit submodule: vendor/lib\n",
+				"Would remove module storage: /repo/.git/worktrees/a/modules/sub (2.0 KiB)\n",
+			},
+		},
+		{
+			name:     "verbose removal lists deinitialized submodules",
+			deinited: true,
+			verbose:  true,
+			want: []string{
+				"Deinitialized submodule: sub\n",
+				"Removed module storage: /repo/.git/worktrees/a/modules/sub (2.0 KiB)\n",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := base
+			r.Check = tt.check
+			r.Deinited = tt.deinited
+			got := r.Format(FormatOptions{Verbose: tt.verbose}).Stdout
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("Format() missing %q in:\n%s", want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestReason_Code(t *testing.T) {
+	t.Parallel()
+
+	// Every reason has its own code, which porcelain output relies on
+	seen := make(map[string]bool)
+	for _, r := range []SkipReason{
+		SkipNotMerged, SkipSameCommit, SkipHasChanges, SkipLocked, SkipCurrentDir, SkipDetached,
+		SkipDirtySubmodule, SkipProtected, SkipVerifyFailed, SkipUnpushedCommits, SkipNotManaged, SkipExcluded,
+	} {
+		code := r.Code()
+		if code == "" || strings.ContainsAny(code, " -") || seen[code] {
+			t.Errorf("SkipReason(%q).Code() = %q, want a unique snake_case code", r, code)
+		}
+		seen[code] = true
+	}
+
+	seen = make(map[string]bool)
+	for _, r := range []CleanReason{
+		CleanMerged, CleanUpstreamGone, CleanSquashMerged, CleanPRMerged, CleanPRClosed, CleanDetached,
+	} {
+		code := r.Code()
+		if code == "" || strings.ContainsAny(code, " -") || seen[code] {
+			t.Errorf("CleanReason(%q).Code() = %q, want a unique snake_case code", r, code)
+		}
+		seen[code] = true
+	}
+}
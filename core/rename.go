@@ -0,0 +1,259 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"strings"
+)
+
+// RenameOptions configures the rename command.
+type RenameOptions struct {
+	NoPrefix bool // Use names verbatim, ignoring branch_prefix and branch_aliases
+}
+
+// RenameResult holds the result of a rename operation.
+type RenameResult struct {
+	OldBranch   string
+	NewBranch   string
+	OldPath     string
+	NewPath     string
+	CleanedDirs []string // Empty parent directories of OldPath that were removed
+	Symlinks    []string // Symlinks re-pointed after the move
+	SymlinkErr  error    // Failure to re-point symlinks (the rename itself succeeded)
+
+	// Upstream handling when the branch tracked a remote branch of the same name
+	OldUpstream string // Upstream before the rename ("<remote>/<branch>")
+	NewUpstream string // Upstream after the rename (empty = unset)
+	UpstreamErr error  // Failure to update the upstream (the rename itself succeeded)
+
+	CwdMoved bool // The current directory was inside the moved worktree
+}
+
+// Warnings returns the warnings of the rename.
+func (r RenameResult) Warnings() []Warning {
+	var warnings []Warning
+	if r.SymlinkErr != nil {
+		warnings = append(warnings, Warning{Code: WarningRepointFailed, Subject: r.NewPath, Message: r.SymlinkErr.Error()})
+	}
+	if r.UpstreamErr != nil {
+		warnings = append(warnings, Warning{Code: WarningUpstreamFailed, Subject: r.NewBranch, Message: r.UpstreamErr.Error()})
+	}
+	return warnings
+}
+
+// Format formats the RenameResult for display.
+func (r RenameResult) Format(opts FormatOptions) FormatResult {
+	var stdout, stderr strings.Builder
+
+	if opts.Verbose {
+		fmt.Fprintf(&stdout, "Renamed branch: %s -> %s\n", r.OldBranch, r.NewBranch)
+		if r.NewPath != r.OldPath {
+			fmt.Fprintf(&stdout, "Moved worktree: %s -> %s\n", r.OldPath, r.NewPath)
+		}
+		for _, dir := range r.CleanedDirs {
+			fmt.Fprintf(&stdout, "Removed empty directory: %s\n", dir)
+		}
+		for _, link := range r.Symlinks {
+			fmt.Fprintf(&stdout, "Updated symlink: %s\n", link)
+		}
+		if r.OldUpstream != "" && r.NewUpstream != "" {
+			fmt.Fprintf(&stdout, "Updated upstream: %s -> %s\n", r.OldUpstream, r.NewUpstream)
+		}
+	}
+
+	writeWarnings(&stderr, r.Warnings())
+	if r.UpstreamErr == nil && r.OldUpstream != "" && r.NewUpstream == "" {
+		remote, _, _ := strings.Cut(r.OldUpstream, "/")
+		fmt.Fprintf(&stderr, "hint: %s no longer tracks %s; to publish the new name, run:\n  git push -u %s %s\n",
+			r.NewBranch, r.OldUpstream, remote, r.NewBranch)
+	}
+	if r.CwdMoved {
+		fmt.Fprintf(&stderr, "hint: the current directory was moved; run:\n  cd %s\n", r.NewPath)
+	}
+
+	fmt.Fprintf(&stdout, "twig rename: %s -> %s (%s)\n", r.OldBranch, r.NewBranch, r.NewPath)
+	return FormatResult{Stdout: stdout.String(), Stderr: stderr.String()}
+}
+
+// RenameCommand renames a branch and moves its worktree to match.
+type RenameCommand struct {
+	FS     FileSystem
+	Git    *GitRunner
+	Config *Config
+	Log    *slog.Logger
+}
+
+// NewRenameCommand creates a RenameCommand with explicit dependencies.
+func NewRenameCommand(fs FileSystem, git *GitRunner, cfg *Config, log *slog.Logger) *RenameCommand {
+	if log == nil {
+		log = NewNopLogger()
+	}
+	return &RenameCommand{
+		FS:     fs,
+		Git:    git,
+		Config: cfg,
+		Log:    log,
+	}
+}
+
+// NewDefaultRenameCommand creates a RenameCommand with production defaults.
+func NewDefaultRenameCommand(cfg *Config, log *slog.Logger, gitOpts ...GitRunnerOption) *RenameCommand {
+	git := newDefaultGitRunner(cfg.WorktreeSourceDir, log, gitOpts)
+	return NewRenameCommand(git.defaultFS(), git, cfg, log)
+}
+
+// Run renames the branch oldName to newName, moves its worktree to the
+// path for newName under WorktreeDestBaseDir, re-points symlinks that the
+// move broke, and follows the upstream to the new name.
+// oldName is matched against checked-out branches as given first, then
+// after applying branch_aliases and branch_prefix. cwd is only used to
+// tell the caller when the current directory was moved.
+func (c *RenameCommand) Run(ctx context.Context, oldName, newName, cwd string, opts RenameOptions) (RenameResult, error) {
+	result := RenameResult{OldBranch: oldName, NewBranch: newName}
+
+	if oldName == "" || newName == "" {
+		return result, fmt.Errorf("old and new branch names are required")
+	}
+	if c.Config.WorktreeDestBaseDir == "" {
+		return result, fmt.Errorf("worktree destination base directory is not configured")
+	}
+
+	worktrees, err := c.Git.WorktreeList(ctx)
+	if err != nil {
+		return result, fmt.Errorf("failed to list worktrees: %w", err)
+	}
+
+	candidates := []string{oldName}
+	newBranch, newWtName := newName, newName
+	if !opts.NoPrefix {
+		resolved, _ := c.Config.ResolveBranch(oldName)
+		candidates = append(candidates, resolved)
+		newBranch, newWtName = c.Config.ResolveBranch(newName)
+	}
+	var wt *Worktree
+	for _, candidate := range candidates {
+		if wt = findWorktreeByBranch(worktrees, candidate); wt != nil {
+			break
+		}
+	}
+	if wt == nil {
+		return result, fmt.Errorf("branch %q is not checked out in any worktree", candidates[len(candidates)-1])
+	}
+
+	result.OldBranch = wt.Branch
+	result.NewBranch = newBranch
+	result.OldPath = wt.Path
+	result.NewPath = filepath.Join(c.Config.WorktreeDestBaseDir, newWtName)
+
+	switch {
+	case wt == &worktrees[0]:
+		return result, fmt.Errorf("cannot rename the branch of the main worktree (use git branch -m)")
+	case wt.Path == c.Config.WorktreeSourceDir:
+		return result, fmt.Errorf("cannot rename %s: its worktree is the symlink source of other worktrees", wt.Branch)
+	case wt.Locked:
+		return result, fmt.Errorf("worktree for %s is locked; unlock it first (git worktree unlock %s)", wt.Branch, wt.Path)
+	case newBranch == wt.Branch:
+		return result, fmt.Errorf("branch is already named %s", newBranch)
+	}
+
+	exists, err := c.Git.LocalBranchExists(ctx, newBranch)
+	if err != nil {
+		return result, fmt.Errorf("failed to check branch %s: %w", newBranch, err)
+	}
+	if exists {
+		return result, fmt.Errorf("branch %s already exists", newBranch)
+	}
+	move := result.NewPath != result.OldPath
+	if move {
+		_, err := c.FS.Stat(result.NewPath)
+		if err == nil {
+			return result, fmt.Errorf("destination %s already exists", result.NewPath)
+		}
+		if !c.FS.IsNotExist(err) {
+			return result, fmt.Errorf("failed to check destination %s: %w", result.NewPath, err)
+		}
+	}
+
+	if err := NewGitLockWaiter(c.FS, c.Git, c.Config.GitLockWaitDuration(), c.Log).WaitFor(ctx, wt.Path); err != nil {
+		return result, err
+	}
+
+	// Read the upstream before renaming; git keeps it under the new name
+	remote, remoteRef, err := c.Git.BranchUpstream(ctx, wt.Branch)
+	if err != nil {
+		c.Log.DebugContext(ctx, "failed to read upstream",
+			LogAttrKeyCategory.String(), LogCategoryRename,
+			"branch", wt.Branch,
+			"error", err.Error())
+	}
+
+	if err := c.Git.BranchRename(ctx, wt.Branch, newBranch); err != nil {
+		return result, err
+	}
+
+	if move {
+		if err := c.FS.MkdirAll(filepath.Dir(result.NewPath), 0755); err != nil {
+			c.rollbackRename(ctx, newBranch, wt.Branch)
+			return result, fmt.Errorf("failed to create directory for %s: %w", result.NewPath, err)
+		}
+		if err := c.Git.WorktreeMove(ctx, result.OldPath, result.NewPath); err != nil {
+			c.rollbackRename(ctx, newBranch, wt.Branch)
+			return result, err
+		}
+		if c.Config.ShouldCleanupEmptyDirs() {
+			result.CleanedDirs = removeEmptyParentDirs(ctx, c.FS, c.Config.WorktreeDestBaseDir, result.OldPath, c.Log, LogCategoryRename)
+		}
+		result.Symlinks, result.SymlinkErr = repointSymlinks(c.FS, result.OldPath, result.NewPath)
+		result.CwdMoved = cwd != "" && isWithinDir(result.OldPath, cwd)
+	}
+
+	// An upstream of the same name no longer matches; one that tracks a
+	// different branch (e.g. --track=upstream/main) is left as is.
+	if remote != "" && remoteRef == RefsHeadsPrefix+wt.Branch {
+		result.OldUpstream = remote + "/" + wt.Branch
+		result.NewUpstream, result.UpstreamErr = c.followUpstream(ctx, remote, newBranch)
+	}
+
+	if err := NewNoteStore(c.FS, c.Git).Rename(ctx, wt.Branch, newBranch); err != nil {
+		c.Log.DebugContext(ctx, "failed to move note",
+			LogAttrKeyCategory.String(), LogCategoryRename,
+			"branch", newBranch,
+			"error", err.Error())
+	}
+
+	c.Log.DebugContext(ctx, "renamed worktree",
+		LogAttrKeyCategory.String(), LogCategoryRename,
+		"from", result.OldBranch,
+		"to", result.NewBranch,
+		"path", result.NewPath,
+		"symlinks", len(result.Symlinks))
+	return result, nil
+}
+
+// followUpstream points the upstream of branch at <remote>/<branch> when
+// that remote-tracking branch exists, and unsets it otherwise.
+// Returns the new upstream, or "" if it was unset.
+func (c *RenameCommand) followUpstream(ctx context.Context, remote, branch string) (string, error) {
+	upstream := remote + "/" + branch
+	exists, err := c.Git.RemoteBranchExists(ctx, upstream)
+	if err != nil {
+		return "", fmt.Errorf("failed to check %s: %w", upstream, err)
+	}
+	if exists {
+		return upstream, c.Git.SetUpstream(ctx, branch, upstream)
+	}
+	return "", c.Git.UnsetUpstream(ctx, branch)
+}
+
+// rollbackRename restores the branch name after the worktree could not
+// be moved, so a failed rename leaves nothing half done.
+func (c *RenameCommand) rollbackRename(ctx context.Context, newBranch, oldBranch string) {
+	if err := c.Git.BranchRename(ctx, newBranch, oldBranch); err != nil {
+		c.Log.DebugContext(ctx, "failed to restore branch name",
+			LogAttrKeyCategory.String(), LogCategoryRename,
+			"branch", newBranch,
+			"error", err.Error())
+	}
+}
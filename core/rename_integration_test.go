@@ -0,0 +1,61 @@
+//go:build integration
+
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/708u/twig/internal/testutil"
+)
+
+func TestRenameCommand_Integration(t *testing.T) {
+	t.Parallel()
+
+	t.Run("MovesWorktreeAndKeepsSymlinks", func(t *testing.T) {
+		t.Parallel()
+
+		repoDir, mainDir := testutil.SetupTestRepo(t, testutil.Symlinks(".envrc"))
+		if err := os.WriteFile(filepath.Join(mainDir, ".envrc"), []byte("export A=1"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		cfgResult, err := LoadConfig(mainDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cfg := cfgResult.Config
+
+		git := NewGitRunner(mainDir)
+		if _, err := NewAddCommand(osFS{}, git, cfg, nil, AddOptions{}).Run(t.Context(), "feat/a"); err != nil {
+			t.Fatalf("add failed: %v", err)
+		}
+
+		result, err := NewRenameCommand(osFS{}, git, cfg, nil).Run(t.Context(), "feat/a", "b", "", RenameOptions{})
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+
+		newPath := filepath.Join(repoDir, "b")
+		if result.NewPath != newPath {
+			t.Errorf("NewPath = %s, want %s", result.NewPath, newPath)
+		}
+		if _, err := os.Stat(filepath.Join(repoDir, "feat")); !os.IsNotExist(err) {
+			t.Errorf("empty parent dir should be removed, stat err = %v", err)
+		}
+
+		out := strings.TrimSpace(testutil.RunGit(t, newPath, "branch", "--show-current"))
+		if out != "b" {
+			t.Errorf("branch = %q, want %q", out, "b")
+		}
+		data, err := os.ReadFile(filepath.Join(newPath, ".envrc"))
+		if err != nil {
+			t.Fatalf("symlink does not resolve after move: %v", err)
+		}
+		if string(data) != "export A=1" {
+			t.Errorf(".envrc = %q, want %q", data, "export A=1")
+		}
+	})
+}
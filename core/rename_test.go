@@ -0,0 +1,379 @@
+package core
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/708u/twig/internal/testutil"
+)
+
+func TestRenameCommand_Run(t *testing.T) {
+	t.Parallel()
+
+	worktrees := []testutil.MockWorktree{
+		{Path: "/repo/main", Branch: "main"},
+		{Path: "/repo/main-worktree/feat/a", Branch: "feat/a"},
+		{Path: "/repo/main-worktree/login", Branch: "users/me/login"},
+		{Path: "/repo/main-worktree/frozen", Branch: "frozen", Locked: true},
+	}
+
+	tests := []struct {
+		name             string
+		oldName          string
+		newName          string
+		opts             RenameOptions
+		cwd              string
+		existingBranches []string
+		existingPaths    []string
+		upstreams        map[string]string
+		remoteBranches   map[string][]string
+		moveErr          error
+		wantResult       RenameResult
+		wantArgs         []string
+		wantNotArgs      []string
+		errContains      string
+	}{
+		{
+			name:    "renames branch and moves worktree",
+			oldName: "feat/a",
+			newName: "feat/b",
+			opts:    RenameOptions{NoPrefix: true},
+			cwd:     "/repo/main-worktree/feat/a/src",
+			wantResult: RenameResult{
+				OldBranch: "feat/a",
+				NewBranch: "feat/b",
+				OldPath:   "/repo/main-worktree/feat/a",
+				NewPath:   "/repo/main-worktree/feat/b",
+				CwdMoved:  true,
+			},
+			wantArgs: []string{
+				"branch -m feat/a feat/b",
+				"worktree move /repo/main-worktree/feat/a /repo/main-worktree/feat/b",
+			},
+			wantNotArgs: []string{"--unset-upstream", "--set-upstream-to"},
+		},
+		{
+			name:    "resolves branch_prefix for both names",
+			oldName: "login",
+			newName: "signin",
+			wantResult: RenameResult{
+				OldBranch: "users/me/login",
+				NewBranch: "users/me/signin",
+				OldPath:   "/repo/main-worktree/login",
+				NewPath:   "/repo/main-worktree/signin",
+			},
+			wantArgs: []string{"branch -m users/me/login users/me/signin"},
+		},
+		{
+			name:           "follows upstream when remote branch exists",
+			oldName:        "feat/a",
+			newName:        "feat/b",
+			opts:           RenameOptions{NoPrefix: true},
+			upstreams:      map[string]string{"feat/a": "origin/feat/a"},
+			remoteBranches: map[string][]string{"origin": {"feat/b"}},
+			wantResult: RenameResult{
+				OldBranch:   "feat/a",
+				NewBranch:   "feat/b",
+				OldPath:     "/repo/main-worktree/feat/a",
+				NewPath:     "/repo/main-worktree/feat/b",
+				OldUpstream: "origin/feat/a",
+				NewUpstream: "origin/feat/b",
+			},
+			wantArgs: []string{"branch --set-upstream-to=origin/feat/b feat/b"},
+		},
+		{
+			name:      "unsets upstream when remote branch is missing",
+			oldName:   "feat/a",
+			newName:   "feat/b",
+			opts:      RenameOptions{NoPrefix: true},
+			upstreams: map[string]string{"feat/a": "origin/feat/a"},
+			wantResult: RenameResult{
+				OldBranch:   "feat/a",
+				NewBranch:   "feat/b",
+				OldPath:     "/repo/main-worktree/feat/a",
+				NewPath:     "/repo/main-worktree/feat/b",
+				OldUpstream: "origin/feat/a",
+			},
+			wantArgs: []string{"branch --unset-upstream feat/b"},
+		},
+		{
+			name:      "keeps upstream of a different name",
+			oldName:   "feat/a",
+			newName:   "feat/b",
+			opts:      RenameOptions{NoPrefix: true},
+			upstreams: map[string]string{"feat/a": "origin/main"},
+			wantResult: RenameResult{
+				OldBranch: "feat/a",
+				NewBranch: "feat/b",
+				OldPath:   "/repo/main-worktree/feat/a",
+				NewPath:   "/repo/main-worktree/feat/b",
+			},
+			wantNotArgs: []string{"--unset-upstream", "--set-upstream-to"},
+		},
+		{
+			name:        "main worktree",
+			oldName:     "main",
+			newName:     "trunk",
+			opts:        RenameOptions{NoPrefix: true},
+			errContains: "cannot rename the branch of the main worktree",
+		},
+		{
+			name:        "locked worktree",
+			oldName:     "frozen",
+			newName:     "thawed",
+			opts:        RenameOptions{NoPrefix: true},
+			errContains: "worktree for frozen is locked",
+		},
+		{
+			name:        "not checked out",
+			oldName:     "feat/x",
+			newName:     "feat/y",
+			opts:        RenameOptions{NoPrefix: true},
+			errContains: `branch "feat/x" is not checked out in any worktree`,
+		},
+		{
+			name:        "same name",
+			oldName:     "feat/a",
+			newName:     "feat/a",
+			opts:        RenameOptions{NoPrefix: true},
+			errContains: "branch is already named feat/a",
+		},
+		{
+			name:             "new branch exists",
+			oldName:          "feat/a",
+			newName:          "feat/b",
+			opts:             RenameOptions{NoPrefix: true},
+			existingBranches: []string{"feat/b"},
+			errContains:      "branch feat/b already exists",
+			wantNotArgs:      []string{"branch -m"},
+		},
+		{
+			name:          "destination exists",
+			oldName:       "feat/a",
+			newName:       "feat/b",
+			opts:          RenameOptions{NoPrefix: true},
+			existingPaths: []string{"/repo/main-worktree/feat/b"},
+			errContains:   "destination /repo/main-worktree/feat/b already exists",
+			wantNotArgs:   []string{"branch -m"},
+		},
+		{
+			name:        "move failure restores branch name",
+			oldName:     "feat/a",
+			newName:     "feat/b",
+			opts:        RenameOptions{NoPrefix: true},
+			moveErr:     errors.New("failed to move worktree: target is dirty"),
+			errContains: "target is dirty",
+			wantArgs:    []string{"branch -m feat/a feat/b", "branch -m feat/b feat/a"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var captured []string
+			mockGit := &testutil.MockGitExecutor{
+				Worktrees:        worktrees,
+				ExistingBranches: tt.existingBranches,
+				Upstreams:        tt.upstreams,
+				RemoteBranches:   tt.remoteBranches,
+				WorktreeMoveErr:  tt.moveErr,
+				CapturedArgs:     &captured,
+			}
+			git := &GitRunner{Executor: mockGit, Dir: "/repo/main", Log: NewNopLogger()}
+			cfg := &Config{
+				WorktreeSourceDir:   "/repo/main",
+				WorktreeDestBaseDir: "/repo/main-worktree",
+				BranchPrefix:        "users/me/",
+			}
+			mockFS := &testutil.MockFS{ExistingPaths: tt.existingPaths}
+
+			cmd := NewRenameCommand(mockFS, git, cfg, nil)
+			result, err := cmd.Run(t.Context(), tt.oldName, tt.newName, tt.cwd, tt.opts)
+
+			args := strings.Join(captured, " ")
+			for _, want := range tt.wantArgs {
+				if !strings.Contains(args, want) {
+					t.Errorf("git args %q should contain %q", args, want)
+				}
+			}
+			for _, notWant := range tt.wantNotArgs {
+				if strings.Contains(args, notWant) {
+					t.Errorf("git args %q should not contain %q", args, notWant)
+				}
+			}
+
+			if tt.errContains != "" {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				if !strings.Contains(err.Error(), tt.errContains) {
+					t.Errorf("error = %q, want to contain %q", err.Error(), tt.errContains)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if result.OldBranch != tt.wantResult.OldBranch || result.NewBranch != tt.wantResult.NewBranch {
+				t.Errorf("branch = %s -> %s, want %s -> %s",
+					result.OldBranch, result.NewBranch, tt.wantResult.OldBranch, tt.wantResult.NewBranch)
+			}
+			if result.OldPath != tt.wantResult.OldPath || result.NewPath != tt.wantResult.NewPath {
+				t.Errorf("path = %s -> %s, want %s -> %s",
+					result.OldPath, result.NewPath, tt.wantResult.OldPath, tt.wantResult.NewPath)
+			}
+			if result.OldUpstream != tt.wantResult.OldUpstream || result.NewUpstream != tt.wantResult.NewUpstream {
+				t.Errorf("upstream = %q -> %q, want %q -> %q",
+					result.OldUpstream, result.NewUpstream, tt.wantResult.OldUpstream, tt.wantResult.NewUpstream)
+			}
+			if result.UpstreamErr != nil {
+				t.Errorf("unexpected UpstreamErr: %v", result.UpstreamErr)
+			}
+			if result.CwdMoved != tt.wantResult.CwdMoved {
+				t.Errorf("CwdMoved = %v, want %v", result.CwdMoved, tt.wantResult.CwdMoved)
+			}
+		})
+	}
+}
+
+func TestRenameResult_Format(t *testing.T) {
+	t.Parallel()
+
+	base := RenameResult{
+		OldBranch: "feat/a",
+		NewBranch: "feat/b",
+		OldPath:   "/wt/feat/a",
+		NewPath:   "/wt/feat/b",
+	}
+
+	tests := []struct {
+		name       string
+		modify     func(r *RenameResult)
+		verbose    bool
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			name:       "default",
+			wantStdout: "twig rename: feat/a -> feat/b (/wt/feat/b)\n",
+		},
+		{
+			name: "verbose",
+			modify: func(r *RenameResult) {
+				r.CleanedDirs = []string{"/wt/feat"}
+				r.Symlinks = []string{"/wt/feat/b/.envrc"}
+				r.OldUpstream = "origin/feat/a"
+				r.NewUpstream = "origin/feat/b"
+			},
+			verbose: true,
+			wantStdout: "Renamed branch: feat/a -> feat/b\n" +
+				"Moved worktree: /wt/feat/a -> /wt/feat/b\n" +
+				"Removed empty directory: /wt/feat\n" +
+				"Updated symlink: /wt/feat/b/.envrc\n" +
+				"Updated upstream: origin/feat/a -> origin/feat/b\n" +
+				"twig rename: feat/a -> feat/b (/wt/feat/b)\n",
+		},
+		{
+			name: "upstream unset and cwd moved",
+			modify: func(r *RenameResult) {
+				r.OldUpstream = "origin/feat/a"
+				r.CwdMoved = true
+			},
+			wantStdout: "twig rename: feat/a -> feat/b (/wt/feat/b)\n",
+			wantStderr: "hint: feat/b no longer tracks origin/feat/a; to publish the new name, run:\n" +
+				"  git push -u origin feat/b\n" +
+				"hint: the current directory was moved; run:\n" +
+				"  cd /wt/feat/b\n",
+		},
+		{
+			name: "warnings",
+			modify: func(r *RenameResult) {
+				r.SymlinkErr = errors.New("failed to read /wt/feat/b")
+				r.OldUpstream = "origin/feat/a"
+				r.UpstreamErr = errors.New("failed to unset upstream of feat/b")
+			},
+			wantStdout: "twig rename: feat/a -> feat/b (/wt/feat/b)\n",
+			wantStderr: "warning: failed to read /wt/feat/b\n" +
+				"warning: failed to unset upstream of feat/b\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := base
+			if tt.modify != nil {
+				tt.modify(&r)
+			}
+			got := r.Format(FormatOptions{Verbose: tt.verbose})
+			if got.Stdout != tt.wantStdout {
+				t.Errorf("Stdout = %q, want %q", got.Stdout, tt.wantStdout)
+			}
+			if got.Stderr != tt.wantStderr {
+				t.Errorf("Stderr = %q, want %q", got.Stderr, tt.wantStderr)
+			}
+		})
+	}
+}
+
+func TestRepointSymlinks(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	oldDir := filepath.Join(root, "wt", "feat", "a")
+	newDir := filepath.Join(root, "wt", "b")
+	shared := filepath.Join(root, "main", ".envrc")
+	if err := os.MkdirAll(filepath.Dir(shared), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(shared, []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(newDir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(newDir, "sub", "file"), []byte("y"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Created as if from oldDir, then moved to newDir
+	outside, _ := filepath.Rel(oldDir, shared)
+	links := map[string]string{
+		".envrc":     outside,
+		"inside":     "sub/file",
+		"absolute":   shared,
+		"sub/nested": filepath.Join("..", outside),
+	}
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(newDir, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	updated, err := repointSymlinks(osFS{}, oldDir, newDir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(updated) != 2 {
+		t.Errorf("updated = %v, want .envrc and sub/nested", updated)
+	}
+	for name := range links {
+		data, err := os.ReadFile(filepath.Join(newDir, name))
+		if err != nil {
+			t.Errorf("%s does not resolve: %v", name, err)
+			continue
+		}
+		if name != "inside" && string(data) != "x" {
+			t.Errorf("%s content = %q, want %q", name, data, "x")
+		}
+	}
+	if target, _ := os.Readlink(filepath.Join(newDir, "inside")); target != "sub/file" {
+		t.Errorf("inside target = %q, should be unchanged", target)
+	}
+}
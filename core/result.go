@@ -0,0 +1,134 @@
+package core
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/708u/twig/format"
+)
+
+// FormatOptions configures output formatting.
+type FormatOptions = format.Options
+
+// FormatResult holds formatted output strings.
+type FormatResult = format.Result
+
+// Formatter formats command results.
+type Formatter = format.Formatter
+
+// worktreeIdentifier returns the name printed for a worktree in quiet
+// output: its branch, or its path when detached.
+func worktreeIdentifier(branch, path string) string {
+	if branch == "" {
+		return path
+	}
+	return branch
+}
+
+// WarningCode identifies the kind of a Warning, so callers can handle
+// warnings without matching message text. Codes are not renamed between
+// versions.
+type WarningCode string
+
+// Warning codes.
+const (
+	WarningSymlinkSkipped         WarningCode = "symlink_skipped"          // A symlink was not created
+	WarningSymlinkSource          WarningCode = "symlink_source"           // A symlink was created from a suspicious source
+	WarningCarryLeft              WarningCode = "carry_left"               // Carried changes were left in the source worktree
+	WarningSubmoduleSkipped       WarningCode = "submodule_skipped"        // Submodule initialization failed
+	WarningSubmoduleNoReference   WarningCode = "submodule_no_reference"   // A submodule was cloned without a reference repository
+	WarningSubmodulePathUnmatched WarningCode = "submodule_path_unmatched" // A submodule_paths entry matched no submodule
+	WarningUpstreamFailed         WarningCode = "upstream_failed"          // The upstream could not be set or updated
+	WarningAdoptedChanges         WarningCode = "adopted_changes"          // Adopted files differ from the branch
+	WarningReplacedLeft           WarningCode = "replaced_left"            // A replaced directory could not be deleted
+	WarningPROutdated             WarningCode = "pr_outdated"              // The local PR branch differs from the PR head
+	WarningCaseCollision          WarningCode = "case_collision"           // A branch or path differs from another only in case
+	WarningDescriptionFailed      WarningCode = "description_failed"       // The branch description could not be set
+	WarningProvenanceFailed       WarningCode = "provenance_failed"        // The provenance marker could not be written
+	WarningHookFailed             WarningCode = "hook_failed"              // A hook command failed
+	WarningRepointFailed          WarningCode = "repoint_failed"           // Symlinks could not be repointed
+	WarningAuditFailed            WarningCode = "audit_failed"             // Removals could not be recorded in the audit log
+	WarningAuditMalformed         WarningCode = "audit_malformed"          // Audit log lines could not be parsed
+	WarningFetchFailed            WarningCode = "fetch_failed"             // A remote could not be fetched
+	WarningForgeFailed            WarningCode = "forge_failed"             // PR states could not be looked up
+	WarningSquashCheckFailed      WarningCode = "squash_check_failed"      // A branch could not be checked for a squash merge
+	WarningSearchFailed           WarningCode = "search_failed"            // A worktree could not be searched
+)
+
+// Warning is a problem that did not make a command fail. Results collect
+// them with a Warnings method, and Format renders them on stderr.
+type Warning struct {
+	Code    WarningCode `json:"code"`
+	Subject string      `json:"subject,omitempty"` // Branch, path, submodule or hook the warning is about
+	Message string      `json:"message"`
+}
+
+// String formats the warning as printed on stderr.
+func (w Warning) String() string {
+	return "warning: " + w.Message
+}
+
+// writeWarnings writes each warning on its own line.
+func writeWarnings(w io.Writer, warnings []Warning) {
+	for _, warning := range warnings {
+		fmt.Fprintln(w, warning)
+	}
+}
+
+// symlinkWarnings returns the warnings of symlinks that were skipped or
+// created from a suspicious source.
+func symlinkWarnings(symlinks []SymlinkResult) []Warning {
+	var warnings []Warning
+	for _, s := range symlinks {
+		switch {
+		case s.Skipped:
+			warnings = append(warnings, Warning{Code: WarningSymlinkSkipped, Subject: s.Dst, Message: s.Reason})
+		case s.Warning != "":
+			warnings = append(warnings, Warning{Code: WarningSymlinkSource, Subject: s.Dst, Message: s.Warning})
+		}
+	}
+	return warnings
+}
+
+// Warnings returns the warnings of a submodule initialization.
+func (r SubmoduleInitResult) Warnings() []Warning {
+	var warnings []Warning
+	if r.Skipped {
+		warnings = append(warnings, Warning{Code: WarningSubmoduleSkipped, Message: r.Reason})
+	}
+	for _, sm := range r.NoReferenceSubmodules {
+		warnings = append(warnings, Warning{
+			Code:    WarningSubmoduleNoReference,
+			Subject: sm,
+			Message: fmt.Sprintf("submodule %s: reference not available, initialize in main worktree first", sm),
+		})
+	}
+	for _, p := range r.UnmatchedPaths {
+		warnings = append(warnings, Warning{
+			Code:    WarningSubmodulePathUnmatched,
+			Subject: p,
+			Message: fmt.Sprintf("submodule_paths entry %q matches no submodule", p),
+		})
+	}
+	return warnings
+}
+
+// warning returns the warning of a failed hook.
+func (h HookResult) warning() Warning {
+	return Warning{
+		Code:    WarningHookFailed,
+		Subject: h.Command,
+		Message: fmt.Sprintf("hook %q failed: %v", h.Command, h.Err),
+	}
+}
+
+// hookWarnings returns a warning for each hook that failed.
+func hookWarnings(hooks []HookResult) []Warning {
+	var warnings []Warning
+	for _, h := range hooks {
+		if h.Err != nil {
+			warnings = append(warnings, h.warning())
+		}
+	}
+	return warnings
+}
@@ -0,0 +1,120 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestResult_Warnings(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		warnings  []Warning
+		stderr    string
+		wantCodes []WarningCode
+	}{
+		{
+			name: "add",
+			warnings: AddResult{
+				Branch: "feat/a",
+				Symlinks: []SymlinkResult{
+					{Dst: "/wt/.envrc", Skipped: true, Reason: ".envrc does not exist, skipping"},
+					{Dst: "/wt/.tool-versions", Warning: ".tool-versions is a symlink chain"},
+				},
+				SubmoduleInit:  SubmoduleInitResult{NoReferenceSubmodules: []string{"lib"}},
+				DescriptionErr: errors.New("failed to set branch description"),
+				HookResults:    []HookResult{{Command: "make setup", Err: errors.New("exit status 2")}},
+			}.Warnings(),
+			wantCodes: []WarningCode{
+				WarningSymlinkSkipped,
+				WarningSymlinkSource,
+				WarningSubmoduleNoReference,
+				WarningDescriptionFailed,
+				WarningHookFailed,
+			},
+		},
+		{
+			name: "sync_check_has_none",
+			warnings: SyncResult{
+				Check:   true,
+				Targets: []SyncTargetResult{{Symlinks: []SymlinkResult{{Skipped: true, Reason: "missing"}}}},
+			}.Warnings(),
+		},
+		{
+			name: "sync_skips_failed_targets",
+			warnings: SyncResult{
+				Targets: []SyncTargetResult{
+					{Branch: "feat/a", SubmoduleInit: SubmoduleInitResult{UnmatchedPaths: []string{"vendor"}}},
+					{Branch: "feat/b", Err: errors.New("boom"), Symlinks: []SymlinkResult{{Skipped: true, Reason: "missing"}}},
+				},
+			}.Warnings(),
+			wantCodes: []WarningCode{WarningSubmodulePathUnmatched},
+		},
+		{
+			name: "clean_candidates",
+			warnings: CleanResult{
+				FetchErrs: []error{errors.New("failed to fetch origin")},
+				ForgeErr:  errors.New("gh not found"),
+				AuditErr:  errors.New("ignored before removal"),
+			}.Warnings(),
+			wantCodes: []WarningCode{WarningFetchFailed, WarningForgeFailed},
+		},
+		{
+			name: "clean_removals",
+			warnings: CleanResult{
+				Removed:   []RemovedWorktree{{Branch: "feat/a"}},
+				FetchErrs: []error{errors.New("shown before removal")},
+				AuditErr:  errors.New("failed to write audit log"),
+			}.Warnings(),
+			wantCodes: []WarningCode{WarningAuditFailed},
+		},
+		{
+			name: "adopt_prefixes_name",
+			warnings: AdoptResult{Worktrees: []AdoptedWorktree{{
+				Branch:     "feat/a",
+				RepointErr: errors.New("permission denied"),
+			}}}.Warnings(),
+			wantCodes: []WarningCode{WarningRepointFailed},
+			stderr:    "warning: feat/a: permission denied\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var codes []WarningCode
+			for _, w := range tt.warnings {
+				codes = append(codes, w.Code)
+			}
+			if !slices.Equal(codes, tt.wantCodes) {
+				t.Errorf("codes = %v, want %v", codes, tt.wantCodes)
+			}
+			if tt.stderr != "" {
+				var stderr strings.Builder
+				writeWarnings(&stderr, tt.warnings)
+				if stderr.String() != tt.stderr {
+					t.Errorf("stderr = %q, want %q", stderr.String(), tt.stderr)
+				}
+			}
+		})
+	}
+}
+
+func TestWarning_JSON(t *testing.T) {
+	t.Parallel()
+
+	w := Warning{Code: WarningHookFailed, Subject: "make setup", Message: `hook "make setup" failed: exit status 2`}
+	data, err := json.Marshal(w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"code":"hook_failed","subject":"make setup","message":"hook \"make setup\" failed: exit status 2"}`
+	if string(data) != want {
+		t.Errorf("json = %s, want %s", data, want)
+	}
+}
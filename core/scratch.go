@@ -0,0 +1,168 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// scratchDirName is stored under <git-common-dir>/twig. Keeping scratch
+	// space inside the repository keeps it on the same filesystem as the
+	// worktrees, so finished files can be moved into place with a rename.
+	scratchDirName = "tmp"
+
+	// scratchOwnerFileName records the process that created a scratch dir.
+	scratchOwnerFileName = ".owner"
+
+	// scratchOrphanAge is how old a scratch dir without a readable owner
+	// must be before it counts as a leftover. The owner file is written
+	// right after the directory is created, so only a crash in between
+	// leaves a dir without one.
+	scratchOrphanAge = time.Hour
+)
+
+// ScratchDir is a private temporary directory for one twig operation.
+// Directories are created under <git-common-dir>/twig/tmp, named
+// "<purpose>-<random>", and record their owner so that leftovers from
+// crashed processes can be found by twig doctor.
+type ScratchDir struct {
+	Path string
+}
+
+// ScratchDirOptions configures scratch dir creation.
+type ScratchDirOptions struct {
+	Purpose string // Short name of the operation (e.g. "carry"), used as the dir prefix
+	Command string // Command name recorded for twig doctor
+	Log     *slog.Logger
+}
+
+// CreateScratchDir creates a new scratch dir in the repository whose git
+// common dir is commonDir. Concurrent callers always get distinct
+// directories. The caller must Remove it when done; prefer WithScratchDir.
+func CreateScratchDir(ctx context.Context, commonDir string, opts ScratchDirOptions) (*ScratchDir, error) {
+	log := opts.Log
+	if log == nil {
+		log = NewNopLogger()
+	}
+	if opts.Purpose == "" || strings.ContainsAny(opts.Purpose, `/\`) {
+		return nil, fmt.Errorf("invalid scratch dir purpose %q", opts.Purpose)
+	}
+
+	base := filepath.Join(commonDir, auditDirName, scratchDirName)
+	if err := os.MkdirAll(base, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create scratch base dir: %w", err)
+	}
+	path, err := os.MkdirTemp(base, opts.Purpose+"-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create scratch dir: %w", err)
+	}
+
+	host, _ := os.Hostname()
+	data, err := json.Marshal(lockOwner{
+		PID:       os.Getpid(),
+		Host:      host,
+		Command:   opts.Command,
+		StartedAt: time.Now(),
+	})
+	if err == nil {
+		err = os.WriteFile(filepath.Join(path, scratchOwnerFileName), data, 0644)
+	}
+	if err != nil {
+		os.RemoveAll(path)
+		return nil, fmt.Errorf("failed to record scratch dir owner: %w", err)
+	}
+
+	log.DebugContext(ctx, "created scratch dir",
+		LogAttrKeyCategory.String(), LogCategoryScratch,
+		"path", path)
+	return &ScratchDir{Path: path}, nil
+}
+
+// Remove deletes the scratch dir and everything in it.
+func (s *ScratchDir) Remove() error {
+	if err := os.RemoveAll(s.Path); err != nil {
+		return fmt.Errorf("failed to remove scratch dir: %w", err)
+	}
+	return nil
+}
+
+// WithScratchDir runs fn with a new scratch dir and removes the dir
+// afterwards, whether fn succeeds or fails. An error from fn takes
+// precedence over a failure to remove the dir.
+func WithScratchDir(ctx context.Context, commonDir string, opts ScratchDirOptions, fn func(dir string) error) error {
+	scratch, err := CreateScratchDir(ctx, commonDir, opts)
+	if err != nil {
+		return err
+	}
+
+	fnErr := fn(scratch.Path)
+	removeErr := scratch.Remove()
+	if fnErr != nil {
+		if removeErr != nil && opts.Log != nil {
+			opts.Log.DebugContext(ctx, "failed to remove scratch dir after error",
+				LogAttrKeyCategory.String(), LogCategoryScratch,
+				"path", scratch.Path,
+				"error", removeErr.Error())
+		}
+		return fnErr
+	}
+	return removeErr
+}
+
+// ScratchLeftover is a scratch dir whose owning process is gone.
+type ScratchLeftover struct {
+	Path      string
+	Command   string    // Command that created the dir (empty if unknown)
+	PID       int       // Process that created the dir (0 if unknown)
+	StartedAt time.Time // When the dir was created (modification time if unknown)
+}
+
+// FindScratchLeftovers returns scratch dirs left behind by twig processes
+// that exited without removing them (e.g. killed or crashed). Dirs owned
+// by a running process, or by a process on another host, are not reported.
+func FindScratchLeftovers(commonDir string, now time.Time) ([]ScratchLeftover, error) {
+	base := filepath.Join(commonDir, auditDirName, scratchDirName)
+	entries, err := os.ReadDir(base)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read scratch dirs: %w", err)
+	}
+
+	host, _ := os.Hostname()
+	var leftovers []ScratchLeftover
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		path := filepath.Join(base, entry.Name())
+
+		owner, err := readLockOwner(filepath.Join(path, scratchOwnerFileName))
+		if err != nil {
+			info, statErr := entry.Info()
+			if statErr != nil || now.Sub(info.ModTime()) < scratchOrphanAge {
+				continue
+			}
+			leftovers = append(leftovers, ScratchLeftover{Path: path, StartedAt: info.ModTime()})
+			continue
+		}
+		if owner.Host != host || processAlive(owner.PID) {
+			continue
+		}
+		leftovers = append(leftovers, ScratchLeftover{
+			Path:      path,
+			Command:   owner.Command,
+			PID:       owner.PID,
+			StartedAt: owner.StartedAt,
+		})
+	}
+	return leftovers, nil
+}
@@ -0,0 +1,187 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCreateScratchDir(t *testing.T) {
+	t.Parallel()
+
+	t.Run("distinct dirs with owner", func(t *testing.T) {
+		t.Parallel()
+
+		commonDir := t.TempDir()
+		opts := ScratchDirOptions{Purpose: "carry", Command: "add"}
+
+		a, err := CreateScratchDir(t.Context(), commonDir, opts)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		b, err := CreateScratchDir(t.Context(), commonDir, opts)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if a.Path == b.Path {
+			t.Fatalf("scratch dirs share path %s", a.Path)
+		}
+
+		base := filepath.Join(commonDir, auditDirName, scratchDirName)
+		if filepath.Dir(a.Path) != base || !strings.HasPrefix(filepath.Base(a.Path), "carry-") {
+			t.Errorf("Path = %s, want %s/carry-*", a.Path, base)
+		}
+		owner, err := readLockOwner(filepath.Join(a.Path, scratchOwnerFileName))
+		if err != nil {
+			t.Fatalf("owner not readable: %v", err)
+		}
+		if owner.PID != os.Getpid() || owner.Command != "add" {
+			t.Errorf("owner = %+v, want pid %d and command add", owner, os.Getpid())
+		}
+
+		if err := a.Remove(); err != nil {
+			t.Fatalf("Remove failed: %v", err)
+		}
+		if _, err := os.Stat(a.Path); !os.IsNotExist(err) {
+			t.Errorf("scratch dir should be removed, stat err = %v", err)
+		}
+		if _, err := os.Stat(b.Path); err != nil {
+			t.Errorf("other scratch dir should remain: %v", err)
+		}
+	})
+
+	t.Run("invalid purpose", func(t *testing.T) {
+		t.Parallel()
+
+		for _, purpose := range []string{"", "a/b"} {
+			if _, err := CreateScratchDir(t.Context(), t.TempDir(), ScratchDirOptions{Purpose: purpose}); err == nil {
+				t.Errorf("purpose %q: expected error", purpose)
+			}
+		}
+	})
+}
+
+func TestWithScratchDir(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		fnErr   error
+		wantErr bool
+	}{
+		{name: "success"},
+		{name: "failure", fnErr: errors.New("boom"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var used string
+			err := WithScratchDir(t.Context(), t.TempDir(), ScratchDirOptions{Purpose: "bundle"}, func(dir string) error {
+				used = dir
+				if err := os.WriteFile(filepath.Join(dir, "data"), []byte("x"), 0644); err != nil {
+					t.Fatal(err)
+				}
+				return tt.fnErr
+			})
+			if (err != nil) != tt.wantErr || (tt.fnErr != nil && !errors.Is(err, tt.fnErr)) {
+				t.Errorf("error = %v, want %v", err, tt.fnErr)
+			}
+			if _, err := os.Stat(used); !os.IsNotExist(err) {
+				t.Errorf("scratch dir should be removed, stat err = %v", err)
+			}
+		})
+	}
+}
+
+func TestFindScratchLeftovers(t *testing.T) {
+	t.Parallel()
+
+	exited := exec.Command("true")
+	if err := exited.Run(); err != nil {
+		t.Skipf("cannot run helper process: %v", err)
+	}
+	host, _ := os.Hostname()
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		owner   *lockOwner    // nil = no owner file
+		age     time.Duration // age of an ownerless dir
+		want    bool
+		wantPID int
+	}{
+		{
+			name:    "exited process",
+			owner:   &lockOwner{PID: exited.Process.Pid, Host: host, Command: "add"},
+			want:    true,
+			wantPID: exited.Process.Pid,
+		},
+		{
+			name:  "running process",
+			owner: &lockOwner{PID: os.Getpid(), Host: host, Command: "add"},
+		},
+		{
+			name:  "other host",
+			owner: &lockOwner{PID: exited.Process.Pid, Host: "other-host", Command: "add"},
+		},
+		{
+			name: "recent dir without owner",
+			age:  time.Minute,
+		},
+		{
+			name: "old dir without owner",
+			age:  2 * scratchOrphanAge,
+			want: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			commonDir := t.TempDir()
+			path := filepath.Join(commonDir, auditDirName, scratchDirName, "carry-1")
+			if err := os.MkdirAll(path, 0755); err != nil {
+				t.Fatal(err)
+			}
+			if tt.owner != nil {
+				data, err := json.Marshal(tt.owner)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(filepath.Join(path, scratchOwnerFileName), data, 0644); err != nil {
+					t.Fatal(err)
+				}
+			} else if err := os.Chtimes(path, now.Add(-tt.age), now.Add(-tt.age)); err != nil {
+				t.Fatal(err)
+			}
+
+			leftovers, err := FindScratchLeftovers(commonDir, now)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := len(leftovers) == 1; got != tt.want {
+				t.Fatalf("leftovers = %+v, want found = %v", leftovers, tt.want)
+			}
+			if tt.want && (leftovers[0].Path != path || leftovers[0].PID != tt.wantPID) {
+				t.Errorf("leftover = %+v, want path %s pid %d", leftovers[0], path, tt.wantPID)
+			}
+		})
+	}
+
+	t.Run("no scratch dirs", func(t *testing.T) {
+		t.Parallel()
+
+		leftovers, err := FindScratchLeftovers(t.TempDir(), now)
+		if err != nil || leftovers != nil {
+			t.Errorf("FindScratchLeftovers() = %v, %v, want nil, nil", leftovers, err)
+		}
+	})
+}
@@ -0,0 +1,92 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// defaultSourceRemote is the remote whose HEAD suggests default_source.
+const defaultSourceRemote = "origin"
+
+// defaultSourceFallbacks are the conventional default branch names tried
+// when the remote HEAD is unknown.
+var defaultSourceFallbacks = []string{"main", "master"}
+
+// InferredSource is a default_source guessed from the repository.
+type InferredSource struct {
+	Branch string
+	Reason string // How the branch was chosen (e.g. "origin/HEAD")
+}
+
+// InferDefaultSource guesses default_source when it is not configured:
+// the default branch of origin, then main, then master. Only branches
+// checked out in a worktree qualify, since the source must be a worktree.
+// Returns nil if no branch qualifies.
+func InferDefaultSource(ctx context.Context, git *GitRunner) (*InferredSource, error) {
+	branches, err := git.WorktreeListBranches(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	remoteHEAD, err := git.RemoteHEADBranch(ctx, defaultSourceRemote)
+	if err != nil {
+		return nil, err
+	}
+	if remoteHEAD != "" && slices.Contains(branches, remoteHEAD) {
+		return &InferredSource{Branch: remoteHEAD, Reason: defaultSourceRemote + "/HEAD"}, nil
+	}
+
+	for _, name := range defaultSourceFallbacks {
+		if slices.Contains(branches, name) {
+			return &InferredSource{Branch: name, Reason: "branch " + name + " exists"}, nil
+		}
+	}
+	return nil, nil
+}
+
+// SaveDefaultSource records branch as default_source in the local config
+// file in dir and returns the file path. The file is created if needed;
+// the key is inserted before the first table so that it stays top-level.
+func SaveDefaultSource(dir, branch string) (string, error) {
+	path := filepath.Join(dir, configDir, localConfigFileName)
+
+	cfg, _, err := loadConfigFile(path)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", path, err)
+	}
+	if cfg != nil && cfg.DefaultSource != "" {
+		return "", fmt.Errorf("%s already sets default_source = %q", path, cfg.DefaultSource)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+	line := "default_source = " + strconv.Quote(branch) + "\n"
+
+	lines := strings.SplitAfter(string(content), "\n")
+	insertAt := len(lines)
+	for i, l := range lines {
+		if strings.HasPrefix(strings.TrimSpace(l), "[") {
+			insertAt = i
+			break
+		}
+	}
+	if insertAt == len(lines) && len(content) > 0 && !strings.HasSuffix(string(content), "\n") {
+		line = "\n" + line
+	}
+	updated := strings.Join(slices.Insert(lines, insertAt, line), "")
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, []byte(updated), 0644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
@@ -0,0 +1,148 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/708u/twig/internal/testutil"
+)
+
+func TestInferDefaultSource(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		worktrees   []testutil.MockWorktree
+		remoteHEADs map[string]string
+		want        *InferredSource
+	}{
+		{
+			name: "remote HEAD checked out",
+			worktrees: []testutil.MockWorktree{
+				{Path: "/repo/main", Branch: "main"},
+				{Path: "/repo/develop", Branch: "develop"},
+			},
+			remoteHEADs: map[string]string{"origin": "develop"},
+			want:        &InferredSource{Branch: "develop", Reason: "origin/HEAD"},
+		},
+		{
+			name: "remote HEAD not checked out falls back to main",
+			worktrees: []testutil.MockWorktree{
+				{Path: "/repo/main", Branch: "main"},
+			},
+			remoteHEADs: map[string]string{"origin": "develop"},
+			want:        &InferredSource{Branch: "main", Reason: "branch main exists"},
+		},
+		{
+			name: "master",
+			worktrees: []testutil.MockWorktree{
+				{Path: "/repo/master", Branch: "master"},
+				{Path: "/repo/feat", Branch: "feat/a"},
+			},
+			want: &InferredSource{Branch: "master", Reason: "branch master exists"},
+		},
+		{
+			name: "nothing qualifies",
+			worktrees: []testutil.MockWorktree{
+				{Path: "/repo/trunk", Branch: "trunk"},
+			},
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mock := &testutil.MockGitExecutor{Worktrees: tt.worktrees, RemoteHEADs: tt.remoteHEADs}
+			got, err := InferDefaultSource(t.Context(), &GitRunner{Executor: mock, Log: NewNopLogger()})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+				t.Errorf("InferDefaultSource() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSaveDefaultSource(t *testing.T) {
+	t.Parallel()
+
+	strPtr := func(s string) *string { return &s }
+
+	tests := []struct {
+		name    string
+		local   *string // nil: no local config file
+		want    string
+		wantErr string
+	}{
+		{
+			name: "creates file",
+			want: "default_source = \"main\"\n",
+		},
+		{
+			name:  "inserts before first table",
+			local: strPtr("symlinks = [\".envrc\"]\n\n[profiles.lite]\nhooks = []\n"),
+			want:  "symlinks = [\".envrc\"]\n\ndefault_source = \"main\"\n[profiles.lite]\nhooks = []\n",
+		},
+		{
+			name:  "appends without trailing newline",
+			local: strPtr("branch_prefix = \"me/\""),
+			want:  "branch_prefix = \"me/\"\ndefault_source = \"main\"\n",
+		},
+		{
+			name:    "already set",
+			local:   strPtr("default_source = \"develop\"\n"),
+			wantErr: `already sets default_source = "develop"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			path := filepath.Join(dir, configDir, localConfigFileName)
+			if tt.local != nil {
+				if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, []byte(*tt.local), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got, err := SaveDefaultSource(dir, "main")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != path {
+				t.Errorf("path = %q, want %q", got, path)
+			}
+			content, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(content) != tt.want {
+				t.Errorf("content = %q, want %q", content, tt.want)
+			}
+
+			result, err := LoadConfig(dir, WithGetenv(mapGetenv(nil)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Config.DefaultSource != "main" {
+				t.Errorf("DefaultSource = %q, want main", result.Config.DefaultSource)
+			}
+		})
+	}
+}
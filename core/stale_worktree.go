@@ -0,0 +1,99 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// lockReasonInitializing is the lock reason git worktree add sets while
+// it creates a worktree. It is left behind when the add is interrupted.
+const lockReasonInitializing = "initializing"
+
+// StaleWorktreeError is returned by twig add when the administrative
+// files of a worktree that no longer exists (.git/worktrees/<name>) block
+// the new worktree, typically after an interrupted twig add.
+type StaleWorktreeError struct {
+	Worktree Worktree
+}
+
+func (e *StaleWorktreeError) Error() string {
+	wt := e.Worktree
+	reason := "its directory is missing"
+	if isInterruptedWorktree(wt) {
+		reason = "its creation was interrupted"
+	}
+	owner := ""
+	if wt.Branch != "" {
+		owner = " of " + wt.Branch
+	}
+	return fmt.Sprintf("stale worktree entry%s at %s blocks the new worktree (%s); "+
+		"rerun with --repair to prune it", owner, wt.Path, reason)
+}
+
+// isInterruptedWorktree reports whether wt was left locked by a git
+// worktree add that did not finish.
+func isInterruptedWorktree(wt Worktree) bool {
+	return wt.Locked && wt.LockReason == lockReasonInitializing
+}
+
+// findStaleWorktree returns the stale worktree entry registered at wtPath
+// or for branch, or nil. An entry is stale when git reports it prunable,
+// or when an interrupted git worktree add left it locked. branch is empty
+// for detached worktrees.
+func (c *AddCommand) findStaleWorktree(ctx context.Context, branch, wtPath string) (*Worktree, error) {
+	worktrees, err := c.Git.WorktreeList(ctx)
+	if err != nil {
+		return nil, err
+	}
+	wtPath = filepath.Clean(wtPath)
+	for i, wt := range worktrees {
+		if i == 0 || !(wt.Prunable || isInterruptedWorktree(wt)) {
+			continue
+		}
+		if filepath.Clean(wt.Path) == wtPath || (branch != "" && wt.Branch == branch) {
+			return &worktrees[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// pruneWorktreeEntry removes the administrative files of the stale
+// worktree wt, like git worktree prune does, but for this entry only.
+// The worktree directory itself, if any is left, is not touched.
+func (c *AddCommand) pruneWorktreeEntry(ctx context.Context, wt Worktree) error {
+	commonDir, err := c.Git.GitCommonDir(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to find the git directory: %w", err)
+	}
+	adminRoot := filepath.Join(commonDir, "worktrees")
+	entries, err := c.FS.ReadDir(adminRoot)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", adminRoot, err)
+	}
+	for _, e := range entries {
+		adminDir := filepath.Join(adminRoot, e.Name())
+		data, err := c.FS.ReadFile(filepath.Join(adminDir, "gitdir"))
+		if err != nil {
+			continue
+		}
+		// gitdir holds the path of <worktree>/.git, relative to adminDir
+		// with worktree.useRelativePaths
+		gitFile := strings.TrimSpace(string(data))
+		if !filepath.IsAbs(gitFile) {
+			gitFile = filepath.Join(adminDir, gitFile)
+		}
+		if filepath.Clean(filepath.Dir(gitFile)) != filepath.Clean(wt.Path) {
+			continue
+		}
+		c.Log.DebugContext(ctx, "pruning stale worktree entry",
+			"path", wt.Path,
+			"admin_dir", adminDir)
+		if err := c.FS.RemoveAll(adminDir); err != nil {
+			return fmt.Errorf("failed to prune stale worktree entry %s: %w", adminDir, err)
+		}
+		return nil
+	}
+	return fmt.Errorf("cannot find the worktree entry for %s in %s", wt.Path, adminRoot)
+}
@@ -0,0 +1,73 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// branchStatusFormat lists every local branch with its commit, upstream
+// and tracking state in one for-each-ref call. Fields are NUL-separated
+// because upstream names may contain spaces in odd remote setups.
+const branchStatusFormat = "--format=%(refname:short)%00%(objectname)%00%(upstream:short)%00%(upstream:track,nobracket)"
+
+// upstreamTrackGone is reported by %(upstream:track) when the upstream
+// ref no longer exists, typically after the remote branch was deleted.
+const upstreamTrackGone = "gone"
+
+// BranchStatus is the tracking state of a local branch.
+type BranchStatus struct {
+	Branch   string // Local branch name
+	Commit   string // Commit the branch points to
+	Upstream string // Upstream ref (e.g. "origin/feat/a"), empty if none
+	Gone     bool   // Upstream is configured but no longer exists
+	Ahead    int    // Commits on the branch not on its upstream
+	Behind   int    // Commits on the upstream not on the branch
+}
+
+// BranchStatuses returns the tracking state of every local branch from a
+// single git for-each-ref call.
+func (g *GitRunner) BranchStatuses(ctx context.Context) (map[string]BranchStatus, error) {
+	out, err := g.Run(ctx, GitCmdForEachRef, branchStatusFormat, "refs/heads/")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get branch info: %w", err)
+	}
+	return parseBranchStatuses(string(out)), nil
+}
+
+// parseBranchStatuses parses the output of branchStatusFormat.
+func parseBranchStatuses(out string) map[string]BranchStatus {
+	statuses := make(map[string]BranchStatus)
+	for line := range strings.SplitSeq(out, "\n") {
+		fields := strings.Split(line, "\x00")
+		if len(fields) < 2 || fields[0] == "" {
+			continue
+		}
+		status := BranchStatus{Branch: fields[0], Commit: fields[1]}
+		if len(fields) > 2 {
+			status.Upstream = fields[2]
+		}
+		if len(fields) > 3 {
+			parseUpstreamTrack(&status, fields[3])
+		}
+		statuses[status.Branch] = status
+	}
+	return statuses
+}
+
+// parseUpstreamTrack parses %(upstream:track,nobracket), which is empty
+// when in sync, "gone", or "ahead N", "behind N" or "ahead N, behind M".
+func parseUpstreamTrack(status *BranchStatus, track string) {
+	for part := range strings.SplitSeq(track, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), " ")
+		switch key {
+		case upstreamTrackGone:
+			status.Gone = true
+		case "ahead":
+			status.Ahead, _ = strconv.Atoi(value)
+		case "behind":
+			status.Behind, _ = strconv.Atoi(value)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package core
+
+import (
+	"maps"
+	"testing"
+
+	"github.com/708u/twig/internal/testutil"
+)
+
+func TestParseBranchStatuses(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		out  string
+		want map[string]BranchStatus
+	}{
+		{
+			name: "no_upstream",
+			out:  "main\x00abc123\x00\x00\n",
+			want: map[string]BranchStatus{
+				"main": {Branch: "main", Commit: "abc123"},
+			},
+		},
+		{
+			name: "in_sync",
+			out:  "feat/a\x00abc123\x00origin/feat/a\x00\n",
+			want: map[string]BranchStatus{
+				"feat/a": {Branch: "feat/a", Commit: "abc123", Upstream: "origin/feat/a"},
+			},
+		},
+		{
+			name: "ahead_and_behind",
+			out:  "feat/a\x00abc123\x00origin/feat/a\x00ahead 2, behind 3\n",
+			want: map[string]BranchStatus{
+				"feat/a": {Branch: "feat/a", Commit: "abc123", Upstream: "origin/feat/a", Ahead: 2, Behind: 3},
+			},
+		},
+		{
+			name: "behind_only",
+			out:  "feat/a\x00abc123\x00origin/feat/a\x00behind 1\n",
+			want: map[string]BranchStatus{
+				"feat/a": {Branch: "feat/a", Commit: "abc123", Upstream: "origin/feat/a", Behind: 1},
+			},
+		},
+		{
+			name: "gone",
+			out:  "feat/a\x00abc123\x00origin/feat/a\x00gone\nmain\x00def456\x00\x00\n",
+			want: map[string]BranchStatus{
+				"feat/a": {Branch: "feat/a", Commit: "abc123", Upstream: "origin/feat/a", Gone: true},
+				"main":   {Branch: "main", Commit: "def456"},
+			},
+		},
+		{
+			name: "empty",
+			out:  "\n",
+			want: map[string]BranchStatus{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := parseBranchStatuses(tt.out)
+			if !maps.Equal(got, tt.want) {
+				t.Errorf("parseBranchStatuses() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGitRunner_BranchStatuses(t *testing.T) {
+	t.Parallel()
+
+	mockGit := &testutil.MockGitExecutor{
+		Worktrees: []testutil.MockWorktree{
+			{Path: "/repo/main", Branch: "main", HEAD: "aaa"},
+			{Path: "/repo/feat/a", Branch: "feat/a", HEAD: "bbb"},
+		},
+		Upstreams:            map[string]string{"feat/a": "origin/feat/a"},
+		UpstreamGoneBranches: []string{"feat/a"},
+	}
+	git := &GitRunner{Executor: mockGit, Log: NewNopLogger()}
+
+	branches, err := git.BranchStatuses(t.Context())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := BranchStatus{Branch: "feat/a", Commit: "bbb", Upstream: "origin/feat/a", Gone: true}
+	if got := branches["feat/a"]; got != want {
+		t.Errorf("branches[feat/a] = %+v, want %+v", got, want)
+	}
+	if got := branches["main"]; got.Commit != "aaa" || got.Upstream != "" {
+		t.Errorf("branches[main] = %+v, want commit aaa without upstream", got)
+	}
+}
@@ -0,0 +1,368 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+)
+
+// Values of the symlink_style setting.
+const (
+	// SymlinkStyleRelative links by a path relative to the link, so links
+	// keep working when the repository is reached by another path, as in
+	// a container bind mount.
+	SymlinkStyleRelative = "relative"
+	// SymlinkStyleAbsolute links by the absolute path of the source.
+	SymlinkStyleAbsolute = "absolute"
+)
+
+// SupportedSymlinkStyles lists the valid values of the symlink_style
+// setting.
+var SupportedSymlinkStyles = []string{SymlinkStyleRelative, SymlinkStyleAbsolute}
+
+// symlinkTarget returns the target of a link at dst to src in style.
+func symlinkTarget(src, dst, style string) (string, error) {
+	if style == SymlinkStyleAbsolute {
+		return src, nil
+	}
+	return filepath.Rel(filepath.Dir(dst), src)
+}
+
+// trackedPaths is the set of paths tracked in a worktree's branch.
+// Parent directories of tracked files are included so that symlinking
+// a directory containing tracked content is also detected.
+type trackedPaths map[string]struct{}
+
+// loadTrackedPaths returns the tracked paths of the worktree git runs in.
+func loadTrackedPaths(ctx context.Context, git *GitRunner) (trackedPaths, error) {
+	files, err := git.TrackedFiles(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tracked := make(trackedPaths, len(files))
+	for _, f := range files {
+		for p := filepath.Clean(f); p != "." && p != string(filepath.Separator); p = filepath.Dir(p) {
+			if _, ok := tracked[p]; ok {
+				break
+			}
+			tracked[p] = struct{}{}
+		}
+	}
+	return tracked, nil
+}
+
+// contains reports whether rel is tracked or is a directory containing tracked files.
+func (t trackedPaths) contains(rel string) bool {
+	_, ok := t[filepath.Clean(rel)]
+	return ok
+}
+
+// trackedSkipReason returns the skip reason for a symlink that would shadow tracked content.
+func trackedSkipReason(match string) string {
+	return fmt.Sprintf("skipping symlink for %s (tracked in target branch, exclude it from symlinks)", match)
+}
+
+// maxSymlinkHops bounds how many symlinks are followed when resolving a
+// symlink source, matching the usual ELOOP limit.
+const maxSymlinkHops = 40
+
+// symlinkSourceIssue reports why linking match in srcDir could expose files
+// other than the ones configured: the source is reached through a chain of
+// two or more symlinks, or any hop resolves outside srcDir (e.g. into another
+// repository). Symlinked parent directories are followed as well. An empty
+// result means the source is safe to link. Targets are compared lexically.
+func symlinkSourceIssue(fsys FileSystem, srcDir, match string) string {
+	srcDir = filepath.Clean(srcDir)
+	cur := srcDir
+	hops := 0
+	outside := ""
+
+	for _, elem := range strings.Split(filepath.Clean(match), string(filepath.Separator)) {
+		cur = filepath.Join(cur, elem)
+		for {
+			info, err := fsys.Lstat(cur)
+			if err != nil || info == nil || info.Mode()&fs.ModeSymlink == 0 {
+				break
+			}
+			hops++
+			if hops > maxSymlinkHops {
+				return "has too many levels of symlinks"
+			}
+			target, err := fsys.Readlink(cur)
+			if err != nil {
+				break
+			}
+			if !filepath.IsAbs(target) {
+				target = filepath.Join(filepath.Dir(cur), target)
+			}
+			cur = filepath.Clean(target)
+			if outside == "" && !isWithinDir(srcDir, cur) {
+				outside = cur
+			}
+		}
+	}
+
+	switch {
+	case outside != "":
+		return fmt.Sprintf("resolves outside the source worktree (%s)", outside)
+	case hops >= 2:
+		return fmt.Sprintf("is a chain of %d symlinks", hops)
+	}
+	return ""
+}
+
+// isWithinDir reports whether path is dir or below it.
+func isWithinDir(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// sourceIssueWarning returns the warning for a link created despite issue.
+func sourceIssueWarning(match, issue string) string {
+	return fmt.Sprintf("symlink source %s %s", match, issue)
+}
+
+// strictSkipReason returns the skip reason for a link refused by strict_symlinks.
+func strictSkipReason(match, issue string) string {
+	return fmt.Sprintf("skipping symlink for %s (source %s, strict_symlinks is enabled)", match, issue)
+}
+
+// createSymlinks creates symlinks from srcDir to dstDir based on glob patterns.
+// Existing symlinks are replaced. Regular files are skipped to prevent data loss.
+// Paths in tracked are skipped since a symlink would shadow content committed
+// in the target branch. A nil tracked disables the check.
+// Sources reached through a symlink chain or outside srcDir are linked with
+// a warning, or skipped when strict is set. style is a symlink_style value;
+// links are relative unless it is SymlinkStyleAbsolute.
+// Returns results for each symlink operation.
+func createSymlinks(fsys FileSystem, srcDir, dstDir string, patterns []string, tracked trackedPaths, strict bool, style string) ([]SymlinkResult, error) {
+	var results []SymlinkResult
+
+	for _, pattern := range patterns {
+		matches, err := fsys.Glob(srcDir, pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid glob pattern %s: %w", pattern, err)
+		}
+		if len(matches) == 0 {
+			results = append(results, SymlinkResult{
+				Skipped: true,
+				Reason:  fmt.Sprintf("%s does not match any files, skipping", pattern),
+			})
+			continue
+		}
+
+		for _, match := range matches {
+			src := filepath.Join(srcDir, match)
+			dst := filepath.Join(dstDir, match)
+			dstParent := filepath.Dir(dst)
+
+			if tracked.contains(match) {
+				results = append(results, SymlinkResult{
+					Src:     src,
+					Dst:     dst,
+					Skipped: true,
+					Reason:  trackedSkipReason(match),
+				})
+				continue
+			}
+
+			var warning string
+			if issue := symlinkSourceIssue(fsys, srcDir, match); issue != "" {
+				if strict {
+					results = append(results, SymlinkResult{
+						Src:     src,
+						Dst:     dst,
+						Skipped: true,
+						Reason:  strictSkipReason(match, issue),
+					})
+					continue
+				}
+				warning = sourceIssueWarning(match, issue)
+			}
+
+			// Check if destination already exists
+			if info, err := fsys.Lstat(dst); err == nil && info != nil {
+				isSymlink := info.Mode()&fs.ModeSymlink != 0
+				if isSymlink {
+					// Remove existing symlink and recreate
+					if err := fsys.Remove(dst); err != nil {
+						return nil, fmt.Errorf("failed to remove existing symlink for %s: %w", match, err)
+					}
+				} else {
+					// Skip regular files to prevent data loss
+					results = append(results, SymlinkResult{
+						Src:     src,
+						Dst:     dst,
+						Skipped: true,
+						Reason:  fmt.Sprintf("skipping symlink for %s (regular file exists)", match),
+					})
+					continue
+				}
+			}
+
+			if dstParent != dstDir {
+				if err := fsys.MkdirAll(dstParent, 0755); err != nil {
+					return nil, fmt.Errorf("failed to create directory for %s: %w", match, err)
+				}
+			}
+
+			target, err := symlinkTarget(src, dst, style)
+			if err != nil {
+				return nil, fmt.Errorf("failed to compute relative path for %s: %w", match, err)
+			}
+			if err := fsys.Symlink(target, dst); err != nil {
+				return nil, fmt.Errorf("failed to create symlink for %s: %w", match, err)
+			}
+
+			results = append(results, SymlinkResult{Src: src, Dst: dst, Warning: warning})
+		}
+	}
+
+	return results, nil
+}
+
+// Reasons a twig-managed symlink is stale.
+const (
+	staleReasonSourceMissing = "source missing"
+	staleReasonUnmatched     = "not in symlinks config"
+)
+
+// StaleSymlink is a twig-managed symlink that no longer belongs in a worktree.
+type StaleSymlink struct {
+	Path   string // Symlink path in the target worktree
+	Target string // Absolute path the symlink points to
+	Reason string
+}
+
+// findStaleSymlinks returns twig-managed symlinks in dstDir that are broken
+// or no longer matched by patterns in srcDir. A symlink is twig-managed if it
+// points to the same relative path in srcDir, which is how createSymlinks
+// links files. Symlinks are not followed, and .git directories and nested
+// repositories (worktrees, submodules) are not entered.
+func findStaleSymlinks(fsys FileSystem, srcDir, dstDir string, patterns []string) ([]StaleSymlink, error) {
+	matched := make(map[string]bool)
+	for _, pattern := range patterns {
+		matches, err := fsys.Glob(srcDir, pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid glob pattern %s: %w", pattern, err)
+		}
+		for _, m := range matches {
+			matched[filepath.Clean(m)] = true
+		}
+	}
+
+	var stale []StaleSymlink
+	err := walkSymlinks(fsys, dstDir, func(p string) error {
+		if s, ok := staleSymlink(fsys, srcDir, dstDir, p, matched); ok {
+			stale = append(stale, s)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stale, nil
+}
+
+// walkSymlinks calls fn for each symlink under root. Symlinks are not
+// followed, and .git directories and nested repositories (worktrees,
+// submodules) are not entered. Unreadable subdirectories are skipped.
+func walkSymlinks(fsys FileSystem, root string, fn func(path string) error) error {
+	var fnErr error
+	var walk func(dir string) error
+	walk = func(dir string) error {
+		entries, err := fsys.ReadDir(dir)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if fnErr != nil {
+				return nil
+			}
+			p := filepath.Join(dir, entry.Name())
+			if entry.Type()&fs.ModeSymlink != 0 {
+				fnErr = fn(p)
+				continue
+			}
+			if !entry.IsDir() || entry.Name() == ".git" {
+				continue
+			}
+			if _, err := fsys.Lstat(filepath.Join(p, ".git")); err == nil {
+				continue
+			}
+			// Unreadable subdirectories are skipped; only the root is required
+			_ = walk(p)
+		}
+		return nil
+	}
+	if err := walk(root); err != nil {
+		return fmt.Errorf("failed to read %s: %w", root, err)
+	}
+	return fnErr
+}
+
+// repointSymlinks rewrites the relative symlinks of a worktree that was
+// moved from oldDir to newDir so that they keep pointing at the same
+// files. createSymlinks links files by a path relative to the link, so
+// links into the source worktree break when the worktree depth changes
+// (e.g. feat/a -> a). Links that stay inside the worktree and absolute
+// links are left alone. Returns the paths of the rewritten symlinks.
+func repointSymlinks(fsys FileSystem, oldDir, newDir string) ([]string, error) {
+	var repointed []string
+	err := walkSymlinks(fsys, newDir, func(p string) error {
+		target, err := fsys.Readlink(p)
+		if err != nil || filepath.IsAbs(target) {
+			return nil
+		}
+		rel, err := filepath.Rel(newDir, p)
+		if err != nil {
+			return nil
+		}
+		resolved := filepath.Join(filepath.Dir(filepath.Join(oldDir, rel)), target)
+		if isWithinDir(oldDir, resolved) {
+			return nil
+		}
+
+		newTarget, err := filepath.Rel(filepath.Dir(p), resolved)
+		if err != nil || newTarget == target {
+			return nil
+		}
+		if err := fsys.Remove(p); err != nil {
+			return fmt.Errorf("failed to remove symlink for %s: %w", rel, err)
+		}
+		if err := fsys.Symlink(newTarget, p); err != nil {
+			return fmt.Errorf("failed to create symlink for %s: %w", rel, err)
+		}
+		repointed = append(repointed, p)
+		return nil
+	})
+	return repointed, err
+}
+
+// staleSymlink checks whether the symlink at path is twig-managed and stale.
+func staleSymlink(fsys FileSystem, srcDir, dstDir, path string, matched map[string]bool) (StaleSymlink, bool) {
+	target, err := fsys.Readlink(path)
+	if err != nil {
+		return StaleSymlink{}, false
+	}
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(filepath.Dir(path), target)
+	}
+	rel, err := filepath.Rel(dstDir, path)
+	if err != nil || filepath.Clean(target) != filepath.Join(srcDir, rel) {
+		return StaleSymlink{}, false
+	}
+
+	s := StaleSymlink{Path: path, Target: filepath.Clean(target)}
+	if _, err := fsys.Stat(s.Target); fsys.IsNotExist(err) {
+		s.Reason = staleReasonSourceMissing
+	} else if !matched[rel] {
+		s.Reason = staleReasonUnmatched
+	} else {
+		return StaleSymlink{}, false
+	}
+	return s, true
+}
@@ -1,12 +1,16 @@
-package twig
+package core
 
 import (
 	"context"
 	"fmt"
 	"io/fs"
 	"log/slog"
+	"path/filepath"
 	"slices"
 	"strings"
+	"time"
+
+	"github.com/708u/twig/format"
 )
 
 // SyncCommand syncs symlinks and submodules from source worktree to target worktrees.
@@ -20,23 +24,38 @@ type SyncCommand struct {
 type SyncOptions struct {
 	Check              bool     // Show what would be synced (dry-run)
 	All                bool     // Sync all worktrees
+	IncludeMain        bool     // Include the main worktree in All (--include-main)
 	Source             string   // Source branch
 	SourcePath         string   // Source worktree path
 	Symlinks           []string // Symlink patterns from source config
 	InitSubmodules     bool     // Whether to init submodules from source config
 	SubmoduleReference bool     // Whether to use --reference for submodule init
+	SubmoduleRefDir    string   // Reference repositories searched before the main worktree (submodule_reference_dir)
+	SubmodulePaths     []string // Submodules to initialize (empty = all)
+	NoSubmoduleRecurse bool     // Leave nested submodules uninitialized
+	DeleteStale        bool     // Remove twig-managed symlinks that are broken or no longer configured
+	StrictSymlinks     bool     // Refuse symlinks whose source is a chain or outside the source worktree
+	SymlinkStyle       string   // symlink_style of the source config (empty = relative)
+	EnvFile            bool     // Refresh the generated .twig.env in targets
+	SymlinksOnly       bool     // Sync only symlinks, including DeleteStale (--symlinks-only)
+	SubmodulesOnly     bool     // Sync only submodules (--submodules-only)
 	Verbose            bool     // Verbose output
+
+	// EnvFileVars are the extra variables written to .twig.env (env_file_vars).
+	EnvFileVars map[string]string
 }
 
 // SyncTargetResult holds the result of syncing a single worktree.
 type SyncTargetResult struct {
-	Branch        string
-	WorktreePath  string
-	Symlinks      []SymlinkResult
-	SubmoduleInit SubmoduleInitResult
-	Skipped       bool
-	SkipReason    string
-	Err           error
+	Branch         string
+	WorktreePath   string
+	Symlinks       []SymlinkResult
+	StaleSymlinks  []StaleSymlink // Removed (or would be removed in check mode) with DeleteStale
+	SubmoduleInit  SubmoduleInitResult
+	EnvFileUpdated bool // .twig.env was (or would be in check mode) rewritten
+	Skipped        bool
+	SkipReason     string
+	Err            error
 }
 
 // SyncResult aggregates results from sync operations.
@@ -60,26 +79,29 @@ func NewSyncCommand(fs FileSystem, git *GitRunner, log *slog.Logger) *SyncComman
 }
 
 // NewDefaultSyncCommand creates a SyncCommand with production defaults.
-func NewDefaultSyncCommand(gitDir string, log *slog.Logger) *SyncCommand {
-	return NewSyncCommand(osFS{}, NewGitRunner(gitDir, WithLogger(log)), log)
+func NewDefaultSyncCommand(gitDir string, log *slog.Logger, gitOpts ...GitRunnerOption) *SyncCommand {
+	git := newDefaultGitRunner(gitDir, log, gitOpts)
+	return NewSyncCommand(git.defaultFS(), git, log)
 }
 
 // SyncFormatOptions configures sync output formatting.
 type SyncFormatOptions struct {
-	Verbose bool
-	Quiet   bool
+	Verbose      bool
+	Quiet        bool
+	ColorEnabled bool // Color errors, skip reasons and the check mode symlink diff
 }
 
 // Format formats the SyncResult for display.
 func (r SyncResult) Format(opts SyncFormatOptions) FormatResult {
 	if opts.Quiet {
-		return r.formatQuiet()
+		return r.formatQuiet(opts)
 	}
 	return r.formatDefault(opts)
 }
 
-// formatQuiet outputs minimal information.
-func (r SyncResult) formatQuiet() FormatResult {
+// formatQuiet outputs the paths of the synced worktrees. Errors and
+// warnings are kept on stderr.
+func (r SyncResult) formatQuiet(opts SyncFormatOptions) FormatResult {
 	var stdout strings.Builder
 	for i := range r.Targets {
 		t := &r.Targets[i]
@@ -87,7 +109,7 @@ func (r SyncResult) formatQuiet() FormatResult {
 			fmt.Fprintln(&stdout, t.WorktreePath)
 		}
 	}
-	return FormatResult{Stdout: stdout.String()}
+	return FormatResult{Stdout: stdout.String(), Stderr: r.formatDefault(opts).Stderr}
 }
 
 // formatDefault outputs the default or verbose format.
@@ -108,7 +130,8 @@ func (r SyncResult) formatDefault(opts SyncFormatOptions) FormatResult {
 	for i := range r.Targets {
 		t := &r.Targets[i]
 		if t.Err != nil {
-			fmt.Fprintf(&stderr, "error: %s: %v\n", t.Branch, t.Err)
+			fmt.Fprintf(&stderr, "%s %s: %v\n", format.Paint(opts.ColorEnabled, format.ColorError, "error:"), t.Branch, t.Err)
+			writeGitErrorHint(&stderr, t.Err)
 			continue
 		}
 
@@ -131,17 +154,44 @@ func (r SyncResult) formatCheckTarget(stdout *strings.Builder, t SyncTargetResul
 		return
 	}
 
+	// Symlinks are shown like a diff: "+" links would be created, "-"
+	// links point at the wrong target and would be replaced, and
+	// unprefixed links are already correct
 	fmt.Fprintf(stdout, "%s:\n", t.Branch)
 	for _, s := range t.Symlinks {
-		if !s.Skipped {
-			fmt.Fprintf(stdout, "  Would create symlink: %s\n", s.Dst)
-		} else if opts.Verbose {
-			fmt.Fprintf(stdout, "  Would skip: %s (%s)\n", s.Dst, s.Reason)
+		if s.Skipped {
+			if opts.Verbose {
+				fmt.Fprintf(stdout, "  Would skip: %s (%s)\n", s.Dst, s.Reason)
+			}
+			continue
+		}
+		name := s.Dst
+		if rel, err := filepath.Rel(t.WorktreePath, s.Dst); err == nil && t.WorktreePath != "" {
+			name = rel
+		}
+		var warning string
+		if s.Warning != "" {
+			warning = fmt.Sprintf(" (warning: %s)", s.Warning)
 		}
+		switch s.State {
+		case SymlinkCorrect:
+			fmt.Fprintf(stdout, "    %s -> %s%s\n", name, s.Src, warning)
+		case SymlinkWrongTarget:
+			fmt.Fprintln(stdout, format.Paint(opts.ColorEnabled, format.ColorFailure, fmt.Sprintf("  - %s -> %s", name, s.Target)))
+			fmt.Fprintln(stdout, format.Paint(opts.ColorEnabled, format.ColorSuccess, fmt.Sprintf("  + %s -> %s%s", name, s.Src, warning)))
+		default:
+			fmt.Fprintln(stdout, format.Paint(opts.ColorEnabled, format.ColorSuccess, fmt.Sprintf("  + %s -> %s%s", name, s.Src, warning)))
+		}
+	}
+	for _, s := range t.StaleSymlinks {
+		fmt.Fprintf(stdout, "  Would remove stale symlink: %s (%s)\n", s.Path, s.Reason)
 	}
 	if t.SubmoduleInit.Attempted {
 		fmt.Fprintln(stdout, "  Would initialize submodules")
 	}
+	if t.EnvFileUpdated {
+		fmt.Fprintf(stdout, "  Would update %s\n", EnvFileName)
+	}
 	fmt.Fprintln(stdout)
 }
 
@@ -150,22 +200,12 @@ func (r SyncResult) formatTarget(stdout, stderr *strings.Builder, t SyncTargetRe
 	// Count created symlinks
 	var createdCount int
 	for _, s := range t.Symlinks {
-		if s.Skipped {
-			fmt.Fprintf(stderr, "warning: %s\n", s.Reason)
-		} else {
+		if !s.Skipped {
 			createdCount++
 		}
 	}
 
-	// Output submodule init warning
-	if t.SubmoduleInit.Skipped {
-		fmt.Fprintf(stderr, "warning: %s\n", t.SubmoduleInit.Reason)
-	}
-
-	// Output warning for submodules that couldn't use reference
-	for _, sm := range t.SubmoduleInit.NoReferenceSubmodules {
-		fmt.Fprintf(stderr, "warning: submodule %s: reference not available, initialize in main worktree first\n", sm)
-	}
+	writeWarnings(stderr, t.Warnings())
 
 	if opts.Verbose {
 		fmt.Fprintf(stdout, "Syncing from %s to %s\n", r.SourceBranch, t.Branch)
@@ -174,21 +214,36 @@ func (r SyncResult) formatTarget(stdout, stderr *strings.Builder, t SyncTargetRe
 				fmt.Fprintf(stdout, "Created symlink: %s -> %s\n", s.Dst, s.Src)
 			}
 		}
+		for _, s := range t.StaleSymlinks {
+			fmt.Fprintf(stdout, "Removed stale symlink: %s (%s)\n", s.Path, s.Reason)
+		}
 		if t.SubmoduleInit.Attempted && t.SubmoduleInit.Count > 0 {
 			fmt.Fprintf(stdout, "Initialized %d submodule(s)\n", t.SubmoduleInit.Count)
 		}
+		if t.EnvFileUpdated {
+			fmt.Fprintf(stdout, "Updated %s\n", EnvFileName)
+		}
 	}
 
 	if t.Skipped {
-		fmt.Fprintf(stdout, "Skipped %s: %s\n", t.Branch, t.SkipReason)
+		fmt.Fprintf(stdout, "Skipped %s: %s\n", t.Branch, format.Paint(opts.ColorEnabled, format.ColorReason, t.SkipReason))
 		return
 	}
 
+	var staleInfo string
+	if len(t.StaleSymlinks) > 0 {
+		staleInfo = fmt.Sprintf(", %d stale removed", len(t.StaleSymlinks))
+	}
+
 	var submoduleInfo string
 	if t.SubmoduleInit.Attempted && t.SubmoduleInit.Count > 0 {
 		submoduleInfo = fmt.Sprintf(", %d submodule(s) initialized", t.SubmoduleInit.Count)
 	}
-	fmt.Fprintf(stdout, "Synced %s from %s: %d symlinks created%s\n", t.Branch, r.SourceBranch, createdCount, submoduleInfo)
+	var envFileInfo string
+	if t.EnvFileUpdated {
+		envFileInfo = ", " + EnvFileName + " updated"
+	}
+	fmt.Fprintf(stdout, "Synced %s from %s: %d symlinks created%s%s%s\n", t.Branch, r.SourceBranch, createdCount, staleInfo, submoduleInfo, envFileInfo)
 }
 
 // Run syncs symlinks and submodules from source to target worktrees.
@@ -197,6 +252,7 @@ func (c *SyncCommand) Run(ctx context.Context, targets []string, cwd string, opt
 		LogAttrKeyCategory.String(), LogCategorySync,
 		"targets", targets,
 		"all", opts.All,
+		"includeMain", opts.IncludeMain,
 		"check", opts.Check)
 
 	var result SyncResult
@@ -210,8 +266,11 @@ func (c *SyncCommand) Run(ctx context.Context, targets []string, cwd string, opt
 		"symlinksCount", len(opts.Symlinks),
 		"initSubmodules", opts.InitSubmodules)
 
-	// Check if there's anything to sync
-	if len(opts.Symlinks) == 0 && !opts.InitSubmodules {
+	opts = opts.scoped()
+
+	// Check if there's anything to sync. With DeleteStale, targets are still
+	// reconciled so links for removed patterns are cleaned up.
+	if len(opts.Symlinks) == 0 && !opts.InitSubmodules && !opts.DeleteStale && !opts.EnvFile {
 		result.NothingToSync = true
 		c.Log.DebugContext(ctx, "nothing to sync",
 			LogAttrKeyCategory.String(), LogCategorySync)
@@ -219,7 +278,7 @@ func (c *SyncCommand) Run(ctx context.Context, targets []string, cwd string, opt
 	}
 
 	// Resolve target worktrees
-	targetWTs, err := c.resolveTargets(ctx, targets, opts.Source, cwd, opts.All)
+	targetWTs, err := c.resolveTargets(ctx, targets, opts.Source, cwd, opts.All, opts.IncludeMain)
 	if err != nil {
 		return result, err
 	}
@@ -252,20 +311,36 @@ func (c *SyncCommand) Run(ctx context.Context, targets []string, cwd string, opt
 	return result, nil
 }
 
+// scoped returns opts with everything outside SymlinksOnly or
+// SubmodulesOnly turned off.
+func (o SyncOptions) scoped() SyncOptions {
+	if o.SymlinksOnly {
+		o.InitSubmodules = false
+		o.EnvFile = false
+	}
+	if o.SubmodulesOnly {
+		o.Symlinks = nil
+		o.DeleteStale = false
+		o.EnvFile = false
+	}
+	return o
+}
+
 // resolveTargets resolves the list of target worktrees.
-func (c *SyncCommand) resolveTargets(ctx context.Context, targets []string, sourceBranch, cwd string, all bool) ([]Worktree, error) {
+func (c *SyncCommand) resolveTargets(ctx context.Context, targets []string, sourceBranch, cwd string, all, includeMain bool) ([]Worktree, error) {
 	// Get all worktrees
 	allWTs, err := c.Git.WorktreeList(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list worktrees: %w", err)
 	}
 
-	// If --all, return all worktrees except main (first) and source
+	// If --all, return all worktrees except main (first, unless
+	// includeMain) and source
 	if all {
 		var result []Worktree
 		for i, wt := range allWTs {
 			// Skip main worktree (first one), bare, and source
-			if i == 0 || wt.Bare || wt.Branch == sourceBranch {
+			if (i == 0 && !includeMain) || wt.Bare || wt.Branch == sourceBranch {
 				continue
 			}
 			result = append(result, wt)
@@ -322,16 +397,26 @@ func (c *SyncCommand) syncTarget(ctx context.Context, sourcePath string, target
 
 	// Sync symlinks (always replace existing symlinks to ensure sync)
 	if len(opts.Symlinks) > 0 {
+		tracked, err := loadTrackedPaths(ctx, c.Git.InDir(target.Path))
+		if err != nil {
+			c.Log.DebugContext(ctx, "failed to list tracked files",
+				LogAttrKeyCategory.String(), LogCategorySync,
+				"branch", target.Branch,
+				"error", err)
+		}
+
 		if opts.Check {
 			// In check mode, predict what would be created
-			symlinks, err := c.predictSymlinks(sourcePath, target.Path, opts.Symlinks)
+			symlinks, err := c.predictSymlinks(sourcePath, target.Path, opts.Symlinks, tracked, opts.StrictSymlinks)
 			if err != nil {
 				result.Err = err
 				return result
 			}
 			result.Symlinks = symlinks
 		} else {
-			symlinks, err := createSymlinks(c.FS, sourcePath, target.Path, opts.Symlinks)
+			start := time.Now()
+			symlinks, err := createSymlinks(c.FS, sourcePath, target.Path, opts.Symlinks, tracked, opts.StrictSymlinks, opts.SymlinkStyle)
+			c.Git.Timings.recordSince(TimingPhaseSymlinks, start)
 			if err != nil {
 				result.Err = err
 				return result
@@ -340,6 +425,29 @@ func (c *SyncCommand) syncTarget(ctx context.Context, sourcePath string, target
 		}
 	}
 
+	// Remove twig-managed symlinks that are broken or no longer configured.
+	// Runs after creation so links for current patterns are never stale.
+	if opts.DeleteStale {
+		stale, err := findStaleSymlinks(c.FS, sourcePath, target.Path, opts.Symlinks)
+		if err != nil {
+			result.Err = err
+			return result
+		}
+		if !opts.Check {
+			for _, s := range stale {
+				if err := c.FS.Remove(s.Path); err != nil {
+					result.Err = fmt.Errorf("failed to remove stale symlink %s: %w", s.Path, err)
+					return result
+				}
+				c.Log.DebugContext(ctx, "removed stale symlink",
+					LogAttrKeyCategory.String(), LogCategorySync,
+					"path", s.Path,
+					"reason", s.Reason)
+			}
+		}
+		result.StaleSymlinks = stale
+	}
+
 	// Sync submodules
 	if opts.InitSubmodules {
 		if opts.Check {
@@ -348,8 +456,17 @@ func (c *SyncCommand) syncTarget(ctx context.Context, sourcePath string, target
 		} else {
 			wtGit := c.Git.InDir(target.Path)
 			var updateOpts []SubmoduleUpdateOption
+			if len(opts.SubmodulePaths) > 0 {
+				updateOpts = append(updateOpts, WithSubmodulePaths(opts.SubmodulePaths))
+			}
+			if opts.NoSubmoduleRecurse {
+				updateOpts = append(updateOpts, WithoutSubmoduleRecursion())
+			}
 
 			if opts.SubmoduleReference {
+				if opts.SubmoduleRefDir != "" {
+					updateOpts = append(updateOpts, WithSubmoduleReferenceDir(opts.SubmoduleRefDir))
+				}
 				if mainPath, err := c.Git.MainWorktreePath(ctx); err == nil {
 					updateOpts = append(updateOpts, WithSubmoduleReference(mainPath))
 				}
@@ -365,17 +482,31 @@ func (c *SyncCommand) syncTarget(ctx context.Context, sourcePath string, target
 				result.SubmoduleInit.Count = subResult.Count
 				result.SubmoduleInit.NoReferenceSubmodules = subResult.NoReference
 			}
+			result.SubmoduleInit.UnmatchedPaths = subResult.Unmatched
 		}
 	}
 
-	// Check if anything was synced
+	// Refresh the env file, which records the source and may be missing
+	// in worktrees created before env_file was enabled
+	if opts.EnvFile {
+		content := envFileContent(target.Branch, target.Path, sourcePath, opts.EnvFileVars)
+		_, updated, err := writeEnvFile(c.FS, target.Path, content, opts.Check)
+		if err != nil {
+			result.Err = err
+			return result
+		}
+		result.EnvFileUpdated = updated
+	}
+
+	// Check if anything was synced. Links that are already correct
+	// would only be recreated as they are.
 	createdSymlinks := 0
 	for _, s := range result.Symlinks {
-		if !s.Skipped {
+		if !s.Skipped && s.State != SymlinkCorrect {
 			createdSymlinks++
 		}
 	}
-	if createdSymlinks == 0 && !result.SubmoduleInit.Attempted {
+	if createdSymlinks == 0 && len(result.StaleSymlinks) == 0 && !result.SubmoduleInit.Attempted && !result.EnvFileUpdated {
 		result.Skipped = true
 		result.SkipReason = "up to date"
 	}
@@ -384,7 +515,7 @@ func (c *SyncCommand) syncTarget(ctx context.Context, sourcePath string, target
 }
 
 // predictSymlinks predicts what symlinks would be created without actually creating them.
-func (c *SyncCommand) predictSymlinks(srcDir, dstDir string, patterns []string) ([]SymlinkResult, error) {
+func (c *SyncCommand) predictSymlinks(srcDir, dstDir string, patterns []string, tracked trackedPaths, strict bool) ([]SymlinkResult, error) {
 	var results []SymlinkResult
 
 	for _, pattern := range patterns {
@@ -404,12 +535,48 @@ func (c *SyncCommand) predictSymlinks(srcDir, dstDir string, patterns []string)
 			src := srcDir + "/" + match
 			dst := dstDir + "/" + match
 
+			if tracked.contains(match) {
+				results = append(results, SymlinkResult{
+					Src:     src,
+					Dst:     dst,
+					Skipped: true,
+					Reason:  trackedSkipReason(match),
+				})
+				continue
+			}
+
+			var warning string
+			if issue := symlinkSourceIssue(c.FS, srcDir, match); issue != "" {
+				if strict {
+					results = append(results, SymlinkResult{
+						Src:     src,
+						Dst:     dst,
+						Skipped: true,
+						Reason:  strictSkipReason(match, issue),
+					})
+					continue
+				}
+				warning = sourceIssueWarning(match, issue)
+			}
+
 			// Check if destination already exists
 			if info, err := c.FS.Lstat(dst); err == nil {
 				isSymlink := info.Mode()&fs.ModeSymlink != 0
 				if isSymlink {
-					// Would replace existing symlink
-					results = append(results, SymlinkResult{Src: src, Dst: dst})
+					// Would replace existing symlink unless it already points at src
+					result := SymlinkResult{Src: src, Dst: dst, Warning: warning, State: SymlinkCorrect}
+					target, err := c.FS.Readlink(dst)
+					if err != nil {
+						return nil, fmt.Errorf("failed to read symlink %s: %w", dst, err)
+					}
+					if !filepath.IsAbs(target) {
+						target = filepath.Join(filepath.Dir(dst), target)
+					}
+					if filepath.Clean(target) != filepath.Clean(src) {
+						result.State = SymlinkWrongTarget
+						result.Target = target
+					}
+					results = append(results, result)
 				} else {
 					// Would skip regular file
 					results = append(results, SymlinkResult{
@@ -421,7 +588,7 @@ func (c *SyncCommand) predictSymlinks(srcDir, dstDir string, patterns []string)
 				}
 			} else {
 				// Would create
-				results = append(results, SymlinkResult{Src: src, Dst: dst})
+				results = append(results, SymlinkResult{Src: src, Dst: dst, Warning: warning, State: SymlinkMissing})
 			}
 		}
 	}
@@ -429,6 +596,26 @@ func (c *SyncCommand) predictSymlinks(srcDir, dstDir string, patterns []string)
 	return results, nil
 }
 
+// Warnings returns the warnings of syncing the target.
+func (t SyncTargetResult) Warnings() []Warning {
+	return append(symlinkWarnings(t.Symlinks), t.SubmoduleInit.Warnings()...)
+}
+
+// Warnings returns the warnings of all targets that were synced. Check
+// mode has none, as nothing was changed.
+func (r SyncResult) Warnings() []Warning {
+	if r.Check {
+		return nil
+	}
+	var warnings []Warning
+	for i := range r.Targets {
+		if r.Targets[i].Err == nil {
+			warnings = append(warnings, r.Targets[i].Warnings()...)
+		}
+	}
+	return warnings
+}
+
 // HasErrors returns true if any errors occurred.
 func (r SyncResult) HasErrors() bool {
 	for i := range r.Targets {
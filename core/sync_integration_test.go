@@ -0,0 +1,402 @@
+//go:build integration
+
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/708u/twig/internal/testutil"
+)
+
+func TestSyncCommand_CwdInWorktreeSubdir_Integration(t *testing.T) {
+	t.Parallel()
+
+	t.Run("SelectsCorrectWorktreeFromSubdir", func(t *testing.T) {
+		t.Parallel()
+
+		repoDir, mainDir := testutil.SetupTestRepo(t, testutil.Symlinks(".envrc"), testutil.DefaultSource("main"))
+
+		// Create .envrc in main
+		if err := os.WriteFile(filepath.Join(mainDir, ".envrc"), []byte("# envrc"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		// Create worktree for feat/x
+		testutil.RunGit(t, mainDir, "worktree", "add", filepath.Join(repoDir, "feat", "x"), "-b", "feat/x")
+		wtPath := filepath.Join(repoDir, "feat", "x")
+
+		// Create a subdirectory inside the worktree
+		subdir := filepath.Join(wtPath, "subdir", "nested")
+		if err := os.MkdirAll(subdir, 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		// Load config from main
+		result, err := LoadConfig(mainDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		cmd := NewSyncCommand(osFS{}, NewGitRunner(mainDir), nil)
+
+		// Run sync with cwd set to nested subdirectory inside worktree
+		syncResult, err := cmd.Run(t.Context(), nil, subdir, SyncOptions{
+			Source:     result.Config.DefaultSource,
+			SourcePath: mainDir,
+			Symlinks:   result.Config.Symlinks,
+		})
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+
+		// Verify the correct worktree (feat/x) was selected
+		if len(syncResult.Targets) != 1 {
+			t.Fatalf("expected 1 target, got %d", len(syncResult.Targets))
+		}
+		if syncResult.Targets[0].Branch != "feat/x" {
+			t.Errorf("target branch = %q, want %q", syncResult.Targets[0].Branch, "feat/x")
+		}
+		if syncResult.Targets[0].WorktreePath != wtPath {
+			t.Errorf("target path = %q, want %q", syncResult.Targets[0].WorktreePath, wtPath)
+		}
+	})
+
+	t.Run("SimilarPrefixWorktreesAreDistinguished", func(t *testing.T) {
+		t.Parallel()
+
+		// This test verifies that worktrees with similar path prefixes are correctly distinguished
+		// e.g., /repo and /repo-worktree should not be confused
+		tmpDir := t.TempDir()
+		tmpDir, _ = filepath.EvalSymlinks(tmpDir)
+
+		// Create main repo at /tmp/repo
+		mainDir := filepath.Join(tmpDir, "repo")
+		if err := os.MkdirAll(mainDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		testutil.RunGit(t, mainDir, "init")
+		testutil.RunGit(t, mainDir, "config", "user.email", "test@example.com")
+		testutil.RunGit(t, mainDir, "config", "user.name", "Test User")
+		testutil.RunGit(t, mainDir, "commit", "--allow-empty", "-m", "initial")
+		testutil.RunGit(t, mainDir, "branch", "-M", "main")
+
+		// Create .twig/settings.toml
+		twigDir := filepath.Join(mainDir, ".twig")
+		if err := os.MkdirAll(twigDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		settings := `worktree_destination_base_dir = "` + filepath.Join(tmpDir, "repo-worktree") + `"
+default_source = "main"
+symlinks = [".envrc"]
+`
+		if err := os.WriteFile(filepath.Join(twigDir, "settings.toml"), []byte(settings), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		// Create .envrc
+		if err := os.WriteFile(filepath.Join(mainDir, ".envrc"), []byte("# envrc"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		// Create worktree at /tmp/repo-worktree/feat/x (note: "repo-worktree", not "repo/worktree")
+		wtBaseDir := filepath.Join(tmpDir, "repo-worktree")
+		if err := os.MkdirAll(wtBaseDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		wtPath := filepath.Join(wtBaseDir, "feat", "x")
+		testutil.RunGit(t, mainDir, "worktree", "add", wtPath, "-b", "feat/x")
+
+		// Create subdirectory in worktree
+		subdir := filepath.Join(wtPath, "subdir")
+		if err := os.MkdirAll(subdir, 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := LoadConfig(mainDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		cmd := NewSyncCommand(osFS{}, NewGitRunner(mainDir), nil)
+
+		// Run sync with cwd in worktree subdirectory
+		// This should select feat/x, not be confused with main repo (/repo vs /repo-worktree)
+		syncResult, err := cmd.Run(t.Context(), nil, subdir, SyncOptions{
+			Source:     result.Config.DefaultSource,
+			SourcePath: mainDir,
+			Symlinks:   result.Config.Symlinks,
+		})
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+
+		// Verify feat/x was selected
+		if len(syncResult.Targets) != 1 {
+			t.Fatalf("expected 1 target, got %d", len(syncResult.Targets))
+		}
+		if syncResult.Targets[0].Branch != "feat/x" {
+			t.Errorf("target branch = %q, want %q", syncResult.Targets[0].Branch, "feat/x")
+		}
+	})
+}
+
+func TestSyncCommand_DeleteStale_Integration(t *testing.T) {
+	t.Parallel()
+
+	repoDir, mainDir := testutil.SetupTestRepo(t)
+
+	for _, name := range []string{".envrc", ".tool-versions", ".old"} {
+		if err := os.WriteFile(filepath.Join(mainDir, name), []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wtPath := filepath.Join(repoDir, "feat", "x")
+	testutil.RunGit(t, mainDir, "worktree", "add", wtPath, "-b", "feat/x")
+
+	cmd := NewSyncCommand(osFS{}, NewGitRunner(mainDir), nil)
+	opts := SyncOptions{Source: "main", SourcePath: mainDir}
+
+	// Initial sync links all three files
+	opts.Symlinks = []string{".envrc", ".tool-versions", ".old"}
+	if _, err := cmd.Run(t.Context(), []string{"feat/x"}, mainDir, opts); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	// A user-created symlink pointing elsewhere in the source is left alone
+	if err := os.Symlink(filepath.Join(mainDir, ".envrc"), filepath.Join(wtPath, "my-link")); err != nil {
+		t.Fatal(err)
+	}
+
+	// .tool-versions is dropped from config and .old is deleted in the source
+	if err := os.Remove(filepath.Join(mainDir, ".old")); err != nil {
+		t.Fatal(err)
+	}
+	opts.Symlinks = []string{".envrc", ".old"}
+	opts.DeleteStale = true
+
+	opts.Check = true
+	checkResult, err := cmd.Run(t.Context(), []string{"feat/x"}, mainDir, opts)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if got := len(checkResult.Targets[0].StaleSymlinks); got != 2 {
+		t.Fatalf("check found %d stale symlinks, want 2: %+v", got, checkResult.Targets[0].StaleSymlinks)
+	}
+	if _, err := os.Lstat(filepath.Join(wtPath, ".tool-versions")); err != nil {
+		t.Errorf("check mode should not remove symlinks: %v", err)
+	}
+
+	opts.Check = false
+	result, err := cmd.Run(t.Context(), []string{"feat/x"}, mainDir, opts)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if result.HasErrors() {
+		t.Fatalf("unexpected target error: %v", result.Targets[0].Err)
+	}
+
+	for _, name := range []string{".tool-versions", ".old"} {
+		if _, err := os.Lstat(filepath.Join(wtPath, name)); !os.IsNotExist(err) {
+			t.Errorf("stale symlink %s should be removed", name)
+		}
+	}
+	for _, name := range []string{".envrc", "my-link"} {
+		if _, err := os.Lstat(filepath.Join(wtPath, name)); err != nil {
+			t.Errorf("symlink %s should be kept: %v", name, err)
+		}
+	}
+}
+
+func TestSyncCommand_CheckPreview_Integration(t *testing.T) {
+	t.Parallel()
+
+	repoDir, mainDir := testutil.SetupTestRepo(t, testutil.Symlinks(".envrc", ".tool-versions", ".env.local"), testutil.DefaultSource("main"))
+	for _, name := range []string{".envrc", ".tool-versions", ".env.local"} {
+		if err := os.WriteFile(filepath.Join(mainDir, name), []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wtPath := filepath.Join(repoDir, "feat", "x")
+	testutil.RunGit(t, mainDir, "worktree", "add", wtPath, "-b", "feat/x")
+
+	// .envrc is correct, .tool-versions points elsewhere, .env.local is missing
+	if err := os.Symlink(filepath.Join(mainDir, ".envrc"), filepath.Join(wtPath, ".envrc")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(repoDir, "old", ".tool-versions"), filepath.Join(wtPath, ".tool-versions")); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := LoadConfig(mainDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmd := NewSyncCommand(osFS{}, NewGitRunner(mainDir), nil)
+	opts := SyncOptions{
+		Check:      true,
+		Source:     result.Config.DefaultSource,
+		SourcePath: mainDir,
+		Symlinks:   result.Config.Symlinks,
+	}
+
+	syncResult, err := cmd.Run(t.Context(), []string{"feat/x"}, mainDir, opts)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	states := make(map[string]SymlinkState)
+	for _, s := range syncResult.Targets[0].Symlinks {
+		states[filepath.Base(s.Dst)] = s.State
+	}
+	want := map[string]SymlinkState{
+		".envrc":         SymlinkCorrect,
+		".tool-versions": SymlinkWrongTarget,
+		".env.local":     SymlinkMissing,
+	}
+	for name, state := range want {
+		if states[name] != state {
+			t.Errorf("%s state = %q, want %q", name, states[name], state)
+		}
+	}
+	if target, err := os.Readlink(filepath.Join(wtPath, ".tool-versions")); err != nil || target != filepath.Join(repoDir, "old", ".tool-versions") {
+		t.Errorf("check mode changed .tool-versions: %q, %v", target, err)
+	}
+
+	// Once every link is correct the target is up to date
+	opts.Check = false
+	if _, err := cmd.Run(t.Context(), []string{"feat/x"}, mainDir, opts); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	opts.Check = true
+	syncResult, err = cmd.Run(t.Context(), []string{"feat/x"}, mainDir, opts)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if !syncResult.Targets[0].Skipped || syncResult.Targets[0].SkipReason != "up to date" {
+		t.Errorf("target = %+v, want skipped as up to date", syncResult.Targets[0])
+	}
+}
+
+func TestSyncCommand_EnvFile_Integration(t *testing.T) {
+	t.Parallel()
+
+	repoDir, mainDir := testutil.SetupTestRepo(t)
+
+	wtPath := filepath.Join(repoDir, "feat", "x")
+	testutil.RunGit(t, mainDir, "worktree", "add", wtPath, "-b", "feat/x")
+
+	cmd := NewSyncCommand(osFS{}, NewGitRunner(mainDir), nil)
+	opts := SyncOptions{
+		Source:      "main",
+		SourcePath:  mainDir,
+		EnvFile:     true,
+		EnvFileVars: map[string]string{"PORT": "3001"},
+	}
+
+	// A worktree created before env_file was enabled gets the file
+	result, err := cmd.Run(t.Context(), []string{"feat/x"}, mainDir, opts)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if !result.Targets[0].EnvFileUpdated {
+		t.Fatalf("EnvFileUpdated = false, want true: %+v", result.Targets[0])
+	}
+	data, err := os.ReadFile(filepath.Join(wtPath, EnvFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{
+		`TWIG_BRANCH="feat/x"`,
+		`TWIG_WORKTREE_PATH="` + wtPath + `"`,
+		`TWIG_SOURCE_PATH="` + mainDir + `"`,
+		`PORT="3001"`,
+	} {
+		if !strings.Contains(string(data), line+"\n") {
+			t.Errorf("%s is missing %s:\n%s", EnvFileName, line, data)
+		}
+	}
+
+	// Unchanged on the next sync
+	result, err = cmd.Run(t.Context(), []string{"feat/x"}, mainDir, opts)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if !result.Targets[0].Skipped || result.Targets[0].SkipReason != "up to date" {
+		t.Errorf("second sync = %+v, want up to date", result.Targets[0])
+	}
+
+	// Changed vars are picked up, and check mode only reports them
+	opts.EnvFileVars = map[string]string{"PORT": "3002"}
+	opts.Check = true
+	result, err = cmd.Run(t.Context(), []string{"feat/x"}, mainDir, opts)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if !result.Targets[0].EnvFileUpdated {
+		t.Error("check mode should report the env file update")
+	}
+	if after, _ := os.ReadFile(filepath.Join(wtPath, EnvFileName)); string(after) != string(data) {
+		t.Error("check mode should not rewrite the env file")
+	}
+}
+
+func TestSyncCommand_SubmoduleReferenceDir_Integration(t *testing.T) {
+	// Allow file:// protocol for local submodule URLs in tests
+	t.Setenv("GIT_CONFIG_COUNT", "1")
+	t.Setenv("GIT_CONFIG_KEY_0", "protocol.file.allow")
+	t.Setenv("GIT_CONFIG_VALUE_0", "always")
+
+	repoDir, mainDir := testutil.SetupTestRepo(t)
+
+	submoduleRepo := filepath.Join(repoDir, "submodule-repo")
+	if err := os.MkdirAll(submoduleRepo, 0755); err != nil {
+		t.Fatal(err)
+	}
+	testutil.RunGit(t, submoduleRepo, "init")
+	testutil.RunGit(t, submoduleRepo, "config", "user.email", "test@example.com")
+	testutil.RunGit(t, submoduleRepo, "config", "user.name", "Test")
+	if err := os.WriteFile(filepath.Join(submoduleRepo, "submodule-file.txt"), []byte("submodule content"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	testutil.RunGit(t, submoduleRepo, "add", ".")
+	testutil.RunGit(t, submoduleRepo, "commit", "-m", "initial")
+	testutil.RunGit(t, mainDir, "submodule", "add", submoduleRepo, "mysub")
+	testutil.RunGit(t, mainDir, "commit", "-m", "add submodule")
+
+	mirror := filepath.Join(repoDir, "mirror")
+	testutil.RunGit(t, repoDir, "clone", "--bare", submoduleRepo, filepath.Join(mirror, "mysub"))
+
+	wtPath := filepath.Join(repoDir, "feat", "x")
+	testutil.RunGit(t, mainDir, "worktree", "add", wtPath, "-b", "feat/x")
+
+	cmd := NewSyncCommand(osFS{}, NewGitRunner(mainDir), nil)
+	result, err := cmd.Run(t.Context(), []string{"feat/x"}, mainDir, SyncOptions{
+		Source:             "main",
+		SourcePath:         mainDir,
+		InitSubmodules:     true,
+		SubmoduleReference: true,
+		SubmoduleRefDir:    mirror,
+	})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if init := result.Targets[0].SubmoduleInit; init.Count != 1 || len(init.NoReferenceSubmodules) != 0 {
+		t.Fatalf("SubmoduleInit = %+v, want 1 submodule with reference", init)
+	}
+
+	// The mirror is preferred over the main worktree's .git/modules
+	subPath := filepath.Join(wtPath, "mysub")
+	alternates := strings.TrimSpace(testutil.RunGit(t, subPath, "rev-parse", "--git-path", "objects/info/alternates"))
+	if !filepath.IsAbs(alternates) {
+		alternates = filepath.Join(subPath, alternates)
+	}
+	data, err := os.ReadFile(alternates)
+	if err != nil {
+		t.Fatalf("submodule has no alternates: %v", err)
+	}
+	if !strings.Contains(string(data), filepath.Join(mirror, "mysub")) {
+		t.Errorf("alternates = %q, want the mirror", data)
+	}
+}
@@ -1,7 +1,9 @@
-package twig
+package core
 
 import (
+	"errors"
 	"io/fs"
+	"os"
 	"strings"
 	"testing"
 
@@ -36,7 +38,7 @@ func TestSyncResult_Format(t *testing.T) {
 						Branch:       "feat/a",
 						WorktreePath: "/repo/feat/a",
 						Symlinks: []SymlinkResult{
-							{Src: "/repo/main/.envrc", Dst: "/repo/feat/a/.envrc"},
+							{Src: "/repo/main/.envrc", Dst: "/repo/feat/a/.envrc", State: SymlinkMissing},
 						},
 						SubmoduleInit: SubmoduleInitResult{Attempted: true},
 					},
@@ -46,9 +48,37 @@ func TestSyncResult_Format(t *testing.T) {
 			wantStdout: `Would sync from main:
 
 feat/a:
-  Would create symlink: /repo/feat/a/.envrc
+  + .envrc -> /repo/main/.envrc
   Would initialize submodules
 
+`,
+		},
+		{
+			name: "check_mode_symlink_diff",
+			result: SyncResult{
+				Check:        true,
+				SourceBranch: "main",
+				Targets: []SyncTargetResult{
+					{
+						Branch:       "feat/a",
+						WorktreePath: "/repo/feat/a",
+						Symlinks: []SymlinkResult{
+							{Src: "/repo/main/.envrc", Dst: "/repo/feat/a/.envrc", State: SymlinkCorrect},
+							{Src: "/repo/main/.tool-versions", Dst: "/repo/feat/a/.tool-versions", State: SymlinkWrongTarget, Target: "/old/.tool-versions"},
+							{Src: "/repo/main/.claude", Dst: "/repo/feat/a/.claude", State: SymlinkMissing, Warning: ".claude is outside the source worktree"},
+						},
+					},
+				},
+			},
+			opts: SyncFormatOptions{},
+			wantStdout: `Would sync from main:
+
+feat/a:
+    .envrc -> /repo/main/.envrc
+  - .tool-versions -> /old/.tool-versions
+  + .tool-versions -> /repo/main/.tool-versions
+  + .claude -> /repo/main/.claude (warning: .claude is outside the source worktree)
+
 `,
 		},
 		{
@@ -92,6 +122,49 @@ feat/a:
 			opts:       SyncFormatOptions{},
 			wantStdout: "Synced feat/a from main: 2 symlinks created, 1 submodule(s) initialized\n",
 		},
+		{
+			name: "check_mode_stale_symlinks",
+			result: SyncResult{
+				Check:        true,
+				SourceBranch: "main",
+				Targets: []SyncTargetResult{
+					{
+						Branch:       "feat/a",
+						WorktreePath: "/repo/feat/a",
+						StaleSymlinks: []StaleSymlink{
+							{Path: "/repo/feat/a/.old", Target: "/repo/main/.old", Reason: "source missing"},
+						},
+					},
+				},
+			},
+			opts: SyncFormatOptions{},
+			wantStdout: `Would sync from main:
+
+feat/a:
+  Would remove stale symlink: /repo/feat/a/.old (source missing)
+
+`,
+		},
+		{
+			name: "normal_mode_stale_symlinks_verbose",
+			result: SyncResult{
+				SourceBranch: "main",
+				Targets: []SyncTargetResult{
+					{
+						Branch:       "feat/a",
+						WorktreePath: "/repo/feat/a",
+						StaleSymlinks: []StaleSymlink{
+							{Path: "/repo/feat/a/.old", Target: "/repo/main/.old", Reason: "not in symlinks config"},
+						},
+					},
+				},
+			},
+			opts: SyncFormatOptions{Verbose: true},
+			wantStdout: `Syncing from main to feat/a
+Removed stale symlink: /repo/feat/a/.old (not in symlinks config)
+Synced feat/a from main: 0 symlinks created, 1 stale removed
+`,
+		},
 		{
 			name: "normal_mode_verbose",
 			result: SyncResult{
@@ -290,6 +363,7 @@ func TestSyncCommand_resolveTargets(t *testing.T) {
 		sourceBranch string
 		cwd          string
 		all          bool
+		includeMain  bool
 		setupGit     func() *testutil.MockGitExecutor
 		wantBranches []string
 		wantErr      bool
@@ -377,6 +451,39 @@ func TestSyncCommand_resolveTargets(t *testing.T) {
 			},
 			wantBranches: []string{"feat/a", "feat/b"},
 		},
+		{
+			name:         "all_flag_include_main",
+			sourceBranch: "tooling",
+			cwd:          "/repo/main",
+			all:          true,
+			includeMain:  true,
+			setupGit: func() *testutil.MockGitExecutor {
+				return &testutil.MockGitExecutor{
+					Worktrees: []testutil.MockWorktree{
+						{Path: "/repo/main", Branch: "main"},
+						{Path: "/repo/tooling", Branch: "tooling"},
+						{Path: "/repo/feat/a", Branch: "feat/a"},
+					},
+				}
+			},
+			wantBranches: []string{"main", "feat/a"},
+		},
+		{
+			name:         "all_flag_include_main_when_main_is_source",
+			sourceBranch: "main",
+			cwd:          "/repo/main",
+			all:          true,
+			includeMain:  true,
+			setupGit: func() *testutil.MockGitExecutor {
+				return &testutil.MockGitExecutor{
+					Worktrees: []testutil.MockWorktree{
+						{Path: "/repo/main", Branch: "main"},
+						{Path: "/repo/feat/a", Branch: "feat/a"},
+					},
+				}
+			},
+			wantBranches: []string{"feat/a"},
+		},
 	}
 
 	for _, tt := range tests {
@@ -389,7 +496,7 @@ func TestSyncCommand_resolveTargets(t *testing.T) {
 				Log: NewNopLogger(),
 			}
 
-			targets, err := cmd.resolveTargets(t.Context(), tt.targets, tt.sourceBranch, tt.cwd, tt.all)
+			targets, err := cmd.resolveTargets(t.Context(), tt.targets, tt.sourceBranch, tt.cwd, tt.all, tt.includeMain)
 
 			if tt.wantErr {
 				if err == nil {
@@ -419,15 +526,77 @@ func TestSyncCommand_resolveTargets(t *testing.T) {
 	}
 }
 
+func TestSyncCommand_Run_Scope(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		symlinksOnly   bool
+		submodulesOnly bool
+		wantSymlinks   bool
+		wantSubmodules bool
+		wantEnvFile    bool
+	}{
+		{name: "everything", wantSymlinks: true, wantSubmodules: true, wantEnvFile: true},
+		{name: "symlinks only", symlinksOnly: true, wantSymlinks: true},
+		{name: "submodules only", submodulesOnly: true, wantSubmodules: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockFS := &testutil.MockFS{GlobResults: map[string][]string{".envrc": {".envrc"}}}
+			mockGit := &testutil.MockGitExecutor{
+				Worktrees: []testutil.MockWorktree{
+					{Path: "/repo/main", Branch: "main"},
+					{Path: "/repo/feat", Branch: "feat"},
+				},
+			}
+			cmd := NewSyncCommand(mockFS, &GitRunner{Executor: mockGit, Log: NewNopLogger()}, nil)
+
+			result, err := cmd.Run(t.Context(), nil, "/repo/main", SyncOptions{
+				Check:          true,
+				All:            true,
+				Source:         "main",
+				SourcePath:     "/repo/main",
+				Symlinks:       []string{".envrc"},
+				InitSubmodules: true,
+				EnvFile:        true,
+				SymlinksOnly:   tt.symlinksOnly,
+				SubmodulesOnly: tt.submodulesOnly,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(result.Targets) != 1 {
+				t.Fatalf("Targets = %+v, want 1 target", result.Targets)
+			}
+			got := result.Targets[0]
+			if (len(got.Symlinks) > 0) != tt.wantSymlinks {
+				t.Errorf("Symlinks = %+v, want synced = %v", got.Symlinks, tt.wantSymlinks)
+			}
+			if got.SubmoduleInit.Attempted != tt.wantSubmodules {
+				t.Errorf("SubmoduleInit.Attempted = %v, want %v", got.SubmoduleInit.Attempted, tt.wantSubmodules)
+			}
+			if got.EnvFileUpdated != tt.wantEnvFile {
+				t.Errorf("EnvFileUpdated = %v, want %v", got.EnvFileUpdated, tt.wantEnvFile)
+			}
+		})
+	}
+}
+
 func TestSyncCommand_predictSymlinks(t *testing.T) {
 	t.Parallel()
 
 	tests := []struct {
 		name        string
 		patterns    []string
+		tracked     trackedPaths
 		setupFS     func() *testutil.MockFS
 		wantCreated int
 		wantSkipped int
+		wantState   SymlinkState // State of the first result
 		wantErr     bool
 	}{
 		{
@@ -441,6 +610,7 @@ func TestSyncCommand_predictSymlinks(t *testing.T) {
 				}
 			},
 			wantCreated: 1,
+			wantState:   SymlinkMissing,
 		},
 		{
 			name:     "existing_symlink_replaced",
@@ -450,7 +620,28 @@ func TestSyncCommand_predictSymlinks(t *testing.T) {
 					GlobResults: map[string][]string{
 						".envrc": {".envrc"},
 					},
-					ExistingPaths: []string{"/dst/.envrc"},
+					ExistingPaths:  []string{"/dst/.envrc"},
+					SymlinkTargets: map[string]string{"/dst/.envrc": "/old/.envrc"},
+					LstatFunc: func(name string) (fs.FileInfo, error) {
+						return &testutil.MockFileInfo{
+							ModeVal: fs.ModeSymlink,
+						}, nil
+					},
+				}
+			},
+			wantCreated: 1,
+			wantState:   SymlinkWrongTarget,
+		},
+		{
+			name:     "existing_relative_symlink_correct",
+			patterns: []string{".envrc"},
+			setupFS: func() *testutil.MockFS {
+				return &testutil.MockFS{
+					GlobResults: map[string][]string{
+						".envrc": {".envrc"},
+					},
+					ExistingPaths:  []string{"/dst/.envrc"},
+					SymlinkTargets: map[string]string{"/dst/.envrc": "../src/.envrc"},
 					LstatFunc: func(name string) (fs.FileInfo, error) {
 						return &testutil.MockFileInfo{
 							ModeVal: fs.ModeSymlink,
@@ -459,6 +650,7 @@ func TestSyncCommand_predictSymlinks(t *testing.T) {
 				}
 			},
 			wantCreated: 1,
+			wantState:   SymlinkCorrect,
 		},
 		{
 			name:     "existing_regular_file_skipped",
@@ -488,6 +680,19 @@ func TestSyncCommand_predictSymlinks(t *testing.T) {
 			},
 			wantSkipped: 1,
 		},
+		{
+			name:     "tracked_in_target_skipped",
+			patterns: []string{".envrc"},
+			tracked:  trackedPaths{".envrc": {}},
+			setupFS: func() *testutil.MockFS {
+				return &testutil.MockFS{
+					GlobResults: map[string][]string{
+						".envrc": {".envrc"},
+					},
+				}
+			},
+			wantSkipped: 1,
+		},
 	}
 
 	for _, tt := range tests {
@@ -497,7 +702,7 @@ func TestSyncCommand_predictSymlinks(t *testing.T) {
 			mockFS := tt.setupFS()
 			cmd := &SyncCommand{FS: mockFS}
 
-			results, err := cmd.predictSymlinks("/src", "/dst", tt.patterns)
+			results, err := cmd.predictSymlinks("/src", "/dst", tt.patterns, tt.tracked, false)
 
 			if tt.wantErr {
 				if err == nil {
@@ -525,6 +730,140 @@ func TestSyncCommand_predictSymlinks(t *testing.T) {
 			if skipped != tt.wantSkipped {
 				t.Errorf("skipped = %d, want %d", skipped, tt.wantSkipped)
 			}
+			if tt.wantState != "" && results[0].State != tt.wantState {
+				t.Errorf("State = %q, want %q", results[0].State, tt.wantState)
+			}
+		})
+	}
+}
+
+func TestFindStaleSymlinks(t *testing.T) {
+	t.Parallel()
+
+	mockFS := &testutil.MockFS{
+		DirContents: map[string][]os.DirEntry{
+			"/repo/feat": {
+				sizedDirEntry{name: ".envrc", mode: fs.ModeSymlink},
+				sizedDirEntry{name: ".old", mode: fs.ModeSymlink},
+				sizedDirEntry{name: ".removed-pattern", mode: fs.ModeSymlink},
+				sizedDirEntry{name: "own-link", mode: fs.ModeSymlink},
+				sizedDirEntry{name: "config", isDir: true},
+				sizedDirEntry{name: "sub-repo", isDir: true},
+				sizedDirEntry{name: ".git", isDir: true},
+			},
+			"/repo/feat/config": {
+				sizedDirEntry{name: "local.toml", mode: fs.ModeSymlink},
+			},
+			"/repo/feat/sub-repo": {
+				sizedDirEntry{name: ".stale-in-nested", mode: fs.ModeSymlink},
+			},
+		},
+		SymlinkTargets: map[string]string{
+			"/repo/feat/.envrc":                    "../main/.envrc",
+			"/repo/feat/.old":                      "../main/.old",
+			"/repo/feat/.removed-pattern":          "../main/.removed-pattern",
+			"/repo/feat/own-link":                  "config/local.toml",
+			"/repo/feat/config/local.toml":         "../../main/config/local.toml",
+			"/repo/feat/sub-repo/.stale-in-nested": "../../main/sub-repo/.stale-in-nested",
+		},
+		// Stat: existing sources; Lstat: nested repository marker
+		ExistingPaths: []string{
+			"/repo/main/.envrc",
+			"/repo/main/.removed-pattern",
+			"/repo/main/config/local.toml",
+			"/repo/feat/sub-repo/.git",
+		},
+		GlobResults: map[string][]string{
+			".envrc":   {".envrc"},
+			"config/*": {"config/local.toml"},
+		},
+	}
+
+	stale, err := findStaleSymlinks(mockFS, "/repo/main", "/repo/feat", []string{".envrc", "config/*"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []StaleSymlink{
+		{Path: "/repo/feat/.old", Target: "/repo/main/.old", Reason: staleReasonSourceMissing},
+		{Path: "/repo/feat/.removed-pattern", Target: "/repo/main/.removed-pattern", Reason: staleReasonUnmatched},
+	}
+	if len(stale) != len(want) {
+		t.Fatalf("got %d stale symlinks, want %d: %+v", len(stale), len(want), stale)
+	}
+	for i := range want {
+		if stale[i] != want[i] {
+			t.Errorf("stale[%d] = %+v, want %+v", i, stale[i], want[i])
+		}
+	}
+}
+
+func TestSyncCommand_syncTarget_DeleteStale(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		check       bool
+		removeErr   error
+		wantRemoved []string
+		wantErr     bool
+		wantSkipped bool
+	}{
+		{
+			name:        "removes stale symlinks",
+			wantRemoved: []string{"/repo/feat/.old"},
+		},
+		{
+			name:  "check mode does not remove",
+			check: true,
+		},
+		{
+			name:      "remove failure is target error",
+			removeErr: errors.New("permission denied"),
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var removed []string
+			mockFS := &testutil.MockFS{
+				DirContents: map[string][]os.DirEntry{
+					"/repo/feat": {sizedDirEntry{name: ".old", mode: fs.ModeSymlink}},
+				},
+				SymlinkTargets: map[string]string{"/repo/feat/.old": "../main/.old"},
+				RemoveFunc: func(name string) error {
+					if tt.removeErr != nil {
+						return tt.removeErr
+					}
+					removed = append(removed, name)
+					return nil
+				},
+			}
+			cmd := NewSyncCommand(mockFS, &GitRunner{Executor: &testutil.MockGitExecutor{}, Log: NewNopLogger()}, nil)
+
+			result := cmd.syncTarget(t.Context(), "/repo/main", Worktree{Path: "/repo/feat", Branch: "feat"}, SyncOptions{
+				Check:       tt.check,
+				DeleteStale: true,
+			})
+
+			if (result.Err != nil) != tt.wantErr {
+				t.Fatalf("Err = %v, want error = %v", result.Err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(result.StaleSymlinks) != 1 {
+				t.Errorf("StaleSymlinks = %+v, want 1 entry", result.StaleSymlinks)
+			}
+			if strings.Join(removed, ",") != strings.Join(tt.wantRemoved, ",") {
+				t.Errorf("removed = %v, want %v", removed, tt.wantRemoved)
+			}
+			if result.Skipped {
+				t.Errorf("target should not be skipped when stale symlinks are found")
+			}
 		})
 	}
 }
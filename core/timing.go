@@ -0,0 +1,127 @@
+package core
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Phases recorded in Timings besides git commands (see timingPhaseGit).
+const (
+	TimingPhaseConfig     = "config load"
+	TimingPhaseSymlinks   = "symlink creation"
+	TimingPhaseSubmodules = "submodule init"
+)
+
+// TimingPhase is the time spent in one phase of a command.
+type TimingPhase struct {
+	Name  string
+	Count int           // Times the phase ran
+	Total time.Duration // Summed over all runs
+}
+
+// Timings accumulates how long each phase of a command took, for the
+// --timing breakdown. It is safe for concurrent use.
+type Timings struct {
+	mu     sync.Mutex
+	phases map[string]*TimingPhase
+}
+
+// NewTimings creates an empty Timings.
+func NewTimings() *Timings {
+	return &Timings{phases: make(map[string]*TimingPhase)}
+}
+
+// Record adds one run of phase taking d. Recording into a nil Timings
+// does nothing, so callers need not check whether --timing is on.
+func (t *Timings) Record(phase string, d time.Duration) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	p, ok := t.phases[phase]
+	if !ok {
+		p = &TimingPhase{Name: phase}
+		t.phases[phase] = p
+	}
+	p.Count++
+	p.Total += d
+}
+
+// Phases returns the recorded phases, longest first.
+func (t *Timings) Phases() []TimingPhase {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	phases := make([]TimingPhase, 0, len(t.phases))
+	for _, p := range t.phases {
+		phases = append(phases, *p)
+	}
+	slices.SortFunc(phases, func(a, b TimingPhase) int {
+		if c := cmp.Compare(b.Total, a.Total); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Name, b.Name)
+	})
+	return phases
+}
+
+// Format returns the breakdown printed by --timing for a command that
+// ran for elapsed. Phases overlap where they run in parallel or nest
+// (submodule init runs git), so they need not add up to elapsed.
+func (t *Timings) Format(elapsed time.Duration) string {
+	phases := t.Phases()
+	width := 0
+	for _, p := range phases {
+		width = max(width, len(p.Name))
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "timing: %s total\n", formatTimingDuration(elapsed))
+	for _, p := range phases {
+		fmt.Fprintf(&sb, "  %-*s  %8s", width, p.Name, formatTimingDuration(p.Total))
+		if p.Count > 1 {
+			fmt.Fprintf(&sb, "  (%d calls)", p.Count)
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// formatTimingDuration rounds d for display, keeping three significant
+// digits for durations below a second.
+func formatTimingDuration(d time.Duration) string {
+	switch {
+	case d >= time.Second:
+		return d.Round(time.Millisecond).String()
+	case d >= time.Millisecond:
+		return d.Round(10 * time.Microsecond).String()
+	default:
+		return d.Round(time.Microsecond).String()
+	}
+}
+
+// recordSince records phase as having run since start.
+func (t *Timings) recordSince(phase string, start time.Time) {
+	t.Record(phase, time.Since(start))
+}
+
+// timingPhaseGit names the phase of a git command after its subcommand,
+// and the sub-subcommand for commands such as git worktree.
+func timingPhaseGit(args []string) string {
+	args = gitSubcommandArgs(args)
+	if len(args) == 0 {
+		return "git"
+	}
+	phase := "git " + args[0]
+	switch args[0] {
+	case GitCmdWorktree, GitCmdStash, GitCmdSparseCheckout, GitCmdSubmodule:
+		if len(args) > 1 && !strings.HasPrefix(args[1], "-") {
+			phase += " " + args[1]
+		}
+	}
+	return phase
+}
@@ -0,0 +1,51 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimings_Format(t *testing.T) {
+	t.Parallel()
+
+	timings := NewTimings()
+	timings.Record("git status", 40*time.Millisecond)
+	timings.Record(TimingPhaseConfig, 2*time.Millisecond)
+	timings.Record("git status", 15*time.Millisecond)
+	timings.Record(TimingPhaseSubmodules, 1500*time.Millisecond)
+
+	got := timings.Format(1600 * time.Millisecond)
+	want := "timing: 1.6s total\n" +
+		"  submodule init      1.5s\n" +
+		"  git status          55ms  (2 calls)\n" +
+		"  config load          2ms\n"
+	if got != want {
+		t.Errorf("Format() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestTimingPhaseGit(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{args: []string{GitCmdWorktree, GitWorktreeList, "--porcelain"}, want: "git worktree list"},
+		{args: []string{GitCmdStash, "--include-untracked"}, want: "git stash"},
+		{args: []string{GitCmdRevParse, "--abbrev-ref", "HEAD"}, want: "git rev-parse"},
+		{args: []string{GitCmdSubmodule, GitSubmoduleUpdate, "--init"}, want: "git submodule update"},
+		{args: []string{"-c", "user.name=twig", GitCmdCommitTree, "HEAD^{tree}"}, want: "git commit-tree"},
+		{args: nil, want: "git"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			t.Parallel()
+
+			if got := timingPhaseGit(tt.args); got != tt.want {
+				t.Errorf("timingPhaseGit(%v) = %q, want %q", tt.args, got, tt.want)
+			}
+		})
+	}
+}
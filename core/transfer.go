@@ -0,0 +1,248 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// changesPatchFile is the name of the patch written to the scratch dir
+// while transferring changes.
+const changesPatchFile = "changes.patch"
+
+// changeSet holds the uncommitted changes of a worktree, captured so they
+// can be copied to another worktree. Transferring changes this way leaves
+// the user's stash stack alone.
+type changeSet struct {
+	root      string   // Root of the worktree the changes were captured from
+	patch     []byte   // Changes to tracked files against HEAD
+	added     []string // Files staged as new, relative to root
+	untracked []string // Untracked files, relative to root
+}
+
+func (s *changeSet) empty() bool {
+	return len(s.patch) == 0 && len(s.untracked) == 0
+}
+
+// captureChanges records the changes in the worktree of src, limited to
+// pathspecs if any. Ignored files are included only with includeIgnored.
+// The worktree itself is not modified.
+func (c *AddCommand) captureChanges(ctx context.Context, src *GitRunner, pathspecs []string, includeIgnored bool) (*changeSet, error) {
+	root, err := src.WorktreeRoot(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find worktree root of %s: %w", src.Dir, err)
+	}
+	patch, err := src.DiffPatch(ctx, pathspecs...)
+	if err != nil {
+		return nil, err
+	}
+	added, err := src.StagedNewFiles(ctx, pathspecs...)
+	if err != nil {
+		return nil, err
+	}
+	untracked, err := src.UntrackedFiles(ctx, pathspecs...)
+	if err != nil {
+		return nil, err
+	}
+	if includeIgnored {
+		ignored, err := src.IgnoredFiles(ctx, pathspecs...)
+		if err != nil {
+			return nil, err
+		}
+		untracked = append(untracked, ignored...)
+	}
+	return &changeSet{root: root, patch: patch, added: added, untracked: untracked}, nil
+}
+
+// applyChanges reproduces changes in the worktree at dir, which must be
+// clean. Like git stash apply, modifications are left unstaged and only
+// files that were staged as new are added to the index.
+func (c *AddCommand) applyChanges(ctx context.Context, dir string, changes *changeSet, patchFile string) error {
+	dst := c.Git.InDir(dir)
+	if len(changes.patch) > 0 {
+		// --3way merges the changes when the new worktree starts from a
+		// different commit than the source
+		if err := dst.ApplyPatch(ctx, patchFile, WithThreeWay()); err != nil {
+			return err
+		}
+		if err := dst.ResetIndex(ctx); err != nil {
+			return err
+		}
+		if len(changes.added) > 0 {
+			if err := dst.Add(ctx, changes.added...); err != nil {
+				return err
+			}
+		}
+	}
+	for _, path := range changes.untracked {
+		if err := c.copyUntrackedFile(filepath.Join(changes.root, path), filepath.Join(dir, path)); err != nil {
+			return fmt.Errorf("failed to copy %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// copyUntrackedFile copies an untracked file, keeping symlinks as links
+// and the permission bits of regular files. Like git stash apply, it
+// refuses to overwrite a file that already exists at the destination.
+func (c *AddCommand) copyUntrackedFile(srcPath, dstPath string) error {
+	if _, err := c.FS.Lstat(dstPath); err == nil {
+		return fmt.Errorf("%s already exists", dstPath)
+	} else if !c.FS.IsNotExist(err) {
+		return err
+	}
+	info, err := c.FS.Lstat(srcPath)
+	if err != nil {
+		return err
+	}
+	if err := c.FS.MkdirAll(filepath.Dir(dstPath), 0755); err != nil {
+		return err
+	}
+	if info != nil && info.Mode()&fs.ModeSymlink != 0 {
+		target, err := c.FS.Readlink(srcPath)
+		if err != nil {
+			return err
+		}
+		return c.FS.Symlink(target, dstPath)
+	}
+	data, err := c.FS.ReadFile(srcPath)
+	if err != nil {
+		return err
+	}
+	perm := fs.FileMode(0644)
+	if info != nil {
+		perm = info.Mode().Perm()
+	}
+	return c.FS.WriteFile(dstPath, data, perm)
+}
+
+// carryBackup records what restoreChanges needs to put the source
+// worktree back as it was before removeChanges.
+type carryBackup struct {
+	index    string // Tree of the index before it was reset (empty = not reset)
+	reversed bool   // The patch was reversed in the worktree
+}
+
+// removeChanges undoes the captured changes in the source worktree after
+// they were carried, like git stash push does. A patch that no longer
+// reverses cleanly means the files were edited in the meantime, and those
+// edits are kept. backup records how far it got, for restoreChanges.
+func (c *AddCommand) removeChanges(ctx context.Context, src *GitRunner, changes *changeSet, pathspecs []string, patchFile string, backup *carryBackup) error {
+	if len(changes.patch) > 0 {
+		tree, err := src.WriteTree(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to back up the index: %w", err)
+		}
+		backup.index = tree
+		if err := src.ResetIndex(ctx, pathspecs...); err != nil {
+			return err
+		}
+		if err := src.InDir(changes.root).ApplyPatch(ctx, patchFile, WithReverse()); err != nil {
+			return err
+		}
+		backup.reversed = true
+	}
+	for _, path := range changes.untracked {
+		file := filepath.Join(changes.root, path)
+		if err := c.FS.Remove(file); err != nil && !c.FS.IsNotExist(err) {
+			return err
+		}
+		removeEmptyParentDirs(ctx, c.FS, changes.root, file, c.Log, LogCategoryDebug)
+	}
+	return nil
+}
+
+// restoreChanges puts the source worktree back as it was before a failed
+// removeChanges: the patch is applied again if it was reversed, untracked
+// files already removed are copied back from the new worktree at dir, and
+// the index is read back from the backup.
+func (c *AddCommand) restoreChanges(ctx context.Context, src *GitRunner, changes *changeSet, patchFile, dir string, backup carryBackup) error {
+	root := src.InDir(changes.root)
+	if backup.reversed {
+		if err := root.ApplyPatch(ctx, patchFile); err != nil {
+			return fmt.Errorf("failed to apply the changes again: %w", err)
+		}
+	}
+	for _, path := range changes.untracked {
+		file := filepath.Join(changes.root, path)
+		if _, err := c.FS.Lstat(file); err == nil {
+			continue
+		} else if !c.FS.IsNotExist(err) {
+			return fmt.Errorf("failed to restore %s: %w", path, err)
+		}
+		if err := c.copyUntrackedFile(filepath.Join(dir, path), file); err != nil {
+			return fmt.Errorf("failed to restore %s: %w", path, err)
+		}
+	}
+	if backup.index != "" {
+		if err := root.ReadTree(ctx, backup.index); err != nil {
+			return fmt.Errorf("failed to restore the index: %w", err)
+		}
+	}
+	return nil
+}
+
+// finishCarry removes the carried changes from the source worktree once
+// they are in the new worktree at dir. If that fails, the source is
+// restored to how it was before the command, so the changes are in both
+// worktrees. If restoring fails too, the patch is saved under
+// <git-common-dir>/twig. It returns "" when the changes were removed, and
+// otherwise what happened and where the changes are, for AddResult.CarryLeft.
+func (c *AddCommand) finishCarry(ctx context.Context, src *GitRunner, changes *changeSet, pathspecs []string, patchFile, dir string) string {
+	var backup carryBackup
+	err := c.removeChanges(ctx, src, changes, pathspecs, patchFile, &backup)
+	if err == nil {
+		return ""
+	}
+	c.Log.DebugContext(ctx, "failed to remove carried changes",
+		"path", c.CarryFrom,
+		"error", err)
+
+	restoreErr := c.restoreChanges(ctx, src, changes, patchFile, dir, backup)
+	if restoreErr == nil {
+		return fmt.Sprintf("failed to remove carried changes from %s, so they were restored there and are in both worktrees: %v", c.CarryFrom, err)
+	}
+	c.Log.DebugContext(ctx, "failed to restore carried changes",
+		"path", c.CarryFrom,
+		"error", restoreErr)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "failed to remove carried changes from %s: %v; restoring them failed too: %v\n", c.CarryFrom, err, restoreErr)
+	fmt.Fprintf(&sb, "  the carried changes are in %s", dir)
+	if patchFile != "" {
+		if saved, err := c.saveCarryPatch(ctx, patchFile); err != nil {
+			c.Log.DebugContext(ctx, "failed to save carry patch", "error", err)
+		} else {
+			fmt.Fprintf(&sb, "\n  the patch of the tracked changes is saved at %s", saved)
+		}
+	}
+	if backup.index != "" {
+		fmt.Fprintf(&sb, "\n  the index of %s before the carry is tree %s (git read-tree %s)", c.CarryFrom, backup.index, backup.index)
+	}
+	return sb.String()
+}
+
+// saveCarryPatch copies the patch of carried changes out of the scratch
+// dir, which is removed when add returns, to <git-common-dir>/twig.
+func (c *AddCommand) saveCarryPatch(ctx context.Context, patchFile string) (string, error) {
+	commonDir, err := c.Git.GitCommonDir(ctx)
+	if err != nil {
+		return "", err
+	}
+	data, err := c.FS.ReadFile(patchFile)
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(commonDir, auditDirName)
+	if err := c.FS.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, fmt.Sprintf("carry-%s.patch", time.Now().Format("20060102-150405")))
+	if err := c.FS.WriteFile(path, data, 0644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
@@ -0,0 +1,108 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// VCS names returned by VCS.Name.
+const (
+	VCSGit         = "git"
+	VCSJJColocated = "jj-colocated"
+)
+
+// jjDirName is the directory jj keeps next to .git in a colocated
+// repository.
+const jjDirName = ".jj"
+
+// VCS is a version control system used on top of git. twig always runs
+// git, but a VCS managing the repository can change what git reports,
+// and knows how to read it back.
+type VCS interface {
+	// Name identifies the VCS, e.g. VCSGit.
+	Name() string
+
+	// ResolveBranches sets the branch of worktrees that git reports as
+	// detached but the VCS tracks on a branch. worktrees is updated in
+	// place.
+	ResolveBranches(ctx context.Context, git *GitRunner, worktrees []Worktree) error
+}
+
+// DetectVCS returns the VCS of the repository whose main worktree is at
+// mainPath: jj when a .jj directory sits next to .git, git otherwise.
+func DetectVCS(mainPath string) VCS {
+	if mainPath != "" {
+		if _, err := statFunc(filepath.Join(mainPath, jjDirName)); err == nil {
+			return jjColocatedVCS{}
+		}
+	}
+	return gitVCS{}
+}
+
+// gitVCS is plain git, where git's view of branches is the whole truth.
+type gitVCS struct{}
+
+func (gitVCS) Name() string { return VCSGit }
+
+func (gitVCS) ResolveBranches(context.Context, *GitRunner, []Worktree) error { return nil }
+
+// jjColocatedVCS is a jj repository colocated with git. jj keeps HEAD
+// detached at the parent of the working-copy commit, and exports its
+// bookmarks as git branches, so a detached worktree is on the bookmark
+// pointing at its HEAD. A bookmark on the working-copy commit itself is
+// not visible to git and is not resolved.
+type jjColocatedVCS struct{}
+
+func (jjColocatedVCS) Name() string { return VCSJJColocated }
+
+// ResolveBranches reads the bookmark of each detached worktree from the
+// branches at its HEAD. Worktrees whose HEAD has no bookmark, or more
+// than one, stay detached, as does a bookmark at the HEAD of several
+// worktrees or checked out in another one.
+func (jjColocatedVCS) ResolveBranches(ctx context.Context, git *GitRunner, worktrees []Worktree) error {
+	if !slices.ContainsFunc(worktrees, func(wt Worktree) bool { return wt.Detached && !wt.Bare }) {
+		return nil
+	}
+	out, err := git.Run(ctx, GitCmdForEachRef, "--format=%(objectname) %(refname)", RefsHeadsPrefix)
+	if err != nil {
+		return fmt.Errorf("failed to list bookmarks: %w", err)
+	}
+	bookmarks := make(map[string][]string) // commit -> bookmarks
+	for line := range strings.SplitSeq(strings.TrimSpace(string(out)), "\n") {
+		commit, ref, ok := strings.Cut(line, " ")
+		if !ok {
+			continue
+		}
+		bookmarks[commit] = append(bookmarks[commit], strings.TrimPrefix(ref, RefsHeadsPrefix))
+	}
+
+	// bookmarkOf returns the only bookmark at the HEAD of a detached worktree
+	bookmarkOf := func(wt Worktree) string {
+		if !wt.Detached || wt.Bare || len(bookmarks[wt.HEAD]) != 1 {
+			return ""
+		}
+		return bookmarks[wt.HEAD][0]
+	}
+	checkedOut := make(map[string]bool)
+	claims := make(map[string]int)
+	for _, wt := range worktrees {
+		if !wt.Detached && wt.Branch != "" {
+			checkedOut[wt.Branch] = true
+		}
+		if bookmark := bookmarkOf(wt); bookmark != "" {
+			claims[bookmark]++
+		}
+	}
+	for i := range worktrees {
+		wt := &worktrees[i]
+		if bookmark := bookmarkOf(*wt); bookmark != "" && claims[bookmark] == 1 && !checkedOut[bookmark] {
+			wt.Branch = bookmark
+			wt.Detached = false
+			wt.Bookmark = true
+		}
+	}
+	return nil
+}
@@ -0,0 +1,211 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// DefaultWatchInterval is how often twig watch polls the source worktree.
+const DefaultWatchInterval = 2 * time.Second
+
+// WatchOptions configures the watch command.
+type WatchOptions struct {
+	SourcePath string        // Source worktree whose symlink sources and config are watched
+	Interval   time.Duration // Poll interval (0 = DefaultWatchInterval)
+
+	// OnError is called with sync errors that do not stop the watch,
+	// such as a busy lock or a failed git command. Nil ignores them.
+	OnError func(err error)
+}
+
+// WatchChange is what a poll found changed in the source worktree.
+type WatchChange struct {
+	Paths []string // Paths relative to the source worktree, sorted
+}
+
+// WatchSyncFunc reconciles the target worktrees after change. It returns
+// the symlink patterns to watch from then on, since the change may have
+// been to the config. A *WatchConfigError stops the watch; other errors
+// are reported and the change is synced again with the next one.
+type WatchSyncFunc func(ctx context.Context, change WatchChange) (symlinks []string, err error)
+
+// WatchConfigError is returned by a WatchSyncFunc when the config of the
+// source worktree cannot be loaded, which stops the watch.
+type WatchConfigError struct {
+	Err error
+}
+
+func (e *WatchConfigError) Error() string {
+	return fmt.Sprintf("failed to load config from source worktree: %v", e.Err)
+}
+
+func (e *WatchConfigError) Unwrap() error {
+	return e.Err
+}
+
+// WatchCommand polls the source worktree for the changes twig sync
+// reconciles: files matching the symlink patterns appearing or
+// disappearing, and edits to the config files. Edits to a file that is
+// already symlinked need no sync, as the links show them.
+//
+// Polling is used instead of filesystem notifications so that patterns
+// matching files in directories that do not exist yet are seen too.
+type WatchCommand struct {
+	FS  FileSystem
+	Log *slog.Logger
+}
+
+// NewWatchCommand creates a WatchCommand with explicit dependencies.
+func NewWatchCommand(fs FileSystem, log *slog.Logger) *WatchCommand {
+	if log == nil {
+		log = NewNopLogger()
+	}
+	return &WatchCommand{
+		FS:  fs,
+		Log: log,
+	}
+}
+
+// NewDefaultWatchCommand creates a WatchCommand with production defaults.
+func NewDefaultWatchCommand(log *slog.Logger, gitOpts ...GitRunnerOption) *WatchCommand {
+	return NewWatchCommand(newDefaultFS(gitOpts), log)
+}
+
+// Run watches the matches of symlinks and the config files in the source
+// worktree, calling sync after each poll that found a change, until ctx
+// is done or sync returns a *WatchConfigError.
+func (c *WatchCommand) Run(ctx context.Context, symlinks []string, opts WatchOptions, sync WatchSyncFunc) error {
+	if opts.SourcePath == "" {
+		return fmt.Errorf("source worktree is required")
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = DefaultWatchInterval
+	}
+
+	prev, err := c.snapshot(opts.SourcePath, symlinks)
+	if err != nil {
+		return err
+	}
+	c.Log.DebugContext(ctx, "watch started",
+		LogAttrKeyCategory.String(), LogCategoryWatch,
+		"source", opts.SourcePath,
+		"interval", opts.Interval,
+		"paths", len(prev))
+	return c.watch(ctx, prev, symlinks, opts, sync)
+}
+
+// watch polls opts.SourcePath every opts.Interval until ctx is done,
+// comparing against prev, the snapshot of the matches of symlinks.
+func (c *WatchCommand) watch(ctx context.Context, prev watchSnapshot, symlinks []string, opts WatchOptions, sync WatchSyncFunc) error {
+	sourcePath := opts.SourcePath
+	ticker := time.NewTicker(opts.Interval)
+	defer ticker.Stop()
+
+	// pending holds the changes of failed syncs, which are synced again
+	// together with the next change
+	var pending []string
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		cur, err := c.snapshot(sourcePath, symlinks)
+		if err != nil {
+			return err
+		}
+		changed := prev.changes(cur)
+		if len(changed) == 0 {
+			continue
+		}
+		c.Log.DebugContext(ctx, "source changed",
+			LogAttrKeyCategory.String(), LogCategoryWatch,
+			"paths", changed)
+
+		if len(pending) > 0 {
+			changed = append(pending, changed...)
+			slices.Sort(changed)
+			changed = slices.Compact(changed)
+		}
+
+		next, err := sync(ctx, WatchChange{Paths: changed})
+		prev = cur
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			var configErr *WatchConfigError
+			if errors.As(err, &configErr) {
+				return err
+			}
+			c.Log.DebugContext(ctx, "sync failed, retrying with the next change",
+				LogAttrKeyCategory.String(), LogCategoryWatch,
+				"error", err)
+			if opts.OnError != nil {
+				opts.OnError(err)
+			}
+			pending = changed
+			continue
+		}
+		pending = nil
+		if !slices.Equal(next, symlinks) {
+			// Files the new patterns select were synced already, so they
+			// are not reported as changes on the next poll
+			symlinks = next
+			if prev, err = c.snapshot(sourcePath, symlinks); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// watchSnapshot maps each watched path, relative to the source worktree,
+// to a signature that changes when the path needs a sync.
+type watchSnapshot map[string]string
+
+// snapshot records the matches of patterns under sourcePath and the
+// modification time and size of the config files.
+func (c *WatchCommand) snapshot(sourcePath string, patterns []string) (watchSnapshot, error) {
+	snap := make(watchSnapshot)
+	for _, name := range []string{configFileName, localConfigFileName} {
+		rel := filepath.Join(configDir, name)
+		info, err := c.FS.Stat(filepath.Join(sourcePath, rel))
+		if err != nil || info == nil {
+			continue
+		}
+		snap[rel] = fmt.Sprintf("%d %d", info.ModTime().UnixNano(), info.Size())
+	}
+	for _, pattern := range patterns {
+		matches, err := c.FS.Glob(sourcePath, pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid glob pattern %q: %w", pattern, err)
+		}
+		for _, match := range matches {
+			snap[match] = ""
+		}
+	}
+	return snap, nil
+}
+
+// changes returns the paths added, removed or changed in cur, sorted.
+func (s watchSnapshot) changes(cur watchSnapshot) []string {
+	var changed []string
+	for path, sig := range cur {
+		if old, ok := s[path]; !ok || old != sig {
+			changed = append(changed, path)
+		}
+	}
+	for path := range s {
+		if _, ok := cur[path]; !ok {
+			changed = append(changed, path)
+		}
+	}
+	slices.Sort(changed)
+	return changed
+}
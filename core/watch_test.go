@@ -0,0 +1,212 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestWatchSnapshot_Changes(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		prev watchSnapshot
+		cur  watchSnapshot
+		want []string
+	}{
+		{
+			name: "unchanged",
+			prev: watchSnapshot{".env": "", ".twig/settings.toml": "1 10"},
+			cur:  watchSnapshot{".env": "", ".twig/settings.toml": "1 10"},
+			want: nil,
+		},
+		{
+			name: "added and removed",
+			prev: watchSnapshot{".env": "", ".envrc": ""},
+			cur:  watchSnapshot{".env": "", "config/local.yml": ""},
+			want: []string{".envrc", "config/local.yml"},
+		},
+		{
+			name: "config edited",
+			prev: watchSnapshot{".twig/settings.toml": "1 10"},
+			cur:  watchSnapshot{".twig/settings.toml": "2 12"},
+			want: []string{".twig/settings.toml"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := tt.prev.changes(tt.cur)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("changes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWatchCommand_Watch(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, configDir), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	changes := make(chan []string)
+	symlinks := []string{".env*", "*.yml"}
+	sync := func(ctx context.Context, change WatchChange) ([]string, error) {
+		changes <- change.Paths
+		return symlinks, nil
+	}
+
+	// The initial snapshot is taken here so that the writes below are
+	// always seen as changes
+	cmd := NewWatchCommand(osFS{}, nil)
+	prev, err := cmd.snapshot(dir, symlinks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.watch(ctx, prev, symlinks, WatchOptions{SourcePath: dir, Interval: 10 * time.Millisecond}, sync)
+	}()
+
+	steps := []struct {
+		name  string
+		apply func() error
+		want  []string
+	}{
+		{
+			name:  "source added",
+			apply: func() error { return os.WriteFile(filepath.Join(dir, ".env"), nil, 0644) },
+			want:  []string{".env"},
+		},
+		{
+			name:  "unmatched file added",
+			apply: func() error { return os.WriteFile(filepath.Join(dir, "README.md"), nil, 0644) },
+		},
+		{
+			name: "config edited",
+			apply: func() error {
+				return os.WriteFile(filepath.Join(dir, configDir, configFileName), []byte("symlinks = [\".env*\"]\n"), 0644)
+			},
+			want: []string{filepath.Join(configDir, configFileName)},
+		},
+		{
+			name:  "source removed",
+			apply: func() error { return os.Remove(filepath.Join(dir, ".env")) },
+			want:  []string{".env"},
+		},
+	}
+
+	// Steps share the directory, so they run in order
+	for _, step := range steps {
+		if err := step.apply(); err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		if step.want == nil {
+			continue
+		}
+		select {
+		case got := <-changes:
+			if !slices.Equal(got, step.want) {
+				t.Errorf("%s: changed = %v, want %v", step.name, got, step.want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%s: no change reported, want %v", step.name, step.want)
+		}
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("watch() error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("watch() did not return after cancel")
+	}
+}
+
+func TestWatchCommand_Watch_SyncErrors(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	symlinks := []string{".env*"}
+	errBusy := errors.New("lock busy")
+	errConfig := &WatchConfigError{Err: errors.New("toml: bad value")}
+
+	// Each sync returns the next error in turn
+	errs := []error{errBusy, nil, errConfig}
+	changes := make(chan []string, len(errs))
+	sync := func(ctx context.Context, change WatchChange) ([]string, error) {
+		changes <- change.Paths
+		err := errs[0]
+		errs = errs[1:]
+		return symlinks, err
+	}
+	var reported []error
+	opts := WatchOptions{
+		SourcePath: dir,
+		Interval:   10 * time.Millisecond,
+		OnError:    func(err error) { reported = append(reported, err) },
+	}
+
+	cmd := NewWatchCommand(osFS{}, nil)
+	prev, err := cmd.snapshot(dir, symlinks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.watch(t.Context(), prev, symlinks, opts, sync)
+	}()
+
+	steps := []struct {
+		name string
+		file string
+		want []string
+	}{
+		// The failed sync keeps watching
+		{name: "lock busy", file: ".env", want: []string{".env"}},
+		// The change of the failed sync is synced again with the next one
+		{name: "retried", file: ".envrc", want: []string{".env", ".envrc"}},
+		// A config error stops the watch
+		{name: "config error", file: ".env.local", want: []string{".env.local"}},
+	}
+	for _, step := range steps {
+		if err := os.WriteFile(filepath.Join(dir, step.file), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case got := <-changes:
+			if !slices.Equal(got, step.want) {
+				t.Errorf("%s: changed = %v, want %v", step.name, got, step.want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%s: no change reported, want %v", step.name, step.want)
+		}
+	}
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, errConfig) {
+			t.Errorf("watch() error = %v, want %v", err, errConfig)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("watch() did not return after a config error")
+	}
+	if len(reported) != 1 || !errors.Is(reported[0], errBusy) {
+		t.Errorf("OnError got %v, want [%v]", reported, errBusy)
+	}
+}
@@ -0,0 +1,16 @@
+// Package twig keeps the import path of the twig library stable.
+//
+// The library is split into packages that can be used on their own:
+//
+//   - core: the operations (AddCommand, RemoveCommand, CleanCommand,
+//     SyncCommand, ...), git, config and the other abstractions they use.
+//   - format: the output primitives results render with (format options
+//     and results, the color palette).
+//   - cli: the cobra command tree of the twig binary.
+//
+// Every identifier this package exported before the split is an alias of
+// the same identifier in core or format, so existing code keeps building
+// and values can be passed between both import paths. New code should
+// import core directly. This package never imports cli, so it does not
+// pull in cobra.
+package twig
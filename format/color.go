@@ -0,0 +1,183 @@
+package format
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// ColorMode defines color output behavior.
+type ColorMode string
+
+const (
+	ColorModeAuto   ColorMode = "auto"   // Color when TTY, unless NO_COLOR or CLICOLOR_FORCE is set
+	ColorModeAlways ColorMode = "always" // Always color
+	ColorModeNever  ColorMode = "never"  // No color
+)
+
+// The palette. The functions color their arguments unless color output
+// is disabled; SetColor overrides the ones with a [colors] setting.
+var (
+	// Section headers
+	ColorClean = color.New(color.FgGreen, color.Bold).SprintFunc()
+	ColorSkip  = color.New(color.FgYellow, color.Bold).SprintFunc()
+
+	// Status markers
+	ColorSuccess = color.New(color.FgGreen).SprintFunc() // ✓
+	ColorFailure = color.New(color.FgRed).SprintFunc()   // ✗
+
+	// Worktree markers in list output
+	ColorMain    = color.New(color.FgCyan).SprintFunc()              // *
+	ColorCurrent = color.New(color.FgGreen, color.Bold).SprintFunc() // @
+
+	// Worktree states in list --tree
+	ColorDirty  = color.New(color.FgYellow).SprintFunc()
+	ColorLocked = color.New(color.FgRed).SprintFunc()
+
+	// Match prefixes in grep output
+	ColorGrepBranch = color.New(color.FgCyan).SprintFunc()
+	ColorGrepFile   = color.New(color.FgMagenta).SprintFunc()
+	ColorGrepLine   = color.New(color.FgGreen).SprintFunc()
+
+	// Reasons
+	ColorReason = color.New(color.FgHiBlack).SprintFunc() // (merged)
+
+	// Errors
+	ColorError = color.New(color.FgRed).SprintFunc()
+)
+
+// colorTTY is the fatih/color default (TTY detection, NO_COLOR,
+// TERM=dumb), restored by ColorModeAuto.
+var colorTTY = !color.NoColor
+
+// SetColorMode configures color output based on mode.
+func SetColorMode(mode ColorMode) {
+	color.NoColor = !colorEnabled(mode, os.Getenv, colorTTY)
+}
+
+// colorEnabled decides whether to color output. Explicit modes win over
+// the environment; in auto mode NO_COLOR disables color and
+// CLICOLOR_FORCE enables it even without a TTY (https://no-color.org,
+// https://bixense.com/clicolors).
+func colorEnabled(mode ColorMode, getenv func(string) string, tty bool) bool {
+	switch mode {
+	case ColorModeAlways:
+		return true
+	case ColorModeNever:
+		return false
+	}
+	if getenv("NO_COLOR") != "" {
+		return false
+	}
+	if force := getenv("CLICOLOR_FORCE"); force != "" && force != "0" {
+		return true
+	}
+	return tty
+}
+
+// IsColorEnabled returns whether color output is enabled.
+// This should be called after SetColorMode.
+func IsColorEnabled() bool {
+	return !color.NoColor
+}
+
+// Paint applies colorize to s when enabled is set.
+func Paint(enabled bool, colorize func(...any) string, s string) string {
+	if enabled {
+		return colorize(s)
+	}
+	return s
+}
+
+// colorNames maps the color names accepted in [colors] to attributes.
+var colorNames = map[string]color.Attribute{
+	"black":         color.FgBlack,
+	"red":           color.FgRed,
+	"green":         color.FgGreen,
+	"yellow":        color.FgYellow,
+	"blue":          color.FgBlue,
+	"magenta":       color.FgMagenta,
+	"cyan":          color.FgCyan,
+	"white":         color.FgWhite,
+	"brightblack":   color.FgHiBlack,
+	"brightred":     color.FgHiRed,
+	"brightgreen":   color.FgHiGreen,
+	"brightyellow":  color.FgHiYellow,
+	"brightblue":    color.FgHiBlue,
+	"brightmagenta": color.FgHiMagenta,
+	"brightcyan":    color.FgHiCyan,
+	"brightwhite":   color.FgHiWhite,
+}
+
+// colorAttrNames maps the attribute names accepted in [colors].
+var colorAttrNames = map[string]color.Attribute{
+	"bold":      color.Bold,
+	"dim":       color.Faint,
+	"italic":    color.Italic,
+	"ul":        color.Underline,
+	"underline": color.Underline,
+}
+
+// ParseColorSpec parses a [colors] value like git's color settings: at
+// most one color name plus attributes, separated by spaces (e.g.
+// "bold red", "brightblack"). "normal" means no color.
+func ParseColorSpec(spec string) ([]color.Attribute, error) {
+	var attrs []color.Attribute
+	hasColor := false
+	for _, word := range strings.Fields(strings.ToLower(spec)) {
+		if word == "normal" {
+			continue
+		}
+		if attr, ok := colorAttrNames[word]; ok {
+			attrs = append(attrs, attr)
+			continue
+		}
+		attr, ok := colorNames[word]
+		if !ok {
+			return nil, fmt.Errorf("unknown color %q", word)
+		}
+		if hasColor {
+			return nil, fmt.Errorf("more than one color in %q", spec)
+		}
+		hasColor = true
+		attrs = append(attrs, attr)
+	}
+	return attrs, nil
+}
+
+// settableColors maps the [colors] setting names to the palette entries
+// they override.
+var settableColors = map[string]*func(...any) string{
+	"clean":   &ColorClean,
+	"skip":    &ColorSkip,
+	"success": &ColorSuccess,
+	"failure": &ColorFailure,
+	"main":    &ColorMain,
+	"current": &ColorCurrent,
+	"reason":  &ColorReason,
+	"error":   &ColorError,
+}
+
+// SetColor overrides the palette entry of the [colors] setting name with
+// spec, parsed by ParseColorSpec. An empty spec keeps the default.
+func SetColor(name, spec string) error {
+	colorize, ok := settableColors[name]
+	if !ok {
+		return fmt.Errorf("unknown color setting %q", name)
+	}
+	if spec == "" {
+		return nil
+	}
+	attrs, err := ParseColorSpec(spec)
+	if err != nil {
+		return err
+	}
+	if len(attrs) == 0 {
+		*colorize = fmt.Sprint
+		return nil
+	}
+	*colorize = color.New(attrs...).SprintFunc()
+	return nil
+}
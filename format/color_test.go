@@ -0,0 +1,236 @@
+package format
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestSetColorMode(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		mode        ColorMode
+		wantNoColor bool
+	}{
+		{
+			name:        "always_enables_color",
+			mode:        ColorModeAlways,
+			wantNoColor: false,
+		},
+		{
+			name:        "never_disables_color",
+			mode:        ColorModeNever,
+			wantNoColor: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Save original state
+			original := color.NoColor
+			defer func() { color.NoColor = original }()
+
+			SetColorMode(tt.mode)
+
+			if color.NoColor != tt.wantNoColor {
+				t.Errorf("color.NoColor = %v, want %v", color.NoColor, tt.wantNoColor)
+			}
+		})
+	}
+}
+
+func TestIsColorEnabled(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		noColor  bool
+		wantBool bool
+	}{
+		{
+			name:     "returns_true_when_color_enabled",
+			noColor:  false,
+			wantBool: true,
+		},
+		{
+			name:     "returns_false_when_color_disabled",
+			noColor:  true,
+			wantBool: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Save original state
+			original := color.NoColor
+			defer func() { color.NoColor = original }()
+
+			color.NoColor = tt.noColor
+			got := IsColorEnabled()
+
+			if got != tt.wantBool {
+				t.Errorf("IsColorEnabled() = %v, want %v", got, tt.wantBool)
+			}
+		})
+	}
+}
+
+func TestColorFunctions(t *testing.T) {
+	// Save original state
+	original := color.NoColor
+	defer func() { color.NoColor = original }()
+
+	// Test with color enabled
+	color.NoColor = false
+
+	tests := []struct {
+		name string
+		fn   func(a ...any) string
+		text string
+	}{
+		{"ColorClean", ColorClean, "clean:"},
+		{"ColorSkip", ColorSkip, "skip:"},
+		{"ColorSuccess", ColorSuccess, "✓"},
+		{"ColorFailure", ColorFailure, "✗"},
+		{"ColorReason", ColorReason, "(merged)"},
+		{"ColorError", ColorError, "error:"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name+"_with_color", func(t *testing.T) {
+			result := tt.fn(tt.text)
+			// With color enabled, result should contain ANSI codes
+			if result == tt.text {
+				t.Errorf("%s should add ANSI codes when color is enabled", tt.name)
+			}
+			// Result should still contain the original text
+			if len(result) < len(tt.text) {
+				t.Errorf("%s result should contain original text", tt.name)
+			}
+		})
+	}
+
+	// Test with color disabled
+	color.NoColor = true
+
+	for _, tt := range tests {
+		t.Run(tt.name+"_without_color", func(t *testing.T) {
+			result := tt.fn(tt.text)
+			// With color disabled, result should equal original text
+			if result != tt.text {
+				t.Errorf("%s() = %q, want %q when color disabled", tt.name, result, tt.text)
+			}
+		})
+	}
+}
+
+func TestColorEnabled(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		mode ColorMode
+		env  map[string]string
+		tty  bool
+		want bool
+	}{
+		{name: "auto_tty", mode: ColorModeAuto, tty: true, want: true},
+		{name: "auto_pipe", mode: ColorModeAuto, tty: false, want: false},
+		{name: "auto_no_color", mode: ColorModeAuto, env: map[string]string{"NO_COLOR": "1"}, tty: true, want: false},
+		{name: "auto_clicolor_force", mode: ColorModeAuto, env: map[string]string{"CLICOLOR_FORCE": "1"}, want: true},
+		{name: "auto_clicolor_force_zero", mode: ColorModeAuto, env: map[string]string{"CLICOLOR_FORCE": "0"}, want: false},
+		{
+			name: "auto_no_color_wins_over_force",
+			mode: ColorModeAuto,
+			env:  map[string]string{"NO_COLOR": "1", "CLICOLOR_FORCE": "1"},
+			want: false,
+		},
+		{name: "always_ignores_no_color", mode: ColorModeAlways, env: map[string]string{"NO_COLOR": "1"}, want: true},
+		{name: "never_ignores_force", mode: ColorModeNever, env: map[string]string{"CLICOLOR_FORCE": "1"}, tty: true, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			getenv := func(key string) string { return tt.env[key] }
+			if got := colorEnabled(tt.mode, getenv, tt.tty); got != tt.want {
+				t.Errorf("colorEnabled() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseColorSpec(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		spec    string
+		want    []color.Attribute
+		wantErr bool
+	}{
+		{spec: "green", want: []color.Attribute{color.FgGreen}},
+		{spec: "bold red", want: []color.Attribute{color.Bold, color.FgRed}},
+		{spec: "BrightBlack ul", want: []color.Attribute{color.FgHiBlack, color.Underline}},
+		{spec: "normal", want: nil},
+		{spec: "crimson", wantErr: true},
+		{spec: "red green", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ParseColorSpec(tt.spec)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseColorSpec(%q) = %v, want error", tt.spec, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseColorSpec(%q) error: %v", tt.spec, err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("ParseColorSpec(%q) = %v, want %v", tt.spec, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetColor(t *testing.T) {
+	// Save original state
+	original := color.NoColor
+	originalClean, originalError := ColorClean, ColorError
+	defer func() {
+		color.NoColor = original
+		ColorClean, ColorError = originalClean, originalError
+	}()
+
+	color.NoColor = false
+	if err := SetColor("clean", "blue"); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetColor("error", "normal"); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := ColorClean("clean:"), color.New(color.FgBlue).Sprint("clean:"); got != want {
+		t.Errorf("ColorClean() = %q, want %q", got, want)
+	}
+	if got := ColorError("error:"); got != "error:" {
+		t.Errorf("ColorError() = %q, want uncolored with normal", got)
+	}
+	if err := SetColor("skip", "crimson"); err == nil {
+		t.Error("SetColor(skip, crimson) = nil, want error")
+	}
+	if err := SetColor("title", "red"); err == nil {
+		t.Error("SetColor(title, red) = nil, want error for an unknown setting")
+	}
+	if got, want := ColorSkip("skip:"), color.New(color.FgYellow, color.Bold).Sprint("skip:"); got != want {
+		t.Errorf("ColorSkip() = %q, want default %q", got, want)
+	}
+}
@@ -0,0 +1,25 @@
+// Package format holds the output primitives shared by twig's results:
+// the options and result of formatting, and the color palette.
+//
+// It depends on neither the core operations nor the CLI framework, so the
+// core package can render its results with it and the CLI can configure
+// colors without importing each other.
+package format
+
+// Options configures output formatting.
+type Options struct {
+	Verbose      bool
+	Quiet        bool // Print only the branches or paths affected; errors and warnings still go to stderr
+	ColorEnabled bool // Enable color output (--color=auto/always)
+}
+
+// Result holds formatted output strings.
+type Result struct {
+	Stdout string
+	Stderr string
+}
+
+// Formatter formats command results.
+type Formatter interface {
+	Format(opts Options) Result
+}
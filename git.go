@@ -1,1023 +0,0 @@
-package twig
-
-import (
-	"context"
-	"errors"
-	"fmt"
-	"log/slog"
-	"os"
-	"os/exec"
-	"path/filepath"
-	"strings"
-)
-
-// GitExecutor abstracts git command execution for testability.
-// Commands are fixed to "git" - only subcommands and args are passed.
-type GitExecutor interface {
-	// Run executes git with args and returns stdout.
-	Run(ctx context.Context, args ...string) ([]byte, error)
-}
-
-type osGitExecutor struct{}
-
-func (e osGitExecutor) Run(ctx context.Context, args ...string) ([]byte, error) {
-	return exec.CommandContext(ctx, "git", args...).Output()
-}
-
-// GitOp represents the type of git operation.
-type GitOp int
-
-const (
-	OpWorktreeRemove GitOp = iota + 1
-	OpBranchDelete
-)
-
-// Git command names.
-const (
-	GitCmdWorktree   = "worktree"
-	GitCmdBranch     = "branch"
-	GitCmdStash      = "stash"
-	GitCmdStatus     = "status"
-	GitCmdRevParse   = "rev-parse"
-	GitCmdDiff       = "diff"
-	GitCmdFetch      = "fetch"
-	GitCmdForEachRef = "for-each-ref"
-	GitCmdRevList    = "rev-list"
-	GitCmdCheckout   = "checkout"
-	GitCmdReset      = "reset"
-)
-
-// Git worktree subcommands.
-const (
-	GitWorktreeAdd    = "add"
-	GitWorktreeRemove = "remove"
-	GitWorktreeList   = "list"
-	GitWorktreePrune  = "prune"
-)
-
-// Git stash subcommands.
-const (
-	GitStashPush  = "push"
-	GitStashApply = "apply"
-	GitStashDrop  = "drop"
-	GitStashList  = "list"
-)
-
-// Porcelain output format prefixes and values.
-const (
-	PorcelainWorktreePrefix = "worktree "
-	PorcelainHEADPrefix     = "HEAD "
-	PorcelainBranchPrefix   = "branch refs/heads/"
-	PorcelainDetached       = "detached"
-	PorcelainBare           = "bare"
-	PorcelainLocked         = "locked"
-	PorcelainPrunable       = "prunable"
-)
-
-// RefsHeadsPrefix is the git refs prefix for local branches.
-const RefsHeadsPrefix = "refs/heads/"
-
-func (op GitOp) String() string {
-	switch op {
-	case OpWorktreeRemove:
-		return "remove worktree"
-	case OpBranchDelete:
-		return "delete branch"
-	default:
-		return "unknown operation"
-	}
-}
-
-// GitError represents an error from a git operation with structured information.
-type GitError struct {
-	Op     GitOp
-	Stderr string
-	Err    error
-}
-
-func (e *GitError) Error() string {
-	if e.Stderr != "" {
-		return fmt.Sprintf("failed to %s: %v: %s", e.Op, e.Err, e.Stderr)
-	}
-	return fmt.Sprintf("failed to %s: %v", e.Op, e.Err)
-}
-
-func (e *GitError) Unwrap() error {
-	return e.Err
-}
-
-// newGitError creates a GitError from a git operation error.
-// It extracts stderr from exec.ExitError if available.
-func newGitError(op GitOp, err error) *GitError {
-	gitErr := &GitError{
-		Op:  op,
-		Err: err,
-	}
-	var exitErr *exec.ExitError
-	if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
-		gitErr.Stderr = strings.TrimSpace(string(exitErr.Stderr))
-	}
-	return gitErr
-}
-
-// GitRunner provides git operations using GitExecutor.
-type GitRunner struct {
-	Executor GitExecutor
-	Dir      string
-	Log      *slog.Logger
-}
-
-type gitRunnerOptions struct {
-	log *slog.Logger
-}
-
-// GitRunnerOption configures GitRunner.
-type GitRunnerOption func(*gitRunnerOptions)
-
-// WithLogger sets the logger for GitRunner.
-func WithLogger(log *slog.Logger) GitRunnerOption {
-	return func(o *gitRunnerOptions) {
-		o.log = log
-	}
-}
-
-// NewGitRunner creates a new GitRunner with the default executor.
-func NewGitRunner(dir string, opts ...GitRunnerOption) *GitRunner {
-	o := &gitRunnerOptions{
-		log: NewNopLogger(),
-	}
-	for _, opt := range opts {
-		opt(o)
-	}
-	return &GitRunner{
-		Executor: osGitExecutor{},
-		Dir:      dir,
-		Log:      o.log,
-	}
-}
-
-// InDir returns a GitRunner that executes commands in the specified directory.
-func (g *GitRunner) InDir(dir string) *GitRunner {
-	return &GitRunner{Executor: g.Executor, Dir: dir, Log: g.Log}
-}
-
-// Run executes git command with -C flag.
-func (g *GitRunner) Run(ctx context.Context, args ...string) ([]byte, error) {
-	fullArgs := append([]string{"-C", g.Dir}, args...)
-	g.Log.Debug(strings.Join(append([]string{"git"}, fullArgs...), " "), "category", LogCategoryGit)
-	return g.Executor.Run(ctx, fullArgs...)
-}
-
-type worktreeAddOptions struct {
-	createBranch bool
-	lock         bool
-	lockReason   string
-}
-
-func (o worktreeAddOptions) lockArgs() []string {
-	if !o.lock {
-		return nil
-	}
-	if o.lockReason != "" {
-		return []string{"--lock", "--reason", o.lockReason}
-	}
-	return []string{"--lock"}
-}
-
-// WorktreeAddOption is a functional option for WorktreeAdd.
-type WorktreeAddOption func(*worktreeAddOptions)
-
-// WithCreateBranch creates a new branch when adding the worktree.
-func WithCreateBranch() WorktreeAddOption {
-	return func(o *worktreeAddOptions) {
-		o.createBranch = true
-	}
-}
-
-// WithLock locks the worktree after creation.
-func WithLock() WorktreeAddOption {
-	return func(o *worktreeAddOptions) {
-		o.lock = true
-	}
-}
-
-// WithLockReason sets the reason for locking the worktree.
-func WithLockReason(reason string) WorktreeAddOption {
-	return func(o *worktreeAddOptions) {
-		o.lockReason = reason
-	}
-}
-
-// WorktreeAdd creates a new worktree at the specified path.
-func (g *GitRunner) WorktreeAdd(ctx context.Context, path, branch string, opts ...WorktreeAddOption) ([]byte, error) {
-	var o worktreeAddOptions
-	for _, opt := range opts {
-		opt(&o)
-	}
-
-	if o.createBranch {
-		return g.worktreeAddWithNewBranch(ctx, branch, path, o)
-	}
-	return g.worktreeAdd(ctx, path, branch, o)
-}
-
-// LocalBranchExists checks if a branch exists in the local repository.
-func (g *GitRunner) LocalBranchExists(ctx context.Context, branch string) (bool, error) {
-	_, err := g.Run(ctx, GitCmdRevParse, "--verify", RefsHeadsPrefix+branch)
-	if err != nil {
-		// git rev-parse returns exit code 128 for non-existent refs
-		var exitErr interface{ ExitCode() int }
-		if errors.As(err, &exitErr) {
-			// ExitError means git ran but ref doesn't exist
-			return false, nil
-		}
-		// Other errors (context canceled, etc.)
-		return false, err
-	}
-	return true, nil
-}
-
-// BranchList returns all local branch names.
-func (g *GitRunner) BranchList(ctx context.Context) ([]string, error) {
-	output, err := g.Run(ctx, GitCmdBranch, "--format=%(refname:short)")
-	if err != nil {
-		return nil, err
-	}
-	var branches []string
-	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
-		if line != "" {
-			branches = append(branches, line)
-		}
-	}
-	return branches, nil
-}
-
-// FindRemotesForBranch returns all remotes that have the specified branch
-// in local remote-tracking branches.
-// This checks refs/remotes/*/<branch> locally without network access.
-func (g *GitRunner) FindRemotesForBranch(ctx context.Context, branch string) ([]string, error) {
-	out, err := g.Run(ctx, GitCmdForEachRef, "--format=%(refname:short)",
-		fmt.Sprintf("refs/remotes/*/%s", branch))
-	if err != nil {
-		return nil, err
-	}
-
-	remotes := []string{}
-	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
-		if line == "" {
-			continue
-		}
-		// Extract "origin" from "origin/branch"
-		if idx := strings.Index(line, "/"); idx > 0 {
-			remotes = append(remotes, line[:idx])
-		}
-	}
-	return remotes, nil
-}
-
-// FindRemoteForBranch finds the remote that has the specified branch.
-// Returns the remote name if exactly one remote has the branch.
-// Returns empty string if no remote has the branch.
-// Returns error if multiple remotes have the branch (ambiguous).
-func (g *GitRunner) FindRemoteForBranch(ctx context.Context, branch string) (string, error) {
-	remotes, err := g.FindRemotesForBranch(ctx, branch)
-	if err != nil {
-		return "", err
-	}
-
-	switch len(remotes) {
-	case 0:
-		return "", nil
-	case 1:
-		return remotes[0], nil
-	default:
-		return "", fmt.Errorf("branch %q exists on multiple remotes: %v", branch, remotes)
-	}
-}
-
-// Fetch fetches the specified refspec from the remote.
-func (g *GitRunner) Fetch(ctx context.Context, remote string, refspec ...string) error {
-	args := []string{GitCmdFetch, remote}
-	args = append(args, refspec...)
-	_, err := g.Run(ctx, args...)
-	return err
-}
-
-// Worktree holds worktree path and branch information.
-type Worktree struct {
-	Path           string
-	Branch         string
-	HEAD           string
-	Detached       bool
-	Locked         bool
-	LockReason     string
-	Prunable       bool
-	PrunableReason string
-	Bare           bool
-}
-
-// ShortHEAD returns the first 7 characters of the HEAD commit hash.
-func (w Worktree) ShortHEAD() string {
-	if len(w.HEAD) >= 7 {
-		return w.HEAD[:7]
-	}
-	return w.HEAD
-}
-
-// WorktreeList returns all worktrees with their paths and branches.
-func (g *GitRunner) WorktreeList(ctx context.Context) ([]Worktree, error) {
-	out, err := g.worktreeListPorcelain(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("failed to list worktrees: %w", err)
-	}
-
-	// porcelain format:
-	// worktree /path/to/worktree
-	// HEAD abc123
-	// branch refs/heads/branch-name
-	// detached (optional)
-	// bare (optional)
-	// locked [reason] (optional)
-	// prunable [reason] (optional)
-	// (blank line)
-
-	var worktrees []Worktree
-	var current Worktree
-	for _, line := range strings.Split(string(out), "\n") {
-		switch {
-		case strings.HasPrefix(line, PorcelainWorktreePrefix):
-			current = Worktree{Path: strings.TrimPrefix(line, PorcelainWorktreePrefix)}
-		case strings.HasPrefix(line, PorcelainHEADPrefix):
-			current.HEAD = strings.TrimPrefix(line, PorcelainHEADPrefix)
-		case strings.HasPrefix(line, PorcelainBranchPrefix):
-			current.Branch = strings.TrimPrefix(line, PorcelainBranchPrefix)
-		case line == PorcelainDetached:
-			current.Detached = true
-		case line == PorcelainBare:
-			current.Bare = true
-		case strings.HasPrefix(line, PorcelainLocked):
-			current.Locked = true
-			if reason, ok := strings.CutPrefix(line, PorcelainLocked+" "); ok {
-				current.LockReason = reason
-			}
-		case strings.HasPrefix(line, PorcelainPrunable):
-			current.Prunable = true
-			if reason, ok := strings.CutPrefix(line, PorcelainPrunable+" "); ok {
-				current.PrunableReason = reason
-			}
-		case line == "" && current.Path != "":
-			worktrees = append(worktrees, current)
-			current = Worktree{}
-		}
-	}
-	return worktrees, nil
-}
-
-// WorktreeListBranches returns a list of branch names currently checked out in worktrees.
-func (g *GitRunner) WorktreeListBranches(ctx context.Context) ([]string, error) {
-	output, err := g.worktreeListPorcelain(ctx)
-	if err != nil {
-		return nil, err
-	}
-
-	var branches []string
-	for line := range strings.SplitSeq(string(output), "\n") {
-		if branch, ok := strings.CutPrefix(line, PorcelainBranchPrefix); ok {
-			branches = append(branches, branch)
-		}
-	}
-	return branches, nil
-}
-
-// WorktreeFindByBranch returns the Worktree for the given branch.
-// Returns an error if the branch is not checked out in any worktree.
-func (g *GitRunner) WorktreeFindByBranch(ctx context.Context, branch string) (*Worktree, error) {
-	worktrees, err := g.WorktreeList(ctx)
-	if err != nil {
-		return nil, err
-	}
-
-	for i := range worktrees {
-		if worktrees[i].Branch == branch {
-			return &worktrees[i], nil
-		}
-	}
-
-	return nil, fmt.Errorf("branch %q is not checked out in any worktree", branch)
-}
-
-// WorktreeForceLevel represents the force level for worktree removal.
-// Matches git worktree remove behavior.
-type WorktreeForceLevel uint8
-
-const (
-	// WorktreeForceLevelNone means no force - fail on uncommitted changes or locked.
-	WorktreeForceLevelNone WorktreeForceLevel = iota
-	// WorktreeForceLevelUnclean removes unclean worktrees (-f).
-	WorktreeForceLevelUnclean
-	// WorktreeForceLevelLocked also removes locked worktrees (-f -f).
-	WorktreeForceLevelLocked
-)
-
-type worktreeRemoveOptions struct {
-	forceLevel WorktreeForceLevel
-}
-
-// WorktreeRemoveOption is a functional option for WorktreeRemove.
-type WorktreeRemoveOption func(*worktreeRemoveOptions)
-
-// WithForceRemove forces worktree removal.
-func WithForceRemove(level WorktreeForceLevel) WorktreeRemoveOption {
-	return func(o *worktreeRemoveOptions) {
-		o.forceLevel = level
-	}
-}
-
-// WorktreeRemove removes the worktree at the given path.
-// By default fails if there are uncommitted changes. Use WithForceRemove() to force.
-func (g *GitRunner) WorktreeRemove(ctx context.Context, path string, opts ...WorktreeRemoveOption) ([]byte, error) {
-	var o worktreeRemoveOptions
-	for _, opt := range opts {
-		opt(&o)
-	}
-
-	out, err := g.worktreeRemove(ctx, path, o.forceLevel)
-	if err != nil {
-		return nil, newGitError(OpWorktreeRemove, err)
-	}
-	return out, nil
-}
-
-type branchDeleteOptions struct {
-	force bool
-}
-
-// BranchDeleteOption is a functional option for BranchDelete.
-type BranchDeleteOption func(*branchDeleteOptions)
-
-// WithForceDelete forces branch deletion even if not fully merged.
-func WithForceDelete() BranchDeleteOption {
-	return func(o *branchDeleteOptions) {
-		o.force = true
-	}
-}
-
-// BranchDelete deletes a local branch.
-// By default uses -d (safe delete). Use WithForceDelete() to use -D (force delete).
-func (g *GitRunner) BranchDelete(ctx context.Context, branch string, opts ...BranchDeleteOption) ([]byte, error) {
-	var o branchDeleteOptions
-	for _, opt := range opts {
-		opt(&o)
-	}
-
-	out, err := g.branchDelete(ctx, branch, o.force)
-	if err != nil {
-		return nil, newGitError(OpBranchDelete, err)
-	}
-	return out, nil
-}
-
-// FileStatus represents a file with its git status.
-type FileStatus struct {
-	Status string // e.g., " M", "A ", "??"
-	Path   string
-}
-
-// ChangedFiles returns files with uncommitted changes including staged,
-// unstaged, and untracked files. Untracked directories are collapsed
-// into a single entry. Status codes are the first 2 characters from
-// git status --porcelain output.
-func (g *GitRunner) ChangedFiles(ctx context.Context) ([]FileStatus, error) {
-	return g.changedFiles(ctx, "-unormal")
-}
-
-// ChangedFilesAll is like ChangedFiles but lists individual files within
-// untracked directories instead of collapsing them.
-func (g *GitRunner) ChangedFilesAll(ctx context.Context) ([]FileStatus, error) {
-	return g.changedFiles(ctx, "-uall")
-}
-
-func (g *GitRunner) changedFiles(ctx context.Context, untrackedMode string) ([]FileStatus, error) {
-	output, err := g.Run(ctx, GitCmdStatus, "--porcelain", untrackedMode)
-	if err != nil {
-		return nil, fmt.Errorf("failed to check git status: %w", err)
-	}
-
-	files := make([]FileStatus, 0)
-	for _, line := range strings.Split(string(output), "\n") {
-		if len(line) < 3 {
-			continue
-		}
-		// Format: "XY filename" where XY is 2-char status
-		status := line[:2]
-		path := strings.TrimSpace(line[2:])
-		// Handle renamed files "old -> new"
-		if idx := strings.Index(path, " -> "); idx != -1 {
-			path = path[idx+4:]
-		}
-		files = append(files, FileStatus{Status: status, Path: path})
-	}
-	return files, nil
-}
-
-// HasChanges checks if there are any uncommitted changes (staged, unstaged, or untracked).
-func (g *GitRunner) HasChanges(ctx context.Context) (bool, error) {
-	files, err := g.ChangedFiles(ctx)
-	if err != nil {
-		return false, err
-	}
-	return len(files) > 0, nil
-}
-
-// StashPush stashes changes including untracked files.
-// If pathspecs are provided, only matching files are stashed.
-// Returns the stash commit hash for later reference.
-//
-// Race condition note:
-// There is a small race window between "stash push" and "rev-parse stash@{0}".
-// If another process creates a stash in this window, we may get the wrong hash.
-// However, this window is very small (milliseconds) and acceptable in practice.
-//
-// Why not use "stash create" + "stash store" pattern?
-// "stash create" does not support -u/--include-untracked option (git limitation).
-// It can only stash tracked file changes, not untracked files.
-// See: https://git-scm.com/docs/git-stash
-func (g *GitRunner) StashPush(ctx context.Context, message string, pathspecs ...string) (string, error) {
-	args := []string{GitCmdStash, GitStashPush, "-u", "-m", message}
-	if len(pathspecs) > 0 {
-		args = append(args, "--")
-		args = append(args, pathspecs...)
-	}
-	if _, err := g.Run(ctx, args...); err != nil {
-		return "", err
-	}
-	out, err := g.Run(ctx, GitCmdRevParse, "stash@{0}")
-	if err != nil {
-		return "", err
-	}
-	return strings.TrimSpace(string(out)), nil
-}
-
-// StashApplyByHash applies the stash with the given hash without dropping it.
-func (g *GitRunner) StashApplyByHash(ctx context.Context, hash string) ([]byte, error) {
-	return g.Run(ctx, GitCmdStash, GitStashApply, hash)
-}
-
-// StashPopByHash applies and drops the stash with the given hash.
-func (g *GitRunner) StashPopByHash(ctx context.Context, hash string) ([]byte, error) {
-	if _, err := g.StashApplyByHash(ctx, hash); err != nil {
-		return nil, err
-	}
-	return g.StashDropByHash(ctx, hash)
-}
-
-// StashDropByHash drops the stash with the given hash.
-func (g *GitRunner) StashDropByHash(ctx context.Context, hash string) ([]byte, error) {
-	out, err := g.Run(ctx, GitCmdStash, GitStashList, "--format=%gd %H")
-	if err != nil {
-		return nil, err
-	}
-	for line := range strings.SplitSeq(string(out), "\n") {
-		if strings.HasSuffix(line, hash) {
-			ref := strings.Fields(line)[0]
-			return g.Run(ctx, GitCmdStash, GitStashDrop, ref)
-		}
-	}
-	return nil, fmt.Errorf("stash not found: %s", hash)
-}
-
-// private methods for git command execution
-
-func (g *GitRunner) worktreeAdd(ctx context.Context, path, branch string, o worktreeAddOptions) ([]byte, error) {
-	args := []string{GitCmdWorktree, GitWorktreeAdd}
-	args = append(args, o.lockArgs()...)
-	args = append(args, path, branch)
-	return g.Run(ctx, args...)
-}
-
-func (g *GitRunner) worktreeAddWithNewBranch(ctx context.Context, branch, path string, o worktreeAddOptions) ([]byte, error) {
-	args := []string{GitCmdWorktree, GitWorktreeAdd}
-	args = append(args, o.lockArgs()...)
-	args = append(args, "-b", branch, path)
-	return g.Run(ctx, args...)
-}
-
-func (g *GitRunner) worktreeListPorcelain(ctx context.Context) ([]byte, error) {
-	return g.Run(ctx, GitCmdWorktree, GitWorktreeList, "--porcelain")
-}
-
-func (g *GitRunner) worktreeRemove(ctx context.Context, path string, forceLevel WorktreeForceLevel) ([]byte, error) {
-	args := []string{GitCmdWorktree, GitWorktreeRemove}
-	// git worktree remove:
-	// -f (once): remove unclean worktree
-	// -f -f (twice): also remove locked worktree
-	for range forceLevel {
-		args = append(args, "-f")
-	}
-	args = append(args, path)
-	return g.Run(ctx, args...)
-}
-
-func (g *GitRunner) branchDelete(ctx context.Context, branch string, force bool) ([]byte, error) {
-	flag := "-d"
-	if force {
-		flag = "-D"
-	}
-	return g.Run(ctx, GitCmdBranch, flag, branch)
-}
-
-// IsBranchMerged checks if branch is merged into target.
-func (g *GitRunner) IsBranchMerged(ctx context.Context, branch, target string) (bool, error) {
-	result, err := g.ClassifyBranchMergeStatus(ctx, target)
-	if err != nil {
-		return false, err
-	}
-	return result.Merged[branch], nil
-}
-
-// BranchMergeStatus holds the classification of branches by merge status.
-type BranchMergeStatus struct {
-	// Merged contains branches that are considered merged
-	// (via git branch --merged or upstream gone, excluding same-commit branches).
-	Merged map[string]bool
-	// SameCommit contains branches pointing to the same commit as target.
-	// These are excluded from Merged because they could be newly created or ff-merged.
-	SameCommit map[string]bool
-}
-
-// ClassifyBranchMergeStatus classifies branches by their merge status relative to target.
-// A branch is merged if it's in `git branch --merged <target>` or if its upstream is gone.
-// Branches pointing to the same commit as target are returned separately in SameCommit.
-// This is more efficient than calling IsBranchMerged for each branch individually.
-func (g *GitRunner) ClassifyBranchMergeStatus(ctx context.Context, target string) (BranchMergeStatus, error) {
-	result := BranchMergeStatus{
-		Merged:     make(map[string]bool),
-		SameCommit: make(map[string]bool),
-	}
-
-	// Get all branch info in one call: name, commit hash, and upstream status
-	// Format: "branch-name <commit-hash> [gone]" or "branch-name <commit-hash>"
-	refOut, err := g.Run(ctx, GitCmdForEachRef, "--format=%(refname:short) %(objectname) %(upstream:track)", "refs/heads/")
-	if err != nil {
-		return result, fmt.Errorf("failed to get branch info: %w", err)
-	}
-
-	// Build branch->commit map and detect upstream gone
-	branchCommits := make(map[string]string)
-	for line := range strings.SplitSeq(strings.TrimSpace(string(refOut)), "\n") {
-		if line == "" {
-			continue
-		}
-		// Parse: "branch-name <commit> [gone]" or "branch-name <commit>"
-		parts := strings.SplitN(line, " ", 3)
-		if len(parts) < 2 {
-			continue
-		}
-		branch, commit := parts[0], parts[1]
-		branchCommits[branch] = commit
-
-		// Check for upstream gone (squash/rebase merges)
-		if len(parts) == 3 && parts[2] == "[gone]" {
-			result.Merged[branch] = true
-		}
-	}
-
-	// Get target commit for same-commit detection
-	targetCommit, ok := branchCommits[target]
-	if !ok {
-		// Target might be a remote ref or tag, fall back to rev-parse
-		var targetHead []byte
-		targetHead, err = g.Run(ctx, GitCmdRevParse, target)
-		if err != nil {
-			return result, fmt.Errorf("failed to get target HEAD: %w", err)
-		}
-		targetCommit = strings.TrimSpace(string(targetHead))
-	}
-
-	// Get traditionally merged branches
-	var out []byte
-	out, err = g.Run(ctx, GitCmdBranch, "--merged", target, "--format=%(refname:short)")
-	if err != nil {
-		return result, fmt.Errorf("failed to check merged branches: %w", err)
-	}
-	for line := range strings.SplitSeq(strings.TrimSpace(string(out)), "\n") {
-		if line == "" {
-			continue
-		}
-		// Track branches pointing to the same commit as target separately
-		// (could be newly created or ff-merged - we can't distinguish)
-		if branchCommits[line] == targetCommit {
-			result.SameCommit[line] = true
-			continue
-		}
-		result.Merged[line] = true
-	}
-
-	return result, nil
-}
-
-// IsBranchUpstreamGone checks if the branch's upstream tracking branch is gone.
-// This indicates the remote branch was deleted, typically after a PR merge.
-func (g *GitRunner) IsBranchUpstreamGone(ctx context.Context, branch string) (bool, error) {
-	// git for-each-ref --format='%(upstream:track)' refs/heads/<branch>
-	// Returns "[gone]" if upstream was deleted
-	out, err := g.Run(ctx, "for-each-ref", "--format=%(upstream:track)", "refs/heads/"+branch)
-	if err != nil {
-		return false, fmt.Errorf("failed to check upstream status: %w", err)
-	}
-	return strings.TrimSpace(string(out)) == "[gone]", nil
-}
-
-// WorktreePrune removes references to worktrees that no longer exist.
-func (g *GitRunner) WorktreePrune(ctx context.Context) ([]byte, error) {
-	out, err := g.Run(ctx, GitCmdWorktree, GitWorktreePrune)
-	if err != nil {
-		return nil, fmt.Errorf("failed to prune worktrees: %w", err)
-	}
-	return out, nil
-}
-
-// GitCmdSubmodule is the git submodule command.
-const GitCmdSubmodule = "submodule"
-
-// Git submodule subcommands.
-const (
-	GitSubmoduleUpdate = "update"
-)
-
-// SubmoduleCleanStatus indicates whether it's safe to remove a worktree with submodules.
-type SubmoduleCleanStatus int
-
-const (
-	// SubmoduleCleanStatusNone: no initialized submodules exist.
-	SubmoduleCleanStatusNone SubmoduleCleanStatus = iota
-	// SubmoduleCleanStatusClean: submodules exist but all are clean.
-	SubmoduleCleanStatusClean
-	// SubmoduleCleanStatusDirty: submodules have uncommitted changes or are at different commits.
-	SubmoduleCleanStatusDirty
-)
-
-// SubmoduleState represents the state of a submodule.
-type SubmoduleState int
-
-const (
-	SubmoduleStateUninitialized SubmoduleState = iota
-	SubmoduleStateClean
-	SubmoduleStateModified
-	SubmoduleStateConflict
-)
-
-// SubmoduleInfo holds information about a submodule.
-type SubmoduleInfo struct {
-	SHA   string
-	Path  string
-	State SubmoduleState
-}
-
-// SubmoduleStatus runs `git submodule status --recursive` and parses the output.
-// Returns a list of SubmoduleInfo for all submodules.
-func (g *GitRunner) SubmoduleStatus(ctx context.Context) ([]SubmoduleInfo, error) {
-	out, err := g.Run(ctx, GitCmdSubmodule, "status", "--recursive")
-	if err != nil {
-		return nil, fmt.Errorf("failed to get submodule status: %w", err)
-	}
-
-	var submodules []SubmoduleInfo
-	for line := range strings.SplitSeq(string(out), "\n") {
-		if len(line) == 0 {
-			continue
-		}
-
-		// Format: " SHA path (desc)" or "+SHA path (desc)" or "-SHA path (desc)" or "USHA path (desc)"
-		// The first character is the state prefix
-		var state SubmoduleState
-		switch line[0] {
-		case '-':
-			state = SubmoduleStateUninitialized
-		case ' ':
-			state = SubmoduleStateClean
-		case '+':
-			state = SubmoduleStateModified
-		case 'U':
-			state = SubmoduleStateConflict
-		default:
-			// Unknown prefix, skip
-			continue
-		}
-
-		// Parse SHA and path
-		rest := strings.TrimSpace(line[1:])
-		fields := strings.Fields(rest)
-		if len(fields) < 2 {
-			continue
-		}
-
-		submodules = append(submodules, SubmoduleInfo{
-			SHA:   fields[0],
-			Path:  fields[1],
-			State: state,
-		})
-	}
-
-	return submodules, nil
-}
-
-// CheckSubmoduleCleanStatus determines if it's safe to remove a worktree with submodules.
-// Returns:
-//   - SubmoduleCleanStatusNone: no initialized submodules
-//   - SubmoduleCleanStatusClean: submodules exist but are clean (safe to auto-force)
-//   - SubmoduleCleanStatusDirty: submodules have changes (requires user --force)
-func (g *GitRunner) CheckSubmoduleCleanStatus(ctx context.Context) (SubmoduleCleanStatus, error) {
-	submodules, err := g.SubmoduleStatus(ctx)
-	if err != nil {
-		return SubmoduleCleanStatusNone, err
-	}
-
-	var hasInitialized bool
-	for _, sm := range submodules {
-		if sm.State == SubmoduleStateUninitialized {
-			continue
-		}
-		hasInitialized = true
-
-		// Check for modified commit (+ prefix) or conflict (U prefix)
-		if sm.State == SubmoduleStateModified || sm.State == SubmoduleStateConflict {
-			return SubmoduleCleanStatusDirty, nil
-		}
-
-		// Check for uncommitted changes within the submodule
-		// sm.Path is relative to the worktree, so we need to join it with g.Dir
-		smAbsPath := filepath.Join(g.Dir, sm.Path)
-		smRunner := g.InDir(smAbsPath)
-		hasChanges, err := smRunner.HasChanges(ctx)
-		if err != nil {
-			// If we can't check, assume dirty for safety
-			return SubmoduleCleanStatusDirty, nil
-		}
-		if hasChanges {
-			return SubmoduleCleanStatusDirty, nil
-		}
-	}
-
-	if !hasInitialized {
-		return SubmoduleCleanStatusNone, nil
-	}
-	return SubmoduleCleanStatusClean, nil
-}
-
-// WorktreeRoot returns the root path of the worktree containing the current directory.
-// Uses git rev-parse --show-toplevel which returns the path git internally uses.
-func (g *GitRunner) WorktreeRoot(ctx context.Context) (string, error) {
-	out, err := g.Run(ctx, GitCmdRevParse, "--show-toplevel")
-	if err != nil {
-		return "", err
-	}
-	return strings.TrimSpace(string(out)), nil
-}
-
-// SubmoduleUpdateOption configures SubmoduleUpdate behavior.
-type SubmoduleUpdateOption func(*submoduleUpdateOptions)
-
-type submoduleUpdateOptions struct {
-	referencePath string
-}
-
-// WithSubmoduleReference enables --reference optimization using main worktree's modules.
-func WithSubmoduleReference(mainWorktreePath string) SubmoduleUpdateOption {
-	return func(o *submoduleUpdateOptions) {
-		o.referencePath = mainWorktreePath
-	}
-}
-
-// SubmoduleUpdateResult holds the result of SubmoduleUpdate.
-type SubmoduleUpdateResult struct {
-	Count       int      // number of initialized submodules
-	NoReference []string // submodules that couldn't use reference
-}
-
-// SubmoduleUpdate runs git submodule update --init.
-// With WithSubmoduleReference, uses --reference for faster initialization.
-func (g *GitRunner) SubmoduleUpdate(ctx context.Context, opts ...SubmoduleUpdateOption) (SubmoduleUpdateResult, error) {
-	var o submoduleUpdateOptions
-	for _, opt := range opts {
-		opt(&o)
-	}
-
-	// Without reference: simple recursive init
-	if o.referencePath == "" {
-		args := []string{GitCmdSubmodule, GitSubmoduleUpdate, "--init", "--recursive"}
-		if _, err := g.Run(ctx, args...); err != nil {
-			return SubmoduleUpdateResult{}, fmt.Errorf("failed to initialize submodules: %w", err)
-		}
-
-		submodules, err := g.SubmoduleStatus(ctx)
-		if err != nil {
-			return SubmoduleUpdateResult{}, nil
-		}
-		var count int
-		for _, sm := range submodules {
-			if sm.State != SubmoduleStateUninitialized {
-				count++
-			}
-		}
-		return SubmoduleUpdateResult{Count: count}, nil
-	}
-
-	// With reference: init each submodule individually
-	submodules, err := g.SubmoduleStatus(ctx)
-	if err != nil {
-		return SubmoduleUpdateResult{}, fmt.Errorf("failed to get submodule status: %w", err)
-	}
-
-	var result SubmoduleUpdateResult
-	for _, sm := range submodules {
-		if sm.State != SubmoduleStateUninitialized {
-			result.Count++
-			continue
-		}
-
-		refPath := filepath.Join(o.referencePath, ".git", "modules", sm.Path)
-		args := []string{GitCmdSubmodule, GitSubmoduleUpdate, "--init"}
-
-		if _, statErr := statFunc(refPath); statErr == nil {
-			args = append(args, "--reference", refPath)
-		} else {
-			result.NoReference = append(result.NoReference, sm.Path)
-		}
-		args = append(args, "--", sm.Path)
-
-		if _, runErr := g.Run(ctx, args...); runErr != nil {
-			g.Log.Debug("submodule init failed", "path", sm.Path, "error", runErr)
-			continue
-		}
-		result.Count++
-	}
-
-	return result, nil
-}
-
-// GitDir returns the worktree-specific git directory.
-// For the main worktree this is .git, for linked worktrees .git/worktrees/<name>.
-func (g *GitRunner) GitDir(ctx context.Context) (string, error) {
-	out, err := g.Run(ctx, GitCmdRevParse, "--path-format=absolute", "--git-dir")
-	if err != nil {
-		return "", err
-	}
-	return strings.TrimSpace(string(out)), nil
-}
-
-// MainWorktreePath returns the path of the main worktree.
-// Uses git rev-parse --git-common-dir which returns the shared .git directory.
-func (g *GitRunner) MainWorktreePath(ctx context.Context) (string, error) {
-	out, err := g.Run(ctx, GitCmdRevParse, "--path-format=absolute", "--git-common-dir")
-	if err != nil {
-		return "", err
-	}
-	gitDir := strings.TrimSpace(string(out))
-	return filepath.Dir(gitDir), nil
-}
-
-// IsFirstParentAncestor checks if commit is on the first-parent lineage of target.
-// This distinguishes WIP branches (ancestor on first-parent line) from genuinely
-// merged branches (reachable only via merge commit second parent).
-func (g *GitRunner) IsFirstParentAncestor(ctx context.Context, commit, target string) (bool, error) {
-	// Get the parent of commit to use as exclusion boundary
-	parentOut, err := g.Run(ctx, GitCmdRevParse, commit+"^")
-	isRoot := err != nil // root commit has no parent
-
-	if isRoot {
-		// Root commit: check if commit appears in first-parent list of target
-		out, err := g.Run(ctx, GitCmdRevList, "--first-parent", target)
-		if err != nil {
-			return false, fmt.Errorf("failed to list first-parent commits: %w", err)
-		}
-		for line := range strings.SplitSeq(strings.TrimSpace(string(out)), "\n") {
-			if line == commit {
-				return true, nil
-			}
-		}
-		return false, nil
-	}
-
-	parent := strings.TrimSpace(string(parentOut))
-
-	// List commits on first-parent lineage of target that are NOT ancestors of parent.
-	// If commit appears in this list, it means commit itself is on the first-parent line.
-	out, err := g.Run(ctx, GitCmdRevList, "--first-parent", target, "--not", parent)
-	if err != nil {
-		return false, fmt.Errorf("failed to list first-parent commits: %w", err)
-	}
-	for line := range strings.SplitSeq(strings.TrimSpace(string(out)), "\n") {
-		if line == commit {
-			return true, nil
-		}
-	}
-	return false, nil
-}
-
-// statFunc is a variable for testing purposes.
-var statFunc = osStat
-
-func osStat(path string) (any, error) {
-	return os.Stat(path)
-}
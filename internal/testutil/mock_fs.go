@@ -35,12 +35,16 @@ type MockFS struct {
 	StatFunc       func(name string) (fs.FileInfo, error)
 	LstatFunc      func(name string) (fs.FileInfo, error)
 	SymlinkFunc    func(oldname, newname string) error
+	ReadlinkFunc   func(name string) (string, error)
 	IsNotExistFunc func(err error) bool
 	GlobFunc       func(dir, pattern string) ([]string, error)
 	MkdirAllFunc   func(path string, perm fs.FileMode) error
 	ReadDirFunc    func(name string) ([]os.DirEntry, error)
 	RemoveFunc     func(name string) error
+	RemoveAllFunc  func(path string) error
+	RenameFunc     func(oldpath, newpath string) error
 	WriteFileFunc  func(name string, data []byte, perm fs.FileMode) error
+	AppendFileFunc func(name string, data []byte, perm fs.FileMode) error
 	ReadFileFunc   func(name string) ([]byte, error)
 
 	// ExistingPaths is a list of paths that exist (Stat returns nil, nil).
@@ -49,6 +53,9 @@ type MockFS struct {
 	// SymlinkErr is returned by Symlink if set.
 	SymlinkErr error
 
+	// SymlinkTargets maps symlink path to the target returned by Readlink.
+	SymlinkTargets map[string]string
+
 	// GlobResults maps pattern to matching paths.
 	GlobResults map[string][]string
 
@@ -67,12 +74,21 @@ type MockFS struct {
 	// RemoveErr is returned by Remove if set.
 	RemoveErr error
 
+	// RemoveAllErr is returned by RemoveAll if set.
+	RemoveAllErr error
+
+	// RenameErr is returned by Rename if set.
+	RenameErr error
+
 	// WriteFileErr is returned by WriteFile if set.
 	WriteFileErr error
 
-	// WrittenFiles records files written by WriteFile.
+	// WrittenFiles records files written by WriteFile and AppendFile.
 	WrittenFiles map[string][]byte
 
+	// AppendFileErr is returned by AppendFile if set.
+	AppendFileErr error
+
 	// ReadFileResults maps path to file content.
 	ReadFileResults map[string][]byte
 
@@ -107,6 +123,16 @@ func (m *MockFS) Symlink(oldname, newname string) error {
 	return m.SymlinkErr
 }
 
+func (m *MockFS) Readlink(name string) (string, error) {
+	if m.ReadlinkFunc != nil {
+		return m.ReadlinkFunc(name)
+	}
+	if target, ok := m.SymlinkTargets[name]; ok {
+		return target, nil
+	}
+	return "", fs.ErrNotExist
+}
+
 func (m *MockFS) IsNotExist(err error) bool {
 	if m.IsNotExistFunc != nil {
 		return m.IsNotExistFunc(err)
@@ -156,6 +182,20 @@ func (m *MockFS) Remove(name string) error {
 	return m.RemoveErr
 }
 
+func (m *MockFS) RemoveAll(path string) error {
+	if m.RemoveAllFunc != nil {
+		return m.RemoveAllFunc(path)
+	}
+	return m.RemoveAllErr
+}
+
+func (m *MockFS) Rename(oldpath, newpath string) error {
+	if m.RenameFunc != nil {
+		return m.RenameFunc(oldpath, newpath)
+	}
+	return m.RenameErr
+}
+
 func (m *MockFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
 	if m.WriteFileFunc != nil {
 		return m.WriteFileFunc(name, data, perm)
@@ -166,6 +206,19 @@ func (m *MockFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
 	return m.WriteFileErr
 }
 
+func (m *MockFS) AppendFile(name string, data []byte, perm fs.FileMode) error {
+	if m.AppendFileFunc != nil {
+		return m.AppendFileFunc(name, data, perm)
+	}
+	if m.AppendFileErr != nil {
+		return m.AppendFileErr
+	}
+	if m.WrittenFiles != nil {
+		m.WrittenFiles[name] = append(m.WrittenFiles[name], data...)
+	}
+	return nil
+}
+
 func (m *MockFS) ReadFile(name string) ([]byte, error) {
 	if m.ReadFileFunc != nil {
 		return m.ReadFileFunc(name)
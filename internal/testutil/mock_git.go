@@ -3,6 +3,7 @@ package testutil
 import (
 	"context"
 	"slices"
+	"strconv"
 	"strings"
 )
 
@@ -66,20 +67,9 @@ type MockGitExecutor struct {
 	// Used when different worktrees need different status results.
 	StatusOutputMap map[string]string
 
-	// StashPushErr is returned when stash push is called.
-	StashPushErr error
-
-	// StashHash is returned when stash push succeeds and used for subsequent stash operations.
-	StashHash string
-
-	// StashApplyErr is returned when stash apply is called.
-	StashApplyErr error
-
-	// StashPopErr is returned when stash pop is called.
-	StashPopErr error
-
-	// StashDropErr is returned when stash drop is called.
-	StashDropErr error
+	// StashListOutput overrides the output of stash list if set.
+	// Format: "<ref> <hash> <subject>" per line.
+	StashListOutput string
 
 	// MergedBranches maps target branch to list of branches merged into it.
 	MergedBranches map[string][]string
@@ -102,9 +92,29 @@ type MockGitExecutor struct {
 	// Used by for-each-ref to check local remote-tracking branches.
 	RemoteBranches map[string][]string
 
+	// LsRemoteBranches maps remote name to the branches ls-remote
+	// reports, i.e. branches on the remote itself.
+	LsRemoteBranches map[string][]string
+
+	// LsRemoteErrs maps remote name to the error returned by ls-remote.
+	LsRemoteErrs map[string]error
+
+	// RemoteHEADs maps remote name to its default branch
+	// (refs/remotes/<remote>/HEAD). Used by symbolic-ref.
+	RemoteHEADs map[string]string
+
 	// FetchErr is returned when fetch is called.
 	FetchErr error
 
+	// FetchErrs maps remote name to the error returned when fetching it.
+	FetchErrs map[string]error
+
+	// FetchableBranches maps remote name to the branches that fetching
+	// "<remote> <branch>" finds on the server. A fetched branch is added
+	// to RemoteBranches; fetching any other branch fails. Nil disables
+	// this check.
+	FetchableBranches map[string][]string
+
 	// SubmoduleStatusOutput is the output of `git submodule status --recursive`.
 	// Empty string means no submodules.
 	SubmoduleStatusOutput string
@@ -118,6 +128,12 @@ type MockGitExecutor struct {
 	// SubmoduleUpdateArgs captures the args passed to submodule update.
 	SubmoduleUpdateArgs []string
 
+	// SubmoduleDeinitErr is returned when submodule deinit is called.
+	SubmoduleDeinitErr error
+
+	// SubmoduleDeinitCalled is set to true when submodule deinit is called.
+	SubmoduleDeinitCalled bool
+
 	// WorktreeRootMap maps directory to its worktree root.
 	// Used by rev-parse --show-toplevel to return the worktree root for a directory.
 	WorktreeRootMap map[string]string
@@ -126,6 +142,10 @@ type MockGitExecutor struct {
 	// Used by rev-list --first-parent to detect WIP branches.
 	FirstParentAncestors map[string][]string
 
+	// UnpushedCommits maps branch to the count returned by
+	// git rev-list --count for commits not on a remote.
+	UnpushedCommits map[string]int
+
 	// RootCommits is a list of commits that have no parent (root commits).
 	RootCommits []string
 
@@ -133,15 +153,67 @@ type MockGitExecutor struct {
 	// Used by rev-parse --git-dir.
 	GitDirMap map[string]string
 
+	// GitCommonDir is returned by rev-parse --git-common-dir.
+	// Empty means the command returns no output.
+	GitCommonDir string
+
 	// DiffNameOnlyOutput maps "filter:fromRef:toRef" to file list output.
 	// Used by git diff --name-only --diff-filter=X.
 	DiffNameOnlyOutput map[string]string
 
+	// DiffPatchOutput is returned by git diff --binary (the patch of changes
+	// captured by --sync and --carry).
+	DiffPatchOutput string
+
+	// SquashMergedBranches maps target branch to branches squash-merged into it.
+	// Used by merge-base/commit-tree/cherry to detect squash merges.
+	SquashMergedBranches map[string][]string
+
+	// TrackedFilesMap maps directory to files tracked in that worktree.
+	// Used by git ls-files.
+	TrackedFilesMap map[string][]string
+
+	// UntrackedFilesMap maps directory to untracked files in that worktree.
+	// Used by git ls-files --others.
+	UntrackedFilesMap map[string][]string
+
+	// IgnoredFilesMap maps directory to ignored untracked files in that
+	// worktree. Used by git ls-files --others --ignored and check-ignore.
+	IgnoredFilesMap map[string][]string
+
+	// ApplyErrMap maps directory to the error returned by git apply there.
+	ApplyErrMap map[string]error
+
 	// CheckoutErr is returned when checkout is called.
 	CheckoutErr error
 
 	// ResetErr is returned when reset is called.
 	ResetErr error
+
+	// PushErr is returned when push is called.
+	PushErr error
+
+	// MissingCommits is a list of commits that rev-parse --verify <commit>^{commit}
+	// reports as missing (e.g. garbage collected).
+	MissingCommits []string
+
+	// RemoteURLs maps remote name to its URL.
+	// Used by remote get-url.
+	RemoteURLs map[string]string
+
+	// Upstreams maps branch name to its upstream ("<remote>/<branch>").
+	// Used by for-each-ref with the %(upstream:remotename) format.
+	Upstreams map[string]string
+
+	// WorktreeMoveErr is returned when worktree move is called.
+	WorktreeMoveErr error
+
+	// BranchRenameErr is returned when branch -m is called.
+	BranchRenameErr error
+
+	// GitConfig maps git config keys (e.g. "checkout.defaultRemote") to
+	// their values. Used by git config --get.
+	GitConfig map[string]string
 }
 
 func (m *MockGitExecutor) Run(ctx context.Context, args ...string) ([]byte, error) {
@@ -152,10 +224,13 @@ func (m *MockGitExecutor) Run(ctx context.Context, args ...string) ([]byte, erro
 }
 
 func (m *MockGitExecutor) defaultRun(args ...string) ([]byte, error) {
-	// Extract -C <dir> option and track it for commands that need it
+	// Extract -C <dir> option and track it for commands that need it,
+	// skipping -c <name>=<value> options
 	var dir string
-	for len(args) >= 2 && args[0] == "-C" {
-		dir = args[1]
+	for len(args) >= 2 && (args[0] == "-C" || args[0] == "-c") {
+		if args[0] == "-C" {
+			dir = args[1]
+		}
 		args = args[2:]
 	}
 
@@ -177,6 +252,8 @@ func (m *MockGitExecutor) defaultRun(args ...string) ([]byte, error) {
 				return m.handleWorktreeRemove(args)
 			case "prune":
 				return m.handleWorktreePrune()
+			case "move":
+				return m.handleWorktreeMove(args)
 			}
 		}
 	case "branch":
@@ -199,10 +276,58 @@ func (m *MockGitExecutor) defaultRun(args ...string) ([]byte, error) {
 		return m.handleReset(args)
 	case "diff":
 		return m.handleDiff(args)
+	case "merge-base":
+		return m.handleMergeBase(args)
+	case "commit-tree":
+		return m.handleCommitTree(args)
+	case "cherry":
+		return m.handleCherry(args)
+	case "ls-files":
+		return m.handleLsFiles(args, dir)
+	case "check-ignore":
+		return m.handleCheckIgnore(args, dir)
+	case "apply":
+		return m.handleApply(args, dir)
+	case "write-tree":
+		return m.handleWriteTree(args)
+	case "read-tree":
+		return m.handleReadTree(args)
+	case "push":
+		return m.handlePush(args)
+	case "remote":
+		return m.handleRemote(args)
+	case "ls-remote":
+		return m.handleLsRemote(args)
+	case "symbolic-ref":
+		return m.handleSymbolicRef(args)
+	case "config":
+		return m.handleConfig(args)
 	}
 	return nil, nil
 }
 
+func (m *MockGitExecutor) handleConfig(args []string) ([]byte, error) {
+	// args: ["config", "--get", "checkout.defaultRemote"]
+	value, ok := m.GitConfig[args[len(args)-1]]
+	if !ok {
+		return nil, &MockExitError{Code: 1}
+	}
+	return []byte(value + "\n"), nil
+}
+
+func (m *MockGitExecutor) handleSymbolicRef(args []string) ([]byte, error) {
+	// args: ["symbolic-ref", "--quiet", "refs/remotes/<remote>/HEAD"]
+	ref := args[len(args)-1]
+	remote, ok := strings.CutPrefix(ref, "refs/remotes/")
+	remote, ok2 := strings.CutSuffix(remote, "/HEAD")
+	if ok && ok2 {
+		if branch, found := m.RemoteHEADs[remote]; found {
+			return []byte("refs/remotes/" + remote + "/" + branch + "\n"), nil
+		}
+	}
+	return nil, &MockExitError{Code: 1}
+}
+
 func (m *MockGitExecutor) handleRevParse(args []string, dir string) ([]byte, error) {
 	// Handle --git-dir for GitDir
 	for _, arg := range args[1:] {
@@ -220,6 +345,19 @@ func (m *MockGitExecutor) handleRevParse(args []string, dir string) ([]byte, err
 		}
 	}
 
+	// Handle --git-common-dir for GitCommonDir
+	if slices.Contains(args[1:], "--git-common-dir") {
+		if m.GitCommonDir == "" {
+			return nil, nil
+		}
+		return []byte(m.GitCommonDir + "\n"), nil
+	}
+
+	// Handle --git-path <path> relative to GitCommonDir
+	if i := slices.Index(args, "--git-path"); i > 0 && i+1 < len(args) {
+		return []byte(m.GitCommonDir + "/" + args[i+1] + "\n"), nil
+	}
+
 	// Handle --show-toplevel for WorktreeRoot
 	if len(args) >= 2 && args[1] == "--show-toplevel" {
 		// Look up the worktree root for the given directory
@@ -239,13 +377,9 @@ func (m *MockGitExecutor) handleRevParse(args []string, dir string) ([]byte, err
 		return nil, &MockExitError{Code: 128}
 	}
 
-	// Handle stash@{0} for StashPush hash retrieval
-	if len(args) >= 2 && args[1] == "stash@{0}" {
-		hash := m.StashHash
-		if hash == "" {
-			hash = "abc123def456"
-		}
-		return []byte(hash + "\n"), nil
+	// Handle rev-parse <branch>^{tree} for tree lookup (IsBranchSquashMerged)
+	if len(args) == 2 && strings.HasSuffix(args[1], "^{tree}") {
+		return []byte("tree-" + strings.TrimSuffix(args[1], "^{tree}") + "\n"), nil
 	}
 
 	// Handle rev-parse <commit>^ for parent lookup (IsFirstParentAncestor)
@@ -299,6 +433,22 @@ func (m *MockGitExecutor) handleRevParse(args []string, dir string) ([]byte, err
 		return nil, nil
 	}
 	ref := args[2]
+	if commit, ok := strings.CutSuffix(ref, "^{commit}"); ok {
+		if slices.Contains(m.MissingCommits, commit) {
+			return nil, &MockExitError{Code: 128}
+		}
+		if hash, ok := m.BranchHEADs[commit]; ok {
+			return []byte(hash + "\n"), nil
+		}
+		return []byte(commit + "\n"), nil
+	}
+	if remoteBranch, ok := strings.CutPrefix(ref, "refs/remotes/"); ok {
+		remote, branch, _ := strings.Cut(remoteBranch, "/")
+		if slices.Contains(m.RemoteBranches[remote], branch) {
+			return nil, nil
+		}
+		return nil, &MockExitError{Code: 1}
+	}
 	branch, ok := strings.CutPrefix(ref, "refs/heads/")
 	if !ok {
 		return nil, nil
@@ -365,6 +515,13 @@ func (m *MockGitExecutor) handleWorktreeRemove(args []string) ([]byte, error) {
 	return nil, m.WorktreeRemoveErr
 }
 
+func (m *MockGitExecutor) handleWorktreeMove(args []string) ([]byte, error) {
+	if m.CapturedArgs != nil {
+		*m.CapturedArgs = append(*m.CapturedArgs, args...)
+	}
+	return nil, m.WorktreeMoveErr
+}
+
 func (m *MockGitExecutor) handleWorktreePrune() ([]byte, error) {
 	return nil, m.WorktreePruneErr
 }
@@ -377,6 +534,14 @@ func (m *MockGitExecutor) handleBranch(args []string) ([]byte, error) {
 	if len(args) >= 3 && (args[1] == "-d" || args[1] == "-D") {
 		return nil, m.BranchDeleteErr
 	}
+	// args: ["branch", "-m", "old", "new"]
+	if len(args) >= 4 && args[1] == "-m" {
+		return nil, m.BranchRenameErr
+	}
+	// args: ["branch", "--format=%(refname:short)"]
+	if len(args) == 2 && strings.HasPrefix(args[1], "--format=") {
+		return []byte(strings.Join(m.ExistingBranches, "\n")), nil
+	}
 	// args: ["branch", "--merged", "target", "--format=%(refname:short)"]
 	if len(args) >= 3 && args[1] == "--merged" {
 		target := args[2]
@@ -386,6 +551,48 @@ func (m *MockGitExecutor) handleBranch(args []string) ([]byte, error) {
 	return nil, nil
 }
 
+func (m *MockGitExecutor) handlePush(args []string) ([]byte, error) {
+	if m.CapturedArgs != nil {
+		*m.CapturedArgs = append(*m.CapturedArgs, args...)
+	}
+	return nil, m.PushErr
+}
+
+func (m *MockGitExecutor) handleRemote(args []string) ([]byte, error) {
+	// args: ["remote"]
+	if len(args) == 1 {
+		if len(m.Remotes) == 0 {
+			return []byte{}, nil
+		}
+		return []byte(strings.Join(m.Remotes, "\n") + "\n"), nil
+	}
+	// args: ["remote", "get-url", "origin"]
+	if len(args) >= 3 && args[1] == "get-url" {
+		url, ok := m.RemoteURLs[args[2]]
+		if !ok {
+			return nil, &MockExitError{Code: 2}
+		}
+		return []byte(url + "\n"), nil
+	}
+	return nil, nil
+}
+
+func (m *MockGitExecutor) handleLsRemote(args []string) ([]byte, error) {
+	// args: ["ls-remote", "--heads", "origin", "refs/heads/feat/a"]
+	if len(args) < 4 {
+		return nil, nil
+	}
+	remote := args[2]
+	if err, ok := m.LsRemoteErrs[remote]; ok {
+		return nil, err
+	}
+	branch := strings.TrimPrefix(args[3], "refs/heads/")
+	if slices.Contains(m.LsRemoteBranches[remote], branch) {
+		return []byte("abc123\trefs/heads/" + branch + "\n"), nil
+	}
+	return []byte{}, nil
+}
+
 func (m *MockGitExecutor) handleStatus(args []string, dir string) ([]byte, error) {
 	// args: ["status", "--porcelain"]
 	if len(args) >= 2 && args[1] == "--porcelain" {
@@ -408,41 +615,8 @@ func (m *MockGitExecutor) handleStatus(args []string, dir string) ([]byte, error
 }
 
 func (m *MockGitExecutor) handleStash(args []string) ([]byte, error) {
-	if len(args) < 2 {
-		return nil, nil
-	}
-	switch args[1] {
-	case "create":
-		// stash create returns hash on stdout
-		if m.StashPushErr != nil {
-			return nil, m.StashPushErr
-		}
-		hash := m.StashHash
-		if hash == "" {
-			hash = "abc123def456"
-		}
-		return []byte(hash + "\n"), nil
-	case "store":
-		// stash store adds to reflog, no output
-		return nil, nil
-	case "push":
-		if m.CapturedArgs != nil {
-			*m.CapturedArgs = append(*m.CapturedArgs, args...)
-		}
-		return nil, m.StashPushErr
-	case "apply":
-		return nil, m.StashApplyErr
-	case "pop":
-		return nil, m.StashPopErr
-	case "drop":
-		return nil, m.StashDropErr
-	case "list":
-		// Return stash list with format "%gd %H"
-		hash := m.StashHash
-		if hash == "" {
-			hash = "abc123def456"
-		}
-		return []byte("stash@{0} " + hash + "\n"), nil
+	if len(args) >= 2 && args[1] == "list" {
+		return []byte(m.StashListOutput), nil
 	}
 	return nil, nil
 }
@@ -463,11 +637,29 @@ func (m *MockGitExecutor) handleForEachRef(args []string) ([]byte, error) {
 		}
 	}
 
-	// Handle refs/heads/ for MergedBranches (all branches with commit hash and upstream status)
-	// Format: "%(refname:short) %(objectname) %(upstream:track)"
+	// Handle refs/heads/ for branch statuses (all branches with commit hash and upstream status)
+	// Format: "%(refname:short)%00%(objectname)%00%(upstream:short)%00%(upstream:track,nobracket)"
+	// or the space-separated "%(refname:short) %(objectname) %(upstream:track)"
 	if ref == "refs/heads/" && strings.Contains(format, "%(objectname)") {
 		var lines []string
 		seen := make(map[string]bool)
+		addLine := func(branch, head string) {
+			gone := slices.Contains(m.UpstreamGoneBranches, branch)
+			if strings.Contains(format, "%00") {
+				track := ""
+				if gone {
+					track = "gone"
+				}
+				lines = append(lines, strings.Join([]string{branch, head, m.Upstreams[branch], track}, "\x00"))
+			} else {
+				line := branch + " " + head
+				if gone {
+					line += " [gone]"
+				}
+				lines = append(lines, line)
+			}
+			seen[branch] = true
+		}
 
 		// First, add branches from Worktrees
 		for _, wt := range m.Worktrees {
@@ -478,34 +670,21 @@ func (m *MockGitExecutor) handleForEachRef(args []string) ([]byte, error) {
 			if head == "" {
 				head = "default-" + wt.Branch
 			}
-			line := wt.Branch + " " + head
-			if slices.Contains(m.UpstreamGoneBranches, wt.Branch) {
-				line += " [gone]"
-			}
-			lines = append(lines, line)
-			seen[wt.Branch] = true
+			addLine(wt.Branch, head)
 		}
 
 		// Then, add branches from BranchHEADs (if not already added)
 		for branch, head := range m.BranchHEADs {
-			if seen[branch] {
-				continue
-			}
-			line := branch + " " + head
-			if slices.Contains(m.UpstreamGoneBranches, branch) {
-				line += " [gone]"
+			if !seen[branch] {
+				addLine(branch, head)
 			}
-			lines = append(lines, line)
-			seen[branch] = true
 		}
 
 		// Finally, add branches from UpstreamGoneBranches (if not already added)
 		for _, branch := range m.UpstreamGoneBranches {
-			if seen[branch] {
-				continue
+			if !seen[branch] {
+				addLine(branch, "default-"+branch)
 			}
-			line := branch + " default-" + branch + " [gone]"
-			lines = append(lines, line)
 		}
 
 		return []byte(strings.Join(lines, "\n") + "\n"), nil
@@ -521,6 +700,17 @@ func (m *MockGitExecutor) handleForEachRef(args []string) ([]byte, error) {
 		return []byte(strings.Join(lines, "\n") + "\n"), nil
 	}
 
+	// Handle refs/heads/<branch> for upstream lookup
+	// Format: "%(upstream:remotename) %(upstream:remoteref)"
+	if branch, ok := strings.CutPrefix(ref, "refs/heads/"); ok && branch != "" &&
+		strings.Contains(format, "%(upstream:remotename)") {
+		remote, remoteBranch, ok := strings.Cut(m.Upstreams[branch], "/")
+		if !ok {
+			return []byte(" \n"), nil
+		}
+		return []byte(remote + " refs/heads/" + remoteBranch + "\n"), nil
+	}
+
 	// Handle refs/heads/<branch> for single branch upstream tracking check
 	if branch, ok := strings.CutPrefix(ref, "refs/heads/"); ok && branch != "" {
 		if slices.Contains(m.UpstreamGoneBranches, branch) {
@@ -551,6 +741,22 @@ func (m *MockGitExecutor) handleFetch(args []string) ([]byte, error) {
 	if m.CapturedArgs != nil {
 		*m.CapturedArgs = append(*m.CapturedArgs, args...)
 	}
+	if err, ok := m.FetchErrs[args[len(args)-1]]; ok {
+		return nil, err
+	}
+	if m.FetchableBranches != nil && len(args) == 3 {
+		remote, branch := args[1], args[2]
+		if !slices.Contains(m.FetchableBranches[remote], branch) {
+			// git fails with "couldn't find remote ref"
+			return nil, &MockExitError{Code: 1}
+		}
+		if m.RemoteBranches == nil {
+			m.RemoteBranches = make(map[string][]string)
+		}
+		if !slices.Contains(m.RemoteBranches[remote], branch) {
+			m.RemoteBranches[remote] = append(m.RemoteBranches[remote], branch)
+		}
+	}
 	return nil, m.FetchErr
 }
 
@@ -568,6 +774,10 @@ func (m *MockGitExecutor) handleSubmodule(args []string) ([]byte, error) {
 		m.SubmoduleUpdateCalled = true
 		m.SubmoduleUpdateArgs = args
 		return nil, m.SubmoduleUpdateErr
+	case "deinit":
+		// args: ["submodule", "deinit", "-f", "--all"]
+		m.SubmoduleDeinitCalled = true
+		return nil, m.SubmoduleDeinitErr
 	}
 	return nil, nil
 }
@@ -579,6 +789,12 @@ func (m *MockGitExecutor) handleRevList(args []string) ([]byte, error) {
 		return nil, nil
 	}
 
+	// args: ["rev-list", "--count", "<branch>@{push}..<branch>"]
+	if args[1] == "--count" {
+		_, branch, _ := strings.Cut(args[2], "..")
+		return []byte(strconv.Itoa(m.UnpushedCommits[branch]) + "\n"), nil
+	}
+
 	// Find the target (first non-flag arg after "rev-list")
 	target := ""
 	for _, arg := range args[1:] {
@@ -614,6 +830,9 @@ func (m *MockGitExecutor) handleReset(args []string) ([]byte, error) {
 }
 
 func (m *MockGitExecutor) handleDiff(args []string) ([]byte, error) {
+	if slices.Contains(args, "--binary") {
+		return []byte(m.DiffPatchOutput), nil
+	}
 	if m.DiffNameOnlyOutput == nil {
 		return []byte{}, nil
 	}
@@ -621,9 +840,12 @@ func (m *MockGitExecutor) handleDiff(args []string) ([]byte, error) {
 	// Expected args: ["diff", "--name-only", "--diff-filter=X", "from", "to"]
 	var filter, fromRef, toRef string
 	for _, arg := range args[1:] {
+		if arg == "--" {
+			break
+		}
 		if strings.HasPrefix(arg, "--diff-filter=") {
 			filter = strings.TrimPrefix(arg, "--diff-filter=")
-		} else if !strings.HasPrefix(arg, "--") {
+		} else if !strings.HasPrefix(arg, "-") {
 			if fromRef == "" {
 				fromRef = arg
 			} else {
@@ -637,3 +859,85 @@ func (m *MockGitExecutor) handleDiff(args []string) ([]byte, error) {
 	}
 	return []byte{}, nil
 }
+
+func (m *MockGitExecutor) handleApply(args []string, dir string) ([]byte, error) {
+	if m.CapturedArgs != nil {
+		*m.CapturedArgs = append(*m.CapturedArgs, args...)
+	}
+	return nil, m.ApplyErrMap[dir]
+}
+
+// MockIndexTree is the tree returned by git write-tree.
+const MockIndexTree = "4b825dc642cb6eb9a060e54bf8d69288fbee4904"
+
+func (m *MockGitExecutor) handleWriteTree(args []string) ([]byte, error) {
+	if m.CapturedArgs != nil {
+		*m.CapturedArgs = append(*m.CapturedArgs, args...)
+	}
+	return []byte(MockIndexTree + "\n"), nil
+}
+
+func (m *MockGitExecutor) handleReadTree(args []string) ([]byte, error) {
+	if m.CapturedArgs != nil {
+		*m.CapturedArgs = append(*m.CapturedArgs, args...)
+	}
+	return nil, nil
+}
+
+func (m *MockGitExecutor) handleMergeBase(args []string) ([]byte, error) {
+	// args: ["merge-base", "<target>", "<branch>"]
+	if len(args) < 3 {
+		return nil, nil
+	}
+	return []byte("base-" + args[2] + "\n"), nil
+}
+
+func (m *MockGitExecutor) handleCommitTree(args []string) ([]byte, error) {
+	// args: ["commit-tree", "tree-<branch>", "-p", "<base>", "-m", "<msg>"]
+	if len(args) < 2 {
+		return nil, nil
+	}
+	branch := strings.TrimPrefix(args[1], "tree-")
+	return []byte("squash-" + branch + "\n"), nil
+}
+
+func (m *MockGitExecutor) handleCherry(args []string) ([]byte, error) {
+	// args: ["cherry", "<target>", "squash-<branch>"]
+	if len(args) < 3 {
+		return nil, nil
+	}
+	target := args[1]
+	branch := strings.TrimPrefix(args[2], "squash-")
+	if slices.Contains(m.SquashMergedBranches[target], branch) {
+		return []byte("- " + args[2] + "\n"), nil
+	}
+	return []byte("+ " + args[2] + "\n"), nil
+}
+
+func (m *MockGitExecutor) handleLsFiles(args []string, dir string) ([]byte, error) {
+	files := m.TrackedFilesMap[dir]
+	if slices.Contains(args, "--others") {
+		files = m.UntrackedFilesMap[dir]
+	}
+	if slices.Contains(args, "--ignored") {
+		files = m.IgnoredFilesMap[dir]
+	}
+	if len(files) == 0 {
+		return []byte{}, nil
+	}
+	return []byte(strings.Join(files, "\x00") + "\x00"), nil
+}
+
+func (m *MockGitExecutor) handleCheckIgnore(args []string, dir string) ([]byte, error) {
+	// args: ["check-ignore", "--", <path>...]
+	var out strings.Builder
+	for _, path := range args[2:] {
+		if slices.Contains(m.IgnoredFilesMap[dir], path) {
+			out.WriteString(path + "\n")
+		}
+	}
+	if out.Len() == 0 {
+		return nil, &MockExitError{Code: 1}
+	}
+	return []byte(out.String()), nil
+}
@@ -0,0 +1,56 @@
+package twig
+
+import (
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// TestLibraryDoesNotImportCLI keeps CLI dependencies out of the library
+// packages so downstream users can depend on them without cobra.
+func TestLibraryDoesNotImportCLI(t *testing.T) {
+	t.Parallel()
+
+	forbidden := []string{
+		"github.com/spf13/cobra",
+		"github.com/spf13/pflag",
+		"github.com/708u/twig/cli",
+		"github.com/708u/twig/cmd/",
+	}
+
+	for _, dir := range []string{".", "core", "format"} {
+		t.Run(dir, func(t *testing.T) {
+			t.Parallel()
+
+			files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(files) == 0 {
+				t.Fatalf("no Go files in %s", dir)
+			}
+
+			fset := token.NewFileSet()
+			for _, file := range files {
+				if strings.HasSuffix(file, "_test.go") {
+					continue
+				}
+				f, err := parser.ParseFile(fset, file, nil, parser.ImportsOnly)
+				if err != nil {
+					t.Fatalf("failed to parse %s: %v", file, err)
+				}
+				for _, imp := range f.Imports {
+					path, _ := strconv.Unquote(imp.Path.Value)
+					for _, prefix := range forbidden {
+						if strings.HasPrefix(path, prefix) {
+							t.Errorf("%s imports %s; keep CLI code in package cli", file, path)
+						}
+					}
+				}
+			}
+		})
+	}
+}